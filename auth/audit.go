@@ -39,6 +39,18 @@ func (m *MysqlAudit) ValidateHash(
 	return getter, err
 }
 
+// Negotiate sends authentication calls to an AuditMethod.
+func (m *MysqlAudit) Negotiate(
+	c *mysql.Conn,
+	user string,
+	addr net.Addr,
+) (mysql.Getter, error) {
+	getter, err := m.AuthServer.Negotiate(c, user, addr)
+	m.audit.Authentication(user, addr.String(), err)
+
+	return getter, err
+}
+
 // NewAudit creates a wrapped Auth that sends audit trails to the specified
 // method.
 func NewAudit(auth Auth, method AuditMethod) Auth {
@@ -0,0 +1,21 @@
+package auth
+
+import "github.com/src-d/go-mysql-server/sql"
+
+// Authorizer decides whether users can run statements, so embedders can
+// enforce their own access policies. The engine calls it before running a
+// statement with each access the statement makes to a table, and the first
+// error it returns stops the statement.
+type Authorizer interface {
+	// Authorize returns an error if the user cannot make the access, such
+	// as ErrNotAuthorized.
+	Authorize(ctx *sql.Context, user string, access sql.TableAccess) error
+}
+
+// AuthorizerFunc is a function that implements the Authorizer interface.
+type AuthorizerFunc func(ctx *sql.Context, user string, access sql.TableAccess) error
+
+// Authorize implements the Authorizer interface.
+func (f AuthorizerFunc) Authorize(ctx *sql.Context, user string, access sql.TableAccess) error {
+	return f(ctx, user, access)
+}
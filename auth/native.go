@@ -32,18 +32,11 @@ type nativeUser struct {
 	Password        string
 	JSONPermissions []string `json:"Permissions"`
 	Permissions     Permission
-}
-
-// Allowed checks if the user has certain permission.
-func (u nativeUser) Allowed(p Permission) error {
-	if u.Permissions&p == p {
-		return nil
-	}
-
-	// permissions needed but not granted to the user
-	p2 := (^u.Permissions) & p
-
-	return ErrNotAuthorized.Wrap(ErrNoPermission.New(p2))
+	Hosts           []string
+	// sha2Password is the caching_sha2_password hash of the password. It
+	// is only known when the clear text password is given.
+	sha2Password string
+	hashed       bool
 }
 
 // NativePassword generates a mysql_native_password string.
@@ -67,7 +60,8 @@ func NativePassword(password string) string {
 	return fmt.Sprintf("*%s", s)
 }
 
-// Native holds mysql_native_password users.
+// Native holds mysql_native_password users. It is the Provider used by the
+// static user configurations.
 type Native struct {
 	users map[string]nativeUser
 }
@@ -77,9 +71,10 @@ type Native struct {
 func NewNativeSingle(name, password string, perm Permission) *Native {
 	users := make(map[string]nativeUser)
 	users[name] = nativeUser{
-		Name:        name,
-		Password:    NativePassword(password),
-		Permissions: perm,
+		Name:         name,
+		Password:     NativePassword(password),
+		Permissions:  perm,
+		sha2Password: CachingSha2Password(password),
 	}
 
 	return &Native{users}
@@ -105,7 +100,10 @@ func NewNativeFile(file string) (*Native, error) {
 			return nil, ErrParseUserFile.Wrap(ErrDuplicateUser.New(u.Name))
 		}
 
-		if !regNative.MatchString(u.Password) {
+		if regNative.MatchString(u.Password) {
+			u.hashed = true
+		} else {
+			u.sha2Password = CachingSha2Password(u.Password)
 			u.Password = NativePassword(u.Password)
 		}
 
@@ -130,26 +128,40 @@ func NewNativeFile(file string) (*Native, error) {
 
 // Mysql implements Auth interface.
 func (s *Native) Mysql() mysql.AuthServer {
-	auth := mysql.NewAuthServerStatic()
-
-	for k, v := range s.users {
-		auth.Entries[k] = []*mysql.AuthServerStaticEntry{
-			{
-				MysqlNativePassword: v.Password,
-				Password:            v.Password},
-		}
-	}
-
-	return auth
+	return NewProviderAuth(s, NativePasswordMethod).Mysql()
 }
 
 // Allowed implements Auth interface.
 func (s *Native) Allowed(ctx *sql.Context, permission Permission) error {
-	name := ctx.Client().User
+	return NewProviderAuth(s, NativePasswordMethod).Allowed(ctx, permission)
+}
+
+// User implements Provider interface.
+func (s *Native) User(name string) (*User, error) {
 	u, ok := s.users[name]
 	if !ok {
-		return ErrNotAuthorized.Wrap(ErrNoPermission.New(permission))
+		return nil, ErrUnknownUser.New(name)
+	}
+
+	return &User{Name: u.Name, Permissions: u.Permissions, Hosts: u.Hosts}, nil
+}
+
+// Authenticate implements Provider interface.
+func (s *Native) Authenticate(user *User, credentials *Credentials) error {
+	u, ok := s.users[user.Name]
+	if !ok {
+		return ErrInvalidCredentials.New(user.Name)
+	}
+
+	if credentials.MatchNativePassword(u.Password) {
+		return nil
+	}
+
+	// Users given with a mysql_native_password hash cannot use
+	// caching_sha2_password, since the hash it needs is not known.
+	if !u.hashed && credentials.MatchCachingSha2Password(u.sha2Password) {
+		return nil
 	}
 
-	return u.Allowed(permission)
+	return ErrInvalidCredentials.New(user.Name)
 }
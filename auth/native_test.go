@@ -110,6 +110,28 @@ func TestNativeAuthentication(t *testing.T) {
 	testAuthentication(t, a, tests, nil)
 }
 
+func TestNativeAuthenticationHosts(t *testing.T) {
+	req := require.New(t)
+
+	conf, err := writeConfig(`
+[
+	{ "name": "local", "password": "password", "hosts": ["localhost"] },
+	{ "name": "remote", "password": "password", "hosts": ["10.0.0.1"] }
+]`)
+	req.NoError(err)
+	defer os.Remove(conf)
+
+	a, err := auth.NewNativeFile(conf)
+	req.NoError(err)
+
+	tests := []authenticationTest{
+		{"local", "password", true},
+		{"remote", "password", false},
+	}
+
+	testAuthentication(t, a, tests, nil)
+}
+
 func TestNativeAuthorizationSingleAll(t *testing.T) {
 	a := auth.NewNativeSingle("user", "password", auth.AllPermissions)
 
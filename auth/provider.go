@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+var (
+	// ErrUnknownUser is returned by a Provider when the user does not exist.
+	ErrUnknownUser = errors.NewKind("unknown user: %s")
+	// ErrInvalidCredentials is returned by a Provider when the credentials
+	// given by the client are not valid for the user.
+	ErrInvalidCredentials = errors.NewKind("invalid credentials for user: %s")
+)
+
+const (
+	// NativePasswordMethod authenticates the clients with the
+	// mysql_native_password plugin.
+	NativePasswordMethod = mysql.MysqlNativePassword
+	// ClearPasswordMethod asks the clients for their clear text password
+	// with the mysql_clear_password plugin. As the password travels
+	// unencrypted, clients can only use it over TLS.
+	ClearPasswordMethod = mysql.MysqlClearPassword
+	// CachingSha2PasswordMethod authenticates the clients with the
+	// caching_sha2_password plugin, the default one of MySQL 8. Like the
+	// rest of plugins other than mysql_native_password, clients can only
+	// use it over TLS.
+	CachingSha2PasswordMethod = "caching_sha2_password"
+)
+
+// User is an account returned by a Provider.
+type User struct {
+	// Name of the user.
+	Name string
+	// Permissions granted to the user.
+	Permissions Permission
+	// Hosts the user can connect from. They can be IP addresses, CIDR
+	// ranges, "localhost" or "%" for any host. If empty, the user can
+	// connect from any host.
+	Hosts []string
+}
+
+// Allowed checks if the user has certain permission.
+func (u *User) Allowed(p Permission) error {
+	if u.Permissions&p == p {
+		return nil
+	}
+
+	// permissions needed but not granted to the user
+	p2 := (^u.Permissions) & p
+
+	return ErrNotAuthorized.Wrap(ErrNoPermission.New(p2))
+}
+
+// AllowedHost checks if the user can connect from the given address.
+func (u *User) AllowedHost(addr net.Addr) bool {
+	if len(u.Hosts) == 0 {
+		return true
+	}
+
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.UnixAddr:
+		// Connections through a unix socket can only come from localhost.
+		ip = net.IPv6loopback
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+		ip = net.ParseIP(host)
+	}
+
+	for _, host := range u.Hosts {
+		switch {
+		case host == "%":
+			return true
+		case strings.EqualFold(host, "localhost"):
+			if ip != nil && ip.IsLoopback() {
+				return true
+			}
+		case strings.Contains(host, "/"):
+			_, network, err := net.ParseCIDR(host)
+			if err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		default:
+			if hostIP := net.ParseIP(host); hostIP != nil && hostIP.Equal(ip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// CachingSha2Password generates the hash of a password used to verify
+// caching_sha2_password credentials.
+func CachingSha2Password(password string) string {
+	if len(password) == 0 {
+		return ""
+	}
+
+	// sha256(sha256(password))
+	s1 := sha256.Sum256([]byte(password))
+	s2 := sha256.Sum256(s1[:])
+
+	return strings.ToUpper(hex.EncodeToString(s2[:]))
+}
+
+// Credentials are the secret sent by a client to authenticate. Clients
+// using mysql_native_password or caching_sha2_password send a scramble of
+// their password, while clients using mysql_clear_password send the
+// password itself.
+type Credentials struct {
+	// Method is the authentication plugin used by the client.
+	Method string
+	// Password is the clear text password, if the client sent it.
+	Password string
+
+	salt     []byte
+	scramble []byte
+}
+
+// MatchPassword checks if the credentials are the given clear text
+// password.
+func (c *Credentials) MatchPassword(password string) bool {
+	switch c.Method {
+	case ClearPasswordMethod:
+		return c.Password == password
+	case CachingSha2PasswordMethod:
+		return c.MatchCachingSha2Password(CachingSha2Password(password))
+	default:
+		return bytes.Equal(c.scramble, mysql.ScramblePassword(c.salt, []byte(password)))
+	}
+}
+
+// MatchCachingSha2Password checks if the credentials match the given
+// caching_sha2_password hash, as returned by CachingSha2Password.
+func (c *Credentials) MatchCachingSha2Password(hash string) bool {
+	switch c.Method {
+	case ClearPasswordMethod:
+		return CachingSha2Password(c.Password) == hash
+	case CachingSha2PasswordMethod:
+	default:
+		return false
+	}
+
+	if hash == "" {
+		return len(c.scramble) == 0
+	}
+
+	stage2, err := hex.DecodeString(hash)
+	if err != nil || len(c.scramble) != sha256.Size {
+		return false
+	}
+
+	// stage1 = scramble XOR sha256(stage2 + salt)
+	h := sha256.New()
+	h.Write(stage2)
+	h.Write(c.salt)
+	stage1 := h.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= c.scramble[i]
+	}
+
+	candidate := sha256.Sum256(stage1)
+
+	return bytes.Equal(candidate[:], stage2)
+}
+
+// MatchNativePassword checks if the credentials match the given
+// mysql_native_password hash, as returned by NativePassword.
+func (c *Credentials) MatchNativePassword(hash string) bool {
+	switch c.Method {
+	case ClearPasswordMethod:
+		return NativePassword(c.Password) == hash
+	case NativePasswordMethod:
+	default:
+		return false
+	}
+
+	if hash == "" {
+		return len(c.scramble) == 0
+	}
+
+	stage2, err := hex.DecodeString(strings.TrimPrefix(hash, "*"))
+	if err != nil || len(c.scramble) != sha1.Size {
+		return false
+	}
+
+	// stage1 = scramble XOR sha1(salt + stage2)
+	h := sha1.New()
+	h.Write(c.salt)
+	h.Write(stage2)
+	stage1 := h.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= c.scramble[i]
+	}
+
+	h.Reset()
+	h.Write(stage1)
+
+	return bytes.Equal(h.Sum(nil), stage2)
+}
+
+// Provider looks up and verifies the users of the server. Implementing it
+// allows backing the authentication with any user store.
+type Provider interface {
+	// User returns the user with the given name, or ErrUnknownUser if it
+	// does not exist.
+	User(name string) (*User, error)
+	// Authenticate checks the credentials sent by a client to log in as
+	// the given user. It returns ErrInvalidCredentials if they are not
+	// valid.
+	Authenticate(user *User, credentials *Credentials) error
+}
+
+// ProviderAuth is an Auth method backed by a Provider.
+type ProviderAuth struct {
+	provider Provider
+	method   string
+}
+
+// NewProviderAuth creates an Auth method that authenticates and authorizes
+// users with the given provider, asking the clients to use the given
+// authentication method. Providers that verify the passwords against an
+// external service, such as LDAP, need ClearPasswordMethod. Clients that
+// try to use a different method are asked to switch to it.
+func NewProviderAuth(provider Provider, method string) *ProviderAuth {
+	return &ProviderAuth{provider: provider, method: method}
+}
+
+// Mysql implements Auth interface.
+func (a *ProviderAuth) Mysql() mysql.AuthServer {
+	return &mysqlProvider{a.provider, a.method}
+}
+
+// Allowed implements Auth interface.
+func (a *ProviderAuth) Allowed(ctx *sql.Context, permission Permission) error {
+	u, err := a.provider.User(ctx.Client().User)
+	if err != nil {
+		if ErrUnknownUser.Is(err) {
+			return ErrNotAuthorized.Wrap(ErrNoPermission.New(permission))
+		}
+		return err
+	}
+
+	return u.Allowed(permission)
+}
+
+// mysqlProvider is a mysql.AuthServer that verifies the users with a
+// Provider.
+type mysqlProvider struct {
+	provider Provider
+	method   string
+}
+
+// AuthMethod implements mysql.AuthServer interface.
+func (m *mysqlProvider) AuthMethod(user string) (string, error) {
+	if m.method == "" {
+		return NativePasswordMethod, nil
+	}
+
+	return m.method, nil
+}
+
+// Salt implements mysql.AuthServer interface.
+func (m *mysqlProvider) Salt() ([]byte, error) {
+	return mysql.NewSalt()
+}
+
+// ValidateHash implements mysql.AuthServer interface.
+func (m *mysqlProvider) ValidateHash(
+	salt []byte,
+	user string,
+	resp []byte,
+	addr net.Addr,
+) (mysql.Getter, error) {
+	credentials := &Credentials{
+		Method:   NativePasswordMethod,
+		salt:     salt,
+		scramble: resp,
+	}
+
+	return m.authenticate(user, credentials, addr)
+}
+
+// Negotiate implements mysql.AuthServer interface.
+func (m *mysqlProvider) Negotiate(
+	c *mysql.Conn,
+	user string,
+	addr net.Addr,
+) (mysql.Getter, error) {
+	var credentials *Credentials
+	switch m.method {
+	case CachingSha2PasswordMethod:
+		// The client answers the switch request with its scramble. As the
+		// request carries no salt, the scramble is only computed with the
+		// password, which is safe because the connection uses TLS.
+		scramble, err := c.ReadPacket()
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = &Credentials{Method: m.method, scramble: scramble}
+	case ClearPasswordMethod:
+		password, err := mysql.AuthServerNegotiateClearOrDialog(c, m.method)
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = &Credentials{Method: m.method, Password: password}
+	default:
+		return nil, mysql.NewSQLError(
+			mysql.CRServerHandshakeErr,
+			mysql.SSUnknownSQLState,
+			"unsupported authentication method: %s", m.method,
+		)
+	}
+
+	return m.authenticate(user, credentials, addr)
+}
+
+func (m *mysqlProvider) authenticate(
+	name string,
+	credentials *Credentials,
+	addr net.Addr,
+) (mysql.Getter, error) {
+	u, err := m.provider.User(name)
+	if err == nil && u.AllowedHost(addr) {
+		err = m.provider.Authenticate(u, credentials)
+		if err == nil {
+			return userData(u.Name), nil
+		}
+	}
+
+	return nil, mysql.NewSQLError(
+		mysql.ERAccessDeniedError,
+		mysql.SSAccessDeniedError,
+		"Access denied for user '%v'", name,
+	)
+}
+
+// userData is the mysql.Getter of the users authenticated by a Provider.
+type userData string
+
+// Get implements mysql.Getter interface.
+func (u userData) Get() *query.VTGateCallerID {
+	return &query.VTGateCallerID{Username: string(u)}
+}
@@ -0,0 +1,149 @@
+// +build !windows
+
+package auth_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+// mapProvider is a Provider keeping the clear text password of its users.
+type mapProvider map[string]struct {
+	password string
+	user     auth.User
+}
+
+func (p mapProvider) User(name string) (*auth.User, error) {
+	u, ok := p[name]
+	if !ok {
+		return nil, auth.ErrUnknownUser.New(name)
+	}
+
+	user := u.user
+	return &user, nil
+}
+
+func (p mapProvider) Authenticate(user *auth.User, credentials *auth.Credentials) error {
+	if !credentials.MatchPassword(p[user.Name].password) {
+		return auth.ErrInvalidCredentials.New(user.Name)
+	}
+
+	return nil
+}
+
+var testProvider = mapProvider{
+	"user": {
+		password: "password",
+		user:     auth.User{Name: "user", Permissions: auth.AllPermissions},
+	},
+	"reader": {
+		password: "secret",
+		user:     auth.User{Name: "reader", Permissions: auth.ReadPerm},
+	},
+	"remote": {
+		password: "password",
+		user: auth.User{
+			Name:        "remote",
+			Permissions: auth.AllPermissions,
+			Hosts:       []string{"10.0.0.0/8"},
+		},
+	},
+}
+
+func TestProviderAuthentication(t *testing.T) {
+	a := auth.NewProviderAuth(testProvider, auth.NativePasswordMethod)
+
+	tests := []authenticationTest{
+		{"user", "password", true},
+		{"user", "other_password", false},
+		{"user", "", false},
+		{"reader", "secret", true},
+		{"reader", "password", false},
+		{"remote", "password", false},
+		{"nonexistent", "", false},
+	}
+
+	testAuthentication(t, a, tests, nil)
+}
+
+func TestProviderAuthorization(t *testing.T) {
+	a := auth.NewProviderAuth(testProvider, auth.NativePasswordMethod)
+
+	tests := []authorizationTest{
+		{"user", queries["select"], true},
+		{"user", queries["insert"], true},
+		{"reader", queries["select"], true},
+		{"reader", queries["insert"], false},
+		{"nonexistent", queries["select"], false},
+	}
+
+	testAuthorization(t, a, tests, nil)
+}
+
+func TestProviderAuthMethod(t *testing.T) {
+	req := require.New(t)
+
+	methods := []string{
+		auth.NativePasswordMethod,
+		auth.ClearPasswordMethod,
+		auth.CachingSha2PasswordMethod,
+	}
+
+	for _, m := range methods {
+		method, err := auth.NewProviderAuth(testProvider, m).Mysql().AuthMethod("user")
+		req.NoError(err)
+		req.Equal(m, method)
+	}
+
+	method, err := auth.NewProviderAuth(testProvider, "").Mysql().AuthMethod("user")
+	req.NoError(err)
+	req.Equal(mysql.MysqlNativePassword, method)
+}
+
+func TestCredentials(t *testing.T) {
+	req := require.New(t)
+
+	clear := &auth.Credentials{Method: auth.ClearPasswordMethod, Password: "password"}
+	req.True(clear.MatchPassword("password"))
+	req.False(clear.MatchPassword("other"))
+	req.True(clear.MatchNativePassword(auth.NativePassword("password")))
+	req.False(clear.MatchNativePassword(auth.NativePassword("other")))
+	req.True(clear.MatchCachingSha2Password(auth.CachingSha2Password("password")))
+	req.False(clear.MatchCachingSha2Password(auth.CachingSha2Password("other")))
+}
+
+func TestUserAllowedHost(t *testing.T) {
+	tcp := func(ip string) net.Addr {
+		return &net.TCPAddr{IP: net.ParseIP(ip), Port: 3306}
+	}
+
+	testCases := []struct {
+		name    string
+		hosts   []string
+		addr    net.Addr
+		allowed bool
+	}{
+		{"no hosts", nil, tcp("192.168.1.1"), true},
+		{"any host", []string{"%"}, tcp("192.168.1.1"), true},
+		{"same ip", []string{"192.168.1.1"}, tcp("192.168.1.1"), true},
+		{"other ip", []string{"192.168.1.1"}, tcp("192.168.1.2"), false},
+		{"in range", []string{"192.168.0.0/16"}, tcp("192.168.1.2"), true},
+		{"out of range", []string{"192.168.0.0/16"}, tcp("10.0.0.1"), false},
+		{"localhost", []string{"localhost"}, tcp("127.0.0.1"), true},
+		{"not localhost", []string{"localhost"}, tcp("10.0.0.1"), false},
+		{"unix socket", []string{"localhost"}, &net.UnixAddr{Name: "/tmp/mysql.sock"}, true},
+		{"any of them", []string{"10.0.0.1", "localhost"}, tcp("::1"), true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &auth.User{Name: "user", Hosts: tt.hosts}
+			require.Equal(t, tt.allowed, u.AllowedHost(tt.addr))
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Package csv implements a read-only database whose tables are the CSV and
+// TSV files of a directory.
+package csv
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// DefaultSampleSize is the number of records of a file used to infer the
+// types of its columns, if no other is given in the options of the database.
+const DefaultSampleSize = 1000
+
+// HeaderMode tells whether the first record of the files is a header with
+// the names of the columns.
+type HeaderMode byte
+
+const (
+	// DetectHeader guesses whether the first record of each file is a
+	// header. It is when all its values are distinct and not empty and,
+	// unless all the columns are text, some of them do not have the type
+	// inferred for the rest of the values of its column.
+	DetectHeader HeaderMode = iota
+	// WithHeader uses the first record of the files as header.
+	WithHeader
+	// WithoutHeader reads the first record of the files as a row.
+	WithoutHeader
+)
+
+// Options of a database of CSV and TSV files.
+type Options struct {
+	// Header tells whether the files have a header. Columns of files
+	// without a header, or whose header has an empty value, are named
+	// c1, c2 and so on after their position.
+	Header HeaderMode
+	// SampleSize is the number of records of every file used to infer the
+	// types of its columns. It's DefaultSampleSize if it's zero, and all the
+	// records of the files are used if it's negative.
+	SampleSize int
+}
+
+func (o Options) sampleSize() int {
+	if o.SampleSize == 0 {
+		return DefaultSampleSize
+	}
+	return o.SampleSize
+}
+
+// extensions are the separators of the values of the files with each
+// extension. Quotes are not expected in TSV files.
+var extensions = map[string]rune{
+	".csv": ',',
+	".tsv": '\t',
+}
+
+// Database is a read-only database with a table for every file with the
+// .csv or .tsv extension in a directory, named after the file without its
+// extension. The schema of the tables is inferred when the database is
+// created, but their rows are read from the files in every query, so the
+// changes made to the files are seen as long as their columns are the same.
+type Database struct {
+	name   string
+	dir    string
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the files of the given directory. If
+// options is nil, the default ones are used. Empty files are skipped.
+func NewDatabase(name, dir string, options *Options) (*Database, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Database{name: name, dir: dir, tables: make(map[string]sql.Table)}
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		comma, ok := extensions[ext]
+		if f.IsDir() || !ok {
+			continue
+		}
+
+		table := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		t, err := openTable(table, filepath.Join(dir, f.Name()), comma, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if t != nil {
+			d.tables[table] = t
+		}
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
@@ -0,0 +1,216 @@
+package csv
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/stretchr/testify/require"
+)
+
+func newDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "csv")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestDatabaseSchemaInference(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"people.csv": "\ufeffid,name,score,born,seen\n" +
+			"1,alice,9.5,1990-01-02,2019-01-02 10:00:00\n" +
+			"2,\"bob, jr\",7,,2019-01-03\n" +
+			"3,carol,,1991-03-04,\n",
+		"pairs.TSV": "1\ta\n2\tb\n",
+		"names.tsv": "first\tlast\nada\tlovelace\n",
+		"empty.csv": "",
+		"notes.txt": "a,b\n",
+	})
+
+	db, err := NewDatabase("files", dir, nil)
+	require.NoError(err)
+	require.Equal("files", db.Name())
+
+	tables := db.Tables()
+	require.Len(tables, 3)
+
+	require.Equal(sql.Schema{
+		{Name: "id", Type: sql.Int64, Nullable: true, Source: "people"},
+		{Name: "name", Type: sql.Text, Nullable: true, Source: "people"},
+		{Name: "score", Type: sql.Float64, Nullable: true, Source: "people"},
+		{Name: "born", Type: sql.Date, Nullable: true, Source: "people"},
+		{Name: "seen", Type: sql.Timestamp, Nullable: true, Source: "people"},
+	}, tables["people"].Schema())
+
+	require.Equal(sql.Schema{
+		{Name: "c1", Type: sql.Int64, Nullable: true, Source: "pairs"},
+		{Name: "c2", Type: sql.Text, Nullable: true, Source: "pairs"},
+	}, tables["pairs"].Schema())
+
+	require.Equal(sql.Schema{
+		{Name: "first", Type: sql.Text, Nullable: true, Source: "names"},
+		{Name: "last", Type: sql.Text, Nullable: true, Source: "names"},
+	}, tables["names"].Schema())
+
+	date := func(s string) time.Time {
+		t, err := time.Parse(sql.TimestampLayout, s)
+		require.NoError(err)
+		return t
+	}
+
+	require.Equal([]sql.Row{
+		{int64(1), "alice", 9.5, date("1990-01-02 00:00:00"), date("2019-01-02 10:00:00")},
+		{int64(2), "bob, jr", float64(7), nil, date("2019-01-03 00:00:00")},
+		{int64(3), "carol", nil, date("1991-03-04 00:00:00"), nil},
+	}, tableRows(t, tables["people"]))
+
+	require.Equal([]sql.Row{{int64(1), "a"}, {int64(2), "b"}}, tableRows(t, tables["pairs"]))
+}
+
+func TestDatabaseHeaderOptions(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"t.csv": "a,b,a\nc,,e\n",
+	})
+
+	db, err := NewDatabase("files", dir, &Options{Header: WithHeader})
+	require.NoError(err)
+	table := db.Tables()["t"]
+	require.Equal([]string{"a", "b", "a_2"}, columnNames(table.Schema()))
+	require.Equal([]sql.Row{{"c", nil, "e"}}, tableRows(t, table))
+
+	db, err = NewDatabase("files", dir, &Options{Header: WithoutHeader})
+	require.NoError(err)
+	table = db.Tables()["t"]
+	require.Equal([]string{"c1", "c2", "c3"}, columnNames(table.Schema()))
+	require.Len(tableRows(t, table), 2)
+
+	// Headers with repeated values are not detected.
+	db, err = NewDatabase("files", dir, nil)
+	require.NoError(err)
+	require.Equal([]string{"c1", "c2", "c3"}, columnNames(db.Tables()["t"].Schema()))
+}
+
+func TestTableProjection(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"t.csv": "i,s,f\n1,a,1.5\n2,b,2.5\n",
+	})
+
+	db, err := NewDatabase("files", dir, nil)
+	require.NoError(err)
+	table := db.Tables()["t"].(*Table)
+
+	projected := table.WithProjection([]string{"f", "i"}).(*Table)
+	require.Equal([]string{"f", "i"}, projected.Projection())
+	require.Equal([]string{"f", "i"}, columnNames(projected.Schema()))
+	require.Equal([]sql.Row{{1.5, int64(1)}, {2.5, int64(2)}}, tableRows(t, projected))
+
+	projected = projected.WithProjection([]string{"i"}).(*Table)
+	require.Equal([]sql.Row{{int64(1)}, {int64(2)}}, tableRows(t, projected))
+
+	require.Nil(table.Projection())
+	require.Len(tableRows(t, table)[0], 3)
+}
+
+func TestNewTable(t *testing.T) {
+	require := require.New(t)
+
+	var opened int
+	open := func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(strings.NewReader("a\tb\n1\tx,y\n")), nil
+	}
+
+	table, err := NewTable("t", "s3://bucket/t.TSV", open, nil)
+	require.NoError(err)
+	require.Equal([]string{"a", "b"}, columnNames(table.Schema()))
+	require.Equal([]sql.Row{{int64(1), "x,y"}}, tableRows(t, table))
+	require.Equal(2, opened)
+
+	open = func() (io.ReadCloser, error) { return ioutil.NopCloser(strings.NewReader("")), nil }
+	table, err = NewTable("t", "t.csv", open, nil)
+	require.NoError(err)
+	require.Nil(table)
+}
+
+func TestTableInvalidValues(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"t.csv": "i,s\n1,a\n2,b\nthree,c\n",
+	})
+
+	db, err := NewDatabase("files", dir, &Options{SampleSize: 2})
+	require.NoError(err)
+	table := db.Tables()["t"]
+	require.Equal(sql.Int64, table.Schema()[0].Type)
+
+	// Values of the columns that are not projected are not converted.
+	require.Len(tableRows(t, table.(*Table).WithProjection([]string{"s"})), 3)
+
+	iter, err := table.PartitionRows(sql.NewEmptyContext(), partition{})
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.True(ErrInvalidValue.Is(err))
+
+	dir = newDir(t, map[string]string{
+		"t.csv": "i,s\n1,a\n2\n",
+	})
+	_, err = NewDatabase("files", dir, nil)
+	require.True(ErrInvalidFile.Is(err))
+}
+
+func TestDatabaseQueries(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"users.csv":  "id,name\n1,alice\n2,bob\n3,carol\n",
+		"orders.tsv": "user_id\ttotal\n1\t10.5\n1\t2.5\n3\t4\n",
+	})
+
+	db, err := NewDatabase("files", dir, nil)
+	require.NoError(err)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), nil)
+
+	_, iter, err := e.Query(
+		sql.NewEmptyContext(),
+		`SELECT u.name, SUM(o.total) FROM users u
+		INNER JOIN orders o ON u.id = o.user_id
+		GROUP BY u.name ORDER BY u.name`,
+	)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"alice", float64(13)}, {"carol", float64(4)}}, rows)
+}
+
+func columnNames(schema sql.Schema) []string {
+	var names []string
+	for _, col := range schema {
+		names = append(names, col.Name)
+	}
+	return names
+}
+
+func tableRows(t *testing.T, table sql.Table) []sql.Row {
+	t.Helper()
+	iter, err := table.PartitionRows(sql.NewEmptyContext(), partition{})
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows
+}
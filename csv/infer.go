@@ -0,0 +1,202 @@
+package csv
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// kind is the type inferred for the values of a column. Kinds are widened as
+// values that do not fit in the current one are found, up to text, which
+// fits any value.
+type kind byte
+
+const (
+	kindNull kind = iota
+	kindInt
+	kindFloat
+	kindDate
+	kindTimestamp
+	kindText
+)
+
+// timestampLayouts are the layouts of the values inferred as timestamps.
+var timestampLayouts = []string{sql.TimestampLayout, time.RFC3339}
+
+// kindOf returns the narrowest kind of a value. Empty values are NULL.
+func kindOf(v string) kind {
+	if v == "" {
+		return kindNull
+	}
+
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return kindInt
+	}
+
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return kindFloat
+	}
+
+	if _, err := time.Parse(sql.DateLayout, v); err == nil {
+		return kindDate
+	}
+
+	for _, layout := range timestampLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return kindTimestamp
+		}
+	}
+
+	return kindText
+}
+
+// widen returns the narrowest kind that fits the values of both kinds.
+func widen(a, b kind) kind {
+	if a > b {
+		a, b = b, a
+	}
+
+	switch {
+	case a == kindNull || a == b:
+		return b
+	case a == kindInt && b == kindFloat:
+		return kindFloat
+	case a == kindDate && b == kindTimestamp:
+		return kindTimestamp
+	default:
+		return kindText
+	}
+}
+
+func (k kind) sqlType() sql.Type {
+	switch k {
+	case kindInt:
+		return sql.Int64
+	case kindFloat:
+		return sql.Float64
+	case kindDate:
+		return sql.Date
+	case kindTimestamp:
+		return sql.Timestamp
+	default:
+		return sql.Text
+	}
+}
+
+// fits returns whether the value can be converted to the given kind.
+func (k kind) fits(v string) bool {
+	return widen(k, kindOf(v)) == k
+}
+
+// convert converts a value of a file to the given kind. Empty values are
+// NULL.
+func (k kind) convert(v string) (interface{}, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	switch k {
+	case kindInt:
+		return strconv.ParseInt(v, 10, 64)
+	case kindFloat:
+		return strconv.ParseFloat(v, 64)
+	case kindDate:
+		t, err := time.Parse(sql.DateLayout, v)
+		if err != nil {
+			return nil, err
+		}
+		return t.UTC(), nil
+	case kindTimestamp:
+		// Dates are widened to timestamps.
+		var err error
+		for _, layout := range append(timestampLayouts, sql.DateLayout) {
+			var t time.Time
+			if t, err = time.Parse(layout, v); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return nil, err
+	default:
+		return v, nil
+	}
+}
+
+// inferSchema infers the columns of a file from its first records and
+// returns them along with whether the first record is a header.
+func inferSchema(table string, records [][]string, header HeaderMode) (sql.Schema, []kind, bool) {
+	first := records[0]
+	rest := make([]kind, len(first))
+	for _, record := range records[1:] {
+		for i, v := range record {
+			rest[i] = widen(rest[i], kindOf(v))
+		}
+	}
+
+	hasHeader := header == WithHeader
+	if header == DetectHeader {
+		hasHeader = isHeader(first, rest)
+	}
+
+	kinds := rest
+	if !hasHeader {
+		for i, v := range first {
+			kinds[i] = widen(kinds[i], kindOf(v))
+		}
+	}
+
+	schema := make(sql.Schema, len(first))
+	seen := make(map[string]bool, len(first))
+	for i := range first {
+		name := fmt.Sprintf("c%d", i+1)
+		if hasHeader && first[i] != "" {
+			name = first[i]
+		}
+
+		// Repeated names are made unique, so all the columns can be
+		// referenced.
+		base := name
+		for n := 2; seen[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		seen[name] = true
+
+		schema[i] = &sql.Column{
+			Name:     name,
+			Type:     kinds[i].sqlType(),
+			Nullable: true,
+			Source:   table,
+		}
+	}
+
+	return schema, kinds, hasHeader
+}
+
+// isHeader returns whether the first record of a file is a header given the
+// kinds inferred from the records after it. It is when all its values are
+// distinct and not empty, and they are not values of the columns, which is
+// assumed when all the columns are text.
+func isHeader(first []string, kinds []kind) bool {
+	seen := make(map[string]bool, len(first))
+	for _, v := range first {
+		if v == "" || seen[v] {
+			return false
+		}
+		seen[v] = true
+	}
+
+	allText := true
+	for i, k := range kinds {
+		if k == kindText || k == kindNull {
+			continue
+		}
+
+		allText = false
+		if !k.fits(first[i]) {
+			return true
+		}
+	}
+
+	return allText
+}
@@ -0,0 +1,291 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidValue is returned when a value of a file does not have the type
+// inferred for its column, which can happen with values that were not in
+// the records used to infer it.
+var ErrInvalidValue = errors.NewKind("value %q in line %d of %s is not valid for column %s of type %s")
+
+// ErrInvalidFile is returned when a file cannot be read as CSV or TSV.
+var ErrInvalidFile = errors.NewKind("unable to read %s: %s")
+
+// utf8BOM is removed from the beginning of the files.
+const utf8BOM = "\ufeff"
+
+// Table is a table whose rows are read from a CSV or TSV file every time
+// they are requested. Only the values of the projected columns are
+// converted.
+type Table struct {
+	name      string
+	path      string
+	open      func() (io.ReadCloser, error)
+	comma     rune
+	hasHeader bool
+	// kinds are the kinds of all the columns of the file.
+	kinds []kind
+
+	schema sql.Schema
+	// columns are the positions in the file of the columns of the schema
+	// if the table is projected.
+	columns    []int
+	projection []string
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.ProjectedTable = (*Table)(nil)
+
+// openTable infers the schema of the table of a file. It returns nil if the
+// file is empty.
+func openTable(name, path string, comma rune, options Options) (*Table, error) {
+	open := func() (io.ReadCloser, error) { return os.Open(path) }
+	return newTable(name, path, open, comma, options)
+}
+
+// NewTable creates a table whose rows are read from the CSV or TSV file
+// opened with open, which is called every time the rows are requested. The
+// values are separated by tabs if the path has the .tsv extension, and by
+// commas otherwise, and the path is used in the errors. If options is nil,
+// the default ones are used. It returns nil if the file is empty.
+func NewTable(
+	name, path string,
+	open func() (io.ReadCloser, error),
+	options *Options,
+) (*Table, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+
+	comma, ok := extensions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		comma = ','
+	}
+
+	return newTable(name, path, open, comma, opts)
+}
+
+func newTable(
+	name, path string,
+	open func() (io.ReadCloser, error),
+	comma rune,
+	options Options,
+) (*Table, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &Table{name: name, path: path, open: open, comma: comma}
+	r := t.newReader(f)
+
+	var records [][]string
+	for n := options.sampleSize(); n < 0 || len(records) < n; {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, ErrInvalidFile.New(path, err)
+		}
+
+		if len(records) > 0 && len(record) != len(records[0]) {
+			line, _ := r.FieldPos(0)
+			return nil, ErrInvalidFile.New(
+				path,
+				fmt.Sprintf("line %d has %d values instead of %d", line, len(record), len(records[0])),
+			)
+		}
+
+		// Records are reused by the reader.
+		records = append(records, append([]string(nil), record...))
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	t.schema, t.kinds, t.hasHeader = inferSchema(name, records, options.Header)
+	return t, nil
+}
+
+func (t *Table) newReader(f io.Reader) *csv.Reader {
+	br := bufio.NewReader(f)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	r := csv.NewReader(br)
+	r.Comma = t.comma
+	r.ReuseRecord = true
+	r.FieldsPerRecord = -1
+	if t.comma == '\t' {
+		r.LazyQuotes = true
+	}
+	return r
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("CSVTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the file are
+// in a single partition.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	f, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := t.columns
+	if columns == nil {
+		columns = make([]int, len(t.kinds))
+		for i := range columns {
+			columns[i] = i
+		}
+	}
+
+	return &tableIter{
+		t:         t,
+		f:         f,
+		r:         t.newReader(f),
+		columns:   columns,
+		skipFirst: t.hasHeader,
+	}, nil
+}
+
+// WithProjection implements the sql.ProjectedTable interface.
+func (t *Table) WithProjection(colNames []string) sql.Table {
+	if len(colNames) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.columns = make([]int, len(colNames))
+	nt.schema = make(sql.Schema, len(colNames))
+	for i, name := range colNames {
+		idx := t.schema.IndexOf(name, t.name)
+		if idx < 0 {
+			return t
+		}
+
+		nt.schema[i] = t.schema[idx]
+		nt.columns[i] = idx
+		if t.columns != nil {
+			nt.columns[i] = t.columns[idx]
+		}
+	}
+	nt.projection = colNames
+
+	return &nt
+}
+
+// Projection implements the sql.ProjectedTable interface.
+func (t *Table) Projection() []string {
+	return t.projection
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+type tableIter struct {
+	t         *Table
+	f         io.ReadCloser
+	r         *csv.Reader
+	columns   []int
+	skipFirst bool
+}
+
+func (i *tableIter) Next() (sql.Row, error) {
+	record, err := i.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		return nil, ErrInvalidFile.New(i.t.path, err)
+	}
+
+	if i.skipFirst {
+		i.skipFirst = false
+		return i.Next()
+	}
+
+	line, _ := i.r.FieldPos(0)
+	if len(record) != len(i.t.kinds) {
+		return nil, ErrInvalidFile.New(
+			i.t.path,
+			fmt.Sprintf("line %d has %d values instead of %d", line, len(record), len(i.t.kinds)),
+		)
+	}
+
+	row := make(sql.Row, len(i.columns))
+	for j, c := range i.columns {
+		k := i.t.kinds[c]
+		v, err := k.convert(record[c])
+		if err != nil {
+			return nil, ErrInvalidValue.New(record[c], line, i.t.path, i.t.schema[j].Name, k.sqlType())
+		}
+		row[j] = v
+	}
+
+	return row, nil
+}
+
+func (i *tableIter) Close() error {
+	return i.f.Close()
+}
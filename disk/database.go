@@ -0,0 +1,184 @@
+package disk
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrDatabaseClosed is returned when the rows of a table of a closed
+// database are changed.
+var ErrDatabaseClosed = errors.NewKind("database %s is closed")
+
+// DefaultSnapshotThreshold is the number of changes logged by a table
+// after which its snapshot is written again, if no other is given in the
+// options of the database.
+const DefaultSnapshotThreshold = 10000
+
+const (
+	snapshotExt = ".snapshot"
+	logExt      = ".log"
+)
+
+// Options of a database stored on disk.
+type Options struct {
+	// SnapshotThreshold is the number of changes logged by a table after
+	// which all its rows are written in a new snapshot and the log is
+	// truncated. It's DefaultSnapshotThreshold if it's not positive.
+	SnapshotThreshold int
+	// Sync makes every change be synced to disk before it's applied, so no
+	// change is lost if the machine crashes and not only the process. It
+	// makes writes much slower.
+	Sync bool
+}
+
+func (o Options) snapshotThreshold() int {
+	if o.SnapshotThreshold <= 0 {
+		return DefaultSnapshotThreshold
+	}
+	return o.SnapshotThreshold
+}
+
+// Database is a database whose tables are stored in a directory, so it can
+// be opened again with all their rows. Every table is stored in two files
+// named after it: a snapshot with its schema and rows, and a log with the
+// changes made to the rows since the snapshot was written.
+type Database struct {
+	name    string
+	dir     string
+	options Options
+
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+var _ sql.Database = (*Database)(nil)
+var _ sql.TableCreator = (*Database)(nil)
+var _ sql.TableDropper = (*Database)(nil)
+
+// NewDatabase opens the database with the given name stored in the given
+// directory, which is created if it does not exist. If options is nil, the
+// default ones are used. The database must be closed once it's not used.
+func NewDatabase(name, dir string, options *Options) (*Database, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	d := &Database{name: name, dir: dir, tables: make(map[string]*Table)}
+	if options != nil {
+		d.options = *options
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), snapshotExt) {
+			continue
+		}
+
+		name, err := url.PathUnescape(strings.TrimSuffix(f.Name(), snapshotExt))
+		if err != nil {
+			continue
+		}
+
+		t, err := openTable(d, name)
+		if err != nil {
+			_ = d.Close()
+			return nil, err
+		}
+		d.tables[name] = t
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make(map[string]sql.Table, len(d.tables))
+	for name, t := range d.tables {
+		tables[name] = t
+	}
+	return tables
+}
+
+// CreateTable implements the sql.TableCreator interface.
+func (d *Database) CreateTable(ctx *sql.Context, name string, schema sql.Schema) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; ok {
+		return sql.ErrTableAlreadyExists.New(name)
+	}
+
+	t, err := createTable(d, name, schema)
+	if err != nil {
+		return err
+	}
+
+	d.tables[name] = t
+	return nil
+}
+
+// DropTable implements the sql.TableDropper interface. The files of the
+// table are removed.
+func (d *Database) DropTable(ctx *sql.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t, ok := d.tables[name]
+	if !ok {
+		return sql.ErrTableNotFound.New(name)
+	}
+
+	if err := t.close(); err != nil {
+		return err
+	}
+
+	// Once the snapshot is removed the table no longer exists, even if
+	// the log is not removed.
+	if err := os.Remove(t.snapshotPath()); err != nil {
+		return err
+	}
+	_ = os.Remove(t.logPath())
+
+	delete(d.tables, name)
+	return nil
+}
+
+// Close writes the snapshots of the tables with changes in their logs and
+// closes them. The rows of the tables can still be read, but not changed.
+func (d *Database) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var err error
+	for _, t := range d.tables {
+		if cerr := t.close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// path returns the path of the file with the given extension of a table.
+// Table names are escaped, so any name can be used.
+func (d *Database) path(table, ext string) string {
+	return filepath.Join(d.dir, url.PathEscape(table)+ext)
+}
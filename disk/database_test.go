@@ -0,0 +1,183 @@
+package disk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "disk")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestDatabaseCreateAndDropTable(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	dir := tempDir(t)
+
+	db, err := NewDatabase("test", dir, nil)
+	require.NoError(err)
+	require.Equal("test", db.Name())
+	require.Len(db.Tables(), 0)
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: sql.VarChar(20), Nullable: true, Default: "none"},
+	}
+	require.NoError(db.CreateTable(ctx, "a/b", schema))
+
+	err = db.CreateTable(ctx, "a/b", schema)
+	require.True(sql.ErrTableAlreadyExists.Is(err))
+
+	err = db.CreateTable(ctx, "t", sql.Schema{{Name: "t", Type: sql.Tuple(sql.Int64, sql.Text)}})
+	require.True(ErrUnsupportedColumnType.Is(err))
+
+	require.NoError(db.Close())
+
+	db, err = NewDatabase("test", dir, nil)
+	require.NoError(err)
+
+	tables := db.Tables()
+	require.Len(tables, 1)
+	require.Equal("a/b", tables["a/b"].Name())
+	require.Equal(sql.Schema{
+		{Name: "id", Type: sql.Int64, PrimaryKey: true, AutoIncrement: true, Source: "a/b"},
+		{Name: "name", Type: sql.VarChar(20), Nullable: true, Default: "none", Source: "a/b"},
+	}, tables["a/b"].Schema())
+
+	require.NoError(db.DropTable(ctx, "a/b"))
+	require.True(sql.ErrTableNotFound.Is(db.DropTable(ctx, "a/b")))
+	require.NoError(db.Close())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.Len(files, 0)
+}
+
+func TestDatabaseQueries(t *testing.T) {
+	require := require.New(t)
+	dir := tempDir(t)
+
+	query := func(db *Database, q string) []sql.Row {
+		t.Helper()
+		catalog := sql.NewCatalog()
+		catalog.AddDatabase(db)
+		e := sqle.New(catalog, analyzer.NewDefault(catalog), nil)
+
+		ctx := sql.NewEmptyContext()
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		return rows
+	}
+
+	db, err := NewDatabase("mydb", dir, &Options{SnapshotThreshold: 3})
+	require.NoError(err)
+
+	query(db, "CREATE TABLE users (id BIGINT AUTO_INCREMENT PRIMARY KEY, name TEXT NOT NULL)")
+	query(db, "INSERT INTO users (name) VALUES ('alice'), ('bob'), ('carol'), ('dave')")
+	query(db, "UPDATE users SET name = 'robert' WHERE name = 'bob'")
+	query(db, "DELETE FROM users WHERE id = 3")
+	require.NoError(db.Close())
+
+	db, err = NewDatabase("mydb", dir, &Options{SnapshotThreshold: 3})
+	require.NoError(err)
+
+	rows := query(db, "SELECT id, name FROM users ORDER BY id")
+	require.Equal([]sql.Row{
+		{int64(1), "alice"},
+		{int64(2), "robert"},
+		{int64(4), "dave"},
+	}, rows)
+
+	query(db, "INSERT INTO users (name) VALUES ('eve')")
+	rows = query(db, "SELECT id FROM users WHERE name = 'eve'")
+	require.Equal([]sql.Row{{int64(5)}}, rows)
+	require.NoError(db.Close())
+}
+
+func TestDatabaseRecovery(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	dir := tempDir(t)
+	schema := sql.Schema{{Name: "i", Type: sql.Int64}}
+
+	db, err := NewDatabase("test", dir, nil)
+	require.NoError(err)
+	require.NoError(db.CreateTable(ctx, "t", schema))
+
+	table := db.Tables()["t"].(*Table)
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(table.Insert(ctx, sql.NewRow(i)))
+	}
+
+	// The database is not closed, as if the process crashed, and the last
+	// change was not completely written.
+	logPath := filepath.Join(dir, "t"+logExt)
+	log, err := ioutil.ReadFile(logPath)
+	require.NoError(err)
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(err)
+	_, err = f.Write([]byte{0, 0, 1, 0, 1, 2})
+	require.NoError(err)
+	require.NoError(f.Close())
+
+	db, err = NewDatabase("test", dir, nil)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1)}, {int64(2)}, {int64(3)}}, tableRows(t, db, "t"))
+
+	table = db.Tables()["t"].(*Table)
+	require.NoError(table.Delete(ctx, sql.NewRow(int64(2))))
+	require.Equal(sql.ErrDeleteRowNotFound, table.Delete(ctx, sql.NewRow(int64(2))))
+	require.NoError(db.Close())
+
+	// The log has changes that are already in the snapshot if the process
+	// crashed before truncating it.
+	require.NoError(ioutil.WriteFile(logPath, log, 0644))
+
+	db, err = NewDatabase("test", dir, nil)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1)}, {int64(3)}}, tableRows(t, db, "t"))
+
+	require.NoError(db.Close())
+	require.True(ErrDatabaseClosed.Is(table.Insert(ctx, sql.NewRow(int64(4)))))
+}
+
+func tableRows(t *testing.T, db *Database, name string) []sql.Row {
+	t.Helper()
+	ctx := sql.NewEmptyContext()
+	table := db.Tables()[name]
+
+	var rows []sql.Row
+	partitions, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	for {
+		p, err := partitions.Next()
+		if err != nil {
+			break
+		}
+
+		iter, err := table.PartitionRows(ctx, p)
+		require.NoError(t, err)
+		r, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		rows = append(rows, r...)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][0].(int64) < rows[j][0].(int64)
+	})
+	return rows
+}
@@ -0,0 +1,299 @@
+package disk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"hash/crc32"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+// ErrCorruptTable is returned when the files of a table cannot be read.
+var ErrCorruptTable = errors.NewKind("table %s is corrupt: %s")
+
+// ErrUnsupportedColumnType is returned when a table is created with a
+// column whose type cannot be stored.
+var ErrUnsupportedColumnType = errors.NewKind("column %s has type %s, which cannot be stored on disk")
+
+func init() {
+	// The values of the rows are encoded as interfaces, so the types that
+	// are not basic must be registered.
+	gob.Register(time.Time{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register(sql.Point{})
+	gob.Register(sql.LineString{})
+	gob.Register(sql.Polygon{})
+}
+
+// Kinds of changes written in the log of a table.
+const (
+	opInsert byte = iota + 1
+	opDelete
+	opUpdate
+)
+
+// header is the first record of the snapshot of a table.
+type header struct {
+	Columns []column
+	// Seq is the sequence number of the last change included in the
+	// snapshot. Changes of the log up to it are not replayed.
+	Seq uint64
+}
+
+// column is a column of the schema of a table as it's stored.
+type column struct {
+	Name          string
+	Type          query.Type
+	Length        int
+	Default       interface{}
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+// entry is a change of the rows of a table, or a row of a snapshot, which
+// are inserts.
+type entry struct {
+	Seq uint64
+	Op  byte
+	Row []interface{}
+	// Old is the row before an update.
+	Old []interface{}
+}
+
+func encodeSchema(schema sql.Schema) ([]column, error) {
+	columns := make([]column, len(schema))
+	for i, col := range schema {
+		c := column{
+			Name:          col.Name,
+			Type:          col.Type.Type(),
+			Default:       col.Default,
+			Nullable:      col.Nullable,
+			PrimaryKey:    col.PrimaryKey,
+			AutoIncrement: col.AutoIncrement,
+		}
+
+		if t, ok := col.Type.(interface{ Capacity() int }); ok {
+			c.Length = t.Capacity()
+		}
+
+		// Types that cannot be created again from the stored ones, such
+		// as tuples, are not supported.
+		typ, err := columnType(c)
+		if err != nil || !reflect.DeepEqual(typ, col.Type) {
+			return nil, ErrUnsupportedColumnType.New(col.Name, col.Type)
+		}
+
+		columns[i] = c
+	}
+	return columns, nil
+}
+
+func decodeSchema(table string, columns []column) (sql.Schema, error) {
+	schema := make(sql.Schema, len(columns))
+	for i, c := range columns {
+		typ, err := columnType(c)
+		if err != nil {
+			return nil, err
+		}
+
+		schema[i] = &sql.Column{
+			Name:          c.Name,
+			Type:          typ,
+			Default:       c.Default,
+			Nullable:      c.Nullable,
+			Source:        table,
+			PrimaryKey:    c.PrimaryKey,
+			AutoIncrement: c.AutoIncrement,
+		}
+	}
+	return schema, nil
+}
+
+func columnType(c column) (sql.Type, error) {
+	switch c.Type {
+	case sqltypes.Char:
+		return sql.Char(c.Length), nil
+	case sqltypes.VarChar:
+		return sql.VarChar(c.Length), nil
+	default:
+		return sql.MysqlTypeToType(c.Type)
+	}
+}
+
+// Records are written with their length and checksum before them, so a
+// record that was not completely written is detected when it's read.
+const recordHeaderSize = 8
+
+func writeRecord(w io.Writer, v interface{}) error {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, recordHeaderSize))
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+
+	b := buf.Bytes()
+	data := b[recordHeaderSize:]
+	binary.BigEndian.PutUint32(b, uint32(len(data)))
+	binary.BigEndian.PutUint32(b[4:], crc32.ChecksumIEEE(data))
+
+	_, err := w.Write(b)
+	return err
+}
+
+// errTornRecord is returned when a record is incomplete or its checksum
+// does not match.
+var errTornRecord = errors.NewKind("incomplete record").New()
+
+// readRecord reads the next record into v, returning io.EOF if there are no
+// more records.
+func readRecord(r io.Reader, v interface{}) error {
+	var h [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return errTornRecord
+		}
+		return err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(h[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errTornRecord
+		}
+		return err
+	}
+
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(h[4:]) {
+		return errTornRecord
+	}
+
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// writeSnapshot writes the schema and rows of a table to the file in the
+// given path, replacing it atomically once it's synced.
+func writeSnapshot(path string, h header, rows []sql.Row) (err error) {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	if err = writeRecord(w, h); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err = writeRecord(w, entry{Op: opInsert, Row: row}); err != nil {
+			return err
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readSnapshot reads the schema and rows of a table from the file in the
+// given path.
+func readSnapshot(table, path string) (header, []sql.Row, error) {
+	var h header
+	f, err := os.Open(path)
+	if err != nil {
+		return h, nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if err := readRecord(r, &h); err != nil {
+		return h, nil, ErrCorruptTable.New(table, err)
+	}
+
+	var rows []sql.Row
+	for {
+		var e entry
+		if err := readRecord(r, &e); err != nil {
+			if err == io.EOF {
+				return h, rows, nil
+			}
+			return h, nil, ErrCorruptTable.New(table, err)
+		}
+		rows = append(rows, sql.NewRow(e.Row...))
+	}
+}
+
+// readLog calls fn with the changes of the log in the given file, and
+// returns the size of the records that were completely written, so a
+// torn record at the end, written during a crash, can be truncated.
+func readLog(f *os.File, fn func(entry) error) (int64, error) {
+	r := &countingReader{r: bufio.NewReader(f)}
+	var size int64
+	for {
+		var e entry
+		if err := readRecord(r, &e); err != nil {
+			if err == io.EOF || err == errTornRecord {
+				return size, nil
+			}
+			return 0, err
+		}
+
+		if err := fn(e); err != nil {
+			return 0, err
+		}
+		size = r.n
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,388 @@
+package disk
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Table is a table whose rows are kept in memory and persisted on disk. Its
+// changes are appended to a log, which is compacted into a snapshot of all
+// the rows once it grows, so the rows are recovered from both when the
+// database is opened again.
+type Table struct {
+	name   string
+	schema sql.Schema
+	db     *Database
+
+	mu   sync.RWMutex
+	rows []sql.Row
+	// seq is the sequence number of the last change of the table.
+	seq uint64
+	// logged is the number of changes written to the log since the last
+	// snapshot.
+	logged int
+	log    *os.File
+	// size is the size of the log, so a change that fails to be written
+	// can be removed from it.
+	size int64
+	// autoIncrement is the greatest value of the auto increment column
+	// inserted in the table.
+	autoIncrement uint64
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.Inserter = (*Table)(nil)
+var _ sql.Replacer = (*Table)(nil)
+var _ sql.Updater = (*Table)(nil)
+var _ sql.AutoIncrementTable = (*Table)(nil)
+
+// createTable creates the files of a new table without rows.
+func createTable(db *Database, name string, schema sql.Schema) (*Table, error) {
+	columns, err := encodeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{name: name, schema: schema, db: db}
+	if err := writeSnapshot(t.snapshotPath(), header{Columns: columns}, nil); err != nil {
+		return nil, err
+	}
+
+	t.log, err = os.OpenFile(t.logPath(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		_ = os.Remove(t.snapshotPath())
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// openTable reads the rows of a table from its snapshot and log.
+func openTable(db *Database, name string) (*Table, error) {
+	t := &Table{name: name, db: db}
+
+	h, rows, err := readSnapshot(name, t.snapshotPath())
+	if err != nil {
+		return nil, err
+	}
+
+	t.schema, err = decodeSchema(name, h.Columns)
+	if err != nil {
+		return nil, ErrCorruptTable.New(name, err)
+	}
+
+	t.rows = rows
+	t.seq = h.Seq
+	for _, row := range rows {
+		t.updateAutoIncrement(row)
+	}
+
+	f, err := os.OpenFile(t.logPath(), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := readLog(f, func(e entry) error {
+		// Changes already in the snapshot are in the log if the
+		// database was closed before truncating it.
+		if e.Seq <= t.seq {
+			return nil
+		}
+
+		t.seq = e.Seq
+		t.logged++
+		return t.apply(e)
+	})
+	if err != nil {
+		f.Close()
+		return nil, ErrCorruptTable.New(name, err)
+	}
+
+	// A change that was not completely written is discarded, as the
+	// statement making it failed.
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	t.log = f
+	t.size = size
+	return t, nil
+}
+
+func (t *Table) snapshotPath() string { return t.db.path(t.name, snapshotExt) }
+
+func (t *Table) logPath() string { return t.db.path(t.name, logExt) }
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("DiskTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the table are
+// in a single partition.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface. The rows are copied, so
+// they are not affected by the changes made while they are read.
+func (t *Table) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	t.mu.RLock()
+	rows := make([]sql.Row, len(t.rows))
+	copy(rows, t.rows)
+	t.mu.RUnlock()
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// Insert implements the sql.Inserter interface.
+func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
+	if err := checkRow(t.schema, row); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.write(entry{Op: opInsert, Row: row})
+}
+
+// Delete implements the sql.Deleter interface.
+func (t *Table) Delete(ctx *sql.Context, row sql.Row) error {
+	if err := checkRow(t.schema, row); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.indexOf(row) < 0 {
+		return sql.ErrDeleteRowNotFound
+	}
+
+	return t.write(entry{Op: opDelete, Row: row})
+}
+
+// Update implements the sql.Updater interface.
+func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
+	if err := checkRow(t.schema, oldRow); err != nil {
+		return err
+	}
+	if err := checkRow(t.schema, newRow); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.indexOf(oldRow) < 0 {
+		return nil
+	}
+
+	return t.write(entry{Op: opUpdate, Row: newRow, Old: oldRow})
+}
+
+// NextAutoIncrementValue implements the sql.AutoIncrementTable interface.
+func (t *Table) NextAutoIncrementValue(*sql.Context) (uint64, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.autoIncrement + 1, nil
+}
+
+// write appends the change to the log and applies it to the rows once it's
+// written. The log is compacted into a new snapshot when it has more
+// changes than the snapshot threshold of the database.
+func (t *Table) write(e entry) error {
+	if t.log == nil {
+		return ErrDatabaseClosed.New(t.db.name)
+	}
+
+	e.Seq = t.seq + 1
+	w := &countingWriter{w: t.log}
+	err := writeRecord(w, e)
+	if err == nil && t.db.options.Sync {
+		err = t.log.Sync()
+	}
+
+	if err != nil {
+		if w.n > 0 {
+			_ = t.log.Truncate(t.size)
+			_, _ = t.log.Seek(t.size, io.SeekStart)
+		}
+		return err
+	}
+
+	t.size += w.n
+	t.seq = e.Seq
+	t.logged++
+	if err := t.apply(e); err != nil {
+		return err
+	}
+
+	if t.logged >= t.db.options.snapshotThreshold() {
+		// The change is already in the log, so the snapshot is tried
+		// again with the next change if it fails.
+		if err := t.snapshot(); err != nil {
+			logrus.WithField("table", t.name).WithError(err).Warn("unable to write the snapshot of the table")
+		}
+	}
+
+	return nil
+}
+
+// apply changes the rows with the given change.
+func (t *Table) apply(e entry) error {
+	switch e.Op {
+	case opInsert:
+		t.rows = append(t.rows, e.Row)
+		t.updateAutoIncrement(e.Row)
+	case opDelete:
+		if i := t.indexOf(e.Row); i >= 0 {
+			t.rows = append(t.rows[:i], t.rows[i+1:]...)
+		}
+	case opUpdate:
+		if i := t.indexOf(e.Old); i >= 0 {
+			t.rows[i] = e.Row
+			t.updateAutoIncrement(e.Row)
+		}
+	default:
+		return fmt.Errorf("unknown change %d", e.Op)
+	}
+	return nil
+}
+
+// snapshot writes all the rows in a new snapshot and truncates the log.
+func (t *Table) snapshot() error {
+	columns, err := encodeSchema(t.schema)
+	if err != nil {
+		return err
+	}
+
+	if err := writeSnapshot(t.snapshotPath(), header{Columns: columns, Seq: t.seq}, t.rows); err != nil {
+		return err
+	}
+
+	if err := t.log.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := t.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	t.logged = 0
+	t.size = 0
+	return nil
+}
+
+// close compacts the log of the table and closes it.
+func (t *Table) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.log == nil {
+		return nil
+	}
+
+	var err error
+	if t.logged > 0 {
+		err = t.snapshot()
+	}
+
+	if cerr := t.log.Close(); err == nil {
+		err = cerr
+	}
+
+	t.log = nil
+	return err
+}
+
+// indexOf returns the position of the first row equal to the given one, or
+// -1 if there is none.
+func (t *Table) indexOf(row sql.Row) int {
+	for i, r := range t.rows {
+		if reflect.DeepEqual(r, row) {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateAutoIncrement keeps the greatest value of the auto increment column
+// of the table, if it has one, so generated values are never repeated.
+func (t *Table) updateAutoIncrement(row sql.Row) {
+	for i, col := range t.schema {
+		if !col.AutoIncrement || row[i] == nil {
+			continue
+		}
+
+		v, err := sql.Int64.Convert(row[i])
+		if err == nil && v.(int64) > 0 && uint64(v.(int64)) > t.autoIncrement {
+			t.autoIncrement = uint64(v.(int64))
+		}
+	}
+}
+
+func checkRow(schema sql.Schema, row sql.Row) error {
+	if len(row) != len(schema) {
+		return sql.ErrUnexpectedRowLength.New(len(schema), len(row))
+	}
+
+	for i, value := range row {
+		if !schema[i].Check(value) {
+			return sql.ErrInvalidType.New(value)
+		}
+	}
+
+	return nil
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
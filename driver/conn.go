@@ -0,0 +1,283 @@
+package driver
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"math"
+	"sync/atomic"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// lastPid is the ID of the process of the last query run by the driver.
+var lastPid uint64
+
+// conn is a connection to an engine, with its own session.
+type conn struct {
+	engine  *sqle.Engine
+	session sql.Session
+}
+
+var _ sqldriver.Conn = (*conn)(nil)
+var _ sqldriver.ExecerContext = (*conn)(nil)
+var _ sqldriver.QueryerContext = (*conn)(nil)
+
+// Prepare implements the driver.Conn interface. Statements are parsed
+// every time they are run, with the literals of their arguments.
+func (c *conn) Prepare(query string) (sqldriver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close implements the driver.Conn interface.
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin implements the driver.Conn interface.
+func (c *conn) Begin() (sqldriver.Tx, error) {
+	return nil, ErrTransactionsNotSupported.New()
+}
+
+// query runs a query with the given arguments.
+func (c *conn) query(
+	ctx context.Context,
+	query string,
+	args []sqldriver.NamedValue,
+) (sql.Node, sql.RowIter, error) {
+	query, err := interpolate(query, args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sctx := sql.NewContext(
+		ctx,
+		sql.WithSession(c.session),
+		sql.WithPid(atomic.AddUint64(&lastPid, 1)),
+		sql.WithQuery(query),
+	)
+	return c.engine.QueryWithPlan(sctx, query)
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c *conn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []sqldriver.NamedValue,
+) (sqldriver.Result, error) {
+	node, iter, err := c.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sql.RowIterToRows(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The statements that change rows return the number of rows they
+	// changed in the last column of their only row.
+	n := changedRows(node)
+	if n == nil || len(rows) == 0 || len(rows[0]) == 0 {
+		return result{}, nil
+	}
+
+	affected, err := sql.Int64.Convert(rows[0][len(rows[0])-1])
+	if err != nil {
+		return nil, err
+	}
+
+	r := result{affected: affected.(int64)}
+	switch n.(type) {
+	case *plan.InsertInto, *plan.LoadData:
+		r.insertID = c.session.GetLastQueryInfo(sql.InsertID)
+	}
+	return r, nil
+}
+
+// changedRows returns the statement that changes rows in the given plan,
+// or nil if it does not change rows.
+func changedRows(n sql.Node) sql.Node {
+	if qp, ok := n.(*plan.QueryProcess); ok {
+		n = qp.Child
+	}
+
+	switch n.(type) {
+	case *plan.InsertInto, *plan.Update, *plan.DeleteFrom, *plan.LoadData, *plan.SelectIntoFile:
+		return n
+	default:
+		return nil
+	}
+}
+
+// result is the result of a statement run with Exec, with the first value
+// automatically generated by the INSERT or LOAD DATA statements, as sent
+// in the OK packets by the server.
+type result struct {
+	affected int64
+	insertID int64
+}
+
+var _ sqldriver.Result = result{}
+
+// LastInsertId implements the driver.Result interface.
+func (r result) LastInsertId() (int64, error) {
+	return r.insertID, nil
+}
+
+// RowsAffected implements the driver.Result interface.
+func (r result) RowsAffected() (int64, error) {
+	return r.affected, nil
+}
+
+// QueryContext implements the driver.QueryerContext interface.
+func (c *conn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []sqldriver.NamedValue,
+) (sqldriver.Rows, error) {
+	node, iter, err := c.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{schema: node.Schema(), iter: iter}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+var _ sqldriver.StmtExecContext = (*stmt)(nil)
+var _ sqldriver.StmtQueryContext = (*stmt)(nil)
+
+// Close implements the driver.Stmt interface.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput implements the driver.Stmt interface.
+func (s *stmt) NumInput() int {
+	return placeholders(s.query)
+}
+
+// Exec implements the driver.Stmt interface.
+func (s *stmt) Exec(args []sqldriver.Value) (sqldriver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query implements the driver.Stmt interface.
+func (s *stmt) Query(args []sqldriver.Value) (sqldriver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements the driver.StmtExecContext interface.
+func (s *stmt) ExecContext(ctx context.Context, args []sqldriver.NamedValue) (sqldriver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// QueryContext implements the driver.StmtQueryContext interface.
+func (s *stmt) QueryContext(ctx context.Context, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []sqldriver.Value) []sqldriver.NamedValue {
+	named := make([]sqldriver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = sqldriver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type rows struct {
+	schema sql.Schema
+	iter   sql.RowIter
+}
+
+var _ sqldriver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+var _ sqldriver.RowsColumnTypeNullable = (*rows)(nil)
+
+// Columns implements the driver.Rows interface.
+func (r *rows) Columns() []string {
+	columns := make([]string, len(r.schema))
+	for i, col := range r.schema {
+		columns[i] = col.Name
+	}
+	return columns
+}
+
+// Close implements the driver.Rows interface.
+func (r *rows) Close() error {
+	return r.iter.Close()
+}
+
+// Next implements the driver.Rows interface.
+func (r *rows) Next(dest []sqldriver.Value) error {
+	row, err := r.iter.Next()
+	if err != nil {
+		return err
+	}
+
+	for i := range dest {
+		if dest[i], err = value(r.schema[i].Type, row[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName implements the
+// driver.RowsColumnTypeDatabaseTypeName interface.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.schema[index].Type.Type().String()
+}
+
+// ColumnTypeNullable implements the driver.RowsColumnTypeNullable
+// interface.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.schema[index].Nullable, true
+}
+
+// value converts a value of a row to one of the types of the values of the
+// drivers. Unsigned integers too large for an int64, and the values
+// without a Go type of their own, such as JSON documents, are converted to
+// their text, as they are sent with the MySQL protocol.
+func value(typ sql.Type, v interface{}) (sqldriver.Value, error) {
+	// Tables may keep dates as they were inserted, as strings.
+	if s, ok := v.(string); ok && sql.IsTime(typ) {
+		return typ.Convert(s)
+	}
+
+	switch v := v.(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time:
+		return v, nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float32:
+		return float64(v), nil
+	}
+
+	val, err := typ.SQL(v)
+	if err != nil {
+		return nil, err
+	}
+	return val.ToBytes(), nil
+}
@@ -0,0 +1,143 @@
+// Package driver implements a database/sql driver that runs the queries in
+// an engine of the same process, so they are not sent over the network
+// with the MySQL protocol, and their rows are not encoded.
+//
+// The driver is registered with the name gms. Its data source names are
+// memory://name, which opens an engine with a memory database with the
+// given name, or memory if there is none. All the connections to the same
+// data source name use the same engine. Existing engines are used with
+// NewConnector and sql.OpenDB:
+//
+//	db := sql.OpenDB(driver.NewConnector(engine, "mydb"))
+//
+// Placeholders in queries are replaced with the literals of their
+// arguments before they are parsed. Transactions are not supported.
+package driver
+
+import (
+	"context"
+	dsql "database/sql"
+	sqldriver "database/sql/driver"
+	"net/url"
+	"sync"
+	"sync/atomic"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// DriverName is the name the driver is registered with.
+const DriverName = "gms"
+
+// ErrInvalidDSN is returned when a data source name cannot be parsed.
+var ErrInvalidDSN = errors.NewKind("invalid data source name %q: %s")
+
+// ErrTransactionsNotSupported is returned when a transaction is started.
+var ErrTransactionsNotSupported = errors.NewKind("transactions are not supported")
+
+func init() {
+	dsql.Register(DriverName, new(Driver))
+}
+
+// Driver is the database/sql driver of the engine.
+type Driver struct {
+	mu      sync.Mutex
+	engines map[string]*connector
+}
+
+var _ sqldriver.Driver = (*Driver)(nil)
+var _ sqldriver.DriverContext = (*Driver)(nil)
+
+// Open implements the driver.Driver interface.
+func (d *Driver) Open(dsn string) (sqldriver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector implements the driver.DriverContext interface. The engine
+// of a data source name is created the first time it's opened.
+func (d *Driver) OpenConnector(dsn string) (sqldriver.Connector, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, ErrInvalidDSN.New(dsn, err)
+	}
+
+	if u.Scheme != "memory" {
+		return nil, ErrInvalidDSN.New(dsn, "the scheme must be memory")
+	}
+
+	if u.Path != "" && u.Path != "/" || u.RawQuery != "" {
+		return nil, ErrInvalidDSN.New(dsn, "only the name of the database can be given")
+	}
+
+	name := u.Host
+	if name == "" {
+		name = "memory"
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if c, ok := d.engines[dsn]; ok {
+		return c, nil
+	}
+
+	e := sqle.NewDefault()
+	e.AddDatabase(memory.NewDatabase(name))
+	c := &connector{driver: d, engine: e, database: name}
+
+	if d.engines == nil {
+		d.engines = make(map[string]*connector)
+	}
+	d.engines[dsn] = c
+	return c, nil
+}
+
+// NewConnector returns a connector of the given engine, to be opened with
+// sql.OpenDB. The given database, if any, is made the current one when
+// connections are opened. As in the rest of the clients of the engine, the
+// current database is shared by all of them.
+func NewConnector(e *sqle.Engine, database string) sqldriver.Connector {
+	return &connector{driver: new(Driver), engine: e, database: database}
+}
+
+type connector struct {
+	driver   *Driver
+	engine   *sqle.Engine
+	database string
+}
+
+var _ sqldriver.Connector = (*connector)(nil)
+
+// lastConnectionID is the ID of the last session created, which is shared
+// by all the engines, as it's only used to tell apart the sessions.
+var lastConnectionID uint32
+
+// Connect implements the driver.Connector interface.
+func (c *connector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	id := atomic.AddUint32(&lastConnectionID, 1)
+	conn := &conn{
+		engine:  c.engine,
+		session: sql.NewSession("", "", "", id),
+	}
+
+	if c.database != "" {
+		db, err := c.engine.Catalog.Database(c.database)
+		if err != nil {
+			return nil, err
+		}
+		c.engine.Catalog.SetCurrentDatabase(db.Name())
+	}
+
+	return conn, nil
+}
+
+// Driver implements the driver.Connector interface.
+func (c *connector) Driver() sqldriver.Driver {
+	return c.driver
+}
@@ -0,0 +1,175 @@
+package driver
+
+import (
+	dsql "database/sql"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver(t *testing.T) {
+	require := require.New(t)
+
+	db, err := dsql.Open(DriverName, "memory://shop")
+	require.NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE items (id BIGINT NOT NULL, name TEXT NOT NULL, price DOUBLE, added DATETIME)")
+	require.NoError(err)
+
+	added := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	result, err := db.Exec("INSERT INTO items VALUES (?, ?, ?, ?), (?, ?, ?, ?)",
+		1, "it's", 2.5, added,
+		2, "pen", nil, added.Add(time.Hour),
+	)
+	require.NoError(err)
+	affected, err := result.RowsAffected()
+	require.NoError(err)
+	require.Equal(int64(2), affected)
+
+	// Connections to the same data source name use the same engine.
+	other, err := dsql.Open(DriverName, "memory://shop")
+	require.NoError(err)
+	defer other.Close()
+
+	var count int
+	require.NoError(other.QueryRow("SELECT COUNT(*) FROM items").Scan(&count))
+	require.Equal(2, count)
+
+	rows, err := db.Query("SELECT id, name, price, added FROM items WHERE name <> ? ORDER BY id", "?")
+	require.NoError(err)
+
+	columns, err := rows.Columns()
+	require.NoError(err)
+	require.Equal([]string{"id", "name", "price", "added"}, columns)
+
+	types, err := rows.ColumnTypes()
+	require.NoError(err)
+	require.Equal("INT64", types[0].DatabaseTypeName())
+	nullable, ok := types[2].Nullable()
+	require.True(ok)
+	require.True(nullable)
+
+	type item struct {
+		id    int64
+		name  string
+		price dsql.NullFloat64
+		added time.Time
+	}
+	var items []item
+	for rows.Next() {
+		var i item
+		require.NoError(rows.Scan(&i.id, &i.name, &i.price, &i.added))
+		items = append(items, i)
+	}
+	require.NoError(rows.Err())
+	require.NoError(rows.Close())
+	require.Equal([]item{
+		{1, "it's", dsql.NullFloat64{Float64: 2.5, Valid: true}, added},
+		{2, "pen", dsql.NullFloat64{}, added.Add(time.Hour)},
+	}, items)
+
+	stmt, err := db.Prepare("SELECT name FROM items WHERE id = ?")
+	require.NoError(err)
+	defer stmt.Close()
+
+	var name string
+	require.NoError(stmt.QueryRow(2).Scan(&name))
+	require.Equal("pen", name)
+	require.Equal(dsql.ErrNoRows, stmt.QueryRow(3).Scan(&name))
+
+	result, err = db.Exec("DELETE FROM items WHERE id = ?", 1)
+	require.NoError(err)
+	affected, err = result.RowsAffected()
+	require.NoError(err)
+	require.Equal(int64(1), affected)
+
+	_, err = db.Begin()
+	require.True(ErrTransactionsNotSupported.Is(err))
+
+	_, err = db.Exec("SELECT ?")
+	require.True(ErrArguments.Is(err))
+}
+
+func TestDriverLastInsertId(t *testing.T) {
+	require := require.New(t)
+
+	db, err := dsql.Open(DriverName, "memory://autoinc")
+	require.NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE notes (id BIGINT AUTO_INCREMENT PRIMARY KEY, body TEXT)")
+	require.NoError(err)
+
+	result, err := db.Exec("INSERT INTO notes (body) VALUES (?), (?)", "a", "b")
+	require.NoError(err)
+	id, err := result.LastInsertId()
+	require.NoError(err)
+	require.Equal(int64(1), id)
+
+	result, err = db.Exec("INSERT INTO notes (body) VALUES (?)", "c")
+	require.NoError(err)
+	id, err = result.LastInsertId()
+	require.NoError(err)
+	require.Equal(int64(3), id)
+
+	// The statements that don't generate values have no insert id.
+	result, err = db.Exec("UPDATE notes SET body = ? WHERE id = ?", "d", 3)
+	require.NoError(err)
+	id, err = result.LastInsertId()
+	require.NoError(err)
+	require.Equal(int64(0), id)
+	affected, err := result.RowsAffected()
+	require.NoError(err)
+	require.Equal(int64(1), affected)
+}
+
+func TestDriverInvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"mysql://localhost", "memory://db/table", "memory://db?a=b"} {
+		_, err := dsql.Open(DriverName, dsn)
+		require.True(t, ErrInvalidDSN.Is(err), dsn)
+	}
+}
+
+func TestConnector(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("numbers", sql.Schema{
+		{Name: "n", Type: sql.Uint64, Source: "numbers"},
+		{Name: "doc", Type: sql.JSON, Source: "numbers", Nullable: true},
+	})
+	ctx := sql.NewEmptyContext()
+	require.NoError(table.Insert(ctx, sql.NewRow(uint64(1), map[string]interface{}{"a": float64(1)})))
+	require.NoError(table.Insert(ctx, sql.NewRow(uint64(1<<63), nil)))
+
+	mydb := memory.NewDatabase("mydb")
+	mydb.AddTable("numbers", table)
+	e := sqle.NewDefault()
+	e.AddDatabase(memory.NewDatabase("other"))
+	e.AddDatabase(mydb)
+
+	db := dsql.OpenDB(NewConnector(e, "mydb"))
+	defer db.Close()
+
+	rows, err := db.Query("SELECT n, doc FROM numbers ORDER BY n")
+	require.NoError(err)
+	defer rows.Close()
+
+	var values [][]interface{}
+	for rows.Next() {
+		var n, doc interface{}
+		require.NoError(rows.Scan(&n, &doc))
+		values = append(values, []interface{}{n, doc})
+	}
+	require.NoError(rows.Err())
+	require.Equal([][]interface{}{
+		{int64(1), []byte(`{"a":1}`)},
+		{[]byte("9223372036854775808"), nil},
+	}, values)
+
+	require.Error(dsql.OpenDB(NewConnector(e, "missing")).Ping())
+}
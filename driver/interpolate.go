@@ -0,0 +1,160 @@
+package driver
+
+import (
+	sqldriver "database/sql/driver"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrArguments is returned when the arguments of a query don't match its
+// placeholders.
+var ErrArguments = errors.NewKind("query has %d placeholders and %d arguments")
+
+// ErrNamedArgument is returned when an argument of a query has a name, as
+// only the ? placeholders are supported.
+var ErrNamedArgument = errors.NewKind("named argument %s is not supported")
+
+// ErrUnsupportedArgument is returned when an argument of a query has a
+// type that is not a value of the drivers.
+var ErrUnsupportedArgument = errors.NewKind("argument %d has the unsupported type %T")
+
+// placeholderPositions returns the positions of the ? placeholders of a
+// query, which are the question marks outside of strings, quoted
+// identifiers and comments.
+func placeholderPositions(query string) []int {
+	var positions []int
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '?':
+			positions = append(positions, i)
+		case '\'', '"', '`':
+			// Quotes are escaped by doubling them, which is the same as
+			// two consecutive quoted parts, and backslashes escape the
+			// next character in strings.
+			for i++; i < len(query) && query[i] != c; i++ {
+				if query[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case '#':
+			i = lineEnd(query, i)
+		case '-':
+			if strings.HasPrefix(query[i:], "-- ") || query[i:] == "--" ||
+				strings.HasPrefix(query[i:], "--\t") || strings.HasPrefix(query[i:], "--\n") {
+				i = lineEnd(query, i)
+			}
+		case '/':
+			if strings.HasPrefix(query[i:], "/*") {
+				end := strings.Index(query[i+2:], "*/")
+				if end < 0 {
+					return positions
+				}
+				i += end + 3
+			}
+		}
+	}
+	return positions
+}
+
+func lineEnd(query string, i int) int {
+	if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(query)
+}
+
+func placeholders(query string) int {
+	return len(placeholderPositions(query))
+}
+
+// interpolate replaces the placeholders of a query with the literals of
+// the given arguments.
+func interpolate(query string, args []sqldriver.NamedValue) (string, error) {
+	positions := placeholderPositions(query)
+	if len(positions) != len(args) {
+		return "", ErrArguments.New(len(positions), len(args))
+	}
+
+	if len(args) == 0 {
+		return query, nil
+	}
+
+	var b strings.Builder
+	var last int
+	for i, pos := range positions {
+		arg := args[i]
+		if arg.Name != "" {
+			return "", ErrNamedArgument.New(arg.Name)
+		}
+
+		b.WriteString(query[last:pos])
+		if err := writeLiteral(&b, arg.Ordinal, arg.Value); err != nil {
+			return "", err
+		}
+		last = pos + 1
+	}
+	b.WriteString(query[last:])
+
+	return b.String(), nil
+}
+
+func writeLiteral(b *strings.Builder, ordinal int, v sqldriver.Value) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("NULL")
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		if v {
+			b.WriteString("TRUE")
+		} else {
+			b.WriteString("FALSE")
+		}
+	case []byte:
+		if v == nil {
+			b.WriteString("NULL")
+			break
+		}
+		b.WriteString("X'")
+		b.WriteString(hex.EncodeToString(v))
+		b.WriteString("'")
+	case string:
+		writeString(b, v)
+	case time.Time:
+		writeString(b, v.Format("2006-01-02 15:04:05.999999"))
+	default:
+		return ErrUnsupportedArgument.New(ordinal, v)
+	}
+	return nil
+}
+
+// writeString writes a string literal, escaping its quotes, backslashes
+// and the characters that cannot be written as they are.
+func writeString(b *strings.Builder, s string) {
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case 0x1a:
+			b.WriteString(`\Z`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+}
@@ -0,0 +1,58 @@
+package driver
+
+import (
+	sqldriver "database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	testCases := []struct {
+		query    string
+		args     []sqldriver.Value
+		expected string
+	}{
+		{"SELECT 1", nil, "SELECT 1"},
+		{
+			"SELECT ?, ?, ?, ?, ?",
+			[]sqldriver.Value{nil, int64(-1), 1.5, true, false},
+			"SELECT NULL, -1, 1.5, TRUE, FALSE",
+		},
+		{
+			"INSERT INTO t VALUES (?, ?, ?)",
+			[]sqldriver.Value{"it's a \\ \n", []byte{0xca, 0xfe}, ts},
+			`INSERT INTO t VALUES ('it\'s a \\ \n', X'cafe', '2019-01-02 03:04:05.6')`,
+		},
+		{
+			"SELECT '?', \"?\", `?`, 'it''s ?', 'a\\'?' /* ? */, ? -- ?\n# ?\n, ?",
+			[]sqldriver.Value{int64(1), int64(2)},
+			"SELECT '?', \"?\", `?`, 'it''s ?', 'a\\'?' /* ? */, 1 -- ?\n# ?\n, 2",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			query, err := interpolate(tt.query, namedValues(tt.args))
+			require.NoError(err)
+			require.Equal(tt.expected, query)
+			require.Equal(len(tt.args), placeholders(tt.query))
+		})
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	require := require.New(t)
+
+	_, err := interpolate("SELECT ?, ?", namedValues([]sqldriver.Value{int64(1)}))
+	require.True(ErrArguments.Is(err))
+
+	_, err = interpolate("SELECT ?", []sqldriver.NamedValue{{Name: "a", Ordinal: 1, Value: int64(1)}})
+	require.True(ErrNamedArgument.Is(err))
+
+	_, err = interpolate("SELECT ?", namedValues([]sqldriver.Value{struct{}{}}))
+	require.True(ErrUnsupportedArgument.Is(err))
+}
@@ -0,0 +1,171 @@
+package sqle
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// Dump writes the schema and rows of the databases of the engine as SQL
+// statements compatible with the ones written by mysqldump, so they can be
+// restored with Restore or by MySQL.
+func (e *Engine) Dump(ctx *sql.Context, w io.Writer, opts plan.DumpOptions) error {
+	_, err := plan.WriteDump(ctx, e.Catalog, w, opts)
+	return err
+}
+
+// Restore runs the statements of a dump, such as the ones written by Dump or
+// mysqldump, stopping at the first one that fails. Dumps creating their
+// databases need a database creator in the catalog. As with mysqldump,
+// timestamps are read in UTC.
+func (e *Engine) Restore(ctx *sql.Context, r io.Reader) error {
+	typ, tz := ctx.Get(sql.TimeZoneVariable)
+	ctx.Set(sql.TimeZoneVariable, sql.Text, "+00:00")
+	defer ctx.Set(sql.TimeZoneVariable, typ, tz)
+
+	return splitStatements(r, func(query string) error {
+		_, iter, err := e.Query(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		for {
+			if _, err := iter.Next(); err == io.EOF {
+				break
+			} else if err != nil {
+				_ = iter.Close()
+				return err
+			}
+		}
+
+		return iter.Close()
+	})
+}
+
+// splitStatements calls fn with each statement of a script, which are
+// separated by semicolons outside of quotes and comments. Line comments are
+// removed, and statements with nothing but spaces and comments are skipped.
+func splitStatements(r io.Reader, fn func(string) error) error {
+	rd := bufio.NewReader(r)
+	var stmt bytes.Buffer
+	var empty = true
+
+	flush := func() error {
+		s := stmt.String()
+		stmt.Reset()
+		if empty {
+			return nil
+		}
+
+		empty = true
+		return fn(s)
+	}
+
+	for {
+		c, err := rd.ReadByte()
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case c == ';':
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		case c == '#' || c == '-' && isLineComment(rd):
+			if _, err := rd.ReadString('\n'); err != nil && err != io.EOF {
+				return err
+			}
+			stmt.WriteByte('\n')
+			continue
+		case c == '/' && peekByte(rd) == '*':
+			if err := copyComment(rd, &stmt); err != nil {
+				return err
+			}
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			empty = false
+			stmt.WriteByte(c)
+			if err := copyQuoted(rd, &stmt, c); err != nil {
+				return err
+			}
+			continue
+		case !strings.ContainsRune(" \t\r\n", rune(c)):
+			empty = false
+		}
+
+		stmt.WriteByte(c)
+	}
+}
+
+// isLineComment checks if a dash starts a comment, which is two dashes
+// followed by a space or the end of the line.
+func isLineComment(rd *bufio.Reader) bool {
+	b, _ := rd.Peek(2)
+	return len(b) > 0 && b[0] == '-' && (len(b) == 1 || strings.ContainsRune(" \t\r\n", rune(b[1])))
+}
+
+func peekByte(rd *bufio.Reader) byte {
+	b, err := rd.Peek(1)
+	if err != nil {
+		return 0
+	}
+	return b[0]
+}
+
+// copyComment copies a block comment, whose slash was already read.
+func copyComment(rd *bufio.Reader, buf *bytes.Buffer) error {
+	var prev byte
+	buf.WriteByte('/')
+	for i := 0; ; i++ {
+		c, err := rd.ReadByte()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(c)
+
+		// The first character is the asterisk opening the comment.
+		if i > 1 && prev == '*' && c == '/' {
+			return nil
+		}
+		prev = c
+	}
+}
+
+// copyQuoted copies the rest of a quoted string or identifier, whose
+// quotes are escaped writing them twice, or with a backslash in strings.
+func copyQuoted(rd *bufio.Reader, buf *bytes.Buffer, quote byte) error {
+	for {
+		c, err := rd.ReadByte()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(c)
+
+		switch {
+		case c == '\\' && quote != '`':
+			c, err := rd.ReadByte()
+			if err != nil {
+				return io.ErrUnexpectedEOF
+			}
+			buf.WriteByte(c)
+		case c == quote:
+			// A doubled quote is read as the next quoted part.
+			return nil
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package sqle
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	require := require.New(t)
+
+	script := "-- comment; with a semicolon\n" +
+		"/*!40101 SET NAMES utf8mb4 */;\n" +
+		"INSERT INTO t VALUES ('a;b', 'it''s', 'c\\';', \"d;\"), (1 - -1);\n" +
+		"# another comment;\n" +
+		"SELECT `a;``b` /* c; */ FROM t;\n" +
+		";\n" +
+		"SELECT 1"
+
+	var stmts []string
+	require.NoError(splitStatements(strings.NewReader(script), func(s string) error {
+		stmts = append(stmts, strings.TrimSpace(s))
+		return nil
+	}))
+	require.Equal([]string{
+		"INSERT INTO t VALUES ('a;b', 'it''s', 'c\\';', \"d;\"), (1 - -1)",
+		"SELECT `a;``b` /* c; */ FROM t",
+		"SELECT 1",
+	}, stmts)
+
+	err := splitStatements(strings.NewReader("SELECT 'a"), func(string) error { return nil })
+	require.Error(err)
+}
+
+func TestDumpRestore(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "name", Type: sql.Text, Source: "t", Nullable: true},
+		{Name: "score", Type: sql.Float64, Source: "t", Nullable: true},
+		{Name: "active", Type: sql.Boolean, Source: "t", Nullable: true},
+		{Name: "day", Type: sql.Date, Source: "t", Nullable: true},
+		{Name: "at", Type: sql.Timestamp, Source: "t", Nullable: true},
+		{Name: "data", Type: sql.Blob, Source: "t", Nullable: true},
+		{Name: "doc", Type: sql.JSON, Source: "t", Nullable: true},
+	}
+	table := memory.NewTable("t", schema)
+
+	at := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	day := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+	rows := []sql.Row{
+		{int64(1), "it's a \"test\"\n\\ \x00;", 1.5, true, day, at, []byte{0, 1, 0xff}, `{"a":[1,"b"]}`},
+		{int64(2), nil, nil, nil, nil, nil, nil, nil},
+		{int64(3), "", 0.0, false, day, at, []byte{}, `{}`},
+	}
+	for _, row := range rows {
+		require.NoError(table.Insert(ctx, row))
+	}
+
+	db := memory.NewDatabase("mydb")
+	db.AddTable("t", table)
+	db.AddTable("empty", memory.NewTable("empty", sql.Schema{{Name: "a", Type: sql.Int32, Source: "empty"}}))
+
+	e := NewDefault()
+	e.AddDatabase(db)
+
+	var buf bytes.Buffer
+	require.NoError(e.Dump(ctx, &buf, plan.DumpOptions{Databases: []string{"mydb"}, CreateDatabases: true}))
+	dump := buf.String()
+	require.Contains(dump, "CREATE DATABASE IF NOT EXISTS `mydb`")
+	require.Contains(dump, "INSERT INTO `t` VALUES (1,'it\\'s a \\\"test\\\"\\n\\\\ \\0;',1.5,1,'2019-01-02',"+
+		"'2019-01-02 03:04:05',X'0001FF','{\\\"a\\\":[1,\\\"b\\\"]}'),(2,NULL,NULL,NULL,NULL,NULL,NULL,NULL),"+
+		"(3,'',0,0,'2019-01-02','2019-01-02 03:04:05',X'','{}');\n")
+	require.NotContains(dump, "INSERT INTO `empty`")
+
+	restored := NewDefault()
+	restored.Catalog.SetDatabaseCreator(memory.DatabaseCreator{})
+
+	// Restoring twice replaces the tables.
+	for i := 0; i < 2; i++ {
+		require.NoError(restored.Restore(sql.NewEmptyContext(), strings.NewReader(dump)))
+	}
+
+	query := "SELECT * FROM mydb.t ORDER BY id"
+	_, iter, err := e.Query(ctx, query)
+	require.NoError(err)
+	expected, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	_, iter, err = restored.Query(ctx, query)
+	require.NoError(err)
+	result, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, result)
+
+	tables := restored.Catalog.AllDatabases()
+	restoredDB, err := tables.Database("mydb")
+	require.NoError(err)
+	require.Len(restoredDB.Tables(), 2)
+	require.True(restoredDB.Tables()["t"].Schema()[0].PrimaryKey)
+
+	dir, err := ioutil.TempDir("", "dump")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// The DUMP statement writes the same dump to a file.
+	e.Catalog.SetSecureFilePriv(dir)
+	_, iter, err = e.Query(ctx, "DUMP DATABASES mydb INTO 'mydb.sql'")
+	require.NoError(err)
+	result, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(3)}}, result)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "mydb.sql"))
+	require.NoError(err)
+	require.Equal(dump, string(data))
+}
+
+func TestRestoreMySQLDump(t *testing.T) {
+	require := require.New(t)
+
+	const dump = `-- MySQL dump 10.13  Distrib 5.7.26, for Linux (x86_64)
+--
+-- Host: localhost    Database: mydb
+-- ------------------------------------------------------
+-- Server version	5.7.26
+
+/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
+/*!40101 SET @OLD_CHARACTER_SET_RESULTS=@@CHARACTER_SET_RESULTS */;
+/*!40101 SET @OLD_COLLATION_CONNECTION=@@COLLATION_CONNECTION */;
+/*!40101 SET NAMES utf8 */;
+/*!40103 SET @OLD_TIME_ZONE=@@TIME_ZONE */;
+/*!40103 SET TIME_ZONE='+00:00' */;
+/*!40014 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0 */;
+/*!40014 SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0 */;
+/*!40101 SET @OLD_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_VALUE_ON_ZERO' */;
+/*!40111 SET @OLD_SQL_NOTES=@@SQL_NOTES, SQL_NOTES=0 */;
+
+--
+-- Table structure for table ` + "`users`" + `
+--
+
+DROP TABLE IF EXISTS ` + "`users`" + `;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE ` + "`users`" + ` (
+  ` + "`id`" + ` int(11) NOT NULL AUTO_INCREMENT,
+  ` + "`name`" + ` varchar(255) DEFAULT NULL,
+  ` + "`created`" + ` datetime DEFAULT NULL,
+  PRIMARY KEY (` + "`id`" + `),
+  KEY ` + "`name`" + ` (` + "`name`" + `)
+) ENGINE=InnoDB AUTO_INCREMENT=3 DEFAULT CHARSET=latin1;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Dumping data for table ` + "`users`" + `
+--
+
+LOCK TABLES ` + "`users`" + ` WRITE;
+/*!40000 ALTER TABLE ` + "`users`" + ` DISABLE KEYS */;
+INSERT INTO ` + "`users`" + ` VALUES (1,'O\'Brien; \"Bob\"','2019-01-02 03:04:05'),(2,NULL,NULL);
+/*!40000 ALTER TABLE ` + "`users`" + ` ENABLE KEYS */;
+UNLOCK TABLES;
+/*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
+
+/*!40101 SET SQL_MODE=@OLD_SQL_MODE */;
+/*!40014 SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS */;
+/*!40014 SET UNIQUE_CHECKS=@OLD_UNIQUE_CHECKS */;
+/*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;
+/*!40101 SET CHARACTER_SET_RESULTS=@OLD_CHARACTER_SET_RESULTS */;
+/*!40101 SET COLLATION_CONNECTION=@OLD_COLLATION_CONNECTION */;
+/*!40111 SET SQL_NOTES=@OLD_SQL_NOTES */;
+
+-- Dump completed on 2019-06-01 10:00:00
+`
+
+	e := NewDefault()
+	e.AddDatabase(memory.NewDatabase("mydb"))
+	ctx := sql.NewEmptyContext()
+	require.NoError(e.Restore(ctx, strings.NewReader(dump)))
+
+	_, iter, err := e.Query(ctx, "SELECT id, name, created FROM users ORDER BY id")
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{int32(1), `O'Brien; "Bob"`, time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{int32(2), nil, nil},
+	}, rows)
+}
+
+func TestRestoreWithoutDatabaseCreator(t *testing.T) {
+	require := require.New(t)
+
+	e := NewDefault()
+	err := e.Restore(sql.NewEmptyContext(), strings.NewReader("CREATE DATABASE IF NOT EXISTS `db`;\nUSE `db`;\n"))
+	require.Error(err)
+	require.True(sql.ErrDatabaseCreation.Is(err))
+}
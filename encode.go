@@ -0,0 +1,145 @@
+package sqle
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// WriteJSON writes the rows of a result to w as a JSON array with an object
+// per row, keyed by the names of the columns:
+//
+//	schema, iter, err := engine.Query(ctx, "SELECT id, name FROM users")
+//	...
+//	defer iter.Close()
+//	err = sqle.WriteJSON(w, schema, iter)
+//
+// The values are encoded with JSONValue. The rows are written as they are
+// read, so nothing is written if reading the first one fails, and the
+// array is truncated if reading any other fails. The iterator is not
+// closed.
+func WriteJSON(w io.Writer, schema sql.Schema, iter sql.RowIter) error {
+	row, err := iter.Next()
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	keys := make([][]byte, len(schema))
+	for i, col := range schema {
+		var jerr error
+		if keys[i], jerr = json.Marshal(col.Name); jerr != nil {
+			return jerr
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+	for n := 0; err == nil; n++ {
+		var values []sqltypes.Value
+		if values, err = rowValues(schema, row); err != nil {
+			break
+		}
+
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			bw.Write(JSONValue(schema[i].Type, v))
+		}
+		bw.WriteByte('}')
+
+		row, err = iter.Next()
+	}
+
+	if err == io.EOF {
+		err = nil
+		bw.WriteString("]\n")
+	}
+
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// JSONValue encodes a value of the given type in JSON. Numbers are written
+// as they are sent with the MySQL protocol, keeping their precision, and
+// JSON values as they are, while the rest are strings.
+func JSONValue(typ sql.Type, v sqltypes.Value) []byte {
+	if v.IsNull() {
+		return []byte("null")
+	}
+
+	raw := v.Raw()
+	if (v.IsIntegral() || v.IsFloat() || v.Type() == sqltypes.Decimal || typ == sql.JSON) && json.Valid(raw) {
+		return raw
+	}
+
+	data, _ := json.Marshal(string(raw))
+	return data
+}
+
+// WriteCSV writes the rows of a result to w as CSV, with a header with the
+// names of the columns. NULL values are empty. The rows are written as
+// they are read, so nothing is written if reading the first one fails, and
+// the rows are truncated if reading any other fails. The iterator is not
+// closed.
+func WriteCSV(w io.Writer, schema sql.Schema, iter sql.RowIter) error {
+	row, err := iter.Next()
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	record := make([]string, len(schema))
+	for i, col := range schema {
+		record[i] = col.Name
+	}
+	cw.Write(record)
+
+	for err == nil {
+		var values []sqltypes.Value
+		if values, err = rowValues(schema, row); err != nil {
+			break
+		}
+
+		for i, v := range values {
+			record[i] = v.ToString()
+		}
+		cw.Write(record)
+
+		row, err = iter.Next()
+	}
+	if err == io.EOF {
+		err = nil
+	}
+
+	cw.Flush()
+	if ferr := cw.Error(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// rowValues returns the values of a row as they are sent with the MySQL
+// protocol.
+func rowValues(schema sql.Schema, row sql.Row) ([]sqltypes.Value, error) {
+	values := make([]sqltypes.Value, len(row))
+	for i, v := range row {
+		var err error
+		if values[i], err = schema[i].Type.SQL(v); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,523 @@
+package sqle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// DefaultArrowBatchSize is the number of rows of the record batches
+// written by WriteArrow when no size is given.
+const DefaultArrowBatchSize = 1024
+
+// WriteArrow writes the rows of a result to w in the Apache Arrow IPC
+// streaming format: a schema message, record batches of up to batchSize
+// rows, or DefaultArrowBatchSize if it's not positive, and the end of
+// stream marker.
+//
+// Integers, floats, booleans, dates and timestamps are written as the
+// Arrow types with the same width, binary and geometry values as binary
+// data, and the rest as UTF-8 strings with their MySQL text. TIMESTAMP
+// columns are in UTC, while DATETIME ones have no time zone.
+//
+// The batches are written as they are filled, so nothing is written if
+// reading the first row fails, and the stream has no end marker if
+// reading any other fails. The iterator is not closed.
+func WriteArrow(w io.Writer, schema sql.Schema, iter sql.RowIter, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = DefaultArrowBatchSize
+	}
+
+	row, err := iter.Next()
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	aw := &arrowWriter{w: bufio.NewWriter(w)}
+	fields := make([]fbTable, len(schema))
+	for i, col := range schema {
+		c := newArrowColumn(col.Type)
+		aw.columns = append(aw.columns, c)
+		fields[i] = fbTable{
+			col.Name,
+			col.Nullable,
+			c.typeType,
+			c.typeTable,
+			nil,
+			[]fbTable{},
+		}
+	}
+
+	aw.message(arrowSchema, fbTable{nil, fields}, nil)
+	for err == nil {
+		if err = aw.append(row); err != nil {
+			break
+		}
+
+		if aw.rows == batchSize {
+			aw.batch()
+		}
+		row, err = iter.Next()
+	}
+
+	if err == io.EOF {
+		err = nil
+		if aw.rows > 0 {
+			aw.batch()
+		}
+		aw.write(arrowContinuation, 0)
+	}
+
+	if ferr := aw.w.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+const (
+	// arrowContinuation is the marker before the metadata of the messages.
+	arrowContinuation uint32 = 0xFFFFFFFF
+	// arrowMetadataV5 is the version of the metadata of the messages.
+	arrowMetadataV5 int16 = 4
+)
+
+// Types of the headers of the Arrow messages.
+const (
+	arrowSchema      uint8 = 1
+	arrowRecordBatch uint8 = 3
+)
+
+// Types of the Arrow fields.
+const (
+	arrowInt       uint8 = 2
+	arrowFloat     uint8 = 3
+	arrowBinary    uint8 = 4
+	arrowUtf8      uint8 = 5
+	arrowBool      uint8 = 6
+	arrowDate      uint8 = 8
+	arrowTimestamp uint8 = 10
+)
+
+// Units and precisions of the Arrow types.
+const (
+	arrowSingle int16 = 1
+	arrowDouble int16 = 2
+	arrowDay    int16 = 0
+	arrowMicros int16 = 2
+)
+
+// arrowWriter writes the messages of an Arrow stream, keeping the columns
+// of the rows of the next record batch.
+type arrowWriter struct {
+	w       *bufio.Writer
+	columns []*arrowColumn
+	rows    int
+}
+
+func (w *arrowWriter) append(row sql.Row) error {
+	for i, c := range w.columns {
+		if err := c.append(row[i]); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	return nil
+}
+
+// batch writes the rows appended so far as a record batch.
+func (w *arrowWriter) batch() {
+	var nodes, buffers, body []byte
+	for _, c := range w.columns {
+		nodes = appendInt64s(nodes, int64(c.n), int64(c.nulls))
+		for _, buf := range c.buffers() {
+			buffers = appendInt64s(buffers, int64(len(body)), int64(len(buf)))
+			body = append(body, buf...)
+			for len(body)%8 != 0 {
+				body = append(body, 0)
+			}
+		}
+		c.reset()
+	}
+
+	w.message(arrowRecordBatch, fbTable{
+		int64(w.rows),
+		fbStructs{n: len(w.columns), data: nodes},
+		fbStructs{n: len(buffers) / 16, data: buffers},
+	}, body)
+	w.rows = 0
+}
+
+// message writes a message with the given header and body, which must be
+// padded to 8 bytes.
+func (w *arrowWriter) message(typ uint8, header fbTable, body []byte) {
+	metadata := new(fbBuilder).finish(fbTable{
+		arrowMetadataV5,
+		typ,
+		header,
+		int64(len(body)),
+	})
+
+	w.write(arrowContinuation, uint32(len(metadata)))
+	w.w.Write(metadata)
+	w.w.Write(body)
+}
+
+func (w *arrowWriter) write(values ...uint32) {
+	var b [4]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint32(b[:], v)
+		w.w.Write(b[:])
+	}
+}
+
+// arrowColumn keeps the values of a column of a record batch in its Arrow
+// buffers.
+type arrowColumn struct {
+	typ       sql.Type
+	typeType  uint8
+	typeTable fbTable
+	// width is the size of the values of fixed size types.
+	width  int
+	signed bool
+
+	n, nulls int
+	validity []byte
+	offsets  []byte
+	values   []byte
+}
+
+func newArrowColumn(typ sql.Type) *arrowColumn {
+	c := &arrowColumn{typ: typ, typeTable: fbTable{}}
+	switch typ.Type() {
+	case sqltypes.Int8, sqltypes.Int16, sqltypes.Int24, sqltypes.Int32, sqltypes.Int64,
+		sqltypes.Uint8, sqltypes.Uint16, sqltypes.Uint24, sqltypes.Uint32, sqltypes.Uint64:
+		c.typeType = arrowInt
+		c.signed = sqltypes.IsSigned(typ.Type())
+		switch typ.Type() {
+		case sqltypes.Int8, sqltypes.Uint8:
+			c.width = 1
+		case sqltypes.Int16, sqltypes.Uint16:
+			c.width = 2
+		case sqltypes.Int64, sqltypes.Uint64:
+			c.width = 8
+		default:
+			c.width = 4
+		}
+		c.typeTable = fbTable{int32(c.width * 8), c.signed}
+	case sqltypes.Float32:
+		c.typeType, c.width = arrowFloat, 4
+		c.typeTable = fbTable{arrowSingle}
+	case sqltypes.Float64:
+		c.typeType, c.width = arrowFloat, 8
+		c.typeTable = fbTable{arrowDouble}
+	case sqltypes.Date:
+		c.typeType, c.width = arrowDate, 4
+		c.typeTable = fbTable{arrowDay}
+	case sqltypes.Timestamp:
+		c.typeType, c.width = arrowTimestamp, 8
+		c.typeTable = fbTable{arrowMicros, "UTC"}
+	case sqltypes.Datetime:
+		c.typeType, c.width = arrowTimestamp, 8
+		c.typeTable = fbTable{arrowMicros}
+	case sqltypes.Blob, sqltypes.Binary, sqltypes.VarBinary, sqltypes.Geometry:
+		c.typeType = arrowBinary
+	default:
+		c.typeType = arrowUtf8
+	}
+
+	if typ == sql.Boolean {
+		c.typeType, c.width = arrowBool, 0
+		c.typeTable = fbTable{}
+	}
+
+	c.reset()
+	return c
+}
+
+func (c *arrowColumn) reset() {
+	c.n, c.nulls = 0, 0
+	c.validity = c.validity[:0]
+	c.values = c.values[:0]
+	c.offsets = append(c.offsets[:0], 0, 0, 0, 0)
+}
+
+func (c *arrowColumn) append(v interface{}) error {
+	i := c.n
+	if i%8 == 0 {
+		c.validity = append(c.validity, 0)
+		if c.typeType == arrowBool {
+			c.values = append(c.values, 0)
+		}
+	}
+	c.n++
+
+	if v == nil {
+		c.nulls++
+		switch c.typeType {
+		case arrowBool:
+		case arrowBinary, arrowUtf8:
+			c.offsets = appendUint32(c.offsets, uint32(len(c.values)))
+		default:
+			c.values = append(c.values, make([]byte, c.width)...)
+		}
+		return nil
+	}
+	c.validity[i/8] |= 1 << uint(i%8)
+
+	var bits uint64
+	switch c.typeType {
+	case arrowBool:
+		b, err := sql.Boolean.Convert(v)
+		if err != nil {
+			return err
+		}
+		if b.(bool) {
+			c.values[i/8] |= 1 << uint(i%8)
+		}
+		return nil
+	case arrowBinary, arrowUtf8:
+		value, err := c.typ.SQL(v)
+		if err != nil {
+			return err
+		}
+		c.values = append(c.values, value.ToBytes()...)
+		c.offsets = appendUint32(c.offsets, uint32(len(c.values)))
+		return nil
+	case arrowInt:
+		if c.signed {
+			n, err := sql.Int64.Convert(v)
+			if err != nil {
+				return err
+			}
+			bits = uint64(n.(int64))
+		} else {
+			n, err := sql.Uint64.Convert(v)
+			if err != nil {
+				return err
+			}
+			bits = n.(uint64)
+		}
+	case arrowFloat:
+		f, err := sql.Float64.Convert(v)
+		if err != nil {
+			return err
+		}
+		if c.width == 4 {
+			bits = uint64(math.Float32bits(float32(f.(float64))))
+		} else {
+			bits = math.Float64bits(f.(float64))
+		}
+	case arrowDate, arrowTimestamp:
+		t, err := c.typ.Convert(v)
+		if err != nil {
+			return err
+		}
+		bits = uint64(arrowTime(c.typeType, t.(time.Time)))
+	}
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], bits)
+	c.values = append(c.values, b[:c.width]...)
+	return nil
+}
+
+// arrowTime returns the days since the epoch of a date, or the
+// microseconds since the epoch of a timestamp. The times are in UTC, as
+// the types convert them.
+func arrowTime(typ uint8, t time.Time) int64 {
+	if typ == arrowDate {
+		return t.Truncate(24*time.Hour).Unix() / (24 * 60 * 60)
+	}
+	return t.Unix()*1e6 + int64(t.Nanosecond()/1e3)
+}
+
+// buffers returns the buffers of the column: the validity bitmap, which
+// is empty if there are no NULL values, the offsets of the variable size
+// types and the values.
+func (c *arrowColumn) buffers() [][]byte {
+	validity := c.validity
+	if c.nulls == 0 {
+		validity = nil
+	}
+
+	if c.typeType == arrowBinary || c.typeType == arrowUtf8 {
+		return [][]byte{validity, c.offsets, c.values}
+	}
+	return [][]byte{validity, c.values}
+}
+
+// fbTable is a flatbuffers table with its fields by their id. Absent
+// fields are nil, and the rest are scalars (bool, uint8, int16, int32 or
+// int64), strings, tables, vectors of tables or vectors of structs.
+type fbTable []interface{}
+
+// fbStructs is a vector of n structs, which are already encoded.
+type fbStructs struct {
+	n    int
+	data []byte
+}
+
+// fbBuilder encodes flatbuffers from front to back: vtables are before
+// their tables and the objects referenced by a table are after it, so all
+// the offsets are positive.
+type fbBuilder struct {
+	buf []byte
+}
+
+// finish encodes a buffer with the given root table, padded to 8 bytes.
+func (b *fbBuilder) finish(root fbTable) []byte {
+	b.buf = append(b.buf[:0], 0, 0, 0, 0)
+	b.offset(0, b.table(root))
+	b.align(8)
+	return b.buf
+}
+
+func (b *fbBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// offset writes at the given position the offset to another one.
+func (b *fbBuilder) offset(at, to int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(to-at))
+}
+
+func (b *fbBuilder) table(t fbTable) int {
+	// The fields are after the offset to the vtable, aligned to their
+	// size, and the tables are aligned to 8 bytes.
+	fields := make([]int, len(t))
+	size := 4
+	for i, v := range t {
+		if v == nil {
+			continue
+		}
+
+		n := fbSize(v)
+		size = (size + n - 1) / n * n
+		fields[i] = size
+		size += n
+	}
+
+	b.align(2)
+	vtable := len(b.buf)
+	b.buf = appendUint16(b.buf, uint16(4+2*len(t)), uint16(size))
+	for _, f := range fields {
+		b.buf = appendUint16(b.buf, uint16(f))
+	}
+
+	b.align(8)
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(pos-vtable))
+
+	for i, v := range t {
+		at := pos + fields[i]
+		switch v := v.(type) {
+		case bool:
+			if v {
+				b.buf[at] = 1
+			}
+		case uint8:
+			b.buf[at] = v
+		case int16:
+			binary.LittleEndian.PutUint16(b.buf[at:], uint16(v))
+		case int32:
+			binary.LittleEndian.PutUint32(b.buf[at:], uint32(v))
+		case int64:
+			binary.LittleEndian.PutUint64(b.buf[at:], uint64(v))
+		}
+	}
+
+	for i, v := range t {
+		at := pos + fields[i]
+		switch v := v.(type) {
+		case string:
+			b.offset(at, b.string(v))
+		case fbTable:
+			b.offset(at, b.table(v))
+		case []fbTable:
+			b.offset(at, b.tables(v))
+		case fbStructs:
+			b.offset(at, b.structs(v))
+		}
+	}
+
+	return pos
+}
+
+func (b *fbBuilder) string(s string) int {
+	b.align(4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+func (b *fbBuilder) tables(tables []fbTable) int {
+	b.align(4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(tables)))
+	b.buf = append(b.buf, make([]byte, 4*len(tables))...)
+	for i, t := range tables {
+		b.offset(pos+4+4*i, b.table(t))
+	}
+	return pos
+}
+
+// structs encodes a vector of structs with their fields aligned to 8
+// bytes.
+func (b *fbBuilder) structs(s fbStructs) int {
+	for (len(b.buf)+4)%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(s.n))
+	b.buf = append(b.buf, s.data...)
+	return pos
+}
+
+// fbSize returns the size of a field in its table.
+func fbSize(v interface{}) int {
+	switch v.(type) {
+	case bool, uint8:
+		return 1
+	case int16:
+		return 2
+	case int64:
+		return 8
+	default:
+		return 4
+	}
+}
+
+func appendUint16(b []byte, values ...uint16) []byte {
+	var buf [2]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint16(buf[:], v)
+		b = append(b, buf[:]...)
+	}
+	return b
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendInt64s(b []byte, values ...int64) []byte {
+	var buf [8]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		b = append(b, buf[:]...)
+	}
+	return b
+}
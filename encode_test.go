@@ -0,0 +1,322 @@
+package sqle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+// failingIter returns its rows and then an error.
+type failingIter struct {
+	rows []sql.Row
+}
+
+func (i *failingIter) Next() (sql.Row, error) {
+	if len(i.rows) == 0 {
+		return nil, fmt.Errorf("read failed")
+	}
+	row := i.rows[0]
+	i.rows = i.rows[1:]
+	return row, nil
+}
+
+func (i *failingIter) Close() error { return nil }
+
+var encodeTestSchema = sql.Schema{
+	{Name: "id", Type: sql.Int64},
+	{Name: "name", Type: sql.Text, Nullable: true},
+	{Name: "score", Type: sql.Float64, Nullable: true},
+	{Name: "doc", Type: sql.JSON, Nullable: true},
+}
+
+var encodeTestRows = []sql.Row{
+	{int64(1), "alice, \"a\"", 9.5, map[string]interface{}{"a": float64(1)}},
+	{int64(2), nil, nil, nil},
+}
+
+func TestWriteJSON(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	require.NoError(WriteJSON(&buf, encodeTestSchema, sql.RowsToRowIter(encodeTestRows...)))
+	require.Equal(
+		`[{"id":1,"name":"alice, \"a\"","score":9.5,"doc":{"a":1}},`+
+			`{"id":2,"name":null,"score":null,"doc":null}]`+"\n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	require.NoError(WriteJSON(&buf, encodeTestSchema, sql.RowsToRowIter()))
+	require.Equal("[]\n", buf.String())
+
+	// Nothing is written if the first row cannot be read.
+	buf.Reset()
+	require.Error(WriteJSON(&buf, encodeTestSchema, &failingIter{}))
+	require.Equal(0, buf.Len())
+
+	buf.Reset()
+	require.Error(WriteJSON(&buf, encodeTestSchema, &failingIter{encodeTestRows[1:]}))
+	require.Equal(`[{"id":2,"name":null,"score":null,"doc":null}`, buf.String())
+}
+
+func TestWriteCSV(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	require.NoError(WriteCSV(&buf, encodeTestSchema, sql.RowsToRowIter(encodeTestRows...)))
+	require.Equal(
+		"id,name,score,doc\n1,\"alice, \"\"a\"\"\",9.5,\"{\"\"a\"\":1}\"\n2,,,\n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	require.Error(WriteCSV(&buf, encodeTestSchema, &failingIter{}))
+	require.Equal(0, buf.Len())
+}
+
+func TestWriteQueryResults(t *testing.T) {
+	require := require.New(t)
+	e := newRowsTestEngine(t)
+	ctx := sql.NewEmptyContext()
+
+	schema, iter, err := e.Query(ctx, "SELECT id, name, active FROM users ORDER BY id")
+	require.NoError(err)
+	defer iter.Close()
+
+	var buf bytes.Buffer
+	require.NoError(WriteJSON(&buf, schema, iter))
+	require.Equal(`[{"id":1,"name":"alice","active":"1"},{"id":2,"name":"bob","active":"0"}]`+"\n", buf.String())
+}
+
+// fbTestTable reads a table of a flatbuffer.
+type fbTestTable struct {
+	buf []byte
+	pos int
+}
+
+func fbTestRoot(buf []byte) fbTestTable {
+	return fbTestTable{buf, int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field returns the position of a field, or 0 if it's absent.
+func (t fbTestTable) field(id int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+
+	offset := int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*id:]))
+	if offset == 0 {
+		return 0
+	}
+	return t.pos + offset
+}
+
+func (t fbTestTable) deref(pos int) int {
+	return pos + int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+func (t fbTestTable) int(id, size int) int64 {
+	pos := t.field(id)
+	if pos == 0 {
+		return 0
+	}
+
+	switch size {
+	case 1:
+		return int64(t.buf[pos])
+	case 2:
+		return int64(int16(binary.LittleEndian.Uint16(t.buf[pos:])))
+	case 4:
+		return int64(int32(binary.LittleEndian.Uint32(t.buf[pos:])))
+	default:
+		return int64(binary.LittleEndian.Uint64(t.buf[pos:]))
+	}
+}
+
+func (t fbTestTable) table(id int) fbTestTable {
+	return fbTestTable{t.buf, t.deref(t.field(id))}
+}
+
+func (t fbTestTable) string(id int) string {
+	pos := t.deref(t.field(id))
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	return string(t.buf[pos+4 : pos+4+n])
+}
+
+// vector returns the position of the first element of a vector and its
+// length.
+func (t fbTestTable) vector(id int) (int, int) {
+	pos := t.deref(t.field(id))
+	return pos + 4, int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+// readArrowMessage reads a message of an Arrow stream, returning its
+// metadata and body, or false at the end of the stream.
+func readArrowMessage(t *testing.T, r io.Reader) (fbTestTable, []byte, bool) {
+	require := require.New(t)
+
+	var prefix [8]byte
+	_, err := io.ReadFull(r, prefix[:])
+	require.NoError(err)
+	require.Equal(arrowContinuation, binary.LittleEndian.Uint32(prefix[:]))
+
+	size := binary.LittleEndian.Uint32(prefix[4:])
+	if size == 0 {
+		return fbTestTable{}, nil, false
+	}
+	require.Equal(uint32(0), size%8)
+
+	metadata := make([]byte, size)
+	_, err = io.ReadFull(r, metadata)
+	require.NoError(err)
+
+	message := fbTestRoot(metadata)
+	require.Equal(int64(arrowMetadataV5), message.int(0, 2))
+
+	body := make([]byte, message.int(3, 8))
+	_, err = io.ReadFull(r, body)
+	require.NoError(err)
+	return message, body, true
+}
+
+func TestWriteArrow(t *testing.T) {
+	require := require.New(t)
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int32},
+		{Name: "name", Type: sql.Text, Nullable: true},
+		{Name: "score", Type: sql.Float64, Nullable: true},
+		{Name: "active", Type: sql.Boolean},
+		{Name: "day", Type: sql.Date},
+		{Name: "at", Type: sql.Timestamp},
+	}
+
+	at := time.Date(2019, 1, 2, 3, 4, 5, 6000, time.UTC)
+	var rows []sql.Row
+	for i := 0; i < 10; i++ {
+		var name, score interface{}
+		if i%3 != 0 {
+			name, score = fmt.Sprint("name", i), float64(i)/2
+		}
+		rows = append(rows, sql.NewRow(int32(i), name, score, i%2 == 0, at, at))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(WriteArrow(&buf, schema, sql.RowsToRowIter(rows...), 4))
+
+	message, _, ok := readArrowMessage(t, &buf)
+	require.True(ok)
+	require.Equal(int64(arrowSchema), message.int(1, 1))
+
+	fields, n := message.table(2).vector(1)
+	require.Equal(len(schema), n)
+
+	// The first field of the types is the width of the integers, the
+	// precision of the floats and the unit of the dates and timestamps.
+	expectedTypes := []struct {
+		typ   uint8
+		size  int
+		first int64
+	}{
+		{arrowInt, 4, 32},
+		{arrowUtf8, 0, 0},
+		{arrowFloat, 2, int64(arrowDouble)},
+		{arrowBool, 0, 0},
+		{arrowDate, 2, int64(arrowDay)},
+		{arrowTimestamp, 2, int64(arrowMicros)},
+	}
+	for i, col := range schema {
+		field := fbTestTable{message.buf, message.deref(fields + 4*i)}
+		require.Equal(col.Name, field.string(0))
+		require.Equal(col.Nullable, field.int(1, 1) == 1)
+		require.Equal(int64(expectedTypes[i].typ), field.int(2, 1))
+		require.Equal(expectedTypes[i].first, field.table(3).int(0, expectedTypes[i].size))
+	}
+	require.Equal("UTC", fbTestTable{message.buf, message.deref(fields + 4*5)}.table(3).string(1))
+
+	var read int
+	for {
+		message, body, ok := readArrowMessage(t, &buf)
+		if !ok {
+			break
+		}
+		require.Equal(int64(arrowRecordBatch), message.int(1, 1))
+
+		batch := message.table(2)
+		length := int(batch.int(0, 8))
+		if len(rows)-read >= 4 {
+			require.Equal(4, length)
+		} else {
+			require.Equal(len(rows)-read, length)
+		}
+
+		nodes, n := batch.vector(1)
+		require.Equal(len(schema), n)
+		buffers, n := batch.vector(2)
+		require.Equal(13, n)
+
+		buffer := func(i int) []byte {
+			pos := buffers + 16*i
+			offset := binary.LittleEndian.Uint64(message.buf[pos:])
+			size := binary.LittleEndian.Uint64(message.buf[pos+8:])
+			require.Equal(uint64(0), offset%8)
+			return body[offset : offset+size]
+		}
+		nulls := func(column int) int {
+			return int(binary.LittleEndian.Uint64(message.buf[nodes+16*column+8:]))
+		}
+
+		ids, offsets, names := buffer(1), buffer(3), buffer(4)
+		scores, active := buffer(6), buffer(8)
+		days, times := buffer(10), buffer(12)
+		require.Equal(0, len(buffer(0)))
+		require.Equal(0, nulls(0))
+
+		var expectedNulls int
+		for j := 0; j < length; j++ {
+			i := read + j
+			require.Equal(int32(i), int32(binary.LittleEndian.Uint32(ids[4*j:])))
+
+			valid := buffer(2)[j/8]&(1<<uint(j%8)) != 0
+			require.Equal(rows[i][1] != nil, valid)
+			if valid {
+				start := binary.LittleEndian.Uint32(offsets[4*j:])
+				end := binary.LittleEndian.Uint32(offsets[4*j+4:])
+				require.Equal(rows[i][1], string(names[start:end]))
+				require.Equal(rows[i][2], math.Float64frombits(binary.LittleEndian.Uint64(scores[8*j:])))
+			} else {
+				expectedNulls++
+			}
+
+			require.Equal(i%2 == 0, active[j/8]&(1<<uint(j%8)) != 0)
+			require.Equal(uint32(17898), binary.LittleEndian.Uint32(days[4*j:]))
+			require.Equal(uint64(at.UnixNano()/1e3), binary.LittleEndian.Uint64(times[8*j:]))
+		}
+		require.Equal(expectedNulls, nulls(1))
+		require.Equal(expectedNulls, nulls(2))
+
+		read += length
+	}
+	require.Equal(len(rows), read)
+	require.Equal(0, buf.Len())
+
+	// Results without rows only have the schema.
+	buf.Reset()
+	require.NoError(WriteArrow(&buf, schema, sql.RowsToRowIter(), 0))
+	_, _, ok = readArrowMessage(t, &buf)
+	require.True(ok)
+	_, _, ok = readArrowMessage(t, &buf)
+	require.False(ok)
+
+	buf.Reset()
+	require.Error(WriteArrow(&buf, schema, &failingIter{}, 0))
+	require.Equal(0, buf.Len())
+}
@@ -1,11 +1,13 @@
 package sqle
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-kit/kit/metrics/discard"
 	opentracing "github.com/opentracing/opentracing-go"
-	"github.com/sirupsen/logrus"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
 	"github.com/src-d/go-mysql-server/auth"
 	"github.com/src-d/go-mysql-server/sql"
 	"github.com/src-d/go-mysql-server/sql/analyzer"
@@ -20,13 +22,35 @@ type Config struct {
 	VersionPostfix string
 	// Auth used for authentication and authorization.
 	Auth auth.Auth
+	// Authorizer, if set, checks the accesses made to tables by every
+	// statement before running it.
+	Authorizer auth.Authorizer
+	// Interceptors wrap the execution of every statement, the first one
+	// being the outermost.
+	Interceptors []Interceptor
+	// Dialect, if set, extends the SQL parsed by the engine with custom
+	// statements.
+	Dialect *parse.Dialect
+	// CaseSensitiveNames makes the names of the databases, tables and
+	// columns case sensitive, like lower_case_table_names set to 0 in
+	// MySQL. They are not case sensitive by default.
+	CaseSensitiveNames bool
 }
 
 // Engine is a SQL engine.
 type Engine struct {
-	Catalog  *sql.Catalog
-	Analyzer *analyzer.Analyzer
-	Auth     auth.Auth
+	Catalog    *sql.Catalog
+	Analyzer   *analyzer.Analyzer
+	Auth       auth.Auth
+	Authorizer auth.Authorizer
+	// Interceptors wrap the execution of every statement, the first one
+	// being the outermost.
+	Interceptors []Interceptor
+	// Dialect, if set, extends the SQL parsed by the engine with custom
+	// statements.
+	Dialect *parse.Dialect
+
+	plans *planCache
 }
 
 var (
@@ -38,16 +62,39 @@ var (
 
 	// QueryHistogram describes a queries latency.
 	QueryHistogram = discard.NewHistogram()
+
+	// QueryTypeCounter describes a metric that accumulates the number of
+	// queries of each type, such as select, insert or ddl, once their rows
+	// are read.
+	QueryTypeCounter = discard.NewCounter()
+
+	// QueryTypeErrorCounter describes a metric that accumulates the number
+	// of failed queries of each type.
+	QueryTypeErrorCounter = discard.NewCounter()
+
+	// QueryDurationHistogram describes the latency of the queries of each
+	// type, until their rows are read.
+	QueryDurationHistogram = discard.NewHistogram()
+
+	// RowsReadCounter describes a metric that accumulates the number of rows
+	// read from the tables by the queries.
+	RowsReadCounter = discard.NewCounter()
+
+	// RowsReturnedCounter describes a metric that accumulates the number of
+	// rows returned by the queries.
+	RowsReturnedCounter = discard.NewCounter()
 )
 
 func observeQuery(ctx *sql.Context, query string) func(err error) {
-	logrus.WithField("query", query).Debug("executing query")
+	ctx.Logger().WithField("query", query).Debug("executing query")
 	span, _ := ctx.Span("query", opentracing.Tag{Key: "query", Value: query})
 
 	t := time.Now()
 	return func(err error) {
 		if err != nil {
 			QueryErrorCounter.With("query", query, "error", err.Error()).Add(1)
+			ext.Error.Set(span, true)
+			span.LogFields(log.Error(err))
 		} else {
 			QueryCounter.With("query", query).Add(1)
 			QueryHistogram.With("query", query, "duration", "seconds").Observe(time.Since(t).Seconds())
@@ -61,8 +108,17 @@ func observeQuery(ctx *sql.Context, query string) func(err error) {
 // the default settings use `NewDefault`.
 func New(c *sql.Catalog, a *analyzer.Analyzer, cfg *Config) *Engine {
 	var versionPostfix string
+	var authorizer auth.Authorizer
+	var interceptors []Interceptor
+	var dialect *parse.Dialect
 	if cfg != nil {
 		versionPostfix = cfg.VersionPostfix
+		authorizer = cfg.Authorizer
+		interceptors = cfg.Interceptors
+		dialect = cfg.Dialect
+		if cfg.CaseSensitiveNames {
+			c.SetCaseSensitiveNames(true)
+		}
 	}
 
 	c.MustRegister(
@@ -84,7 +140,15 @@ func New(c *sql.Catalog, a *analyzer.Analyzer, cfg *Config) *Engine {
 		au = cfg.Auth
 	}
 
-	return &Engine{c, a, au}
+	return &Engine{
+		Catalog:      c,
+		Analyzer:     a,
+		Auth:         au,
+		Authorizer:   authorizer,
+		Interceptors: interceptors,
+		Dialect:      dialect,
+		plans:        newPlanCache(planCacheSize()),
+	}
 }
 
 // NewDefault creates a new default Engine.
@@ -100,63 +164,332 @@ func (e *Engine) Query(
 	ctx *sql.Context,
 	query string,
 ) (sql.Schema, sql.RowIter, error) {
+	analyzed, iter, err := e.QueryWithPlan(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return analyzed.Schema(), iter, nil
+}
+
+// QueryWithPlan executes a query as Query does, also returning the plan it
+// runs, whose schema is the one of its rows.
+func (e *Engine) QueryWithPlan(
+	ctx *sql.Context,
+	query string,
+) (sql.Node, sql.RowIter, error) {
 	var (
 		parsed, analyzed sql.Node
 		iter             sql.RowIter
 		err              error
 	)
 
+	start := time.Now()
+	qtype := unknownQueryType
 	finish := observeQuery(ctx, query)
-	defer finish(err)
+	defer func() {
+		finish(err)
+		if err != nil {
+			QueryTypeCounter.With("type", qtype).Add(1)
+			QueryTypeErrorCounter.With("type", qtype).Add(1)
+			QueryDurationHistogram.With("type", qtype).Observe(time.Since(start).Seconds())
+		}
+	}()
 
-	parsed, err = parse.Parse(ctx, query)
-	if err != nil {
-		return nil, nil, err
+	// The version is read before the query is analyzed, so the plan is not
+	// reused if the schema changes during the analysis.
+	version := e.Catalog.SchemaVersion()
+	key := planCacheKey(e.Catalog.CurrentDatabase(), query)
+	if e.Catalog.Privileges() != nil {
+		// Plans are only reused by the user whose privileges were checked
+		// when analyzing them.
+		key = ctx.Client().User + "\x00" + key
+	}
+	// Some queries are only valid in some SQL modes, such as the grouped
+	// queries with columns that are not grouped.
+	_, mode := ctx.Get(sql.SQLModeVariable)
+	if mode, ok := mode.(string); ok && mode != "" {
+		key = mode + "\x00" + key
 	}
+	cached, hit := e.plans.get(key, version)
+	if hit {
+		parsed = cached.parsed
+	} else {
+		parsed, err = e.Dialect.Parse(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	qtype = queryType(parsed)
+
+	// The hints are read even if the plan is reused, as some of them, such
+	// as the parallelism, are applied once the plan is prepared.
+	ctx = ctx.WithHints(parse.Hints(ctx, query, parsed))
+
+	// The queries rewritten by the interceptors are analyzed without the
+	// plan cache, as their plans differ from the ones of the same query.
+	original := parsed
+	handler := func(
+		ctx *sql.Context,
+		query string,
+		parsed sql.Node,
+	) (analyzed sql.Node, iter sql.RowIter, err error) {
+		rewritten := !sameNode(parsed, original)
+		qtype = queryType(parsed)
+
+		var perm = auth.ReadPerm
+		var typ = sql.QueryProcess
+		switch parsed.(type) {
+		case *plan.CreateIndex:
+			typ = sql.CreateIndexProcess
+			perm = auth.ReadPerm | auth.WritePerm
+		case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.DropIndex, *plan.UnlockTables, *plan.LockTables,
+			*plan.AnalyzeTable, *plan.Grant, *plan.Revoke, *plan.LoadData, *plan.SelectIntoFile,
+			*plan.CreateDatabase, *plan.DropDatabase, *plan.Dump:
+			perm = auth.ReadPerm | auth.WritePerm
+		}
+
+		err = e.Auth.Allowed(ctx, perm)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = e.authorize(ctx, parsed)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ctx, err = e.Catalog.AddProcess(ctx, typ, query)
+		defer func() {
+			if err != nil && ctx != nil {
+				e.Catalog.Done(ctx.Pid())
+			}
+		}()
 
-	var perm = auth.ReadPerm
-	var typ = sql.QueryProcess
-	switch parsed.(type) {
-	case *plan.CreateIndex:
-		typ = sql.CreateIndexProcess
-		perm = auth.ReadPerm | auth.WritePerm
-	case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.DropIndex, *plan.UnlockTables, *plan.LockTables:
-		perm = auth.ReadPerm | auth.WritePerm
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var timeout time.Duration
+		var cancel context.CancelFunc
+		if isSelect(parsed) {
+			timeout = executionTimeout(ctx, query)
+		}
+
+		if timeout > 0 {
+			ctx, cancel = withTimeout(ctx, timeout)
+			defer func() {
+				if err != nil {
+					cancel()
+				}
+			}()
+		}
+
+		switch {
+		case rewritten:
+			analyzed, err = e.Analyzer.Analyze(ctx, parsed)
+		case hit:
+			analyzed, err = e.Analyzer.Finish(ctx, cached.prepared)
+		default:
+			analyzed, err = e.analyze(ctx, key, version, parsed)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		iter, err = analyzed.RowIter(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if changesSchema(parsed) {
+			e.Catalog.SchemaChanged()
+		}
+
+		switch parsed.(type) {
+		case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.LoadData, *plan.SelectIntoFile, *plan.Dump:
+			// these nodes already report the number of affected rows
+		default:
+			iter = newTimeZoneIter(ctx, iter, analyzed.Schema())
+			iter = newFoundRowsIter(ctx, iter, hasCalcFoundRows(analyzed))
+		}
+
+		if timeout > 0 {
+			iter = newTimeoutIter(ctx, iter, timeout, cancel)
+		}
+
+		return analyzed, newMetricsIter(ctx, iter, qtype, start), nil
 	}
 
-	err = e.Auth.Allowed(ctx, perm)
-	if err != nil {
-		return nil, nil, err
+	analyzed, iter, err = e.intercept(handler)(ctx, query, parsed)
+	return analyzed, iter, err
+}
+
+// intercept returns the handler of the queries that calls the interceptors
+// of the engine, in order, before the given one.
+func (e *Engine) intercept(handler QueryHandler) QueryHandler {
+	for i := len(e.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := e.Interceptors[i], handler
+		handler = func(ctx *sql.Context, query string, parsed sql.Node) (sql.Node, sql.RowIter, error) {
+			return interceptor(ctx, query, parsed, next)
+		}
 	}
+	return handler
+}
 
-	ctx, err = e.Catalog.AddProcess(ctx, typ, query)
-	defer func() {
-		if err != nil && ctx != nil {
-			e.Catalog.Done(ctx.Pid())
+// authorize checks the accesses made by the parsed query with the
+// authorizer of the engine, if any. It's done for every execution, even if
+// the plan is reused, as the decisions of the authorizer may change.
+func (e *Engine) authorize(ctx *sql.Context, parsed sql.Node) error {
+	if e.Authorizer == nil {
+		return nil
+	}
+
+	user := ctx.Client().User
+	for _, access := range plan.TableAccesses(ctx, e.Catalog, parsed) {
+		if err := e.Authorizer.Authorize(ctx, user, access); err != nil {
+			return err
 		}
-	}()
+	}
 
+	return nil
+}
+
+// analyze analyzes the parsed query, caching the plan when it can be
+// reused by the following executions of the same query.
+func (e *Engine) analyze(
+	ctx *sql.Context,
+	key string,
+	version uint64,
+	parsed sql.Node,
+) (sql.Node, error) {
+	if e.plans == nil || !isCacheable(parsed) {
+		return e.Analyzer.Analyze(ctx, parsed)
+	}
+
+	prepared, err := e.Analyzer.Prepare(ctx, parsed)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	if analyzer.Reusable(prepared) {
+		e.plans.put(key, &cachedPlan{parsed, prepared, version})
 	}
 
-	analyzed, err = e.Analyzer.Analyze(ctx, parsed)
+	return e.Analyzer.Finish(ctx, prepared)
+}
+
+// foundRowsIter keeps track of the number of rows returned by a query and
+// stores them in the session when closed, so they can be retrieved later
+// using FOUND_ROWS().
+type foundRowsIter struct {
+	ctx   *sql.Context
+	iter  sql.RowIter
+	batch sql.RowBatchIter
+	count int64
+	// calcFoundRows is true when the query has a SQL_CALC_FOUND_ROWS limit,
+	// in which case the limit itself reports the found rows.
+	calcFoundRows bool
+}
+
+func newFoundRowsIter(ctx *sql.Context, iter sql.RowIter, calcFoundRows bool) *foundRowsIter {
+	return &foundRowsIter{ctx: ctx, iter: iter, calcFoundRows: calcFoundRows}
+}
+
+func (i *foundRowsIter) Next() (sql.Row, error) {
+	row, err := i.iter.Next()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	iter, err = analyzed.RowIter(ctx)
+	i.count++
+	return row, nil
+}
+
+func (i *foundRowsIter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.iter)
+	}
+
+	n, err := i.batch.NextBatch(rows)
 	if err != nil {
-		return nil, nil, err
+		return 0, err
 	}
 
-	return analyzed.Schema(), iter, nil
+	i.count += int64(n)
+	return n, nil
+}
+
+func (i *foundRowsIter) Close() error {
+	i.ctx.SetLastQueryInfo(sql.RowCount, -1)
+	if !i.calcFoundRows {
+		i.ctx.SetLastQueryInfo(sql.FoundRows, i.count)
+	}
+	return i.iter.Close()
+}
+
+func hasCalcFoundRows(n sql.Node) bool {
+	var found bool
+	plan.Inspect(n, func(n sql.Node) bool {
+		if l, ok := n.(*plan.Limit); ok && l.CalcFoundRows {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// timeZoneIter shows the timestamps of the rows of a query, which are kept
+// in UTC, in the time zone of the session.
+type timeZoneIter struct {
+	iter    sql.RowIter
+	loc     *time.Location
+	columns []int
+}
+
+// newTimeZoneIter returns the given iterator if the schema has no
+// timestamps.
+func newTimeZoneIter(ctx *sql.Context, iter sql.RowIter, schema sql.Schema) sql.RowIter {
+	var columns []int
+	for i, col := range schema {
+		if sql.IsTimestamp(col.Type) {
+			columns = append(columns, i)
+		}
+	}
+	if len(columns) == 0 {
+		return iter
+	}
+
+	return &timeZoneIter{iter: iter, loc: ctx.Location(), columns: columns}
+}
+
+func (i *timeZoneIter) Next() (sql.Row, error) {
+	row, err := i.iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows may be the ones stored by the tables, so they are copied.
+	row = row.Copy()
+	for _, idx := range i.columns {
+		if idx >= len(row) {
+			break
+		}
+		if t, ok := row[idx].(time.Time); ok {
+			row[idx] = sql.ConvertTimeZone(t.UTC(), time.UTC, i.loc)
+		}
+	}
+	return row, nil
+}
+
+func (i *timeZoneIter) Close() error {
+	return i.iter.Close()
 }
 
 // Async returns true if the query is async. If there are any errors with the
 // query it returns false
 func (e *Engine) Async(ctx *sql.Context, query string) bool {
-	parsed, err := parse.Parse(ctx, query)
+	parsed, err := e.Dialect.Parse(ctx, query)
 	if err != nil {
 		return false
 	}
@@ -170,6 +503,28 @@ func (e *Engine) AddDatabase(db sql.Database) {
 	e.Catalog.AddDatabase(db)
 }
 
+// DropDatabase removes the database with the given name from the catalog.
+func (e *Engine) DropDatabase(name string) error {
+	return e.Catalog.DropDatabase(name)
+}
+
+// RenameDatabase renames the database with the given name in the catalog.
+func (e *Engine) RenameDatabase(name, newName string) error {
+	return e.Catalog.RenameDatabase(name, newName)
+}
+
+// AddRowChangeHook adds a hook notified of the rows changed by every
+// INSERT, REPLACE, UPDATE and DELETE statement executed by the engine.
+func (e *Engine) AddRowChangeHook(hook sql.RowChangeHook) {
+	e.Catalog.AddRowChangeHook(hook)
+}
+
+// AddIndexDriver registers the given index driver in the catalog, so indexes
+// can be created with it and the existing ones are loaded on Init.
+func (e *Engine) AddIndexDriver(driver sql.IndexDriver) {
+	e.Catalog.RegisterIndexDriver(driver)
+}
+
 // Init performs all the initialization requirements for the engine to work.
 func (e *Engine) Init() error {
 	return e.Catalog.LoadIndexes(e.Catalog.AllDatabases())
@@ -2,13 +2,21 @@ package sqle_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/metrics"
 	"github.com/opentracing/opentracing-go"
 
 	sqle "github.com/src-d/go-mysql-server"
@@ -16,11 +24,14 @@ import (
 	"github.com/src-d/go-mysql-server/memory"
 	"github.com/src-d/go-mysql-server/sql"
 	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function"
 	"github.com/src-d/go-mysql-server/sql/parse"
 	"github.com/src-d/go-mysql-server/sql/plan"
 	"github.com/src-d/go-mysql-server/test"
 
 	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-errors.v1"
 )
 
 var queries = []struct {
@@ -47,6 +58,26 @@ var queries = []struct {
 		"SELECT i FROM mytable WHERE i = 2;",
 		[]sql.Row{{int64(2)}},
 	},
+	{
+		"SELECT 0x41, x'4142', b'1000001', 0x41 + 1, b'1010' = 10, x'41' = 'A', CONCAT(0x41, 'b'), _utf8mb4'abc', _binary'abc';",
+		[]sql.Row{{[]byte("A"), []byte("AB"), []byte("A"), int64(66), true, true, "Ab", "abc", []byte("abc")}},
+	},
+	{
+		"SELECT i FROM mytable WHERE i = 0x02;",
+		[]sql.Row{{int64(2)}},
+	},
+	{
+		"SELECT i FROM mytable WHERE i = '2abc';",
+		[]sql.Row{{int64(2)}},
+	},
+	{
+		"SELECT i FROM mytable WHERE s = 0;",
+		[]sql.Row{{int64(1)}, {int64(2)}, {int64(3)}},
+	},
+	{
+		"SELECT '3abc' + 1, '' = 0, 'abc' = 0, '3.5' = 3, - ' 2x', CAST('12abc' AS SIGNED);",
+		[]sql.Row{{float64(4), true, true, false, float64(-2), int64(12)}},
+	},
 	{
 		"SELECT i FROM mytable WHERE i > 2;",
 		[]sql.Row{{int64(3)}},
@@ -171,6 +202,38 @@ var queries = []struct {
 		"SELECT i FROM niltable WHERE b IS NOT FALSE",
 		[]sql.Row{{int64(1)}, {int64(2)}, {int64(4)}, {nil}},
 	},
+	{
+		"SELECT i FROM niltable WHERE b OR f > 2",
+		[]sql.Row{{int64(1)}, {nil}, {int64(4)}},
+	},
+	{
+		"SELECT i FROM niltable WHERE NOT (b AND f > 1)",
+		[]sql.Row{{int64(1)}, {nil}},
+	},
+	{
+		"SELECT i FROM niltable WHERE i IN (1, NULL)",
+		[]sql.Row{{int64(1)}},
+	},
+	{
+		"SELECT i FROM niltable WHERE i NOT IN (2, NULL)",
+		nil,
+	},
+	{
+		"SELECT n.i, m.i FROM niltable n JOIN mytable m ON n.i = m.i",
+		[]sql.Row{{int64(1), int64(1)}, {int64(2), int64(2)}},
+	},
+	{
+		"SELECT n.i, m.i FROM niltable n JOIN mytable m ON n.i = m.i OR n.b",
+		[]sql.Row{
+			{int64(1), int64(1)}, {int64(1), int64(2)}, {int64(1), int64(3)},
+			{int64(2), int64(2)},
+			{int64(4), int64(1)}, {int64(4), int64(2)}, {int64(4), int64(3)},
+		},
+	},
+	{
+		"SELECT NULL AND FALSE, NULL OR TRUE, NOT NULL, NULL OR FALSE, 1 AND 2, 0 OR 0.0",
+		[]sql.Row{{false, true, nil, nil, true, false}},
+	},
 	{
 		"SELECT COUNT(*) FROM mytable;",
 		[]sql.Row{{int64(3)}},
@@ -183,6 +246,10 @@ var queries = []struct {
 		"SELECT COUNT(*) AS c FROM mytable;",
 		[]sql.Row{{int64(3)}},
 	},
+	{
+		"SELECT COUNT(*) + 1, COUNT(1 + 1), SUM(1 + 1) FROM mytable WHERE 1 = 1",
+		[]sql.Row{{int64(4), int64(3), float64(6)}},
+	},
 	{
 		"SELECT substring(s, 2, 3) FROM mytable",
 		[]sql.Row{{"irs"}, {"eco"}, {"hir"}},
@@ -756,10 +823,12 @@ var queries = []struct {
 		`SHOW VARIABLES`,
 		[]sql.Row{
 			{"auto_increment_increment", int64(1)},
-			{"time_zone", time.Local.String()},
-			{"system_time_zone", time.Local.String()},
+			{"autocommit", int64(1)},
+			{"time_zone", "SYSTEM"},
+			{"system_time_zone", sql.DefaultSessionConfig()["system_time_zone"].Value},
 			{"max_allowed_packet", math.MaxInt32},
 			{"sql_mode", ""},
+			{"lower_case_table_names", int32(2)},
 			{"gtid_mode", int32(0)},
 			{"collation_database", "utf8_bin"},
 			{"ndbinfo_version", ""},
@@ -767,6 +836,17 @@ var queries = []struct {
 			{"transaction_isolation", "READ UNCOMMITTED"},
 			{"version", ""},
 			{"version_comment", ""},
+			{"socket", ""},
+			{"wait_timeout", int64(28800)},
+			{"interactive_timeout", int64(28800)},
+			{"net_read_timeout", int64(30)},
+			{"net_write_timeout", int64(60)},
+			{"long_query_time", float64(10)},
+			{"trace_context", ""},
+			{"session_track_schema", int64(1)},
+			{"session_track_state_change", int64(0)},
+			{"session_track_system_variables", "time_zone,autocommit,character_set_client,character_set_results,character_set_connection"},
+			{"session_track_transaction_info", "OFF"},
 		},
 	},
 	{
@@ -1188,6 +1268,14 @@ var queries = []struct {
 		"ROLLBACK",
 		[]sql.Row{},
 	},
+	{
+		"START TRANSACTION",
+		[]sql.Row{},
+	},
+	{
+		"COMMIT",
+		[]sql.Row{},
+	},
 	{
 		"SELECT substring(s, 1, 1) FROM mytable ORDER BY substring(s, 1, 1)",
 		[]sql.Row{{"f"}, {"s"}, {"t"}},
@@ -1483,6 +1571,18 @@ var queries = []struct {
 		`SELECT LAST(i) FROM (SELECT i FROM mytable ORDER BY i) t`,
 		[]sql.Row{{int64(3)}},
 	},
+	{
+		`SELECT a.i, b.i FROM (SELECT i FROM mytable) a, (SELECT i FROM mytable) b WHERE a.i = b.i ORDER BY a.i`,
+		[]sql.Row{
+			{int64(1), int64(1)},
+			{int64(2), int64(2)},
+			{int64(3), int64(3)},
+		},
+	},
+	{
+		`SELECT i FROM mytable WHERE i = (SELECT MAX(i) FROM mytable) OR i + 2 = (SELECT MAX(i) FROM mytable) ORDER BY i`,
+		[]sql.Row{{int64(1)}, {int64(3)}},
+	},
 	{
 		`SELECT COUNT(DISTINCT t.i) FROM tabletest t, mytable t2`,
 		[]sql.Row{{int64(3)}},
@@ -1568,6 +1668,41 @@ var queries = []struct {
 			{int64(3)},
 		},
 	},
+	{
+		`SELECT i FROM mytable WHERE i IN (SELECT i2 FROM othertable WHERE s2 <> 'first')`,
+		[]sql.Row{
+			{int64(1)},
+			{int64(2)},
+		},
+	},
+	{
+		`SELECT i FROM mytable WHERE EXISTS (SELECT * FROM othertable WHERE i2 = 1) AND i > 1`,
+		[]sql.Row{
+			{int64(2)},
+			{int64(3)},
+		},
+	},
+	{
+		`SELECT i FROM mytable WHERE NOT EXISTS (SELECT * FROM othertable WHERE i2 > 5)`,
+		[]sql.Row{
+			{int64(1)},
+			{int64(2)},
+			{int64(3)},
+		},
+	},
+	{
+		`SELECT i FROM mytable WHERE EXISTS (SELECT i FROM niltable WHERE niltable.i = mytable.i)`,
+		[]sql.Row{
+			{int64(1)},
+			{int64(2)},
+		},
+	},
+	{
+		`SELECT i FROM mytable t WHERE i NOT IN (SELECT i2 FROM othertable WHERE i2 = 1) AND NOT EXISTS (SELECT * FROM niltable n WHERE n.i = t.i)`,
+		[]sql.Row{
+			{int64(3)},
+		},
+	},
 	{
 		`SELECT (SELECT i FROM mytable ORDER BY i ASC LIMIT 1) AS x`,
 		[]sql.Row{{int64(1)}},
@@ -1586,6 +1721,134 @@ var queries = []struct {
 			{int64(4)},
 		},
 	},
+	{
+		`SELECT JSON_OBJECTAGG(s2, i2) FROM othertable`,
+		[]sql.Row{{map[string]interface{}{"first": int64(3), "second": int64(2), "third": int64(1)}}},
+	},
+	{
+		`SELECT ST_AsText(POINT(i, i * 2)) FROM mytable WHERE i = 2`,
+		[]sql.Row{{"POINT(2 4)"}},
+	},
+	{
+		`SELECT i FROM mytable WHERE ST_Contains(ST_GeomFromText('POLYGON((0 0,2.5 0,2.5 2.5,0 2.5,0 0))'), POINT(i, 1)) ORDER BY i`,
+		[]sql.Row{{int64(1)}, {int64(2)}},
+	},
+	{
+		`SELECT ST_Distance(ST_GeomFromText('POINT(0 0)'), POINT(3, 4)), ST_X(POINT(1, 2)), ST_Y(POINT(1, 2))`,
+		[]sql.Row{{5.0, 1.0, 2.0}},
+	},
+	{
+		`SELECT i, JSON_ARRAYAGG(s) FROM mytable GROUP BY i ORDER BY i`,
+		[]sql.Row{
+			{int64(1), []interface{}{"first row"}},
+			{int64(2), []interface{}{"second row"}},
+			{int64(3), []interface{}{"third row"}},
+		},
+	},
+	{
+		`SELECT i, COUNT(*) AS c FROM mytable GROUP BY ROLLUP(i) ORDER BY i`,
+		[]sql.Row{
+			{nil, int64(3)},
+			{int64(1), int64(1)},
+			{int64(2), int64(1)},
+			{int64(3), int64(1)},
+		},
+	},
+	{
+		`SELECT i, s, COUNT(*) FROM mytable GROUP BY i, s WITH ROLLUP`,
+		[]sql.Row{
+			{int64(1), "first row", int64(1)},
+			{int64(2), "second row", int64(1)},
+			{int64(3), "third row", int64(1)},
+			{int64(1), nil, int64(1)},
+			{int64(2), nil, int64(1)},
+			{int64(3), nil, int64(1)},
+			{nil, nil, int64(3)},
+		},
+	},
+	{
+		`SELECT i, s, GROUPING(i, s) AS g, COUNT(*) FROM mytable GROUP BY i, s WITH ROLLUP`,
+		[]sql.Row{
+			{int64(1), "first row", int64(0), int64(1)},
+			{int64(2), "second row", int64(0), int64(1)},
+			{int64(3), "third row", int64(0), int64(1)},
+			{int64(1), nil, int64(1), int64(1)},
+			{int64(2), nil, int64(1), int64(1)},
+			{int64(3), nil, int64(1), int64(1)},
+			{nil, nil, int64(3), int64(3)},
+		},
+	},
+	{
+		`SELECT i, COUNT(*) FROM mytable GROUP BY ROLLUP(i) HAVING GROUPING(i) = 1`,
+		[]sql.Row{{nil, int64(3)}},
+	},
+	{
+		`SELECT i > 1 AS big, COUNT(*) FROM mytable GROUP BY CUBE(i > 1) HAVING COUNT(*) > 1`,
+		[]sql.Row{
+			{true, int64(2)},
+			{nil, int64(3)},
+		},
+	},
+	{
+		`SELECT i, SUBSTRING(s, 1, 1) AS f, COUNT(*) FROM mytable
+		GROUP BY GROUPING SETS ((i), (SUBSTRING(s, 1, 1)), ())`,
+		[]sql.Row{
+			{int64(1), nil, int64(1)},
+			{int64(2), nil, int64(1)},
+			{int64(3), nil, int64(1)},
+			{nil, "f", int64(1)},
+			{nil, "s", int64(1)},
+			{nil, "t", int64(1)},
+			{nil, nil, int64(3)},
+		},
+	},
+	{
+		`SELECT i, s2 FROM mytable,
+		LATERAL (SELECT s2 FROM othertable WHERE i2 <= mytable.i ORDER BY s2 LIMIT 1) t
+		ORDER BY i`,
+		[]sql.Row{
+			{int64(1), "third"},
+			{int64(2), "second"},
+			{int64(3), "first"},
+		},
+	},
+	{
+		`SELECT m.i, t.s2 FROM mytable m
+		LEFT JOIN LATERAL (SELECT s2 FROM othertable WHERE i2 < m.i ORDER BY i2 DESC LIMIT 1) t ON true
+		ORDER BY m.i`,
+		[]sql.Row{
+			{int64(1), nil},
+			{int64(2), "third"},
+			{int64(3), "second"},
+		},
+	},
+	{
+		`SELECT i, c FROM mytable JOIN LATERAL (SELECT COUNT(*) AS c FROM othertable WHERE i2 < i) t ON c > 0`,
+		[]sql.Row{
+			{int64(2), int64(1)},
+			{int64(3), int64(2)},
+		},
+	},
+	{
+		`SELECT * FROM mytable m, LATERAL (SELECT * FROM othertable WHERE i2 = m.i) t, LATERAL (SELECT i2 * 10 AS x) u`,
+		[]sql.Row{
+			{int64(1), "first row", "third", int64(1), int64(10)},
+			{int64(2), "second row", "second", int64(2), int64(20)},
+			{int64(3), "third row", "first", int64(3), int64(30)},
+		},
+	},
+	{
+		`SELECT i FROM mytable TABLESAMPLE BERNOULLI (100) WHERE i > 1`,
+		[]sql.Row{{int64(2)}, {int64(3)}},
+	},
+	{
+		`SELECT COUNT(*) FROM mytable AS t TABLESAMPLE SYSTEM (0) REPEATABLE (1)`,
+		[]sql.Row{{int64(0)}},
+	},
+	{
+		`SELECT i FROM mytable t TABLESAMPLE (100) ORDER BY i`,
+		[]sql.Row{{int64(1)}, {int64(2)}, {int64(3)}},
+	},
 }
 
 func TestQueries(t *testing.T) {
@@ -1640,6 +1903,102 @@ func TestSessionSelectLimit(t *testing.T) {
 	})
 }
 
+func TestLastQueryInfo(t *testing.T) {
+	ctx := newCtx()
+	e := newEngine(t)
+
+	q := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{"SELECT * FROM mytable LIMIT 1", []sql.Row{{int64(1), "first row"}}},
+		{"SELECT FOUND_ROWS(), ROW_COUNT()", []sql.Row{{int64(1), int64(-1)}}},
+		{"SELECT SQL_CALC_FOUND_ROWS * FROM mytable ORDER BY i LIMIT 1", []sql.Row{{int64(1), "first row"}}},
+		{"SELECT FOUND_ROWS()", []sql.Row{{int64(3)}}},
+		{"SELECT FOUND_ROWS()", []sql.Row{{int64(1)}}},
+		{"SELECT SQL_CALC_FOUND_ROWS * FROM mytable ORDER BY i LIMIT 1 OFFSET 1", []sql.Row{{int64(2), "second row"}}},
+		{"SELECT FOUND_ROWS()", []sql.Row{{int64(3)}}},
+		{"SELECT SQL_CALC_FOUND_ROWS * FROM mytable ORDER BY i LIMIT 1 OFFSET 5", []sql.Row(nil)},
+		{"SELECT FOUND_ROWS()", []sql.Row{{int64(3)}}},
+		{"INSERT INTO mytable (i, s) VALUES (4, 'fourth row'), (5, 'fifth row')", []sql.Row{{int64(2)}}},
+		{"SELECT ROW_COUNT()", []sql.Row{{int64(2)}}},
+		{"SELECT LAST_INSERT_ID()", []sql.Row{{uint64(0)}}},
+		{"SELECT LAST_INSERT_ID(42)", []sql.Row{{uint64(42)}}},
+		{"SELECT LAST_INSERT_ID()", []sql.Row{{uint64(42)}}},
+		{"CREATE TABLE autoinc (id BIGINT AUTO_INCREMENT PRIMARY KEY, s TEXT)", []sql.Row(nil)},
+		{"INSERT INTO autoinc (s) VALUES ('first'), ('second')", []sql.Row{{int64(2)}}},
+		{"SELECT LAST_INSERT_ID()", []sql.Row{{uint64(1)}}},
+		{"INSERT INTO autoinc (id, s) VALUES (10, 'third')", []sql.Row{{int64(1)}}},
+		{"SELECT LAST_INSERT_ID()", []sql.Row{{uint64(1)}}},
+		{"INSERT INTO autoinc (id, s) VALUES (NULL, 'fourth')", []sql.Row{{int64(1)}}},
+		{"SELECT LAST_INSERT_ID()", []sql.Row{{uint64(11)}}},
+		{"SELECT id, s FROM autoinc ORDER BY id", []sql.Row{
+			{int64(1), "first"},
+			{int64(2), "second"},
+			{int64(10), "third"},
+			{int64(11), "fourth"},
+		}},
+	}
+
+	for _, tt := range q {
+		testQueryWithContext(ctx, t, e, tt.query, tt.expected)
+	}
+}
+
+func TestAddIndexDriver(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	idx := &loadedIndex{id: "idx_i", db: "mydb", table: "mytable", exprs: []string{"mytable.i"}}
+	e.AddIndexDriver(&loadedIndexDriver{[]sql.Index{idx}})
+	require.NoError(e.Init())
+
+	require.Equal("loaded", e.Catalog.IndexDriver("loaded").ID())
+	require.True(e.Catalog.CanUseIndex(idx))
+	require.Equal(idx, e.Catalog.Index("mydb", "idx_i"))
+	e.Catalog.ReleaseIndex(idx)
+}
+
+// loadedIndexDriver is an index driver that only loads the given indexes.
+type loadedIndexDriver struct {
+	indexes []sql.Index
+}
+
+func (loadedIndexDriver) ID() string { return "loaded" }
+func (loadedIndexDriver) Create(db, table, id string, exprs []sql.Expression, config map[string]string) (sql.Index, error) {
+	panic("not implemented")
+}
+func (d loadedIndexDriver) LoadAll(db, table string) ([]sql.Index, error) {
+	var result []sql.Index
+	for _, idx := range d.indexes {
+		if idx.Database() == db && idx.Table() == table {
+			result = append(result, idx)
+		}
+	}
+	return result, nil
+}
+func (loadedIndexDriver) Save(*sql.Context, sql.Index, sql.PartitionIndexKeyValueIter) error {
+	return nil
+}
+func (loadedIndexDriver) Delete(sql.Index, sql.PartitionIter) error { return nil }
+
+type loadedIndex struct {
+	id, db, table string
+	exprs         []string
+}
+
+func (i *loadedIndex) Get(...interface{}) (sql.IndexLookup, error) {
+	panic("not implemented")
+}
+func (i *loadedIndex) Has(sql.Partition, ...interface{}) (bool, error) {
+	panic("not implemented")
+}
+func (i *loadedIndex) ID() string            { return i.id }
+func (i *loadedIndex) Database() string      { return i.db }
+func (i *loadedIndex) Table() string         { return i.table }
+func (i *loadedIndex) Expressions() []string { return i.exprs }
+func (i *loadedIndex) Driver() string        { return "loaded" }
+
 func TestSessionDefaults(t *testing.T) {
 	ctx := newCtx()
 	ctx.Session.Set("auto_increment_increment", sql.Int64, 0)
@@ -1841,6 +2200,37 @@ func TestDescribe(t *testing.T) {
 	})
 }
 
+func TestDescribeJSON(t *testing.T) {
+	require := require.New(t)
+	e := newEngine(t)
+
+	_, iter, err := e.Query(newCtx(), `EXPLAIN FORMAT=JSON SELECT i FROM mytable WHERE s = 'first row'`)
+	require.NoError(err)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Len(rows, 1)
+
+	var plan struct {
+		Node     string
+		Schema   []struct{ Name, Type, Source string }
+		Children []struct {
+			Node        string
+			Description string
+		}
+	}
+	require.NoError(json.Unmarshal([]byte(rows[0][0].(string)), &plan))
+
+	require.Equal("Project", plan.Node)
+	require.Len(plan.Schema, 1)
+	require.Equal("i", plan.Schema[0].Name)
+	require.Equal("BIGINT", plan.Schema[0].Type)
+	require.Equal("mytable", plan.Schema[0].Source)
+	require.Len(plan.Children, 1)
+	require.Equal("ResolvedTable", plan.Children[0].Node)
+	require.Equal("Table(mytable): Projected Filtered", plan.Children[0].Description)
+}
+
 func TestOrderByColumns(t *testing.T) {
 	require := require.New(t)
 	e := newEngine(t)
@@ -2517,6 +2907,47 @@ func TestCreateTable(t *testing.T) {
 	require.Equal(s, testTable.Schema())
 }
 
+func TestCreatePartitionedTable(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	testQuery(t, e,
+		"CREATE TABLE t1(a INTEGER, b TEXT) PARTITION BY RANGE (a) ("+
+			"PARTITION p0 VALUES LESS THAN (10), "+
+			"PARTITION p1 VALUES LESS THAN (20), "+
+			"PARTITION p2 VALUES LESS THAN MAXVALUE)",
+		[]sql.Row(nil),
+	)
+
+	db, err := e.Catalog.Database("mydb")
+	require.NoError(err)
+
+	testTable, ok := db.Tables()["t1"]
+	require.True(ok)
+
+	prunable, ok := testTable.(sql.PrunableTable)
+	require.True(ok)
+	require.Equal([]string{"p0", "p1", "p2"}, prunable.Partitioning().Names())
+
+	testQuery(t, e,
+		"INSERT INTO t1 VALUES (1, 'a'), (15, 'b'), (25, 'c'), (12, 'd')",
+		[]sql.Row{{int64(4)}},
+	)
+
+	testQuery(t, e,
+		"SELECT b FROM t1 WHERE a >= 10 AND a < 20 ORDER BY b",
+		[]sql.Row{{"b"}, {"d"}},
+	)
+
+	testQuery(t, e,
+		"SELECT b FROM t1 WHERE a IN (1, 25) ORDER BY b",
+		[]sql.Row{{"a"}, {"c"}},
+	)
+
+	_, _, err = e.Query(newCtx(), "CREATE TABLE t2(a INTEGER) PARTITION BY LIST (a) (PARTITION p0 VALUES IN ('foo'))")
+	require.Error(err)
+}
+
 func TestDropTable(t *testing.T) {
 	require := require.New(t)
 
@@ -3122,6 +3553,24 @@ func TestTracing(t *testing.T) {
 	require.Equal(expectedSpans, spanOperations)
 }
 
+func TestQueryWithPlan(t *testing.T) {
+	require := require.New(t)
+	e := newEngine(t)
+	ctx := newCtx()
+
+	node, iter, err := e.QueryWithPlan(ctx, "SELECT mytable.i FROM mytable JOIN tabletest ON mytable.i = tabletest.i")
+	require.NoError(err)
+	require.Equal(sql.Schema{{Name: "i", Type: sql.Int64, Source: "mytable"}}, node.Schema())
+	require.Contains(node.String(), "mytable")
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Len(rows, 3)
+
+	// Both sides of the join count the rows they read.
+	require.Equal(uint64(6), ctx.RowsExamined())
+}
+
 func TestReadOnly(t *testing.T) {
 	require := require.New(t)
 
@@ -3157,29 +3606,289 @@ func TestReadOnly(t *testing.T) {
 	require.True(auth.ErrNotAuthorized.Is(err))
 }
 
-func TestSessionVariables(t *testing.T) {
+func TestPrivileges(t *testing.T) {
 	require := require.New(t)
 
 	e := newEngine(t)
+	store := sql.NewMemoryPrivilegeStore()
+	require.NoError(store.Grant("root", sql.GlobalPrivilegeLevel, sql.AllPrivileges|sql.GrantOptionPrivilege))
+	e.Catalog.SetPrivileges(store)
 
-	session := sql.NewBaseSession()
-	ctx := sql.NewContext(context.Background(), sql.WithSession(session), sql.WithPid(1))
+	rootCtx := sql.NewContext(
+		context.Background(),
+		sql.WithPid(atomic.AddUint64(&pid, 1)),
+		sql.WithSession(sql.NewSession("address", "client", "root", 1)),
+	)
 
-	_, _, err := e.Query(ctx, `set autocommit=1, sql_mode = concat(@@sql_mode,',STRICT_TRANS_TABLES')`)
-	require.NoError(err)
+	_, _, err := e.Query(newCtx(), `SELECT i FROM mytable`)
+	require.Error(err)
+	require.True(sql.ErrPrivilegeCheckFailed.Is(err))
 
-	ctx = sql.NewContext(context.Background(), sql.WithSession(session), sql.WithPid(2))
+	testQueryWithContext(rootCtx, t, e, `GRANT SELECT ON mydb.* TO 'user'@'%'`, nil)
+	testQueryWithContext(rootCtx, t, e, `GRANT INSERT ON mytable TO user`, nil)
 
-	_, iter, err := e.Query(ctx, `SELECT @@autocommit, @@session.sql_mode`)
-	require.NoError(err)
+	testQuery(t, e, `SELECT i FROM mytable ORDER BY i`, []sql.Row{
+		{int64(1)}, {int64(2)}, {int64(3)},
+	})
 
-	rows, err := sql.RowIterToRows(iter)
-	require.NoError(err)
+	testQuery(t, e, `SHOW GRANTS`, []sql.Row{
+		{"GRANT SELECT ON `mydb`.* TO `user`"},
+		{"GRANT INSERT ON `mydb`.`mytable` TO `user`"},
+	})
 
-	require.Equal([]sql.Row{{int8(1), ",STRICT_TRANS_TABLES"}}, rows)
+	_, _, err = e.Query(newCtx(), `SELECT s FROM foo.other_table`)
+	require.Error(err)
+	require.True(sql.ErrPrivilegeCheckFailed.Is(err))
+
+	_, _, err = e.Query(newCtx(), `INSERT INTO othertable (s2, i2) VALUES ('a', 1)`)
+	require.Error(err)
+	require.True(sql.ErrPrivilegeCheckFailed.Is(err))
+
+	_, _, err = e.Query(newCtx(), `GRANT SELECT ON mydb.* TO other`)
+	require.Error(err)
+	require.True(sql.ErrPrivilegeCheckFailed.Is(err))
+
+	_, _, err = e.Query(newCtx(), `SHOW GRANTS FOR root`)
+	require.Error(err)
+	require.True(sql.ErrPrivilegeCheckFailed.Is(err))
+
+	testQueryWithContext(rootCtx, t, e, `REVOKE SELECT ON mydb.* FROM user`, nil)
+
+	_, _, err = e.Query(newCtx(), `SELECT i FROM mytable`)
+	require.Error(err)
+	require.True(sql.ErrPrivilegeCheckFailed.Is(err))
 }
 
-func TestSessionVariablesONOFF(t *testing.T) {
+func TestAuthorizer(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+
+	var accesses []sql.TableAccess
+	e.Authorizer = auth.AuthorizerFunc(func(ctx *sql.Context, user string, access sql.TableAccess) error {
+		require.Equal("user", user)
+		accesses = append(accesses, access)
+		if access.Table == "othertable" && access.Privileges != sql.SelectPrivilege {
+			return auth.ErrNotAuthorized.New()
+		}
+		return nil
+	})
+
+	testQuery(t, e, `SELECT s FROM mytable WHERE i = 1`, []sql.Row{{"first row"}})
+	require.Equal([]sql.TableAccess{
+		{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "mytable", Columns: []string{"s", "i"}},
+	}, accesses)
+
+	accesses = nil
+	testQuery(t, e, `SELECT s FROM mytable WHERE i = 1`, []sql.Row{{"first row"}})
+	require.Len(accesses, 1)
+
+	accesses = nil
+	_, _, err := e.Query(newCtx(), `DELETE FROM othertable`)
+	require.Error(err)
+	require.True(auth.ErrNotAuthorized.Is(err))
+	require.Equal([]sql.TableAccess{
+		{Privileges: sql.DeletePrivilege, Database: "mydb", Table: "othertable"},
+	}, accesses)
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+
+	_, iter, err := e.Query(newCtx(), `ANALYZE TABLE mytable, mydb.nope`)
+	require.NoError(err)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Len(rows, 2)
+	require.Equal(sql.NewRow("mydb.mytable", "analyze", "status", "OK"), rows[0])
+	require.Equal(sql.NewRow("mydb.nope", "analyze", "Error"), rows[1][:3])
+
+	stats := e.Catalog.TableStatistics("mydb", "mytable")
+	require.NotNil(stats)
+	require.Equal(uint64(3), stats.RowCount)
+	require.Equal(uint64(3), stats.Column("i").DistinctCount)
+	require.Equal(int64(1), stats.Column("i").Min)
+	require.Equal(int64(3), stats.Column("i").Max)
+}
+
+func TestSelectIntoFile(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "outfile")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	e := newEngine(t)
+	e.Catalog.SetSecureFilePriv(dir)
+
+	testQuery(t, e,
+		"SELECT s, COUNT(*) FROM mytable WHERE i IN (SELECT i FROM mytable WHERE i > 1) "+
+			"GROUP BY s ORDER BY s INTO OUTFILE 'out.csv' FIELDS TERMINATED BY ','",
+		[]sql.Row{{int64(2)}},
+	)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "out.csv"))
+	require.NoError(err)
+	require.Equal("second row,1\nthird row,1\n", string(data))
+
+	// The files written can be loaded back.
+	testQuery(t, e, "CREATE TABLE copy (s TEXT NOT NULL, n BIGINT NOT NULL)", []sql.Row{})
+	testQuery(t, e, "LOAD DATA INFILE 'out.csv' INTO TABLE copy FIELDS TERMINATED BY ','", []sql.Row{{int64(2)}})
+	testQuery(t, e, "SELECT s, n FROM copy ORDER BY s", []sql.Row{
+		{"second row", int64(1)},
+		{"third row", int64(1)},
+	})
+
+	testQuery(t, e, "SELECT s INTO DUMPFILE 'out.txt' FROM mytable WHERE i = 1", []sql.Row{{int64(1)}})
+	data, err = ioutil.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(err)
+	require.Equal("first row", string(data))
+
+	_, iter, err := e.Query(newCtx(), "SELECT * FROM mytable INTO OUTFILE 'out.txt'")
+	if err == nil {
+		_, err = sql.RowIterToRows(iter)
+	}
+	require.True(os.IsExist(err))
+}
+
+func TestSessionVariables(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+
+	session := sql.NewBaseSession()
+	ctx := sql.NewContext(context.Background(), sql.WithSession(session), sql.WithPid(1))
+
+	_, _, err := e.Query(ctx, `set autocommit=1, sql_mode = concat(@@sql_mode,',STRICT_TRANS_TABLES')`)
+	require.NoError(err)
+
+	ctx = sql.NewContext(context.Background(), sql.WithSession(session), sql.WithPid(2))
+
+	_, iter, err := e.Query(ctx, `SELECT @@autocommit, @@session.sql_mode`)
+	require.NoError(err)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	require.Equal([]sql.Row{{int8(1), ",STRICT_TRANS_TABLES"}}, rows)
+}
+
+func TestMaxExecutionTime(t *testing.T) {
+	e := newEngine(t)
+
+	testCases := []struct {
+		name    string
+		setup   string
+		query   string
+		timeout bool
+	}{
+		{
+			"hint",
+			"",
+			"SELECT /*+ MAX_EXECUTION_TIME(50) */ SLEEP(1) FROM mytable",
+			true,
+		},
+		{
+			"session variable",
+			"SET max_execution_time = 50",
+			"SELECT SLEEP(1) FROM mytable",
+			true,
+		},
+		{
+			"hint has precedence",
+			"SET max_execution_time = 50",
+			"SELECT /*+ MAX_EXECUTION_TIME(10000) */ SLEEP(0.01) FROM mytable",
+			false,
+		},
+		{
+			"no timeout",
+			"",
+			"SELECT SLEEP(0.01) FROM mytable",
+			false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			session := sql.NewBaseSession()
+			if tt.setup != "" {
+				_, iter, err := e.Query(sql.NewContext(context.Background(), sql.WithSession(session)), tt.setup)
+				require.NoError(err)
+				_, err = sql.RowIterToRows(iter)
+				require.NoError(err)
+			}
+
+			ctx := sql.NewContext(context.Background(), sql.WithSession(session))
+			start := time.Now()
+			_, iter, err := e.Query(ctx, tt.query)
+			require.NoError(err)
+
+			rows, err := sql.RowIterToRows(iter)
+			if tt.timeout {
+				require.Error(err)
+				require.True(sqle.ErrMaxExecutionTimeExceeded.Is(err))
+				require.True(time.Since(start) < time.Second)
+				require.NoError(iter.Close())
+			} else {
+				require.NoError(err)
+				require.Len(rows, 3)
+			}
+		})
+	}
+}
+
+func TestQueryHints(t *testing.T) {
+	require := require.New(t)
+	e := newEngine(t)
+
+	var hints *sql.QueryHints
+	e.Analyzer = analyzer.NewBuilder(e.Catalog).
+		AddRuleAfter("reorder_joins", "record_hints", func(ctx *sql.Context, _ *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+			hints = ctx.Hints()
+			return n, nil
+		}).
+		Build()
+
+	testQuery(
+		t, e,
+		"SELECT /*+ JOIN_ORDER(othertable, mytable) PARALLEL(2) NO_INDEX(mytable) */ i, s2 FROM mytable INNER JOIN othertable ON i = i2 ORDER BY i",
+		[]sql.Row{
+			{int64(1), "third"},
+			{int64(2), "second"},
+			{int64(3), "first"},
+		},
+	)
+	require.Equal(&sql.QueryHints{
+		JoinOrder:   []string{"othertable", "mytable"},
+		NoIndex:     map[string][]string{"mytable": {}},
+		Parallelism: 2,
+	}, hints)
+
+	testQuery(
+		t, e,
+		"SELECT i FROM mytable FORCE INDEX (idx_i) WHERE i IN (SELECT i2 FROM othertable IGNORE INDEX (idx_i2)) ORDER BY i",
+		[]sql.Row{{int64(1)}, {int64(2)}, {int64(3)}},
+	)
+	require.Equal(&sql.QueryHints{
+		NoIndex:    map[string][]string{"othertable": {"idx_i2"}},
+		UseIndex:   map[string][]string{"mytable": {"idx_i"}},
+		ForceIndex: map[string]bool{"mytable": true},
+	}, hints)
+
+	ctx := newCtx()
+	testQueryWithContext(ctx, t, e, "SELECT /*+ BKA(mytable) */ i FROM mytable", []sql.Row{
+		{int64(1)}, {int64(2)}, {int64(3)},
+	})
+	require.Len(ctx.Warnings(), 1)
+	require.Equal(1064, ctx.Warnings()[0].Code)
+}
+
+func TestSessionVariablesONOFF(t *testing.T) {
 	require := require.New(t)
 
 	e := newEngine(t)
@@ -3264,6 +3973,74 @@ func TestLocks(t *testing.T) {
 	require.Equal(1, t2.unlocks)
 }
 
+func TestSubqueriesToSemiJoins(t *testing.T) {
+	e := newEngine(t)
+
+	testCases := []struct {
+		query    string
+		join     sql.Node
+		expected []sql.Row
+	}{
+		{
+			`SELECT i FROM mytable WHERE i IN (SELECT i2 FROM othertable WHERE s2 <> 'first') ORDER BY i`,
+			(*plan.SemiJoin)(nil),
+			[]sql.Row{{int64(1)}, {int64(2)}},
+		},
+		{
+			`SELECT i FROM mytable WHERE i NOT IN (SELECT i2 FROM othertable WHERE s2 <> 'first') ORDER BY i`,
+			(*plan.AntiJoin)(nil),
+			[]sql.Row{{int64(3)}},
+		},
+		{
+			`SELECT i FROM mytable WHERE NOT EXISTS (SELECT * FROM othertable WHERE i2 > 2) ORDER BY i`,
+			(*plan.AntiJoin)(nil),
+			nil,
+		},
+		{
+			`SELECT i FROM mytable WHERE EXISTS (SELECT i FROM niltable WHERE niltable.i = mytable.i) ORDER BY i`,
+			(*plan.SemiJoin)(nil),
+			[]sql.Row{{int64(1)}, {int64(2)}},
+		},
+		{
+			`SELECT i FROM mytable t WHERE NOT EXISTS (SELECT * FROM niltable WHERE i = t.i AND b) ORDER BY i`,
+			(*plan.AntiJoin)(nil),
+			[]sql.Row{{int64(2)}, {int64(3)}},
+		},
+		{
+			`SELECT i FROM mytable WHERE i IN (SELECT 4 - i2 FROM othertable WHERE s2 <> 'first' AND mytable.s = concat(s2, ' row')) ORDER BY i`,
+			(*plan.SemiJoin)(nil),
+			[]sql.Row{{int64(2)}, {int64(3)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			ctx := newCtx()
+
+			parsed, err := parse.Parse(ctx, tt.query)
+			require.NoError(err)
+			result, err := e.Analyzer.Analyze(ctx, parsed)
+			require.NoError(err)
+
+			var found bool
+			plan.Inspect(result, func(n sql.Node) bool {
+				if n != nil && reflect.TypeOf(n) == reflect.TypeOf(tt.join) {
+					found = true
+				}
+				return !found
+			})
+			require.True(found, "expected a %T in the plan:\n%s", tt.join, result)
+
+			iter, err := result.RowIter(ctx)
+			require.NoError(err)
+			rows, err := sql.RowIterToRows(iter)
+			require.NoError(err)
+			require.Equal(tt.expected, rows)
+		})
+	}
+}
+
 func TestDescribeNoPruneColumns(t *testing.T) {
 	require := require.New(t)
 	ctx := newCtx()
@@ -3422,6 +4199,19 @@ func TestDeleteFromErrors(t *testing.T) {
 	}
 }
 
+func TestTableSampleErrors(t *testing.T) {
+	e := newEngine(t)
+
+	_, _, err := e.Query(newCtx(), `SELECT * FROM mytable TABLESAMPLE (200)`)
+	require.True(t, sql.ErrInvalidSamplePercentage.Is(err))
+
+	_, _, err = e.Query(newCtx(), `SELECT * FROM mytable TABLESAMPLE BERNOULLI (-1)`)
+	require.True(t, sql.ErrInvalidSamplePercentage.Is(err))
+
+	_, _, err = e.Query(newCtx(), `SELECT * FROM mytable TABLESAMPLE BLOCK (10)`)
+	require.True(t, sql.ErrInvalidSampleMethod.Is(err))
+}
+
 type mockSpan struct {
 	opentracing.Span
 	finished bool
@@ -3448,73 +4238,1123 @@ func TestRootSpanFinish(t *testing.T) {
 	require.True(t, fakeSpan.finished)
 }
 
-var generatorQueries = []struct {
-	query    string
-	expected []sql.Row
-}{
-	{
-		`SELECT a, EXPLODE(b), c FROM t`,
-		[]sql.Row{
-			{int64(1), "a", "first"},
-			{int64(1), "b", "first"},
-			{int64(2), "c", "second"},
-			{int64(2), "d", "second"},
-			{int64(3), "e", "third"},
-			{int64(3), "f", "third"},
-		},
-	},
-	{
-		`SELECT a, EXPLODE(b) AS x, c FROM t`,
-		[]sql.Row{
-			{int64(1), "a", "first"},
-			{int64(1), "b", "first"},
-			{int64(2), "c", "second"},
-			{int64(2), "d", "second"},
-			{int64(3), "e", "third"},
-			{int64(3), "f", "third"},
-		},
-	},
-	{
-		`SELECT EXPLODE(SPLIT(c, "")) FROM t LIMIT 5`,
-		[]sql.Row{
-			{"f"},
-			{"i"},
-			{"r"},
-			{"s"},
-			{"t"},
-		},
-	},
-	{
-		`SELECT a, EXPLODE(b) AS x, c FROM t WHERE x = 'e'`,
-		[]sql.Row{
-			{int64(3), "e", "third"},
-		},
-	},
+// mockCounter is a metrics.Counter that accumulates the values by labels.
+type mockCounter struct {
+	values map[string]float64
+	labels string
 }
 
-func TestGenerators(t *testing.T) {
-	table := memory.NewPartitionedTable("t", sql.Schema{
-		{Name: "a", Type: sql.Int64, Source: "t"},
-		{Name: "b", Type: sql.Array(sql.Text), Source: "t"},
-		{Name: "c", Type: sql.Text, Source: "t"},
-	}, testNumPartitions)
+func newMockCounter() *mockCounter {
+	return &mockCounter{values: make(map[string]float64)}
+}
 
-	insertRows(
-		t, table,
-		sql.NewRow(int64(1), []interface{}{"a", "b"}, "first"),
-		sql.NewRow(int64(2), []interface{}{"c", "d"}, "second"),
-		sql.NewRow(int64(3), []interface{}{"e", "f"}, "third"),
-	)
+func (c *mockCounter) With(labelValues ...string) metrics.Counter {
+	return &mockCounter{values: c.values, labels: strings.Join(labelValues, ",")}
+}
 
-	db := memory.NewDatabase("db")
+func (c *mockCounter) Add(delta float64) {
+	c.values[c.labels] += delta
+}
+
+func TestQueryMetrics(t *testing.T) {
+	require := require.New(t)
+
+	queries, errors, returned, read := newMockCounter(), newMockCounter(), newMockCounter(), newMockCounter()
+	defer func(queries, errors, returned, read metrics.Counter) {
+		sqle.QueryTypeCounter = queries
+		sqle.QueryTypeErrorCounter = errors
+		sqle.RowsReturnedCounter = returned
+		sqle.RowsReadCounter = read
+	}(sqle.QueryTypeCounter, sqle.QueryTypeErrorCounter, sqle.RowsReturnedCounter, sqle.RowsReadCounter)
+	sqle.QueryTypeCounter = queries
+	sqle.QueryTypeErrorCounter = errors
+	sqle.RowsReturnedCounter = returned
+	sqle.RowsReadCounter = read
+
+	e := newEngine(t)
+	for _, q := range []string{
+		"SELECT i FROM mytable",
+		"SELECT s FROM mytable ORDER BY i LIMIT 1",
+		"SHOW COLUMNS FROM mytable",
+		"INSERT INTO mytable (i, s) VALUES (4, 'fourth row')",
+	} {
+		_, iter, err := e.Query(newCtx(), q)
+		require.NoError(err)
+		_, err = sql.RowIterToRows(iter)
+		require.NoError(err)
+	}
+
+	_, _, err := e.Query(newCtx(), "SELECT * FROM nope")
+	require.Error(err)
+	_, _, err = e.Query(newCtx(), "SELEKT 1")
+	require.Error(err)
+
+	require.Equal(map[string]float64{
+		"type,select":  3,
+		"type,show":    1,
+		"type,insert":  1,
+		"type,unknown": 1,
+	}, queries.values)
+	require.Equal(map[string]float64{
+		"type,select":  1,
+		"type,unknown": 1,
+	}, errors.values)
+	require.Equal(map[string]float64{"": 3 + 1 + 2 + 1}, returned.values)
+	require.Equal(map[string]float64{"": 3 + 3}, read.values)
+}
+
+// checkedDatabase is a database whose backend cannot be reached if err is
+// not nil.
+type checkedDatabase struct {
+	*memory.Database
+	err error
+}
+
+func (d *checkedDatabase) CheckHealth(*sql.Context) error {
+	return d.err
+}
+
+func TestEngineStatus(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	status := e.Status(newCtx())
+	require.True(status.Ready)
+	require.Equal([]string{"mydb", "foo", "information_schema"}, status.Databases)
+	require.Equal(0, status.NotReadyIndexes)
+	require.Empty(status.Checks)
+
+	e.AddDatabase(&checkedDatabase{Database: memory.NewDatabase("ok")})
+	e.AddDatabase(&checkedDatabase{Database: memory.NewDatabase("down"), err: io.ErrUnexpectedEOF})
+
+	status = e.Status(newCtx())
+	require.False(status.Ready)
+	require.Len(status.Checks, 2)
+	require.Equal("ok", status.Checks[0].Database)
+	require.NoError(status.Checks[0].Err)
+	require.Equal("down", status.Checks[1].Database)
+	require.Equal(io.ErrUnexpectedEOF, status.Checks[1].Err)
+}
+
+// tenantProvider materializes a database with a table t for every tenant
+// the first time a query uses it.
+type tenantProvider struct {
+	mu    sync.Mutex
+	dbs   map[string]sql.Database
+	users []string
+}
+
+func (p *tenantProvider) Database(ctx *sql.Context, name string) (sql.Database, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !strings.HasPrefix(name, "tenant_") {
+		return nil, sql.ErrDatabaseNotFound.New(name)
+	}
+
+	p.users = append(p.users, ctx.Client().User)
+	if db, ok := p.dbs[name]; ok {
+		return db, nil
+	}
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "tenant", Type: sql.Text, Source: "t"},
+	})
+	if err := table.Insert(ctx, sql.NewRow(name)); err != nil {
+		return nil, err
+	}
+
+	db := memory.NewDatabase(name)
 	db.AddTable("t", table)
+	p.dbs[name] = db
+	return db, nil
+}
 
-	catalog := sql.NewCatalog()
-	catalog.AddDatabase(db)
-	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+func TestDatabaseProvider(t *testing.T) {
+	require := require.New(t)
 
-	for _, q := range generatorQueries {
-		testQuery(t, e, q.query, q.expected)
+	e := newEngine(t)
+	p := &tenantProvider{dbs: make(map[string]sql.Database)}
+	e.Catalog.SetDatabaseProvider(p)
+
+	testQuery(t, e, "SELECT tenant FROM tenant_1.t", []sql.Row{{"tenant_1"}})
+	testQuery(t, e, "SELECT tenant FROM tenant_2.t", []sql.Row{{"tenant_2"}})
+	require.Len(p.dbs, 2)
+	require.Equal([]string{"user", "user"}, p.users)
+
+	// Databases of the catalog are not looked up in the provider.
+	testQuery(t, e, "SELECT i FROM mydb.mytable WHERE i = 1", []sql.Row{{int64(1)}})
+	require.Len(p.users, 2)
+
+	_, _, err := e.Query(newCtx(), "USE tenant_1")
+	require.NoError(err)
+	testQuery(t, e, "SELECT tenant FROM t", []sql.Row{{"tenant_1"}})
+	require.Len(p.dbs, 2)
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM other.t")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	e.Catalog.SetDatabaseProvider(nil)
+	_, _, err = e.Query(newCtx(), "SELECT * FROM tenant_1.t")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+}
+
+func TestScalarFunctions(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	require.NoError(e.Catalog.Register(sql.ScalarFunction{
+		Name:    "times",
+		Args:    []sql.Type{sql.Int64, sql.Int64},
+		Returns: sql.Int64,
+		Fn: func(ctx *sql.Context, args ...interface{}) (interface{}, error) {
+			if args[0] == nil || args[1] == nil {
+				return nil, nil
+			}
+			return args[0].(int64) * args[1].(int64), nil
+		},
+	}))
+
+	testQuery(t, e, "SELECT TIMES(i, '10') FROM mytable ORDER BY i", []sql.Row{
+		{int64(10)}, {int64(20)}, {int64(30)},
+	})
+	testQuery(t, e, "SELECT i FROM mytable WHERE times(i, i) = 4", []sql.Row{{int64(2)}})
+	testQuery(t, e, "SELECT times(NULL, 2)", []sql.Row{{nil}})
+
+	_, _, err := e.Query(newCtx(), "SELECT times(i) FROM mytable")
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	_, _, err = e.Query(newCtx(), "SELECT times(i, 'abc') FROM mytable")
+	require.True(sql.ErrFunctionArgumentType.Is(err))
+
+	_, iter, err := e.Query(newCtx(), "SELECT times(i, s) FROM mytable")
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.True(sql.ErrFunctionArgumentType.Is(err))
+}
+
+// distinctCount counts the distinct values of its argument.
+type distinctCount struct{}
+
+func (distinctCount) NewBuffer() interface{} { return map[int64]struct{}{} }
+
+func (distinctCount) Update(ctx *sql.Context, buffer interface{}, args ...interface{}) (interface{}, error) {
+	if args[0] != nil {
+		buffer.(map[int64]struct{})[args[0].(int64)] = struct{}{}
+	}
+	return buffer, nil
+}
+
+func (distinctCount) Merge(ctx *sql.Context, buffer, partial interface{}) (interface{}, error) {
+	for v := range partial.(map[int64]struct{}) {
+		buffer.(map[int64]struct{})[v] = struct{}{}
+	}
+	return buffer, nil
+}
+
+func (distinctCount) Eval(ctx *sql.Context, buffer interface{}) (interface{}, error) {
+	return len(buffer.(map[int64]struct{})), nil
+}
+
+func TestAggregateFunctions(t *testing.T) {
+	fn := sql.AggregateFunction{
+		Name:       "distinct_count",
+		Args:       []sql.Type{sql.Int64},
+		Returns:    sql.Int64,
+		Aggregator: distinctCount{},
+	}
+
+	engines := map[string]*sqle.Engine{
+		"sequential": newEngine(t),
+		"parallel":   newEngineWithParallelism(t, 2),
+	}
+
+	for name, e := range engines {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			require.NoError(e.Catalog.Register(fn))
+
+			testQuery(t, e, "SELECT DISTINCT_COUNT(i % 2) FROM mytable", []sql.Row{{int64(2)}})
+			testQuery(t, e, "SELECT distinct_count(i) FROM mytable WHERE i > 5", []sql.Row{{int64(0)}})
+			testQuery(t, e, "SELECT i % 2 AS odd, distinct_count(i) AS n FROM mytable GROUP BY i % 2", []sql.Row{
+				{int64(0), int64(1)}, {int64(1), int64(2)},
+			})
+			testQuery(t, e, "SELECT distinct_count(i), COUNT(*) FROM mytable GROUP BY s ORDER BY s", []sql.Row{
+				{int64(1), int64(1)}, {int64(1), int64(1)}, {int64(1), int64(1)},
+			})
+
+			_, _, err := e.Query(newCtx(), "SELECT distinct_count(i, i) FROM mytable")
+			require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+			_, _, err = e.Query(newCtx(), "SELECT distinct_count('abc') FROM mytable")
+			require.True(sql.ErrFunctionArgumentType.Is(err))
+		})
+	}
+}
+
+func TestTableFunctions(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	require.NoError(e.Catalog.Register(sql.TableFunction{
+		Name: "series",
+		Args: []sql.Type{sql.Int64, sql.Int64},
+		Schema: func(ctx *sql.Context, args ...interface{}) (sql.Schema, error) {
+			return sql.Schema{{Name: "n", Type: sql.Int64}}, nil
+		},
+		RowIter: func(ctx *sql.Context, args ...interface{}) (sql.RowIter, error) {
+			var rows []sql.Row
+			for i := args[0].(int64); i <= args[1].(int64); i++ {
+				rows = append(rows, sql.NewRow(i))
+			}
+			return sql.RowsToRowIter(rows...), nil
+		},
+	}))
+
+	testQuery(t, e, "SELECT * FROM series(1, 3)", []sql.Row{
+		{int64(1)}, {int64(2)}, {int64(3)},
+	})
+	testQuery(t, e, "SELECT g.n * 2 FROM SERIES(1, 2 + 2) AS g WHERE n > 2 ORDER BY g.n", []sql.Row{
+		{int64(6)}, {int64(8)},
+	})
+	testQuery(t, e, "SELECT * FROM series(2, '3'), mytable WHERE series.n = 3", []sql.Row{
+		{int64(3), int64(1), "first row"}, {int64(3), int64(2), "second row"}, {int64(3), int64(3), "third row"},
+	})
+	testQuery(t, e, "SELECT g.n, t.s FROM series(2, 5) g JOIN mytable t ON g.n = t.i", []sql.Row{
+		{int64(2), "second row"}, {int64(3), "third row"},
+	})
+	testQuery(t, e, "SELECT COUNT(*) FROM series(1, 100) WHERE n IN (SELECT i FROM mytable)", []sql.Row{
+		{int64(3)},
+	})
+
+	_, _, err := e.Query(newCtx(), "SELECT series(1, 2)")
+	require.True(sql.ErrTableFunctionInExpression.Is(err))
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM upper('a')")
+	require.True(sql.ErrNotTableFunction.Is(err))
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM series(1)")
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM mytable, series(1, i)")
+	require.True(analyzer.ErrTableFunctionArguments.Is(err))
+}
+
+func TestGenerateSeries(t *testing.T) {
+	e := newEngine(t)
+
+	testQuery(t, e, "SELECT * FROM generate_series(1, 3)", []sql.Row{
+		{int64(1)}, {int64(2)}, {int64(3)},
+	})
+	testQuery(t, e, "SELECT s.value FROM generate_series(10, 1, -4) AS s ORDER BY s.value DESC", []sql.Row{
+		{int64(10)}, {int64(6)}, {int64(2)},
+	})
+	testQuery(t, e, "SELECT i, value FROM mytable JOIN generate_series(2, 10, 2) g ON i = g.value", []sql.Row{
+		{int64(2), int64(2)},
+	})
+	testQuery(t, e, "SELECT COUNT(*) FROM generate_series(3, 1)", []sql.Row{{int64(0)}})
+	testQuery(t, e, "SELECT COUNT(*) FROM generate_series(1, NULL)", []sql.Row{{int64(0)}})
+
+	_, _, err := e.Query(newCtx(), "SELECT * FROM generate_series(1, 3, 0)")
+	require.True(t, function.ErrGenerateSeriesStep.Is(err))
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM generate_series(1, 3, 1, 1)")
+	require.True(t, sql.ErrInvalidArgumentNumber.Is(err))
+}
+
+func TestRowChangeHooks(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	var changes []sql.TableChanges
+	e.AddRowChangeHook(func(ctx *sql.Context, c sql.TableChanges) {
+		changes = append(changes, c)
+	})
+
+	for _, q := range []string{
+		"INSERT INTO mytable (i, s) VALUES (4, 'fourth row')",
+		"UPDATE mytable SET s = 'updated' WHERE i = 4",
+		"UPDATE mytable SET s = 'updated' WHERE i = 4",
+		"DELETE FROM mytable WHERE i = 4",
+		"SELECT * FROM mytable",
+	} {
+		_, iter, err := e.Query(newCtx(), q)
+		require.NoError(err)
+		_, err = sql.RowIterToRows(iter)
+		require.NoError(err)
+	}
+
+	require.Len(changes, 3)
+	for _, c := range changes {
+		require.Equal("mydb", c.Database)
+		require.Equal("mytable", c.Table)
+		require.Len(c.Schema, 2)
+	}
+
+	require.Equal([]sql.RowChange{
+		{Type: sql.RowInserted, After: sql.NewRow(int64(4), "fourth row")},
+	}, changes[0].Rows)
+	require.Equal([]sql.RowChange{{
+		Type:   sql.RowUpdated,
+		Before: sql.NewRow(int64(4), "fourth row"),
+		After:  sql.NewRow(int64(4), "updated"),
+	}}, changes[1].Rows)
+	require.Equal([]sql.RowChange{
+		{Type: sql.RowDeleted, Before: sql.NewRow(int64(4), "updated")},
+	}, changes[2].Rows)
+}
+
+type versionedTable struct {
+	*memory.Table
+	versions map[string]sql.Table
+}
+
+func (t *versionedTable) AsOf(ctx *sql.Context, version interface{}) (sql.Table, error) {
+	v, ok := t.versions[version.(string)]
+	if !ok {
+		return nil, sql.ErrVersionNotFound.New(t.Name(), version)
+	}
+	return v, nil
+}
+
+func TestVersionedTables(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	db, err := e.Catalog.Database("mydb")
+	require.NoError(err)
+
+	schema := sql.Schema{{Name: "i", Type: sql.Int64, Source: "history"}}
+	current := memory.NewTable("history", schema)
+	old := memory.NewTable("history", schema)
+	ctx := newCtx()
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(current.Insert(ctx, sql.NewRow(i)))
+		if i < 3 {
+			require.NoError(old.Insert(ctx, sql.NewRow(i*10)))
+		}
+	}
+
+	db.(*memory.Database).AddTable("history", &versionedTable{
+		Table:    current,
+		versions: map[string]sql.Table{"v1": old},
+	})
+
+	testQuery(t, e, "SELECT i FROM history", []sql.Row{{int64(1)}, {int64(2)}, {int64(3)}})
+	testQuery(t, e, "SELECT i FROM history AS OF 'v1'", []sql.Row{{int64(10)}, {int64(20)}})
+	testQuery(t, e, "SELECT h.i FROM mydb.history AS OF CONCAT('v', '1') AS h WHERE h.i > 10", []sql.Row{{int64(20)}})
+	testQuery(t, e, "SELECT s FROM history AS OF 'v1' JOIN mytable ON history.i = mytable.i * 10", []sql.Row{
+		{"first row"}, {"second row"},
+	})
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM history AS OF 'v2'")
+	require.True(sql.ErrVersionNotFound.Is(err))
+
+	_, _, err = e.Query(newCtx(), "SELECT * FROM mytable AS OF 'v1'")
+	require.True(sql.ErrAsOfNotSupported.Is(err))
+}
+
+type tenantKey struct{}
+
+func TestInterceptors(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+
+	var observed []string
+	e.Interceptors = []sqle.Interceptor{
+		// Observes the statements.
+		func(ctx *sql.Context, query string, parsed sql.Node, next sqle.QueryHandler) (sql.Node, sql.RowIter, error) {
+			observed = append(observed, query)
+			return next(ctx, query, parsed)
+		},
+		// Denies deleting rows.
+		func(ctx *sql.Context, query string, parsed sql.Node, next sqle.QueryHandler) (sql.Node, sql.RowIter, error) {
+			if _, ok := parsed.(*plan.DeleteFrom); ok {
+				return nil, nil, fmt.Errorf("DELETE is not allowed")
+			}
+			return next(ctx, query, parsed)
+		},
+		// Filters the rows of mytable of the tenant of the session.
+		func(ctx *sql.Context, query string, parsed sql.Node, next sqle.QueryHandler) (sql.Node, sql.RowIter, error) {
+			tenant := ctx.Session.State(tenantKey{})
+			if tenant == nil {
+				return next(ctx, query, parsed)
+			}
+
+			rewritten, err := plan.TransformUp(parsed, func(n sql.Node) (sql.Node, error) {
+				if t, ok := n.(*plan.UnresolvedTable); ok && t.Name() == "mytable" {
+					return plan.NewFilter(expression.NewEquals(
+						expression.NewUnresolvedQualifiedColumn("mytable", "i"),
+						expression.NewLiteral(tenant, sql.Int64),
+					), n), nil
+				}
+				return n, nil
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return next(ctx, query, rewritten)
+		},
+	}
+
+	query := "SELECT s FROM mytable ORDER BY s"
+	testQuery(t, e, query, []sql.Row{{"first row"}, {"second row"}, {"third row"}})
+
+	for tenant, expected := range map[int64]string{1: "first row", 2: "second row"} {
+		ctx := newCtx()
+		ctx.Session.SetState(tenantKey{}, tenant)
+		_, iter, err := e.Query(ctx, query)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		require.Equal([]sql.Row{{expected}}, rows)
+	}
+
+	testQuery(t, e, query, []sql.Row{{"first row"}, {"second row"}, {"third row"}})
+
+	_, _, err := e.Query(newCtx(), "DELETE FROM mytable")
+	require.EqualError(err, "DELETE is not allowed")
+
+	require.Equal([]string{query, query, query, query, "DELETE FROM mytable"}, observed)
+}
+
+type reindexSource struct{ source string }
+
+func (n *reindexSource) Resolved() bool       { return true }
+func (n *reindexSource) String() string       { return "ReindexSource(" + n.source + ")" }
+func (n *reindexSource) Children() []sql.Node { return nil }
+
+func (n *reindexSource) Schema() sql.Schema {
+	return sql.Schema{{Name: "reindexed", Type: sql.Text}}
+}
+
+func (n *reindexSource) RowIter(*sql.Context) (sql.RowIter, error) {
+	return sql.RowsToRowIter(sql.NewRow(n.source)), nil
+}
+
+func (n *reindexSource) WithChildren(children ...sql.Node) (sql.Node, error) {
+	return n, nil
+}
+
+func TestDialect(t *testing.T) {
+	require := require.New(t)
+
+	e := newEngine(t)
+	e.Dialect = &parse.Dialect{
+		Statements: []parse.Statement{{
+			Prefix: "REINDEX SOURCE",
+			Parse: func(ctx *sql.Context, query string) (sql.Node, error) {
+				words := strings.Fields(query)
+				if len(words) != 3 {
+					return nil, fmt.Errorf("syntax: REINDEX SOURCE <name>")
+				}
+				return &reindexSource{words[2]}, nil
+			},
+		}},
+	}
+
+	testQuery(t, e, "reindex source foo;", []sql.Row{{"foo"}})
+	testQuery(t, e, "SELECT i FROM mytable WHERE i = 1", []sql.Row{{int64(1)}})
+
+	_, _, err := e.Query(newCtx(), "REINDEX SOURCE")
+	require.EqualError(err, "syntax: REINDEX SOURCE <name>")
+}
+
+func TestMemoryIndexes(t *testing.T) {
+	e := newEngine(t)
+	db, err := e.Catalog.Database("mydb")
+	require.NoError(t, err)
+	e.AddIndexDriver(memory.NewIndexDriver(db.(*memory.Database)))
+
+	testQuery(t, e, "CREATE INDEX idx_i ON mytable USING memory (i) WITH (async = false)", []sql.Row{})
+	testQuery(t, e, "CREATE INDEX idx_s ON mytable USING memory (s) WITH (async = false, type = 'hash')", []sql.Row{})
+	testQuery(t, e, "INSERT INTO mytable VALUES (4, 'fourth row'), (5, 'fifth row')", []sql.Row{{int64(2)}})
+	testQuery(t, e, "DELETE FROM mytable WHERE i = 1", []sql.Row{{int64(1)}})
+	testQuery(t, e, "UPDATE mytable SET i = 30 WHERE i = 3", []sql.Row{{int64(1), int64(1)}})
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{"SELECT s FROM mytable WHERE i = 2", []sql.Row{{"second row"}}},
+		{"SELECT s FROM mytable WHERE i = 3", nil},
+		{"SELECT i FROM mytable WHERE i > 4 ORDER BY i", []sql.Row{{int64(5)}, {int64(30)}}},
+		{"SELECT i FROM mytable WHERE i BETWEEN 2 AND 4 ORDER BY i", []sql.Row{{int64(2)}, {int64(4)}}},
+		{"SELECT i FROM mytable WHERE i IN (1, 2, 5) ORDER BY i", []sql.Row{{int64(2)}, {int64(5)}}},
+		{"SELECT i FROM mytable WHERE s = 'third row'", []sql.Row{{int64(30)}}},
+		{"SELECT i FROM mytable WHERE s = 'fifth row' AND i > 1", []sql.Row{{int64(5)}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			analyzed, iter, err := e.QueryWithPlan(newCtx(), tt.query)
+			require.NoError(err)
+			rows, err := sql.RowIterToRows(iter)
+			require.NoError(err)
+			require.Equal(tt.expected, rows)
+
+			var indexed bool
+			plan.Inspect(analyzed, func(n sql.Node) bool {
+				if rt, ok := n.(*plan.ResolvedTable); ok {
+					if it, ok := rt.Table.(sql.IndexableTable); ok && it.IndexLookup() != nil {
+						indexed = true
+					}
+				}
+				return true
+			})
+			require.True(indexed, "the query does not use the index:\n%s", analyzed)
+		})
+	}
+
+	testQuery(t, e, "DROP INDEX idx_i ON mytable", []sql.Row{})
+	testQuery(t, e, "INSERT INTO mytable VALUES (6, 'sixth row')", []sql.Row{{int64(1)}})
+	testQuery(t, e, "SELECT i FROM mytable WHERE s = 'sixth row'", []sql.Row{{int64(6)}})
+	testQuery(t, e, "SELECT s FROM mytable WHERE i = 6", []sql.Row{{"sixth row"}})
+}
+
+var generatorQueries = []struct {
+	query    string
+	expected []sql.Row
+}{
+	{
+		`SELECT a, EXPLODE(b), c FROM t`,
+		[]sql.Row{
+			{int64(1), "a", "first"},
+			{int64(1), "b", "first"},
+			{int64(2), "c", "second"},
+			{int64(2), "d", "second"},
+			{int64(3), "e", "third"},
+			{int64(3), "f", "third"},
+		},
+	},
+	{
+		`SELECT a, EXPLODE(b) AS x, c FROM t`,
+		[]sql.Row{
+			{int64(1), "a", "first"},
+			{int64(1), "b", "first"},
+			{int64(2), "c", "second"},
+			{int64(2), "d", "second"},
+			{int64(3), "e", "third"},
+			{int64(3), "f", "third"},
+		},
+	},
+	{
+		`SELECT EXPLODE(SPLIT(c, "")) FROM t LIMIT 5`,
+		[]sql.Row{
+			{"f"},
+			{"i"},
+			{"r"},
+			{"s"},
+			{"t"},
+		},
+	},
+	{
+		`SELECT a, EXPLODE(b) AS x, c FROM t WHERE x = 'e'`,
+		[]sql.Row{
+			{int64(3), "e", "third"},
+		},
+	},
+}
+
+func TestGenerators(t *testing.T) {
+	table := memory.NewPartitionedTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Array(sql.Text), Source: "t"},
+		{Name: "c", Type: sql.Text, Source: "t"},
+	}, testNumPartitions)
+
+	insertRows(
+		t, table,
+		sql.NewRow(int64(1), []interface{}{"a", "b"}, "first"),
+		sql.NewRow(int64(2), []interface{}{"c", "d"}, "second"),
+		sql.NewRow(int64(3), []interface{}{"e", "f"}, "third"),
+	)
+
+	db := memory.NewDatabase("db")
+	db.AddTable("t", table)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+
+	for _, q := range generatorQueries {
+		testQuery(t, e, q.query, q.expected)
+	}
+}
+
+func TestTimeZones(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "i", Type: sql.Int64, Source: "t"},
+		{Name: "ts", Type: sql.Timestamp, Source: "t"},
+		{Name: "dt", Type: sql.Datetime, Source: "t"},
+	})
+
+	db := memory.NewDatabase("db")
+	db.AddTable("t", table)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+
+	run := func(ctx *sql.Context, query string) []sql.Row {
+		_, iter, err := e.Query(ctx, query)
+		require.NoError(err, query)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, query)
+		return rows
+	}
+
+	ctx := newCtx()
+	run(ctx, "SET time_zone = '+02:00'")
+	run(ctx, "INSERT INTO t VALUES (1, '2019-01-02 03:04:05', '2019-01-02 03:04:05')")
+	run(ctx, "UPDATE t SET ts = '2019-01-02 05:04:05' WHERE i = 1")
+	run(ctx, "INSERT INTO t VALUES (2, '2019-07-02 03:04:05', '2019-07-02 03:04:05')")
+
+	// Timestamps are stored in UTC and read in the time zone of the session.
+	utc := newCtx()
+	run(utc, "SET time_zone = '+00:00'")
+	require.Equal([]sql.Row{
+		{int64(1), time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{int64(2), time.Date(2019, 7, 2, 1, 4, 5, 0, time.UTC), time.Date(2019, 7, 2, 3, 4, 5, 0, time.UTC)},
+	}, run(utc, "SELECT * FROM t ORDER BY i"))
+
+	require.Equal([]sql.Row{
+		{time.Date(2019, 1, 2, 5, 4, 5, 0, time.UTC), time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{time.Date(2019, 7, 2, 3, 4, 5, 0, time.UTC), time.Date(2019, 7, 2, 3, 4, 5, 0, time.UTC)},
+	}, run(ctx, "SELECT ts, dt FROM t ORDER BY i"))
+	require.Equal([]sql.Row{{int64(2)}}, run(ctx, "SELECT i FROM t WHERE ts = '2019-07-02 03:04:05'"))
+
+	run(ctx, "SET time_zone = 'Europe/Madrid'")
+	require.Equal([]sql.Row{
+		{time.Date(2019, 1, 2, 4, 4, 5, 0, time.UTC)},
+		{time.Date(2019, 7, 2, 3, 4, 5, 0, time.UTC)},
+	}, run(ctx, "SELECT ts FROM t ORDER BY i"))
+
+	require.Equal([]sql.Row{
+		{time.Date(2019, 1, 2, 9, 4, 5, 0, time.UTC)},
+	}, run(ctx, "SELECT CONVERT_TZ(dt, 'Europe/Madrid', '+07:00') FROM t WHERE i = 1"))
+	require.Equal([]sql.Row{
+		{time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}, run(ctx, "SELECT CONVERT_TZ(ts, @@time_zone, 'UTC') FROM t WHERE i = 1"))
+
+	_, _, err := e.Query(ctx, "SET time_zone = 'Foo/Bar'")
+	require.Error(err)
+	require.True(sql.ErrUnknownTimeZone.Is(err))
+
+	// The global time zone is the one of the new sessions.
+	run(ctx, "SET GLOBAL time_zone = '-05:00'")
+	require.Equal("-05:00", catalog.TimeZone())
+	require.Equal([]sql.Row{{"Europe/Madrid"}}, run(ctx, "SELECT @@time_zone"))
+
+	run(ctx, "SET time_zone = DEFAULT")
+	require.Equal([]sql.Row{{"-05:00"}}, run(ctx, "SELECT @@time_zone"))
+}
+
+func TestTimePrecision(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(memory.NewDatabase("db"))
+	catalog.AddDatabase(sql.NewInformationSchemaDatabase(catalog))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+
+	run := func(query string) []sql.Row {
+		_, iter, err := e.Query(newCtx(), query)
+		require.NoError(err, query)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, query)
+		return rows
+	}
+
+	run("CREATE TABLE t (i BIGINT, dt DATETIME(6), ts TIMESTAMP(3), d DATETIME, tm TIME(2))")
+	run("INSERT INTO t VALUES (1, '2019-01-02 03:04:05.123456', '2019-01-02 03:04:05.123456', '2019-01-02 03:04:05.6', '12:34:56.789')")
+
+	// Fractional seconds are rounded to the precision of the columns.
+	require.Equal([]sql.Row{{
+		time.Date(2019, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		time.Date(2019, 1, 2, 3, 4, 5, 123000000, time.UTC),
+		time.Date(2019, 1, 2, 3, 4, 6, 0, time.UTC),
+		12*time.Hour + 34*time.Minute + 56*time.Second + 790*time.Millisecond,
+		int32(123456),
+	}}, run("SELECT dt, ts, d, tm, MICROSECOND(dt) FROM t"))
+
+	require.Equal([]sql.Row{{int64(1)}}, run("SELECT i FROM t WHERE dt = '2019-01-02 03:04:05.123456'"))
+	require.Equal([]sql.Row{{int64(1)}}, run("SELECT i FROM t WHERE dt > '2019-01-02 03:04:05.123455'"))
+	require.Len(run("SELECT i FROM t WHERE dt = '2019-01-02 03:04:05'"), 0)
+	require.Equal([]sql.Row{{int64(1)}}, run("SELECT i FROM t WHERE tm > '12:34:56.78'"))
+
+	require.Equal([]sql.Row{
+		{time.Date(2019, 1, 2, 3, 4, 5, 120000000, time.UTC), 3*time.Hour + 4*time.Minute + 5123456*time.Microsecond},
+	}, run("SELECT CAST('2019-01-02 03:04:05.12' AS DATETIME(2)), CAST(dt AS TIME) FROM t"))
+
+	schema, _, err := e.Query(newCtx(), "SELECT dt, ts, tm FROM t")
+	require.NoError(err)
+	require.Equal(sql.DatetimeWithPrecision(6), schema[0].Type)
+	require.Equal(sql.TimestampWithPrecision(3), schema[1].Type)
+	require.Equal(sql.TimeWithPrecision(2), schema[2].Type)
+
+	require.Equal([]sql.Row{
+		{"dt", uint64(6)}, {"ts", uint64(3)}, {"d", uint64(0)}, {"tm", uint64(2)},
+	}, run("SELECT column_name, datetime_precision FROM information_schema.columns WHERE table_name = 't' AND datetime_precision IS NOT NULL ORDER BY ordinal_position"))
+}
+
+func TestSQLModeStrict(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(memory.NewDatabase("db"))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+	ctx := newCtx()
+
+	run := func(query string) ([]sql.Row, error) {
+		_, iter, err := e.Query(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return sql.RowIterToRows(iter)
+	}
+
+	_, err := run("CREATE TABLE t (i TINYINT, u TINYINT UNSIGNED, f DOUBLE)")
+	require.NoError(err)
+
+	// Out of range values are clamped and values that are not numbers are
+	// read from the start of the strings if not in strict mode.
+	_, err = run("INSERT INTO t VALUES (1, 1, 1), (300, -1, '2.5 apples')")
+	require.NoError(err)
+
+	rows, err := run("SHOW WARNINGS")
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{"Warning", 1366, "Incorrect double value: '2.5 apples' for column 'f' at row 2"},
+		{"Warning", 1264, "Out of range value for column 'u' at row 2"},
+		{"Warning", 1264, "Out of range value for column 'i' at row 2"},
+	}, rows)
+
+	rows, err = run("SELECT i, u, f FROM t ORDER BY f")
+	require.NoError(err)
+	require.Equal([]sql.Row{{int8(1), uint8(1), 1.0}, {int8(127), uint8(0), 2.5}}, rows)
+
+	_, err = run("SET sql_mode = 'STRICT_TRANS_TABLES'")
+	require.NoError(err)
+
+	_, err = run("INSERT INTO t VALUES (1, 1, 1), (1, 256, 1)")
+	require.Error(err)
+	require.True(sql.ErrValueOutOfRange.Is(err))
+	require.Equal("Out of range value for column 'u' at row 2", err.Error())
+
+	_, err = run("UPDATE t SET i = i * 2")
+	require.Error(err)
+	require.True(sql.ErrValueOutOfRange.Is(err))
+
+	_, err = run("INSERT INTO t (f) VALUES ('foo')")
+	require.Error(err)
+	require.True(sql.ErrTruncatedWrongValue.Is(err))
+}
+
+func TestInsertHexLiterals(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(memory.NewDatabase("db"))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+
+	run := func(query string) []sql.Row {
+		_, iter, err := e.Query(newCtx(), query)
+		require.NoError(err, query)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err, query)
+		return rows
+	}
+
+	run("CREATE TABLE t (i BIGINT, s TEXT)")
+	run("INSERT INTO t VALUES (0x41, _utf8mb4 x'41'), (b'11', _utf8mb4'b')")
+	run("UPDATE t SET i = i + 0x10 WHERE s = 'A'")
+	run("UPDATE t SET i = b'111' WHERE s = 'b'")
+	require.Equal([]sql.Row{{int64(81), "A"}, {int64(7), "b"}}, run("SELECT i, s FROM t ORDER BY s"))
+
+	run("CREATE TABLE b (b BLOB)")
+	run("INSERT INTO b VALUES (0x41), (x'0102')")
+	require.Equal([]sql.Row{{[]byte("A")}, {[]byte{1, 2}}}, run("SELECT b FROM b ORDER BY b DESC"))
+}
+
+func TestZeroDates(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(memory.NewDatabase("db"))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+	ctx := newCtx()
+
+	run := func(query string) ([]sql.Row, error) {
+		_, iter, err := e.Query(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return sql.RowIterToRows(iter)
+	}
+
+	_, err := run("CREATE TABLE t (i BIGINT, d DATE, dt DATETIME, ts TIMESTAMP)")
+	require.NoError(err)
+
+	_, err = run("INSERT INTO t VALUES (1, '0000-00-00', '0000-00-00 00:00:00', '0000-00-00 00:00:00'), (2, '2019-01-02', '2019-01-00 00:00:00', 'foo')")
+	require.NoError(err)
+
+	rows, err := run("SHOW WARNINGS")
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{"Warning", 1366, "Incorrect datetime value: 'foo' for column 'ts' at row 2"},
+		{"Warning", 1366, "Incorrect datetime value: '2019-01-00 00:00:00' for column 'dt' at row 2"},
+	}, rows)
+
+	rows, err = run("SELECT i, YEAR(d), MONTH(dt), DAY(ts), DAYOFWEEK(d) FROM t ORDER BY i")
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{int64(1), int32(0), int32(0), int32(0), nil},
+		{int64(2), int32(2019), int32(0), int32(0), int32(4)},
+	}, rows)
+
+	rows, err = run("SELECT i FROM t WHERE d = '0000-00-00' OR d < '1000-01-01'")
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1)}}, rows)
+
+	// The first date of the calendar is not the zero date.
+	_, err = run("INSERT INTO t (i, d, dt) VALUES (5, '0001-01-01', '0001-01-01 00:00:00')")
+	require.NoError(err)
+
+	rows, err = run("SELECT i, d, YEAR(d), DAY(dt) FROM t WHERE i = 5 AND d <> '0000-00-00' AND dt <> '0000-00-00'")
+	require.NoError(err)
+	first := time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal([]sql.Row{{int64(5), first, int32(1), int32(1)}}, rows)
+
+	rows, err = run("SELECT i FROM t WHERE d = '0000-00-00' ORDER BY i")
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1)}}, rows)
+
+	_, err = run("SET sql_mode = 'NO_ZERO_DATE'")
+	require.NoError(err)
+
+	_, err = run("INSERT INTO t (i, d) VALUES (3, '0000-00-00')")
+	require.NoError(err)
+
+	// The warnings are not kept by the plans of SHOW WARNINGS.
+	rows, err = run("SHOW WARNINGS")
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{"Warning", 1366, "Incorrect date value: '0000-00-00' for column 'd' at row 1"},
+	}, rows)
+
+	_, err = run("SET sql_mode = 'TRADITIONAL'")
+	require.NoError(err)
+
+	_, err = run("INSERT INTO t (i, d) VALUES (4, '0000-00-00')")
+	require.True(sql.ErrTruncatedWrongValue.Is(err))
+
+	_, err = run("INSERT INTO t (i, dt) VALUES (4, '2019-00-01 00:00:00')")
+	require.True(sql.ErrTruncatedWrongValue.Is(err))
+}
+
+func TestOnlyFullGroupBy(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(memory.NewDatabase("db"))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+	ctx := newCtx()
+
+	run := func(query string) ([]sql.Row, error) {
+		_, iter, err := e.Query(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return sql.RowIterToRows(iter)
+	}
+
+	_, err := run("CREATE TABLE t (id BIGINT PRIMARY KEY, g BIGINT, s TEXT)")
+	require.NoError(err)
+
+	_, err = run("INSERT INTO t VALUES (1, 1, 'a'), (2, 1, 'a'), (3, 2, 'b')")
+	require.NoError(err)
+
+	// The columns that are not grouped have the value of any row of their
+	// group.
+	rows, err := run("SELECT g, s FROM t GROUP BY g ORDER BY g")
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1), "a"}, {int64(2), "b"}}, rows)
+
+	rows, err = run("SELECT s, COUNT(*) FROM t WHERE g = 2")
+	require.NoError(err)
+	require.Equal([]sql.Row{{"b", int64(1)}}, rows)
+
+	_, err = run("SET sql_mode = 'ONLY_FULL_GROUP_BY'")
+	require.NoError(err)
+
+	_, err = run("SELECT g, s FROM t GROUP BY g ORDER BY g")
+	require.True(analyzer.ErrValidationGroupBy.Is(err))
+
+	_, err = run("SELECT s, COUNT(*) FROM t WHERE g = 2")
+	require.True(analyzer.ErrValidationGroupBy.Is(err))
+
+	rows, err = run("SELECT g, ANY_VALUE(s) FROM t GROUP BY g ORDER BY g")
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(1), "a"}, {int64(2), "b"}}, rows)
+
+	rows, err = run("SELECT g + 1 AS x, COUNT(*) FROM t GROUP BY g ORDER BY x")
+	require.NoError(err)
+	require.Equal([]sql.Row{{int64(2), int64(2)}, {int64(3), int64(1)}}, rows)
+
+	rows, err = run("SELECT id, g, s FROM t GROUP BY id ORDER BY id")
+	require.NoError(err)
+	require.Len(rows, 3)
+}
+
+func TestAliasResolution(t *testing.T) {
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(memory.NewDatabase("db"))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), new(sqle.Config))
+	ctx := newCtx()
+
+	for _, q := range []string{
+		"CREATE TABLE t (id BIGINT PRIMARY KEY, g BIGINT, s TEXT)",
+		"INSERT INTO t VALUES (1, 1, 'a'), (2, 1, 'b'), (3, 2, 'c'), (4, 3, 'c')",
+	} {
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(t, err)
+		_, err = sql.RowIterToRows(iter)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		// ORDER BY refers to the aliases before the columns.
+		{
+			"SELECT id AS g, g AS id FROM t ORDER BY g DESC",
+			[]sql.Row{{int64(4), int64(3)}, {int64(3), int64(2)}, {int64(2), int64(1)}, {int64(1), int64(1)}},
+		},
+		{
+			"SELECT id AS g, g AS id FROM t ORDER BY 2 DESC, 1",
+			[]sql.Row{{int64(4), int64(3)}, {int64(3), int64(2)}, {int64(1), int64(1)}, {int64(2), int64(1)}},
+		},
+		{
+			"SELECT g AS x, COUNT(*) AS c FROM t GROUP BY x ORDER BY c DESC, x",
+			[]sql.Row{{int64(1), int64(2)}, {int64(2), int64(1)}, {int64(3), int64(1)}},
+		},
+		// GROUP BY refers to the columns before the aliases.
+		{
+			"SELECT COUNT(*) AS g FROM t GROUP BY g ORDER BY g",
+			[]sql.Row{{int64(1)}, {int64(1)}, {int64(2)}},
+		},
+		{
+			"SELECT UPPER(s) AS u, COUNT(*) FROM t GROUP BY u ORDER BY u",
+			[]sql.Row{{"A", int64(1)}, {"B", int64(1)}, {"C", int64(2)}},
+		},
+		{
+			"SELECT id AS g, COUNT(*) FROM t GROUP BY 1 ORDER BY 1",
+			[]sql.Row{{int64(1), int64(1)}, {int64(2), int64(1)}, {int64(3), int64(1)}, {int64(4), int64(1)}},
+		},
+		// HAVING refers to the grouping columns, then to the aliases and
+		// then to the other columns.
+		{
+			"SELECT COUNT(*) AS g FROM t GROUP BY g HAVING g > 1",
+			[]sql.Row{{int64(1)}, {int64(1)}},
+		},
+		{
+			"SELECT s AS g, COUNT(*) FROM t GROUP BY s HAVING g = 'c'",
+			[]sql.Row{{"c", int64(2)}},
+		},
+		{
+			"SELECT g AS x, COUNT(*) AS c FROM t GROUP BY x HAVING c > 1 AND x = 1",
+			[]sql.Row{{int64(1), int64(2)}},
+		},
+		{
+			"SELECT g AS x, COUNT(*) FROM t GROUP BY x HAVING SUM(id) > 3 ORDER BY x",
+			[]sql.Row{{int64(3), int64(1)}},
+		},
+		{
+			"SELECT g AS x FROM t GROUP BY x HAVING MAX(t.s) > 'b' ORDER BY x",
+			[]sql.Row{{int64(2)}, {int64(3)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			_, iter, err := e.Query(ctx, tt.query)
+			require.NoError(t, err)
+			rows, err := sql.RowIterToRows(iter)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, rows)
+		})
+	}
+
+	errorCases := []struct {
+		query string
+		err   *errors.Kind
+	}{
+		{"SELECT g FROM t GROUP BY 2", parse.ErrGroupByColumnIndex},
+		{"SELECT COUNT(*) AS c FROM t GROUP BY c", analyzer.ErrGroupByAggregate},
+		{"SELECT COUNT(*) AS c FROM t GROUP BY 1", analyzer.ErrGroupByAggregate},
+		{"SELECT id FROM t ORDER BY 2", analyzer.ErrOrderByColumnIndex},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.query, func(t *testing.T) {
+			_, _, err := e.Query(ctx, tt.query)
+			require.True(t, tt.err.Is(err), "%v", err)
+		})
+	}
+}
+
+func TestCaseSensitiveNames(t *testing.T) {
+	for _, caseSensitive := range []bool{false, true} {
+		catalog := sql.NewCatalog()
+		catalog.AddDatabase(memory.NewDatabase("Db"))
+		e := sqle.New(catalog, analyzer.NewDefault(catalog), &sqle.Config{
+			CaseSensitiveNames: caseSensitive,
+		})
+		ctx := newCtx()
+
+		run := func(query string) ([]sql.Row, error) {
+			_, iter, err := e.Query(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			return sql.RowIterToRows(iter)
+		}
+
+		_, err := run("CREATE TABLE Users (Id BIGINT PRIMARY KEY, Name TEXT)")
+		require.NoError(t, err)
+
+		_, err = run("INSERT INTO Users (Id, Name) VALUES (1, 'foo')")
+		require.NoError(t, err)
+
+		for _, query := range []string{
+			"SELECT Id, Name FROM Db.Users",
+			"SELECT u.Id, Users.Name FROM Users u JOIN Users ON u.Id = Users.Id",
+		} {
+			rows, err := run(query)
+			require.NoError(t, err, query)
+			require.Equal(t, []sql.Row{{int64(1), "foo"}}, rows, query)
+		}
+
+		for _, query := range []string{
+			"SELECT id, name FROM users",
+			"SELECT ID FROM Users",
+			"SELECT Id FROM db.Users",
+			"INSERT INTO users (id, NAME) VALUES (2, 'bar')",
+		} {
+			_, err := run(query)
+			if caseSensitive {
+				require.Error(t, err, query)
+			} else {
+				require.NoError(t, err, query)
+			}
+		}
 	}
 }
 
@@ -0,0 +1,120 @@
+package sqle
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/parse"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrMaxExecutionTimeExceeded is returned when a query is interrupted
+// because it ran for longer than its maximum execution time.
+var ErrMaxExecutionTimeExceeded = errors.NewKind("query execution was interrupted, maximum statement execution time of %s exceeded")
+
+const (
+	maxExecutionTimeKey        = "MAX_EXECUTION_TIME"
+	maxExecutionTimeSessionVar = "max_execution_time"
+)
+
+// maxExecutionTime is the default maximum execution time of the queries,
+// read from the environment in milliseconds. Zero means there is no limit.
+var maxExecutionTime = func() time.Duration {
+	v := strings.TrimSpace(os.Getenv(maxExecutionTimeKey))
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		logrus.Warnf("invalid value %q for %s, queries will have no time limit", v, maxExecutionTimeKey)
+		return 0
+	}
+	return time.Duration(n) * time.Millisecond
+}()
+
+// executionTimeout returns the maximum execution time of the query, which
+// is set with the MAX_EXECUTION_TIME hint of the query, the
+// max_execution_time session variable or the MAX_EXECUTION_TIME environment
+// variable, in that order of precedence. Zero means there is no limit.
+func executionTimeout(ctx *sql.Context, query string) time.Duration {
+	if d, ok := parse.MaxExecutionTime(query); ok {
+		return d
+	}
+
+	_, val := ctx.Get(maxExecutionTimeSessionVar)
+	if val == nil {
+		return maxExecutionTime
+	}
+
+	n, err := sql.Int64.Convert(val)
+	if err != nil || n.(int64) < 0 {
+		return maxExecutionTime
+	}
+	return time.Duration(n.(int64)) * time.Millisecond
+}
+
+// timeoutIter cancels the context of the query once it has run for longer
+// than its maximum execution time, and reports it with a clearer error than
+// the one returned by the iterators that stopped because of that.
+type timeoutIter struct {
+	ctx     *sql.Context
+	iter    sql.RowIter
+	batch   sql.RowBatchIter
+	timeout time.Duration
+	cancel  context.CancelFunc
+}
+
+// withTimeout returns a context that is cancelled after the given timeout
+// and the function to release it.
+func withTimeout(ctx *sql.Context, timeout time.Duration) (*sql.Context, context.CancelFunc) {
+	newCtx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx.WithContext(newCtx), cancel
+}
+
+func newTimeoutIter(
+	ctx *sql.Context,
+	iter sql.RowIter,
+	timeout time.Duration,
+	cancel context.CancelFunc,
+) *timeoutIter {
+	return &timeoutIter{ctx: ctx, iter: iter, timeout: timeout, cancel: cancel}
+}
+
+func (i *timeoutIter) Next() (sql.Row, error) {
+	row, err := i.iter.Next()
+	if err != nil {
+		return nil, i.err(err)
+	}
+	return row, nil
+}
+
+func (i *timeoutIter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.iter)
+	}
+
+	n, err := i.batch.NextBatch(rows)
+	if err != nil {
+		return 0, i.err(err)
+	}
+	return n, nil
+}
+
+func (i *timeoutIter) err(err error) error {
+	if err != io.EOF && i.ctx.Err() == context.DeadlineExceeded {
+		return ErrMaxExecutionTimeExceeded.New(i.timeout)
+	}
+	return err
+}
+
+func (i *timeoutIter) Close() error {
+	defer i.cancel()
+	return i.iter.Close()
+}
@@ -0,0 +1,109 @@
+package federated
+
+import (
+	dsql "database/sql"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrRemoteQuery is returned when a query of a remote database fails.
+var ErrRemoteQuery = errors.NewKind("unable to query the remote table %s: %s")
+
+// Database is a database whose tables are the tables of a remote database,
+// read with queries sent to it. The filters, projections and limits of the
+// queries of the engine are pushed down to the queries of the remote
+// database, so only the rows and columns needed are sent by it. Filters
+// pushed down are evaluated by the remote database with its own semantics,
+// such as the collations of its strings.
+type Database struct {
+	name    string
+	db      *dsql.DB
+	dialect Dialect
+	tables  map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the given name whose tables are the
+// ones of the remote database of the given connection, which uses the
+// given dialect. The tables and their schemas are read once, when the
+// database is created. The connection is not closed by the database.
+func NewDatabase(name string, db *dsql.DB, dialect Dialect) (*Database, error) {
+	d := &Database{
+		name:    name,
+		db:      db,
+		dialect: dialect,
+		tables:  make(map[string]sql.Table),
+	}
+
+	names, err := d.tableNames()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		schema, err := d.tableSchema(name)
+		if err != nil {
+			return nil, err
+		}
+		d.tables[name] = newTable(d, name, schema)
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
+
+func (d *Database) tableNames() ([]string, error) {
+	rows, err := d.db.Query(d.dialect.tablesQuery())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// tableSchema reads the schema of a table from the types of the columns of
+// a query without rows.
+func (d *Database) tableSchema(table string) (sql.Schema, error) {
+	rows, err := d.db.Query("SELECT * FROM " + d.dialect.quote(table) + " LIMIT 0")
+	if err != nil {
+		return nil, ErrRemoteQuery.New(table, err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, ErrRemoteQuery.New(table, err)
+	}
+
+	schema := make(sql.Schema, len(types))
+	for i, ct := range types {
+		nullable, ok := ct.Nullable()
+		schema[i] = &sql.Column{
+			Name:     ct.Name(),
+			Type:     columnType(ct.DatabaseTypeName(), ct.ScanType()),
+			Nullable: nullable || !ok,
+			Source:   table,
+		}
+	}
+	return schema, nil
+}
@@ -0,0 +1,172 @@
+package federated
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"net"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+// remoteDatabase starts a server with a memory database and returns a
+// connection to it.
+func remoteDatabase(t *testing.T) *dsql.DB {
+	require := require.New(t)
+
+	remote := memory.NewDatabase("remote")
+	users := memory.NewTable("users", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users"},
+		{Name: "name", Type: sql.Text, Source: "users"},
+		{Name: "score", Type: sql.Float64, Source: "users", Nullable: true},
+	})
+	remote.AddTable("users", users)
+
+	ctx := sql.NewEmptyContext()
+	for _, row := range []sql.Row{
+		{int64(1), "alice", 9.5},
+		{int64(2), "bob", nil},
+		{int64(3), "carol", 7.0},
+		{int64(4), "dave", 3.5},
+	} {
+		require.NoError(users.Insert(ctx, row))
+	}
+
+	e := sqle.NewDefault()
+	e.AddDatabase(remote)
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	addr := l.Addr().String()
+	require.NoError(l.Close())
+
+	s, err := server.NewDefaultServer(server.Config{
+		Protocol: "tcp",
+		Address:  addr,
+		Auth:     new(auth.None),
+	}, e)
+	require.NoError(err)
+	go s.Start()
+	t.Cleanup(func() { s.Close() })
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(%s)/remote", addr))
+	require.NoError(err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDatabase(t *testing.T) {
+	require := require.New(t)
+
+	db, err := NewDatabase("fed", remoteDatabase(t), MySQL)
+	require.NoError(err)
+	require.Equal("fed", db.Name())
+
+	tables := db.Tables()
+	require.Len(tables, 1)
+	require.Equal(sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users"},
+		{Name: "name", Type: sql.Text, Source: "users"},
+		{Name: "score", Type: sql.Float64, Nullable: true, Source: "users"},
+	}, tables["users"].Schema())
+
+	local := memory.NewDatabase("local")
+	orders := memory.NewTable("orders", sql.Schema{
+		{Name: "user_id", Type: sql.Int64, Source: "orders"},
+		{Name: "total", Type: sql.Int64, Source: "orders"},
+	})
+	local.AddTable("orders", orders)
+	ctx := sql.NewEmptyContext()
+	require.NoError(orders.Insert(ctx, sql.NewRow(int64(1), int64(10))))
+	require.NoError(orders.Insert(ctx, sql.NewRow(int64(3), int64(20))))
+	require.NoError(orders.Insert(ctx, sql.NewRow(int64(3), int64(5))))
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+	e.AddDatabase(local)
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{
+			"SELECT name FROM fed.users WHERE score > 5 ORDER BY name",
+			[]sql.Row{{"alice"}, {"carol"}},
+		},
+		{
+			"SELECT id FROM fed.users WHERE score IS NULL OR name IN ('dave', 'eve') ORDER BY id",
+			[]sql.Row{{int64(2)}, {int64(4)}},
+		},
+		{
+			"SELECT id, name FROM fed.users LIMIT 2",
+			[]sql.Row{{int64(1), "alice"}, {int64(2), "bob"}},
+		},
+		{
+			"SELECT u.name, SUM(o.total) FROM fed.users u INNER JOIN local.orders o ON u.id = o.user_id " +
+				"WHERE u.id < 4 GROUP BY u.name ORDER BY u.name",
+			[]sql.Row{{"alice", float64(10)}, {"carol", float64(25)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			testQuery(t, e, tt.query, tt.expected)
+		})
+	}
+}
+
+func testQuery(t *testing.T, e *sqle.Engine, query string, expected []sql.Row) {
+	t.Helper()
+	require := require.New(t)
+
+	_, iter, err := e.Query(sql.NewEmptyContext(), query)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, rows)
+}
+
+func TestTablePushdown(t *testing.T) {
+	require := require.New(t)
+
+	db, err := NewDatabase("fed", remoteDatabase(t), MySQL)
+	require.NoError(err)
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+
+	analyzed, iter, err := e.QueryWithPlan(
+		sql.NewEmptyContext(),
+		"SELECT name FROM fed.users WHERE id >= 2 AND LENGTH(name) > 3 LIMIT 5",
+	)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"carol"}, {"dave"}}, rows)
+
+	var table *Table
+	plan.Inspect(analyzed, func(n sql.Node) bool {
+		if rt, ok := n.(*plan.ResolvedTable); ok {
+			t := rt.Table
+			if w, ok := t.(sql.TableWrapper); ok {
+				t = w.Underlying()
+			}
+			table, _ = t.(*Table)
+		}
+		return true
+	})
+	require.NotNil(table)
+
+	// The filter with a function is not pushed down, so neither is the
+	// limit.
+	q, args := table.query()
+	require.Equal("SELECT `name`, `id` FROM `users` WHERE (`id` >= ?)", q)
+	require.Equal([]interface{}{int8(2)}, args)
+}
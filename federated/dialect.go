@@ -0,0 +1,305 @@
+package federated
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// Dialect is the dialect of SQL of a remote database, which determines how
+// the queries sent to it are generated.
+type Dialect int
+
+const (
+	// MySQL is the dialect of MySQL and compatible databases.
+	MySQL Dialect = iota
+	// Postgres is the dialect of PostgreSQL.
+	Postgres
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case MySQL:
+		return "MySQL"
+	case Postgres:
+		return "Postgres"
+	default:
+		return fmt.Sprintf("Dialect(%d)", int(d))
+	}
+}
+
+// quote returns the given identifier quoted.
+func (d Dialect) quote(name string) string {
+	if d == Postgres {
+		return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+	}
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// placeholder returns the placeholder of the argument in the given
+// position, starting at 1.
+func (d Dialect) placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// tablesQuery returns the query of the names of the tables of the remote
+// database.
+func (d Dialect) tablesQuery() string {
+	if d == Postgres {
+		return "SELECT table_name FROM information_schema.tables " +
+			"WHERE table_schema = current_schema() ORDER BY table_name"
+	}
+	return "SHOW TABLES"
+}
+
+// columnType returns the type of a column with the given type name in the
+// remote database, as returned by its driver, and the type the driver
+// scans its values to, which tells whether integers are unsigned.
+func columnType(name string, scanType reflect.Type) sql.Type {
+	var unsigned bool
+	if scanType != nil {
+		switch scanType.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+			unsigned = true
+		}
+	}
+
+	switch strings.ToUpper(name) {
+	case "TINYINT":
+		if unsigned {
+			return sql.Uint8
+		}
+		return sql.Int8
+	case "SMALLINT", "INT2":
+		if unsigned {
+			return sql.Uint16
+		}
+		return sql.Int16
+	case "MEDIUMINT", "INT", "INTEGER", "INT4":
+		if unsigned {
+			return sql.Uint32
+		}
+		return sql.Int32
+	case "BIGINT", "INT8":
+		if unsigned {
+			return sql.Uint64
+		}
+		return sql.Int64
+	case "FLOAT", "FLOAT4", "REAL":
+		return sql.Float32
+	case "DOUBLE", "FLOAT8", "DECIMAL", "NUMERIC":
+		return sql.Float64
+	case "BOOL", "BOOLEAN":
+		return sql.Boolean
+	case "DATE":
+		return sql.Date
+	case "DATETIME":
+		return sql.Datetime
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		return sql.Timestamp
+	case "JSON", "JSONB":
+		return sql.JSON
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BYTEA":
+		return sql.Blob
+	default:
+		return sql.Text
+	}
+}
+
+// queryWriter writes the query of the rows of a table in a dialect, with
+// the values of the literals as arguments.
+type queryWriter struct {
+	dialect Dialect
+	sb      strings.Builder
+	args    []interface{}
+}
+
+// selectQuery returns the query of the given columns of the rows of a
+// table matching all the filters, which must be supported, up to the given
+// number of rows if it's not zero. If a sample is given, only the rows of
+// the sample are queried, which are chosen by PostgreSQL with its own
+// TABLESAMPLE and by MySQL with RAND, as it cannot sample tables.
+func (d Dialect) selectQuery(
+	table string,
+	columns []string,
+	filters []sql.Expression,
+	limit uint64,
+	sample *sql.TableSample,
+) (string, []interface{}) {
+	w := &queryWriter{dialect: d}
+	w.sb.WriteString("SELECT ")
+	for i, c := range columns {
+		if i > 0 {
+			w.sb.WriteString(", ")
+		}
+		w.sb.WriteString(d.quote(c))
+	}
+
+	w.sb.WriteString(" FROM ")
+	w.sb.WriteString(d.quote(table))
+
+	var where bool
+	if sample != nil {
+		where = w.sample(sample)
+	}
+
+	for _, f := range filters {
+		if !where {
+			w.sb.WriteString(" WHERE ")
+			where = true
+		} else {
+			w.sb.WriteString(" AND ")
+		}
+		w.expression(f)
+	}
+
+	if limit > 0 {
+		w.sb.WriteString(" LIMIT ")
+		w.sb.WriteString(strconv.FormatUint(limit, 10))
+	}
+
+	return w.sb.String(), w.args
+}
+
+// sample writes the sample of the rows of the table after its name,
+// returning whether it wrote a WHERE clause.
+func (w *queryWriter) sample(sample *sql.TableSample) bool {
+	if w.dialect == Postgres {
+		w.sb.WriteString(" TABLESAMPLE " + string(sample.Method) + " (")
+		w.arg(sample.Percentage)
+		w.sb.WriteString(")")
+		if sample.Repeatable {
+			w.sb.WriteString(" REPEATABLE (")
+			w.arg(sample.Seed)
+			w.sb.WriteString(")")
+		}
+		return false
+	}
+
+	w.sb.WriteString(" WHERE RAND(")
+	if sample.Repeatable {
+		w.arg(sample.Seed)
+	}
+	w.sb.WriteString(") < ")
+	w.arg(sample.Percentage / 100)
+	return true
+}
+
+// arg writes the placeholder of the given argument of the query.
+func (w *queryWriter) arg(v interface{}) {
+	w.args = append(w.args, v)
+	w.sb.WriteString(w.dialect.placeholder(len(w.args)))
+}
+
+// supported returns whether the given expression can be written in a
+// query of the rows of the given table.
+func supported(table string, e sql.Expression) bool {
+	switch e := e.(type) {
+	case *expression.GetField:
+		return strings.EqualFold(e.Table(), table)
+	case *expression.Literal:
+		return true
+	case *expression.Equals, *expression.LessThan, *expression.LessThanOrEqual,
+		*expression.GreaterThan, *expression.GreaterThanOrEqual,
+		*expression.And, *expression.Or, *expression.Not, *expression.IsNull,
+		*expression.Between, *expression.Like:
+	case *expression.In, *expression.NotIn:
+		// Only lists of values are supported, not subqueries.
+		children := e.Children()
+		tuple, ok := children[1].(expression.Tuple)
+		if !ok || !supported(table, children[0]) {
+			return false
+		}
+
+		for _, child := range tuple {
+			if !supported(table, child) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+
+	for _, child := range e.Children() {
+		if !supported(table, child) {
+			return false
+		}
+	}
+	return true
+}
+
+// expression writes a supported expression.
+func (w *queryWriter) expression(e sql.Expression) {
+	switch e := e.(type) {
+	case *expression.GetField:
+		w.sb.WriteString(w.dialect.quote(e.Name()))
+	case *expression.Literal:
+		if e.Value() == nil {
+			w.sb.WriteString("NULL")
+			return
+		}
+
+		w.arg(e.Value())
+	case *expression.Equals:
+		w.binary(e.Left(), "=", e.Right())
+	case *expression.LessThan:
+		w.binary(e.Left(), "<", e.Right())
+	case *expression.LessThanOrEqual:
+		w.binary(e.Left(), "<=", e.Right())
+	case *expression.GreaterThan:
+		w.binary(e.Left(), ">", e.Right())
+	case *expression.GreaterThanOrEqual:
+		w.binary(e.Left(), ">=", e.Right())
+	case *expression.And:
+		w.binary(e.Left, "AND", e.Right)
+	case *expression.Or:
+		w.binary(e.Left, "OR", e.Right)
+	case *expression.Like:
+		w.binary(e.Left, "LIKE", e.Right)
+	case *expression.In:
+		w.binary(e.Left(), "IN", e.Right())
+	case *expression.NotIn:
+		w.binary(e.Left(), "NOT IN", e.Right())
+	case *expression.Not:
+		w.sb.WriteString("(NOT ")
+		w.expression(e.Child)
+		w.sb.WriteString(")")
+	case *expression.IsNull:
+		w.sb.WriteString("(")
+		w.expression(e.Child)
+		w.sb.WriteString(" IS NULL)")
+	case *expression.Between:
+		w.sb.WriteString("(")
+		w.expression(e.Val)
+		w.sb.WriteString(" BETWEEN ")
+		w.expression(e.Lower)
+		w.sb.WriteString(" AND ")
+		w.expression(e.Upper)
+		w.sb.WriteString(")")
+	case expression.Tuple:
+		w.sb.WriteString("(")
+		for i, child := range e {
+			if i > 0 {
+				w.sb.WriteString(", ")
+			}
+			w.expression(child)
+		}
+		w.sb.WriteString(")")
+	}
+}
+
+func (w *queryWriter) binary(left sql.Expression, op string, right sql.Expression) {
+	w.sb.WriteString("(")
+	w.expression(left)
+	w.sb.WriteString(" " + op + " ")
+	w.expression(right)
+	w.sb.WriteString(")")
+}
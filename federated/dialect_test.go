@@ -0,0 +1,146 @@
+package federated
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectQuery(t *testing.T) {
+	a := expression.NewGetFieldWithTable(0, sql.Int64, "t", "a", false)
+	b := expression.NewGetFieldWithTable(1, sql.Text, "t", "b`c", true)
+	lit := func(v interface{}, typ sql.Type) sql.Expression { return expression.NewLiteral(v, typ) }
+
+	filters := []sql.Expression{
+		expression.NewOr(
+			expression.NewGreaterThan(a, lit(int64(1), sql.Int64)),
+			expression.NewIsNull(b),
+		),
+		expression.NewIn(a, expression.NewTuple(lit(int64(2), sql.Int64), lit(int64(3), sql.Int64))),
+		expression.NewNot(expression.NewLike(b, lit("x%", sql.Text))),
+		expression.NewBetween(a, lit(int64(0), sql.Int64), lit(nil, sql.Null)),
+	}
+
+	testCases := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{
+			MySQL,
+			"SELECT `a`, `b``c` FROM `t` WHERE ((`a` > ?) OR (`b``c` IS NULL)) AND (`a` IN (?, ?)) " +
+				"AND (NOT (`b``c` LIKE ?)) AND (`a` BETWEEN ? AND NULL) LIMIT 10",
+		},
+		{
+			Postgres,
+			`SELECT "a", "b` + "`" + `c" FROM "t" WHERE (("a" > $1) OR ("b` + "`" + `c" IS NULL)) AND ("a" IN ($2, $3)) ` +
+				`AND (NOT ("b` + "`" + `c" LIKE $4)) AND ("a" BETWEEN $5 AND NULL) LIMIT 10`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.dialect.String(), func(t *testing.T) {
+			require := require.New(t)
+			q, args := tt.dialect.selectQuery("t", []string{"a", "b`c"}, filters, 10, nil)
+			require.Equal(tt.expected, q)
+			require.Equal([]interface{}{int64(1), int64(2), int64(3), "x%", int64(0)}, args)
+		})
+	}
+}
+
+func TestSelectQuerySample(t *testing.T) {
+	filters := []sql.Expression{
+		expression.NewGreaterThan(
+			expression.NewGetFieldWithTable(0, sql.Int64, "t", "a", false),
+			expression.NewLiteral(int64(1), sql.Int64),
+		),
+	}
+	bernoulli := sql.TableSample{Method: sql.BernoulliSample, Percentage: 10}
+	system := sql.TableSample{Method: sql.SystemSample, Percentage: 5}.WithSeed(42)
+
+	testCases := []struct {
+		dialect  Dialect
+		sample   sql.TableSample
+		expected string
+		args     []interface{}
+	}{
+		{
+			MySQL,
+			bernoulli,
+			"SELECT `a` FROM `t` WHERE RAND() < ? AND (`a` > ?) LIMIT 10",
+			[]interface{}{0.1, int64(1)},
+		},
+		{
+			MySQL,
+			system,
+			"SELECT `a` FROM `t` WHERE RAND(?) < ? AND (`a` > ?) LIMIT 10",
+			[]interface{}{int64(42), 0.05, int64(1)},
+		},
+		{
+			Postgres,
+			bernoulli,
+			`SELECT "a" FROM "t" TABLESAMPLE BERNOULLI ($1) WHERE ("a" > $2) LIMIT 10`,
+			[]interface{}{float64(10), int64(1)},
+		},
+		{
+			Postgres,
+			system,
+			`SELECT "a" FROM "t" TABLESAMPLE SYSTEM ($1) REPEATABLE ($2) WHERE ("a" > $3) LIMIT 10`,
+			[]interface{}{float64(5), int64(42), int64(1)},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expected, func(t *testing.T) {
+			require := require.New(t)
+			sample := tt.sample
+			q, args := tt.dialect.selectQuery("t", []string{"a"}, filters, 10, &sample)
+			require.Equal(tt.expected, q)
+			require.Equal(tt.args, args)
+		})
+	}
+}
+
+func TestHandledFilters(t *testing.T) {
+	require := require.New(t)
+	table := newTable(nil, "t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Text, Source: "t"},
+	})
+
+	a := expression.NewGetFieldWithTable(0, sql.Int64, "t", "a", false)
+	b := expression.NewGetFieldWithTable(1, sql.Text, "t", "b", false)
+	other := expression.NewGetFieldWithTable(2, sql.Int64, "u", "a", false)
+	one := expression.NewLiteral(int64(1), sql.Int64)
+
+	handled := []sql.Expression{
+		expression.NewEquals(a, one),
+		expression.NewAnd(expression.NewLessThanOrEqual(a, one), expression.NewGreaterThanOrEqual(one, a)),
+		expression.NewNotIn(b, expression.NewTuple(expression.NewLiteral("x", sql.Text))),
+	}
+	notHandled := []sql.Expression{
+		expression.NewEquals(a, other),
+		expression.NewEquals(expression.NewArithmetic(a, one, "+"), one),
+		expression.NewRegexp(b, expression.NewLiteral("x", sql.Text)),
+		expression.NewIn(a, one),
+	}
+
+	require.Equal(handled, table.HandledFilters(append(append([]sql.Expression(nil), handled...), notHandled...)))
+}
+
+func TestColumnType(t *testing.T) {
+	require := require.New(t)
+	unsigned := reflect.TypeOf(uint32(0))
+
+	require.Equal(sql.Int32, columnType("INT", nil))
+	require.Equal(sql.Uint32, columnType("INT", unsigned))
+	require.Equal(sql.Int64, columnType("int8", nil))
+	require.Equal(sql.Float64, columnType("DECIMAL", nil))
+	require.Equal(sql.Timestamp, columnType("TIMESTAMPTZ", nil))
+	require.Equal(sql.Blob, columnType("BYTEA", nil))
+	require.Equal(sql.JSON, columnType("JSONB", nil))
+	require.Equal(sql.Text, columnType("VARCHAR", nil))
+	require.Equal(sql.Text, columnType("UUID", nil))
+}
@@ -0,0 +1,262 @@
+package federated
+
+import (
+	dsql "database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Table is a table of a remote database. Its rows are read with a query
+// of the columns of the projection and the filters, limit and sample pushed
+// down to the table.
+type Table struct {
+	name   string
+	db     *Database
+	schema sql.Schema
+
+	projection []string
+	filters    []sql.Expression
+	limit      uint64
+	sample     *sql.TableSample
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.ProjectedTable = (*Table)(nil)
+var _ sql.FilteredTable = (*Table)(nil)
+var _ sql.LimitedTable = (*Table)(nil)
+var _ sql.SampledTable = (*Table)(nil)
+
+func newTable(db *Database, name string, schema sql.Schema) *Table {
+	return &Table{name: name, db: db, schema: schema}
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("FederatedTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the table are
+// in a single partition.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
+	q, args := t.query()
+	rows, err := t.db.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, ErrRemoteQuery.New(t.name, err)
+	}
+
+	return &tableIter{ctx: ctx, table: t.name, schema: t.schema, rows: rows}, nil
+}
+
+// query returns the query of the rows of the table and its arguments.
+func (t *Table) query() (string, []interface{}) {
+	columns := make([]string, len(t.schema))
+	for i, col := range t.schema {
+		columns[i] = col.Name
+	}
+	return t.db.dialect.selectQuery(t.name, columns, t.filters, t.limit, t.sample)
+}
+
+// HandledFilters implements the sql.FilteredTable interface. The filters
+// with only columns of the table and the expressions that can be written
+// in the queries of the remote database are handled.
+func (t *Table) HandledFilters(filters []sql.Expression) []sql.Expression {
+	var handled []sql.Expression
+	for _, f := range filters {
+		if supported(t.name, f) {
+			handled = append(handled, f)
+		}
+	}
+	return handled
+}
+
+// WithFilters implements the sql.FilteredTable interface.
+func (t *Table) WithFilters(filters []sql.Expression) sql.Table {
+	if len(filters) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.filters = filters
+	return &nt
+}
+
+// Filters implements the sql.FilteredTable interface.
+func (t *Table) Filters() []sql.Expression {
+	return t.filters
+}
+
+// WithProjection implements the sql.ProjectedTable interface.
+func (t *Table) WithProjection(colNames []string) sql.Table {
+	if len(colNames) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.schema = make(sql.Schema, len(colNames))
+	for i, name := range colNames {
+		idx := t.schema.IndexOf(name, t.name)
+		if idx < 0 {
+			return t
+		}
+		nt.schema[i] = t.schema[idx]
+	}
+	nt.projection = colNames
+
+	return &nt
+}
+
+// Projection implements the sql.ProjectedTable interface.
+func (t *Table) Projection() []string {
+	return t.projection
+}
+
+// WithLimit implements the sql.LimitedTable interface.
+func (t *Table) WithLimit(limit uint64) sql.Table {
+	if limit == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.limit = limit
+	return &nt
+}
+
+// Limit implements the sql.LimitedTable interface.
+func (t *Table) Limit() uint64 {
+	return t.limit
+}
+
+// WithSample implements the sql.SampledTable interface. The rows are
+// sampled by the remote database, so they're not sent.
+func (t *Table) WithSample(sample sql.TableSample) sql.Table {
+	nt := *t
+	nt.sample = &sample
+	return &nt
+}
+
+// Sample implements the sql.SampledTable interface.
+func (t *Table) Sample() *sql.TableSample {
+	return t.sample
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+type tableIter struct {
+	ctx    *sql.Context
+	table  string
+	schema sql.Schema
+	rows   *dsql.Rows
+}
+
+func (i *tableIter) Next() (sql.Row, error) {
+	if !i.rows.Next() {
+		if err := i.rows.Err(); err != nil {
+			return nil, ErrRemoteQuery.New(i.table, err)
+		}
+		return nil, io.EOF
+	}
+
+	values := make([]interface{}, len(i.schema))
+	dest := make([]interface{}, len(values))
+	for j := range values {
+		dest[j] = &values[j]
+	}
+
+	if err := i.rows.Scan(dest...); err != nil {
+		return nil, ErrRemoteQuery.New(i.table, err)
+	}
+
+	row := make(sql.Row, len(values))
+	for j, v := range values {
+		var err error
+		if row[j], err = convert(i.schema[j].Type, v); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+// Close closes the rows of the query. The context of the query is
+// cancelled when the engine is done with it, which may be before all the
+// rows are read, as with limits, and the error of the rows being closed
+// because of it is ignored.
+func (i *tableIter) Close() error {
+	if err := i.rows.Close(); err != nil && i.ctx.Err() == nil {
+		return ErrRemoteQuery.New(i.table, err)
+	}
+	return nil
+}
+
+// convert converts a value scanned from the remote database to the type
+// of its column. Drivers return most values as bytes, which are the text
+// of the values unless the column is a blob.
+func convert(typ sql.Type, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if b, ok := v.([]byte); ok {
+		if typ == sql.Blob {
+			return append([]byte(nil), b...), nil
+		}
+		v = string(b)
+	}
+
+	// JSON values are kept decoded, as the JSON functions expect them.
+	if typ == sql.JSON {
+		if s, ok := v.(string); ok {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(s), &doc); err != nil {
+				return nil, sql.ErrInvalidType.New(s)
+			}
+			return doc, nil
+		}
+		return v, nil
+	}
+
+	return typ.Convert(v)
+}
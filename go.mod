@@ -8,12 +8,13 @@ require (
 	github.com/go-sql-driver/mysql v1.4.1
 	github.com/gogo/protobuf v1.2.1 // indirect
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
-	github.com/golang/protobuf v1.3.0 // indirect
+	github.com/golang/protobuf v1.3.0
 	github.com/hashicorp/golang-lru v0.5.3
 	github.com/mitchellh/hashstructure v1.0.0
 	github.com/oliveagle/jsonpath v0.0.0-20180606110733-2e52cf6e6852
 	github.com/opentracing/opentracing-go v1.0.2
 	github.com/pilosa/pilosa v1.3.0
+	github.com/prometheus/client_golang v0.9.2
 	github.com/sanity-io/litter v1.1.0
 	github.com/shirou/w32 v0.0.0-20160930032740-bb4de0191aa4 // indirect
 	github.com/sirupsen/logrus v1.3.0
@@ -21,7 +22,7 @@ require (
 	github.com/src-d/go-oniguruma v1.0.0
 	github.com/stretchr/testify v1.3.0
 	go.etcd.io/bbolt v1.3.2
-	google.golang.org/grpc v1.19.0 // indirect
+	google.golang.org/grpc v1.19.0
 	gopkg.in/src-d/go-errors.v1 v1.0.0
 	gopkg.in/yaml.v2 v2.2.2
 	vitess.io/vitess v3.0.0-rc.3.0.20190602171040-12bfde34629c+incompatible
@@ -0,0 +1,37 @@
+package sqle
+
+import (
+	"reflect"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// QueryHandler runs a parsed statement, returning the plan it runs and its
+// rows.
+type QueryHandler func(ctx *sql.Context, query string, parsed sql.Node) (sql.Node, sql.RowIter, error)
+
+// Interceptor wraps the execution of the statements of an engine, so
+// integrations can add features such as row filters per tenant, rewriting
+// queries or sending shadow traffic without forking it. It's called with
+// every parsed statement, whose session is the one of the context, and
+// next, which authorizes, analyzes and runs it. The interceptor can:
+//
+//   - Observe the statement, its plan and its rows, wrapping the row
+//     iterator returned by next.
+//   - Deny the statement, returning an error without calling next.
+//   - Rewrite the statement, calling next with a different node. The node
+//     given to next is the one authorized, and the plans of rewritten
+//     statements are not cached.
+type Interceptor func(
+	ctx *sql.Context,
+	query string,
+	parsed sql.Node,
+	next QueryHandler,
+) (sql.Node, sql.RowIter, error)
+
+// sameNode reports whether both nodes are the same one. Nodes of types that
+// cannot be compared are never the same.
+func sameNode(a, b sql.Node) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
@@ -237,13 +237,21 @@ func tcpSocks(accept AcceptFn) ([]sockTabEntry, error) {
 
 // GetConnInode returns the Linux inode number of a TCP connection
 func GetConnInode(c *net.TCPConn) (n uint64, err error) {
-	f, err := c.File()
+	// The descriptor is not taken with File, as it would make the socket
+	// blocking, so closing the connection would wait for its reads.
+	raw, err := c.SyscallConn()
 	if err != nil {
 		return
 	}
 
-	socketStr := fmt.Sprintf("/proc/%d/fd/%d", os.Getpid(), f.Fd())
-	socketLnk, err := os.Readlink(socketStr)
+	var socketLnk string
+	cerr := raw.Control(func(fd uintptr) {
+		socketStr := fmt.Sprintf("/proc/%d/fd/%d", os.Getpid(), fd)
+		socketLnk, err = os.Readlink(socketStr)
+	})
+	if cerr != nil {
+		return 0, cerr
+	}
 	if err != nil {
 		return
 	}
@@ -0,0 +1,275 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker is a cluster of a single broker that keeps the record batches
+// produced in memory, with the requests of the versions used by the
+// client.
+type fakeBroker struct {
+	t  *testing.T
+	l  net.Listener
+	mu sync.Mutex
+	// logs are the batches of the partitions of the topics, and starts
+	// the first offset of the partitions.
+	logs   map[string]map[int32][][]byte
+	starts map[string]map[int32]int64
+	// fetches are the offsets of the fetch requests by partition.
+	fetches map[int32][]int64
+	// notLeader makes the next request of messages fail as if the leader
+	// of its partition changed.
+	notLeader bool
+}
+
+func newFakeBroker(t *testing.T, topics map[string]int) *fakeBroker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &fakeBroker{
+		t:       t,
+		l:       l,
+		logs:    make(map[string]map[int32][][]byte),
+		starts:  make(map[string]map[int32]int64),
+		fetches: make(map[int32][]int64),
+	}
+	for topic, partitions := range topics {
+		b.logs[topic] = make(map[int32][][]byte)
+		b.starts[topic] = make(map[int32]int64)
+		for p := 0; p < partitions; p++ {
+			b.logs[topic][int32(p)] = nil
+		}
+	}
+
+	go b.serve()
+	t.Cleanup(func() { l.Close() })
+	return b
+}
+
+func (b *fakeBroker) addr() string { return b.l.Addr().String() }
+
+func (b *fakeBroker) serve() {
+	for {
+		c, err := b.l.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(c)
+	}
+}
+
+func (b *fakeBroker) handle(c net.Conn) {
+	defer c.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(c, req); err != nil {
+			return
+		}
+
+		d := &decoder{b: req}
+		api, version, correlation := d.int16(), d.int16(), d.int32()
+		d.string()
+		if version != apiVersions[api] {
+			return
+		}
+
+		var resp encoder
+		resp.int32(0)
+		resp.int32(correlation)
+
+		b.mu.Lock()
+		switch api {
+		case apiMetadata:
+			b.metadata(&resp)
+		case apiListOffsets:
+			b.listOffsets(d, &resp)
+		case apiFetch:
+			b.fetch(d, &resp)
+		case apiProduce:
+			b.produce(d, &resp)
+		}
+		b.mu.Unlock()
+
+		out := resp.buf.Bytes()
+		binary.BigEndian.PutUint32(out, uint32(len(out)-4))
+		if _, err := c.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) metadata(resp *encoder) {
+	host, port, _ := net.SplitHostPort(b.addr())
+	p, _ := strconv.Atoi(port)
+
+	resp.int32(1)
+	resp.int32(7)
+	resp.string(host)
+	resp.int32(int32(p))
+	resp.int16(-1)
+	resp.int32(7)
+
+	resp.int32(int32(len(b.logs) + 1))
+	for topic, partitions := range b.logs {
+		resp.int16(0)
+		resp.string(topic)
+		resp.int8(0)
+		resp.int32(int32(len(partitions)))
+		for p := range partitions {
+			resp.int16(0)
+			resp.int32(p)
+			resp.int32(7)
+			resp.int32(1)
+			resp.int32(7)
+			resp.int32(1)
+			resp.int32(7)
+		}
+	}
+
+	resp.int16(0)
+	resp.string("__consumer_offsets")
+	resp.int8(1)
+	resp.int32(0)
+}
+
+// partitionRequest reads the topic and partition of a request with a
+// single partition.
+func partitionRequest(d *decoder) (string, int32) {
+	d.int32()
+	topic := d.string()
+	d.int32()
+	return topic, d.int32()
+}
+
+func (b *fakeBroker) nextOffset(topic string, partition int32) int64 {
+	next := b.starts[topic][partition]
+	for _, batch := range b.logs[topic][partition] {
+		next = batchOffset(batch) + int64(binary.BigEndian.Uint32(batch[23:])) + 1
+	}
+	return next
+}
+
+func batchOffset(batch []byte) int64 {
+	return int64(binary.BigEndian.Uint64(batch))
+}
+
+func (b *fakeBroker) listOffsets(d *decoder, resp *encoder) {
+	d.int32()
+	topic, partition := partitionRequest(d)
+	timestamp := d.int64()
+
+	offset := int64(-1)
+	switch timestamp {
+	case earliestOffset:
+		offset = b.starts[topic][partition]
+	case latestOffset:
+		offset = b.nextOffset(topic, partition)
+	default:
+		for _, batch := range b.logs[topic][partition] {
+			messages, _, err := decodeRecordBatches(partition, batch)
+			require.NoError(b.t, err)
+			for _, m := range messages {
+				if offset < 0 && millis(m.Timestamp) >= timestamp {
+					offset = m.Offset
+				}
+			}
+		}
+	}
+
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(1)
+	resp.int32(partition)
+	resp.int16(0)
+	resp.int64(-1)
+	resp.int64(offset)
+}
+
+func (b *fakeBroker) errorCode(topic string, partition int32) int16 {
+	if _, ok := b.logs[topic][partition]; !ok {
+		return errUnknownTopicOrPartition
+	}
+
+	if b.notLeader {
+		b.notLeader = false
+		return errNotLeaderForPartition
+	}
+	return 0
+}
+
+// fetch returns the batches of the partition with messages from the offset
+// requested, followed by the beginning of another batch, as brokers may
+// return partial batches.
+func (b *fakeBroker) fetch(d *decoder, resp *encoder) {
+	d.int32()
+	d.int32()
+	d.int32()
+	d.int32()
+	d.int8()
+	topic, partition := partitionRequest(d)
+	offset := d.int64()
+	b.fetches[partition] = append(b.fetches[partition], offset)
+
+	code := b.errorCode(topic, partition)
+	var records []byte
+	if code == 0 {
+		records = []byte{}
+		for _, batch := range b.logs[topic][partition] {
+			last := batchOffset(batch) + int64(binary.BigEndian.Uint32(batch[23:]))
+			if last >= offset && len(records) == 0 {
+				records = append(records, batch...)
+			}
+		}
+		if len(records) > 0 {
+			records = append(records, 0, 0, 0, 0, 0, 0, 0, 9, 0, 0, 1)
+		}
+	}
+
+	resp.int32(0)
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(1)
+	resp.int32(partition)
+	resp.int16(code)
+	resp.int64(b.nextOffset(topic, partition))
+	resp.int64(b.nextOffset(topic, partition))
+	resp.int32(-1)
+	resp.bytes(records)
+}
+
+func (b *fakeBroker) produce(d *decoder, resp *encoder) {
+	d.string()
+	d.int16()
+	d.int32()
+	topic, partition := partitionRequest(d)
+	batch := append([]byte(nil), d.bytes()...)
+
+	code := b.errorCode(topic, partition)
+	offset := int64(-1)
+	if code == 0 {
+		offset = b.nextOffset(topic, partition)
+		binary.BigEndian.PutUint64(batch, uint64(offset))
+		b.logs[topic][partition] = append(b.logs[topic][partition], batch)
+	}
+
+	resp.int32(1)
+	resp.string(topic)
+	resp.int32(1)
+	resp.int32(partition)
+	resp.int16(code)
+	resp.int64(offset)
+	resp.int64(-1)
+	resp.int32(0)
+}
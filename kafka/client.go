@@ -0,0 +1,485 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrBroker is returned when a request to a broker fails.
+var ErrBroker = errors.NewKind("request to broker %s failed: %s")
+
+// ErrPartition is returned when a broker returns an error for a partition
+// of a topic.
+var ErrPartition = errors.NewKind("partition %d of topic %s: %s")
+
+// ErrUnsupportedFormat is returned when the messages of a topic cannot be
+// read.
+var ErrUnsupportedFormat = errors.NewKind("unable to read messages: %s")
+
+// Error codes of the partitions that are retried after reading the
+// metadata of the cluster again.
+const (
+	errUnknownTopicOrPartition int16 = 3
+	errLeaderNotAvailable      int16 = 5
+	errNotLeaderForPartition   int16 = 6
+)
+
+// errorNames are the names of the most common error codes.
+var errorNames = map[int16]string{
+	1:  "OFFSET_OUT_OF_RANGE",
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_FOR_PARTITION",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	19: "NOT_ENOUGH_REPLICAS",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+}
+
+func errorName(code int16) string {
+	if name, ok := errorNames[code]; ok {
+		return name
+	}
+	return "error " + strconv.Itoa(int(code))
+}
+
+// Config of the connection to a Kafka cluster.
+type Config struct {
+	// Brokers are the addresses of the brokers used to discover the
+	// brokers of the cluster.
+	Brokers []string
+	// ClientID identifies the requests in the logs of the brokers. It's
+	// go-mysql-server if it's empty.
+	ClientID string
+	// Timeout of the connections and of the requests. It's 10 seconds if
+	// it's zero.
+	Timeout time.Duration
+}
+
+// client sends requests to the brokers of a cluster, to the leaders of the
+// partitions for the requests of their messages.
+type client struct {
+	config Config
+
+	mu      sync.Mutex
+	brokers map[int32]string
+	// leaders are the brokers of the leaders of the partitions by topic.
+	leaders map[string]map[int32]int32
+	conns   map[string]*conn
+}
+
+func newClient(config Config) *client {
+	if config.ClientID == "" {
+		config.ClientID = "go-mysql-server"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &client{
+		config:  config,
+		brokers: make(map[int32]string),
+		leaders: make(map[string]map[int32]int32),
+		conns:   make(map[string]*conn),
+	}
+}
+
+// conn is a connection to a broker, which sends one request at a time.
+type conn struct {
+	mu          sync.Mutex
+	c           net.Conn
+	correlation int32
+}
+
+func (c *client) conn(addr string) (*conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cn, ok := c.conns[addr]; ok {
+		return cn, nil
+	}
+
+	nc, err := net.DialTimeout("tcp", addr, c.config.Timeout)
+	if err != nil {
+		return nil, ErrBroker.New(addr, err)
+	}
+
+	cn := &conn{c: nc}
+	c.conns[addr] = cn
+	return cn, nil
+}
+
+// request sends a request to the broker with the given address and returns
+// the body of its response. Connections are closed after failed requests.
+func (c *client) request(addr string, api int16, body []byte) (*decoder, error) {
+	cn, err := c.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+
+	cn.correlation++
+	var req encoder
+	req.int32(0)
+	req.int16(api)
+	req.int16(apiVersions[api])
+	req.int32(cn.correlation)
+	req.string(c.config.ClientID)
+	req.buf.Write(body)
+
+	b := req.buf.Bytes()
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+
+	resp, err := cn.roundTrip(b, c.config.Timeout)
+	if err != nil {
+		c.closeConn(addr, cn)
+		return nil, ErrBroker.New(addr, err)
+	}
+
+	d := &decoder{b: resp}
+	if id := d.int32(); id != cn.correlation {
+		c.closeConn(addr, cn)
+		return nil, ErrBroker.New(addr, fmt.Sprintf("unexpected correlation id %d", id))
+	}
+	return d, nil
+}
+
+func (cn *conn) roundTrip(req []byte, timeout time.Duration) ([]byte, error) {
+	if err := cn.c.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err := cn.c.Write(req); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(cn.c, size[:]); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(cn.c, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *client) closeConn(addr string, cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conns[addr] == cn {
+		delete(c.conns, addr)
+	}
+	_ = cn.c.Close()
+}
+
+// close closes all the connections.
+func (c *client) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	for addr, cn := range c.conns {
+		if e := cn.c.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(c.conns, addr)
+	}
+	return err
+}
+
+// topicMetadata are the partitions of a topic.
+type topicMetadata struct {
+	name       string
+	internal   bool
+	partitions []int32
+}
+
+// metadata reads the metadata of the given topics, or of all of them if
+// topics is nil, from the first broker of the config that answers, and
+// keeps the leaders of their partitions.
+func (c *client) metadata(topics []string) ([]topicMetadata, error) {
+	var req encoder
+	if topics == nil {
+		req.int32(-1)
+	} else {
+		req.int32(int32(len(topics)))
+		for _, t := range topics {
+			req.string(t)
+		}
+	}
+
+	var d *decoder
+	var err error
+	for _, addr := range c.bootstrap() {
+		if d, err = c.request(addr, apiMetadata, req.buf.Bytes()); err == nil {
+			break
+		}
+	}
+
+	if d == nil {
+		if err == nil {
+			err = ErrBroker.New("", "no brokers")
+		}
+		return nil, err
+	}
+
+	brokers := make(map[int32]string)
+	for n := d.arrayLen(); n > 0; n-- {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string()
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.int32()
+
+	var result []topicMetadata
+	leaders := make(map[string]map[int32]int32)
+	for n := d.arrayLen(); n > 0; n-- {
+		code := d.int16()
+		t := topicMetadata{name: d.string(), internal: d.int8() != 0}
+		leaders[t.name] = make(map[int32]int32)
+		for p := d.arrayLen(); p > 0; p-- {
+			d.int16()
+			partition := d.int32()
+			leaders[t.name][partition] = d.int32()
+			for r := d.arrayLen(); r > 0; r-- {
+				d.int32()
+			}
+			for r := d.arrayLen(); r > 0; r-- {
+				d.int32()
+			}
+			t.partitions = append(t.partitions, partition)
+		}
+
+		if code != 0 {
+			return nil, ErrPartition.New(-1, t.name, errorName(code))
+		}
+		result = append(result, t)
+	}
+
+	if d.err != nil {
+		return nil, ErrBroker.New("", d.err)
+	}
+
+	c.mu.Lock()
+	for id, addr := range brokers {
+		c.brokers[id] = addr
+	}
+	for t, l := range leaders {
+		c.leaders[t] = l
+	}
+	c.mu.Unlock()
+
+	return result, nil
+}
+
+// bootstrap returns the addresses of the brokers of the config followed by
+// the ones of the cluster.
+func (c *client) bootstrap() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addrs := append([]string(nil), c.config.Brokers...)
+	for _, addr := range c.brokers {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// leader returns the address of the leader of a partition.
+func (c *client) leader(topic string, partition int32) (string, error) {
+	c.mu.Lock()
+	id, ok := c.leaders[topic][partition]
+	addr := c.brokers[id]
+	c.mu.Unlock()
+
+	if !ok || addr == "" {
+		return "", ErrPartition.New(partition, topic, errorName(errLeaderNotAvailable))
+	}
+	return addr, nil
+}
+
+// withLeader calls fn with the address of the leader of a partition, and
+// calls it again after reading the metadata of the topic if the leader
+// changed.
+func (c *client) withLeader(topic string, partition int32, fn func(addr string) (int16, error)) error {
+	var code int16
+	for attempt := 0; attempt < 3; attempt++ {
+		addr, err := c.leader(topic, partition)
+		if err == nil {
+			if code, err = fn(addr); err != nil {
+				return err
+			}
+
+			switch code {
+			case 0:
+				return nil
+			case errUnknownTopicOrPartition, errLeaderNotAvailable, errNotLeaderForPartition:
+			default:
+				return ErrPartition.New(partition, topic, errorName(code))
+			}
+		}
+
+		if _, err := c.metadata([]string{topic}); err != nil {
+			return err
+		}
+	}
+
+	return ErrPartition.New(partition, topic, errorName(code))
+}
+
+// offset returns the first offset of a partition of a message with a
+// timestamp greater than or equal to the given one, in milliseconds, or
+// the earliest or latest offset with the earliestOffset and latestOffset
+// timestamps. The latest offset is the one following the last message,
+// which is also returned if no messages have such timestamp.
+func (c *client) offset(topic string, partition int32, timestamp int64) (int64, error) {
+	var offset int64
+	err := c.withLeader(topic, partition, func(addr string) (int16, error) {
+		var req encoder
+		req.int32(-1)
+		req.int32(1)
+		req.string(topic)
+		req.int32(1)
+		req.int32(partition)
+		req.int64(timestamp)
+
+		d, err := c.request(addr, apiListOffsets, req.buf.Bytes())
+		if err != nil {
+			return 0, err
+		}
+
+		var code int16 = errUnknownTopicOrPartition
+		for n := d.arrayLen(); n > 0; n-- {
+			d.string()
+			for p := d.arrayLen(); p > 0; p-- {
+				d.int32()
+				code = d.int16()
+				d.int64()
+				offset = d.int64()
+			}
+		}
+
+		if d.err != nil {
+			return 0, ErrBroker.New(addr, d.err)
+		}
+		return code, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if offset < 0 && timestamp >= 0 {
+		return c.offset(topic, partition, latestOffset)
+	}
+	return offset, nil
+}
+
+// fetch returns the messages of a partition from the given offset, and
+// the offset following the last batch of messages returned.
+func (c *client) fetch(topic string, partition int32, offset int64) ([]Message, int64, error) {
+	var messages []Message
+	next := int64(-1)
+	err := c.withLeader(topic, partition, func(addr string) (int16, error) {
+		var req encoder
+		req.int32(-1)
+		req.int32(0)
+		req.int32(1)
+		req.int32(16 << 20)
+		req.int8(0)
+		req.int32(1)
+		req.string(topic)
+		req.int32(1)
+		req.int32(partition)
+		req.int64(offset)
+		req.int32(1 << 20)
+
+		d, err := c.request(addr, apiFetch, req.buf.Bytes())
+		if err != nil {
+			return 0, err
+		}
+
+		d.int32()
+		var code int16 = errUnknownTopicOrPartition
+		var records []byte
+		for n := d.arrayLen(); n > 0; n-- {
+			d.string()
+			for p := d.arrayLen(); p > 0; p-- {
+				d.int32()
+				code = d.int16()
+				d.int64()
+				d.int64()
+				for a := d.arrayLen(); a > 0; a-- {
+					d.int64()
+					d.int64()
+				}
+				records = d.bytes()
+			}
+		}
+
+		if d.err != nil {
+			return 0, ErrBroker.New(addr, d.err)
+		}
+
+		if code == 0 {
+			messages, next, err = decodeRecordBatches(partition, records)
+		}
+		return code, err
+	})
+	return messages, next, err
+}
+
+// produce appends messages to a partition of a topic, once they are
+// written to all its replicas in sync, and returns the offset of the first
+// one.
+func (c *client) produce(topic string, partition int32, messages []Message) (int64, error) {
+	batch := encodeRecordBatch(messages)
+	var offset int64
+	err := c.withLeader(topic, partition, func(addr string) (int16, error) {
+		var req encoder
+		req.int16(-1)
+		req.int16(-1)
+		req.int32(int32(c.config.Timeout / time.Millisecond))
+		req.int32(1)
+		req.string(topic)
+		req.int32(1)
+		req.int32(partition)
+		req.bytes(batch)
+
+		d, err := c.request(addr, apiProduce, req.buf.Bytes())
+		if err != nil {
+			return 0, err
+		}
+
+		var code int16 = errUnknownTopicOrPartition
+		for n := d.arrayLen(); n > 0; n-- {
+			d.string()
+			for p := d.arrayLen(); p > 0; p-- {
+				d.int32()
+				code = d.int16()
+				offset = d.int64()
+				d.int64()
+			}
+		}
+
+		if d.err != nil {
+			return 0, ErrBroker.New(addr, d.err)
+		}
+		return code, nil
+	})
+	return offset, err
+}
@@ -0,0 +1,63 @@
+// Package kafka implements a database whose tables are the topics of a
+// Kafka cluster, which are read and appended with queries.
+package kafka
+
+import (
+	"sort"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Database is a database with a table for every topic of a Kafka cluster.
+// Reading a table reads the messages of its topic that were written to all
+// the replicas in sync of their partitions when the query started, so
+// queries of topics still being written are bounded, and inserting rows in
+// it produces messages.
+type Database struct {
+	name   string
+	client *client
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the given topics of the cluster of
+// the config, or with all the topics but the internal ones if topics is
+// nil. The topics and their partitions are read when the database is
+// created. The database must be closed once it's not used.
+func NewDatabase(name string, config Config, topics []string) (*Database, error) {
+	c := newClient(config)
+	metadata, err := c.metadata(topics)
+	if err != nil {
+		_ = c.close()
+		return nil, err
+	}
+
+	d := &Database{name: name, client: c, tables: make(map[string]sql.Table)}
+	for _, m := range metadata {
+		if m.internal && topics == nil {
+			continue
+		}
+
+		partitions := append([]int32(nil), m.partitions...)
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		d.tables[m.name] = newTable(c, m.name, partitions)
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
+
+// Close closes the connections to the brokers.
+func (d *Database) Close() error {
+	return d.client.close()
+}
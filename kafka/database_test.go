@@ -0,0 +1,174 @@
+package kafka
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDatabase(t *testing.T, b *fakeBroker) *Database {
+	db, err := NewDatabase("topics", Config{Brokers: []string{b.addr()}}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testQuery(t *testing.T, e *sqle.Engine, query string, expected []sql.Row) {
+	t.Helper()
+	require := require.New(t)
+
+	_, iter, err := e.Query(sql.NewEmptyContext(), query)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, rows)
+}
+
+func TestDatabase(t *testing.T) {
+	require := require.New(t)
+	b := newFakeBroker(t, map[string]int{"events": 3, "logs": 1})
+	db := newTestDatabase(t, b)
+	require.Equal("topics", db.Name())
+
+	var names []string
+	for name := range db.Tables() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal([]string{"events", "logs"}, names)
+
+	users := memory.NewTable("users", sql.Schema{
+		{Name: "id", Type: sql.Text, Source: "users"},
+		{Name: "name", Type: sql.Text, Source: "users"},
+	})
+	ctx := sql.NewEmptyContext()
+	require.NoError(users.Insert(ctx, sql.NewRow("u1", "alice")))
+	require.NoError(users.Insert(ctx, sql.NewRow("u2", "bob")))
+	local := memory.NewDatabase("local")
+	local.AddTable("users", users)
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+	e.AddDatabase(local)
+
+	testQuery(t, e, "INSERT INTO topics.events (`partition`, `timestamp`, `key`, `value`) VALUES "+
+		`(0, '2019-01-01 00:00:00', 'u1', '{"kind": "login"}'),`+
+		`(0, '2019-01-01 00:01:00', 'u2', '{"kind": "login"}'),`+
+		`(0, '2019-01-01 00:02:00', 'u1', '{"kind": "logout"}'),`+
+		`(1, '2019-01-02 00:00:00', 'u2', '{"kind": "logout"}')`,
+		[]sql.Row{{int64(4)}},
+	)
+
+	// The partition of messages with a key is the one of the Java client,
+	// and the messages without key or partition are spread over all the
+	// partitions.
+	b.mu.Lock()
+	b.notLeader = true
+	b.mu.Unlock()
+	testQuery(t, e, "INSERT INTO topics.events (`key`, `value`) VALUES ('foobar', 'x')", []sql.Row{{int64(1)}})
+	testQuery(t, e, "INSERT INTO topics.logs (`value`) VALUES ('a'), ('b'), (NULL)", []sql.Row{{int64(3)}})
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{
+			"SELECT `partition`, `offset`, `key` FROM topics.events WHERE `value` = 'x'",
+			[]sql.Row{{int32(0), int64(3), "foobar"}},
+		},
+		{
+			"SELECT `offset`, `value` FROM topics.logs",
+			[]sql.Row{{int64(0), "a"}, {int64(1), "b"}, {int64(2), nil}},
+		},
+		{
+			"SELECT `partition`, `offset` FROM topics.events WHERE `partition` = 0 AND `offset` >= 1 AND `offset` < 3 ORDER BY `offset`",
+			[]sql.Row{{int32(0), int64(1)}, {int32(0), int64(2)}},
+		},
+		{
+			"SELECT `timestamp` FROM topics.events WHERE `timestamp` > '2019-01-01 00:00:30' AND `timestamp` < '2020-01-01' AND `partition` < 2 ORDER BY 1",
+			[]sql.Row{
+				{time.Date(2019, 1, 1, 0, 1, 0, 0, time.UTC)},
+				{time.Date(2019, 1, 1, 0, 2, 0, 0, time.UTC)},
+				{time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			"SELECT u.name, COUNT(*) FROM topics.events e INNER JOIN local.users u ON e.`key` = u.id " +
+				"WHERE JSON_EXTRACT(e.`value`, '$.kind') = 'login' GROUP BY u.name ORDER BY u.name",
+			[]sql.Row{{"alice", int64(1)}, {"bob", int64(1)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			testQuery(t, e, tt.query, tt.expected)
+		})
+	}
+}
+
+func TestTableBounds(t *testing.T) {
+	require := require.New(t)
+	b := newFakeBroker(t, map[string]int{"events": 2})
+	db := newTestDatabase(t, b)
+	table := db.Tables()["events"].(*Table)
+
+	ctx := sql.NewEmptyContext()
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		require.NoError(table.Insert(ctx, sql.NewRow(int32(1), nil, start.Add(time.Duration(i)*time.Hour), nil, "v")))
+	}
+
+	field := func(idx int) sql.Expression {
+		col := table.Schema()[idx]
+		return expression.NewGetFieldWithTable(idx, col.Type, "events", col.Name, true)
+	}
+	lit := func(v interface{}, typ sql.Type) sql.Expression { return expression.NewLiteral(v, typ) }
+
+	filtered := table.WithFilters([]sql.Expression{
+		expression.NewAnd(
+			expression.NewLessThan(lit(int64(0), sql.Int64), field(partitionColumn)),
+			expression.NewBetween(field(offsetColumn), lit(int64(2), sql.Int64), lit(int64(8), sql.Int64)),
+		),
+		expression.NewGreaterThanOrEqual(field(timestampColumn), lit("2019-01-01 04:00:00", sql.Text)),
+		expression.NewNot(expression.NewEquals(field(offsetColumn), lit(int64(6), sql.Int64))),
+	}).(*Table)
+
+	bounds := filtered.bounds()
+	require.Equal(offsetRange{1, 1<<31 - 1}, bounds.partition)
+	require.Equal(offsetRange{2, 8}, bounds.offset)
+	require.Equal(millis(start.Add(4*time.Hour)), bounds.timestamp)
+
+	partitions, err := filtered.Partitions(ctx)
+	require.NoError(err)
+	p, err := partitions.Next()
+	require.NoError(err)
+	require.Equal("1", string(p.Key()))
+	_, err = partitions.Next()
+	require.Error(err)
+
+	iter, err := filtered.PartitionRows(ctx, p)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	var offsets []int64
+	for _, row := range rows {
+		offsets = append(offsets, row[offsetColumn].(int64))
+	}
+	require.Equal([]int64{4, 5, 7, 8}, offsets)
+
+	// Every batch has a single message, so there is a request for every
+	// message from the first one with the timestamp.
+	b.mu.Lock()
+	require.Equal([]int64{4, 5, 6, 7, 8}, b.fetches[1])
+	require.Empty(b.fetches[0])
+	b.mu.Unlock()
+
+	require.True(ErrPartition.Is(table.Insert(ctx, sql.NewRow(int32(5), nil, nil, nil, "v"))))
+}
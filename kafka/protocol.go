@@ -0,0 +1,407 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"time"
+)
+
+// Keys of the APIs of the requests, which are all sent with the versions
+// below.
+const (
+	apiProduce     int16 = 0
+	apiFetch       int16 = 1
+	apiListOffsets int16 = 2
+	apiMetadata    int16 = 3
+)
+
+var apiVersions = map[int16]int16{
+	apiProduce:     3,
+	apiFetch:       4,
+	apiListOffsets: 1,
+	apiMetadata:    1,
+}
+
+// Timestamps of the ListOffsets requests of the latest and earliest
+// offsets of a partition.
+const (
+	latestOffset   int64 = -1
+	earliestOffset int64 = -2
+)
+
+// Attributes of the record batches.
+const (
+	compressionMask  = 0x07
+	compressionNone  = 0
+	compressionGzip  = 1
+	transactionalBit = 0x10
+	controlBit       = 0x20
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// encoder writes the fields of requests and record batches.
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) int8(v int8) { e.buf.WriteByte(byte(v)) }
+
+func (e *encoder) int16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.buf.Write(b[:])
+}
+
+func (e *encoder) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	e.buf.Write(b[:n])
+}
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) bytes(b []byte) {
+	if b == nil {
+		e.int32(-1)
+		return
+	}
+	e.int32(int32(len(b)))
+	e.buf.Write(b)
+}
+
+// varbytes writes bytes with their length as a varint, as in the records.
+func (e *encoder) varbytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.buf.Write(b)
+}
+
+// decoder reads the fields of responses and record batches. The first
+// error found is kept and the values read after it are zero.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = fmt.Errorf("unexpected end of data")
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (d *decoder) int16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *decoder) int32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) int64() int64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = fmt.Errorf("invalid varint")
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.next(int(n)))
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+func (d *decoder) varbytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+// arrayLen reads the length of an array, which is 0 for null arrays.
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 || int(n) > len(d.b) {
+		return 0
+	}
+	return int(n)
+}
+
+// Message is a message of a partition of a topic.
+type Message struct {
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Key       []byte
+	Value     []byte
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+// encodeRecordBatch encodes the messages in an uncompressed record batch
+// of version 2 whose offsets start at 0.
+func encodeRecordBatch(messages []Message) []byte {
+	first, max := millis(messages[0].Timestamp), millis(messages[0].Timestamp)
+	for _, m := range messages {
+		ts := millis(m.Timestamp)
+		if ts < first {
+			first = ts
+		}
+		if ts > max {
+			max = ts
+		}
+	}
+
+	var records encoder
+	for i, m := range messages {
+		var r encoder
+		r.int8(0)
+		r.varint(millis(m.Timestamp) - first)
+		r.varint(int64(i))
+		r.varbytes(m.Key)
+		r.varbytes(m.Value)
+		r.varint(0)
+
+		records.varint(int64(r.buf.Len()))
+		records.buf.Write(r.buf.Bytes())
+	}
+
+	// The CRC covers the batch from its attributes.
+	var body encoder
+	body.int16(compressionNone)
+	body.int32(int32(len(messages) - 1))
+	body.int64(first)
+	body.int64(max)
+	body.int64(-1)
+	body.int16(-1)
+	body.int32(-1)
+	body.int32(int32(len(messages)))
+	body.buf.Write(records.buf.Bytes())
+
+	var batch encoder
+	batch.int64(0)
+	batch.int32(int32(4 + 1 + 4 + body.buf.Len()))
+	batch.int32(-1)
+	batch.int8(2)
+	batch.int32(int32(crc32.Checksum(body.buf.Bytes(), crc32c)))
+	batch.buf.Write(body.buf.Bytes())
+	return batch.buf.Bytes()
+}
+
+// decodeRecordBatches decodes the messages of the record batches of a
+// partition, and returns them along with the offset following the last
+// batch, which may only have control records. A partial batch at the end
+// is ignored, as brokers may return them.
+func decodeRecordBatches(partition int32, b []byte) ([]Message, int64, error) {
+	var messages []Message
+	next := int64(-1)
+	for len(b) >= 12 {
+		baseOffset := int64(binary.BigEndian.Uint64(b))
+		length := int(int32(binary.BigEndian.Uint32(b[8:])))
+		if length < 0 || 12+length > len(b) {
+			break
+		}
+
+		batch := b[12 : 12+length]
+		b = b[12+length:]
+
+		if len(batch) < 5 || batch[4] != 2 {
+			return nil, 0, ErrUnsupportedFormat.New("only record batches of version 2 are supported")
+		}
+
+		crc := binary.BigEndian.Uint32(batch[5:])
+		if crc32.Checksum(batch[9:], crc32c) != crc {
+			return nil, 0, ErrUnsupportedFormat.New("record batch has an invalid checksum")
+		}
+
+		d := &decoder{b: batch[9:]}
+		attributes := d.int16()
+		lastOffsetDelta := d.int32()
+		firstTimestamp := d.int64()
+		d.int64()
+		d.int64()
+		d.int16()
+		d.int32()
+		count := d.arrayLen()
+		if d.err != nil {
+			return nil, 0, ErrUnsupportedFormat.New(d.err)
+		}
+		next = baseOffset + int64(lastOffsetDelta) + 1
+
+		if attributes&controlBit != 0 {
+			continue
+		}
+
+		records := d.b
+		switch attributes & compressionMask {
+		case compressionNone:
+		case compressionGzip:
+			r, err := gzip.NewReader(bytes.NewReader(records))
+			if err != nil {
+				return nil, 0, ErrUnsupportedFormat.New(err)
+			}
+			if records, err = ioutil.ReadAll(r); err != nil {
+				return nil, 0, ErrUnsupportedFormat.New(err)
+			}
+		default:
+			return nil, 0, ErrUnsupportedFormat.New(
+				fmt.Sprintf("compression codec %d is not supported", attributes&compressionMask),
+			)
+		}
+
+		d = &decoder{b: records}
+		for i := 0; i < count; i++ {
+			length := d.varint()
+			r := &decoder{b: d.next(int(length))}
+			r.int8()
+			timestampDelta := r.varint()
+			offsetDelta := r.varint()
+			key := r.varbytes()
+			value := r.varbytes()
+			for headers := r.varint(); headers > 0; headers-- {
+				r.varbytes()
+				r.varbytes()
+			}
+
+			if err := firstErr(d.err, r.err); err != nil {
+				return nil, 0, ErrUnsupportedFormat.New(err)
+			}
+
+			messages = append(messages, Message{
+				Partition: partition,
+				Offset:    baseOffset + offsetDelta,
+				Timestamp: fromMillis(firstTimestamp + timestampDelta),
+				Key:       key,
+				Value:     value,
+			})
+		}
+	}
+
+	return messages, next, nil
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// murmur2 is the hash used by the default partitioner of the Java client
+// to choose the partition of the messages with a key, so the messages with
+// the same key are in the same partition regardless of the client that
+// produced them.
+func murmur2(data []byte) int32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return int32(h)
+}
@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMurmur2(t *testing.T) {
+	// Values of the tests of the Java client.
+	testCases := []struct {
+		data     string
+		expected int32
+	}{
+		{"21", -973932308},
+		{"foobar", -790332482},
+		{"a-little-bit-long-string", -985981536},
+		{"a-little-bit-longer-string", -1486304829},
+		{"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", -58897971},
+		{"abc", 479470107},
+	}
+
+	for _, tt := range testCases {
+		require.Equal(t, tt.expected, murmur2([]byte(tt.data)), tt.data)
+	}
+}
+
+func testMessages() []Message {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	return []Message{
+		{Offset: 0, Timestamp: ts, Key: []byte("a"), Value: []byte("1")},
+		{Offset: 1, Timestamp: ts.Add(-time.Second), Key: nil, Value: []byte{}},
+		{Offset: 2, Timestamp: ts.Add(time.Minute), Key: []byte("c"), Value: nil},
+	}
+}
+
+func TestRecordBatch(t *testing.T) {
+	require := require.New(t)
+	batch := encodeRecordBatch(testMessages())
+	binary.BigEndian.PutUint64(batch, 10)
+
+	// A second batch and the beginning of a third one.
+	data := append(append([]byte(nil), batch...), encodeRecordBatch(testMessages()[:1])...)
+	binary.BigEndian.PutUint64(data[len(batch):], 13)
+	data = append(data, batch[:20]...)
+
+	messages, next, err := decodeRecordBatches(4, data)
+	require.NoError(err)
+	require.Equal(int64(14), next)
+
+	expected := append(testMessages(), testMessages()[0])
+	for i := range expected {
+		expected[i].Partition = 4
+		expected[i].Offset += 10
+	}
+	expected[3].Offset = 13
+	require.Equal(expected, messages)
+
+	batch[len(batch)-1]++
+	_, _, err = decodeRecordBatches(4, batch)
+	require.True(ErrUnsupportedFormat.Is(err))
+}
+
+func TestRecordBatchGzip(t *testing.T) {
+	require := require.New(t)
+	batch := encodeRecordBatch(testMessages())
+
+	// Records start after the 61 bytes of the header of the batch.
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(batch[61:])
+	require.NoError(err)
+	require.NoError(w.Close())
+
+	compressed := append(append([]byte(nil), batch[:61]...), buf.Bytes()...)
+	binary.BigEndian.PutUint32(compressed[8:], uint32(len(compressed)-12))
+	binary.BigEndian.PutUint16(compressed[21:], compressionGzip)
+	binary.BigEndian.PutUint32(compressed[17:], crc32.Checksum(compressed[21:], crc32c))
+
+	messages, next, err := decodeRecordBatches(0, compressed)
+	require.NoError(err)
+	require.Equal(int64(3), next)
+	require.Equal(testMessages(), messages)
+
+	binary.BigEndian.PutUint16(compressed[21:], controlBit)
+	binary.BigEndian.PutUint32(compressed[17:], crc32.Checksum(compressed[21:], crc32c))
+	messages, next, err = decodeRecordBatches(0, compressed)
+	require.NoError(err)
+	require.Equal(int64(3), next)
+	require.Empty(messages)
+}
@@ -0,0 +1,523 @@
+package kafka
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// Positions of the columns of the tables.
+const (
+	partitionColumn = iota
+	offsetColumn
+	timestampColumn
+	keyColumn
+	valueColumn
+)
+
+// Table is the table of a topic, with a row for every message. Every
+// partition of the topic is a partition of the table, and the bounds of
+// the partitions, offsets and timestamps of the filters are used to only
+// read the messages that can match them.
+//
+// Inserted rows are produced as messages with their key and value, in the
+// partition of the row or in the one chosen by their key, as the Java
+// client does, and with the timestamp of the row or the current one. The
+// offset of the rows is ignored, as it's given by the broker.
+type Table struct {
+	name       string
+	client     *client
+	partitions []int32
+	schema     sql.Schema
+	filters    []sql.Expression
+	// next is the position of the partition of the next message without
+	// key or partition.
+	next *uint32
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.FilteredTable = (*Table)(nil)
+var _ sql.Inserter = (*Table)(nil)
+
+func newTable(c *client, name string, partitions []int32) *Table {
+	return &Table{
+		name:       name,
+		client:     c,
+		partitions: partitions,
+		schema: sql.Schema{
+			{Name: "partition", Type: sql.Int32, Nullable: true, Source: name},
+			{Name: "offset", Type: sql.Int64, Nullable: true, Source: name},
+			{Name: "timestamp", Type: sql.Timestamp, Nullable: true, Source: name},
+			{Name: "key", Type: sql.Text, Nullable: true, Source: name},
+			{Name: "value", Type: sql.Text, Nullable: true, Source: name},
+		},
+		next: new(uint32),
+	}
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("KafkaTable(%s, partitions=%d)", t.name, len(t.partitions))
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The partitions of the
+// topic outside of the bounds of the filters are skipped.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	b := t.bounds()
+	var partitions []int32
+	for _, p := range t.partitions {
+		if int64(p) >= b.partition.min && int64(p) <= b.partition.max {
+			partitions = append(partitions, p)
+		}
+	}
+	return &partitionIter{partitions: partitions}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
+	tp, ok := p.(partition)
+	if !ok {
+		return nil, fmt.Errorf("invalid partition %q of table %s", p.Key(), t.name)
+	}
+	b := t.bounds()
+
+	start, err := t.client.offset(t.name, int32(tp), earliestOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := t.client.offset(t.name, int32(tp), latestOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.offset.min > start {
+		start = b.offset.min
+	}
+
+	if b.offset.max < end-1 {
+		end = b.offset.max + 1
+	}
+
+	if b.timestamp > 0 && start < end {
+		offset, err := t.client.offset(t.name, int32(tp), b.timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		if offset > start {
+			start = offset
+		}
+	}
+
+	return &tableIter{
+		ctx:       ctx,
+		t:         t,
+		partition: int32(tp),
+		offset:    start,
+		end:       end,
+	}, nil
+}
+
+// HandledFilters implements the sql.FilteredTable interface. All the
+// filters with only columns of the table are handled.
+func (t *Table) HandledFilters(filters []sql.Expression) []sql.Expression {
+	var handled []sql.Expression
+	for _, f := range filters {
+		var hasOtherFields bool
+		expression.Inspect(f, func(e sql.Expression) bool {
+			if e, ok := e.(*expression.GetField); ok {
+				if e.Table() != t.name || !t.schema.Contains(e.Name(), t.name) {
+					hasOtherFields = true
+					return false
+				}
+			}
+			return true
+		})
+
+		if !hasOtherFields {
+			handled = append(handled, f)
+		}
+	}
+
+	return handled
+}
+
+// WithFilters implements the sql.FilteredTable interface.
+func (t *Table) WithFilters(filters []sql.Expression) sql.Table {
+	if len(filters) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.filters = filters
+	return &nt
+}
+
+// Filters implements the sql.FilteredTable interface.
+func (t *Table) Filters() []sql.Expression {
+	return t.filters
+}
+
+// Insert implements the sql.Inserter interface.
+func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
+	if err := t.schema.CheckRow(row); err != nil {
+		return err
+	}
+
+	if len(t.partitions) == 0 {
+		return ErrPartition.New(-1, t.name, errorName(errLeaderNotAvailable))
+	}
+
+	var values [valueColumn + 1]interface{}
+	for i, v := range row {
+		if v == nil {
+			continue
+		}
+
+		var err error
+		if values[i], err = t.schema[i].Type.Convert(v); err != nil {
+			return err
+		}
+	}
+
+	m := Message{Timestamp: time.Now()}
+	if ts, ok := values[timestampColumn].(time.Time); ok {
+		m.Timestamp = ts
+	}
+
+	if key, ok := values[keyColumn].(string); ok {
+		m.Key = []byte(key)
+	}
+
+	if value, ok := values[valueColumn].(string); ok {
+		m.Value = []byte(value)
+	}
+
+	switch p := values[partitionColumn].(type) {
+	case int32:
+		if !t.hasPartition(p) {
+			return ErrPartition.New(p, t.name, errorName(errUnknownTopicOrPartition))
+		}
+		m.Partition = p
+	default:
+		if m.Key != nil {
+			// The partition of the Java client, with the positive hash.
+			idx := int(murmur2(m.Key)&0x7fffffff) % len(t.partitions)
+			m.Partition = t.partitions[idx]
+		} else {
+			next := atomic.AddUint32(t.next, 1) - 1
+			m.Partition = t.partitions[int(next%uint32(len(t.partitions)))]
+		}
+	}
+
+	_, err := t.client.produce(t.name, m.Partition, []Message{m})
+	return err
+}
+
+func (t *Table) hasPartition(p int32) bool {
+	for _, tp := range t.partitions {
+		if tp == p {
+			return true
+		}
+	}
+	return false
+}
+
+// offsetRange is a range of values, with both ends included.
+type offsetRange struct {
+	min, max int64
+}
+
+func (r *offsetRange) restrict(min, max int64) {
+	if min > r.min {
+		r.min = min
+	}
+	if max < r.max {
+		r.max = max
+	}
+}
+
+// bounds of the messages that can match the filters of a table.
+type bounds struct {
+	partition offsetRange
+	offset    offsetRange
+	// timestamp is the minimum timestamp of the messages in milliseconds,
+	// or 0 if there is none. Timestamps are not used as upper bounds, as
+	// they may not be in the same order as the offsets.
+	timestamp int64
+}
+
+// bounds returns the bounds of the messages that can match the filters of
+// the table, from the comparisons of columns with literals that must all
+// be true.
+func (t *Table) bounds() bounds {
+	b := bounds{
+		partition: offsetRange{0, 1<<31 - 1},
+		offset:    offsetRange{0, 1<<63 - 1},
+	}
+
+	var visit func(e sql.Expression)
+	visit = func(e sql.Expression) {
+		switch e := e.(type) {
+		case *expression.And:
+			visit(e.Left)
+			visit(e.Right)
+		case *expression.Between:
+			col := t.column(e.Val)
+			lower, lok := literal(e.Lower)
+			upper, uok := literal(e.Upper)
+			if col >= 0 && lok && uok {
+				t.restrict(&b, col, lower, ">=")
+				t.restrict(&b, col, upper, "<=")
+			}
+		case expression.Comparer:
+			col, lit, swapped := t.column(e.Left()), e.Right(), false
+			if col < 0 {
+				col, lit, swapped = t.column(e.Right()), e.Left(), true
+			}
+
+			v, ok := literal(lit)
+			if col < 0 || !ok {
+				return
+			}
+
+			var op string
+			switch e.(type) {
+			case *expression.Equals:
+				op = "="
+			case *expression.LessThan:
+				op = "<"
+			case *expression.LessThanOrEqual:
+				op = "<="
+			case *expression.GreaterThan:
+				op = ">"
+			case *expression.GreaterThanOrEqual:
+				op = ">="
+			default:
+				return
+			}
+
+			// With the literal on the left the operator is reversed, as in
+			// 5 < offset, which is offset > 5.
+			if swapped {
+				op = reversed[op]
+			}
+			t.restrict(&b, col, v, op)
+		}
+	}
+
+	for _, f := range t.filters {
+		visit(f)
+	}
+	return b
+}
+
+var reversed = map[string]string{
+	"=":  "=",
+	"<":  ">",
+	"<=": ">=",
+	">":  "<",
+	">=": "<=",
+}
+
+// column returns the position of the column of a field of the table whose
+// values have bounds, or -1 if the expression is not one.
+func (t *Table) column(e sql.Expression) int {
+	gf, ok := e.(*expression.GetField)
+	if !ok || !strings.EqualFold(gf.Table(), t.name) {
+		return -1
+	}
+
+	switch idx := t.schema.IndexOf(gf.Name(), t.name); idx {
+	case partitionColumn, offsetColumn, timestampColumn:
+		return idx
+	default:
+		return -1
+	}
+}
+
+func literal(e sql.Expression) (interface{}, bool) {
+	l, ok := e.(*expression.Literal)
+	if !ok || l.Value() == nil {
+		return nil, false
+	}
+	return l.Value(), true
+}
+
+// restrict restricts the bounds of a column with the result of comparing
+// its values with the given one with the operator. Values that cannot be
+// converted to the type of the column do not restrict the bounds.
+func (t *Table) restrict(b *bounds, col int, value interface{}, op string) {
+	v, err := t.schema[col].Type.Convert(value)
+	if err != nil || v == nil {
+		return
+	}
+
+	if col == timestampColumn {
+		if op == "=" || op == ">=" || op == ">" {
+			if ms := millis(v.(time.Time)); ms > b.timestamp {
+				b.timestamp = ms
+			}
+		}
+		return
+	}
+
+	var n int64
+	switch v := v.(type) {
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	default:
+		return
+	}
+
+	r := &b.offset
+	if col == partitionColumn {
+		r = &b.partition
+	}
+
+	switch op {
+	case "=":
+		r.restrict(n, n)
+	case "<":
+		r.restrict(r.min, n-1)
+	case "<=":
+		r.restrict(r.min, n)
+	case ">":
+		r.restrict(n+1, r.max)
+	case ">=":
+		r.restrict(n, r.max)
+	}
+}
+
+type partition int32
+
+func (p partition) Key() []byte { return []byte(strconv.Itoa(int(p))) }
+
+type partitionIter struct {
+	partitions []int32
+	pos        int
+}
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.pos >= len(i.partitions) {
+		return nil, io.EOF
+	}
+	i.pos++
+	return partition(i.partitions[i.pos-1]), nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+// tableIter reads the messages of a partition from an offset up to another
+// one, excluded, in batches.
+type tableIter struct {
+	ctx       *sql.Context
+	t         *Table
+	partition int32
+	offset    int64
+	end       int64
+	messages  []Message
+}
+
+func (i *tableIter) Next() (sql.Row, error) {
+	for {
+		for len(i.messages) > 0 {
+			m := i.messages[0]
+			i.messages = i.messages[1:]
+			if m.Offset >= i.end {
+				i.messages = nil
+				break
+			}
+
+			row := sql.NewRow(m.Partition, m.Offset, m.Timestamp, text(m.Key), text(m.Value))
+			ok, err := i.matches(row)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				return row, nil
+			}
+		}
+
+		if i.offset >= i.end {
+			return nil, io.EOF
+		}
+
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
+		messages, next, err := i.t.client.fetch(i.t.name, i.partition, i.offset)
+		if err != nil {
+			return nil, err
+		}
+
+		// Stop if the partition has no more messages, as when they were
+		// deleted since the query started.
+		if next <= i.offset {
+			return nil, io.EOF
+		}
+
+		// Batches may start before the offset requested.
+		for len(messages) > 0 && messages[0].Offset < i.offset {
+			messages = messages[1:]
+		}
+		i.messages = messages
+		i.offset = next
+	}
+}
+
+func text(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+func (i *tableIter) matches(row sql.Row) (bool, error) {
+	for _, f := range i.t.filters {
+		result, err := f.Eval(i.ctx, row)
+		if err != nil {
+			return false, err
+		}
+
+		if result != true {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (i *tableIter) Close() error { return nil }
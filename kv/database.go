@@ -0,0 +1,204 @@
+package kv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	bolt "go.etcd.io/bbolt"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrDatabaseClosed is returned when the rows of a table of a closed
+// database are read or changed.
+var ErrDatabaseClosed = errors.NewKind("database %s is closed")
+
+// Options of a database stored in a key-value store.
+type Options struct {
+	// Timeout is the time to wait for the lock of the file of the store,
+	// which can only be opened by one process at a time. If it's zero, it
+	// waits indefinitely.
+	Timeout time.Duration
+	// NoSync makes changes be written without syncing them to disk, so
+	// they are faster, but the ones made before the machine crashes may be
+	// lost.
+	NoSync bool
+}
+
+// Database is a database whose tables are stored in an embedded key-value
+// store, in a single file. Every change of the rows of a table, along with
+// the changes of its indexes, is made in a transaction of the store, so it
+// is either completely written or not written at all.
+type Database struct {
+	name string
+	db   *bolt.DB
+
+	mu     sync.RWMutex
+	tables map[string]*Table
+
+	// indexes are the indexes of every table, which are read in the
+	// transactions changing its rows, so their lock is never held while
+	// waiting for a transaction.
+	indexMu sync.RWMutex
+	indexes map[string][]*kvIndex
+}
+
+var _ sql.Database = (*Database)(nil)
+var _ sql.TableCreator = (*Database)(nil)
+var _ sql.TableDropper = (*Database)(nil)
+
+// NewDatabase opens the database with the given name stored in the file in
+// the given path, which is created if it does not exist. If options is nil,
+// the default ones are used. The database must be closed once it's not
+// used.
+func NewDatabase(name, path string, options *Options) (*Database, error) {
+	var o Options
+	if options != nil {
+		o = *options
+	}
+
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: o.Timeout, NoSync: o.NoSync})
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Database{
+		name:    name,
+		db:      db,
+		tables:  make(map[string]*Table),
+		indexes: make(map[string][]*kvIndex),
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			t, err := openTable(d, string(name), b)
+			if err != nil {
+				return err
+			}
+
+			d.tables[t.name] = t
+			return nil
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make(map[string]sql.Table, len(d.tables))
+	for name, t := range d.tables {
+		tables[name] = t
+	}
+	return tables
+}
+
+// CreateTable implements the sql.TableCreator interface.
+func (d *Database) CreateTable(ctx *sql.Context, name string, schema sql.Schema) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; ok {
+		return sql.ErrTableAlreadyExists.New(name)
+	}
+
+	t, err := createTable(d, name, schema)
+	if err != nil {
+		return err
+	}
+
+	d.tables[name] = t
+	return nil
+}
+
+// DropTable implements the sql.TableDropper interface. The rows and the
+// indexes of the table are removed.
+func (d *Database) DropTable(ctx *sql.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; !ok {
+		return sql.ErrTableNotFound.New(name)
+	}
+
+	err := d.update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(d.tables, name)
+
+	d.indexMu.Lock()
+	delete(d.indexes, name)
+	d.indexMu.Unlock()
+	return nil
+}
+
+// Close closes the store of the database. Its tables can no longer be read
+// or changed.
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// view runs the given function in a read-only transaction of the store.
+func (d *Database) view(fn func(*bolt.Tx) error) error {
+	err := d.db.View(fn)
+	if err == bolt.ErrDatabaseNotOpen {
+		return ErrDatabaseClosed.New(d.name)
+	}
+	return err
+}
+
+// update runs the given function in a read-write transaction of the store,
+// which is rolled back if the function fails.
+func (d *Database) update(fn func(*bolt.Tx) error) error {
+	err := d.db.Update(fn)
+	if err == bolt.ErrDatabaseNotOpen {
+		return ErrDatabaseClosed.New(d.name)
+	}
+	return err
+}
+
+// tableIndexes returns the indexes of the given table.
+func (d *Database) tableIndexes(table string) []*kvIndex {
+	d.indexMu.RLock()
+	defer d.indexMu.RUnlock()
+	return d.indexes[table]
+}
+
+// addIndex adds an index to the ones updated with the changes of its
+// table.
+func (d *Database) addIndex(idx *kvIndex) {
+	d.indexMu.Lock()
+	defer d.indexMu.Unlock()
+	indexes := d.indexes[idx.table]
+	d.indexes[idx.table] = append(indexes[:len(indexes):len(indexes)], idx)
+}
+
+// removeIndex removes an index from the ones updated with the changes of
+// its table.
+func (d *Database) removeIndex(idx *kvIndex) {
+	d.indexMu.Lock()
+	defer d.indexMu.Unlock()
+	indexes := d.indexes[idx.table]
+	for i, other := range indexes {
+		if other == idx {
+			d.indexes[idx.table] = append(indexes[:i:i], indexes[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package kv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/stretchr/testify/require"
+)
+
+func tempPath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "kv")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "db")
+}
+
+func TestDatabaseCreateAndDropTable(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	path := tempPath(t)
+
+	db, err := NewDatabase("test", path, nil)
+	require.NoError(err)
+	require.Equal("test", db.Name())
+	require.Len(db.Tables(), 0)
+
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: sql.VarChar(20), Nullable: true, Default: "none"},
+	}
+	require.NoError(db.CreateTable(ctx, "a/b", schema))
+
+	err = db.CreateTable(ctx, "a/b", schema)
+	require.True(sql.ErrTableAlreadyExists.Is(err))
+
+	err = db.CreateTable(ctx, "t", sql.Schema{{Name: "t", Type: sql.Tuple(sql.Int64, sql.Text)}})
+	require.True(ErrUnsupportedColumnType.Is(err))
+
+	err = db.CreateTable(ctx, "t", sql.Schema{{Name: "j", Type: sql.JSON, PrimaryKey: true}})
+	require.True(ErrUnsupportedKeyType.Is(err))
+
+	require.NoError(db.Close())
+
+	db, err = NewDatabase("test", path, nil)
+	require.NoError(err)
+
+	tables := db.Tables()
+	require.Len(tables, 1)
+	require.Equal("a/b", tables["a/b"].Name())
+	require.Equal(sql.Schema{
+		{Name: "id", Type: sql.Int64, PrimaryKey: true, AutoIncrement: true, Source: "a/b"},
+		{Name: "name", Type: sql.VarChar(20), Nullable: true, Default: "none", Source: "a/b"},
+	}, tables["a/b"].Schema())
+
+	require.NoError(db.DropTable(ctx, "a/b"))
+	require.True(sql.ErrTableNotFound.Is(db.DropTable(ctx, "a/b")))
+	require.NoError(db.Close())
+
+	db, err = NewDatabase("test", path, nil)
+	require.NoError(err)
+	require.Len(db.Tables(), 0)
+	require.NoError(db.Close())
+}
+
+func TestTablePrimaryKey(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	path := tempPath(t)
+
+	db, err := NewDatabase("test", path, nil)
+	require.NoError(err)
+
+	require.NoError(db.CreateTable(ctx, "t", sql.Schema{
+		{Name: "a", Type: sql.Text, PrimaryKey: true},
+		{Name: "b", Type: sql.Int32, PrimaryKey: true},
+		{Name: "c", Type: sql.Float64, Nullable: true},
+	}))
+
+	table := db.Tables()["t"].(*Table)
+	require.NoError(table.Insert(ctx, sql.NewRow("y", int32(1), 1.5)))
+	require.NoError(table.Insert(ctx, sql.NewRow("x", int32(2), nil)))
+	require.NoError(table.Insert(ctx, sql.NewRow("x", int32(-1), 2.5)))
+
+	err = table.Insert(ctx, sql.NewRow("x", int32(2), 3.5))
+	require.True(ErrDuplicateKey.Is(err))
+
+	// None of the rows of a batch are inserted if one of them fails.
+	err = table.InsertBatch(ctx, []sql.Row{{"w", int32(1), nil}, {"y", int32(1), 3.5}})
+	require.True(ErrDuplicateKey.Is(err))
+
+	// Rows are sorted by their primary key.
+	require.Equal([]sql.Row{
+		{"x", int32(-1), 2.5},
+		{"x", int32(2), nil},
+		{"y", int32(1), 1.5},
+	}, tableRows(t, db, "t"))
+
+	// Rows are identified by their primary key.
+	require.NoError(table.Update(ctx, sql.NewRow("x", int32(2), 0.0), sql.NewRow("x", int32(2), 4.5)))
+	require.NoError(table.Update(ctx, sql.NewRow("y", int32(1), 1.5), sql.NewRow("z", int32(1), 1.5)))
+
+	err = table.Update(ctx, sql.NewRow("z", int32(1), 1.5), sql.NewRow("x", int32(-1), 1.5))
+	require.True(ErrDuplicateKey.Is(err))
+
+	require.NoError(table.Delete(ctx, sql.NewRow("x", int32(-1), nil)))
+	require.Equal(sql.ErrDeleteRowNotFound, table.Delete(ctx, sql.NewRow("x", int32(-1), nil)))
+	require.NoError(table.InsertBatch(ctx, []sql.Row{{"w", int32(1), nil}, {"a", int32(3), 0.5}}))
+	require.NoError(db.Close())
+
+	require.True(ErrDatabaseClosed.Is(table.Insert(ctx, sql.NewRow("w", int32(1), nil))))
+
+	db, err = NewDatabase("test", path, nil)
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{"a", int32(3), 0.5},
+		{"w", int32(1), nil},
+		{"x", int32(2), 4.5},
+		{"z", int32(1), 1.5},
+	}, tableRows(t, db, "t"))
+	require.NoError(db.Close())
+}
+
+func TestTableWithoutPrimaryKey(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	db, err := NewDatabase("test", tempPath(t), nil)
+	require.NoError(err)
+	defer db.Close()
+
+	require.NoError(db.CreateTable(ctx, "t", sql.Schema{{Name: "i", Type: sql.Int64}}))
+	table := db.Tables()["t"].(*Table)
+
+	// Rows are read in the order they were inserted, in batches.
+	var expected []sql.Row
+	for i := int64(batchSize + 10); i > 0; i-- {
+		require.NoError(table.Insert(ctx, sql.NewRow(i%3)))
+		expected = append(expected, sql.NewRow(i%3))
+	}
+	require.Equal(expected, tableRows(t, db, "t"))
+
+	require.NoError(table.Delete(ctx, sql.NewRow(int64(1))))
+	require.NoError(table.Update(ctx, sql.NewRow(int64(2)), sql.NewRow(int64(5))))
+	require.NoError(table.Update(ctx, sql.NewRow(int64(7)), sql.NewRow(int64(8))))
+	require.Equal(sql.ErrDeleteRowNotFound, table.Delete(ctx, sql.NewRow(int64(7))))
+
+	rows := tableRows(t, db, "t")
+	require.Len(rows, len(expected)-1)
+	require.Equal(sql.NewRow(int64(5)), rows[0])
+	require.Equal(sql.NewRow(int64(0)), rows[1])
+}
+
+func TestKeyEncodingOrder(t *testing.T) {
+	testCases := []struct {
+		typ    sql.Type
+		values []interface{}
+	}{
+		{sql.Int64, []interface{}{nil, int64(math.MinInt64), int64(-2), int64(0), int64(3), int64(math.MaxInt64)}},
+		{sql.Uint64, []interface{}{uint64(0), uint64(1), uint64(math.MaxUint64)}},
+		{sql.Float64, []interface{}{math.Inf(-1), -2.5, -0.5, 0.0, 0.25, 3.0, math.Inf(1)}},
+		{sql.Text, []interface{}{"", "a", "a\x00", "a\x00b", "a\x01", "ab", "b"}},
+		{sql.Timestamp, []interface{}{
+			time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 1, 0, 0, 0, 1000, time.UTC),
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{sql.Boolean, []interface{}{false, true}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			require := require.New(t)
+			var keys [][]byte
+			for _, v := range tt.values {
+				// The encoding of a value may be followed by others.
+				key, err := appendKey(nil, tt.typ, v)
+				require.NoError(err)
+				key, err = appendKey(key, sql.Int64, int64(-1))
+				require.NoError(err)
+				keys = append(keys, key)
+			}
+
+			require.True(sort.SliceIsSorted(keys, func(i, j int) bool {
+				return bytes.Compare(keys[i], keys[j]) < 0
+			}))
+		})
+	}
+}
+
+func TestDatabaseQueries(t *testing.T) {
+	require := require.New(t)
+	path := tempPath(t)
+
+	query := func(db *Database, q string) []sql.Row {
+		t.Helper()
+		catalog := sql.NewCatalog()
+		catalog.AddDatabase(db)
+		e := sqle.New(catalog, analyzer.NewDefault(catalog), nil)
+
+		ctx := sql.NewEmptyContext()
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		return rows
+	}
+
+	db, err := NewDatabase("mydb", path, nil)
+	require.NoError(err)
+
+	query(db, "CREATE TABLE users (id BIGINT AUTO_INCREMENT PRIMARY KEY, name TEXT NOT NULL)")
+	query(db, "INSERT INTO users (name) VALUES ('alice'), ('bob'), ('carol'), ('dave')")
+	query(db, "UPDATE users SET name = 'robert' WHERE name = 'bob'")
+	query(db, "DELETE FROM users WHERE id = 3")
+	query(db, "REPLACE INTO users VALUES (4, 'david')")
+	require.NoError(db.Close())
+
+	db, err = NewDatabase("mydb", path, nil)
+	require.NoError(err)
+
+	rows := query(db, "SELECT id, name FROM users ORDER BY id")
+	require.Equal([]sql.Row{
+		{int64(1), "alice"},
+		{int64(2), "robert"},
+		{int64(4), "david"},
+	}, rows)
+
+	query(db, "DELETE FROM users WHERE id = 4")
+	query(db, "INSERT INTO users (name) VALUES ('eve')")
+	rows = query(db, "SELECT id FROM users WHERE name = 'eve'")
+	require.Equal([]sql.Row{{int64(5)}}, rows)
+	require.NoError(db.Close())
+}
+
+func tableRows(t *testing.T, db *Database, name string) []sql.Row {
+	t.Helper()
+	ctx := sql.NewEmptyContext()
+	table := db.Tables()[name]
+
+	var rows []sql.Row
+	partitions, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	for {
+		p, err := partitions.Next()
+		if err != nil {
+			break
+		}
+
+		iter, err := table.PartitionRows(ctx, p)
+		require.NoError(t, err)
+		r, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		rows = append(rows, r...)
+	}
+	return rows
+}
@@ -0,0 +1,253 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+// ErrCorruptTable is returned when the schema, rows or indexes of a table
+// cannot be read from the store.
+var ErrCorruptTable = errors.NewKind("table %s is corrupt: %s")
+
+// ErrUnsupportedColumnType is returned when a table is created with a
+// column whose type cannot be stored.
+var ErrUnsupportedColumnType = errors.NewKind("column %s has type %s, which cannot be stored")
+
+// ErrUnsupportedKeyType is returned when a column whose values cannot be
+// sorted is part of the primary key of a table or of an index.
+var ErrUnsupportedKeyType = errors.NewKind("column %s has type %s, which cannot be used in keys")
+
+func init() {
+	// The values of the rows are encoded as interfaces, so the types that
+	// are not basic must be registered.
+	gob.Register(time.Time{})
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register(sql.Point{})
+	gob.Register(sql.LineString{})
+	gob.Register(sql.Polygon{})
+}
+
+// column is a column of the schema of a table as it's stored.
+type column struct {
+	Name          string
+	Type          query.Type
+	Length        int
+	Default       interface{}
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+func encodeSchema(schema sql.Schema) ([]byte, error) {
+	columns := make([]column, len(schema))
+	for i, col := range schema {
+		c := column{
+			Name:          col.Name,
+			Type:          col.Type.Type(),
+			Default:       col.Default,
+			Nullable:      col.Nullable,
+			PrimaryKey:    col.PrimaryKey,
+			AutoIncrement: col.AutoIncrement,
+		}
+
+		if t, ok := col.Type.(interface{ Capacity() int }); ok {
+			c.Length = t.Capacity()
+		}
+
+		// Types that cannot be created again from the stored ones, such
+		// as tuples, are not supported.
+		typ, err := columnType(c)
+		if err != nil || !reflect.DeepEqual(typ, col.Type) {
+			return nil, ErrUnsupportedColumnType.New(col.Name, col.Type)
+		}
+
+		if col.PrimaryKey && !isKeyType(typ) {
+			return nil, ErrUnsupportedKeyType.New(col.Name, col.Type)
+		}
+
+		columns[i] = c
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(columns); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSchema(table string, data []byte) (sql.Schema, error) {
+	var columns []column
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&columns); err != nil {
+		return nil, err
+	}
+
+	schema := make(sql.Schema, len(columns))
+	for i, c := range columns {
+		typ, err := columnType(c)
+		if err != nil {
+			return nil, err
+		}
+
+		schema[i] = &sql.Column{
+			Name:          c.Name,
+			Type:          typ,
+			Default:       c.Default,
+			Nullable:      c.Nullable,
+			Source:        table,
+			PrimaryKey:    c.PrimaryKey,
+			AutoIncrement: c.AutoIncrement,
+		}
+	}
+	return schema, nil
+}
+
+func columnType(c column) (sql.Type, error) {
+	switch c.Type {
+	case sqltypes.Char:
+		return sql.Char(c.Length), nil
+	case sqltypes.VarChar:
+		return sql.VarChar(c.Length), nil
+	default:
+		return sql.MysqlTypeToType(c.Type)
+	}
+}
+
+func encodeRow(row sql.Row) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode([]interface{}(row)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeRow(data []byte) (sql.Row, error) {
+	var row []interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&row); err != nil {
+		return nil, err
+	}
+	return sql.NewRow(row...), nil
+}
+
+// isKeyType returns whether the values of the given type can be encoded in
+// keys.
+func isKeyType(typ sql.Type) bool {
+	if typ == sql.JSON {
+		return false
+	}
+	return sql.IsNumber(typ) || sql.IsText(typ) || sql.IsTime(typ) || typ == sql.Boolean
+}
+
+// Markers of the values of keys. Null values are sorted before the rest.
+const (
+	keyNull  byte = 0x01
+	keyValue byte = 0x02
+)
+
+// appendKey appends the encoding of the given value of a key, converted to
+// the given type, to b. Keys are compared byte by byte in the store, so
+// the encoding of the values keeps their order, and every value can be
+// followed by others, as the encoding of a string ends with a terminator.
+func appendKey(b []byte, typ sql.Type, v interface{}) ([]byte, error) {
+	if v == nil {
+		return append(b, keyNull), nil
+	}
+
+	v, err := typ.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, keyValue)
+	switch v := v.(type) {
+	case int8:
+		return appendInt(b, int64(v)), nil
+	case int16:
+		return appendInt(b, int64(v)), nil
+	case int32:
+		return appendInt(b, int64(v)), nil
+	case int64:
+		return appendInt(b, v), nil
+	case uint8:
+		return appendUint(b, uint64(v)), nil
+	case uint16:
+		return appendUint(b, uint64(v)), nil
+	case uint32:
+		return appendUint(b, uint64(v)), nil
+	case uint64:
+		return appendUint(b, v), nil
+	case float32:
+		return appendFloat(b, float64(v)), nil
+	case float64:
+		return appendFloat(b, v), nil
+	case bool:
+		if v {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case time.Time:
+		b = appendInt(b, v.Unix())
+		return appendUint(b, uint64(v.Nanosecond())), nil
+	case string:
+		return appendBytes(b, []byte(v)), nil
+	case []byte:
+		return appendBytes(b, v), nil
+	default:
+		return nil, sql.ErrInvalidType.New(v)
+	}
+}
+
+// appendInt flips the sign bit, so negative numbers are sorted before the
+// positive ones.
+func appendInt(b []byte, v int64) []byte {
+	return appendUint(b, uint64(v)^(1<<63))
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// decodeUint decodes a number encoded with appendUint, which is zero if
+// the data is missing.
+func decodeUint(data []byte) uint64 {
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// appendFloat flips all the bits of negative numbers and the sign bit of
+// the rest, so they are sorted by their value.
+func appendFloat(b []byte, v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return appendUint(b, bits)
+}
+
+// appendBytes escapes the zero bytes, so the terminator, a zero byte
+// followed by one, is sorted before any other byte.
+func appendBytes(b, v []byte) []byte {
+	for _, c := range v {
+		if c == 0 {
+			b = append(b, 0, 0xff)
+		} else {
+			b = append(b, c)
+		}
+	}
+	return append(b, 0, 1)
+}
@@ -0,0 +1,626 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	bolt "go.etcd.io/bbolt"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// IndexDriverID is the ID of the driver of the indexes of the tables
+// stored in a key-value store.
+const IndexDriverID = "kv"
+
+var (
+	// ErrNotKVTable is returned when an index of the kv driver is created
+	// on a table that is not stored in a key-value store.
+	ErrNotKVTable = errors.NewKind("table %s of database %s is not stored in a key-value store")
+
+	// ErrUnsupportedIndexExpression is returned when an index of the kv
+	// driver is created with an expression that is not a column.
+	ErrUnsupportedIndexExpression = errors.NewKind("expression %s cannot be indexed, only columns can")
+
+	errNotKVIndex       = errors.NewKind("index %s is not an index of a key-value store")
+	errInvalidKeyLength = errors.NewKind("index %s has %d expressions, but the key has %d values")
+	errColumnNotFound   = errors.NewKind("could not find column %s")
+)
+
+// IndexDriver is the driver of the indexes of the tables stored in a
+// key-value store. The indexes are stored along with the rows of their
+// tables and are changed in the same transactions, so they are never
+// outdated and are loaded again when the database is opened.
+type IndexDriver struct {
+	dbs []*Database
+}
+
+var _ sql.IndexDriver = (*IndexDriver)(nil)
+
+// NewIndexDriver creates the driver of the indexes of the tables of the
+// given databases.
+func NewIndexDriver(dbs ...*Database) *IndexDriver {
+	return &IndexDriver{dbs: dbs}
+}
+
+// ID implements the sql.IndexDriver interface.
+func (d *IndexDriver) ID() string { return IndexDriverID }
+
+// Create implements the sql.IndexDriver interface. The index is stored
+// once it's saved.
+func (d *IndexDriver) Create(
+	db, table, id string,
+	expressions []sql.Expression,
+	config map[string]string,
+) (sql.Index, error) {
+	t, err := d.table(db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	def := indexDefinition{ID: id}
+	for _, e := range expressions {
+		gf, ok := e.(*expression.GetField)
+		if !ok {
+			return nil, ErrUnsupportedIndexExpression.New(e)
+		}
+
+		def.Columns = append(def.Columns, gf.Name())
+		def.Expressions = append(def.Expressions, e.String())
+	}
+
+	return newIndex(t, def)
+}
+
+func (d *IndexDriver) database(db string) *Database {
+	for _, database := range d.dbs {
+		if strings.EqualFold(database.Name(), db) {
+			return database
+		}
+	}
+	return nil
+}
+
+func (d *IndexDriver) table(db, table string) (*Table, error) {
+	if database := d.database(db); database != nil {
+		for name, t := range database.Tables() {
+			if strings.EqualFold(name, table) {
+				return t.(*Table), nil
+			}
+		}
+	}
+	return nil, ErrNotKVTable.New(table, db)
+}
+
+// LoadAll implements the sql.IndexDriver interface.
+func (d *IndexDriver) LoadAll(db, table string) ([]sql.Index, error) {
+	database := d.database(db)
+	if database == nil {
+		return nil, nil
+	}
+
+	var indexes []sql.Index
+	for _, idx := range database.tableIndexes(table) {
+		indexes = append(indexes, idx)
+	}
+	return indexes, nil
+}
+
+// Save implements the sql.IndexDriver interface. The keys of the rows are
+// read from the table in the same transaction in which the index is
+// stored, instead of the given iterator, so no change of the rows is
+// missed.
+func (d *IndexDriver) Save(
+	ctx *sql.Context,
+	i sql.Index,
+	iter sql.PartitionIndexKeyValueIter,
+) error {
+	idx, ok := i.(*kvIndex)
+	if !ok {
+		return errNotKVIndex.New(i.ID())
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	db := idx.t.db
+	err := db.update(func(tx *bolt.Tx) error {
+		b, rows, err := idx.t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		if _, err := b.Bucket(indexesBucket).CreateBucket([]byte(idx.id)); err != nil {
+			return err
+		}
+
+		c := rows.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			row, err := decodeRow(v)
+			if err != nil {
+				return ErrCorruptTable.New(idx.table, err)
+			}
+
+			if err := idx.add(b, k, row); err != nil {
+				return err
+			}
+		}
+
+		definitions, err := readIndexDefinitions(b)
+		if err != nil {
+			return ErrCorruptTable.New(idx.table, err)
+		}
+
+		if err := writeIndexDefinitions(b, append(definitions, idx.def)); err != nil {
+			return err
+		}
+
+		// The index is changed by the transactions after this one, which
+		// are not run until this one is committed.
+		db.addIndex(idx)
+		return nil
+	})
+	if err != nil {
+		db.removeIndex(idx)
+	}
+	return err
+}
+
+// Delete implements the sql.IndexDriver interface.
+func (d *IndexDriver) Delete(i sql.Index, _ sql.PartitionIter) error {
+	idx, ok := i.(*kvIndex)
+	if !ok {
+		return errNotKVIndex.New(i.ID())
+	}
+
+	db := idx.t.db
+	return db.update(func(tx *bolt.Tx) error {
+		db.removeIndex(idx)
+
+		b := tx.Bucket([]byte(idx.table))
+		if b == nil {
+			return nil
+		}
+
+		err := b.Bucket(indexesBucket).DeleteBucket([]byte(idx.id))
+		if err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+
+		definitions, err := readIndexDefinitions(b)
+		if err != nil {
+			return ErrCorruptTable.New(idx.table, err)
+		}
+
+		for j, def := range definitions {
+			if def.ID == idx.id {
+				definitions = append(definitions[:j], definitions[j+1:]...)
+				break
+			}
+		}
+		return writeIndexDefinitions(b, definitions)
+	})
+}
+
+// indexDefinition is an index as it's stored.
+type indexDefinition struct {
+	ID          string
+	Columns     []string
+	Expressions []string
+}
+
+func readIndexDefinitions(b *bolt.Bucket) ([]indexDefinition, error) {
+	data := b.Get(indexDefinitionsKey)
+	if data == nil {
+		return nil, nil
+	}
+
+	var definitions []indexDefinition
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&definitions); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+func writeIndexDefinitions(b *bolt.Bucket, definitions []indexDefinition) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(definitions); err != nil {
+		return err
+	}
+	return b.Put(indexDefinitionsKey, buf.Bytes())
+}
+
+// kvIndex is an index of a table stored in a bucket, whose keys are the
+// keys of the index followed by the keys of the rows, so they are sorted
+// by the values of the indexed columns. The values are the keys of the
+// rows. Rows with null values in the indexed columns are not stored, as
+// no lookup matches them.
+type kvIndex struct {
+	id      string
+	table   string
+	t       *Table
+	def     indexDefinition
+	columns []int
+}
+
+var _ sql.Index = (*kvIndex)(nil)
+var _ sql.AscendIndex = (*kvIndex)(nil)
+var _ sql.DescendIndex = (*kvIndex)(nil)
+
+func newIndex(t *Table, def indexDefinition) (*kvIndex, error) {
+	idx := &kvIndex{id: def.ID, table: t.name, t: t, def: def}
+	for _, name := range def.Columns {
+		c := t.columnIndex(name)
+		if c < 0 {
+			return nil, errColumnNotFound.New(name)
+		}
+
+		if !isKeyType(t.schema[c].Type) {
+			return nil, ErrUnsupportedKeyType.New(name, t.schema[c].Type)
+		}
+
+		idx.columns = append(idx.columns, c)
+	}
+	return idx, nil
+}
+
+// ID implements the sql.Index interface.
+func (i *kvIndex) ID() string { return i.id }
+
+// Database implements the sql.Index interface.
+func (i *kvIndex) Database() string { return i.t.db.Name() }
+
+// Table implements the sql.Index interface.
+func (i *kvIndex) Table() string { return i.table }
+
+// Expressions implements the sql.Index interface.
+func (i *kvIndex) Expressions() []string { return i.def.Expressions }
+
+// Driver implements the sql.Index interface.
+func (i *kvIndex) Driver() string { return IndexDriverID }
+
+// Get implements the sql.Index interface.
+func (i *kvIndex) Get(key ...interface{}) (sql.IndexLookup, error) {
+	return i.between(key, true, key, true)
+}
+
+// Has implements the sql.Index interface.
+func (i *kvIndex) Has(_ sql.Partition, key ...interface{}) (bool, error) {
+	lookup, err := i.Get(key...)
+	if err != nil {
+		return false, err
+	}
+
+	keys, err := lookup.(*indexLookup).keys()
+	return len(keys) > 0, err
+}
+
+// AscendGreaterOrEqual implements the sql.AscendIndex interface.
+func (i *kvIndex) AscendGreaterOrEqual(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(keys, true, nil, false)
+}
+
+// AscendLessThan implements the sql.AscendIndex interface.
+func (i *kvIndex) AscendLessThan(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(nil, false, keys, false)
+}
+
+// AscendRange implements the sql.AscendIndex interface.
+func (i *kvIndex) AscendRange(greaterOrEqual, lessThan []interface{}) (sql.IndexLookup, error) {
+	return i.between(greaterOrEqual, true, lessThan, false)
+}
+
+// DescendGreater implements the sql.DescendIndex interface.
+func (i *kvIndex) DescendGreater(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(keys, false, nil, false)
+}
+
+// DescendLessOrEqual implements the sql.DescendIndex interface.
+func (i *kvIndex) DescendLessOrEqual(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(nil, false, keys, true)
+}
+
+// DescendRange implements the sql.DescendIndex interface.
+func (i *kvIndex) DescendRange(lessOrEqual, greaterThan []interface{}) (sql.IndexLookup, error) {
+	return i.between(greaterThan, false, lessOrEqual, true)
+}
+
+// prefix returns the encoding of the given values of the indexed columns,
+// and false if any of them is null.
+func (i *kvIndex) prefix(values []interface{}) ([]byte, bool, error) {
+	if len(values) != len(i.columns) {
+		return nil, false, errInvalidKeyLength.New(i.id, len(i.columns), len(values))
+	}
+
+	var prefix []byte
+	for j, v := range values {
+		if v == nil {
+			return nil, false, nil
+		}
+
+		var err error
+		prefix, err = appendKey(prefix, i.t.schema[i.columns[j]].Type, v)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return prefix, true, nil
+}
+
+// entry returns the key of the entry of the row with the given key, and
+// false if the row is not indexed.
+func (i *kvIndex) entry(key []byte, row sql.Row) ([]byte, bool, error) {
+	values := make([]interface{}, len(i.columns))
+	for j, c := range i.columns {
+		values[j] = row[c]
+	}
+
+	prefix, ok, err := i.prefix(values)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return append(prefix, key...), true, nil
+}
+
+// add adds the row with the given key to the index, in the bucket of its
+// table. The index may not be stored yet if it's being saved.
+func (i *kvIndex) add(b *bolt.Bucket, key []byte, row sql.Row) error {
+	ib := b.Bucket(indexesBucket).Bucket([]byte(i.id))
+	if ib == nil {
+		return nil
+	}
+
+	entry, ok, err := i.entry(key, row)
+	if err != nil || !ok {
+		return err
+	}
+	return ib.Put(entry, key)
+}
+
+// remove removes the row with the given key from the index, in the bucket
+// of its table.
+func (i *kvIndex) remove(b *bolt.Bucket, key []byte, row sql.Row) error {
+	ib := b.Bucket(indexesBucket).Bucket([]byte(i.id))
+	if ib == nil {
+		return nil
+	}
+
+	entry, ok, err := i.entry(key, row)
+	if err != nil || !ok {
+		return err
+	}
+	return ib.Delete(entry)
+}
+
+// between returns the lookup of the keys between the given ones, which are
+// not bounded if they are nil. Keys with null values match no rows.
+func (i *kvIndex) between(
+	from []interface{},
+	fromInclusive bool,
+	to []interface{},
+	toInclusive bool,
+) (sql.IndexLookup, error) {
+	var start, end []byte
+	if from != nil {
+		prefix, ok, err := i.prefix(from)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return i.lookup(nil), nil
+		}
+		start = prefix
+	}
+
+	if to != nil {
+		prefix, ok, err := i.prefix(to)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return i.lookup(nil), nil
+		}
+		end = prefix
+	}
+
+	return i.lookup(func(ib *bolt.Bucket) [][]byte {
+		var keys [][]byte
+		c := ib.Cursor()
+		k, v := c.First()
+		if start != nil {
+			k, v = c.Seek(start)
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			if start != nil && !fromInclusive && bytes.HasPrefix(k, start) {
+				continue
+			}
+
+			if end != nil {
+				equal := bytes.HasPrefix(k, end)
+				if (equal && !toInclusive) || (!equal && bytes.Compare(k, end) > 0) {
+					break
+				}
+			}
+
+			keys = append(keys, append([]byte(nil), v...))
+		}
+		return keys
+	}), nil
+}
+
+// lookup returns a lookup of the keys of the rows returned by the given
+// function for the bucket of the index. If it's nil, no row matches.
+func (i *kvIndex) lookup(scan func(*bolt.Bucket) [][]byte) *indexLookup {
+	return &indexLookup{
+		index: i,
+		ids:   []string{i.id},
+		keys: func() ([][]byte, error) {
+			if scan == nil {
+				return nil, nil
+			}
+
+			var keys [][]byte
+			err := i.t.db.view(func(tx *bolt.Tx) error {
+				b, _, err := i.t.buckets(tx)
+				if err != nil {
+					return err
+				}
+
+				ib := b.Bucket(indexesBucket).Bucket([]byte(i.id))
+				if ib == nil {
+					return errNotKVIndex.New(i.id)
+				}
+
+				keys = scan(ib)
+				return nil
+			})
+
+			sort.Slice(keys, func(a, b int) bool {
+				return bytes.Compare(keys[a], keys[b]) < 0
+			})
+			return keys, err
+		},
+	}
+}
+
+// indexLookup is a lookup of an index of a table stored in a key-value
+// store. The keys of the rows are found when they are read, so they are
+// the ones of the rows in the table at that moment.
+type indexLookup struct {
+	index *kvIndex
+	ids   []string
+	// keys returns the keys of the rows, sorted.
+	keys func() ([][]byte, error)
+}
+
+var _ sql.IndexLookup = (*indexLookup)(nil)
+var _ sql.SetOperations = (*indexLookup)(nil)
+var _ sql.Mergeable = (*indexLookup)(nil)
+
+// Values implements the sql.IndexLookup interface.
+func (l *indexLookup) Values(sql.Partition) (sql.IndexValueIter, error) {
+	keys, err := l.keys()
+	if err != nil {
+		return nil, err
+	}
+	return &indexValueIter{keys: keys}, nil
+}
+
+// Indexes implements the sql.IndexLookup interface.
+func (l *indexLookup) Indexes() []string { return l.ids }
+
+// IsMergeable implements the sql.Mergeable interface. Lookups of indexes of
+// the same table can be merged.
+func (l *indexLookup) IsMergeable(other sql.IndexLookup) bool {
+	o, ok := other.(*indexLookup)
+	return ok && o.index.t.db == l.index.t.db && o.index.table == l.index.table
+}
+
+// Intersection implements the sql.SetOperations interface.
+func (l *indexLookup) Intersection(lookups ...sql.IndexLookup) sql.IndexLookup {
+	return l.merge(lookups, func(a, b [][]byte) [][]byte {
+		var result [][]byte
+		for i, j := 0, 0; i < len(a) && j < len(b); {
+			switch c := bytes.Compare(a[i], b[j]); {
+			case c < 0:
+				i++
+			case c > 0:
+				j++
+			default:
+				result = append(result, a[i])
+				i++
+				j++
+			}
+		}
+		return result
+	})
+}
+
+// Union implements the sql.SetOperations interface.
+func (l *indexLookup) Union(lookups ...sql.IndexLookup) sql.IndexLookup {
+	return l.merge(lookups, func(a, b [][]byte) [][]byte {
+		var result [][]byte
+		i, j := 0, 0
+		for i < len(a) && j < len(b) {
+			switch c := bytes.Compare(a[i], b[j]); {
+			case c < 0:
+				result = append(result, a[i])
+				i++
+			case c > 0:
+				result = append(result, b[j])
+				j++
+			default:
+				result = append(result, a[i])
+				i++
+				j++
+			}
+		}
+		result = append(result, a[i:]...)
+		return append(result, b[j:]...)
+	})
+}
+
+// Difference implements the sql.SetOperations interface.
+func (l *indexLookup) Difference(lookups ...sql.IndexLookup) sql.IndexLookup {
+	return l.merge(lookups, func(a, b [][]byte) [][]byte {
+		var result [][]byte
+		j := 0
+		for _, k := range a {
+			for j < len(b) && bytes.Compare(b[j], k) < 0 {
+				j++
+			}
+			if j >= len(b) || !bytes.Equal(b[j], k) {
+				result = append(result, k)
+			}
+		}
+		return result
+	})
+}
+
+func (l *indexLookup) merge(lookups []sql.IndexLookup, op func(a, b [][]byte) [][]byte) sql.IndexLookup {
+	ids := append([]string(nil), l.ids...)
+	keys := l.keys
+	for _, other := range lookups {
+		o := other.(*indexLookup)
+		ids = append(ids, o.ids...)
+
+		left := keys
+		keys = func() ([][]byte, error) {
+			a, err := left()
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := o.keys()
+			if err != nil {
+				return nil, err
+			}
+			return op(a, b), nil
+		}
+	}
+
+	return &indexLookup{index: l.index, ids: ids, keys: keys}
+}
+
+type indexValueIter struct {
+	keys [][]byte
+	pos  int
+}
+
+func (i *indexValueIter) Next() ([]byte, error) {
+	if i.pos >= len(i.keys) {
+		return nil, io.EOF
+	}
+
+	key := i.keys[i.pos]
+	i.pos++
+	return key, nil
+}
+
+func (i *indexValueIter) Close() error { return nil }
@@ -0,0 +1,186 @@
+package kv
+
+import (
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	path := tempPath(t)
+
+	db, err := NewDatabase("mydb", path, nil)
+	require.NoError(err)
+
+	require.NoError(db.CreateTable(ctx, "t", sql.Schema{
+		{Name: "i", Type: sql.Int64, PrimaryKey: true},
+		{Name: "s", Type: sql.Text, Nullable: true},
+	}))
+	table := db.Tables()["t"].(*Table)
+	for i, s := range []interface{}{"b", "a", nil, "c", "a"} {
+		require.NoError(table.Insert(ctx, sql.NewRow(int64(i), s)))
+	}
+
+	driver := NewIndexDriver(db)
+	s := expression.NewGetFieldWithTable(0, sql.Text, "t", "s", true)
+
+	_, err = driver.Create("mydb", "t", "idx", []sql.Expression{expression.NewLiteral(1, sql.Int64)}, nil)
+	require.True(ErrUnsupportedIndexExpression.Is(err))
+
+	_, err = driver.Create("otherdb", "t", "idx", []sql.Expression{s}, nil)
+	require.True(ErrNotKVTable.Is(err))
+
+	idx, err := driver.Create("mydb", "t", "idx", []sql.Expression{s}, nil)
+	require.NoError(err)
+
+	iter, err := table.IndexKeyValues(ctx, []string{"s"})
+	require.NoError(err)
+	require.NoError(driver.Save(ctx, idx, iter))
+
+	lookupRows := func(lookup sql.IndexLookup, err error) []sql.Row {
+		t.Helper()
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(mustRows(t, table.WithIndexLookup(lookup)))
+		require.NoError(err)
+		return rows
+	}
+
+	require.Equal([]sql.Row{{int64(1), "a"}, {int64(4), "a"}}, lookupRows(idx.Get("a")))
+	require.Len(lookupRows(idx.Get(nil)), 0)
+
+	// The index is changed along with the rows.
+	require.NoError(table.Update(ctx, sql.NewRow(int64(4), "a"), sql.NewRow(int64(4), "d")))
+	require.NoError(table.Delete(ctx, sql.NewRow(int64(1), "a")))
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(5), "a")))
+	require.NoError(table.Update(ctx, sql.NewRow(int64(2), nil), sql.NewRow(int64(2), "b")))
+
+	ascend := idx.(sql.AscendIndex)
+	descend := idx.(sql.DescendIndex)
+	require.Equal([]sql.Row{{int64(5), "a"}}, lookupRows(idx.Get("a")))
+	require.Equal([]sql.Row{{int64(0), "b"}, {int64(2), "b"}}, lookupRows(idx.Get("b")))
+	require.Equal(
+		[]sql.Row{{int64(0), "b"}, {int64(2), "b"}, {int64(3), "c"}, {int64(4), "d"}},
+		lookupRows(ascend.AscendGreaterOrEqual("b")),
+	)
+	require.Equal(
+		[]sql.Row{{int64(0), "b"}, {int64(2), "b"}, {int64(5), "a"}},
+		lookupRows(ascend.AscendLessThan("c")),
+	)
+	require.Equal([]sql.Row{{int64(3), "c"}}, lookupRows(ascend.AscendRange([]interface{}{"c"}, []interface{}{"d"})))
+	require.Equal([]sql.Row{{int64(3), "c"}, {int64(4), "d"}}, lookupRows(descend.DescendGreater("b")))
+	require.Equal([]sql.Row{{int64(0), "b"}, {int64(2), "b"}, {int64(3), "c"}}, lookupRows(descend.DescendRange([]interface{}{"c"}, []interface{}{"a"})))
+
+	has, err := idx.Has(partition{}, "d")
+	require.NoError(err)
+	require.True(has)
+
+	// Lookups of the same table can be merged.
+	b, err := idx.Get("b")
+	require.NoError(err)
+	geb, err := ascend.AscendGreaterOrEqual("b")
+	require.NoError(err)
+	c, err := idx.Get("c")
+	require.NoError(err)
+	require.True(b.(sql.Mergeable).IsMergeable(c))
+	require.Equal([]sql.Row{{int64(0), "b"}, {int64(2), "b"}, {int64(3), "c"}}, lookupRows(b.(sql.SetOperations).Union(c), nil))
+	require.Equal([]sql.Row{{int64(3), "c"}}, lookupRows(geb.(sql.SetOperations).Intersection(c), nil))
+	require.Equal([]sql.Row{{int64(4), "d"}}, lookupRows(geb.(sql.SetOperations).Difference(b, c), nil))
+	require.NoError(db.Close())
+
+	// The index is stored with the table.
+	db, err = NewDatabase("mydb", path, nil)
+	require.NoError(err)
+	driver = NewIndexDriver(db)
+	table = db.Tables()["t"].(*Table)
+
+	indexes, err := driver.LoadAll("mydb", "t")
+	require.NoError(err)
+	require.Len(indexes, 1)
+	idx = indexes[0]
+	require.Equal("idx", idx.ID())
+	require.Equal([]string{"t.s"}, idx.Expressions())
+
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(6), "c")))
+	require.Equal([]sql.Row{{int64(3), "c"}, {int64(6), "c"}}, lookupRows(idx.Get("c")))
+
+	require.NoError(driver.Delete(idx, nil))
+	indexes, err = driver.LoadAll("mydb", "t")
+	require.NoError(err)
+	require.Len(indexes, 0)
+	require.NoError(db.Close())
+
+	db, err = NewDatabase("mydb", path, nil)
+	require.NoError(err)
+	indexes, err = NewIndexDriver(db).LoadAll("mydb", "t")
+	require.NoError(err)
+	require.Len(indexes, 0)
+	require.NoError(db.Close())
+}
+
+func TestIndexQueries(t *testing.T) {
+	db, err := NewDatabase("mydb", tempPath(t), nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	catalog.RegisterIndexDriver(NewIndexDriver(db))
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), nil)
+
+	query := func(t *testing.T, q string) (sql.Node, []sql.Row) {
+		t.Helper()
+		analyzed, iter, err := e.QueryWithPlan(sql.NewEmptyContext(), q)
+		require.NoError(t, err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		return analyzed, rows
+	}
+
+	query(t, "CREATE TABLE t (i BIGINT PRIMARY KEY, s TEXT)")
+	query(t, "INSERT INTO t VALUES (1, 'first'), (2, 'second'), (3, 'third')")
+	query(t, "CREATE INDEX idx_s ON t USING kv (s) WITH (async = false)")
+	query(t, "UPDATE t SET s = 'fourth' WHERE i = 3")
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{"SELECT i FROM t WHERE s = 'second'", []sql.Row{{int64(2)}}},
+		{"SELECT i FROM t WHERE s = 'third'", nil},
+		{"SELECT i FROM t WHERE s > 'fo' ORDER BY i", []sql.Row{{int64(2)}, {int64(3)}}},
+		{"SELECT i FROM t WHERE s IN ('first', 'fourth') ORDER BY i", []sql.Row{{int64(1)}, {int64(3)}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			analyzed, rows := query(t, tt.query)
+			require.Equal(tt.expected, rows)
+
+			var indexed bool
+			plan.Inspect(analyzed, func(n sql.Node) bool {
+				if rt, ok := n.(*plan.ResolvedTable); ok {
+					if it, ok := rt.Table.(sql.IndexableTable); ok && it.IndexLookup() != nil {
+						indexed = true
+					}
+				}
+				return true
+			})
+			require.True(indexed, "the query does not use the index:\n%s", analyzed)
+		})
+	}
+}
+
+func mustRows(t *testing.T, table sql.Table) sql.RowIter {
+	t.Helper()
+	iter, err := table.PartitionRows(sql.NewEmptyContext(), partition{})
+	require.NoError(t, err)
+	return iter
+}
@@ -0,0 +1,694 @@
+package kv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	bolt "go.etcd.io/bbolt"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrDuplicateKey is returned when a row is inserted or updated with the
+// primary key of another row of the table.
+var ErrDuplicateKey = errors.NewKind("duplicate entry %s for the primary key of table %s")
+
+// Names of the keys and buckets in the bucket of a table.
+var (
+	schemaKey           = []byte("schema")
+	autoIncrementKey    = []byte("auto_increment")
+	rowsBucket          = []byte("rows")
+	indexesBucket       = []byte("indexes")
+	indexDefinitionsKey = []byte("index_definitions")
+)
+
+// batchSize is the number of rows read in every transaction while the rows
+// of a table are iterated, so the transactions changing them while they
+// are read are not blocked by one that lasts until all of them are read.
+const batchSize = 256
+
+// Table is a table stored in a bucket of the key-value store. The rows are
+// stored by their primary key, so they are sorted by it, or by the order in
+// which they were inserted if the table has no primary key. A row of a
+// table with a primary key is always identified by it, so deleting a row
+// deletes the one with its primary key.
+type Table struct {
+	name   string
+	schema sql.Schema
+	db     *Database
+	// keys are the positions of the columns of the primary key.
+	keys   []int
+	lookup sql.IndexLookup
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.Inserter = (*Table)(nil)
+var _ sql.BatchInserter = (*Table)(nil)
+var _ sql.Replacer = (*Table)(nil)
+var _ sql.Updater = (*Table)(nil)
+var _ sql.AutoIncrementTable = (*Table)(nil)
+var _ sql.IndexableTable = (*Table)(nil)
+
+func newTable(db *Database, name string, schema sql.Schema) *Table {
+	t := &Table{name: name, schema: schema, db: db}
+	for i, col := range schema {
+		if col.PrimaryKey {
+			t.keys = append(t.keys, i)
+		}
+	}
+	return t
+}
+
+// createTable creates the bucket of a new table without rows.
+func createTable(db *Database, name string, schema sql.Schema) (*Table, error) {
+	data, err := encodeSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(name))
+		if err != nil {
+			return err
+		}
+
+		if _, err := b.CreateBucket(rowsBucket); err != nil {
+			return err
+		}
+
+		if _, err := b.CreateBucket(indexesBucket); err != nil {
+			return err
+		}
+
+		return b.Put(schemaKey, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTable(db, name, schema), nil
+}
+
+// openTable reads the schema and the indexes of a table from its bucket.
+// It's only called while the database is opened, so its indexes are added
+// without locking them.
+func openTable(db *Database, name string, b *bolt.Bucket) (*Table, error) {
+	data := b.Get(schemaKey)
+	if data == nil || b.Bucket(rowsBucket) == nil || b.Bucket(indexesBucket) == nil {
+		return nil, ErrCorruptTable.New(name, "missing schema or rows")
+	}
+
+	schema, err := decodeSchema(name, data)
+	if err != nil {
+		return nil, ErrCorruptTable.New(name, err)
+	}
+
+	t := newTable(db, name, schema)
+
+	definitions, err := readIndexDefinitions(b)
+	if err != nil {
+		return nil, ErrCorruptTable.New(name, err)
+	}
+
+	for _, def := range definitions {
+		idx, err := newIndex(t, def)
+		if err != nil {
+			return nil, ErrCorruptTable.New(name, err)
+		}
+		db.indexes[name] = append(db.indexes[name], idx)
+	}
+
+	return t, nil
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("KVTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the table are
+// in a single partition.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface. The rows are read in
+// batches, each of them in its own transaction, so the rows changed while
+// they are read may or may not be returned.
+func (t *Table) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
+	if t.lookup == nil {
+		return &rowIter{iter: &tableIter{t: t}}, nil
+	}
+
+	values, err := t.lookup.Values(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rowIter{iter: &lookupIter{t: t, values: values}}, nil
+}
+
+// WithIndexLookup implements the sql.IndexableTable interface.
+func (t *Table) WithIndexLookup(lookup sql.IndexLookup) sql.Table {
+	if lookup == nil {
+		return t
+	}
+
+	nt := *t
+	nt.lookup = lookup
+	return &nt
+}
+
+// IndexLookup implements the sql.IndexableTable interface.
+func (t *Table) IndexLookup() sql.IndexLookup {
+	return t.lookup
+}
+
+// IndexKeyValues implements the sql.IndexableTable interface. The values
+// of the rows are their keys in the table.
+func (t *Table) IndexKeyValues(
+	ctx *sql.Context,
+	colNames []string,
+) (sql.PartitionIndexKeyValueIter, error) {
+	columns := make([]int, len(colNames))
+	for i, name := range colNames {
+		columns[i] = t.columnIndex(name)
+		if columns[i] < 0 {
+			return nil, errColumnNotFound.New(name)
+		}
+	}
+
+	return &partitionIndexKeyValueIter{t: t, columns: columns}, nil
+}
+
+// Insert implements the sql.Inserter interface.
+func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
+	return t.InsertBatch(ctx, []sql.Row{row})
+}
+
+// InsertBatch implements the sql.BatchInserter interface. All the rows are
+// inserted in a single transaction of the store.
+func (t *Table) InsertBatch(ctx *sql.Context, rows []sql.Row) error {
+	for _, row := range rows {
+		if err := checkRow(t.schema, row); err != nil {
+			return err
+		}
+	}
+
+	return t.db.update(func(tx *bolt.Tx) error {
+		b, bucket, err := t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := t.insert(b, bucket, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insert inserts a row in the bucket of the rows of the table.
+func (t *Table) insert(b, rows *bolt.Bucket, row sql.Row) error {
+	var key []byte
+	if len(t.keys) > 0 {
+		var err error
+		if key, err = t.rowKey(row); err != nil {
+			return err
+		}
+
+		if rows.Get(key) != nil {
+			return ErrDuplicateKey.New(t.keyString(row), t.name)
+		}
+	} else {
+		seq, err := rows.NextSequence()
+		if err != nil {
+			return err
+		}
+		key = appendUint(nil, seq)
+	}
+
+	if err := t.put(b, rows, key, row); err != nil {
+		return err
+	}
+
+	return t.updateAutoIncrement(b, row)
+}
+
+// Delete implements the sql.Deleter interface.
+func (t *Table) Delete(ctx *sql.Context, row sql.Row) error {
+	if err := checkRow(t.schema, row); err != nil {
+		return err
+	}
+
+	return t.db.update(func(tx *bolt.Tx) error {
+		b, rows, err := t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		key, old, err := t.find(rows, row)
+		if err != nil {
+			return err
+		}
+
+		if key == nil {
+			return sql.ErrDeleteRowNotFound
+		}
+
+		return t.remove(b, rows, key, old)
+	})
+}
+
+// Update implements the sql.Updater interface.
+func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
+	if err := checkRow(t.schema, oldRow); err != nil {
+		return err
+	}
+	if err := checkRow(t.schema, newRow); err != nil {
+		return err
+	}
+
+	return t.db.update(func(tx *bolt.Tx) error {
+		b, rows, err := t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		key, old, err := t.find(rows, oldRow)
+		if err != nil || key == nil {
+			return err
+		}
+
+		newKey := key
+		if len(t.keys) > 0 {
+			if newKey, err = t.rowKey(newRow); err != nil {
+				return err
+			}
+
+			if !bytes.Equal(key, newKey) && rows.Get(newKey) != nil {
+				return ErrDuplicateKey.New(t.keyString(newRow), t.name)
+			}
+		}
+
+		if err := t.remove(b, rows, key, old); err != nil {
+			return err
+		}
+
+		if err := t.put(b, rows, newKey, newRow); err != nil {
+			return err
+		}
+
+		return t.updateAutoIncrement(b, newRow)
+	})
+}
+
+// NextAutoIncrementValue implements the sql.AutoIncrementTable interface.
+func (t *Table) NextAutoIncrementValue(*sql.Context) (uint64, error) {
+	var next uint64
+	err := t.db.view(func(tx *bolt.Tx) error {
+		b, _, err := t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		next = decodeUint(b.Get(autoIncrementKey)) + 1
+		return nil
+	})
+	return next, err
+}
+
+// buckets returns the bucket of the table and the bucket of its rows.
+func (t *Table) buckets(tx *bolt.Tx) (*bolt.Bucket, *bolt.Bucket, error) {
+	b := tx.Bucket([]byte(t.name))
+	if b == nil {
+		return nil, nil, sql.ErrTableNotFound.New(t.name)
+	}
+	return b, b.Bucket(rowsBucket), nil
+}
+
+// columnIndex returns the position of the column with the given name, or
+// -1 if there is none.
+func (t *Table) columnIndex(name string) int {
+	for i, col := range t.schema {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowKey returns the key of a row of a table with a primary key.
+func (t *Table) rowKey(row sql.Row) ([]byte, error) {
+	var key []byte
+	for _, i := range t.keys {
+		var err error
+		if key, err = appendKey(key, t.schema[i].Type, row[i]); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (t *Table) keyString(row sql.Row) string {
+	values := make([]string, len(t.keys))
+	for i, c := range t.keys {
+		values[i] = fmt.Sprint(row[c])
+	}
+	return "'" + strings.Join(values, "-") + "'"
+}
+
+// find returns the key of the row of the table with the key of the given
+// one, or equal to it if the table has no primary key, along with the
+// stored row. The key is nil if there is no such row.
+func (t *Table) find(rows *bolt.Bucket, row sql.Row) ([]byte, sql.Row, error) {
+	if len(t.keys) > 0 {
+		key, err := t.rowKey(row)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data := rows.Get(key)
+		if data == nil {
+			return nil, nil, nil
+		}
+
+		old, err := decodeRow(data)
+		if err != nil {
+			return nil, nil, ErrCorruptTable.New(t.name, err)
+		}
+		return key, old, nil
+	}
+
+	c := rows.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		old, err := decodeRow(v)
+		if err != nil {
+			return nil, nil, ErrCorruptTable.New(t.name, err)
+		}
+
+		if reflect.DeepEqual(old, row) {
+			return append([]byte(nil), k...), old, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// put stores the row with the given key and adds it to the indexes.
+func (t *Table) put(b, rows *bolt.Bucket, key []byte, row sql.Row) error {
+	data, err := encodeRow(row)
+	if err != nil {
+		return err
+	}
+
+	if err := rows.Put(key, data); err != nil {
+		return err
+	}
+
+	for _, idx := range t.db.tableIndexes(t.name) {
+		if err := idx.add(b, key, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove removes the row with the given key and removes it from the
+// indexes.
+func (t *Table) remove(b, rows *bolt.Bucket, key []byte, row sql.Row) error {
+	for _, idx := range t.db.tableIndexes(t.name) {
+		if err := idx.remove(b, key, row); err != nil {
+			return err
+		}
+	}
+	return rows.Delete(key)
+}
+
+// updateAutoIncrement stores the greatest value of the auto increment
+// column of the table, if it has one, so generated values are never
+// repeated, even after the rows with the greatest values are deleted.
+func (t *Table) updateAutoIncrement(b *bolt.Bucket, row sql.Row) error {
+	for i, col := range t.schema {
+		if !col.AutoIncrement || row[i] == nil {
+			continue
+		}
+
+		v, err := sql.Int64.Convert(row[i])
+		if err != nil || v.(int64) <= 0 {
+			continue
+		}
+
+		if uint64(v.(int64)) > decodeUint(b.Get(autoIncrementKey)) {
+			if err := b.Put(autoIncrementKey, appendUint(nil, uint64(v.(int64)))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkRow(schema sql.Schema, row sql.Row) error {
+	if len(row) != len(schema) {
+		return sql.ErrUnexpectedRowLength.New(len(schema), len(row))
+	}
+
+	for i, value := range row {
+		if !schema[i].Check(value) {
+			return sql.ErrInvalidType.New(value)
+		}
+	}
+
+	return nil
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+// keyRowIter is an iterator of the rows of a table with their keys.
+type keyRowIter interface {
+	next() ([]byte, sql.Row, error)
+	close() error
+}
+
+// tableIter iterates all the rows of a table in the order of their keys.
+type tableIter struct {
+	t *Table
+	// last is the key of the last row read.
+	last []byte
+	keys [][]byte
+	rows []sql.Row
+	pos  int
+	done bool
+}
+
+func (i *tableIter) next() ([]byte, sql.Row, error) {
+	if i.pos >= len(i.rows) {
+		if i.done {
+			return nil, nil, io.EOF
+		}
+
+		if err := i.read(); err != nil {
+			return nil, nil, err
+		}
+
+		if len(i.rows) == 0 {
+			return nil, nil, io.EOF
+		}
+	}
+
+	key, row := i.keys[i.pos], i.rows[i.pos]
+	i.pos++
+	return key, row, nil
+}
+
+// read reads the next batch of rows.
+func (i *tableIter) read() error {
+	i.keys, i.rows, i.pos = i.keys[:0], i.rows[:0], 0
+	return i.t.db.view(func(tx *bolt.Tx) error {
+		_, rows, err := i.t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		c := rows.Cursor()
+		k, v := c.First()
+		if i.last != nil {
+			k, v = c.Seek(i.last)
+			if bytes.Equal(k, i.last) {
+				k, v = c.Next()
+			}
+		}
+
+		for ; k != nil && len(i.rows) < batchSize; k, v = c.Next() {
+			row, err := decodeRow(v)
+			if err != nil {
+				return ErrCorruptTable.New(i.t.name, err)
+			}
+
+			i.keys = append(i.keys, append([]byte(nil), k...))
+			i.rows = append(i.rows, row)
+		}
+
+		i.done = k == nil
+		if len(i.keys) > 0 {
+			i.last = i.keys[len(i.keys)-1]
+		}
+		return nil
+	})
+}
+
+func (i *tableIter) close() error { return nil }
+
+// lookupIter iterates the rows of a table with the keys returned by an
+// index lookup. The keys of the rows deleted after the lookup was made are
+// skipped.
+type lookupIter struct {
+	t      *Table
+	values sql.IndexValueIter
+	keys   [][]byte
+	rows   []sql.Row
+	pos    int
+}
+
+func (i *lookupIter) next() ([]byte, sql.Row, error) {
+	for i.pos >= len(i.rows) {
+		if err := i.read(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	key, row := i.keys[i.pos], i.rows[i.pos]
+	i.pos++
+	return key, row, nil
+}
+
+// read reads the rows of the next batch of keys.
+func (i *lookupIter) read() error {
+	var keys [][]byte
+	for len(keys) < batchSize {
+		key, err := i.values.Next()
+		if err == io.EOF {
+			if len(keys) == 0 {
+				return io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	i.keys, i.rows, i.pos = i.keys[:0], i.rows[:0], 0
+	return i.t.db.view(func(tx *bolt.Tx) error {
+		_, rows, err := i.t.buckets(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			data := rows.Get(key)
+			if data == nil {
+				continue
+			}
+
+			row, err := decodeRow(data)
+			if err != nil {
+				return ErrCorruptTable.New(i.t.name, err)
+			}
+
+			i.keys = append(i.keys, key)
+			i.rows = append(i.rows, row)
+		}
+		return nil
+	})
+}
+
+func (i *lookupIter) close() error { return i.values.Close() }
+
+type rowIter struct {
+	iter keyRowIter
+}
+
+func (i *rowIter) Next() (sql.Row, error) {
+	_, row, err := i.iter.next()
+	return row, err
+}
+
+func (i *rowIter) Close() error { return i.iter.close() }
+
+type partitionIndexKeyValueIter struct {
+	t       *Table
+	columns []int
+	done    bool
+}
+
+func (i *partitionIndexKeyValueIter) Next() (sql.Partition, sql.IndexKeyValueIter, error) {
+	if i.done {
+		return nil, nil, io.EOF
+	}
+	i.done = true
+	return partition{}, &indexKeyValueIter{iter: &tableIter{t: i.t}, columns: i.columns}, nil
+}
+
+func (i *partitionIndexKeyValueIter) Close() error { return nil }
+
+type indexKeyValueIter struct {
+	iter    *tableIter
+	columns []int
+}
+
+func (i *indexKeyValueIter) Next() ([]interface{}, []byte, error) {
+	key, row, err := i.iter.next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make([]interface{}, len(i.columns))
+	for j, c := range i.columns {
+		values[j] = row[c]
+	}
+	return values, key, nil
+}
+
+func (i *indexKeyValueIter) Close() error { return nil }
@@ -23,6 +23,12 @@ func (d *Database) Name() string {
 	return d.name
 }
 
+// Rename implements the sql.DatabaseRenamer interface.
+func (d *Database) Rename(name string) error {
+	d.name = name
+	return nil
+}
+
 // Tables returns all tables in the database.
 func (d *Database) Tables() map[string]sql.Table {
 	return d.tables
@@ -44,6 +50,28 @@ func (d *Database) CreateTable(ctx *sql.Context, name string, schema sql.Schema)
 	return nil
 }
 
+// CreatePartitionedTable creates a table with the given name and schema,
+// whose rows are distributed in partitions as declared in the partitioning.
+func (d *Database) CreatePartitionedTable(
+	ctx *sql.Context,
+	name string,
+	schema sql.Schema,
+	partitioning *sql.Partitioning,
+) error {
+	_, ok := d.tables[name]
+	if ok {
+		return sql.ErrTableAlreadyExists.New(name)
+	}
+
+	t, err := NewTableWithPartitioning(name, schema, partitioning)
+	if err != nil {
+		return err
+	}
+
+	d.tables[name] = t
+	return nil
+}
+
 // DropTable drops the table with the given name
 func (d *Database) DropTable(ctx *sql.Context, name string) error {
 	_, ok := d.tables[name]
@@ -55,3 +83,13 @@ func (d *Database) DropTable(ctx *sql.Context, name string) error {
 	return nil
 }
 
+// DatabaseCreator creates in-memory databases for the CREATE DATABASE
+// statements of a catalog.
+type DatabaseCreator struct{}
+
+var _ sql.DatabaseCreator = DatabaseCreator{}
+
+// CreateDatabase implements the sql.DatabaseCreator interface.
+func (DatabaseCreator) CreateDatabase(ctx *sql.Context, name string) (sql.Database, error) {
+	return NewDatabase(name), nil
+}
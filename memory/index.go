@@ -0,0 +1,752 @@
+package memory
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// IndexDriverID is the ID of the driver of the indexes of memory tables.
+const IndexDriverID = "memory"
+
+// Kinds of the indexes of memory tables, given in the "type" option of
+// CREATE INDEX.
+const (
+	// BTreeIndex keeps the keys sorted, so it can be used for ranges as
+	// well as for equalities. It's the default.
+	BTreeIndex = "btree"
+	// HashIndex can only be used for equalities.
+	HashIndex = "hash"
+)
+
+var (
+	// ErrInvalidIndexType is returned when an index is created with a type
+	// that is not supported.
+	ErrInvalidIndexType = errors.NewKind("invalid index type %q, expecting btree or hash")
+
+	// ErrNotMemoryTable is returned when an index of the memory driver is
+	// created on a table that is not a memory table.
+	ErrNotMemoryTable = errors.NewKind("table %s of database %s is not a memory table")
+
+	errNotMemoryIndex   = errors.NewKind("index %s is not an index of memory tables")
+	errInvalidKeyLength = errors.NewKind("index %s has %d expressions, but the key has %d values")
+)
+
+// IndexDriver is the driver of the indexes of memory tables, which are kept
+// in memory and updated with every change of the rows of their tables, so
+// point and range lookups do not read the whole table.
+type IndexDriver struct {
+	dbs []*Database
+
+	mu      sync.Mutex
+	indexes map[string][]sql.Index
+}
+
+var _ sql.IndexDriver = (*IndexDriver)(nil)
+
+// NewIndexDriver creates the driver of the indexes of the tables of the
+// given databases.
+func NewIndexDriver(dbs ...*Database) *IndexDriver {
+	return &IndexDriver{dbs: dbs, indexes: make(map[string][]sql.Index)}
+}
+
+// ID implements the sql.IndexDriver interface.
+func (d *IndexDriver) ID() string { return IndexDriverID }
+
+// Create implements the sql.IndexDriver interface.
+func (d *IndexDriver) Create(
+	db, table, id string,
+	expressions []sql.Expression,
+	config map[string]string,
+) (sql.Index, error) {
+	t, err := d.table(db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	// The expressions are evaluated on the rows of the table when they
+	// change, so their fields are the ones of the table.
+	exprs := make([]sql.Expression, len(expressions))
+	for i, e := range expressions {
+		exprs[i], err = expression.TransformUp(e, func(e sql.Expression) (sql.Expression, error) {
+			gf, ok := e.(*expression.GetField)
+			if !ok {
+				return e, nil
+			}
+
+			for j, col := range t.schema {
+				if strings.EqualFold(col.Name, gf.Name()) {
+					return gf.WithIndex(j), nil
+				}
+			}
+			return nil, errColumnNotFound.New(gf.Name())
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	base := &memoryIndex{
+		db:          db,
+		table:       table,
+		id:          id,
+		exprs:       exprs,
+		expressions: make([]string, len(expressions)),
+		t:           t,
+	}
+	for i, e := range expressions {
+		base.expressions[i] = e.String()
+	}
+
+	switch strings.ToLower(config["type"]) {
+	case "", BTreeIndex:
+		base.newKeys = newBTreeKeys
+		return &btreeIndex{base}, nil
+	case HashIndex:
+		base.newKeys = newHashKeys
+		return &hashIndex{base}, nil
+	default:
+		return nil, ErrInvalidIndexType.New(config["type"])
+	}
+}
+
+func (d *IndexDriver) table(db, table string) (*Table, error) {
+	for _, database := range d.dbs {
+		if !strings.EqualFold(database.Name(), db) {
+			continue
+		}
+
+		for name, t := range database.Tables() {
+			if strings.EqualFold(name, table) {
+				if t, ok := t.(*Table); ok {
+					return t, nil
+				}
+			}
+		}
+	}
+	return nil, ErrNotMemoryTable.New(table, db)
+}
+
+// LoadAll implements the sql.IndexDriver interface. Indexes are only kept in
+// memory, so it returns the ones created by the driver.
+func (d *IndexDriver) LoadAll(db, table string) ([]sql.Index, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.indexes[db+"."+table], nil
+}
+
+// Save implements the sql.IndexDriver interface. Once the rows are indexed,
+// the index is updated with the changes of the table.
+func (d *IndexDriver) Save(
+	ctx *sql.Context,
+	i sql.Index,
+	iter sql.PartitionIndexKeyValueIter,
+) error {
+	idx, ok := indexOf(i)
+	if !ok {
+		return errNotMemoryIndex.New(i.ID())
+	}
+
+	keys := make(map[string]indexKeys)
+	for {
+		p, kviter, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = iter.Close()
+			return err
+		}
+
+		k := idx.emptyKeys()
+		for {
+			values, location, err := kviter.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				_ = kviter.Close()
+				_ = iter.Close()
+				return err
+			}
+
+			value, err := decodeIndexValue(location)
+			if err != nil {
+				_ = kviter.Close()
+				_ = iter.Close()
+				return err
+			}
+
+			key, err := idx.convert(values)
+			if err != nil {
+				_ = kviter.Close()
+				_ = iter.Close()
+				return err
+			}
+			k.add(key, value.Pos)
+		}
+
+		if err := kviter.Close(); err != nil {
+			_ = iter.Close()
+			return err
+		}
+		keys[string(p.Key())] = k
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.partitions = keys
+	idx.mu.Unlock()
+	idx.t.addIndex(idx)
+
+	d.mu.Lock()
+	key := idx.db + "." + idx.table
+	d.indexes[key] = append(d.indexes[key], i)
+	d.mu.Unlock()
+	return nil
+}
+
+// Delete implements the sql.IndexDriver interface.
+func (d *IndexDriver) Delete(i sql.Index, _ sql.PartitionIter) error {
+	idx, ok := indexOf(i)
+	if !ok {
+		return errNotMemoryIndex.New(i.ID())
+	}
+
+	idx.t.removeIndex(idx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := idx.db + "." + idx.table
+	indexes := d.indexes[key]
+	for j, other := range indexes {
+		if other == i {
+			d.indexes[key] = append(indexes[:j:j], indexes[j+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func indexOf(i sql.Index) (*memoryIndex, bool) {
+	switch i := i.(type) {
+	case *btreeIndex:
+		return i.memoryIndex, true
+	case *hashIndex:
+		return i.memoryIndex, true
+	default:
+		return nil, false
+	}
+}
+
+// memoryIndex has the keys of the rows of every partition of a table, with
+// their positions in the partition.
+type memoryIndex struct {
+	db, table, id string
+	exprs         []sql.Expression
+	expressions   []string
+	t             *Table
+	newKeys       func([]sql.Type) indexKeys
+
+	mu         sync.RWMutex
+	partitions map[string]indexKeys
+}
+
+// ID implements the sql.Index interface.
+func (i *memoryIndex) ID() string { return i.id }
+
+// Database implements the sql.Index interface.
+func (i *memoryIndex) Database() string { return i.db }
+
+// Table implements the sql.Index interface.
+func (i *memoryIndex) Table() string { return i.table }
+
+// Expressions implements the sql.Index interface.
+func (i *memoryIndex) Expressions() []string { return i.expressions }
+
+// Driver implements the sql.Index interface.
+func (i *memoryIndex) Driver() string { return IndexDriverID }
+
+// Get implements the sql.Index interface.
+func (i *memoryIndex) Get(key ...interface{}) (sql.IndexLookup, error) {
+	k, err := i.convert(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.lookup(func(keys indexKeys) []int {
+		return keys.equal(k)
+	}), nil
+}
+
+// Has implements the sql.Index interface.
+func (i *memoryIndex) Has(partition sql.Partition, key ...interface{}) (bool, error) {
+	k, err := i.convert(key)
+	if err != nil {
+		return false, err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	keys, ok := i.partitions[string(partition.Key())]
+	return ok && len(keys.equal(k)) > 0, nil
+}
+
+// emptyKeys returns the keys of a partition without rows.
+func (i *memoryIndex) emptyKeys() indexKeys {
+	types := make([]sql.Type, len(i.exprs))
+	for j, e := range i.exprs {
+		types[j] = e.Type()
+	}
+	return i.newKeys(types)
+}
+
+// convert converts the values of a key to the types of the expressions, so
+// they can be compared with the ones of the rows.
+func (i *memoryIndex) convert(key []interface{}) ([]interface{}, error) {
+	if len(key) != len(i.exprs) {
+		return nil, errInvalidKeyLength.New(i.id, len(i.exprs), len(key))
+	}
+
+	converted := make([]interface{}, len(key))
+	for j, v := range key {
+		if v == nil {
+			continue
+		}
+
+		var err error
+		converted[j], err = i.exprs[j].Type().Convert(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return converted, nil
+}
+
+// keyOf returns the key of the given row of the table.
+func (i *memoryIndex) keyOf(row sql.Row) ([]interface{}, error) {
+	values := make([]interface{}, len(i.exprs))
+	for j, e := range i.exprs {
+		v, err := e.Eval(sql.NewEmptyContext(), row)
+		if err != nil {
+			return nil, err
+		}
+		values[j] = v
+	}
+	return i.convert(values)
+}
+
+// add adds the key of the row at the given position of a partition.
+func (i *memoryIndex) add(partition string, key []interface{}, pos int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	keys, ok := i.partitions[partition]
+	if !ok {
+		keys = i.emptyKeys()
+		i.partitions[partition] = keys
+	}
+	keys.add(key, pos)
+}
+
+// remove removes the key of the row at the given position of a partition.
+// If shift is true, the rows after it were moved to the previous position.
+func (i *memoryIndex) remove(partition string, key []interface{}, pos int, shift bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if keys, ok := i.partitions[partition]; ok {
+		keys.remove(key, pos, shift)
+	}
+}
+
+// lookup returns a lookup of the positions returned by the given function
+// for the keys of every partition.
+func (i *memoryIndex) lookup(positions func(indexKeys) []int) *indexLookup {
+	return &indexLookup{
+		index: i,
+		ids:   []string{i.id},
+		positions: func(partition string) []int {
+			i.mu.RLock()
+			defer i.mu.RUnlock()
+
+			keys, ok := i.partitions[partition]
+			if !ok {
+				return nil
+			}
+
+			p := positions(keys)
+			sort.Ints(p)
+			return p
+		},
+	}
+}
+
+// btreeIndex is an index whose keys are sorted, so it can also be used for
+// ranges of keys.
+type btreeIndex struct{ *memoryIndex }
+
+var _ sql.AscendIndex = (*btreeIndex)(nil)
+var _ sql.DescendIndex = (*btreeIndex)(nil)
+
+// AscendGreaterOrEqual implements the sql.AscendIndex interface.
+func (i *btreeIndex) AscendGreaterOrEqual(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(keys, true, nil, false)
+}
+
+// AscendLessThan implements the sql.AscendIndex interface.
+func (i *btreeIndex) AscendLessThan(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(nil, false, keys, false)
+}
+
+// AscendRange implements the sql.AscendIndex interface.
+func (i *btreeIndex) AscendRange(greaterOrEqual, lessThan []interface{}) (sql.IndexLookup, error) {
+	return i.between(greaterOrEqual, true, lessThan, false)
+}
+
+// DescendGreater implements the sql.DescendIndex interface.
+func (i *btreeIndex) DescendGreater(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(keys, false, nil, false)
+}
+
+// DescendLessOrEqual implements the sql.DescendIndex interface.
+func (i *btreeIndex) DescendLessOrEqual(keys ...interface{}) (sql.IndexLookup, error) {
+	return i.between(nil, false, keys, true)
+}
+
+// DescendRange implements the sql.DescendIndex interface.
+func (i *btreeIndex) DescendRange(lessOrEqual, greaterThan []interface{}) (sql.IndexLookup, error) {
+	return i.between(greaterThan, false, lessOrEqual, true)
+}
+
+// between returns the lookup of the keys between the given ones, which are
+// not bounded if they are nil.
+func (i *btreeIndex) between(
+	from []interface{},
+	fromInclusive bool,
+	to []interface{},
+	toInclusive bool,
+) (sql.IndexLookup, error) {
+	var err error
+	if from != nil {
+		if from, err = i.convert(from); err != nil {
+			return nil, err
+		}
+	}
+
+	if to != nil {
+		if to, err = i.convert(to); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.lookup(func(keys indexKeys) []int {
+		return keys.(*btreeKeys).between(from, fromInclusive, to, toInclusive)
+	}), nil
+}
+
+// hashIndex is an index whose keys are hashed, so it can only be used for
+// equalities.
+type hashIndex struct{ *memoryIndex }
+
+// indexLookup is a lookup of an index of a memory table. The positions of
+// the rows are found when they are read, so they are the ones of the rows
+// in the table at that moment.
+type indexLookup struct {
+	index     *memoryIndex
+	ids       []string
+	positions func(partition string) []int
+}
+
+var _ sql.IndexLookup = (*indexLookup)(nil)
+var _ sql.SetOperations = (*indexLookup)(nil)
+var _ sql.Mergeable = (*indexLookup)(nil)
+
+// Values implements the sql.IndexLookup interface.
+func (l *indexLookup) Values(p sql.Partition) (sql.IndexValueIter, error) {
+	key := string(p.Key())
+	return &indexValueIter{key: key, positions: l.positions(key)}, nil
+}
+
+// Indexes implements the sql.IndexLookup interface.
+func (l *indexLookup) Indexes() []string { return l.ids }
+
+// IsMergeable implements the sql.Mergeable interface. Lookups of indexes of
+// the same table can be merged.
+func (l *indexLookup) IsMergeable(other sql.IndexLookup) bool {
+	o, ok := other.(*indexLookup)
+	return ok && o.index.t == l.index.t
+}
+
+// Intersection implements the sql.SetOperations interface.
+func (l *indexLookup) Intersection(lookups ...sql.IndexLookup) sql.IndexLookup {
+	return l.merge(lookups, func(a, b []int) []int {
+		var result []int
+		for i, j := 0, 0; i < len(a) && j < len(b); {
+			switch {
+			case a[i] < b[j]:
+				i++
+			case a[i] > b[j]:
+				j++
+			default:
+				result = append(result, a[i])
+				i++
+				j++
+			}
+		}
+		return result
+	})
+}
+
+// Union implements the sql.SetOperations interface.
+func (l *indexLookup) Union(lookups ...sql.IndexLookup) sql.IndexLookup {
+	return l.merge(lookups, func(a, b []int) []int {
+		var result []int
+		i, j := 0, 0
+		for i < len(a) && j < len(b) {
+			switch {
+			case a[i] < b[j]:
+				result = append(result, a[i])
+				i++
+			case a[i] > b[j]:
+				result = append(result, b[j])
+				j++
+			default:
+				result = append(result, a[i])
+				i++
+				j++
+			}
+		}
+		result = append(result, a[i:]...)
+		return append(result, b[j:]...)
+	})
+}
+
+// Difference implements the sql.SetOperations interface.
+func (l *indexLookup) Difference(lookups ...sql.IndexLookup) sql.IndexLookup {
+	return l.merge(lookups, func(a, b []int) []int {
+		var result []int
+		j := 0
+		for _, p := range a {
+			for j < len(b) && b[j] < p {
+				j++
+			}
+			if j >= len(b) || b[j] != p {
+				result = append(result, p)
+			}
+		}
+		return result
+	})
+}
+
+func (l *indexLookup) merge(lookups []sql.IndexLookup, op func(a, b []int) []int) sql.IndexLookup {
+	ids := append([]string(nil), l.ids...)
+	positions := l.positions
+	for _, other := range lookups {
+		o := other.(*indexLookup)
+		ids = append(ids, o.ids...)
+
+		left := positions
+		positions = func(partition string) []int {
+			return op(left(partition), o.positions(partition))
+		}
+	}
+
+	return &indexLookup{index: l.index, ids: ids, positions: positions}
+}
+
+type indexValueIter struct {
+	key       string
+	positions []int
+	pos       int
+}
+
+func (i *indexValueIter) Next() ([]byte, error) {
+	if i.pos >= len(i.positions) {
+		return nil, io.EOF
+	}
+
+	value := &indexValue{Key: i.key, Pos: i.positions[i.pos]}
+	i.pos++
+	return encodeIndexValue(value)
+}
+
+func (i *indexValueIter) Close() error { return nil }
+
+// indexKeys are the keys of the rows of a partition.
+type indexKeys interface {
+	add(key []interface{}, pos int)
+	remove(key []interface{}, pos int, shift bool)
+	equal(key []interface{}) []int
+}
+
+// btreeKeys are the keys of an index sorted by their values and positions.
+type btreeKeys struct {
+	types   []sql.Type
+	entries []indexEntry
+}
+
+type indexEntry struct {
+	key []interface{}
+	pos int
+}
+
+func newBTreeKeys(types []sql.Type) indexKeys {
+	return &btreeKeys{types: types}
+}
+
+func (k *btreeKeys) add(key []interface{}, pos int) {
+	i := k.search(key, pos)
+	k.entries = append(k.entries, indexEntry{})
+	copy(k.entries[i+1:], k.entries[i:])
+	k.entries[i] = indexEntry{key, pos}
+}
+
+func (k *btreeKeys) remove(key []interface{}, pos int, shift bool) {
+	i := k.search(key, pos)
+	if i < len(k.entries) && k.entries[i].pos == pos {
+		k.entries = append(k.entries[:i], k.entries[i+1:]...)
+	}
+
+	if shift {
+		for j := range k.entries {
+			if k.entries[j].pos > pos {
+				k.entries[j].pos--
+			}
+		}
+	}
+}
+
+// search returns the position of the first entry that is not less than the
+// given key and position.
+func (k *btreeKeys) search(key []interface{}, pos int) int {
+	return sort.Search(len(k.entries), func(i int) bool {
+		e := k.entries[i]
+		if c := compareKeys(k.types, e.key, key); c != 0 {
+			return c > 0
+		}
+		return e.pos >= pos
+	})
+}
+
+func (k *btreeKeys) equal(key []interface{}) []int {
+	return k.between(key, true, key, true)
+}
+
+func (k *btreeKeys) between(
+	from []interface{},
+	fromInclusive bool,
+	to []interface{},
+	toInclusive bool,
+) []int {
+	if hasNulls(from) || hasNulls(to) {
+		return nil
+	}
+
+	start := 0
+	if from != nil {
+		start = sort.Search(len(k.entries), func(i int) bool {
+			c := compareKeys(k.types, k.entries[i].key, from)
+			return c > 0 || (c == 0 && fromInclusive)
+		})
+	}
+
+	var positions []int
+	for _, e := range k.entries[start:] {
+		if to != nil {
+			c := compareKeys(k.types, e.key, to)
+			if c > 0 || (c == 0 && !toInclusive) {
+				break
+			}
+		}
+
+		if !hasNulls(e.key) {
+			positions = append(positions, e.pos)
+		}
+	}
+	return positions
+}
+
+// hashKeys are the keys of an index hashed by their values.
+type hashKeys struct {
+	buckets map[string][]int
+}
+
+func newHashKeys([]sql.Type) indexKeys {
+	return &hashKeys{buckets: make(map[string][]int)}
+}
+
+func hashKey(key []interface{}) string {
+	return fmt.Sprintf("%#v", key)
+}
+
+func (k *hashKeys) add(key []interface{}, pos int) {
+	h := hashKey(key)
+	k.buckets[h] = append(k.buckets[h], pos)
+}
+
+func (k *hashKeys) remove(key []interface{}, pos int, shift bool) {
+	h := hashKey(key)
+	bucket := k.buckets[h]
+	for i, p := range bucket {
+		if p == pos {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+
+	if len(bucket) == 0 {
+		delete(k.buckets, h)
+	} else {
+		k.buckets[h] = bucket
+	}
+
+	if shift {
+		for _, bucket := range k.buckets {
+			for i, p := range bucket {
+				if p > pos {
+					bucket[i] = p - 1
+				}
+			}
+		}
+	}
+}
+
+func (k *hashKeys) equal(key []interface{}) []int {
+	if hasNulls(key) {
+		return nil
+	}
+	return append([]int(nil), k.buckets[hashKey(key)]...)
+}
+
+func compareKeys(types []sql.Type, a, b []interface{}) int {
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+
+		c, err := types[i].Compare(a[i], b[i])
+		if err != nil || c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func hasNulls(key []interface{}) bool {
+	for _, v := range key {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
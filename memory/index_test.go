@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"io"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func newIndexedTable(t *testing.T, typ string) (*Table, sql.Index) {
+	t.Helper()
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	table := NewPartitionedTable("t", sql.Schema{
+		{Name: "s", Type: sql.Text, Source: "t"},
+		{Name: "i", Type: sql.Int64, Source: "t", Nullable: true},
+	}, 2)
+	for i, s := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(table.Insert(ctx, sql.NewRow(s, int64(i+1))))
+	}
+
+	db := NewDatabase("db")
+	db.AddTable("t", table)
+
+	driver := NewIndexDriver(db)
+	idx, err := driver.Create("db", "t", "idx", []sql.Expression{
+		expression.NewGetFieldWithTable(0, sql.Int64, "t", "i", true),
+	}, map[string]string{"type": typ})
+	require.NoError(err)
+
+	iter, err := table.IndexKeyValues(ctx, []string{"i"})
+	require.NoError(err)
+	require.NoError(driver.Save(ctx, idx, iter))
+
+	indexes, err := driver.LoadAll("db", "t")
+	require.NoError(err)
+	require.Equal([]sql.Index{idx}, indexes)
+
+	return table, idx
+}
+
+func lookupRows(t *testing.T, table *Table, lookup sql.IndexLookup) []sql.Row {
+	t.Helper()
+	ctx := sql.NewEmptyContext()
+	indexed := table.WithIndexLookup(lookup)
+
+	var rows []sql.Row
+	partitions, err := indexed.Partitions(ctx)
+	require.NoError(t, err)
+	for {
+		p, err := partitions.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		iter, err := indexed.PartitionRows(ctx, p)
+		require.NoError(t, err)
+		r, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		rows = append(rows, r...)
+	}
+	return rows
+}
+
+func TestBTreeIndex(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	table, idx := newIndexedTable(t, "")
+
+	require.Equal("idx", idx.ID())
+	require.Equal("db", idx.Database())
+	require.Equal("t", idx.Table())
+	require.Equal([]string{"t.i"}, idx.Expressions())
+	require.Equal(IndexDriverID, idx.Driver())
+
+	lookup, err := idx.Get(int8(3))
+	require.NoError(err)
+	require.Equal([]sql.Row{{"c", int64(3)}}, lookupRows(t, table, lookup))
+
+	ok, err := idx.Has(&partition{key: []byte("0")}, int64(3))
+	require.NoError(err)
+	require.True(ok)
+
+	ai := idx.(sql.AscendIndex)
+	di := idx.(sql.DescendIndex)
+
+	lookup, err = ai.AscendGreaterOrEqual(int64(4))
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{{"d", int64(4)}, {"e", int64(5)}}, lookupRows(t, table, lookup))
+
+	lookup, err = ai.AscendLessThan(int64(2))
+	require.NoError(err)
+	require.Equal([]sql.Row{{"a", int64(1)}}, lookupRows(t, table, lookup))
+
+	lookup, err = ai.AscendRange([]interface{}{int64(2)}, []interface{}{int64(4)})
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{{"b", int64(2)}, {"c", int64(3)}}, lookupRows(t, table, lookup))
+
+	lookup, err = di.DescendGreater(int64(4))
+	require.NoError(err)
+	require.Equal([]sql.Row{{"e", int64(5)}}, lookupRows(t, table, lookup))
+
+	lookup, err = di.DescendLessOrEqual(int64(1))
+	require.NoError(err)
+	require.Equal([]sql.Row{{"a", int64(1)}}, lookupRows(t, table, lookup))
+
+	lookup, err = di.DescendRange([]interface{}{int64(4)}, []interface{}{int64(2)})
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{{"c", int64(3)}, {"d", int64(4)}}, lookupRows(t, table, lookup))
+
+	// The lookups read the rows of the table when they are read.
+	lookup, err = ai.AscendGreaterOrEqual(int64(3))
+	require.NoError(err)
+
+	require.NoError(table.Insert(ctx, sql.NewRow("f", int64(6))))
+	require.NoError(table.Insert(ctx, sql.NewRow("g", nil)))
+	require.NoError(table.Delete(ctx, sql.NewRow("a", int64(1))))
+	require.NoError(table.Delete(ctx, sql.NewRow("d", int64(4))))
+	require.NoError(table.Update(ctx, sql.NewRow("c", int64(3)), sql.NewRow("c", int64(30))))
+	require.NoError(table.Update(ctx, sql.NewRow("e", int64(5)), sql.NewRow("e", int64(2))))
+
+	require.ElementsMatch([]sql.Row{
+		{"f", int64(6)},
+		{"c", int64(30)},
+	}, lookupRows(t, table, lookup))
+
+	lookup, err = idx.Get(int64(2))
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{{"b", int64(2)}, {"e", int64(2)}}, lookupRows(t, table, lookup))
+
+	lookup, err = idx.Get(nil)
+	require.NoError(err)
+	require.Len(lookupRows(t, table, lookup), 0)
+}
+
+func TestHashIndex(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	table, idx := newIndexedTable(t, HashIndex)
+
+	_, ok := idx.(sql.AscendIndex)
+	require.False(ok)
+
+	require.NoError(table.Insert(ctx, sql.NewRow("f", int64(3))))
+	require.NoError(table.Delete(ctx, sql.NewRow("a", int64(1))))
+
+	lookup, err := idx.Get(int64(3))
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{{"c", int64(3)}, {"f", int64(3)}}, lookupRows(t, table, lookup))
+
+	lookup, err = idx.Get(int64(1))
+	require.NoError(err)
+	require.Len(lookupRows(t, table, lookup), 0)
+}
+
+func TestIndexLookupSetOperations(t *testing.T) {
+	require := require.New(t)
+	table, idx := newIndexedTable(t, BTreeIndex)
+	ai := idx.(sql.AscendIndex)
+
+	ge2, err := ai.AscendGreaterOrEqual(int64(2))
+	require.NoError(err)
+	lt4, err := ai.AscendLessThan(int64(4))
+	require.NoError(err)
+	eq5, err := idx.Get(int64(5))
+	require.NoError(err)
+
+	require.True(ge2.(sql.Mergeable).IsMergeable(lt4))
+
+	ops := ge2.(sql.SetOperations)
+	require.ElementsMatch(
+		[]sql.Row{{"b", int64(2)}, {"c", int64(3)}},
+		lookupRows(t, table, ops.Intersection(lt4)),
+	)
+	require.ElementsMatch(
+		[]sql.Row{{"d", int64(4)}},
+		lookupRows(t, table, ops.Difference(lt4, eq5)),
+	)
+	require.ElementsMatch(
+		[]sql.Row{{"a", int64(1)}, {"b", int64(2)}, {"c", int64(3)}, {"e", int64(5)}},
+		lookupRows(t, table, lt4.(sql.SetOperations).Union(eq5)),
+	)
+	require.Equal([]string{"idx", "idx", "idx"}, ops.Difference(lt4, eq5).Indexes())
+}
+
+func TestIndexDriverErrors(t *testing.T) {
+	require := require.New(t)
+	db := NewDatabase("db")
+	db.AddTable("t", NewTable("t", sql.Schema{{Name: "i", Type: sql.Int64}}))
+	driver := NewIndexDriver(db)
+	exprs := []sql.Expression{expression.NewGetFieldWithTable(0, sql.Int64, "t", "i", false)}
+
+	_, err := driver.Create("db", "t", "idx", exprs, map[string]string{"type": "bitmap"})
+	require.True(ErrInvalidIndexType.Is(err))
+
+	_, err = driver.Create("db", "u", "idx", exprs, nil)
+	require.True(ErrNotMemoryTable.Is(err))
+
+	idx, err := driver.Create("db", "t", "idx", exprs, nil)
+	require.NoError(err)
+	_, err = idx.Get(int64(1), int64(2))
+	require.Error(err)
+}
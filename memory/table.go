@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 
 	"github.com/src-d/go-mysql-server/sql"
@@ -20,18 +21,34 @@ type Table struct {
 	keys       [][]byte
 
 	insert int
+	// autoIncrement is the greatest value of the auto increment column
+	// inserted in the table.
+	autoIncrement uint64
 
 	filters    []sql.Expression
 	projection []string
 	columns    []int
 	lookup     sql.IndexLookup
+	limit      uint64
+	order      []sql.OrderByColumn
+
+	partitioning *sql.Partitioning
+	selected     []string
+
+	// indexes are the indexes of the memory index driver, which are
+	// updated when the rows change.
+	indexes []*memoryIndex
 }
 
 var _ sql.Table = (*Table)(nil)
 var _ sql.Inserter = (*Table)(nil)
+var _ sql.AutoIncrementTable = (*Table)(nil)
 var _ sql.FilteredTable = (*Table)(nil)
 var _ sql.ProjectedTable = (*Table)(nil)
 var _ sql.IndexableTable = (*Table)(nil)
+var _ sql.LimitedTable = (*Table)(nil)
+var _ sql.TopNTable = (*Table)(nil)
+var _ sql.PrunableTable = (*Table)(nil)
 
 // NewTable creates a new Table with the given name and schema.
 func NewTable(name string, schema sql.Schema) *Table {
@@ -61,6 +78,35 @@ func NewPartitionedTable(name string, schema sql.Schema, numPartitions int) *Tab
 	}
 }
 
+// NewTableWithPartitioning creates a new Table with the given name and
+// schema, whose rows are distributed in the partitions declared in the given
+// partitioning. The keys of the partitions are their names.
+func NewTableWithPartitioning(
+	name string,
+	schema sql.Schema,
+	partitioning *sql.Partitioning,
+) (*Table, error) {
+	partitioning, err := partitioning.Resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+	var partitions = map[string][]sql.Row{}
+	for _, name := range partitioning.Names() {
+		keys = append(keys, []byte(name))
+		partitions[name] = []sql.Row{}
+	}
+
+	return &Table{
+		name:         name,
+		schema:       schema,
+		partitions:   partitions,
+		keys:         keys,
+		partitioning: partitioning,
+	}, nil
+}
+
 // Name implements the sql.Table interface.
 func (t *Table) Name() string {
 	return t.name
@@ -74,7 +120,7 @@ func (t *Table) Schema() sql.Schema {
 // Partitions implements the sql.Table interface.
 func (t *Table) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
 	var keys [][]byte
-	for _, k := range t.keys {
+	for _, k := range t.partitionKeys() {
 		if rows, ok := t.partitions[string(k)]; ok && len(rows) > 0 {
 			keys = append(keys, k)
 		}
@@ -82,9 +128,23 @@ func (t *Table) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
 	return &partitionIter{keys: keys}, nil
 }
 
+// partitionKeys returns the keys of the partitions that are read, which
+// may not be all of them if partitions were selected.
+func (t *Table) partitionKeys() [][]byte {
+	if t.selected == nil {
+		return t.keys
+	}
+
+	var keys [][]byte
+	for _, name := range t.selected {
+		keys = append(keys, []byte(name))
+	}
+	return keys
+}
+
 // PartitionCount implements the sql.PartitionCounter interface.
 func (t *Table) PartitionCount(ctx *sql.Context) (int64, error) {
-	return int64(len(t.partitions)), nil
+	return int64(len(t.partitionKeys())), nil
 }
 
 // PartitionRows implements the sql.PartitionRows interface.
@@ -105,12 +165,101 @@ func (t *Table) PartitionRows(ctx *sql.Context, partition sql.Partition) (sql.Ro
 		}
 	}
 
-	return &tableIter{
+	iter := &tableIter{
+		ctx:         ctx,
 		rows:        rows,
 		columns:     t.columns,
 		filters:     t.filters,
 		indexValues: values,
-	}, nil
+		limit:       t.limit,
+	}
+
+	if len(t.order) > 0 {
+		return t.topNIter(iter)
+	}
+
+	return iter, nil
+}
+
+// topNIter returns an iterator with the first rows returned by the given
+// iterator once they are sorted by the order of the table.
+func (t *Table) topNIter(iter *tableIter) (sql.RowIter, error) {
+	type sortColumn struct {
+		sql.OrderByColumn
+		idx int
+		typ sql.Type
+	}
+
+	var columns = make([]sortColumn, len(t.order))
+	for i, c := range t.order {
+		idx := t.schema.IndexOf(c.Name, t.name)
+		if idx < 0 {
+			return nil, errColumnNotFound.New(c.Name)
+		}
+
+		typ := t.schema[idx].Type
+		if len(t.columns) > 0 {
+			idx = t.columns[idx]
+		}
+		columns[i] = sortColumn{c, idx, typ}
+	}
+
+	// Rows are sorted before being projected, and the limit is applied
+	// once they are sorted.
+	projection := iter.columns
+	iter.columns = nil
+	iter.limit = 0
+
+	rows, err := sql.RowIterToRows(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	var sortErr error
+	sort.SliceStable(rows, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+
+		for _, c := range columns {
+			a, b := rows[i][c.idx], rows[j][c.idx]
+			if a == nil || b == nil {
+				if a == b {
+					continue
+				}
+				return (a == nil) == c.NullsFirst
+			}
+
+			if c.Descending {
+				a, b = b, a
+			}
+
+			cmp, err := c.typ.Compare(a, b)
+			if err != nil {
+				sortErr = err
+				return false
+			}
+
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+
+		return false
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	if t.limit > 0 && uint64(len(rows)) > t.limit {
+		rows = rows[:t.limit]
+	}
+
+	for i, row := range rows {
+		rows[i] = projectOnRow(projection, row)
+	}
+
+	return &tableIter{rows: rows}, nil
 }
 
 type partition struct {
@@ -137,24 +286,32 @@ func (p *partitionIter) Next() (sql.Partition, error) {
 func (p *partitionIter) Close() error { return nil }
 
 type tableIter struct {
+	ctx     *sql.Context
 	columns []int
 	filters []sql.Expression
 
 	rows        []sql.Row
 	indexValues sql.IndexValueIter
 	pos         int
+
+	limit uint64
+	count uint64
 }
 
-var _ sql.RowIter = (*tableIter)(nil)
+var _ sql.RowBatchIter = (*tableIter)(nil)
 
 func (i *tableIter) Next() (sql.Row, error) {
+	if i.limit > 0 && i.count >= i.limit {
+		return nil, io.EOF
+	}
+
 	row, err := i.getRow()
 	if err != nil {
 		return nil, err
 	}
 
 	for _, f := range i.filters {
-		result, err := f.Eval(sql.NewEmptyContext(), row)
+		result, err := f.Eval(i.ctx, row)
 		if err != nil {
 			return nil, err
 		}
@@ -164,9 +321,14 @@ func (i *tableIter) Next() (sql.Row, error) {
 		}
 	}
 
+	i.count++
 	return projectOnRow(i.columns, row), nil
 }
 
+func (i *tableIter) NextBatch(rows []sql.Row) (int, error) {
+	return sql.FillRowBatch(i, rows)
+}
+
 func (i *tableIter) Close() error {
 	if i.indexValues == nil {
 		return nil
@@ -247,14 +409,64 @@ func (t *Table) Insert(ctx *sql.Context, row sql.Row) error {
 		return err
 	}
 
+	keys, err := t.indexKeys(row)
+	if err != nil {
+		return err
+	}
+
+	key, err := t.insertKey(row)
+	if err != nil {
+		return err
+	}
+
+	t.partitions[key] = append(t.partitions[key], row)
+	for i, idx := range t.indexes {
+		idx.add(key, keys[i], len(t.partitions[key])-1)
+	}
+
+	t.updateAutoIncrement(row)
+	return nil
+}
+
+// NextAutoIncrementValue implements the sql.AutoIncrementTable interface.
+func (t *Table) NextAutoIncrementValue(*sql.Context) (uint64, error) {
+	return t.autoIncrement + 1, nil
+}
+
+// updateAutoIncrement keeps the greatest value of the auto increment column
+// of the table, if it has one, so generated values are never repeated.
+func (t *Table) updateAutoIncrement(row sql.Row) {
+	for i, col := range t.schema {
+		if !col.AutoIncrement || row[i] == nil {
+			continue
+		}
+
+		v, err := sql.Int64.Convert(row[i])
+		if err == nil && v.(int64) > 0 && uint64(v.(int64)) > t.autoIncrement {
+			t.autoIncrement = uint64(v.(int64))
+		}
+	}
+}
+
+// insertKey returns the key of the partition where the row will be
+// inserted. Rows are distributed among all partitions unless the table has
+// declarative partitioning.
+func (t *Table) insertKey(row sql.Row) (string, error) {
+	if t.partitioning != nil {
+		idx := t.schema.IndexOf(t.partitioning.Column, t.name)
+		p, err := t.partitioning.PartitionOf(t.schema[idx].Type, row[idx])
+		if err != nil {
+			return "", err
+		}
+		return string(t.keys[p]), nil
+	}
+
 	key := string(t.keys[t.insert])
 	t.insert++
 	if t.insert == len(t.keys) {
 		t.insert = 0
 	}
-
-	t.partitions[key] = append(t.partitions[key], row)
-	return nil
+	return key, nil
 }
 
 // Delete the given row from the table.
@@ -274,7 +486,15 @@ func (t *Table) Delete(ctx *sql.Context, row sql.Row) error {
 				}
 			}
 			if matches {
+				keys, err := t.indexKeys(partitionRow)
+				if err != nil {
+					return err
+				}
+
 				t.partitions[partitionIndex] = append(partition[:partitionRowIndex], partition[partitionRowIndex+1:]...)
+				for i, idx := range t.indexes {
+					idx.remove(partitionIndex, keys[i], partitionRowIndex, true)
+				}
 				break
 			}
 		}
@@ -298,6 +518,38 @@ func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
 		return err
 	}
 
+	// With declarative partitioning, the row may belong to a different
+	// partition after the update.
+	if t.partitioning != nil {
+		key, err := t.insertKey(newRow)
+		if err != nil {
+			return err
+		}
+
+		keys, err := t.indexKeys(newRow)
+		if err != nil {
+			return err
+		}
+
+		if err := t.Delete(ctx, oldRow); err != nil {
+			if err == sql.ErrDeleteRowNotFound {
+				return nil
+			}
+			return err
+		}
+
+		t.partitions[key] = append(t.partitions[key], newRow)
+		for i, idx := range t.indexes {
+			idx.add(key, keys[i], len(t.partitions[key])-1)
+		}
+		return nil
+	}
+
+	newKeys, err := t.indexKeys(newRow)
+	if err != nil {
+		return err
+	}
+
 	matches := false
 	for partitionIndex, partition := range t.partitions {
 		for partitionRowIndex, partitionRow := range partition {
@@ -309,7 +561,16 @@ func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
 				}
 			}
 			if matches {
+				oldKeys, err := t.indexKeys(partitionRow)
+				if err != nil {
+					return err
+				}
+
 				t.partitions[partitionIndex][partitionRowIndex] = newRow
+				for i, idx := range t.indexes {
+					idx.remove(partitionIndex, oldKeys[i], partitionRowIndex, false)
+					idx.add(partitionIndex, newKeys[i], partitionRowIndex)
+				}
 				break
 			}
 		}
@@ -321,6 +582,39 @@ func (t *Table) Update(ctx *sql.Context, oldRow sql.Row, newRow sql.Row) error {
 	return nil
 }
 
+// indexKeys returns the keys of the given row in the indexes of the table.
+func (t *Table) indexKeys(row sql.Row) ([][]interface{}, error) {
+	if len(t.indexes) == 0 {
+		return nil, nil
+	}
+
+	keys := make([][]interface{}, len(t.indexes))
+	for i, idx := range t.indexes {
+		var err error
+		keys[i], err = idx.keyOf(row)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// addIndex adds an index of the memory index driver, whose keys must be the
+// ones of the current rows.
+func (t *Table) addIndex(idx *memoryIndex) {
+	t.indexes = append(t.indexes[:len(t.indexes):len(t.indexes)], idx)
+}
+
+// removeIndex removes an index of the memory index driver.
+func (t *Table) removeIndex(idx *memoryIndex) {
+	for i, other := range t.indexes {
+		if other == idx {
+			t.indexes = append(t.indexes[:i:i], t.indexes[i+1:]...)
+			return
+		}
+	}
+}
+
 func checkRow(schema sql.Schema, row sql.Row) error {
 	if len(row) != len(schema) {
 		return sql.ErrUnexpectedRowLength.New(len(schema), len(row))
@@ -349,6 +643,18 @@ func (t *Table) String() string {
 		kind += "Filtered "
 	}
 
+	if len(t.order) > 0 {
+		kind += "Sorted "
+	}
+
+	if t.limit > 0 {
+		kind += "Limited "
+	}
+
+	if t.selected != nil {
+		kind += "Pruned "
+	}
+
 	if t.lookup != nil {
 		kind += "Indexed"
 	}
@@ -481,6 +787,59 @@ func (t *Table) IndexKeyValues(
 	}, nil
 }
 
+// WithLimit implements the sql.LimitedTable interface.
+func (t *Table) WithLimit(limit uint64) sql.Table {
+	if limit == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.limit = limit
+	return &nt
+}
+
+// Limit implements the sql.LimitedTable interface.
+func (t *Table) Limit() uint64 {
+	return t.limit
+}
+
+// WithTopN implements the sql.TopNTable interface.
+func (t *Table) WithTopN(order []sql.OrderByColumn, limit uint64) sql.Table {
+	if len(order) == 0 {
+		return t.WithLimit(limit)
+	}
+
+	nt := *t
+	nt.order = order
+	nt.limit = limit
+	return &nt
+}
+
+// TopN implements the sql.TopNTable interface.
+func (t *Table) TopN() ([]sql.OrderByColumn, uint64) {
+	return t.order, t.limit
+}
+
+// Partitioning implements the sql.PrunableTable interface.
+func (t *Table) Partitioning() *sql.Partitioning {
+	return t.partitioning
+}
+
+// WithSelectedPartitions implements the sql.PrunableTable interface.
+func (t *Table) WithSelectedPartitions(names []string) sql.Table {
+	nt := *t
+	nt.selected = names
+	if nt.selected == nil {
+		nt.selected = []string{}
+	}
+	return &nt
+}
+
+// SelectedPartitions implements the sql.PrunableTable interface.
+func (t *Table) SelectedPartitions() []string {
+	return t.selected
+}
+
 // Projection implements the sql.ProjectedTable interface.
 func (t *Table) Projection() []string {
 	return t.projection
@@ -318,6 +318,137 @@ func TestIndexed(t *testing.T) {
 	}
 }
 
+func TestLimited(t *testing.T) {
+	require := require.New(t)
+
+	table := NewPartitionedTable("foo", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "foo"},
+	}, 2)
+	for i := int64(0); i < 10; i++ {
+		require.NoError(table.Insert(sql.NewEmptyContext(), sql.NewRow(i)))
+	}
+
+	filtered := table.WithFilters([]sql.Expression{
+		expression.NewGreaterThan(
+			expression.NewGetFieldWithTable(0, sql.Int64, "foo", "a", false),
+			expression.NewLiteral(int64(3), sql.Int64),
+		),
+	})
+	limited := filtered.(*Table).WithLimit(2)
+	require.Equal(uint64(2), limited.(*Table).Limit())
+
+	// The limit is applied to each partition.
+	rows := testFlatRows(t, limited)
+	require.ElementsMatch([]sql.Row{
+		sql.NewRow(int64(4)), sql.NewRow(int64(6)),
+		sql.NewRow(int64(5)), sql.NewRow(int64(7)),
+	}, rows)
+}
+
+func TestTopN(t *testing.T) {
+	require := require.New(t)
+
+	table := NewPartitionedTable("foo", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "foo", Nullable: true},
+		{Name: "b", Type: sql.Text, Source: "foo"},
+	}, 2)
+	for _, row := range []sql.Row{
+		sql.NewRow(int64(1), "a"),
+		sql.NewRow(int64(5), "b"),
+		sql.NewRow(nil, "c"),
+		sql.NewRow(int64(3), "d"),
+		sql.NewRow(int64(2), "e"),
+		sql.NewRow(int64(4), "f"),
+	} {
+		require.NoError(table.Insert(sql.NewEmptyContext(), row))
+	}
+
+	projected := table.WithProjection([]string{"b", "a"})
+	sorted := projected.(*Table).WithTopN(
+		[]sql.OrderByColumn{{Name: "a", Descending: true}},
+		2,
+	)
+
+	order, limit := sorted.(*Table).TopN()
+	require.Equal([]sql.OrderByColumn{{Name: "a", Descending: true}}, order)
+	require.Equal(uint64(2), limit)
+
+	p, err := sorted.Partitions(sql.NewEmptyContext())
+	require.NoError(err)
+
+	var partitions [][]sql.Row
+	for {
+		part, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+
+		iter, err := sorted.PartitionRows(sql.NewEmptyContext(), part)
+		require.NoError(err)
+
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		partitions = append(partitions, rows)
+	}
+
+	require.Equal([][]sql.Row{
+		{sql.NewRow("e", int64(2)), sql.NewRow("a", int64(1))},
+		{sql.NewRow("b", int64(5)), sql.NewRow("f", int64(4))},
+	}, partitions)
+}
+
+func TestTableWithPartitioning(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	table, err := NewTableWithPartitioning("foo", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "foo"},
+		{Name: "b", Type: sql.Text, Source: "foo"},
+	}, &sql.Partitioning{
+		Type:   sql.RangePartitioning,
+		Column: "a",
+		Definitions: []sql.PartitionDefinition{
+			{Name: "p0", LessThan: int64(10)},
+			{Name: "p1", LessThan: int64(20)},
+			{Name: "p2"},
+		},
+	})
+	require.NoError(err)
+
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(1), "a")))
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(15), "b")))
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(25), "c")))
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(5), "d")))
+
+	require.Equal(map[string][]sql.Row{
+		"p0": {sql.NewRow(int64(1), "a"), sql.NewRow(int64(5), "d")},
+		"p1": {sql.NewRow(int64(15), "b")},
+		"p2": {sql.NewRow(int64(25), "c")},
+	}, table.partitions)
+
+	pruned := table.WithSelectedPartitions([]string{"p1", "p2"})
+	require.Equal([]string{"p1", "p2"}, pruned.(sql.PrunableTable).SelectedPartitions())
+	require.Equal([]sql.Row{
+		sql.NewRow(int64(15), "b"),
+		sql.NewRow(int64(25), "c"),
+	}, testFlatRows(t, pruned))
+
+	count, err := pruned.(sql.PartitionCounter).PartitionCount(ctx)
+	require.NoError(err)
+	require.Equal(int64(2), count)
+
+	empty := table.WithSelectedPartitions(nil)
+	require.Equal([]sql.Row{}, testFlatRows(t, empty))
+
+	require.NoError(table.Update(ctx, sql.NewRow(int64(5), "d"), sql.NewRow(int64(30), "d")))
+	require.Equal([]sql.Row{sql.NewRow(int64(1), "a")}, table.partitions["p0"])
+	require.Equal([]sql.Row{
+		sql.NewRow(int64(25), "c"),
+		sql.NewRow(int64(30), "d"),
+	}, table.partitions["p2"])
+}
+
 func testFlatRows(t *testing.T, table sql.Table) []sql.Row {
 	var require = require.New(t)
 
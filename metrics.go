@@ -0,0 +1,118 @@
+package sqle
+
+import (
+	"io"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// unknownQueryType is the type of the queries that cannot be parsed.
+const unknownQueryType = "unknown"
+
+// queryType returns the type of a parsed query used as the label of the
+// metrics of the queries, such as select, insert or ddl.
+func queryType(parsed sql.Node) string {
+	switch n := parsed.(type) {
+	case *plan.InsertInto:
+		if n.IsReplace {
+			return "replace"
+		}
+		return "insert"
+	case *plan.LoadData:
+		return "load"
+	case *plan.SelectIntoFile:
+		return "select"
+	case *plan.Dump:
+		return "dump"
+	case *plan.Update:
+		return "update"
+	case *plan.DeleteFrom:
+		return "delete"
+	case *plan.CreateTable, *plan.DropTable, *plan.CreateIndex, *plan.DropIndex,
+		*plan.CreateDatabase, *plan.DropDatabase:
+		return "ddl"
+	case *plan.Grant, *plan.Revoke:
+		return "dcl"
+	case *plan.Set:
+		return "set"
+	case *plan.Use:
+		return "use"
+	case *plan.Describe, *plan.DescribeQuery:
+		return "describe"
+	case *plan.ShowGrants, *plan.ShowProcessList, *plan.ShowMasterStatus, *plan.ShowBinaryLogs,
+		*plan.ShowCollation, *plan.ShowCreateDatabase, *plan.ShowCreateTable, *plan.ShowIndexes,
+		*plan.ShowTables, *plan.ShowColumns, *plan.ShowDatabases, *plan.ShowTableStatus,
+		*plan.ShowVariables, plan.ShowWarnings:
+		return "show"
+	}
+
+	if isSelect(parsed) {
+		return "select"
+	}
+	return "other"
+}
+
+// metricsIter observes the metrics of a query once its rows are read.
+type metricsIter struct {
+	ctx    *sql.Context
+	iter   sql.RowIter
+	batch  sql.RowBatchIter
+	typ    string
+	start  time.Time
+	rows   int64
+	failed bool
+	closed bool
+}
+
+func newMetricsIter(ctx *sql.Context, iter sql.RowIter, typ string, start time.Time) *metricsIter {
+	return &metricsIter{ctx: ctx, iter: iter, typ: typ, start: start}
+}
+
+func (i *metricsIter) Next() (sql.Row, error) {
+	row, err := i.iter.Next()
+	if err != nil {
+		if err != io.EOF {
+			i.failed = true
+		}
+		return nil, err
+	}
+
+	i.rows++
+	return row, nil
+}
+
+func (i *metricsIter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.iter)
+	}
+
+	n, err := i.batch.NextBatch(rows)
+	if err != nil {
+		if err != io.EOF {
+			i.failed = true
+		}
+		return 0, err
+	}
+
+	i.rows += int64(n)
+	return n, nil
+}
+
+func (i *metricsIter) Close() error {
+	err := i.iter.Close()
+	if i.closed {
+		return err
+	}
+	i.closed = true
+
+	QueryTypeCounter.With("type", i.typ).Add(1)
+	if i.failed || err != nil {
+		QueryTypeErrorCounter.With("type", i.typ).Add(1)
+	}
+	QueryDurationHistogram.With("type", i.typ).Observe(time.Since(i.start).Seconds())
+	RowsReturnedCounter.Add(float64(i.rows))
+	RowsReadCounter.Add(float64(i.ctx.RowsExamined()))
+	return err
+}
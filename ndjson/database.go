@@ -0,0 +1,109 @@
+// Package ndjson implements a read-only database whose tables are the files
+// of a directory with a JSON object in every line, such as the ones
+// exported by many logging systems.
+package ndjson
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// DefaultSampleSize is the number of objects of a file used to infer its
+// columns, if no other is given in the options of the database.
+const DefaultSampleSize = 1000
+
+// DefaultRemainderColumn is the name of the column with the fields of the
+// objects that are not in other columns, if no other is given in the
+// options of the database.
+const DefaultRemainderColumn = "_extra"
+
+// Options of a database of JSON lines files.
+type Options struct {
+	// SampleSize is the number of objects of every file used to infer its
+	// columns. It's DefaultSampleSize if it's zero, and all the objects of
+	// the files are used if it's negative.
+	SampleSize int
+	// RemainderColumn is the name of the JSON column with the fields of
+	// every object that are not in other columns. It's
+	// DefaultRemainderColumn if it's empty.
+	RemainderColumn string
+}
+
+func (o Options) sampleSize() int {
+	if o.SampleSize == 0 {
+		return DefaultSampleSize
+	}
+	return o.SampleSize
+}
+
+func (o Options) remainderColumn() string {
+	if o.RemainderColumn == "" {
+		return DefaultRemainderColumn
+	}
+	return o.RemainderColumn
+}
+
+// extensions are the extensions of the files read as tables.
+var extensions = map[string]bool{
+	".ndjson": true,
+	".jsonl":  true,
+	".json":   true,
+}
+
+// Database is a read-only database with a table for every file with the
+// .ndjson, .jsonl or .json extension in a directory, named after the file
+// without its extension. Every line of the files must be a JSON object, or
+// be empty. The columns of the tables are inferred when the database is
+// created, but their rows are read from the files in every query, so the
+// lines appended to the files are seen.
+type Database struct {
+	name   string
+	dir    string
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the files of the given directory. If
+// options is nil, the default ones are used.
+func NewDatabase(name, dir string, options *Options) (*Database, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Database{name: name, dir: dir, tables: make(map[string]sql.Table)}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || !extensions[strings.ToLower(ext)] {
+			continue
+		}
+
+		table := strings.TrimSuffix(f.Name(), ext)
+		t, err := openTable(table, filepath.Join(dir, f.Name()), opts)
+		if err != nil {
+			return nil, err
+		}
+		d.tables[table] = t
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
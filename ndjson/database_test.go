@@ -0,0 +1,149 @@
+package ndjson
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/stretchr/testify/require"
+)
+
+func newDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "ndjson")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+type obj = map[string]interface{}
+
+const logs = `{"ts": "2019-01-02 10:00:00", "level": "info", "status": 200, "took": 1, "ok": true}
+{"ts": "2019-01-02 10:00:01", "level": "error", "status": 500, "took": 2.5, "ok": false, "err": {"code": 7}}
+
+{"ts": "2019-01-02 10:00:02", "Level": "warn", "status": "unknown", "took": null, "tags": ["a", "b"]}
+{"ts": "2019-01-02 10:00:03", "level": "info", "status": 201, "user": "alice"}
+{"ts": "2019-01-02 10:00:04", "lev`
+
+func TestDatabase(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"logs.ndjson": logs,
+		"empty.jsonl": "",
+		"notes.txt":   "{}\n",
+	})
+
+	db, err := NewDatabase("logs", dir, &Options{SampleSize: 3})
+	require.NoError(err)
+	require.Equal("logs", db.Name())
+
+	tables := db.Tables()
+	require.Len(tables, 2)
+
+	require.Equal(sql.Schema{
+		{Name: "ts", Type: sql.Text, Nullable: true, Source: "logs"},
+		{Name: "level", Type: sql.Text, Nullable: true, Source: "logs"},
+		{Name: "status", Type: sql.JSON, Nullable: true, Source: "logs"},
+		{Name: "took", Type: sql.Float64, Nullable: true, Source: "logs"},
+		{Name: "ok", Type: sql.Boolean, Nullable: true, Source: "logs"},
+		{Name: "err", Type: sql.JSON, Nullable: true, Source: "logs"},
+		{Name: "tags", Type: sql.JSON, Nullable: true, Source: "logs"},
+		{Name: "_extra", Type: sql.JSON, Nullable: true, Source: "logs"},
+	}, tables["logs"].Schema())
+
+	require.Equal(sql.Schema{
+		{Name: "_extra", Type: sql.JSON, Nullable: true, Source: "empty"},
+	}, tables["empty"].Schema())
+
+	require.Equal([]sql.Row{
+		{"2019-01-02 10:00:00", "info", json.Number("200"), float64(1), true, nil, nil, nil},
+		{"2019-01-02 10:00:01", "error", json.Number("500"), 2.5, false, obj{"code": json.Number("7")}, nil, nil},
+		{"2019-01-02 10:00:02", nil, "unknown", nil, nil, nil, []interface{}{"a", "b"}, obj{"Level": "warn"}},
+		{"2019-01-02 10:00:03", "info", json.Number("201"), nil, nil, nil, nil, obj{"user": "alice"}},
+	}, tableRows(t, tables["logs"]))
+
+	require.Len(tableRows(t, tables["empty"]), 0)
+}
+
+func TestTableValuesNotFitting(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"t.jsonl": `{"i": 1, "s": "a"}` + "\n" + `{"i": "two", "s": "b", "x": 1}` + "\n",
+	})
+
+	db, err := NewDatabase("db", dir, &Options{SampleSize: 1, RemainderColumn: "rest"})
+	require.NoError(err)
+	table := db.Tables()["t"].(*Table)
+
+	require.Equal([]sql.Row{
+		{int64(1), "a", nil},
+		{nil, "b", obj{"i": "two", "x": json.Number("1")}},
+	}, tableRows(t, table))
+
+	projected := table.WithProjection([]string{"rest", "i"}).(*Table)
+	require.Equal([]string{"rest", "i"}, projected.Projection())
+	require.Equal([]sql.Row{
+		{nil, int64(1)},
+		{obj{"i": "two", "x": json.Number("1")}, nil},
+	}, tableRows(t, projected))
+
+	projected = projected.WithProjection([]string{"i"}).(*Table)
+	require.Equal([]sql.Row{{int64(1)}, {nil}}, tableRows(t, projected))
+}
+
+func TestTableInvalidLines(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{
+		"t.jsonl": "{\"i\": 1}\n[1, 2]\n",
+	})
+
+	_, err := NewDatabase("db", dir, nil)
+	require.True(ErrInvalidLine.Is(err))
+
+	db, err := NewDatabase("db", dir, &Options{SampleSize: 1})
+	require.NoError(err)
+
+	iter, err := db.Tables()["t"].PartitionRows(sql.NewEmptyContext(), partition{})
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.True(ErrInvalidLine.Is(err))
+}
+
+func TestDatabaseQueries(t *testing.T) {
+	require := require.New(t)
+	dir := newDir(t, map[string]string{"logs.ndjson": logs})
+
+	db, err := NewDatabase("logs", dir, &Options{SampleSize: 3})
+	require.NoError(err)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), nil)
+
+	_, iter, err := e.Query(
+		sql.NewEmptyContext(),
+		`SELECT ts, JSON_EXTRACT(_extra, '$.user') FROM logs
+		WHERE level IS NOT NULL AND ok IS NULL ORDER BY ts`,
+	)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"2019-01-02 10:00:03", "alice"}}, rows)
+}
+
+func tableRows(t *testing.T, table sql.Table) []sql.Row {
+	t.Helper()
+	iter, err := table.PartitionRows(sql.NewEmptyContext(), partition{})
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	return rows
+}
@@ -0,0 +1,142 @@
+package ndjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// kind is the type inferred for the values of a field. Kinds are widened as
+// values that do not fit in the current one are found, up to JSON, which
+// fits any value.
+type kind byte
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindText
+	kindJSON
+)
+
+// kindOf returns the narrowest kind of a value decoded using numbers.
+func kindOf(v interface{}) kind {
+	switch v := v.(type) {
+	case nil:
+		return kindNull
+	case bool:
+		return kindBool
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return kindInt
+		}
+		return kindFloat
+	case string:
+		return kindText
+	default:
+		return kindJSON
+	}
+}
+
+// widen returns the narrowest kind that fits the values of both kinds.
+func widen(a, b kind) kind {
+	if a > b {
+		a, b = b, a
+	}
+
+	switch {
+	case a == kindNull || a == b:
+		return b
+	case a == kindInt && b == kindFloat:
+		return kindFloat
+	default:
+		return kindJSON
+	}
+}
+
+func (k kind) sqlType() sql.Type {
+	switch k {
+	case kindBool:
+		return sql.Boolean
+	case kindInt:
+		return sql.Int64
+	case kindFloat:
+		return sql.Float64
+	case kindText:
+		return sql.Text
+	default:
+		return sql.JSON
+	}
+}
+
+// convert converts a value decoded using numbers to the given kind, and
+// returns whether it fits in it. Values of JSON columns are kept as they
+// were decoded.
+func (k kind) convert(v interface{}) (interface{}, bool) {
+	if v == nil || k == kindJSON {
+		return v, true
+	}
+
+	if widen(k, kindOf(v)) != k {
+		return nil, false
+	}
+
+	switch k {
+	case kindInt:
+		n, err := v.(json.Number).Int64()
+		return n, err == nil
+	case kindFloat:
+		n, err := v.(json.Number).Float64()
+		return n, err == nil
+	default:
+		return v, true
+	}
+}
+
+// decodeObject decodes a line with a JSON object. It returns the names of
+// its fields in the order they're written and their values, with numbers
+// decoded as json.Number.
+func decodeObject(line []byte) ([]string, map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	if t, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if t != json.Delim('{') {
+		return nil, nil, fmt.Errorf("found %v", t)
+	}
+
+	var keys []string
+	values := make(map[string]interface{})
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := t.(string)
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = v
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, nil, fmt.Errorf("unexpected data after the object")
+	}
+
+	return keys, values, nil
+}
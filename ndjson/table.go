@@ -0,0 +1,328 @@
+package ndjson
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidLine is returned when a line of a file is not a JSON object.
+var ErrInvalidLine = errors.NewKind("line %d of %s is not a JSON object: %s")
+
+// field is a top level field of the objects of a file with a column.
+type field struct {
+	name string
+	kind kind
+}
+
+// Table is a table whose rows are read from a file with a JSON object in
+// every line every time they are requested. The top level fields of the
+// objects found when the database was created have a column, and the rest
+// of the fields, and the values that do not have the type inferred for
+// their column, are in a JSON column.
+type Table struct {
+	name   string
+	path   string
+	open   func() (io.ReadCloser, error)
+	fields []field
+	// index has the positions of the fields by name.
+	index map[string]int
+
+	schema sql.Schema
+	// columns are the positions of the columns of the schema in the
+	// schema of all the columns if the table is projected. The last one
+	// is the remainder column.
+	columns    []int
+	projection []string
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.ProjectedTable = (*Table)(nil)
+
+// openTable infers the columns of the table of a file.
+func openTable(name, path string, options Options) (*Table, error) {
+	open := func() (io.ReadCloser, error) { return os.Open(path) }
+	return newTable(name, path, open, options)
+}
+
+// NewTable creates a table whose rows are read from the file with a JSON
+// object in every line opened with open, which is called every time the
+// rows are requested. The path is only used in the errors. If options is
+// nil, the default ones are used.
+func NewTable(
+	name, path string,
+	open func() (io.ReadCloser, error),
+	options *Options,
+) (*Table, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+	return newTable(name, path, open, opts)
+}
+
+func newTable(
+	name, path string,
+	open func() (io.ReadCloser, error),
+	options Options,
+) (*Table, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &Table{name: name, path: path, open: open, index: make(map[string]int)}
+
+	// Fields are only matched by name, so the names of the columns must
+	// be different regardless of their case.
+	remainder := options.remainderColumn()
+	names := map[string]bool{strings.ToLower(remainder): true}
+
+	r := &lineReader{path: path, r: bufio.NewReader(f)}
+	for n := options.sampleSize(); n < 0 || r.objects < n; {
+		keys, values, err := r.next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			k := kindOf(values[key])
+			if i, ok := t.index[key]; ok {
+				t.fields[i].kind = widen(t.fields[i].kind, k)
+				continue
+			}
+
+			if names[strings.ToLower(key)] {
+				continue
+			}
+			names[strings.ToLower(key)] = true
+
+			t.index[key] = len(t.fields)
+			t.fields = append(t.fields, field{key, k})
+		}
+	}
+
+	for i, f := range t.fields {
+		if f.kind == kindNull {
+			t.fields[i].kind = kindJSON
+		}
+
+		t.schema = append(t.schema, &sql.Column{
+			Name:     f.name,
+			Type:     t.fields[i].kind.sqlType(),
+			Nullable: true,
+			Source:   name,
+		})
+	}
+
+	t.schema = append(t.schema, &sql.Column{
+		Name:     remainder,
+		Type:     sql.JSON,
+		Nullable: true,
+		Source:   name,
+	})
+
+	return t, nil
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("NDJSONTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the file are
+// in a single partition.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	f, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := t.columns
+	if columns == nil {
+		columns = make([]int, len(t.fields)+1)
+		for i := range columns {
+			columns[i] = i
+		}
+	}
+
+	return &tableIter{
+		t:       t,
+		f:       f,
+		r:       &lineReader{path: t.path, r: bufio.NewReader(f)},
+		columns: columns,
+	}, nil
+}
+
+// WithProjection implements the sql.ProjectedTable interface.
+func (t *Table) WithProjection(colNames []string) sql.Table {
+	if len(colNames) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.columns = make([]int, len(colNames))
+	nt.schema = make(sql.Schema, len(colNames))
+	for i, name := range colNames {
+		idx := t.schema.IndexOf(name, t.name)
+		if idx < 0 {
+			return t
+		}
+
+		nt.schema[i] = t.schema[idx]
+		nt.columns[i] = idx
+		if t.columns != nil {
+			nt.columns[i] = t.columns[idx]
+		}
+	}
+	nt.projection = colNames
+
+	return &nt
+}
+
+// Projection implements the sql.ProjectedTable interface.
+func (t *Table) Projection() []string {
+	return t.projection
+}
+
+// row returns the values of the given columns of an object.
+func (t *Table) row(columns []int, keys []string, values map[string]interface{}) sql.Row {
+	row := make(sql.Row, len(columns))
+	for i, c := range columns {
+		if c < len(t.fields) {
+			row[i], _ = t.fields[c].kind.convert(values[t.fields[c].name])
+			continue
+		}
+
+		remainder := make(map[string]interface{})
+		for _, key := range keys {
+			v := values[key]
+			if f, ok := t.index[key]; ok {
+				if _, fits := t.fields[f].kind.convert(v); fits {
+					continue
+				}
+			}
+			remainder[key] = v
+		}
+
+		if len(remainder) > 0 {
+			row[i] = remainder
+		}
+	}
+	return row
+}
+
+// lineReader reads the objects of the lines of a file. Empty lines are
+// skipped.
+type lineReader struct {
+	path    string
+	r       *bufio.Reader
+	line    int
+	objects int
+}
+
+func (r *lineReader) next() ([]string, map[string]interface{}, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+
+		complete := err == nil
+		if len(line) == 0 && !complete {
+			return nil, nil, io.EOF
+		}
+
+		r.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		keys, values, derr := decodeObject(line)
+		if derr != nil {
+			// The last line may not be completely written yet if the
+			// file is being appended to.
+			if !complete {
+				return nil, nil, io.EOF
+			}
+			return nil, nil, ErrInvalidLine.New(r.line, r.path, derr)
+		}
+
+		r.objects++
+		return keys, values, nil
+	}
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+type tableIter struct {
+	t       *Table
+	f       io.ReadCloser
+	r       *lineReader
+	columns []int
+}
+
+func (i *tableIter) Next() (sql.Row, error) {
+	keys, values, err := i.r.next()
+	if err != nil {
+		return nil, err
+	}
+	return i.t.row(i.columns, keys, values), nil
+}
+
+func (i *tableIter) Close() error {
+	return i.f.Close()
+}
@@ -0,0 +1,196 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// readColumn reads all the values of a column in a row group, converted to
+// the type of the column.
+func readColumn(f io.ReaderAt, c column, chunk columnChunk) ([]interface{}, error) {
+	data, err := readChunk(f, chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(data)
+	var dict []interface{}
+	values := make([]interface{}, 0, chunk.numValues)
+	for int64(len(values)) < chunk.numValues {
+		h, err := readPageHeader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if h.compressedSize > int64(r.Len()) {
+			return nil, errTruncated
+		}
+
+		page := make([]byte, h.compressedSize)
+		if _, err := io.ReadFull(r, page); err != nil {
+			return nil, err
+		}
+
+		switch h.typ {
+		case pageDictionary:
+			page, err = decompress(chunk.codec, page, h.uncompressedSize)
+			if err != nil {
+				return nil, err
+			}
+
+			if dict, err = c.decodeValues(page, encodingPlain, int(h.numValues), nil); err != nil {
+				return nil, err
+			}
+		case pageData, pageDataV2:
+			page, err := c.readDataPage(h, chunk.codec, page, dict)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, page...)
+		}
+	}
+
+	return values, nil
+}
+
+// readDataPage reads the values of a data page, with nil for the null
+// values.
+func (c column) readDataPage(h *pageHeader, codec int64, page []byte, dict []interface{}) ([]interface{}, error) {
+	n := int(h.numValues)
+	var levels []byte
+	if h.v2 {
+		// The levels of the pages of the second version are never
+		// compressed, and the repetition levels of a column that is not
+		// repeated are empty.
+		size := h.repLevelsLength + h.defLevelsLength
+		if size < 0 || size > int64(len(page)) {
+			return nil, errTruncated
+		}
+		levels = page[h.repLevelsLength:size]
+		page = page[size:]
+
+		if h.compressed {
+			var err error
+			if page, err = decompress(codec, page, h.uncompressedSize-size); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		var err error
+		if page, err = decompress(codec, page, h.uncompressedSize); err != nil {
+			return nil, err
+		}
+
+		if c.optional() {
+			if h.levelsEncoding != encodingRLE {
+				return nil, ErrUnsupportedEncoding.New(c.element.name, h.levelsEncoding)
+			}
+
+			if len(page) < 4 {
+				return nil, errTruncated
+			}
+
+			size := int(binary.LittleEndian.Uint32(page))
+			if size < 0 || 4+size > len(page) {
+				return nil, errTruncated
+			}
+			levels = page[4 : 4+size]
+			page = page[4+size:]
+		}
+	}
+
+	// Null values are not stored, and their definition level is zero.
+	notNull := n
+	var defined []int32
+	if c.optional() {
+		var err error
+		if defined, _, err = decodeHybrid(levels, 1, n); err != nil {
+			return nil, err
+		}
+
+		notNull = 0
+		for _, l := range defined {
+			if l > 0 {
+				notNull++
+			}
+		}
+	}
+
+	values, err := c.decodeValues(page, h.encoding, notNull, dict)
+	if err != nil {
+		return nil, err
+	}
+
+	if defined == nil {
+		return values, nil
+	}
+
+	result := make([]interface{}, n)
+	var next int
+	for i, l := range defined {
+		if l > 0 {
+			result[i] = values[next]
+			next++
+		}
+	}
+	return result, nil
+}
+
+// decodeValues decodes n values with the given encoding and converts them
+// to the type of the column.
+func (c column) decodeValues(data []byte, encoding int64, n int, dict []interface{}) ([]interface{}, error) {
+	switch encoding {
+	case encodingPlain:
+		values, err := decodePlain(data, c.element.typ, int(c.element.typeLength), n)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, v := range values {
+			values[i] = c.value(v)
+		}
+		return values, nil
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if len(data) < 1 {
+			return nil, errTruncated
+		}
+
+		indexes, _, err := decodeHybrid(data[1:], int(data[0]), n)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]interface{}, n)
+		for i, idx := range indexes {
+			if idx < 0 || int(idx) >= len(dict) {
+				return nil, fmt.Errorf("dictionary index %d out of range", idx)
+			}
+			values[i] = dict[idx]
+		}
+		return values, nil
+	default:
+		return nil, ErrUnsupportedEncoding.New(c.element.name, encoding)
+	}
+}
+
+func decompress(codec int64, data []byte, size int64) ([]byte, error) {
+	switch codec {
+	case codecUncompressed:
+		return data, nil
+	case codecSnappy:
+		return decodeSnappy(data)
+	case codecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(io.LimitReader(r, size))
+	default:
+		return nil, ErrUnsupportedCodec.New(codec)
+	}
+}
@@ -0,0 +1,63 @@
+// Package parquet implements a read-only database whose tables are the
+// Parquet files of a directory.
+package parquet
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Database is a read-only database with a table for every file with the
+// .parquet extension in a directory, named after the file without its
+// extension. The metadata of the files is read when the database is
+// created, so the files must not be changed while it's used.
+//
+// Only the primitive columns at the top level of the files that are not
+// repeated are exposed. Pages can be compressed with snappy or gzip, and
+// their values must use the plain or dictionary encodings.
+type Database struct {
+	name   string
+	dir    string
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the Parquet files of the given
+// directory.
+func NewDatabase(name, dir string) (*Database, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Database{name: name, dir: dir, tables: make(map[string]sql.Table)}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || strings.ToLower(ext) != ".parquet" {
+			continue
+		}
+
+		table := strings.TrimSuffix(f.Name(), ext)
+		t, err := openTable(table, filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		d.tables[table] = t
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
@@ -0,0 +1,357 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "parquet")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+var typesColumns = []testColumn{
+	{name: "id", typ: typeInt64, converted: -1},
+	{name: "name", typ: typeByteArray, converted: convertedUTF8, optional: true, dictionary: true},
+	{name: "score", typ: typeDouble, converted: -1, optional: true},
+	{name: "ok", typ: typeBoolean, converted: -1},
+	{name: "day", typ: typeInt32, converted: convertedDate},
+	{name: "ts", typ: typeInt64, converted: convertedTimestampMillis},
+	{name: "price", typ: typeInt32, converted: convertedDecimal, scale: 2},
+	{name: "raw", typ: typeByteArray, converted: -1, optional: true},
+	{name: "x", typ: typeInt32, converted: -1, group: "nested"},
+	{name: "legacy", typ: typeInt96, converted: -1},
+	{name: "small", typ: typeFloat, converted: -1},
+}
+
+func int96(t time.Time) []byte {
+	b := make([]byte, 12)
+	days := t.Unix()/86400 + julianEpoch
+	nanos := t.UnixNano() - t.Unix()/86400*86400*int64(time.Second)
+	for i := 0; i < 8; i++ {
+		b[i] = byte(uint64(nanos) >> (8 * uint(i)))
+	}
+	for i := 0; i < 4; i++ {
+		b[8+i] = byte(uint32(days) >> (8 * uint(i)))
+	}
+	return b
+}
+
+func typesGroups() [][][]interface{} {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	return [][][]interface{}{
+		{
+			{int64(1), int64(2)},
+			{"alice", nil},
+			{1.5, nil},
+			{true, false},
+			{int32(17897), int32(17898)},
+			{ts.UnixNano() / int64(time.Millisecond), int64(0)},
+			{int32(1234), int32(-5)},
+			{"\x01\x02", nil},
+			{int32(7), int32(8)},
+			{int96(ts), int96(ts.Add(time.Hour))},
+			{float32(0.5), float32(1)},
+		},
+		{
+			{int64(3)},
+			{"alice"},
+			{nil},
+			{true},
+			{int32(0)},
+			{int64(1000)},
+			{int32(1)},
+			{"x"},
+			{int32(9)},
+			{int96(ts)},
+			{float32(2)},
+		},
+	}
+}
+
+func typesRows() []sql.Row {
+	ts := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []sql.Row{
+		{int64(1), "alice", 1.5, true, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), ts, 12.34, []byte{1, 2}, ts, float32(0.5)},
+		{int64(2), nil, nil, false, time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC), time.Unix(0, 0).UTC(), -0.05, nil, ts.Add(time.Hour), float32(1)},
+		{int64(3), "alice", nil, true, time.Unix(0, 0).UTC(), time.Unix(1, 0).UTC(), 0.01, []byte("x"), ts, float32(2)},
+	}
+}
+
+func TestDatabase(t *testing.T) {
+	require := require.New(t)
+	dir := tempDir(t)
+	writeTestFile(t, filepath.Join(dir, "types.parquet"), testFile{
+		columns: typesColumns,
+		groups:  typesGroups(),
+		codec:   codecSnappy,
+	})
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644))
+
+	db, err := NewDatabase("lake", dir)
+	require.NoError(err)
+	require.Equal("lake", db.Name())
+	require.Len(db.Tables(), 1)
+
+	table := db.Tables()["types"]
+	require.Equal(sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "types"},
+		{Name: "name", Type: sql.Text, Nullable: true, Source: "types"},
+		{Name: "score", Type: sql.Float64, Nullable: true, Source: "types"},
+		{Name: "ok", Type: sql.Boolean, Source: "types"},
+		{Name: "day", Type: sql.Date, Source: "types"},
+		{Name: "ts", Type: sql.Timestamp, Source: "types"},
+		{Name: "price", Type: sql.Float64, Source: "types"},
+		{Name: "raw", Type: sql.Blob, Nullable: true, Source: "types"},
+		{Name: "legacy", Type: sql.Timestamp, Source: "types"},
+		{Name: "small", Type: sql.Float32, Source: "types"},
+	}, table.Schema())
+
+	count, err := table.(sql.PartitionCounter).PartitionCount(sql.NewEmptyContext())
+	require.NoError(err)
+	require.Equal(int64(2), count)
+
+	require.Equal(typesRows(), tableRows(t, table))
+
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "bad.parquet"), []byte("PAR1"), 0644))
+	_, err = NewDatabase("lake", dir)
+	require.True(ErrInvalidFile.Is(err))
+}
+
+type bytesFile struct{ *bytes.Reader }
+
+func (bytesFile) Close() error { return nil }
+
+func TestNewTable(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(tempDir(t), "types.parquet")
+	writeTestFile(t, path, testFile{
+		columns: typesColumns,
+		groups:  typesGroups(),
+		codec:   codecGzip,
+	})
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+
+	open := func() (File, error) { return bytesFile{bytes.NewReader(data)}, nil }
+	table, err := NewTable("remote", "s3://bucket/types.parquet", int64(len(data)), open)
+	require.NoError(err)
+	require.Equal("remote", table.Schema()[0].Source)
+	require.Equal(typesRows(), tableRows(t, table))
+
+	_, err = NewTable("remote", "s3://bucket/types.parquet", int64(len(data)-1), open)
+	require.True(ErrInvalidFile.Is(err))
+}
+
+func TestTableCodecsAndPages(t *testing.T) {
+	for _, codec := range []int64{codecUncompressed, codecSnappy, codecGzip} {
+		for _, v2 := range []bool{false, true} {
+			t.Run(fmt.Sprintf("codec=%d,v2=%v", codec, v2), func(t *testing.T) {
+				require := require.New(t)
+				dir := tempDir(t)
+				writeTestFile(t, filepath.Join(dir, "types.parquet"), testFile{
+					columns: typesColumns,
+					groups:  typesGroups(),
+					codec:   codec,
+					v2:      v2,
+				})
+
+				db, err := NewDatabase("lake", dir)
+				require.NoError(err)
+				require.Equal(typesRows(), tableRows(t, db.Tables()["types"]))
+			})
+		}
+	}
+
+	dir := tempDir(t)
+	writeTestFile(t, filepath.Join(dir, "t.parquet"), testFile{
+		columns: typesColumns,
+		groups:  typesGroups(),
+		codec:   4,
+	})
+	db, err := NewDatabase("lake", dir)
+	require.NoError(t, err)
+
+	table := db.Tables()["t"]
+	iter, err := table.PartitionRows(sql.NewEmptyContext(), &partition{0})
+	require.Nil(t, iter)
+	require.True(t, ErrInvalidFile.Is(err))
+	require.Contains(t, err.Error(), "unsupported compression codec 4")
+}
+
+func newRangesFile(t *testing.T, noStats bool) *Table {
+	dir := tempDir(t)
+	var groups [][][]interface{}
+	for g := 0; g < 3; g++ {
+		var ids, names []interface{}
+		for i := 1; i <= 5; i++ {
+			id := int64(g*5 + i)
+			ids = append(ids, id)
+			if g == 1 {
+				names = append(names, nil)
+			} else {
+				names = append(names, fmt.Sprintf("n%02d", id))
+			}
+		}
+		groups = append(groups, [][]interface{}{ids, names})
+	}
+
+	writeTestFile(t, filepath.Join(dir, "ranges.parquet"), testFile{
+		columns: []testColumn{
+			{name: "id", typ: typeInt64, converted: -1},
+			{name: "name", typ: typeByteArray, converted: convertedUTF8, optional: true},
+		},
+		groups:  groups,
+		noStats: noStats,
+	})
+
+	db, err := NewDatabase("lake", dir)
+	require.NoError(t, err)
+	return db.Tables()["ranges"].(*Table)
+}
+
+func TestTableFilters(t *testing.T) {
+	id := expression.NewGetFieldWithTable(0, sql.Int64, "ranges", "id", false)
+	name := expression.NewGetFieldWithTable(1, sql.Text, "ranges", "name", true)
+	lit := func(v interface{}) sql.Expression {
+		return expression.NewLiteral(v, sql.Int64)
+	}
+
+	testCases := []struct {
+		filter sql.Expression
+		groups []int
+		rows   int
+	}{
+		{expression.NewGreaterThan(id, lit(int64(10))), []int{2}, 5},
+		{expression.NewGreaterThanOrEqual(id, lit(int64(10))), []int{1, 2}, 6},
+		{expression.NewLessThan(id, lit(int64(6))), []int{0}, 5},
+		{expression.NewLessThanOrEqual(id, lit(int64(6))), []int{0, 1}, 6},
+		{expression.NewEquals(lit(int64(7)), id), []int{1}, 1},
+		{expression.NewLessThan(lit(int64(14)), id), []int{2}, 1},
+		{expression.NewEquals(id, lit(int64(20))), nil, 0},
+		{expression.NewOr(
+			expression.NewEquals(id, lit(int64(1))),
+			expression.NewEquals(id, lit(int64(15))),
+		), []int{0, 2}, 2},
+		{expression.NewAnd(
+			expression.NewGreaterThan(id, lit(int64(3))),
+			expression.NewLessThan(id, lit(int64(7))),
+		), []int{0, 1}, 3},
+		{expression.NewIsNull(name), []int{1}, 5},
+		{expression.NewNot(expression.NewIsNull(name)), []int{0, 2}, 10},
+		{expression.NewEquals(name, expression.NewLiteral("n12", sql.Text)), []int{2}, 1},
+		{expression.NewEquals(name, expression.NewLiteral("n07", sql.Text)), nil, 0},
+		{expression.NewIsNull(id), nil, 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.filter.String(), func(t *testing.T) {
+			require := require.New(t)
+			table := newRangesFile(t, false)
+
+			filters := []sql.Expression{tt.filter}
+			require.Equal(filters, table.HandledFilters(filters))
+
+			filtered := table.WithFilters(filters).(*Table)
+			require.Equal(filters, filtered.Filters())
+			require.Equal(tt.groups, filtered.rowGroups())
+			require.Len(tableRows(t, filtered), tt.rows)
+
+			// Without statistics all the row groups are read, with the
+			// same result.
+			filtered = newRangesFile(t, true).WithFilters(filters).(*Table)
+			require.Equal([]int{0, 1, 2}, filtered.rowGroups())
+			require.Len(tableRows(t, filtered), tt.rows)
+		})
+	}
+
+	other := expression.NewGetFieldWithTable(0, sql.Int64, "other", "id", false)
+	require.Len(t, newRangesFile(t, false).HandledFilters([]sql.Expression{
+		expression.NewEquals(other, lit(int64(1))),
+	}), 0)
+}
+
+func TestTableProjection(t *testing.T) {
+	require := require.New(t)
+	table := newRangesFile(t, false)
+
+	projected := table.WithProjection([]string{"name", "id"}).(*Table)
+	require.Equal([]string{"name", "id"}, projected.Projection())
+	require.Equal(sql.Schema{
+		{Name: "name", Type: sql.Text, Nullable: true, Source: "ranges"},
+		{Name: "id", Type: sql.Int64, Source: "ranges"},
+	}, projected.Schema())
+
+	// Filters use the columns of the table without projection, even if
+	// they're not projected.
+	id := expression.NewGetFieldWithTable(0, sql.Int64, "ranges", "id", false)
+	filtered := table.WithFilters([]sql.Expression{
+		expression.NewEquals(id, expression.NewLiteral(int64(12), sql.Int64)),
+	}).(*Table).WithProjection([]string{"name"})
+	require.Equal([]sql.Row{{"n12"}}, tableRows(t, filtered))
+
+	require.Equal([]sql.Row{{"n01", int64(1)}}, tableRows(t, projected)[:1])
+}
+
+func TestDatabaseQueries(t *testing.T) {
+	require := require.New(t)
+	dir := tempDir(t)
+	writeTestFile(t, filepath.Join(dir, "types.parquet"), testFile{
+		columns: typesColumns,
+		groups:  typesGroups(),
+		codec:   codecGzip,
+	})
+
+	db, err := NewDatabase("lake", dir)
+	require.NoError(err)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	e := sqle.New(catalog, analyzer.NewDefault(catalog), nil)
+
+	_, iter, err := e.Query(
+		sql.NewEmptyContext(),
+		`SELECT name, COUNT(*), SUM(price) FROM types
+		WHERE id > 1 AND name IS NOT NULL GROUP BY name`,
+	)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"alice", int64(1), 0.01}}, rows)
+}
+
+func tableRows(t *testing.T, table sql.Table) []sql.Row {
+	t.Helper()
+	ctx := sql.NewEmptyContext()
+
+	var rows []sql.Row
+	partitions, err := table.Partitions(ctx)
+	require.NoError(t, err)
+	for {
+		p, err := partitions.Next()
+		if err != nil {
+			break
+		}
+
+		iter, err := table.PartitionRows(ctx, p)
+		require.NoError(t, err)
+		r, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		rows = append(rows, r...)
+	}
+	return rows
+}
@@ -0,0 +1,160 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+var errTruncated = fmt.Errorf("page is truncated")
+
+// decodeHybrid decodes n values of the given bit width encoded with the
+// hybrid of run length encoding and bit packing used for the levels and the
+// dictionary indexes. It returns the values and the number of bytes read.
+func decodeHybrid(data []byte, bitWidth int, n int) ([]int32, int, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, 0, fmt.Errorf("invalid bit width %d", bitWidth)
+	}
+
+	values := make([]int32, 0, n)
+	pos := 0
+	for len(values) < n {
+		header, read := binary.Uvarint(data[pos:])
+		if read <= 0 {
+			return nil, 0, errTruncated
+		}
+		pos += read
+
+		if header&1 == 0 {
+			// A run of the same value, stored in the bytes needed for
+			// the bit width.
+			count := int(header >> 1)
+			width := (bitWidth + 7) / 8
+			if pos+width > len(data) {
+				return nil, 0, errTruncated
+			}
+
+			var v uint32
+			for i := 0; i < width; i++ {
+				v |= uint32(data[pos+i]) << (8 * uint(i))
+			}
+			pos += width
+
+			for i := 0; i < count && len(values) < n; i++ {
+				values = append(values, int32(v))
+			}
+			continue
+		}
+
+		// Groups of 8 values packed with the bit width, from the least
+		// significant bit.
+		count := int(header>>1) * 8
+		size := (count*bitWidth + 7) / 8
+		if pos+size > len(data) {
+			return nil, 0, errTruncated
+		}
+
+		packed := data[pos : pos+size]
+		pos += size
+		for i := 0; i < count && len(values) < n; i++ {
+			var v uint32
+			for b := 0; b < bitWidth; b++ {
+				bit := i*bitWidth + b
+				if packed[bit/8]&(1<<uint(bit%8)) != 0 {
+					v |= 1 << uint(b)
+				}
+			}
+			values = append(values, int32(v))
+		}
+	}
+
+	return values, pos, nil
+}
+
+// bitWidth returns the number of bits needed for the given maximum value.
+func bitWidth(max int) int {
+	return bits.Len(uint(max))
+}
+
+// decodePlain decodes n values of the given physical type with the plain
+// encoding. Byte arrays are not copied.
+func decodePlain(data []byte, typ int64, typeLength int, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	pos := 0
+	for i := 0; i < n; i++ {
+		var size int
+		switch typ {
+		case typeBoolean:
+			if i/8 >= len(data) {
+				return nil, errTruncated
+			}
+			values[i] = data[i/8]&(1<<uint(i%8)) != 0
+			continue
+		case typeInt32:
+			size = 4
+		case typeInt64:
+			size = 8
+		case typeInt96:
+			size = 12
+		case typeFloat:
+			size = 4
+		case typeDouble:
+			size = 8
+		case typeByteArray:
+			if pos+4 > len(data) {
+				return nil, errTruncated
+			}
+			size = int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if size < 0 {
+				return nil, errTruncated
+			}
+		case typeFixedLenByteArray:
+			size = typeLength
+		default:
+			return nil, fmt.Errorf("unknown physical type %d", typ)
+		}
+
+		if pos+size > len(data) {
+			return nil, errTruncated
+		}
+
+		values[i] = decodeValue(data[pos:pos+size], typ)
+		pos += size
+	}
+
+	return values, nil
+}
+
+// decodeValue decodes a single value of the given physical type, such as
+// the ones of the statistics. Integers and floats are decoded as int32,
+// int64, float32 and float64, and the rest as []byte.
+func decodeValue(b []byte, typ int64) interface{} {
+	switch typ {
+	case typeBoolean:
+		return len(b) > 0 && b[0] != 0
+	case typeInt32:
+		if len(b) < 4 {
+			return nil
+		}
+		return int32(binary.LittleEndian.Uint32(b))
+	case typeInt64:
+		if len(b) < 8 {
+			return nil
+		}
+		return int64(binary.LittleEndian.Uint64(b))
+	case typeFloat:
+		if len(b) < 4 {
+			return nil
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case typeDouble:
+		if len(b) < 8 {
+			return nil
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	default:
+		return b
+	}
+}
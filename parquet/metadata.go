@@ -0,0 +1,327 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// magic is written at the beginning and the end of Parquet files.
+const magic = "PAR1"
+
+// Physical types of the values.
+const (
+	typeBoolean           = 0
+	typeInt32             = 1
+	typeInt64             = 2
+	typeInt96             = 3
+	typeFloat             = 4
+	typeDouble            = 5
+	typeByteArray         = 6
+	typeFixedLenByteArray = 7
+)
+
+// Repetitions of the fields of the schema.
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+)
+
+// Converted types, which tell how the values of a physical type are
+// interpreted.
+const (
+	convertedUTF8            = 0
+	convertedEnum            = 4
+	convertedDecimal         = 5
+	convertedDate            = 6
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+	convertedUint8           = 11
+	convertedUint16          = 12
+	convertedUint32          = 13
+	convertedUint64          = 14
+	convertedJSON            = 19
+)
+
+// Compression codecs of the pages.
+const (
+	codecUncompressed = 0
+	codecSnappy       = 1
+	codecGzip         = 2
+)
+
+// Kinds of pages.
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// Encodings of the values and levels of the pages.
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingRLEDictionary   = 8
+)
+
+// Units of the timestamps of the logical types.
+const (
+	unitMillis = 1
+	unitMicros = 2
+	unitNanos  = 3
+)
+
+type fileMetadata struct {
+	schema    []schemaElement
+	numRows   int64
+	rowGroups []rowGroup
+}
+
+type schemaElement struct {
+	typ         int64
+	hasType     bool
+	typeLength  int64
+	repetition  int64
+	name        string
+	numChildren int64
+	converted   int64
+	hasConv     bool
+	scale       int64
+	// unit of the timestamps with a logical type, or zero.
+	unit int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	numRows int64
+}
+
+type columnChunk struct {
+	typ                  int64
+	path                 []string
+	codec                int64
+	numValues            int64
+	totalCompressedSize  int64
+	dataPageOffset       int64
+	dictionaryPageOffset int64
+	statistics           *statistics
+}
+
+type statistics struct {
+	min, max     []byte
+	hasMinMax    bool
+	nullCount    int64
+	hasNullCount bool
+	// deprecated is true if min and max were sorted as signed values,
+	// which is only right for signed numbers.
+	deprecated bool
+}
+
+type pageHeader struct {
+	typ              int64
+	uncompressedSize int64
+	compressedSize   int64
+	numValues        int64
+	encoding         int64
+	// levelsEncoding is the encoding of the definition levels of the data
+	// pages of the first version.
+	levelsEncoding int64
+	// The fields of the data pages of the second version.
+	v2              bool
+	defLevelsLength int64
+	repLevelsLength int64
+	compressed      bool
+}
+
+// readMetadata reads the metadata at the end of a Parquet file of the
+// given size.
+func readMetadata(f io.ReaderAt, size int64) (*fileMetadata, error) {
+	if size < int64(2*len(magic)+4) {
+		return nil, fmt.Errorf("file is too small")
+	}
+
+	var footer [8]byte
+	if _, err := f.ReadAt(footer[:], size-8); err != nil {
+		return nil, err
+	}
+
+	if string(footer[4:]) != magic {
+		return nil, fmt.Errorf("file does not end with %s", magic)
+	}
+
+	length := int64(binary.LittleEndian.Uint32(footer[:4]))
+	if length > size-8-int64(len(magic)) {
+		return nil, fmt.Errorf("metadata length %d is too big", length)
+	}
+
+	buf := make([]byte, length)
+	if _, err := f.ReadAt(buf, size-8-length); err != nil {
+		return nil, err
+	}
+
+	r := &thriftReader{r: bytes.NewReader(buf)}
+	s, err := r.readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &fileMetadata{numRows: s.int(3)}
+	for _, e := range s.structs(2) {
+		m.schema = append(m.schema, decodeSchemaElement(e))
+	}
+
+	for _, g := range s.structs(4) {
+		rg := rowGroup{numRows: g.int(3)}
+		for _, c := range g.structs(1) {
+			if c.has(1) {
+				return nil, fmt.Errorf("columns in other files are not supported")
+			}
+			rg.columns = append(rg.columns, decodeColumnChunk(c.strct(3)))
+		}
+		m.rowGroups = append(m.rowGroups, rg)
+	}
+
+	return m, nil
+}
+
+func decodeSchemaElement(s thriftStruct) schemaElement {
+	e := schemaElement{
+		typ:         s.int(1),
+		hasType:     s.has(1),
+		typeLength:  s.int(2),
+		repetition:  s.int(3),
+		name:        s.string(4),
+		numChildren: s.int(5),
+		converted:   s.int(6),
+		hasConv:     s.has(6),
+		scale:       s.int(7),
+	}
+
+	// The logical types are only needed for the types that do not have a
+	// converted type, such as timestamps in nanoseconds.
+	logical := s.strct(10)
+	switch {
+	case logical.has(1):
+		e.converted, e.hasConv = convertedUTF8, true
+	case logical.has(5):
+		e.converted, e.hasConv = convertedDecimal, true
+		e.scale = logical.strct(5).int(1)
+	case logical.has(6):
+		e.converted, e.hasConv = convertedDate, true
+	case logical.has(8):
+		unit := logical.strct(8).strct(2)
+		switch {
+		case unit.has(unitMillis):
+			e.unit = unitMillis
+		case unit.has(unitMicros):
+			e.unit = unitMicros
+		case unit.has(unitNanos):
+			e.unit = unitNanos
+		}
+	case logical.has(12):
+		e.converted, e.hasConv = convertedJSON, true
+	}
+
+	return e
+}
+
+func decodeColumnChunk(s thriftStruct) columnChunk {
+	c := columnChunk{
+		typ:                  s.int(1),
+		codec:                s.int(4),
+		numValues:            s.int(5),
+		totalCompressedSize:  s.int(7),
+		dataPageOffset:       s.int(9),
+		dictionaryPageOffset: s.int(11),
+	}
+
+	for _, p := range s.list(3) {
+		if p, ok := p.([]byte); ok {
+			c.path = append(c.path, string(p))
+		}
+	}
+
+	if s.has(12) {
+		st := s.strct(12)
+		stats := &statistics{
+			nullCount:    st.int(3),
+			hasNullCount: st.has(3),
+		}
+
+		switch {
+		case st.has(5) && st.has(6):
+			stats.min, stats.max, stats.hasMinMax = st.bytes(6), st.bytes(5), true
+		case st.has(1) && st.has(2):
+			stats.min, stats.max, stats.hasMinMax = st.bytes(2), st.bytes(1), true
+			stats.deprecated = true
+		}
+		c.statistics = stats
+	}
+
+	return c
+}
+
+// readPageHeader reads the header of a page.
+func readPageHeader(r io.ByteReader) (*pageHeader, error) {
+	s, err := (&thriftReader{r: r}).readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &pageHeader{
+		typ:              s.int(1),
+		uncompressedSize: s.int(2),
+		compressedSize:   s.int(3),
+	}
+
+	switch h.typ {
+	case pageData:
+		d := s.strct(5)
+		h.numValues = d.int(1)
+		h.encoding = d.int(2)
+		h.levelsEncoding = d.int(3)
+	case pageDictionary:
+		d := s.strct(7)
+		h.numValues = d.int(1)
+		h.encoding = d.int(2)
+	case pageDataV2:
+		d := s.strct(8)
+		h.v2 = true
+		h.numValues = d.int(1)
+		h.encoding = d.int(4)
+		h.defLevelsLength = d.int(5)
+		h.repLevelsLength = d.int(6)
+		h.compressed = !d.has(7) || d.bool(7)
+	}
+
+	if h.compressedSize < 0 || h.uncompressedSize < 0 {
+		return nil, fmt.Errorf("invalid page sizes")
+	}
+
+	return h, nil
+}
+
+// readChunk reads all the bytes of a column chunk.
+func readChunk(f io.ReaderAt, c columnChunk) ([]byte, error) {
+	start := c.dataPageOffset
+	if c.dictionaryPageOffset > 0 && c.dictionaryPageOffset < start {
+		start = c.dictionaryPageOffset
+	}
+
+	if c.totalCompressedSize < 0 || c.totalCompressedSize > maxChunkSize {
+		return nil, fmt.Errorf("column chunk of %d bytes is too big", c.totalCompressedSize)
+	}
+
+	buf := make([]byte, c.totalCompressedSize)
+	if n, err := f.ReadAt(buf, start); n < len(buf) {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// maxChunkSize is the maximum size of the column chunks, which are read in
+// memory.
+const maxChunkSize = 1 << 31
@@ -0,0 +1,201 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// column is a column of a Parquet file exposed as a column of its table.
+type column struct {
+	element schemaElement
+	// chunk is the position of the chunks of the column in the row
+	// groups.
+	chunk int
+	typ   sql.Type
+}
+
+func (c column) optional() bool {
+	return c.element.repetition == repetitionOptional
+}
+
+// columns returns the columns of the schema of a file. Only the primitive
+// fields at the top level that are not repeated have a column, as nested
+// and repeated fields cannot be represented in a table.
+func columns(schema []schemaElement) ([]column, error) {
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("file has no schema")
+	}
+
+	var result []column
+	var leaves int
+	pos := 1
+	for i := 0; i < int(schema[0].numChildren); i++ {
+		if pos >= len(schema) {
+			return nil, fmt.Errorf("schema is truncated")
+		}
+
+		e := schema[pos]
+		if e.numChildren > 0 {
+			var err error
+			pos, leaves, err = skipGroup(schema, pos, leaves)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if e.repetition != repetitionRepeated {
+			typ, err := columnType(e)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, column{element: e, chunk: leaves, typ: typ})
+		}
+
+		pos++
+		leaves++
+	}
+
+	return result, nil
+}
+
+// skipGroup skips the group of the schema in the given position and
+// returns the position after it and the number of leaves found so far.
+func skipGroup(schema []schemaElement, pos, leaves int) (int, int, error) {
+	if pos >= len(schema) {
+		return 0, 0, fmt.Errorf("schema is truncated")
+	}
+
+	children := int(schema[pos].numChildren)
+	pos++
+	if children == 0 {
+		return pos, leaves + 1, nil
+	}
+
+	for i := 0; i < children; i++ {
+		var err error
+		if pos, leaves, err = skipGroup(schema, pos, leaves); err != nil {
+			return 0, 0, err
+		}
+	}
+	return pos, leaves, nil
+}
+
+func columnType(e schemaElement) (sql.Type, error) {
+	conv := int64(-1)
+	if e.hasConv {
+		conv = e.converted
+	}
+
+	switch e.typ {
+	case typeBoolean:
+		return sql.Boolean, nil
+	case typeInt32:
+		switch conv {
+		case convertedDate:
+			return sql.Date, nil
+		case convertedDecimal:
+			return sql.Float64, nil
+		case convertedUint8, convertedUint16, convertedUint32:
+			return sql.Uint32, nil
+		default:
+			return sql.Int32, nil
+		}
+	case typeInt64:
+		switch {
+		case conv == convertedTimestampMillis || conv == convertedTimestampMicros || e.unit != 0:
+			return sql.Timestamp, nil
+		case conv == convertedDecimal:
+			return sql.Float64, nil
+		case conv == convertedUint64:
+			return sql.Uint64, nil
+		default:
+			return sql.Int64, nil
+		}
+	case typeInt96:
+		return sql.Timestamp, nil
+	case typeFloat:
+		return sql.Float32, nil
+	case typeDouble:
+		return sql.Float64, nil
+	case typeByteArray, typeFixedLenByteArray:
+		switch conv {
+		case convertedUTF8, convertedEnum, convertedJSON:
+			return sql.Text, nil
+		case convertedDecimal:
+			return sql.Float64, nil
+		default:
+			return sql.Blob, nil
+		}
+	default:
+		return nil, ErrUnsupportedColumn.New(e.name, e.typ)
+	}
+}
+
+// julianEpoch is the Julian day of the Unix epoch, used by the INT96
+// timestamps.
+const julianEpoch = 2440588
+
+// value converts a value decoded from a page or the statistics of the
+// column to the type of the column.
+func (c column) value(v interface{}) interface{} {
+	e := c.element
+	switch v := v.(type) {
+	case int32:
+		switch {
+		case c.typ == sql.Date:
+			return time.Unix(int64(v)*86400, 0).UTC()
+		case c.typ == sql.Uint32:
+			return uint32(v)
+		case e.converted == convertedDecimal:
+			return float64(v) / math.Pow10(int(e.scale))
+		}
+		return v
+	case int64:
+		switch {
+		case c.typ == sql.Timestamp:
+			switch {
+			case e.unit == unitNanos:
+				return time.Unix(0, v).UTC()
+			case e.unit == unitMicros || e.converted == convertedTimestampMicros:
+				return time.Unix(0, v*int64(time.Microsecond)).UTC()
+			default:
+				return time.Unix(0, v*int64(time.Millisecond)).UTC()
+			}
+		case c.typ == sql.Uint64:
+			return uint64(v)
+		case e.converted == convertedDecimal:
+			return float64(v) / math.Pow10(int(e.scale))
+		}
+		return v
+	case []byte:
+		switch {
+		case e.typ == typeInt96:
+			if len(v) != 12 {
+				return nil
+			}
+			nanos := int64(binary.LittleEndian.Uint64(v))
+			days := int64(binary.LittleEndian.Uint32(v[8:])) - julianEpoch
+			return time.Unix(days*86400, nanos).UTC()
+		case c.typ == sql.Text:
+			return string(v)
+		case c.typ == sql.Float64:
+			// Decimals are big endian two's complement integers.
+			n := new(big.Int).SetBytes(v)
+			if len(v) > 0 && v[0]&0x80 != 0 {
+				n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(v)*8)))
+			}
+			f, _ := new(big.Float).SetInt(n).Float64()
+			return f / math.Pow10(int(e.scale))
+		default:
+			return append([]byte(nil), v...)
+		}
+	default:
+		return v
+	}
+}
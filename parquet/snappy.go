@@ -0,0 +1,92 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var errCorruptSnappy = fmt.Errorf("corrupt snappy data")
+
+// decodeSnappy decompresses a block of data compressed with snappy, which
+// is the codec used by default by most of the writers of Parquet files.
+func decodeSnappy(src []byte) ([]byte, error) {
+	n, read := binary.Uvarint(src)
+	if read <= 0 || n > maxChunkSize {
+		return nil, errCorruptSnappy
+	}
+	src = src[read:]
+
+	dst := make([]byte, 0, n)
+	for len(src) > 0 {
+		tag := src[0]
+		src = src[1:]
+
+		if tag&3 == 0 {
+			// Literal, whose length minus one is in the tag or, if
+			// it does not fit, in the following 1 to 4 bytes.
+			length := int(tag >> 2)
+			if length >= 60 {
+				size := length - 59
+				if len(src) < size {
+					return nil, errCorruptSnappy
+				}
+
+				length = 0
+				for i := 0; i < size; i++ {
+					length |= int(src[i]) << (8 * uint(i))
+				}
+				src = src[size:]
+			}
+			length++
+
+			if length <= 0 || length > len(src) || len(dst)+length > int(n) {
+				return nil, errCorruptSnappy
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		}
+
+		// Copy of bytes already decompressed.
+		var length, offset int
+		switch tag & 3 {
+		case 1:
+			if len(src) < 1 {
+				return nil, errCorruptSnappy
+			}
+			length = 4 + int(tag>>2)&7
+			offset = int(tag>>5)<<8 | int(src[0])
+			src = src[1:]
+		case 2:
+			if len(src) < 2 {
+				return nil, errCorruptSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src))
+			src = src[2:]
+		case 3:
+			if len(src) < 4 {
+				return nil, errCorruptSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src))
+			src = src[4:]
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(n) {
+			return nil, errCorruptSnappy
+		}
+
+		// The copy may overlap with the bytes it appends, so it's done
+		// byte by byte.
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(n) {
+		return nil, errCorruptSnappy
+	}
+	return dst, nil
+}
@@ -0,0 +1,38 @@
+package parquet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSnappy(t *testing.T) {
+	require := require.New(t)
+
+	// "abc" as a literal, then copies of 9 bytes with offset 3 and of 2
+	// bytes with offset 12, with 1 and 2 bytes for the offset.
+	data := []byte{14, 2 << 2, 'a', 'b', 'c', 1 | 5<<2, 3, 2 | 1<<2, 12, 0}
+	b, err := decodeSnappy(data)
+	require.NoError(err)
+	require.Equal("abcabcabcabcab", string(b))
+
+	// Literal whose length is in the next byte.
+	literal := make([]byte, 100)
+	for i := range literal {
+		literal[i] = byte(i)
+	}
+	b, err = decodeSnappy(append([]byte{100, 60 << 2, 99}, literal...))
+	require.NoError(err)
+	require.Equal(literal, b)
+
+	for _, corrupt := range [][]byte{
+		{},
+		{3, 2 << 2, 'a', 'b'},
+		{3, 1 | 0<<2, 1},
+		{4, 0, 'a', 1 | 0<<2, 1},
+		{2, 2 << 2, 'a', 'b', 'c'},
+	} {
+		_, err := decodeSnappy(corrupt)
+		require.Error(err)
+	}
+}
@@ -0,0 +1,140 @@
+package parquet
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// skipRowGroup returns whether none of the rows of a row group can match
+// all the filters, according to the statistics of its columns.
+func (t *Table) skipRowGroup(rg rowGroup, filters []sql.Expression) bool {
+	for _, f := range filters {
+		if t.excludes(rg, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludes returns whether the statistics of the row group prove that the
+// filter is not true for any of its rows. Filters that cannot be checked
+// with the statistics do not exclude any row.
+func (t *Table) excludes(rg rowGroup, f sql.Expression) bool {
+	switch f := f.(type) {
+	case *expression.And:
+		return t.excludes(rg, f.Left) || t.excludes(rg, f.Right)
+	case *expression.Or:
+		return t.excludes(rg, f.Left) && t.excludes(rg, f.Right)
+	case *expression.IsNull:
+		c, stats := t.fieldStats(rg, f.Child)
+		return stats != nil && stats.hasNullCount && (!c.optional() || stats.nullCount == 0)
+	case *expression.Not:
+		if isNull, ok := f.Child.(*expression.IsNull); ok {
+			_, stats := t.fieldStats(rg, isNull.Child)
+			return stats != nil && stats.hasNullCount && stats.nullCount == rg.numRows
+		}
+	case expression.Comparer:
+		return t.excludesComparison(rg, f)
+	}
+	return false
+}
+
+func (t *Table) excludesComparison(rg rowGroup, f expression.Comparer) bool {
+	field, lit := f.Left(), f.Right()
+	swapped := false
+	if _, ok := field.(*expression.Literal); ok {
+		field, lit, swapped = lit, field, true
+	}
+
+	l, ok := lit.(*expression.Literal)
+	if !ok {
+		return false
+	}
+
+	c, stats := t.fieldStats(rg, field)
+	if stats == nil {
+		return false
+	}
+
+	// Comparisons are never true for null values.
+	if stats.hasNullCount && stats.nullCount == rg.numRows {
+		return true
+	}
+
+	if !stats.hasMinMax || l.Value() == nil {
+		return false
+	}
+
+	// The statistics of old writers are only sorted right for signed
+	// numbers.
+	if stats.deprecated {
+		switch c.typ {
+		case sql.Int32, sql.Int64, sql.Float32, sql.Float64, sql.Date, sql.Timestamp:
+		default:
+			return false
+		}
+	}
+
+	min := c.value(decodeValue(stats.min, c.element.typ))
+	max := c.value(decodeValue(stats.max, c.element.typ))
+	v, err := c.typ.Convert(l.Value())
+	if min == nil || max == nil || err != nil {
+		return false
+	}
+
+	cmpMin, err := c.typ.Compare(v, min)
+	if err != nil {
+		return false
+	}
+
+	cmpMax, err := c.typ.Compare(v, max)
+	if err != nil {
+		return false
+	}
+
+	// With the literal on the left the operator is reversed, as in
+	// 5 < i, which is i > 5.
+	switch f.(type) {
+	case *expression.Equals:
+		return cmpMin < 0 || cmpMax > 0
+	case *expression.LessThan:
+		if swapped {
+			return cmpMax >= 0
+		}
+		return cmpMin <= 0
+	case *expression.LessThanOrEqual:
+		if swapped {
+			return cmpMax > 0
+		}
+		return cmpMin < 0
+	case *expression.GreaterThan:
+		if swapped {
+			return cmpMin <= 0
+		}
+		return cmpMax >= 0
+	case *expression.GreaterThanOrEqual:
+		if swapped {
+			return cmpMin < 0
+		}
+		return cmpMax > 0
+	}
+	return false
+}
+
+// fieldStats returns the column of the field and its statistics in the
+// row group, if the expression is a field of the table.
+func (t *Table) fieldStats(rg rowGroup, e sql.Expression) (column, *statistics) {
+	gf, ok := e.(*expression.GetField)
+	if !ok || !strings.EqualFold(gf.Table(), t.name) {
+		return column{}, nil
+	}
+
+	for _, c := range t.columns {
+		if strings.EqualFold(c.element.name, gf.Name()) && c.chunk < len(rg.columns) {
+			return c, rg.columns[c.chunk].statistics
+		}
+	}
+	return column{}, nil
+}
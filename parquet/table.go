@@ -0,0 +1,364 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidFile is returned when a file cannot be read as a Parquet file.
+var ErrInvalidFile = errors.NewKind("unable to read %s: %s")
+
+// ErrUnsupportedColumn is returned when a column of a file has a physical
+// type that is not known.
+var ErrUnsupportedColumn = errors.NewKind("column %s has the unsupported physical type %d")
+
+// ErrUnsupportedEncoding is returned when the values of a column are
+// encoded with an encoding that is not supported.
+var ErrUnsupportedEncoding = errors.NewKind("column %s has the unsupported encoding %d")
+
+// ErrUnsupportedCodec is returned when the pages of a column are compressed
+// with a codec that is not supported. Only snappy and gzip are supported.
+var ErrUnsupportedCodec = errors.NewKind("unsupported compression codec %d")
+
+// File is a Parquet file read by a table.
+type File interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// Table is a table whose rows are read from a Parquet file. Every row group
+// of the file is a partition. Only the column chunks of the projected
+// columns and of the columns used by the filters are read, and the row
+// groups whose statistics show that none of their rows match the filters
+// are skipped.
+type Table struct {
+	name     string
+	path     string
+	open     func() (File, error)
+	metadata *fileMetadata
+	columns  []column
+
+	schema sql.Schema
+	// projected are the positions in columns of the columns of the
+	// schema if the table is projected.
+	projected  []int
+	projection []string
+	filters    []sql.Expression
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.ProjectedTable = (*Table)(nil)
+var _ sql.FilteredTable = (*Table)(nil)
+var _ sql.PartitionCounter = (*Table)(nil)
+
+// openTable reads the metadata of a Parquet file.
+func openTable(name, path string) (*Table, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	open := func() (File, error) { return os.Open(path) }
+	return NewTable(name, path, fi.Size(), open)
+}
+
+// NewTable creates a table whose rows are read from the Parquet file of the
+// given size opened with open, which is called every time a row group is
+// read. Its metadata is read once, so the file must not change. The path
+// is only used in the errors.
+func NewTable(name, path string, size int64, open func() (File, error)) (*Table, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	metadata, err := readMetadata(f, size)
+	if err != nil {
+		return nil, ErrInvalidFile.New(path, err)
+	}
+
+	columns, err := columns(metadata.schema)
+	if err != nil {
+		return nil, ErrInvalidFile.New(path, err)
+	}
+
+	t := &Table{name: name, path: path, open: open, metadata: metadata, columns: columns}
+	for _, c := range columns {
+		t.schema = append(t.schema, &sql.Column{
+			Name:     c.element.name,
+			Type:     c.typ,
+			Nullable: c.optional(),
+			Source:   name,
+		})
+	}
+
+	return t, nil
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("ParquetTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{groups: t.rowGroups()}, nil
+}
+
+// PartitionCount implements the sql.PartitionCounter interface.
+func (t *Table) PartitionCount(*sql.Context) (int64, error) {
+	return int64(len(t.rowGroups())), nil
+}
+
+// rowGroups returns the positions of the row groups that are not skipped
+// with the filters of the table.
+func (t *Table) rowGroups() []int {
+	var groups []int
+	for i, rg := range t.metadata.rowGroups {
+		if !t.skipRowGroup(rg, t.filters) {
+			groups = append(groups, i)
+		}
+	}
+	return groups
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
+	rg, ok := p.(*partition)
+	if !ok || rg.group >= len(t.metadata.rowGroups) {
+		return nil, fmt.Errorf("invalid partition %q of table %s", p.Key(), t.name)
+	}
+	group := t.metadata.rowGroups[rg.group]
+
+	projected := t.projected
+	if projected == nil {
+		projected = make([]int, len(t.columns))
+		for i := range projected {
+			projected[i] = i
+		}
+	}
+
+	// Filters use the positions of the columns in the schema without
+	// projection.
+	read := make(map[int]bool)
+	for _, c := range projected {
+		read[c] = true
+	}
+
+	for _, f := range t.filters {
+		expression.Inspect(f, func(e sql.Expression) bool {
+			if gf, ok := e.(*expression.GetField); ok && gf.Index() < len(t.columns) {
+				read[gf.Index()] = true
+			}
+			return true
+		})
+	}
+
+	f, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make([][]interface{}, len(t.columns))
+	for c := range read {
+		col := t.columns[c]
+		if col.chunk >= len(group.columns) {
+			return nil, ErrInvalidFile.New(t.path, "missing column chunk")
+		}
+
+		values[c], err = readColumn(f, col, group.columns[col.chunk])
+		if err != nil {
+			return nil, ErrInvalidFile.New(t.path, err)
+		}
+
+		if int64(len(values[c])) != group.numRows {
+			return nil, ErrInvalidFile.New(t.path, "column chunks have a different number of rows")
+		}
+	}
+
+	return &tableIter{
+		ctx:       ctx,
+		values:    values,
+		rows:      int(group.numRows),
+		projected: projected,
+		filters:   t.filters,
+	}, nil
+}
+
+// HandledFilters implements the sql.FilteredTable interface. All the
+// filters with only columns of the table are handled.
+func (t *Table) HandledFilters(filters []sql.Expression) []sql.Expression {
+	var handled []sql.Expression
+	for _, f := range filters {
+		var hasOtherFields bool
+		expression.Inspect(f, func(e sql.Expression) bool {
+			if e, ok := e.(*expression.GetField); ok {
+				if e.Table() != t.name || !t.schema.Contains(e.Name(), t.name) {
+					hasOtherFields = true
+					return false
+				}
+			}
+			return true
+		})
+
+		if !hasOtherFields {
+			handled = append(handled, f)
+		}
+	}
+
+	return handled
+}
+
+// WithFilters implements the sql.FilteredTable interface.
+func (t *Table) WithFilters(filters []sql.Expression) sql.Table {
+	if len(filters) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.filters = filters
+	return &nt
+}
+
+// Filters implements the sql.FilteredTable interface.
+func (t *Table) Filters() []sql.Expression {
+	return t.filters
+}
+
+// WithProjection implements the sql.ProjectedTable interface.
+func (t *Table) WithProjection(colNames []string) sql.Table {
+	if len(colNames) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.projected = make([]int, len(colNames))
+	nt.schema = make(sql.Schema, len(colNames))
+	for i, name := range colNames {
+		idx := t.schema.IndexOf(name, t.name)
+		if idx < 0 {
+			return t
+		}
+
+		nt.schema[i] = t.schema[idx]
+		nt.projected[i] = idx
+		if t.projected != nil {
+			nt.projected[i] = t.projected[idx]
+		}
+	}
+	nt.projection = colNames
+
+	return &nt
+}
+
+// Projection implements the sql.ProjectedTable interface.
+func (t *Table) Projection() []string {
+	return t.projection
+}
+
+type partition struct {
+	group int
+}
+
+func (p *partition) Key() []byte { return []byte(strconv.Itoa(p.group)) }
+
+type partitionIter struct {
+	groups []int
+	pos    int
+}
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.pos >= len(i.groups) {
+		return nil, io.EOF
+	}
+
+	p := &partition{i.groups[i.pos]}
+	i.pos++
+	return p, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+type tableIter struct {
+	ctx *sql.Context
+	// values are the values of the columns of the row group that were
+	// read, by their position in the schema without projection.
+	values    [][]interface{}
+	rows      int
+	pos       int
+	projected []int
+	filters   []sql.Expression
+}
+
+func (i *tableIter) Next() (sql.Row, error) {
+	for i.pos < i.rows {
+		row := make(sql.Row, len(i.values))
+		for c, values := range i.values {
+			if values != nil {
+				row[c] = values[i.pos]
+			}
+		}
+		i.pos++
+
+		ok, err := i.matches(row)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			projected := make(sql.Row, len(i.projected))
+			for j, c := range i.projected {
+				projected[j] = row[c]
+			}
+			return projected, nil
+		}
+	}
+
+	return nil, io.EOF
+}
+
+func (i *tableIter) matches(row sql.Row) (bool, error) {
+	for _, f := range i.filters {
+		result, err := f.Eval(i.ctx, row)
+		if err != nil {
+			return false, err
+		}
+
+		if result != true {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (i *tableIter) Close() error { return nil }
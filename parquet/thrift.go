@@ -0,0 +1,254 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Types of the values of the Thrift compact protocol, in which the
+// metadata of Parquet files is encoded.
+const (
+	compactStop   = 0
+	compactTrue   = 1
+	compactFalse  = 2
+	compactByte   = 3
+	compactI16    = 4
+	compactI32    = 5
+	compactI64    = 6
+	compactDouble = 7
+	compactBinary = 8
+	compactList   = 9
+	compactSet    = 10
+	compactMap    = 11
+	compactStruct = 12
+)
+
+// Limits of the decoded values, so corrupt files do not exhaust the memory
+// or the stack.
+const (
+	maxThriftDepth    = 64
+	maxThriftElements = 1 << 24
+)
+
+// thriftStruct is a decoded Thrift struct, with its fields by id. Integers
+// are decoded as int64, binaries as []byte, lists and sets as []interface{}
+// and structs as thriftStruct. Maps are skipped, as they are not used by
+// Parquet.
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s thriftStruct) bool(id int16) bool {
+	v, _ := s[id].(bool)
+	return v
+}
+
+func (s thriftStruct) bytes(id int16) []byte {
+	v, _ := s[id].([]byte)
+	return v
+}
+
+func (s thriftStruct) string(id int16) string {
+	return string(s.bytes(id))
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+func (s thriftStruct) structs(id int16) []thriftStruct {
+	var result []thriftStruct
+	for _, v := range s.list(id) {
+		if v, ok := v.(thriftStruct); ok {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func (s thriftStruct) strct(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// thriftReader decodes values of the Thrift compact protocol.
+type thriftReader struct {
+	r     io.ByteReader
+	depth int
+}
+
+func (r *thriftReader) readStruct() (thriftStruct, error) {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > maxThriftDepth {
+		return nil, fmt.Errorf("thrift struct nested too deeply")
+	}
+
+	s := make(thriftStruct)
+	var id int16
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		typ := b & 0x0f
+		if typ == compactStop {
+			return s, nil
+		}
+
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := r.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+
+		var v interface{}
+		switch typ {
+		case compactTrue:
+			v = true
+		case compactFalse:
+			v = false
+		default:
+			v, err = r.readValue(typ)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if v != nil {
+			s[id] = v
+		}
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case compactTrue, compactFalse:
+		// Booleans are written as a byte in lists.
+		b, err := r.r.ReadByte()
+		return b == compactTrue, err
+	case compactByte:
+		b, err := r.r.ReadByte()
+		return int64(int8(b)), err
+	case compactI16, compactI32, compactI64:
+		return r.readVarint()
+	case compactDouble:
+		var buf [8]byte
+		for i := range buf {
+			b, err := r.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			buf[i] = b
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf[:])), nil
+	case compactBinary:
+		return r.readBinary()
+	case compactList, compactSet:
+		return r.readList()
+	case compactMap:
+		return nil, r.skipMap()
+	case compactStruct:
+		return r.readStruct()
+	default:
+		return nil, fmt.Errorf("unknown thrift type %d", typ)
+	}
+}
+
+// readVarint reads a zigzag encoded integer.
+func (r *thriftReader) readVarint() (int64, error) {
+	v, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, err
+	}
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (r *thriftReader) readSize() (int, error) {
+	v, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, err
+	}
+
+	if v > maxThriftElements {
+		return 0, fmt.Errorf("thrift size %d is too big", v)
+	}
+	return int(v), nil
+}
+
+func (r *thriftReader) readBinary() ([]byte, error) {
+	n, err := r.readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, n)
+	for i := range b {
+		if b[i], err = r.r.ReadByte(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (r *thriftReader) readList() ([]interface{}, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(b >> 4)
+	if n == 15 {
+		if n, err = r.readSize(); err != nil {
+			return nil, err
+		}
+	}
+
+	var list []interface{}
+	for i := 0; i < n; i++ {
+		v, err := r.readValue(b & 0x0f)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (r *thriftReader) skipMap() error {
+	n, err := r.readSize()
+	if err != nil || n == 0 {
+		return err
+	}
+
+	types, err := r.r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := r.readValue(types >> 4); err != nil {
+			return err
+		}
+		if _, err := r.readValue(types & 0x0f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
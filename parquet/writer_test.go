@@ -0,0 +1,472 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The functions of this file write Parquet files for the tests.
+
+// tfield is a field of a Thrift struct to encode.
+type tfield struct {
+	id int16
+	v  interface{}
+}
+
+// tstruct is a Thrift struct to encode. Fields must be sorted by id.
+type tstruct []tfield
+
+func writeThrift(buf *bytes.Buffer, s tstruct) {
+	var last int16
+	for _, f := range s {
+		typ := compactType(f.v)
+		if b, ok := f.v.(bool); ok {
+			typ = compactFalse
+			if b {
+				typ = compactTrue
+			}
+		}
+
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta<<4) | typ)
+		} else {
+			buf.WriteByte(typ)
+			writeZigzag(buf, int64(f.id))
+		}
+		last = f.id
+
+		if _, ok := f.v.(bool); !ok {
+			writeThriftValue(buf, f.v)
+		}
+	}
+	buf.WriteByte(compactStop)
+}
+
+func compactType(v interface{}) byte {
+	switch v.(type) {
+	case bool:
+		return compactTrue
+	case int32:
+		return compactI32
+	case int64:
+		return compactI64
+	case []byte, string:
+		return compactBinary
+	case []interface{}:
+		return compactList
+	case tstruct:
+		return compactStruct
+	default:
+		panic("unknown thrift value")
+	}
+}
+
+func writeThriftValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			buf.WriteByte(compactTrue)
+		} else {
+			buf.WriteByte(compactFalse)
+		}
+	case int32:
+		writeZigzag(buf, int64(v))
+	case int64:
+		writeZigzag(buf, v)
+	case string:
+		writeThriftValue(buf, []byte(v))
+	case []byte:
+		writeUvarint(buf, uint64(len(v)))
+		buf.Write(v)
+	case []interface{}:
+		var typ byte = compactI32
+		if len(v) > 0 {
+			typ = compactType(v[0])
+		}
+
+		if len(v) < 15 {
+			buf.WriteByte(byte(len(v)<<4) | typ)
+		} else {
+			buf.WriteByte(0xf0 | typ)
+			writeUvarint(buf, uint64(len(v)))
+		}
+
+		for _, e := range v {
+			writeThriftValue(buf, e)
+		}
+	case tstruct:
+		writeThrift(buf, v)
+	}
+}
+
+func writeZigzag(buf *bytes.Buffer, v int64) {
+	writeUvarint(buf, uint64(v<<1)^uint64(v>>63))
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+// testColumn is a column of a file written for the tests.
+type testColumn struct {
+	name       string
+	typ        int64
+	typeLength int32
+	converted  int32
+	scale      int32
+	optional   bool
+	// dictionary encodes the values with a dictionary.
+	dictionary bool
+	// group is the name of the group with the column, if any.
+	group string
+}
+
+func (c testColumn) hasConverted() bool { return c.converted >= 0 }
+
+// testFile is a file written for the tests, with the values of every
+// column for every row group. Null values are nil.
+type testFile struct {
+	columns []testColumn
+	groups  [][][]interface{}
+	codec   int64
+	v2      bool
+	// noStats does not write the statistics of the columns.
+	noStats bool
+}
+
+func writeTestFile(t *testing.T, path string, f testFile) {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+
+	var groups []interface{}
+	var numRows int64
+	for _, group := range f.groups {
+		var chunks []interface{}
+		var rows int64
+		for i, c := range f.columns {
+			values := group[i]
+			rows = int64(len(values))
+			start := int64(buf.Len())
+			meta := writeChunk(&buf, c, values, f)
+			chunks = append(chunks, tstruct{{2, start}, {3, meta}})
+		}
+
+		numRows += rows
+		groups = append(groups, tstruct{
+			{1, chunks},
+			{2, int64(buf.Len())},
+			{3, rows},
+		})
+	}
+
+	schema := []interface{}{tstruct{{4, "schema"}, {5, int32(len(f.columns))}}}
+	for _, c := range f.columns {
+		if c.group != "" {
+			schema = append(schema, tstruct{{3, int32(repetitionOptional)}, {4, c.group}, {5, int32(1)}})
+		}
+
+		e := tstruct{{1, int32(c.typ)}}
+		if c.typeLength > 0 {
+			e = append(e, tfield{2, c.typeLength})
+		}
+
+		repetition := int32(repetitionRequired)
+		if c.optional {
+			repetition = repetitionOptional
+		}
+		e = append(e, tfield{3, repetition}, tfield{4, c.name})
+
+		if c.hasConverted() {
+			e = append(e, tfield{6, c.converted})
+			if c.converted == convertedDecimal {
+				e = append(e, tfield{7, c.scale})
+			}
+		}
+		schema = append(schema, e)
+	}
+
+	var meta bytes.Buffer
+	writeThrift(&meta, tstruct{
+		{1, int32(1)},
+		{2, schema},
+		{3, numRows},
+		{4, groups},
+	})
+
+	buf.Write(meta.Bytes())
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, uint32(meta.Len())))
+	buf.WriteString(magic)
+
+	require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+}
+
+// writeChunk writes the pages of a column chunk and returns its metadata.
+func writeChunk(buf *bytes.Buffer, c testColumn, values []interface{}, f testFile) tstruct {
+	var notNull []interface{}
+	var levels []int32
+	for _, v := range values {
+		if v == nil {
+			levels = append(levels, 0)
+			continue
+		}
+		levels = append(levels, 1)
+		notNull = append(notNull, v)
+	}
+
+	start := int64(buf.Len())
+	var dictionaryOffset int64
+	encoding := int32(encodingPlain)
+	data := encodePlain(c, notNull)
+
+	if c.dictionary {
+		var dict []interface{}
+		var indexes []int32
+		for _, v := range notNull {
+			idx := -1
+			for i, d := range dict {
+				if bytes.Equal(encodePlain(c, []interface{}{d}), encodePlain(c, []interface{}{v})) {
+					idx = i
+				}
+			}
+
+			if idx < 0 {
+				idx = len(dict)
+				dict = append(dict, v)
+			}
+			indexes = append(indexes, int32(idx))
+		}
+
+		dictionaryOffset = start
+		page := encodePlain(c, dict)
+		writePage(buf, f.codec, page, tstruct{
+			{1, int32(pageDictionary)},
+			{2, int32(len(page))},
+			{3, int32(len(compress(f.codec, page)))},
+			{7, tstruct{{1, int32(len(dict))}, {2, int32(encodingPlain)}}},
+		})
+
+		width := bitWidth(len(dict) - 1)
+		data = append([]byte{byte(width)}, encodeBitPacked(indexes, width)...)
+		encoding = encodingRLEDictionary
+	}
+
+	dataOffset := int64(buf.Len())
+	var defLevels []byte
+	if c.optional {
+		defLevels = encodeRuns(levels)
+	}
+
+	if f.v2 {
+		compressed := compress(f.codec, data)
+		page := append(append([]byte(nil), defLevels...), compressed...)
+		buf.Write(pageHeader2(tstruct{
+			{1, int32(pageDataV2)},
+			{2, int32(len(defLevels) + len(data))},
+			{3, int32(len(page))},
+			{8, tstruct{
+				{1, int32(len(values))},
+				{2, int32(len(values) - len(notNull))},
+				{3, int32(len(values))},
+				{4, encoding},
+				{5, int32(len(defLevels))},
+				{6, int32(0)},
+				{7, f.codec != codecUncompressed},
+			}},
+		}))
+		buf.Write(page)
+	} else {
+		var page []byte
+		if c.optional {
+			var size [4]byte
+			binary.LittleEndian.PutUint32(size[:], uint32(len(defLevels)))
+			page = append(size[:], defLevels...)
+		}
+		page = append(page, data...)
+
+		writePage(buf, f.codec, page, tstruct{
+			{1, int32(pageData)},
+			{2, int32(len(page))},
+			{3, int32(len(compress(f.codec, page)))},
+			{5, tstruct{
+				{1, int32(len(values))},
+				{2, encoding},
+				{3, int32(encodingRLE)},
+				{4, int32(encodingRLE)},
+			}},
+		})
+	}
+
+	meta := tstruct{
+		{1, int32(c.typ)},
+		{2, []interface{}{int32(encodingPlain), int32(encodingRLE)}},
+		{3, []interface{}{c.name}},
+		{4, int32(f.codec)},
+		{5, int64(len(values))},
+		{6, int64(buf.Len()) - start},
+		{7, int64(buf.Len()) - start},
+		{9, dataOffset},
+	}
+
+	if c.dictionary {
+		meta = append(meta, tfield{11, dictionaryOffset})
+	}
+
+	if !f.noStats && len(notNull) > 0 {
+		min, max := notNull[0], notNull[0]
+		for _, v := range notNull {
+			if lessThan(v, min) {
+				min = v
+			}
+			if lessThan(max, v) {
+				max = v
+			}
+		}
+
+		meta = append(meta, tfield{12, tstruct{
+			{3, int64(len(values) - len(notNull))},
+			{5, statsValue(c, max)},
+			{6, statsValue(c, min)},
+		}})
+	} else if !f.noStats {
+		meta = append(meta, tfield{12, tstruct{{3, int64(len(values))}}})
+	}
+
+	return meta
+}
+
+func pageHeader2(h tstruct) []byte {
+	var buf bytes.Buffer
+	writeThrift(&buf, h)
+	return buf.Bytes()
+}
+
+func writePage(buf *bytes.Buffer, codec int64, page []byte, header tstruct) {
+	buf.Write(pageHeader2(header))
+	buf.Write(compress(codec, page))
+}
+
+func lessThan(a, b interface{}) bool {
+	switch a := a.(type) {
+	case int32:
+		return a < b.(int32)
+	case int64:
+		return a < b.(int64)
+	case float64:
+		return a < b.(float64)
+	case string:
+		return a < b.(string)
+	default:
+		return false
+	}
+}
+
+func statsValue(c testColumn, v interface{}) []byte {
+	if c.typ == typeByteArray {
+		return []byte(v.(string))
+	}
+	return encodePlain(c, []interface{}{v})
+}
+
+func encodePlain(c testColumn, values []interface{}) []byte {
+	var buf bytes.Buffer
+	if c.typ == typeBoolean {
+		b := make([]byte, (len(values)+7)/8)
+		for i, v := range values {
+			if v.(bool) {
+				b[i/8] |= 1 << uint(i%8)
+			}
+		}
+		return b
+	}
+
+	for _, v := range values {
+		switch v := v.(type) {
+		case int32, int64:
+			_ = binary.Write(&buf, binary.LittleEndian, v)
+		case float32:
+			_ = binary.Write(&buf, binary.LittleEndian, math.Float32bits(v))
+		case float64:
+			_ = binary.Write(&buf, binary.LittleEndian, math.Float64bits(v))
+		case string:
+			_ = binary.Write(&buf, binary.LittleEndian, uint32(len(v)))
+			buf.WriteString(v)
+		case []byte:
+			// Fixed length byte arrays and INT96 values.
+			buf.Write(v)
+		}
+	}
+	return buf.Bytes()
+}
+
+// encodeRuns encodes levels as runs of the same value.
+func encodeRuns(values []int32) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		writeUvarint(&buf, uint64(j-i)<<1)
+		buf.WriteByte(byte(values[i]))
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// encodeBitPacked encodes values packed with the given bit width.
+func encodeBitPacked(values []int32, width int) []byte {
+	groups := (len(values) + 7) / 8
+	var buf bytes.Buffer
+	writeUvarint(&buf, uint64(groups)<<1|1)
+
+	packed := make([]byte, (groups*8*width+7)/8)
+	for i, v := range values {
+		for b := 0; b < width; b++ {
+			if v&(1<<uint(b)) != 0 {
+				bit := i*width + b
+				packed[bit/8] |= 1 << uint(bit%8)
+			}
+		}
+	}
+	buf.Write(packed)
+	return buf.Bytes()
+}
+
+func compress(codec int64, data []byte) []byte {
+	var buf bytes.Buffer
+	switch codec {
+	case codecGzip:
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write(data)
+		_ = w.Close()
+	case codecSnappy:
+		// Snappy data with only literals of up to 256 bytes.
+		writeUvarint(&buf, uint64(len(data)))
+		for len(data) > 0 {
+			n := len(data)
+			if n > 256 {
+				n = 256
+			}
+
+			if n <= 60 {
+				buf.WriteByte(byte(n-1) << 2)
+			} else {
+				buf.WriteByte(60 << 2)
+				buf.WriteByte(byte(n - 1))
+			}
+			buf.Write(data[:n])
+			data = data[n:]
+		}
+	default:
+		return data
+	}
+	return buf.Bytes()
+}
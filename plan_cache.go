@@ -0,0 +1,187 @@
+package sqle
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+const (
+	planCacheSizeKey = "PLAN_CACHE_SIZE"
+	// defaultPlanCacheSize is the number of plans cached when the size is
+	// not set in the environment.
+	defaultPlanCacheSize = 1000
+)
+
+// planCacheSize returns the number of plans that can be cached, which is
+// read from the environment.
+func planCacheSize() int {
+	v := strings.TrimSpace(os.Getenv(planCacheSizeKey))
+	if v == "" {
+		return defaultPlanCacheSize
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		logrus.Warnf("invalid value %q for %s, using the default", v, planCacheSizeKey)
+		return defaultPlanCacheSize
+	}
+	return n
+}
+
+// planCache keeps the analyzed plans of the last queries executed, so
+// queries that are executed again do not need to be parsed and analyzed.
+// Plans are cached by the normalized text of the query and the database in
+// use, and they are discarded when the schema version of the catalog is not
+// the one they were analyzed with.
+type planCache struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+// cachedPlan is a query parsed and prepared by the analyzer.
+type cachedPlan struct {
+	parsed   sql.Node
+	prepared sql.Node
+	// version is the schema version of the catalog the query was analyzed
+	// with.
+	version uint64
+}
+
+// newPlanCache returns a cache of the given size, or nil if the size is 0,
+// which disables the cache.
+func newPlanCache(size int) *planCache {
+	if size <= 0 {
+		return nil
+	}
+
+	cache, _ := lru.New(size)
+	return &planCache{cache: cache}
+}
+
+// get returns the plan cached with the given key, unless it was analyzed
+// with a schema version other than the given one, in which case it's
+// removed.
+func (c *planCache) get(key string, version uint64) (*cachedPlan, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	p := v.(*cachedPlan)
+	if p.version != version {
+		c.cache.Remove(key)
+		return nil, false
+	}
+	return p, true
+}
+
+func (c *planCache) put(key string, p *cachedPlan) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.cache.Add(key, p)
+	c.mu.Unlock()
+}
+
+// planCacheKey returns the key of the plan of the query when executed with
+// the given database in use.
+func planCacheKey(db, query string) string {
+	return db + "\x00" + normalizeQuery(query)
+}
+
+// normalizeQuery returns the query without the spaces at the start and end
+// nor a trailing semicolon, and with every sequence of spaces that is not
+// quoted replaced by a single space, so queries only differing in the
+// formatting share the same plan.
+func normalizeQuery(query string) string {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+
+	var buf strings.Builder
+	var quote rune
+	var space, escaped bool
+	for _, r := range strings.TrimSpace(query) {
+		switch {
+		case quote != 0:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		}
+
+		if space {
+			buf.WriteRune(' ')
+			space = false
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+// isCacheable reports whether the plan of the parsed query can be cached,
+// which is only the case of queries that read rows. Queries with tables
+// AS OF a version are not cached, as their versions are evaluated when
+// they are analyzed.
+func isCacheable(parsed sql.Node) bool {
+	if !isSelect(parsed) {
+		return false
+	}
+
+	var asOf bool
+	plan.Inspect(parsed, func(n sql.Node) bool {
+		if t, ok := n.(*plan.UnresolvedTable); ok && t.AsOf != nil {
+			asOf = true
+		}
+		return !asOf
+	})
+	return !asOf
+}
+
+// isSelect reports whether the parsed query is a SELECT.
+func isSelect(parsed sql.Node) bool {
+	switch parsed.(type) {
+	case *plan.Project, *plan.GroupBy, *plan.Filter, *plan.Sort, *plan.Limit,
+		*plan.Offset, *plan.Distinct, *plan.OrderedDistinct, *plan.Having:
+		return true
+	default:
+		return false
+	}
+}
+
+// changesSchema reports whether the parsed query creates or drops tables,
+// or changes the privileges the plans were checked with. Changes of indexes
+// and statistics are tracked by the catalog itself.
+func changesSchema(parsed sql.Node) bool {
+	switch parsed.(type) {
+	case *plan.CreateTable, *plan.DropTable, *plan.Grant, *plan.Revoke:
+		return true
+	default:
+		return false
+	}
+}
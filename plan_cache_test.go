@@ -0,0 +1,93 @@
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"  SELECT\n\t1 ;  ", "SELECT 1"},
+		{"SELECT  a,   b FROM t", "SELECT a, b FROM t"},
+		{"SELECT 'a  b' FROM  t", "SELECT 'a  b' FROM t"},
+		{`SELECT "a \"  b"  FROM t`, `SELECT "a \"  b" FROM t`},
+		{"SELECT `a  b`  FROM t", "SELECT `a  b` FROM t"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.expected, normalizeQuery(tt.query))
+		})
+	}
+}
+
+func TestPlanCache(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+	})
+	require.NoError(table.Insert(sql.NewEmptyContext(), sql.NewRow(int64(1))))
+
+	db := memory.NewDatabase("db")
+	db.AddTable("t", table)
+
+	e := NewDefault()
+	e.AddDatabase(db)
+
+	query := func(q string) []sql.Row {
+		ctx := sql.NewContext(context.Background(), sql.WithSession(sql.NewBaseSession()))
+		_, iter, err := e.Query(ctx, q)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		return rows
+	}
+
+	require.Equal([]sql.Row{{int64(1)}}, query("SELECT a FROM t WHERE a = 1"))
+
+	key := planCacheKey("db", "SELECT a FROM t WHERE a = 1")
+	cached, ok := e.plans.get(key, e.Catalog.SchemaVersion())
+	require.True(ok)
+
+	// Rows inserted after the plan was cached are returned.
+	require.NoError(table.Insert(sql.NewEmptyContext(), sql.NewRow(int64(1))))
+	require.Equal(
+		[]sql.Row{{int64(1)}, {int64(1)}},
+		query("SELECT  a FROM t\nWHERE a = 1;"),
+	)
+
+	again, ok := e.plans.get(key, e.Catalog.SchemaVersion())
+	require.True(ok)
+	require.True(cached == again)
+
+	// Statements that do not read rows are not cached.
+	query("SET autocommit = 1")
+	_, ok = e.plans.get(planCacheKey("db", "SET autocommit = 1"), e.Catalog.SchemaVersion())
+	require.False(ok)
+
+	// Nor plans that depend on the session.
+	query("SELECT @@autocommit")
+	_, ok = e.plans.get(planCacheKey("db", "SELECT @@autocommit"), e.Catalog.SchemaVersion())
+	require.False(ok)
+
+	query("CREATE TABLE t2(b INTEGER)")
+	_, ok = e.plans.get(key, e.Catalog.SchemaVersion())
+	require.False(ok)
+
+	query("SELECT a FROM t WHERE a = 1")
+	_, ok = e.plans.get(key, e.Catalog.SchemaVersion())
+	require.True(ok)
+
+	query("ANALYZE TABLE t")
+	_, ok = e.plans.get(key, e.Catalog.SchemaVersion())
+	require.False(ok)
+}
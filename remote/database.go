@@ -0,0 +1,101 @@
+// Package remote implements a database whose tables are served by data
+// sources with the gRPC service of the rpc package, so they can be written
+// in any language.
+package remote
+
+import (
+	"context"
+
+	"github.com/src-d/go-mysql-server/remote/rpc"
+	"github.com/src-d/go-mysql-server/sql"
+	"google.golang.org/grpc"
+	errors "gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+// ErrRemoteCall is returned when a call to the service of a data source
+// fails.
+var ErrRemoteCall = errors.NewKind("call %s of the remote table %s failed: %s")
+
+// ErrUnsupportedColumn is returned when a column of a remote table has a
+// type that is not known.
+var ErrUnsupportedColumn = errors.NewKind("column %s of the remote table %s has the unsupported type %q")
+
+// Database is a database whose tables are the tables of a data source,
+// read with calls to its gRPC service. The projections and the filters of
+// the queries of the engine are sent to the data source when the rows are
+// read, so it can skip the columns and rows not needed, but the rows are
+// filtered again by the engine, so data sources may ignore them.
+type Database struct {
+	name   string
+	client rpc.TableClient
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the given name whose tables are the
+// ones of the data source of the given connection. The tables and their
+// schemas are read once, when the database is created. The connection is
+// not closed by the database.
+func NewDatabase(name string, conn *grpc.ClientConn) (*Database, error) {
+	d := &Database{
+		name:   name,
+		client: rpc.NewTableClient(conn),
+		tables: make(map[string]sql.Table),
+	}
+
+	ctx := context.Background()
+	resp, err := d.client.ListTables(ctx, new(rpc.ListTablesRequest))
+	if err != nil {
+		return nil, ErrRemoteCall.New("ListTables", name, err)
+	}
+
+	for _, table := range resp.Tables {
+		schema, err := d.tableSchema(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		d.tables[table] = newTable(d.client, table, schema)
+	}
+
+	return d, nil
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
+
+func (d *Database) tableSchema(ctx context.Context, table string) (sql.Schema, error) {
+	resp, err := d.client.GetSchema(ctx, &rpc.GetSchemaRequest{Table: table})
+	if err != nil {
+		return nil, ErrRemoteCall.New("GetSchema", table, err)
+	}
+
+	schema := make(sql.Schema, len(resp.Columns))
+	for i, col := range resp.Columns {
+		typ, ok := query.Type_value[col.Type]
+		if !ok {
+			return nil, ErrUnsupportedColumn.New(col.Name, table, col.Type)
+		}
+
+		t, err := sql.MysqlTypeToType(query.Type(typ))
+		if err != nil {
+			return nil, ErrUnsupportedColumn.New(col.Name, table, col.Type)
+		}
+
+		schema[i] = &sql.Column{
+			Name:     col.Name,
+			Type:     t,
+			Nullable: col.Nullable,
+			Source:   table,
+		}
+	}
+	return schema, nil
+}
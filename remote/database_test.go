@@ -0,0 +1,262 @@
+package remote
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/remote/rpc"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeSource is a data source with tables kept in memory that ignores the
+// filters of the scans, which it records.
+type fakeSource struct {
+	columns map[string][]*rpc.Column
+	rows    map[string][][]*rpc.Value
+
+	mu    sync.Mutex
+	scans []*rpc.ScanRequest
+}
+
+func (s *fakeSource) ListTables(context.Context, *rpc.ListTablesRequest) (*rpc.ListTablesResponse, error) {
+	resp := new(rpc.ListTablesResponse)
+	for name := range s.columns {
+		resp.Tables = append(resp.Tables, name)
+	}
+	return resp, nil
+}
+
+func (s *fakeSource) GetSchema(_ context.Context, req *rpc.GetSchemaRequest) (*rpc.GetSchemaResponse, error) {
+	return &rpc.GetSchemaResponse{Columns: s.columns[req.Table]}, nil
+}
+
+// Scan sends the rows in batches of two.
+func (s *fakeSource) Scan(req *rpc.ScanRequest, stream rpc.Table_ScanServer) error {
+	s.mu.Lock()
+	s.scans = append(s.scans, req)
+	s.mu.Unlock()
+
+	positions := make([]int, len(req.Columns))
+	for i, name := range req.Columns {
+		for j, col := range s.columns[req.Table] {
+			if col.Name == name {
+				positions[i] = j
+			}
+		}
+	}
+
+	resp := new(rpc.ScanResponse)
+	for _, values := range s.rows[req.Table] {
+		row := &rpc.Row{Values: values}
+		if len(req.Columns) > 0 {
+			row = &rpc.Row{Values: make([]*rpc.Value, len(positions))}
+			for i, p := range positions {
+				row.Values[i] = values[p]
+			}
+		}
+
+		resp.Rows = append(resp.Rows, row)
+		if len(resp.Rows) == 2 {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			resp = new(rpc.ScanResponse)
+		}
+	}
+
+	if len(resp.Rows) > 0 {
+		return stream.Send(resp)
+	}
+	return nil
+}
+
+func (s *fakeSource) lastScan() *rpc.ScanRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scans[len(s.scans)-1]
+}
+
+func intValue(n int64) *rpc.Value {
+	return &rpc.Value{Kind: &rpc.Value_IntValue{IntValue: n}}
+}
+
+func stringValue(s string) *rpc.Value {
+	return &rpc.Value{Kind: &rpc.Value_StringValue{StringValue: s}}
+}
+
+var nullValue = &rpc.Value{Kind: &rpc.Value_NullValue{NullValue: true}}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		columns: map[string][]*rpc.Column{
+			"users": {
+				{Name: "id", Type: "INT64"},
+				{Name: "name", Type: "VARCHAR", Nullable: true},
+				{Name: "tags", Type: "JSON", Nullable: true},
+				{Name: "created", Type: "DATETIME"},
+			},
+			"empty": {
+				{Name: "data", Type: "BLOB"},
+			},
+		},
+		rows: map[string][][]*rpc.Value{
+			"users": {
+				{intValue(1), stringValue("alice"), stringValue(`["a"]`), stringValue("2019-01-01 00:00:00")},
+				{intValue(2), stringValue("bob"), nullValue, stringValue("2019-01-02 00:00:00")},
+				{intValue(3), nullValue, stringValue(`["b", "c"]`), stringValue("2019-01-03 00:00:00")},
+				{intValue(4), stringValue("dave"), stringValue("[]"), stringValue("2019-01-04 00:00:00")},
+			},
+		},
+	}
+}
+
+// serve serves the data source and returns a connection to it.
+func serve(t *testing.T, source rpc.TableServer) *grpc.ClientConn {
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	rpc.RegisterTableServer(s, source)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testQuery(t *testing.T, e *sqle.Engine, query string, expected []sql.Row) {
+	t.Helper()
+	require := require.New(t)
+
+	_, iter, err := e.Query(sql.NewEmptyContext(), query)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, rows)
+}
+
+func TestDatabase(t *testing.T) {
+	require := require.New(t)
+	source := newFakeSource()
+	db, err := NewDatabase("remote", serve(t, source))
+	require.NoError(err)
+	require.Equal("remote", db.Name())
+
+	var names []string
+	for name := range db.Tables() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal([]string{"empty", "users"}, names)
+
+	require.Equal(sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users"},
+		{Name: "name", Type: sql.Text, Nullable: true, Source: "users"},
+		{Name: "tags", Type: sql.JSON, Nullable: true, Source: "users"},
+		{Name: "created", Type: sql.Datetime, Source: "users"},
+	}, db.Tables()["users"].Schema())
+
+	orders := memory.NewTable("orders", sql.Schema{
+		{Name: "user_id", Type: sql.Int64, Source: "orders"},
+		{Name: "total", Type: sql.Float64, Source: "orders"},
+	})
+	ctx := sql.NewEmptyContext()
+	require.NoError(orders.Insert(ctx, sql.NewRow(int64(1), 10.5)))
+	require.NoError(orders.Insert(ctx, sql.NewRow(int64(4), 3.0)))
+	require.NoError(orders.Insert(ctx, sql.NewRow(int64(1), 2.5)))
+	local := memory.NewDatabase("local")
+	local.AddTable("orders", orders)
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+	e.AddDatabase(local)
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{
+			"SELECT * FROM remote.users WHERE id = 1",
+			[]sql.Row{{int64(1), "alice", []interface{}{"a"}, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			"SELECT id, name FROM remote.users WHERE id >= 2 AND name IS NOT NULL",
+			[]sql.Row{{int64(2), "bob"}, {int64(4), "dave"}},
+		},
+		{
+			"SELECT JSON_EXTRACT(tags, '$[1]') FROM remote.users WHERE id = 3",
+			[]sql.Row{{"c"}},
+		},
+		{
+			"SELECT u.name, SUM(o.total) FROM remote.users u INNER JOIN local.orders o ON u.id = o.user_id " +
+				"GROUP BY u.name ORDER BY u.name",
+			[]sql.Row{{"alice", float64(13)}, {"dave", float64(3)}},
+		},
+		{
+			"SELECT COUNT(*) FROM remote.empty",
+			[]sql.Row{{int64(0)}},
+		},
+		{
+			"SELECT id FROM remote.users LIMIT 1",
+			[]sql.Row{{int64(1)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			testQuery(t, e, tt.query, tt.expected)
+		})
+	}
+}
+
+func TestTableScanRequest(t *testing.T) {
+	require := require.New(t)
+	source := newFakeSource()
+	db, err := NewDatabase("remote", serve(t, source))
+	require.NoError(err)
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+
+	// Only the columns of the projection and the filters are read, and the
+	// filters are hints, as the data source ignores them.
+	testQuery(t, e, "SELECT name FROM remote.users "+
+		"WHERE 4 > id AND created != '2019-01-01 00:00:00' AND name LIKE '%b%' AND id <> '3'",
+		[]sql.Row{{"bob"}},
+	)
+
+	req := source.lastScan()
+	require.Equal("users", req.Table)
+	require.Equal([]string{"id", "name", "created"}, req.Columns)
+	require.Equal([]*rpc.Filter{
+		{Column: "id", Operator: rpc.Filter_LESS, Value: intValue(4)},
+		{Column: "created", Operator: rpc.Filter_NOT_EQUAL, Value: stringValue("2019-01-01 00:00:00")},
+	}, req.Filters)
+
+	testQuery(t, e, "SELECT COUNT(*) FROM remote.users WHERE tags IS NULL OR id BETWEEN 2 AND 3", []sql.Row{{int64(2)}})
+	require.Empty(source.lastScan().Filters)
+
+	testQuery(t, e, "SELECT id FROM remote.users WHERE id BETWEEN 2 AND 3 AND tags IS NULL", []sql.Row{{int64(2)}})
+	require.Equal([]*rpc.Filter{
+		{Column: "id", Operator: rpc.Filter_GREATER_OR_EQUAL, Value: intValue(2)},
+		{Column: "id", Operator: rpc.Filter_LESS_OR_EQUAL, Value: intValue(3)},
+		{Column: "tags", Operator: rpc.Filter_IS_NULL},
+	}, source.lastScan().Filters)
+}
+
+func TestDatabaseUnsupportedColumn(t *testing.T) {
+	source := newFakeSource()
+	source.columns["users"][0].Type = "DECIMAL"
+	_, err := NewDatabase("remote", serve(t, source))
+	require.True(t, ErrUnsupportedColumn.Is(err))
+}
@@ -0,0 +1,156 @@
+package remote
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/remote/rpc"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// hints returns the filters sent to the data source, from the comparisons
+// of columns with literals that must all be true for the rows to match the
+// filters of the table.
+func (t *Table) hints() []*rpc.Filter {
+	var hints []*rpc.Filter
+	add := func(col int, op rpc.Filter_Operator, lit sql.Expression) {
+		h := &rpc.Filter{Column: t.schema[col].Name, Operator: op}
+		if lit != nil {
+			v, ok := t.value(col, lit)
+			if !ok {
+				return
+			}
+			h.Value = v
+		}
+		hints = append(hints, h)
+	}
+
+	var visit func(e sql.Expression)
+	visit = func(e sql.Expression) {
+		switch e := e.(type) {
+		case *expression.And:
+			visit(e.Left)
+			visit(e.Right)
+		case *expression.Between:
+			if col := t.column(e.Val); col >= 0 {
+				add(col, rpc.Filter_GREATER_OR_EQUAL, e.Lower)
+				add(col, rpc.Filter_LESS_OR_EQUAL, e.Upper)
+			}
+		case *expression.IsNull:
+			if col := t.column(e.Child); col >= 0 {
+				add(col, rpc.Filter_IS_NULL, nil)
+			}
+		case *expression.Not:
+			switch child := e.Child.(type) {
+			case *expression.IsNull:
+				if col := t.column(child.Child); col >= 0 {
+					add(col, rpc.Filter_IS_NOT_NULL, nil)
+				}
+			case *expression.Equals:
+				col, lit := t.column(child.Left()), child.Right()
+				if col < 0 {
+					col, lit = t.column(child.Right()), child.Left()
+				}
+				if col >= 0 {
+					add(col, rpc.Filter_NOT_EQUAL, lit)
+				}
+			}
+		case expression.Comparer:
+			col, lit, swapped := t.column(e.Left()), e.Right(), false
+			if col < 0 {
+				col, lit, swapped = t.column(e.Right()), e.Left(), true
+			}
+			if col < 0 {
+				return
+			}
+
+			var op rpc.Filter_Operator
+			switch e.(type) {
+			case *expression.Equals:
+				op = rpc.Filter_EQUAL
+			case *expression.LessThan:
+				op = rpc.Filter_LESS
+			case *expression.LessThanOrEqual:
+				op = rpc.Filter_LESS_OR_EQUAL
+			case *expression.GreaterThan:
+				op = rpc.Filter_GREATER
+			case *expression.GreaterThanOrEqual:
+				op = rpc.Filter_GREATER_OR_EQUAL
+			default:
+				return
+			}
+
+			// With the literal on the left the operator is reversed, as in
+			// 5 < id, which is id > 5.
+			if swapped {
+				op = reversed[op]
+			}
+			add(col, op, lit)
+		}
+	}
+
+	for _, f := range t.filters {
+		visit(f)
+	}
+	return hints
+}
+
+var reversed = map[rpc.Filter_Operator]rpc.Filter_Operator{
+	rpc.Filter_EQUAL:            rpc.Filter_EQUAL,
+	rpc.Filter_LESS:             rpc.Filter_GREATER,
+	rpc.Filter_LESS_OR_EQUAL:    rpc.Filter_GREATER_OR_EQUAL,
+	rpc.Filter_GREATER:          rpc.Filter_LESS,
+	rpc.Filter_GREATER_OR_EQUAL: rpc.Filter_LESS_OR_EQUAL,
+}
+
+// column returns the position in the schema of the column of a field of
+// the table, or -1 if the expression is not one.
+func (t *Table) column(e sql.Expression) int {
+	gf, ok := e.(*expression.GetField)
+	if !ok || !strings.EqualFold(gf.Table(), t.name) {
+		return -1
+	}
+	return t.schema.IndexOf(gf.Name(), t.name)
+}
+
+// value returns the value of a literal compared with a column, converted
+// to the type of the column. Literals whose values the engine would not
+// compare as values of the type of the column, such as strings compared
+// with numbers, have no value.
+func (t *Table) value(col int, e sql.Expression) (*rpc.Value, bool) {
+	l, ok := e.(*expression.Literal)
+	if !ok || l.Value() == nil {
+		return nil, false
+	}
+
+	typ := t.schema[col].Type
+	if !sameKind(typ, l.Type()) {
+		return nil, false
+	}
+
+	v, err := typ.Convert(l.Value())
+	if err != nil || v == nil {
+		return nil, false
+	}
+
+	value, err := encodeValue(typ, v)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func sameKind(column, literal sql.Type) bool {
+	switch {
+	case sql.IsInteger(column):
+		return sql.IsInteger(literal)
+	case sql.IsDecimal(column):
+		return sql.IsNumber(literal)
+	case sql.IsTime(column):
+		return sql.IsTime(literal) || sql.IsText(literal)
+	case sql.IsText(column) && column != sql.JSON:
+		return sql.IsText(literal) && literal != sql.JSON
+	default:
+		return false
+	}
+}
@@ -0,0 +1,6 @@
+// Package rpc has the gRPC service implemented by the data sources whose
+// tables are read by the engine, as defined in table.proto, and its
+// clients.
+package rpc
+
+//go:generate protoc --go_out=plugins=grpc:. table.proto
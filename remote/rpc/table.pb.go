@@ -0,0 +1,842 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: table.proto
+
+package rpc
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Filter_Operator int32
+
+const (
+	Filter_EQUAL            Filter_Operator = 0
+	Filter_NOT_EQUAL        Filter_Operator = 1
+	Filter_LESS             Filter_Operator = 2
+	Filter_LESS_OR_EQUAL    Filter_Operator = 3
+	Filter_GREATER          Filter_Operator = 4
+	Filter_GREATER_OR_EQUAL Filter_Operator = 5
+	// IS_NULL and IS_NOT_NULL filters have no value.
+	Filter_IS_NULL     Filter_Operator = 6
+	Filter_IS_NOT_NULL Filter_Operator = 7
+)
+
+var Filter_Operator_name = map[int32]string{
+	0: "EQUAL",
+	1: "NOT_EQUAL",
+	2: "LESS",
+	3: "LESS_OR_EQUAL",
+	4: "GREATER",
+	5: "GREATER_OR_EQUAL",
+	6: "IS_NULL",
+	7: "IS_NOT_NULL",
+}
+
+var Filter_Operator_value = map[string]int32{
+	"EQUAL":            0,
+	"NOT_EQUAL":        1,
+	"LESS":             2,
+	"LESS_OR_EQUAL":    3,
+	"GREATER":          4,
+	"GREATER_OR_EQUAL": 5,
+	"IS_NULL":          6,
+	"IS_NOT_NULL":      7,
+}
+
+func (x Filter_Operator) String() string {
+	return proto.EnumName(Filter_Operator_name, int32(x))
+}
+
+func (Filter_Operator) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{7, 0}
+}
+
+type ListTablesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTablesRequest) Reset()         { *m = ListTablesRequest{} }
+func (m *ListTablesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTablesRequest) ProtoMessage()    {}
+func (*ListTablesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{0}
+}
+
+func (m *ListTablesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesRequest.Unmarshal(m, b)
+}
+func (m *ListTablesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListTablesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesRequest.Merge(m, src)
+}
+func (m *ListTablesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTablesRequest.Size(m)
+}
+func (m *ListTablesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTablesRequest proto.InternalMessageInfo
+
+type ListTablesResponse struct {
+	Tables               []string `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTablesResponse) Reset()         { *m = ListTablesResponse{} }
+func (m *ListTablesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListTablesResponse) ProtoMessage()    {}
+func (*ListTablesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{1}
+}
+
+func (m *ListTablesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesResponse.Unmarshal(m, b)
+}
+func (m *ListTablesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListTablesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesResponse.Merge(m, src)
+}
+func (m *ListTablesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListTablesResponse.Size(m)
+}
+func (m *ListTablesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTablesResponse proto.InternalMessageInfo
+
+func (m *ListTablesResponse) GetTables() []string {
+	if m != nil {
+		return m.Tables
+	}
+	return nil
+}
+
+type GetSchemaRequest struct {
+	Table                string   `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSchemaRequest) Reset()         { *m = GetSchemaRequest{} }
+func (m *GetSchemaRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSchemaRequest) ProtoMessage()    {}
+func (*GetSchemaRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{2}
+}
+
+func (m *GetSchemaRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSchemaRequest.Unmarshal(m, b)
+}
+func (m *GetSchemaRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSchemaRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSchemaRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSchemaRequest.Merge(m, src)
+}
+func (m *GetSchemaRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSchemaRequest.Size(m)
+}
+func (m *GetSchemaRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSchemaRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSchemaRequest proto.InternalMessageInfo
+
+func (m *GetSchemaRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+type GetSchemaResponse struct {
+	Columns              []*Column `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GetSchemaResponse) Reset()         { *m = GetSchemaResponse{} }
+func (m *GetSchemaResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSchemaResponse) ProtoMessage()    {}
+func (*GetSchemaResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{3}
+}
+
+func (m *GetSchemaResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSchemaResponse.Unmarshal(m, b)
+}
+func (m *GetSchemaResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSchemaResponse.Marshal(b, m, deterministic)
+}
+func (m *GetSchemaResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSchemaResponse.Merge(m, src)
+}
+func (m *GetSchemaResponse) XXX_Size() int {
+	return xxx_messageInfo_GetSchemaResponse.Size(m)
+}
+func (m *GetSchemaResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSchemaResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSchemaResponse proto.InternalMessageInfo
+
+func (m *GetSchemaResponse) GetColumns() []*Column {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	Table string `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	// Columns are the columns whose values are returned in the rows, in this
+	// order. All the columns of the table are returned if there are none.
+	Columns []string `protobuf:"bytes,2,rep,name=columns,proto3" json:"columns,omitempty"`
+	// Filters are conditions matched by all the rows the query reads. They
+	// are hints to skip the rows that don't match them, which may be
+	// ignored, as the engine filters the rows again.
+	Filters              []*Filter `protobuf:"bytes,3,rep,name=filters,proto3" json:"filters,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ScanRequest) Reset()         { *m = ScanRequest{} }
+func (m *ScanRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanRequest) ProtoMessage()    {}
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{4}
+}
+
+func (m *ScanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanRequest.Unmarshal(m, b)
+}
+func (m *ScanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanRequest.Marshal(b, m, deterministic)
+}
+func (m *ScanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanRequest.Merge(m, src)
+}
+func (m *ScanRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanRequest.Size(m)
+}
+func (m *ScanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanRequest proto.InternalMessageInfo
+
+func (m *ScanRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *ScanRequest) GetColumns() []string {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *ScanRequest) GetFilters() []*Filter {
+	if m != nil {
+		return m.Filters
+	}
+	return nil
+}
+
+type ScanResponse struct {
+	// Rows are the next rows of the table.
+	Rows                 []*Row   `protobuf:"bytes,1,rep,name=rows,proto3" json:"rows,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScanResponse) Reset()         { *m = ScanResponse{} }
+func (m *ScanResponse) String() string { return proto.CompactTextString(m) }
+func (*ScanResponse) ProtoMessage()    {}
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{5}
+}
+
+func (m *ScanResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanResponse.Unmarshal(m, b)
+}
+func (m *ScanResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanResponse.Marshal(b, m, deterministic)
+}
+func (m *ScanResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanResponse.Merge(m, src)
+}
+func (m *ScanResponse) XXX_Size() int {
+	return xxx_messageInfo_ScanResponse.Size(m)
+}
+func (m *ScanResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanResponse proto.InternalMessageInfo
+
+func (m *ScanResponse) GetRows() []*Row {
+	if m != nil {
+		return m.Rows
+	}
+	return nil
+}
+
+// Column is a column of a table.
+type Column struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Type is the MySQL type of the column, such as INT64 or VARCHAR.
+	Type                 string   `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Nullable             bool     `protobuf:"varint,3,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Column) Reset()         { *m = Column{} }
+func (m *Column) String() string { return proto.CompactTextString(m) }
+func (*Column) ProtoMessage()    {}
+func (*Column) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{6}
+}
+
+func (m *Column) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Column.Unmarshal(m, b)
+}
+func (m *Column) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Column.Marshal(b, m, deterministic)
+}
+func (m *Column) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Column.Merge(m, src)
+}
+func (m *Column) XXX_Size() int {
+	return xxx_messageInfo_Column.Size(m)
+}
+func (m *Column) XXX_DiscardUnknown() {
+	xxx_messageInfo_Column.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Column proto.InternalMessageInfo
+
+func (m *Column) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Column) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Column) GetNullable() bool {
+	if m != nil {
+		return m.Nullable
+	}
+	return false
+}
+
+// Filter compares the values of a column with a value of its type.
+type Filter struct {
+	Column               string          `protobuf:"bytes,1,opt,name=column,proto3" json:"column,omitempty"`
+	Operator             Filter_Operator `protobuf:"varint,2,opt,name=operator,proto3,enum=gms.remote.Filter_Operator" json:"operator,omitempty"`
+	Value                *Value          `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Filter) Reset()         { *m = Filter{} }
+func (m *Filter) String() string { return proto.CompactTextString(m) }
+func (*Filter) ProtoMessage()    {}
+func (*Filter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{7}
+}
+
+func (m *Filter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Filter.Unmarshal(m, b)
+}
+func (m *Filter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Filter.Marshal(b, m, deterministic)
+}
+func (m *Filter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Filter.Merge(m, src)
+}
+func (m *Filter) XXX_Size() int {
+	return xxx_messageInfo_Filter.Size(m)
+}
+func (m *Filter) XXX_DiscardUnknown() {
+	xxx_messageInfo_Filter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Filter proto.InternalMessageInfo
+
+func (m *Filter) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+func (m *Filter) GetOperator() Filter_Operator {
+	if m != nil {
+		return m.Operator
+	}
+	return Filter_EQUAL
+}
+
+func (m *Filter) GetValue() *Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// Row is a row of a table, with a value for each column.
+type Row struct {
+	Values               []*Value `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Row) Reset()         { *m = Row{} }
+func (m *Row) String() string { return proto.CompactTextString(m) }
+func (*Row) ProtoMessage()    {}
+func (*Row) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{8}
+}
+
+func (m *Row) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Row.Unmarshal(m, b)
+}
+func (m *Row) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Row.Marshal(b, m, deterministic)
+}
+func (m *Row) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Row.Merge(m, src)
+}
+func (m *Row) XXX_Size() int {
+	return xxx_messageInfo_Row.Size(m)
+}
+func (m *Row) XXX_DiscardUnknown() {
+	xxx_messageInfo_Row.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Row proto.InternalMessageInfo
+
+func (m *Row) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+// Value is a value of a row or a filter. Integers and floating point
+// numbers are sent as numbers, binary strings as bytes and the rest of the
+// values, such as decimals, dates or JSON documents, as strings.
+type Value struct {
+	// Types that are valid to be assigned to Kind:
+	//	*Value_NullValue
+	//	*Value_IntValue
+	//	*Value_UintValue
+	//	*Value_DoubleValue
+	//	*Value_StringValue
+	//	*Value_BytesValue
+	Kind                 isValue_Kind `protobuf_oneof:"kind"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *Value) Reset()         { *m = Value{} }
+func (m *Value) String() string { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()    {}
+func (*Value) Descriptor() ([]byte, []int) {
+	return fileDescriptor_448a2743262f7a00, []int{9}
+}
+
+func (m *Value) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Value.Unmarshal(m, b)
+}
+func (m *Value) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Value.Marshal(b, m, deterministic)
+}
+func (m *Value) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Value.Merge(m, src)
+}
+func (m *Value) XXX_Size() int {
+	return xxx_messageInfo_Value.Size(m)
+}
+func (m *Value) XXX_DiscardUnknown() {
+	xxx_messageInfo_Value.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Value proto.InternalMessageInfo
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_NullValue struct {
+	NullValue bool `protobuf:"varint,1,opt,name=null_value,json=nullValue,proto3,oneof"`
+}
+
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"zigzag64,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_UintValue struct {
+	UintValue uint64 `protobuf:"varint,3,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+type Value_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,6,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+func (*Value_NullValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_UintValue) isValue_Kind() {}
+
+func (*Value_DoubleValue) isValue_Kind() {}
+
+func (*Value_StringValue) isValue_Kind() {}
+
+func (*Value_BytesValue) isValue_Kind() {}
+
+func (m *Value) GetKind() isValue_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
+
+func (m *Value) GetNullValue() bool {
+	if x, ok := m.GetKind().(*Value_NullValue); ok {
+		return x.NullValue
+	}
+	return false
+}
+
+func (m *Value) GetIntValue() int64 {
+	if x, ok := m.GetKind().(*Value_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
+}
+
+func (m *Value) GetUintValue() uint64 {
+	if x, ok := m.GetKind().(*Value_UintValue); ok {
+		return x.UintValue
+	}
+	return 0
+}
+
+func (m *Value) GetDoubleValue() float64 {
+	if x, ok := m.GetKind().(*Value_DoubleValue); ok {
+		return x.DoubleValue
+	}
+	return 0
+}
+
+func (m *Value) GetStringValue() string {
+	if x, ok := m.GetKind().(*Value_StringValue); ok {
+		return x.StringValue
+	}
+	return ""
+}
+
+func (m *Value) GetBytesValue() []byte {
+	if x, ok := m.GetKind().(*Value_BytesValue); ok {
+		return x.BytesValue
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Value) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Value_NullValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_UintValue)(nil),
+		(*Value_DoubleValue)(nil),
+		(*Value_StringValue)(nil),
+		(*Value_BytesValue)(nil),
+	}
+}
+
+func init() {
+	proto.RegisterEnum("gms.remote.Filter_Operator", Filter_Operator_name, Filter_Operator_value)
+	proto.RegisterType((*ListTablesRequest)(nil), "gms.remote.ListTablesRequest")
+	proto.RegisterType((*ListTablesResponse)(nil), "gms.remote.ListTablesResponse")
+	proto.RegisterType((*GetSchemaRequest)(nil), "gms.remote.GetSchemaRequest")
+	proto.RegisterType((*GetSchemaResponse)(nil), "gms.remote.GetSchemaResponse")
+	proto.RegisterType((*ScanRequest)(nil), "gms.remote.ScanRequest")
+	proto.RegisterType((*ScanResponse)(nil), "gms.remote.ScanResponse")
+	proto.RegisterType((*Column)(nil), "gms.remote.Column")
+	proto.RegisterType((*Filter)(nil), "gms.remote.Filter")
+	proto.RegisterType((*Row)(nil), "gms.remote.Row")
+	proto.RegisterType((*Value)(nil), "gms.remote.Value")
+}
+
+func init() { proto.RegisterFile("table.proto", fileDescriptor_448a2743262f7a00) }
+
+var fileDescriptor_448a2743262f7a00 = []byte{
+	// 610 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x94, 0xcf, 0x6e, 0xd3, 0x40,
+	0x10, 0xc6, 0xb3, 0x89, 0xed, 0xda, 0xe3, 0x96, 0x26, 0x43, 0x05, 0x51, 0xa0, 0x10, 0xdc, 0x03,
+	0x41, 0xaa, 0xac, 0x2a, 0x3d, 0x70, 0xe0, 0xd4, 0xa2, 0xd0, 0x20, 0xa2, 0x06, 0x36, 0x29, 0x07,
+	0x2e, 0x51, 0x92, 0x2e, 0xad, 0x55, 0xc7, 0x1b, 0xec, 0x35, 0x55, 0xaf, 0x5c, 0x78, 0x48, 0xc4,
+	0xbb, 0xa0, 0xfd, 0xe3, 0xc4, 0x22, 0x94, 0xdb, 0xce, 0x7c, 0xbf, 0x99, 0xfd, 0x76, 0x9c, 0x09,
+	0xf8, 0x62, 0x3a, 0x8b, 0x59, 0xb8, 0x4c, 0xb9, 0xe0, 0x08, 0x57, 0x8b, 0x2c, 0x4c, 0xd9, 0x82,
+	0x0b, 0x16, 0x3c, 0x84, 0xc6, 0x20, 0xca, 0xc4, 0x58, 0xca, 0x19, 0x65, 0xdf, 0x72, 0x96, 0x89,
+	0xe0, 0x10, 0xb0, 0x9c, 0xcc, 0x96, 0x3c, 0xc9, 0x18, 0x3e, 0x02, 0x47, 0x75, 0xc9, 0x9a, 0xa4,
+	0x5d, 0xeb, 0x78, 0xd4, 0x44, 0x41, 0x07, 0xea, 0x67, 0x4c, 0x8c, 0xe6, 0xd7, 0x6c, 0x31, 0x35,
+	0x1d, 0x70, 0x0f, 0x6c, 0xa5, 0x36, 0x49, 0x9b, 0x74, 0x3c, 0xaa, 0x83, 0xe0, 0x04, 0x1a, 0x25,
+	0xd2, 0xb4, 0x3d, 0x84, 0xad, 0x39, 0x8f, 0xf3, 0x45, 0xa2, 0xfb, 0xfa, 0x5d, 0x0c, 0xd7, 0xfe,
+	0xc2, 0xb7, 0x4a, 0xa2, 0x05, 0x12, 0xdc, 0x80, 0x3f, 0x9a, 0x4f, 0x93, 0xff, 0xde, 0x83, 0xcd,
+	0x75, 0xcb, 0xaa, 0xb2, 0x5a, 0x84, 0xf2, 0xb2, 0xaf, 0x51, 0x2c, 0x58, 0x9a, 0x35, 0x6b, 0x9b,
+	0x97, 0xbd, 0x53, 0x12, 0x2d, 0x90, 0xe0, 0x18, 0xb6, 0xf5, 0x65, 0xc6, 0xea, 0x01, 0x58, 0x29,
+	0xbf, 0x2d, 0x7c, 0xee, 0x96, 0x4b, 0x29, 0xbf, 0xa5, 0x4a, 0x0c, 0x06, 0xe0, 0x68, 0xd3, 0x88,
+	0x60, 0x25, 0xd3, 0x45, 0xe1, 0x4d, 0x9d, 0x65, 0x4e, 0xdc, 0x2d, 0x59, 0xb3, 0xaa, 0x73, 0xf2,
+	0x8c, 0x2d, 0x70, 0x93, 0x3c, 0x8e, 0xd5, 0x3b, 0x6a, 0x6d, 0xd2, 0x71, 0xe9, 0x2a, 0x0e, 0x7e,
+	0x56, 0xc1, 0xd1, 0xb6, 0xe4, 0xfc, 0xf5, 0x33, 0x4c, 0x43, 0x13, 0xe1, 0x6b, 0x70, 0xf9, 0x92,
+	0xa5, 0x53, 0xc1, 0x53, 0xd5, 0xf6, 0x41, 0xf7, 0xc9, 0xe6, 0xa3, 0xc2, 0xa1, 0x41, 0xe8, 0x0a,
+	0xc6, 0x97, 0x60, 0x7f, 0x9f, 0xc6, 0xb9, 0xbe, 0xd4, 0xef, 0x36, 0xca, 0x55, 0x9f, 0xa5, 0x40,
+	0xb5, 0x1e, 0xfc, 0x20, 0xe0, 0x16, 0xf5, 0xe8, 0x81, 0xdd, 0xfb, 0x74, 0x71, 0x32, 0xa8, 0x57,
+	0x70, 0x07, 0xbc, 0xf3, 0xe1, 0x78, 0xa2, 0x43, 0x82, 0x2e, 0x58, 0x83, 0xde, 0x68, 0x54, 0xaf,
+	0x62, 0x03, 0x76, 0xe4, 0x69, 0x32, 0xa4, 0x46, 0xac, 0xa1, 0x0f, 0x5b, 0x67, 0xb4, 0x77, 0x32,
+	0xee, 0xd1, 0xba, 0x85, 0x7b, 0x50, 0x37, 0xc1, 0x1a, 0xb1, 0x25, 0xf2, 0x7e, 0x34, 0x39, 0xbf,
+	0x18, 0x0c, 0xea, 0x0e, 0xee, 0x82, 0x2f, 0x83, 0xe1, 0x58, 0x27, 0xb6, 0x82, 0x23, 0xa8, 0x51,
+	0x7e, 0x8b, 0xaf, 0xc0, 0x51, 0xa6, 0x8a, 0xaf, 0xf0, 0x0f, 0xd7, 0x06, 0x08, 0x7e, 0x13, 0xb0,
+	0x55, 0x06, 0x9f, 0x03, 0xc8, 0x89, 0x4e, 0xf4, 0x73, 0xe5, 0xf8, 0xdc, 0x7e, 0x85, 0x7a, 0x32,
+	0xa7, 0x81, 0x7d, 0xf0, 0xa2, 0x44, 0x18, 0x5d, 0x0e, 0x11, 0xfb, 0x15, 0xea, 0x46, 0x89, 0x58,
+	0xd5, 0xe7, 0x6b, 0x5d, 0x8e, 0xcb, 0x92, 0xf5, 0xf9, 0x0a, 0x38, 0x80, 0xed, 0x4b, 0x9e, 0xcf,
+	0x62, 0x66, 0x10, 0xab, 0x4d, 0x3a, 0xa4, 0x5f, 0xa1, 0xbe, 0xce, 0xae, 0xa0, 0x4c, 0xa4, 0x51,
+	0x72, 0x65, 0x20, 0x5b, 0x7e, 0x46, 0x09, 0xe9, 0xac, 0x86, 0x5e, 0x80, 0x3f, 0xbb, 0x13, 0x2c,
+	0x33, 0x8c, 0xd3, 0x26, 0x9d, 0xed, 0x7e, 0x85, 0x82, 0x4a, 0x2a, 0xe4, 0xd4, 0x01, 0xeb, 0x26,
+	0x4a, 0x2e, 0xbb, 0xbf, 0x08, 0xd8, 0x6a, 0x47, 0xf1, 0x03, 0xc0, 0x7a, 0x61, 0x71, 0xbf, 0x3c,
+	0x92, 0x8d, 0xed, 0x6e, 0x3d, 0xbb, 0x4f, 0x36, 0xbf, 0xf2, 0x3e, 0x78, 0xab, 0x2d, 0xc5, 0xa7,
+	0x65, 0xf8, 0xef, 0x35, 0x6f, 0xed, 0xdf, 0xa3, 0x9a, 0x4e, 0x6f, 0xc0, 0x92, 0xfb, 0x83, 0x8f,
+	0xcb, 0x58, 0x69, 0x7d, 0x5b, 0xcd, 0x4d, 0x41, 0x97, 0x1e, 0x91, 0xd3, 0x03, 0x68, 0xcd, 0xf9,
+	0x22, 0xbc, 0x8a, 0xc4, 0x75, 0x3e, 0x0b, 0xb3, 0x74, 0x7e, 0x59, 0x82, 0x3f, 0x92, 0x2f, 0xb5,
+	0x74, 0x39, 0x9f, 0x39, 0xea, 0x1f, 0xed, 0xf8, 0xcf, 0x00, 0xb1, 0x7f, 0xb2, 0x6c, 0xe0, 0x04,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// TableClient is the client API for Table service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type TableClient interface {
+	// ListTables returns the names of the tables of the data source.
+	ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesResponse, error)
+	// GetSchema returns the columns of a table.
+	GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error)
+	// Scan returns the rows of a table, in batches.
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (Table_ScanClient, error)
+}
+
+type tableClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTableClient(cc *grpc.ClientConn) TableClient {
+	return &tableClient{cc}
+}
+
+func (c *tableClient) ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesResponse, error) {
+	out := new(ListTablesResponse)
+	err := c.cc.Invoke(ctx, "/gms.remote.Table/ListTables", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tableClient) GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error) {
+	out := new(GetSchemaResponse)
+	err := c.cc.Invoke(ctx, "/gms.remote.Table/GetSchema", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tableClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (Table_ScanClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Table_serviceDesc.Streams[0], "/gms.remote.Table/Scan", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tableScanClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Table_ScanClient interface {
+	Recv() (*ScanResponse, error)
+	grpc.ClientStream
+}
+
+type tableScanClient struct {
+	grpc.ClientStream
+}
+
+func (x *tableScanClient) Recv() (*ScanResponse, error) {
+	m := new(ScanResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TableServer is the server API for Table service.
+type TableServer interface {
+	// ListTables returns the names of the tables of the data source.
+	ListTables(context.Context, *ListTablesRequest) (*ListTablesResponse, error)
+	// GetSchema returns the columns of a table.
+	GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error)
+	// Scan returns the rows of a table, in batches.
+	Scan(*ScanRequest, Table_ScanServer) error
+}
+
+func RegisterTableServer(s *grpc.Server, srv TableServer) {
+	s.RegisterService(&_Table_serviceDesc, srv)
+}
+
+func _Table_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTablesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TableServer).ListTables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gms.remote.Table/ListTables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TableServer).ListTables(ctx, req.(*ListTablesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Table_GetSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TableServer).GetSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gms.remote.Table/GetSchema",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TableServer).GetSchema(ctx, req.(*GetSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Table_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TableServer).Scan(m, &tableScanServer{stream})
+}
+
+type Table_ScanServer interface {
+	Send(*ScanResponse) error
+	grpc.ServerStream
+}
+
+type tableScanServer struct {
+	grpc.ServerStream
+}
+
+func (x *tableScanServer) Send(m *ScanResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Table_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "gms.remote.Table",
+	HandlerType: (*TableServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTables",
+			Handler:    _Table_ListTables_Handler,
+		},
+		{
+			MethodName: "GetSchema",
+			Handler:    _Table_GetSchema_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Scan",
+			Handler:       _Table_Scan_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "table.proto",
+}
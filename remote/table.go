@@ -0,0 +1,315 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/src-d/go-mysql-server/remote/rpc"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// Table is a table of a data source. Its rows are read with a scan of the
+// columns of the projection and of the filters, which are sent to the data
+// source as hints when they compare a column with a literal.
+type Table struct {
+	name   string
+	client rpc.TableClient
+	schema sql.Schema
+
+	// projected are the positions of the columns of the projection in the
+	// schema without projection, and projectedSchema their schema.
+	projected       []int
+	projectedSchema sql.Schema
+	projection      []string
+	filters         []sql.Expression
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.ProjectedTable = (*Table)(nil)
+var _ sql.FilteredTable = (*Table)(nil)
+
+func newTable(client rpc.TableClient, name string, schema sql.Schema) *Table {
+	return &Table{name: name, client: client, schema: schema}
+}
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	if t.projectedSchema != nil {
+		return t.projectedSchema
+	}
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("RemoteTable(%s)", t.name)
+	schema := t.Schema()
+	var columns = make([]string, len(schema))
+	for i, col := range schema {
+		columns[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(columns...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the table are
+// in a single partition.
+func (t *Table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
+	req, read := t.scanRequest()
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	stream, err := t.client.Scan(scanCtx, req)
+	if err != nil {
+		cancel()
+		return nil, ErrRemoteCall.New("Scan", t.name, err)
+	}
+
+	projected := t.projected
+	if projected == nil {
+		projected = make([]int, len(t.schema))
+		for i := range projected {
+			projected[i] = i
+		}
+	}
+
+	return &tableIter{
+		ctx:       ctx,
+		cancel:    cancel,
+		table:     t,
+		stream:    stream,
+		read:      read,
+		projected: projected,
+	}, nil
+}
+
+// scanRequest returns the request of the scan of the rows of the table and
+// the positions in the schema of the columns read, which are the ones of
+// the projection and the ones used by the filters.
+func (t *Table) scanRequest() (*rpc.ScanRequest, []int) {
+	req := &rpc.ScanRequest{Table: t.name, Filters: t.hints()}
+	if t.projected == nil {
+		read := make([]int, len(t.schema))
+		for i := range read {
+			read[i] = i
+		}
+		return req, read
+	}
+
+	used := make([]bool, len(t.schema))
+	for _, c := range t.projected {
+		used[c] = true
+	}
+
+	// Filters use the positions of the columns in the schema without
+	// projection.
+	for _, f := range t.filters {
+		expression.Inspect(f, func(e sql.Expression) bool {
+			if gf, ok := e.(*expression.GetField); ok && gf.Index() < len(t.schema) {
+				used[gf.Index()] = true
+			}
+			return true
+		})
+	}
+
+	var read []int
+	for i, ok := range used {
+		if ok {
+			read = append(read, i)
+			req.Columns = append(req.Columns, t.schema[i].Name)
+		}
+	}
+	return req, read
+}
+
+// HandledFilters implements the sql.FilteredTable interface. All the
+// filters with only columns of the table are handled, as they are
+// evaluated with the rows sent by the data source.
+func (t *Table) HandledFilters(filters []sql.Expression) []sql.Expression {
+	var handled []sql.Expression
+	for _, f := range filters {
+		var hasOtherFields bool
+		expression.Inspect(f, func(e sql.Expression) bool {
+			if e, ok := e.(*expression.GetField); ok {
+				if e.Table() != t.name || !t.schema.Contains(e.Name(), t.name) {
+					hasOtherFields = true
+					return false
+				}
+			}
+			return true
+		})
+
+		if !hasOtherFields {
+			handled = append(handled, f)
+		}
+	}
+
+	return handled
+}
+
+// WithFilters implements the sql.FilteredTable interface.
+func (t *Table) WithFilters(filters []sql.Expression) sql.Table {
+	if len(filters) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.filters = filters
+	return &nt
+}
+
+// Filters implements the sql.FilteredTable interface.
+func (t *Table) Filters() []sql.Expression {
+	return t.filters
+}
+
+// WithProjection implements the sql.ProjectedTable interface.
+func (t *Table) WithProjection(colNames []string) sql.Table {
+	if len(colNames) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.projected = make([]int, len(colNames))
+	nt.projectedSchema = make(sql.Schema, len(colNames))
+	for i, name := range colNames {
+		idx := t.schema.IndexOf(name, t.name)
+		if idx < 0 {
+			return t
+		}
+		nt.projected[i] = idx
+		nt.projectedSchema[i] = t.schema[idx]
+	}
+	nt.projection = colNames
+
+	return &nt
+}
+
+// Projection implements the sql.ProjectedTable interface.
+func (t *Table) Projection() []string {
+	return t.projection
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
+
+type tableIter struct {
+	ctx    *sql.Context
+	cancel context.CancelFunc
+	table  *Table
+	stream rpc.Table_ScanClient
+	// read are the positions in the schema of the columns of the values of
+	// the rows sent by the data source.
+	read      []int
+	projected []int
+	rows      []*rpc.Row
+}
+
+func (i *tableIter) Next() (sql.Row, error) {
+	for {
+		for len(i.rows) > 0 {
+			r := i.rows[0]
+			i.rows = i.rows[1:]
+
+			row, err := i.row(r)
+			if err != nil {
+				return nil, err
+			}
+
+			ok, err := i.matches(row)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				projected := make(sql.Row, len(i.projected))
+				for j, c := range i.projected {
+					projected[j] = row[c]
+				}
+				return projected, nil
+			}
+		}
+
+		resp, err := i.stream.Recv()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, ErrRemoteCall.New("Scan", i.table.name, err)
+		}
+		i.rows = resp.Rows
+	}
+}
+
+// row decodes a row sent by the data source to a row with the values of
+// the columns read in their positions in the schema.
+func (i *tableIter) row(r *rpc.Row) (sql.Row, error) {
+	if len(r.Values) != len(i.read) {
+		return nil, ErrRemoteCall.New(
+			"Scan",
+			i.table.name,
+			fmt.Sprintf("got a row with %d values instead of %d", len(r.Values), len(i.read)),
+		)
+	}
+
+	row := make(sql.Row, len(i.table.schema))
+	for j, c := range i.read {
+		v, err := decodeValue(i.table.schema[c].Type, r.Values[j])
+		if err != nil {
+			return nil, err
+		}
+		row[c] = v
+	}
+	return row, nil
+}
+
+func (i *tableIter) matches(row sql.Row) (bool, error) {
+	for _, f := range i.table.filters {
+		result, err := f.Eval(i.ctx, row)
+		if err != nil {
+			return false, err
+		}
+
+		if result != true {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Close cancels the scan, as the engine may be done with the rows before
+// all of them are read.
+func (i *tableIter) Close() error {
+	i.cancel()
+	return nil
+}
@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/src-d/go-mysql-server/remote/rpc"
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// encodeValue encodes a value of the given type as it is sent with the
+// MySQL protocol.
+func encodeValue(typ sql.Type, v interface{}) (*rpc.Value, error) {
+	val, err := typ.SQL(v)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case val.IsNull():
+		return &rpc.Value{Kind: &rpc.Value_NullValue{NullValue: true}}, nil
+	case val.IsSigned():
+		n, err := strconv.ParseInt(val.ToString(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &rpc.Value{Kind: &rpc.Value_IntValue{IntValue: n}}, nil
+	case val.IsUnsigned():
+		n, err := strconv.ParseUint(val.ToString(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &rpc.Value{Kind: &rpc.Value_UintValue{UintValue: n}}, nil
+	case val.IsFloat():
+		f, err := strconv.ParseFloat(val.ToString(), 64)
+		if err != nil {
+			return nil, err
+		}
+		return &rpc.Value{Kind: &rpc.Value_DoubleValue{DoubleValue: f}}, nil
+	case val.IsBinary():
+		return &rpc.Value{Kind: &rpc.Value_BytesValue{BytesValue: val.ToBytes()}}, nil
+	default:
+		return &rpc.Value{Kind: &rpc.Value_StringValue{StringValue: val.ToString()}}, nil
+	}
+}
+
+// decodeValue converts a value sent by a data source to the type of its
+// column.
+func decodeValue(typ sql.Type, v *rpc.Value) (interface{}, error) {
+	var value interface{}
+	switch kind := v.GetKind().(type) {
+	case nil, *rpc.Value_NullValue:
+		return nil, nil
+	case *rpc.Value_IntValue:
+		value = kind.IntValue
+	case *rpc.Value_UintValue:
+		value = kind.UintValue
+	case *rpc.Value_DoubleValue:
+		value = kind.DoubleValue
+	case *rpc.Value_StringValue:
+		value = kind.StringValue
+	case *rpc.Value_BytesValue:
+		if typ == sql.Blob {
+			return kind.BytesValue, nil
+		}
+		value = string(kind.BytesValue)
+	}
+
+	// JSON values are kept decoded, as the JSON functions expect them.
+	if typ == sql.JSON {
+		if s, ok := value.(string); ok {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(s), &doc); err != nil {
+				return nil, sql.ErrInvalidType.New(s)
+			}
+			return doc, nil
+		}
+		return value, nil
+	}
+
+	return typ.Convert(value)
+}
@@ -0,0 +1,429 @@
+package sqle
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+var (
+	// ErrNoCurrentRow is returned when the values of a result are read
+	// before calling Next or after it returns false.
+	ErrNoCurrentRow = errors.NewKind("there is no current row, Next must return true before reading values")
+
+	// ErrUnknownColumn is returned when a value of a column that is not in
+	// a result is read.
+	ErrUnknownColumn = errors.NewKind("column %q is not in the result")
+
+	// ErrNullValue is returned when a NULL value is read with a typed
+	// accessor of a result.
+	ErrNullValue = errors.NewKind("value of column %q is NULL")
+
+	// ErrInvalidScanTarget is returned when the destination of Scan or
+	// ScanAll is not a pointer to a struct or a slice of structs.
+	ErrInvalidScanTarget = errors.NewKind("scan destination must be %s, got %T")
+
+	// ErrScanColumn is returned when the value of a column cannot be
+	// stored in the field of a struct.
+	ErrScanColumn = errors.NewKind("unable to scan column %q into field %s: %s")
+)
+
+// Rows is the result of a query with typed accessors of the values of its
+// rows, which are read one at a time with Next:
+//
+//	rows, err := engine.QueryRows(ctx, "SELECT id, name FROM users")
+//	...
+//	defer rows.Close()
+//	for rows.Next() {
+//		id, err := rows.GetInt64("id")
+//		...
+//	}
+//	if err := rows.Err(); err != nil {
+//		...
+//	}
+//
+// The accessors convert the values to the requested type as the engine
+// converts them, and the columns are looked up by their name, ignoring the
+// case.
+type Rows struct {
+	schema sql.Schema
+	iter   sql.RowIter
+	row    sql.Row
+	err    error
+	closed bool
+}
+
+// QueryRows runs a query and returns its result. The result must be
+// closed once it's not used.
+func (e *Engine) QueryRows(ctx *sql.Context, query string) (*Rows, error) {
+	schema, iter, err := e.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{schema: schema, iter: iter}, nil
+}
+
+// Schema returns the columns of the result.
+func (r *Rows) Schema() sql.Schema {
+	return r.schema
+}
+
+// Next reads the next row, returning false when there are no more rows or
+// reading it failed, in which case Err returns the error. The result is
+// closed once all the rows are read.
+func (r *Rows) Next() bool {
+	if r.closed {
+		r.row = nil
+		return false
+	}
+
+	row, err := r.iter.Next()
+	if err != nil {
+		r.row = nil
+		if err != io.EOF {
+			r.err = err
+		}
+		if cerr := r.Close(); r.err == nil {
+			r.err = cerr
+		}
+		return false
+	}
+
+	r.row = row
+	return true
+}
+
+// Err returns the error reading the rows, if any.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close closes the result. It can be called more than once.
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.iter.Close()
+}
+
+// Row returns the values of the current row.
+func (r *Rows) Row() sql.Row {
+	return r.row
+}
+
+// Index returns the position of the column with the given name, or -1 if
+// there is none.
+func (r *Rows) Index(column string) int {
+	for i, col := range r.schema {
+		if strings.EqualFold(col.Name, column) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Value returns the value of a column of the current row.
+func (r *Rows) Value(column string) (interface{}, error) {
+	if r.row == nil {
+		return nil, ErrNoCurrentRow.New()
+	}
+
+	i := r.Index(column)
+	if i < 0 {
+		return nil, ErrUnknownColumn.New(column)
+	}
+	return r.row[i], nil
+}
+
+// IsNull returns whether the value of a column of the current row is
+// NULL.
+func (r *Rows) IsNull(column string) (bool, error) {
+	v, err := r.Value(column)
+	return v == nil, err
+}
+
+// typed returns the value of a column converted to the given type. NULL
+// values return ErrNullValue.
+func (r *Rows) typed(column string, typ sql.Type) (interface{}, error) {
+	v, err := r.Value(column)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, ErrNullValue.New(column)
+	}
+	return typ.Convert(v)
+}
+
+// GetInt64 returns the value of a column of the current row as an int64.
+func (r *Rows) GetInt64(column string) (int64, error) {
+	v, err := r.typed(column, sql.Int64)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// GetUint64 returns the value of a column of the current row as an
+// uint64.
+func (r *Rows) GetUint64(column string) (uint64, error) {
+	v, err := r.typed(column, sql.Uint64)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint64), nil
+}
+
+// GetFloat64 returns the value of a column of the current row as a
+// float64.
+func (r *Rows) GetFloat64(column string) (float64, error) {
+	v, err := r.typed(column, sql.Float64)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+// GetBool returns the value of a column of the current row as a bool.
+func (r *Rows) GetBool(column string) (bool, error) {
+	v, err := r.typed(column, sql.Boolean)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// GetString returns the value of a column of the current row as a string.
+func (r *Rows) GetString(column string) (string, error) {
+	v, err := r.typed(column, sql.Text)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// GetBytes returns the value of a column of the current row as bytes.
+func (r *Rows) GetBytes(column string) ([]byte, error) {
+	v, err := r.typed(column, sql.Blob)
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// GetTime returns the value of a column of the current row as a time.
+func (r *Rows) GetTime(column string) (time.Time, error) {
+	v, err := r.typed(column, sql.Datetime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return v.(time.Time), nil
+}
+
+// Scan stores the values of the current row in the fields of the struct
+// dest points to. Fields are matched with the columns by the name in their
+// sql tag, such as `sql:"created_at"`, or by their own name, ignoring the
+// case. Fields tagged with `sql:"-"`, unexported fields and fields without
+// a column are not changed, and columns without a field are ignored.
+//
+// Values are converted to the types of the fields as the engine converts
+// them. NULL values set pointer, interface, slice and map fields to nil,
+// and return an error for the other types. Fields whose pointer implements
+// the sql.Scanner interface of the database/sql package are scanned by
+// their Scan method.
+func (r *Rows) Scan(dest interface{}) error {
+	if r.row == nil {
+		return ErrNoCurrentRow.New()
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidScanTarget.New("a pointer to a struct", dest)
+	}
+
+	return r.scanStruct(v.Elem(), r.fields(v.Elem().Type()))
+}
+
+// ScanAll reads the rest of the rows and appends them to the slice of
+// structs or of pointers to structs dest points to, scanning them as Scan
+// does. The result is closed once all the rows are read.
+func (r *Rows) ScanAll(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrInvalidScanTarget.New("a pointer to a slice of structs", dest)
+	}
+
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	if isPtr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return ErrInvalidScanTarget.New("a pointer to a slice of structs", dest)
+	}
+
+	fields := r.fields(elem)
+	for r.Next() {
+		item := reflect.New(elem)
+		if err := r.scanStruct(item.Elem(), fields); err != nil {
+			_ = r.Close()
+			return err
+		}
+
+		if isPtr {
+			slice = reflect.Append(slice, item)
+		} else {
+			slice = reflect.Append(slice, item.Elem())
+		}
+	}
+	v.Elem().Set(slice)
+
+	return r.Err()
+}
+
+// scanField is a field of a struct with a column of the result.
+type scanField struct {
+	index  []int
+	name   string
+	column int
+}
+
+// fields returns the fields of a struct type with a column in the result.
+// The fields of embedded structs are matched as if they were fields of
+// the struct.
+func (r *Rows) fields(t reflect.Type) []scanField {
+	var fields []scanField
+	var visit func(t reflect.Type, index []int)
+	visit = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			idx := append(append([]int(nil), index...), i)
+
+			name, ok := f.Tag.Lookup("sql")
+			if name == "-" {
+				continue
+			}
+
+			if f.Anonymous && !ok && f.Type.Kind() == reflect.Struct {
+				visit(f.Type, idx)
+				continue
+			}
+
+			if f.PkgPath != "" {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+
+			if col := r.Index(name); col >= 0 {
+				fields = append(fields, scanField{index: idx, name: f.Name, column: col})
+			}
+		}
+	}
+	visit(t, nil)
+	return fields
+}
+
+func (r *Rows) scanStruct(v reflect.Value, fields []scanField) error {
+	for _, f := range fields {
+		column := r.schema[f.column].Name
+		if err := scanValue(v.FieldByIndex(f.index), r.row[f.column]); err != nil {
+			return ErrScanColumn.New(column, f.name, err)
+		}
+	}
+	return nil
+}
+
+var (
+	scannerType = reflect.TypeOf((*dsql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+	bytesType   = reflect.TypeOf([]byte(nil))
+)
+
+// scanValue stores a value in a field, converted to its type.
+func scanValue(field reflect.Value, value interface{}) error {
+	if field.CanAddr() && field.Addr().Type().Implements(scannerType) {
+		return field.Addr().Interface().(dsql.Scanner).Scan(value)
+	}
+
+	if value == nil {
+		switch field.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		default:
+			return fmt.Errorf("NULL cannot be stored in a %s", field.Type())
+		}
+	}
+
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := scanValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	var typ sql.Type
+	switch {
+	case field.Type() == timeType:
+		typ = sql.Datetime
+	case field.Type() == bytesType:
+		typ = sql.Blob
+	case field.Kind() == reflect.Bool:
+		typ = sql.Boolean
+	case field.Kind() == reflect.String:
+		typ = sql.Text
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		typ = sql.Int64
+	case field.Kind() >= reflect.Uint && field.Kind() <= reflect.Uint64:
+		typ = sql.Uint64
+	case field.Kind() == reflect.Float32 || field.Kind() == reflect.Float64:
+		typ = sql.Float64
+	}
+
+	if typ == nil {
+		v := reflect.ValueOf(value)
+		if !v.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("a %s cannot be stored in a %s", v.Type(), field.Type())
+		}
+		field.Set(v)
+		return nil
+	}
+
+	converted, err := typ.Convert(value)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(converted)
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.OverflowInt(v.Int()) {
+			return fmt.Errorf("%v overflows %s", value, field.Type())
+		}
+		field.SetInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if field.OverflowUint(v.Uint()) {
+			return fmt.Errorf("%v overflows %s", value, field.Type())
+		}
+		field.SetUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		field.SetFloat(v.Float())
+	default:
+		field.Set(v.Convert(field.Type()))
+	}
+	return nil
+}
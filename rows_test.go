@@ -0,0 +1,159 @@
+package sqle
+
+import (
+	dsql "database/sql"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func newRowsTestEngine(t *testing.T) *Engine {
+	table := memory.NewTable("users", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "users"},
+		{Name: "name", Type: sql.Text, Source: "users"},
+		{Name: "score", Type: sql.Float64, Source: "users", Nullable: true},
+		{Name: "active", Type: sql.Boolean, Source: "users"},
+		{Name: "created_at", Type: sql.Datetime, Source: "users"},
+		{Name: "avatar", Type: sql.Blob, Source: "users", Nullable: true},
+	})
+
+	ctx := sql.NewEmptyContext()
+	created := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, table.Insert(ctx, sql.NewRow(int64(1), "alice", 9.5, true, created, []byte{1})))
+	require.NoError(t, table.Insert(ctx, sql.NewRow(int64(2), "bob", nil, false, created.Add(time.Hour), nil)))
+
+	db := memory.NewDatabase("mydb")
+	db.AddTable("users", table)
+	e := NewDefault()
+	e.AddDatabase(db)
+	return e
+}
+
+func TestRowsAccessors(t *testing.T) {
+	require := require.New(t)
+	e := newRowsTestEngine(t)
+
+	rows, err := e.QueryRows(sql.NewEmptyContext(), "SELECT id, name, score, active, created_at, avatar FROM users ORDER BY id")
+	require.NoError(err)
+	defer rows.Close()
+	require.Equal("created_at", rows.Schema()[4].Name)
+
+	_, err = rows.GetInt64("id")
+	require.True(ErrNoCurrentRow.Is(err))
+
+	require.True(rows.Next())
+	id, err := rows.GetInt64("ID")
+	require.NoError(err)
+	require.Equal(int64(1), id)
+
+	// Values are converted as the engine converts them.
+	idText, err := rows.GetString("id")
+	require.NoError(err)
+	require.Equal("1", idText)
+
+	name, err := rows.GetString("name")
+	require.NoError(err)
+	require.Equal("alice", name)
+
+	score, err := rows.GetFloat64("score")
+	require.NoError(err)
+	require.Equal(9.5, score)
+
+	active, err := rows.GetBool("active")
+	require.NoError(err)
+	require.True(active)
+
+	created, err := rows.GetTime("created_at")
+	require.NoError(err)
+	require.Equal(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC), created)
+
+	avatar, err := rows.GetBytes("avatar")
+	require.NoError(err)
+	require.Equal([]byte{1}, avatar)
+
+	_, err = rows.GetInt64("missing")
+	require.True(ErrUnknownColumn.Is(err))
+
+	require.True(rows.Next())
+	null, err := rows.IsNull("score")
+	require.NoError(err)
+	require.True(null)
+	_, err = rows.GetFloat64("score")
+	require.True(ErrNullValue.Is(err))
+
+	require.False(rows.Next())
+	require.NoError(rows.Err())
+	require.Nil(rows.Row())
+}
+
+type userKey struct {
+	ID int64
+}
+
+type user struct {
+	userKey
+	Name    string
+	Score   *float64
+	Active  bool
+	Created time.Time `sql:"created_at"`
+	Avatar  []byte
+	Rank    dsql.NullFloat64 `sql:"score"`
+	Ignored string           `sql:"-"`
+	note    string
+}
+
+func TestRowsScan(t *testing.T) {
+	require := require.New(t)
+	e := newRowsTestEngine(t)
+	ctx := sql.NewEmptyContext()
+	created := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	score := 9.5
+
+	rows, err := e.QueryRows(ctx, "SELECT * FROM users ORDER BY id")
+	require.NoError(err)
+
+	require.True(rows.Next())
+	u := user{Ignored: "x", note: "y"}
+	require.NoError(rows.Scan(&u))
+	require.Equal(user{
+		userKey: userKey{ID: 1},
+		Name:    "alice",
+		Score:   &score,
+		Active:  true,
+		Created: created,
+		Avatar:  []byte{1},
+		Rank:    dsql.NullFloat64{Float64: 9.5, Valid: true},
+		Ignored: "x",
+		note:    "y",
+	}, u)
+
+	require.True(ErrInvalidScanTarget.Is(rows.Scan(u)))
+
+	var rest []*user
+	require.NoError(rows.ScanAll(&rest))
+	require.Equal([]*user{{
+		userKey: userKey{ID: 2},
+		Name:    "bob",
+		Created: created.Add(time.Hour),
+	}}, rest)
+
+	var all []user
+	rows, err = e.QueryRows(ctx, "SELECT id, name FROM users ORDER BY id")
+	require.NoError(err)
+	require.NoError(rows.ScanAll(&all))
+	require.Equal([]user{{userKey: userKey{ID: 1}, Name: "alice"}, {userKey: userKey{ID: 2}, Name: "bob"}}, all)
+
+	// NULL values cannot be stored in fields that are not nullable.
+	var scores []struct{ Score float64 }
+	rows, err = e.QueryRows(ctx, "SELECT score FROM users ORDER BY id")
+	require.NoError(err)
+	require.True(ErrScanColumn.Is(rows.ScanAll(&scores)))
+
+	var small []struct{ ID int8 }
+	rows, err = e.QueryRows(ctx, "SELECT 1000 AS id")
+	require.NoError(err)
+	require.True(ErrScanColumn.Is(rows.ScanAll(&small)))
+}
@@ -0,0 +1,382 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrRequest is returned when a request to the object store fails.
+var ErrRequest = errors.NewKind("request of s3://%s/%s failed: %s")
+
+// emptyPayloadHash is the hash of the body of the requests, which are all
+// without body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Credentials used to sign the requests.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials.
+	SessionToken string
+}
+
+// Config of the connection to a bucket of an S3-compatible object store.
+type Config struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+	// Region of the bucket. It's the one of the AWS_REGION environment
+	// variable if it's empty, or us-east-1 if it's not set.
+	Region string
+	// Endpoint is the URL of the object store, such as
+	// http://localhost:9000. It's the one of AWS S3 if it's empty.
+	Endpoint string
+	// PathStyle tells whether the bucket is in the path of the requests
+	// instead of in the host, as most S3-compatible stores need.
+	PathStyle bool
+	// Credentials used to sign the requests. The ones of the
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	// environment variables are used if they are empty, and the requests
+	// are anonymous if those are not set either.
+	Credentials Credentials
+	// Client is the HTTP client of the requests. It's http.DefaultClient
+	// if it's nil.
+	Client *http.Client
+}
+
+// client sends the requests of the objects of a bucket.
+type client struct {
+	bucket      string
+	region      string
+	endpoint    *url.URL
+	pathStyle   bool
+	credentials Credentials
+	http        *http.Client
+	now         func() time.Time
+}
+
+func newClient(config Config) (*client, error) {
+	c := &client{
+		bucket:      config.Bucket,
+		region:      config.Region,
+		pathStyle:   config.PathStyle,
+		credentials: config.Credentials,
+		http:        config.Client,
+		now:         time.Now,
+	}
+
+	if c.region == "" {
+		c.region = os.Getenv("AWS_REGION")
+	}
+	if c.region == "" {
+		c.region = "us-east-1"
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+		if c.region != "us-east-1" {
+			endpoint = "https://s3." + c.region + ".amazonaws.com"
+		}
+	}
+
+	var err error
+	if c.endpoint, err = url.Parse(endpoint); err != nil {
+		return nil, err
+	}
+
+	if c.credentials == (Credentials{}) {
+		c.credentials = Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+
+	if c.http == nil {
+		c.http = http.DefaultClient
+	}
+
+	return c, nil
+}
+
+// object is an object of a bucket.
+type object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+}
+
+type listResult struct {
+	Contents              []object `xml:"Contents"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// list returns all the objects whose keys have the given prefix, sorted by
+// their keys.
+func (c *client) list(prefix string) ([]object, error) {
+	var objects []object
+	var token string
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do("", query, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, ErrRequest.New(c.bucket, prefix, err)
+		}
+
+		objects = append(objects, result.Contents...)
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// get returns the body of an object.
+func (c *client) get(key string) (io.ReadCloser, error) {
+	resp, err := c.do(key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// do sends a GET request of the given key, or of the bucket if it's empty,
+// with the given query and range, and returns its response if it
+// succeeded.
+func (c *client) do(key string, query url.Values, byteRange string) (*http.Response, error) {
+	u := *c.endpoint
+	path := "/" + key
+	if c.pathStyle {
+		path = "/" + c.bucket + path
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(c.endpoint.Path, "/") + path
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrRequest.New(c.bucket, key, err)
+	}
+
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	c.sign(req, c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, ErrRequest.New(c.bucket, key, err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		return nil, ErrRequest.New(c.bucket, key, responseError(resp))
+	}
+
+	return resp, nil
+}
+
+// responseError returns the error of a response that did not succeed, with
+// the code and message of its body if it has them.
+func responseError(resp *http.Response) string {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if xml.Unmarshal(b, &body) != nil || body.Code == "" {
+		return resp.Status
+	}
+
+	if body.Message == "" {
+		return fmt.Sprintf("%s: %s", resp.Status, body.Code)
+	}
+	return fmt.Sprintf("%s: %s: %s", resp.Status, body.Code, body.Message)
+}
+
+// sign signs a request without body with version 4 of the AWS signature.
+// Requests are not signed without credentials.
+func (c *client) sign(req *http.Request, now time.Time) {
+	if c.credentials.AccessKeyID == "" {
+		return
+	}
+
+	now = now.UTC()
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	req.Header.Set("X-Amz-Date", timestamp)
+	if c.credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.credentials.AccessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	))
+}
+
+func hashHex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery returns the query sorted by key with its keys and values
+// encoded as the signature expects.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode encodes all the bytes of s except the unreserved characters,
+// and the slashes unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			sb.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+// objectFile reads parts of an object with requests of their ranges.
+type objectFile struct {
+	c   *client
+	key string
+}
+
+// ReadAt implements the io.ReaderAt interface.
+func (f *objectFile) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	byteRange := fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1)
+	resp, err := f.c.do(f.key, nil, byteRange)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Stores that do not support ranges return the whole object.
+	if resp.StatusCode == http.StatusOK && off > 0 {
+		if _, err := io.CopyN(ioutil.Discard, resp.Body, off); err != nil {
+			return 0, io.EOF
+		}
+	}
+
+	n, err := io.ReadFull(resp.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (f *objectFile) Close() error { return nil }
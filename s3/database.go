@@ -0,0 +1,181 @@
+// Package s3 implements a read-only database whose tables are the CSV, TSV,
+// JSON lines and Parquet objects of a bucket of an S3-compatible object
+// store.
+package s3
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/csv"
+	"github.com/src-d/go-mysql-server/ndjson"
+	"github.com/src-d/go-mysql-server/parquet"
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrMixedFormats is returned when the objects of a table do not have the
+// same format.
+var ErrMixedFormats = errors.NewKind("objects of table %s have different formats: %s and %s")
+
+// ErrSchemaMismatch is returned when the schema of an object of a table is
+// not the one of the other objects of the table.
+var ErrSchemaMismatch = errors.NewKind("object %s does not have the schema of the other objects of table %s")
+
+// Options of a database of objects.
+type Options struct {
+	// Prefix is the prefix of the keys of the objects of the database,
+	// which is used as a directory. All the objects of the bucket are
+	// used if it's empty.
+	Prefix string
+	// CSV are the options of the CSV and TSV objects.
+	CSV *csv.Options
+	// NDJSON are the options of the JSON lines objects.
+	NDJSON *ndjson.Options
+}
+
+// format is the format of an object.
+type format string
+
+const (
+	formatCSV     format = "csv"
+	formatNDJSON  format = "ndjson"
+	formatParquet format = "parquet"
+)
+
+// formats are the formats of the objects with each extension.
+var formats = map[string]format{
+	".csv":     formatCSV,
+	".tsv":     formatCSV,
+	".ndjson":  formatNDJSON,
+	".jsonl":   formatNDJSON,
+	".json":    formatNDJSON,
+	".parquet": formatParquet,
+}
+
+// Database is a read-only database whose tables are read from the objects
+// of a bucket. The objects directly under the prefix of the database are
+// tables named after the objects without their extension, and the objects
+// under a prefix of the form table/, at any depth, are the partitions of a
+// table with its name, so tables can be partitioned by prefixes, as in
+// table/year=2019/part-0.csv. All the objects of a table must have the same
+// format, which is given by their extensions, and the same schema. Objects
+// with other extensions are skipped.
+//
+// The objects and the schemas of the tables are read when the database is
+// created, and the rows of the objects are read in every query.
+type Database struct {
+	name   string
+	client *client
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates a database with the objects of the bucket of the
+// given config. If options is nil, the default ones are used.
+func NewDatabase(name string, config Config, options *Options) (*Database, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+
+	c, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := opts.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	objects, err := c.list(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Database{name: name, client: c, tables: make(map[string]sql.Table)}
+	tables := make(map[string]*Table)
+	for _, o := range objects {
+		f, ok := formats[strings.ToLower(path.Ext(o.Key))]
+		if !ok || o.Size == 0 {
+			continue
+		}
+
+		rel := strings.TrimPrefix(o.Key, prefix)
+		name := strings.TrimSuffix(rel, path.Ext(rel))
+		if i := strings.Index(rel, "/"); i >= 0 {
+			name = rel[:i]
+		}
+
+		if name == "" {
+			continue
+		}
+
+		t, ok := tables[name]
+		if !ok {
+			t = &Table{name: name, format: f}
+		} else if t.format != f {
+			return nil, ErrMixedFormats.New(name, t.format, f)
+		}
+
+		ot, err := d.openObject(name, o, f, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Empty CSV and TSV objects do not have a table.
+		if ot == nil {
+			continue
+		}
+
+		if len(t.objects) > 0 && !ot.Schema().Equals(t.objects[0].Schema()) {
+			return nil, ErrSchemaMismatch.New(o.Key, name)
+		}
+
+		t.objects = append(t.objects, ot)
+		t.keys = append(t.keys, o.Key)
+		tables[name] = t
+	}
+
+	for name, t := range tables {
+		d.tables[name] = t
+	}
+
+	return d, nil
+}
+
+// openObject returns the table of an object, or nil if the object does not
+// have one.
+func (d *Database) openObject(table string, o object, f format, opts Options) (sql.Table, error) {
+	c := d.client
+	key := o.Key
+	p := "s3://" + c.bucket + "/" + key
+
+	switch f {
+	case formatCSV:
+		t, err := csv.NewTable(table, p, func() (io.ReadCloser, error) { return c.get(key) }, opts.CSV)
+		if t == nil || err != nil {
+			return nil, err
+		}
+		return t, nil
+	case formatNDJSON:
+		return ndjson.NewTable(table, p, func() (io.ReadCloser, error) { return c.get(key) }, opts.NDJSON)
+	default:
+		return parquet.NewTable(table, p, o.Size, func() (parquet.File, error) {
+			return &objectFile{c: c, key: key}, nil
+		})
+	}
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return d.name
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
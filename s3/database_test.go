@@ -0,0 +1,229 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/csv"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore is an object store with a bucket named bucket, which lists its
+// objects in pages of two and requires signed requests.
+type fakeStore struct {
+	*httptest.Server
+	objects map[string]string
+}
+
+func newFakeStore(t *testing.T, objects map[string]string) *fakeStore {
+	s := &fakeStore{objects: objects}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeStore) config() Config {
+	return Config{
+		Bucket:    "bucket",
+		Endpoint:  s.URL,
+		PathStyle: true,
+		Credentials: Credentials{
+			AccessKeyID:     "key",
+			SecretAccessKey: "secret",
+		},
+	}
+}
+
+func (s *fakeStore) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>failed</Message></Error>", code)
+}
+
+func (s *fakeStore) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		s.fail(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	if r.URL.Path == "/bucket/" {
+		s.list(w, r)
+		return
+	}
+
+	content, ok := s.objects[strings.TrimPrefix(r.URL.Path, "/bucket/")]
+	if !ok {
+		s.fail(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	if rng := r.Header.Get("Range"); rng != "" {
+		var start, end int
+		_, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end)
+		if err != nil || start >= len(content) {
+			s.fail(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+			return
+		}
+		if end >= len(content) {
+			end = len(content) - 1
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		content = content[start : end+1]
+	}
+
+	_, _ = w.Write([]byte(content))
+}
+
+func (s *fakeStore) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+	result := listResult{}
+	for i := start; i < len(keys) && i < start+2; i++ {
+		result.Contents = append(result.Contents, object{Key: keys[i], Size: int64(len(s.objects[keys[i]]))})
+	}
+	if start+2 < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(start + 2)
+	}
+
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func TestDatabase(t *testing.T) {
+	require := require.New(t)
+	store := newFakeStore(t, map[string]string{
+		"lake/users.csv":                       "id,name\n1,alice\n2,bob\n",
+		"lake/events/day=1/part-0.jsonl":       `{"user": 1, "kind": "login"}` + "\n",
+		"lake/events/day=1/part-1.jsonl":       `{"user": 2, "kind": "login"}` + "\n",
+		"lake/events/day=2/part-0.jsonl":       `{"user": 1, "kind": "logout"}` + "\n",
+		"lake/events/":                         "",
+		"lake/notes.txt":                       "not a table",
+		"lake/empty.csv":                       "",
+		"other/ignored.csv":                    "a\n1\n",
+		"lake/scores/2019.tsv":                 "user\tscore\n1\t1.5\n",
+		"lake/scores/2020.tsv":                 "user\tscore\n2\t3.0\n",
+		"lake/scores/2021.tsv":                 "user\tscore\n1\t4.25\n",
+		"lake/scores/nested/2022.tsv":          "user\tscore\n3\t0.5\n",
+		"lake/scores/nested/deeper/readme.txt": "skipped",
+	})
+
+	db, err := NewDatabase("lake", store.config(), &Options{Prefix: "lake"})
+	require.NoError(err)
+	require.Equal("lake", db.Name())
+
+	var names []string
+	for name := range db.Tables() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal([]string{"events", "scores", "users"}, names)
+
+	events := db.Tables()["events"]
+	partitions, err := events.Partitions(sql.NewEmptyContext())
+	require.NoError(err)
+	var keys []string
+	for {
+		p, err := partitions.Next()
+		if err != nil {
+			break
+		}
+		keys = append(keys, string(p.Key()))
+	}
+	require.Equal([]string{
+		"lake/events/day=1/part-0.jsonl#0",
+		"lake/events/day=1/part-1.jsonl#0",
+		"lake/events/day=2/part-0.jsonl#0",
+	}, keys)
+
+	schema := db.Tables()["scores"].Schema()
+	require.Len(schema, 2)
+	require.Equal(sql.Int64, schema[0].Type)
+	require.Equal(sql.Float64, schema[1].Type)
+	require.Equal("scores", schema[1].Source)
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{
+			"SELECT name FROM users ORDER BY id DESC",
+			[]sql.Row{{"bob"}, {"alice"}},
+		},
+		{
+			"SELECT u.name, COUNT(*) FROM users u INNER JOIN events e ON u.id = e.user " +
+				"GROUP BY u.name ORDER BY u.name",
+			[]sql.Row{{"alice", int64(2)}, {"bob", int64(1)}},
+		},
+		{
+			"SELECT kind FROM events WHERE user = 1 ORDER BY kind",
+			[]sql.Row{{"login"}, {"logout"}},
+		},
+		{
+			"SELECT SUM(score) FROM scores WHERE user = 1",
+			[]sql.Row{{5.75}},
+		},
+		{
+			"SELECT COUNT(*) FROM scores",
+			[]sql.Row{{int64(4)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			testQuery(t, e, tt.query, tt.expected)
+		})
+	}
+}
+
+func testQuery(t *testing.T, e *sqle.Engine, query string, expected []sql.Row) {
+	t.Helper()
+	require := require.New(t)
+
+	_, iter, err := e.Query(sql.NewEmptyContext(), query)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, rows)
+}
+
+func TestDatabaseErrors(t *testing.T) {
+	require := require.New(t)
+
+	store := newFakeStore(t, map[string]string{
+		"mixed/a.csv":   "a\n1\n",
+		"mixed/b.jsonl": `{"a": 1}` + "\n",
+	})
+	_, err := NewDatabase("lake", store.config(), nil)
+	require.True(ErrMixedFormats.Is(err))
+
+	store = newFakeStore(t, map[string]string{
+		"t/a.csv": "a,b\n1,2\n",
+		"t/b.csv": "a\n1\n",
+	})
+	_, err = NewDatabase("lake", store.config(), &Options{CSV: &csv.Options{Header: csv.WithHeader}})
+	require.True(ErrSchemaMismatch.Is(err))
+
+	config := store.config()
+	config.Credentials.AccessKeyID = "other"
+	_, err = NewDatabase("lake", config, nil)
+	require.True(ErrRequest.Is(err))
+	require.Contains(err.Error(), "AccessDenied")
+}
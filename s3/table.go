@@ -0,0 +1,183 @@
+package s3
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Table is a table whose rows are read from one or more objects with the
+// same format and schema. Every object is read as a table of its format,
+// and the partitions of the table are the partitions of all of them, so
+// the objects are read in parallel.
+type Table struct {
+	name   string
+	format format
+	// objects are the tables of the objects, and keys their keys.
+	objects []sql.Table
+	keys    []string
+
+	projection []string
+	filters    []sql.Expression
+}
+
+var _ sql.Table = (*Table)(nil)
+var _ sql.ProjectedTable = (*Table)(nil)
+var _ sql.FilteredTable = (*Table)(nil)
+
+// Name implements the sql.Table interface.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *Table) Schema() sql.Schema {
+	return t.objects[0].Schema()
+}
+
+// String implements the sql.Table interface.
+func (t *Table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("S3Table(%s, format=%s, objects=%d)", t.name, t.format, len(t.objects))
+	schema := t.Schema()
+	var columns = make([]string, len(schema))
+	for i, col := range schema {
+		columns[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(columns...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface.
+func (t *Table) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{ctx: ctx, t: t}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *Table) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
+	op, ok := p.(*partition)
+	if !ok || op.object >= len(t.objects) {
+		return nil, fmt.Errorf("invalid partition %q of table %s", p.Key(), t.name)
+	}
+	return t.objects[op.object].PartitionRows(ctx, op.Partition)
+}
+
+// HandledFilters implements the sql.FilteredTable interface. The filters
+// handled are the ones handled by the tables of the objects, which are
+// only filtered if they are Parquet objects.
+func (t *Table) HandledFilters(filters []sql.Expression) []sql.Expression {
+	if ft, ok := t.objects[0].(sql.FilteredTable); ok {
+		return ft.HandledFilters(filters)
+	}
+	return nil
+}
+
+// WithFilters implements the sql.FilteredTable interface.
+func (t *Table) WithFilters(filters []sql.Expression) sql.Table {
+	if len(filters) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.objects = make([]sql.Table, len(t.objects))
+	for i, o := range t.objects {
+		nt.objects[i] = o
+		if ft, ok := o.(sql.FilteredTable); ok {
+			nt.objects[i] = ft.WithFilters(filters)
+		}
+	}
+	nt.filters = filters
+	return &nt
+}
+
+// Filters implements the sql.FilteredTable interface.
+func (t *Table) Filters() []sql.Expression {
+	return t.filters
+}
+
+// WithProjection implements the sql.ProjectedTable interface.
+func (t *Table) WithProjection(colNames []string) sql.Table {
+	if len(colNames) == 0 {
+		return t
+	}
+
+	nt := *t
+	nt.objects = make([]sql.Table, len(t.objects))
+	for i, o := range t.objects {
+		pt, ok := o.(sql.ProjectedTable)
+		if !ok {
+			return t
+		}
+		nt.objects[i] = pt.WithProjection(colNames)
+	}
+	nt.projection = colNames
+	return &nt
+}
+
+// Projection implements the sql.ProjectedTable interface.
+func (t *Table) Projection() []string {
+	return t.projection
+}
+
+// partition is a partition of the table of an object.
+type partition struct {
+	sql.Partition
+	object int
+	key    string
+}
+
+func (p *partition) Key() []byte {
+	return []byte(p.key + "#" + string(p.Partition.Key()))
+}
+
+// partitionIter iterates the partitions of the tables of all the objects
+// of a table.
+type partitionIter struct {
+	ctx    *sql.Context
+	t      *Table
+	object int
+	iter   sql.PartitionIter
+}
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	for i.object < len(i.t.objects) {
+		if i.iter == nil {
+			var err error
+			i.iter, err = i.t.objects[i.object].Partitions(i.ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		p, err := i.iter.Next()
+		if err == io.EOF {
+			if err := i.iter.Close(); err != nil {
+				return nil, err
+			}
+			i.iter = nil
+			i.object++
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &partition{Partition: p, object: i.object, key: i.t.keys[i.object]}, nil
+	}
+
+	return nil, io.EOF
+}
+
+func (i *partitionIter) Close() error {
+	if i.iter != nil {
+		return i.iter.Close()
+	}
+	return nil
+}
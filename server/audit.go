@@ -0,0 +1,346 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+)
+
+// AuditEventType is the kind of an audit event.
+type AuditEventType byte
+
+const (
+	// AuditConnect is the event of a client authenticating, successfully
+	// or not.
+	AuditConnect AuditEventType = iota
+	// AuditDisconnect is the event of an authenticated client going away.
+	AuditDisconnect
+	// AuditQuery is the event of a statement being executed.
+	AuditQuery
+)
+
+func (t AuditEventType) String() string {
+	switch t {
+	case AuditConnect:
+		return "connect"
+	case AuditDisconnect:
+		return "disconnect"
+	case AuditQuery:
+		return "query"
+	default:
+		return "unknown"
+	}
+}
+
+// AuditEvent is an event recorded in the audit log of the server.
+type AuditEvent struct {
+	Type AuditEventType
+	// Time is when the event happened, or the statement started.
+	Time time.Time
+	// ConnectionID is the id of the connection, which is 0 in connect
+	// events, as it's not known while authenticating.
+	ConnectionID uint32
+	User         string
+	// Address is the address of the client.
+	Address string
+	// Database is the current database when the statement started.
+	Database string
+	// Query is the statement executed.
+	Query    string
+	Duration time.Duration
+	// Rows is the number of rows of the result of the statement read by
+	// the client.
+	Rows uint64
+	// Err is the error of a failed authentication or statement, if any.
+	Err error
+}
+
+// AuditSink receives the audit events of a server. Events of different
+// connections are given concurrently.
+type AuditSink interface {
+	Audit(AuditEvent)
+}
+
+// AuditFunc is an AuditSink that calls a function with every event.
+type AuditFunc func(AuditEvent)
+
+// Audit implements the AuditSink interface.
+func (f AuditFunc) Audit(e AuditEvent) {
+	f(e)
+}
+
+// AuditWriter is an AuditSink that writes every event as a JSON document
+// in a line, with a single write.
+type AuditWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditWriter creates an AuditWriter that writes to w.
+func NewAuditWriter(w io.Writer) *AuditWriter {
+	return &AuditWriter{w: w}
+}
+
+// NewAuditFile creates an AuditWriter that appends the events to the file
+// at the given path, creating it if it does not exist.
+func NewAuditFile(path string) (*AuditWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditWriter(f), nil
+}
+
+// auditRecord is the JSON document of an audit event.
+type auditRecord struct {
+	Time         time.Time `json:"time"`
+	Event        string    `json:"event"`
+	ConnectionID uint32    `json:"connection_id,omitempty"`
+	User         string    `json:"user"`
+	Address      string    `json:"address"`
+	Database     string    `json:"database,omitempty"`
+	Query        string    `json:"query,omitempty"`
+	Duration     float64   `json:"duration,omitempty"`
+	Rows         uint64    `json:"rows,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Audit implements the AuditSink interface. Events that cannot be written
+// are logged.
+func (w *AuditWriter) Audit(e AuditEvent) {
+	r := auditRecord{
+		Time:         e.Time,
+		Event:        e.Type.String(),
+		ConnectionID: e.ConnectionID,
+		User:         e.User,
+		Address:      e.Address,
+		Database:     e.Database,
+		Query:        e.Query,
+		Duration:     e.Duration.Seconds(),
+		Rows:         e.Rows,
+	}
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+
+	data, err := json.Marshal(r)
+	if err == nil {
+		w.mu.Lock()
+		_, err = w.w.Write(append(data, '\n'))
+		w.mu.Unlock()
+	}
+
+	if err != nil {
+		logrus.Errorf("unable to write %s audit event: %s", e.Type, err)
+	}
+}
+
+// Close closes the underlying writer, if it can be closed.
+func (w *AuditWriter) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// audit gives an event to the audit sink of the server, if any.
+func (h *Handler) audit(e AuditEvent) {
+	if h.auditSink == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h.auditSink.Audit(e)
+}
+
+// auditDisconnect records that an authenticated client went away.
+func (h *Handler) auditDisconnect(id uint32, user string, addr net.Addr) {
+	e := AuditEvent{Type: AuditDisconnect, ConnectionID: id, User: user}
+	if addr != nil {
+		e.Address = addr.String()
+	}
+	h.audit(e)
+}
+
+// queryAudit records a query in the audit trails and the slow query log
+// once it's done, and throttles it with the query limits of the server.
+type queryAudit struct {
+	h     *Handler
+	ctx   *sql.Context
+	db    string
+	start time.Time
+	plan  sql.Node
+	rows  uint64
+	// limited is whether the query holds a reservation of the query limits
+	// of the server, released once it's done.
+	limited bool
+}
+
+// auditQuery starts the audit of the query of the given context, writing
+// it to the general log, if any.
+func (h *Handler) auditQuery(ctx *sql.Context) *queryAudit {
+	a := &queryAudit{
+		h:     h,
+		ctx:   ctx,
+		db:    h.e.Catalog.CurrentDatabase(),
+		start: time.Now(),
+	}
+
+	if h.generalLog != nil {
+		query := ctx.Query()
+		if h.redact != nil {
+			query = h.redact(query)
+		}
+
+		client := ctx.Client()
+		h.generalLog.GeneralQuery(GeneralQuery{
+			Time:         a.start,
+			ConnectionID: ctx.Session.ID(),
+			User:         client.User,
+			Address:      client.Address,
+			Database:     a.db,
+			Query:        query,
+		})
+	}
+
+	return a
+}
+
+// query runs the query in the context of the audit, counting the rows
+// read from its result.
+func (a *queryAudit) query(query string) (sql.Schema, sql.RowIter, error) {
+	if a.h.queries != nil {
+		if err := a.h.queries.acquire(a.ctx.Client().User); err != nil {
+			return nil, nil, err
+		}
+		a.limited = true
+	}
+
+	plan, iter, err := a.h.e.QueryWithPlan(a.ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a.plan = plan
+	return plan.Schema(), &auditRowIter{RowIter: iter, rows: &a.rows}, nil
+}
+
+// done records the query, which failed with err if it's not nil.
+func (a *queryAudit) done(err error) {
+	if a.limited {
+		a.h.queries.release(a.ctx.Client().User)
+		a.limited = false
+	}
+
+	d := time.Since(a.start)
+	if q, ok := a.h.e.Auth.(*auth.Audit); ok {
+		q.Query(a.ctx, d, err)
+	}
+
+	client := a.ctx.Client()
+	rows := atomic.LoadUint64(&a.rows)
+	if a.h.slowLog != nil && d >= longQueryTime(a.ctx) {
+		q := SlowQuery{
+			Time:         a.start,
+			ConnectionID: a.ctx.Session.ID(),
+			User:         client.User,
+			Address:      client.Address,
+			Database:     a.db,
+			Query:        a.ctx.Query(),
+			Duration:     d,
+			RowsSent:     rows,
+			RowsExamined: a.ctx.RowsExamined(),
+			Err:          err,
+		}
+		if a.plan != nil {
+			q.Plan = a.plan.String()
+		}
+		a.h.slowLog.SlowQuery(q)
+	}
+
+	a.h.audit(AuditEvent{
+		Type:         AuditQuery,
+		Time:         a.start,
+		ConnectionID: a.ctx.Session.ID(),
+		User:         client.User,
+		Address:      client.Address,
+		Database:     a.db,
+		Query:        a.ctx.Query(),
+		Duration:     d,
+		Rows:         rows,
+		Err:          err,
+	})
+}
+
+type auditRowIter struct {
+	sql.RowIter
+	batch sql.RowBatchIter
+	rows  *uint64
+}
+
+func (i *auditRowIter) Next() (sql.Row, error) {
+	row, err := i.RowIter.Next()
+	if err == nil {
+		atomic.AddUint64(i.rows, 1)
+	}
+	return row, err
+}
+
+func (i *auditRowIter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.RowIter)
+	}
+
+	n, err := i.batch.NextBatch(rows)
+	atomic.AddUint64(i.rows, uint64(n))
+	return n, err
+}
+
+// auditAuthServer is a mysql.AuthServer that records the authentication
+// of the clients.
+type auditAuthServer struct {
+	mysql.AuthServer
+	h *Handler
+}
+
+// ValidateHash implements the mysql.AuthServer interface.
+func (a *auditAuthServer) ValidateHash(
+	salt []byte,
+	user string,
+	authResponse []byte,
+	remoteAddr net.Addr,
+) (mysql.Getter, error) {
+	getter, err := a.AuthServer.ValidateHash(salt, user, authResponse, remoteAddr)
+	a.connect(user, remoteAddr, err)
+	return getter, err
+}
+
+// Negotiate implements the mysql.AuthServer interface.
+func (a *auditAuthServer) Negotiate(
+	c *mysql.Conn,
+	user string,
+	remoteAddr net.Addr,
+) (mysql.Getter, error) {
+	getter, err := a.AuthServer.Negotiate(c, user, remoteAddr)
+	a.connect(user, remoteAddr, err)
+	return getter, err
+}
+
+func (a *auditAuthServer) connect(user string, addr net.Addr, err error) {
+	e := AuditEvent{Type: AuditConnect, User: user, Err: err}
+	if addr != nil {
+		e.Address = addr.String()
+	}
+	a.h.audit(e)
+}
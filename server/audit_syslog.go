@@ -0,0 +1,15 @@
+// +build !windows,!plan9
+
+package server
+
+import "log/syslog"
+
+// NewAuditSyslog creates an AuditWriter that sends the events to the
+// local syslog daemon with the given tag, with the auth facility.
+func NewAuditSyslog(tag string) (*AuditWriter, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditWriter(w), nil
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	dsql "database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerAudit(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	var mu sync.Mutex
+	var events []AuditEvent
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     auth.NewNativeSingle("user", "pass", auth.AllPermissions),
+		Audit: AuditFunc(func(e AuditEvent) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	wrong, err := dsql.Open("mysql", fmt.Sprintf("user:wrong@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	require.Error(wrong.Ping())
+	require.NoError(wrong.Close())
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("user:pass@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test WHERE c1 < 10").Scan(&n))
+	rows, err := db.Query("SELECT c1 FROM test WHERE c1 < 10")
+	require.NoError(err)
+	for rows.Next() {
+	}
+	require.NoError(rows.Err())
+	_, err = db.Exec("SELECT * FROM nope")
+	require.Error(err)
+	require.NoError(db.Close())
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 6
+	})
+
+	require.Equal(AuditConnect, events[0].Type)
+	require.Equal("user", events[0].User)
+	require.Error(events[0].Err)
+
+	require.Equal(AuditConnect, events[1].Type)
+	require.NoError(events[1].Err)
+	require.NotEmpty(events[1].Address)
+
+	query := events[3]
+	require.Equal(AuditQuery, query.Type)
+	require.Equal("user", query.User)
+	require.Equal("test", query.Database)
+	require.Equal("SELECT c1 FROM test WHERE c1 < 10", query.Query)
+	require.Equal(uint64(10), query.Rows)
+	require.NotZero(query.ConnectionID)
+	require.NoError(query.Err)
+
+	require.Equal(AuditQuery, events[4].Type)
+	require.Error(events[4].Err)
+
+	require.Equal(AuditDisconnect, events[5].Type)
+	require.Equal(query.ConnectionID, events[5].ConnectionID)
+}
+
+func TestAuditWriter(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	w := NewAuditWriter(&buf)
+	w.Audit(AuditEvent{
+		Type:         AuditQuery,
+		Time:         time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+		ConnectionID: 1,
+		User:         "user",
+		Address:      "127.0.0.1:3306",
+		Database:     "db",
+		Query:        "SELECT 1",
+		Duration:     1500 * time.Millisecond,
+		Rows:         1,
+		Err:          errors.New("oops"),
+	})
+	w.Audit(AuditEvent{Type: AuditDisconnect, User: "user"})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(lines, 2)
+
+	var record map[string]interface{}
+	require.NoError(json.Unmarshal(lines[0], &record))
+	require.Equal(map[string]interface{}{
+		"time":          "2019-01-02T03:04:05Z",
+		"event":         "query",
+		"connection_id": float64(1),
+		"user":          "user",
+		"address":       "127.0.0.1:3306",
+		"database":      "db",
+		"query":         "SELECT 1",
+		"duration":      1.5,
+		"rows":          float64(1),
+		"error":         "oops",
+	}, record)
+	require.NoError(w.Close())
+}
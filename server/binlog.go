@@ -0,0 +1,772 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// Types of the events of the binary log.
+const (
+	eventQuery             = 2
+	eventRotate            = 4
+	eventFormatDescription = 15
+	eventXID               = 16
+	eventTableMap          = 19
+	eventHeartbeat         = 27
+	eventWriteRows         = 30
+	eventUpdateRows        = 31
+	eventDeleteRows        = 32
+	eventGTID              = 33
+	eventPreviousGTIDs     = 35
+)
+
+// Types of the columns in the binary log.
+const (
+	binlogTypeTiny       = 0x01
+	binlogTypeShort      = 0x02
+	binlogTypeLong       = 0x03
+	binlogTypeFloat      = 0x04
+	binlogTypeDouble     = 0x05
+	binlogTypeNull       = 0x06
+	binlogTypeLongLong   = 0x08
+	binlogTypeInt24      = 0x09
+	binlogTypeDate       = 0x0a
+	binlogTypeVarChar    = 0x0f
+	binlogTypeTimestamp2 = 0x11
+	binlogTypeDatetime2  = 0x12
+	binlogTypeBlob       = 0xfc
+	binlogTypeString     = 0xfe
+)
+
+const (
+	// binlogHeaderLength is the length of the header of the events.
+	binlogHeaderLength = 19
+	// binlogMagicLength is the length of the magic number that starts
+	// every file of the log, so the first event is after it.
+	binlogMagicLength = 4
+	// binlogServerVersion is the version written in the log, which tells
+	// readers the log has the format of MySQL 5.7.
+	binlogServerVersion = "5.7.9-go-mysql-server"
+	// binlogMaxFileSize is the size after which the log is rotated.
+	binlogMaxFileSize = 1 << 30
+	// binlogMaxRowsSize is the maximum size of the rows of a rows event.
+	binlogMaxRowsSize = 8 * 1024
+
+	// rowsFlagStmtEnd is set in the last rows event of a statement.
+	rowsFlagStmtEnd = 0x0001
+	// eventFlagArtificial is set in the events that are not in the log,
+	// but are sent to replicas.
+	eventFlagArtificial = 0x0020
+)
+
+// binlogPostHeaderLengths are the lengths of the post headers of the
+// events of MySQL 5.7, from the event type 1.
+var binlogPostHeaderLengths = []byte{
+	56, 13, 0, 8, 0, 18, 0, 4, 4, 4, 4, 18, 0, 0, 95, 0, 4, 26, 8, 0, 0, 0,
+	8, 8, 8, 2, 0, 0, 0, 10, 10, 10, 42, 42, 0, 18, 52, 0,
+}
+
+// Binlog is a row-based binary log of the changes made by INSERT, REPLACE,
+// UPDATE and DELETE statements, in the format of MySQL 5.7. Every statement
+// is written as a transaction with a global transaction identifier. The
+// log is kept in memory and sent to the replicas and change data capture
+// tools connected to the server with COM_BINLOG_DUMP or
+// COM_BINLOG_DUMP_GTID.
+type Binlog struct {
+	// MaxSize is the maximum size in bytes of the events kept in memory.
+	// The oldest transactions are discarded once the log gets larger, and
+	// replicas that have not read them cannot continue. Zero keeps all of
+	// them.
+	MaxSize int
+
+	serverID uint32
+	uuid     [16]byte
+
+	mu    sync.Mutex
+	files []*binlogFile
+	// The number of the last file created.
+	fileNumber int
+	size       int
+	// The number of the last transaction written.
+	gno uint64
+	// The transactions up to this number were discarded.
+	purged   uint64
+	tableIDs map[string]uint64
+	// Closed and replaced every time events are written.
+	changed chan struct{}
+}
+
+// binlogFile is a file of the log, which starts with a format description
+// and the global transaction identifiers of the previous files.
+type binlogFile struct {
+	name    string
+	entries []binlogEntry
+	// The position of the next event.
+	end uint32
+	// The position up to which the events were discarded.
+	purged uint32
+}
+
+// binlogEntry is a group of events of a file, which are a transaction or
+// the events at the start or the end of the file.
+type binlogEntry struct {
+	pos uint32
+	// The transaction number, or zero if the events are not a transaction.
+	gno    uint64
+	events [][]byte
+}
+
+var _ sql.BinaryLog = (*Binlog)(nil)
+
+// NewBinlog creates an empty binary log whose events are written by the
+// server with the given id, which must be different from the ids of its
+// replicas.
+func NewBinlog(serverID uint32) *Binlog {
+	b := &Binlog{
+		serverID: serverID,
+		tableIDs: make(map[string]uint64),
+		changed:  make(chan struct{}),
+	}
+	_, _ = rand.Read(b.uuid[:])
+	// Version 4 UUID.
+	b.uuid[6] = b.uuid[6]&0x0f | 0x40
+	b.uuid[8] = b.uuid[8]&0x3f | 0x80
+
+	b.newFile(uint32(time.Now().Unix()))
+	return b
+}
+
+// ServerID returns the id of the server writing the log.
+func (b *Binlog) ServerID() uint32 {
+	return b.serverID
+}
+
+// ServerUUID returns the id of the server in the global transaction
+// identifiers.
+func (b *Binlog) ServerUUID() string {
+	return formatUUID(b.uuid)
+}
+
+// Status implements the sql.BinaryLog interface.
+func (b *Binlog) Status() sql.BinlogStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file := b.files[len(b.files)-1]
+	return sql.BinlogStatus{
+		File:            file.name,
+		Position:        uint64(file.end),
+		ExecutedGtidSet: b.gtidSet(),
+	}
+}
+
+// Files implements the sql.BinaryLog interface.
+func (b *Binlog) Files() []sql.BinlogFile {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	files := make([]sql.BinlogFile, len(b.files))
+	for i, f := range b.files {
+		files[i] = sql.BinlogFile{Name: f.name, Size: uint64(f.end)}
+	}
+	return files
+}
+
+// gtidSet returns the set of the transactions written in the log.
+func (b *Binlog) gtidSet() string {
+	if b.gno == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:1-%d", formatUUID(b.uuid), b.gno)
+}
+
+// RecordChanges implements the sql.ChangeRecorder interface, writing the
+// changes as a transaction with the table map and rows events of the
+// table.
+func (b *Binlog) RecordChanges(ctx *sql.Context, changes sql.TableChanges) error {
+	if len(changes.Rows) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := changes.Database + "." + changes.Table
+	tableID, ok := b.tableIDs[key]
+	if !ok {
+		tableID = uint64(len(b.tableIDs) + 1)
+		b.tableIDs[key] = tableID
+	}
+
+	rowsTypes, rows, err := rowsEvents(tableID, changes)
+	if err != nil {
+		return err
+	}
+
+	gno := b.gno + 1
+	types := []byte{eventGTID, eventQuery, eventTableMap}
+	types = append(types, rowsTypes...)
+	types = append(types, eventXID)
+
+	events := [][]byte{
+		b.gtidEvent(gno),
+		queryEvent(ctx.ID(), changes.Database, "BEGIN"),
+		tableMapEvent(tableID, changes.Database, changes.Table, changes.Schema),
+	}
+	events = append(events, rows...)
+	events = append(events, appendUint64(nil, gno))
+
+	b.gno = gno
+	b.write(uint32(time.Now().Unix()), gno, types, events)
+	return nil
+}
+
+// write adds the events with the given types and bodies to the current
+// file, rotating it when it gets too large.
+func (b *Binlog) write(timestamp uint32, gno uint64, types []byte, bodies [][]byte) {
+	file := b.files[len(b.files)-1]
+	entry := binlogEntry{pos: file.end, gno: gno}
+	for i, body := range bodies {
+		event := b.event(timestamp, types[i], file.end, body, 0)
+		file.end += uint32(len(event))
+		b.size += len(event)
+		entry.events = append(entry.events, event)
+	}
+	file.entries = append(file.entries, entry)
+
+	if file.end >= binlogMaxFileSize {
+		b.rotate(timestamp)
+	}
+
+	b.purge()
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// newFile starts a new file with a format description and the global
+// transaction identifiers written before it.
+func (b *Binlog) newFile(timestamp uint32) {
+	b.fileNumber++
+	file := &binlogFile{
+		name: fmt.Sprintf("binlog.%06d", b.fileNumber),
+		end:  binlogMagicLength,
+	}
+	b.files = append(b.files, file)
+
+	fde := b.formatDescriptionEvent(timestamp, file.end)
+	file.end += uint32(len(fde))
+
+	previous := b.event(timestamp, eventPreviousGTIDs, file.end, b.gtidSetBody(), 0)
+	file.end += uint32(len(previous))
+
+	b.size += len(fde) + len(previous)
+	file.entries = append(file.entries, binlogEntry{
+		pos:    binlogMagicLength,
+		events: [][]byte{fde, previous},
+	})
+}
+
+// rotate ends the current file with a rotate event and starts a new one.
+func (b *Binlog) rotate(timestamp uint32) {
+	file := b.files[len(b.files)-1]
+	next := fmt.Sprintf("binlog.%06d", b.fileNumber+1)
+	event := b.event(timestamp, eventRotate, file.end, rotateBody(next, binlogMagicLength), 0)
+	file.entries = append(file.entries, binlogEntry{pos: file.end, events: [][]byte{event}})
+	file.end += uint32(len(event))
+	b.size += len(event)
+
+	b.newFile(timestamp)
+}
+
+// purge discards the oldest transactions while the log is larger than
+// its maximum size, along with the files left empty.
+func (b *Binlog) purge() {
+	for b.MaxSize > 0 && b.size > b.MaxSize {
+		file := b.files[0]
+		if len(b.files) == 1 && len(file.entries) <= 1 {
+			return
+		}
+
+		if len(file.entries) <= 1 {
+			b.files = b.files[1:]
+			for _, event := range file.entries[0].events {
+				b.size -= len(event)
+			}
+			continue
+		}
+
+		// The first entry has the events at the start of the file.
+		entry := file.entries[1]
+		file.entries = append(file.entries[:1], file.entries[2:]...)
+		file.purged = entry.pos
+		for _, event := range entry.events {
+			b.size -= len(event)
+			file.purged += uint32(len(event))
+		}
+		if entry.gno > b.purged {
+			b.purged = entry.gno
+		}
+	}
+}
+
+// event encodes an event with its header, which has the timestamp, the
+// type, the id of the server, the size of the event, the position of the
+// next event and the flags.
+func (b *Binlog) event(timestamp uint32, typ byte, pos uint32, body []byte, flags uint16) []byte {
+	size := binlogHeaderLength + len(body)
+	data := make([]byte, 0, size)
+	data = appendUint32(data, timestamp)
+	data = append(data, typ)
+	data = appendUint32(data, b.serverID)
+	data = appendUint32(data, uint32(size))
+	if pos != 0 {
+		pos += uint32(size)
+	}
+	data = appendUint32(data, pos)
+	data = appendUint16(data, flags)
+	return append(data, body...)
+}
+
+// formatDescriptionEvent encodes the format description of a file at the
+// given position, or with no position if it's zero. It ends with the
+// checksum algorithm, which is none, and a checksum of the event, which
+// MySQL always writes.
+func (b *Binlog) formatDescriptionEvent(timestamp, pos uint32) []byte {
+	body := appendUint16(nil, 4)
+	version := make([]byte, 50)
+	copy(version, binlogServerVersion)
+	body = append(body, version...)
+	body = appendUint32(body, timestamp)
+	body = append(body, binlogHeaderLength)
+	body = append(body, binlogPostHeaderLengths...)
+	body = append(body, 0)
+	body = append(body, 0, 0, 0, 0)
+
+	event := b.event(timestamp, eventFormatDescription, pos, body, 0)
+	checksum := crc32.ChecksumIEEE(event[:len(event)-4])
+	binary.LittleEndian.PutUint32(event[len(event)-4:], checksum)
+	return event
+}
+
+// gtidSetBody encodes the set of transactions written in the log, with
+// the number of server ids and, for each of them, the id and its
+// intervals of transaction numbers.
+func (b *Binlog) gtidSetBody() []byte {
+	if b.gno == 0 {
+		return appendUint64(nil, 0)
+	}
+
+	body := appendUint64(nil, 1)
+	body = append(body, b.uuid[:]...)
+	body = appendUint64(body, 1)
+	body = appendUint64(body, 1)
+	return appendUint64(body, b.gno+1)
+}
+
+func (b *Binlog) gtidEvent(gno uint64) []byte {
+	body := []byte{1}
+	body = append(body, b.uuid[:]...)
+	body = appendUint64(body, gno)
+	// Logical timestamps, which make every transaction depend on the
+	// previous one.
+	body = append(body, 2)
+	body = appendUint64(body, gno-1)
+	return appendUint64(body, gno)
+}
+
+func rotateBody(file string, pos uint64) []byte {
+	return append(appendUint64(nil, pos), file...)
+}
+
+// queryEvent encodes a statement run in a database by a connection.
+func queryEvent(connID uint32, db, query string) []byte {
+	body := appendUint32(nil, connID)
+	body = appendUint32(body, 0)
+	body = append(body, byte(len(db)))
+	body = appendUint16(body, 0)
+	body = appendUint16(body, 0)
+	body = append(body, db...)
+	body = append(body, 0)
+	return append(body, query...)
+}
+
+// tableMapEvent encodes the types and the nullability of the columns of a
+// table, which the rows events that follow refer to with its id.
+func tableMapEvent(tableID uint64, db, table string, schema sql.Schema) []byte {
+	body := appendTableID(nil, tableID)
+	body = appendUint16(body, 0)
+	body = append(body, byte(len(db)))
+	body = append(body, db...)
+	body = append(body, 0)
+	body = append(body, byte(len(table)))
+	body = append(body, table...)
+	body = append(body, 0)
+	body = appendLenEncInt(body, uint64(len(schema)))
+
+	var meta []byte
+	for _, col := range schema {
+		typ, m := binlogColumnType(col.Type)
+		body = append(body, typ)
+		meta = append(meta, m...)
+	}
+
+	body = appendLenEncInt(body, uint64(len(meta)))
+	body = append(body, meta...)
+
+	nullable := make([]byte, (len(schema)+7)/8)
+	for i, col := range schema {
+		if col.Nullable {
+			nullable[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return append(body, nullable...)
+}
+
+func appendTableID(b []byte, id uint64) []byte {
+	return appendUint64(b, id)[:len(b)+6]
+}
+
+func rowsEventType(typ sql.RowChangeType) byte {
+	switch typ {
+	case sql.RowUpdated:
+		return eventUpdateRows
+	case sql.RowDeleted:
+		return eventDeleteRows
+	default:
+		return eventWriteRows
+	}
+}
+
+// rowsEvents encodes the changed rows in events of at most
+// binlogMaxRowsSize, each of them with changes of the same type. Inserted
+// rows have their values after the change, deleted rows their values
+// before it, and updated rows both of them.
+func rowsEvents(tableID uint64, changes sql.TableChanges) ([]byte, [][]byte, error) {
+	schema := changes.Schema
+	present := make([]byte, (len(schema)+7)/8)
+	for i := range schema {
+		present[i/8] |= 1 << uint(i%8)
+	}
+
+	var types []byte
+	var events [][]byte
+	var rows []byte
+	var typ sql.RowChangeType
+	flush := func() {
+		body := appendTableID(nil, tableID)
+		body = appendUint16(body, 0)
+		// The length of the extra data, which only has its length.
+		body = appendUint16(body, 2)
+		body = appendLenEncInt(body, uint64(len(schema)))
+		body = append(body, present...)
+		if typ == sql.RowUpdated {
+			body = append(body, present...)
+		}
+		types = append(types, rowsEventType(typ))
+		events = append(events, append(body, rows...))
+		rows = nil
+	}
+
+	for i, change := range changes.Rows {
+		if i > 0 && (change.Type != typ || len(rows) >= binlogMaxRowsSize) {
+			flush()
+		}
+		typ = change.Type
+
+		var err error
+		if change.Before != nil {
+			if rows, err = appendBinlogRow(rows, schema, change.Before); err != nil {
+				return nil, nil, err
+			}
+		}
+		if change.After != nil {
+			if rows, err = appendBinlogRow(rows, schema, change.After); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	flush()
+
+	last := events[len(events)-1]
+	binary.LittleEndian.PutUint16(last[6:], rowsFlagStmtEnd)
+
+	return types, events, nil
+}
+
+// binlogColumnType returns the type of a column in the binary log and its
+// metadata. Text, blobs and the types MySQL encodes in their own binary
+// formats, such as JSON, are written as long blobs with their text.
+func binlogColumnType(t sql.Type) (byte, []byte) {
+	switch t.Type() {
+	case sqltypes.Int8, sqltypes.Uint8, sqltypes.Bit:
+		return binlogTypeTiny, nil
+	case sqltypes.Int16, sqltypes.Uint16:
+		return binlogTypeShort, nil
+	case sqltypes.Int24, sqltypes.Uint24:
+		return binlogTypeInt24, nil
+	case sqltypes.Int32, sqltypes.Uint32:
+		return binlogTypeLong, nil
+	case sqltypes.Int64, sqltypes.Uint64:
+		return binlogTypeLongLong, nil
+	case sqltypes.Float32:
+		return binlogTypeFloat, []byte{4}
+	case sqltypes.Float64:
+		return binlogTypeDouble, []byte{8}
+	case sqltypes.Null:
+		return binlogTypeNull, nil
+	case sqltypes.Date:
+		return binlogTypeDate, nil
+	case sqltypes.Datetime:
+		return binlogTypeDatetime2, []byte{0}
+	case sqltypes.Timestamp:
+		return binlogTypeTimestamp2, []byte{0}
+	case sqltypes.VarChar, sqltypes.Char:
+		length := stringByteLength(t)
+		if t.Type() == sqltypes.Char && length < 1024 {
+			// The high bits of the length of strings are in the first
+			// byte, xored with the type.
+			return binlogTypeString, []byte{binlogTypeString ^ byte((length&0x300)>>4), byte(length)}
+		}
+		return binlogTypeVarChar, appendUint16(nil, uint16(length))
+	default:
+		return binlogTypeBlob, []byte{4}
+	}
+}
+
+// stringByteLength returns the maximum length in bytes of the values of a
+// string type, whose characters take at most 3 bytes in utf8.
+func stringByteLength(t sql.Type) int {
+	length := math.MaxUint16
+	if c, ok := t.(interface{ Capacity() int }); ok && c.Capacity()*3 < length {
+		length = c.Capacity() * 3
+	}
+	return length
+}
+
+// appendBinlogRow encodes a row with a bitmap of its null values followed
+// by the rest of the values.
+func appendBinlogRow(b []byte, schema sql.Schema, row sql.Row) ([]byte, error) {
+	nulls := make([]byte, (len(schema)+7)/8)
+	var values []byte
+	for i, col := range schema {
+		if i >= len(row) || row[i] == nil {
+			nulls[i/8] |= 1 << uint(i%8)
+			continue
+		}
+
+		v, err := col.Type.SQL(row[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if v.IsNull() || col.Type.Type() == sqltypes.Null {
+			nulls[i/8] |= 1 << uint(i%8)
+			continue
+		}
+
+		values = appendBinlogValue(values, col.Type, v)
+	}
+
+	b = append(b, nulls...)
+	return append(b, values...), nil
+}
+
+func appendBinlogValue(b []byte, t sql.Type, v sqltypes.Value) []byte {
+	s := v.ToString()
+	typ, _ := binlogColumnType(t)
+	switch typ {
+	case binlogTypeTiny:
+		n, _ := parseInteger(s, !v.IsUnsigned())
+		return append(b, byte(n))
+	case binlogTypeShort:
+		n, _ := parseInteger(s, !v.IsUnsigned())
+		return appendUint16(b, uint16(n))
+	case binlogTypeInt24:
+		n, _ := parseInteger(s, !v.IsUnsigned())
+		return append(b, byte(n), byte(n>>8), byte(n>>16))
+	case binlogTypeLong:
+		n, _ := parseInteger(s, !v.IsUnsigned())
+		return appendUint32(b, uint32(n))
+	case binlogTypeLongLong:
+		n, _ := parseInteger(s, !v.IsUnsigned())
+		return appendUint64(b, n)
+	case binlogTypeFloat:
+		f, _ := strconv.ParseFloat(s, 32)
+		return appendUint32(b, math.Float32bits(float32(f)))
+	case binlogTypeDouble:
+		f, _ := strconv.ParseFloat(s, 64)
+		return appendUint64(b, math.Float64bits(f))
+	case binlogTypeDate:
+		t := parseBinlogTime(s)
+		n := uint32(t.Day()) | uint32(t.Month())<<5 | uint32(t.Year())<<9
+		return append(b, byte(n), byte(n>>8), byte(n>>16))
+	case binlogTypeDatetime2:
+		t := parseBinlogTime(s)
+		ym := uint64(t.Year()*13 + int(t.Month()))
+		n := ym<<22 | uint64(t.Day())<<17 | uint64(t.Hour())<<12 |
+			uint64(t.Minute())<<6 | uint64(t.Second())
+		// Big endian, with the sign bit set for positive values.
+		n |= 1 << 39
+		return append(b, byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	case binlogTypeTimestamp2:
+		n := uint32(parseBinlogTime(s).Unix())
+		return append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	case binlogTypeString, binlogTypeVarChar:
+		if stringByteLength(t) < 256 {
+			return append(append(b, byte(len(s))), s...)
+		}
+		return append(appendUint16(b, uint16(len(s))), s...)
+	default:
+		return append(appendUint32(b, uint32(len(s))), s...)
+	}
+}
+
+// parseBinlogTime parses a date or a datetime, in UTC. Zero dates are
+// returned as the zero time.
+func parseBinlogTime(s string) time.Time {
+	for _, layout := range []string{sql.TimestampLayout, sql.DateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// binlogPosition is a position in a file of the log.
+type binlogPosition struct {
+	file string
+	pos  uint32
+}
+
+// start returns the events sent to a replica before the ones from a
+// position: an artificial rotate event with the position, followed by the
+// format description of the file if the position is not the start of the
+// file, where it would be sent anyway.
+func (b *Binlog) start(p binlogPosition) ([][]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file, err := b.file(p)
+	if err != nil {
+		return nil, err
+	}
+
+	body := rotateBody(p.file, uint64(p.pos))
+	events := [][]byte{b.event(0, eventRotate, 0, body, eventFlagArtificial)}
+	if p.pos > binlogMagicLength {
+		fde := append([]byte(nil), file.entries[0].events[0]...)
+		binary.LittleEndian.PutUint32(fde[13:], 0)
+		checksum := crc32.ChecksumIEEE(fde[:len(fde)-4])
+		binary.LittleEndian.PutUint32(fde[len(fde)-4:], checksum)
+		events = append(events, fde)
+	}
+
+	return events, nil
+}
+
+// read returns the events written after a position, leaving out the
+// transactions skipped, and the position after them. Events of the next
+// files are returned too. It also returns a channel that is closed when
+// more events are written.
+func (b *Binlog) read(
+	p binlogPosition,
+	skip func(gno uint64) bool,
+) ([][]byte, binlogPosition, <-chan struct{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file, err := b.file(p)
+	if err != nil {
+		return nil, p, nil, err
+	}
+
+	var events [][]byte
+	for {
+		for _, e := range file.entries {
+			if e.pos < p.pos || (e.gno != 0 && skip != nil && skip(e.gno)) {
+				continue
+			}
+			events = append(events, e.events...)
+		}
+		p.pos = file.end
+
+		if file == b.files[len(b.files)-1] {
+			return events, p, b.changed, nil
+		}
+
+		for i, f := range b.files {
+			if f == file {
+				file = b.files[i+1]
+				break
+			}
+		}
+		p = binlogPosition{file: file.name, pos: binlogMagicLength}
+	}
+}
+
+// file returns the file of a position, failing if the file or the events
+// at the position were discarded, or the position is not the start of an
+// event.
+func (b *Binlog) file(p binlogPosition) (*binlogFile, error) {
+	for _, f := range b.files {
+		if f.name != p.file {
+			continue
+		}
+
+		if p.pos < f.purged && p.pos > binlogMagicLength {
+			return nil, ErrBinlogPurged.New()
+		}
+
+		if p.pos == binlogMagicLength || p.pos == f.end {
+			return f, nil
+		}
+
+		for _, e := range f.entries {
+			if e.pos == p.pos {
+				return f, nil
+			}
+		}
+
+		if p.pos > f.end {
+			return nil, ErrBinlogPosition.New("position > file size")
+		}
+		return nil, ErrBinlogPosition.New("invalid position")
+	}
+
+	return nil, ErrBinlogFile.New(p.file)
+}
+
+// firstFile returns the name of the oldest file of the log.
+func (b *Binlog) firstFile() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.files[0].name
+}
+
+// purgedGTIDs returns the number of the last transaction discarded.
+func (b *Binlog) purgedGTIDs() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.purged
+}
+
+// heartbeat returns a heartbeat event for a replica at a position.
+func (b *Binlog) heartbeat(p binlogPosition) []byte {
+	event := b.event(0, eventHeartbeat, 0, []byte(p.file), eventFlagArtificial)
+	binary.LittleEndian.PutUint32(event[13:], p.pos)
+	return event
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bufio"
+	dsql "database/sql"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+func eventTypes(events [][]byte) []byte {
+	var types []byte
+	for _, e := range events {
+		types = append(types, e[4])
+	}
+	return types
+}
+
+func TestBinlog(t *testing.T) {
+	require := require.New(t)
+
+	b := NewBinlog(1)
+	require.Equal(sql.BinlogStatus{File: "binlog.000001", Position: 4 + 119 + 27}, b.Status())
+
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64},
+		{Name: "b", Type: sql.Text, Nullable: true},
+	}
+	ctx := sql.NewEmptyContext()
+	require.NoError(b.RecordChanges(ctx, sql.TableChanges{
+		Database: "db",
+		Table:    "t",
+		Schema:   schema,
+		Rows: []sql.RowChange{
+			{Type: sql.RowDeleted, Before: sql.NewRow(int64(1), "foo")},
+			{Type: sql.RowInserted, After: sql.NewRow(int64(1), nil)},
+		},
+	}))
+	require.NoError(b.RecordChanges(ctx, sql.TableChanges{
+		Database: "db",
+		Table:    "t",
+		Schema:   schema,
+		Rows: []sql.RowChange{
+			{Type: sql.RowUpdated, Before: sql.NewRow(int64(1), nil), After: sql.NewRow(int64(2), "bar")},
+		},
+	}))
+
+	status := b.Status()
+	require.Equal(b.ServerUUID()+":1-2", status.ExecutedGtidSet)
+
+	p := binlogPosition{file: "binlog.000001", pos: 4}
+	events, next, _, err := b.read(p, func(uint64) bool { return false })
+	require.NoError(err)
+	require.Equal(status.Position, uint64(next.pos))
+	require.Equal([]byte{
+		eventFormatDescription, eventPreviousGTIDs,
+		eventGTID, eventQuery, eventTableMap, eventDeleteRows, eventWriteRows, eventXID,
+		eventGTID, eventQuery, eventTableMap, eventUpdateRows, eventXID,
+	}, eventTypes(events))
+
+	// Every event has the position of the next one.
+	pos := uint32(4)
+	for _, e := range events {
+		pos += binary.LittleEndian.Uint32(e[9:])
+		require.Equal(pos, binary.LittleEndian.Uint32(e[13:]))
+	}
+
+	// Only the last rows event ends the statement.
+	require.Equal(uint16(0), binary.LittleEndian.Uint16(events[5][19+6:]))
+	require.Equal(uint16(rowsFlagStmtEnd), binary.LittleEndian.Uint16(events[6][19+6:]))
+
+	// The update has the columns before and after, the null bitmaps and
+	// the values.
+	update := events[11][19+10:]
+	require.Equal([]byte{2, 3, 3}, update[:3])
+	require.Equal(appendUint64([]byte{2}, 1), update[3:12])
+	row := append(appendUint64([]byte{0}, 2), 3, 0, 0, 0)
+	require.Equal(append(row, "bar"...), update[12:])
+
+	events, _, _, err = b.read(p, func(gno uint64) bool { return gno == 1 })
+	require.NoError(err)
+	require.Equal([]byte{
+		eventFormatDescription, eventPreviousGTIDs,
+		eventGTID, eventQuery, eventTableMap, eventUpdateRows, eventXID,
+	}, eventTypes(events))
+
+	events, _, _, err = b.read(next, nil)
+	require.NoError(err)
+	require.Len(events, 0)
+
+	_, _, _, err = b.read(binlogPosition{file: "binlog.000001", pos: 5}, nil)
+	require.True(ErrBinlogPosition.Is(err))
+
+	_, _, _, err = b.read(binlogPosition{file: "binlog.000002", pos: 4}, nil)
+	require.True(ErrBinlogFile.Is(err))
+
+	// Starting after the format description sends it again, without a
+	// position.
+	events, err = b.start(next)
+	require.NoError(err)
+	require.Equal([]byte{eventRotate, eventFormatDescription}, eventTypes(events))
+	require.Equal(uint32(0), binary.LittleEndian.Uint32(events[1][13:]))
+
+	b.MaxSize = 1
+	require.NoError(b.RecordChanges(ctx, sql.TableChanges{
+		Database: "db",
+		Table:    "t",
+		Schema:   schema,
+		Rows:     []sql.RowChange{{Type: sql.RowInserted, After: sql.NewRow(int64(3), nil)}},
+	}))
+	require.Equal(uint64(3), b.purgedGTIDs())
+
+	events, _, _, err = b.read(p, nil)
+	require.NoError(err)
+	require.Equal([]byte{eventFormatDescription, eventPreviousGTIDs}, eventTypes(events))
+	_, _, _, err = b.read(binlogPosition{file: "binlog.000001", pos: 4 + 119 + 27}, nil)
+	require.True(ErrBinlogPurged.Is(err))
+}
+
+func TestParseGTIDSet(t *testing.T) {
+	require := require.New(t)
+
+	sid := [16]byte{1, 2, 3}
+	data := appendUint64(nil, 1)
+	data = append(data, sid[:]...)
+	data = appendUint64(data, 2)
+	data = appendUint64(appendUint64(data, 1), 5)
+	data = appendUint64(appendUint64(data, 7), 8)
+
+	set, ok := parseGTIDSet(data)
+	require.True(ok)
+	require.True(set.contains(sid, 4))
+	require.False(set.contains(sid, 5))
+	require.True(set.contains(sid, 7))
+	require.False(set.contains([16]byte{}, 1))
+	require.True(set.containsAll(sid, 4))
+	require.False(set.containsAll(sid, 7))
+
+	_, ok = parseGTIDSet(data[:len(data)-1])
+	require.False(ok)
+}
+
+func TestServerBinlogDump(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		Binlog:   NewBinlog(1),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	// The queries replicas run before asking for the log.
+	var checksum, format string
+	require.NoError(db.QueryRow("SELECT @@global.binlog_checksum, @@binlog_format").Scan(&checksum, &format))
+	require.Equal("NONE", checksum)
+	require.Equal("ROW", format)
+
+	_, err = db.Exec("INSERT INTO test (c1) VALUES (2000), (2001)")
+	require.NoError(err)
+	var before, after int64
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&before))
+	_, err = db.Exec("DELETE FROM test WHERE c1 < 1000")
+	require.NoError(err)
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&after))
+
+	var file, doDB, ignoreDB, gtids string
+	var position uint64
+	require.NoError(db.QueryRow("SHOW MASTER STATUS").Scan(&file, &position, &doDB, &ignoreDB, &gtids))
+	require.Equal("binlog.000001", file)
+	require.Regexp(":1-2$", gtids)
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	require.NoError(err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	readTestPacket(t, r)
+	testHandshake(t, conn, r, 0)
+
+	writeTestPacket(t, conn, 0, []byte{comRegisterSlave})
+	require.Equal(byte(mysql.OKPacket), readTestPacket(t, r)[0])
+
+	dump := appendUint32([]byte{mysql.ComBinlogDump}, 4)
+	dump = appendUint16(dump, binlogDumpNonBlock)
+	dump = appendUint32(dump, 2)
+	writeTestPacket(t, conn, 0, dump)
+
+	var types []byte
+	var rows int64
+	var end uint32
+	for {
+		packet := readTestPacket(t, r)
+		if packet[0] == mysql.EOFPacket {
+			break
+		}
+		require.Equal(byte(mysql.OKPacket), packet[0])
+
+		event := packet[1:]
+		types = append(types, event[4])
+		if event[4] == eventDeleteRows {
+			// A row has a byte with its null bitmap and 4 bytes.
+			rows += int64(len(event)-19-10-1-1) / 5
+		}
+		if pos := binary.LittleEndian.Uint32(event[13:]); pos != 0 {
+			end = pos
+		}
+	}
+
+	require.Equal([]byte{
+		eventRotate, eventFormatDescription, eventPreviousGTIDs,
+		eventGTID, eventQuery, eventTableMap, eventWriteRows, eventXID,
+		eventGTID, eventQuery, eventTableMap, eventDeleteRows, eventXID,
+	}, types)
+	require.Equal(before-after, rows)
+	require.Equal(uint32(position), end)
+
+	// The connection is still usable.
+	writeTestPacket(t, conn, 0, []byte{mysql.ComPing})
+	require.Equal(byte(mysql.OKPacket), readTestPacket(t, r)[0])
+}
@@ -0,0 +1,540 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+)
+
+// maxPacketPayload is the maximum payload of a packet. Longer payloads
+// are split in several packets.
+const maxPacketPayload = 1<<24 - 1
+
+// writeFlushSize is the size of the buffered response after which it is
+// written to the connection.
+const writeFlushSize = 16 * 1024
+
+// capabilityClientInteractive is the CLIENT_INTERACTIVE capability flag of
+// the clients that use interactive_timeout instead of wait_timeout, which
+// vitess does not define.
+const capabilityClientInteractive = 1 << 10
+
+type commandState byte
+
+const (
+	// The server is sending the handshake.
+	commandHandshake commandState = iota
+	// The server is waiting for the handshake response of the client.
+	commandResponse
+	// The commands are handled.
+	commandOn
+	// The connection is encrypted, so its packets cannot be read.
+	commandOff
+)
+
+// commandHandler handles the payload of a command, writing its response.
+type commandHandler func(c *commandConn, data []byte) error
+
+// commands are the commands the vitess listener does not implement, which
+// are handled by commandConn.
+var commands = map[byte]commandHandler{
+	comStmtPrepare:          (*commandConn).comStmtPrepare,
+	comStmtExecute:          (*commandConn).comStmtExecute,
+	comStmtSendLongData:     (*commandConn).comStmtSendLongData,
+	comStmtClose:            (*commandConn).comStmtClose,
+	comStmtReset:            (*commandConn).comStmtReset,
+	comStmtFetch:            (*commandConn).comStmtFetch,
+	comRegisterSlave:        (*commandConn).comRegisterSlave,
+	mysql.ComBinlogDump:     (*commandConn).comBinlogDump,
+	mysql.ComBinlogDumpGTID: (*commandConn).comBinlogDumpGTID,
+}
+
+// commandConn is a connection that handles the commands of the protocol
+// the vitess listener does not implement. Their packets are never seen by
+// the listener, which reads the rest of the packets as usual. The
+// connection id is read from the handshake sent by the server, and the
+// capabilities of the client from its handshake response. Connections
+// that switch to TLS are not read any further. The info of the statements
+// and the changes of the session state, for the clients that track them,
+// are added to the OK packets.
+type commandConn struct {
+	net.Conn
+	h     *Handler
+	state commandState
+	r     *bufio.Reader
+
+	// The packets read that have not been handled, which are read by the
+	// listener.
+	rbuf []byte
+	// The start of the handshake, until the connection id is read.
+	whead []byte
+	// The response being written.
+	wbuf []byte
+	// The sequence number of the next packet of the response.
+	seq byte
+
+	connID       uint32
+	capabilities uint32
+	// interactive is whether the wait_timeout of the session must be set
+	// to its interactive_timeout, as the client is interactive.
+	interactive bool
+	// tracked is the session state last reported to the client, which is
+	// read before its first command if it tracks the session state.
+	tracked *sessionState
+
+	stmts    map[uint32]*preparedStatement
+	lastStmt uint32
+}
+
+func newCommandConn(conn net.Conn, h *Handler) *commandConn {
+	return &commandConn{
+		Conn:  conn,
+		h:     h,
+		r:     bufio.NewReader(conn),
+		stmts: make(map[uint32]*preparedStatement),
+	}
+}
+
+func (c *commandConn) Read(p []byte) (int, error) {
+	for len(c.rbuf) == 0 {
+		if c.state != commandResponse && c.state != commandOn {
+			// The packets of encrypted connections cannot be told apart,
+			// so the client can take wait_timeout to send any of them.
+			if err := c.setReadTimeout("wait_timeout"); err != nil {
+				return 0, err
+			}
+			return c.r.Read(p)
+		}
+
+		if err := c.readPacket(); err != nil {
+			// The connection is going away, so the statements of the
+			// client are not needed anymore.
+			c.closeStatements()
+			return 0, err
+		}
+	}
+
+	n := copy(p, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+// readPacket reads the next packet of the client, handling it if it's a
+// command handled by the connection and leaving it to the listener if it's
+// not.
+func (c *commandConn) readPacket() error {
+	// Clients have wait_timeout seconds to send the next command.
+	timeout := "net_read_timeout"
+	if c.state == commandOn {
+		timeout = "wait_timeout"
+	}
+
+	packet, data, err := c.readPackets(timeout)
+	if err != nil {
+		return err
+	}
+
+	if c.state == commandResponse {
+		c.readCapabilities(data)
+		c.rbuf = packet
+		return nil
+	}
+
+	// Commands are the only packets of the client that start a sequence.
+	if packet[3] == 0 && c.tracksSession() && c.tracked == nil {
+		c.tracked = c.sessionState()
+	}
+
+	handler, ok := commands[firstByte(data)]
+	if !ok && c.loadsLocalData(data) {
+		handler, ok = (*commandConn).comLoadDataLocal, true
+	}
+
+	if !ok || packet[3] != 0 {
+		c.rbuf = packet
+		return nil
+	}
+
+	c.seq = 1
+	c.wbuf = c.wbuf[:0]
+	if err := handler(c, data[1:]); err != nil {
+		return err
+	}
+
+	return c.flush()
+}
+
+// readPackets reads a packet with its header, along with the following
+// ones if its payload is split, returning the packets and their joined
+// payload. The client has the timeout in the given session variable to
+// start sending them, and net_read_timeout to send the rest.
+func (c *commandConn) readPackets(timeout string) (packet, data []byte, err error) {
+	for {
+		if err := c.setReadTimeout(timeout); err != nil {
+			return nil, nil, err
+		}
+
+		var header [4]byte
+		if _, err := io.ReadFull(c.r, header[:]); err != nil {
+			if timeout == "wait_timeout" && isTimeout(err) {
+				c.h.sm.connLogger(c.connID).Infof("closing connection %d, idle for longer than wait_timeout", c.connID)
+			}
+			return nil, nil, err
+		}
+
+		timeout = "net_read_timeout"
+		if err := c.setReadTimeout(timeout); err != nil {
+			return nil, nil, err
+		}
+
+		length := uint24(header[:3])
+		start := len(packet)
+		packet = append(packet, header[:]...)
+		packet = append(packet, make([]byte, length)...)
+		if _, err := io.ReadFull(c.r, packet[start+4:]); err != nil {
+			return nil, nil, err
+		}
+		data = append(data, packet[start+4:]...)
+
+		if length < maxPacketPayload {
+			return packet, data, nil
+		}
+	}
+}
+
+// readCapabilities reads the capabilities of the client, which are the
+// first 4 bytes of its handshake response. Clients requesting TLS send
+// them in a shorter packet before the handshake.
+func (c *commandConn) readCapabilities(data []byte) {
+	if len(data) < 4 {
+		c.state = commandOff
+		return
+	}
+
+	c.capabilities = binary.LittleEndian.Uint32(data)
+	c.interactive = c.capabilities&capabilityClientInteractive != 0
+	if c.capabilities&mysql.CapabilityClientSSL != 0 {
+		c.state = commandOff
+		return
+	}
+
+	c.state = commandOn
+}
+
+func firstByte(data []byte) byte {
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	data := p
+	switch {
+	case c.state == commandHandshake:
+		data = c.readHandshake(p)
+	case c.state == commandOn:
+		data = c.completeOK(p)
+	}
+
+	if err := c.setWriteTimeout(); err != nil {
+		return 0, err
+	}
+
+	n, err := c.Conn.Write(data)
+	if len(data) != len(p) {
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return n, err
+}
+
+// session returns the session of the connection, which does not exist
+// until the client is authenticated.
+func (c *commandConn) session() sql.Session {
+	conn, ok := c.h.connection(c.connID)
+	if !ok {
+		return nil
+	}
+
+	sess := c.h.sm.session(conn)
+	if sess != nil && c.interactive {
+		// As in MySQL, the wait_timeout of interactive clients is their
+		// interactive_timeout when they connect.
+		c.interactive = false
+		typ, value := sess.Get("interactive_timeout")
+		sess.Set("wait_timeout", typ, value)
+	}
+	return sess
+}
+
+// timeout returns the duration of the timeout in the given session
+// variable, which is 0 if there is no timeout. The default value of the
+// variable is used until the session exists.
+func (c *commandConn) timeout(name string) time.Duration {
+	var value interface{}
+	if sess := c.session(); sess != nil {
+		_, value = sess.Get(name)
+	} else {
+		value = sql.DefaultSessionConfig()[name].Value
+	}
+
+	seconds, err := sql.Int64.Convert(value)
+	if err != nil || seconds.(int64) <= 0 {
+		return 0
+	}
+	return time.Duration(seconds.(int64)) * time.Second
+}
+
+// setReadTimeout sets the deadline of the next reads with the timeout in
+// the given session variable. The read timeout of the server, if any, is
+// used if it's shorter.
+func (c *commandConn) setReadTimeout(name string) error {
+	return c.SetReadDeadline(deadline(c.timeout(name), c.h.readTimeout))
+}
+
+// setWriteTimeout sets the deadline of the next writes with the
+// net_write_timeout of the session, or the write timeout of the server if
+// it's shorter.
+func (c *commandConn) setWriteTimeout() error {
+	return c.SetWriteDeadline(deadline(c.timeout("net_write_timeout"), c.h.writeTimeout))
+}
+
+// deadline returns the deadline of the shortest of the given timeouts,
+// ignoring the ones that are 0, or no deadline if all of them are 0.
+func deadline(timeouts ...time.Duration) time.Time {
+	var min time.Duration
+	for _, t := range timeouts {
+		if t > 0 && (min == 0 || t < min) {
+			min = t
+		}
+	}
+
+	if min == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(min)
+}
+
+func isTimeout(err error) bool {
+	nerr, ok := err.(net.Error)
+	return ok && nerr.Timeout()
+}
+
+// readHandshake looks for the connection id in the handshake, which
+// follows the protocol version and the server version, and offers
+// CLIENT_SESSION_TRACK in the upper capability flags, which follow the
+// first part of the salt, a filler byte, the lower capability flags, the
+// character set and the status flags. CLIENT_LOCAL_FILES is offered in
+// the lower ones if the clients can load their files. It returns the
+// bytes to write.
+func (c *commandConn) readHandshake(p []byte) []byte {
+	start := len(c.whead)
+	c.whead = append(c.whead, p...)
+	if len(c.whead) < 4 {
+		return p
+	}
+
+	end := bytes.IndexByte(c.whead[4:], 0)
+	if end < 0 {
+		return p
+	}
+
+	pos := 4 + end + 1
+	lower := pos + 4 + 8 + 1
+	flags := lower + 2 + 1 + 2
+	if len(c.whead) < flags+2 {
+		return p
+	}
+
+	c.connID = binary.LittleEndian.Uint32(c.whead[pos:])
+	if flags >= start {
+		p = append([]byte(nil), p...)
+		upper := binary.LittleEndian.Uint16(p[flags-start:])
+		binary.LittleEndian.PutUint16(p[flags-start:], upper|capabilityClientSessionTrack>>16)
+
+		if c.h.localInfile && lower >= start {
+			lowerFlags := binary.LittleEndian.Uint16(p[lower-start:])
+			binary.LittleEndian.PutUint16(p[lower-start:], lowerFlags|capabilityClientLocalFiles)
+		}
+	}
+
+	c.whead = nil
+	c.state = commandResponse
+	return p
+}
+
+// mysqlConn returns the vitess connection with the id of this one.
+func (c *commandConn) mysqlConn() (*mysql.Conn, error) {
+	conn, ok := c.h.connection(c.connID)
+	if !ok {
+		return nil, ErrConnectionWasClosed.New()
+	}
+	return conn, nil
+}
+
+func (c *commandConn) deprecateEOF() bool {
+	return c.capabilities&mysql.CapabilityClientDeprecateEOF != 0
+}
+
+// writePacket buffers a packet of the response, splitting its payload if
+// needed. The response is written when it gets long.
+func (c *commandConn) writePacket(data []byte) error {
+	for {
+		n := len(data)
+		if n > maxPacketPayload {
+			n = maxPacketPayload
+		}
+
+		header := make([]byte, 4)
+		putUint24(header, n)
+		header[3] = c.seq
+		c.seq++
+
+		c.wbuf = append(c.wbuf, header...)
+		c.wbuf = append(c.wbuf, data[:n]...)
+		data = data[n:]
+
+		if n < maxPacketPayload {
+			break
+		}
+	}
+
+	if len(c.wbuf) >= writeFlushSize {
+		return c.flush()
+	}
+
+	return nil
+}
+
+func (c *commandConn) flush() error {
+	if len(c.wbuf) == 0 {
+		return nil
+	}
+
+	if err := c.setWriteTimeout(); err != nil {
+		return err
+	}
+
+	_, err := c.Conn.Write(c.wbuf)
+	c.wbuf = c.wbuf[:0]
+	return err
+}
+
+func (c *commandConn) writeOK(status uint16) error {
+	return c.writeOKResult(&okResult{}, status)
+}
+
+// writeOKResult writes an OK packet with the result of a statement.
+func (c *commandConn) writeOKResult(r *okResult, status uint16) error {
+	data := []byte{mysql.OKPacket}
+	data = appendLenEncInt(data, r.affected)
+	data = appendLenEncInt(data, r.insertID)
+	data = appendUint16(data, status)
+	data = appendUint16(data, r.warnings)
+
+	changes := c.sessionStateChanges()
+	if r.info != "" || changes != nil {
+		data = appendOKInfo(data, r.info, changes, c.tracksSession())
+	}
+	return c.writePacket(data)
+}
+
+// writeEOF writes the packet that ends a result set, which is an OK packet
+// with the header of an EOF one for the clients with CLIENT_DEPRECATE_EOF.
+func (c *commandConn) writeEOF(status uint16) error {
+	data := []byte{mysql.EOFPacket}
+	if c.deprecateEOF() {
+		data = appendLenEncInt(data, 0)
+		data = appendLenEncInt(data, 0)
+		data = appendUint16(data, status)
+		data = appendUint16(data, 0)
+	} else {
+		data = appendUint16(data, 0)
+		data = appendUint16(data, status)
+	}
+	return c.writePacket(data)
+}
+
+func (c *commandConn) writeError(err error) error {
+	c.h.sm.connLogger(c.connID).Debugf("command failed on connection %d: %s", c.connID, err)
+
+	serr, ok := mysql.NewSQLErrorFromError(sqlError(err)).(*mysql.SQLError)
+	if !ok {
+		serr = mysql.NewSQLError(mysql.ERUnknownError, mysql.SSUnknownSQLState, "%v", err)
+	}
+
+	data := []byte{mysql.ErrPacket}
+	data = appendUint16(data, uint16(serr.Num))
+	data = append(data, '#')
+	data = append(data, serr.State...)
+	data = append(data, serr.Message...)
+	return c.writePacket(data)
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	return append(b, byte(n), byte(n>>8))
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	return append(b, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(n)), uint32(n>>32))
+}
+
+func appendLenEncInt(b []byte, n uint64) []byte {
+	switch {
+	case n < 251:
+		return append(b, byte(n))
+	case n < 1<<16:
+		return appendUint16(append(b, 0xfc), uint16(n))
+	case n < 1<<24:
+		return append(b, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		return appendUint64(append(b, 0xfe), n)
+	}
+}
+
+func appendLenEncString(b []byte, s string) []byte {
+	return append(appendLenEncInt(b, uint64(len(s))), s...)
+}
+
+// readLenEncInt reads a length encoded integer, returning it with the
+// rest of the data.
+func readLenEncInt(data []byte) (uint64, []byte, bool) {
+	if len(data) == 0 {
+		return 0, nil, false
+	}
+
+	var size int
+	switch data[0] {
+	case 0xfc:
+		size = 2
+	case 0xfd:
+		size = 3
+	case 0xfe:
+		size = 8
+	default:
+		return uint64(data[0]), data[1:], true
+	}
+
+	if len(data) < 1+size {
+		return 0, nil, false
+	}
+
+	var n uint64
+	for i := size; i > 0; i-- {
+		n = n<<8 | uint64(data[i])
+	}
+	return n, data[1+size:], true
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+)
+
+// ErrCompressionWithTLS is returned when the server is configured with both
+// compression and TLS, which cannot be used together.
+var ErrCompressionWithTLS = errors.NewKind("compression is not supported with TLS")
+
+// ErrInvalidCompressedPacket is returned when a client sends a compressed
+// packet that cannot be decompressed.
+var ErrInvalidCompressedPacket = errors.NewKind("invalid compressed packet: %s")
+
+// capabilityClientCompress is the CLIENT_COMPRESS capability flag, which
+// vitess does not define.
+const capabilityClientCompress = 1 << 5
+
+const (
+	// Payloads shorter than minCompressLength are sent uncompressed, as in
+	// MySQL.
+	minCompressLength = 50
+	// maxCompressedPayload is the maximum payload of a compressed packet.
+	maxCompressedPayload = 1<<24 - 1
+)
+
+type compressState byte
+
+const (
+	// The server is sending the handshake.
+	compressHandshake compressState = iota
+	// The server is waiting for the client capabilities.
+	compressResponse
+	// The client requested compression, which is used once the client is
+	// authenticated.
+	compressAuth
+	// The connection is compressed.
+	compressOn
+	// The connection is not compressed.
+	compressOff
+)
+
+// compressConn is a connection that offers the compressed protocol to
+// clients, setting CLIENT_COMPRESS in the handshake, and compresses the
+// packets of the clients that request it with zlib once they are
+// authenticated. Before that, the packets sent by the server are buffered
+// until they are complete, so they can be inspected.
+type compressConn struct {
+	net.Conn
+	state compressState
+
+	// The packets being written before compression is used.
+	wbuf []byte
+	// The first bytes read before compression is used, with the header of
+	// the handshake response and the client capabilities.
+	rhead []byte
+	// The decompressed bytes not read yet.
+	rbuf []byte
+	// The sequence number of the next compressed packet.
+	seq byte
+}
+
+func newCompressConn(conn net.Conn) *compressConn {
+	return &compressConn{Conn: conn}
+}
+
+func (c *compressConn) Read(p []byte) (int, error) {
+	switch c.state {
+	case compressOn:
+		return c.readCompressed(p)
+	case compressResponse:
+		n, err := c.Conn.Read(p)
+		c.readCapabilities(p[:n])
+		return n, err
+	default:
+		return c.Conn.Read(p)
+	}
+}
+
+// readCapabilities looks for the capabilities of the client in the
+// handshake response, which are the first 4 bytes after the packet header.
+func (c *compressConn) readCapabilities(p []byte) {
+	missing := 8 - len(c.rhead)
+	if missing > len(p) {
+		missing = len(p)
+	}
+	c.rhead = append(c.rhead, p[:missing]...)
+
+	if len(c.rhead) < 8 {
+		return
+	}
+
+	if binary.LittleEndian.Uint32(c.rhead[4:])&capabilityClientCompress != 0 {
+		c.state = compressAuth
+	} else {
+		c.state = compressOff
+	}
+	c.rhead = nil
+}
+
+func (c *compressConn) readCompressed(p []byte) (int, error) {
+	for len(c.rbuf) == 0 {
+		var header [7]byte
+		if _, err := io.ReadFull(c.Conn, header[:]); err != nil {
+			return 0, err
+		}
+
+		length := uint24(header[:3])
+		c.seq = header[3] + 1
+		uncompressed := uint24(header[4:])
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.Conn, payload); err != nil {
+			return 0, err
+		}
+
+		if uncompressed == 0 {
+			c.rbuf = payload
+			continue
+		}
+
+		r, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return 0, ErrInvalidCompressedPacket.New(err)
+		}
+
+		c.rbuf, err = ioutil.ReadAll(r)
+		if err != nil {
+			return 0, ErrInvalidCompressedPacket.New(err)
+		}
+
+		if len(c.rbuf) != uncompressed {
+			return 0, ErrInvalidCompressedPacket.New("wrong uncompressed length")
+		}
+	}
+
+	n := copy(p, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+func (c *compressConn) Write(p []byte) (int, error) {
+	switch c.state {
+	case compressOn:
+		return len(p), c.writeCompressed(p)
+	case compressHandshake, compressAuth:
+		c.wbuf = append(c.wbuf, p...)
+		return len(p), c.writePackets()
+	default:
+		return c.Conn.Write(p)
+	}
+}
+
+// writePackets writes the complete packets that have been buffered, and
+// the rest of the buffer compressed if the connection gets compressed.
+func (c *compressConn) writePackets() error {
+	for len(c.wbuf) >= 4 {
+		end := 4 + uint24(c.wbuf[:3])
+		if len(c.wbuf) < end {
+			return nil
+		}
+
+		packet := c.wbuf[:end]
+		c.wbuf = c.wbuf[end:]
+
+		switch {
+		case c.state == compressHandshake && len(packet) > 4:
+			offerCompression(packet[4:])
+			c.state = compressResponse
+		case c.state == compressAuth && len(packet) > 4 && packet[4] == mysql.OKPacket:
+			// The client is authenticated, so the following packets are
+			// compressed.
+			c.state = compressOn
+		}
+
+		if _, err := c.Conn.Write(packet); err != nil {
+			return err
+		}
+
+		if c.state == compressOn {
+			rest := c.wbuf
+			c.wbuf = nil
+			return c.writeCompressed(rest)
+		}
+	}
+
+	return nil
+}
+
+// offerCompression sets CLIENT_COMPRESS in the lower capability flags of
+// the handshake, which follow the protocol version, the server version,
+// the connection id, the first part of the salt and a filler byte.
+func offerCompression(handshake []byte) {
+	end := bytes.IndexByte(handshake[1:], 0)
+	if end < 0 {
+		return
+	}
+
+	pos := 1 + end + 1 + 4 + 8 + 1
+	if len(handshake) < pos+2 {
+		return
+	}
+
+	flags := binary.LittleEndian.Uint16(handshake[pos:])
+	binary.LittleEndian.PutUint16(handshake[pos:], flags|capabilityClientCompress)
+}
+
+func (c *compressConn) writeCompressed(p []byte) error {
+	for len(p) > 0 {
+		n := len(p)
+		if n > maxCompressedPayload {
+			n = maxCompressedPayload
+		}
+
+		if err := c.writeCompressedPacket(p[:n]); err != nil {
+			return err
+		}
+		p = p[n:]
+	}
+
+	return nil
+}
+
+func (c *compressConn) writeCompressedPacket(data []byte) error {
+	payload := data
+	var uncompressed int
+	if len(data) >= minCompressLength {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		// Data that does not get smaller is sent uncompressed.
+		if buf.Len() < len(data) {
+			payload = buf.Bytes()
+			uncompressed = len(data)
+		}
+	}
+
+	packet := make([]byte, 7, 7+len(payload))
+	putUint24(packet[:3], len(payload))
+	packet[3] = c.seq
+	putUint24(packet[4:], uncompressed)
+	packet = append(packet, payload...)
+	c.seq++
+
+	_, err := c.Conn.Write(packet)
+	return err
+}
+
+func uint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+func putUint24(b []byte, n int) {
+	b[0] = byte(n)
+	b[1] = byte(n >> 8)
+	b[2] = byte(n >> 16)
+}
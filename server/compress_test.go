@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bufio"
+	dsql "database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+func TestServerCompression(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	_, err = NewDefaultServer(Config{
+		Protocol:    "tcp",
+		Address:     "localhost:" + port,
+		Auth:        new(auth.None),
+		Compression: true,
+		TLS:         &TLSConfig{},
+	}, setupMemDB(require))
+	require.True(ErrCompressionWithTLS.Is(err))
+
+	s, err := NewDefaultServer(Config{
+		Protocol:    "tcp",
+		Address:     "localhost:" + port,
+		Auth:        new(auth.None),
+		Compression: true,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	// Clients that do not request compression are not affected.
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+	require.Equal(1010, n)
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	require.NoError(err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	handshake := readTestPacket(t, r)
+	versionEnd := strings.IndexByte(string(handshake[1:]), 0)
+	flags := binary.LittleEndian.Uint16(handshake[1+versionEnd+1+4+8+1:])
+	require.NotZero(flags & capabilityClientCompress)
+
+	testHandshake(t, conn, r, capabilityClientCompress)
+
+	// From now on, the packets are compressed.
+	compressed := &compressConn{Conn: conn, state: compressOn}
+	query := "SELECT c1 FROM test WHERE c1 >= 0 /* long enough to be compressed */"
+	writeTestPacket(t, compressed, 0, append([]byte{mysql.ComQuery}, query...))
+
+	r = bufio.NewReader(compressed)
+	require.Equal([]byte{1}, readTestPacket(t, r))
+	readTestPacket(t, r) // column definition
+	require.Equal(byte(mysql.EOFPacket), readTestPacket(t, r)[0])
+
+	var rows []string
+	for {
+		row := readTestPacket(t, r)
+		if row[0] == mysql.EOFPacket && len(row) < 9 {
+			break
+		}
+		rows = append(rows, string(row[1:1+row[0]]))
+	}
+
+	require.Len(rows, 1010)
+	require.Equal("0", rows[0])
+	require.Equal("1009", rows[1009])
+}
+
+// testHandshake authenticates as root with the given capabilities besides
+// the basic ones, once the handshake of the server is read.
+func testHandshake(t *testing.T, conn net.Conn, r io.Reader, capabilities uint32) {
+	t.Helper()
+
+	capabilities |= mysql.CapabilityClientProtocol41 |
+		mysql.CapabilityClientSecureConnection |
+		mysql.CapabilityClientPluginAuth |
+		mysql.CapabilityClientConnectWithDB
+
+	response := make([]byte, 4)
+	binary.LittleEndian.PutUint32(response, capabilities)
+	response = append(response, 0, 0, 0, 1, mysql.CharacterSetUtf8)
+	response = append(response, make([]byte, 23)...)
+	response = append(response, "root\x00"...)
+	response = append(response, 0)
+	response = append(response, "test\x00"...)
+	response = append(response, mysql.MysqlNativePassword+"\x00"...)
+	writeTestPacket(t, conn, 1, response)
+
+	ok := readTestPacket(t, r)
+	require.Equal(t, byte(mysql.OKPacket), ok[0])
+}
+
+func readTestPacket(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+
+	var header [4]byte
+	_, err := io.ReadFull(r, header[:])
+	require.NoError(t, err)
+
+	packet := make([]byte, uint24(header[:3]))
+	_, err = io.ReadFull(r, packet)
+	require.NoError(t, err)
+	return packet
+}
+
+func writeTestPacket(t *testing.T, w io.Writer, seq byte, payload []byte) {
+	t.Helper()
+
+	header := make([]byte, 4)
+	putUint24(header, len(payload))
+	header[3] = seq
+
+	_, err := w.Write(append(header, payload...))
+	require.NoError(t, err)
+}
@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"net"
+	"net/url"
 	"sync"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
 	"github.com/src-d/go-mysql-server/sql"
 	"vitess.io/vitess/go/mysql"
 )
@@ -19,6 +22,10 @@ type DoneFunc func()
 // DefaultSessionBuilder is a SessionBuilder that returns a base session.
 func DefaultSessionBuilder(c *mysql.Conn, addr string) sql.Session {
 	client := c.RemoteAddr().String()
+	if _, ok := c.RemoteAddr().(*net.UnixAddr); ok {
+		// Clients connected through a Unix socket are local, as in MySQL.
+		client = "localhost"
+	}
 	return sql.NewSession(addr, client, c.User, c.ConnectionID)
 }
 
@@ -28,11 +35,21 @@ func DefaultSessionBuilder(c *mysql.Conn, addr string) sql.Session {
 type SessionManager struct {
 	addr     string
 	tracer   opentracing.Tracer
+	logger   logrus.FieldLogger
 	memory   *sql.MemoryManager
 	mu       *sync.Mutex
 	builder  SessionBuilder
 	sessions map[uint32]sql.Session
 	pid      uint64
+	// socket is the path of the Unix socket of the server, if any.
+	socket string
+	// binlog is the binary log of the server, if any.
+	binlog *Binlog
+	// catalog has the global time zone of the new sessions, if set.
+	catalog *sql.Catalog
+	// onStart and onEnd are called when sessions start and end, if set.
+	onStart func(sql.Session)
+	onEnd   func(sql.Session)
 }
 
 // NewSessionManager creates a SessionManager with the given SessionBuilder.
@@ -45,6 +62,7 @@ func NewSessionManager(
 	return &SessionManager{
 		addr:     addr,
 		tracer:   tracer,
+		logger:   logrus.StandardLogger(),
 		memory:   memory,
 		mu:       new(sync.Mutex),
 		builder:  builder,
@@ -62,11 +80,59 @@ func (s *SessionManager) nextPid() uint64 {
 // NewSession creates a Session for the given connection and saves it to
 // session pool.
 func (s *SessionManager) NewSession(conn *mysql.Conn) {
+	sess := s.newSession(conn)
 	s.mu.Lock()
-	s.sessions[conn.ConnectionID] = s.builder(conn, s.addr)
+	s.sessions[conn.ConnectionID] = sess
 	s.mu.Unlock()
 }
 
+func (s *SessionManager) newSession(conn *mysql.Conn) sql.Session {
+	sess := s.builder(conn, s.addr)
+	s.initSession(sess)
+	return sess
+}
+
+// newBaseSession creates and saves a base session for the clients that
+// don't use the MySQL protocol, such as the X Protocol or HTTP ones, as
+// there is no MySQL connection to give to the session builder.
+func (s *SessionManager) newBaseSession(id uint32, client, user string) sql.Session {
+	sess := sql.NewSession(s.addr, client, user, id)
+	s.initSession(sess)
+
+	s.mu.Lock()
+	s.sessions[id] = sess
+	s.mu.Unlock()
+	return sess
+}
+
+// initSession sets the variables that depend on the server in a new
+// session, which is then given to the start callback, if any.
+func (s *SessionManager) initSession(sess sql.Session) {
+	if s.onStart != nil {
+		defer s.onStart(sess)
+	}
+
+	if s.socket != "" {
+		sess.Set("socket", sql.Text, s.socket)
+	}
+	if s.catalog != nil {
+		sess.Set(sql.TimeZoneVariable, sql.Text, s.catalog.TimeZone())
+		if s.catalog.CaseSensitiveNames() {
+			sess.Set("lower_case_table_names", sql.Int32, int32(0))
+		}
+	}
+	if s.binlog != nil {
+		// Replicas read these to check they can use the log.
+		sess.Set("log_bin", sql.Int8, int8(1))
+		sess.Set("server_id", sql.Uint32, s.binlog.ServerID())
+		sess.Set("server_uuid", sql.Text, s.binlog.ServerUUID())
+		sess.Set("gtid_mode", sql.Text, "ON")
+		sess.Set("binlog_format", sql.Text, "ROW")
+		sess.Set("binlog_row_image", sql.Text, "FULL")
+		sess.Set("binlog_checksum", sql.Text, "NONE")
+	}
+}
+
 func (s *SessionManager) session(conn *mysql.Conn) sql.Session {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -83,31 +149,124 @@ func (s *SessionManager) NewContextWithQuery(
 	conn *mysql.Conn,
 	query string,
 ) *sql.Context {
+	return s.newContext(s.connSession(conn), query)
+}
+
+// connSession returns the session of the given conn, creating it if it
+// does not exist yet.
+func (s *SessionManager) connSession(conn *mysql.Conn) sql.Session {
 	s.mu.Lock()
 	sess, ok := s.sessions[conn.ConnectionID]
+	s.mu.Unlock()
+	if ok {
+		return sess
+	}
+
+	// The session is created without holding the lock, as the start
+	// callback may take a while.
+	sess = s.newSession(conn)
+
+	s.mu.Lock()
+	existing, ok := s.sessions[conn.ConnectionID]
 	if !ok {
-		sess = s.builder(conn, s.addr)
 		s.sessions[conn.ConnectionID] = sess
 	}
 	s.mu.Unlock()
 
+	if ok {
+		s.endSession(sess)
+		return existing
+	}
+	return sess
+}
+
+// newContext creates a new context for the given session.
+func (s *SessionManager) newContext(sess sql.Session, query string) *sql.Context {
 	context := sql.NewContext(
 		context.Background(),
 		sql.WithSession(sess),
 		sql.WithTracer(s.tracer),
+		sql.WithLogger(s.logger),
 		sql.WithPid(s.nextPid()),
 		sql.WithQuery(query),
 		sql.WithMemoryManager(s.memory),
-		sql.WithRootSpan(s.tracer.StartSpan("query")),
+		sql.WithRootSpan(s.rootSpan(sess)),
 	)
 
 	return context
 }
 
+// rootSpan starts the root span of a query of the given session, which is
+// a child of the span in the trace_context of the session, if any, so the
+// queries can be traced with the requests of the client that sent them.
+func (s *SessionManager) rootSpan(sess sql.Session) opentracing.Span {
+	opts := []opentracing.StartSpanOption{
+		opentracing.Tag{Key: "connection_id", Value: sess.ID()},
+	}
+	if parent := s.traceContext(sess); parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent))
+	}
+	return s.tracer.StartSpan("query", opts...)
+}
+
+// traceContext returns the span context in the trace_context variable of the
+// session, which holds the text map the tracer propagates it with, encoded as
+// a URL query, such as traceparent=00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01.
+// It returns nil if the variable is empty or it does not hold a span context.
+func (s *SessionManager) traceContext(sess sql.Session) opentracing.SpanContext {
+	_, value := sess.Get("trace_context")
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return nil
+	}
+
+	values, err := url.ParseQuery(text)
+	if err != nil {
+		s.connLogger(sess.ID()).Warnf("invalid trace_context %q: %s", text, err)
+		return nil
+	}
+
+	carrier := make(opentracing.TextMapCarrier, len(values))
+	for k := range values {
+		carrier[k] = values.Get(k)
+	}
+
+	parent, err := s.tracer.Extract(opentracing.TextMap, carrier)
+	if err != nil {
+		if err != opentracing.ErrSpanContextNotFound {
+			s.connLogger(sess.ID()).Warnf("invalid trace_context %q: %s", text, err)
+		}
+		return nil
+	}
+	return parent
+}
+
+// connLogger returns the logger of the server with the id of the given
+// connection as a field.
+func (s *SessionManager) connLogger(id uint32) *logrus.Entry {
+	return s.logger.WithField("connection_id", id)
+}
+
 // CloseConn closes the connection in the session manager and all its
 // associated contexts, which are cancelled.
 func (s *SessionManager) CloseConn(conn *mysql.Conn) {
+	s.closeSession(conn.ConnectionID)
+}
+
+func (s *SessionManager) closeSession(id uint32) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.sessions, conn.ConnectionID)
+	sess, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if ok {
+		s.endSession(sess)
+	}
+}
+
+// endSession gives the session to the end callback, if any.
+func (s *SessionManager) endSession(sess sql.Session) {
+	if s.onEnd != nil {
+		s.onEnd(sess)
+	}
 }
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	dsql "database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTraceContext(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	tracer := mocktracer.New()
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		Tracer:   tracer,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("SET trace_context = 'mockpfx-ids-traceid=42&mockpfx-ids-spanid=7&mockpfx-ids-sampled=true'")
+	require.NoError(err)
+	tracer.Reset()
+
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+
+	var root *mocktracer.MockSpan
+	for i := 0; i < 100 && root == nil; i++ {
+		for _, span := range tracer.FinishedSpans() {
+			if span.OperationName == "query" && span.ParentID == 7 {
+				root = span
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NotNil(root)
+	require.Equal(42, root.SpanContext.TraceID)
+
+	// Every span of the query is a descendant of the root span.
+	spans := make(map[int]*mocktracer.MockSpan)
+	names := make(map[string]bool)
+	for _, span := range tracer.FinishedSpans() {
+		spans[span.SpanContext.SpanID] = span
+		names[span.OperationName] = true
+	}
+	for _, span := range spans {
+		for span != root {
+			parent, ok := spans[span.ParentID]
+			require.True(ok, "span %s is not a descendant of the root span", span.OperationName)
+			span = parent
+		}
+	}
+
+	for _, name := range []string{"parse", "prepare", "finish", "batch", "rule.resolve_tables", "plan.GroupBy"} {
+		require.True(names[name], "missing span %s", name)
+	}
+}
+
+func TestServerLogger(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		Logger:   logger,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+
+	var connected, executed *logrus.Entry
+	for _, e := range hook.AllEntries() {
+		switch {
+		case strings.HasPrefix(e.Message, "NewConnection"):
+			connected = e
+		case e.Message == "executing query" && e.Data["query"] == "SELECT COUNT(*) FROM test":
+			executed = e
+		}
+	}
+	require.NotNil(connected)
+	require.NotNil(executed)
+
+	id := connected.Data["connection_id"]
+	require.NotNil(id)
+	require.Equal(id, executed.Data["connection_id"])
+	require.Equal("root", executed.Data["user"])
+	require.NotZero(executed.Data["query_id"])
+}
+
+type tenantKey struct{}
+
+func TestServerSessionCallbacks(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	e := setupMemDB(require)
+	require.NoError(e.Catalog.Register(sql.ScalarFunction{
+		Name:             "tenant",
+		Returns:          sql.Text,
+		NonDeterministic: true,
+		Fn: func(ctx *sql.Context, args ...interface{}) (interface{}, error) {
+			return ctx.Session.State(tenantKey{}), nil
+		},
+	}))
+
+	ended := make(chan interface{}, 1)
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		OnSessionStart: func(sess sql.Session) {
+			sess.SetState(tenantKey{}, "tenant of "+sess.Client().User)
+		},
+		OnSessionEnd: func(sess sql.Session) {
+			ended <- sess.State(tenantKey{})
+		},
+	}, e)
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+
+	var tenant string
+	require.NoError(db.QueryRow("SELECT tenant()").Scan(&tenant))
+	require.Equal("tenant of root", tenant)
+
+	require.NoError(db.Close())
+	select {
+	case tenant := <-ended:
+		require.Equal("tenant of root", tenant)
+	case <-time.After(5 * time.Second):
+		require.Fail("the end of the session was not notified")
+	}
+}
+
+func TestServerGlobalTimeZone(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SET GLOBAL time_zone = '+03:00'")
+	require.NoError(err)
+
+	// The global time zone is the one of the new sessions only.
+	var tz string
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@time_zone").Scan(&tz))
+	require.Equal("SYSTEM", tz)
+
+	other, err := db.Conn(ctx)
+	require.NoError(err)
+	defer other.Close()
+
+	require.NoError(other.QueryRowContext(ctx, "SELECT @@time_zone").Scan(&tz))
+	require.Equal("+03:00", tz)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// endpointServer serves an HTTP handler, such as the metrics or the health
+// of the server, at its own address.
+type endpointServer struct {
+	name     string
+	listener net.Listener
+	server   *http.Server
+	logger   logrus.FieldLogger
+}
+
+func newEndpointServer(
+	name, address string,
+	handler http.Handler,
+	logger logrus.FieldLogger,
+) (*endpointServer, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &endpointServer{
+		name:     name,
+		listener: l,
+		server:   &http.Server{Handler: handler},
+		logger:   logger,
+	}, nil
+}
+
+// serve handles the requests until the server is closed.
+func (s *endpointServer) serve() {
+	err := s.server.Serve(s.listener)
+	if err != nil && err != http.ErrServerClosed {
+		s.logger.Errorf("unable to serve %s: %s", s.name, err)
+	}
+}
+
+func (s *endpointServer) close() error {
+	return s.server.Close()
+}
+
+// shutdown stops accepting requests and waits for the running ones to
+// finish until ctx is done.
+func (s *endpointServer) shutdown(ctx context.Context) {
+	if err := s.server.Shutdown(ctx); err != nil {
+		_ = s.server.Close()
+	}
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GeneralQuery is a statement received by the server.
+type GeneralQuery struct {
+	// Time is when the statement was received.
+	Time         time.Time
+	ConnectionID uint32
+	User         string
+	// Address is the address of the client.
+	Address string
+	// Database is the current database when the statement was received.
+	Database string
+	// Query is the statement, once redacted.
+	Query string
+}
+
+// GeneralLogSink receives the statements of the general log of a server.
+// Statements of different connections are given concurrently.
+type GeneralLogSink interface {
+	GeneralQuery(GeneralQuery)
+}
+
+// GeneralLogFunc is a GeneralLogSink that calls a function with every
+// statement.
+type GeneralLogFunc func(GeneralQuery)
+
+// GeneralQuery implements the GeneralLogSink interface.
+func (f GeneralLogFunc) GeneralQuery(q GeneralQuery) {
+	f(q)
+}
+
+// GeneralLogWriter is a GeneralLogSink that writes the statements in the
+// format of the general query log of MySQL.
+type GeneralLogWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewGeneralLogWriter creates a GeneralLogWriter that writes to w.
+func NewGeneralLogWriter(w io.Writer) *GeneralLogWriter {
+	return &GeneralLogWriter{w: w}
+}
+
+// NewGeneralLogFile creates a GeneralLogWriter that appends the statements
+// to the file at the given path, creating it if it does not exist.
+func NewGeneralLogFile(path string) (*GeneralLogWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewGeneralLogWriter(f), nil
+}
+
+// GeneralQuery implements the GeneralLogSink interface. Statements that
+// cannot be written are logged.
+func (w *GeneralLogWriter) GeneralQuery(q GeneralQuery) {
+	line := fmt.Sprintf("%s\t%6d Query\t%s\n",
+		q.Time.UTC().Format("2006-01-02T15:04:05.000000Z"), q.ConnectionID, q.Query)
+
+	w.mu.Lock()
+	_, err := io.WriteString(w.w, line)
+	w.mu.Unlock()
+	if err != nil {
+		logrus.Errorf("unable to write to the general log: %s", err)
+	}
+}
+
+// Close closes the underlying writer, if it can be closed.
+func (w *GeneralLogWriter) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// RedactLiterals replaces the string, numeric, hexadecimal and bit literals
+// of a query with ?, so it can be written in the general log without the
+// data it contains. Identifiers, including the quoted ones, and comments
+// are kept. It does not need to parse the query, so it can redact the ones
+// that are not valid too.
+func RedactLiterals(query string) string {
+	var buf strings.Builder
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(query, i+1, c)
+			buf.WriteByte('?')
+		case c == '`':
+			end := skipQuoted(query, i+1, c)
+			buf.WriteString(query[i:end])
+			i = end
+		case (c == 'x' || c == 'X' || c == 'b' || c == 'B') &&
+			i+1 < len(query) && query[i+1] == '\'' && !isIdentifierByte(query, i-1):
+			i = skipQuoted(query, i+2, '\'')
+			buf.WriteByte('?')
+		case c == '-' && strings.HasPrefix(query[i:], "-- "), c == '#':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			buf.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query)
+			} else {
+				end += i + 4
+			}
+			buf.WriteString(query[i:end])
+			i = end
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			if isIdentifierByte(query, i-1) {
+				// Digits that are part of an identifier, such as t1.
+				end := i
+				for end < len(query) && isIdentifierByte(query, end) {
+					end++
+				}
+				buf.WriteString(query[i:end])
+				i = end
+				continue
+			}
+
+			i = skipNumber(query, i)
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	return buf.String()
+}
+
+// skipQuoted returns the position after the quote that closes a quoted
+// string or identifier starting at i, which can contain the quote
+// escaped with a backslash or doubled.
+func skipQuoted(query string, i int, quote byte) int {
+	for i < len(query) {
+		switch query[i] {
+		case '\\':
+			i += 2
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(query)
+}
+
+// skipNumber returns the position after the number starting at i, which
+// may be hexadecimal, binary, decimal or in scientific notation.
+func skipNumber(query string, i int) int {
+	if query[i] == '0' && i+1 < len(query) && (query[i+1] == 'x' || query[i+1] == 'b') {
+		i += 2
+		for i < len(query) && isIdentifierByte(query, i) {
+			i++
+		}
+		return i
+	}
+
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case isDigit(c) || c == '.':
+			i++
+		case (c == 'e' || c == 'E') && i+1 < len(query):
+			i++
+			if query[i] == '+' || query[i] == '-' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentifierByte returns whether the byte at position i of the query
+// can be part of an unquoted identifier.
+func isIdentifierByte(query string, i int) bool {
+	if i < 0 || i >= len(query) {
+		return false
+	}
+
+	c := query[i]
+	return c == '_' || c == '$' || isDigit(c) || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	dsql "database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerGeneralLog(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	var mu sync.Mutex
+	var queries []GeneralQuery
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		GeneralLog: GeneralLogFunc(func(q GeneralQuery) {
+			mu.Lock()
+			queries = append(queries, q)
+			mu.Unlock()
+		}),
+		GeneralLogRedact: RedactLiterals,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test WHERE c1 > 1000").Scan(&n))
+	_, err = db.Exec("SELECT * FROM nope WHERE name = 'secret'")
+	require.Error(err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(queries, 2)
+	require.Equal("SELECT COUNT(*) FROM test WHERE c1 > ?", queries[0].Query)
+	require.Equal("root", queries[0].User)
+	require.Equal("test", queries[0].Database)
+	require.NotZero(queries[0].ConnectionID)
+	require.Equal("SELECT * FROM nope WHERE name = ?", queries[1].Query)
+}
+
+func TestRedactLiterals(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT 1", "SELECT ?"},
+		{"SELECT a FROM t1 WHERE b = 'x''y' AND c = \"z\\\"\"", "SELECT a FROM t1 WHERE b = ? AND c = ?"},
+		{"SELECT -1.5e-3, .5, 0x1F, X'ab', b'01', 0b11", "SELECT -?, ?, ?, ?, ?, ?"},
+		{"SELECT `col 'a'`, t2.c3 FROM db1.t2", "SELECT `col 'a'`, t2.c3 FROM db1.t2"},
+		{"SELECT /* 'kept' 1 */ 2 -- 'kept'\n, 3 # 4", "SELECT /* 'kept' 1 */ ? -- 'kept'\n, ? # 4"},
+		{"INSERT INTO t VALUES (1, 'unterminated", "INSERT INTO t VALUES (?, ?"},
+		{"SELECT ax'1'", "SELECT ax?"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.expected, RedactLiterals(tt.query))
+		})
+	}
+}
+
+func TestGeneralLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewGeneralLogWriter(&buf)
+	w.GeneralQuery(GeneralQuery{
+		Time:         time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+		ConnectionID: 12,
+		Query:        "SELECT ?",
+	})
+
+	require.Equal(t, "2019-01-02T03:04:05.000000Z\t    12 Query\tSELECT ?\n", buf.String())
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/server/rpc"
+	"github.com/src-d/go-mysql-server/sql"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// grpcRowsBatch is the number of rows sent in each response of
+// ExecuteQuery.
+const grpcRowsBatch = 128
+
+// grpcServer serves the gRPC query service defined in the rpc package.
+// Every call runs in a new session, with the user given in the
+// authorization metadata with basic authentication.
+type grpcServer struct {
+	listener net.Listener
+	server   *grpc.Server
+	sessions *requestSessions
+}
+
+func newGRPCServer(address string, h *Handler, a mysql.AuthServer) (*grpcServer, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &grpcServer{
+		listener: l,
+		server:   grpc.NewServer(),
+		sessions: newRequestSessions(h, a),
+	}
+	rpc.RegisterQueryServer(s.server, s)
+	return s, nil
+}
+
+// serve handles the calls until the server is closed.
+func (s *grpcServer) serve() {
+	if err := s.server.Serve(s.listener); err != nil && err != grpc.ErrServerStopped {
+		s.sessions.h.sm.logger.Errorf("unable to serve gRPC calls: %s", err)
+	}
+}
+
+// close stops accepting calls and closes the open connections, which
+// cancels their queries.
+func (s *grpcServer) close() {
+	s.server.Stop()
+}
+
+// shutdown stops accepting calls and waits for the running ones to finish
+// until ctx is done, when the connections are closed.
+func (s *grpcServer) shutdown(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+	}
+}
+
+// open authenticates the client of a call and creates its session.
+func (s *grpcServer) open(ctx context.Context, db string) (*requestSession, error) {
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+	}
+
+	var user, password string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			var ok bool
+			user, password, ok = parseBasicAuth(values[0])
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "wrong authorization metadata")
+			}
+		}
+	}
+
+	sess, err := s.sessions.open(ctx, client, user, password)
+	if err != nil {
+		return nil, grpcError(codes.Unauthenticated, err)
+	}
+
+	if db != "" {
+		if err := sess.use(db); err != nil {
+			sess.close()
+			return nil, grpcError(codes.InvalidArgument, err)
+		}
+	}
+
+	return sess, nil
+}
+
+// parseBasicAuth returns the user and password of a basic authentication
+// header.
+func parseBasicAuth(header string) (string, string, bool) {
+	const prefix = "basic "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", "", false
+	}
+
+	data, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// grpcError returns the status of an error, whose message has its MySQL
+// code and state as the errors of the MySQL client libraries. The given
+// code is used unless the error has a more specific one.
+func grpcError(code codes.Code, err error) error {
+	num, state, msg := xError(err)
+	switch {
+	case auth.ErrNotAuthorized.Is(err) || auth.ErrNoPermission.Is(err):
+		code = codes.PermissionDenied
+	case sql.ErrDatabaseNotFound.Is(err) || sql.ErrTableNotFound.Is(err):
+		code = codes.NotFound
+	case err == context.Canceled || num == mysql.ERQueryInterrupted:
+		code = codes.Canceled
+	case num == mysql.ERServerShutdown:
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, fmt.Sprintf("%s (errno %d) (sqlstate %s)", msg, num, state))
+}
+
+// ExecuteQuery implements the rpc.QueryServer interface.
+func (s *grpcServer) ExecuteQuery(
+	req *rpc.ExecuteQueryRequest,
+	stream rpc.Query_ExecuteQueryServer,
+) error {
+	if strings.TrimSpace(req.Query) == "" {
+		return grpcError(codes.InvalidArgument, ErrEmptyQuery.New())
+	}
+
+	sess, err := s.open(stream.Context(), req.Database)
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	err = sess.query(req.Query, func(schema sql.Schema, iter sql.RowIter) error {
+		resp := &rpc.ExecuteQueryResponse{Columns: grpcColumns(schema)}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+
+		resp = new(rpc.ExecuteQueryResponse)
+		for {
+			row, err := iter.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			r, err := grpcRow(schema, row)
+			if err != nil {
+				return err
+			}
+
+			resp.Rows = append(resp.Rows, r)
+			if len(resp.Rows) == grpcRowsBatch {
+				if err := stream.Send(resp); err != nil {
+					return err
+				}
+				resp = new(rpc.ExecuteQueryResponse)
+			}
+		}
+
+		if len(resp.Rows) > 0 {
+			return stream.Send(resp)
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return grpcError(codes.InvalidArgument, err)
+	}
+	return nil
+}
+
+// ListDatabases implements the rpc.QueryServer interface.
+func (s *grpcServer) ListDatabases(
+	ctx context.Context,
+	req *rpc.ListDatabasesRequest,
+) (*rpc.ListDatabasesResponse, error) {
+	names, err := s.names(ctx, "SHOW DATABASES")
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.ListDatabasesResponse{Databases: names}, nil
+}
+
+// ListTables implements the rpc.QueryServer interface.
+func (s *grpcServer) ListTables(
+	ctx context.Context,
+	req *rpc.ListTablesRequest,
+) (*rpc.ListTablesResponse, error) {
+	query := "SHOW TABLES"
+	if req.Database != "" {
+		query += " FROM " + quoteIdentifier(req.Database)
+	}
+
+	names, err := s.names(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.ListTablesResponse{Tables: names}, nil
+}
+
+// DescribeTable implements the rpc.QueryServer interface.
+func (s *grpcServer) DescribeTable(
+	ctx context.Context,
+	req *rpc.DescribeTableRequest,
+) (*rpc.DescribeTableResponse, error) {
+	sess, err := s.open(ctx, req.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.close()
+
+	// The schema of a query that reads no rows is the one of the table.
+	var schema sql.Schema
+	query := "SELECT * FROM " + quoteIdentifier(req.Table) + " LIMIT 0"
+	err = sess.query(query, func(s sql.Schema, _ sql.RowIter) error {
+		schema = s
+		return nil
+	})
+	if err != nil {
+		return nil, grpcError(codes.InvalidArgument, err)
+	}
+
+	return &rpc.DescribeTableResponse{Columns: grpcColumns(schema)}, nil
+}
+
+// names runs a query and returns the values of its first column.
+func (s *grpcServer) names(ctx context.Context, query string) ([]string, error) {
+	sess, err := s.open(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	defer sess.close()
+
+	var names []string
+	err = sess.query(query, func(_ sql.Schema, iter sql.RowIter) error {
+		rows, err := sql.RowIterToRows(iter)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			names = append(names, fmt.Sprint(row[0]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, grpcError(codes.InvalidArgument, err)
+	}
+	return names, nil
+}
+
+func grpcColumns(schema sql.Schema) []*rpc.Column {
+	columns := make([]*rpc.Column, len(schema))
+	for i, col := range schema {
+		columns[i] = &rpc.Column{
+			Name:     col.Name,
+			Type:     col.Type.Type().String(),
+			Nullable: col.Nullable,
+			Source:   col.Source,
+		}
+	}
+	return columns
+}
+
+// grpcRow encodes a row, with the values converted as they are sent with
+// the MySQL protocol.
+func grpcRow(schema sql.Schema, row sql.Row) (*rpc.Row, error) {
+	values, err := rowToSQL(schema, row)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rpc.Row{Values: make([]*rpc.Value, len(values))}
+	for i, v := range values {
+		r.Values[i], err = grpcValue(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func grpcValue(v sqltypes.Value) (*rpc.Value, error) {
+	switch {
+	case v.IsNull():
+		return &rpc.Value{Kind: &rpc.Value_NullValue{NullValue: true}}, nil
+	case v.IsSigned():
+		n, err := strconv.ParseInt(v.ToString(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &rpc.Value{Kind: &rpc.Value_IntValue{IntValue: n}}, nil
+	case v.IsUnsigned():
+		n, err := strconv.ParseUint(v.ToString(), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &rpc.Value{Kind: &rpc.Value_UintValue{UintValue: n}}, nil
+	case v.IsFloat():
+		f, err := strconv.ParseFloat(v.ToString(), 64)
+		if err != nil {
+			return nil, err
+		}
+		return &rpc.Value{Kind: &rpc.Value_DoubleValue{DoubleValue: f}}, nil
+	case v.IsBinary():
+		return &rpc.Value{Kind: &rpc.Value_BytesValue{BytesValue: v.ToBytes()}}, nil
+	default:
+		return &rpc.Value{Kind: &rpc.Value_StringValue{StringValue: v.ToString()}}, nil
+	}
+}
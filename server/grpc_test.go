@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/server/rpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCQuery(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+	grpcPort, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:    "tcp",
+		Address:     "localhost:" + port,
+		GRPCAddress: "localhost:" + grpcPort,
+		Auth:        auth.NewNativeSingle("user", "pass", auth.AllPermissions),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	conn, err := grpc.Dial("localhost:"+grpcPort, grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	client := rpc.NewQueryClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")))
+
+	stream, err := client.ExecuteQuery(ctx, &rpc.ExecuteQueryRequest{
+		Query:    "SELECT c1, 'a' AS s, NULL AS n FROM test WHERE c1 < 200 ORDER BY c1",
+		Database: "test",
+	})
+	require.NoError(err)
+
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.Equal([]*rpc.Column{
+		{Name: "c1", Type: "INT32", Source: "test"},
+		{Name: "s", Type: "TEXT"},
+		{Name: "n", Type: "NULL_TYPE", Nullable: true},
+	}, resp.Columns)
+
+	var rows []*rpc.Row
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		require.True(len(resp.Rows) <= grpcRowsBatch)
+		rows = append(rows, resp.Rows...)
+	}
+	require.Len(rows, 200)
+	require.Equal(&rpc.Row{Values: []*rpc.Value{
+		{Kind: &rpc.Value_IntValue{IntValue: 199}},
+		{Kind: &rpc.Value_StringValue{StringValue: "a"}},
+		{Kind: &rpc.Value_NullValue{NullValue: true}},
+	}}, rows[199])
+
+	stream, err = client.ExecuteQuery(ctx, &rpc.ExecuteQueryRequest{Query: "SELECT * FROM nope"})
+	require.NoError(err)
+	_, err = stream.Recv()
+	require.Equal(codes.NotFound, status.Code(err))
+
+	dbs, err := client.ListDatabases(ctx, &rpc.ListDatabasesRequest{})
+	require.NoError(err)
+	require.Contains(dbs.Databases, "test")
+
+	tables, err := client.ListTables(ctx, &rpc.ListTablesRequest{Database: "test"})
+	require.NoError(err)
+	require.Equal([]string{"test"}, tables.Tables)
+
+	table, err := client.DescribeTable(ctx, &rpc.DescribeTableRequest{Database: "test", Table: "test"})
+	require.NoError(err)
+	require.Equal([]*rpc.Column{{Name: "c1", Type: "INT32", Source: "test"}}, table.Columns)
+
+	wrong := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("user:wrong")))
+	_, err = client.ListDatabases(wrong, &rpc.ListDatabasesRequest{})
+	require.Equal(codes.Unauthenticated, status.Code(err))
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"io"
+	"math"
 	"net"
 	"regexp"
 	"strconv"
@@ -11,12 +12,12 @@ import (
 	"time"
 
 	sqle "github.com/src-d/go-mysql-server"
-	"github.com/src-d/go-mysql-server/auth"
 	"github.com/src-d/go-mysql-server/internal/sockstate"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/plan"
 	"gopkg.in/src-d/go-errors.v1"
 
-	"github.com/sirupsen/logrus"
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/proto/query"
@@ -32,10 +33,45 @@ var ErrRowTimeout = errors.NewKind("row read wait bigger than connection timeout
 // ErrConnectionWasClosed will be returned if we try to use a previously closed connection
 var ErrConnectionWasClosed = errors.NewKind("connection was closed")
 
+// erWarnDataOutOfRange is the MySQL error code of the values out of the
+// range of their columns.
+const erWarnDataOutOfRange = 1264
+
+// ssSyntaxErrorOrAccessViolation is the SQL state of the statements that are
+// not valid, such as the grouped queries with columns that are not grouped.
+const ssSyntaxErrorOrAccessViolation = "42000"
+
+// sqlErrors are the MySQL error codes and SQL states of the errors of the
+// engine that clients tell apart by their code.
+var sqlErrors = map[*errors.Kind]struct {
+	code  int
+	state string
+}{
+	sql.ErrValueOutOfRange:        {erWarnDataOutOfRange, mysql.SSDataOutOfRange},
+	sql.ErrTruncatedWrongValue:    {mysql.ERTruncatedWrongValueForField, mysql.SSUnknownSQLState},
+	sql.ErrDataTooLong:            {mysql.ERDataTooLong, mysql.SSDataTooLong},
+	analyzer.ErrValidationGroupBy: {mysql.ERWrongFieldWithGroup, ssSyntaxErrorOrAccessViolation},
+}
+
+// sqlError returns the MySQL error of an error of the engine with a known
+// error code, or the error as it is otherwise.
+func sqlError(err error) error {
+	for kind, e := range sqlErrors {
+		if kind.Is(err) {
+			return mysql.NewSQLError(e.code, e.state, "%s", err)
+		}
+	}
+	return err
+}
+
 // TODO parametrize
 const rowsBatch = 100
 const tcpCheckerSleepTime = 1
 
+// shutdownCheckInterval is how often the server checks whether the running
+// queries finished while it's shutting down.
+const shutdownCheckInterval = 50 * time.Millisecond
+
 type conntainer struct {
 	MysqlConn *mysql.Conn
 	NetConn   net.Conn
@@ -48,7 +84,26 @@ type Handler struct {
 	sm          *SessionManager
 	c           map[uint32]conntainer
 	readTimeout time.Duration
-	lc          []*net.Conn
+	// writeTimeout is the write timeout of the connections, if any.
+	writeTimeout time.Duration
+	lc           []*net.Conn
+	limits       *connectionLimits
+	// queries throttles the queries, if they are limited.
+	queries *queryLimits
+	// auditSink receives the audit events, if any.
+	auditSink AuditSink
+	// slowLog receives the slow queries, if any.
+	slowLog SlowQuerySink
+	// generalLog receives every statement, redacted with redact if it's
+	// not nil.
+	generalLog GeneralLogSink
+	redact     func(string) string
+	// localInfile is whether the clients can load their files with LOAD
+	// DATA LOCAL INFILE.
+	localInfile bool
+	// closing is whether the server is shutting down, so new queries are
+	// rejected.
+	closing bool
 }
 
 // NewHandler creates a new Handler given a SQLe engine.
@@ -64,7 +119,9 @@ func NewHandler(e *sqle.Engine, sm *SessionManager, rt time.Duration) *Handler {
 // AddNetConnection is used to add the net.Conn to the Handler when available (usually on the
 // Listener.Accept() method)
 func (h *Handler) AddNetConnection(c *net.Conn) {
+	h.mu.Lock()
 	h.lc = append(h.lc, c)
+	h.mu.Unlock()
 }
 
 // NewConnection reports that a new connection has been established.
@@ -77,33 +134,119 @@ func (h *Handler) NewConnection(c *mysql.Conn) {
 			netConn = *h.lc[len(h.lc)-1]
 			h.lc = h.lc[:len(h.lc)-1]
 		} else {
-			logrus.Debug("Could not find TCP socket connection after Accept(), " +
+			h.sm.connLogger(c.ConnectionID).Debug("Could not find TCP socket connection after Accept(), " +
 				"connection checker won't run")
 		}
 		h.c[c.ConnectionID] = conntainer{c, netConn}
 	}
 
+	// Sessions start with autocommit enabled and no transaction.
+	c.StatusFlags = mysql.ServerStatusAutocommit
+
 	h.mu.Unlock()
 
-	logrus.Infof("NewConnection: client %v", c.ConnectionID)
+	ConnectionsGauge.With("protocol", "mysql").Add(1)
+	h.sm.connLogger(c.ConnectionID).Infof("NewConnection: client %v", c.ConnectionID)
+}
+
+// connection returns the connection with the given id.
+func (h *Handler) connection(id uint32) (*mysql.Conn, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.c[id]
+	return c.MysqlConn, ok
 }
 
 // ConnectionClosed reports that a connection has been closed.
 func (h *Handler) ConnectionClosed(c *mysql.Conn) {
+	ConnectionsGauge.With("protocol", "mysql").Add(-1)
 	h.sm.CloseConn(c)
 
 	h.mu.Lock()
 	delete(h.c, c.ConnectionID)
 	h.mu.Unlock()
 
+	if h.limits != nil {
+		h.limits.releaseUser(c.User)
+	}
+
+	if c.User != "" {
+		h.auditDisconnect(c.ConnectionID, c.User, c.RemoteAddr())
+	}
+
 	// If connection was closed, kill only its associated queries.
 	h.e.Catalog.ProcessList.KillOnlyQueries(c.ConnectionID)
 
 	if err := h.e.Catalog.UnlockTables(nil, c.ConnectionID); err != nil {
-		logrus.Errorf("unable to unlock tables on session close: %s", err)
+		h.sm.connLogger(c.ConnectionID).Errorf("unable to unlock tables on session close: %s", err)
 	}
 
-	logrus.Infof("ConnectionClosed: client %v", c.ConnectionID)
+	h.sm.connLogger(c.ConnectionID).Infof("ConnectionClosed: client %v", c.ConnectionID)
+}
+
+// shutdown makes the handler reject the new queries, as the server is
+// shutting down.
+func (h *Handler) shutdown() {
+	h.mu.Lock()
+	h.closing = true
+	h.mu.Unlock()
+}
+
+// checkShutdown returns an ER_SERVER_SHUTDOWN error if the server is
+// shutting down, to reject a new query.
+func (h *Handler) checkShutdown() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closing {
+		return mysql.NewSQLError(mysql.ERServerShutdown, mysql.SSServerShutdown, "Server shutdown in progress")
+	}
+	return nil
+}
+
+// waitQueries waits until no queries are running or ctx is done, in which
+// case its error is returned.
+func (h *Handler) waitQueries(ctx context.Context) error {
+	ticker := time.NewTicker(shutdownCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		running := false
+		for _, p := range h.e.Catalog.ProcessList.Processes() {
+			if p.Type == sql.QueryProcess {
+				running = true
+				break
+			}
+		}
+
+		if !running {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeConnections kills the running queries and closes the connections
+// of the clients, which ends their sessions.
+func (h *Handler) closeConnections() {
+	for _, p := range h.e.Catalog.ProcessList.Processes() {
+		h.e.Catalog.ProcessList.KillOnlyQueries(p.Connection)
+	}
+
+	h.mu.Lock()
+	conns := make([]*mysql.Conn, 0, len(h.c))
+	for _, c := range h.c {
+		conns = append(conns, c.MysqlConn)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close()
+	}
 }
 
 // ComQuery executes a SQL query on the SQLe engine.
@@ -112,15 +255,27 @@ func (h *Handler) ComQuery(
 	query string,
 	callback func(*sqltypes.Result) error,
 ) (err error) {
+	defer func() { err = sqlError(err) }()
+
+	if err := h.checkShutdown(); err != nil {
+		return err
+	}
+
 	ctx := h.sm.NewContextWithQuery(c, query)
+	ctx.SetState(okInfoKey{}, nil)
 
+	var cancel context.CancelFunc = func() {}
 	if !h.e.Async(ctx, query) {
-		newCtx, cancel := context.WithCancel(ctx)
+		var newCtx context.Context
+		newCtx, cancel = context.WithCancel(ctx)
 		ctx = ctx.WithContext(newCtx)
 
 		defer cancel()
 	}
 
+	audit := h.auditQuery(ctx)
+	defer func() { audit.done(err) }()
+
 	handled, err := h.handleKill(c, query)
 	if err != nil {
 		return err
@@ -130,19 +285,35 @@ func (h *Handler) ComQuery(
 		return callback(&sqltypes.Result{})
 	}
 
-	start := time.Now()
-	schema, rows, err := h.e.Query(ctx, query)
-	defer func() {
-		if q, ok := h.e.Auth.(*auth.Audit); ok {
-			q.Query(ctx, time.Since(start), err)
-		}
-	}()
+	schema, rows, err := audit.query(query)
 	if err != nil {
 		return err
 	}
 
+	// The statements that change data are answered with an OK packet
+	// with the rows affected instead of a result set.
+	if n := dataChange(audit.plan); n != nil {
+		foundRows := c.Capabilities&mysql.CapabilityClientFoundRows != 0
+		r, err := dataChangeResult(ctx, n, rows, foundRows)
+		if err != nil {
+			return err
+		}
+
+		h.updateStatus(c)
+		if r.info != "" {
+			ctx.SetState(okInfoKey{}, r.info)
+		}
+		return callback(&sqltypes.Result{RowsAffected: r.affected, InsertID: r.insertID})
+	}
+
+	h.updateStatus(c)
+	fields := h.resultFields(ctx, audit.plan)
+
 	nc, ok := h.c[c.ConnectionID]
 	if !ok {
+		if err := rows.Close(); err != nil {
+			ctx.Logger().Errorf("unable to close the rows of an aborted query: %s", err)
+		}
 		return ErrConnectionWasClosed.New()
 	}
 
@@ -155,6 +326,24 @@ func (h *Handler) ComQuery(
 	errChan := make(chan error)
 	// To close the goroutines
 	quit := make(chan struct{})
+	// Closed when the goroutine reading rows exits
+	readerDone := make(chan struct{})
+
+	// abort stops reading the rows when the query fails, the client goes
+	// away or it takes too long to read a row. The query is cancelled so
+	// the iterators stop, and its rows are closed once the goroutine
+	// reading them exits, without making the client wait for it.
+	abort := func(err error) error {
+		close(quit)
+		cancel()
+		go func() {
+			<-readerDone
+			if cerr := rows.Close(); cerr != nil {
+				ctx.Logger().Errorf("unable to close the rows of an aborted query: %s", cerr)
+			}
+		}()
+		return err
+	}
 
 	// Default waitTime is one minute if there is not timeout configured, in which case
 	// it will loop to iterate again unless the socket died by the OS timeout or other problems.
@@ -171,17 +360,30 @@ func (h *Handler) ComQuery(
 	// This goroutine will be select{}ed giving a chance to Vitess to call the
 	// handler.CloseConnection callback and enforcing the timeout if configured
 	go func() {
+		defer close(readerDone)
+		batches := sql.NewRowBatchIter(rows)
+		batch := make([]sql.Row, rowsBatch)
 		for {
 			select {
 			case <-quit:
 				return
 			default:
-				row, err := rows.Next()
+				n, err := batches.NextBatch(batch)
 				if err != nil {
-					errChan <- err
+					select {
+					case errChan <- err:
+					case <-quit:
+					}
 					return
 				}
-				rowChan <- row
+
+				for _, row := range batch[:n] {
+					select {
+					case rowChan <- row:
+					case <-quit:
+						return
+					}
+				}
 			}
 		}
 	}()
@@ -192,23 +394,26 @@ func (h *Handler) ComQuery(
 	go func() {
 		tcpConn, ok := nc.NetConn.(*net.TCPConn)
 		if !ok {
-			logrus.Debug("Connection checker exiting, connection isn't TCP")
+			ctx.Logger().Debug("Connection checker exiting, connection isn't TCP")
 			return
 		}
 
 		inode, err := sockstate.GetConnInode(tcpConn)
 		if err != nil || inode == 0 {
 			if sockstate.ErrSocketCheckNotImplemented.Is(err) {
-				logrus.Warn("Connection checker exiting, not supported in this OS")
+				ctx.Logger().Warn("Connection checker exiting, not supported in this OS")
 			} else {
-				errChan <- err
+				select {
+				case errChan <- err:
+				case <-quit:
+				}
 			}
 			return
 		}
 
 		t, ok := nc.NetConn.LocalAddr().(*net.TCPAddr)
 		if !ok {
-			logrus.Warn("Connection checker exiting, could not get local port")
+			ctx.Logger().Warn("Connection checker exiting, could not get local port")
 			return
 		}
 
@@ -222,10 +427,16 @@ func (h *Handler) ComQuery(
 			st, err := sockstate.GetInodeSockState(t.Port, inode)
 			switch st {
 			case sockstate.Broken:
-				errChan <- ErrConnectionWasClosed.New()
+				select {
+				case errChan <- ErrConnectionWasClosed.New():
+				case <-quit:
+				}
 				return
 			case sockstate.Error:
-				errChan <- err
+				select {
+				case errChan <- err:
+				case <-quit:
+				}
 				return
 			default: // Established
 				// (juanjux) this check is not free, each iteration takes about 9 milliseconds to run on my machine
@@ -238,13 +449,12 @@ func (h *Handler) ComQuery(
 rowLoop:
 	for {
 		if r == nil {
-			r = &sqltypes.Result{Fields: schemaToFields(schema)}
+			r = &sqltypes.Result{Fields: fields}
 		}
 
 		if r.RowsAffected == rowsBatch {
 			if err := callback(r); err != nil {
-				close(quit)
-				return err
+				return abort(err)
 			}
 
 			r = nil
@@ -257,13 +467,11 @@ rowLoop:
 			if err == io.EOF {
 				break rowLoop
 			}
-			close(quit)
-			return err
+			return abort(err)
 		case row := <-rowChan:
 			outputRow, err := rowToSQL(schema, row)
 			if err != nil {
-				close(quit)
-				return err
+				return abort(err)
 			}
 
 			r.Rows = append(r.Rows, outputRow)
@@ -271,8 +479,7 @@ rowLoop:
 		case <-timer.C:
 			if h.readTimeout != 0 {
 				// Cancel and return so Vitess can call the CloseConnection callback
-				close(quit)
-				return ErrRowTimeout.New()
+				return abort(ErrRowTimeout.New())
 			}
 		}
 		timer.Reset(waitTime)
@@ -334,7 +541,7 @@ func (h *Handler) handleKill(conn *mysql.Conn, query string) (bool, error) {
 	connID := uint32(id)
 	h.e.Catalog.Kill(connID)
 	if s[1] != "query" {
-		logrus.Infof("kill connection: id %d", connID)
+		h.sm.connLogger(connID).Infof("kill connection: id %d", connID)
 
 		h.mu.Lock()
 		c, ok := h.c[connID]
@@ -366,20 +573,102 @@ func rowToSQL(s sql.Schema, row sql.Row) ([]sqltypes.Value, error) {
 	return o, nil
 }
 
+// notFixedDecimals are the decimals of the floating point columns, whose
+// number of decimals is not fixed.
+const notFixedDecimals = 31
+
 func schemaToFields(s sql.Schema) []*query.Field {
 	fields := make([]*query.Field, len(s))
 	for i, c := range s {
 		var charset uint32 = mysql.CharacterSetUtf8
+		length := uint64(sql.DisplayLength(c.Type))
 		if c.Type == sql.Blob {
 			charset = mysql.CharacterSetBinary
+		} else if sql.IsText(c.Type) && c.Type != sql.JSON {
+			// The length is in bytes, and utf8 characters take up to 3.
+			length *= 3
+		}
+
+		if length > math.MaxUint32 {
+			length = math.MaxUint32
+		}
+
+		decimals := uint32(sql.Precision(c.Type))
+		if sql.IsDecimal(c.Type) {
+			decimals = notFixedDecimals
+		}
+
+		_, flags := sqltypes.TypeToMySQL(c.Type.Type())
+		if sql.IsNumber(c.Type) {
+			flags |= int64(query.MySqlFlag_NUM_FLAG)
+		}
+		if c.Type == sql.Text || c.Type == sql.Blob || c.Type == sql.JSON {
+			flags |= int64(query.MySqlFlag_BLOB_FLAG)
+		}
+		if !c.Nullable {
+			flags |= int64(query.MySqlFlag_NOT_NULL_FLAG)
+		}
+		if c.PrimaryKey {
+			flags |= int64(query.MySqlFlag_PRI_KEY_FLAG)
+		}
+		if c.AutoIncrement {
+			flags |= int64(query.MySqlFlag_AUTO_INCREMENT_FLAG)
 		}
 
 		fields[i] = &query.Field{
-			Name:    c.Name,
-			Type:    c.Type.Type(),
-			Charset: charset,
+			Name:         c.Name,
+			Type:         c.Type.Type(),
+			Charset:      charset,
+			ColumnLength: uint32(length),
+			Decimals:     decimals,
+			Flags:        uint32(flags),
+		}
+	}
+
+	return fields
+}
+
+// resultFields returns the fields of the result set of a plan, with the
+// databases, tables and columns they come from, which drivers use to map
+// their types and to update the rows of the result sets.
+func (h *Handler) resultFields(ctx *sql.Context, n sql.Node) []*query.Field {
+	fields := schemaToFields(n.Schema())
+	for i, origin := range plan.ColumnOrigins(n) {
+		if origin == nil || i >= len(fields) {
+			continue
+		}
+
+		f := fields[i]
+		f.Database = h.tableDatabase(ctx, origin.OriginalTable)
+		f.Table = origin.Table
+		f.OrgTable = origin.OriginalTable
+		f.OrgName = origin.Column.Name
+		if origin.Column.PrimaryKey {
+			f.Flags |= uint32(query.MySqlFlag_PRI_KEY_FLAG)
+		}
+		if origin.Column.AutoIncrement {
+			f.Flags |= uint32(query.MySqlFlag_AUTO_INCREMENT_FLAG)
 		}
 	}
 
 	return fields
 }
+
+// tableDatabase returns the database of a table used by a query, which is
+// the current database if it has a table with that name, or the first
+// other database that has it. It's empty if there is none, as for the
+// tables that are not in the catalog.
+func (h *Handler) tableDatabase(ctx *sql.Context, table string) string {
+	catalog := h.e.Catalog
+	current := catalog.CurrentDatabase()
+	if _, err := catalog.ResolveTable(ctx, current, table); err == nil {
+		return current
+	}
+
+	for _, db := range catalog.AllDatabases() {
+		if _, err := catalog.ResolveTable(ctx, db.Name(), table); err == nil {
+			return db.Name()
+		}
+	}
+	return ""
+}
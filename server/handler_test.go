@@ -8,6 +8,7 @@ import (
 
 	sqle "github.com/src-d/go-mysql-server"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
 
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/sqltypes"
@@ -197,21 +198,97 @@ func TestSchemaToFields(t *testing.T) {
 	require := require.New(t)
 
 	schema := sql.Schema{
-		{Name: "foo", Type: sql.Blob},
-		{Name: "bar", Type: sql.Text},
-		{Name: "baz", Type: sql.Int64},
+		{Name: "foo", Type: sql.Blob, Nullable: true},
+		{Name: "bar", Type: sql.VarChar(10), Nullable: true},
+		{Name: "baz", Type: sql.Int64, PrimaryKey: true, AutoIncrement: true},
+		{Name: "qux", Type: sql.Uint8},
+		{Name: "quux", Type: sql.Float64, Nullable: true},
+		{Name: "corge", Type: sql.DatetimeWithPrecision(3), Nullable: true},
 	}
 
 	expected := []*query.Field{
-		{Name: "foo", Type: query.Type_BLOB, Charset: mysql.CharacterSetBinary},
-		{Name: "bar", Type: query.Type_TEXT, Charset: mysql.CharacterSetUtf8},
-		{Name: "baz", Type: query.Type_INT64, Charset: mysql.CharacterSetUtf8},
+		{Name: "foo", Type: query.Type_BLOB, Charset: mysql.CharacterSetBinary, ColumnLength: 65535, Flags: uint32(query.MySqlFlag_BLOB_FLAG | query.MySqlFlag_BINARY_FLAG)},
+		{Name: "bar", Type: query.Type_VARCHAR, Charset: mysql.CharacterSetUtf8, ColumnLength: 30},
+		{Name: "baz", Type: query.Type_INT64, Charset: mysql.CharacterSetUtf8, ColumnLength: 20, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_PRI_KEY_FLAG | query.MySqlFlag_AUTO_INCREMENT_FLAG | query.MySqlFlag_NUM_FLAG)},
+		{Name: "qux", Type: query.Type_UINT8, Charset: mysql.CharacterSetUtf8, ColumnLength: 3, Flags: uint32(query.MySqlFlag_NOT_NULL_FLAG | query.MySqlFlag_UNSIGNED_FLAG | query.MySqlFlag_NUM_FLAG)},
+		{Name: "quux", Type: query.Type_FLOAT64, Charset: mysql.CharacterSetUtf8, ColumnLength: 22, Decimals: 31, Flags: uint32(query.MySqlFlag_NUM_FLAG)},
+		{Name: "corge", Type: query.Type_DATETIME, Charset: mysql.CharacterSetUtf8, ColumnLength: 23, Decimals: 3, Flags: uint32(query.MySqlFlag_BINARY_FLAG)},
 	}
 
 	fields := schemaToFields(schema)
 	require.Equal(expected, fields)
 }
 
+func TestResultFields(t *testing.T) {
+	require := require.New(t)
+
+	e := setupMemDB(require)
+	ctx := sql.NewEmptyContext()
+	_, iter, err := e.Query(ctx, "CREATE TABLE users (id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, name TEXT)")
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	handler := NewHandler(
+		e,
+		NewSessionManager(
+			testSessionBuilder,
+			opentracing.NoopTracer{},
+			sql.NewMemoryManager(nil),
+			"foo",
+		),
+		0,
+	)
+	conn := &mysql.Conn{ConnectionID: 1}
+	handler.NewConnection(conn)
+
+	var fields []*query.Field
+	err = handler.ComQuery(conn, "SELECT u.id AS uid, name, UPPER(name) FROM users u", func(res *sqltypes.Result) error {
+		fields = res.Fields
+		return nil
+	})
+	require.NoError(err)
+	require.Len(fields, 3)
+
+	require.Equal("uid", fields[0].Name)
+	require.Equal("id", fields[0].OrgName)
+	require.Equal("u", fields[0].Table)
+	require.Equal("users", fields[0].OrgTable)
+	require.Equal("test", fields[0].Database)
+	require.NotZero(fields[0].Flags & uint32(query.MySqlFlag_PRI_KEY_FLAG))
+	require.NotZero(fields[0].Flags & uint32(query.MySqlFlag_AUTO_INCREMENT_FLAG))
+	require.NotZero(fields[0].Flags & uint32(query.MySqlFlag_NOT_NULL_FLAG))
+
+	require.Equal("name", fields[1].OrgName)
+	require.Equal("users", fields[1].OrgTable)
+	require.Equal(uint32(196605), fields[1].ColumnLength)
+	require.Zero(fields[1].Flags & uint32(query.MySqlFlag_NOT_NULL_FLAG))
+
+	require.Equal("", fields[2].OrgName)
+	require.Equal("", fields[2].OrgTable)
+	require.Equal("", fields[2].Database)
+}
+
+func TestSQLError(t *testing.T) {
+	require := require.New(t)
+
+	err := sqlError(sql.ErrValueOutOfRange.New("a", 2))
+	require.IsType(&mysql.SQLError{}, err)
+	require.Equal(erWarnDataOutOfRange, err.(*mysql.SQLError).Number())
+	require.Equal(mysql.SSDataOutOfRange, err.(*mysql.SQLError).SQLState())
+
+	err = sqlError(sql.ErrDataTooLong.New("a", 1))
+	require.Equal(mysql.ERDataTooLong, err.(*mysql.SQLError).Number())
+
+	err = sqlError(analyzer.ErrValidationGroupBy.New("a"))
+	require.Equal(mysql.ERWrongFieldWithGroup, err.(*mysql.SQLError).Number())
+	require.Equal(ssSyntaxErrorOrAccessViolation, err.(*mysql.SQLError).SQLState())
+
+	other := fmt.Errorf("other")
+	require.Equal(other, sqlError(other))
+	require.Nil(sqlError(nil))
+}
+
 func TestHandlerTimeout(t *testing.T) {
 	require := require.New(t)
 
@@ -245,6 +322,14 @@ func TestHandlerTimeout(t *testing.T) {
 	})
 	require.EqualError(err, "row read wait bigger than connection timeout")
 
+	// The aborted query is cancelled and removed from the process list
+	// without waiting for it to finish.
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for len(e.Catalog.Processes()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Len(e.Catalog.Processes(), 0)
+
 	err = timeOutHandler.ComQuery(connTimeout, "SELECT SLEEP(0.5)", func(res *sqltypes.Result) error {
 		return nil
 	})
@@ -62,16 +62,18 @@ func testServer(t *testing.T, ready chan struct{}, port string, breakConn bool)
 		return
 	}
 
-	if !breakConn {
-		defer func() {
-			_ = conn.Close()
-		}()
+	defer func() {
+		_ = conn.Close()
+	}()
 
+	// Closing the connection without reading it leaves the socket of the
+	// client in CLOSE_WAIT, which the connection checker detects.
+	if !breakConn {
 		_, err = ioutil.ReadAll(conn)
 		if err != nil {
 			t.Fatal(err)
 		}
-	} // else: dirty return without closing or reading to force the socket into TIME_WAIT
+	}
 }
 func okTestServer(t *testing.T, ready chan struct{}, port string) {
 	testServer(t, ready, port, false)
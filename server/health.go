@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// ServerStatus is the health of a server, as reported to the liveness and
+// readiness probes of orchestrators.
+type ServerStatus struct {
+	sqle.Status
+	// ShuttingDown is whether the server is shutting down, so it does not
+	// accept new queries.
+	ShuttingDown bool
+	// Connections is the number of clients connected with the MySQL and X
+	// protocols.
+	Connections int
+	// Replica is the status of the replica of the configuration, if any.
+	Replica *ReplicaStatus
+}
+
+// Status returns the health of the server. It's ready when its engine is,
+// it's not shutting down and its replica, if any, is running and not
+// further behind its primary than MaxReplicaLag. The health checks of the
+// databases must return once ctx is done.
+func (s *Server) Status(ctx context.Context) ServerStatus {
+	h := s.h
+	status := ServerStatus{
+		Status: h.e.Status(sql.NewContext(ctx, sql.WithLogger(h.sm.logger))),
+	}
+
+	h.mu.Lock()
+	status.ShuttingDown = h.closing
+	status.Connections = len(h.c)
+	h.mu.Unlock()
+
+	if s.x != nil {
+		s.x.mu.Lock()
+		status.Connections += len(s.x.conns)
+		s.x.mu.Unlock()
+	}
+
+	if status.ShuttingDown {
+		status.Ready = false
+	}
+
+	if s.replica != nil {
+		r := s.replica.Status()
+		status.Replica = &r
+		if !r.Running || (s.maxReplicaLag > 0 && r.Lag > s.maxReplicaLag) {
+			status.Ready = false
+		}
+	}
+
+	return status
+}
+
+// healthCheckTimeout is the time the health checks of the databases have
+// to finish when the status is requested to the health endpoint.
+const healthCheckTimeout = 10 * time.Second
+
+// newHealthServer creates a server of the health of the given server, whose
+// liveness is reported at /healthz and readiness at /readyz. Both respond
+// with the status as JSON, with a 503 status code if the server is not
+// live or ready.
+func newHealthServer(address string, s *Server, logger logrus.FieldLogger) (*endpointServer, error) {
+	status := func(r *http.Request) ServerStatus {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+		return s.Status(ctx)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status := status(r)
+		writeHealth(w, status, !status.ShuttingDown, logger)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := status(r)
+		writeHealth(w, status, status.Ready, logger)
+	})
+	return newEndpointServer("health", address, mux, logger)
+}
+
+// healthDocument is the JSON document of the status of a server.
+type healthDocument struct {
+	Ready           bool           `json:"ready"`
+	ShuttingDown    bool           `json:"shutting_down"`
+	Connections     int            `json:"connections"`
+	Databases       []string       `json:"databases"`
+	NotReadyIndexes int            `json:"not_ready_indexes"`
+	Checks          []healthCheck  `json:"checks,omitempty"`
+	Replica         *healthReplica `json:"replica,omitempty"`
+}
+
+type healthCheck struct {
+	Database string  `json:"database"`
+	Duration float64 `json:"duration"`
+	Error    string  `json:"error,omitempty"`
+}
+
+type healthReplica struct {
+	Running  bool    `json:"running"`
+	File     string  `json:"file"`
+	Position uint32  `json:"position"`
+	Lag      float64 `json:"lag"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func writeHealth(w http.ResponseWriter, s ServerStatus, ok bool, logger logrus.FieldLogger) {
+	doc := healthDocument{
+		Ready:           s.Ready,
+		ShuttingDown:    s.ShuttingDown,
+		Connections:     s.Connections,
+		Databases:       s.Databases,
+		NotReadyIndexes: s.NotReadyIndexes,
+	}
+	for _, c := range s.Checks {
+		check := healthCheck{Database: c.Database, Duration: c.Duration.Seconds()}
+		if c.Err != nil {
+			check.Error = c.Err.Error()
+		}
+		doc.Checks = append(doc.Checks, check)
+	}
+	if r := s.Replica; r != nil {
+		doc.Replica = &healthReplica{
+			Running:  r.Running,
+			File:     r.File,
+			Position: r.Position,
+			Lag:      r.Lag.Seconds(),
+		}
+		if r.LastError != nil {
+			doc.Replica.Error = r.LastError.Error()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		logger.Debugf("unable to send the health of the server: %s", err)
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+// checkedDatabase is a database whose backend cannot be reached while err
+// is set.
+type checkedDatabase struct {
+	*memory.Database
+	err error
+}
+
+func (d *checkedDatabase) CheckHealth(*sql.Context) error {
+	return d.err
+}
+
+func TestServerHealth(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+	healthPort, err := getFreePort()
+	require.NoError(err)
+
+	e := setupMemDB(require)
+	remote := &checkedDatabase{Database: memory.NewDatabase("remote"), err: errors.New("connection refused")}
+	e.AddDatabase(remote)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:      "tcp",
+		Address:       "localhost:" + port,
+		Auth:          new(auth.None),
+		HealthAddress: "localhost:" + healthPort,
+	}, e)
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	get := func(path string) (int, healthDocument) {
+		resp, err := http.Get("http://localhost:" + healthPort + path)
+		require.NoError(err)
+		defer resp.Body.Close()
+		require.Equal("application/json", resp.Header.Get("Content-Type"))
+
+		var doc healthDocument
+		require.NoError(json.NewDecoder(resp.Body).Decode(&doc))
+		return resp.StatusCode, doc
+	}
+
+	code, doc := get("/readyz")
+	require.Equal(http.StatusServiceUnavailable, code)
+	require.False(doc.Ready)
+	require.Equal([]string{"test", "remote"}, doc.Databases)
+	require.Len(doc.Checks, 1)
+	require.Equal("remote", doc.Checks[0].Database)
+	require.Equal("connection refused", doc.Checks[0].Error)
+
+	// The server is live even if it's not ready.
+	code, _ = get("/healthz")
+	require.Equal(http.StatusOK, code)
+
+	remote.err = nil
+	code, doc = get("/readyz")
+	require.Equal(http.StatusOK, code)
+	require.True(doc.Ready)
+	require.Empty(doc.Checks[0].Error)
+
+	s.h.shutdown()
+	code, doc = get("/healthz")
+	require.Equal(http.StatusServiceUnavailable, code)
+	require.True(doc.ShuttingDown)
+	require.False(doc.Ready)
+}
+
+func TestServerStatusReplica(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	e := setupMemDB(require)
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		Replica:  NewReplica(ReplicaConfig{Host: "localhost", Port: 1, File: "binlog.000001", Position: 4}, e),
+	}, e)
+	require.NoError(err)
+	defer s.Close()
+
+	// A replica that is not running makes the server not ready.
+	status := s.Status(sql.NewEmptyContext())
+	require.False(status.Ready)
+	require.NotNil(status.Replica)
+	require.False(status.Replica.Running)
+	require.Equal("binlog.000001", status.Replica.File)
+	require.Equal(0, status.Connections)
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// ErrHTTPFormat is returned when the results of the HTTP endpoint are
+// requested in a format that is not supported.
+var ErrHTTPFormat = errors.NewKind("unsupported result format: %s")
+
+// maxHTTPQuery is the maximum size of the body of the HTTP requests.
+const maxHTTPQuery = 64 << 20
+
+// Formats of the results of the HTTP endpoint.
+const (
+	httpFormatJSON  = "json"
+	httpFormatCSV   = "csv"
+	httpFormatArrow = "arrow"
+)
+
+// httpArrowType is the media type of the results in the Arrow streaming
+// format.
+const httpArrowType = "application/vnd.apache.arrow.stream"
+
+// httpServer serves the HTTP endpoint that runs queries, for the clients
+// that cannot use the MySQL protocol. Every request runs a query in a new
+// session, with the user given with basic authentication.
+type httpServer struct {
+	listener net.Listener
+	server   *http.Server
+	sessions *requestSessions
+}
+
+// httpQuery is the body of the requests with a JSON content type.
+type httpQuery struct {
+	Query    string `json:"query"`
+	Database string `json:"database"`
+	Format   string `json:"format"`
+}
+
+// httpError is the JSON encoding of an error.
+type httpError struct {
+	Code    int    `json:"code"`
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// httpColumn is the JSON encoding of a column of the results.
+type httpColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func newHTTPServer(address string, h *Handler, a mysql.AuthServer) (*httpServer, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &httpServer{listener: l, sessions: newRequestSessions(h, a)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/query", s.query)
+	s.server = &http.Server{Handler: mux}
+	return s, nil
+}
+
+// serve handles the requests until the server is closed.
+func (s *httpServer) serve() {
+	err := s.server.Serve(s.listener)
+	if err != nil && err != http.ErrServerClosed {
+		s.sessions.h.sm.logger.Errorf("unable to serve HTTP requests: %s", err)
+	}
+}
+
+// close stops accepting requests and closes the open connections, which
+// cancels their queries.
+func (s *httpServer) close() error {
+	return s.server.Close()
+}
+
+// shutdown stops accepting requests and waits for the running ones to
+// finish until ctx is done, when the connections are closed.
+func (s *httpServer) shutdown(ctx context.Context) {
+	if err := s.server.Shutdown(ctx); err != nil {
+		_ = s.server.Close()
+	}
+}
+
+// query handles the requests to /query, which have the query in the body,
+// either as text or as the query field of a JSON object, which can also
+// have the database the query is run at and the format of the results.
+// They can also be given with the database and format parameters of the
+// URL, and the format with the Accept header.
+func (s *httpServer) query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := readHTTPQuery(r)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, password, _ := r.BasicAuth()
+	sess, err := s.sessions.open(r.Context(), r.RemoteAddr, user, password)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="go-mysql-server"`)
+		writeHTTPError(w, http.StatusUnauthorized, err)
+		return
+	}
+	defer sess.close()
+
+	if q.Database != "" {
+		if err := sess.use(q.Database); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var started bool
+	err = sess.query(q.Query, func(schema sql.Schema, iter sql.RowIter) (err error) {
+		switch q.Format {
+		case httpFormatCSV:
+			started, err = writeHTTPResults(w, "text/csv", func(w io.Writer) error {
+				return sqle.WriteCSV(w, schema, iter)
+			})
+		case httpFormatArrow:
+			started, err = writeHTTPResults(w, httpArrowType, func(w io.Writer) error {
+				return sqle.WriteArrow(w, schema, iter, 0)
+			})
+		default:
+			started, err = writeHTTPJSON(w, schema, iter)
+		}
+		return err
+	})
+
+	switch {
+	case err == nil:
+	case started:
+		s.sessions.h.sm.connLogger(sess.id).Debugf("unable to send the results of an HTTP query: %s", err)
+	default:
+		writeHTTPError(w, http.StatusBadRequest, err)
+	}
+}
+
+// readHTTPQuery reads the query of a request.
+func readHTTPQuery(r *http.Request) (*httpQuery, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxHTTPQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	q := &httpQuery{
+		Database: r.URL.Query().Get("database"),
+		Format:   r.URL.Query().Get("format"),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(body, q); err != nil {
+			return nil, err
+		}
+	} else {
+		q.Query = string(body)
+	}
+
+	if strings.TrimSpace(q.Query) == "" {
+		return nil, ErrEmptyQuery.New()
+	}
+
+	if q.Format == "" {
+		q.Format = httpFormatJSON
+		switch accept := r.Header.Get("Accept"); {
+		case strings.Contains(accept, "text/csv"):
+			q.Format = httpFormatCSV
+		case strings.Contains(accept, httpArrowType):
+			q.Format = httpFormatArrow
+		}
+	}
+
+	switch q.Format {
+	case httpFormatJSON, httpFormatCSV, httpFormatArrow:
+	default:
+		return nil, ErrHTTPFormat.New(q.Format)
+	}
+
+	return q, nil
+}
+
+// writeHTTPError sends an error with its MySQL code.
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	e := newHTTPError(err)
+	switch {
+	case auth.ErrNotAuthorized.Is(err) || auth.ErrNoPermission.Is(err):
+		status = http.StatusForbidden
+	case e.Code == mysql.ERServerShutdown:
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]httpError{"error": e})
+}
+
+func newHTTPError(err error) httpError {
+	code, state, msg := xError(err)
+	return httpError{Code: int(code), State: state, Message: msg}
+}
+
+// writeHTTPJSON sends the rows as a JSON object with the columns and the
+// rows, which are arrays of values, returning whether the response was
+// started. The rows are sent as they are read, so if reading one fails the
+// error is added to the object.
+func writeHTTPJSON(w http.ResponseWriter, schema sql.Schema, iter sql.RowIter) (bool, error) {
+	row, err := iter.Next()
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	columns := make([]httpColumn, len(schema))
+	for i, col := range schema {
+		columns[i] = httpColumn{Name: col.Name, Type: col.Type.Type().String()}
+	}
+
+	data, jerr := json.Marshal(columns)
+	if jerr != nil {
+		return false, jerr
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{"columns":`)
+	bw.Write(data)
+	bw.WriteString(`,"rows":[`)
+
+	for n := 0; err == nil; n++ {
+		var values []sqltypes.Value
+		if values, err = rowToSQL(schema, row); err != nil {
+			break
+		}
+
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteByte('[')
+		for i, v := range values {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(sqle.JSONValue(schema[i].Type, v))
+		}
+		bw.WriteByte(']')
+
+		row, err = iter.Next()
+	}
+	bw.WriteByte(']')
+
+	if err != io.EOF {
+		data, _ := json.Marshal(newHTTPError(err))
+		bw.WriteString(`,"error":`)
+		bw.Write(data)
+	} else {
+		err = nil
+	}
+	bw.WriteString("}\n")
+
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return true, err
+}
+
+// writeHTTPResults sends the rows with an encoder of the engine,
+// returning whether the response was started. The encoders write nothing
+// if reading the first row fails, so the error can still be sent, and
+// truncate the results if reading any other fails.
+func writeHTTPResults(w http.ResponseWriter, contentType string, write func(io.Writer) error) (bool, error) {
+	rw := &httpResultsWriter{ResponseWriter: w, contentType: contentType}
+	err := write(rw)
+	return rw.started, err
+}
+
+// httpResultsWriter sets the content type of a response when it's
+// started.
+type httpResultsWriter struct {
+	http.ResponseWriter
+	contentType string
+	started     bool
+}
+
+func (w *httpResultsWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.Header().Set("Content-Type", w.contentType)
+	}
+	return w.ResponseWriter.Write(p)
+}
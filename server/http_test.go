@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+func newHTTPTestServer(t *testing.T, a auth.Auth) (string, func()) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+	httpPort, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:    "tcp",
+		Address:     "localhost:" + port,
+		HTTPAddress: "localhost:" + httpPort,
+		Auth:        a,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+
+	return "http://localhost:" + httpPort + "/query", func() { s.Close() }
+}
+
+type httpTestResult struct {
+	Columns []httpColumn    `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+	Error   *httpError      `json:"error"`
+}
+
+func httpTestQuery(
+	t *testing.T,
+	req *http.Request,
+) (int, string, string) {
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+}
+
+func TestHTTPQuery(t *testing.T) {
+	require := require.New(t)
+	url, done := newHTTPTestServer(t, auth.NewNativeSingle("user", "pass", auth.AllPermissions))
+	defer done()
+
+	post := func(contentType, body string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		require.NoError(err)
+		req.Header.Set("Content-Type", contentType)
+		req.SetBasicAuth("user", "pass")
+		return req
+	}
+
+	req := post("application/json", `{"query": "SELECT c1, 'a' AS s, NULL AS n FROM test WHERE c1 < 2 ORDER BY c1", "database": "test"}`)
+	status, typ, body := httpTestQuery(t, req)
+	require.Equal(http.StatusOK, status, body)
+	require.Equal("application/json", typ)
+
+	var result httpTestResult
+	require.NoError(json.Unmarshal([]byte(body), &result))
+	require.Equal([]httpColumn{{"c1", "INT32"}, {"s", "TEXT"}, {"n", "NULL_TYPE"}}, result.Columns)
+	require.Equal([][]interface{}{{float64(0), "a", nil}, {float64(1), "a", nil}}, result.Rows)
+	require.Nil(result.Error)
+
+	req = post("text/plain", "SELECT c1, 'a,b' AS s FROM test.test WHERE c1 = 5")
+	req.Header.Set("Accept", "text/csv")
+	status, typ, body = httpTestQuery(t, req)
+	require.Equal(http.StatusOK, status, body)
+	require.Equal("text/csv", typ)
+	require.Equal("c1,s\n5,\"a,b\"\n", body)
+
+	req = post("text/plain", "SELECT c1 FROM test.test WHERE c1 = 5")
+	req.Header.Set("Accept", httpArrowType)
+	status, typ, body = httpTestQuery(t, req)
+	require.Equal(http.StatusOK, status, body)
+	require.Equal(httpArrowType, typ)
+	require.True(strings.HasPrefix(body, "\xff\xff\xff\xff"))
+	require.True(strings.HasSuffix(body, "\xff\xff\xff\xff\x00\x00\x00\x00"))
+
+	req = post("text/plain", "SELECT * FROM nope")
+	status, _, body = httpTestQuery(t, req)
+	require.Equal(http.StatusBadRequest, status)
+
+	var errResult struct{ Error httpError }
+	require.NoError(json.Unmarshal([]byte(body), &errResult))
+	require.Contains(errResult.Error.Message, "nope")
+
+	req = post("text/plain", " ")
+	status, _, _ = httpTestQuery(t, req)
+	require.Equal(http.StatusBadRequest, status)
+
+	req = post("text/plain", "SELECT 1")
+	req.URL.RawQuery = "format=xml"
+	status, _, _ = httpTestQuery(t, req)
+	require.Equal(http.StatusBadRequest, status)
+
+	req = post("text/plain", "SELECT 1")
+	req.SetBasicAuth("user", "wrong")
+	status, _, body = httpTestQuery(t, req)
+	require.Equal(http.StatusUnauthorized, status)
+	errResult.Error = httpError{}
+	require.NoError(json.Unmarshal([]byte(body), &errResult))
+	require.Equal(mysql.ERAccessDeniedError, errResult.Error.Code)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(err)
+	status, _, _ = httpTestQuery(t, req)
+	require.Equal(http.StatusMethodNotAllowed, status)
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"vitess.io/vitess/go/mysql"
+)
+
+const (
+	tooManyConnectionsState     = "08004"
+	tooManyUserConnectionsState = "42000"
+)
+
+// connectionLimits limits the number of clients connected to the server, in
+// total and by user.
+type connectionLimits struct {
+	maxConnections     int
+	maxUserConnections int
+	userConnections    map[string]int
+
+	mu          sync.Mutex
+	connections int
+	users       map[string]int
+}
+
+// newConnectionLimits returns the connection limits of the configuration,
+// or nil if it has none.
+func newConnectionLimits(cfg Config) *connectionLimits {
+	if cfg.MaxConnections <= 0 && cfg.MaxUserConnections <= 0 && len(cfg.UserConnections) == 0 {
+		return nil
+	}
+
+	return &connectionLimits{
+		maxConnections:     cfg.MaxConnections,
+		maxUserConnections: cfg.MaxUserConnections,
+		userConnections:    cfg.UserConnections,
+		users:              make(map[string]int),
+	}
+}
+
+// acquire reserves a connection, returning false if there are too many.
+func (l *connectionLimits) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxConnections > 0 && l.connections >= l.maxConnections {
+		return false
+	}
+
+	l.connections++
+	return true
+}
+
+func (l *connectionLimits) release() {
+	l.mu.Lock()
+	l.connections--
+	l.mu.Unlock()
+}
+
+// acquireUser reserves a connection of an authenticated user, returning an
+// ER_TOO_MANY_USER_CONNECTIONS error if the user has too many.
+func (l *connectionLimits) acquireUser(user string) error {
+	if user == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	max, ok := l.userConnections[user]
+	if !ok {
+		max = l.maxUserConnections
+	}
+
+	if max > 0 && l.users[user] >= max {
+		return mysql.NewSQLError(
+			mysql.ERTooManyUserConnections,
+			tooManyUserConnectionsState,
+			"User %s already has more than 'max_user_connections' active connections",
+			user,
+		)
+	}
+
+	l.users[user]++
+	return nil
+}
+
+func (l *connectionLimits) releaseUser(user string) {
+	if user == "" {
+		return
+	}
+
+	l.mu.Lock()
+	if l.users[user]--; l.users[user] <= 0 {
+		delete(l.users, user)
+	}
+	l.mu.Unlock()
+}
+
+// limitedConn is a connection that releases its reservation when closed.
+type limitedConn struct {
+	net.Conn
+	once   sync.Once
+	limits *connectionLimits
+}
+
+func (c *limitedConn) Close() error {
+	c.once.Do(c.limits.release)
+	return c.Conn.Close()
+}
+
+// rejectConnection sends an ER_CON_COUNT_ERROR error to the client in place
+// of the handshake, as MySQL does, and closes the connection.
+func rejectConnection(conn net.Conn) {
+	msg := "Too many connections"
+	code := uint16(mysql.ERConCount)
+
+	payload := make([]byte, 0, 9+len(msg))
+	payload = append(payload, mysql.ErrPacket)
+	payload = append(payload, byte(code), byte(code>>8))
+	payload = append(payload, '#')
+	payload = append(payload, tooManyConnectionsState...)
+	payload = append(payload, msg...)
+
+	// Packets start with their length in 3 bytes and their sequence number,
+	// which is 0 for the first one.
+	n := len(payload)
+	packet := append([]byte{byte(n), byte(n >> 8), byte(n >> 16), 0}, payload...)
+
+	if _, err := conn.Write(packet); err != nil {
+		logrus.Debugf("unable to reject connection from %s: %s", conn.RemoteAddr(), err)
+	}
+
+	if err := conn.Close(); err != nil {
+		logrus.Debugf("unable to close rejected connection from %s: %s", conn.RemoteAddr(), err)
+	}
+}
+
+// limitedAuthServer is a mysql.AuthServer that rejects the users that have
+// too many connections once they are authenticated.
+type limitedAuthServer struct {
+	mysql.AuthServer
+	limits *connectionLimits
+}
+
+// ValidateHash implements the mysql.AuthServer interface.
+func (a *limitedAuthServer) ValidateHash(
+	salt []byte,
+	user string,
+	authResponse []byte,
+	remoteAddr net.Addr,
+) (mysql.Getter, error) {
+	getter, err := a.AuthServer.ValidateHash(salt, user, authResponse, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.limits.acquireUser(user); err != nil {
+		return nil, err
+	}
+
+	return getter, nil
+}
+
+// Negotiate implements the mysql.AuthServer interface.
+func (a *limitedAuthServer) Negotiate(
+	c *mysql.Conn,
+	user string,
+	remoteAddr net.Addr,
+) (mysql.Getter, error) {
+	getter, err := a.AuthServer.Negotiate(c, user, remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.limits.acquireUser(user); err != nil {
+		return nil, err
+	}
+
+	return getter, nil
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionLimits(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:           "tcp",
+		Address:            "localhost:" + port,
+		Auth:               new(auth.None),
+		MaxConnections:     3,
+		MaxUserConnections: 1,
+		UserConnections:    map[string]int{"root": 2},
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	// Each client keeps its connection open until it's closed.
+	connect := func(user string) (*dsql.DB, error) {
+		db, err := dsql.Open("mysql", fmt.Sprintf("%s:@tcp(localhost:%s)/test", user, port))
+		require.NoError(err)
+		db.SetMaxOpenConns(1)
+
+		if err := db.Ping(); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return db, nil
+	}
+
+	requireError := func(err error, number uint16) {
+		require.Error(err)
+		mysqlErr, ok := err.(*mysql.MySQLError)
+		require.True(ok, "unexpected error: %s", err)
+		require.Equal(number, mysqlErr.Number)
+	}
+
+	// The rejected connections are released once the server closes them.
+	waitConnections := func(n int) {
+		for i := 0; i < 100; i++ {
+			s.h.limits.mu.Lock()
+			connections := s.h.limits.connections
+			s.h.limits.mu.Unlock()
+
+			if connections == n {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.FailNow("connections were not released")
+	}
+
+	a, err := connect("a")
+	require.NoError(err)
+
+	_, err = connect("a")
+	requireError(err, 1203)
+	waitConnections(1)
+
+	root1, err := connect("root")
+	require.NoError(err)
+	defer root1.Close()
+
+	root2, err := connect("root")
+	require.NoError(err)
+	defer root2.Close()
+
+	_, err = connect("b")
+	requireError(err, 1040)
+
+	require.NoError(a.Close())
+	waitConnections(2)
+
+	b, err := connect("b")
+	require.NoError(err)
+	defer b.Close()
+
+	var n int
+	require.NoError(b.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+	require.Equal(1010, n)
+}
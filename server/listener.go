@@ -2,11 +2,20 @@ package server
 
 import (
 	"net"
+	"sync"
+
+	"gopkg.in/src-d/go-errors.v1"
 )
 
+var errListenerClosed = errors.NewKind("listener is closed")
+
 type Listener struct {
 	net.Listener
 	h *Handler
+	// compress offers the compressed protocol to the clients.
+	compress bool
+	// proxy reads the PROXY protocol header sent by proxies.
+	proxy bool
 }
 
 // NewListener creates a new Listener.
@@ -15,15 +24,131 @@ func NewListener(protocol, address string, handler *Handler) (*Listener, error)
 	if err != nil {
 		return nil, err
 	}
-	return &Listener{l, handler}, nil
+	return &Listener{Listener: l, h: handler}, nil
 }
 
 func (l *Listener) Accept() (net.Conn, error) {
-	conn, err := l.Listener.Accept()
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		limits := l.h.limits
+		if limits != nil && !limits.acquire() {
+			rejectConnection(conn)
+			continue
+		}
+
+		l.h.AddNetConnection(&conn)
+
+		// The handler keeps the accepted connection, which is wrapped
+		// in a different variable.
+		c := conn
+		if limits != nil {
+			c = &limitedConn{Conn: c, limits: limits}
+		}
+
+		// Local clients connected through the Unix socket do not go
+		// through proxies.
+		if _, ok := conn.LocalAddr().(*net.TCPAddr); ok && l.proxy {
+			c = newProxyConn(c)
+		}
+
+		if l.compress {
+			c = newCompressConn(c)
+		}
+
+		// The commands of prepared statements are handled before the
+		// packets reach the vitess listener, which does not implement them.
+		return newCommandConn(c, l.h), nil
+	}
+}
+
+// ListenSocket makes the listener also accept connections from a Unix
+// socket created at the given path, which is removed when the listener is
+// closed.
+func (l *Listener) ListenSocket(path string) error {
+	socket, err := net.Listen("unix", path)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	l.h.AddNetConnection(&conn)
-	return conn, err
+	l.Listener = newMultiListener(l.Listener, socket)
+	return nil
+}
+
+// multiListener accepts the connections of several listeners. Its address
+// is the address of the first one.
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan acceptResult
+	closed    chan struct{}
+	once      sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+func newMultiListener(listeners ...net.Listener) *multiListener {
+	l := &multiListener{
+		listeners: listeners,
+		conns:     make(chan acceptResult),
+		closed:    make(chan struct{}),
+	}
+
+	for _, listener := range listeners {
+		go l.accept(listener)
+	}
+
+	return l
+}
+
+func (l *multiListener) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		select {
+		case l.conns <- acceptResult{conn, err}:
+		case <-l.closed:
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Accept implements the net.Listener interface.
+func (l *multiListener) Accept() (net.Conn, error) {
+	select {
+	case r := <-l.conns:
+		return r.conn, r.err
+	case <-l.closed:
+		return nil, errListenerClosed.New()
+	}
+}
+
+// Close implements the net.Listener interface.
+func (l *multiListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.closed)
+		for _, listener := range l.listeners {
+			if e := listener.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	})
+	return err
+}
+
+// Addr implements the net.Listener interface.
+func (l *multiListener) Addr() net.Addr {
+	return l.listeners[0].Addr()
 }
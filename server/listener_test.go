@@ -0,0 +1,61 @@
+package server
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "socket-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "mysql.sock")
+
+	port, err := getFreePort()
+	require.NoError(t, err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Socket:   socket,
+		Auth:     new(auth.None),
+	}, setupMemDB(require.New(t)))
+	require.NoError(t, err)
+	go s.Start()
+
+	dsns := []string{
+		fmt.Sprintf("root:@tcp(localhost:%s)/test", port),
+		fmt.Sprintf("root:@unix(%s)/test", socket),
+	}
+
+	for _, dsn := range dsns {
+		t.Run(dsn, func(t *testing.T) {
+			require := require.New(t)
+
+			db, err := dsql.Open("mysql", dsn)
+			require.NoError(err)
+			defer db.Close()
+
+			var n int
+			require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+			require.Equal(1010, n)
+
+			var path string
+			require.NoError(db.QueryRow("SELECT @@socket").Scan(&path))
+			require.Equal(socket, path)
+		})
+	}
+
+	require.NoError(t, s.Close())
+
+	_, err = os.Stat(socket)
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"io"
+	"regexp"
+
+	"vitess.io/vitess/go/mysql"
+)
+
+const (
+	// capabilityClientLocalFiles is the CLIENT_LOCAL_FILES capability flag
+	// of the clients that can send their files for LOAD DATA LOCAL INFILE,
+	// which vitess does not define.
+	capabilityClientLocalFiles = 1 << 7
+	// localInfileRequest is the header of the packet requesting a file to
+	// the client.
+	localInfileRequest = 0xfb
+)
+
+var loadDataLocalRegex = regexp.MustCompile(`(?i)^\s*load\s+data\s+(?:(?:low_priority|concurrent)\s+)?local\s`)
+
+// loadsLocalData returns whether the packet is a LOAD DATA LOCAL INFILE
+// query, whose file is requested to the client by the connection, as the
+// vitess listener cannot do it.
+func (c *commandConn) loadsLocalData(data []byte) bool {
+	return c.h.localInfile &&
+		firstByte(data) == mysql.ComQuery &&
+		loadDataLocalRegex.Match(data[1:])
+}
+
+// comLoadDataLocal runs a LOAD DATA LOCAL INFILE query, reading the file
+// from the client if it can send it. Its response is an OK packet with the
+// number of rows affected, which is sent after the whole file.
+func (c *commandConn) comLoadDataLocal(data []byte) error {
+	query := string(data)
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	if err := c.h.checkShutdown(); err != nil {
+		return c.writeError(err)
+	}
+
+	ctx := c.h.sm.NewContextWithQuery(conn, query)
+	newCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = ctx.WithContext(newCtx)
+
+	var file *localFile
+	if c.capabilities&capabilityClientLocalFiles != 0 {
+		ctx = ctx.WithLocalFiles(func(name string) (io.ReadCloser, error) {
+			if err := c.writePacket(append([]byte{localInfileRequest}, name...)); err != nil {
+				return nil, err
+			}
+
+			if err := c.flush(); err != nil {
+				return nil, err
+			}
+
+			file = &localFile{c: c}
+			return file, nil
+		})
+	}
+
+	audit := c.h.auditQuery(ctx)
+	result, err := loadData(audit, query)
+	audit.done(err)
+
+	// The packets of the client cannot be read anymore.
+	if file != nil && file.err != nil {
+		return file.err
+	}
+
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	c.h.updateStatus(conn)
+	return c.writeOKResult(result, conn.StatusFlags)
+}
+
+// loadData runs a LOAD DATA query, returning its result.
+func loadData(audit *queryAudit, query string) (*okResult, error) {
+	_, rows, err := audit.query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataChangeResult(audit.ctx, dataChange(audit.plan), rows, false)
+}
+
+// localFile is a file sent by the client after it's requested, in packets
+// that end with an empty one.
+type localFile struct {
+	c    *commandConn
+	data []byte
+	done bool
+	// err is the error reading the packets, after which the connection
+	// cannot be used.
+	err error
+}
+
+func (f *localFile) Read(p []byte) (int, error) {
+	for len(f.data) == 0 {
+		if f.done {
+			return 0, io.EOF
+		}
+
+		if err := f.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+// next reads the next packet of the file.
+func (f *localFile) next() error {
+	packet, data, err := f.c.readPackets("net_read_timeout")
+	if err != nil {
+		f.err = err
+		return err
+	}
+
+	// The response follows the last packet, which are more than one if
+	// the payload was split.
+	f.c.seq = packet[3] + byte(len(data)/maxPacketPayload) + 1
+	f.data = data
+	f.done = len(data) == 0
+	return nil
+}
+
+// Close reads the rest of the file, as the client sends all of it before
+// reading the response.
+func (f *localFile) Close() error {
+	for !f.done && f.err == nil {
+		if err := f.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
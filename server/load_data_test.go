@@ -0,0 +1,76 @@
+package server
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerLoadDataLocal(t *testing.T) {
+	mysqldriver.RegisterReaderHandler("numbers", func() io.Reader {
+		return strings.NewReader("2000\n2001\n2002\n")
+	})
+	defer mysqldriver.DeregisterReaderHandler("numbers")
+
+	// The file is longer than a packet of the client.
+	mysqldriver.RegisterReaderHandler("long", func() io.Reader {
+		return strings.NewReader(strings.Repeat("3000\n", 10000))
+	})
+	defer mysqldriver.DeregisterReaderHandler("long")
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			require := require.New(t)
+
+			port, err := getFreePort()
+			require.NoError(err)
+
+			s, err := NewDefaultServer(Config{
+				Protocol:    "tcp",
+				Address:     "localhost:" + port,
+				Auth:        new(auth.None),
+				LocalInfile: enabled,
+			}, setupMemDB(require))
+			require.NoError(err)
+			go s.Start()
+			defer s.Close()
+
+			db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+			require.NoError(err)
+			defer db.Close()
+
+			res, err := db.Exec("LOAD DATA LOCAL INFILE 'Reader::numbers' INTO TABLE test")
+			if !enabled {
+				require.Error(err)
+				require.Contains(err.Error(), "cannot be read")
+				return
+			}
+			require.NoError(err)
+
+			n, err := res.RowsAffected()
+			require.NoError(err)
+			require.Equal(int64(3), n)
+
+			res, err = db.Exec("load data local infile 'Reader::long' into table test (c1)")
+			require.NoError(err)
+			n, err = res.RowsAffected()
+			require.NoError(err)
+			require.Equal(int64(10000), n)
+
+			var count int
+			require.NoError(db.QueryRow("SELECT COUNT(*) FROM test WHERE c1 >= 2000").Scan(&count))
+			require.Equal(10003, count)
+
+			// The connection is still usable after a failed load.
+			_, err = db.Exec("LOAD DATA LOCAL INFILE 'Reader::numbers' INTO TABLE missing")
+			require.Error(err)
+			require.NoError(db.Ping())
+		})
+	}
+}
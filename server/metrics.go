@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/go-kit/kit/metrics/discard"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+)
+
+var (
+	// ConnectionsGauge describes the number of open connections of each
+	// protocol, mysql or x.
+	ConnectionsGauge = discard.NewGauge()
+)
+
+// metricsNamespace is the namespace of the Prometheus metrics.
+const metricsNamespace = "go_mysql_server"
+
+var enableMetrics sync.Once
+
+// EnablePrometheusMetrics makes the metrics of the engine, the analyzer and
+// the server Prometheus metrics, registered in the default registry. Only
+// the metrics whose labels have a few values are registered, so the ones
+// labeled with queries or regular expressions are kept as they are. It
+// must be called before the engine runs any query, and it does nothing if
+// it's called again.
+func EnablePrometheusMetrics() {
+	enableMetrics.Do(func() {
+		sqle.QueryTypeCounter = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "engine",
+			Name:      "queries_total",
+			Help:      "Number of queries run, by type.",
+		}, []string{"type"})
+		sqle.QueryTypeErrorCounter = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "engine",
+			Name:      "query_errors_total",
+			Help:      "Number of queries that failed, by type.",
+		}, []string{"type"})
+		sqle.QueryDurationHistogram = kitprometheus.NewHistogramFrom(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "engine",
+			Name:      "query_duration_seconds",
+			Help:      "Time it takes to run the queries and read their rows, by type.",
+		}, []string{"type"})
+		sqle.RowsReadCounter = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "engine",
+			Name:      "rows_read_total",
+			Help:      "Number of rows read from the tables.",
+		}, nil)
+		sqle.RowsReturnedCounter = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "engine",
+			Name:      "rows_returned_total",
+			Help:      "Number of rows returned by the queries.",
+		}, nil)
+		analyzer.RuleHistogram = kitprometheus.NewHistogramFrom(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "analyzer",
+			Name:      "rule_duration_seconds",
+			Help:      "Time it takes to apply each rule of the analyzer.",
+			Buckets:   []float64{.00001, .0001, .001, .01, .1, 1},
+		}, []string{"rule"})
+		analyzer.ParallelQueryCounter = kitprometheus.NewCounterFrom(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "analyzer",
+			Name:      "parallel_queries_total",
+			Help:      "Number of queries run in parallel, by parallelism.",
+		}, []string{"parallelism"})
+		ConnectionsGauge = kitprometheus.NewGaugeFrom(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "server",
+			Name:      "connections",
+			Help:      "Number of open connections, by protocol.",
+		}, []string{"protocol"})
+	})
+}
+
+// newMetricsServer creates a server of the Prometheus metrics at /metrics.
+func newMetricsServer(address string, logger logrus.FieldLogger) (*endpointServer, error) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	s, err := newEndpointServer("metrics", address, mux, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	EnablePrometheusMetrics()
+	return s, nil
+}
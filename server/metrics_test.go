@@ -0,0 +1,99 @@
+package server
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+// The metrics must be enabled before any query is run, and the connections
+// of the servers of other tests may still be closing when this one starts.
+func init() {
+	EnablePrometheusMetrics()
+}
+
+func TestServerMetrics(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+	metricsPort, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:       "tcp",
+		Address:        "localhost:" + port,
+		Auth:           new(auth.None),
+		MetricsAddress: "localhost:" + metricsPort,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	// The metrics are global, so only how much they change is checked.
+	url := "http://localhost:" + metricsPort + "/metrics"
+	before := scrapeMetrics(t, url)
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.Query("SELECT * FROM test")
+	require.NoError(err)
+	var n int
+	for rows.Next() {
+		n++
+	}
+	require.NoError(rows.Close())
+	_, err = db.Exec("SELECT * FROM nonexistent")
+	require.Error(err)
+
+	after := scrapeMetrics(t, url)
+	delta := func(name string) float64 {
+		return after[name] - before[name]
+	}
+
+	require.Equal(2.0, delta(`go_mysql_server_engine_queries_total{type="select"}`))
+	require.Equal(1.0, delta(`go_mysql_server_engine_query_errors_total{type="select"}`))
+	require.Equal(2.0, delta(`go_mysql_server_engine_query_duration_seconds_count{type="select"}`))
+	require.Equal(float64(n), delta("go_mysql_server_engine_rows_returned_total"))
+	require.Equal(float64(n), delta("go_mysql_server_engine_rows_read_total"))
+	require.True(delta(`go_mysql_server_analyzer_rule_duration_seconds_count{rule="resolve_tables"}`) >= 2)
+	require.Equal(1.0, delta(`go_mysql_server_server_connections{protocol="mysql"}`))
+}
+
+// scrapeMetrics returns the values of the metrics served at url by their
+// names with labels.
+func scrapeMetrics(t *testing.T, url string) map[string]float64 {
+	t.Helper()
+	require := require.New(t)
+
+	resp, err := http.Get(url)
+	require.NoError(err)
+	defer resp.Body.Close()
+	require.Equal(http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(err)
+
+	metrics := make(map[string]float64)
+	for _, line := range strings.Split(string(body), "\n") {
+		i := strings.LastIndexByte(line, ' ')
+		if strings.HasPrefix(line, "#") || i < 0 {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(err)
+		metrics[line[:i]] = v
+	}
+	return metrics
+}
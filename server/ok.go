@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"vitess.io/vitess/go/mysql"
+)
+
+// serverStatusInTrans is the SERVER_STATUS_IN_TRANS status flag of the
+// sessions in a transaction, which vitess does not define.
+const serverStatusInTrans = 0x0001
+
+// okResult is the result of a statement that changes data, which is sent
+// to the client in an OK packet instead of a result set.
+type okResult struct {
+	affected uint64
+	insertID uint64
+	warnings uint16
+	// info is the information about the statement read by the clients,
+	// such as "Rows matched: 1  Changed: 1  Warnings: 0".
+	info string
+}
+
+// okInfoKey is the key of the session state with the info of the OK packet
+// of the last statement, which is added by commandConn to the OK packet
+// written by the listener, as it cannot write it.
+type okInfoKey struct{}
+
+// dataChange returns the statement that changes data in the given plan,
+// whose result is sent in an OK packet, or nil if it does not change data.
+func dataChange(n sql.Node) sql.Node {
+	if qp, ok := n.(*plan.QueryProcess); ok {
+		n = qp.Child
+	}
+
+	switch n.(type) {
+	case *plan.InsertInto, *plan.Update, *plan.DeleteFrom, *plan.LoadData:
+		return n
+	default:
+		return nil
+	}
+}
+
+// dataChangeResult reads the result of a statement that changes data from
+// the single row of its plan, closing the rows. As in MySQL, the rows
+// affected by an UPDATE are the ones changed, or the ones matched if the
+// client sets CLIENT_FOUND_ROWS. If autocommit is disabled, the session is
+// in a transaction after the statement.
+func dataChangeResult(
+	ctx *sql.Context,
+	n sql.Node,
+	rows sql.RowIter,
+	foundRows bool,
+) (*okResult, error) {
+	row, err := rows.Next()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	counts := make([]uint64, len(row))
+	for i, v := range row {
+		count, err := sql.Int64.Convert(v)
+		if err != nil {
+			return nil, err
+		}
+		counts[i] = uint64(count.(int64))
+	}
+
+	r := &okResult{affected: counts[0], warnings: ctx.WarningCount()}
+	switch n := n.(type) {
+	case *plan.InsertInto:
+		r.insertID = uint64(ctx.GetLastQueryInfo(sql.InsertID))
+		// MySQL only sends the info of the inserts with several rows.
+		if values, ok := n.Right.(*plan.Values); !ok || len(values.ExpressionTuples) > 1 {
+			duplicates := uint64(ctx.GetLastQueryInfo(sql.Duplicates))
+			r.info = fmt.Sprintf("Records: %d  Duplicates: %d  Warnings: %d",
+				r.affected-duplicates, duplicates, r.warnings)
+		}
+	case *plan.LoadData:
+		r.insertID = uint64(ctx.GetLastQueryInfo(sql.InsertID))
+		deleted := uint64(ctx.GetLastQueryInfo(sql.Duplicates))
+		r.info = fmt.Sprintf("Records: %d  Deleted: %d  Skipped: 0  Warnings: %d",
+			r.affected-deleted, deleted, r.warnings)
+	case *plan.Update:
+		matched, changed := counts[0], counts[1]
+		r.affected = changed
+		if foundRows {
+			r.affected = matched
+		}
+		r.info = fmt.Sprintf("Rows matched: %d  Changed: %d  Warnings: %d", matched, changed, r.warnings)
+	}
+
+	if !sql.Autocommit(ctx.Session) {
+		sql.SetInTransaction(ctx.Session, true)
+	}
+
+	return r, nil
+}
+
+// statusFlags returns the status flags of the given session sent to the
+// client, which tell whether autocommit is enabled and whether the session
+// is in a transaction.
+func statusFlags(sess sql.Session) uint16 {
+	var status uint16
+	if sql.Autocommit(sess) {
+		status |= mysql.ServerStatusAutocommit
+	}
+
+	if sql.InTransaction(sess) {
+		status |= serverStatusInTrans
+	}
+	return status
+}
+
+// updateStatus sets the status flags of the connection, which are sent in
+// the packets that end the responses, with the state of its session.
+func (h *Handler) updateStatus(c *mysql.Conn) {
+	if sess := h.sm.session(c); sess != nil {
+		c.StatusFlags = statusFlags(sess)
+	}
+}
+
+// okInfo returns the info of the OK packet of the last statement, if any,
+// which is only sent once.
+func (c *commandConn) okInfo() string {
+	sess := c.session()
+	if sess == nil {
+		return ""
+	}
+
+	info, _ := sess.State(okInfoKey{}).(string)
+	sess.SetState(okInfoKey{}, nil)
+	return info
+}
+
+// completeOK adds the info of the last statement and the changes of the
+// session state to the OK packet at the start of p, if any, returning the
+// bytes to write. Only the OK packets that start the response to a command
+// are changed, as they cannot be told apart from rows in the rest of the
+// response, so the changes made by a command are reported in the next OK
+// packet that starts a response.
+func (c *commandConn) completeOK(p []byte) []byte {
+	if len(p) < 4+7 || p[3] != 1 || p[4] != mysql.OKPacket {
+		return p
+	}
+
+	length := uint24(p[:3])
+	if len(p) < 4+length || length >= maxPacketPayload {
+		return p
+	}
+
+	// OK packets written by the listener end with the warnings, so the
+	// info and the changes follow them.
+	ok := p[4 : 4+length]
+	_, rest, valid := readLenEncInt(ok[1:])
+	if valid {
+		_, rest, valid = readLenEncInt(rest)
+	}
+	if !valid || len(rest) != 4 {
+		return p
+	}
+
+	info := c.okInfo()
+	changes := c.sessionStateChanges()
+	if info == "" && changes == nil {
+		return p
+	}
+
+	data := append([]byte(nil), ok...)
+	data = appendOKInfo(data, info, changes, c.tracksSession())
+
+	packet := make([]byte, 4, 4+len(data)+len(p)-4-length)
+	putUint24(packet, len(data))
+	packet[3] = p[3]
+	packet = append(packet, data...)
+	return append(packet, p[4+length:]...)
+}
+
+// appendOKInfo appends the info and the changes of the session state, if
+// any, to an OK packet that ends with its status flags and warnings, which
+// is flagged with SERVER_SESSION_STATE_CHANGED if there are changes. The
+// info is a string with its length only for the clients that track the
+// session state, and the rest of the packet for the others.
+func appendOKInfo(data []byte, info string, changes []byte, tracksSession bool) []byte {
+	if !tracksSession {
+		return append(data, info...)
+	}
+
+	if changes != nil {
+		status := binary.LittleEndian.Uint16(data[len(data)-4:])
+		binary.LittleEndian.PutUint16(data[len(data)-4:], status|serverSessionStateChanged)
+	}
+
+	data = appendLenEncString(data, info)
+	if changes != nil {
+		data = appendLenEncString(data, string(changes))
+	}
+	return data
+}
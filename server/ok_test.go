@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+func TestServerOKResult(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	require.NoError(err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	readTestPacket(t, r)
+	testHandshake(t, conn, r, 0)
+
+	type ok struct {
+		affected uint64
+		insertID uint64
+		status   uint16
+		info     string
+	}
+
+	query := func(query string) ok {
+		t.Helper()
+		writeTestPacket(t, conn, 0, append([]byte{mysql.ComQuery}, query...))
+		packet := readTestPacket(t, r)
+		require.Equal(byte(mysql.OKPacket), packet[0], string(packet))
+
+		affected, rest, valid := readLenEncInt(packet[1:])
+		require.True(valid)
+		insertID, rest, valid := readLenEncInt(rest)
+		require.True(valid)
+		return ok{affected, insertID, binary.LittleEndian.Uint16(rest), string(rest[4:])}
+	}
+
+	const autocommit = mysql.ServerStatusAutocommit
+
+	query("USE test")
+	query("CREATE TABLE users (id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, name TEXT)")
+
+	require.Equal(ok{1, 1, autocommit, ""}, query("INSERT INTO users (name) VALUES ('a')"))
+	require.Equal(
+		ok{2, 2, autocommit, "Records: 2  Duplicates: 0  Warnings: 0"},
+		query("INSERT INTO users (name) VALUES ('b'), ('c')"),
+	)
+	require.Equal(
+		ok{2, 0, autocommit, "Rows matched: 3  Changed: 2  Warnings: 0"},
+		query("UPDATE users SET name = 'a'"),
+	)
+	require.Equal(ok{2, 0, autocommit, ""}, query("DELETE FROM users WHERE id > 1"))
+	require.Equal(
+		ok{3, 0, autocommit, "Records: 2  Duplicates: 1  Warnings: 0"},
+		query("REPLACE INTO users VALUES (1, 'a'), (4, 'd')"),
+	)
+
+	require.Equal(ok{0, 0, autocommit | serverStatusInTrans, ""}, query("START TRANSACTION"))
+	require.Equal(ok{0, 0, autocommit, ""}, query("COMMIT"))
+
+	require.Equal(ok{0, 0, 0, ""}, query("SET autocommit = 0"))
+	require.Equal(ok{1, 5, serverStatusInTrans, ""}, query("INSERT INTO users (name) VALUES ('e')"))
+	require.Equal(ok{0, 0, 0, ""}, query("ROLLBACK"))
+}
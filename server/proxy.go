@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidProxyHeader is returned when a connection does not start with
+// a valid PROXY protocol header.
+var ErrInvalidProxyHeader = errors.NewKind("invalid PROXY protocol header: %s")
+
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+const (
+	// proxyV1MaxLength is the maximum length of a v1 header, with the
+	// trailing CRLF.
+	proxyV1MaxLength = 107
+
+	proxyV2Local = 0x20
+	proxyV2Proxy = 0x21
+
+	proxyV2Inet  = 0x1
+	proxyV2Inet6 = 0x2
+)
+
+// proxyConn is a connection from a proxy or load balancer that starts with
+// a PROXY protocol header, which is read before the rest of the connection
+// and gives the address of the client.
+type proxyConn struct {
+	net.Conn
+	once sync.Once
+	r    *bufio.Reader
+	addr net.Addr
+	err  error
+}
+
+func newProxyConn(conn net.Conn) *proxyConn {
+	return &proxyConn{Conn: conn}
+}
+
+// readHeader reads the PROXY header the first time it's called. As proxies
+// send it as soon as they connect, it does not wait for the handshake.
+func (c *proxyConn) readHeader() {
+	c.once.Do(func() {
+		c.r = bufio.NewReader(c.Conn)
+		c.addr, c.err = readProxyHeader(c.r)
+	})
+}
+
+func (c *proxyConn) Read(p []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(p)
+}
+
+// RemoteAddr returns the address of the client given by the proxy, or the
+// address of the proxy if it did not give one.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.addr != nil {
+		return c.addr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader reads a v1 or v2 PROXY protocol header, returning the
+// source address in it. The address is nil if the header does not have
+// one, as in health checks made by the proxies.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	signature, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, ErrInvalidProxyHeader.New(err)
+	}
+
+	if bytes.Equal(signature, proxyV2Signature) {
+		return readProxyV2Header(r)
+	}
+
+	// Clients that connect directly send their handshake response, so the
+	// connection is not read any further.
+	if !bytes.HasPrefix(signature, []byte("PROXY ")) {
+		return nil, ErrInvalidProxyHeader.New("missing PROXY signature")
+	}
+
+	return readProxyV1Header(r)
+}
+
+// readProxyV1Header reads a header like "PROXY TCP4 src dst sport dport".
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLength {
+			return nil, ErrInvalidProxyHeader.New("header too long")
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrInvalidProxyHeader.New(err)
+		}
+		line = append(line, b)
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 || fields[0] != "PROXY" {
+		return nil, ErrInvalidProxyHeader.New("missing PROXY signature")
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, ErrInvalidProxyHeader.New("unknown protocol " + fields[1])
+	}
+
+	if len(fields) != 6 {
+		return nil, ErrInvalidProxyHeader.New("wrong number of fields")
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, ErrInvalidProxyHeader.New("invalid source address " + fields[2])
+	}
+
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, ErrInvalidProxyHeader.New("invalid source port " + fields[4])
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header reads a binary header, which has a signature, the
+// version and command, the address family, the length of the rest of the
+// header, the addresses and optional TLVs, which are ignored.
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(proxyV2Signature)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrInvalidProxyHeader.New(err)
+	}
+
+	command := header[12]
+	family := header[13] >> 4
+	length := binary.BigEndian.Uint16(header[14:])
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrInvalidProxyHeader.New(err)
+	}
+
+	switch command {
+	case proxyV2Local:
+		return nil, nil
+	case proxyV2Proxy:
+	default:
+		return nil, ErrInvalidProxyHeader.New("unknown command")
+	}
+
+	var ipLen int
+	switch family {
+	case proxyV2Inet:
+		ipLen = net.IPv4len
+	case proxyV2Inet6:
+		ipLen = net.IPv6len
+	default:
+		// Other families, such as Unix sockets, do not have an address
+		// that can be used to identify the client.
+		return nil, nil
+	}
+
+	// Source and destination addresses followed by their ports.
+	if len(data) < 2*ipLen+4 {
+		return nil, ErrInvalidProxyHeader.New("addresses too short")
+	}
+
+	ip := make(net.IP, ipLen)
+	copy(ip, data[:ipLen])
+	port := binary.BigEndian.Uint16(data[2*ipLen:])
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
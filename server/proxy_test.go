@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	dsql "database/sql"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyV2Header(command, family byte, addresses []byte) []byte {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, command, family<<4|1)
+	header = append(header, byte(len(addresses)>>8), byte(len(addresses)))
+	return append(header, addresses...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header []byte
+		addr   net.Addr
+		err    bool
+	}{
+		{
+			"v1 tcp4",
+			[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 3306\r\n"),
+			&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 56324},
+			false,
+		},
+		{
+			"v1 tcp6",
+			[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 3306\r\n"),
+			&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324},
+			false,
+		},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\n"), nil, false},
+		{"v1 missing fields", []byte("PROXY TCP4 192.0.2.1\r\n"), nil, true},
+		{"v1 invalid address", []byte("PROXY TCP4 foo 198.51.100.1 1 2\r\n"), nil, true},
+		{"v1 without CRLF", bytes.Repeat([]byte("PROXY "), 20), nil, true},
+		{"not a header", []byte("GET / HTTP/1.1\r\n"), nil, true},
+		{
+			"v2 inet",
+			proxyV2Header(proxyV2Proxy, proxyV2Inet, []byte{
+				192, 0, 2, 1,
+				198, 51, 100, 1,
+				0xdc, 0x04,
+				0x0c, 0xea,
+			}),
+			&net.TCPAddr{IP: net.IPv4(192, 0, 2, 1).To4(), Port: 56324},
+			false,
+		},
+		{
+			"v2 inet6 with TLVs",
+			proxyV2Header(proxyV2Proxy, proxyV2Inet6, append(append(append(
+				net.ParseIP("2001:db8::1"),
+				net.ParseIP("2001:db8::2")...),
+				0xdc, 0x04, 0x0c, 0xea),
+				0x04, 0x00, 0x01, 0xff,
+			)),
+			&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324},
+			false,
+		},
+		{"v2 local", proxyV2Header(proxyV2Local, 0, nil), nil, false},
+		{"v2 short addresses", proxyV2Header(proxyV2Proxy, proxyV2Inet, []byte{1, 2}), nil, true},
+		{"v2 unknown command", proxyV2Header(0x22, proxyV2Inet, nil), nil, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			data := append(append([]byte{}, tt.header...), "rest"...)
+			r := bufio.NewReader(bytes.NewReader(data))
+			addr, err := readProxyHeader(r)
+			if tt.err {
+				require.Error(err)
+				require.True(ErrInvalidProxyHeader.Is(err))
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.addr, addr)
+
+			rest, _ := r.ReadString(0)
+			require.Equal("rest", rest)
+		})
+	}
+}
+
+func TestServerProxyProtocol(t *testing.T) {
+	port, err := getFreePort()
+	require.NoError(t, err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:      "tcp",
+		Address:       "localhost:" + port,
+		Auth:          new(auth.None),
+		ProxyProtocol: true,
+	}, setupMemDB(require.New(t)))
+	require.NoError(t, err)
+	go s.Start()
+	defer s.Close()
+
+	mysql.RegisterDial("proxy-test", func(addr string) (net.Conn, error) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = conn.Write([]byte("PROXY TCP4 192.0.2.1 127.0.0.1 56324 " + port + "\r\n"))
+		return conn, err
+	})
+
+	testCases := []struct {
+		network string
+		host    string
+	}{
+		{"proxy-test", "192.0.2.1:56324"},
+		{"tcp", ""},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.network, func(t *testing.T) {
+			require := require.New(t)
+
+			db, err := dsql.Open("mysql", fmt.Sprintf("root:@%s(localhost:%s)/test", tt.network, port))
+			require.NoError(err)
+			defer db.Close()
+
+			rows, err := db.Query("SHOW PROCESSLIST")
+			if tt.host == "" {
+				// Connections without the header are closed.
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			defer rows.Close()
+
+			var hosts []string
+			for rows.Next() {
+				var id, time int
+				var user, host, command, info, state string
+				var db dsql.NullString
+				require.NoError(rows.Scan(&id, &user, &host, &db, &command, &time, &state, &info))
+				hosts = append(hosts, host)
+			}
+			require.NoError(rows.Err())
+			require.Equal([]string{tt.host}, hosts)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+)
+
+const userLimitReachedState = "42000"
+
+// QueryLimits limits the queries run by a user or by all of them.
+type QueryLimits struct {
+	// QueriesPerSecond is the number of queries that can be started every
+	// second, on average, with bursts of up to a second of queries. If 0,
+	// it's not limited.
+	QueriesPerSecond float64
+	// ConcurrentQueries is the number of queries that can run at the same
+	// time, until their rows are read. If 0, it's not limited.
+	ConcurrentQueries int
+}
+
+func (l QueryLimits) empty() bool {
+	return l.QueriesPerSecond <= 0 && l.ConcurrentQueries <= 0
+}
+
+// queryLimits throttles the queries of the server, in total and by user.
+type queryLimits struct {
+	max         QueryLimits
+	maxUser     QueryLimits
+	userQueries map[string]QueryLimits
+	// now returns the current time, which is replaced in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	queries int
+	bucket  *tokenBucket
+	users   map[string]*userQueries
+	// pruned is when the idle users were last forgotten.
+	pruned time.Time
+}
+
+// userQueries are the running queries of a user and its rate limit.
+type userQueries struct {
+	queries int
+	bucket  *tokenBucket
+}
+
+// newQueryLimits returns the query limits of the configuration, or nil if
+// it has none.
+func newQueryLimits(cfg Config) *queryLimits {
+	if cfg.MaxQueries.empty() && cfg.MaxUserQueries.empty() && len(cfg.UserQueries) == 0 {
+		return nil
+	}
+
+	return &queryLimits{
+		max:         cfg.MaxQueries,
+		maxUser:     cfg.MaxUserQueries,
+		userQueries: cfg.UserQueries,
+		now:         time.Now,
+		bucket:      newTokenBucket(cfg.MaxQueries.QueriesPerSecond),
+		users:       make(map[string]*userQueries),
+	}
+}
+
+// acquire reserves a query of the given user, returning an
+// ER_USER_LIMIT_REACHED error if the user or the server run too many.
+func (l *queryLimits) acquire(user string) error {
+	limits, ok := l.userQueries[user]
+	if !ok {
+		limits = l.maxUser
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	u, ok := l.users[user]
+	if !ok {
+		u = &userQueries{bucket: newTokenBucket(limits.QueriesPerSecond)}
+	}
+
+	if limits.ConcurrentQueries > 0 && u.queries >= limits.ConcurrentQueries {
+		return errUserLimitReached(user, "max_user_concurrent_queries", limits.ConcurrentQueries)
+	}
+
+	if l.max.ConcurrentQueries > 0 && l.queries >= l.max.ConcurrentQueries {
+		return errUserLimitReached(user, "max_concurrent_queries", l.max.ConcurrentQueries)
+	}
+
+	// Tokens are only taken once the query is allowed by both buckets, so
+	// rejected queries do not count.
+	if !u.bucket.allow(now) {
+		return errUserLimitReached(user, "max_user_queries_per_second", limits.QueriesPerSecond)
+	}
+
+	if !l.bucket.allow(now) {
+		return errUserLimitReached(user, "max_queries_per_second", l.max.QueriesPerSecond)
+	}
+
+	u.bucket.take()
+	l.bucket.take()
+	u.queries++
+	l.queries++
+	l.users[user] = u
+	return nil
+}
+
+func (l *queryLimits) release(user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.queries--
+	if u, ok := l.users[user]; ok {
+		u.queries--
+	}
+}
+
+// prune forgets the users without running queries whose buckets are full
+// again, as they are the same as new ones, so the users that are gone do
+// not take memory. It does it at most once a second.
+func (l *queryLimits) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Second {
+		return
+	}
+	l.pruned = now
+
+	for user, u := range l.users {
+		if u.queries <= 0 && u.bucket.full(now) {
+			delete(l.users, user)
+		}
+	}
+}
+
+func errUserLimitReached(user, resource string, value interface{}) error {
+	return mysql.NewSQLError(
+		mysql.ERUserLimitReached,
+		userLimitReachedState,
+		"User '%s' has exceeded the '%s' resource (current value: %v)",
+		user, resource, value,
+	)
+}
+
+// tokenBucket limits the rate of events, allowing bursts of up to a second
+// of them. A nil tokenBucket does not limit it.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst}
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// allow returns whether there is a token to take at the given time.
+func (b *tokenBucket) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.refill(now)
+	return b.tokens >= 1
+}
+
+func (b *tokenBucket) take() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+func (b *tokenBucket) full(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
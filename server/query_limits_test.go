@@ -0,0 +1,113 @@
+package server
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+	vtmysql "vitess.io/vitess/go/mysql"
+)
+
+func TestQueryLimits(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	l := newQueryLimits(Config{
+		MaxQueries:     QueryLimits{ConcurrentQueries: 3},
+		MaxUserQueries: QueryLimits{QueriesPerSecond: 2, ConcurrentQueries: 1},
+		UserQueries:    map[string]QueryLimits{"root": {ConcurrentQueries: 2}},
+	})
+	l.now = func() time.Time { return now }
+
+	requireLimit := func(err error, resource string) {
+		require.Error(err)
+		serr, ok := err.(*vtmysql.SQLError)
+		require.True(ok, "unexpected error: %s", err)
+		require.Equal(vtmysql.ERUserLimitReached, serr.Number())
+		require.Contains(serr.Error(), resource)
+	}
+
+	require.NoError(l.acquire("a"))
+	requireLimit(l.acquire("a"), "'max_user_concurrent_queries'")
+	l.release("a")
+
+	require.NoError(l.acquire("a"))
+	l.release("a")
+
+	// The burst of a second of queries is used up.
+	requireLimit(l.acquire("a"), "'max_user_queries_per_second'")
+	now = now.Add(500 * time.Millisecond)
+	require.NoError(l.acquire("a"))
+
+	// Overridden users are not limited by MaxUserQueries.
+	require.NoError(l.acquire("root"))
+	require.NoError(l.acquire("root"))
+	requireLimit(l.acquire("b"), "'max_concurrent_queries'")
+
+	l.release("root")
+	require.NoError(l.acquire("b"))
+
+	l.release("a")
+	l.release("b")
+	l.release("root")
+	require.Equal(0, l.queries)
+
+	// Users are forgotten once their buckets are full.
+	require.Len(l.users, 3)
+	now = now.Add(time.Second)
+	require.NoError(l.acquire("a"))
+	l.release("a")
+	require.Len(l.users, 1)
+}
+
+func TestServerQueryLimits(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol:       "tcp",
+		Address:        "localhost:" + port,
+		Auth:           new(auth.None),
+		MaxUserQueries: QueryLimits{QueriesPerSecond: 0.1},
+		UserQueries:    map[string]QueryLimits{"root": {}},
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	connect := func(user string) *dsql.DB {
+		db, err := dsql.Open("mysql", fmt.Sprintf("%s:@tcp(localhost:%s)/test", user, port))
+		require.NoError(err)
+		db.SetMaxOpenConns(1)
+		return db
+	}
+
+	a := connect("a")
+	defer a.Close()
+
+	var n int
+	require.NoError(a.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+
+	err = a.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+	require.Error(err)
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	require.True(ok, "unexpected error: %s", err)
+	require.Equal(uint16(vtmysql.ERUserLimitReached), mysqlErr.Number)
+	require.Equal("User 'a' has exceeded the 'max_user_queries_per_second' resource (current value: 0.1)", mysqlErr.Message)
+
+	// KILL is never limited, so clients can always stop their queries.
+	_, err = a.Exec("KILL QUERY 1")
+	require.NoError(err)
+
+	root := connect("root")
+	defer root.Close()
+	for i := 0; i < 5; i++ {
+		require.NoError(root.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+	}
+}
@@ -0,0 +1,533 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// defaultConnectRetry is the time a replica waits to connect again to its
+// primary after losing the connection, as MASTER_CONNECT_RETRY in MySQL.
+const defaultConnectRetry = 60 * time.Second
+
+var (
+	// ErrReplicaStopped is returned when a replica that was stopped is
+	// started again.
+	ErrReplicaStopped = errors.NewKind("replica was stopped")
+	// ErrReplicaEvent is returned when the primary sends an event that
+	// cannot be read.
+	ErrReplicaEvent = errors.NewKind("invalid binary log event: %s")
+	// ErrReplicaColumns is returned when a replicated table has different
+	// columns than the table of the primary.
+	ErrReplicaColumns = errors.NewKind("table %s.%s has %d columns on the primary and %d on the replica")
+	// ErrReplicaRowImage is returned when the primary does not log every
+	// column of the rows, as with binlog_row_image=MINIMAL.
+	ErrReplicaRowImage = errors.NewKind("rows of table %s.%s do not have all their columns, binlog_row_image must be FULL")
+)
+
+// ReplicaConfig is the configuration of a replica.
+type ReplicaConfig struct {
+	// Host and Port of the primary.
+	Host string
+	Port int
+	// User and Password used to connect to the primary, which needs the
+	// REPLICATION SLAVE privilege.
+	User     string
+	Password string
+	// ServerID identifies the replica to the primary, and it must be
+	// different from the ids of the primary and its other replicas.
+	ServerID uint32
+	// File and Position of the binary log of the primary to start from.
+	// If File is empty, the replica starts from the current position of
+	// the primary, given by SHOW MASTER STATUS.
+	File     string
+	Position uint32
+	// Databases are the databases replicated. If empty, the changes of
+	// all the databases are replicated.
+	Databases []string
+	// ConnectRetry is the time to wait before connecting to the primary
+	// again after losing the connection. By default, it's one minute.
+	ConnectRetry time.Duration
+}
+
+// ReplicaStatus is the state of a replica.
+type ReplicaStatus struct {
+	// Running is whether the replica is reading the binary log.
+	Running bool
+	// File and Position of the binary log of the primary up to which the
+	// changes have been applied.
+	File     string
+	Position uint32
+	// LastError is the last error the replica got, if any.
+	LastError error
+	// Lag is how far behind the primary the replica was when it applied
+	// the last transaction, as the time between it was logged and applied,
+	// like Seconds_Behind_Master in MySQL.
+	Lag time.Duration
+}
+
+// Replica reads the binary log of a MySQL primary and applies the rows
+// changed on it to the tables of an engine, which must have the same
+// columns. The primary must use row-based logging with full row images.
+// Other statements, such as DDL, are not applied.
+type Replica struct {
+	cfg ReplicaConfig
+	e   *sqle.Engine
+
+	mu      sync.Mutex
+	status  ReplicaStatus
+	conn    *mysql.Conn
+	stopped bool
+	stop    chan struct{}
+}
+
+// NewReplica creates a replica of the primary in the configuration, which
+// applies its changes to the given engine.
+func NewReplica(cfg ReplicaConfig, e *sqle.Engine) *Replica {
+	if cfg.ConnectRetry <= 0 {
+		cfg.ConnectRetry = defaultConnectRetry
+	}
+
+	return &Replica{
+		cfg:    cfg,
+		e:      e,
+		status: ReplicaStatus{File: cfg.File, Position: cfg.Position},
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start starts replicating the changes of the primary, blocking until the
+// replica is stopped or a change cannot be applied. When the connection to
+// the primary is lost, it connects again from the last change applied.
+func (r *Replica) Start() error {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return ErrReplicaStopped.New()
+	}
+	r.status.Running = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.status.Running = false
+		r.mu.Unlock()
+	}()
+
+	for {
+		err := r.replicate()
+
+		r.mu.Lock()
+		stopped := r.stopped
+		if !stopped {
+			r.status.LastError = err
+		}
+		r.mu.Unlock()
+
+		if stopped {
+			return nil
+		}
+
+		if _, ok := err.(replicaApplyError); ok {
+			return err
+		}
+
+		logrus.Warnf("replication from %s:%d failed, retrying in %s: %s",
+			r.cfg.Host, r.cfg.Port, r.cfg.ConnectRetry, err)
+
+		select {
+		case <-r.stop:
+			return nil
+		case <-time.After(r.cfg.ConnectRetry):
+		}
+	}
+}
+
+// Stop stops the replica, closing its connection to the primary.
+func (r *Replica) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return nil
+	}
+
+	r.stopped = true
+	close(r.stop)
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	return nil
+}
+
+// Status returns the state of the replica.
+func (r *Replica) Status() ReplicaStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// replicaApplyError is an error applying a change, which stops the
+// replica instead of making it connect again.
+type replicaApplyError struct {
+	error
+}
+
+// replicate connects to the primary and applies its changes until the
+// connection is lost or a change cannot be applied.
+func (r *Replica) replicate() error {
+	conn, err := mysql.Connect(context.Background(), &mysql.ConnParams{
+		Host:  r.cfg.Host,
+		Port:  r.cfg.Port,
+		Uname: r.cfg.User,
+		Pass:  r.cfg.Password,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		conn.Close()
+		return nil
+	}
+	r.conn = conn
+	file, pos := r.status.File, r.status.Position
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.conn = nil
+		r.mu.Unlock()
+		conn.Close()
+	}()
+
+	// The primary only sends the checksums of the events to the replicas
+	// that tell it they can read them.
+	_, err = conn.ExecuteFetch("SET @master_binlog_checksum = @@global.binlog_checksum", 0, false)
+	if err != nil {
+		return err
+	}
+
+	result, err := conn.ExecuteFetch("SELECT @@global.binlog_checksum", 1, false)
+	if err != nil {
+		return err
+	}
+	checksum := len(result.Rows) > 0 && strings.EqualFold(result.Rows[0][0].ToString(), "CRC32")
+
+	if file == "" {
+		result, err := conn.ExecuteFetch("SHOW MASTER STATUS", 1, false)
+		if err != nil {
+			return err
+		}
+		if len(result.Rows) == 0 {
+			return replicaApplyError{ErrBinlogClosed.New()}
+		}
+
+		n, err := sqltypes.ToUint64(result.Rows[0][1])
+		if err != nil {
+			return err
+		}
+		file, pos = result.Rows[0][0].ToString(), uint32(n)
+		r.setPosition(file, pos)
+	}
+
+	if pos < binlogMagicLength {
+		pos = binlogMagicLength
+	}
+
+	if err := conn.WriteComBinlogDump(r.cfg.ServerID, file, pos, 0); err != nil {
+		return err
+	}
+
+	s := replicaStream{r: r, checksum: checksum, tables: make(map[uint64]*mysql.TableMap)}
+	for {
+		data, err := conn.ReadPacket()
+		if err != nil {
+			return err
+		}
+
+		switch data[0] {
+		case mysql.ErrPacket:
+			return mysql.ParseErrorPacket(data)
+		case mysql.EOFPacket:
+			return io.EOF
+		}
+
+		if err := s.handle(data[1:]); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Replica) setPosition(file string, pos uint32) {
+	r.mu.Lock()
+	r.status.File = file
+	r.status.Position = pos
+	r.mu.Unlock()
+}
+
+// replicates returns whether the changes of a database are replicated.
+func (r *Replica) replicates(db string) bool {
+	if len(r.cfg.Databases) == 0 {
+		return true
+	}
+
+	for _, d := range r.cfg.Databases {
+		if strings.EqualFold(d, db) {
+			return true
+		}
+	}
+	return false
+}
+
+// replicaStream is the state of the binary log read from the primary.
+type replicaStream struct {
+	r *Replica
+	// Whether the events have checksums, which is known before the
+	// format description is read.
+	checksum bool
+	format   mysql.BinlogFormat
+	tables   map[uint64]*mysql.TableMap
+	// Whether the events read are in a transaction, whose end is the
+	// position changes are applied up to.
+	inTransaction bool
+}
+
+// handle applies an event, updating the position of the replica at the
+// end of every transaction.
+func (s *replicaStream) handle(data []byte) error {
+	ev := mysql.NewMysql56BinlogEvent(data)
+	if !ev.IsValid() {
+		return ErrReplicaEvent.New("truncated event")
+	}
+
+	if ev.IsFormatDescription() {
+		var err error
+		s.format, err = ev.Format()
+		s.checksum = s.format.ChecksumAlgorithm == mysql.BinlogChecksumAlgCRC32
+		return err
+	}
+
+	// Rotate events, which give the position the primary starts from, are
+	// sent before the format description.
+	if ev.IsRotate() {
+		body := data[binlogHeaderLength:]
+		if s.checksum {
+			body = body[:len(body)-4]
+		}
+		if len(body) < 8 {
+			return ErrReplicaEvent.New("truncated rotate event")
+		}
+		s.r.setPosition(string(body[8:]), uint32(binary.LittleEndian.Uint64(body)))
+		return nil
+	}
+
+	if s.format.IsZero() {
+		return ErrReplicaEvent.New("no format description")
+	}
+
+	ev, _, err := ev.StripChecksum(s.format)
+	if err != nil {
+		return err
+	}
+
+	next := binary.LittleEndian.Uint32(data[13:])
+	end := false
+	switch {
+	case ev.IsGTID():
+		s.inTransaction = true
+	case ev.IsQuery():
+		q, err := ev.Query(s.format)
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToUpper(q.SQL) {
+		case "BEGIN":
+			s.inTransaction = true
+		case "COMMIT":
+			end = true
+		default:
+			logrus.Debugf("replica skipped statement on %s: %s", q.Database, q.SQL)
+			end = !s.inTransaction
+		}
+	case ev.IsXID():
+		end = true
+	case ev.IsTableMap():
+		tm, err := ev.TableMap(s.format)
+		if err != nil {
+			return err
+		}
+		s.tables[ev.TableID(s.format)] = tm
+	case ev.IsWriteRows(), ev.IsUpdateRows(), ev.IsDeleteRows():
+		tm, ok := s.tables[ev.TableID(s.format)]
+		if !ok {
+			return ErrReplicaEvent.New("rows of an unknown table")
+		}
+
+		if s.r.replicates(tm.Database) {
+			if err := s.r.applyRows(ev, s.format, tm); err != nil {
+				return replicaApplyError{err}
+			}
+		}
+	}
+
+	if end {
+		s.inTransaction = false
+		s.tables = make(map[uint64]*mysql.TableMap)
+		lag := time.Since(time.Unix(int64(ev.Timestamp()), 0))
+		if lag < 0 {
+			lag = 0
+		}
+
+		s.r.mu.Lock()
+		if next != 0 {
+			s.r.status.Position = next
+		}
+		s.r.status.Lag = lag
+		s.r.mu.Unlock()
+	}
+
+	return nil
+}
+
+// applyRows applies the rows of a rows event to the table of the engine
+// with the same name.
+func (r *Replica) applyRows(ev mysql.BinlogEvent, f mysql.BinlogFormat, tm *mysql.TableMap) error {
+	rows, err := ev.Rows(f, tm)
+	if err != nil {
+		return err
+	}
+
+	ctx := sql.NewEmptyContext()
+	table, err := r.e.Catalog.ResolveTable(ctx, tm.Database, tm.Name)
+	if err != nil {
+		return err
+	}
+
+	schema := table.Schema()
+	if len(schema) != len(tm.Types) {
+		return ErrReplicaColumns.New(tm.Database, tm.Name, len(tm.Types), len(schema))
+	}
+
+	for {
+		w, ok := table.(sql.TableWrapper)
+		if !ok {
+			break
+		}
+		table = w.Underlying()
+	}
+
+	// The rows applied are notified to the row change hooks of the
+	// catalog, even if applying the rest fails.
+	changes := sql.TableChanges{Database: tm.Database, Table: tm.Name, Schema: schema}
+	defer func() { r.e.Catalog.NotifyRowChanges(ctx, changes) }()
+
+	for _, row := range rows.Rows {
+		switch {
+		case ev.IsWriteRows():
+			inserter, ok := table.(sql.Inserter)
+			if !ok {
+				return plan.ErrInsertIntoNotSupported.New()
+			}
+
+			values, err := replicaRow(tm, schema, rows.DataColumns, row.NullColumns, row.Data)
+			if err != nil {
+				return err
+			}
+
+			if err := inserter.Insert(ctx, values); err != nil {
+				return err
+			}
+			changes.Rows = append(changes.Rows, sql.RowChange{Type: sql.RowInserted, After: values})
+		case ev.IsDeleteRows():
+			deleter, ok := table.(sql.Deleter)
+			if !ok {
+				return plan.ErrDeleteFromNotSupported.New()
+			}
+
+			values, err := replicaRow(tm, schema, rows.IdentifyColumns, row.NullIdentifyColumns, row.Identify)
+			if err != nil {
+				return err
+			}
+
+			if err := deleter.Delete(ctx, values); err != nil {
+				return err
+			}
+			changes.Rows = append(changes.Rows, sql.RowChange{Type: sql.RowDeleted, Before: values})
+		default:
+			updater, ok := table.(sql.Updater)
+			if !ok {
+				return plan.ErrUpdateNotSupported.New()
+			}
+
+			old, err := replicaRow(tm, schema, rows.IdentifyColumns, row.NullIdentifyColumns, row.Identify)
+			if err != nil {
+				return err
+			}
+
+			values, err := replicaRow(tm, schema, rows.DataColumns, row.NullColumns, row.Data)
+			if err != nil {
+				return err
+			}
+
+			if err := updater.Update(ctx, old, values); err != nil {
+				return err
+			}
+			changes.Rows = append(changes.Rows, sql.RowChange{Type: sql.RowUpdated, Before: old, After: values})
+		}
+	}
+
+	return nil
+}
+
+// replicaRow decodes the values of a row in a rows event, converting them
+// to the types of the columns of the table.
+func replicaRow(
+	tm *mysql.TableMap,
+	schema sql.Schema,
+	columns, nulls mysql.Bitmap,
+	data []byte,
+) (sql.Row, error) {
+	if columns.BitCount() != len(schema) {
+		return nil, ErrReplicaRowImage.New(tm.Database, tm.Name)
+	}
+
+	row := make(sql.Row, len(schema))
+	pos := 0
+	for i, col := range schema {
+		if nulls.Bit(i) {
+			continue
+		}
+
+		v, n, err := mysql.CellValue(data, pos, tm.Types[i], tm.Metadata[i], col.Type.Type())
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+
+		if col.Type == sql.Blob {
+			row[i], err = col.Type.Convert(v.ToBytes())
+		} else {
+			row[i], err = col.Type.Convert(v.ToString())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return row, nil
+}
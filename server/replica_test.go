@@ -0,0 +1,94 @@
+package server
+
+import (
+	dsql "database/sql"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplica(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	binlog := NewBinlog(1)
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		Binlog:   binlog,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec("INSERT INTO test (c1) VALUES (2000)")
+	require.NoError(err)
+
+	// The replica has a copy of the table, and starts from the position
+	// of the primary.
+	e := sqle.NewDefault()
+	replicaDB := memory.NewDatabase("test")
+	table := memory.NewTable("test", sql.Schema{{Name: "c1", Type: sql.Int32, Source: "test"}})
+	replicaDB.AddTable("test", table)
+	e.AddDatabase(replicaDB)
+
+	p, err := strconv.Atoi(port)
+	require.NoError(err)
+	r := NewReplica(ReplicaConfig{Host: "localhost", Port: p, User: "root", ServerID: 2}, e)
+	done := make(chan error)
+	go func() { done <- r.Start() }()
+
+	// Wait for the replica to read the position of the primary.
+	waitFor(t, func() bool { return r.Status().Position != 0 })
+
+	_, err = db.Exec("INSERT INTO test (c1) VALUES (3000), (3001)")
+	require.NoError(err)
+	_, err = db.Exec("UPDATE test SET c1 = 4000 WHERE c1 = 3000")
+	require.NoError(err)
+	_, err = db.Exec("DELETE FROM test WHERE c1 = 3001")
+	require.NoError(err)
+
+	status := binlog.Status()
+	waitFor(t, func() bool { return uint64(r.Status().Position) == status.Position })
+
+	ctx := sql.NewEmptyContext()
+	rows, err := sql.NodeToRows(ctx, plan.NewResolvedTable(table))
+	require.NoError(err)
+	require.Equal([]sql.Row{{int32(4000)}}, rows)
+
+	st := r.Status()
+	require.True(st.Running)
+	require.Equal("binlog.000001", st.File)
+	require.NoError(st.LastError)
+	require.True(st.Lag < 5*time.Second, "lag: %s", st.Lag)
+
+	require.NoError(r.Stop())
+	require.NoError(<-done)
+	require.False(r.Status().Running)
+	require.True(ErrReplicaStopped.Is(r.Start()))
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+)
+
+const comRegisterSlave = 0x15
+
+const (
+	// binlogDumpNonBlock makes the server end the dump with an EOF packet
+	// once the replica has read all the events, instead of waiting for
+	// more of them.
+	binlogDumpNonBlock = 0x01
+	// binlogThroughGTID is set in COM_BINLOG_DUMP_GTID when the set of
+	// transactions of the replica is sent.
+	binlogThroughGTID = 0x04
+)
+
+// erMasterFatalReadingBinlog is the error sent to replicas that cannot
+// read the binary log.
+const erMasterFatalReadingBinlog = 1236
+
+// binlogHeartbeatPeriod is the time after which replicas waiting for
+// events are sent a heartbeat, so they know the server is alive.
+const binlogHeartbeatPeriod = 30 * time.Second
+
+var (
+	// ErrBinlogClosed is returned to replicas when the server does not have
+	// a binary log.
+	ErrBinlogClosed = errors.NewKind("Binary log is not open")
+	// ErrBinlogFile is returned to replicas asking for a file that is not
+	// in the binary log.
+	ErrBinlogFile = errors.NewKind("Could not find first log file name in binary log index file: %s")
+	// ErrBinlogPosition is returned to replicas asking for a position that
+	// is not the start of an event.
+	ErrBinlogPosition = errors.NewKind("Client requested master to start replication from %s")
+	// ErrBinlogPurged is returned to replicas asking for events that were
+	// discarded from the binary log.
+	ErrBinlogPurged = errors.NewKind("The master has purged binary logs containing the events the slave requires")
+	// ErrBinlogPurgedGTIDs is returned to replicas that have not executed
+	// transactions discarded from the binary log.
+	ErrBinlogPurgedGTIDs = errors.NewKind("The slave is connecting using CHANGE MASTER TO MASTER_AUTO_POSITION = 1, but the master has purged binary logs containing GTIDs that the slave requires")
+)
+
+// comRegisterSlave registers a replica, which needs nothing else from the
+// server.
+func (c *commandConn) comRegisterSlave(data []byte) error {
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return c.writeError(err)
+	}
+	return c.writeOK(conn.StatusFlags)
+}
+
+// comBinlogDump sends the binary log from a position given by the file
+// and the position in it.
+func (c *commandConn) comBinlogDump(data []byte) error {
+	if len(data) < 10 {
+		return c.writeError(errMalformedPacket())
+	}
+
+	p := binlogPosition{
+		file: string(data[10:]),
+		pos:  binary.LittleEndian.Uint32(data),
+	}
+	flags := binary.LittleEndian.Uint16(data[4:])
+	return c.dump(p, flags, nil)
+}
+
+// comBinlogDumpGTID sends the binary log from a position, or from the
+// start of the log if the replica does not give one, leaving out the
+// transactions the replica already executed.
+func (c *commandConn) comBinlogDumpGTID(data []byte) error {
+	if len(data) < 10 {
+		return c.writeError(errMalformedPacket())
+	}
+
+	flags := binary.LittleEndian.Uint16(data)
+	length := int(binary.LittleEndian.Uint32(data[6:]))
+	data = data[10:]
+	if len(data) < length+8 {
+		return c.writeError(errMalformedPacket())
+	}
+
+	p := binlogPosition{
+		file: string(data[:length]),
+		pos:  uint32(binary.LittleEndian.Uint64(data[length:])),
+	}
+	data = data[length+8:]
+
+	var executed gtidSet
+	if flags&binlogThroughGTID != 0 {
+		if len(data) < 4 {
+			return c.writeError(errMalformedPacket())
+		}
+
+		var ok bool
+		length := int(binary.LittleEndian.Uint32(data))
+		if len(data) < 4+length {
+			return c.writeError(errMalformedPacket())
+		}
+		if executed, ok = parseGTIDSet(data[4 : 4+length]); !ok {
+			return c.writeError(errMalformedPacket())
+		}
+	}
+
+	b, ok := c.binlog()
+	if ok {
+		if purged := b.purgedGTIDs(); purged > 0 && !executed.containsAll(b.uuid, purged) {
+			return c.writeError(replicationError(ErrBinlogPurgedGTIDs.New()))
+		}
+	}
+
+	return c.dump(p, flags, func(gno uint64) bool {
+		return executed.contains(b.uuid, gno)
+	})
+}
+
+// binlog returns the binary log of the server, if it has one.
+func (c *commandConn) binlog() (*Binlog, bool) {
+	b, ok := c.h.e.Catalog.ChangeRecorder().(*Binlog)
+	return b, ok
+}
+
+// dump sends the events of the binary log from a position, leaving out
+// the transactions skipped. Each event is sent in a packet after an OK
+// byte. Unless the replica asks for a non-blocking dump, it never ends,
+// waiting for new events and sending heartbeats while there are none.
+func (c *commandConn) dump(p binlogPosition, flags uint16, skip func(uint64) bool) error {
+	b, ok := c.binlog()
+	if !ok {
+		return c.writeError(replicationError(ErrBinlogClosed.New()))
+	}
+
+	if err := c.checkReplicationPrivileges(); err != nil {
+		return c.writeError(err)
+	}
+
+	if p.file == "" {
+		p.file = b.firstFile()
+	}
+	if p.pos < binlogMagicLength {
+		p.pos = binlogMagicLength
+	}
+
+	events, err := b.start(p)
+	if err != nil {
+		return c.writeError(replicationError(err))
+	}
+
+	for {
+		if err := c.writeEvents(events); err != nil {
+			return err
+		}
+
+		var changed <-chan struct{}
+		events, p, changed, err = b.read(p, skip)
+		if err != nil {
+			return c.writeError(replicationError(err))
+		}
+
+		if len(events) > 0 {
+			continue
+		}
+
+		if flags&binlogDumpNonBlock != 0 {
+			return c.writeEOF(0)
+		}
+
+		if err := c.flush(); err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(binlogHeartbeatPeriod):
+			events = [][]byte{b.heartbeat(p)}
+		}
+	}
+}
+
+func (c *commandConn) writeEvents(events [][]byte) error {
+	for _, event := range events {
+		if err := c.writePacket(append([]byte{mysql.OKPacket}, event...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReplicationPrivileges checks that the user can read every table,
+// if the catalog has a privilege store, as the binary log has the rows of
+// all of them.
+func (c *commandConn) checkReplicationPrivileges() error {
+	store := c.h.e.Catalog.Privileges()
+	if store == nil {
+		return nil
+	}
+
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return err
+	}
+
+	ok, err := sql.HasPrivileges(store, conn.User, "*", "*", sql.SelectPrivilege)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return sql.ErrPrivilegeCheckFailed.New("REPLICATION SLAVE", conn.User, "the binary log")
+	}
+
+	return nil
+}
+
+func replicationError(err error) error {
+	return mysql.NewSQLError(erMasterFatalReadingBinlog, mysql.SSUnknownSQLState, "%s", err)
+}
+
+// gtidSet is a set of global transaction identifiers, with the intervals
+// of transaction numbers of each server id. The intervals do not include
+// their end.
+type gtidSet map[[16]byte][][2]uint64
+
+// parseGTIDSet parses a set with the number of server ids and, for each of
+// them, the id, the number of intervals and the intervals.
+func parseGTIDSet(data []byte) (gtidSet, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+
+	set := make(gtidSet)
+	n := binary.LittleEndian.Uint64(data)
+	data = data[8:]
+	for i := uint64(0); i < n; i++ {
+		if len(data) < 24 {
+			return nil, false
+		}
+
+		var sid [16]byte
+		copy(sid[:], data)
+		intervals := binary.LittleEndian.Uint64(data[16:])
+		data = data[24:]
+		if uint64(len(data)) < intervals*16 {
+			return nil, false
+		}
+
+		for j := uint64(0); j < intervals; j++ {
+			set[sid] = append(set[sid], [2]uint64{
+				binary.LittleEndian.Uint64(data),
+				binary.LittleEndian.Uint64(data[8:]),
+			})
+			data = data[16:]
+		}
+	}
+
+	return set, len(data) == 0
+}
+
+func (s gtidSet) contains(sid [16]byte, gno uint64) bool {
+	for _, interval := range s[sid] {
+		if gno >= interval[0] && gno < interval[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll returns whether the set contains the transactions of a
+// server id from the first one to the given one.
+func (s gtidSet) containsAll(sid [16]byte, gno uint64) bool {
+	for _, interval := range s[sid] {
+		if interval[0] <= 1 && gno < interval[1] {
+			return true
+		}
+	}
+	return false
+}
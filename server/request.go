@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+)
+
+// ErrEmptyQuery is returned when a request of the HTTP and gRPC clients
+// has no query.
+var ErrEmptyQuery = errors.NewKind("Query was empty")
+
+// requestConnectionIDBase is the first connection ID of the requests of
+// the HTTP and gRPC clients, which is far from the ones of the other
+// clients.
+const requestConnectionIDBase = 3 << 30
+
+// requestSessions creates the sessions of the clients that don't keep a
+// connection, such as the HTTP and gRPC ones, which authenticate and run
+// their queries in a new session on every request.
+type requestSessions struct {
+	h    *Handler
+	auth mysql.AuthServer
+
+	mu     sync.Mutex
+	lastID uint32
+}
+
+func newRequestSessions(h *Handler, a mysql.AuthServer) *requestSessions {
+	return &requestSessions{h: h, auth: a, lastID: requestConnectionIDBase}
+}
+
+// requestSession is the session of a request, whose queries are cancelled
+// if the request is.
+type requestSession struct {
+	s      *requestSessions
+	ctx    context.Context
+	id     uint32
+	user   string
+	client net.Addr
+	sess   sql.Session
+}
+
+// open validates the credentials of the client of a request and creates a
+// session for it. The password is scrambled to validate it as a
+// mysql_native_password hash, as with the PLAIN mechanism of the X
+// Protocol.
+func (s *requestSessions) open(
+	ctx context.Context,
+	client string,
+	user string,
+	password string,
+) (*requestSession, error) {
+	var addr net.Addr
+	if tcpAddr, err := net.ResolveTCPAddr("tcp", client); err == nil {
+		addr = tcpAddr
+	}
+
+	salt, err := mysql.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	var scramble []byte
+	if password != "" {
+		scramble = mysql.ScramblePassword(salt, []byte(password))
+	}
+
+	if _, err := s.auth.ValidateHash(salt, user, scramble, addr); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.lastID++
+	id := s.lastID
+	s.mu.Unlock()
+
+	return &requestSession{
+		s:      s,
+		ctx:    ctx,
+		id:     id,
+		user:   user,
+		client: addr,
+		sess:   s.h.sm.newBaseSession(id, client, user),
+	}, nil
+}
+
+// close ends the session, unlocking its tables.
+func (r *requestSession) close() {
+	h := r.s.h
+	if h.limits != nil {
+		h.limits.releaseUser(r.user)
+	}
+	h.auditDisconnect(r.id, r.user, r.client)
+
+	h.sm.closeSession(r.id)
+	if err := h.e.Catalog.UnlockTables(nil, r.id); err != nil {
+		h.sm.connLogger(r.id).Errorf("unable to unlock tables on session close: %s", err)
+	}
+}
+
+// use makes the given database the current one.
+func (r *requestSession) use(db string) error {
+	return r.query("USE "+quoteIdentifier(db), nil)
+}
+
+// query runs a query in the session, giving its rows to the write
+// function, if any.
+func (r *requestSession) query(
+	query string,
+	write func(sql.Schema, sql.RowIter) error,
+) (err error) {
+	h := r.s.h
+	if err := h.checkShutdown(); err != nil {
+		return err
+	}
+
+	ctx := h.sm.newContext(r.sess, query)
+	if !h.e.Async(ctx, query) {
+		ctx = ctx.WithContext(r.ctx)
+	}
+
+	audit := h.auditQuery(ctx)
+	defer func() { audit.done(err) }()
+
+	schema, iter, err := audit.query(query)
+	if err != nil {
+		return err
+	}
+
+	if write == nil {
+		_, err = sql.RowIterToRows(iter)
+		return err
+	}
+
+	err = write(schema, iter)
+	if cerr := iter.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
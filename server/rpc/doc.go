@@ -0,0 +1,5 @@
+// Package rpc has the gRPC service that runs queries in the engine, as
+// defined in query.proto, and its clients.
+package rpc
+
+//go:generate protoc --go_out=plugins=grpc:. query.proto
@@ -0,0 +1,868 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: query.proto
+
+package rpc
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ExecuteQueryRequest struct {
+	// Query is the statement to run.
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Database is the database the statement is run at, if any.
+	Database             string   `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExecuteQueryRequest) Reset()         { *m = ExecuteQueryRequest{} }
+func (m *ExecuteQueryRequest) String() string { return proto.CompactTextString(m) }
+func (*ExecuteQueryRequest) ProtoMessage()    {}
+func (*ExecuteQueryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{0}
+}
+
+func (m *ExecuteQueryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExecuteQueryRequest.Unmarshal(m, b)
+}
+func (m *ExecuteQueryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExecuteQueryRequest.Marshal(b, m, deterministic)
+}
+func (m *ExecuteQueryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExecuteQueryRequest.Merge(m, src)
+}
+func (m *ExecuteQueryRequest) XXX_Size() int {
+	return xxx_messageInfo_ExecuteQueryRequest.Size(m)
+}
+func (m *ExecuteQueryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExecuteQueryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExecuteQueryRequest proto.InternalMessageInfo
+
+func (m *ExecuteQueryRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *ExecuteQueryRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+type ExecuteQueryResponse struct {
+	// Columns are the columns of the result, only sent in the first
+	// response.
+	Columns []*Column `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	// Rows are the next rows of the result.
+	Rows                 []*Row   `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExecuteQueryResponse) Reset()         { *m = ExecuteQueryResponse{} }
+func (m *ExecuteQueryResponse) String() string { return proto.CompactTextString(m) }
+func (*ExecuteQueryResponse) ProtoMessage()    {}
+func (*ExecuteQueryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{1}
+}
+
+func (m *ExecuteQueryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExecuteQueryResponse.Unmarshal(m, b)
+}
+func (m *ExecuteQueryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExecuteQueryResponse.Marshal(b, m, deterministic)
+}
+func (m *ExecuteQueryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExecuteQueryResponse.Merge(m, src)
+}
+func (m *ExecuteQueryResponse) XXX_Size() int {
+	return xxx_messageInfo_ExecuteQueryResponse.Size(m)
+}
+func (m *ExecuteQueryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExecuteQueryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExecuteQueryResponse proto.InternalMessageInfo
+
+func (m *ExecuteQueryResponse) GetColumns() []*Column {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *ExecuteQueryResponse) GetRows() []*Row {
+	if m != nil {
+		return m.Rows
+	}
+	return nil
+}
+
+type ListDatabasesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListDatabasesRequest) Reset()         { *m = ListDatabasesRequest{} }
+func (m *ListDatabasesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListDatabasesRequest) ProtoMessage()    {}
+func (*ListDatabasesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{2}
+}
+
+func (m *ListDatabasesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListDatabasesRequest.Unmarshal(m, b)
+}
+func (m *ListDatabasesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListDatabasesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListDatabasesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListDatabasesRequest.Merge(m, src)
+}
+func (m *ListDatabasesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListDatabasesRequest.Size(m)
+}
+func (m *ListDatabasesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListDatabasesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListDatabasesRequest proto.InternalMessageInfo
+
+type ListDatabasesResponse struct {
+	Databases            []string `protobuf:"bytes,1,rep,name=databases,proto3" json:"databases,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListDatabasesResponse) Reset()         { *m = ListDatabasesResponse{} }
+func (m *ListDatabasesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListDatabasesResponse) ProtoMessage()    {}
+func (*ListDatabasesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{3}
+}
+
+func (m *ListDatabasesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListDatabasesResponse.Unmarshal(m, b)
+}
+func (m *ListDatabasesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListDatabasesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListDatabasesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListDatabasesResponse.Merge(m, src)
+}
+func (m *ListDatabasesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListDatabasesResponse.Size(m)
+}
+func (m *ListDatabasesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListDatabasesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListDatabasesResponse proto.InternalMessageInfo
+
+func (m *ListDatabasesResponse) GetDatabases() []string {
+	if m != nil {
+		return m.Databases
+	}
+	return nil
+}
+
+type ListTablesRequest struct {
+	Database             string   `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTablesRequest) Reset()         { *m = ListTablesRequest{} }
+func (m *ListTablesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTablesRequest) ProtoMessage()    {}
+func (*ListTablesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{4}
+}
+
+func (m *ListTablesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesRequest.Unmarshal(m, b)
+}
+func (m *ListTablesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListTablesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesRequest.Merge(m, src)
+}
+func (m *ListTablesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTablesRequest.Size(m)
+}
+func (m *ListTablesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTablesRequest proto.InternalMessageInfo
+
+func (m *ListTablesRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+type ListTablesResponse struct {
+	Tables               []string `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTablesResponse) Reset()         { *m = ListTablesResponse{} }
+func (m *ListTablesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListTablesResponse) ProtoMessage()    {}
+func (*ListTablesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{5}
+}
+
+func (m *ListTablesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesResponse.Unmarshal(m, b)
+}
+func (m *ListTablesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListTablesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesResponse.Merge(m, src)
+}
+func (m *ListTablesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListTablesResponse.Size(m)
+}
+func (m *ListTablesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTablesResponse proto.InternalMessageInfo
+
+func (m *ListTablesResponse) GetTables() []string {
+	if m != nil {
+		return m.Tables
+	}
+	return nil
+}
+
+type DescribeTableRequest struct {
+	Database             string   `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Table                string   `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DescribeTableRequest) Reset()         { *m = DescribeTableRequest{} }
+func (m *DescribeTableRequest) String() string { return proto.CompactTextString(m) }
+func (*DescribeTableRequest) ProtoMessage()    {}
+func (*DescribeTableRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{6}
+}
+
+func (m *DescribeTableRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DescribeTableRequest.Unmarshal(m, b)
+}
+func (m *DescribeTableRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DescribeTableRequest.Marshal(b, m, deterministic)
+}
+func (m *DescribeTableRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DescribeTableRequest.Merge(m, src)
+}
+func (m *DescribeTableRequest) XXX_Size() int {
+	return xxx_messageInfo_DescribeTableRequest.Size(m)
+}
+func (m *DescribeTableRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DescribeTableRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DescribeTableRequest proto.InternalMessageInfo
+
+func (m *DescribeTableRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+func (m *DescribeTableRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+type DescribeTableResponse struct {
+	Columns              []*Column `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *DescribeTableResponse) Reset()         { *m = DescribeTableResponse{} }
+func (m *DescribeTableResponse) String() string { return proto.CompactTextString(m) }
+func (*DescribeTableResponse) ProtoMessage()    {}
+func (*DescribeTableResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{7}
+}
+
+func (m *DescribeTableResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DescribeTableResponse.Unmarshal(m, b)
+}
+func (m *DescribeTableResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DescribeTableResponse.Marshal(b, m, deterministic)
+}
+func (m *DescribeTableResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DescribeTableResponse.Merge(m, src)
+}
+func (m *DescribeTableResponse) XXX_Size() int {
+	return xxx_messageInfo_DescribeTableResponse.Size(m)
+}
+func (m *DescribeTableResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DescribeTableResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DescribeTableResponse proto.InternalMessageInfo
+
+func (m *DescribeTableResponse) GetColumns() []*Column {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+// Column is a column of a table or a result.
+type Column struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Type is the MySQL type of the column, such as INT64 or VARCHAR.
+	Type     string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Nullable bool   `protobuf:"varint,3,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	// Source is the table the column belongs to, if any.
+	Source               string   `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Column) Reset()         { *m = Column{} }
+func (m *Column) String() string { return proto.CompactTextString(m) }
+func (*Column) ProtoMessage()    {}
+func (*Column) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{8}
+}
+
+func (m *Column) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Column.Unmarshal(m, b)
+}
+func (m *Column) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Column.Marshal(b, m, deterministic)
+}
+func (m *Column) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Column.Merge(m, src)
+}
+func (m *Column) XXX_Size() int {
+	return xxx_messageInfo_Column.Size(m)
+}
+func (m *Column) XXX_DiscardUnknown() {
+	xxx_messageInfo_Column.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Column proto.InternalMessageInfo
+
+func (m *Column) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Column) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Column) GetNullable() bool {
+	if m != nil {
+		return m.Nullable
+	}
+	return false
+}
+
+func (m *Column) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+// Row is a row of a result, with a value for each column.
+type Row struct {
+	Values               []*Value `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Row) Reset()         { *m = Row{} }
+func (m *Row) String() string { return proto.CompactTextString(m) }
+func (*Row) ProtoMessage()    {}
+func (*Row) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{9}
+}
+
+func (m *Row) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Row.Unmarshal(m, b)
+}
+func (m *Row) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Row.Marshal(b, m, deterministic)
+}
+func (m *Row) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Row.Merge(m, src)
+}
+func (m *Row) XXX_Size() int {
+	return xxx_messageInfo_Row.Size(m)
+}
+func (m *Row) XXX_DiscardUnknown() {
+	xxx_messageInfo_Row.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Row proto.InternalMessageInfo
+
+func (m *Row) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+// Value is a value of a row. Integers and floating point numbers are sent
+// as numbers, binary strings as bytes and the rest of the values, such as
+// decimals, dates or JSON documents, as strings.
+type Value struct {
+	// Types that are valid to be assigned to Kind:
+	//	*Value_NullValue
+	//	*Value_IntValue
+	//	*Value_UintValue
+	//	*Value_DoubleValue
+	//	*Value_StringValue
+	//	*Value_BytesValue
+	Kind                 isValue_Kind `protobuf_oneof:"kind"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *Value) Reset()         { *m = Value{} }
+func (m *Value) String() string { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()    {}
+func (*Value) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c6ac9b241082464, []int{10}
+}
+
+func (m *Value) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Value.Unmarshal(m, b)
+}
+func (m *Value) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Value.Marshal(b, m, deterministic)
+}
+func (m *Value) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Value.Merge(m, src)
+}
+func (m *Value) XXX_Size() int {
+	return xxx_messageInfo_Value.Size(m)
+}
+func (m *Value) XXX_DiscardUnknown() {
+	xxx_messageInfo_Value.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Value proto.InternalMessageInfo
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_NullValue struct {
+	NullValue bool `protobuf:"varint,1,opt,name=null_value,json=nullValue,proto3,oneof"`
+}
+
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"zigzag64,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_UintValue struct {
+	UintValue uint64 `protobuf:"varint,3,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+type Value_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,6,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+func (*Value_NullValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_UintValue) isValue_Kind() {}
+
+func (*Value_DoubleValue) isValue_Kind() {}
+
+func (*Value_StringValue) isValue_Kind() {}
+
+func (*Value_BytesValue) isValue_Kind() {}
+
+func (m *Value) GetKind() isValue_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
+
+func (m *Value) GetNullValue() bool {
+	if x, ok := m.GetKind().(*Value_NullValue); ok {
+		return x.NullValue
+	}
+	return false
+}
+
+func (m *Value) GetIntValue() int64 {
+	if x, ok := m.GetKind().(*Value_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
+}
+
+func (m *Value) GetUintValue() uint64 {
+	if x, ok := m.GetKind().(*Value_UintValue); ok {
+		return x.UintValue
+	}
+	return 0
+}
+
+func (m *Value) GetDoubleValue() float64 {
+	if x, ok := m.GetKind().(*Value_DoubleValue); ok {
+		return x.DoubleValue
+	}
+	return 0
+}
+
+func (m *Value) GetStringValue() string {
+	if x, ok := m.GetKind().(*Value_StringValue); ok {
+		return x.StringValue
+	}
+	return ""
+}
+
+func (m *Value) GetBytesValue() []byte {
+	if x, ok := m.GetKind().(*Value_BytesValue); ok {
+		return x.BytesValue
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Value) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Value_NullValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_UintValue)(nil),
+		(*Value_DoubleValue)(nil),
+		(*Value_StringValue)(nil),
+		(*Value_BytesValue)(nil),
+	}
+}
+
+func init() {
+	proto.RegisterType((*ExecuteQueryRequest)(nil), "gms.rpc.ExecuteQueryRequest")
+	proto.RegisterType((*ExecuteQueryResponse)(nil), "gms.rpc.ExecuteQueryResponse")
+	proto.RegisterType((*ListDatabasesRequest)(nil), "gms.rpc.ListDatabasesRequest")
+	proto.RegisterType((*ListDatabasesResponse)(nil), "gms.rpc.ListDatabasesResponse")
+	proto.RegisterType((*ListTablesRequest)(nil), "gms.rpc.ListTablesRequest")
+	proto.RegisterType((*ListTablesResponse)(nil), "gms.rpc.ListTablesResponse")
+	proto.RegisterType((*DescribeTableRequest)(nil), "gms.rpc.DescribeTableRequest")
+	proto.RegisterType((*DescribeTableResponse)(nil), "gms.rpc.DescribeTableResponse")
+	proto.RegisterType((*Column)(nil), "gms.rpc.Column")
+	proto.RegisterType((*Row)(nil), "gms.rpc.Row")
+	proto.RegisterType((*Value)(nil), "gms.rpc.Value")
+}
+
+func init() { proto.RegisterFile("query.proto", fileDescriptor_5c6ac9b241082464) }
+
+var fileDescriptor_5c6ac9b241082464 = []byte{
+	// 540 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xed, 0x6e, 0xd3, 0x30,
+	0x14, 0xad, 0xfb, 0x91, 0xb5, 0xb7, 0x1d, 0x08, 0xd3, 0x8d, 0x2a, 0x6c, 0x23, 0x18, 0x09, 0x15,
+	0x09, 0x02, 0x1a, 0xe2, 0x05, 0xca, 0x26, 0xfa, 0x03, 0x26, 0xb0, 0x10, 0x3f, 0xf8, 0x83, 0x92,
+	0xd4, 0x2a, 0x11, 0x69, 0xdc, 0xc5, 0x36, 0xa5, 0x0f, 0xc7, 0xa3, 0xf0, 0x2e, 0xc8, 0x1f, 0x49,
+	0x9b, 0xaa, 0x95, 0xe0, 0x5f, 0xee, 0x39, 0xe7, 0xde, 0x73, 0xec, 0xfa, 0x16, 0xfa, 0xb7, 0x8a,
+	0x15, 0xeb, 0x70, 0x59, 0x70, 0xc9, 0xf1, 0xd1, 0x7c, 0x21, 0xc2, 0x62, 0x99, 0x90, 0x77, 0x70,
+	0xff, 0xfa, 0x17, 0x4b, 0x94, 0x64, 0x9f, 0x34, 0x4d, 0xd9, 0xad, 0x62, 0x42, 0xe2, 0x21, 0x74,
+	0x8c, 0x7c, 0x84, 0x02, 0x34, 0xee, 0x51, 0x5b, 0x60, 0x1f, 0xba, 0xb3, 0x48, 0x46, 0x71, 0x24,
+	0xd8, 0xa8, 0x69, 0x88, 0xaa, 0x26, 0x09, 0x0c, 0xeb, 0x83, 0xc4, 0x92, 0xe7, 0x82, 0xe1, 0x67,
+	0x70, 0x94, 0xf0, 0x4c, 0x2d, 0x72, 0x31, 0x42, 0x41, 0x6b, 0xdc, 0xbf, 0xbc, 0x1b, 0x3a, 0xef,
+	0xf0, 0xad, 0xc1, 0x69, 0xc9, 0xe3, 0x00, 0xda, 0x05, 0x5f, 0x89, 0x51, 0xd3, 0xe8, 0x06, 0x95,
+	0x8e, 0xf2, 0x15, 0x35, 0x0c, 0x39, 0x85, 0xe1, 0xfb, 0x54, 0xc8, 0x2b, 0x67, 0x2a, 0x5c, 0x5c,
+	0xf2, 0x06, 0x4e, 0x76, 0x70, 0xe7, 0x7e, 0x06, 0xbd, 0x32, 0xa1, 0xf5, 0xef, 0xd1, 0x0d, 0x40,
+	0x5e, 0xc2, 0x3d, 0xdd, 0xf6, 0x39, 0x8a, 0xb3, 0x6a, 0x56, 0xed, 0x90, 0x68, 0xe7, 0x90, 0xcf,
+	0x01, 0x6f, 0x37, 0x38, 0x93, 0x53, 0xf0, 0xa4, 0x41, 0x9c, 0x83, 0xab, 0xc8, 0x14, 0x86, 0x57,
+	0x4c, 0x24, 0x45, 0x1a, 0x33, 0xd3, 0xf1, 0x0f, 0x0e, 0xfa, 0xe2, 0x4d, 0xb7, 0xbb, 0x5f, 0x5b,
+	0x90, 0x09, 0x9c, 0xec, 0x4c, 0xfa, 0xef, 0xdb, 0x25, 0x33, 0xf0, 0x2c, 0x84, 0x31, 0xb4, 0xf3,
+	0x68, 0x51, 0x7a, 0x9b, 0x6f, 0x8d, 0xc9, 0xf5, 0xb2, 0xb4, 0x35, 0xdf, 0x3a, 0x67, 0xae, 0xb2,
+	0xcc, 0xc4, 0x69, 0x05, 0x68, 0xdc, 0xa5, 0x55, 0xad, 0xcf, 0x2c, 0xb8, 0x2a, 0x12, 0x36, 0x6a,
+	0x9b, 0x0e, 0x57, 0x91, 0x17, 0xd0, 0xa2, 0x7c, 0x85, 0x9f, 0x82, 0xf7, 0x33, 0xca, 0x14, 0x2b,
+	0x63, 0xdd, 0xa9, 0x62, 0x7d, 0xd1, 0x30, 0x75, 0x2c, 0xf9, 0x83, 0xa0, 0x63, 0x10, 0xfc, 0x08,
+	0x40, 0x0f, 0xff, 0x66, 0x08, 0x13, 0xad, 0x3b, 0x6d, 0xd0, 0x9e, 0xc6, 0xac, 0xe0, 0x1c, 0x7a,
+	0x69, 0x2e, 0x1d, 0xaf, 0x63, 0xe2, 0x69, 0x83, 0x76, 0xd3, 0x5c, 0x56, 0xfd, 0x6a, 0xc3, 0xeb,
+	0xb8, 0x6d, 0xdd, 0xaf, 0x2a, 0xc1, 0x13, 0x18, 0xcc, 0xb8, 0x8a, 0x33, 0xe6, 0x24, 0x3a, 0x37,
+	0x9a, 0x36, 0x68, 0xdf, 0xa2, 0x95, 0x48, 0xc8, 0x22, 0xcd, 0xe7, 0x4e, 0xd4, 0xd1, 0x87, 0xd3,
+	0x22, 0x8b, 0x5a, 0xd1, 0x63, 0xe8, 0xc7, 0x6b, 0xc9, 0x84, 0xd3, 0x78, 0x01, 0x1a, 0x0f, 0xa6,
+	0x0d, 0x0a, 0x06, 0x34, 0x92, 0x89, 0x07, 0xed, 0x1f, 0x69, 0x3e, 0xbb, 0xfc, 0xdd, 0x84, 0x8e,
+	0xd9, 0x07, 0xfc, 0x01, 0x06, 0xdb, 0xfb, 0x81, 0xcf, 0xaa, 0x1b, 0xd9, 0xb3, 0x7f, 0xfe, 0xf9,
+	0x01, 0xd6, 0xfe, 0xec, 0xaf, 0x10, 0xbe, 0x81, 0xe3, 0xda, 0x8b, 0xc7, 0x9b, 0x8e, 0x7d, 0x1b,
+	0xe2, 0x5f, 0x1c, 0xa2, 0xdd, 0x43, 0xba, 0x06, 0xd8, 0xbc, 0x6c, 0xec, 0xd7, 0xd4, 0xb5, 0xfd,
+	0xf0, 0x1f, 0xee, 0xe5, 0xdc, 0x98, 0x1b, 0x38, 0xae, 0x3d, 0xd4, 0xad, 0x58, 0xfb, 0x56, 0xc1,
+	0xbf, 0x38, 0x44, 0xdb, 0x79, 0x93, 0x00, 0x1e, 0x24, 0x7c, 0x11, 0xce, 0x53, 0xf9, 0x5d, 0xc5,
+	0xa1, 0x28, 0x92, 0x59, 0xd9, 0xf0, 0x11, 0x7d, 0x6d, 0x15, 0xcb, 0x24, 0xf6, 0xcc, 0x1f, 0xda,
+	0xeb, 0xbf, 0x03, 0x00, 0x98, 0xbd, 0x36, 0xa6, 0xdf, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// QueryClient is the client API for Query service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type QueryClient interface {
+	// ExecuteQuery runs a query. The first response has the columns of its
+	// result, and the rest of them its rows, in batches.
+	ExecuteQuery(ctx context.Context, in *ExecuteQueryRequest, opts ...grpc.CallOption) (Query_ExecuteQueryClient, error)
+	// ListDatabases returns the names of the databases.
+	ListDatabases(ctx context.Context, in *ListDatabasesRequest, opts ...grpc.CallOption) (*ListDatabasesResponse, error)
+	// ListTables returns the names of the tables of a database.
+	ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesResponse, error)
+	// DescribeTable returns the columns of a table.
+	DescribeTable(ctx context.Context, in *DescribeTableRequest, opts ...grpc.CallOption) (*DescribeTableResponse, error)
+}
+
+type queryClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewQueryClient(cc *grpc.ClientConn) QueryClient {
+	return &queryClient{cc}
+}
+
+func (c *queryClient) ExecuteQuery(ctx context.Context, in *ExecuteQueryRequest, opts ...grpc.CallOption) (Query_ExecuteQueryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Query_serviceDesc.Streams[0], "/gms.rpc.Query/ExecuteQuery", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &queryExecuteQueryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Query_ExecuteQueryClient interface {
+	Recv() (*ExecuteQueryResponse, error)
+	grpc.ClientStream
+}
+
+type queryExecuteQueryClient struct {
+	grpc.ClientStream
+}
+
+func (x *queryExecuteQueryClient) Recv() (*ExecuteQueryResponse, error) {
+	m := new(ExecuteQueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *queryClient) ListDatabases(ctx context.Context, in *ListDatabasesRequest, opts ...grpc.CallOption) (*ListDatabasesResponse, error) {
+	out := new(ListDatabasesResponse)
+	err := c.cc.Invoke(ctx, "/gms.rpc.Query/ListDatabases", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesResponse, error) {
+	out := new(ListTablesResponse)
+	err := c.cc.Invoke(ctx, "/gms.rpc.Query/ListTables", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) DescribeTable(ctx context.Context, in *DescribeTableRequest, opts ...grpc.CallOption) (*DescribeTableResponse, error) {
+	out := new(DescribeTableResponse)
+	err := c.cc.Invoke(ctx, "/gms.rpc.Query/DescribeTable", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryServer is the server API for Query service.
+type QueryServer interface {
+	// ExecuteQuery runs a query. The first response has the columns of its
+	// result, and the rest of them its rows, in batches.
+	ExecuteQuery(*ExecuteQueryRequest, Query_ExecuteQueryServer) error
+	// ListDatabases returns the names of the databases.
+	ListDatabases(context.Context, *ListDatabasesRequest) (*ListDatabasesResponse, error)
+	// ListTables returns the names of the tables of a database.
+	ListTables(context.Context, *ListTablesRequest) (*ListTablesResponse, error)
+	// DescribeTable returns the columns of a table.
+	DescribeTable(context.Context, *DescribeTableRequest) (*DescribeTableResponse, error)
+}
+
+func RegisterQueryServer(s *grpc.Server, srv QueryServer) {
+	s.RegisterService(&_Query_serviceDesc, srv)
+}
+
+func _Query_ExecuteQuery_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecuteQueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServer).ExecuteQuery(m, &queryExecuteQueryServer{stream})
+}
+
+type Query_ExecuteQueryServer interface {
+	Send(*ExecuteQueryResponse) error
+	grpc.ServerStream
+}
+
+type queryExecuteQueryServer struct {
+	grpc.ServerStream
+}
+
+func (x *queryExecuteQueryServer) Send(m *ExecuteQueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Query_ListDatabases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDatabasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).ListDatabases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gms.rpc.Query/ListDatabases",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).ListDatabases(ctx, req.(*ListDatabasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTablesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).ListTables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gms.rpc.Query/ListTables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).ListTables(ctx, req.(*ListTablesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_DescribeTable_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeTableRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).DescribeTable(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gms.rpc.Query/DescribeTable",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).DescribeTable(ctx, req.(*DescribeTableRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Query_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "gms.rpc.Query",
+	HandlerType: (*QueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDatabases",
+			Handler:    _Query_ListDatabases_Handler,
+		},
+		{
+			MethodName: "ListTables",
+			Handler:    _Query_ListTables_Handler,
+		},
+		{
+			MethodName: "DescribeTable",
+			Handler:    _Query_DescribeTable_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecuteQuery",
+			Handler:       _Query_ExecuteQuery_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "query.proto",
+}
@@ -1,11 +1,16 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
 	sqle "github.com/src-d/go-mysql-server"
 	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
 
 	"vitess.io/vitess/go/mysql"
 )
@@ -14,6 +19,15 @@ import (
 type Server struct {
 	Listener *mysql.Listener
 	h        *Handler
+	x        *xServer
+	http     *httpServer
+	metrics  *endpointServer
+	health   *endpointServer
+	grpc     *grpcServer
+	// replica and maxReplicaLag are the replica whose status is reported,
+	// if any, and the lag after which the server is not ready.
+	replica       *Replica
+	maxReplicaLag time.Duration
 }
 
 // Config for the mysql server.
@@ -22,11 +36,108 @@ type Config struct {
 	Protocol string
 	// Address of the server.
 	Address string
+	// Socket is the path of a Unix socket the server also accepts
+	// connections from, as local clients connect to by default. It's
+	// reported by the socket session variable.
+	Socket string
 	// Auth of the server.
 	Auth auth.Auth
 	// Tracer to use in the server. By default, a noop tracer will be used if
 	// no tracer is provided.
 	Tracer opentracing.Tracer
+	// Logger is the logger of the server and the queries it runs, whose logs
+	// have the connection id, the query id and the user as fields. By
+	// default, the standard logger of logrus is used.
+	Logger logrus.FieldLogger
+	// TLS enables encrypted connections to the server. If nil, only
+	// unencrypted connections are accepted.
+	TLS *TLSConfig
+	// MaxConnections is the maximum number of clients connected at the same
+	// time. Clients connecting after it's reached are rejected with a "Too
+	// many connections" error. If 0, the number of clients is not limited.
+	MaxConnections int
+	// MaxUserConnections is the maximum number of connections of each user,
+	// like max_user_connections in MySQL. If 0, it's not limited.
+	MaxUserConnections int
+	// UserConnections overrides MaxUserConnections for the given users.
+	UserConnections map[string]int
+	// MaxQueries limits the queries of all the clients, so they cannot
+	// overload the server. Queries over the limits fail with an
+	// ER_USER_LIMIT_REACHED error. KILL statements are never limited.
+	MaxQueries QueryLimits
+	// MaxUserQueries limits the queries of each user, so a single client
+	// cannot starve the rest.
+	MaxUserQueries QueryLimits
+	// UserQueries overrides MaxUserQueries for the given users.
+	UserQueries map[string]QueryLimits
+	// ProxyProtocol makes the server expect a v1 or v2 PROXY protocol
+	// header at the start of every TCP connection, as sent by HAProxy and
+	// other load balancers, which gives the address of the client used to
+	// authenticate it and shown in the process list. Connections without
+	// it are closed, so it must only be used behind such proxies.
+	ProxyProtocol bool
+	// Compression offers the zlib compressed protocol to the clients, which
+	// use it if they request it. The zstd compression of MySQL 8 is not
+	// offered, and it cannot be used with TLS.
+	Compression bool
+	// Binlog is the binary log the changes made by the clients are written
+	// to, which replicas read. If nil, the server has no binary log.
+	Binlog *Binlog
+	// XAddress is the TCP address the server accepts X Protocol
+	// connections at, used by the X DevAPI connectors, usually on port
+	// 33060. If empty, the X Protocol is not served.
+	XAddress string
+	// HTTPAddress is the TCP address of an HTTP endpoint that runs the
+	// queries posted to /query, sending their results as JSON or CSV, for
+	// the clients that cannot use the MySQL protocol. If empty, it's not
+	// served.
+	HTTPAddress string
+	// GRPCAddress is the TCP address of the gRPC query service defined in
+	// the rpc package, which runs queries and describes the databases. If
+	// empty, it's not served.
+	GRPCAddress string
+	// Audit receives the audit events of the server: the authentication of
+	// the clients, their disconnection and every statement they execute.
+	// If nil, no audit events are recorded.
+	Audit AuditSink
+	// SlowQueryLog receives the queries that take at least the
+	// long_query_time of their session to run, with the rows they examined
+	// and sent and their plan. If nil, slow queries are not recorded.
+	SlowQueryLog SlowQuerySink
+	// GeneralLog receives every statement sent by the clients, as soon as
+	// it's received. If nil, there is no general log.
+	GeneralLog GeneralLogSink
+	// GeneralLogRedact, if set, is applied to the statements before they
+	// are given to GeneralLog, so the data they contain is not logged.
+	// RedactLiterals replaces all their literals.
+	GeneralLogRedact func(query string) string
+	// MetricsAddress is the TCP address of an HTTP endpoint that serves the
+	// metrics of the engine and the server at /metrics, in the format of
+	// Prometheus. If empty, it's not served.
+	MetricsAddress string
+	// HealthAddress is the TCP address of an HTTP endpoint that serves the
+	// status of the server for the probes of orchestrators, its liveness
+	// at /healthz and its readiness at /readyz. If empty, it's not served.
+	HealthAddress string
+	// Replica is the replica applying the changes of a primary to the
+	// engine, if any, whose status is reported by the server. The server
+	// does not start or stop it.
+	Replica *Replica
+	// MaxReplicaLag is how far behind its primary the replica can be for
+	// the server to be ready. If 0, the lag is not checked.
+	MaxReplicaLag time.Duration
+	// OnSessionStart is called with the session of every client once it's
+	// authenticated, before it runs any statement, so custom state can be
+	// attached to it with SetState, such as the tenant of the user. It's
+	// called for the clients of all the protocols of the server.
+	OnSessionStart func(sess sql.Session)
+	// OnSessionEnd is called with the session of every client when it
+	// disconnects, so the custom state attached to it can be released.
+	OnSessionEnd func(sess sql.Session)
+	// LocalInfile allows the clients to load their own files with LOAD
+	// DATA LOCAL INFILE, like local_infile in MySQL, which they send when
+	// the server requests them. Only the MySQL protocol supports it.
+	LocalInfile bool
 
 	ConnReadTimeout  time.Duration
 	ConnWriteTimeout time.Duration
@@ -55,33 +166,216 @@ func NewServer(cfg Config, e *sqle.Engine, sb SessionBuilder) (*Server, error) {
 		cfg.ConnWriteTimeout = 0
 	}
 
-	handler := NewHandler(e,
-		NewSessionManager(
-			sb, tracer,
-			e.Catalog.MemoryManager,
-			cfg.Address),
-		cfg.ConnReadTimeout)
+	if cfg.Compression && cfg.TLS != nil {
+		return nil, ErrCompressionWithTLS.New()
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLS != nil {
+		var err error
+		tlsConfig, err = cfg.TLS.Config()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sm := NewSessionManager(
+		sb, tracer,
+		e.Catalog.MemoryManager,
+		cfg.Address)
+	sm.socket = cfg.Socket
+	sm.catalog = e.Catalog
+	sm.onStart = cfg.OnSessionStart
+	sm.onEnd = cfg.OnSessionEnd
+	if cfg.Logger != nil {
+		sm.logger = cfg.Logger
+	}
+	if cfg.Binlog != nil {
+		sm.binlog = cfg.Binlog
+		e.Catalog.SetChangeRecorder(cfg.Binlog)
+	}
+
+	handler := NewHandler(e, sm, cfg.ConnReadTimeout)
+	handler.writeTimeout = cfg.ConnWriteTimeout
 	a := cfg.Auth.Mysql()
+	handler.limits = newConnectionLimits(cfg)
+	handler.queries = newQueryLimits(cfg)
+	if handler.limits != nil {
+		a = &limitedAuthServer{a, handler.limits}
+	}
+	handler.slowLog = cfg.SlowQueryLog
+	handler.generalLog = cfg.GeneralLog
+	handler.redact = cfg.GeneralLogRedact
+	handler.localInfile = cfg.LocalInfile
+	if cfg.Audit != nil {
+		handler.auditSink = cfg.Audit
+		a = &auditAuthServer{a, handler}
+	}
+
 	l, err := NewListener(cfg.Protocol, cfg.Address, handler)
 	if err != nil {
 		return nil, err
 	}
+	l.compress = cfg.Compression
+	l.proxy = cfg.ProxyProtocol
+
+	if cfg.Socket != "" {
+		if err := l.ListenSocket(cfg.Socket); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+	}
 	vtListnr, err := mysql.NewFromListener(l, a, handler, cfg.ConnReadTimeout, cfg.ConnWriteTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Server{Listener: vtListnr, h: handler}, nil
+	vtListnr.TLSConfig = tlsConfig
+	vtListnr.RequireSecureTransport = cfg.TLS != nil && cfg.TLS.RequireSecureTransport
+
+	s := &Server{
+		Listener:      vtListnr,
+		h:             handler,
+		replica:       cfg.Replica,
+		maxReplicaLag: cfg.MaxReplicaLag,
+	}
+	if cfg.XAddress != "" {
+		s.x, err = newXServer(cfg.XAddress, handler, a)
+		if err != nil {
+			vtListnr.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.HTTPAddress != "" {
+		s.http, err = newHTTPServer(cfg.HTTPAddress, handler, a)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.GRPCAddress != "" {
+		s.grpc, err = newGRPCServer(cfg.GRPCAddress, handler, a)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.MetricsAddress != "" {
+		s.metrics, err = newMetricsServer(cfg.MetricsAddress, sm.logger)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.HealthAddress != "" {
+		s.health, err = newHealthServer(cfg.HealthAddress, s, sm.logger)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
 }
 
 // Start starts accepting connections on the server.
 func (s *Server) Start() error {
+	if s.x != nil {
+		go s.x.serve()
+	}
+	if s.http != nil {
+		go s.http.serve()
+	}
+	if s.grpc != nil {
+		go s.grpc.serve()
+	}
+	if s.metrics != nil {
+		go s.metrics.serve()
+	}
+	if s.health != nil {
+		go s.health.serve()
+	}
 	s.Listener.Accept()
 	return nil
 }
 
+// Shutdown shuts down the server gracefully, so it can be replaced without
+// downtime. It stops accepting connections and new queries, which fail
+// with an ER_SERVER_SHUTDOWN error, and waits for the running queries to
+// finish. Once they do or ctx is done, the remaining queries are cancelled
+// and the connections are closed, ending their sessions. The error of ctx
+// is returned if queries had to be cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.h.shutdown()
+	s.Listener.Shutdown()
+	if s.x != nil {
+		_ = s.x.stop()
+	}
+
+	var wg sync.WaitGroup
+	if s.http != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.http.shutdown(ctx)
+		}()
+	}
+	if s.grpc != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.grpc.shutdown(ctx)
+		}()
+	}
+
+	err := s.h.waitQueries(ctx)
+	s.h.closeConnections()
+	if s.x != nil {
+		s.x.closeConnections()
+	}
+	wg.Wait()
+
+	// The metrics and the health are served until the end, so the
+	// shutdown can be monitored.
+	if s.metrics != nil {
+		s.metrics.shutdown(ctx)
+	}
+	if s.health != nil {
+		s.health.shutdown(ctx)
+	}
+
+	return err
+}
+
 // Close closes the server connection.
 func (s *Server) Close() error {
 	s.Listener.Close()
-	return nil
+
+	var err error
+	if s.x != nil {
+		err = s.x.close()
+	}
+	if s.http != nil {
+		if herr := s.http.close(); err == nil {
+			err = herr
+		}
+	}
+	if s.grpc != nil {
+		s.grpc.close()
+	}
+	if s.metrics != nil {
+		if merr := s.metrics.close(); err == nil {
+			err = merr
+		}
+	}
+	if s.health != nil {
+		if herr := s.health.close(); err == nil {
+			err = herr
+		}
+	}
+	return err
 }
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	dsql "database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+	vtmysql "vitess.io/vitess/go/mysql"
+)
+
+func newShutdownTestServer(t *testing.T) (*Server, *dsql.DB) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	return s, db
+}
+
+// waitRunningQuery waits until the server is running a query.
+func waitRunningQuery(t *testing.T, s *Server) {
+	waitFor(t, func() bool {
+		for _, p := range s.h.e.Catalog.ProcessList.Processes() {
+			if p.Type == sql.QueryProcess {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestServerShutdown(t *testing.T) {
+	require := require.New(t)
+	s, db := newShutdownTestServer(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	idle, err := db.Conn(ctx)
+	require.NoError(err)
+	defer idle.Close()
+	require.NoError(idle.PingContext(ctx))
+
+	running, err := db.Conn(ctx)
+	require.NoError(err)
+	defer running.Close()
+
+	result := make(chan error)
+	go func() {
+		var n int
+		result <- running.QueryRowContext(ctx, "SELECT SLEEP(0.5)").Scan(&n)
+	}()
+	waitRunningQuery(t, s)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	shutdown := make(chan error)
+	go func() { shutdown <- s.Shutdown(shutdownCtx) }()
+
+	// New queries are rejected while the running one finishes.
+	waitFor(t, func() bool {
+		var n int
+		err := idle.QueryRowContext(ctx, "SELECT 1").Scan(&n)
+		merr, ok := err.(*mysql.MySQLError)
+		return ok && merr.Number == vtmysql.ERServerShutdown
+	})
+
+	require.NoError(<-result)
+	require.NoError(<-shutdown)
+
+	// The connections are closed, ending their sessions.
+	waitFor(t, func() bool {
+		s.h.sm.mu.Lock()
+		defer s.h.sm.mu.Unlock()
+		return len(s.h.sm.sessions) == 0
+	})
+}
+
+func TestServerShutdownDeadline(t *testing.T) {
+	require := require.New(t)
+	s, db := newShutdownTestServer(t)
+	defer db.Close()
+
+	result := make(chan error)
+	go func() {
+		var n int
+		result <- db.QueryRow("SELECT SLEEP(30)").Scan(&n)
+	}()
+	waitRunningQuery(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.Equal(context.DeadlineExceeded, s.Shutdown(ctx))
+
+	select {
+	case err := <-result:
+		require.Error(err)
+	case <-time.After(5 * time.Second):
+		require.Fail("the running query was not cancelled")
+	}
+}
+
+func TestServerWaitTimeout(t *testing.T) {
+	require := require.New(t)
+	s, db := newShutdownTestServer(t)
+	defer s.Close()
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(err)
+	defer conn.Close()
+
+	var timeout int64
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@net_read_timeout").Scan(&timeout))
+	require.Equal(int64(30), timeout)
+	require.NoError(conn.QueryRowContext(ctx, "SELECT @@wait_timeout").Scan(&timeout))
+	require.Equal(int64(28800), timeout)
+
+	_, err = conn.ExecContext(ctx, "SET wait_timeout = 1")
+	require.NoError(err)
+
+	// Connections idle for longer than wait_timeout are closed.
+	waitFor(t, func() bool {
+		s.h.sm.mu.Lock()
+		defer s.h.sm.mu.Unlock()
+		return len(s.h.sm.sessions) == 0
+	})
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+const (
+	// capabilityClientSessionTrack is the CLIENT_SESSION_TRACK capability
+	// flag of the clients that can read the changes of the session state
+	// in OK packets, which vitess does not define.
+	capabilityClientSessionTrack = 1 << 23
+	// serverSessionStateChanged is the SERVER_SESSION_STATE_CHANGED status
+	// flag of the OK packets with changes of the session state.
+	serverSessionStateChanged = 1 << 14
+)
+
+// Types of the changes of the session state.
+const (
+	sessionTrackSystemVariables  = 0
+	sessionTrackSchema           = 1
+	sessionTrackStateChange      = 2
+	sessionTrackTransactionState = 5
+)
+
+// The transaction states reported to the clients tracking them, which
+// only tell whether there is a transaction, as the engine does not track
+// what is done in them.
+const (
+	idleTransactionState   = "________"
+	activeTransactionState = "T_______"
+)
+
+// sessionState is the state of a session reported to the clients that
+// track it.
+type sessionState struct {
+	schema string
+	// vars are the values of the session variables, as text.
+	vars map[string]string
+	// transaction is the transaction state, or empty if it's not tracked.
+	transaction string
+}
+
+// tracksSession returns whether the client reads the changes of its
+// session state.
+func (c *commandConn) tracksSession() bool {
+	return c.capabilities&capabilityClientSessionTrack != 0
+}
+
+// sessionState returns the current state of the session of the connection,
+// creating the session if needed, or nil if the connection was closed.
+func (c *commandConn) sessionState() *sessionState {
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return nil
+	}
+	c.h.sm.connSession(conn)
+
+	sess := c.session()
+	if sess == nil {
+		return nil
+	}
+
+	state := &sessionState{
+		schema: c.h.e.Catalog.CurrentDatabase(),
+		vars:   make(map[string]string),
+	}
+	for name, v := range sess.GetAll() {
+		if v.Value != nil {
+			state.vars[name] = fmt.Sprint(v.Value)
+		}
+	}
+	if trackingTransaction(sess) {
+		state.transaction = idleTransactionState
+		if sql.InTransaction(sess) {
+			state.transaction = activeTransactionState
+		}
+	}
+	return state
+}
+
+// sessionStateChanges returns the changes of the session state since they
+// were last reported to the client, as sent in OK packets, or nil if there
+// are none. As in MySQL, the session variables session_track_schema,
+// session_track_system_variables, session_track_state_change and
+// session_track_transaction_info choose the changes that are reported.
+func (c *commandConn) sessionStateChanges() []byte {
+	if !c.tracksSession() || c.tracked == nil {
+		return nil
+	}
+
+	state := c.sessionState()
+	if state == nil {
+		return nil
+	}
+	last := c.tracked
+	c.tracked = state
+
+	sess := c.session()
+	changed := state.schema != last.schema
+
+	var names []string
+	for name, value := range state.vars {
+		if last.vars[name] != value {
+			changed = true
+			if trackingVariable(sess, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var data []byte
+	for _, name := range names {
+		v := appendLenEncString(nil, name)
+		v = appendLenEncString(v, state.vars[name])
+		data = appendSessionStateChange(data, sessionTrackSystemVariables, v)
+	}
+
+	if state.schema != last.schema && sessionTrackEnabled(sess, "session_track_schema") {
+		data = appendSessionStateChange(data, sessionTrackSchema, appendLenEncString(nil, state.schema))
+	}
+
+	if changed && sessionTrackEnabled(sess, "session_track_state_change") {
+		data = appendSessionStateChange(data, sessionTrackStateChange, appendLenEncString(nil, "1"))
+	}
+
+	if state.transaction != "" && state.transaction != last.transaction {
+		data = appendSessionStateChange(data, sessionTrackTransactionState, appendLenEncString(nil, state.transaction))
+	}
+
+	return data
+}
+
+func appendSessionStateChange(b []byte, typ byte, data []byte) []byte {
+	return appendLenEncString(append(b, typ), string(data))
+}
+
+// trackingVariable returns whether the changes of the given variable are
+// reported, as it's in the session_track_system_variables of the session,
+// which can be * to report all of them.
+func trackingVariable(sess sql.Session, name string) bool {
+	_, value := sess.Get("session_track_system_variables")
+	list, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	for _, v := range strings.Split(list, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "*" || v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// trackingTransaction returns whether the transaction state is reported,
+// as the session_track_transaction_info of the session is STATE or
+// CHARACTERISTICS.
+func trackingTransaction(sess sql.Session) bool {
+	_, value := sess.Get("session_track_transaction_info")
+	switch v := value.(type) {
+	case string:
+		return strings.EqualFold(v, "STATE") || strings.EqualFold(v, "CHARACTERISTICS")
+	case nil:
+		return false
+	default:
+		n, err := sql.Int64.Convert(v)
+		return err == nil && n.(int64) != 0
+	}
+}
+
+// sessionTrackEnabled returns whether the given boolean variable of the
+// session is enabled.
+func sessionTrackEnabled(sess sql.Session, name string) bool {
+	_, value := sess.Get(name)
+	switch v := value.(type) {
+	case string:
+		return strings.EqualFold(v, "ON") || v == "1"
+	case bool:
+		return v
+	case nil:
+		return false
+	default:
+		n, err := sql.Int64.Convert(v)
+		return err == nil && n.(int64) != 0
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+func TestServerSessionTrack(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	e := setupMemDB(require)
+	e.AddDatabase(memory.NewDatabase("other"))
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+	}, e)
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	require.NoError(err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	handshake := readTestPacket(t, r)
+	versionEnd := strings.IndexByte(string(handshake[1:]), 0)
+	flags := binary.LittleEndian.Uint16(handshake[1+versionEnd+1+4+8+1+2+1+2:])
+	require.NotZero(flags & (capabilityClientSessionTrack >> 16))
+
+	testHandshake(t, conn, r, capabilityClientSessionTrack)
+
+	query := func(query string) []byte {
+		t.Helper()
+		writeTestPacket(t, conn, 0, append([]byte{mysql.ComQuery}, query...))
+		ok := readTestPacket(t, r)
+		require.Equal(byte(mysql.OKPacket), ok[0])
+		return ok
+	}
+
+	// OK packets without changes are not changed.
+	require.Len(query("SET wait_timeout = 60"), 7)
+
+	ok := query("SET time_zone = '+00:00'")
+	require.NotZero(binary.LittleEndian.Uint16(ok[3:]) & serverSessionStateChanged)
+	require.Equal([]byte("\x00\x13\x00\x11\x09time_zone\x06+00:00"), ok[7:])
+
+	ok = query("USE other")
+	require.Equal([]byte("\x00\x08\x01\x06\x05other"), ok[7:])
+
+	ok = query("SET session_track_state_change = ON, session_track_transaction_info = 'STATE'")
+	require.Equal([]byte("\x00\x0f\x02\x02\x011\x05\x09\x08________"), ok[7:])
+
+	ok = query("SET wait_timeout = 120")
+	require.Equal([]byte("\x00\x04\x02\x02\x011"), ok[7:])
+
+	ok = query("SET session_track_system_variables = '*', session_track_schema = OFF")
+	require.Equal(
+		[]byte("\x00\x40\x00\x17\x14session_track_schema\x010"+
+			"\x00\x21\x1esession_track_system_variables\x01*"+
+			"\x02\x02\x011"),
+		ok[7:],
+	)
+
+	// Clients that do not track the session state are not affected.
+	conn2, err := net.Dial("tcp", "localhost:"+port)
+	require.NoError(err)
+	defer conn2.Close()
+
+	r2 := bufio.NewReader(conn2)
+	readTestPacket(t, r2)
+	testHandshake(t, conn2, r2, 0)
+
+	writeTestPacket(t, conn2, 0, append([]byte{mysql.ComQuery}, "SET time_zone = '+01:00'"...))
+	ok = readTestPacket(t, r2)
+	require.Equal(byte(mysql.OKPacket), ok[0])
+	require.Len(ok, 7)
+	require.Zero(binary.LittleEndian.Uint16(ok[3:]) & serverSessionStateChanged)
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// defaultLongQueryTime is the time after which queries are slow when the
+// long_query_time of their session cannot be read.
+const defaultLongQueryTime = 10 * time.Second
+
+// SlowQuery is a query that took at least the long_query_time of its
+// session to run.
+type SlowQuery struct {
+	// Time is when the query started.
+	Time         time.Time
+	ConnectionID uint32
+	User         string
+	// Address is the address of the client.
+	Address string
+	// Database is the current database when the query started.
+	Database string
+	Query    string
+	Duration time.Duration
+	// RowsSent is the number of rows of the result read by the client.
+	RowsSent uint64
+	// RowsExamined is the number of rows read from the tables.
+	RowsExamined uint64
+	// Plan is the plan the query was executed with, or empty if it failed
+	// before it was analyzed.
+	Plan string
+	// Err is the error of the query, if it failed.
+	Err error
+}
+
+// SlowQuerySink receives the slow queries of a server. Queries of
+// different connections are given concurrently.
+type SlowQuerySink interface {
+	SlowQuery(SlowQuery)
+}
+
+// SlowQueryFunc is a SlowQuerySink that calls a function with every slow
+// query.
+type SlowQueryFunc func(SlowQuery)
+
+// SlowQuery implements the SlowQuerySink interface.
+func (f SlowQueryFunc) SlowQuery(q SlowQuery) {
+	f(q)
+}
+
+// SlowQueryWriter is a SlowQuerySink that writes the slow queries in the
+// format of the slow query log of MySQL, so the tools that read it, such as
+// mysqldumpslow or pt-query-digest, can be used. The plan of the queries is
+// written in comments after them.
+type SlowQueryWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSlowQueryWriter creates a SlowQueryWriter that writes to w.
+func NewSlowQueryWriter(w io.Writer) *SlowQueryWriter {
+	return &SlowQueryWriter{w: w}
+}
+
+// NewSlowQueryFile creates a SlowQueryWriter that appends the slow queries
+// to the file at the given path, creating it if it does not exist.
+func NewSlowQueryFile(path string) (*SlowQueryWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewSlowQueryWriter(f), nil
+}
+
+// SlowQuery implements the SlowQuerySink interface. Queries that cannot be
+// written are logged.
+func (w *SlowQueryWriter) SlowQuery(q SlowQuery) {
+	host := q.Address
+	if h, _, err := net.SplitHostPort(q.Address); err == nil {
+		host = h
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Time: %s\n", q.Time.UTC().Format("2006-01-02T15:04:05.000000Z"))
+	fmt.Fprintf(&buf, "# User@Host: %s[%s] @  [%s]  Id: %d\n", q.User, q.User, host, q.ConnectionID)
+	fmt.Fprintf(&buf, "# Query_time: %f  Lock_time: 0.000000 Rows_sent: %d  Rows_examined: %d\n",
+		q.Duration.Seconds(), q.RowsSent, q.RowsExamined)
+	if q.Err != nil {
+		fmt.Fprintf(&buf, "# Error: %s\n", strings.Replace(q.Err.Error(), "\n", " ", -1))
+	}
+	if q.Database != "" {
+		fmt.Fprintf(&buf, "use %s;\n", q.Database)
+	}
+	fmt.Fprintf(&buf, "SET timestamp=%d;\n", q.Time.Unix())
+	fmt.Fprintf(&buf, "%s;\n", strings.TrimRight(strings.TrimSpace(q.Query), ";"))
+	if q.Plan != "" {
+		buf.WriteString("# Plan:\n")
+		for _, line := range strings.Split(strings.TrimRight(q.Plan, "\n"), "\n") {
+			fmt.Fprintf(&buf, "#   %s\n", line)
+		}
+	}
+
+	w.mu.Lock()
+	_, err := w.w.Write(buf.Bytes())
+	w.mu.Unlock()
+	if err != nil {
+		logrus.Errorf("unable to write slow query: %s", err)
+	}
+}
+
+// Close closes the underlying writer, if it can be closed.
+func (w *SlowQueryWriter) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// longQueryTime returns the long_query_time of the session of the given
+// context.
+func longQueryTime(ctx *sql.Context) time.Duration {
+	_, value := ctx.Get("long_query_time")
+	seconds, err := sql.Float64.Convert(value)
+	if err != nil {
+		return defaultLongQueryTime
+	}
+	return time.Duration(seconds.(float64) * float64(time.Second))
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	dsql "database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerSlowQueryLog(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	var mu sync.Mutex
+	var queries []SlowQuery
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+		SlowQueryLog: SlowQueryFunc(func(q SlowQuery) {
+			mu.Lock()
+			queries = append(queries, q)
+			mu.Unlock()
+		}),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// Queries faster than long_query_time are not recorded.
+	var n int
+	require.NoError(db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n))
+
+	_, err = db.Exec("SET long_query_time = 0")
+	require.NoError(err)
+
+	rows, err := db.Query("SELECT c1 % 7 AS m, COUNT(*) AS n FROM test GROUP BY m")
+	require.NoError(err)
+	for rows.Next() {
+	}
+	require.NoError(rows.Err())
+	require.NoError(rows.Close())
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(queries) == 2
+	})
+
+	require.Equal("SET long_query_time = 0", queries[0].Query)
+
+	q := queries[1]
+	require.Equal("SELECT c1 % 7 AS m, COUNT(*) AS n FROM test GROUP BY m", q.Query)
+	require.Equal("root", q.User)
+	require.Equal("test", q.Database)
+	require.Equal(uint64(7), q.RowsSent)
+	require.Equal(uint64(1010), q.RowsExamined)
+	require.Contains(q.Plan, "GroupBy")
+	require.NoError(q.Err)
+}
+
+func TestSlowQueryWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSlowQueryWriter(&buf)
+	w.SlowQuery(SlowQuery{
+		Time:         time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC),
+		ConnectionID: 1,
+		User:         "user",
+		Address:      "127.0.0.1:52514",
+		Database:     "db",
+		Query:        "SELECT * FROM t;",
+		Duration:     1500 * time.Millisecond,
+		RowsSent:     2,
+		RowsExamined: 10,
+		Plan:         "Project(a)\n └─ Table(t)\n",
+		Err:          errors.New("oops"),
+	})
+
+	require.Equal(t, `# Time: 2019-01-02T03:04:05.000000Z
+# User@Host: user[user] @  [127.0.0.1]  Id: 1
+# Query_time: 1.500000  Lock_time: 0.000000 Rows_sent: 2  Rows_examined: 10
+# Error: oops
+use db;
+SET timestamp=1546398245;
+SELECT * FROM t;
+# Plan:
+#   Project(a)
+#    └─ Table(t)
+`, buf.String())
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,825 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+// Commands of prepared statements, which vitess does not define.
+const (
+	comStmtPrepare      = 0x16
+	comStmtExecute      = 0x17
+	comStmtSendLongData = 0x18
+	comStmtClose        = 0x19
+	comStmtReset        = 0x1a
+	comStmtFetch        = 0x1c
+)
+
+const (
+	// cursorTypeReadOnly is the flag of COM_STMT_EXECUTE that opens a
+	// cursor for the result set.
+	cursorTypeReadOnly = 0x01
+
+	serverStatusCursorExists = 0x0040
+	serverStatusLastRowSent  = 0x0080
+)
+
+const (
+	erUnknownStmtHandler  = 1243
+	erStmtHasNoOpenCursor = 1421
+	erMalformedPacket     = 1835
+)
+
+// Types of the binary protocol.
+const (
+	mysqlTypeTiny       = 0x01
+	mysqlTypeShort      = 0x02
+	mysqlTypeLong       = 0x03
+	mysqlTypeFloat      = 0x04
+	mysqlTypeDouble     = 0x05
+	mysqlTypeNull       = 0x06
+	mysqlTypeTimestamp  = 0x07
+	mysqlTypeLongLong   = 0x08
+	mysqlTypeInt24      = 0x09
+	mysqlTypeDate       = 0x0a
+	mysqlTypeTime       = 0x0b
+	mysqlTypeDatetime   = 0x0c
+	mysqlTypeYear       = 0x0d
+	mysqlTypeDecimal    = 0x00
+	mysqlTypeNewDecimal = 0xf6
+
+	// mysqlTypeUnsigned is set in the types of unsigned parameters.
+	mysqlTypeUnsigned = 0x8000
+)
+
+// preparedStatement is a statement prepared by a client, with the types
+// of its parameters and its open cursor, if any.
+type preparedStatement struct {
+	id    uint32
+	query string
+	// The positions of the parameters in the query.
+	params []int
+	// The types of the parameters of the last execution, which are not
+	// sent again if they do not change.
+	types []uint16
+	// The data of the parameters sent with COM_STMT_SEND_LONG_DATA.
+	longData map[int][]byte
+	cursor   *cursor
+}
+
+// cursor is a result set of a prepared statement that is read by the
+// client with COM_STMT_FETCH.
+type cursor struct {
+	ctx    *sql.Context
+	cancel context.CancelFunc
+	schema sql.Schema
+	rows   sql.RowIter
+	audit  *queryAudit
+	closed bool
+}
+
+func (c *commandConn) comStmtPrepare(data []byte) error {
+	text := string(data)
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	// The statement is parsed to return syntax errors when it's prepared,
+	// as in MySQL.
+	ctx := c.h.sm.NewContextWithQuery(conn, text)
+	if _, err := c.h.e.Dialect.Parse(ctx, text); err != nil {
+		return c.writeError(err)
+	}
+
+	c.lastStmt++
+	stmt := &preparedStatement{
+		id:       c.lastStmt,
+		query:    text,
+		params:   placeholders(text),
+		longData: make(map[int][]byte),
+	}
+	c.stmts[stmt.id] = stmt
+
+	// The columns of the result are not known until it's executed, so they
+	// are sent in the response of COM_STMT_EXECUTE.
+	resp := []byte{mysql.OKPacket}
+	resp = appendUint32(resp, stmt.id)
+	resp = appendUint16(resp, 0)
+	resp = appendUint16(resp, uint16(len(stmt.params)))
+	resp = append(resp, 0)
+	resp = appendUint16(resp, 0)
+	if err := c.writePacket(resp); err != nil {
+		return err
+	}
+
+	if len(stmt.params) == 0 {
+		return nil
+	}
+
+	for range stmt.params {
+		field := &query.Field{Name: "?", Type: sqltypes.VarBinary, Charset: mysql.CharacterSetBinary}
+		if err := c.writePacket(columnDefinition(field)); err != nil {
+			return err
+		}
+	}
+
+	if c.deprecateEOF() {
+		return nil
+	}
+	return c.writePacket([]byte{mysql.EOFPacket, 0, 0, 0, 0})
+}
+
+func (c *commandConn) comStmtExecute(data []byte) error {
+	stmt, err := c.statement(data, "mysqld_stmt_execute")
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	if len(data) < 9 {
+		return c.writeError(errMalformedPacket())
+	}
+	flags := data[4]
+
+	query, err := stmt.bind(data[9:])
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	stmt.closeCursor()
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	if err := c.h.checkShutdown(); err != nil {
+		return c.writeError(err)
+	}
+
+	ctx := c.h.sm.NewContextWithQuery(conn, query)
+	newCtx, cancel := context.WithCancel(ctx)
+	ctx = ctx.WithContext(newCtx)
+
+	cur := &cursor{ctx: ctx, cancel: cancel, audit: c.h.auditQuery(ctx)}
+	cur.schema, cur.rows, err = cur.audit.query(query)
+	if err != nil {
+		cur.close(err)
+		return c.writeError(err)
+	}
+
+	if n := dataChange(cur.audit.plan); n != nil {
+		foundRows := c.capabilities&mysql.CapabilityClientFoundRows != 0
+		result, err := dataChangeResult(ctx, n, cur.rows, foundRows)
+		// The rows are closed once they are read.
+		cur.rows = nil
+		cur.close(err)
+		if err != nil {
+			return c.writeError(err)
+		}
+
+		c.h.updateStatus(conn)
+		return c.writeOKResult(result, conn.StatusFlags)
+	}
+
+	c.h.updateStatus(conn)
+	if len(cur.schema) == 0 {
+		cur.close(nil)
+		return c.writeOK(conn.StatusFlags)
+	}
+
+	if err := c.writeColumns(c.h.resultFields(ctx, cur.audit.plan)); err != nil {
+		cur.close(err)
+		return err
+	}
+
+	// With a cursor, the rows are fetched later by the client.
+	if flags&cursorTypeReadOnly != 0 {
+		stmt.cursor = cur
+		return c.writeEOF(conn.StatusFlags | serverStatusCursorExists)
+	}
+
+	if !c.deprecateEOF() {
+		if err := c.writePacket([]byte{mysql.EOFPacket, 0, 0, 0, 0}); err != nil {
+			cur.close(err)
+			return err
+		}
+	}
+
+	err = c.writeRows(cur, math.MaxUint32)
+	if rerr, ok := err.(rowsError); ok {
+		return c.writeError(rerr.error)
+	}
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	cur.close(nil)
+	return c.writeEOF(conn.StatusFlags)
+}
+
+func (c *commandConn) comStmtFetch(data []byte) error {
+	stmt, err := c.statement(data, "mysqld_stmt_fetch")
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	if len(data) < 8 {
+		return c.writeError(errMalformedPacket())
+	}
+
+	if stmt.cursor == nil {
+		return c.writeError(mysql.NewSQLError(erStmtHasNoOpenCursor, mysql.SSUnknownSQLState,
+			"The statement (%d) has no open cursor.", stmt.id))
+	}
+
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	status := conn.StatusFlags | serverStatusCursorExists
+	err = c.writeRows(stmt.cursor, binary.LittleEndian.Uint32(data[4:]))
+	if err == nil {
+		return c.writeEOF(status)
+	}
+
+	// The cursor is closed once all its rows are read or reading them
+	// fails.
+	stmt.cursor = nil
+	if rerr, ok := err.(rowsError); ok {
+		return c.writeError(rerr.error)
+	}
+
+	if err != io.EOF {
+		return err
+	}
+	return c.writeEOF(status | serverStatusLastRowSent)
+}
+
+func (c *commandConn) comStmtSendLongData(data []byte) error {
+	// Nothing is sent back to the client, even on errors.
+	stmt, err := c.statement(data, "mysqld_stmt_send_long_data")
+	if err != nil || len(data) < 6 {
+		return nil
+	}
+
+	param := int(binary.LittleEndian.Uint16(data[4:]))
+	if param < len(stmt.params) {
+		stmt.longData[param] = append(stmt.longData[param], data[6:]...)
+	}
+	return nil
+}
+
+func (c *commandConn) comStmtClose(data []byte) error {
+	// Nothing is sent back to the client.
+	stmt, err := c.statement(data, "mysqld_stmt_close")
+	if err != nil {
+		return nil
+	}
+
+	stmt.closeCursor()
+	delete(c.stmts, stmt.id)
+	return nil
+}
+
+func (c *commandConn) comStmtReset(data []byte) error {
+	stmt, err := c.statement(data, "mysqld_stmt_reset")
+	if err != nil {
+		return c.writeError(err)
+	}
+
+	stmt.closeCursor()
+	stmt.longData = make(map[int][]byte)
+
+	conn, err := c.mysqlConn()
+	if err != nil {
+		return c.writeError(err)
+	}
+	return c.writeOK(conn.StatusFlags)
+}
+
+// statement returns the prepared statement with the id at the start of
+// the given command data.
+func (c *commandConn) statement(data []byte, command string) (*preparedStatement, error) {
+	if len(data) < 4 {
+		return nil, errMalformedPacket()
+	}
+
+	id := binary.LittleEndian.Uint32(data)
+	stmt, ok := c.stmts[id]
+	if !ok {
+		return nil, mysql.NewSQLError(erUnknownStmtHandler, mysql.SSUnknownSQLState,
+			"Unknown prepared statement handler (%d) given to %s", id, command)
+	}
+	return stmt, nil
+}
+
+// closeStatements closes the cursors of all the prepared statements.
+func (c *commandConn) closeStatements() {
+	for id, stmt := range c.stmts {
+		stmt.closeCursor()
+		delete(c.stmts, id)
+	}
+}
+
+func (s *preparedStatement) closeCursor() {
+	if s.cursor != nil {
+		s.cursor.close(nil)
+		s.cursor = nil
+	}
+}
+
+// close closes the rows of the cursor and cancels its context, logging the
+// query in the audit log if there is one. The error is the one that made
+// the query fail, if any.
+func (c *cursor) close(err error) {
+	if c.closed {
+		return
+	}
+	c.closed = true
+
+	if c.rows != nil {
+		if cerr := c.rows.Close(); err == nil {
+			err = cerr
+		}
+	}
+	c.cancel()
+	c.audit.done(err)
+}
+
+func errMalformedPacket() error {
+	return mysql.NewSQLError(erMalformedPacket, mysql.SSUnknownSQLState, "Malformed communication packet.")
+}
+
+// writeColumns writes the number of columns of a result set and their
+// definitions.
+func (c *commandConn) writeColumns(fields []*query.Field) error {
+	if err := c.writePacket(appendLenEncInt(nil, uint64(len(fields)))); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if err := c.writePacket(columnDefinition(field)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func columnDefinition(field *query.Field) []byte {
+	typ, flags := sqltypes.TypeToMySQL(field.Type)
+	if field.Flags != 0 {
+		flags = int64(field.Flags)
+	}
+
+	data := appendLenEncString(nil, "def")
+	data = appendLenEncString(data, field.Database)
+	data = appendLenEncString(data, field.Table)
+	data = appendLenEncString(data, field.OrgTable)
+	data = appendLenEncString(data, field.Name)
+	data = appendLenEncString(data, field.OrgName)
+	data = append(data, 0x0c)
+	data = appendUint16(data, uint16(field.Charset))
+	data = appendUint32(data, field.ColumnLength)
+	data = append(data, byte(typ))
+	data = appendUint16(data, uint16(flags))
+	data = append(data, byte(field.Decimals))
+	return appendUint16(data, 0)
+}
+
+// rowsError is an error reading the rows of a cursor, which is sent to
+// the client instead of the rest of the rows.
+type rowsError struct {
+	error
+}
+
+// writeRows writes at most limit rows of the cursor in the binary
+// protocol. It returns io.EOF once all the rows are read, and the cursor
+// is closed when it does or fails. Errors reading the rows are returned as
+// a rowsError.
+func (c *commandConn) writeRows(cur *cursor, limit uint32) error {
+	for n := uint32(0); n < limit; n++ {
+		row, err := cur.rows.Next()
+		if err == io.EOF {
+			cur.close(nil)
+			return io.EOF
+		}
+
+		var values []sqltypes.Value
+		if err == nil {
+			values, err = rowToSQL(cur.schema, row)
+		}
+
+		if err != nil {
+			cur.close(err)
+			return rowsError{err}
+		}
+
+		if err := c.writePacket(binaryRow(values)); err != nil {
+			cur.close(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// binaryRow encodes a row in the binary protocol, with a bitmap of the
+// null values, which starts at its third bit, followed by the rest of the
+// values.
+func binaryRow(values []sqltypes.Value) []byte {
+	bitmap := make([]byte, (len(values)+7+2)/8)
+	data := []byte{mysql.OKPacket}
+	var rest []byte
+	for i, v := range values {
+		if v.IsNull() || v.Type() == sqltypes.Null {
+			bitmap[(i+2)/8] |= 1 << uint((i+2)%8)
+			continue
+		}
+		rest = appendBinaryValue(rest, v)
+	}
+
+	data = append(data, bitmap...)
+	return append(data, rest...)
+}
+
+func appendBinaryValue(b []byte, v sqltypes.Value) []byte {
+	s := v.ToString()
+	switch v.Type() {
+	case sqltypes.Int8, sqltypes.Uint8:
+		if n, ok := parseInteger(s, v.IsSigned()); ok {
+			return append(b, byte(n))
+		}
+	case sqltypes.Int16, sqltypes.Uint16, sqltypes.Year:
+		if n, ok := parseInteger(s, v.IsSigned()); ok {
+			return appendUint16(b, uint16(n))
+		}
+	case sqltypes.Int24, sqltypes.Uint24, sqltypes.Int32, sqltypes.Uint32:
+		if n, ok := parseInteger(s, v.IsSigned()); ok {
+			return appendUint32(b, uint32(n))
+		}
+	case sqltypes.Int64, sqltypes.Uint64:
+		if n, ok := parseInteger(s, v.IsSigned()); ok {
+			return appendUint64(b, n)
+		}
+	case sqltypes.Float32:
+		if f, err := strconv.ParseFloat(s, 32); err == nil {
+			return appendUint32(b, math.Float32bits(float32(f)))
+		}
+	case sqltypes.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return appendUint64(b, math.Float64bits(f))
+		}
+	case sqltypes.Date, sqltypes.Datetime, sqltypes.Timestamp:
+		if data, ok := binaryDatetime(s); ok {
+			return append(b, data...)
+		}
+	case sqltypes.Time:
+		if data, ok := binaryTime(s); ok {
+			return append(b, data...)
+		}
+	}
+
+	return appendLenEncString(b, s)
+}
+
+// parseInteger parses an integer, returning its bits.
+func parseInteger(s string, signed bool) (uint64, bool) {
+	if signed {
+		n, err := strconv.ParseInt(s, 10, 64)
+		return uint64(n), err == nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	return n, err == nil
+}
+
+// binaryDatetime encodes a date or a datetime, omitting the time if it's
+// midnight and the microseconds if they are zero.
+func binaryDatetime(s string) ([]byte, bool) {
+	var t time.Time
+	var err error
+	switch {
+	case strings.HasPrefix(s, "0000-00-00"):
+		return []byte{0}, true
+	case len(s) == len(sql.DateLayout):
+		t, err = time.Parse(sql.DateLayout, s)
+	default:
+		t, err = time.Parse("2006-01-02 15:04:05.999999999", s)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	data := appendUint16([]byte{0}, uint16(t.Year()))
+	data = append(data, byte(t.Month()), byte(t.Day()))
+	micros := uint32(t.Nanosecond() / 1000)
+	if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 || micros != 0 {
+		data = append(data, byte(t.Hour()), byte(t.Minute()), byte(t.Second()))
+		if micros != 0 {
+			data = appendUint32(data, micros)
+		}
+	}
+
+	data[0] = byte(len(data) - 1)
+	return data, true
+}
+
+// binaryTime encodes a time with its sign, days, hours, minutes and
+// seconds, omitting everything if it's zero and the microseconds if they
+// are zero.
+func binaryTime(s string) ([]byte, bool) {
+	v, err := sql.Time.Convert(s)
+	if err != nil {
+		return nil, false
+	}
+
+	d := v.(time.Duration)
+	if d == 0 {
+		return []byte{0}, true
+	}
+
+	data := []byte{0, 0}
+	if d < 0 {
+		data[1] = 1
+		d = -d
+	}
+
+	data = appendUint32(data, uint32(d/(24*time.Hour)))
+	data = append(data, byte(d/time.Hour%24), byte(d/time.Minute%60), byte(d/time.Second%60))
+	if micros := uint32(d % time.Second / time.Microsecond); micros != 0 {
+		data = appendUint32(data, micros)
+	}
+
+	data[0] = byte(len(data) - 1)
+	return data, true
+}
+
+// bind returns the query of the statement with the parameters of an
+// execution replaced by literals. The data has a bitmap with the null
+// parameters, whether their types are sent, their types and the values of
+// the ones that are not null or were sent with COM_STMT_SEND_LONG_DATA.
+func (s *preparedStatement) bind(data []byte) (string, error) {
+	defer func() {
+		s.longData = make(map[int][]byte)
+	}()
+
+	if len(s.params) == 0 {
+		return s.query, nil
+	}
+
+	bitmapLen := (len(s.params) + 7) / 8
+	if len(data) < bitmapLen+1 {
+		return "", errMalformedPacket()
+	}
+
+	bitmap := data[:bitmapLen]
+	data = data[bitmapLen:]
+	if data[0] == 1 {
+		data = data[1:]
+		if len(data) < 2*len(s.params) {
+			return "", errMalformedPacket()
+		}
+
+		s.types = make([]uint16, len(s.params))
+		for i := range s.types {
+			s.types[i] = binary.LittleEndian.Uint16(data[2*i:])
+		}
+		data = data[2*len(s.params):]
+	} else {
+		data = data[1:]
+	}
+
+	if len(s.types) != len(s.params) {
+		return "", mysql.NewSQLError(mysql.ERWrongArguments, mysql.SSUnknownSQLState,
+			"Incorrect arguments to mysqld_stmt_execute")
+	}
+
+	var b strings.Builder
+	var last int
+	for i, pos := range s.params {
+		var literal string
+		if long, ok := s.longData[i]; ok {
+			literal = quoteString(string(long))
+		} else if bitmap[i/8]&(1<<uint(i%8)) != 0 {
+			literal = "NULL"
+		} else {
+			var err error
+			literal, data, err = readParam(data, s.types[i])
+			if err != nil {
+				return "", err
+			}
+		}
+
+		b.WriteString(s.query[last:pos])
+		b.WriteString(literal)
+		last = pos + 1
+	}
+	b.WriteString(s.query[last:])
+
+	return b.String(), nil
+}
+
+// readParam reads a parameter of the given type in the binary protocol,
+// returning it as a SQL literal with the rest of the data.
+func readParam(data []byte, typ uint16) (string, []byte, error) {
+	unsigned := typ&mysqlTypeUnsigned != 0
+
+	integer := func(size int) (string, []byte, error) {
+		if len(data) < size {
+			return "", nil, errMalformedPacket()
+		}
+
+		var n uint64
+		for i := size - 1; i >= 0; i-- {
+			n = n<<8 | uint64(data[i])
+		}
+
+		if unsigned {
+			return strconv.FormatUint(n, 10), data[size:], nil
+		}
+
+		// Sign extension of the smaller integers.
+		shift := uint(64 - 8*size)
+		return strconv.FormatInt(int64(n<<shift)>>shift, 10), data[size:], nil
+	}
+
+	switch typ &^ mysqlTypeUnsigned {
+	case mysqlTypeNull:
+		return "NULL", data, nil
+	case mysqlTypeTiny:
+		return integer(1)
+	case mysqlTypeShort, mysqlTypeYear:
+		return integer(2)
+	case mysqlTypeLong, mysqlTypeInt24:
+		return integer(4)
+	case mysqlTypeLongLong:
+		return integer(8)
+	case mysqlTypeFloat:
+		if len(data) < 4 {
+			return "", nil, errMalformedPacket()
+		}
+		f := math.Float32frombits(binary.LittleEndian.Uint32(data))
+		return formatFloat(float64(f), 32), data[4:], nil
+	case mysqlTypeDouble:
+		if len(data) < 8 {
+			return "", nil, errMalformedPacket()
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		return formatFloat(f, 64), data[8:], nil
+	case mysqlTypeDate, mysqlTypeDatetime, mysqlTypeTimestamp:
+		return readDatetimeParam(data)
+	case mysqlTypeTime:
+		return readTimeParam(data)
+	}
+
+	length, rest, ok := readLenEncInt(data)
+	if !ok || uint64(len(rest)) < length {
+		return "", nil, errMalformedPacket()
+	}
+
+	value := string(rest[:length])
+	rest = rest[length:]
+
+	// Decimals are sent as strings, but are numbers.
+	switch typ &^ mysqlTypeUnsigned {
+	case mysqlTypeDecimal, mysqlTypeNewDecimal:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value, rest, nil
+		}
+	}
+
+	return quoteString(value), rest, nil
+}
+
+func formatFloat(f float64, bits int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return quoteString(strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// readDatetimeParam reads a date, which may be followed by the time and
+// the microseconds.
+func readDatetimeParam(data []byte) (string, []byte, error) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "", nil, errMalformedPacket()
+	}
+
+	length := int(data[0])
+	value := data[1 : 1+length]
+	rest := data[1+length:]
+
+	var year, month, day, hour, minute, second, micros int
+	switch length {
+	case 0:
+	case 4, 7, 11:
+		year = int(binary.LittleEndian.Uint16(value))
+		month, day = int(value[2]), int(value[3])
+		if length >= 7 {
+			hour, minute, second = int(value[4]), int(value[5]), int(value[6])
+		}
+		if length == 11 {
+			micros = int(binary.LittleEndian.Uint32(value[7:]))
+		}
+	default:
+		return "", nil, errMalformedPacket()
+	}
+
+	s := fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", year, month, day, hour, minute, second)
+	if micros != 0 {
+		s += fmt.Sprintf(".%06d", micros)
+	}
+	return quoteString(s), rest, nil
+}
+
+// readTimeParam reads a time, with its sign, the days and the time, which
+// may be followed by the microseconds.
+func readTimeParam(data []byte) (string, []byte, error) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "", nil, errMalformedPacket()
+	}
+
+	length := int(data[0])
+	value := data[1 : 1+length]
+	rest := data[1+length:]
+
+	var sign string
+	var hours, minute, second, micros int
+	switch length {
+	case 0:
+	case 8, 12:
+		if value[0] == 1 {
+			sign = "-"
+		}
+		hours = 24*int(binary.LittleEndian.Uint32(value[1:])) + int(value[5])
+		minute, second = int(value[6]), int(value[7])
+		if length == 12 {
+			micros = int(binary.LittleEndian.Uint32(value[8:]))
+		}
+	default:
+		return "", nil, errMalformedPacket()
+	}
+
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, minute, second)
+	if micros != 0 {
+		s += fmt.Sprintf(".%06d", micros)
+	}
+	return quoteString(s), rest, nil
+}
+
+var stringEscaper = strings.NewReplacer(
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+	"'", `\'`,
+	`\`, `\\`,
+)
+
+func quoteString(s string) string {
+	return "'" + stringEscaper.Replace(s) + "'"
+}
+
+// placeholders returns the positions of the parameter placeholders of a
+// query, skipping the ones in quoted strings, quoted identifiers and
+// comments.
+func placeholders(query string) []int {
+	var positions []int
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '?':
+			positions = append(positions, i)
+		case ch == '\'' || ch == '"' || ch == '`':
+			for i++; i < len(query) && query[i] != ch; i++ {
+				if query[i] == '\\' && ch != '`' {
+					i++
+				}
+			}
+		case ch == '#' || strings.HasPrefix(query[i:], "-- "):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return positions
+			}
+			i += 2 + end + 1
+		}
+	}
+
+	return positions
+}
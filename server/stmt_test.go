@@ -0,0 +1,194 @@
+package server
+
+import (
+	"bufio"
+	dsql "database/sql"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+func TestPlaceholders(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []int
+	}{
+		{"SELECT 1", nil},
+		{"SELECT ?, ?", []int{7, 10}},
+		{"SELECT '?', \"?\", `?`, ?", []int{22}},
+		{`SELECT 'it\'s ?', ?`, []int{18}},
+		{"SELECT ? /* ? */, ? -- ?\n, ? # ?\n", []int{7, 18, 27}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.expected, placeholders(tt.query))
+		})
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	require := require.New(t)
+
+	stmt := &preparedStatement{
+		query:    "SELECT ?, ?, ?, ?, ?",
+		params:   placeholders("SELECT ?, ?, ?, ?, ?"),
+		longData: map[int][]byte{4: []byte("long")},
+	}
+
+	var data []byte
+	data = append(data, 0x02, 1)
+	data = appendUint16(data, mysqlTypeTiny)
+	data = appendUint16(data, mysqlTypeNull)
+	data = appendUint16(data, mysqlTypeLongLong|mysqlTypeUnsigned)
+	data = appendUint16(data, mysqlTypeDatetime)
+	data = appendUint16(data, 0xfd)
+	data = append(data, 0xff)
+	data = appendUint64(data, 1<<63)
+	data = append(data, 7, 0xe3, 0x07, 12, 31, 23, 59, 58)
+
+	query, err := stmt.bind(data)
+	require.NoError(err)
+	require.Equal("SELECT -1, NULL, 9223372036854775808, '2019-12-31 23:59:58', 'long'", query)
+
+	// The types are not sent again.
+	data = []byte{0x0e, 0, 1}
+	data = appendLenEncString(data, "it's")
+	query, err = stmt.bind(data)
+	require.NoError(err)
+	require.Equal(`SELECT 1, NULL, NULL, NULL, 'it\'s'`, query)
+
+	_, err = stmt.bind([]byte{0})
+	require.Error(err)
+}
+
+func TestServerPreparedStatements(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test", port))
+	require.NoError(err)
+	defer db.Close()
+
+	result, err := db.Exec("INSERT INTO test (c1) VALUES (?)", 2000)
+	require.NoError(err)
+	affected, err := result.RowsAffected()
+	require.NoError(err)
+	require.Equal(int64(1), affected)
+
+	rows, err := db.Query("SELECT c1 FROM test WHERE c1 >= ? AND c1 < ? ORDER BY c1", 1007, 3000)
+	require.NoError(err)
+
+	var values []int
+	for rows.Next() {
+		var n int
+		require.NoError(rows.Scan(&n))
+		values = append(values, n)
+	}
+	require.NoError(rows.Err())
+	require.NoError(rows.Close())
+	require.Equal([]int{1007, 1008, 1009, 2000}, values)
+
+	var str string
+	require.NoError(db.QueryRow("SELECT CONCAT(?, ?)", "it's ", []byte("?")).Scan(&str))
+	require.Equal("it's ?", str)
+
+	_, err = db.Prepare("SELECT FROM")
+	require.Error(err)
+}
+
+func TestServerCursor(t *testing.T) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     new(auth.None),
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	require.NoError(err)
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	readTestPacket(t, r)
+	testHandshake(t, conn, r, 0)
+
+	query := "SELECT c1 FROM test WHERE c1 >= ?"
+	writeTestPacket(t, conn, 0, append([]byte{comStmtPrepare}, query...))
+	prepared := readTestPacket(t, r)
+	require.Equal(byte(mysql.OKPacket), prepared[0])
+	id := binary.LittleEndian.Uint32(prepared[1:])
+	require.Equal(uint16(1), binary.LittleEndian.Uint16(prepared[7:]))
+	readTestPacket(t, r) // parameter definition
+	require.Equal(byte(mysql.EOFPacket), readTestPacket(t, r)[0])
+
+	execute := appendUint32([]byte{comStmtExecute}, id)
+	execute = append(execute, cursorTypeReadOnly, 1, 0, 0, 0, 0, 1)
+	execute = appendUint16(execute, mysqlTypeLongLong)
+	execute = appendUint64(execute, 1000)
+	writeTestPacket(t, conn, 0, execute)
+
+	require.Equal([]byte{1}, readTestPacket(t, r))
+	readTestPacket(t, r) // column definition
+	eof := readTestPacket(t, r)
+	require.Equal(byte(mysql.EOFPacket), eof[0])
+	require.NotZero(binary.LittleEndian.Uint16(eof[3:]) & serverStatusCursorExists)
+
+	fetch := func(n uint32) ([]int32, uint16) {
+		packet := appendUint32([]byte{comStmtFetch}, id)
+		writeTestPacket(t, conn, 0, appendUint32(packet, n))
+
+		var values []int32
+		for {
+			row := readTestPacket(t, r)
+			if row[0] == mysql.EOFPacket {
+				return values, binary.LittleEndian.Uint16(row[3:])
+			}
+			require.Equal(byte(mysql.OKPacket), row[0])
+			values = append(values, int32(binary.LittleEndian.Uint32(row[2:])))
+		}
+	}
+
+	values, status := fetch(4)
+	require.Equal([]int32{1000, 1001, 1002, 1003}, values)
+	require.NotZero(status & serverStatusCursorExists)
+	require.Zero(status & serverStatusLastRowSent)
+
+	values, status = fetch(100)
+	require.Equal([]int32{1004, 1005, 1006, 1007, 1008, 1009}, values)
+	require.NotZero(status & serverStatusLastRowSent)
+
+	packet := appendUint32([]byte{comStmtFetch}, id)
+	writeTestPacket(t, conn, 0, appendUint32(packet, 1))
+	errPacket := readTestPacket(t, r)
+	require.Equal(byte(mysql.ErrPacket), errPacket[0])
+	require.Equal(uint16(erStmtHasNoOpenCursor), binary.LittleEndian.Uint16(errPacket[1:]))
+
+	// Other commands are still handled by the listener.
+	writeTestPacket(t, conn, 0, append([]byte{comStmtClose}, appendUint32(nil, id)...))
+	writeTestPacket(t, conn, 0, []byte{mysql.ComPing})
+	require.Equal(byte(mysql.OKPacket), readTestPacket(t, r)[0])
+}
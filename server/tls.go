@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidTLSConfig is returned when the TLS configuration of the server
+// cannot be used.
+var ErrInvalidTLSConfig = errors.NewKind("invalid TLS configuration: %s")
+
+// TLSConfig configures the encrypted connections to the server. Clients
+// negotiate TLS during the MySQL handshake, the same way they do with a MySQL
+// server with SSL enabled.
+type TLSConfig struct {
+	// CertFile is the path of the PEM encoded certificate of the server.
+	CertFile string
+	// KeyFile is the path of the PEM encoded private key of the certificate.
+	KeyFile string
+	// CAFile is the path of the PEM encoded certificate authorities used to
+	// verify the certificates of the clients. Clients presenting a
+	// certificate not signed by them are rejected.
+	CAFile string
+	// VerifyClientCert requires all the clients to present a certificate
+	// signed by the authorities in CAFile.
+	VerifyClientCert bool
+	// RequireSecureTransport rejects the clients that do not use TLS, like
+	// the require_secure_transport variable of MySQL.
+	RequireSecureTransport bool
+}
+
+// Config returns the crypto/tls configuration of the server.
+func (c *TLSConfig) Config() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, ErrInvalidTLSConfig.New("a certificate and its key are required")
+	}
+
+	if c.VerifyClientCert && c.CAFile == "" {
+		return nil, ErrInvalidTLSConfig.New("verifying client certificates requires a CA file")
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, ErrInvalidTLSConfig.New(err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.CAFile != "" {
+		pem, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, ErrInvalidTLSConfig.New(err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, ErrInvalidTLSConfig.New("no certificates found in " + c.CAFile)
+		}
+
+		config.ClientCAs = pool
+		if c.VerifyClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,330 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	dsql "database/sql"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func newTestCert(
+	t *testing.T,
+	dir, name string,
+	parent *testCert,
+	usage x509.ExtKeyUsage,
+) *testCert {
+	t.Helper()
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(err)
+
+	c := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".pem"),
+		keyFile:  filepath.Join(dir, name+"-key.pem"),
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	require.NoError(ioutil.WriteFile(c.certFile, certPem, 0600))
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	require.NoError(ioutil.WriteFile(c.keyFile, keyPem, 0600))
+
+	return c
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, dir, "ca", nil, x509.ExtKeyUsageAny)
+	srv := newTestCert(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+
+	testCases := []struct {
+		name       string
+		config     TLSConfig
+		err        bool
+		clientAuth tls.ClientAuthType
+	}{
+		{
+			"certificate only",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile},
+			false,
+			tls.NoClientCert,
+		},
+		{
+			"optional client certificates",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile, CAFile: ca.certFile},
+			false,
+			tls.VerifyClientCertIfGiven,
+		},
+		{
+			"required client certificates",
+			TLSConfig{
+				CertFile:         srv.certFile,
+				KeyFile:          srv.keyFile,
+				CAFile:           ca.certFile,
+				VerifyClientCert: true,
+			},
+			false,
+			tls.RequireAndVerifyClientCert,
+		},
+		{
+			"no key",
+			TLSConfig{CertFile: srv.certFile},
+			true,
+			0,
+		},
+		{
+			"client certificates without CA",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile, VerifyClientCert: true},
+			true,
+			0,
+		},
+		{
+			"missing certificate",
+			TLSConfig{CertFile: filepath.Join(dir, "nope.pem"), KeyFile: srv.keyFile},
+			true,
+			0,
+		},
+		{
+			"invalid CA",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile, CAFile: srv.keyFile},
+			true,
+			0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			config, err := tt.config.Config()
+			if tt.err {
+				require.Error(err)
+				require.True(ErrInvalidTLSConfig.Is(err))
+				return
+			}
+
+			require.NoError(err)
+			require.Len(config.Certificates, 1)
+			require.Equal(tt.clientAuth, config.ClientAuth)
+		})
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, dir, "ca", nil, x509.ExtKeyUsageAny)
+	srv := newTestCert(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, dir, "client", ca, x509.ExtKeyUsageClientAuth)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+	require.NoError(t, err)
+
+	require.NoError(t, mysql.RegisterTLSConfig("server-tls-test", &tls.Config{
+		RootCAs:    roots,
+		ServerName: "localhost",
+	}))
+	require.NoError(t, mysql.RegisterTLSConfig("server-tls-test-client-cert", &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{clientCert},
+	}))
+
+	testCases := []struct {
+		name    string
+		config  TLSConfig
+		tls     string
+		success bool
+	}{
+		{
+			"plain connection allowed",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile},
+			"false",
+			true,
+		},
+		{
+			"plain connection rejected",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile, RequireSecureTransport: true},
+			"false",
+			false,
+		},
+		{
+			"secure connection",
+			TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile, RequireSecureTransport: true},
+			"server-tls-test",
+			true,
+		},
+		{
+			"missing client certificate",
+			TLSConfig{
+				CertFile:         srv.certFile,
+				KeyFile:          srv.keyFile,
+				CAFile:           ca.certFile,
+				VerifyClientCert: true,
+			},
+			"server-tls-test",
+			false,
+		},
+		{
+			"client certificate",
+			TLSConfig{
+				CertFile:         srv.certFile,
+				KeyFile:          srv.keyFile,
+				CAFile:           ca.certFile,
+				VerifyClientCert: true,
+			},
+			"server-tls-test-client-cert",
+			true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			port, err := getFreePort()
+			require.NoError(err)
+
+			config := tt.config
+			s, err := NewDefaultServer(Config{
+				Protocol: "tcp",
+				Address:  "localhost:" + port,
+				Auth:     auth.NewNativeSingle("root", "", auth.AllPermissions),
+				TLS:      &config,
+			}, setupMemDB(require))
+			require.NoError(err)
+			go s.Start()
+			defer s.Close()
+
+			db, err := dsql.Open("mysql", fmt.Sprintf("root:@tcp(localhost:%s)/test?tls=%s", port, tt.tls))
+			require.NoError(err)
+			defer db.Close()
+
+			var n int
+			err = db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+			if tt.success {
+				require.NoError(err)
+				require.Equal(1010, n)
+			} else {
+				require.Error(err)
+			}
+		})
+	}
+}
+
+func TestServerCachingSha2Password(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, dir, "ca", nil, x509.ExtKeyUsageAny)
+	srv := newTestCert(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	require.NoError(t, mysql.RegisterTLSConfig("server-sha2-test", &tls.Config{
+		RootCAs:    roots,
+		ServerName: "localhost",
+	}))
+
+	port, err := getFreePort()
+	require.NoError(t, err)
+
+	users := auth.NewNativeSingle("user", "password", auth.AllPermissions)
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		Auth:     auth.NewProviderAuth(users, auth.CachingSha2PasswordMethod),
+		TLS:      &TLSConfig{CertFile: srv.certFile, KeyFile: srv.keyFile},
+	}, setupMemDB(require.New(t)))
+	require.NoError(t, err)
+	go s.Start()
+	defer s.Close()
+
+	testCases := []struct {
+		password string
+		tls      string
+		success  bool
+	}{
+		{"password", "server-sha2-test", true},
+		{"other", "server-sha2-test", false},
+		{"", "server-sha2-test", false},
+		{"password", "false", false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%s-%s", tt.password, tt.tls), func(t *testing.T) {
+			require := require.New(t)
+
+			db, err := dsql.Open("mysql", fmt.Sprintf("user:%s@tcp(localhost:%s)/test?tls=%s", tt.password, port, tt.tls))
+			require.NoError(err)
+			defer db.Close()
+
+			var n int
+			err = db.QueryRow("SELECT COUNT(*) FROM test").Scan(&n)
+			if tt.success {
+				require.NoError(err)
+				require.Equal(1010, n)
+			} else {
+				require.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,1725 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+var (
+	// ErrXDataModel is returned when a CRUD operation is not supported in
+	// the data model it uses.
+	ErrXDataModel = errors.NewKind("Invalid data model for %s")
+	// ErrXCollection is returned when a CRUD operation does not name its
+	// collection or table.
+	ErrXCollection = errors.NewKind("Invalid name of table/collection")
+	// ErrXExpression is returned when an expression is not supported.
+	ErrXExpression = errors.NewKind("Invalid expression: %s")
+	// ErrXOperator is returned when an expression has an unknown operator.
+	ErrXOperator = errors.NewKind("Invalid operator %s")
+	// ErrXPlaceholder is returned when a placeholder has no argument.
+	ErrXPlaceholder = errors.NewKind("Invalid value of placeholder %d")
+	// ErrXDocument is returned when a document inserted is not an object.
+	ErrXDocument = errors.NewKind("Document must be an object, not %s")
+	// ErrXDocumentID is returned when a document is inserted with the id
+	// of another one.
+	ErrXDocumentID = errors.NewKind("Document contains a field value that is not unique but required to be: %s")
+	// ErrXProjection is returned when a projection of documents does not
+	// have a name.
+	ErrXProjection = errors.NewKind("Invalid projection target name")
+	// ErrXUpdate is returned when an update operation is not supported.
+	ErrXUpdate = errors.NewKind("Invalid update operation %d")
+	// ErrXUpdateID is returned when an update changes the id of a
+	// document.
+	ErrXUpdateID = errors.NewKind("Forbidden update operation on '$._id' member")
+	// ErrXOffset is returned when an update or delete has an offset.
+	ErrXOffset = errors.NewKind("Invalid parameter: non-zero offset value not allowed for this operation")
+)
+
+// Data models of the CRUD operations.
+const (
+	xDocument = 1
+	xTable    = 2
+)
+
+// collectionColumns are the columns of the tables of collections, which
+// have the documents and their ids.
+const collectionColumns = "(doc JSON, _id VARCHAR(32) NOT NULL, PRIMARY KEY (_id))"
+
+// isCollection returns whether a table with the given schema is a
+// collection.
+func isCollection(schema sql.Schema) bool {
+	return len(schema) == 2 && schema[0].Name == "doc" && schema[1].Name == "_id"
+}
+
+// xCollection is the collection or table of a CRUD operation.
+type xCollection struct {
+	schema string
+	name   string
+}
+
+func decodeXCollection(f xFields, n uint64) (xCollection, error) {
+	m, err := f.message(n)
+	if err != nil {
+		return xCollection{}, err
+	}
+
+	coll := xCollection{name: m.str(1), schema: m.str(2)}
+	if coll.name == "" {
+		return xCollection{}, ErrXCollection.New()
+	}
+	return coll, nil
+}
+
+func (c xCollection) String() string {
+	if c.schema == "" {
+		return quoteIdentifier(c.name)
+	}
+	return quoteIdentifier(c.schema) + "." + quoteIdentifier(c.name)
+}
+
+// Types of Mysqlx.Expr.Expr.
+const (
+	xExprIdent       = 1
+	xExprLiteral     = 2
+	xExprVariable    = 3
+	xExprFuncCall    = 4
+	xExprOperator    = 5
+	xExprPlaceholder = 6
+	xExprObject      = 7
+	xExprArray       = 8
+)
+
+// Types of the items of document paths.
+const (
+	xPathMember     = 1
+	xPathArrayIndex = 3
+)
+
+// xExpr is an expression of a CRUD operation.
+type xExpr struct {
+	typ uint64
+	// The column and document path of identifiers.
+	column string
+	table  string
+	path   []xPathItem
+	// The value of literals.
+	literal interface{}
+	// The name of functions and operators and their parameters.
+	name   string
+	params []*xExpr
+	// The argument of placeholders.
+	position uint64
+	// The keys and values of objects, and the values of arrays.
+	keys   []string
+	values []*xExpr
+}
+
+// xPathItem is a member or an array index of a document path.
+type xPathItem struct {
+	typ    uint64
+	member string
+	index  uint64
+}
+
+func decodeXExprField(f xFields, n uint64) (*xExpr, error) {
+	if !f.has(n) {
+		return nil, nil
+	}
+
+	m, err := f.message(n)
+	if err != nil {
+		return nil, err
+	}
+	return decodeXExpr(m)
+}
+
+func decodeXExprs(list []xFields) ([]*xExpr, error) {
+	exprs := make([]*xExpr, len(list))
+	for i, m := range list {
+		var err error
+		exprs[i], err = decodeXExpr(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return exprs, nil
+}
+
+// decodeXExpr decodes a Mysqlx.Expr.Expr.
+func decodeXExpr(f xFields) (*xExpr, error) {
+	e := &xExpr{typ: f.uint(1)}
+	switch e.typ {
+	case xExprIdent:
+		id, err := f.message(2)
+		if err != nil {
+			return nil, err
+		}
+
+		e.column = id.str(2)
+		e.table = id.str(3)
+		e.path, err = decodeXPath(id, 1)
+		if err != nil {
+			return nil, err
+		}
+	case xExprLiteral:
+		scalar, err := f.message(4)
+		if err != nil {
+			return nil, err
+		}
+
+		e.literal, err = decodeXScalar(scalar)
+		if err != nil {
+			return nil, err
+		}
+	case xExprVariable:
+		e.name = f.str(3)
+	case xExprFuncCall, xExprOperator:
+		n := uint64(6)
+		if e.typ == xExprFuncCall {
+			n = 5
+		}
+
+		call, err := f.message(n)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.typ == xExprFuncCall {
+			id, err := call.message(1)
+			if err != nil {
+				return nil, err
+			}
+			e.name = id.str(1)
+		} else {
+			e.name = call.str(1)
+		}
+
+		params, err := call.messages(2)
+		if err != nil {
+			return nil, err
+		}
+
+		e.params, err = decodeXExprs(params)
+		if err != nil {
+			return nil, err
+		}
+	case xExprPlaceholder:
+		e.position = f.uint(7)
+	case xExprObject:
+		obj, err := f.message(8)
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := obj.messages(1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			value, err := decodeXExprField(field, 2)
+			if err != nil {
+				return nil, err
+			}
+			e.keys = append(e.keys, field.str(1))
+			e.values = append(e.values, value)
+		}
+	case xExprArray:
+		arr, err := f.message(9)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := arr.messages(1)
+		if err != nil {
+			return nil, err
+		}
+
+		e.values, err = decodeXExprs(values)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrXInvalidMessage.New("unknown expression type")
+	}
+
+	return e, nil
+}
+
+func decodeXPath(f xFields, n uint64) ([]xPathItem, error) {
+	items, err := f.messages(n)
+	if err != nil {
+		return nil, err
+	}
+
+	path := make([]xPathItem, len(items))
+	for i, item := range items {
+		path[i] = xPathItem{typ: item.uint(1), member: item.str(2), index: item.uint(3)}
+		if path[i].typ != xPathMember && path[i].typ != xPathArrayIndex {
+			return nil, ErrXExpression.New("document paths with wildcards are not supported")
+		}
+	}
+	return path, nil
+}
+
+// jsonPath returns a document path in the syntax of the JSON functions.
+func jsonPath(path []xPathItem) string {
+	s := "$"
+	for _, item := range path {
+		if item.typ == xPathArrayIndex {
+			s += fmt.Sprintf("[%d]", item.index)
+		} else {
+			s += "." + item.member
+		}
+	}
+	return s
+}
+
+// sqlOperators are the SQL operators of the binary X Protocol operators.
+var sqlOperators = map[string]string{
+	"==":         "=",
+	"!=":         "<>",
+	"<":          "<",
+	"<=":         "<=",
+	">":          ">",
+	">=":         ">=",
+	"&&":         "AND",
+	"||":         "OR",
+	"xor":        "XOR",
+	"+":          "+",
+	"-":          "-",
+	"*":          "*",
+	"/":          "/",
+	"div":        "DIV",
+	"%":          "%",
+	"&":          "&",
+	"|":          "|",
+	"^":          "^",
+	"<<":         "<<",
+	">>":         ">>",
+	"like":       "LIKE",
+	"not_like":   "NOT LIKE",
+	"regexp":     "REGEXP",
+	"not_regexp": "NOT REGEXP",
+	"is":         "IS",
+	"is_not":     "IS NOT",
+}
+
+// sqlUnaryOperators are the SQL operators of the unary X Protocol
+// operators.
+var sqlUnaryOperators = map[string]string{
+	"!":          "NOT ",
+	"not":        "NOT ",
+	"sign_minus": "-",
+	"sign_plus":  "+",
+	"~":          "~",
+}
+
+// sql returns the expression in SQL, used with tables, whose identifiers
+// are columns.
+func (e *xExpr) sql(args []interface{}) (string, error) {
+	switch e.typ {
+	case xExprIdent:
+		column := quoteIdentifier(e.column)
+		if e.table != "" {
+			column = quoteIdentifier(e.table) + "." + column
+		}
+		if len(e.path) > 0 {
+			return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, %s))", column, quoteString(jsonPath(e.path))), nil
+		}
+		return column, nil
+	case xExprLiteral:
+		return sqlLiteral(e.literal)
+	case xExprPlaceholder:
+		if e.position >= uint64(len(args)) {
+			return "", ErrXPlaceholder.New(e.position)
+		}
+		return sqlLiteral(args[e.position])
+	case xExprFuncCall:
+		params, err := sqlExprs(e.params, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s(%s)", e.name, strings.Join(params, ", ")), nil
+	case xExprOperator:
+		params, err := sqlExprs(e.params, args)
+		if err != nil {
+			return "", err
+		}
+
+		switch name := e.name; {
+		case name == "*" && len(params) == 0:
+			return "*", nil
+		case sqlOperators[name] != "" && len(params) == 2:
+			return fmt.Sprintf("(%s %s %s)", params[0], sqlOperators[name], params[1]), nil
+		case sqlUnaryOperators[name] != "" && len(params) == 1:
+			return fmt.Sprintf("(%s%s)", sqlUnaryOperators[name], params[0]), nil
+		case (name == "in" || name == "not_in") && len(params) > 1:
+			op := "IN"
+			if name == "not_in" {
+				op = "NOT IN"
+			}
+			return fmt.Sprintf("(%s %s (%s))", params[0], op, strings.Join(params[1:], ", ")), nil
+		case (name == "between" || name == "not_between") && len(params) == 3:
+			op := "BETWEEN"
+			if name == "not_between" {
+				op = "NOT BETWEEN"
+			}
+			return fmt.Sprintf("(%s %s %s AND %s)", params[0], op, params[1], params[2]), nil
+		default:
+			return "", ErrXOperator.New(name)
+		}
+	default:
+		return "", ErrXExpression.New("only identifiers, literals, placeholders, functions and operators can be used with tables")
+	}
+}
+
+func sqlExprs(exprs []*xExpr, args []interface{}) ([]string, error) {
+	list := make([]string, len(exprs))
+	for i, e := range exprs {
+		var err error
+		list[i], err = e.sql(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// eval evaluates the expression for a document, whose fields are its
+// identifiers. Values are the ones of encoding/json, with the numbers of
+// the documents as json.Number.
+func (e *xExpr) eval(doc interface{}, args []interface{}) (interface{}, error) {
+	switch e.typ {
+	case xExprIdent:
+		path := e.path
+		if len(path) == 0 && e.column != "" {
+			path = []xPathItem{{typ: xPathMember, member: e.column}}
+		}
+		v, _ := lookupPath(doc, path)
+		return v, nil
+	case xExprLiteral:
+		return jsonValue(e.literal), nil
+	case xExprPlaceholder:
+		if e.position >= uint64(len(args)) {
+			return nil, ErrXPlaceholder.New(e.position)
+		}
+		return jsonValue(args[e.position]), nil
+	case xExprObject:
+		obj := make(map[string]interface{}, len(e.keys))
+		for i, key := range e.keys {
+			v, err := e.values[i].eval(doc, args)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = v
+		}
+		return obj, nil
+	case xExprArray:
+		arr := make([]interface{}, len(e.values))
+		for i, value := range e.values {
+			var err error
+			arr[i], err = value.eval(doc, args)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case xExprOperator:
+		params := make([]interface{}, len(e.params))
+		for i, p := range e.params {
+			var err error
+			params[i], err = p.eval(doc, args)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return evalOperator(e.name, params)
+	default:
+		return nil, ErrXExpression.New("only identifiers, literals, placeholders, objects, arrays and operators can be used with documents")
+	}
+}
+
+// jsonValue converts a value of an argument or literal to the types of
+// encoding/json.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10))
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10))
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = jsonValue(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonValue(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return err == nil && f != 0
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		f, _ := toNumber(v)
+		return f != 0
+	}
+}
+
+// compareValues compares two values, returning false if they cannot be
+// compared.
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := toNumber(a); ok {
+		if fb, ok := toNumber(b); ok {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	sa, ok := a.(string)
+	sb, ok2 := b.(string)
+	if ok && ok2 {
+		return strings.Compare(sa, sb), true
+	}
+
+	if a != nil && b != nil && reflect.DeepEqual(a, b) {
+		return 0, true
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	cmp, ok := compareValues(a, b)
+	return ok && cmp == 0
+}
+
+// contains returns whether a value is in an array, or an object has the
+// members of another one.
+func contains(container, v interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		if arr, ok := v.([]interface{}); ok {
+			for _, item := range arr {
+				if !contains(c, item) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, item := range c {
+			if equalValues(item, v) || contains(item, v) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for key, value := range obj {
+			member, ok := c[key]
+			if !ok || !(equalValues(member, value) || contains(member, value)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equalValues(container, v)
+	}
+}
+
+// likeRegexp returns the regular expression of a LIKE pattern.
+func likeRegexp(pattern string, escape byte) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case ch == escape && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case ch == '%':
+			b.WriteString(".*")
+		case ch == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func evalOperator(name string, params []interface{}) (interface{}, error) {
+	arity := map[string]int{
+		"!": 1, "not": 1, "sign_minus": 1, "sign_plus": 1,
+		"between": 3, "not_between": 3,
+	}[name]
+	if arity == 0 {
+		arity = 2
+	}
+
+	variadic := name == "in" || name == "not_in" || name == "like" || name == "not_like"
+	if len(params) < arity || (!variadic && len(params) != arity) {
+		return nil, ErrXOperator.New(name)
+	}
+
+	a := params[0]
+	switch name {
+	case "!", "not":
+		return !truthy(a), nil
+	case "sign_plus":
+		return a, nil
+	case "sign_minus":
+		f, ok := toNumber(a)
+		if !ok {
+			return nil, nil
+		}
+		return -f, nil
+	case "&&":
+		return truthy(a) && truthy(params[1]), nil
+	case "||":
+		return truthy(a) || truthy(params[1]), nil
+	case "in", "not_in":
+		found := false
+		for _, p := range params[1:] {
+			if equalValues(a, p) {
+				found = true
+			}
+		}
+		return found == (name == "in"), nil
+	case "cont_in", "not_cont_in":
+		return contains(params[1], a) == (name == "cont_in"), nil
+	case "is", "is_not":
+		var is bool
+		switch b := params[1].(type) {
+		case nil:
+			is = a == nil
+		case bool:
+			is = a != nil && truthy(a) == b
+		default:
+			return nil, ErrXOperator.New(name)
+		}
+		return is == (name == "is"), nil
+	case "between", "not_between":
+		low, ok := compareValues(a, params[1])
+		high, ok2 := compareValues(a, params[2])
+		if !ok || !ok2 {
+			return nil, nil
+		}
+		return (low >= 0 && high <= 0) == (name == "between"), nil
+	case "like", "not_like", "regexp", "not_regexp":
+		s, ok := a.(string)
+		pattern, ok2 := params[1].(string)
+		if !ok || !ok2 {
+			return nil, nil
+		}
+
+		var re *regexp.Regexp
+		if strings.HasSuffix(name, "like") {
+			escape := byte('\\')
+			if len(params) > 2 {
+				if e, ok := params[2].(string); ok && len(e) == 1 {
+					escape = e[0]
+				}
+			}
+			re = likeRegexp(pattern, escape)
+		} else {
+			var err error
+			re, err = regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return re.MatchString(s) == !strings.HasPrefix(name, "not_"), nil
+	}
+
+	b := params[1]
+	if a == nil || b == nil {
+		return nil, nil
+	}
+
+	switch name {
+	case "==":
+		return equalValues(a, b), nil
+	case "!=":
+		return !equalValues(a, b), nil
+	case "<", "<=", ">", ">=":
+		cmp, ok := compareValues(a, b)
+		if !ok {
+			return nil, nil
+		}
+		switch name {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	case "+", "-", "*", "/", "div", "%":
+		fa, ok := toNumber(a)
+		fb, ok2 := toNumber(b)
+		if !ok || !ok2 {
+			return nil, nil
+		}
+		switch name {
+		case "+":
+			return fa + fb, nil
+		case "-":
+			return fa - fb, nil
+		case "*":
+			return fa * fb, nil
+		}
+		if fb == 0 {
+			return nil, nil
+		}
+		switch name {
+		case "/":
+			return fa / fb, nil
+		case "div":
+			return float64(int64(fa / fb)), nil
+		default:
+			return float64(int64(fa) % int64(fb)), nil
+		}
+	default:
+		return nil, ErrXOperator.New(name)
+	}
+}
+
+func lookupPath(v interface{}, path []xPathItem) (interface{}, bool) {
+	for _, item := range path {
+		switch item.typ {
+		case xPathMember:
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[item.member]; !ok {
+				return nil, false
+			}
+		default:
+			arr, ok := v.([]interface{})
+			if !ok || item.index >= uint64(len(arr)) {
+				return nil, false
+			}
+			v = arr[item.index]
+		}
+	}
+	return v, true
+}
+
+// setPath sets the value at a path of a document, returning whether it
+// was set. If the last member or index does not exist, it's added if
+// create is true.
+func setPath(doc interface{}, path []xPathItem, value interface{}, create bool) bool {
+	parent, ok := lookupPath(doc, path[:len(path)-1])
+	if !ok {
+		return false
+	}
+
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if last.typ != xPathMember {
+			return false
+		}
+		if _, ok := p[last.member]; !ok && !create {
+			return false
+		}
+		p[last.member] = value
+		return true
+	case []interface{}:
+		if last.typ != xPathArrayIndex {
+			return false
+		}
+		if last.index < uint64(len(p)) {
+			p[last.index] = value
+			return true
+		}
+		if !create {
+			return false
+		}
+		// Arrays cannot grow in place, so the parent is replaced.
+		return len(path) > 1 && setPath(doc, path[:len(path)-1], append(p, value), false)
+	default:
+		return false
+	}
+}
+
+func removePath(doc interface{}, path []xPathItem) {
+	parent, ok := lookupPath(doc, path[:len(path)-1])
+	if !ok {
+		return
+	}
+
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		delete(p, last.member)
+	case []interface{}:
+		if last.typ == xPathArrayIndex && last.index < uint64(len(p)) && len(path) > 1 {
+			arr := append(append([]interface{}{}, p[:last.index]...), p[last.index+1:]...)
+			setPath(doc, path[:len(path)-1], arr, false)
+		}
+	}
+}
+
+// mergePreserve merges two values as JSON_MERGE_PRESERVE: the members of
+// objects are merged and other values are merged into arrays.
+func mergePreserve(a, b interface{}) interface{} {
+	objA, ok := a.(map[string]interface{})
+	objB, ok2 := b.(map[string]interface{})
+	if ok && ok2 {
+		for key, value := range objB {
+			if old, ok := objA[key]; ok {
+				objA[key] = mergePreserve(old, value)
+			} else {
+				objA[key] = value
+			}
+		}
+		return objA
+	}
+
+	toArray := func(v interface{}) []interface{} {
+		if arr, ok := v.([]interface{}); ok {
+			return arr
+		}
+		return []interface{}{v}
+	}
+	return append(toArray(a), toArray(b)...)
+}
+
+// mergePatch applies a JSON merge patch, as defined by RFC 7396.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+// Operations of the updates.
+const (
+	xUpdateSet         = 1
+	xUpdateItemRemove  = 2
+	xUpdateItemSet     = 3
+	xUpdateItemReplace = 4
+	xUpdateItemMerge   = 5
+	xUpdateArrayInsert = 6
+	xUpdateArrayAppend = 7
+	xUpdateMergePatch  = 8
+)
+
+// xUpdateOperation is an operation of an update, which changes a column
+// or a member of the documents.
+type xUpdateOperation struct {
+	column string
+	path   []xPathItem
+	op     uint64
+	value  *xExpr
+}
+
+// apply applies the operation to a document, returning the new document.
+func (o *xUpdateOperation) apply(doc map[string]interface{}, args []interface{}) (map[string]interface{}, error) {
+	if len(o.path) > 0 && o.path[0].typ == xPathMember && o.path[0].member == "_id" {
+		return nil, ErrXUpdateID.New()
+	}
+
+	var value interface{}
+	if o.value != nil {
+		var err error
+		value, err = o.value.eval(doc, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var root interface{} = doc
+	switch o.op {
+	case xUpdateItemRemove:
+		if len(o.path) > 0 {
+			removePath(root, o.path)
+		}
+	case xUpdateItemSet, xUpdateItemReplace:
+		if len(o.path) == 0 {
+			return nil, ErrXUpdate.New(o.op)
+		}
+		setPath(root, o.path, value, o.op == xUpdateItemSet)
+	case xUpdateItemMerge, xUpdateMergePatch:
+		target, ok := lookupPath(root, o.path)
+		if !ok {
+			return doc, nil
+		}
+
+		var merged interface{}
+		if o.op == xUpdateItemMerge {
+			if _, ok := value.(map[string]interface{}); !ok {
+				return nil, ErrXDocument.New(fmt.Sprintf("%T", value))
+			}
+			merged = mergePreserve(target, value)
+		} else {
+			merged = mergePatch(target, value)
+		}
+
+		if len(o.path) == 0 {
+			obj, ok := merged.(map[string]interface{})
+			if !ok {
+				return nil, ErrXDocument.New(fmt.Sprintf("%T", merged))
+			}
+			root = obj
+		} else {
+			setPath(root, o.path, merged, false)
+		}
+	case xUpdateArrayInsert:
+		if len(o.path) < 2 || o.path[len(o.path)-1].typ != xPathArrayIndex {
+			return nil, ErrXUpdate.New(o.op)
+		}
+
+		parent := o.path[:len(o.path)-1]
+		arr, ok := lookupPath(root, parent)
+		if a, isArray := arr.([]interface{}); ok && isArray {
+			i := o.path[len(o.path)-1].index
+			if i > uint64(len(a)) {
+				i = uint64(len(a))
+			}
+			a = append(a[:i], append([]interface{}{value}, a[i:]...)...)
+			setPath(root, parent, a, false)
+		}
+	case xUpdateArrayAppend:
+		if len(o.path) == 0 {
+			return nil, ErrXUpdate.New(o.op)
+		}
+
+		target, ok := lookupPath(root, o.path)
+		if !ok {
+			return doc, nil
+		}
+
+		var arr []interface{}
+		if a, ok := target.([]interface{}); ok {
+			arr = append(a, value)
+		} else {
+			arr = []interface{}{target, value}
+		}
+		setPath(root, o.path, arr, false)
+	default:
+		return nil, ErrXUpdate.New(o.op)
+	}
+
+	return root.(map[string]interface{}), nil
+}
+
+// xLimit is the limit of a CRUD operation.
+type xLimit struct {
+	count  uint64
+	offset uint64
+}
+
+func decodeXLimit(f xFields, n uint64) (*xLimit, error) {
+	if !f.has(n) {
+		return nil, nil
+	}
+
+	m, err := f.message(n)
+	if err != nil {
+		return nil, err
+	}
+	return &xLimit{count: m.uint(1), offset: m.uint(2)}, nil
+}
+
+// xOrder is an ordering expression of a CRUD operation.
+type xOrder struct {
+	expr *xExpr
+	desc bool
+}
+
+func decodeXOrder(f xFields, n uint64) ([]xOrder, error) {
+	list, err := f.messages(n)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]xOrder, len(list))
+	for i, m := range list {
+		expr, err := decodeXExprField(m, 1)
+		if err != nil {
+			return nil, err
+		}
+		if expr == nil {
+			return nil, ErrXInvalidMessage.New("order without expression")
+		}
+		order[i] = xOrder{expr: expr, desc: m.uint(2) == 2}
+	}
+	return order, nil
+}
+
+func decodeXArgs(f xFields, n uint64) ([]interface{}, error) {
+	list, err := f.messages(n)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]interface{}, len(list))
+	for i, m := range list {
+		args[i], err = decodeXScalar(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// sqlClauses returns the WHERE, ORDER BY and LIMIT clauses of a CRUD
+// operation on a table.
+func sqlClauses(criteria *xExpr, order []xOrder, limit *xLimit, args []interface{}) (string, error) {
+	var b strings.Builder
+	if criteria != nil {
+		where, err := criteria.sql(args)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" WHERE " + where)
+	}
+
+	for i, o := range order {
+		expr, err := o.expr.sql(args)
+		if err != nil {
+			return "", err
+		}
+
+		if i == 0 {
+			b.WriteString(" ORDER BY ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(expr)
+		if o.desc {
+			b.WriteString(" DESC")
+		}
+	}
+
+	if limit != nil {
+		if limit.offset > 0 {
+			fmt.Fprintf(&b, " LIMIT %d, %d", limit.offset, limit.count)
+		} else {
+			fmt.Fprintf(&b, " LIMIT %d", limit.count)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// xDoc is a document of a collection.
+type xDoc struct {
+	id  string
+	doc map[string]interface{}
+}
+
+// documents returns the documents of a collection that match the
+// criteria, sorted and limited.
+func (c *xConn) documents(coll xCollection, criteria *xExpr, order []xOrder, limit *xLimit, args []interface{}) ([]xDoc, error) {
+	_, rows, err := c.query("SELECT doc, _id FROM " + coll.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []xDoc
+	var keys [][]interface{}
+	for _, row := range rows {
+		data, err := sql.JSON.Convert(row[0])
+		if err != nil {
+			return nil, err
+		}
+
+		var doc map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(data.([]byte)))
+		d.UseNumber()
+		if err := d.Decode(&doc); err != nil {
+			return nil, err
+		}
+
+		if criteria != nil {
+			match, err := criteria.eval(doc, args)
+			if err != nil {
+				return nil, err
+			}
+			if !truthy(match) {
+				continue
+			}
+		}
+
+		key := make([]interface{}, len(order))
+		for i, o := range order {
+			key[i], err = o.expr.eval(doc, args)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		id, _ := row[1].(string)
+		docs = append(docs, xDoc{id: id, doc: doc})
+		keys = append(keys, key)
+	}
+
+	if len(order) > 0 {
+		idx := make([]int, len(docs))
+		for i := range idx {
+			idx[i] = i
+		}
+
+		sort.SliceStable(idx, func(i, j int) bool {
+			for k, o := range order {
+				cmp, ok := compareValues(keys[idx[i]][k], keys[idx[j]][k])
+				if !ok {
+					// Nulls and values that cannot be compared go first.
+					cmp = 0
+					if keys[idx[i]][k] == nil && keys[idx[j]][k] != nil {
+						cmp = -1
+					} else if keys[idx[i]][k] != nil && keys[idx[j]][k] == nil {
+						cmp = 1
+					}
+				}
+				if o.desc {
+					cmp = -cmp
+				}
+				if cmp != 0 {
+					return cmp < 0
+				}
+			}
+			return false
+		})
+
+		sorted := make([]xDoc, len(docs))
+		for i, j := range idx {
+			sorted[i] = docs[j]
+		}
+		docs = sorted
+	}
+
+	if limit != nil {
+		if limit.offset >= uint64(len(docs)) {
+			return nil, nil
+		}
+		docs = docs[limit.offset:]
+		if limit.count < uint64(len(docs)) {
+			docs = docs[:limit.count]
+		}
+	}
+
+	return docs, nil
+}
+
+// find sends the rows of a table or the documents of a collection that
+// match the criteria of a Mysqlx.Crud.Find.
+func (c *xConn) find(f xFields) error {
+	coll, err := decodeXCollection(f, 2)
+	if err != nil {
+		return err
+	}
+
+	criteria, err := decodeXExprField(f, 5)
+	if err != nil {
+		return err
+	}
+
+	limit, err := decodeXLimit(f, 6)
+	if err != nil {
+		return err
+	}
+
+	order, err := decodeXOrder(f, 7)
+	if err != nil {
+		return err
+	}
+
+	args, err := decodeXArgs(f, 11)
+	if err != nil {
+		return err
+	}
+
+	projections, err := f.messages(4)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*xExpr, len(projections))
+	aliases := make([]string, len(projections))
+	for i, p := range projections {
+		fields[i], err = decodeXExprField(p, 1)
+		if err != nil {
+			return err
+		}
+		if fields[i] == nil {
+			return ErrXProjection.New()
+		}
+		aliases[i] = p.str(2)
+	}
+
+	if f.uint(3) == xTable {
+		return c.findRows(coll, fields, aliases, criteria, order, limit, f, args)
+	}
+
+	if f.has(8) || f.has(9) {
+		return ErrXDataModel.New("grouping of documents")
+	}
+
+	docs, err := c.documents(coll, criteria, order, limit, args)
+	if err != nil {
+		return err
+	}
+
+	schema := sql.Schema{{Name: "doc", Type: sql.JSON}}
+	rows := make([]sql.Row, len(docs))
+	for i, d := range docs {
+		var doc interface{} = d.doc
+		if len(fields) > 0 {
+			doc, err = project(d.doc, fields, aliases, args)
+			if err != nil {
+				return err
+			}
+		}
+
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		rows[i] = sql.Row{string(data)}
+	}
+
+	if err := c.writeResultset(schema, rows); err != nil {
+		return err
+	}
+	return c.writeOk(0)
+}
+
+// project returns the document with the projected fields, named by their
+// aliases or the last member of their paths. A single object without
+// alias is the whole document.
+func project(doc map[string]interface{}, fields []*xExpr, aliases []string, args []interface{}) (interface{}, error) {
+	if len(fields) == 1 && aliases[0] == "" && fields[0].typ == xExprObject {
+		return fields[0].eval(doc, args)
+	}
+
+	result := make(map[string]interface{}, len(fields))
+	for i, field := range fields {
+		name := aliases[i]
+		if name == "" && field.typ == xExprIdent {
+			if n := len(field.path); n > 0 && field.path[n-1].typ == xPathMember {
+				name = field.path[n-1].member
+			} else {
+				name = field.column
+			}
+		}
+		if name == "" {
+			return nil, ErrXProjection.New()
+		}
+
+		v, err := field.eval(doc, args)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = v
+	}
+	return result, nil
+}
+
+func (c *xConn) findRows(
+	coll xCollection,
+	fields []*xExpr,
+	aliases []string,
+	criteria *xExpr,
+	order []xOrder,
+	limit *xLimit,
+	f xFields,
+	args []interface{},
+) error {
+	columns := "*"
+	if len(fields) > 0 {
+		list := make([]string, len(fields))
+		for i, field := range fields {
+			var err error
+			list[i], err = field.sql(args)
+			if err != nil {
+				return err
+			}
+			if aliases[i] != "" {
+				list[i] += " AS " + quoteIdentifier(aliases[i])
+			}
+		}
+		columns = strings.Join(list, ", ")
+	}
+
+	where, err := sqlClauses(criteria, nil, nil, args)
+	if err != nil {
+		return err
+	}
+
+	grouping, err := f.messages(8)
+	if err != nil {
+		return err
+	}
+
+	var group string
+	if len(grouping) > 0 {
+		exprs, err := decodeXExprs(grouping)
+		if err != nil {
+			return err
+		}
+
+		list, err := sqlExprs(exprs, args)
+		if err != nil {
+			return err
+		}
+		group = " GROUP BY " + strings.Join(list, ", ")
+	}
+
+	having, err := decodeXExprField(f, 9)
+	if err != nil {
+		return err
+	}
+	if having != nil {
+		cond, err := having.sql(args)
+		if err != nil {
+			return err
+		}
+		group += " HAVING " + cond
+	}
+
+	rest, err := sqlClauses(nil, order, limit, args)
+	if err != nil {
+		return err
+	}
+
+	return c.execute("SELECT " + columns + " FROM " + coll.String() + where + group + rest)
+}
+
+// documentIDs generate the ids of the documents inserted without one,
+// which have a prefix, the time the server started and a serial number in
+// hexadecimal, as in MySQL.
+var documentIDs = struct {
+	start  int64
+	serial uint64
+}{start: time.Now().Unix()}
+
+func newDocumentID() string {
+	serial := atomic.AddUint64(&documentIDs.serial, 1)
+	return fmt.Sprintf("%04x%08x%016x", 0, uint32(documentIDs.start), serial)
+}
+
+// insert inserts the rows of a Mysqlx.Crud.Insert into a table, or its
+// documents into a collection.
+func (c *xConn) insert(f xFields) error {
+	coll, err := decodeXCollection(f, 1)
+	if err != nil {
+		return err
+	}
+
+	args, err := decodeXArgs(f, 5)
+	if err != nil {
+		return err
+	}
+
+	list, err := f.messages(4)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]*xExpr, len(list))
+	for i, m := range list {
+		values, err := m.messages(1)
+		if err != nil {
+			return err
+		}
+
+		rows[i], err = decodeXExprs(values)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.uint(2) == xTable {
+		if f.bool(6) {
+			return ErrXDataModel.New("upserts of rows")
+		}
+		return c.insertRows(coll, f, rows, args)
+	}
+
+	var values []string
+	var generated []interface{}
+	ids := make(map[string]bool)
+	for _, row := range rows {
+		if len(row) != 1 {
+			return ErrXDocument.New(fmt.Sprintf("a row with %d values", len(row)))
+		}
+
+		v, err := row[0].eval(nil, args)
+		if err != nil {
+			return err
+		}
+
+		doc, err := toDocument(v)
+		if err != nil {
+			return err
+		}
+
+		id, ok := doc["_id"].(string)
+		if !ok {
+			if _, exists := doc["_id"]; exists {
+				return ErrXDocument.New("a document with an _id that is not a string")
+			}
+			id = newDocumentID()
+			doc["_id"] = id
+			generated = append(generated, []byte(id))
+		}
+
+		if ids[id] {
+			return ErrXDocumentID.New(id)
+		}
+		ids[id] = true
+
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		values = append(values, fmt.Sprintf("(%s, %s)", quoteString(string(data)), quoteString(id)))
+	}
+
+	// The ids are not unique in every table, so they are checked before
+	// inserting the documents. Upserts replace the documents that exist.
+	existing, err := c.documents(coll, nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range existing {
+		if !ids[d.id] {
+			continue
+		}
+
+		if !f.bool(6) {
+			return ErrXDocumentID.New(d.id)
+		}
+
+		if _, _, err := c.query("DELETE FROM " + coll.String() + " WHERE _id = " + quoteString(d.id)); err != nil {
+			return err
+		}
+	}
+
+	if len(values) > 0 {
+		query := "INSERT INTO " + coll.String() + " (doc, _id) VALUES " + strings.Join(values, ", ")
+		if _, _, err := c.query(query); err != nil {
+			return err
+		}
+	}
+
+	if len(generated) > 0 {
+		if err := c.notice(xStateGeneratedDocumentIDs, generated...); err != nil {
+			return err
+		}
+	}
+	return c.writeOk(uint64(len(values)))
+}
+
+// toDocument returns the document of a value, which is an object or a
+// string with its JSON.
+func toDocument(v interface{}) (map[string]interface{}, error) {
+	if s, ok := v.(string); ok {
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			return nil, ErrXDocument.New("invalid JSON")
+		}
+	}
+
+	doc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrXDocument.New(fmt.Sprintf("%T", v))
+	}
+	return doc, nil
+}
+
+func (c *xConn) insertRows(coll xCollection, f xFields, rows [][]*xExpr, args []interface{}) error {
+	columns, err := f.messages(3)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdentifier(col.str(1))
+	}
+
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		list, err := sqlExprs(row, args)
+		if err != nil {
+			return err
+		}
+		values[i] = "(" + strings.Join(list, ", ") + ")"
+	}
+
+	query := "INSERT INTO " + coll.String()
+	if len(names) > 0 {
+		query += " (" + strings.Join(names, ", ") + ")"
+	}
+	return c.execute(query + " VALUES " + strings.Join(values, ", "))
+}
+
+// update applies the operations of a Mysqlx.Crud.Update to the rows of a
+// table or the documents of a collection that match its criteria.
+func (c *xConn) update(f xFields) error {
+	coll, err := decodeXCollection(f, 2)
+	if err != nil {
+		return err
+	}
+
+	criteria, err := decodeXExprField(f, 4)
+	if err != nil {
+		return err
+	}
+
+	limit, err := decodeXLimit(f, 5)
+	if err != nil {
+		return err
+	}
+
+	order, err := decodeXOrder(f, 7)
+	if err != nil {
+		return err
+	}
+
+	args, err := decodeXArgs(f, 8)
+	if err != nil {
+		return err
+	}
+
+	if limit != nil && limit.offset > 0 {
+		return ErrXOffset.New()
+	}
+
+	list, err := f.messages(6)
+	if err != nil {
+		return err
+	}
+
+	ops := make([]xUpdateOperation, len(list))
+	for i, m := range list {
+		source, err := m.message(1)
+		if err != nil {
+			return err
+		}
+
+		path, err := decodeXPath(source, 1)
+		if err != nil {
+			return err
+		}
+
+		value, err := decodeXExprField(m, 3)
+		if err != nil {
+			return err
+		}
+
+		ops[i] = xUpdateOperation{column: source.str(2), path: path, op: m.uint(2), value: value}
+	}
+
+	if f.uint(3) == xTable {
+		set := make([]string, len(ops))
+		for i, op := range ops {
+			if op.op != xUpdateSet || len(op.path) > 0 || op.value == nil {
+				return ErrXUpdate.New(op.op)
+			}
+
+			value, err := op.value.sql(args)
+			if err != nil {
+				return err
+			}
+			set[i] = quoteIdentifier(op.column) + " = " + value
+		}
+
+		clauses, err := sqlClauses(criteria, order, limit, args)
+		if err != nil {
+			return err
+		}
+		return c.execute("UPDATE " + coll.String() + " SET " + strings.Join(set, ", ") + clauses)
+	}
+
+	docs, err := c.documents(coll, criteria, order, limit, args)
+	if err != nil {
+		return err
+	}
+
+	var affected uint64
+	for _, d := range docs {
+		before, err := json.Marshal(d.doc)
+		if err != nil {
+			return err
+		}
+
+		doc := d.doc
+		for _, op := range ops {
+			if op.op == xUpdateSet {
+				return ErrXUpdate.New(op.op)
+			}
+
+			doc, err = op.apply(doc, args)
+			if err != nil {
+				return err
+			}
+		}
+
+		if id, _ := doc["_id"].(string); id != d.id {
+			return ErrXUpdateID.New()
+		}
+
+		after, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(before, after) {
+			continue
+		}
+
+		// JSON values cannot be compared by every table, so the documents
+		// are replaced.
+		if err := c.replaceDocument(coll, d.id, after); err != nil {
+			return err
+		}
+		affected++
+	}
+
+	return c.writeOk(affected)
+}
+
+func (c *xConn) replaceDocument(coll xCollection, id string, doc []byte) error {
+	queries := []string{
+		"DELETE FROM " + coll.String() + " WHERE _id = " + quoteString(id),
+		fmt.Sprintf("INSERT INTO %s (doc, _id) VALUES (%s, %s)", coll, quoteString(string(doc)), quoteString(id)),
+	}
+
+	for _, q := range queries {
+		if _, _, err := c.query(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delete deletes the rows of a table or the documents of a collection that
+// match the criteria of a Mysqlx.Crud.Delete.
+func (c *xConn) delete(f xFields) error {
+	coll, err := decodeXCollection(f, 1)
+	if err != nil {
+		return err
+	}
+
+	criteria, err := decodeXExprField(f, 3)
+	if err != nil {
+		return err
+	}
+
+	limit, err := decodeXLimit(f, 4)
+	if err != nil {
+		return err
+	}
+
+	order, err := decodeXOrder(f, 5)
+	if err != nil {
+		return err
+	}
+
+	args, err := decodeXArgs(f, 6)
+	if err != nil {
+		return err
+	}
+
+	if limit != nil && limit.offset > 0 {
+		return ErrXOffset.New()
+	}
+
+	if f.uint(2) == xTable {
+		clauses, err := sqlClauses(criteria, order, limit, args)
+		if err != nil {
+			return err
+		}
+		return c.execute("DELETE FROM " + coll.String() + clauses)
+	}
+
+	docs, err := c.documents(coll, criteria, order, limit, args)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range docs {
+		if _, _, err := c.query("DELETE FROM " + coll.String() + " WHERE _id = " + quoteString(d.id)); err != nil {
+			return err
+		}
+	}
+
+	return c.writeOk(uint64(len(docs)))
+}
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrXInvalidMessage is returned when an X Protocol message cannot be
+// decoded.
+var ErrXInvalidMessage = errors.NewKind("Invalid message: %s")
+
+// maxXMessage is the maximum size of the X Protocol messages sent by the
+// clients, as mysqlx_max_allowed_packet in MySQL.
+const maxXMessage = 64 << 20
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// readXMessage reads a message, which has its length, including its type,
+// in 4 bytes, its type in a byte and its payload, a protobuf message.
+func readXMessage(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:4]); err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[:4])
+	if length == 0 || length > maxXMessage {
+		return 0, nil, ErrXInvalidMessage.New("wrong message length")
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+
+	return data[0], data[1:], nil
+}
+
+// appendXMessage appends a message with the given type and payload.
+func appendXMessage(b []byte, typ byte, payload []byte) []byte {
+	b = appendUint32(b, uint32(len(payload)+1))
+	b = append(b, typ)
+	return append(b, payload...)
+}
+
+// xField is a field of a protobuf message. Varints and fixed size numbers
+// are kept in n, and length-delimited fields in data.
+type xField struct {
+	n    uint64
+	data []byte
+}
+
+// xFields are the fields of a protobuf message by number. Repeated fields
+// have a value for each element.
+type xFields map[uint64][]xField
+
+// parseXFields decodes the fields of a protobuf message.
+func parseXFields(data []byte) (xFields, error) {
+	fields := make(xFields)
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, ErrXInvalidMessage.New("wrong field key")
+		}
+		data = data[n:]
+
+		var f xField
+		switch key & 7 {
+		case wireVarint:
+			f.n, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, ErrXInvalidMessage.New("wrong varint")
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return nil, ErrXInvalidMessage.New("wrong fixed64")
+			}
+			f.n = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return nil, ErrXInvalidMessage.New("wrong fixed32")
+			}
+			f.n = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, ErrXInvalidMessage.New("wrong length")
+			}
+			f.data = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return nil, ErrXInvalidMessage.New("unsupported wire type")
+		}
+
+		fields[key>>3] = append(fields[key>>3], f)
+	}
+
+	return fields, nil
+}
+
+func (f xFields) has(n uint64) bool {
+	return len(f[n]) > 0
+}
+
+func (f xFields) uint(n uint64) uint64 {
+	if v := f[n]; len(v) > 0 {
+		return v[len(v)-1].n
+	}
+	return 0
+}
+
+func (f xFields) sint(n uint64) int64 {
+	v := f.uint(n)
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (f xFields) bool(n uint64) bool {
+	return f.uint(n) != 0
+}
+
+func (f xFields) bytes(n uint64) []byte {
+	if v := f[n]; len(v) > 0 {
+		return v[len(v)-1].data
+	}
+	return nil
+}
+
+func (f xFields) str(n uint64) string {
+	return string(f.bytes(n))
+}
+
+// message decodes the embedded message with the given number, which is
+// empty if it's not set.
+func (f xFields) message(n uint64) (xFields, error) {
+	return parseXFields(f.bytes(n))
+}
+
+// messages decodes the elements of a repeated embedded message.
+func (f xFields) messages(n uint64) ([]xFields, error) {
+	var messages []xFields
+	for _, v := range f[n] {
+		m, err := parseXFields(v.data)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// xWriter encodes a protobuf message.
+type xWriter []byte
+
+func (w *xWriter) key(n uint64, typ uint64) {
+	*w = xWriter(appendUvarint([]byte(*w), n<<3|typ))
+}
+
+func (w *xWriter) uint(n uint64, v uint64) {
+	w.key(n, wireVarint)
+	*w = xWriter(appendUvarint([]byte(*w), v))
+}
+
+func (w *xWriter) sint(n uint64, v int64) {
+	w.uint(n, uint64(v<<1)^uint64(v>>63))
+}
+
+func (w *xWriter) bool(n uint64, v bool) {
+	var i uint64
+	if v {
+		i = 1
+	}
+	w.uint(n, i)
+}
+
+func (w *xWriter) double(n uint64, v float64) {
+	w.key(n, wireFixed64)
+	*w = xWriter(appendUint64([]byte(*w), math.Float64bits(v)))
+}
+
+func (w *xWriter) bytes(n uint64, v []byte) {
+	w.key(n, wireBytes)
+	*w = xWriter(appendUvarint([]byte(*w), uint64(len(v))))
+	*w = append(*w, v...)
+}
+
+func (w *xWriter) str(n uint64, v string) {
+	w.bytes(n, []byte(v))
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// Types of Mysqlx.Datatypes.Scalar.
+const (
+	xScalarSint   = 1
+	xScalarUint   = 2
+	xScalarNull   = 3
+	xScalarOctets = 4
+	xScalarDouble = 5
+	xScalarFloat  = 6
+	xScalarBool   = 7
+	xScalarString = 8
+)
+
+// Types of Mysqlx.Datatypes.Any.
+const (
+	xAnyScalar = 1
+	xAnyObject = 2
+	xAnyArray  = 3
+)
+
+// decodeXScalar decodes a Mysqlx.Datatypes.Scalar as nil, an int64, an
+// uint64, a float64, a bool, a string or a []byte.
+func decodeXScalar(f xFields) (interface{}, error) {
+	switch f.uint(1) {
+	case xScalarSint:
+		return f.sint(2), nil
+	case xScalarUint:
+		return f.uint(3), nil
+	case xScalarNull:
+		return nil, nil
+	case xScalarOctets:
+		octets, err := f.message(5)
+		if err != nil {
+			return nil, err
+		}
+		return octets.bytes(1), nil
+	case xScalarDouble:
+		return math.Float64frombits(f.uint(6)), nil
+	case xScalarFloat:
+		return float64(math.Float32frombits(uint32(f.uint(7)))), nil
+	case xScalarBool:
+		return f.bool(8), nil
+	case xScalarString:
+		s, err := f.message(9)
+		if err != nil {
+			return nil, err
+		}
+		return s.str(1), nil
+	default:
+		return nil, ErrXInvalidMessage.New("unknown scalar type")
+	}
+}
+
+// decodeXAny decodes a Mysqlx.Datatypes.Any, with objects as
+// map[string]interface{} and arrays as []interface{}.
+func decodeXAny(f xFields) (interface{}, error) {
+	switch f.uint(1) {
+	case xAnyScalar:
+		scalar, err := f.message(2)
+		if err != nil {
+			return nil, err
+		}
+		return decodeXScalar(scalar)
+	case xAnyObject:
+		obj, err := f.message(3)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := obj.messages(1)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			value, err := field.message(2)
+			if err != nil {
+				return nil, err
+			}
+			m[field.str(1)], err = decodeXAny(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case xAnyArray:
+		arr, err := f.message(4)
+		if err != nil {
+			return nil, err
+		}
+		values, err := arr.messages(1)
+		if err != nil {
+			return nil, err
+		}
+		a := make([]interface{}, len(values))
+		for i, value := range values {
+			a[i], err = decodeXAny(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	default:
+		return nil, ErrXInvalidMessage.New("unknown any type")
+	}
+}
+
+// xScalar encodes a value as a Mysqlx.Datatypes.Scalar.
+func xScalar(v interface{}) []byte {
+	var w xWriter
+	switch v := v.(type) {
+	case nil:
+		w.uint(1, xScalarNull)
+	case int64:
+		w.uint(1, xScalarSint)
+		w.sint(2, v)
+	case uint64:
+		w.uint(1, xScalarUint)
+		w.uint(3, v)
+	case float64:
+		w.uint(1, xScalarDouble)
+		w.double(6, v)
+	case bool:
+		w.uint(1, xScalarBool)
+		w.bool(8, v)
+	case []byte:
+		var octets xWriter
+		octets.bytes(1, v)
+		w.uint(1, xScalarOctets)
+		w.bytes(5, octets)
+	case string:
+		var s xWriter
+		s.str(1, v)
+		w.uint(1, xScalarString)
+		w.bytes(9, s)
+	}
+	return w
+}
+
+// xAnyValue encodes a scalar as a Mysqlx.Datatypes.Any.
+func xAnyValue(v interface{}) []byte {
+	var w xWriter
+	w.uint(1, xAnyScalar)
+	w.bytes(2, xScalar(v))
+	return w
+}
+
+// xAnyStrings encodes an array of strings as a Mysqlx.Datatypes.Any.
+func xAnyStrings(values ...string) []byte {
+	var arr xWriter
+	for _, v := range values {
+		arr.bytes(1, xAnyValue(v))
+	}
+
+	var w xWriter
+	w.uint(1, xAnyArray)
+	w.bytes(4, arr)
+	return w
+}
@@ -0,0 +1,1298 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// Types of the messages sent by X Protocol clients.
+const (
+	xClientCapabilitiesGet = 1
+	xClientCapabilitiesSet = 2
+	xClientClose           = 3
+	xClientAuthStart       = 4
+	xClientAuthContinue    = 5
+	xClientSessionReset    = 6
+	xClientSessionClose    = 7
+	xClientStmtExecute     = 12
+	xClientFind            = 17
+	xClientInsert          = 18
+	xClientUpdate          = 19
+	xClientDelete          = 20
+	xClientExpectOpen      = 24
+	xClientExpectClose     = 25
+)
+
+// Types of the messages sent by the server.
+const (
+	xServerOk             = 0
+	xServerError          = 1
+	xServerCapabilities   = 2
+	xServerAuthContinue   = 3
+	xServerAuthOk         = 4
+	xServerNotice         = 11
+	xServerColumnMetaData = 12
+	xServerRow            = 13
+	xServerFetchDone      = 14
+	xServerStmtExecuteOk  = 17
+)
+
+// Notices and the parameters of the session state changes.
+const (
+	xNoticeWarning             = 1
+	xNoticeSessionStateChanged = 3
+	xNoticeScopeLocal          = 2
+
+	xStateRowsAffected         = 4
+	xStateClientID             = 11
+	xStateGeneratedDocumentIDs = 12
+)
+
+// Conditions of the expectation blocks.
+const (
+	xExpectNoError        = 1
+	xExpectFieldExists    = 2
+	xExpectDocIDGenerated = 3
+
+	xExpectCopyPrevious = 0
+	xExpectUnset        = 1
+)
+
+// erNotSupportedAuthMode is the error of clients authenticating with a
+// mechanism that is not supported, which vitess does not define.
+const erNotSupportedAuthMode = 1251
+
+// xConnectionIDBase is the first connection id of the X Protocol clients,
+// so they are not the same as the ids of the MySQL clients.
+const xConnectionIDBase = 1 << 31
+
+var xMechanisms = []string{"MYSQL41", "PLAIN"}
+
+var (
+	// ErrXUnexpectedMessage is returned when a client sends a message that
+	// cannot be sent at that point, or that is not supported.
+	ErrXUnexpectedMessage = errors.NewKind("Unexpected message received: %d")
+	// ErrXCapabilityPrepare is returned when a client sets a capability
+	// with a value that is not supported.
+	ErrXCapabilityPrepare = errors.NewKind("Capability prepare failed for '%s'")
+	// ErrXCapabilityNotFound is returned when a client sets a capability
+	// that does not exist.
+	ErrXCapabilityNotFound = errors.NewKind("Capability '%s' doesn't exist")
+	// ErrXAuthMechanism is returned when a client authenticates with a
+	// mechanism that is not supported.
+	ErrXAuthMechanism = errors.NewKind("Invalid authentication method %s")
+	// ErrXAuthData is returned when the authentication data of a client
+	// is not valid.
+	ErrXAuthData = errors.NewKind("Invalid authentication data")
+	// ErrXNamespace is returned when a statement is executed in an unknown
+	// namespace.
+	ErrXNamespace = errors.NewKind("Unknown namespace %s")
+	// ErrXAdminCommand is returned when an admin command does not exist.
+	ErrXAdminCommand = errors.NewKind("Invalid mysqlx command %s")
+	// ErrXAdminArgument is returned when an argument of an admin command is
+	// missing or has the wrong type.
+	ErrXAdminArgument = errors.NewKind("Invalid value for argument '%s' of %s")
+	// ErrXArguments is returned when the arguments of a statement do not
+	// match its placeholders.
+	ErrXArguments = errors.NewKind("Statement has %d placeholders, but %d arguments were given")
+	// ErrXNotice is returned when notices that do not exist are enabled or
+	// disabled.
+	ErrXNotice = errors.NewKind("Invalid notice name %s")
+	// ErrXUnknownClient is returned when a client that does not exist is
+	// killed.
+	ErrXUnknownClient = errors.NewKind("Unknown thread id: %d")
+	// ErrXExpectationFailed is returned for the messages of an expectation
+	// block with the no_error condition after one of them failed.
+	ErrXExpectationFailed = errors.NewKind("Expectation failed: no_error")
+	// ErrXExpectNotOpen is returned when an expectation block is closed,
+	// but none is open.
+	ErrXExpectNotOpen = errors.NewKind("Expect block currently not open")
+	// ErrXExpectCondition is returned when an expectation block has an
+	// unknown condition.
+	ErrXExpectCondition = errors.NewKind("Unknown condition key: %d")
+)
+
+// xErrorCodes are the error codes of the X Protocol errors.
+var xErrorCodes = map[*errors.Kind]uint16{
+	ErrXInvalidMessage:     5000,
+	ErrXUnexpectedMessage:  5000,
+	ErrXCapabilityPrepare:  5001,
+	ErrXCapabilityNotFound: 5002,
+	ErrXAuthMechanism:      erNotSupportedAuthMode,
+	ErrXAuthData:           mysql.ERAccessDeniedError,
+	ErrXNamespace:          5162,
+	ErrXAdminCommand:       5157,
+	ErrXAdminArgument:      5016,
+	ErrXArguments:          5015,
+	ErrXNotice:             5163,
+	ErrXUnknownClient:      mysql.ERNoSuchThread,
+	ErrXExpectationFailed:  5159,
+	ErrXExpectNotOpen:      5158,
+	ErrXExpectCondition:    5160,
+	ErrXDataModel:          5012,
+	ErrXCollection:         5156,
+	ErrXExpression:         5154,
+	ErrXOperator:           5150,
+	ErrXPlaceholder:        5152,
+	ErrXDocument:           5117,
+	ErrXDocumentID:         5116,
+	ErrXProjection:         5114,
+	ErrXUpdate:             5050,
+	ErrXUpdateID:           5053,
+	ErrXOffset:             5012,
+}
+
+// dmlQuery matches the queries that return the number of rows affected.
+var dmlQuery = regexp.MustCompile(`(?i)^\s*(insert|replace|update|delete)\s`)
+
+// xServer serves the X Protocol, used by the X DevAPI connectors, which
+// executes statements and the CRUD operations of the document store.
+type xServer struct {
+	listener net.Listener
+	h        *Handler
+	auth     mysql.AuthServer
+
+	mu     sync.Mutex
+	conns  map[uint32]*xConn
+	lastID uint32
+	closed bool
+}
+
+func newXServer(address string, h *Handler, a mysql.AuthServer) (*xServer, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &xServer{
+		listener: l,
+		h:        h,
+		auth:     a,
+		conns:    make(map[uint32]*xConn),
+		lastID:   xConnectionIDBase,
+	}, nil
+}
+
+// serve accepts connections until the server is closed.
+func (s *xServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if !closed {
+				s.h.sm.logger.Errorf("unable to accept X Protocol connection: %s", err)
+			}
+			return
+		}
+
+		s.mu.Lock()
+		s.lastID++
+		c := &xConn{
+			s:        s,
+			conn:     conn,
+			r:        bufio.NewReader(conn),
+			id:       s.lastID,
+			warnings: true,
+		}
+		s.conns[c.id] = c
+		s.mu.Unlock()
+
+		go c.run()
+	}
+}
+
+// close stops accepting connections and closes the open ones.
+func (s *xServer) close() error {
+	err := s.stop()
+	s.closeConnections()
+	return err
+}
+
+// stop stops accepting connections.
+func (s *xServer) stop() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	return s.listener.Close()
+}
+
+// closeConnections closes the open connections, which ends their
+// sessions.
+func (s *xServer) closeConnections() {
+	s.mu.Lock()
+	for _, c := range s.conns {
+		_ = c.conn.Close()
+	}
+	s.mu.Unlock()
+}
+
+func (s *xServer) conn(id uint32) (*xConn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conns[id]
+	return c, ok
+}
+
+// xConn is a connection of an X Protocol client. The messages sent to it
+// are buffered until the response to a message is complete.
+type xConn struct {
+	s    *xServer
+	conn net.Conn
+	r    *bufio.Reader
+	id   uint32
+	out  []byte
+
+	// The salt sent to the client authenticating with MYSQL41.
+	salt []byte
+	user string
+	sess sql.Session
+
+	// expect has an element for each open expectation block, which is
+	// true if it has the no_error condition. failed is whether a message
+	// failed in a block with no_error.
+	expect []bool
+	failed bool
+
+	warnings bool
+}
+
+func (c *xConn) run() {
+	defer c.close()
+
+	ConnectionsGauge.With("protocol", "x").Add(1)
+	c.s.h.sm.connLogger(c.id).Infof("NewConnection: X Protocol client %v", c.id)
+	for {
+		typ, payload, err := readXMessage(c.r)
+		if err != nil {
+			if err != io.EOF {
+				c.s.h.sm.connLogger(c.id).Debugf("unable to read from X Protocol client %d: %s", c.id, err)
+			}
+			return
+		}
+
+		done, err := c.handle(typ, payload)
+		if err != nil {
+			c.s.h.sm.connLogger(c.id).Debugf("message %d failed on X Protocol client %d: %s", typ, c.id, err)
+			if werr := c.writeError(err); werr != nil {
+				return
+			}
+		}
+
+		if err := c.flush(); err != nil || done {
+			return
+		}
+	}
+}
+
+func (c *xConn) close() {
+	_ = c.conn.Close()
+
+	c.s.mu.Lock()
+	delete(c.s.conns, c.id)
+	c.s.mu.Unlock()
+
+	c.closeSession()
+	ConnectionsGauge.With("protocol", "x").Add(-1)
+	c.s.h.sm.connLogger(c.id).Infof("ConnectionClosed: X Protocol client %v", c.id)
+}
+
+// closeSession ends the session of the client, if it's authenticated, so
+// it must authenticate again.
+func (c *xConn) closeSession() {
+	if c.user != "" {
+		if c.s.h.limits != nil {
+			c.s.h.limits.releaseUser(c.user)
+		}
+		c.s.h.auditDisconnect(c.id, c.user, c.conn.RemoteAddr())
+	}
+	c.user = ""
+	c.endSession()
+}
+
+// setSession sets the session of the client. Other connections read it
+// to list the clients.
+func (c *xConn) setSession(sess sql.Session) {
+	c.s.mu.Lock()
+	c.sess = sess
+	c.s.mu.Unlock()
+}
+
+// endSession removes the session of the client, if any, killing its
+// queries and unlocking its tables.
+func (c *xConn) endSession() {
+	if c.sess == nil {
+		return
+	}
+	c.setSession(nil)
+
+	h := c.s.h
+	h.sm.closeSession(c.id)
+	h.e.Catalog.ProcessList.KillOnlyQueries(c.id)
+	if err := h.e.Catalog.UnlockTables(nil, c.id); err != nil {
+		h.sm.connLogger(c.id).Errorf("unable to unlock tables on session close: %s", err)
+	}
+}
+
+// handle handles a message, returning whether the connection must be
+// closed.
+func (c *xConn) handle(typ byte, payload []byte) (bool, error) {
+	f, err := parseXFields(payload)
+	if err != nil {
+		return false, err
+	}
+
+	switch typ {
+	case xClientCapabilitiesGet:
+		return false, c.capabilitiesGet()
+	case xClientCapabilitiesSet:
+		return false, c.capabilitiesSet(f)
+	case xClientClose:
+		return true, c.write(xServerOk, nil)
+	case xClientAuthStart:
+		return false, c.authStart(f)
+	case xClientAuthContinue:
+		return false, c.authContinue(f)
+	}
+
+	if c.sess == nil {
+		return false, ErrXUnexpectedMessage.New(typ)
+	}
+
+	switch typ {
+	case xClientExpectOpen:
+		return false, c.expectOpen(f)
+	case xClientExpectClose:
+		return false, c.expectClose()
+	case xClientSessionReset:
+		c.endSession()
+		c.setSession(c.s.h.sm.newBaseSession(c.id, c.conn.RemoteAddr().String(), c.user))
+		return false, c.write(xServerOk, nil)
+	case xClientSessionClose:
+		c.closeSession()
+		return false, c.write(xServerOk, nil)
+	}
+
+	if c.failed {
+		return false, ErrXExpectationFailed.New()
+	}
+
+	switch typ {
+	case xClientStmtExecute:
+		err = c.stmtExecute(f)
+	case xClientFind:
+		err = c.find(f)
+	case xClientInsert:
+		err = c.insert(f)
+	case xClientUpdate:
+		err = c.update(f)
+	case xClientDelete:
+		err = c.delete(f)
+	default:
+		err = ErrXUnexpectedMessage.New(typ)
+	}
+
+	if err != nil && len(c.expect) > 0 && c.expect[len(c.expect)-1] {
+		c.failed = true
+	}
+	return false, err
+}
+
+// write buffers a message, sending the buffered ones if they are too many.
+func (c *xConn) write(typ byte, payload []byte) error {
+	c.out = appendXMessage(c.out, typ, payload)
+	if len(c.out) >= writeFlushSize {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *xConn) flush() error {
+	if len(c.out) == 0 {
+		return nil
+	}
+
+	_, err := c.conn.Write(c.out)
+	c.out = c.out[:0]
+	return err
+}
+
+func (c *xConn) writeError(err error) error {
+	code, state, msg := xError(err)
+
+	var w xWriter
+	w.uint(1, 0)
+	w.uint(2, uint64(code))
+	w.str(3, msg)
+	w.str(4, state)
+	return c.write(xServerError, w)
+}
+
+// xError returns the code, SQL state and message of an error.
+func xError(err error) (uint16, string, string) {
+	for kind, code := range xErrorCodes {
+		if kind.Is(err) {
+			state := mysql.SSUnknownSQLState
+			if code == mysql.ERAccessDeniedError {
+				state = mysql.SSAccessDeniedError
+			}
+			return code, state, err.Error()
+		}
+	}
+
+	serr, ok := mysql.NewSQLErrorFromError(sqlError(err)).(*mysql.SQLError)
+	if !ok {
+		serr = mysql.NewSQLError(mysql.ERUnknownError, mysql.SSUnknownSQLState, "%v", err)
+	}
+	return uint16(serr.Num), serr.State, serr.Message
+}
+
+// notice sends a change of the session state.
+func (c *xConn) notice(param uint64, values ...interface{}) error {
+	var state xWriter
+	state.uint(1, param)
+	for _, v := range values {
+		state.bytes(2, xScalar(v))
+	}
+
+	var frame xWriter
+	frame.uint(1, xNoticeSessionStateChanged)
+	frame.uint(2, xNoticeScopeLocal)
+	frame.bytes(3, state)
+	return c.write(xServerNotice, frame)
+}
+
+// writeWarnings sends the warnings of the last query as notices, if they
+// are enabled.
+func (c *xConn) writeWarnings() error {
+	if !c.warnings {
+		return nil
+	}
+
+	for _, w := range c.sess.Warnings() {
+		level := uint64(2)
+		switch strings.ToLower(w.Level) {
+		case "note":
+			level = 1
+		case "error":
+			level = 3
+		}
+
+		var warning xWriter
+		warning.uint(1, level)
+		warning.uint(2, uint64(w.Code))
+		warning.str(3, w.Message)
+
+		var frame xWriter
+		frame.uint(1, xNoticeWarning)
+		frame.uint(2, xNoticeScopeLocal)
+		frame.bytes(3, warning)
+		if err := c.write(xServerNotice, frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *xConn) capabilitiesGet() error {
+	var w xWriter
+	capability := func(name string, value []byte) {
+		var c xWriter
+		c.str(1, name)
+		c.bytes(2, value)
+		w.bytes(1, c)
+	}
+
+	capability("authentication.mechanisms", xAnyStrings(xMechanisms...))
+	capability("doc.formats", xAnyValue("text"))
+	capability("node_type", xAnyValue("mysql"))
+	capability("client.pwd_expire_ok", xAnyValue(false))
+	return c.write(xServerCapabilities, w)
+}
+
+// capabilitiesSet accepts the capabilities set by the client, except TLS
+// and compression, which are not supported.
+func (c *xConn) capabilitiesSet(f xFields) error {
+	caps, err := f.message(1)
+	if err != nil {
+		return err
+	}
+
+	list, err := caps.messages(1)
+	if err != nil {
+		return err
+	}
+
+	for _, capability := range list {
+		switch name := capability.str(1); name {
+		case "tls", "compression":
+			return ErrXCapabilityPrepare.New(name)
+		case "client.pwd_expire_ok", "client.interactive", "session_connect_attrs":
+		default:
+			return ErrXCapabilityNotFound.New(name)
+		}
+	}
+
+	return c.write(xServerOk, nil)
+}
+
+func (c *xConn) authStart(f xFields) error {
+	if c.sess != nil {
+		return ErrXUnexpectedMessage.New(xClientAuthStart)
+	}
+
+	switch mechanism := f.str(1); mechanism {
+	case "MYSQL41":
+		salt, err := mysql.NewSalt()
+		if err != nil {
+			return err
+		}
+		c.salt = salt
+
+		var w xWriter
+		w.bytes(1, salt)
+		return c.write(xServerAuthContinue, w)
+	case "PLAIN":
+		c.salt = nil
+		return c.authenticate(f.bytes(3))
+	default:
+		return ErrXAuthMechanism.New(mechanism)
+	}
+}
+
+func (c *xConn) authContinue(f xFields) error {
+	if c.sess != nil || c.salt == nil {
+		return ErrXUnexpectedMessage.New(xClientAuthContinue)
+	}
+	return c.authenticate(f.bytes(1))
+}
+
+// authenticate validates the credentials of the client, which has the
+// schema, the user and the password, or its hash scrambled with the salt
+// with MYSQL41, separated by zero bytes. The password sent with PLAIN is
+// scrambled to validate it as a mysql_native_password hash.
+func (c *xConn) authenticate(data []byte) error {
+	parts := bytes.SplitN(data, []byte{0}, 3)
+	if len(parts) != 3 {
+		return ErrXAuthData.New()
+	}
+	schema, user, secret := string(parts[0]), string(parts[1]), parts[2]
+
+	salt := c.salt
+	c.salt = nil
+
+	var scramble []byte
+	if salt != nil {
+		if len(secret) > 0 {
+			if secret[0] != '*' {
+				return ErrXAuthData.New()
+			}
+
+			var err error
+			scramble, err = hex.DecodeString(string(secret[1:]))
+			if err != nil {
+				return ErrXAuthData.New()
+			}
+		}
+	} else {
+		var err error
+		salt, err = mysql.NewSalt()
+		if err != nil {
+			return err
+		}
+
+		if len(secret) > 0 {
+			scramble = mysql.ScramblePassword(salt, secret)
+		}
+	}
+
+	if _, err := c.s.auth.ValidateHash(salt, user, scramble, c.conn.RemoteAddr()); err != nil {
+		return err
+	}
+
+	c.user = user
+	c.setSession(c.s.h.sm.newBaseSession(c.id, c.conn.RemoteAddr().String(), user))
+	if schema != "" {
+		if _, _, err := c.query("USE " + quoteIdentifier(schema)); err != nil {
+			c.closeSession()
+			return err
+		}
+	}
+
+	if err := c.notice(xStateClientID, uint64(c.id)); err != nil {
+		return err
+	}
+	return c.write(xServerAuthOk, nil)
+}
+
+func (c *xConn) expectOpen(f xFields) error {
+	noError := false
+	if f.uint(1) == xExpectCopyPrevious && len(c.expect) > 0 {
+		noError = c.expect[len(c.expect)-1]
+	}
+
+	conditions, err := f.messages(2)
+	if err != nil {
+		return err
+	}
+
+	for _, cond := range conditions {
+		switch key := cond.uint(1); key {
+		case xExpectNoError:
+			noError = cond.uint(3) != xExpectUnset
+		case xExpectFieldExists, xExpectDocIDGenerated:
+		default:
+			return ErrXExpectCondition.New(key)
+		}
+	}
+
+	c.expect = append(c.expect, noError)
+	if c.failed {
+		return ErrXExpectationFailed.New()
+	}
+	return c.write(xServerOk, nil)
+}
+
+func (c *xConn) expectClose() error {
+	if len(c.expect) == 0 {
+		return ErrXExpectNotOpen.New()
+	}
+
+	c.expect = c.expect[:len(c.expect)-1]
+	failed := c.failed
+	// The failure is kept while the outer blocks have no_error.
+	c.failed = failed && len(c.expect) > 0 && c.expect[len(c.expect)-1]
+	if failed {
+		return ErrXExpectationFailed.New()
+	}
+	return c.write(xServerOk, nil)
+}
+
+// query runs a query in the session of the client, returning all its rows.
+func (c *xConn) query(query string) (sql.Schema, []sql.Row, error) {
+	h := c.s.h
+	if err := h.checkShutdown(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx := h.sm.newContext(c.sess, query)
+
+	audit := h.auditQuery(ctx)
+	schema, iter, err := audit.query(query)
+	var rows []sql.Row
+	if err == nil {
+		rows, err = sql.RowIterToRows(iter)
+	}
+	audit.done(err)
+
+	return schema, rows, err
+}
+
+func (c *xConn) stmtExecute(f xFields) error {
+	values, err := f.messages(2)
+	if err != nil {
+		return err
+	}
+
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i], err = decodeXAny(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch namespace := f.str(3); namespace {
+	case "", "sql":
+		query, err := bindXArgs(f.str(1), args)
+		if err != nil {
+			return err
+		}
+		return c.execute(query)
+	case "mysqlx", "xplugin":
+		return c.adminCommand(f.str(1), args)
+	default:
+		return ErrXNamespace.New(namespace)
+	}
+}
+
+// execute runs a query and sends its result.
+func (c *xConn) execute(query string) error {
+	schema, rows, err := c.query(query)
+	if err != nil {
+		return err
+	}
+
+	// The result of the statements that change rows is the number of rows
+	// affected.
+	if dmlQuery.MatchString(query) {
+		var affected uint64
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			n, err := sql.Int64.Convert(rows[0][len(rows[0])-1])
+			if err != nil {
+				return err
+			}
+			affected = uint64(n.(int64))
+		}
+		return c.writeOk(affected)
+	}
+
+	if len(schema) > 0 {
+		if err := c.writeResultset(schema, rows); err != nil {
+			return err
+		}
+	}
+	return c.writeOk(0)
+}
+
+// writeOk ends the execution of a statement, with the number of rows it
+// changed.
+func (c *xConn) writeOk(affected uint64) error {
+	if err := c.writeWarnings(); err != nil {
+		return err
+	}
+	if err := c.notice(xStateRowsAffected, affected); err != nil {
+		return err
+	}
+	return c.write(xServerStmtExecuteOk, nil)
+}
+
+func (c *xConn) writeResultset(schema sql.Schema, rows []sql.Row) error {
+	for _, col := range schema {
+		if err := c.write(xServerColumnMetaData, xColumnMetaData(col)); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		payload, err := xRow(schema, row)
+		if err != nil {
+			return err
+		}
+
+		if err := c.write(xServerRow, payload); err != nil {
+			return err
+		}
+	}
+
+	return c.write(xServerFetchDone, nil)
+}
+
+// Types of the columns in the X Protocol.
+const (
+	xColumnSint     = 1
+	xColumnUint     = 2
+	xColumnDouble   = 5
+	xColumnFloat    = 6
+	xColumnBytes    = 7
+	xColumnTime     = 10
+	xColumnDatetime = 12
+	xColumnDecimal  = 18
+
+	xContentJSON = 2
+
+	xCollationUtf8   = 33
+	xCollationBinary = 63
+)
+
+func xColumnType(t sql.Type) uint64 {
+	switch typ := t.Type(); {
+	case sqltypes.IsSigned(typ):
+		return xColumnSint
+	case sqltypes.IsUnsigned(typ):
+		return xColumnUint
+	case typ == sqltypes.Float64:
+		return xColumnDouble
+	case typ == sqltypes.Float32:
+		return xColumnFloat
+	case typ == sqltypes.Decimal:
+		return xColumnDecimal
+	case typ == sqltypes.Time:
+		return xColumnTime
+	case typ == sqltypes.Date, typ == sqltypes.Datetime, typ == sqltypes.Timestamp:
+		return xColumnDatetime
+	default:
+		return xColumnBytes
+	}
+}
+
+func xColumnMetaData(col *sql.Column) []byte {
+	typ := xColumnType(col.Type)
+
+	var w xWriter
+	w.uint(1, typ)
+	w.str(2, col.Name)
+	w.str(3, col.Name)
+	w.str(4, col.Source)
+	w.str(5, col.Source)
+	if typ == xColumnBytes {
+		if col.Type == sql.Blob {
+			w.uint(8, xCollationBinary)
+		} else {
+			w.uint(8, xCollationUtf8)
+		}
+	}
+	if col.Type == sql.JSON {
+		w.uint(12, xContentJSON)
+	}
+	return w
+}
+
+// xRow encodes a row, with a field for each value, which is empty if it's
+// null.
+func xRow(schema sql.Schema, row sql.Row) ([]byte, error) {
+	var w xWriter
+	for i, v := range row {
+		value, err := schema[i].Type.SQL(v)
+		if err != nil {
+			return nil, err
+		}
+
+		field, err := xValue(xColumnType(schema[i].Type), value)
+		if err != nil {
+			return nil, err
+		}
+		w.bytes(1, field)
+	}
+	return w, nil
+}
+
+// xValue encodes a value of a column with the given type. Integers are
+// varints, signed ones zigzag encoded, floating point numbers are little
+// endian, decimals are packed BCD and dates and times have a varint for
+// each part. Other values are sent as bytes followed by a zero byte.
+func xValue(typ uint64, v sqltypes.Value) ([]byte, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+
+	s := v.ToString()
+	switch typ {
+	case xColumnSint:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return appendUvarint(nil, uint64(n<<1)^uint64(n>>63)), nil
+	case xColumnUint:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return appendUvarint(nil, n), nil
+	case xColumnDouble:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return appendUint64(nil, math.Float64bits(f)), nil
+	case xColumnFloat:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, err
+		}
+		return appendUint32(nil, math.Float32bits(float32(f))), nil
+	case xColumnDecimal:
+		return xDecimal(s)
+	case xColumnDatetime, xColumnTime:
+		return xTime(typ, s)
+	default:
+		return append([]byte(s), 0), nil
+	}
+}
+
+// xDecimal encodes a decimal with its scale in a byte, followed by its
+// digits in BCD and the sign in the last nibble.
+func xDecimal(s string) ([]byte, error) {
+	sign := byte(0xc)
+	if strings.HasPrefix(s, "-") {
+		sign = 0xd
+		s = s[1:]
+	}
+
+	var scale int
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+
+	nibbles := make([]byte, 0, len(s)+2)
+	for _, ch := range []byte(s) {
+		if ch < '0' || ch > '9' {
+			return nil, ErrXInvalidMessage.New("invalid decimal " + s)
+		}
+		nibbles = append(nibbles, ch-'0')
+	}
+	nibbles = append(nibbles, sign)
+	if len(nibbles)%2 != 0 {
+		nibbles = append(nibbles, 0)
+	}
+
+	b := []byte{byte(scale)}
+	for i := 0; i < len(nibbles); i += 2 {
+		b = append(b, nibbles[i]<<4|nibbles[i+1])
+	}
+	return b, nil
+}
+
+// xTime encodes a date or datetime with a varint for the year, month,
+// day, hours, minutes, seconds and microseconds, or a time with a byte
+// for its sign and varints for the hours, minutes, seconds and
+// microseconds. The trailing parts that are zero may be omitted.
+func xTime(typ uint64, s string) ([]byte, error) {
+	var b []byte
+	if typ == xColumnTime {
+		if strings.HasPrefix(s, "-") {
+			b = append(b, 1)
+			s = s[1:]
+		} else {
+			b = append(b, 0)
+		}
+	}
+
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if frac := strings.IndexByte(s, '.'); frac >= 0 && len(parts) > 0 {
+		// The fraction has up to 6 digits, which are microseconds.
+		last := parts[len(parts)-1]
+		parts[len(parts)-1] = (last + "000000")[:6]
+	}
+
+	for _, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		b = appendUvarint(b, n)
+	}
+	return b, nil
+}
+
+// bindXArgs replaces the placeholders of a query with the arguments.
+func bindXArgs(query string, args []interface{}) (string, error) {
+	positions := placeholders(query)
+	if len(positions) != len(args) {
+		return "", ErrXArguments.New(len(positions), len(args))
+	}
+
+	var b strings.Builder
+	var last int
+	for i, pos := range positions {
+		literal, err := sqlLiteral(args[i])
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(query[last:pos])
+		b.WriteString(literal)
+		last = pos + 1
+	}
+	b.WriteString(query[last:])
+
+	return b.String(), nil
+}
+
+// sqlLiteral returns a value as a SQL literal. Objects and arrays are
+// given as JSON strings.
+func sqlLiteral(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return formatFloat(v, 64), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return quoteString(v), nil
+	case []byte:
+		return quoteString(string(v)), nil
+	default:
+		doc, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return quoteString(string(doc)), nil
+	}
+}
+
+func quoteIdentifier(s string) string {
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// adminArgs returns the arguments of an admin command by name. They are
+// sent in an object, or in order by older clients.
+func adminArgs(args []interface{}, names ...string) (map[string]interface{}, error) {
+	if len(args) == 1 {
+		if m, ok := args[0].(map[string]interface{}); ok {
+			return m, nil
+		}
+	}
+
+	if len(args) > len(names) {
+		return nil, ErrXArguments.New(len(names), len(args))
+	}
+
+	m := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		m[names[i]] = arg
+	}
+	return m, nil
+}
+
+// adminString returns a string argument of an admin command, or the
+// default if it's not given.
+func adminString(command string, args map[string]interface{}, name, def string) (string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return def, nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		return "", ErrXAdminArgument.New(name, command)
+	}
+}
+
+// adminCommand executes the commands of the mysqlx namespace, which
+// manage the collections, notices and clients.
+func (c *xConn) adminCommand(command string, list []interface{}) error {
+	switch command {
+	case "ping":
+		return c.writeOk(0)
+	case "list_objects":
+		args, err := adminArgs(list, "schema", "pattern")
+		if err != nil {
+			return err
+		}
+		return c.listObjects(command, args)
+	case "create_collection", "ensure_collection", "drop_collection":
+		args, err := adminArgs(list, "schema", "name")
+		if err != nil {
+			return err
+		}
+		return c.collectionCommand(command, args)
+	case "enable_notices", "disable_notices":
+		return c.notices(command == "enable_notices", list)
+	case "list_notices":
+		enabled := int64(0)
+		if c.warnings {
+			enabled = 1
+		}
+		return c.adminResult(sql.Schema{
+			{Name: "notice", Type: sql.Text},
+			{Name: "enabled", Type: sql.Int64},
+		}, []sql.Row{
+			{"warnings", enabled},
+			{"account_expired", int64(1)},
+			{"generated_insert_id", int64(1)},
+			{"rows_affected", int64(1)},
+			{"produced_message", int64(1)},
+		})
+	case "list_clients":
+		return c.listClients()
+	case "kill_client":
+		args, err := adminArgs(list, "id")
+		if err != nil {
+			return err
+		}
+		return c.killClient(command, args)
+	default:
+		return ErrXAdminCommand.New(command)
+	}
+}
+
+func (c *xConn) adminResult(schema sql.Schema, rows []sql.Row) error {
+	if err := c.writeResultset(schema, rows); err != nil {
+		return err
+	}
+	return c.writeOk(0)
+}
+
+// currentSchema returns the given schema or, if empty, the current
+// database.
+func (c *xConn) currentSchema(schema string) string {
+	if schema == "" {
+		return c.s.h.e.Catalog.CurrentDatabase()
+	}
+	return schema
+}
+
+// listObjects lists the tables and collections of a schema whose names
+// match a LIKE pattern.
+func (c *xConn) listObjects(command string, args map[string]interface{}) error {
+	schema, err := adminString(command, args, "schema", "")
+	if err != nil {
+		return err
+	}
+
+	pattern, err := adminString(command, args, "pattern", "%")
+	if err != nil {
+		return err
+	}
+
+	ctx := c.s.h.sm.newContext(c.sess, "")
+	defer ctx.RootSpan().Finish()
+
+	db, err := c.s.h.e.Catalog.ResolveDatabase(ctx, c.currentSchema(schema))
+	if err != nil {
+		return err
+	}
+
+	match := likeRegexp(pattern, '\\')
+	var rows []sql.Row
+	for name, table := range db.Tables() {
+		if !match.MatchString(name) {
+			continue
+		}
+
+		typ := "TABLE"
+		if isCollection(table.Schema()) {
+			typ = "COLLECTION"
+		}
+		rows = append(rows, sql.Row{name, typ})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0].(string) < rows[j][0].(string) })
+
+	return c.adminResult(sql.Schema{
+		{Name: "name", Type: sql.Text},
+		{Name: "type", Type: sql.Text},
+	}, rows)
+}
+
+func (c *xConn) collectionCommand(command string, args map[string]interface{}) error {
+	schema, err := adminString(command, args, "schema", "")
+	if err != nil {
+		return err
+	}
+
+	name, err := adminString(command, args, "name", "")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return ErrXAdminArgument.New("name", command)
+	}
+
+	coll := xCollection{schema: schema, name: name}
+	var query string
+	switch command {
+	case "create_collection":
+		query = "CREATE TABLE " + coll.String() + " " + collectionColumns
+	case "ensure_collection":
+		ctx := c.s.h.sm.newContext(c.sess, "")
+		_, err := c.s.h.e.Catalog.ResolveTable(ctx, c.currentSchema(schema), name)
+		ctx.RootSpan().Finish()
+		if err == nil {
+			return c.writeOk(0)
+		}
+		query = "CREATE TABLE " + coll.String() + " " + collectionColumns
+	case "drop_collection":
+		query = "DROP TABLE " + coll.String()
+	}
+
+	if _, _, err := c.query(query); err != nil {
+		return err
+	}
+	return c.writeOk(0)
+}
+
+// notices enables or disables the warnings, the only notices that can be
+// disabled.
+func (c *xConn) notices(enable bool, args []interface{}) error {
+	if len(args) == 1 {
+		if m, ok := args[0].(map[string]interface{}); ok {
+			list, ok := m["notice"].([]interface{})
+			if !ok {
+				return ErrXAdminArgument.New("notice", "enable_notices")
+			}
+			args = list
+		}
+	}
+
+	for _, arg := range args {
+		name, err := adminString("enable_notices", map[string]interface{}{"notice": arg}, "notice", "")
+		if err != nil {
+			return err
+		}
+
+		switch name {
+		case "warnings":
+			c.warnings = enable
+		case "account_expired", "generated_insert_id", "rows_affected", "produced_message":
+		default:
+			return ErrXNotice.New(name)
+		}
+	}
+
+	return c.writeOk(0)
+}
+
+func (c *xConn) listClients() error {
+	c.s.mu.Lock()
+	var rows []sql.Row
+	for id, conn := range c.s.conns {
+		if conn.sess == nil {
+			continue
+		}
+		client := conn.sess.Client()
+		rows = append(rows, sql.Row{uint64(id), client.User, client.Address, uint64(id)})
+	}
+	c.s.mu.Unlock()
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0].(uint64) < rows[j][0].(uint64) })
+
+	return c.adminResult(sql.Schema{
+		{Name: "client_id", Type: sql.Uint64},
+		{Name: "user", Type: sql.Text},
+		{Name: "host", Type: sql.Text},
+		{Name: "sql_session", Type: sql.Uint64},
+	}, rows)
+}
+
+func (c *xConn) killClient(command string, args map[string]interface{}) error {
+	var id uint64
+	switch v := args["id"].(type) {
+	case uint64:
+		id = v
+	case int64:
+		id = uint64(v)
+	default:
+		return ErrXAdminArgument.New("id", command)
+	}
+
+	conn, ok := c.s.conn(uint32(id))
+	if !ok || uint64(uint32(id)) != id {
+		return ErrXUnknownClient.New(id)
+	}
+
+	if err := conn.conn.Close(); err != nil {
+		return err
+	}
+	return c.writeOk(0)
+}
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"bufio"
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/mysql"
+)
+
+type xTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newXTestClient(t *testing.T, a auth.Auth) (*xTestClient, func()) {
+	require := require.New(t)
+
+	port, err := getFreePort()
+	require.NoError(err)
+	xport, err := getFreePort()
+	require.NoError(err)
+
+	s, err := NewDefaultServer(Config{
+		Protocol: "tcp",
+		Address:  "localhost:" + port,
+		XAddress: "localhost:" + xport,
+		Auth:     a,
+	}, setupMemDB(require))
+	require.NoError(err)
+	go s.Start()
+
+	conn, err := net.Dial("tcp", "localhost:"+xport)
+	require.NoError(err)
+
+	c := &xTestClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	return c, func() {
+		conn.Close()
+		s.Close()
+	}
+}
+
+func (c *xTestClient) send(typ byte, msg xWriter) {
+	_, err := c.conn.Write(appendXMessage(nil, typ, msg))
+	require.NoError(c.t, err)
+}
+
+func (c *xTestClient) recv() (byte, xFields) {
+	typ, payload, err := readXMessage(c.r)
+	require.NoError(c.t, err)
+	f, err := parseXFields(payload)
+	require.NoError(c.t, err)
+	return typ, f
+}
+
+// xTestResult is the result of a statement, with the session state
+// changes by parameter.
+type xTestResult struct {
+	columns []xFields
+	rows    [][][]byte
+	state   map[uint64][]interface{}
+	err     xFields
+}
+
+// result reads the result of a statement, up to the message that ends it.
+func (c *xTestClient) result() xTestResult {
+	r := xTestResult{state: make(map[uint64][]interface{})}
+	for {
+		typ, f := c.recv()
+		switch typ {
+		case xServerColumnMetaData:
+			r.columns = append(r.columns, f)
+		case xServerRow:
+			var row [][]byte
+			for _, field := range f[1] {
+				row = append(row, field.data)
+			}
+			r.rows = append(r.rows, row)
+		case xServerNotice:
+			if f.uint(1) != xNoticeSessionStateChanged {
+				continue
+			}
+			state, err := f.message(3)
+			require.NoError(c.t, err)
+			values, err := state.messages(2)
+			require.NoError(c.t, err)
+			for _, v := range values {
+				value, err := decodeXScalar(v)
+				require.NoError(c.t, err)
+				r.state[state.uint(1)] = append(r.state[state.uint(1)], value)
+			}
+		case xServerFetchDone:
+		case xServerStmtExecuteOk, xServerOk, xServerAuthOk:
+			return r
+		case xServerError:
+			r.err = f
+			return r
+		default:
+			c.t.Fatalf("unexpected message %d", typ)
+		}
+	}
+}
+
+func (c *xTestClient) stmt(namespace, stmt string, args ...[]byte) xTestResult {
+	var w xWriter
+	w.str(1, stmt)
+	for _, arg := range args {
+		w.bytes(2, arg)
+	}
+	w.str(3, namespace)
+	c.send(xClientStmtExecute, w)
+	return c.result()
+}
+
+func xTestObject(fields ...interface{}) []byte {
+	var obj xWriter
+	for i := 0; i < len(fields); i += 2 {
+		var field xWriter
+		field.str(1, fields[i].(string))
+		field.bytes(2, fields[i+1].([]byte))
+		obj.bytes(1, field)
+	}
+
+	var w xWriter
+	w.uint(1, xAnyObject)
+	w.bytes(3, obj)
+	return w
+}
+
+func xTestIdent(path ...string) []byte {
+	var id xWriter
+	for _, member := range path {
+		var item xWriter
+		item.uint(1, xPathMember)
+		item.str(2, member)
+		id.bytes(1, item)
+	}
+
+	var w xWriter
+	w.uint(1, xExprIdent)
+	w.bytes(2, id)
+	return w
+}
+
+func xTestColumn(name string) []byte {
+	var id xWriter
+	id.str(2, name)
+
+	var w xWriter
+	w.uint(1, xExprIdent)
+	w.bytes(2, id)
+	return w
+}
+
+func xTestLiteral(v interface{}) []byte {
+	var w xWriter
+	w.uint(1, xExprLiteral)
+	w.bytes(4, xScalar(v))
+	return w
+}
+
+func xTestPlaceholder(position uint64) []byte {
+	var w xWriter
+	w.uint(1, xExprPlaceholder)
+	w.uint(7, position)
+	return w
+}
+
+func xTestOperator(name string, params ...[]byte) []byte {
+	var op xWriter
+	op.str(1, name)
+	for _, p := range params {
+		op.bytes(2, p)
+	}
+
+	var w xWriter
+	w.uint(1, xExprOperator)
+	w.bytes(6, op)
+	return w
+}
+
+func xTestCollection(name string) []byte {
+	var w xWriter
+	w.str(1, name)
+	w.str(2, "test")
+	return w
+}
+
+func (c *xTestClient) authenticate(data string) xTestResult {
+	var start xWriter
+	start.str(1, "MYSQL41")
+	c.send(xClientAuthStart, start)
+
+	typ, f := c.recv()
+	require.Equal(c.t, byte(xServerAuthContinue), typ)
+	require.Len(c.t, f.bytes(1), 20)
+
+	var cont xWriter
+	cont.str(1, data)
+	c.send(xClientAuthContinue, cont)
+	return c.result()
+}
+
+func TestXProtocol(t *testing.T) {
+	require := require.New(t)
+	c, done := newXTestClient(t, new(auth.None))
+	defer done()
+
+	c.send(xClientCapabilitiesGet, nil)
+	typ, f := c.recv()
+	require.Equal(byte(xServerCapabilities), typ)
+	caps, err := f.messages(1)
+	require.NoError(err)
+	value, err := caps[0].message(2)
+	require.NoError(err)
+	mechanisms, err := decodeXAny(value)
+	require.NoError(err)
+	require.Equal("authentication.mechanisms", caps[0].str(1))
+	require.Equal([]interface{}{"MYSQL41", "PLAIN"}, mechanisms)
+
+	var capability, set, capabilities xWriter
+	capability.str(1, "tls")
+	capability.bytes(2, xAnyValue(true))
+	capabilities.bytes(1, capability)
+	set.bytes(1, capabilities)
+	c.send(xClientCapabilitiesSet, set)
+	require.Equal(uint64(5001), c.result().err.uint(2))
+
+	// Statements cannot be executed before authenticating.
+	require.Equal(uint64(5000), c.stmt("sql", "SELECT 1").err.uint(2))
+
+	r := c.authenticate("test\x00root\x00")
+	require.Nil(r.err)
+	require.Equal([]interface{}{uint64(xConnectionIDBase + 1)}, r.state[xStateClientID])
+
+	r = c.stmt("sql", "SELECT c1 FROM test WHERE c1 < ? ORDER BY c1", xAnyValue(int64(2)))
+	require.Nil(r.err)
+	require.Len(r.columns, 1)
+	require.Equal(uint64(xColumnSint), r.columns[0].uint(1))
+	require.Equal("c1", r.columns[0].str(2))
+	require.Equal([][][]byte{{{0}}, {{2}}}, r.rows)
+
+	r = c.stmt("sql", "INSERT INTO test (c1) VALUES (2000), (2001)")
+	require.Nil(r.err)
+	require.Equal([]interface{}{uint64(2)}, r.state[xStateRowsAffected])
+
+	r = c.stmt("mysqlx", "create_collection", xTestObject("schema", xAnyValue("test"), "name", xAnyValue("people")))
+	require.Nil(r.err)
+
+	// Documents are inserted with their ids, which are generated if they
+	// do not have one.
+	var insert xWriter
+	insert.bytes(1, xTestCollection("people"))
+	insert.uint(2, xDocument)
+	for _, doc := range []string{`{"name": "alice", "age": 30}`, `{"_id": "b", "name": "bob", "age": 40}`} {
+		var row xWriter
+		row.bytes(1, xTestLiteral(doc))
+		insert.bytes(4, row)
+	}
+	c.send(xClientInsert, insert)
+	r = c.result()
+	require.Nil(r.err)
+	require.Len(r.state[xStateGeneratedDocumentIDs], 1)
+	require.Len(r.state[xStateGeneratedDocumentIDs][0], 28)
+	require.Equal([]interface{}{uint64(2)}, r.state[xStateRowsAffected])
+
+	c.send(xClientInsert, insert)
+	require.Equal(uint64(5116), c.result().err.uint(2))
+
+	find := func(criteria []byte, args ...[]byte) [][][]byte {
+		var find, projection xWriter
+		find.bytes(2, xTestCollection("people"))
+		find.uint(3, xDocument)
+		projection.bytes(1, xTestIdent("name"))
+		find.bytes(4, projection)
+		find.bytes(5, criteria)
+		for _, arg := range args {
+			find.bytes(11, arg)
+		}
+		c.send(xClientFind, find)
+
+		r := c.result()
+		require.Nil(r.err)
+		require.Equal(uint64(xContentJSON), r.columns[0].uint(12))
+		return r.rows
+	}
+
+	rows := find(xTestOperator(">", xTestIdent("age"), xTestPlaceholder(0)), xScalar(int64(35)))
+	require.Equal([][][]byte{{[]byte(`{"name":"bob"}` + "\x00")}}, rows)
+
+	var update, op, source xWriter
+	update.bytes(2, xTestCollection("people"))
+	update.uint(3, xDocument)
+	update.bytes(4, xTestOperator("==", xTestIdent("name"), xTestLiteral("alice")))
+	var path xWriter
+	path.uint(1, xPathMember)
+	path.str(2, "age")
+	source.bytes(1, path)
+	op.bytes(1, source)
+	op.uint(2, xUpdateItemSet)
+	op.bytes(3, xTestOperator("+", xTestIdent("age"), xTestLiteral(int64(1))))
+	update.bytes(6, op)
+	c.send(xClientUpdate, update)
+	r = c.result()
+	require.Nil(r.err)
+	require.Equal([]interface{}{uint64(1)}, r.state[xStateRowsAffected])
+
+	rows = find(xTestOperator("==", xTestIdent("age"), xTestLiteral(int64(31))))
+	require.Equal([][][]byte{{[]byte(`{"name":"alice"}` + "\x00")}}, rows)
+
+	// Tables are queried with SQL.
+	var findRows, projection xWriter
+	findRows.bytes(2, xTestCollection("test"))
+	findRows.uint(3, xTable)
+	projection.bytes(1, xTestColumn("c1"))
+	projection.str(2, "x")
+	findRows.bytes(4, projection)
+	findRows.bytes(5, xTestOperator("==", xTestColumn("c1"), xTestLiteral(int64(5))))
+	c.send(xClientFind, findRows)
+	r = c.result()
+	require.Nil(r.err)
+	require.Equal("x", r.columns[0].str(2))
+	require.Equal([][][]byte{{{10}}}, r.rows)
+
+	var del xWriter
+	del.bytes(1, xTestCollection("people"))
+	del.uint(2, xDocument)
+	del.bytes(3, xTestOperator("in", xTestIdent("name"), xTestLiteral("bob"), xTestLiteral("carol")))
+	c.send(xClientDelete, del)
+	r = c.result()
+	require.Nil(r.err)
+	require.Equal([]interface{}{uint64(1)}, r.state[xStateRowsAffected])
+
+	r = c.stmt("mysqlx", "list_objects", xTestObject("schema", xAnyValue("test")))
+	require.Nil(r.err)
+	require.Equal([][][]byte{
+		{[]byte("people\x00"), []byte("COLLECTION\x00")},
+		{[]byte("test\x00"), []byte("TABLE\x00")},
+	}, r.rows)
+
+	// After a message fails in a block with no_error, the next ones fail
+	// until it's closed.
+	var open, cond xWriter
+	cond.uint(1, xExpectNoError)
+	open.bytes(2, cond)
+	c.send(xClientExpectOpen, open)
+	require.Nil(c.result().err)
+	require.Equal(uint64(mysql.ERUnknownError), c.stmt("sql", "SELECT * FROM nope").err.uint(2))
+	require.Equal(uint64(5159), c.stmt("sql", "SELECT 1").err.uint(2))
+	c.send(xClientExpectClose, nil)
+	require.Equal(uint64(5159), c.result().err.uint(2))
+	require.Nil(c.stmt("sql", "SELECT 1").err)
+
+	require.Nil(c.stmt("mysqlx", "drop_collection", xTestObject("schema", xAnyValue("test"), "name", xAnyValue("people"))).err)
+	require.Equal(uint64(5157), c.stmt("mysqlx", "nope").err.uint(2))
+
+	c.send(xClientClose, nil)
+	require.Nil(c.result().err)
+}
+
+func TestXProtocolAuth(t *testing.T) {
+	require := require.New(t)
+	c, done := newXTestClient(t, auth.NewNativeSingle("user", "pass", auth.AllPermissions))
+	defer done()
+
+	var start xWriter
+	start.str(1, "MYSQL41")
+	c.send(xClientAuthStart, start)
+	typ, f := c.recv()
+	require.Equal(byte(xServerAuthContinue), typ)
+
+	hash := "*" + hex.EncodeToString(mysql.ScramblePassword(f.bytes(1), []byte("wrong")))
+	var cont xWriter
+	cont.str(1, "\x00user\x00"+hash)
+	c.send(xClientAuthContinue, cont)
+	require.Equal(uint64(mysql.ERAccessDeniedError), c.result().err.uint(2))
+
+	c.send(xClientAuthStart, start)
+	_, f = c.recv()
+	hash = "*" + hex.EncodeToString(mysql.ScramblePassword(f.bytes(1), []byte("pass")))
+	cont = nil
+	cont.str(1, "test\x00user\x00"+hash)
+	c.send(xClientAuthContinue, cont)
+	require.Nil(c.result().err)
+
+	require.Nil(c.stmt("sql", "SELECT 1").err)
+	c.send(xClientSessionClose, nil)
+	require.Nil(c.result().err)
+
+	start = nil
+	start.str(1, "SHA256_MEMORY")
+	c.send(xClientAuthStart, start)
+	require.Equal(uint64(erNotSupportedAuthMode), c.result().err.uint(2))
+
+	start = nil
+	start.str(1, "PLAIN")
+	start.str(3, "test\x00user\x00pass")
+	c.send(xClientAuthStart, start)
+	require.Nil(c.result().err)
+}
+
+func TestXValue(t *testing.T) {
+	require := require.New(t)
+
+	b, err := xDecimal("-12.345")
+	require.NoError(err)
+	require.Equal([]byte{3, 0x12, 0x34, 0x5d}, b)
+
+	b, err = xDecimal("12")
+	require.NoError(err)
+	require.Equal([]byte{0, 0x12, 0xc0}, b)
+
+	b, err = xTime(xColumnDatetime, "2019-07-04 13:05:09.25")
+	require.NoError(err)
+	require.Equal([]byte{0xe3, 0x0f, 7, 4, 13, 5, 9, 0x90, 0xa1, 0x0f}, b)
+
+	b, err = xTime(xColumnTime, "-01:02:03")
+	require.NoError(err)
+	require.Equal([]byte{1, 1, 2, 3}, b)
+}
@@ -0,0 +1,135 @@
+package sql
+
+import "strings"
+
+// Aggregator computes an aggregation of the values of the arguments of an
+// AggregateFunction in all the rows of a group. The state of every group
+// is kept in a buffer, a value created and updated by the aggregator, so
+// the same aggregator is used for all the groups of all queries.
+type Aggregator interface {
+	// NewBuffer returns the buffer of a group without rows.
+	NewBuffer() interface{}
+	// Update returns the buffer after adding the values of the arguments
+	// in a row of the group, which are nil when they are NULL.
+	Update(ctx *Context, buffer interface{}, args ...interface{}) (interface{}, error)
+	// Merge returns the buffer after adding the rows aggregated in the
+	// partial buffer, which was aggregated separately from other rows of
+	// the same group, such as when the partitions of a table are
+	// aggregated in parallel.
+	Merge(ctx *Context, buffer, partial interface{}) (interface{}, error)
+	// Eval returns the result of the aggregation of a group from its
+	// buffer. A nil result is NULL.
+	Eval(ctx *Context, buffer interface{}) (interface{}, error)
+}
+
+// AggregateFunction is an aggregate function defined with an Aggregator and
+// the types of its arguments and result. It can be used as the rest of
+// aggregations, with or without GROUP BY. The analyzer validates the types
+// of the arguments, and they are converted to the declared types before
+// they are aggregated, as the result is converted to the declared type.
+type AggregateFunction struct {
+	// Name of the function, which is not case sensitive.
+	Name string
+	// Args are the types of the arguments.
+	Args []Type
+	// Variadic makes the last type of Args the one of any number of
+	// arguments at the end, including none.
+	Variadic bool
+	// Returns is the type of the result.
+	Returns Type
+	// Aggregator aggregates the values of the arguments.
+	Aggregator Aggregator
+}
+
+// Call implements the Function interface.
+func (fn AggregateFunction) Call(args ...Expression) (Expression, error) {
+	if err := checkFunctionArity(fn.name(), fn.Args, fn.Variadic, len(args)); err != nil {
+		return nil, err
+	}
+	return &AggregateFunctionCall{fn: fn, args: args}, nil
+}
+
+// ArgType returns the type the i-th argument of the function is declared
+// with.
+func (fn AggregateFunction) ArgType(i int) Type {
+	return functionArgType(fn.Args, i)
+}
+
+func (fn AggregateFunction) name() string { return strings.ToLower(fn.Name) }
+
+func (AggregateFunction) isFunction() {}
+
+// AggregateFunctionCall is the aggregation of a call to an
+// AggregateFunction. Its buffer is a row with the buffer of the Aggregator.
+type AggregateFunctionCall struct {
+	fn   AggregateFunction
+	args []Expression
+}
+
+var _ Aggregation = (*AggregateFunctionCall)(nil)
+
+// Function returns the function called.
+func (c *AggregateFunctionCall) Function() AggregateFunction { return c.fn }
+
+// Resolved implements the Expression interface.
+func (c *AggregateFunctionCall) Resolved() bool {
+	for _, arg := range c.args {
+		if !arg.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNullable implements the Expression interface.
+func (c *AggregateFunctionCall) IsNullable() bool { return true }
+
+// Type implements the Expression interface.
+func (c *AggregateFunctionCall) Type() Type { return c.fn.Returns }
+
+// Children implements the Expression interface.
+func (c *AggregateFunctionCall) Children() []Expression { return c.args }
+
+// WithChildren implements the Expression interface.
+func (c *AggregateFunctionCall) WithChildren(children ...Expression) (Expression, error) {
+	if len(children) != len(c.args) {
+		return nil, ErrInvalidChildrenNumber.New(c, len(children), len(c.args))
+	}
+	return &AggregateFunctionCall{fn: c.fn, args: children}, nil
+}
+
+// NewBuffer implements the Aggregation interface.
+func (c *AggregateFunctionCall) NewBuffer() Row {
+	return NewRow(c.fn.Aggregator.NewBuffer())
+}
+
+// Update implements the Aggregation interface.
+func (c *AggregateFunctionCall) Update(ctx *Context, buffer, row Row) error {
+	args, err := evalFunctionArgs(ctx, c.fn.name(), c.fn.ArgType, c.args, row)
+	if err != nil {
+		return err
+	}
+
+	buffer[0], err = c.fn.Aggregator.Update(ctx, buffer[0], args...)
+	return err
+}
+
+// Merge implements the Aggregation interface.
+func (c *AggregateFunctionCall) Merge(ctx *Context, buffer, partial Row) error {
+	var err error
+	buffer[0], err = c.fn.Aggregator.Merge(ctx, buffer[0], partial[0])
+	return err
+}
+
+// Eval implements the Aggregation interface.
+func (c *AggregateFunctionCall) Eval(ctx *Context, buffer Row) (interface{}, error) {
+	result, err := c.fn.Aggregator.Eval(ctx, buffer[0])
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return c.fn.Returns.Convert(result)
+}
+
+func (c *AggregateFunctionCall) String() string {
+	return functionCallString(c.fn.name(), c.args)
+}
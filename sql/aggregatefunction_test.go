@@ -0,0 +1,80 @@
+package sql_test
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+// distinctCount counts the distinct values of its argument.
+type distinctCount struct{}
+
+func (distinctCount) NewBuffer() interface{} { return map[int64]struct{}{} }
+
+func (distinctCount) Update(ctx *sql.Context, buffer interface{}, args ...interface{}) (interface{}, error) {
+	if args[0] != nil {
+		buffer.(map[int64]struct{})[args[0].(int64)] = struct{}{}
+	}
+	return buffer, nil
+}
+
+func (distinctCount) Merge(ctx *sql.Context, buffer, partial interface{}) (interface{}, error) {
+	for v := range partial.(map[int64]struct{}) {
+		buffer.(map[int64]struct{})[v] = struct{}{}
+	}
+	return buffer, nil
+}
+
+func (distinctCount) Eval(ctx *sql.Context, buffer interface{}) (interface{}, error) {
+	return len(buffer.(map[int64]struct{})), nil
+}
+
+func TestAggregateFunction(t *testing.T) {
+	require := require.New(t)
+
+	r := sql.NewFunctionRegistry()
+	require.NoError(r.Register(sql.AggregateFunction{
+		Name:       "DISTINCT_COUNT",
+		Args:       []sql.Type{sql.Int64},
+		Returns:    sql.Int64,
+		Aggregator: distinctCount{},
+	}))
+	f, err := r.Function("distinct_count")
+	require.NoError(err)
+
+	_, err = f.Call()
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	e, err := f.Call(expression.NewGetField(0, sql.Text, "a", true))
+	require.NoError(err)
+	require.Equal(sql.Int64, e.Type())
+	require.Equal("distinct_count(a)", e.String())
+
+	agg, ok := e.(sql.Aggregation)
+	require.True(ok)
+
+	ctx := sql.NewEmptyContext()
+	buffer := agg.NewBuffer()
+	for _, v := range []interface{}{"1", "2", nil, "1"} {
+		require.NoError(agg.Update(ctx, buffer, sql.NewRow(v)))
+	}
+
+	partial := agg.NewBuffer()
+	for _, v := range []interface{}{"3", "2"} {
+		require.NoError(agg.Update(ctx, partial, sql.NewRow(v)))
+	}
+
+	v, err := agg.Eval(ctx, partial)
+	require.NoError(err)
+	require.Equal(int64(2), v)
+
+	require.NoError(agg.Merge(ctx, buffer, partial))
+	v, err = agg.Eval(ctx, buffer)
+	require.NoError(err)
+	require.Equal(int64(3), v)
+
+	err = agg.Update(ctx, buffer, sql.NewRow("abc"))
+	require.True(sql.ErrFunctionArgumentType.Is(err))
+}
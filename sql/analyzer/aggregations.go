@@ -25,14 +25,18 @@ func reorderAggregations(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, e
 
 			a.Log("fixing aggregations of node of type: %T", n)
 
-			return fixAggregations(n.Aggregate, n.Grouping, n.Child)
+			return fixAggregations(n.Aggregate, n.Grouping, n.GroupingSets, n.Child)
 		default:
 			return n, nil
 		}
 	})
 }
 
-func fixAggregations(projection, grouping []sql.Expression, child sql.Node) (sql.Node, error) {
+func fixAggregations(
+	projection, grouping []sql.Expression,
+	sets [][]int,
+	child sql.Node,
+) (sql.Node, error) {
 	var aggregate = make([]sql.Expression, 0, len(projection))
 	var newProjection = make([]sql.Expression, len(projection))
 
@@ -67,7 +71,7 @@ func fixAggregations(projection, grouping []sql.Expression, child sql.Node) (sql
 
 	return plan.NewProject(
 		newProjection,
-		plan.NewGroupBy(aggregate, grouping, child),
+		plan.NewGroupBy(aggregate, grouping, child).WithGroupingSets(sets),
 	), nil
 }
 
@@ -106,14 +110,18 @@ func containsHiddenAggregation(e sql.Expression) bool {
 	return containsAggregation(e)
 }
 
+// containsAggregation reports whether an expression has aggregations,
+// including the calls to aggregate functions that are not resolved yet.
 func containsAggregation(e sql.Expression) bool {
 	var hasAgg bool
 	expression.Inspect(e, func(e sql.Expression) bool {
-		if _, ok := e.(sql.Aggregation); ok {
+		switch e := e.(type) {
+		case sql.Aggregation:
 			hasAgg = true
-			return false
+		case *expression.UnresolvedFunction:
+			hasAgg = e.IsAggregate
 		}
-		return true
+		return !hasAgg
 	})
 	return hasAgg
 }
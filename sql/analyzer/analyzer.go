@@ -4,8 +4,12 @@ import (
 	"os"
 
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
 	"github.com/sirupsen/logrus"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
 	"gopkg.in/src-d/go-errors.v1"
 )
 
@@ -16,12 +20,17 @@ const maxAnalysisIterations = 1000
 // ErrMaxAnalysisIters is thrown when the analysis iterations are exceeded
 var ErrMaxAnalysisIters = errors.NewKind("exceeded max analysis iterations (%d)")
 
+// ErrRuleNotFound is thrown when a rule is placed relative to a rule that
+// does not exist in the analyzer.
+var ErrRuleNotFound = errors.NewKind("analyzer rule %q not found")
+
 // Builder provides an easy way to generate Analyzer with custom rules and options.
 type Builder struct {
 	preAnalyzeRules     []Rule
 	postAnalyzeRules    []Rule
 	preValidationRules  []Rule
 	postValidationRules []Rule
+	ruleEdits           []ruleEdit
 	catalog             *sql.Catalog
 	debug               bool
 	parallelism         int
@@ -74,7 +83,68 @@ func (ab *Builder) AddPostValidationRule(name string, fn RuleFunc) *Builder {
 	return ab
 }
 
-// Build creates a new Analyzer using all previous data setted to the Builder
+// AddRuleBefore adds a new rule to the analyzer right before the rule with
+// the given name, in the same batch, so it's applied with the same
+// guarantees as that rule, such as the plan being already resolved.
+func (ab *Builder) AddRuleBefore(existing, name string, fn RuleFunc) *Builder {
+	ab.ruleEdits = append(ab.ruleEdits, ruleEdit{existing, &Rule{name, fn}, false})
+
+	return ab
+}
+
+// AddRuleAfter adds a new rule to the analyzer right after the rule with the
+// given name, in the same batch.
+func (ab *Builder) AddRuleAfter(existing, name string, fn RuleFunc) *Builder {
+	ab.ruleEdits = append(ab.ruleEdits, ruleEdit{existing, &Rule{name, fn}, true})
+
+	return ab
+}
+
+// RemoveRule removes the rule with the given name from the analyzer.
+func (ab *Builder) RemoveRule(name string) *Builder {
+	ab.ruleEdits = append(ab.ruleEdits, ruleEdit{name, nil, false})
+
+	return ab
+}
+
+// ruleEdit is a change of the rules of the analyzer relative to an existing
+// rule: the new rule is added before or after it, or the existing rule is
+// removed if there is no new rule.
+type ruleEdit struct {
+	existing string
+	rule     *Rule
+	after    bool
+}
+
+func (e ruleEdit) apply(batches []*Batch) error {
+	for _, b := range batches {
+		for i, r := range b.Rules {
+			if r.Name != e.existing {
+				continue
+			}
+
+			var rules = make([]Rule, 0, len(b.Rules)+1)
+			switch {
+			case e.rule == nil:
+				rules = append(append(rules, b.Rules[:i]...), b.Rules[i+1:]...)
+			case e.after:
+				rules = append(append(rules, b.Rules[:i+1]...), *e.rule)
+				rules = append(rules, b.Rules[i+1:]...)
+			default:
+				rules = append(append(rules, b.Rules[:i]...), *e.rule)
+				rules = append(rules, b.Rules[i:]...)
+			}
+			b.Rules = rules
+			return nil
+		}
+	}
+
+	return ErrRuleNotFound.New(e.existing)
+}
+
+// Build creates a new Analyzer using all previous data setted to the Builder.
+// It panics if a rule was added relative to, or removing, a rule that does
+// not exist.
 func (ab *Builder) Build() *Analyzer {
 	_, debug := os.LookupEnv(debugAnalyzerKey)
 	var batches = []*Batch{
@@ -125,6 +195,12 @@ func (ab *Builder) Build() *Analyzer {
 		},
 	}
 
+	for _, e := range ab.ruleEdits {
+		if err := e.apply(batches); err != nil {
+			panic(err)
+		}
+	}
+
 	return &Analyzer{
 		Debug:       debug || ab.debug,
 		Batches:     batches,
@@ -158,16 +234,61 @@ func (a *Analyzer) Log(msg string, args ...interface{}) {
 	}
 }
 
+// caseSensitiveNames reports whether the names of the databases, tables and
+// columns are case sensitive in the catalog of the analyzer.
+func (a *Analyzer) caseSensitiveNames() bool {
+	return a != nil && a.Catalog != nil && a.Catalog.CaseSensitiveNames()
+}
+
 // Analyze the node and all its children.
 func (a *Analyzer) Analyze(ctx *sql.Context, n sql.Node) (sql.Node, error) {
-	span, ctx := ctx.Span("analyze", opentracing.Tags{
+	return a.analyze(ctx, "analyze", n, a.Batches)
+}
+
+// Prepare analyzes the node with all the batches but the last one, whose
+// rules depend on the execution of the query, such as tracking its process.
+// The resulting node can be kept and executed as many times as needed, as
+// long as Finish is applied to it before every execution.
+func (a *Analyzer) Prepare(ctx *sql.Context, n sql.Node) (sql.Node, error) {
+	if len(a.Batches) == 0 {
+		return n, nil
+	}
+	return a.analyze(ctx, "prepare", n, a.Batches[:len(a.Batches)-1])
+}
+
+// Finish analyzes a node returned by Prepare with the batch skipped by it,
+// so it can be executed in the given context.
+func (a *Analyzer) Finish(ctx *sql.Context, n sql.Node) (sql.Node, error) {
+	if len(a.Batches) == 0 {
+		return n, nil
+	}
+	return a.analyze(ctx, "finish", n, a.Batches[len(a.Batches)-1:])
+}
+
+func (a *Analyzer) analyze(
+	ctx *sql.Context,
+	spanName string,
+	n sql.Node,
+	batches []*Batch,
+) (sql.Node, error) {
+	span, ctx := ctx.Span(spanName, opentracing.Tags{
 		"plan": n.String(),
 	})
 
 	prev := n
 	var err error
+	defer func() {
+		if err != nil && !ErrMaxAnalysisIters.Is(err) {
+			ext.Error.Set(span, true)
+			span.LogFields(log.Error(err))
+		} else if prev != nil {
+			span.SetTag("IsResolved", prev.Resolved())
+		}
+		span.Finish()
+	}()
+
 	a.Log("starting analysis of node of type: %T", n)
-	for _, batch := range a.Batches {
+	for _, batch := range batches {
 		prev, err = batch.Eval(ctx, a, prev)
 		if ErrMaxAnalysisIters.Is(err) {
 			a.Log(err.Error())
@@ -178,14 +299,37 @@ func (a *Analyzer) Analyze(ctx *sql.Context, n sql.Node) (sql.Node, error) {
 		}
 	}
 
-	defer func() {
-		if prev != nil {
-			span.SetTag("IsResolved", prev.Resolved())
+	return prev, err
+}
+
+// Reusable reports whether a node returned by Prepare can be executed more
+// than once. Nodes are not reusable when they hold resources that are
+// released after their execution, such as indexes, values of the session
+// they were analyzed in, such as the warnings shown by SHOW WARNINGS, or
+// subqueries, which are completely analyzed.
+func Reusable(n sql.Node) bool {
+	var reusable = true
+	plan.Inspect(n, func(node sql.Node) bool {
+		switch node.(type) {
+		case *releaser, *plan.SubqueryAlias, plan.ShowWarnings:
+			reusable = false
 		}
-		span.Finish()
-	}()
+		return reusable
+	})
 
-	return prev, err
+	if !reusable {
+		return false
+	}
+
+	plan.InspectExpressions(n, func(e sql.Expression) bool {
+		switch e.(type) {
+		case *expression.Subquery, *expression.GetSessionField:
+			reusable = false
+		}
+		return reusable
+	})
+
+	return reusable
 }
 
 type equaler interface {
@@ -178,12 +178,52 @@ func TestAnalyzer_Analyze(t *testing.T) {
 	analyzed, err = a.Analyze(sql.NewEmptyContext(), notAnalyzed)
 	expected = plan.NewLimit(
 		int64(1),
-		plan.NewResolvedTable(table.WithProjection([]string{"i"})),
+		plan.NewResolvedTable(
+			table.WithProjection([]string{"i"}).(*memory.Table).WithLimit(1),
+		),
 	)
 	require.NoError(err)
 	require.Equal(expected, analyzed)
 }
 
+func TestAnalyzer_PrepareAndFinish(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("mytable", sql.Schema{
+		{Name: "i", Type: sql.Int32, Source: "mytable"},
+	})
+
+	db := memory.NewDatabase("mydb")
+	db.AddTable("mytable", table)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	a := NewDefault(catalog)
+
+	notAnalyzed := plan.NewProject(
+		[]sql.Expression{expression.NewUnresolvedColumn("i")},
+		plan.NewUnresolvedTable("mytable", ""),
+	)
+
+	prepared, err := a.Prepare(sql.NewEmptyContext(), notAnalyzed)
+	require.NoError(err)
+	require.Equal(plan.NewResolvedTable(table.WithProjection([]string{"i"})), prepared)
+	require.True(Reusable(prepared))
+
+	finished, err := a.Finish(sql.NewEmptyContext(), prepared)
+	require.NoError(err)
+
+	analyzed, err := a.Analyze(sql.NewEmptyContext(), notAnalyzed)
+	require.NoError(err)
+	require.Equal(analyzed.String(), finished.String())
+
+	require.False(Reusable(&releaser{prepared, func() {}}))
+	require.False(Reusable(plan.NewProject(
+		[]sql.Expression{expression.NewGetSessionField("autocommit", sql.Int64, int64(1))},
+		prepared,
+	)))
+}
+
 func TestMaxIterations(t *testing.T) {
 	require := require.New(t)
 	tName := "my-table"
@@ -260,6 +300,67 @@ func TestAddPostValidationRule(t *testing.T) {
 	require.Equal(countRules(a.Batches), defRulesCount+1)
 }
 
+func TestAddRuleBeforeAndAfter(t *testing.T) {
+	require := require.New(t)
+
+	defRulesCount := countRules(NewDefault(nil).Batches)
+
+	a := NewBuilder(nil).
+		AddRuleBefore("pushdown", "foo", pushdown).
+		AddRuleAfter("pushdown", "bar", pushdown).
+		AddRuleAfter("clear_warnings", "baz", pushdown).
+		Build()
+
+	require.Equal(defRulesCount+3, countRules(a.Batches))
+	names := ruleNames(a.Batches[3].Rules)
+	i := indexOf(names, "pushdown")
+	require.Equal([]string{"foo", "pushdown", "bar"}, names[i-1:i+2])
+
+	last := a.Batches[len(a.Batches)-1].Rules
+	require.Equal("baz", last[len(last)-1].Name)
+
+	// The default rules are not changed.
+	require.Equal(defRulesCount, countRules(NewDefault(nil).Batches))
+}
+
+func TestRemoveRule(t *testing.T) {
+	require := require.New(t)
+
+	defRulesCount := countRules(NewDefault(nil).Batches)
+
+	a := NewBuilder(nil).RemoveRule("pushdown").Build()
+
+	require.Equal(defRulesCount-1, countRules(a.Batches))
+	for _, b := range a.Batches {
+		require.NotContains(ruleNames(b.Rules), "pushdown")
+	}
+}
+
+func TestAddRuleNotFound(t *testing.T) {
+	require := require.New(t)
+
+	require.Panics(func() {
+		NewBuilder(nil).AddRuleAfter("foo", "bar", pushdown).Build()
+	})
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func ruleNames(rules []Rule) []string {
+	var names = make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
 func countRules(batches []*Batch) int {
 	var count int
 	for _, b := range batches {
@@ -56,10 +56,61 @@ func assignCatalog(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			nc := *node
 			nc.Catalog = a.Catalog
 			return &nc, nil
+		case *plan.AnalyzeTable:
+			nc := *node
+			nc.Catalog = a.Catalog
+			nc.CurrentDatabase = a.Catalog.CurrentDatabase()
+			return &nc, nil
 		case *plan.UnlockTables:
 			nc := *node
 			nc.Catalog = a.Catalog
 			return &nc, nil
+		case *plan.LoadData:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.SelectIntoFile:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.Set:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.CreateDatabase:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.DropDatabase:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.Dump:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.Grant:
+			nc := *node
+			nc.Catalog = a.Catalog
+			nc.CurrentDatabase = a.Catalog.CurrentDatabase()
+			return &nc, nil
+		case *plan.Revoke:
+			nc := *node
+			nc.Catalog = a.Catalog
+			nc.CurrentDatabase = a.Catalog.CurrentDatabase()
+			return &nc, nil
+		case *plan.ShowMasterStatus:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.ShowBinaryLogs:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
+		case *plan.ShowGrants:
+			nc := *node
+			nc.Catalog = a.Catalog
+			return &nc, nil
 		default:
 			return n, nil
 		}
@@ -68,6 +68,13 @@ func TestAssignCatalog(t *testing.T) {
 	require.True(ok)
 	require.Equal(c, lt.Catalog)
 
+	node, err = f.Apply(sql.NewEmptyContext(), a, plan.NewAnalyzeTable())
+	require.NoError(err)
+	at, ok := node.(*plan.AnalyzeTable)
+	require.True(ok)
+	require.Equal(c, at.Catalog)
+	require.Equal("foo", at.CurrentDatabase)
+
 	node, err = f.Apply(sql.NewEmptyContext(), a, plan.NewUnlockTables())
 	require.NoError(err)
 	ut, ok := node.(*plan.UnlockTables)
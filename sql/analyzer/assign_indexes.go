@@ -63,6 +63,12 @@ func assignIndexes(a *Analyzer, node sql.Node) (map[string]*indexLookup, error)
 	}
 
 	plan.Inspect(node, func(node sql.Node) bool {
+		// The indexes of derived tables were already assigned when their
+		// queries were analyzed.
+		if _, ok := node.(*plan.SubqueryAlias); ok {
+			return false
+		}
+
 		filter, ok := node.(*plan.Filter)
 		if !ok {
 			return true
@@ -87,6 +93,20 @@ func assignIndexes(a *Analyzer, node sql.Node) (map[string]*indexLookup, error)
 	return indexes, err
 }
 
+// removeHintedIndexes removes the lookups of the tables that use any index
+// not allowed by the optimizer hints of the query.
+func removeHintedIndexes(a *Analyzer, hints *sql.QueryHints, indexes map[string]*indexLookup) {
+	for table, lookup := range indexes {
+		for _, idx := range lookup.indexes {
+			if !hints.IndexAllowed(idx.Table(), idx.ID()) {
+				a.Log("index %q of table %q not used because of the optimizer hints", idx.ID(), idx.Table())
+				delete(indexes, table)
+				break
+			}
+		}
+	}
+}
+
 func getIndexes(e sql.Expression, aliases map[string]sql.Expression, a *Analyzer) (map[string]*indexLookup, error) {
 	var result = make(map[string]*indexLookup)
 	switch e := e.(type) {
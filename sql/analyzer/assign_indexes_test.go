@@ -1224,3 +1224,21 @@ func (i *mergeableIndexLookup) Union(indexes ...sql.IndexLookup) sql.IndexLookup
 		append(i.intersections, intersections...),
 	}
 }
+
+func TestRemoveHintedIndexes(t *testing.T) {
+	require := require.New(t)
+
+	idx1 := &dummyIndex{"t1", []sql.Expression{col(0, "t1", "a")}}
+	idx2 := &dummyIndex{"t2", []sql.Expression{col(0, "t2", "b")}}
+	lookup1 := &indexLookup{&mergeableIndexLookup{id: "1"}, []sql.Index{idx1}}
+	lookup2 := &indexLookup{&mergeableIndexLookup{id: "2"}, []sql.Index{idx2}}
+
+	indexes := map[string]*indexLookup{"t1": lookup1, "t2": lookup2}
+	removeHintedIndexes(NewDefault(nil), nil, indexes)
+	require.Equal(map[string]*indexLookup{"t1": lookup1, "t2": lookup2}, indexes)
+
+	removeHintedIndexes(NewDefault(nil), &sql.QueryHints{
+		NoIndex: map[string][]string{"t2": {"t2.b"}},
+	}, indexes)
+	require.Equal(map[string]*indexLookup{"t1": lookup1}, indexes)
+}
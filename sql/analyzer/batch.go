@@ -2,10 +2,21 @@ package analyzer
 
 import (
 	"reflect"
+	"time"
 
+	"github.com/go-kit/kit/metrics/discard"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
 	"github.com/src-d/go-mysql-server/sql"
 )
 
+var (
+	// RuleHistogram describes the time it takes to apply each rule of the
+	// analyzer, labeled with its name.
+	RuleHistogram = discard.NewHistogram()
+)
+
 // RuleFunc is the function to be applied in a rule.
 type RuleFunc func(*sql.Context, *Analyzer, sql.Node) (sql.Node, error)
 
@@ -34,6 +45,13 @@ func (b *Batch) Eval(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error
 		return n, nil
 	}
 
+	span, ctx := ctx.Span("batch", opentracing.Tag{Key: "batch", Value: b.Desc})
+	iterations := 1
+	defer func() {
+		span.SetTag("iterations", iterations)
+		span.Finish()
+	}()
+
 	prev := n
 	cur, err := b.evalOnce(ctx, a, n)
 	if err != nil {
@@ -44,15 +62,15 @@ func (b *Batch) Eval(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error
 		return cur, nil
 	}
 
-	for i := 1; !nodesEqual(prev, cur); {
+	for !nodesEqual(prev, cur) {
 		prev = cur
 		cur, err = b.evalOnce(ctx, a, cur)
 		if err != nil {
 			return nil, err
 		}
 
-		i++
-		if i >= b.Iterations {
+		iterations++
+		if iterations >= b.Iterations {
 			return cur, ErrMaxAnalysisIters.New(b.Iterations)
 		}
 	}
@@ -64,10 +82,17 @@ func (b *Batch) evalOnce(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, e
 	result := n
 	for _, rule := range b.Rules {
 		var err error
+		span, ctx := ctx.Span("rule." + rule.Name)
+		start := time.Now()
 		result, err = rule.Apply(ctx, a, result)
+		RuleHistogram.With("rule", rule.Name).Observe(time.Since(start).Seconds())
 		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(log.Error(err))
+			span.Finish()
 			return nil, err
 		}
+		span.Finish()
 	}
 
 	return result, nil
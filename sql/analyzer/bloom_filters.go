@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// addBloomFilters adds a bloom filter to the inner joins whose right side is
+// kept in memory and whose condition compares columns of both sides. The
+// join builds it with the keys of the rows of its right side before reading
+// the left side, and the rows of the left side whose keys are not in it are
+// skipped. It's added as a filter of the left side, so it's pushed down to
+// the table when it's a sql.FilteredTable handling it.
+func addBloomFilters(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("add_bloom_filters")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		j, ok := node.(*plan.InnerJoin)
+		if !ok || j.Strategy != plan.InMemoryJoinStrategy || j.BloomFilter != nil {
+			return node, nil
+		}
+
+		probe, build := bloomFilterKeys(j)
+		if len(probe) == 0 {
+			return node, nil
+		}
+
+		a.Log("adding bloom filter to join with %d keys", len(probe))
+
+		filter := expression.NewBloomFilter(probe...)
+		nj := *j
+		nj.Left = plan.NewFilter(filter, j.Left)
+		nj.BloomFilter = filter.WithKeys(build...)
+		return &nj, nil
+	})
+}
+
+// bloomFilterKeys returns the columns of the left side of the join compared
+// for equality with columns of the right side in its condition, and those
+// columns of the right side, with their indexes in the right side. Only
+// columns of the same integer or text type are used, as only the values of
+// those types are equal when their hashes are.
+func bloomFilterKeys(j *plan.InnerJoin) (probe, build []sql.Expression) {
+	leftLen := len(j.Left.Schema())
+	for _, e := range splitExpression(j.Cond) {
+		eq, ok := e.(*expression.Equals)
+		if !ok {
+			continue
+		}
+
+		left, ok := eq.Left().(*expression.GetField)
+		if !ok {
+			continue
+		}
+
+		right, ok := eq.Right().(*expression.GetField)
+		if !ok {
+			continue
+		}
+
+		if left.Index() >= leftLen {
+			left, right = right, left
+		}
+
+		if left.Index() >= leftLen || right.Index() < leftLen {
+			continue
+		}
+
+		typ := left.Type()
+		if typ != right.Type() || !(sql.IsInteger(typ) || sql.IsText(typ)) {
+			continue
+		}
+
+		probe = append(probe, left)
+		build = append(build, expression.NewGetFieldWithTable(
+			right.Index()-leftLen,
+			right.Type(),
+			right.Table(),
+			right.Name(),
+			right.IsNullable(),
+		))
+	}
+
+	return probe, build
+}
+
+// resetBloomFilters gives every bloom filter of the plan new keys, shared
+// by the join building them and the filter using them, so the executions
+// of the same plan, which may run at the same time when the plan is
+// cached, don't share them.
+func resetBloomFilters(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("reset_bloom_filters")
+	defer span.Finish()
+
+	var refs = make(map[*expression.BloomFilterRef]*expression.BloomFilterRef)
+	plan.Inspect(n, func(node sql.Node) bool {
+		if j, ok := node.(*plan.InnerJoin); ok && j.BloomFilter != nil {
+			refs[j.BloomFilter.Ref()] = new(expression.BloomFilterRef)
+		}
+		return true
+	})
+
+	if len(refs) == 0 {
+		return n, nil
+	}
+
+	reset := func(e sql.Expression) (sql.Expression, error) {
+		if f, ok := e.(*expression.BloomFilter); ok {
+			if ref, ok := refs[f.Ref()]; ok {
+				return f.WithRef(ref), nil
+			}
+		}
+		return e, nil
+	}
+
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		switch node := node.(type) {
+		case *plan.InnerJoin:
+			if node.BloomFilter == nil {
+				return node, nil
+			}
+
+			nj := *node
+			nj.BloomFilter = node.BloomFilter.WithRef(refs[node.BloomFilter.Ref()])
+			return &nj, nil
+		case *plan.Filter:
+			return plan.TransformExpressions(node, reset)
+		case *plan.ResolvedTable:
+			ft, ok := node.Table.(sql.FilteredTable)
+			if !ok || len(ft.Filters()) == 0 {
+				return node, nil
+			}
+
+			var filters = make([]sql.Expression, len(ft.Filters()))
+			for i, f := range ft.Filters() {
+				var err error
+				filters[i], err = expression.TransformUp(f, reset)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return plan.NewResolvedTable(ft.WithFilters(filters)), nil
+		default:
+			return node, nil
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddBloomFilters(t *testing.T) {
+	f := getRule("bloom_filters")
+
+	t1 := plan.NewResolvedTable(memory.NewTable("t1", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t1"},
+		{Name: "b", Type: sql.Text, Source: "t1"},
+	}))
+	t2 := plan.NewResolvedTable(memory.NewTable("t2", sql.Schema{
+		{Name: "c", Type: sql.Int64, Source: "t2"},
+		{Name: "d", Type: sql.Float64, Source: "t2"},
+	}))
+
+	join := func(strategy plan.JoinStrategy, cond sql.Expression) *plan.InnerJoin {
+		j := plan.NewInnerJoin(t1, t2, cond)
+		j.Strategy = strategy
+		return j
+	}
+
+	testCases := []struct {
+		name  string
+		node  sql.Node
+		probe []sql.Expression
+		build []sql.Expression
+	}{
+		{
+			"equal columns of both sides",
+			join(plan.InMemoryJoinStrategy, expression.NewAnd(
+				eq(col(2, "t2", "c"), col(0, "t1", "a")),
+				expression.NewGreaterThan(col(0, "t1", "a"), lit(1)),
+			)),
+			[]sql.Expression{col(0, "t1", "a")},
+			[]sql.Expression{col(0, "t2", "c")},
+		},
+		{
+			"columns of different types",
+			join(plan.InMemoryJoinStrategy, eq(
+				col(0, "t1", "a"),
+				expression.NewGetFieldWithTable(3, sql.Float64, "t2", "d", false),
+			)),
+			nil,
+			nil,
+		},
+		{
+			"columns of the same side",
+			join(plan.InMemoryJoinStrategy, eq(col(0, "t1", "a"), col(0, "t1", "a"))),
+			nil,
+			nil,
+		},
+		{
+			"right side not in memory",
+			join(plan.MultipassJoinStrategy, eq(col(0, "t1", "a"), col(2, "t2", "c"))),
+			nil,
+			nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), tt.node)
+			require.NoError(err)
+
+			if tt.probe == nil {
+				require.Equal(tt.node, result)
+				return
+			}
+
+			j, ok := result.(*plan.InnerJoin)
+			require.True(ok)
+			require.Equal(tt.node.(*plan.InnerJoin).Cond, j.Cond)
+			require.Equal(t2, j.Right)
+			require.Equal(tt.build, j.BloomFilter.Keys())
+
+			filter, ok := j.Left.(*plan.Filter)
+			require.True(ok)
+			require.Equal(t1, filter.Child)
+
+			probe, ok := filter.Expression.(*expression.BloomFilter)
+			require.True(ok)
+			require.Equal(tt.probe, probe.Keys())
+			require.True(probe.Ref() == j.BloomFilter.Ref())
+		})
+	}
+}
+
+func TestResetBloomFilters(t *testing.T) {
+	require := require.New(t)
+	f := getRuleFrom(OnceAfterAll, "reset_bloom_filters")
+
+	t1 := plan.NewResolvedTable(memory.NewTable("t1", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t1"},
+	}))
+	t2 := memory.NewTable("t2", sql.Schema{
+		{Name: "b", Type: sql.Int64, Source: "t2"},
+	})
+
+	probe := expression.NewBloomFilter(col(0, "t2", "b"))
+	pushed := plan.NewResolvedTable(t2.WithFilters([]sql.Expression{probe}))
+
+	j := plan.NewInnerJoin(
+		plan.NewFilter(probe, pushed),
+		t1,
+		eq(col(0, "t2", "b"), col(1, "t1", "a")),
+	)
+	j.Strategy = plan.InMemoryJoinStrategy
+	j.BloomFilter = probe.WithKeys(col(0, "t1", "a"))
+
+	result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), j)
+	require.NoError(err)
+
+	rj, ok := result.(*plan.InnerJoin)
+	require.True(ok)
+	ref := rj.BloomFilter.Ref()
+	require.False(ref == probe.Ref())
+	require.Equal(j.BloomFilter.Keys(), rj.BloomFilter.Keys())
+
+	filter := rj.Left.(*plan.Filter)
+	require.True(filter.Expression.(*expression.BloomFilter).Ref() == ref)
+
+	table := filter.Child.(*plan.ResolvedTable).Table.(sql.FilteredTable)
+	require.True(table.Filters()[0].(*expression.BloomFilter).Ref() == ref)
+
+	// The original plan is not modified.
+	require.True(j.BloomFilter.Ref() == probe.Ref())
+	require.True(pushed.Table.(sql.FilteredTable).Filters()[0] == probe)
+}
@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// eliminateCommonSubqueries makes the subqueries repeated in a query be
+// executed only once. Identical subquery expressions are replaced with the
+// same expression, which keeps the result of the subquery after the first
+// time it's evaluated, and identical derived tables read the rows of their
+// query from a cache shared by all of them, which is released once the
+// query is finished. Generated queries, such as the ones of BI tools, tend
+// to have the same subqueries copied many times.
+func eliminateCommonSubqueries(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("eliminate_common_subqueries")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	n, err := shareSubqueryExpressions(a, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return cacheDerivedTables(a, n)
+}
+
+// shareSubqueryExpressions replaces the subquery and EXISTS expressions of
+// the node with the first one found with the same query.
+func shareSubqueryExpressions(a *Analyzer, n sql.Node) (sql.Node, error) {
+	var subqueries = make(map[string]sql.Expression)
+	var repeated bool
+	plan.InspectExpressions(n, func(e sql.Expression) bool {
+		switch e := e.(type) {
+		case *expression.Subquery, *expression.Exists:
+			key := e.String()
+			if _, ok := subqueries[key]; ok {
+				repeated = true
+			} else if isDeterministic(subqueryNode(e)) {
+				subqueries[key] = e
+			}
+		}
+		return true
+	})
+
+	if !repeated {
+		return n, nil
+	}
+
+	return plan.TransformExpressionsUp(n, func(e sql.Expression) (sql.Expression, error) {
+		switch e.(type) {
+		case *expression.Subquery, *expression.Exists:
+			if s, ok := subqueries[e.String()]; ok && s != e {
+				a.Log("sharing repeated subquery %s", e)
+				return s, nil
+			}
+		}
+		return e, nil
+	})
+}
+
+// cacheDerivedTables makes the derived tables of the node with the same
+// query read their rows from the same cache.
+func cacheDerivedTables(a *Analyzer, n sql.Node) (sql.Node, error) {
+	var queries = make(map[string]int)
+	plan.Inspect(n, func(node sql.Node) bool {
+		if alias, ok := node.(*plan.SubqueryAlias); ok {
+			if isDeterministic(alias.Child) {
+				queries[alias.Child.String()]++
+			}
+			return false
+		}
+		return true
+	})
+
+	var caches = make(map[string]*plan.CachedResults)
+	n, err := plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		alias, ok := node.(*plan.SubqueryAlias)
+		if !ok {
+			return node, nil
+		}
+
+		key := alias.Child.String()
+		if queries[key] < 2 {
+			return node, nil
+		}
+
+		cached, ok := caches[key]
+		if !ok {
+			// The query of the subquery is already wrapped in its own
+			// process, which would be marked as done, and the whole query
+			// cancelled, as soon as the subquery finished.
+			child := alias.Child
+			if qp, ok := child.(*plan.QueryProcess); ok {
+				child = qp.Child
+			}
+
+			cached = plan.NewCachedResults(child)
+			caches[key] = cached
+		}
+
+		a.Log("caching rows of derived table %q", alias.Name())
+		return plan.NewSubqueryAlias(alias.Name(), cached), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(caches) == 0 {
+		return n, nil
+	}
+
+	return &releaser{n, func() {
+		for _, c := range caches {
+			c.Dispose()
+		}
+	}}, nil
+}
+
+// subqueryNode returns the query of a subquery or EXISTS expression.
+func subqueryNode(e sql.Expression) sql.Node {
+	if exists, ok := e.(*expression.Exists); ok {
+		return exists.Query.Query
+	}
+	return e.(*expression.Subquery).Query
+}
+
+// isDeterministic returns whether the node returns the same rows every time
+// it's executed during a query, which is the case unless it has expressions
+// that are not deterministic or it's the query of a LATERAL derived table.
+func isDeterministic(n sql.Node) bool {
+	var deterministic = true
+	plan.Inspect(n, func(n sql.Node) bool {
+		if _, ok := n.(*plan.LateralRow); ok {
+			deterministic = false
+		}
+		return deterministic
+	})
+	if !deterministic {
+		return false
+	}
+
+	plan.InspectExpressions(n, func(e sql.Expression) bool {
+		switch e := e.(type) {
+		case sql.NonDeterministicExpression:
+			deterministic = !e.IsNonDeterministic()
+		case *expression.Subquery:
+			deterministic = isDeterministic(e.Query)
+		}
+		return deterministic
+	})
+	return deterministic
+}
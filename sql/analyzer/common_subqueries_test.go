@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareSubqueryExpressions(t *testing.T) {
+	require := require.New(t)
+	f := getRule("eliminate_common_subqueries")
+
+	table := plan.NewResolvedTable(memory.NewTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+	}))
+
+	query := func() sql.Node {
+		return plan.NewProject([]sql.Expression{col(0, "t", "a")}, table)
+	}
+	random := func() sql.Node {
+		return plan.NewProject([]sql.Expression{function.NewNow()}, table)
+	}
+
+	node := plan.NewProject(
+		[]sql.Expression{
+			expression.NewSubquery(query()),
+			expression.NewSubquery(random()),
+		},
+		plan.NewFilter(
+			expression.NewAnd(
+				expression.NewIn(col(0, "t", "a"), expression.NewSubquery(query())),
+				eq(col(0, "t", "a"), expression.NewSubquery(random())),
+			),
+			table,
+		),
+	)
+
+	result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), node)
+	require.NoError(err)
+
+	project, ok := result.(*plan.Project)
+	require.True(ok)
+	filter := project.Child.(*plan.Filter)
+	and := filter.Expression.(*expression.And)
+
+	// Repeated subqueries are the same expression, unless they are not
+	// deterministic.
+	require.True(project.Projections[0] == and.Left.(*expression.In).Right())
+	require.False(project.Projections[1] == and.Right.(*expression.Equals).Right())
+}
+
+func TestCacheDerivedTables(t *testing.T) {
+	require := require.New(t)
+	f := getRule("eliminate_common_subqueries")
+
+	table := plan.NewResolvedTable(memory.NewTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+	}))
+
+	query := func() sql.Node {
+		return plan.NewProject([]sql.Expression{col(0, "t", "a")}, table)
+	}
+
+	z := plan.NewSubqueryAlias("z", table)
+	node := plan.NewCrossJoin(
+		plan.NewCrossJoin(
+			plan.NewSubqueryAlias("x", plan.NewQueryProcess(query(), nil)),
+			plan.NewSubqueryAlias("y", query()),
+		),
+		z,
+	)
+
+	result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), node)
+	require.NoError(err)
+
+	r, ok := result.(*releaser)
+	require.True(ok)
+
+	join := r.Child.(*plan.CrossJoin)
+	x := join.Left.(*plan.CrossJoin).Left.(*plan.SubqueryAlias)
+	y := join.Left.(*plan.CrossJoin).Right.(*plan.SubqueryAlias)
+	require.Equal("x", x.Name())
+	require.Equal("y", y.Name())
+
+	// The cached query is not wrapped in the process of the subquery.
+	cached, ok := x.Child.(*plan.CachedResults)
+	require.True(ok)
+	require.Equal(query(), cached.Child)
+	require.True(y.Child == x.Child)
+
+	require.True(join.Right == z)
+}
@@ -73,7 +73,7 @@ func convertDates(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 				grouping[i] = gr
 			}
 
-			result = plan.NewGroupBy(aggregate, grouping, exp.Child)
+			result = plan.NewGroupBy(aggregate, grouping, exp.Child).WithGroupingSets(exp.GroupingSets)
 		case *plan.Project:
 			var projections = make([]sql.Expression, len(exp.Projections))
 			for i, e := range exp.Projections {
@@ -92,6 +92,10 @@ func convertDates(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 			}
 
 			result = plan.NewProject(projections, exp.Child)
+		case *plan.Update:
+			// The fields set are the columns of the table, so they can't be
+			// converted.
+			result = n
 		default:
 			result, err = plan.TransformExpressions(n, func(e sql.Expression) (sql.Expression, error) {
 				return addDateConvert(e, n, replacements, nodeReplacements, expressions, false)
@@ -158,11 +162,11 @@ func addDateConvert(
 			}
 		}
 
-		switch e.Type() {
-		case sql.Date:
+		switch typ := e.Type(); {
+		case typ == sql.Date:
 			result = expression.NewConvert(e, expression.ConvertToDate)
-		case sql.Timestamp:
-			result = expression.NewConvert(e, expression.ConvertToDatetime)
+		case sql.IsTimestamp(typ):
+			result = expression.NewConvertWithPrecision(e, expression.ConvertToDatetime, sql.Precision(typ))
 		default:
 			result = e
 		}
@@ -0,0 +1,435 @@
+package analyzer
+
+import (
+	"math"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+const (
+	// defaultRowCount is the number of rows assumed for tables without
+	// statistics.
+	defaultRowCount = 1000
+	// defaultSelectivity is the fraction of rows assumed to satisfy a
+	// predicate whose selectivity cannot be estimated.
+	defaultSelectivity = 1.0 / 3
+	// defaultEqualitySelectivity is the fraction of rows assumed to satisfy
+	// an equality predicate on a column without statistics.
+	defaultEqualitySelectivity = 0.1
+	// indexLookupCostFactor is the cost of reading a row using an index
+	// relative to reading it with a sequential scan of the table.
+	indexLookupCostFactor = 4
+	// estimatedColumnSize is the number of bytes each value of a row is
+	// assumed to use in memory.
+	estimatedColumnSize = 32
+)
+
+// costEstimator estimates the number of rows produced by the nodes of a
+// query and the selectivity of its predicates using the statistics of the
+// tables, if they provide them or they were collected with ANALYZE TABLE.
+type costEstimator struct {
+	ctx     *sql.Context
+	catalog *sql.Catalog
+	stats   map[string]*sql.TableStatistics
+	filters filters
+}
+
+// newCostEstimator creates a cost estimator for the tables in the given
+// node. Filters are the predicates that only involve a single table, by
+// table name. The catalog may be nil, in which case only the statistics
+// provided by the tables are used.
+func newCostEstimator(
+	ctx *sql.Context,
+	catalog *sql.Catalog,
+	n sql.Node,
+	filters filters,
+) *costEstimator {
+	e := &costEstimator{
+		ctx:     ctx,
+		catalog: catalog,
+		stats:   make(map[string]*sql.TableStatistics),
+		filters: filters,
+	}
+
+	plan.Inspect(n, func(node sql.Node) bool {
+		switch node := node.(type) {
+		case *plan.TableAlias:
+			if t, ok := node.Child.(*plan.ResolvedTable); ok {
+				e.addStatistics(node.Name(), t.Table)
+			}
+		case *plan.ResolvedTable:
+			e.addStatistics(node.Name(), node.Table)
+		}
+		return true
+	})
+
+	return e
+}
+
+func (e *costEstimator) addStatistics(name string, t sql.Table) {
+	if w, ok := t.(sql.TableWrapper); ok {
+		t = w.Underlying()
+	}
+
+	st, ok := t.(sql.StatisticsTable)
+	if !ok {
+		if e.catalog == nil {
+			return
+		}
+
+		if stats := e.catalog.TableStatistics(e.catalog.CurrentDatabase(), t.Name()); stats != nil {
+			e.stats[name] = stats
+		}
+		return
+	}
+
+	// Statistics are only a hint, so the query can still be executed with
+	// the default estimations if they cannot be retrieved.
+	stats, err := st.Statistics(e.ctx)
+	if err != nil || stats == nil {
+		return
+	}
+
+	e.stats[name] = stats
+}
+
+// hasStatistics reports whether any of the tables has statistics.
+func (e *costEstimator) hasStatistics() bool {
+	return len(e.stats) > 0
+}
+
+// tableHasStatistics reports whether the table with the given name has
+// statistics.
+func (e *costEstimator) tableHasStatistics(table string) bool {
+	_, ok := e.stats[table]
+	return ok
+}
+
+// rows returns the estimated number of rows the node will produce.
+func (e *costEstimator) rows(n sql.Node) float64 {
+	switch n := n.(type) {
+	case *plan.ResolvedTable:
+		return e.tableRows(n.Name())
+	case *plan.TableAlias:
+		if _, ok := n.Child.(*plan.ResolvedTable); ok {
+			return e.tableRows(n.Name())
+		}
+		return e.rows(n.Child)
+	case *plan.Filter:
+		// Predicates of a single table are already taken into account
+		// by the table estimation.
+		selectivity := 1.0
+		for _, expr := range splitExpression(n.Expression) {
+			if len(exprToTableFilters(expr)) == 0 {
+				selectivity *= e.selectivity(expr)
+			}
+		}
+		return e.rows(n.Child) * selectivity
+	case *plan.Limit:
+		return math.Min(e.rows(n.Child), float64(n.Limit))
+	case *plan.InnerJoin:
+		return e.rows(n.Left) * e.rows(n.Right) * e.selectivity(n.Cond)
+	case *plan.LeftJoin:
+		left := e.rows(n.Left)
+		return math.Max(left, left*e.rows(n.Right)*e.selectivity(n.Cond))
+	case *plan.RightJoin:
+		right := e.rows(n.Right)
+		return math.Max(right, e.rows(n.Left)*right*e.selectivity(n.Cond))
+	case *plan.CrossJoin:
+		return e.rows(n.Left) * e.rows(n.Right)
+	case *plan.SemiJoin:
+		return e.rows(n.Left) * math.Min(1, e.rows(n.Right)*e.selectivity(n.Cond))
+	case *plan.AntiJoin:
+		return e.rows(n.Left) * (1 - math.Min(1, e.rows(n.Right)*e.selectivity(n.Cond)))
+	case *plan.GroupBy:
+		if len(n.Grouping) == 0 {
+			return 1
+		}
+		return e.rows(n.Child)
+	}
+
+	var rows = 1.0
+	for _, child := range n.Children() {
+		rows *= e.rows(child)
+	}
+	return rows
+}
+
+func (e *costEstimator) tableRows(table string) float64 {
+	rows := float64(defaultRowCount)
+	if stats, ok := e.stats[table]; ok {
+		rows = float64(stats.RowCount)
+	}
+
+	return rows * e.tableSelectivity(table)
+}
+
+// tableSelectivity returns the estimated fraction of rows of the table that
+// satisfy all the filters of the table.
+func (e *costEstimator) tableSelectivity(table string) float64 {
+	selectivity := 1.0
+	for _, f := range e.filters[table] {
+		selectivity *= e.selectivity(f)
+	}
+	return selectivity
+}
+
+// selectivity returns the estimated fraction of rows that satisfy the given
+// predicate.
+func (e *costEstimator) selectivity(expr sql.Expression) float64 {
+	switch expr := expr.(type) {
+	case *expression.And:
+		return e.selectivity(expr.Left) * e.selectivity(expr.Right)
+	case *expression.Or:
+		left, right := e.selectivity(expr.Left), e.selectivity(expr.Right)
+		return left + right - left*right
+	case *expression.Not:
+		return 1 - e.selectivity(expr.Child)
+	case *expression.Literal:
+		if v, err := sql.Boolean.Convert(expr.Value()); err == nil && v == true {
+			return 1
+		}
+		return 0
+	case *expression.IsNull:
+		col, ok := expr.Child.(*expression.GetField)
+		if !ok {
+			return defaultEqualitySelectivity
+		}
+
+		stats, colStats := e.columnStatistics(col)
+		if colStats == nil || stats.RowCount == 0 {
+			return defaultEqualitySelectivity
+		}
+		return float64(colStats.NullCount) / float64(stats.RowCount)
+	case *expression.Equals:
+		if l, ok := expr.Left().(*expression.GetField); ok {
+			if r, ok := expr.Right().(*expression.GetField); ok {
+				return e.joinSelectivity(l, r)
+			}
+		}
+
+		col, value, ok := e.columnAndValue(expr.Left(), expr.Right())
+		if !ok {
+			return defaultEqualitySelectivity
+		}
+		return e.equalitySelectivity(col, value)
+	case *expression.In:
+		tuple, ok := expr.Right().(expression.Tuple)
+		if !ok {
+			return defaultSelectivity
+		}
+
+		var selectivity float64
+		for _, el := range tuple {
+			col, value, ok := e.columnAndValue(expr.Left(), el)
+			if !ok {
+				selectivity += defaultEqualitySelectivity
+			} else {
+				selectivity += e.equalitySelectivity(col, value)
+			}
+		}
+		return math.Min(1, selectivity)
+	case *expression.LessThan:
+		return e.rangeSelectivity(expr.Left(), expr.Right(), true)
+	case *expression.LessThanOrEqual:
+		return e.rangeSelectivity(expr.Left(), expr.Right(), true)
+	case *expression.GreaterThan:
+		return e.rangeSelectivity(expr.Left(), expr.Right(), false)
+	case *expression.GreaterThanOrEqual:
+		return e.rangeSelectivity(expr.Left(), expr.Right(), false)
+	default:
+		return defaultSelectivity
+	}
+}
+
+func (e *costEstimator) columnStatistics(
+	col *expression.GetField,
+) (*sql.TableStatistics, *sql.ColumnStatistics) {
+	stats, ok := e.stats[col.Table()]
+	if !ok {
+		return nil, nil
+	}
+	return stats, stats.Column(col.Name())
+}
+
+// columnAndValue returns the column and the value of a comparison between a
+// column and an expression that can be evaluated without a row.
+func (e *costEstimator) columnAndValue(
+	left, right sql.Expression,
+) (*expression.GetField, interface{}, bool) {
+	col, ok := left.(*expression.GetField)
+	other := right
+	if !ok {
+		col, ok = right.(*expression.GetField)
+		other = left
+	}
+
+	if !ok || !isEvaluable(other) {
+		return nil, nil, false
+	}
+
+	value, err := other.Eval(e.ctx, nil)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return col, value, true
+}
+
+func (e *costEstimator) equalitySelectivity(col *expression.GetField, value interface{}) float64 {
+	if value == nil {
+		return 0
+	}
+
+	stats, colStats := e.columnStatistics(col)
+	if colStats == nil || stats.RowCount == 0 {
+		return defaultEqualitySelectivity
+	}
+
+	for _, b := range colStats.Histogram {
+		cmp, err := col.Type().Compare(value, b.UpperBound)
+		if err != nil {
+			break
+		}
+
+		if cmp <= 0 {
+			if b.DistinctCount == 0 {
+				return 0
+			}
+			return float64(b.RowCount) / float64(b.DistinctCount) / float64(stats.RowCount)
+		}
+	}
+
+	if len(colStats.Histogram) > 0 {
+		// The value is greater than any value in the table.
+		return 0
+	}
+
+	if colStats.DistinctCount == 0 {
+		return defaultEqualitySelectivity
+	}
+
+	nonNull := float64(stats.RowCount-colStats.NullCount) / float64(stats.RowCount)
+	return nonNull / float64(colStats.DistinctCount)
+}
+
+// joinSelectivity returns the estimated selectivity of an equality between
+// two columns, which is the inverse of the greatest cardinality of both
+// columns.
+func (e *costEstimator) joinSelectivity(left, right *expression.GetField) float64 {
+	var distinct uint64
+	for _, col := range []*expression.GetField{left, right} {
+		if _, colStats := e.columnStatistics(col); colStats != nil && colStats.DistinctCount > distinct {
+			distinct = colStats.DistinctCount
+		}
+	}
+
+	if distinct == 0 {
+		return defaultEqualitySelectivity
+	}
+	return 1 / float64(distinct)
+}
+
+// rangeSelectivity returns the estimated selectivity of a comparison
+// between the left and right expressions. If less is true, the comparison
+// is left < right, otherwise it is left > right.
+func (e *costEstimator) rangeSelectivity(left, right sql.Expression, less bool) float64 {
+	col, value, ok := e.columnAndValue(left, right)
+	if !ok {
+		return defaultSelectivity
+	}
+
+	if _, isCol := left.(*expression.GetField); !isCol {
+		// The column is on the right side, so the comparison is reversed.
+		less = !less
+	}
+
+	if value == nil {
+		return 0
+	}
+
+	stats, colStats := e.columnStatistics(col)
+	if colStats == nil || len(colStats.Histogram) == 0 || stats.RowCount == 0 {
+		return defaultSelectivity
+	}
+
+	// Count the rows in the buckets below the value and half of the rows
+	// in the bucket containing the value, as nothing is known about the
+	// distribution inside a bucket.
+	var below float64
+	for _, b := range colStats.Histogram {
+		cmp, err := col.Type().Compare(value, b.UpperBound)
+		if err != nil {
+			return defaultSelectivity
+		}
+
+		if cmp <= 0 {
+			below += float64(b.RowCount) / 2
+			break
+		}
+		below += float64(b.RowCount)
+	}
+
+	total := float64(stats.RowCount)
+	nonNull := total - float64(colStats.NullCount)
+	below = math.Min(below, nonNull)
+	if less {
+		return below / total
+	}
+	return (nonNull - below) / total
+}
+
+// indexLookupIsCheaper reports whether reading the rows of the table that
+// satisfy its filters using an index is expected to be cheaper than
+// scanning the whole table. Without statistics, or if the index is forced
+// with the hints of the query, indexes are always assumed to be cheaper.
+func (e *costEstimator) indexLookupIsCheaper(table string) bool {
+	if !e.tableHasStatistics(table) || e.ctx.Hints().IndexForced(table) {
+		return true
+	}
+
+	return e.tableSelectivity(table)*indexLookupCostFactor < 1
+}
+
+// fitsInMemory reports whether the estimated rows of the node can be kept
+// in memory.
+func (e *costEstimator) fitsInMemory(n sql.Node, rows float64) bool {
+	size := rows * float64(len(n.Schema())*estimatedColumnSize)
+	return size <= float64(e.ctx.Memory.Available())
+}
+
+// indexJoinIsCheaper reports whether looking up the rows of the secondary
+// side of a join in an index for every row of the primary side is expected
+// to be cheaper than a nested loop join. Without statistics, or if the index
+// is forced with the hints of the query, index lookups are always assumed
+// to be cheaper.
+func (e *costEstimator) indexJoinIsCheaper(primary, secondary sql.Node) bool {
+	if !e.hasStatistics() {
+		return true
+	}
+
+	if t, ok := secondary.(sql.Nameable); ok && e.ctx.Hints().IndexForced(t.Name()) {
+		return true
+	}
+
+	primaryRows, secondaryRows := e.rows(primary), e.rows(secondary)
+	cost := nestedLoopJoinCost(primaryRows, secondaryRows, e.fitsInMemory(secondary, secondaryRows))
+	return indexJoinCost(primaryRows) < cost
+}
+
+// indexJoinCost returns the estimated cost of a join that looks up the
+// rows of the secondary side in an index for every row of the primary side.
+func indexJoinCost(primary float64) float64 {
+	return primary + primary*indexLookupCostFactor
+}
+
+// nestedLoopJoinCost returns the estimated cost of a nested loop join, as
+// the number of rows read from both sides.
+func nestedLoopJoinCost(primary, secondary float64, inMemory bool) float64 {
+	if inMemory {
+		return primary + secondary
+	}
+	return primary + primary*secondary
+}
@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+type statisticsTable struct {
+	*memory.Table
+	stats *sql.TableStatistics
+}
+
+func newStatisticsTable(name string, stats *sql.TableStatistics, columns ...string) *statisticsTable {
+	var schema sql.Schema
+	for _, c := range columns {
+		schema = append(schema, &sql.Column{Name: c, Type: sql.Int64, Source: name})
+	}
+	return &statisticsTable{memory.NewTable(name, schema), stats}
+}
+
+func (t *statisticsTable) Statistics(*sql.Context) (*sql.TableStatistics, error) {
+	return t.stats, nil
+}
+
+func TestCostEstimatorRows(t *testing.T) {
+	stats := &sql.TableStatistics{
+		RowCount: 100,
+		Columns: map[string]*sql.ColumnStatistics{
+			"a": {
+				DistinctCount: 4,
+				NullCount:     20,
+				Histogram: sql.Histogram{
+					{UpperBound: int64(10), RowCount: 40, DistinctCount: 2},
+					{UpperBound: int64(20), RowCount: 40, DistinctCount: 2},
+				},
+			},
+			"b": {DistinctCount: 10},
+		},
+	}
+
+	table := plan.NewResolvedTable(newStatisticsTable("t", stats, "a", "b"))
+	other := plan.NewResolvedTable(memory.NewTable("other", nil))
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected float64
+	}{
+		{"table", table, 100},
+		{"table without statistics", other, defaultRowCount},
+		{"equality in histogram", plan.NewFilter(eq(col(0, "t", "a"), lit(15)), table), 20},
+		{"equality out of histogram", plan.NewFilter(eq(col(0, "t", "a"), lit(25)), table), 0},
+		{"equality with cardinality", plan.NewFilter(eq(lit(3), col(1, "t", "b")), table), 10},
+		{"equality without statistics", plan.NewFilter(eq(col(0, "other", "c"), lit(3)), other), 100},
+		{"less than", plan.NewFilter(lt(col(0, "t", "a"), lit(15)), table), 60},
+		{"greater than", plan.NewFilter(gt(col(0, "t", "a"), lit(15)), table), 20},
+		{"reversed greater than", plan.NewFilter(gt(lit(15), col(0, "t", "a")), table), 60},
+		{"is null", plan.NewFilter(expression.NewIsNull(col(0, "t", "a")), table), 20},
+		{"and", plan.NewFilter(and(eq(col(0, "t", "a"), lit(15)), eq(col(1, "t", "b"), lit(1))), table), 2},
+		{"or", plan.NewFilter(or(eq(col(1, "t", "b"), lit(1)), eq(col(1, "t", "b"), lit(2))), table), 19},
+		{"not", plan.NewFilter(not(eq(col(1, "t", "b"), lit(1))), table), 90},
+		{"in", plan.NewFilter(expression.NewIn(col(1, "t", "b"), expression.NewTuple(lit(1), lit(2))), table), 20},
+		{"limit", plan.NewLimit(5, table), 5},
+		{"cross join", plan.NewCrossJoin(table, other), 100 * defaultRowCount},
+		{"inner join", plan.NewInnerJoin(table, table, eq(col(1, "t", "b"), col(1, "t", "b"))), 1000},
+		{"group by without grouping", plan.NewGroupBy(nil, nil, table), 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			costs := newCostEstimator(ctx, nil, tt.node, findFilters(ctx, tt.node))
+			require.InDelta(t, tt.expected, costs.rows(tt.node), 0.001)
+		})
+	}
+}
+
+func TestIndexLookupIsCheaper(t *testing.T) {
+	require := require.New(t)
+
+	stats := &sql.TableStatistics{
+		RowCount: 100,
+		Columns: map[string]*sql.ColumnStatistics{
+			"a": {DistinctCount: 2},
+			"b": {DistinctCount: 100},
+		},
+	}
+	table := plan.NewResolvedTable(newStatisticsTable("t", stats, "a", "b"))
+
+	ctx := sql.NewEmptyContext()
+	node := plan.NewFilter(eq(col(0, "t", "a"), lit(1)), table)
+	costs := newCostEstimator(ctx, nil, node, findFilters(ctx, node))
+	require.False(costs.indexLookupIsCheaper("t"))
+
+	hints := &sql.QueryHints{}
+	hints.AddIndexHint("t", sql.IndexHint{Type: sql.ForceIndexHint, Indexes: []string{"idx"}})
+	forced := ctx.WithHints(hints)
+	costs = newCostEstimator(forced, nil, node, findFilters(forced, node))
+	require.True(costs.indexLookupIsCheaper("t"))
+
+	node = plan.NewFilter(eq(col(1, "t", "b"), lit(1)), table)
+	costs = newCostEstimator(ctx, nil, node, findFilters(ctx, node))
+	require.True(costs.indexLookupIsCheaper("t"))
+
+	node = plan.NewFilter(
+		eq(col(0, "other", "a"), lit(1)),
+		plan.NewResolvedTable(memory.NewTable("other", nil)),
+	)
+	costs = newCostEstimator(ctx, nil, node, findFilters(ctx, node))
+	require.True(costs.indexLookupIsCheaper("other"))
+}
+
+func TestCostEstimatorCatalogStatistics(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.SetCurrentDatabase("db")
+	catalog.SetTableStatistics("db", "t", &sql.TableStatistics{RowCount: 42})
+
+	ctx := sql.NewEmptyContext()
+	node := plan.NewResolvedTable(memory.NewTable("t", nil))
+	costs := newCostEstimator(ctx, catalog, node, nil)
+	require.True(costs.hasStatistics())
+	require.Equal(float64(42), costs.rows(node))
+}
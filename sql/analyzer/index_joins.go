@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// indexJoins replaces the inner joins whose secondary side is a table with
+// an index on the columns compared in the join condition with indexed
+// joins, which look up the matching rows of the table in the index for
+// every row of the primary side instead of iterating all of them.
+func indexJoins(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("index_joins")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	switch n.(type) {
+	case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.CreateIndex, *plan.LoadData:
+		return n, nil
+	}
+
+	a.Log("index joins, node of type: %T", n)
+
+	costs := newCostEstimator(ctx, a.Catalog, n, findFilters(ctx, n))
+
+	var indexes []sql.Index
+	node, err := plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		j, ok := node.(*plan.InnerJoin)
+		if !ok {
+			return node, nil
+		}
+
+		join, idx := indexJoin(a, ctx.Hints(), costs, j)
+		if idx != nil {
+			indexes = append(indexes, idx)
+		}
+		return join, nil
+	})
+
+	release := func() {
+		for _, idx := range indexes {
+			a.Catalog.ReleaseIndex(idx)
+		}
+	}
+
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	if len(indexes) > 0 {
+		return &releaser{node, release}, nil
+	}
+
+	return node, nil
+}
+
+// indexJoin returns an indexed join equivalent to the given join and the
+// index it uses, or the same join and a nil index if no index can be used.
+func indexJoin(
+	a *Analyzer,
+	hints *sql.QueryHints,
+	costs *costEstimator,
+	j *plan.InnerJoin,
+) (sql.Node, sql.Index) {
+	rt, ok := j.Right.(*plan.ResolvedTable)
+	if !ok {
+		return j, nil
+	}
+
+	table, ok := rt.Table.(sql.IndexableTable)
+	if !ok || table.IndexLookup() != nil {
+		return j, nil
+	}
+
+	var primaryTables = make(map[string]struct{})
+	for _, col := range j.Left.Schema() {
+		primaryTables[col.Source] = struct{}{}
+	}
+
+	var columns []sql.Expression
+	var keys = make(map[string]sql.Expression)
+	for _, cond := range splitExpression(j.Cond) {
+		eq, ok := cond.(*expression.Equals)
+		if !ok {
+			continue
+		}
+
+		col, key, ok := indexJoinKey(rt.Name(), primaryTables, eq.Left(), eq.Right())
+		if !ok {
+			col, key, ok = indexJoinKey(rt.Name(), primaryTables, eq.Right(), eq.Left())
+		}
+
+		if !ok {
+			continue
+		}
+
+		if _, ok := keys[col.String()]; !ok {
+			columns = append(columns, col)
+			keys[col.String()] = key
+		}
+	}
+
+	if len(columns) == 0 {
+		return j, nil
+	}
+
+	if !costs.indexJoinIsCheaper(j.Left, j.Right) {
+		a.Log("table %q will be joined without index, as it's cheaper", rt.Name())
+		return j, nil
+	}
+
+	idx := a.Catalog.IndexByExpression(a.Catalog.CurrentDatabase(), columns...)
+	if idx == nil {
+		return j, nil
+	}
+
+	if !hints.IndexAllowed(idx.Table(), idx.ID()) {
+		a.Log("index %q of table %q not used because of the optimizer hints", idx.ID(), idx.Table())
+		a.Catalog.ReleaseIndex(idx)
+		return j, nil
+	}
+
+	// The keys to look up must be in the same order as the expressions of
+	// the index.
+	var keyExprs []sql.Expression
+	for _, e := range idx.Expressions() {
+		key, ok := keys[e]
+		if !ok || idx.Table() != rt.Name() {
+			a.Catalog.ReleaseIndex(idx)
+			return j, nil
+		}
+		keyExprs = append(keyExprs, key)
+	}
+
+	a.Log("join with table %q transformed into an indexed join using index %q", rt.Name(), idx.ID())
+
+	// The primary side goes first in the rows of the join, so the keys and
+	// the condition don't need to be changed.
+	return plan.NewIndexedJoin(j.Left, table, j.Cond, idx, keyExprs), idx
+}
+
+// indexJoinKey returns the column of the given table and the key expression
+// to look up in an index of the column, if the column is compared with an
+// expression that only uses columns of the primary tables.
+func indexJoinKey(
+	table string,
+	primaryTables map[string]struct{},
+	col, key sql.Expression,
+) (*expression.GetField, sql.Expression, bool) {
+	gf, ok := col.(*expression.GetField)
+	if !ok || gf.Table() != table {
+		return nil, nil, false
+	}
+
+	// Keys are not converted, so they must have the type of the column to
+	// be found in the index.
+	if gf.Type().Type() != key.Type().Type() {
+		return nil, nil, false
+	}
+
+	tables := expressionTables(key)
+	if len(tables) == 0 || !containsAll(primaryTables, nil, tables) {
+		return nil, nil, false
+	}
+
+	return gf, key, true
+}
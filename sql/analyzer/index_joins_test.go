@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexJoins(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	idx := &dummyIndex{"t2", []sql.Expression{col(0, "t2", "bar")}}
+	done, ready, err := catalog.AddIndex(idx)
+	require.NoError(err)
+	close(done)
+	<-ready
+
+	a := NewDefault(catalog)
+	f := getRule("index_joins")
+
+	t1 := memory.NewTable("t1", sql.Schema{
+		{Name: "foo", Type: sql.Int64, Source: "t1"},
+	})
+	t2 := memory.NewTable("t2", sql.Schema{
+		{Name: "bar", Type: sql.Int64, Source: "t2"},
+		{Name: "baz", Type: sql.Int64, Source: "t2"},
+	})
+
+	cond := and(
+		eq(col(0, "t1", "foo"), col(1, "t2", "bar")),
+		gt(col(2, "t2", "baz"), lit(1)),
+	)
+	node := plan.NewProject(
+		[]sql.Expression{col(0, "t1", "foo")},
+		plan.NewInnerJoin(plan.NewResolvedTable(t1), plan.NewResolvedTable(t2), cond),
+	)
+
+	result, err := f.Apply(sql.NewEmptyContext(), a, node)
+	require.NoError(err)
+
+	r, ok := result.(*releaser)
+	require.True(ok)
+
+	expected := plan.NewProject(
+		[]sql.Expression{col(0, "t1", "foo")},
+		plan.NewIndexedJoin(
+			plan.NewResolvedTable(t1),
+			t2,
+			cond,
+			idx,
+			[]sql.Expression{col(0, "t1", "foo")},
+		),
+	)
+	require.Equal(expected, r.Child)
+}
+
+func TestIndexJoinsUnchanged(t *testing.T) {
+	catalog := sql.NewCatalog()
+	idx := &dummyIndex{"t2", []sql.Expression{col(0, "t2", "bar")}}
+	done, ready, err := catalog.AddIndex(idx)
+	require.NoError(t, err)
+	close(done)
+	<-ready
+
+	t1 := plan.NewResolvedTable(memory.NewTable("t1", sql.Schema{
+		{Name: "foo", Type: sql.Int64, Source: "t1"},
+	}))
+	t2 := plan.NewResolvedTable(memory.NewTable("t2", sql.Schema{
+		{Name: "bar", Type: sql.Int64, Source: "t2"},
+		{Name: "qux", Type: sql.Text, Source: "t2"},
+	}))
+
+	testCases := []struct {
+		name string
+		node sql.Node
+	}{
+		{
+			"no index on the column",
+			plan.NewInnerJoin(t2, t1, eq(col(0, "t2", "bar"), col(2, "t1", "foo"))),
+		},
+		{
+			"not an equality",
+			plan.NewInnerJoin(t1, t2, lt(col(0, "t1", "foo"), col(1, "t2", "bar"))),
+		},
+		{
+			"key is a constant",
+			plan.NewInnerJoin(t1, t2, eq(col(1, "t2", "bar"), lit(1))),
+		},
+		{
+			"different types",
+			plan.NewInnerJoin(t1, t2, eq(
+				col(0, "t1", "foo"),
+				expression.NewGetFieldWithTable(1, sql.Text, "t2", "bar", false),
+			)),
+		},
+		{
+			"index not allowed by the hints",
+			plan.NewInnerJoin(t1, t2, eq(col(0, "t1", "foo"), col(1, "t2", "bar"))),
+		},
+		{
+			"secondary side is not a table",
+			plan.NewInnerJoin(
+				t1,
+				plan.NewFilter(eq(col(0, "t2", "bar"), lit(1)), t2),
+				eq(col(0, "t1", "foo"), col(1, "t2", "bar")),
+			),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := sql.NewEmptyContext().WithHints(&sql.QueryHints{
+				NoIndex: map[string][]string{"t2": {"t2.bar"}},
+			})
+			result, err := getRule("index_joins").Apply(ctx, NewDefault(catalog), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.node, result)
+		})
+	}
+}
@@ -416,16 +416,27 @@ func evalFilter(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, error)
 
 func isFalse(e sql.Expression) bool {
 	lit, ok := e.(*expression.Literal)
-	return ok &&
-		lit.Type() == sql.Boolean &&
-		!lit.Value().(bool)
+	if !ok || lit.Type() != sql.Boolean {
+		return false
+	}
+
+	v, ok := lit.Value().(bool)
+	return ok && !v
 }
 
 func isTrue(e sql.Expression) bool {
 	lit, ok := e.(*expression.Literal)
-	return ok &&
-		lit.Type() == sql.Boolean &&
-		lit.Value().(bool)
+	if !ok || lit.Type() != sql.Boolean {
+		return false
+	}
+
+	v, ok := lit.Value().(bool)
+	return ok && v
+}
+
+func isNull(e sql.Expression) bool {
+	lit, ok := e.(*expression.Literal)
+	return ok && lit.Value() == nil
 }
 
 // hasNaturalJoin checks whether there is a natural join at some point in the
@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// optimizeJoins uses the estimated cost of the joins to decide which side of
+// each inner join is iterated once and which one is iterated for every row
+// of the other, and whether that side is kept in memory or not. Joins are
+// only optimized if there are statistics for any of the tables; otherwise
+// the decision is left to the execution of the join.
+func optimizeJoins(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("optimize_joins")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	costs := newCostEstimator(ctx, a.Catalog, n, findFilters(ctx, n))
+	if !costs.hasStatistics() {
+		return n, nil
+	}
+
+	a.Log("optimizing joins, node of type: %T", n)
+
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		switch j := node.(type) {
+		case *plan.InnerJoin:
+			return optimizeInnerJoin(a, costs, j)
+		case *plan.LeftJoin:
+			nj := *j
+			nj.Strategy = joinStrategy(costs, j.Right)
+			return &nj, nil
+		case *plan.RightJoin:
+			nj := *j
+			nj.Strategy = joinStrategy(costs, j.Left)
+			return &nj, nil
+		default:
+			return node, nil
+		}
+	})
+}
+
+func optimizeInnerJoin(a *Analyzer, costs *costEstimator, j *plan.InnerJoin) (sql.Node, error) {
+	left, right := costs.rows(j.Left), costs.rows(j.Right)
+	cost := nestedLoopJoinCost(left, right, costs.fitsInMemory(j.Right, right))
+	swappedCost := nestedLoopJoinCost(right, left, costs.fitsInMemory(j.Left, left))
+
+	if swappedCost >= cost {
+		nj := *j
+		nj.Strategy = joinStrategy(costs, j.Right)
+		return &nj, nil
+	}
+
+	a.Log("swapping sides of join, estimated cost %v is lower than %v", swappedCost, cost)
+
+	swapped := plan.NewInnerJoin(j.Right, j.Left, j.Cond)
+	cond, err := fixFieldIndexes(swapped.Schema(), j.Cond)
+	if err != nil {
+		if ErrFieldMissing.Is(err) {
+			// The condition cannot be rewritten for the new schema, so
+			// the join is left untouched.
+			return j, nil
+		}
+		return nil, err
+	}
+
+	swapped.Cond = cond
+	swapped.Strategy = joinStrategy(costs, j.Left)
+
+	// Columns are projected in the original order, so nodes on top of the
+	// join don't need to be changed.
+	var (
+		leftLen  = len(j.Left.Schema())
+		rightLen = len(j.Right.Schema())
+		fields   = make([]sql.Expression, 0, leftLen+rightLen)
+	)
+	for i, col := range j.Schema() {
+		idx := i - leftLen
+		if i < leftLen {
+			idx = rightLen + i
+		}
+
+		fields = append(fields, expression.NewGetFieldWithTable(
+			idx,
+			col.Type,
+			col.Source,
+			col.Name,
+			col.Nullable,
+		))
+	}
+
+	return plan.NewProject(fields, swapped), nil
+}
+
+// joinStrategy returns the strategy to use in a join given its secondary
+// side, which is the one iterated once for every row of the primary side.
+func joinStrategy(costs *costEstimator, secondary sql.Node) plan.JoinStrategy {
+	if costs.fitsInMemory(secondary, costs.rows(secondary)) {
+		return plan.InMemoryJoinStrategy
+	}
+	return plan.MultipassJoinStrategy
+}
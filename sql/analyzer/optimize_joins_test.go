@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedMemoryReporter struct {
+	used, max uint64
+}
+
+func (r fixedMemoryReporter) UsedMemory() uint64 { return r.used }
+func (r fixedMemoryReporter) MaxMemory() uint64  { return r.max }
+
+func TestOptimizeJoins(t *testing.T) {
+	f := getRule("optimize_joins")
+
+	// Only the small table fits in memory.
+	ctx := sql.NewContext(context.TODO(), sql.WithMemoryManager(
+		sql.NewMemoryManager(fixedMemoryReporter{0, 10000}),
+	))
+
+	big := plan.NewResolvedTable(newStatisticsTable("big", &sql.TableStatistics{RowCount: 1000}, "a"))
+	small := plan.NewResolvedTable(newStatisticsTable("small", &sql.TableStatistics{RowCount: 100}, "b"))
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected sql.Node
+	}{
+		{
+			"small table is already the secondary side",
+			plan.NewInnerJoin(big, small, eq(col(0, "big", "a"), col(1, "small", "b"))),
+			&plan.InnerJoin{
+				BinaryNode: plan.BinaryNode{Left: big, Right: small},
+				Cond:       eq(col(0, "big", "a"), col(1, "small", "b")),
+				Strategy:   plan.InMemoryJoinStrategy,
+			},
+		},
+		{
+			"sides are swapped",
+			plan.NewInnerJoin(small, big, eq(col(0, "small", "b"), col(1, "big", "a"))),
+			plan.NewProject(
+				[]sql.Expression{
+					expression.NewGetFieldWithTable(1, sql.Int64, "small", "b", false),
+					expression.NewGetFieldWithTable(0, sql.Int64, "big", "a", false),
+				},
+				&plan.InnerJoin{
+					BinaryNode: plan.BinaryNode{Left: big, Right: small},
+					Cond:       eq(col(1, "small", "b"), col(0, "big", "a")),
+					Strategy:   plan.InMemoryJoinStrategy,
+				},
+			),
+		},
+		{
+			"left join does not fit in memory",
+			plan.NewLeftJoin(small, big, eq(col(0, "small", "b"), col(1, "big", "a"))),
+			&plan.LeftJoin{
+				BinaryNode: plan.BinaryNode{Left: small, Right: big},
+				Cond:       eq(col(0, "small", "b"), col(1, "big", "a")),
+				Strategy:   plan.MultipassJoinStrategy,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.Apply(ctx, NewDefault(nil), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestOptimizeJoinsWithoutStatistics(t *testing.T) {
+	f := getRule("optimize_joins")
+
+	node := plan.NewInnerJoin(
+		plan.NewResolvedTable(memory.NewTable("a", nil)),
+		plan.NewResolvedTable(memory.NewTable("b", nil)),
+		expression.NewLiteral(true, sql.Boolean),
+	)
+
+	result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), node)
+	require.NoError(t, err)
+	require.Equal(t, node, result)
+}
@@ -24,8 +24,34 @@ func shouldParallelize(node sql.Node) bool {
 	}
 }
 
+// parallelismSessionVar is the session variable that overrides the
+// parallelism level of the analyzer for the queries of the session.
+const parallelismSessionVar = "parallelism"
+
+// parallelismFor returns the number of partitions of the tables that will
+// be iterated concurrently in the query, which is set with the PARALLEL
+// hint of the query, the parallelism session variable or the parallelism
+// of the analyzer, in that order of precedence.
+func parallelismFor(ctx *sql.Context, a *Analyzer) int {
+	if h := ctx.Hints(); h != nil && h.Parallelism > 0 {
+		return h.Parallelism
+	}
+
+	_, val := ctx.Get(parallelismSessionVar)
+	if val == nil {
+		return a.Parallelism
+	}
+
+	n, err := sql.Int64.Convert(val)
+	if err != nil || n.(int64) <= 0 {
+		return a.Parallelism
+	}
+	return int(n.(int64))
+}
+
 func parallelize(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, error) {
-	if a.Parallelism <= 1 || !node.Resolved() {
+	parallelism := parallelismFor(ctx, a)
+	if parallelism <= 1 || !node.Resolved() {
 		return node, nil
 	}
 
@@ -38,9 +64,9 @@ func parallelize(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, error)
 		if !isParallelizable(node) {
 			return node, nil
 		}
-		ParallelQueryCounter.With("parallelism", strconv.Itoa(a.Parallelism)).Add(1)
+		ParallelQueryCounter.With("parallelism", strconv.Itoa(parallelism)).Add(1)
 
-		return plan.NewExchange(a.Parallelism, node), nil
+		return plan.NewExchange(parallelism, node), nil
 	})
 
 	if err != nil {
@@ -55,6 +55,40 @@ func TestParallelize(t *testing.T) {
 	require.Equal(expected, result)
 }
 
+func TestParallelizeSessionParallelism(t *testing.T) {
+	require := require.New(t)
+	table := memory.NewTable("t", nil)
+	rule := getRuleFrom(OnceAfterAll, "parallelize")
+	node := plan.NewProject(nil, plan.NewResolvedTable(table))
+
+	ctx := sql.NewEmptyContext()
+	ctx.Set(parallelismSessionVar, sql.Int64, int64(4))
+
+	result, err := rule.Apply(ctx, &Analyzer{Parallelism: 1}, node)
+	require.NoError(err)
+	require.Equal(plan.NewExchange(4, node), result)
+
+	ctx.Set(parallelismSessionVar, sql.Int64, int64(1))
+
+	result, err = rule.Apply(ctx, &Analyzer{Parallelism: 2}, node)
+	require.NoError(err)
+	require.Equal(node, result)
+}
+
+func TestParallelizeHints(t *testing.T) {
+	require := require.New(t)
+	table := memory.NewTable("t", nil)
+	rule := getRuleFrom(OnceAfterAll, "parallelize")
+	node := plan.NewProject(nil, plan.NewResolvedTable(table))
+
+	ctx := sql.NewEmptyContext().WithHints(&sql.QueryHints{Parallelism: 3})
+	ctx.Set(parallelismSessionVar, sql.Int64, int64(4))
+
+	result, err := rule.Apply(ctx, &Analyzer{Parallelism: 2}, node)
+	require.NoError(err)
+	require.Equal(plan.NewExchange(3, node), result)
+}
+
 func TestParallelizeCreateIndex(t *testing.T) {
 	require := require.New(t)
 	table := memory.NewTable("t", nil)
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// checkPrivileges checks that the user running the query has been granted
+// the privileges needed to run it, if the catalog has a privilege store.
+// It runs before the tables are resolved, as their databases are only known
+// by the unresolved tables.
+func checkPrivileges(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	store := a.Catalog.Privileges()
+	if store == nil {
+		return n, nil
+	}
+
+	span, _ := ctx.Span("check_privileges")
+	defer span.Finish()
+
+	user := ctx.Client().User
+	if s, ok := n.(*plan.ShowGrants); ok && s.User != "" && s.User != user {
+		// Only the users that can grant privileges on everything can see
+		// the privileges of others.
+		err := checkAccess(store, user, sql.TableAccess{
+			Privileges: sql.GrantOptionPrivilege,
+			Database:   "*",
+			Table:      "*",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, access := range plan.TableAccesses(ctx, a.Catalog, n) {
+		if err := checkAccess(store, user, access); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+func checkAccess(store sql.PrivilegeStore, user string, access sql.TableAccess) error {
+	// Everyone can read the information schema, which describes the catalog.
+	if access.Privileges == sql.SelectPrivilege &&
+		strings.EqualFold(access.Database, sql.InformationSchemaDatabaseName) {
+		return nil
+	}
+
+	ok, err := sql.HasPrivileges(store, user, access.Database, access.Table, access.Privileges)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return sql.ErrPrivilegeCheckFailed.New(
+			access.Privileges,
+			user,
+			fmt.Sprintf("table '%s.%s'", access.Database, access.Table),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPrivileges(t *testing.T) {
+	store := sql.NewMemoryPrivilegeStore()
+	require.NoError(t, store.Grant("user", sql.PrivilegeLevel{Database: "mydb", Table: "*"}, sql.SelectPrivilege))
+	require.NoError(t, store.Grant("user", sql.PrivilegeLevel{Database: "mydb", Table: "t1"}, sql.InsertPrivilege|sql.GrantOptionPrivilege))
+	require.NoError(t, store.Grant("user", sql.PrivilegeLevel{Database: "other", Table: "t3"}, sql.DeletePrivilege))
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(sql.UnresolvedDatabase("mydb"))
+	catalog.SetPrivileges(store)
+	a := NewDefault(catalog)
+
+	rule := getRule("check_privileges")
+
+	subquery := func(n sql.Node) sql.Expression {
+		return expression.NewIn(lit(1), expression.NewSubquery(n))
+	}
+
+	testCases := []struct {
+		name    string
+		node    sql.Node
+		allowed bool
+	}{
+		{
+			"select",
+			plan.NewProject(nil, plan.NewUnresolvedTable("t1", "")),
+			true,
+		},
+		{
+			"select from another database",
+			plan.NewProject(nil, plan.NewUnresolvedTable("t3", "other")),
+			false,
+		},
+		{
+			"select from a subquery",
+			plan.NewFilter(
+				subquery(plan.NewUnresolvedTable("t3", "other")),
+				plan.NewUnresolvedTable("t1", ""),
+			),
+			false,
+		},
+		{
+			"select from a derived table",
+			plan.NewSubqueryAlias("x", plan.NewUnresolvedTable("t1", "mydb")),
+			true,
+		},
+		{
+			"select from the information schema",
+			plan.NewUnresolvedTable("tables", "information_schema"),
+			true,
+		},
+		{
+			"insert",
+			plan.NewInsertInto(
+				plan.NewUnresolvedTable("t1", ""),
+				plan.NewUnresolvedTable("t2", ""),
+				false, nil,
+			),
+			true,
+		},
+		{
+			"insert without privileges",
+			plan.NewInsertInto(
+				plan.NewUnresolvedTable("t2", ""),
+				plan.NewUnresolvedTable("t1", ""),
+				false, nil,
+			),
+			false,
+		},
+		{
+			"delete",
+			plan.NewDeleteFrom(plan.NewUnresolvedTable("t3", "other")),
+			true,
+		},
+		{
+			"delete without privileges",
+			plan.NewDeleteFrom(plan.NewUnresolvedTable("t1", "")),
+			false,
+		},
+		{
+			"drop table",
+			plan.NewDropTable(sql.UnresolvedDatabase(""), false, "t1"),
+			false,
+		},
+		{
+			"grant own privileges",
+			plan.NewGrant(sql.InsertPrivilege, sql.PrivilegeLevel{Table: "t1"}, "other"),
+			true,
+		},
+		{
+			"grant other privileges",
+			plan.NewGrant(sql.DeletePrivilege, sql.PrivilegeLevel{Table: "t1"}, "other"),
+			false,
+		},
+		{
+			"grant on the whole database",
+			plan.NewGrant(sql.InsertPrivilege, sql.PrivilegeLevel{Table: "*"}, "other"),
+			false,
+		},
+		{
+			"show own grants",
+			plan.NewShowGrants("user"),
+			true,
+		},
+		{
+			"show grants of others",
+			plan.NewShowGrants("other"),
+			false,
+		},
+	}
+
+	session := sql.NewSession("", "", "user", 1)
+	ctx := sql.NewContext(context.Background(), sql.WithSession(session))
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := rule.Apply(ctx, a, tt.node)
+			if tt.allowed {
+				require.NoError(err)
+				require.Equal(tt.node, result)
+			} else {
+				require.Error(err)
+				require.True(sql.ErrPrivilegeCheckFailed.Is(err))
+			}
+		})
+	}
+
+	catalog.SetPrivileges(nil)
+	result, err := rule.Apply(ctx, a, plan.NewDeleteFrom(plan.NewUnresolvedTable("t1", "")))
+	require.NoError(t, err)
+	require.NotNil(t, result)
+}
@@ -27,34 +27,40 @@ func trackProcess(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 				return n, nil
 			}
 
-			name := n.Table.Name()
-			if _, ok := seen[name]; ok {
-				return n, nil
+			// Every table counts the rows examined by the query, but
+			// the progress is only reported once for each name.
+			var onPartitionDone, onPartitionStart plan.NamedNotifyFunc
+			onRowNext := func(string) {
+				ctx.AddRowsExamined(1)
 			}
 
-			var total int64 = -1
-			if counter, ok := n.Table.(sql.PartitionCounter); ok {
-				count, err := counter.PartitionCount(ctx)
-				if err != nil {
-					return nil, err
+			name := n.Table.Name()
+			if _, ok := seen[name]; !ok {
+				var total int64 = -1
+				if counter, ok := n.Table.(sql.PartitionCounter); ok {
+					count, err := counter.PartitionCount(ctx)
+					if err != nil {
+						return nil, err
+					}
+					total = count
 				}
-				total = count
-			}
-			processList.AddTableProgress(ctx.Pid(), name, total)
+				processList.AddTableProgress(ctx.Pid(), name, total)
 
-			seen[name] = struct{}{}
+				seen[name] = struct{}{}
 
-			onPartitionDone := func(partitionName string) {
-				processList.UpdateTableProgress(ctx.Pid(), name, 1)
-				processList.RemovePartitionProgress(ctx.Pid(), name, partitionName)
-			}
+				onPartitionDone = func(partitionName string) {
+					processList.UpdateTableProgress(ctx.Pid(), name, 1)
+					processList.RemovePartitionProgress(ctx.Pid(), name, partitionName)
+				}
 
-			onPartitionStart := func(partitionName string) {
-				processList.AddPartitionProgress(ctx.Pid(), name, partitionName, -1)
-			}
+				onPartitionStart = func(partitionName string) {
+					processList.AddPartitionProgress(ctx.Pid(), name, partitionName, -1)
+				}
 
-			onRowNext := func(partitionName string) {
-				processList.UpdatePartitionProgress(ctx.Pid(), name, partitionName, 1)
+				onRowNext = func(partitionName string) {
+					ctx.AddRowsExamined(1)
+					processList.UpdatePartitionProgress(ctx.Pid(), name, partitionName, 1)
+				}
 			}
 
 			var t sql.Table
@@ -25,6 +25,17 @@ func pruneColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 		return plan.NewDescribeQuery(describe.Format, pruned), nil
 	}
 
+	// The columns of the query written to a file are the ones of its rows,
+	// not the ones of the node.
+	if into, ok := n.(*plan.SelectIntoFile); ok {
+		pruned, err := pruneColumns(ctx, a, into.Child)
+		if err != nil {
+			return nil, err
+		}
+
+		return into.WithChildren(pruned)
+	}
+
 	columns := make(usedColumns)
 
 	// All the columns required for the output of the query must be mark as
@@ -114,6 +125,15 @@ func findUsedColumns(columns usedColumns, n sql.Node) {
 			return true
 		case *plan.SubqueryAlias:
 			return false
+		case *plan.LateralJoin:
+			// The query of the derived table may use any of the columns
+			// of the left side.
+			for _, col := range n.Left.Schema() {
+				if _, ok := columns[col.Source]; !ok {
+					columns[col.Source] = make(map[string]struct{})
+				}
+				columns[col.Source][col.Name] = struct{}{}
+			}
 		}
 
 		exp, ok := n.(sql.Expressioner)
@@ -265,7 +285,7 @@ func pruneGroupBy(n *plan.GroupBy, columns usedColumns) sql.Node {
 		return n.Child
 	}
 
-	return plan.NewGroupBy(remaining, n.Grouping, n.Child)
+	return plan.NewGroupBy(remaining, n.Grouping, n.Child).WithGroupingSets(n.GroupingSets)
 }
 
 func shouldPruneExpr(e sql.Expression, cols usedColumns) bool {
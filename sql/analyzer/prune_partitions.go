@@ -0,0 +1,387 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// prunePartitions selects, for every table with declarative partitioning,
+// only the partitions that may contain rows matching the filters on its
+// partitioning column, so the rest of them are not read at all.
+func prunePartitions(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("prune_partitions")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	switch n.(type) {
+	case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.CreateIndex, *plan.LoadData:
+		return n, nil
+	}
+
+	filters := findFilters(ctx, n)
+	if len(filters) == 0 {
+		return n, nil
+	}
+
+	a.Log("prune partitions, node of type: %T", n)
+
+	return prunePartitionsDown(ctx, a, n, filters)
+}
+
+// prunePartitionsDown transforms the tree from the root down, so tables
+// under an alias are pruned with the filters of the alias and not with the
+// ones of the table name.
+func prunePartitionsDown(ctx *sql.Context, a *Analyzer, n sql.Node, filters filters) (sql.Node, error) {
+	switch n := n.(type) {
+	case *plan.TableAlias:
+		rt, ok := n.Child.(*plan.ResolvedTable)
+		if !ok {
+			return n, nil
+		}
+
+		table, err := pruneTable(ctx, a, rt, filters[n.Name()], n.Name())
+		if err != nil {
+			return nil, err
+		}
+		return n.WithChildren(table)
+	case *plan.ResolvedTable:
+		return pruneTable(ctx, a, n, filters[n.Name()], n.Name())
+	case *plan.SubqueryAlias:
+		// The filters of the outer query do not apply to the tables of the
+		// subquery, even if they have the same names.
+		return n, nil
+	}
+
+	children := n.Children()
+	if len(children) == 0 {
+		return n, nil
+	}
+
+	var newChildren = make([]sql.Node, len(children))
+	for i, child := range children {
+		var err error
+		newChildren[i], err = prunePartitionsDown(ctx, a, child, filters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return n.WithChildren(newChildren...)
+}
+
+// pruneTable returns the table with only the partitions that may contain
+// rows matching all the given filters selected.
+func pruneTable(
+	ctx *sql.Context,
+	a *Analyzer,
+	rt *plan.ResolvedTable,
+	filters []sql.Expression,
+	tableName string,
+) (sql.Node, error) {
+	table, ok := rt.Table.(sql.PrunableTable)
+	if !ok || len(filters) == 0 {
+		return rt, nil
+	}
+
+	partitioning := table.Partitioning()
+	if partitioning == nil || table.SelectedPartitions() != nil {
+		return rt, nil
+	}
+
+	var column *sql.Column
+	for _, col := range table.Schema() {
+		if strings.ToLower(col.Name) == strings.ToLower(partitioning.Column) {
+			column = col
+			break
+		}
+	}
+
+	if column == nil {
+		return rt, nil
+	}
+
+	p := &partitionPruner{ctx, partitioning, column, tableName}
+
+	var selected = newStringSet(partitioning.Names())
+	for _, f := range filters {
+		names, ok, err := p.partitions(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			selected = selected.intersect(newStringSet(names))
+		}
+	}
+
+	if len(selected) == len(partitioning.Definitions) {
+		return rt, nil
+	}
+
+	var names = make([]string, 0, len(selected))
+	for _, name := range partitioning.Names() {
+		if _, ok := selected[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	a.Log("table %q pruned to partitions %v", tableName, names)
+
+	return plan.NewResolvedTable(table.WithSelectedPartitions(names)), nil
+}
+
+// partitionPruner computes the partitions of a table that may contain rows
+// matching a filter on its partitioning column.
+type partitionPruner struct {
+	ctx          *sql.Context
+	partitioning *sql.Partitioning
+	column       *sql.Column
+	table        string
+}
+
+// partitions returns the names of the partitions that may contain rows
+// matching the given filter. If nothing can be known about the partitions
+// from the filter, the second value returned is false.
+func (p *partitionPruner) partitions(e sql.Expression) ([]string, bool, error) {
+	switch e := e.(type) {
+	case *expression.And:
+		left, lok, err := p.partitions(e.Left)
+		if err != nil {
+			return nil, false, err
+		}
+
+		right, rok, err := p.partitions(e.Right)
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch {
+		case lok && rok:
+			return newStringSet(left).intersect(newStringSet(right)).slice(), true, nil
+		case lok:
+			return left, true, nil
+		default:
+			return right, rok, nil
+		}
+	case *expression.Or:
+		left, lok, err := p.partitions(e.Left)
+		if err != nil || !lok {
+			return nil, false, err
+		}
+
+		right, rok, err := p.partitions(e.Right)
+		if err != nil || !rok {
+			return nil, false, err
+		}
+
+		return newStringSet(left).union(newStringSet(right)).slice(), true, nil
+	case *expression.Equals:
+		return p.comparison(e.Left(), e.Right(), func(v interface{}, _ bool) sql.PartitionRange {
+			return sql.NewPartitionPoint(v)
+		})
+	case *expression.LessThan:
+		return p.comparison(e.Left(), e.Right(), func(v interface{}, swapped bool) sql.PartitionRange {
+			return openRange(v, !swapped, false)
+		})
+	case *expression.LessThanOrEqual:
+		return p.comparison(e.Left(), e.Right(), func(v interface{}, swapped bool) sql.PartitionRange {
+			return openRange(v, !swapped, true)
+		})
+	case *expression.GreaterThan:
+		return p.comparison(e.Left(), e.Right(), func(v interface{}, swapped bool) sql.PartitionRange {
+			return openRange(v, swapped, false)
+		})
+	case *expression.GreaterThanOrEqual:
+		return p.comparison(e.Left(), e.Right(), func(v interface{}, swapped bool) sql.PartitionRange {
+			return openRange(v, swapped, true)
+		})
+	case *expression.In:
+		tuple, ok := e.Right().(expression.Tuple)
+		if !ok || !p.isColumn(e.Left()) {
+			return nil, false, nil
+		}
+
+		var names = make(stringSet)
+		for _, el := range tuple {
+			v, ok, err := p.value(el)
+			if err != nil || !ok {
+				return nil, false, err
+			}
+
+			// A NULL in the list never matches any row.
+			if v == nil {
+				continue
+			}
+
+			partitions, err := p.partitioning.PartitionsInRange(p.column.Type, sql.NewPartitionPoint(v))
+			if err != nil {
+				return nil, false, err
+			}
+			names = names.union(newStringSet(partitions))
+		}
+
+		return names.slice(), true, nil
+	case *expression.Between:
+		if !p.isColumn(e.Val) {
+			return nil, false, nil
+		}
+
+		lower, lok, err := p.value(e.Lower)
+		if err != nil || !lok {
+			return nil, false, err
+		}
+
+		upper, uok, err := p.value(e.Upper)
+		if err != nil || !uok {
+			return nil, false, err
+		}
+
+		if lower == nil || upper == nil {
+			return nil, true, nil
+		}
+
+		return p.inRange(sql.PartitionRange{
+			Lower:          lower,
+			Upper:          upper,
+			LowerInclusive: true,
+			UpperInclusive: true,
+		})
+	default:
+		return nil, false, nil
+	}
+}
+
+// openRange returns the range of values less than the given one if upper
+// is true, or greater than it otherwise.
+func openRange(v interface{}, upper, inclusive bool) sql.PartitionRange {
+	if upper {
+		return sql.PartitionRange{Upper: v, UpperInclusive: inclusive}
+	}
+	return sql.PartitionRange{Lower: v, LowerInclusive: inclusive}
+}
+
+// comparison returns the partitions that may contain rows matching the
+// comparison of the partitioning column with a constant value. The range
+// function receives the value and whether the column is on the right side
+// of the comparison.
+func (p *partitionPruner) comparison(
+	left, right sql.Expression,
+	rangeFn func(v interface{}, swapped bool) sql.PartitionRange,
+) ([]string, bool, error) {
+	var swapped bool
+	var value sql.Expression
+	switch {
+	case p.isColumn(left):
+		value = right
+	case p.isColumn(right):
+		value, swapped = left, true
+	default:
+		return nil, false, nil
+	}
+
+	v, ok, err := p.value(value)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	// Comparisons with NULL never match any row.
+	if v == nil {
+		return nil, true, nil
+	}
+
+	return p.inRange(rangeFn(v, swapped))
+}
+
+func (p *partitionPruner) inRange(r sql.PartitionRange) ([]string, bool, error) {
+	names, err := p.partitioning.PartitionsInRange(p.column.Type, r)
+	if err != nil {
+		return nil, false, err
+	}
+	return names, true, nil
+}
+
+// isColumn reports whether the expression is the partitioning column.
+func (p *partitionPruner) isColumn(e sql.Expression) bool {
+	f, ok := e.(*expression.GetField)
+	return ok &&
+		f.Table() == p.table &&
+		strings.ToLower(f.Name()) == strings.ToLower(p.column.Name)
+}
+
+// value evaluates an expression compared with the partitioning column. The
+// second value returned is false if the expression is not a constant or its
+// type is not comparable with the type of the column without conversions
+// that could change the result of the comparison.
+func (p *partitionPruner) value(e sql.Expression) (interface{}, bool, error) {
+	if !isEvaluable(e) || !comparableTypes(p.column.Type, e.Type()) {
+		return nil, false, nil
+	}
+
+	v, err := e.Eval(p.ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return v, true, nil
+}
+
+// comparableTypes reports whether values of the type b can be converted to
+// the type a and compared with the values of a with the same result.
+func comparableTypes(a, b sql.Type) bool {
+	switch {
+	case b == sql.Null:
+		return true
+	case sql.IsInteger(a):
+		return sql.IsInteger(b)
+	case sql.IsText(a):
+		return sql.IsText(b)
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+type stringSet map[string]struct{}
+
+func newStringSet(values []string) stringSet {
+	var set = make(stringSet, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func (s stringSet) intersect(other stringSet) stringSet {
+	var result = make(stringSet)
+	for v := range s {
+		if _, ok := other[v]; ok {
+			result[v] = struct{}{}
+		}
+	}
+	return result
+}
+
+func (s stringSet) union(other stringSet) stringSet {
+	var result = make(stringSet, len(s)+len(other))
+	for v := range s {
+		result[v] = struct{}{}
+	}
+	for v := range other {
+		result[v] = struct{}{}
+	}
+	return result
+}
+
+func (s stringSet) slice() []string {
+	var result = make([]string, 0, len(s))
+	for v := range s {
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrunePartitions(t *testing.T) {
+	table, err := memory.NewTableWithPartitioning("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Text, Source: "t"},
+	}, &sql.Partitioning{
+		Type:   sql.RangePartitioning,
+		Column: "a",
+		Definitions: []sql.PartitionDefinition{
+			{Name: "p0", LessThan: int64(10)},
+			{Name: "p1", LessThan: int64(20)},
+			{Name: "p2"},
+		},
+	})
+	require.NoError(t, err)
+
+	a := col(0, "t", "a")
+	b := expression.NewGetFieldWithTable(1, sql.Text, "t", "b", false)
+	rt := plan.NewResolvedTable(table)
+	pruned := func(names ...string) sql.Node {
+		if names == nil {
+			names = []string{}
+		}
+		return plan.NewResolvedTable(table.WithSelectedPartitions(names))
+	}
+
+	testCases := []struct {
+		name     string
+		filter   sql.Expression
+		expected sql.Node
+	}{
+		{
+			"equals",
+			eq(a, lit(15)),
+			pruned("p1"),
+		},
+		{
+			"equals with column on the right",
+			eq(lit(5), a),
+			pruned("p0"),
+		},
+		{
+			"less than",
+			lt(a, lit(10)),
+			pruned("p0"),
+		},
+		{
+			"greater than with column on the right",
+			lt(lit(15), a),
+			pruned("p1", "p2"),
+		},
+		{
+			"less than or equal",
+			lte(a, lit(10)),
+			pruned("p0", "p1"),
+		},
+		{
+			"greater than or equal",
+			gte(a, lit(20)),
+			pruned("p2"),
+		},
+		{
+			"in",
+			expression.NewIn(a, expression.NewTuple(lit(1), lit(25))),
+			pruned("p0", "p2"),
+		},
+		{
+			"between",
+			expression.NewBetween(a, lit(12), lit(18)),
+			pruned("p1"),
+		},
+		{
+			"and",
+			and(gt(a, lit(5)), lt(a, lit(15))),
+			pruned("p0", "p1"),
+		},
+		{
+			"and with other column",
+			and(eq(b, expression.NewLiteral("foo", sql.Text)), eq(a, lit(25))),
+			pruned("p2"),
+		},
+		{
+			"or",
+			expression.NewOr(eq(a, lit(1)), eq(a, lit(15))),
+			pruned("p0", "p1"),
+		},
+		{
+			"contradiction",
+			and(lt(a, lit(5)), gt(a, lit(25))),
+			pruned(),
+		},
+		{
+			"or with other column",
+			expression.NewOr(eq(a, lit(1)), eq(b, expression.NewLiteral("foo", sql.Text))),
+			rt,
+		},
+		{
+			"all partitions",
+			gt(a, lit(-1)),
+			rt,
+		},
+		{
+			"different types",
+			lt(a, expression.NewLiteral(5.5, sql.Float64)),
+			rt,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			node := plan.NewProject(
+				[]sql.Expression{a},
+				plan.NewFilter(tt.filter, rt),
+			)
+
+			result, err := getRule("prune_partitions").Apply(sql.NewEmptyContext(), NewDefault(nil), node)
+			require.NoError(err)
+
+			expected := plan.NewProject(
+				[]sql.Expression{a},
+				plan.NewFilter(tt.filter, tt.expected),
+			)
+			require.Equal(expected, result)
+		})
+	}
+}
+
+func TestPrunePartitionsAlias(t *testing.T) {
+	require := require.New(t)
+
+	table, err := memory.NewTableWithPartitioning("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+	}, &sql.Partitioning{
+		Type:   sql.ListPartitioning,
+		Column: "a",
+		Definitions: []sql.PartitionDefinition{
+			{Name: "p0", Values: []interface{}{int64(1), int64(2)}},
+			{Name: "p1", Values: []interface{}{int64(3)}},
+		},
+	})
+	require.NoError(err)
+
+	rt := plan.NewResolvedTable(table)
+	node := plan.NewFilter(
+		eq(col(0, "x", "a"), lit(3)),
+		plan.NewCrossJoin(rt, plan.NewTableAlias("x", rt)),
+	)
+
+	result, err := getRule("prune_partitions").Apply(sql.NewEmptyContext(), NewDefault(nil), node)
+	require.NoError(err)
+
+	expected := plan.NewFilter(
+		eq(col(0, "x", "a"), lit(3)),
+		plan.NewCrossJoin(
+			rt,
+			plan.NewTableAlias("x", plan.NewResolvedTable(table.WithSelectedPartitions([]string{"p1"}))),
+		),
+	)
+	require.Equal(expected, result)
+}
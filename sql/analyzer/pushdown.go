@@ -20,7 +20,7 @@ func pushdown(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 
 	// don't do pushdown on certain queries
 	switch n.(type) {
-	case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.CreateIndex:
+	case *plan.InsertInto, *plan.DeleteFrom, *plan.Update, *plan.CreateIndex, *plan.LoadData:
 		return n, nil
 	}
 
@@ -42,11 +42,14 @@ func pushdown(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+	removeHintedIndexes(a, ctx.Hints(), indexes)
 	indexSpan.Finish()
 
+	costs := newCostEstimator(ctx, a.Catalog, n, filters)
+
 	a.Log("transforming nodes with pushdown of filters, projections and indexes")
 
-	return transformPushdown(a, n, filters, indexes, fieldsByTable)
+	return transformPushdown(a, n, filters, indexes, fieldsByTable, costs)
 }
 
 // fixFieldIndexesOnExpressions executes fixFieldIndexes on a list of exprs.
@@ -114,6 +117,10 @@ func findFilters(ctx *sql.Context, n sql.Node) filters {
 		case *plan.Filter:
 			fs := exprToTableFilters(node.Expression)
 			filters.merge(fs)
+		case *plan.SubqueryAlias:
+			// The filters of derived tables were already pushed down when
+			// their queries were analyzed.
+			return false
 		}
 		return true
 	})
@@ -127,6 +134,7 @@ func transformPushdown(
 	filters filters,
 	indexes map[string]*indexLookup,
 	fieldsByTable map[string][]string,
+	costs *costEstimator,
 ) (sql.Node, error) {
 	// Now all nodes can be transformed. Since traversal of the tree is done
 	// from inner to outer the filters have to be processed first so they get
@@ -138,7 +146,14 @@ func transformPushdown(
 		a.Log("transforming node of type: %T", node)
 		switch node := node.(type) {
 		case *plan.Filter:
-			return pushdownFilter(a, node, handledFilters)
+			n, err := pushdownFilter(a, node, handledFilters)
+			if err != nil {
+				return nil, err
+			}
+
+			// The schema of the child may have changed if its table
+			// was projected.
+			return transformExpressioners(n)
 		case *plan.ResolvedTable:
 			return pushdownTable(
 				a,
@@ -148,6 +163,7 @@ func transformPushdown(
 				&queryIndexes,
 				fieldsByTable,
 				indexes,
+				costs,
 			)
 		default:
 			return transformExpressioners(node)
@@ -214,21 +230,71 @@ func transformExpressioners(node sql.Node) (sql.Node, error) {
 			return nil, err
 		}
 
-		n = plan.NewInnerJoin(j.Left, j.Right, cond)
+		n, err = j.WithExpressions(cond)
+		if err != nil {
+			return nil, err
+		}
+
+		if j.BloomFilter != nil {
+			keys, err := fixFieldIndexesOnExpressions(j.Right.Schema(), j.BloomFilter.Keys()...)
+			if err != nil {
+				return nil, err
+			}
+
+			nj := *n.(*plan.InnerJoin)
+			nj.BloomFilter = j.BloomFilter.WithKeys(keys...)
+			n = &nj
+		}
 	case *plan.RightJoin:
 		cond, err := fixFieldIndexes(j.Schema(), j.Cond)
 		if err != nil {
 			return nil, err
 		}
 
-		n = plan.NewRightJoin(j.Left, j.Right, cond)
+		n, err = j.WithExpressions(cond)
+		if err != nil {
+			return nil, err
+		}
+	case *plan.LateralJoin:
+		cond, err := fixFieldIndexes(append(j.Left.Schema(), j.Right.Schema()...), j.Cond)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err = j.WithExpressions(cond)
+		if err != nil {
+			return nil, err
+		}
+	case *plan.SemiJoin:
+		cond, err := fixFieldIndexes(append(j.Left.Schema(), j.Right.Schema()...), j.Cond)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err = j.WithExpressions(cond)
+		if err != nil {
+			return nil, err
+		}
+	case *plan.AntiJoin:
+		cond, err := fixFieldIndexes(append(j.Left.Schema(), j.Right.Schema()...), j.Cond)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err = j.WithExpressions(cond)
+		if err != nil {
+			return nil, err
+		}
 	case *plan.LeftJoin:
 		cond, err := fixFieldIndexes(j.Schema(), j.Cond)
 		if err != nil {
 			return nil, err
 		}
 
-		n = plan.NewLeftJoin(j.Left, j.Right, cond)
+		n, err = j.WithExpressions(cond)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return n, nil
@@ -242,6 +308,7 @@ func pushdownTable(
 	queryIndexes *[]sql.Index,
 	fieldsByTable map[string][]string,
 	indexes map[string]*indexLookup,
+	costs *costEstimator,
 ) (sql.Node, error) {
 	var table = node.Table
 
@@ -271,6 +338,11 @@ func pushdownTable(
 
 	if it, ok := table.(sql.IndexableTable); ok {
 		indexLookup, ok := indexes[node.Name()]
+		if ok && !costs.indexLookupIsCheaper(node.Name()) {
+			a.Log("table %q will be scanned, as it's cheaper than using the index", node.Name())
+			ok = false
+		}
+
 		if ok {
 			*queryIndexes = append(*queryIndexes, indexLookup.indexes...)
 			table = it.WithIndexLookup(indexLookup.lookup)
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// pushdownLimit pushes the limits of the query, and the sort fields if they
+// are right before the limit, to the tables that can honor them, so they
+// don't need to return all their rows. Limits and sorts are kept in the plan,
+// because tables may apply them to each partition separately.
+func pushdownLimit(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("pushdown_limit")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	a.Log("pushdown limit, node of type: %T", n)
+
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		limit, ok := node.(*plan.Limit)
+		if !ok || limit.CalcFoundRows || limit.Limit <= 0 {
+			return node, nil
+		}
+
+		child, ok := pushdownLimitToTable(a, limit.Child, uint64(limit.Limit), nil)
+		if !ok {
+			return node, nil
+		}
+
+		return limit.WithChildren(child)
+	})
+}
+
+// pushdownLimitToTable pushes the given limit and sort fields down to the
+// table under the node, if the nodes in between don't change the number of
+// rows. It returns false if the limit could not be pushed down.
+func pushdownLimitToTable(
+	a *Analyzer,
+	n sql.Node,
+	limit uint64,
+	order []plan.SortField,
+) (sql.Node, bool) {
+	switch n := n.(type) {
+	case *plan.Offset:
+		if n.Offset < 0 {
+			return nil, false
+		}
+		return pushdownLimitToChild(a, n, n.Child, limit+uint64(n.Offset), order)
+	case *plan.Project:
+		return pushdownLimitToChild(a, n, n.Child, limit, order)
+	case *plan.Sort:
+		if order != nil {
+			return nil, false
+		}
+		return pushdownLimitToChild(a, n, n.Child, limit, n.SortFields)
+	case *plan.ResolvedTable:
+		if len(order) == 0 {
+			lt, ok := n.Table.(sql.LimitedTable)
+			if !ok {
+				return nil, false
+			}
+
+			a.Log("table %q transformed with pushdown of limit %d", n.Name(), limit)
+			return plan.NewResolvedTable(lt.WithLimit(limit)), true
+		}
+
+		tt, ok := n.Table.(sql.TopNTable)
+		if !ok {
+			return nil, false
+		}
+
+		columns, ok := orderByColumns(n.Name(), order)
+		if !ok {
+			return nil, false
+		}
+
+		a.Log("table %q transformed with pushdown of top %d rows", n.Name(), limit)
+		return plan.NewResolvedTable(tt.WithTopN(columns, limit)), true
+	default:
+		return nil, false
+	}
+}
+
+func pushdownLimitToChild(
+	a *Analyzer,
+	n, child sql.Node,
+	limit uint64,
+	order []plan.SortField,
+) (sql.Node, bool) {
+	child, ok := pushdownLimitToTable(a, child, limit, order)
+	if !ok {
+		return nil, false
+	}
+
+	node, err := n.WithChildren(child)
+	if err != nil {
+		return nil, false
+	}
+
+	return node, true
+}
+
+// orderByColumns converts the sort fields into the columns of the given
+// table. It returns false if any of the fields is not a column of the table.
+func orderByColumns(table string, fields []plan.SortField) ([]sql.OrderByColumn, bool) {
+	var columns = make([]sql.OrderByColumn, len(fields))
+	for i, f := range fields {
+		gf, ok := f.Column.(*expression.GetField)
+		if !ok || gf.Table() != table {
+			return nil, false
+		}
+
+		columns[i] = sql.OrderByColumn{
+			Name:       gf.Name(),
+			Descending: f.Order == plan.Descending,
+			NullsFirst: f.NullOrdering == plan.NullsFirst,
+		}
+	}
+	return columns, true
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushdownLimit(t *testing.T) {
+	f := getRule("pushdown_limit")
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Int64, Source: "t"},
+	})
+
+	sortByA := []plan.SortField{
+		{Column: col(0, "t", "a"), Order: plan.Descending, NullOrdering: plan.NullsLast},
+	}
+	orderByA := []sql.OrderByColumn{{Name: "a", Descending: true}}
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected sql.Node
+	}{
+		{
+			"limit",
+			plan.NewLimit(5, plan.NewResolvedTable(table)),
+			plan.NewLimit(5, plan.NewResolvedTable(table.WithLimit(5))),
+		},
+		{
+			"limit with offset",
+			plan.NewLimit(5, plan.NewOffset(2, plan.NewResolvedTable(table))),
+			plan.NewLimit(5, plan.NewOffset(2, plan.NewResolvedTable(table.WithLimit(7)))),
+		},
+		{
+			"limit with projection",
+			plan.NewLimit(5, plan.NewProject(
+				[]sql.Expression{col(1, "t", "b")},
+				plan.NewResolvedTable(table),
+			)),
+			plan.NewLimit(5, plan.NewProject(
+				[]sql.Expression{col(1, "t", "b")},
+				plan.NewResolvedTable(table.WithLimit(5)),
+			)),
+		},
+		{
+			"top-n",
+			plan.NewLimit(5, plan.NewSort(sortByA, plan.NewResolvedTable(table))),
+			plan.NewLimit(5, plan.NewSort(
+				sortByA,
+				plan.NewResolvedTable(table.WithTopN(orderByA, 5)),
+			)),
+		},
+		{
+			"sort by expression",
+			plan.NewLimit(5, plan.NewSort(
+				[]plan.SortField{{Column: expression.NewArithmetic(col(0, "t", "a"), lit(1), "+")}},
+				plan.NewResolvedTable(table),
+			)),
+			plan.NewLimit(5, plan.NewSort(
+				[]plan.SortField{{Column: expression.NewArithmetic(col(0, "t", "a"), lit(1), "+")}},
+				plan.NewResolvedTable(table),
+			)),
+		},
+		{
+			"filter",
+			plan.NewLimit(5, plan.NewFilter(
+				eq(col(0, "t", "a"), lit(1)),
+				plan.NewResolvedTable(table),
+			)),
+			plan.NewLimit(5, plan.NewFilter(
+				eq(col(0, "t", "a"), lit(1)),
+				plan.NewResolvedTable(table),
+			)),
+		},
+		{
+			"sql_calc_found_rows",
+			&plan.Limit{
+				UnaryNode:     plan.UnaryNode{Child: plan.NewResolvedTable(table)},
+				Limit:         5,
+				CalcFoundRows: true,
+			},
+			&plan.Limit{
+				UnaryNode:     plan.UnaryNode{Child: plan.NewResolvedTable(table)},
+				Limit:         5,
+				CalcFoundRows: true,
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
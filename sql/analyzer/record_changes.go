@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// recordChanges makes INSERT, REPLACE, UPDATE, DELETE and LOAD DATA
+// statements record the rows they change when the catalog has a change
+// recorder or row change hooks. It runs before the tables are resolved, as
+// their databases are not kept after.
+func recordChanges(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	recorder := a.Catalog.StatementChangeRecorder()
+	if recorder == nil {
+		return n, nil
+	}
+
+	span, _ := ctx.Span("record_changes")
+	defer span.Finish()
+
+	target := func(n sql.Node) *plan.ChangeTarget {
+		var table *plan.UnresolvedTable
+		plan.Inspect(n, func(n sql.Node) bool {
+			if t, ok := n.(*plan.UnresolvedTable); ok && table == nil {
+				table = t
+			}
+			return table == nil
+		})
+
+		if table == nil {
+			return nil
+		}
+
+		db := table.Database
+		if db == "" {
+			db = a.Catalog.CurrentDatabase()
+		}
+
+		return &plan.ChangeTarget{Recorder: recorder, Database: db, Table: table.Name()}
+	}
+
+	return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+		switch n := n.(type) {
+		case *plan.InsertInto:
+			nc := *n
+			nc.Changes = target(n.Left)
+			return &nc, nil
+		case *plan.Update:
+			nc := *n
+			nc.Changes = target(n.Node)
+			return &nc, nil
+		case *plan.DeleteFrom:
+			nc := *n
+			nc.Changes = target(n.Node)
+			return &nc, nil
+		case *plan.LoadData:
+			nc := *n
+			nc.Changes = target(n.Child)
+			return &nc, nil
+		default:
+			return n, nil
+		}
+	})
+}
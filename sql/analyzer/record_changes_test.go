@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+type nopRecorder struct{}
+
+func (nopRecorder) RecordChanges(*sql.Context, sql.TableChanges) error {
+	return nil
+}
+
+func TestRecordChanges(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	catalog.SetCurrentDatabase("mydb")
+	a := NewDefault(catalog)
+	rule := getRule("record_changes")
+
+	insert := plan.NewInsertInto(
+		plan.NewUnresolvedTable("t1", ""),
+		plan.NewProject(nil, plan.NewUnresolvedTable("t2", "other")),
+		false,
+		nil,
+	)
+
+	// Without a recorder, nothing is recorded.
+	result, err := rule.Apply(sql.NewEmptyContext(), a, insert)
+	require.NoError(err)
+	require.Equal(insert, result)
+
+	recorder := nopRecorder{}
+	catalog.SetChangeRecorder(recorder)
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		database string
+		table    string
+	}{
+		{"insert", insert, "mydb", "t1"},
+		{
+			"update",
+			plan.NewUpdate(plan.NewUnresolvedTable("t1", "other"), nil),
+			"other",
+			"t1",
+		},
+		{
+			"delete",
+			plan.NewDeleteFrom(plan.NewFilter(
+				expression.NewLiteral(true, sql.Boolean),
+				plan.NewUnresolvedTable("t3", ""),
+			)),
+			"mydb",
+			"t3",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rule.Apply(sql.NewEmptyContext(), a, tt.node)
+			require.NoError(err)
+
+			var target *plan.ChangeTarget
+			switch n := result.(type) {
+			case *plan.InsertInto:
+				target = n.Changes
+			case *plan.Update:
+				target = n.Changes
+			case *plan.DeleteFrom:
+				target = n.Changes
+			}
+			require.Equal(&plan.ChangeTarget{
+				Recorder: recorder,
+				Database: tt.database,
+				Table:    tt.table,
+			}, target)
+		})
+	}
+}
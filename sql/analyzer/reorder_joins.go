@@ -0,0 +1,433 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// reorderJoins changes the order in which the tables of a chain of inner
+// joins are joined, so the relations with fewer estimated rows are joined
+// first and the intermediate results are as small as possible. As in
+// optimize_joins, joins are only reordered if there are statistics for any
+// of the tables, unless the order is given with the JOIN_ORDER optimizer
+// hint.
+func reorderJoins(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("reorder_joins")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	hints := ctx.Hints()
+	costs := newCostEstimator(ctx, a.Catalog, n, findFilters(ctx, n))
+	if !costs.hasStatistics() && (hints == nil || len(hints.JoinOrder) == 0) {
+		return n, nil
+	}
+
+	a.Log("reordering joins, node of type: %T", n)
+
+	return reorderJoinsDown(a, hints, costs, n)
+}
+
+// reorderJoinsDown reorders the outermost join of every chain of inner joins
+// in the given node.
+func reorderJoinsDown(
+	a *Analyzer,
+	hints *sql.QueryHints,
+	costs *costEstimator,
+	n sql.Node,
+) (sql.Node, error) {
+	if j, ok := n.(*plan.InnerJoin); ok {
+		return reorderJoin(a, hints, costs, j)
+	}
+
+	children := n.Children()
+	if len(children) == 0 {
+		return n, nil
+	}
+
+	var newChildren = make([]sql.Node, len(children))
+	for i, c := range children {
+		var err error
+		newChildren[i], err = reorderJoinsDown(a, hints, costs, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return n.WithChildren(newChildren...)
+}
+
+// joinRelation is one of the relations joined in a chain of inner joins.
+type joinRelation struct {
+	node   sql.Node
+	tables map[string]struct{}
+	rows   float64
+}
+
+func reorderJoin(
+	a *Analyzer,
+	hints *sql.QueryHints,
+	costs *costEstimator,
+	j *plan.InnerJoin,
+) (sql.Node, error) {
+	var relations []*joinRelation
+	var conds []sql.Expression
+	var seen = make(map[string]struct{})
+	var duplicated bool
+
+	var flatten func(n sql.Node) error
+	flatten = func(n sql.Node) error {
+		if j, ok := n.(*plan.InnerJoin); ok {
+			conds = append(conds, splitExpression(j.Cond)...)
+			if err := flatten(j.Left); err != nil {
+				return err
+			}
+			return flatten(j.Right)
+		}
+
+		n, err := reorderJoinsDown(a, hints, costs, n)
+		if err != nil {
+			return err
+		}
+
+		r := &joinRelation{
+			node:   n,
+			tables: make(map[string]struct{}),
+			rows:   costs.rows(n),
+		}
+		for _, col := range n.Schema() {
+			if _, ok := r.tables[col.Source]; ok {
+				continue
+			}
+
+			if _, ok := seen[col.Source]; ok {
+				duplicated = true
+			}
+			seen[col.Source] = struct{}{}
+			r.tables[col.Source] = struct{}{}
+		}
+
+		relations = append(relations, r)
+		return nil
+	}
+
+	if err := flatten(j); err != nil {
+		return nil, err
+	}
+
+	// Columns are matched by table and name when the conditions are
+	// rewritten, so joins of the same table without aliases cannot be
+	// reordered.
+	if duplicated {
+		return rebuildJoins(j, relations)
+	}
+
+	if order, ok := hintedJoinOrder(hints, relations); ok {
+		reordered, ok, err := buildJoinChain(order, conds)
+		if err != nil && !ErrFieldMissing.Is(err) {
+			return nil, err
+		}
+
+		if err == nil && ok {
+			a.Log("reordering joins as given by the optimizer hints")
+			return projectOriginalSchema(j.Schema(), reordered)
+		}
+	}
+
+	if len(relations) < 3 || !costs.hasStatistics() {
+		return rebuildJoins(j, relations)
+	}
+
+	order, ok := greedyJoinOrder(costs, relations, conds)
+	if !ok {
+		a.Log("join chain cannot be reordered without cross joins")
+		return rebuildJoins(j, relations)
+	}
+
+	reordered, ok, err := buildJoinChain(order, conds)
+	if err != nil {
+		if ErrFieldMissing.Is(err) {
+			return rebuildJoins(j, relations)
+		}
+		return nil, err
+	}
+
+	if !ok {
+		a.Log("join conditions use tables outside of the join chain")
+		return rebuildJoins(j, relations)
+	}
+
+	original, err := rebuildJoins(j, relations)
+	if err != nil {
+		return nil, err
+	}
+
+	cost, reorderedCost := joinChainCost(costs, original), joinChainCost(costs, reordered)
+	if reorderedCost >= cost {
+		return original, nil
+	}
+
+	a.Log("reordering joins, estimated cost %v is lower than %v", reorderedCost, cost)
+
+	return projectOriginalSchema(j.Schema(), reordered)
+}
+
+// rebuildJoins replaces the relations of the join chain with the given ones,
+// keeping their order.
+func rebuildJoins(j *plan.InnerJoin, relations []*joinRelation) (sql.Node, error) {
+	var i int
+	var rebuild func(n sql.Node) (sql.Node, error)
+	rebuild = func(n sql.Node) (sql.Node, error) {
+		j, ok := n.(*plan.InnerJoin)
+		if !ok {
+			r := relations[i]
+			i++
+			return r.node, nil
+		}
+
+		left, err := rebuild(j.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := rebuild(j.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return j.WithChildren(left, right)
+	}
+
+	return rebuild(j)
+}
+
+// hintedJoinOrder returns the relations in the order given by the JOIN_ORDER
+// optimizer hint: the relations of the hinted tables go first, in the order
+// of the hint, followed by the rest of them in their original order. It
+// returns false if less than two of the relations are in the hint.
+func hintedJoinOrder(hints *sql.QueryHints, relations []*joinRelation) ([]*joinRelation, bool) {
+	if hints == nil {
+		return nil, false
+	}
+
+	var order []*joinRelation
+	var used = make(map[*joinRelation]bool)
+	for _, name := range hints.JoinOrder {
+		for _, r := range relations {
+			if !used[r] && hasTable(r.tables, name) {
+				used[r] = true
+				order = append(order, r)
+			}
+		}
+	}
+
+	if len(order) < 2 {
+		return nil, false
+	}
+
+	for _, r := range relations {
+		if !used[r] {
+			order = append(order, r)
+		}
+	}
+
+	return order, true
+}
+
+func hasTable(tables map[string]struct{}, name string) bool {
+	for t := range tables {
+		if strings.EqualFold(t, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// greedyJoinOrder returns the relations in the order they should be joined.
+// The relation with the fewest rows goes first, and then the relation that
+// produces the fewest rows when joined with the previous ones is added
+// until all of them are joined. It returns false if some relation cannot be
+// joined using the conditions.
+func greedyJoinOrder(
+	costs *costEstimator,
+	relations []*joinRelation,
+	conds []sql.Expression,
+) ([]*joinRelation, bool) {
+	var remaining = append([]*joinRelation(nil), relations...)
+	var first int
+	for i, r := range remaining {
+		if r.rows < remaining[first].rows {
+			first = i
+		}
+	}
+
+	var order = []*joinRelation{remaining[first]}
+	var joined = make(map[string]struct{})
+	for t := range remaining[first].tables {
+		joined[t] = struct{}{}
+	}
+	rows := remaining[first].rows
+	remaining = append(remaining[:first], remaining[first+1:]...)
+
+	for len(remaining) > 0 {
+		var best = -1
+		var bestRows float64
+		for i, r := range remaining {
+			var selectivity = 1.0
+			var connected bool
+			for _, cond := range conds {
+				tables := expressionTables(cond)
+				if len(tables) == 0 || !containsAny(r.tables, tables) {
+					continue
+				}
+
+				if !containsAll(joined, r.tables, tables) {
+					continue
+				}
+
+				connected = true
+				selectivity *= costs.selectivity(cond)
+			}
+
+			if !connected {
+				continue
+			}
+
+			joinRows := rows * r.rows * selectivity
+			if best < 0 || joinRows < bestRows {
+				best, bestRows = i, joinRows
+			}
+		}
+
+		if best < 0 {
+			return nil, false
+		}
+
+		r := remaining[best]
+		order = append(order, r)
+		for t := range r.tables {
+			joined[t] = struct{}{}
+		}
+		rows = bestRows
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+
+	return order, true
+}
+
+// buildJoinChain joins the relations in the given order. Every condition is
+// used in the first join in which all the tables it mentions are available.
+// It returns false if any of the conditions could not be used.
+func buildJoinChain(order []*joinRelation, conds []sql.Expression) (sql.Node, bool, error) {
+	var used = make([]bool, len(conds))
+	var joined = make(map[string]struct{})
+	for t := range order[0].tables {
+		joined[t] = struct{}{}
+	}
+
+	node := order[0].node
+	for _, r := range order[1:] {
+		for t := range r.tables {
+			joined[t] = struct{}{}
+		}
+
+		var joinConds []sql.Expression
+		for i, cond := range conds {
+			if !used[i] && containsAll(joined, nil, expressionTables(cond)) {
+				used[i] = true
+				joinConds = append(joinConds, cond)
+			}
+		}
+
+		join := plan.NewInnerJoin(node, r.node, nil)
+		cond, err := fixFieldIndexes(join.Schema(), expression.JoinAnd(joinConds...))
+		if err != nil {
+			return nil, false, err
+		}
+
+		join.Cond = cond
+		node = join
+	}
+
+	for _, u := range used {
+		if !u {
+			return nil, false, nil
+		}
+	}
+
+	return node, true, nil
+}
+
+// joinChainCost returns the estimated cost of a chain of joins, as the sum
+// of the rows produced by each of the joins.
+func joinChainCost(costs *costEstimator, n sql.Node) float64 {
+	j, ok := n.(*plan.InnerJoin)
+	if !ok {
+		return 0
+	}
+
+	return costs.rows(j) + joinChainCost(costs, j.Left) + joinChainCost(costs, j.Right)
+}
+
+// projectOriginalSchema returns a projection of the node with the columns in
+// the order of the given schema, so nodes on top of it don't need to be
+// changed.
+func projectOriginalSchema(schema sql.Schema, n sql.Node) (sql.Node, error) {
+	newSchema := n.Schema()
+	var fields = make([]sql.Expression, len(schema))
+	for i, col := range schema {
+		idx := newSchema.IndexOf(col.Name, col.Source)
+		if idx < 0 {
+			return nil, ErrFieldMissing.New(col.Name)
+		}
+
+		fields[i] = expression.NewGetFieldWithTable(
+			idx,
+			col.Type,
+			col.Source,
+			col.Name,
+			col.Nullable,
+		)
+	}
+
+	return plan.NewProject(fields, n), nil
+}
+
+// expressionTables returns the names of the tables whose columns are used
+// in the given expression.
+func expressionTables(e sql.Expression) []string {
+	var tables []string
+	expression.Inspect(e, func(e sql.Expression) bool {
+		if gf, ok := e.(*expression.GetField); ok && !stringContains(tables, gf.Table()) {
+			tables = append(tables, gf.Table())
+		}
+		return true
+	})
+	return tables
+}
+
+func containsAny(set map[string]struct{}, tables []string) bool {
+	for _, t := range tables {
+		if _, ok := set[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll reports whether all the tables are in any of the given sets.
+func containsAll(set, other map[string]struct{}, tables []string) bool {
+	for _, t := range tables {
+		_, ok := set[t]
+		_, ok2 := other[t]
+		if !ok && !ok2 {
+			return false
+		}
+	}
+	return true
+}
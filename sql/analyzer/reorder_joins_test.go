@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorderJoins(t *testing.T) {
+	require := require.New(t)
+	f := getRule("reorder_joins")
+
+	a := plan.NewResolvedTable(newStatisticsTable("a", &sql.TableStatistics{
+		RowCount: 1000,
+		Columns:  map[string]*sql.ColumnStatistics{"ax": {DistinctCount: 100}},
+	}, "ax"))
+	b := plan.NewResolvedTable(newStatisticsTable("b", &sql.TableStatistics{
+		RowCount: 10,
+		Columns:  map[string]*sql.ColumnStatistics{"by": {DistinctCount: 10}},
+	}, "by"))
+	c := plan.NewResolvedTable(newStatisticsTable("c", &sql.TableStatistics{
+		RowCount: 100,
+		Columns: map[string]*sql.ColumnStatistics{
+			"cx": {DistinctCount: 100},
+			"cy": {DistinctCount: 10},
+		},
+	}, "cx", "cy"))
+
+	node := plan.NewInnerJoin(
+		plan.NewInnerJoin(a, c, eq(col(0, "a", "ax"), col(1, "c", "cx"))),
+		b,
+		eq(col(2, "c", "cy"), col(3, "b", "by")),
+	)
+
+	expected := plan.NewProject(
+		[]sql.Expression{
+			expression.NewGetFieldWithTable(3, sql.Int64, "a", "ax", false),
+			expression.NewGetFieldWithTable(1, sql.Int64, "c", "cx", false),
+			expression.NewGetFieldWithTable(2, sql.Int64, "c", "cy", false),
+			expression.NewGetFieldWithTable(0, sql.Int64, "b", "by", false),
+		},
+		plan.NewInnerJoin(
+			plan.NewInnerJoin(b, c, eq(col(2, "c", "cy"), col(0, "b", "by"))),
+			a,
+			eq(col(3, "a", "ax"), col(1, "c", "cx")),
+		),
+	)
+
+	result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), node)
+	require.NoError(err)
+	require.Equal(expected, result)
+}
+
+func TestReorderJoinsUnchanged(t *testing.T) {
+	f := getRule("reorder_joins")
+
+	a := plan.NewResolvedTable(newStatisticsTable("a", &sql.TableStatistics{RowCount: 10}, "ax"))
+	b := plan.NewResolvedTable(newStatisticsTable("b", &sql.TableStatistics{RowCount: 1000}, "by"))
+	c := plan.NewResolvedTable(newStatisticsTable("c", &sql.TableStatistics{RowCount: 100}, "cz"))
+
+	testCases := []struct {
+		name string
+		node sql.Node
+	}{
+		{
+			"written order is the cheapest",
+			plan.NewInnerJoin(
+				plan.NewInnerJoin(a, c, eq(col(0, "a", "ax"), col(1, "c", "cz"))),
+				b,
+				eq(col(1, "c", "cz"), col(2, "b", "by")),
+			),
+		},
+		{
+			"cross join needed",
+			plan.NewInnerJoin(
+				plan.NewInnerJoin(b, c, eq(col(0, "b", "by"), col(1, "c", "cz"))),
+				a,
+				expression.NewLiteral(true, sql.Boolean),
+			),
+		},
+		{
+			"without statistics",
+			plan.NewInnerJoin(
+				plan.NewInnerJoin(
+					plan.NewResolvedTable(memory.NewTable("x", nil)),
+					plan.NewResolvedTable(memory.NewTable("y", nil)),
+					expression.NewLiteral(true, sql.Boolean),
+				),
+				plan.NewResolvedTable(memory.NewTable("z", nil)),
+				expression.NewLiteral(true, sql.Boolean),
+			),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.node, result)
+		})
+	}
+}
+
+func TestReorderJoinsHints(t *testing.T) {
+	require := require.New(t)
+	f := getRule("reorder_joins")
+
+	x := plan.NewResolvedTable(memory.NewTable("x", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "x"},
+	}))
+	y := plan.NewResolvedTable(memory.NewTable("y", sql.Schema{
+		{Name: "b", Type: sql.Int64, Source: "y"},
+	}))
+
+	node := plan.NewInnerJoin(x, y, eq(col(0, "x", "a"), col(1, "y", "b")))
+
+	expected := plan.NewProject(
+		[]sql.Expression{
+			expression.NewGetFieldWithTable(1, sql.Int64, "x", "a", false),
+			expression.NewGetFieldWithTable(0, sql.Int64, "y", "b", false),
+		},
+		plan.NewInnerJoin(y, x, eq(col(1, "x", "a"), col(0, "y", "b"))),
+	)
+
+	// Joins are reordered even without statistics.
+	ctx := sql.NewEmptyContext().WithHints(&sql.QueryHints{JoinOrder: []string{"Y", "x"}})
+	result, err := f.Apply(ctx, NewDefault(nil), node)
+	require.NoError(err)
+	require.Equal(expected, result)
+
+	ctx = sql.NewEmptyContext().WithHints(&sql.QueryHints{JoinOrder: []string{"y", "z"}})
+	result, err = f.Apply(ctx, NewDefault(nil), node)
+	require.NoError(err)
+	require.Equal(node, result)
+}
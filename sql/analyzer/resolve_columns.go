@@ -26,8 +26,15 @@ func checkAliases(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 			return true
 		}
 
+		// The columns of a projection refer to the columns of its child
+		// even if there are aliases with the same name.
+		columns := getNodeAvailableColumns(p)
 		aliases := lookForAliasDeclarations(p)
 		for alias := range aliases {
+			if _, ok := columns[strings.ToLower(alias)]; ok {
+				continue
+			}
+
 			if isAliasUsed(p, alias) {
 				err = ErrMisusedAlias.New(alias)
 			}
@@ -133,17 +140,59 @@ func qualifyColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 		}
 
 		columns := getNodeAvailableColumns(n)
+		lateral := getNodeLateralColumns(n)
 		tables := getNodeAvailableTables(n)
 
+		if having, ok := n.(*plan.Having); ok {
+			n = qualifyGroupedColumns(having)
+		}
+
 		return plan.TransformExpressions(n, func(e sql.Expression) (sql.Expression, error) {
-			return qualifyExpression(e, columns, tables)
+			return qualifyExpression(e, columns, lateral, tables)
 		})
 	})
 }
 
+// qualifyGroupedColumns qualifies the columns of the condition of a having
+// that are grouping columns of its group by with their tables. As in MySQL,
+// they refer to the grouping columns even if there are aliases with the same
+// name in the group by.
+func qualifyGroupedColumns(having *plan.Having) *plan.Having {
+	groupBy, err := findGroupBy(having)
+	if err != nil {
+		return having
+	}
+
+	var tables = make(map[string]string)
+	for _, g := range groupBy.Grouping {
+		if col, ok := g.(column); ok && col.Table() != "" {
+			tables[strings.ToLower(col.Name())] = col.Table()
+		}
+	}
+
+	cond, _ := expression.TransformUp(having.Cond, func(e sql.Expression) (sql.Expression, error) {
+		col, ok := e.(*expression.UnresolvedColumn)
+		if !ok || col.Table() != "" {
+			return e, nil
+		}
+
+		if table, ok := tables[strings.ToLower(col.Name())]; ok {
+			return expression.NewUnresolvedQualifiedColumn(table, col.Name()), nil
+		}
+		return e, nil
+	})
+
+	return plan.NewHaving(cond, having.Child)
+}
+
+// qualifyExpression qualifies the columns of the expression with the tables
+// that have them among the available columns. Columns qualified with one of
+// the tables preceding a LATERAL derived table refer to its lateral columns
+// even if the tables of the query have columns with the same name.
 func qualifyExpression(
 	e sql.Expression,
 	columns map[string][]string,
+	lateral map[string][]string,
 	tables map[string]string,
 ) (sql.Expression, error) {
 	switch col := e.(type) {
@@ -169,7 +218,7 @@ func qualifyExpression(
 			// If the table exists but it's not available for this node it
 			// means some work is still needed, so just return the column
 			// and let it be resolved in the next pass.
-			if !stringContains(availableTables, table) {
+			if !stringContains(availableTables, table) && !stringContains(lateral[name], table) {
 				return col, nil
 			}
 
@@ -216,9 +265,41 @@ func qualifyExpression(
 func getNodeAvailableColumns(n sql.Node) map[string][]string {
 	var columns = make(map[string][]string)
 	getColumnsInNodes(n.Children(), columns)
+
+	// The columns of the tables preceding a LATERAL derived table are only
+	// available if the tables of its query don't have them.
+	for col, tables := range getNodeLateralColumns(n) {
+		if _, ok := columns[col]; !ok {
+			columns[col] = tables
+		}
+	}
+
+	return columns
+}
+
+// getNodeLateralColumns returns the columns of the tables preceding a
+// LATERAL derived table available for a node of its query.
+func getNodeLateralColumns(n sql.Node) map[string][]string {
+	var columns = make(map[string][]string)
+	getLateralColumnsInNodes(n.Children(), columns)
 	return columns
 }
 
+func getLateralColumnsInNodes(nodes []sql.Node, columns map[string][]string) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *plan.LateralRow:
+			for _, col := range n.Schema() {
+				name := strings.ToLower(col.Name)
+				columns[name] = append(columns[name], strings.ToLower(col.Source))
+			}
+		case *plan.ResolvedTable, *plan.SubqueryAlias, *plan.Project, *plan.GroupBy:
+		default:
+			getLateralColumnsInNodes(n.Children(), columns)
+		}
+	}
+}
+
 func getColumnsInNodes(nodes []sql.Node, columns map[string][]string) {
 	indexCol := func(table, col string) {
 		col = strings.ToLower(col)
@@ -244,6 +325,7 @@ func getColumnsInNodes(nodes []sql.Node, columns map[string][]string) {
 			for _, col := range n.Schema() {
 				indexCol(col.Source, col.Name)
 			}
+		case *plan.LateralRow:
 		case *plan.Project:
 			indexExpressions(n.Projections)
 		case *plan.GroupBy:
@@ -276,6 +358,13 @@ func getNodesAvailableTables(tables map[string]string, nodes ...sql.Node) {
 				// table with either the alias or the name.
 				tables[name] = name
 			}
+		case *plan.LateralRow:
+			// The tables of the query of the derived table come first.
+			for alias, name := range n.Tables() {
+				if _, ok := tables[alias]; !ok {
+					tables[alias] = name
+				}
+			}
 		default:
 			getNodesAvailableTables(tables, n.Children()...)
 		}
@@ -325,7 +414,7 @@ func resolveColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 				return resolveGlobalOrSessionColumn(ctx, uc)
 			}
 
-			return resolveColumnExpression(ctx, uc, columns)
+			return resolveColumnExpression(ctx, a, uc, columns)
 		})
 	})
 }
@@ -358,8 +447,11 @@ func resolveGlobalOrSessionColumn(ctx *sql.Context, col column) (sql.Expression,
 	return expression.NewGetSessionField(name, typ, value), nil
 }
 
+// resolveColumnExpression resolves a column with the given columns, which
+// must have the same name if the names are case sensitive in the catalog.
 func resolveColumnExpression(
 	ctx *sql.Context,
+	a *Analyzer,
 	e column,
 	columns map[tableCol]indexedCol,
 ) (sql.Expression, error) {
@@ -373,14 +465,14 @@ func resolveColumnExpression(
 			// time to resolve other parts so this can be resolved.
 			return &deferredColumn{uc}, nil
 		default:
-			if table != "" {
-				return nil, ErrColumnTableNotFound.New(e.Table(), e.Name())
-			}
-
-			return nil, ErrColumnNotFound.New(e.Name())
+			return nil, columnNotFound(e)
 		}
 	}
 
+	if a.caseSensitiveNames() && col.Name != e.Name() {
+		return nil, columnNotFound(e)
+	}
+
 	return expression.NewGetFieldWithTable(
 		col.index,
 		col.Type,
@@ -390,6 +482,14 @@ func resolveColumnExpression(
 	), nil
 }
 
+// columnNotFound returns the error of a column that does not exist.
+func columnNotFound(e column) error {
+	if e.Table() != "" {
+		return ErrColumnTableNotFound.New(e.Table(), e.Name())
+	}
+	return ErrColumnNotFound.New(e.Name())
+}
+
 // resolveGroupingColumns reorders the aggregation in a groupby so aliases
 // defined in it can be resolved in the grouping of the groupby. To do so,
 // all aliases are pushed down to a projection node under the group by.
@@ -406,16 +506,25 @@ func resolveGroupingColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node
 		}
 
 		// The reason we have two sets of columns, one for grouping and
-		// one for aggregate is because an alias can redefine a column name
-		// of the child schema. In the grouping, if that column is referenced
-		// it refers to the alias, and not the one in the child. However,
-		// in the aggregate, aliases in that same aggregate cannot be used,
-		// so it refers to the column in the child node.
+		// one for aggregate is because the grouping can refer to aliases
+		// defined in the aggregate, but in the aggregate, aliases in that
+		// same aggregate cannot be used, so it refers to the column in the
+		// child node. As in MySQL, if an alias redefines a column name of
+		// the child schema, the grouping refers to the column, and not to
+		// the alias.
+		childColumns := getNodeAvailableColumns(g)
 		var groupingColumns = make(map[string]struct{})
+		var groupingAliases = make(map[string]struct{})
 		for _, g := range g.Grouping {
 			for _, n := range findAllColumns(g) {
 				groupingColumns[strings.ToLower(n)] = struct{}{}
 			}
+
+			for _, col := range findUnqualifiedColumns(g) {
+				if _, ok := childColumns[col]; !ok {
+					groupingAliases[col] = struct{}{}
+				}
+			}
 		}
 
 		var aggregateColumns = make(map[string]struct{})
@@ -423,7 +532,7 @@ func resolveGroupingColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node
 			// This alias is going to be pushed down, so don't bother gathering
 			// its requirements.
 			if alias, ok := agg.(*expression.Alias); ok {
-				if _, ok := groupingColumns[strings.ToLower(alias.Name())]; ok {
+				if _, ok := groupingAliases[strings.ToLower(alias.Name())]; ok {
 					continue
 				}
 			}
@@ -442,18 +551,22 @@ func resolveGroupingColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node
 		var needsReorder bool
 		for _, a := range g.Aggregate {
 			alias, ok := a.(*expression.Alias)
-			// Note that aliases of aggregations cannot be used in the grouping
-			// because the grouping is needed before computing the aggregation.
-			if !ok || containsAggregation(alias) {
+			if !ok {
 				newAggregate = append(newAggregate, a)
 				continue
 			}
 
 			name := strings.ToLower(alias.Name())
+			_, ok = groupingAliases[name]
+			// Note that aliases of aggregations cannot be used in the grouping
+			// because the grouping is needed before computing the aggregation.
+			if ok && containsAggregation(alias) {
+				return nil, ErrGroupByAggregate.New(alias.Name())
+			}
+
 			// Only if the alias is required in the grouping set needsReorder
 			// to true. If it's not required, there's no need for a reorder if
 			// no other alias is required.
-			_, ok = groupingColumns[name]
 			if ok {
 				aliases[name] = len(newAggregate)
 				needsReorder = true
@@ -536,7 +649,7 @@ func resolveGroupingColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node
 		return plan.NewGroupBy(
 			newAggregate, g.Grouping,
 			plan.NewProject(projection, g.Child),
-		), nil
+		).WithGroupingSets(g.GroupingSets), nil
 	})
 }
 
@@ -552,6 +665,20 @@ func findAllColumns(e sql.Expression) []string {
 	return cols
 }
 
+// findUnqualifiedColumns returns the lowercased names of the columns of an
+// expression that are not qualified with a table, which may be aliases.
+func findUnqualifiedColumns(e sql.Expression) []string {
+	var cols []string
+	expression.Inspect(e, func(e sql.Expression) bool {
+		col, ok := e.(*expression.UnresolvedColumn)
+		if ok && col.Table() == "" {
+			cols = append(cols, strings.ToLower(col.Name()))
+		}
+		return true
+	})
+	return cols
+}
+
 func dedupStrings(in []string) []string {
 	var seen = make(map[string]struct{})
 	var result []string
@@ -73,6 +73,19 @@ func TestMisusedAlias(t *testing.T) {
 
 	_, err := f.Apply(sql.NewEmptyContext(), nil, node)
 	require.EqualError(err, ErrMisusedAlias.New("alias_i").Error())
+
+	// Columns of the table are not aliases, even if there are aliases with
+	// the same name.
+	node = plan.NewProject(
+		[]sql.Expression{
+			expression.NewAlias(expression.NewLiteral(int64(1), sql.Int64), "i"),
+			expression.NewAlias(expression.NewUnresolvedColumn("i"), "j"),
+		},
+		plan.NewResolvedTable(table),
+	)
+
+	_, err = f.Apply(sql.NewEmptyContext(), nil, node)
+	require.NoError(err)
 }
 
 func TestQualifyColumns(t *testing.T) {
@@ -376,3 +389,41 @@ func TestResolveGroupingColumns(t *testing.T) {
 
 	require.Equal(expected, result)
 }
+
+func TestResolveGroupingColumnsShadowedAlias(t *testing.T) {
+	require := require.New(t)
+
+	a := NewDefault(nil)
+	table := plan.NewResolvedTable(memory.NewTable("table", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "table"},
+		{Name: "b", Type: sql.Int64, Source: "table"},
+	}))
+
+	// The grouping refers to the column of the table and not to the alias
+	// with the same name.
+	node := plan.NewGroupBy(
+		[]sql.Expression{
+			expression.NewAlias(expression.NewUnresolvedColumn("a"), "b"),
+		},
+		[]sql.Expression{expression.NewUnresolvedColumn("b")},
+		table,
+	)
+
+	result, err := resolveGroupingColumns(sql.NewEmptyContext(), a, node)
+	require.NoError(err)
+	require.Equal(node, result)
+
+	node = plan.NewGroupBy(
+		[]sql.Expression{
+			expression.NewAlias(
+				expression.NewUnresolvedFunction("count", true, expression.NewStar()),
+				"c",
+			),
+		},
+		[]sql.Expression{expression.NewUnresolvedColumn("c")},
+		table,
+	)
+
+	_, err = resolveGroupingColumns(sql.NewEmptyContext(), a, node)
+	require.True(ErrGroupByAggregate.Is(err))
+}
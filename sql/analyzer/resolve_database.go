@@ -28,7 +28,7 @@ func resolveDatabase(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error
 			}
 		}
 
-		db, err := a.Catalog.Database(dbName)
+		db, err := a.Catalog.ResolveDatabase(ctx, dbName)
 		if err != nil {
 			return nil, err
 		}
@@ -1,6 +1,8 @@
 package analyzer
 
 import (
+	"strings"
+
 	"github.com/src-d/go-mysql-server/sql"
 	"github.com/src-d/go-mysql-server/sql/expression"
 	"github.com/src-d/go-mysql-server/sql/plan"
@@ -17,31 +19,107 @@ func resolveFunctions(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, erro
 			return n, nil
 		}
 
-		return plan.TransformExpressionsUp(n, func(e sql.Expression) (sql.Expression, error) {
+		n, err := plan.TransformExpressionsUp(n, func(e sql.Expression) (sql.Expression, error) {
 			a.Log("transforming expression of type: %T", e)
 			if e.Resolved() {
 				return e, nil
 			}
 
-			uf, ok := e.(*expression.UnresolvedFunction)
-			if !ok {
-				return e, nil
+			return resolveFunction(a, e)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The parser only knows the builtin aggregations, so a projection
+		// with aggregate functions of the catalog aggregates all the rows.
+		if p, ok := n.(*plan.Project); ok {
+			for _, e := range p.Projections {
+				if containsAggregation(e) {
+					return plan.NewGroupBy(p.Projections, nil, p.Child), nil
+				}
 			}
+		}
+
+		return n, nil
+	})
+}
+
+// resolveFunction resolves a call to a function with the function of the
+// catalog with its name.
+func resolveFunction(a *Analyzer, e sql.Expression) (sql.Expression, error) {
+	uf, ok := e.(*expression.UnresolvedFunction)
+	if !ok {
+		return e, nil
+	}
+
+	n := uf.Name()
+	f, err := a.Catalog.Function(n)
+	if err != nil {
+		return nil, err
+	}
+
+	rf, err := f.Call(uf.Arguments...)
+	if err != nil {
+		return nil, err
+	}
 
-			n := uf.Name()
-			f, err := a.Catalog.Function(n)
+	a.Log("resolved function %q", n)
+
+	return rf, nil
+}
+
+// resolveTableFunctions resolves the table functions in the FROM clause
+// with the tables of the rows they return for their arguments, which are
+// evaluated, so the schemas of the tables are known before the columns are
+// resolved, as the schemas of the rest of tables.
+func resolveTableFunctions(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("resolve_table_functions")
+	defer span.Finish()
+
+	return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+		t, ok := n.(*plan.UnresolvedTableFunction)
+		if !ok {
+			return n, nil
+		}
+
+		args := make([]interface{}, len(t.Arguments))
+		for i, arg := range t.Arguments {
+			arg, err := expression.TransformUp(arg, func(e sql.Expression) (sql.Expression, error) {
+				return resolveFunction(a, e)
+			})
 			if err != nil {
 				return nil, err
 			}
 
-			rf, err := f.Call(uf.Arguments...)
+			if !arg.Resolved() {
+				return nil, ErrTableFunctionArguments.New(t.Name())
+			}
+
+			v, err := arg.Eval(ctx, nil)
 			if err != nil {
 				return nil, err
 			}
+			args[i] = v
+		}
 
-			a.Log("resolved function %q", n)
+		f, err := a.Catalog.Function(strings.ToLower(t.Name()))
+		if err != nil {
+			return nil, err
+		}
 
-			return rf, nil
-		})
+		fn, ok := f.(sql.TableFunction)
+		if !ok {
+			return nil, sql.ErrNotTableFunction.New(t.Name())
+		}
+
+		table, err := fn.Table(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		a.Log("resolved table function %q", t.Name())
+
+		return plan.NewResolvedTable(table), nil
 	})
 }
@@ -27,10 +27,22 @@ func resolveHaving(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, erro
 		var requiresProjection bool
 		if containsAggregation(having.Cond) {
 			var err error
-			having, requiresProjection, err = replaceAggregations(having)
+			having, err = projectAggregationColumns(ctx, a, having)
 			if err != nil {
 				return nil, err
 			}
+
+			replaced, projection, err := replaceAggregations(ctx, a, having)
+			if err != nil {
+				return nil, err
+			}
+
+			// The columns of some aggregations can't be resolved yet, so
+			// they are replaced in the next pass.
+			if replaced == nil {
+				return having, nil
+			}
+			having, requiresProjection = replaced, projection
 		}
 
 		missingCols := findMissingColumns(having, having.Cond)
@@ -41,6 +53,14 @@ func resolveHaving(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, erro
 			if err != nil {
 				return nil, err
 			}
+
+			// The columns are resolved now that they are in the child, so
+			// the projection of the original aggregation is resolved too.
+			cond, err := resolveChildColumns(ctx, a, having, having.Cond)
+			if err != nil {
+				return nil, err
+			}
+			having = plan.NewHaving(cond, having.Child)
 			requiresProjection = true
 		}
 
@@ -52,23 +72,46 @@ func resolveHaving(ctx *sql.Context, a *Analyzer, node sql.Node) (sql.Node, erro
 	})
 }
 
-func findMissingColumns(node sql.Node, expr sql.Expression) []string {
-	var schemaCols []string
+func findMissingColumns(node sql.Node, expr sql.Expression) []tableCol {
+	var schemaCols []tableCol
 	for _, col := range node.Schema() {
-		schemaCols = append(schemaCols, strings.ToLower(col.Name))
+		schemaCols = append(schemaCols, tableCol{
+			strings.ToLower(col.Source),
+			strings.ToLower(col.Name),
+		})
 	}
 
-	var missingCols []string
+	var missingCols []tableCol
 	for _, n := range findExprNameables(expr) {
-		name := strings.ToLower(n.Name())
-		if !stringContains(schemaCols, name) {
-			missingCols = append(missingCols, n.Name())
+		missing := tableCol{col: strings.ToLower(n.Name())}
+		if t, ok := n.(sql.Tableable); ok {
+			missing.table = strings.ToLower(t.Table())
+		}
+
+		if !containsTableCol(schemaCols, missing) {
+			missingCols = append(missingCols, missing)
 		}
 	}
 
 	return missingCols
 }
 
+// containsTableCol reports whether a column is one of the given ones.
+func containsTableCol(cols []tableCol, col tableCol) bool {
+	for _, c := range cols {
+		if matchesTableCol(c, col) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTableCol reports whether a column is the one referenced by another,
+// which matches the columns of any table if it's not qualified.
+func matchesTableCol(col, ref tableCol) bool {
+	return col.col == ref.col && (ref.table == "" || col.table == ref.table)
+}
+
 func projectOriginalAggregation(having *plan.Having, schema sql.Schema) *plan.Project {
 	var projection []sql.Expression
 	for i, col := range schema {
@@ -85,7 +128,7 @@ var errHavingChildMissingRef = errors.NewKind("cannot find column %s referenced
 
 func pushMissingColumnsUp(
 	having *plan.Having,
-	missingCols []string,
+	missingCols []tableCol,
 ) (*plan.Having, error) {
 	groupBy, err := findGroupBy(having)
 	if err != nil {
@@ -97,13 +140,13 @@ func pushMissingColumnsUp(
 	for _, c := range missingCols {
 		idx := -1
 		for i, col := range schema {
-			if strings.ToLower(c) == strings.ToLower(col.Name) {
+			if matchesTableCol(tableCol{strings.ToLower(col.Source), strings.ToLower(col.Name)}, c) {
 				idx = i
 				break
 			}
 		}
 		if idx < 0 {
-			return nil, errHavingChildMissingRef.New(c)
+			return nil, errHavingChildMissingRef.New(c.col)
 		}
 		col := schema[idx]
 		newAggregate = append(
@@ -120,6 +163,93 @@ func pushMissingColumnsUp(
 	return node.(*plan.Having), nil
 }
 
+// projectAggregationColumns adds the columns used by the aggregations of the
+// condition of a having to the projection under its group by, if any, which
+// projects the aliases used in the grouping.
+func projectAggregationColumns(ctx *sql.Context, a *Analyzer, having *plan.Having) (*plan.Having, error) {
+	groupBy, err := findGroupBy(having)
+	if err != nil {
+		return nil, err
+	}
+
+	project, ok := groupBy.Child.(*plan.Project)
+	if !ok {
+		return having, nil
+	}
+
+	var names []string
+	for _, col := range project.Schema() {
+		names = append(names, strings.ToLower(col.Name))
+	}
+
+	var missing []sql.Expression
+	expression.Inspect(having.Cond, func(e sql.Expression) bool {
+		if _, ok := e.(sql.Aggregation); !ok || err != nil {
+			return err == nil
+		}
+
+		for _, n := range findExprNameables(e) {
+			col, ok := n.(column)
+			name := strings.ToLower(n.Name())
+			if !ok || col.Resolved() || stringContains(names, name) {
+				continue
+			}
+
+			var resolved sql.Expression
+			resolved, err = resolveChildColumns(
+				ctx, a, project,
+				expression.NewUnresolvedQualifiedColumn(col.Table(), col.Name()),
+			)
+			if err != nil {
+				return false
+			}
+
+			if resolved.Resolved() {
+				names = append(names, name)
+				missing = append(missing, resolved)
+			}
+		}
+		return false
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missing) == 0 {
+		return having, nil
+	}
+
+	node, err := withGroupByChild(having, plan.NewProject(
+		append(append([]sql.Expression{}, project.Projections...), missing...),
+		project.Child,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return node.(*plan.Having), nil
+}
+
+// withGroupByChild replaces the child of the group by under a node.
+func withGroupByChild(n sql.Node, child sql.Node) (sql.Node, error) {
+	if g, ok := n.(*plan.GroupBy); ok {
+		return plan.NewGroupBy(g.Aggregate, g.Grouping, child).WithGroupingSets(g.GroupingSets), nil
+	}
+
+	children := n.Children()
+	if len(children) != 1 {
+		return nil, errHavingNeedsGroupBy.New()
+	}
+
+	newChild, err := withGroupByChild(children[0], child)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.WithChildren(newChild)
+}
+
 func findGroupBy(n sql.Node) (*plan.GroupBy, error) {
 	children := n.Children()
 	if len(children) != 1 {
@@ -175,7 +305,9 @@ func addColumnsToGroupBy(node sql.Node, columns []sql.Expression) (sql.Node, err
 		}
 		return node.WithChildren(child)
 	case *plan.GroupBy:
-		return plan.NewGroupBy(append(node.Aggregate, columns...), node.Grouping, node.Child), nil
+		return plan.NewGroupBy(
+			append(node.Aggregate, columns...), node.Grouping, node.Child,
+		).WithGroupingSets(node.GroupingSets), nil
 	default:
 		return nil, errHavingNeedsGroupBy.New()
 	}
@@ -245,7 +377,11 @@ func pushColumnsUp(node sql.Node, columns []int) (sql.Node, []int, error) {
 		if err != nil {
 			return nil, nil, err
 		}
-		return plan.NewLimit(node.Limit, child), columns, nil
+		n, err := node.WithChildren(child)
+		if err != nil {
+			return nil, nil, err
+		}
+		return n, columns, nil
 	case *plan.Offset:
 		child, columns, err := pushColumnsUp(node.Child, columns)
 		if err != nil {
@@ -271,13 +407,18 @@ func pushColumnsUp(node sql.Node, columns []int) (sql.Node, []int, error) {
 	}
 }
 
-func replaceAggregations(having *plan.Having) (*plan.Having, bool, error) {
+// replaceAggregations replaces the aggregations of the condition of a having
+// with the columns of the group by that compute them, adding the ones that
+// are not computed yet. It returns a nil having if the columns of the new
+// aggregations can't be resolved yet.
+func replaceAggregations(ctx *sql.Context, a *Analyzer, having *plan.Having) (*plan.Having, bool, error) {
 	groupBy, err := findGroupBy(having)
 	if err != nil {
 		return nil, false, err
 	}
 
 	var newAggregate []sql.Expression
+	var unresolved bool
 
 	var pushUp []int
 	var tokenToIdx = make(map[int]int)
@@ -314,18 +455,34 @@ func replaceAggregations(having *plan.Having) (*plan.Having, bool, error) {
 			}
 		}
 
-		newAggregate = append(newAggregate, agg)
+		// The columns of the new aggregations are the ones of the child of
+		// the group by, where they will be computed.
+		resolved, err := resolveChildColumns(ctx, a, groupBy, agg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !resolved.Resolved() {
+			unresolved = true
+			return e, nil
+		}
+
+		newAggregate = append(newAggregate, resolved)
 		return expression.NewGetField(
 			len(having.Child.Schema())+len(newAggregate)-1,
-			agg.Type(),
-			agg.String(),
-			agg.IsNullable(),
+			resolved.Type(),
+			resolved.String(),
+			resolved.IsNullable(),
 		), nil
 	})
 	if err != nil {
 		return nil, false, err
 	}
 
+	if unresolved {
+		return nil, false, nil
+	}
+
 	// The new aggregations will be added to the group by and pushed up until
 	// the topmost node.
 	having = plan.NewHaving(cond, having.Child)
@@ -369,6 +526,33 @@ func replaceAggregations(having *plan.Having) (*plan.Having, bool, error) {
 	return plan.NewHaving(cond, having.Child), requiresProjection, nil
 }
 
+// resolveChildColumns resolves the columns of an expression with the columns
+// of the children of a node, leaving the ones that are not found unresolved.
+func resolveChildColumns(ctx *sql.Context, a *Analyzer, n sql.Node, e sql.Expression) (sql.Expression, error) {
+	columns := getNodeAvailableColumns(n)
+	lateral := getNodeLateralColumns(n)
+	tables := getNodeAvailableTables(n)
+	indexed := findChildIndexedColumns(n)
+
+	return expression.TransformUp(e, func(e sql.Expression) (sql.Expression, error) {
+		if _, ok := e.(column); !ok || e.Resolved() {
+			return e, nil
+		}
+
+		qualified, err := qualifyExpression(e, columns, lateral, tables)
+		if err != nil {
+			return nil, err
+		}
+
+		uc, ok := qualified.(column)
+		if !ok || qualified.Resolved() {
+			return qualified, nil
+		}
+
+		return resolveColumnExpression(ctx, a, uc, indexed)
+	})
+}
+
 func aggregationEquals(a, b sql.Expression) bool {
 	// First unwrap aliases
 	if alias, ok := b.(*expression.Alias); ok {
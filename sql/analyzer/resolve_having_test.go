@@ -114,7 +114,7 @@ func TestResolveHaving(t *testing.T) {
 				},
 				plan.NewHaving(
 					expression.NewGreaterThan(
-						expression.NewUnresolvedColumn("i"),
+						expression.NewGetFieldWithTable(1, sql.Int64, "t", "i", false),
 						expression.NewLiteral(int64(5), sql.Int64),
 					),
 					plan.NewGroupBy(
@@ -161,7 +161,7 @@ func TestResolveHaving(t *testing.T) {
 				},
 				plan.NewHaving(
 					expression.NewGreaterThan(
-						expression.NewUnresolvedColumn("i"),
+						expression.NewGetFieldWithTable(1, sql.Int64, "t", "i", false),
 						expression.NewLiteral(int64(5), sql.Int64),
 					),
 					plan.NewProject(
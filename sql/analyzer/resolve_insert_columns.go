@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// resolveInsertColumns replaces the columns of the inserts with the names
+// of the columns of their tables, which are written in any case if the
+// names are not case sensitive in the catalog.
+func resolveInsertColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("resolve_insert_columns")
+	defer span.Finish()
+
+	if a.caseSensitiveNames() {
+		return n, nil
+	}
+
+	return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+		insert, ok := n.(*plan.InsertInto)
+		if !ok || len(insert.Columns) == 0 || !insert.Left.Resolved() {
+			return n, nil
+		}
+
+		var names = make(map[string]string)
+		for _, col := range insert.Left.Schema() {
+			names[strings.ToLower(col.Name)] = col.Name
+		}
+
+		var columns = make([]string, len(insert.Columns))
+		for i, col := range insert.Columns {
+			columns[i] = col
+			if name, ok := names[strings.ToLower(col)]; ok {
+				columns[i] = name
+			}
+		}
+
+		ni := *insert
+		ni.Columns = columns
+		return &ni, nil
+	})
+}
@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveInsertColumns(t *testing.T) {
+	require := require.New(t)
+
+	f := getRule("resolve_insert_columns")
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "Id", Type: sql.Int64, Source: "t"},
+		{Name: "name", Type: sql.Text, Source: "t"},
+	})
+
+	values := plan.NewValues([][]sql.Expression{{
+		expression.NewLiteral(int64(1), sql.Int64),
+		expression.NewLiteral("foo", sql.Text),
+	}})
+	node := plan.NewInsertInto(plan.NewResolvedTable(table), values, false, []string{"ID", "Name"})
+
+	catalog := sql.NewCatalog()
+	a := NewBuilder(catalog).Build()
+
+	result, err := f.Apply(sql.NewEmptyContext(), a, node)
+	require.NoError(err)
+	require.Equal([]string{"Id", "name"}, result.(*plan.InsertInto).Columns)
+
+	catalog.SetCaseSensitiveNames(true)
+	result, err = f.Apply(sql.NewEmptyContext(), a, node)
+	require.NoError(err)
+	require.Equal([]string{"ID", "Name"}, result.(*plan.InsertInto).Columns)
+}
@@ -118,7 +118,7 @@ func fixSortDependencies(sort *plan.Sort, missingCols []string) (sql.Node, error
 			expressions,
 			plan.NewSort(
 				sort.SortFields,
-				plan.NewGroupBy(newExpressions, child.Grouping, child.Child),
+				plan.NewGroupBy(newExpressions, child.Grouping, child.Child).WithGroupingSets(child.GroupingSets),
 			),
 		), nil
 	default:
@@ -162,7 +162,7 @@ func pushSortDown(sort *plan.Sort) (sql.Node, error) {
 			child.Aggregate,
 			child.Grouping,
 			plan.NewSort(sort.SortFields, child.Child),
-		), nil
+		).WithGroupingSets(child.GroupingSets), nil
 	case *plan.ResolvedTable:
 		return sort, nil
 	default:
@@ -23,7 +23,7 @@ func resolveStar(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 				return n, nil
 			}
 
-			expressions, err := expandStars(n.Projections, n.Child.Schema())
+			expressions, err := expandStars(n.Projections, n.Child.Schema(), lateralColumns(n.Child))
 			if err != nil {
 				return nil, err
 			}
@@ -34,25 +34,32 @@ func resolveStar(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 				return n, nil
 			}
 
-			aggregate, err := expandStars(n.Aggregate, n.Child.Schema())
+			aggregate, err := expandStars(n.Aggregate, n.Child.Schema(), lateralColumns(n.Child))
 			if err != nil {
 				return nil, err
 			}
 
-			return plan.NewGroupBy(aggregate, n.Grouping, n.Child), nil
+			return plan.NewGroupBy(aggregate, n.Grouping, n.Child).WithGroupingSets(n.GroupingSets), nil
 		default:
 			return n, nil
 		}
 	})
 }
 
-func expandStars(exprs []sql.Expression, schema sql.Schema) ([]sql.Expression, error) {
+// expandStars expands the stars of the expressions with the columns of the
+// schema. Stars without a table don't expand the given lateral columns, of
+// the tables preceding a LATERAL derived table.
+func expandStars(
+	exprs []sql.Expression,
+	schema sql.Schema,
+	lateral map[*sql.Column]bool,
+) ([]sql.Expression, error) {
 	var expressions []sql.Expression
 	for _, e := range exprs {
 		if s, ok := e.(*expression.Star); ok {
 			var exprs []sql.Expression
 			for i, col := range schema {
-				if s.Table == "" || s.Table == col.Source {
+				if s.Table == "" && !lateral[col] || s.Table == col.Source {
 					exprs = append(exprs, expression.NewGetFieldWithTable(
 						i, col.Type, col.Source, col.Name, col.Nullable,
 					))
@@ -11,9 +11,24 @@ func resolveSubqueries(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, err
 	defer span.Finish()
 
 	a.Log("resolving subqueries")
+
+	// The derived tables of lateral joins are resolved once the tables
+	// preceding them are, as they refer to their columns.
+	var lateral = make(map[sql.Node]bool)
+	plan.Inspect(n, func(n sql.Node) bool {
+		if j, ok := n.(*plan.LateralJoin); ok {
+			lateral[j.Right] = true
+		}
+		return true
+	})
+
 	n, err := plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
 		switch n := n.(type) {
 		case *plan.SubqueryAlias:
+			if lateral[n] {
+				return n, nil
+			}
+
 			a.Log("found subquery %q with child of type %T", n.Name(), n.Child)
 			child, err := a.Analyze(ctx, n.Child)
 			if err != nil {
@@ -47,3 +62,86 @@ func resolveSubqueries(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, err
 		return s.WithQuery(q), nil
 	})
 }
+
+// resolveLateralJoins resolves the derived tables of lateral joins, whose
+// queries read the row of the tables preceding them, so they can refer to
+// their columns. The columns of the tables of the query come first, as in
+// MySQL, and the row is bound to each row of the tables when the join is
+// executed.
+func resolveLateralJoins(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("resolve_lateral_joins")
+	defer span.Finish()
+
+	return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+		j, ok := n.(*plan.LateralJoin)
+		if !ok {
+			return n, nil
+		}
+
+		sq, ok := j.Right.(*plan.SubqueryAlias)
+		if !ok || sq.Resolved() {
+			return n, nil
+		}
+
+		a.Log("found lateral subquery %q with child of type %T", sq.Name(), sq.Child)
+
+		tables := make(map[string]string)
+		getNodesAvailableTables(tables, j.Left)
+		row := plan.NewLateralRow(j.Left.Schema(), tables)
+
+		child, err := withLateralRow(sq.Child, row)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err = a.Analyze(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+
+		return j.WithChildren(j.Left, plan.NewSubqueryAlias(sq.Name(), child))
+	})
+}
+
+// withLateralRow returns the query of a LATERAL derived table reading the
+// given lateral row along with the tables of its FROM clause.
+func withLateralRow(n sql.Node, row *plan.LateralRow) (sql.Node, error) {
+	switch n.(type) {
+	case *plan.TableAlias, *plan.SubqueryAlias:
+		return plan.NewCrossJoin(row, n), nil
+	}
+
+	children := n.Children()
+	if len(children) != 1 {
+		return plan.NewCrossJoin(row, n), nil
+	}
+
+	child, err := withLateralRow(children[0], row)
+	if err != nil {
+		return nil, err
+	}
+	return n.WithChildren(child)
+}
+
+// lateralColumns returns the columns of the lateral rows read by the node,
+// which are not expanded by the stars of its query.
+func lateralColumns(n sql.Node) map[*sql.Column]bool {
+	var columns = make(map[*sql.Column]bool)
+	plan.Inspect(n, func(n sql.Node) bool {
+		switch n := n.(type) {
+		case *plan.LateralRow:
+			for _, col := range n.Schema() {
+				columns[col] = true
+			}
+		case *plan.LateralJoin:
+			for col := range lateralColumns(n.Left) {
+				columns[col] = true
+			}
+			return false
+		case *plan.SubqueryAlias:
+			return false
+		}
+		return true
+	})
+	return columns
+}
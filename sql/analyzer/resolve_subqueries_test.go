@@ -88,3 +88,77 @@ func TestResolveSubqueries(t *testing.T) {
 	require.NoError(err)
 	require.Equal(expected, result)
 }
+
+func TestResolveLateralJoins(t *testing.T) {
+	require := require.New(t)
+
+	table1 := memory.NewTable("foo", sql.Schema{{Name: "a", Type: sql.Int64, Source: "foo"}})
+	table2 := memory.NewTable("bar", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "bar"},
+		{Name: "b", Type: sql.Int64, Source: "bar"},
+	})
+	db := memory.NewDatabase("mydb")
+	db.AddTable("foo", table1)
+	db.AddTable("bar", table2)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	a := withoutProcessTracking(NewDefault(catalog))
+
+	// SELECT * FROM foo f, LATERAL (SELECT a, b FROM bar WHERE b = f.a) t
+	node := plan.NewLateralJoin(
+		plan.NewTableAlias("f", plan.NewResolvedTable(table1)),
+		plan.NewSubqueryAlias(
+			"t",
+			plan.NewProject(
+				[]sql.Expression{
+					expression.NewUnresolvedColumn("a"),
+					expression.NewUnresolvedColumn("b"),
+				},
+				plan.NewFilter(
+					expression.NewEquals(
+						expression.NewUnresolvedColumn("b"),
+						expression.NewUnresolvedQualifiedColumn("f", "a"),
+					),
+					plan.NewUnresolvedTable("bar", ""),
+				),
+			),
+		),
+		expression.NewLiteral(true, sql.Boolean),
+	)
+
+	result, err := resolveLateralJoins(sql.NewEmptyContext(), a, node)
+	require.NoError(err)
+	require.True(result.Resolved())
+
+	var row *plan.LateralRow
+	plan.Inspect(result, func(n sql.Node) bool {
+		if r, ok := n.(*plan.LateralRow); ok {
+			row = r
+		}
+		return true
+	})
+	require.NotNil(row)
+	require.Equal(map[string]string{"f": "foo", "foo": "foo"}, row.Tables())
+
+	// The column a of the query is the one of bar, not the one of foo.
+	require.Equal(sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Int64, Source: "t"},
+	}, result.(*plan.LateralJoin).Right.Schema())
+	require.Equal(
+		"bar.b = foo.a",
+		findFilterExpression(result.(*plan.LateralJoin).Right).String(),
+	)
+}
+
+func findFilterExpression(n sql.Node) sql.Expression {
+	var e sql.Expression
+	plan.Inspect(n, func(n sql.Node) bool {
+		if f, ok := n.(*plan.Filter); ok {
+			e = f.Expression
+		}
+		return e == nil
+	})
+	return e
+}
@@ -3,6 +3,7 @@ package analyzer
 import (
 	"github.com/src-d/go-mysql-server/memory"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
 	"github.com/src-d/go-mysql-server/sql/plan"
 )
 
@@ -38,7 +39,7 @@ func resolveTables(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			db = a.Catalog.CurrentDatabase()
 		}
 
-		rt, err := a.Catalog.Table(db, name)
+		rt, err := a.Catalog.ResolveTable(ctx, db, name)
 		if err != nil {
 			if sql.ErrTableNotFound.Is(err) && name == dualTableName {
 				rt = dualTable
@@ -48,8 +49,121 @@ func resolveTables(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error)
 			}
 		}
 
+		if t.AsOf != nil {
+			rt, err = resolveAsOf(ctx, a, t, rt)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if t.Sample != nil {
+			rt, err = resolveTableSample(ctx, a, t, rt)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		a.Log("table resolved: %q", t.Name())
 
 		return plan.NewResolvedTable(rt), nil
 	})
 }
+
+// resolveAsOf returns the given table as of the version it's queried, which
+// is evaluated.
+func resolveAsOf(
+	ctx *sql.Context,
+	a *Analyzer,
+	t *plan.UnresolvedTable,
+	table sql.Table,
+) (sql.Table, error) {
+	versioned, ok := table.(sql.VersionedTable)
+	if !ok {
+		return nil, sql.ErrAsOfNotSupported.New(t.Name())
+	}
+
+	version, err := expression.TransformUp(t.AsOf, func(e sql.Expression) (sql.Expression, error) {
+		return resolveFunction(a, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !version.Resolved() {
+		return nil, ErrAsOfVersion.New(t.Name())
+	}
+
+	v, err := version.Eval(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return versioned.AsOf(ctx, v)
+}
+
+// resolveTableSample returns the given table with only the rows of the
+// sample it's queried with, which is sampled by the table itself if it's a
+// sql.SampledTable.
+func resolveTableSample(
+	ctx *sql.Context,
+	a *Analyzer,
+	t *plan.UnresolvedTable,
+	table sql.Table,
+) (sql.Table, error) {
+	percentage, err := evalTableSampleArgument(ctx, a, t, t.Sample.Percentage, sql.Float64)
+	if err != nil {
+		return nil, err
+	}
+
+	sample, err := sql.NewTableSample(t.Sample.Method, percentage.(float64))
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Sample.Seed != nil {
+		seed, err := evalTableSampleArgument(ctx, a, t, t.Sample.Seed, sql.Int64)
+		if err != nil {
+			return nil, err
+		}
+		sample = sample.WithSeed(seed.(int64))
+	}
+
+	if sampled, ok := table.(sql.SampledTable); ok {
+		a.Log("table %q samples its rows", t.Name())
+		return sampled.WithSample(sample), nil
+	}
+
+	return plan.NewSamplingTable(table, sample), nil
+}
+
+// evalTableSampleArgument evaluates the percentage or the seed of the
+// sample of a table, which must be constant, converted to the given type.
+func evalTableSampleArgument(
+	ctx *sql.Context,
+	a *Analyzer,
+	t *plan.UnresolvedTable,
+	e sql.Expression,
+	typ sql.Type,
+) (interface{}, error) {
+	e, err := expression.TransformUp(e, func(e sql.Expression) (sql.Expression, error) {
+		return resolveFunction(a, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.Resolved() {
+		return nil, ErrTableSampleArguments.New(t.Name())
+	}
+
+	v, err := e.Eval(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, ErrTableSampleArguments.New(t.Name())
+	}
+
+	return typ.Convert(v)
+}
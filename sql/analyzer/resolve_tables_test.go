@@ -91,3 +91,147 @@ func TestResolveTablesNested(t *testing.T) {
 	)
 	require.Equal(expected, analyzed)
 }
+
+type versionedTable struct {
+	*memory.Table
+	versions map[string]sql.Table
+}
+
+func (t *versionedTable) AsOf(ctx *sql.Context, version interface{}) (sql.Table, error) {
+	v, ok := t.versions[version.(string)]
+	if !ok {
+		return nil, sql.ErrVersionNotFound.New(t.Name(), version)
+	}
+	return v, nil
+}
+
+func TestResolveTablesAsOf(t *testing.T) {
+	require := require.New(t)
+
+	f := getRule("resolve_tables")
+
+	old := memory.NewTable("mytable", sql.Schema{{Name: "i", Type: sql.Int32}})
+	db := memory.NewDatabase("mydb")
+	db.AddTable("mytable", &versionedTable{
+		Table:    memory.NewTable("mytable", sql.Schema{{Name: "i", Type: sql.Int32}}),
+		versions: map[string]sql.Table{"v1": old},
+	})
+	db.AddTable("other", memory.NewTable("other", nil))
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+
+	a := NewBuilder(catalog).AddPostAnalyzeRule(f.Name, f.Apply).Build()
+
+	asOf := func(name string, version sql.Expression) sql.Node {
+		t := plan.NewUnresolvedTable(name, "")
+		t.AsOf = version
+		return t
+	}
+
+	analyzed, err := f.Apply(sql.NewEmptyContext(), a, asOf("mytable", expression.NewLiteral("v1", sql.Text)))
+	require.NoError(err)
+	require.Equal(plan.NewResolvedTable(old), analyzed)
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, asOf("mytable", expression.NewLiteral("v2", sql.Text)))
+	require.True(sql.ErrVersionNotFound.Is(err))
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, asOf("mytable", expression.NewUnresolvedColumn("i")))
+	require.True(ErrAsOfVersion.Is(err))
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, asOf("other", expression.NewLiteral("v1", sql.Text)))
+	require.True(sql.ErrAsOfNotSupported.Is(err))
+}
+
+type sampledTable struct {
+	*memory.Table
+	sample *sql.TableSample
+}
+
+func (t *sampledTable) WithSample(sample sql.TableSample) sql.Table {
+	return &sampledTable{t.Table, &sample}
+}
+
+func (t *sampledTable) Sample() *sql.TableSample {
+	return t.sample
+}
+
+func TestResolveTablesTableSample(t *testing.T) {
+	require := require.New(t)
+
+	f := getRule("resolve_tables")
+
+	table := memory.NewTable("mytable", sql.Schema{{Name: "i", Type: sql.Int32}})
+	sampled := &sampledTable{Table: memory.NewTable("sampled", sql.Schema{{Name: "i", Type: sql.Int32}})}
+	db := memory.NewDatabase("mydb")
+	db.AddTable("mytable", table)
+	db.AddTable("sampled", sampled)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+
+	a := NewBuilder(catalog).AddPostAnalyzeRule(f.Name, f.Apply).Build()
+
+	sample := func(name, method string, percentage, seed sql.Expression) sql.Node {
+		t := plan.NewUnresolvedTable(name, "")
+		t.Sample = &plan.UnresolvedTableSample{Method: method, Percentage: percentage, Seed: seed}
+		return t
+	}
+
+	ten := expression.NewLiteral(int8(10), sql.Int8)
+	analyzed, err := f.Apply(
+		sql.NewEmptyContext(), a,
+		sample("sampled", "system", ten, expression.NewLiteral("42", sql.Text)),
+	)
+	require.NoError(err)
+	require.Equal(plan.NewResolvedTable(&sampledTable{sampled.Table, &sql.TableSample{
+		Method:     sql.SystemSample,
+		Percentage: 10,
+		Repeatable: true,
+		Seed:       42,
+	}}), analyzed)
+
+	analyzed, err = f.Apply(sql.NewEmptyContext(), a, sample("mytable", "bernoulli", ten, nil))
+	require.NoError(err)
+	wrapper, ok := analyzed.(*plan.ResolvedTable).Table.(*plan.SamplingTable)
+	require.True(ok)
+	require.Equal(table, wrapper.Table)
+	require.Equal(&sql.TableSample{Method: sql.BernoulliSample, Percentage: 10}, wrapper.Sample())
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, sample("mytable", "bernoulli", expression.NewUnresolvedColumn("i"), nil))
+	require.True(ErrTableSampleArguments.Is(err))
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, sample("mytable", "bernoulli", ten, expression.NewLiteral(nil, sql.Null)))
+	require.True(ErrTableSampleArguments.Is(err))
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, sample("mytable", "block", ten, nil))
+	require.True(sql.ErrInvalidSampleMethod.Is(err))
+
+	for _, percentage := range []interface{}{int16(200), int8(-1)} {
+		_, err = f.Apply(sql.NewEmptyContext(), a, sample("mytable", "bernoulli", expression.NewLiteral(percentage, sql.Int16), nil))
+		require.True(sql.ErrInvalidSamplePercentage.Is(err))
+	}
+}
+
+func TestResolveTablesCaseSensitive(t *testing.T) {
+	require := require.New(t)
+
+	f := getRule("resolve_tables")
+
+	table := memory.NewTable("MyTable", sql.Schema{{Name: "i", Type: sql.Int32}})
+	db := memory.NewDatabase("mydb")
+	db.AddTable("MyTable", table)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	catalog.SetCaseSensitiveNames(true)
+
+	a := NewBuilder(catalog).AddPostAnalyzeRule(f.Name, f.Apply).Build()
+
+	analyzed, err := f.Apply(sql.NewEmptyContext(), a, plan.NewUnresolvedTable("MyTable", ""))
+	require.NoError(err)
+	require.Equal(plan.NewResolvedTable(table), analyzed)
+
+	_, err = f.Apply(sql.NewEmptyContext(), a, plan.NewUnresolvedTable("mytable", ""))
+	require.True(sql.ErrTableNotFound.Is(err))
+}
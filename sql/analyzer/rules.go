@@ -26,8 +26,14 @@ var DefaultRules = []Rule{
 // OnceBeforeDefault contains the rules to be applied just once before the
 // DefaultRules.
 var OnceBeforeDefault = []Rule{
+	{"check_privileges", checkPrivileges},
+	{"record_changes", recordChanges},
+	{"decorrelate_subqueries", decorrelateSubqueries},
 	{"resolve_subqueries", resolveSubqueries},
 	{"resolve_tables", resolveTables},
+	{"resolve_table_functions", resolveTableFunctions},
+	{"resolve_lateral_joins", resolveLateralJoins},
+	{"resolve_insert_columns", resolveInsertColumns},
 	{"check_aliases", checkAliases},
 }
 
@@ -36,17 +42,27 @@ var OnceBeforeDefault = []Rule{
 var OnceAfterDefault = []Rule{
 	{"resolve_generators", resolveGenerators},
 	{"remove_unnecessary_converts", removeUnnecessaryConverts},
+	{"simplify_expressions", simplifyExpressions},
+	{"subqueries_to_semi_joins", convertSubqueriesToSemiJoins},
 	{"assign_catalog", assignCatalog},
 	{"prune_columns", pruneColumns},
 	{"convert_dates", convertDates},
+	{"reorder_joins", reorderJoins},
+	{"optimize_joins", optimizeJoins},
+	{"prune_partitions", prunePartitions},
+	{"bloom_filters", addBloomFilters},
 	{"pushdown", pushdown},
+	{"index_joins", indexJoins},
 	{"erase_projection", eraseProjection},
+	{"pushdown_limit", pushdownLimit},
+	{"eliminate_common_subqueries", eliminateCommonSubqueries},
 }
 
 // OnceAfterAll contains the rules to be applied just once after all other
 // rules have been applied.
 var OnceAfterAll = []Rule{
 	{"track_process", trackProcess},
+	{"reset_bloom_filters", resetBloomFilters},
 	{"parallelize", parallelize},
 	{"clear_warnings", clearWarnings},
 }
@@ -63,9 +79,22 @@ var (
 	ErrAmbiguousColumnName = errors.NewKind("ambiguous column name %q, it's present in all these tables: %v")
 	// ErrFieldMissing is returned when the field is not on the schema.
 	ErrFieldMissing = errors.NewKind("field %q is not on schema")
+
+	// ErrTableFunctionArguments is returned when the arguments of a table
+	// function are not constant.
+	ErrTableFunctionArguments = errors.NewKind("arguments of table function %q must be constant")
+	// ErrAsOfVersion is returned when the version a table is queried as of
+	// is not constant.
+	ErrAsOfVersion = errors.NewKind("version of table %q in AS OF must be constant")
+	// ErrTableSampleArguments is returned when the percentage or the seed
+	// of the sample of a table are not constant.
+	ErrTableSampleArguments = errors.NewKind("percentage and seed of TABLESAMPLE of table %q must be constant and not NULL")
 	// ErrOrderByColumnIndex is returned when in an order clause there is a
 	// column that is unknown.
 	ErrOrderByColumnIndex = errors.NewKind("unknown column %d in order by clause")
+	// ErrGroupByAggregate is returned when the grouping of a group by refers
+	// to an aggregation.
+	ErrGroupByAggregate = errors.NewKind("can't group on %q")
 	// ErrMisusedAlias is returned when a alias is defined and used in the same projection.
 	ErrMisusedAlias = errors.NewKind("column %q does not exist in scope, but there is an alias defined in" +
 		" this projection with that name. Aliases cannot be used in the same projection they're defined in")
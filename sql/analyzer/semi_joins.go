@@ -0,0 +1,358 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// decorrelateSubqueries replaces the IN, EXISTS and NOT EXISTS subqueries
+// of filters whose conditions refer to the tables of the filtered node, and
+// can't be resolved on their own, with semi and anti joins of the node and
+// the subquery. The conjunctions of the condition of the subquery that refer
+// to the tables of the node are moved to the condition of the join, and the
+// subquery returns the columns of its own tables they use instead. As in
+// MySQL, the unqualified columns of the subquery refer to its own tables.
+// Subqueries that refer to the tables of the node anywhere else, or whose
+// query is not a projection of a filter, are left as they are.
+func decorrelateSubqueries(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("decorrelate_subqueries")
+	defer span.Finish()
+
+	var subqueries int
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		filter, ok := node.(*plan.Filter)
+		if !ok || filter.Resolved() {
+			return node, nil
+		}
+
+		outer := queryTables(filter.Child)
+		var child = filter.Child
+		var remaining []sql.Expression
+		for _, e := range splitExpression(filter.Expression) {
+			name := fmt.Sprintf("__correlated%d", subqueries+1)
+			right, anti, cond, err := decorrelateSubquery(e, name, outer)
+			if err != nil {
+				return nil, err
+			}
+
+			if right == nil {
+				remaining = append(remaining, e)
+				continue
+			}
+
+			subqueries++
+			a.Log("decorrelating subquery %q, anti: %t", name, anti)
+
+			if anti {
+				child = plan.NewAntiJoin(child, right, cond)
+			} else {
+				child = plan.NewSemiJoin(child, right, cond)
+			}
+		}
+
+		if child == filter.Child {
+			return node, nil
+		}
+
+		if len(remaining) == 0 {
+			return child, nil
+		}
+
+		return plan.NewFilter(expression.JoinAnd(remaining...), child), nil
+	})
+}
+
+// decorrelateSubquery returns the derived table with the given name of the
+// subquery of the given conjunction of a filter if it refers to the given
+// tables of the filtered node, along with whether it must be an anti join
+// and the condition of the join.
+func decorrelateSubquery(
+	e sql.Expression,
+	name string,
+	outer map[string]bool,
+) (right sql.Node, anti bool, cond sql.Expression, err error) {
+	var subquery *expression.Subquery
+	var left sql.Expression
+	switch e := e.(type) {
+	case *expression.Exists:
+		subquery = e.Query
+	case *expression.Not:
+		exists, ok := e.Child.(*expression.Exists)
+		if !ok {
+			return nil, false, nil, nil
+		}
+		subquery, anti = exists.Query, true
+	case *expression.In:
+		s, ok := e.Right().(*expression.Subquery)
+		if !ok {
+			return nil, false, nil, nil
+		}
+		subquery, left = s, e.Left()
+	default:
+		return nil, false, nil, nil
+	}
+
+	project, ok := subquery.Query.(*plan.Project)
+	if !ok || (left != nil && len(project.Projections) != 1) {
+		return nil, false, nil, nil
+	}
+
+	filter, ok := project.Child.(*plan.Filter)
+	if !ok {
+		return nil, false, nil, nil
+	}
+
+	// The tables of the subquery hide the ones of the node with the same
+	// name.
+	var tables = make(map[string]bool)
+	for table := range outer {
+		tables[table] = true
+	}
+	for table := range queryTables(filter.Child) {
+		delete(tables, table)
+	}
+
+	var correlated, conds []sql.Expression
+	for _, e := range splitExpression(filter.Expression) {
+		if refersToTables(e, tables) {
+			correlated = append(correlated, e)
+		} else {
+			conds = append(conds, e)
+		}
+	}
+
+	if len(correlated) == 0 || refersToTables(expression.JoinAnd(conds...), tables) {
+		return nil, false, nil, nil
+	}
+
+	for _, e := range project.Projections {
+		if refersToTables(e, tables) {
+			return nil, false, nil, nil
+		}
+	}
+
+	var fromRefersToTables bool
+	plan.Inspect(filter.Child, func(n sql.Node) bool {
+		if exp, ok := n.(sql.Expressioner); ok {
+			for _, e := range exp.Expressions() {
+				fromRefersToTables = fromRefersToTables || refersToTables(e, tables)
+			}
+		}
+		return !fromRefersToTables
+	})
+	if fromRefersToTables {
+		return nil, false, nil, nil
+	}
+
+	// The subquery returns the value compared by IN, if any, and the
+	// columns of its tables used by the conditions moved to the join, which
+	// refer to them by their position.
+	var projections []sql.Expression
+	var columns = make(map[string]sql.Expression)
+	column := func(e sql.Expression) sql.Expression {
+		if col, ok := columns[e.String()]; ok {
+			return col
+		}
+
+		alias := fmt.Sprintf("__column%d", len(projections)+1)
+		projections = append(projections, expression.NewAlias(e, alias))
+		col := expression.NewUnresolvedQualifiedColumn(name, alias)
+		columns[e.String()] = col
+		return col
+	}
+
+	var joinConds []sql.Expression
+	if left != nil {
+		value := project.Projections[0]
+		if alias, ok := value.(*expression.Alias); ok {
+			value = alias.Child
+		}
+		joinConds = append(joinConds, expression.NewEquals(left, column(value)))
+	}
+
+	for _, e := range correlated {
+		cond, err := expression.TransformUp(e, func(e sql.Expression) (sql.Expression, error) {
+			if col, ok := e.(*expression.UnresolvedColumn); ok && !refersToTables(col, tables) {
+				return column(col), nil
+			}
+			return e, nil
+		})
+		if err != nil {
+			return nil, false, nil, err
+		}
+		joinConds = append(joinConds, cond)
+	}
+
+	if len(projections) == 0 {
+		projections = append(projections, expression.NewAlias(
+			expression.NewLiteral(true, sql.Boolean),
+			"__column1",
+		))
+	}
+
+	var child = filter.Child
+	if len(conds) > 0 {
+		child = plan.NewFilter(expression.JoinAnd(conds...), child)
+	}
+
+	right = plan.NewSubqueryAlias(name, plan.NewProject(projections, child))
+	return right, anti, expression.JoinAnd(joinConds...), nil
+}
+
+// queryTables returns the names and aliases of the tables of a query, which
+// may not be resolved yet, in lower case.
+func queryTables(n sql.Node) map[string]bool {
+	var tables = make(map[string]bool)
+	plan.Inspect(n, func(n sql.Node) bool {
+		switch n := n.(type) {
+		case *plan.TableAlias:
+			tables[strings.ToLower(n.Name())] = true
+			if t, ok := n.Child.(sql.Nameable); ok {
+				tables[strings.ToLower(t.Name())] = true
+			}
+			return false
+		case *plan.SubqueryAlias, *plan.UnresolvedTable, *plan.ResolvedTable:
+			tables[strings.ToLower(n.(sql.Nameable).Name())] = true
+			return false
+		}
+		return true
+	})
+	return tables
+}
+
+// refersToTables reports whether the expression has columns qualified with
+// any of the given tables.
+func refersToTables(e sql.Expression, tables map[string]bool) bool {
+	if e == nil {
+		return false
+	}
+
+	var found bool
+	expression.Inspect(e, func(e sql.Expression) bool {
+		if col, ok := e.(*expression.UnresolvedColumn); ok && tables[strings.ToLower(col.Table())] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// convertSubqueriesToSemiJoins replaces the IN and EXISTS subqueries of
+// filters with semi joins of the filtered node and the subquery, and the
+// NOT IN and NOT EXISTS subqueries with anti joins, so the rows of the
+// subquery are read once and looked up instead of compared with every row.
+// Only the subqueries that are conjunctions of the filter can be replaced,
+// and NOT IN only when neither side can be null, as NOT IN is null instead
+// of true when any of them is.
+func convertSubqueriesToSemiJoins(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("subqueries_to_semi_joins")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	var subqueries int
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		filter, ok := node.(*plan.Filter)
+		if !ok {
+			return node, nil
+		}
+
+		var child = filter.Child
+		var remaining []sql.Expression
+		for _, e := range splitExpression(filter.Expression) {
+			subquery, anti, cond := semiJoinCondition(e)
+			if subquery == nil {
+				remaining = append(remaining, e)
+				continue
+			}
+
+			subqueries++
+			name := fmt.Sprintf("__subquery%d", subqueries)
+			right := plan.NewSubqueryAlias(name, subquery.Query)
+
+			if cond == nil {
+				cond = expression.NewLiteral(true, sql.Boolean)
+			} else {
+				col := right.Schema()[0]
+				cond = expression.NewEquals(cond, expression.NewGetFieldWithTable(
+					len(child.Schema()),
+					col.Type,
+					name,
+					col.Name,
+					col.Nullable,
+				))
+			}
+
+			a.Log("converting subquery %q to semi join, anti: %t", name, anti)
+
+			if anti {
+				child = plan.NewAntiJoin(child, right, cond)
+			} else {
+				child = plan.NewSemiJoin(child, right, cond)
+			}
+		}
+
+		if child == filter.Child {
+			return node, nil
+		}
+
+		if len(remaining) == 0 {
+			return child, nil
+		}
+
+		return plan.NewFilter(expression.JoinAnd(remaining...), child), nil
+	})
+}
+
+// semiJoinCondition returns the subquery of the given conjunction of a
+// filter if it can be replaced with a semi join, whether it must be an anti
+// join and the expression of the left side that must be equal to the value
+// of the subquery, which is nil for EXISTS.
+func semiJoinCondition(e sql.Expression) (subquery *expression.Subquery, anti bool, left sql.Expression) {
+	if not, ok := e.(*expression.Not); ok {
+		if exists, ok := not.Child.(*expression.Exists); ok && exists.Resolved() {
+			return exists.Query, true, nil
+		}
+		return nil, false, nil
+	}
+
+	switch e := e.(type) {
+	case *expression.Exists:
+		if e.Resolved() {
+			return e.Query, false, nil
+		}
+	case *expression.In:
+		s, ok := e.Right().(*expression.Subquery)
+		if ok && canCompareWithSubquery(e.Left(), s) {
+			return s, false, e.Left()
+		}
+	case *expression.NotIn:
+		s, ok := e.Right().(*expression.Subquery)
+		if ok && canCompareWithSubquery(e.Left(), s) &&
+			!e.Left().IsNullable() && !s.IsNullable() {
+			return s, true, e.Left()
+		}
+	}
+
+	return nil, false, nil
+}
+
+// canCompareWithSubquery reports whether comparing the expression with the
+// values of the subquery for equality gives the same result as IN, which
+// is the case when both are numbers or both are text.
+func canCompareWithSubquery(e sql.Expression, s *expression.Subquery) bool {
+	if !s.Resolved() || len(s.Query.Schema()) != 1 || containsSubquery(e) {
+		return false
+	}
+
+	left, right := e.Type(), s.Type()
+	return left == right ||
+		(sql.IsNumber(left) && sql.IsNumber(right)) ||
+		(sql.IsText(left) && sql.IsText(right))
+}
@@ -0,0 +1,231 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertSubqueriesToSemiJoins(t *testing.T) {
+	f := getRule("subqueries_to_semi_joins")
+
+	t1 := plan.NewResolvedTable(memory.NewTable("t1", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t1"},
+		{Name: "b", Type: sql.Int64, Source: "t1", Nullable: true},
+	}))
+	t2 := plan.NewResolvedTable(memory.NewTable("t2", sql.Schema{
+		{Name: "c", Type: sql.Int64, Source: "t2"},
+		{Name: "d", Type: sql.Int64, Source: "t2", Nullable: true},
+	}))
+
+	c := expression.NewSubquery(plan.NewProject(
+		[]sql.Expression{col(0, "t2", "c")},
+		t2,
+	))
+	d := expression.NewSubquery(plan.NewProject(
+		[]sql.Expression{expression.NewGetFieldWithTable(1, sql.Int64, "t2", "d", true)},
+		t2,
+	))
+	// The rule reads the schema of the subquery aliases to build the join
+	// conditions, which is then kept in the alias.
+	alias := func(name string, n sql.Node) *plan.SubqueryAlias {
+		a := plan.NewSubqueryAlias(name, n)
+		_ = a.Schema()
+		return a
+	}
+
+	exists := expression.NewExists(expression.NewSubquery(t2))
+	greater := expression.NewGreaterThan(col(0, "t1", "a"), lit(1))
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected sql.Node
+	}{
+		{
+			"in",
+			plan.NewFilter(expression.NewIn(col(0, "t1", "a"), c), t1),
+			plan.NewSemiJoin(
+				t1,
+				alias("__subquery1", c.Query),
+				eq(col(0, "t1", "a"), col(2, "__subquery1", "c")),
+			),
+		},
+		{
+			"not in with non nullable values",
+			plan.NewFilter(expression.NewNotIn(col(0, "t1", "a"), c), t1),
+			plan.NewAntiJoin(
+				t1,
+				alias("__subquery1", c.Query),
+				eq(col(0, "t1", "a"), col(2, "__subquery1", "c")),
+			),
+		},
+		{
+			"not in with nullable values",
+			plan.NewFilter(expression.NewNotIn(col(0, "t1", "a"), d), t1),
+			plan.NewFilter(expression.NewNotIn(col(0, "t1", "a"), d), t1),
+		},
+		{
+			"not exists and other conjunctions",
+			plan.NewFilter(
+				expression.NewAnd(
+					greater,
+					expression.NewNot(exists),
+				),
+				t1,
+			),
+			plan.NewFilter(
+				greater,
+				plan.NewAntiJoin(
+					t1,
+					plan.NewSubqueryAlias("__subquery1", t2),
+					expression.NewLiteral(true, sql.Boolean),
+				),
+			),
+		},
+		{
+			"exists in a disjunction",
+			plan.NewFilter(expression.NewOr(greater, exists), t1),
+			plan.NewFilter(expression.NewOr(greater, exists), t1),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDecorrelateSubqueries(t *testing.T) {
+	f := getRule("decorrelate_subqueries")
+
+	t1 := plan.NewUnresolvedTable("t1", "")
+	t2 := plan.NewUnresolvedTable("t2", "")
+	a := expression.NewUnresolvedColumn("a")
+	c := expression.NewUnresolvedColumn("c")
+	t1a := expression.NewUnresolvedQualifiedColumn("t1", "a")
+	t2c := expression.NewUnresolvedQualifiedColumn("t2", "c")
+	greater := expression.NewGreaterThan(c, lit(1))
+
+	subquery := func(projection sql.Expression, cond sql.Expression) *expression.Subquery {
+		return expression.NewSubquery(plan.NewProject(
+			[]sql.Expression{projection},
+			plan.NewFilter(cond, t2),
+		))
+	}
+	column := func(n int) sql.Expression {
+		return expression.NewUnresolvedQualifiedColumn("__correlated1", fmt.Sprintf("__column%d", n))
+	}
+	alias := func(e sql.Expression, n int) sql.Expression {
+		return expression.NewAlias(e, fmt.Sprintf("__column%d", n))
+	}
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected sql.Node
+	}{
+		{
+			"exists",
+			plan.NewFilter(
+				expression.NewExists(subquery(expression.NewStar(), eq(t2c, t1a))),
+				t1,
+			),
+			plan.NewSemiJoin(
+				t1,
+				plan.NewSubqueryAlias("__correlated1", plan.NewProject(
+					[]sql.Expression{alias(t2c, 1)},
+					t2,
+				)),
+				eq(column(1), t1a),
+			),
+		},
+		{
+			"not exists and other conjunctions",
+			plan.NewFilter(
+				expression.NewAnd(
+					expression.NewNot(expression.NewExists(
+						subquery(c, expression.NewAnd(greater, eq(c, t1a))),
+					)),
+					eq(a, lit(2)),
+				),
+				t1,
+			),
+			plan.NewFilter(
+				eq(a, lit(2)),
+				plan.NewAntiJoin(
+					t1,
+					plan.NewSubqueryAlias("__correlated1", plan.NewProject(
+						[]sql.Expression{alias(c, 1)},
+						plan.NewFilter(greater, t2),
+					)),
+					eq(column(1), t1a),
+				),
+			),
+		},
+		{
+			"in",
+			plan.NewFilter(
+				expression.NewIn(a, subquery(
+					expression.NewAlias(c, "x"),
+					expression.NewGreaterThan(t1a, expression.NewUnresolvedColumn("d")),
+				)),
+				t1,
+			),
+			plan.NewSemiJoin(
+				t1,
+				plan.NewSubqueryAlias("__correlated1", plan.NewProject(
+					[]sql.Expression{alias(c, 1), alias(expression.NewUnresolvedColumn("d"), 2)},
+					t2,
+				)),
+				expression.NewAnd(
+					eq(a, column(1)),
+					expression.NewGreaterThan(t1a, column(2)),
+				),
+			),
+		},
+		{
+			"not correlated",
+			plan.NewFilter(expression.NewExists(subquery(c, greater)), t1),
+			plan.NewFilter(expression.NewExists(subquery(c, greater)), t1),
+		},
+		{
+			"correlated projection",
+			plan.NewFilter(expression.NewExists(subquery(t1a, eq(c, t1a))), t1),
+			plan.NewFilter(expression.NewExists(subquery(t1a, eq(c, t1a))), t1),
+		},
+		{
+			"table of the subquery with the same name",
+			plan.NewFilter(
+				expression.NewExists(expression.NewSubquery(plan.NewProject(
+					[]sql.Expression{c},
+					plan.NewFilter(eq(t1a, lit(1)), t1),
+				))),
+				t1,
+			),
+			plan.NewFilter(
+				expression.NewExists(expression.NewSubquery(plan.NewProject(
+					[]sql.Expression{c},
+					plan.NewFilter(eq(t1a, lit(1)), t1),
+				))),
+				t1,
+			),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package analyzer
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// simplifyExpressions evaluates in advance the expressions that don't
+// depend on the rows, removes the predicates that are always true or false
+// and pushes negations down to the comparisons, so the predicates can be
+// split into more conjunctions to push down and evaluating them is cheaper.
+// Filters that are always true are removed, and the ones that are never
+// true are replaced by an empty table.
+func simplifyExpressions(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, ctx := ctx.Span("simplify_expressions")
+	defer span.Finish()
+
+	if !n.Resolved() {
+		return n, nil
+	}
+
+	a.Log("simplifying expressions, node of type: %T", n)
+
+	simplify := func(e sql.Expression) (sql.Expression, error) {
+		return simplifyExpression(ctx, e)
+	}
+
+	return plan.TransformUp(n, func(node sql.Node) (sql.Node, error) {
+		switch node := node.(type) {
+		case *plan.Filter:
+			cond, err := simplify(node.Expression)
+			if err != nil {
+				return nil, err
+			}
+
+			if isTrue(cond) {
+				return node.Child, nil
+			}
+
+			if isFalse(cond) || isNull(cond) {
+				return plan.EmptyTable, nil
+			}
+
+			return plan.NewFilter(cond, node.Child), nil
+		case *plan.InnerJoin:
+			cond, err := simplify(node.Cond)
+			if err != nil {
+				return nil, err
+			}
+
+			if isTrue(cond) {
+				return plan.NewCrossJoin(node.Left, node.Right), nil
+			}
+
+			return node.WithExpressions(cond)
+		case *plan.Project:
+			projections, err := simplifyProjections(ctx, node.Projections)
+			if err != nil {
+				return nil, err
+			}
+
+			return plan.NewProject(projections, node.Child), nil
+		case *plan.GroupBy:
+			aggregate, err := simplifyProjections(ctx, node.Aggregate)
+			if err != nil {
+				return nil, err
+			}
+
+			var grouping = make([]sql.Expression, len(node.Grouping))
+			for i, g := range node.Grouping {
+				grouping[i], err = simplify(g)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return plan.NewGroupBy(aggregate, grouping, node.Child).WithGroupingSets(node.GroupingSets), nil
+		case *plan.Having, *plan.LeftJoin, *plan.RightJoin, *plan.Sort:
+			return plan.TransformExpressions(node, simplify)
+		default:
+			return node, nil
+		}
+	})
+}
+
+// simplifyProjections simplifies the given expressions, which are the
+// columns of a node, keeping the names of the columns.
+func simplifyProjections(ctx *sql.Context, exprs []sql.Expression) ([]sql.Expression, error) {
+	var result = make([]sql.Expression, len(exprs))
+	for i, e := range exprs {
+		if alias, ok := e.(*expression.Alias); ok {
+			child, err := simplifyExpression(ctx, alias.Child)
+			if err != nil {
+				return nil, err
+			}
+
+			result[i], err = alias.WithChildren(child)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		simplified, err := simplifyExpression(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+
+		// Simplified expressions are always written differently.
+		if _, ok := e.(sql.Nameable); ok || simplified.String() == e.String() {
+			result[i] = e
+		} else {
+			result[i] = expression.NewAlias(simplified, e.String())
+		}
+	}
+	return result, nil
+}
+
+// simplifyExpression returns the given expression with all its constant
+// parts evaluated and its logical operators simplified. The arguments of
+// aggregations are left as they are, as they are evaluated with each of the
+// aggregated rows and may be placeholders, such as the star of COUNT(*).
+func simplifyExpression(ctx *sql.Context, e sql.Expression) (sql.Expression, error) {
+	if _, ok := e.(sql.Aggregation); ok {
+		return e, nil
+	}
+
+	children := e.Children()
+	if len(children) > 0 {
+		var newChildren = make([]sql.Expression, len(children))
+		for i, c := range children {
+			var err error
+			newChildren[i], err = simplifyExpression(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		e, err = e.WithChildren(newChildren...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return simplifyNode(ctx, e)
+}
+
+// simplifyNode simplifies the given expression, whose children are already
+// simplified.
+func simplifyNode(ctx *sql.Context, e sql.Expression) (sql.Expression, error) {
+	switch e := e.(type) {
+	case *expression.Not:
+		return negate(e.Child), nil
+	case *expression.And:
+		if isFalse(e.Left) {
+			return e.Left, nil
+		}
+
+		if isFalse(e.Right) {
+			return e.Right, nil
+		}
+
+		if isTrue(e.Left) && e.Right.Type() == sql.Boolean {
+			return e.Right, nil
+		}
+
+		if isTrue(e.Right) && e.Left.Type() == sql.Boolean {
+			return e.Left, nil
+		}
+	case *expression.Or:
+		if isTrue(e.Left) {
+			return e.Left, nil
+		}
+
+		if isTrue(e.Right) {
+			return e.Right, nil
+		}
+
+		if isFalse(e.Left) && e.Right.Type() == sql.Boolean {
+			return e.Right, nil
+		}
+
+		if isFalse(e.Right) && e.Left.Type() == sql.Boolean {
+			return e.Left, nil
+		}
+	case *expression.Equals:
+		// A column that can't be null is always equal to itself.
+		if isSameColumn(e.Left(), e.Right()) && !e.Left().IsNullable() {
+			return expression.NewLiteral(true, sql.Boolean), nil
+		}
+	}
+
+	if !isConstant(e) {
+		return e, nil
+	}
+
+	val, err := e.Eval(ctx, nil)
+	if err != nil {
+		// The error will be returned when the query is executed, if the
+		// expression is evaluated at all.
+		return e, nil
+	}
+
+	return expression.NewLiteral(val, e.Type()), nil
+}
+
+// negate returns the negation of the given expression, with the negation
+// pushed down to its comparisons when possible.
+func negate(e sql.Expression) sql.Expression {
+	switch e := e.(type) {
+	case *expression.Not:
+		// The negation of a negation is a boolean, so it's only the same as
+		// the negated expression when it's already a boolean.
+		if e.Child.Type() == sql.Boolean {
+			return e.Child
+		}
+	case *expression.And:
+		return expression.NewOr(negate(e.Left), negate(e.Right))
+	case *expression.Or:
+		return expression.NewAnd(negate(e.Left), negate(e.Right))
+	case *expression.GreaterThan:
+		return expression.NewLessThanOrEqual(e.Left(), e.Right())
+	case *expression.GreaterThanOrEqual:
+		return expression.NewLessThan(e.Left(), e.Right())
+	case *expression.LessThan:
+		return expression.NewGreaterThanOrEqual(e.Left(), e.Right())
+	case *expression.LessThanOrEqual:
+		return expression.NewGreaterThan(e.Left(), e.Right())
+	case *expression.In:
+		return expression.NewNotIn(e.Left(), e.Right())
+	case *expression.NotIn:
+		return expression.NewIn(e.Left(), e.Right())
+	case *expression.Literal:
+		if v, ok := e.Value().(bool); ok && e.Type() == sql.Boolean {
+			return expression.NewLiteral(!v, sql.Boolean)
+		}
+	}
+
+	return expression.NewNot(e)
+}
+
+// isConstant returns whether the expression can be evaluated before the
+// query is executed and replaced with its result.
+func isConstant(e sql.Expression) bool {
+	switch e.(type) {
+	case *expression.Literal, expression.Tuple, *expression.Alias,
+		*expression.Interval, *function.Generate:
+		return false
+	}
+
+	if !isEvaluable(e) {
+		return false
+	}
+
+	var constant = true
+	expression.Inspect(e, func(e sql.Expression) bool {
+		switch e := e.(type) {
+		case sql.Aggregation, *expression.BloomFilter, *expression.Star:
+			constant = false
+		case *expression.GetSessionField:
+			// Values of the session can't be part of plans that may be
+			// reused by other sessions.
+			constant = false
+		case sql.NonDeterministicExpression:
+			constant = !e.IsNonDeterministic()
+		}
+		return constant
+	})
+	return constant
+}
+
+// isSameColumn returns whether both expressions are the same column.
+func isSameColumn(left, right sql.Expression) bool {
+	l, ok := left.(*expression.GetField)
+	if !ok {
+		return false
+	}
+
+	r, ok := right.(*expression.GetField)
+	if !ok {
+		return false
+	}
+
+	return l.Index() == r.Index() && l.Table() == r.Table() && l.Name() == r.Name()
+}
@@ -0,0 +1,201 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function"
+	"github.com/src-d/go-mysql-server/sql/expression/function/aggregation"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimplifyExpressions(t *testing.T) {
+	f := getRule("simplify_expressions")
+
+	t1 := plan.NewResolvedTable(memory.NewTable("t1", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t1"},
+		{Name: "b", Type: sql.Int64, Source: "t1"},
+	}))
+	t2 := plan.NewResolvedTable(memory.NewTable("t2", sql.Schema{
+		{Name: "c", Type: sql.Int64, Source: "t2"},
+	}))
+
+	plus := expression.NewPlus(lit(1), lit(1))
+	now := function.NewNow()
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected sql.Node
+	}{
+		{
+			"tautology is removed",
+			plan.NewFilter(
+				expression.NewAnd(
+					eq(lit(1), lit(1)),
+					expression.NewGreaterThan(col(0, "t1", "a"), lit(1)),
+				),
+				t1,
+			),
+			plan.NewFilter(
+				expression.NewGreaterThan(col(0, "t1", "a"), lit(1)),
+				t1,
+			),
+		},
+		{
+			"filter always true",
+			plan.NewFilter(
+				expression.NewOr(
+					eq(col(0, "t1", "a"), col(0, "t1", "a")),
+					expression.NewGreaterThan(col(1, "t1", "b"), lit(1)),
+				),
+				t1,
+			),
+			t1,
+		},
+		{
+			"filter never true",
+			plan.NewFilter(
+				expression.NewAnd(
+					expression.NewGreaterThan(col(0, "t1", "a"), lit(1)),
+					eq(lit(1), lit(2)),
+				),
+				t1,
+			),
+			plan.EmptyTable,
+		},
+		{
+			"filter with null",
+			plan.NewFilter(
+				eq(lit(1), expression.NewLiteral(nil, sql.Null)),
+				t1,
+			),
+			plan.EmptyTable,
+		},
+		{
+			"de morgan",
+			plan.NewFilter(
+				expression.NewNot(expression.NewOr(
+					expression.NewGreaterThan(col(0, "t1", "a"), lit(1)),
+					expression.NewNot(expression.NewLessThan(col(1, "t1", "b"), lit(2))),
+				)),
+				t1,
+			),
+			plan.NewFilter(
+				expression.NewAnd(
+					expression.NewLessThanOrEqual(col(0, "t1", "a"), lit(1)),
+					expression.NewLessThan(col(1, "t1", "b"), lit(2)),
+				),
+				t1,
+			),
+		},
+		{
+			"double negation of a non boolean",
+			plan.NewFilter(
+				expression.NewNot(expression.NewNot(col(0, "t1", "a"))),
+				t1,
+			),
+			plan.NewFilter(
+				expression.NewNot(expression.NewNot(col(0, "t1", "a"))),
+				t1,
+			),
+		},
+		{
+			"negation of in",
+			plan.NewFilter(
+				expression.NewNot(expression.NewIn(
+					col(0, "t1", "a"),
+					expression.NewTuple(lit(1), plus),
+				)),
+				t1,
+			),
+			plan.NewFilter(
+				expression.NewNotIn(
+					col(0, "t1", "a"),
+					expression.NewTuple(lit(1), expression.NewLiteral(int64(2), plus.Type())),
+				),
+				t1,
+			),
+		},
+		{
+			"constant projections keep their names",
+			plan.NewProject(
+				[]sql.Expression{
+					plus,
+					expression.NewAlias(plus, "foo"),
+					now,
+					col(0, "t1", "a"),
+				},
+				t1,
+			),
+			plan.NewProject(
+				[]sql.Expression{
+					expression.NewAlias(expression.NewLiteral(int64(2), plus.Type()), plus.String()),
+					expression.NewAlias(expression.NewLiteral(int64(2), plus.Type()), "foo"),
+					now,
+					col(0, "t1", "a"),
+				},
+				t1,
+			),
+		},
+		{
+			"arguments of aggregations",
+			plan.NewGroupBy(
+				[]sql.Expression{
+					aggregation.NewCount(expression.NewStar()),
+					aggregation.NewSum(plus),
+				},
+				[]sql.Expression{col(0, "t1", "a")},
+				t1,
+			),
+			plan.NewGroupBy(
+				[]sql.Expression{
+					aggregation.NewCount(expression.NewStar()),
+					aggregation.NewSum(plus),
+				},
+				[]sql.Expression{col(0, "t1", "a")},
+				t1,
+			),
+		},
+		{
+			"session variables",
+			plan.NewProject(
+				[]sql.Expression{
+					expression.NewPlus(
+						expression.NewGetSessionField("sql_select_limit", sql.Int64, int64(1)),
+						lit(1),
+					),
+				},
+				t1,
+			),
+			plan.NewProject(
+				[]sql.Expression{
+					expression.NewPlus(
+						expression.NewGetSessionField("sql_select_limit", sql.Int64, int64(1)),
+						lit(1),
+					),
+				},
+				t1,
+			),
+		},
+		{
+			"join always true",
+			plan.NewInnerJoin(t1, t2, expression.NewOr(
+				eq(col(0, "t1", "a"), col(2, "t2", "c")),
+				eq(lit(1), lit(1)),
+			)),
+			plan.NewCrossJoin(t1, t2),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := f.Apply(sql.NewEmptyContext(), NewDefault(nil), tt.node)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
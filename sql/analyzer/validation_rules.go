@@ -6,6 +6,7 @@ import (
 	"github.com/src-d/go-mysql-server/sql"
 	"github.com/src-d/go-mysql-server/sql/expression"
 	"github.com/src-d/go-mysql-server/sql/expression/function"
+	"github.com/src-d/go-mysql-server/sql/expression/function/aggregation"
 	"github.com/src-d/go-mysql-server/sql/plan"
 	"gopkg.in/src-d/go-errors.v1"
 )
@@ -21,6 +22,7 @@ const (
 	validateIntervalUsageRule   = "validate_interval_usage"
 	validateExplodeUsageRule    = "validate_explode_usage"
 	validateSubqueryColumnsRule = "validate_subquery_columns"
+	validateFunctionArgsRule    = "validate_function_arguments"
 )
 
 var (
@@ -32,6 +34,9 @@ var (
 	// ErrValidationGroupBy is returned when the aggregation expression does not
 	// appear in the grouping columns.
 	ErrValidationGroupBy = errors.NewKind("GroupBy aggregate expression '%v' doesn't appear in the grouping columns")
+	// ErrGroupingArgument is returned when an argument of GROUPING is not
+	// one of the grouping expressions of its query.
+	ErrGroupingArgument = errors.NewKind("argument %d of GROUPING is not in GROUP BY: %s")
 	// ErrValidationSchemaSource is returned when there is any column source
 	// that does not match the table name.
 	ErrValidationSchemaSource = errors.NewKind("one or more schema sources are empty")
@@ -78,6 +83,7 @@ var DefaultValidationRules = []Rule{
 	{validateIntervalUsageRule, validateIntervalUsage},
 	{validateExplodeUsageRule, validateExplodeUsage},
 	{validateSubqueryColumnsRule, validateSubqueryColumns},
+	{validateFunctionArgsRule, validateFunctionArguments},
 }
 
 func validateIsResolved(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
@@ -108,49 +114,145 @@ func validateOrderBy(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error
 	return n, nil
 }
 
+// onlyFullGroupBy is the SQL mode in which the grouped queries can't select
+// columns that are not grouped nor aggregated.
+const onlyFullGroupBy = "ONLY_FULL_GROUP_BY"
+
 func validateGroupBy(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 	span, _ := ctx.Span("validate_group_by")
 	defer span.Finish()
 
-	switch n := n.(type) {
-	case *plan.GroupBy:
-		// Allow the parser use the GroupBy node to eval the aggregation functions
-		// for sql statementes that don't make use of the GROUP BY expression.
-		if len(n.Grouping) == 0 {
-			return n, nil
+	// Unless ONLY_FULL_GROUP_BY is enabled, the columns that are not grouped
+	// nor aggregated have the value of any row of their group, as in MySQL.
+	onlyFull := ctx.HasSQLMode(onlyFullGroupBy)
+
+	var err error
+	plan.Inspect(n, func(node sql.Node) bool {
+		gb, ok := node.(*plan.GroupBy)
+		if !ok {
+			return true
+		}
+
+		for _, expr := range gb.Grouping {
+			if containsAggregation(expr) {
+				err = ErrGroupByAggregate.New(expr.String())
+				return false
+			}
 		}
 
 		var validAggs []string
-		for _, expr := range n.Grouping {
+		for _, expr := range gb.Grouping {
 			validAggs = append(validAggs, expr.String())
+			if alias, ok := expr.(*expression.Alias); ok {
+				validAggs = append(validAggs, alias.Child.String())
+			}
 		}
 
-		// TODO: validate columns inside aggregations
-		// and allow any kind of expression that make use of the grouping
-		// columns.
-		for _, expr := range n.Aggregate {
-			if _, ok := expr.(sql.Aggregation); !ok {
-				if !isValidAgg(validAggs, expr) {
-					return nil, ErrValidationGroupBy.New(expr.String())
-				}
+		if err = validateGroupingArguments(validAggs, gb.Aggregate); err != nil {
+			return false
+		}
+
+		if !onlyFull {
+			return true
+		}
+
+		dependent := dependentTables(gb)
+		for _, expr := range gb.Aggregate {
+			if !isValidAgg(validAggs, dependent, expr) {
+				err = ErrValidationGroupBy.New(expr.String())
+				return false
 			}
 		}
 
-		return n, nil
+		return true
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return n, nil
 }
 
-func isValidAgg(validAggs []string, expr sql.Expression) bool {
+// validateGroupingArguments checks that the arguments of the calls to
+// GROUPING in the given expressions are grouping expressions, given by
+// their string.
+func validateGroupingArguments(grouping []string, exprs []sql.Expression) error {
+	var err error
+	for _, e := range exprs {
+		expression.Inspect(e, func(e sql.Expression) bool {
+			if err != nil {
+				return false
+			}
+
+			g, ok := e.(*aggregation.Grouping)
+			if !ok {
+				return true
+			}
+
+			for i, arg := range g.Children() {
+				if !stringContains(grouping, arg.String()) {
+					err = ErrGroupingArgument.New(i+1, arg)
+					return false
+				}
+			}
+			return false
+		})
+	}
+	return err
+}
+
+// isValidAgg reports whether an expression of a grouped query has a single
+// value in each group, that is, whether it's an aggregation, an ANY_VALUE,
+// one of the grouping expressions, or an expression of those and columns of
+// the tables whose rows are determined by the grouping columns.
+func isValidAgg(validAggs []string, dependent map[string]bool, expr sql.Expression) bool {
 	switch expr := expr.(type) {
-	case sql.Aggregation:
+	case sql.Aggregation, *function.AnyValue:
 		return true
 	case *expression.Alias:
-		return isValidAgg(validAggs, expr.Child)
-	default:
-		return stringContains(validAggs, expr.String())
+		return isValidAgg(validAggs, dependent, expr.Child)
 	}
+
+	if stringContains(validAggs, expr.String()) {
+		return true
+	}
+
+	if gf, ok := expr.(*expression.GetField); ok {
+		return dependent[gf.Table()]
+	}
+
+	for _, child := range expr.Children() {
+		if !isValidAgg(validAggs, dependent, child) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dependentTables returns the tables of the child of a GroupBy whose primary
+// key columns are all grouping columns, whose other columns are functionally
+// dependent on them.
+func dependentTables(n *plan.GroupBy) map[string]bool {
+	grouped := make(map[string]bool)
+	for _, expr := range n.Grouping {
+		if gf, ok := expr.(*expression.GetField); ok {
+			grouped[gf.Table()+"."+gf.Name()] = true
+		}
+	}
+
+	dependent := make(map[string]bool)
+	for _, col := range n.Child.Schema() {
+		if !col.PrimaryKey {
+			continue
+		}
+
+		covered, ok := dependent[col.Source]
+		dependent[col.Source] = (covered || !ok) && grouped[col.Source+"."+col.Name]
+	}
+
+	return dependent
 }
 
 func validateSchemaSource(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
@@ -333,6 +435,11 @@ func validateExplodeUsage(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node,
 func validateSubqueryColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
 	valid := true
 	plan.InspectExpressions(n, func(e sql.Expression) bool {
+		// The subquery of EXISTS can return any number of columns.
+		if _, ok := e.(*expression.Exists); ok {
+			return false
+		}
+
 		s, ok := e.(*expression.Subquery)
 		if ok && len(s.Query.Schema()) != 1 {
 			valid = false
@@ -349,6 +456,66 @@ func validateSubqueryColumns(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Nod
 	return n, nil
 }
 
+// validateFunctionArguments checks that the arguments of the scalar and
+// aggregate functions have a type that can be converted to the declared
+// one, and the literal ones can be converted already.
+func validateFunctionArguments(ctx *sql.Context, a *Analyzer, n sql.Node) (sql.Node, error) {
+	span, _ := ctx.Span("validate_function_arguments")
+	defer span.Finish()
+
+	var err error
+	plan.InspectExpressions(n, func(e sql.Expression) bool {
+		var name string
+		var argType func(int) sql.Type
+		switch call := e.(type) {
+		case *sql.ScalarFunctionCall:
+			name, argType = call.Function().Name, call.Function().ArgType
+		case *sql.AggregateFunctionCall:
+			name, argType = call.Function().Name, call.Function().ArgType
+		default:
+			return true
+		}
+
+		for i, arg := range e.Children() {
+			typ := argType(i)
+			if !argumentTypeConvertible(typ, arg.Type()) {
+				err = sql.ErrFunctionArgumentType.New(strings.ToLower(name), i+1, typ, arg.Type())
+				return false
+			}
+
+			if l, ok := arg.(*expression.Literal); ok && l.Value() != nil {
+				if _, cerr := typ.Convert(l.Value()); cerr != nil {
+					err = sql.ErrFunctionArgumentType.New(strings.ToLower(name), i+1, typ, arg)
+					return false
+				}
+			}
+		}
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// argumentTypeConvertible returns whether the values of an expression with
+// the given type can be converted to the declared type of an argument.
+func argumentTypeConvertible(declared, typ sql.Type) bool {
+	switch {
+	case typ == sql.Null, typ == declared:
+		return true
+	case sql.IsText(declared):
+		return !sql.IsTuple(typ) && !sql.IsArray(typ)
+	case sql.IsNumber(declared), declared == sql.Boolean:
+		return sql.IsNumber(typ) || sql.IsText(typ) || typ == sql.Boolean
+	case sql.IsTime(declared):
+		return sql.IsTime(typ) || sql.IsText(typ)
+	default:
+		return false
+	}
+}
+
 func stringContains(strs []string, target string) bool {
 	for _, s := range strs {
 		if s == target {
@@ -127,8 +127,134 @@ func TestValidateGroupByErr(t *testing.T) {
 		plan.NewResolvedTable(child),
 	)
 
+	// The columns that are not grouped have the value of any row of their
+	// group, unless ONLY_FULL_GROUP_BY is enabled.
 	_, err = vr.Apply(sql.NewEmptyContext(), nil, p)
-	require.Error(err)
+	require.NoError(err)
+
+	ctx := sql.NewEmptyContext()
+	ctx.Set(sql.SQLModeVariable, sql.Text, "ONLY_FULL_GROUP_BY")
+	_, err = vr.Apply(ctx, nil, p)
+	require.True(ErrValidationGroupBy.Is(err))
+
+	_, err = vr.Apply(ctx, nil, plan.NewSort(nil, p))
+	require.True(ErrValidationGroupBy.Is(err))
+}
+
+func TestValidateGroupByGrouping(t *testing.T) {
+	require := require.New(t)
+
+	vr := getValidationRule(validateGroupByRule)
+
+	child := memory.NewTable("test", sql.Schema{
+		{Name: "col1", Type: sql.Text, Source: "test"},
+		{Name: "col2", Type: sql.Int64, Source: "test"},
+	})
+	col1 := expression.NewGetFieldWithTable(0, sql.Text, "test", "col1", false)
+	col2 := expression.NewGetFieldWithTable(1, sql.Int64, "test", "col2", false)
+
+	grouping, err := aggregation.NewGrouping(col1)
+	require.NoError(err)
+	p := plan.NewGroupBy(
+		[]sql.Expression{col1, grouping},
+		[]sql.Expression{col1},
+		plan.NewResolvedTable(child),
+	).WithGroupingSets([][]int{{0}, {}})
+
+	_, err = vr.Apply(sql.NewEmptyContext(), nil, p)
+	require.NoError(err)
+
+	grouping, err = aggregation.NewGrouping(col1, col2)
+	require.NoError(err)
+	p = plan.NewGroupBy(
+		[]sql.Expression{col1, expression.NewAlias(grouping, "g")},
+		[]sql.Expression{col1},
+		plan.NewResolvedTable(child),
+	).WithGroupingSets([][]int{{0}, {}})
+
+	_, err = vr.Apply(sql.NewEmptyContext(), nil, p)
+	require.True(ErrGroupingArgument.Is(err))
+}
+
+func TestValidateGroupByOnlyFullGroupBy(t *testing.T) {
+	child := memory.NewTable("test", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "test", PrimaryKey: true},
+		{Name: "col1", Type: sql.Text, Source: "test"},
+		{Name: "col2", Type: sql.Int64, Source: "test"},
+	})
+
+	id := expression.NewGetFieldWithTable(0, sql.Int64, "test", "id", false)
+	col1 := expression.NewGetFieldWithTable(1, sql.Text, "test", "col1", true)
+	col2 := expression.NewGetFieldWithTable(2, sql.Int64, "test", "col2", true)
+
+	testCases := []struct {
+		name      string
+		aggregate []sql.Expression
+		grouping  []sql.Expression
+		ok        bool
+	}{
+		{
+			"any value",
+			[]sql.Expression{col2, function.NewAnyValue(col1)},
+			[]sql.Expression{col2},
+			true,
+		},
+		{
+			"expression of grouping columns",
+			[]sql.Expression{
+				expression.NewAlias(expression.NewPlus(col2, expression.NewLiteral(int64(1), sql.Int64)), "x"),
+				aggregation.NewCount(col1),
+			},
+			[]sql.Expression{col2},
+			true,
+		},
+		{
+			"expression of columns not grouped",
+			[]sql.Expression{expression.NewPlus(col2, col2)},
+			[]sql.Expression{col1},
+			false,
+		},
+		{
+			"grouping expression",
+			[]sql.Expression{function.NewLower(col1)},
+			[]sql.Expression{function.NewLower(col1)},
+			true,
+		},
+		{
+			"grouped primary key",
+			[]sql.Expression{col1, col2},
+			[]sql.Expression{id},
+			true,
+		},
+		{
+			"literal without grouping",
+			[]sql.Expression{expression.NewLiteral(int64(1), sql.Int64), aggregation.NewCount(col1)},
+			nil,
+			true,
+		},
+		{
+			"column without grouping",
+			[]sql.Expression{col1, aggregation.NewCount(col1)},
+			nil,
+			false,
+		},
+	}
+
+	vr := getValidationRule(validateGroupByRule)
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			ctx.Set(sql.SQLModeVariable, sql.Text, "ONLY_FULL_GROUP_BY")
+
+			p := plan.NewGroupBy(tt.aggregate, tt.grouping, plan.NewResolvedTable(child))
+			_, err := vr.Apply(ctx, nil, p)
+			if tt.ok {
+				require.NoError(t, err)
+			} else {
+				require.True(t, ErrValidationGroupBy.Is(err))
+			}
+		})
+	}
 }
 
 func TestValidateSchemaSource(t *testing.T) {
@@ -705,6 +831,61 @@ func TestValidateSubqueryColumns(t *testing.T) {
 	require.NoError(err)
 }
 
+func TestValidateFunctionArguments(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	fn := sql.ScalarFunction{
+		Name:    "Double",
+		Args:    []sql.Type{sql.Int64},
+		Returns: sql.Int64,
+		Fn: func(ctx *sql.Context, args ...interface{}) (interface{}, error) {
+			return args[0].(int64) * 2, nil
+		},
+	}
+
+	call := func(arg sql.Expression) sql.Node {
+		e, err := fn.Call(arg)
+		require.NoError(err)
+		return plan.NewProject([]sql.Expression{e}, dummyNode{true})
+	}
+
+	testCases := []struct {
+		name string
+		arg  sql.Expression
+		ok   bool
+	}{
+		{"int", expression.NewGetField(0, sql.Int32, "a", false), true},
+		{"text", expression.NewGetField(0, sql.Text, "a", false), true},
+		{"null", expression.NewLiteral(nil, sql.Null), true},
+		{"numeric literal", expression.NewLiteral("12", sql.Text), true},
+		{"invalid literal", expression.NewLiteral("abc", sql.Text), false},
+		{"date", expression.NewGetField(0, sql.Date, "a", false), false},
+		{"array", expression.NewGetField(0, sql.Array(sql.Int64), "a", false), false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateFunctionArguments(ctx, nil, call(tt.arg))
+			if tt.ok {
+				require.NoError(err)
+			} else {
+				require.True(sql.ErrFunctionArgumentType.Is(err))
+			}
+		})
+	}
+
+	agg, err := sql.AggregateFunction{
+		Name:    "total",
+		Args:    []sql.Type{sql.Int64},
+		Returns: sql.Int64,
+	}.Call(expression.NewLiteral("abc", sql.Text))
+	require.NoError(err)
+
+	_, err = validateFunctionArguments(ctx, nil, plan.NewGroupBy([]sql.Expression{agg}, nil, dummyNode{true}))
+	require.True(sql.ErrFunctionArgumentType.Is(err))
+}
+
 type dummyNode struct{ resolved bool }
 
 func (n dummyNode) String() string                           { return "dummynode" }
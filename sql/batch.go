@@ -0,0 +1,85 @@
+package sql
+
+import "io"
+
+// RowBatchSize is the number of rows read at once by the consumers of row
+// iterators that read rows in batches.
+const RowBatchSize = 128
+
+// RowBatchIter is a RowIter that can also produce its rows in batches.
+// Reading rows in batches avoids a call through the iterator interfaces of
+// all the nodes of a plan for every row, which is most of the cost of
+// scanning many rows. The rows of an iterator should be read either with
+// Next or with NextBatch, but not mixing them.
+type RowBatchIter interface {
+	RowIter
+	// NextBatch reads the next rows into the given slice, which must not be
+	// empty, and returns the number of rows read. At least one row is read
+	// unless there are no more rows, in which case io.EOF is returned. The
+	// rows stay valid after the next call, but the slice is reused by the
+	// caller.
+	NextBatch(rows []Row) (int, error)
+}
+
+// NewRowBatchIter returns the given iterator if it can produce its rows in
+// batches, or an iterator that reads its rows one by one to fill the
+// batches otherwise.
+func NewRowBatchIter(iter RowIter) RowBatchIter {
+	if b, ok := iter.(RowBatchIter); ok {
+		return b
+	}
+	return &rowBatchAdapter{iter: iter}
+}
+
+type rowBatchAdapter struct {
+	iter RowIter
+	done bool
+}
+
+func (i *rowBatchAdapter) Next() (Row, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+
+	row, err := i.iter.Next()
+	if err == io.EOF {
+		i.done = true
+	}
+	return row, err
+}
+
+func (i *rowBatchAdapter) NextBatch(rows []Row) (int, error) {
+	return FillRowBatch(i, rows)
+}
+
+func (i *rowBatchAdapter) Close() error {
+	return i.iter.Close()
+}
+
+// FillRowBatch fills the batch with the next rows of the iterator, read one
+// by one. It's meant to be used by the iterators that can't read their rows
+// in batches more efficiently than that, and by the ones that only need to
+// be called less often through the RowIter interface. The iterator must
+// keep returning io.EOF once it has returned it.
+func FillRowBatch(iter RowIter, rows []Row) (int, error) {
+	var n int
+	for n < len(rows) {
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		rows[n] = row
+		n++
+	}
+
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
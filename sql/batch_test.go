@@ -0,0 +1,48 @@
+package sql
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowBatchIter(t *testing.T) {
+	require := require.New(t)
+
+	iter := NewRowBatchIter(RowsToRowIter(
+		NewRow(1),
+		NewRow(2),
+		NewRow(3),
+	))
+
+	batch := make([]Row, 2)
+	n, err := iter.NextBatch(batch)
+	require.NoError(err)
+	require.Equal([]Row{NewRow(1), NewRow(2)}, batch[:n])
+
+	n, err = iter.NextBatch(batch)
+	require.NoError(err)
+	require.Equal([]Row{NewRow(3)}, batch[:n])
+
+	_, err = iter.NextBatch(batch)
+	require.Equal(io.EOF, err)
+
+	_, err = iter.NextBatch(batch)
+	require.Equal(io.EOF, err)
+
+	require.NoError(iter.Close())
+}
+
+func TestRowIterToRowsBatches(t *testing.T) {
+	require := require.New(t)
+
+	var expected []Row
+	for i := 0; i < RowBatchSize*2+1; i++ {
+		expected = append(expected, NewRow(i))
+	}
+
+	rows, err := RowIterToRows(RowsToRowIter(expected...))
+	require.NoError(err)
+	require.Equal(expected, rows)
+}
@@ -0,0 +1,98 @@
+package sql
+
+// RowChangeType is the kind of change made to a row.
+type RowChangeType byte
+
+const (
+	// RowInserted is a row added to a table.
+	RowInserted RowChangeType = iota
+	// RowUpdated is a row whose values changed.
+	RowUpdated
+	// RowDeleted is a row removed from a table.
+	RowDeleted
+)
+
+// RowChange is a change made to a row by a statement. Before is nil for
+// inserted rows and After is nil for deleted rows.
+type RowChange struct {
+	Type   RowChangeType
+	Before Row
+	After  Row
+}
+
+// TableChanges are the changes made to the rows of a table by a statement,
+// in the order they were made.
+type TableChanges struct {
+	Database string
+	Table    string
+	Schema   Schema
+	Rows     []RowChange
+}
+
+// ChangeRecorder records the rows changed by INSERT, REPLACE, UPDATE and
+// DELETE statements. It's called once per statement with all the changes
+// it made, even if it failed after making some of them.
+type ChangeRecorder interface {
+	RecordChanges(ctx *Context, changes TableChanges) error
+}
+
+// RowChangeHook is notified of the rows changed by INSERT, REPLACE, UPDATE
+// and DELETE statements, and by the replication of the changes of a
+// source. It's called once per statement with all the changes it made,
+// after they are made, so it can be used to invalidate caches or to send
+// the changes to other systems. The changes must not be modified.
+type RowChangeHook func(ctx *Context, changes TableChanges)
+
+// hookedRecorder records the changes with a ChangeRecorder, if any, and
+// then notifies them to hooks.
+type hookedRecorder struct {
+	recorder ChangeRecorder
+	hooks    []RowChangeHook
+}
+
+func (r *hookedRecorder) RecordChanges(ctx *Context, changes TableChanges) error {
+	var err error
+	if r.recorder != nil {
+		err = r.recorder.RecordChanges(ctx, changes)
+	}
+
+	notifyRowChanges(ctx, r.hooks, changes)
+	return err
+}
+
+func notifyRowChanges(ctx *Context, hooks []RowChangeHook, changes TableChanges) {
+	if len(changes.Rows) == 0 {
+		return
+	}
+
+	for _, hook := range hooks {
+		hook(ctx, changes)
+	}
+}
+
+// BinlogStatus is the position of a binary log, as shown by SHOW MASTER
+// STATUS.
+type BinlogStatus struct {
+	// File is the name of the current log file.
+	File string
+	// Position is the position of the next event in the file.
+	Position uint64
+	// ExecutedGtidSet are the global transaction identifiers of the
+	// transactions written in the log.
+	ExecutedGtidSet string
+}
+
+// BinlogFile is a file of a binary log, as shown by SHOW BINARY LOGS.
+type BinlogFile struct {
+	Name string
+	Size uint64
+}
+
+// BinaryLog is a ChangeRecorder that writes the changes in a binary log.
+type BinaryLog interface {
+	ChangeRecorder
+	// Status returns the current position of the log.
+	Status() BinlogStatus
+	// Files returns the files of the log, from the oldest one.
+	Files() []BinlogFile
+}
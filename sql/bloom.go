@@ -0,0 +1,62 @@
+package sql
+
+import "math"
+
+// BloomFilter is a set of hashes that can tell whether a hash may be in
+// the set, with some false positives, or is definitely not in it, using a
+// small and fixed amount of memory.
+type BloomFilter struct {
+	bits   []uint64
+	hashes uint64
+}
+
+// NewBloomFilter returns an empty bloom filter sized to hold n hashes with
+// the given rate of false positives.
+func NewBloomFilter(n uint64, falsePositiveRate float64) *BloomFilter {
+	if n == 0 {
+		n = 1
+	}
+
+	bits := math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	words := uint64(math.Ceil(bits / 64))
+	if words == 0 {
+		words = 1
+	}
+
+	hashes := uint64(math.Round(float64(words*64) / float64(n) * math.Ln2))
+	if hashes == 0 {
+		hashes = 1
+	}
+
+	return &BloomFilter{bits: make([]uint64, words), hashes: hashes}
+}
+
+// Add the given hash to the set.
+func (f *BloomFilter) Add(hash uint64) {
+	h1, h2 := splitHash(hash)
+	size := uint64(len(f.bits)) * 64
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % size
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain reports whether the given hash may be in the set. If it's
+// false, the hash is definitely not in it.
+func (f *BloomFilter) MayContain(hash uint64) bool {
+	h1, h2 := splitHash(hash)
+	size := uint64(len(f.bits)) * 64
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % size
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// splitHash returns the two hashes combined to choose the bits of a hash,
+// taken from its halves. The second one is odd, so it never repeats bits.
+func splitHash(hash uint64) (uint64, uint64) {
+	return hash & math.MaxUint32, hash>>32 | 1
+}
@@ -0,0 +1,33 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	require := require.New(t)
+
+	f := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(CacheKey(i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		require.True(f.MayContain(CacheKey(i)))
+	}
+
+	var falsePositives int
+	for i := 1000; i < 11000; i++ {
+		if f.MayContain(CacheKey(i)) {
+			falsePositives++
+		}
+	}
+	require.True(falsePositives < 300, "%d false positives", falsePositives)
+}
+
+func TestBloomFilterEmpty(t *testing.T) {
+	f := NewBloomFilter(0, 0.01)
+	require.False(t, f.MayContain(CacheKey(1)))
+}
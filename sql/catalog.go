@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/src-d/go-mysql-server/internal/similartext"
 
@@ -13,17 +14,67 @@ import (
 // ErrDatabaseNotFound is thrown when a database is not found
 var ErrDatabaseNotFound = errors.NewKind("database not found: %s")
 
+// ErrDatabaseExists is returned when a database is renamed with the name of
+// another database of the catalog.
+var ErrDatabaseExists = errors.NewKind("database already exists: %s")
+
+// ErrDatabaseNotRenamable is returned when renaming a database that does
+// not implement DatabaseRenamer.
+var ErrDatabaseNotRenamable = errors.NewKind("database %s cannot be renamed")
+
+// DatabaseProvider provides the databases of a catalog that are not added
+// to it, which are resolved by name every time a query uses them, so
+// databases can be materialized on demand, such as the ones of each tenant
+// of a multi-tenant integration. The provider can cache the databases it
+// materializes, and it must call SchemaChanged on the catalog when the
+// database of a name changes, as plans are cached with the databases they
+// resolved.
+type DatabaseProvider interface {
+	// Database returns the database with the given name, or an error of
+	// kind ErrDatabaseNotFound if it does not exist. The context is the one
+	// of the query resolving the database, with its session.
+	Database(ctx *Context, name string) (Database, error)
+}
+
+// ErrDatabaseCreation is returned when a database is created in a catalog
+// without a database creator.
+var ErrDatabaseCreation = errors.NewKind("database %s cannot be created, as the catalog has no database creator")
+
+// DatabaseCreator creates the databases of the CREATE DATABASE statements,
+// which are added to the catalog.
+type DatabaseCreator interface {
+	// CreateDatabase returns a new database with the given name.
+	CreateDatabase(ctx *Context, name string) (Database, error)
+}
+
+// DatabaseRenamer should be implemented by databases that can be renamed.
+type DatabaseRenamer interface {
+	Database
+	// Rename changes the name of the database.
+	Rename(name string) error
+}
+
 // Catalog holds databases, tables and functions.
 type Catalog struct {
 	FunctionRegistry
 	*IndexRegistry
 	*ProcessList
 	*MemoryManager
+	*StatisticsRegistry
 
 	mu              sync.RWMutex
 	currentDatabase string
 	dbs             Databases
 	locks           sessionLocks
+	schemaChanges   uint64
+	privileges      PrivilegeStore
+	changes         ChangeRecorder
+	hooks           []RowChangeHook
+	provider        DatabaseProvider
+	creator         DatabaseCreator
+	secureFilePriv  string
+	timeZone        string
+	caseSensitive   bool
 }
 
 type (
@@ -35,11 +86,12 @@ type (
 // NewCatalog returns a new empty Catalog.
 func NewCatalog() *Catalog {
 	return &Catalog{
-		FunctionRegistry: NewFunctionRegistry(),
-		IndexRegistry:    NewIndexRegistry(),
-		MemoryManager:    NewMemoryManager(ProcessMemory),
-		ProcessList:      NewProcessList(),
-		locks:            make(sessionLocks),
+		FunctionRegistry:   NewFunctionRegistry(),
+		IndexRegistry:      NewIndexRegistry(),
+		MemoryManager:      NewMemoryManager(ProcessMemory),
+		ProcessList:        NewProcessList(),
+		StatisticsRegistry: NewStatisticsRegistry(),
+		locks:              make(sessionLocks),
 	}
 }
 
@@ -67,14 +119,284 @@ func (c *Catalog) AllDatabases() Databases {
 	return result
 }
 
-// AddDatabase adds a new database to the catalog.
+// AddDatabase adds a new database to the catalog, replacing the database
+// with the same name, if any. Databases can be added, dropped and renamed
+// while queries run, which keep using the databases they resolved.
 func (c *Catalog) AddDatabase(db Database) {
 	c.mu.Lock()
 	if c.currentDatabase == "" {
 		c.currentDatabase = db.Name()
 	}
 
-	c.dbs.Add(db)
+	if i := c.dbs.index(db.Name()); i >= 0 {
+		dbs := make(Databases, len(c.dbs))
+		copy(dbs, c.dbs)
+		dbs[i] = db
+		c.dbs = dbs
+	} else {
+		c.dbs.Add(db)
+	}
+	c.mu.Unlock()
+	c.SchemaChanged()
+}
+
+// DropDatabase removes the database with the given name from the catalog,
+// along with the statistics of its tables. Its indexes are kept, so they
+// are used again if the database is added back. The current database is
+// unset if it's the one dropped.
+func (c *Catalog) DropDatabase(name string) error {
+	c.mu.Lock()
+	i := c.dbs.index(name)
+	if i < 0 {
+		c.mu.Unlock()
+		return ErrDatabaseNotFound.New(name)
+	}
+
+	name = c.dbs[i].Name()
+	dbs := make(Databases, 0, len(c.dbs)-1)
+	dbs = append(dbs, c.dbs[:i]...)
+	c.dbs = append(dbs, c.dbs[i+1:]...)
+
+	if strings.EqualFold(c.currentDatabase, name) {
+		c.currentDatabase = ""
+	}
+	for _, locks := range c.locks {
+		delete(locks, name)
+	}
+	c.mu.Unlock()
+
+	c.StatisticsRegistry.deleteDatabase(name)
+	c.SchemaChanged()
+	return nil
+}
+
+// RenameDatabase renames the database with the given name, which must
+// implement DatabaseRenamer, moving the statistics of its tables. Its
+// indexes are not moved, as they are stored by the index drivers with the
+// name of the database. The current database is renamed too.
+func (c *Catalog) RenameDatabase(name, newName string) error {
+	c.mu.Lock()
+	i := c.dbs.index(name)
+	if i < 0 {
+		c.mu.Unlock()
+		return ErrDatabaseNotFound.New(name)
+	}
+
+	db := c.dbs[i]
+	if j := c.dbs.index(newName); j >= 0 && j != i {
+		c.mu.Unlock()
+		return ErrDatabaseExists.New(newName)
+	}
+
+	renamer, ok := db.(DatabaseRenamer)
+	if !ok {
+		c.mu.Unlock()
+		return ErrDatabaseNotRenamable.New(db.Name())
+	}
+
+	name = db.Name()
+	if err := renamer.Rename(newName); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+
+	if strings.EqualFold(c.currentDatabase, name) {
+		c.currentDatabase = newName
+	}
+	for _, locks := range c.locks {
+		if tables, ok := locks[name]; ok {
+			delete(locks, name)
+			locks[newName] = tables
+		}
+	}
+	c.mu.Unlock()
+
+	c.StatisticsRegistry.renameDatabase(name, newName)
+	c.SchemaChanged()
+	return nil
+}
+
+// SchemaVersion returns a number that changes every time the databases of
+// the catalog, the schema of their tables, their indexes or statistics
+// change. Plans analyzed with a different schema version are outdated.
+func (c *Catalog) SchemaVersion() uint64 {
+	return atomic.LoadUint64(&c.schemaChanges) +
+		atomic.LoadUint64(&c.IndexRegistry.changes) +
+		atomic.LoadUint64(&c.StatisticsRegistry.changes)
+}
+
+// SchemaChanged changes the schema version. It must be called every time a
+// table is created, dropped or its schema is altered, which the engine does
+// for the statements it executes. Integrations that modify the tables of
+// their databases directly must call it too.
+func (c *Catalog) SchemaChanged() {
+	atomic.AddUint64(&c.schemaChanges, 1)
+}
+
+// Privileges returns the store of the privileges granted to the users, or
+// nil if privileges are not enforced.
+func (c *Catalog) Privileges() PrivilegeStore {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.privileges
+}
+
+// SetPrivileges sets the store of the privileges granted to the users, which
+// enables checking them before executing any statement. A nil store
+// disables the checks. Cached plans are only reused by the users that
+// analyzed them, so integrations that change the privileges of the store
+// directly must call SchemaChanged afterwards.
+func (c *Catalog) SetPrivileges(store PrivilegeStore) {
+	c.mu.Lock()
+	c.privileges = store
+	c.mu.Unlock()
+	c.SchemaChanged()
+}
+
+// ChangeRecorder returns the recorder of the rows changed by statements, or
+// nil if they are not recorded.
+func (c *Catalog) ChangeRecorder() ChangeRecorder {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.changes
+}
+
+// SetChangeRecorder sets the recorder of the rows changed by statements. A
+// nil recorder stops recording them.
+func (c *Catalog) SetChangeRecorder(r ChangeRecorder) {
+	c.mu.Lock()
+	c.changes = r
+	c.mu.Unlock()
+	c.SchemaChanged()
+}
+
+// SetSecureFilePriv sets the directory of the files of the server that can
+// be read and written by queries, like secure_file_priv in MySQL. Files of
+// the server cannot be used when it's empty, which is the default.
+func (c *Catalog) SetSecureFilePriv(dir string) {
+	c.mu.Lock()
+	c.secureFilePriv = dir
+	c.mu.Unlock()
+}
+
+// SecureFilePriv returns the directory of the files of the server that can
+// be read and written by queries, which is empty if they cannot be used.
+func (c *Catalog) SecureFilePriv() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.secureFilePriv
+}
+
+// SetTimeZone sets the global time zone, which is the time zone of the new
+// sessions of the server, like the global time_zone variable of MySQL. The
+// time zone is SYSTEM, an offset from UTC or a named time zone.
+func (c *Catalog) SetTimeZone(name string) error {
+	if _, err := LoadTimeZone(name); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.timeZone = name
+	c.mu.Unlock()
+	return nil
+}
+
+// TimeZone returns the global time zone, which is SYSTEM by default.
+func (c *Catalog) TimeZone() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.timeZone == "" {
+		return SystemTimeZone
+	}
+	return c.timeZone
+}
+
+// SetCaseSensitiveNames sets whether the names of the databases, tables and
+// columns used by queries must have the same case as the ones of the
+// catalog, like lower_case_table_names set to 0 in MySQL. They are not case
+// sensitive by default, as with lower_case_table_names set to 2, so that
+// schemas created on case-insensitive servers keep working.
+func (c *Catalog) SetCaseSensitiveNames(caseSensitive bool) {
+	c.mu.Lock()
+	c.caseSensitive = caseSensitive
+	c.mu.Unlock()
+	c.SchemaChanged()
+}
+
+// CaseSensitiveNames reports whether the names of the databases, tables and
+// columns are case sensitive.
+func (c *Catalog) CaseSensitiveNames() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.caseSensitive
+}
+
+// AddRowChangeHook adds a hook notified of the rows changed by statements,
+// after the change recorder of the catalog, if any, records them.
+func (c *Catalog) AddRowChangeHook(hook RowChangeHook) {
+	c.mu.Lock()
+	c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], hook)
+	c.mu.Unlock()
+	c.SchemaChanged()
+}
+
+// StatementChangeRecorder returns the recorder of the rows changed by
+// statements, which records them with the change recorder of the catalog
+// and notifies them to its row change hooks, or nil if there are neither.
+func (c *Catalog) StatementChangeRecorder() ChangeRecorder {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.hooks) == 0 {
+		return c.changes
+	}
+	return &hookedRecorder{recorder: c.changes, hooks: c.hooks}
+}
+
+// NotifyRowChanges notifies the given changes to the row change hooks of
+// the catalog without recording them. It's used for the changes that are
+// not made by statements, such as the ones replicated from a source.
+func (c *Catalog) NotifyRowChanges(ctx *Context, changes TableChanges) {
+	c.mu.RLock()
+	hooks := c.hooks
+	c.mu.RUnlock()
+
+	notifyRowChanges(ctx, hooks, changes)
+}
+
+// DatabaseProvider returns the provider of the databases that are not added
+// to the catalog, or nil if there is none.
+func (c *Catalog) DatabaseProvider() DatabaseProvider {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.provider
+}
+
+// SetDatabaseProvider sets the provider of the databases that are not added
+// to the catalog, which are resolved with it by the queries using them. The
+// databases added to the catalog take precedence over the ones of the
+// provider. A nil provider removes it.
+func (c *Catalog) SetDatabaseProvider(p DatabaseProvider) {
+	c.mu.Lock()
+	c.provider = p
+	c.mu.Unlock()
+	c.SchemaChanged()
+}
+
+// DatabaseCreator returns the creator of the databases of the CREATE
+// DATABASE statements, or nil if there is none.
+func (c *Catalog) DatabaseCreator() DatabaseCreator {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.creator
+}
+
+// SetDatabaseCreator sets the creator of the databases of the CREATE
+// DATABASE statements. Databases cannot be created without one, which is
+// the default.
+func (c *Catalog) SetDatabaseCreator(creator DatabaseCreator) {
+	c.mu.Lock()
+	c.creator = creator
 	c.mu.Unlock()
 }
 
@@ -82,30 +404,72 @@ func (c *Catalog) AddDatabase(db Database) {
 func (c *Catalog) Database(db string) (Database, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.dbs.Database(db)
+	return c.dbs.database(db, c.caseSensitive)
 }
 
 // Table returns the table in the given database with the given name.
 func (c *Catalog) Table(db, table string) (Table, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.dbs.Table(db, table)
+	d, err := c.dbs.database(db, c.caseSensitive)
+	if err != nil {
+		return nil, err
+	}
+	return databaseTable(d, table, c.caseSensitive)
+}
+
+// ResolveDatabase returns the database with the given name for the query of
+// the given context, which is the one added to the catalog, if any, or the
+// one of the database provider.
+func (c *Catalog) ResolveDatabase(ctx *Context, db string) (Database, error) {
+	c.mu.RLock()
+	result, err := c.dbs.database(db, c.caseSensitive)
+	provider := c.provider
+	c.mu.RUnlock()
+
+	if err == nil || provider == nil || !ErrDatabaseNotFound.Is(err) {
+		return result, err
+	}
+
+	// The provider is not called with the lock held, as it may take a
+	// while to materialize the database.
+	return provider.Database(ctx, db)
+}
+
+// ResolveTable returns the table in the given database with the given name
+// for the query of the given context, resolving the database as
+// ResolveDatabase does.
+func (c *Catalog) ResolveTable(ctx *Context, db, table string) (Table, error) {
+	d, err := c.ResolveDatabase(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	return databaseTable(d, table, c.CaseSensitiveNames())
 }
 
 // Databases is a collection of Database.
 type Databases []Database
 
-// Database returns the Database with the given name if it exists.
+// Database returns the Database with the given name if it exists, which is
+// not case sensitive.
 func (d Databases) Database(name string) (Database, error) {
+	return d.database(name, false)
+}
 
+// database returns the database with the given name, which is case
+// sensitive or not.
+func (d Databases) database(name string, caseSensitive bool) (Database, error) {
 	if len(d) == 0 {
 		return nil, ErrDatabaseNotFound.New(name)
 	}
 
-	name = strings.ToLower(name)
+	if !caseSensitive {
+		name = strings.ToLower(name)
+	}
+
 	var dbNames []string
 	for _, db := range d {
-		if strings.ToLower(db.Name()) == name {
+		if db.Name() == name || !caseSensitive && strings.ToLower(db.Name()) == name {
 			return db, nil
 		}
 		dbNames = append(dbNames, db.Name())
@@ -119,6 +483,17 @@ func (d *Databases) Add(db Database) {
 	*d = append(*d, db)
 }
 
+// index returns the position of the database with the given name, or -1 if
+// it does not exist.
+func (d Databases) index(name string) int {
+	for i, db := range d {
+		if strings.EqualFold(db.Name(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
 // Table returns the Table with the given name if it exists.
 func (d Databases) Table(dbName string, tableName string) (Table, error) {
 	db, err := d.Database(dbName)
@@ -126,7 +501,15 @@ func (d Databases) Table(dbName string, tableName string) (Table, error) {
 		return nil, err
 	}
 
-	tableName = strings.ToLower(tableName)
+	return databaseTable(db, tableName, false)
+}
+
+// databaseTable returns the table of the given database with the given
+// name, which is case sensitive or not.
+func databaseTable(db Database, tableName string, caseSensitive bool) (Table, error) {
+	if !caseSensitive {
+		tableName = strings.ToLower(tableName)
+	}
 
 	tables := db.Tables()
 	if len(tables) == 0 {
@@ -136,19 +519,20 @@ func (d Databases) Table(dbName string, tableName string) (Table, error) {
 	// Try to get the table by key, but if the name is not the same,
 	// then use the slow path and iterate over all tables comparing
 	// the name.
-	table, ok := tables[tableName]
-	if !ok {
+	if table, ok := tables[tableName]; ok {
+		return table, nil
+	}
+
+	if !caseSensitive {
 		for name, table := range tables {
 			if strings.ToLower(name) == tableName {
 				return table, nil
 			}
 		}
-
-		similar := similartext.FindFromMap(tables, tableName)
-		return nil, ErrTableNotFound.New(tableName + similar)
 	}
 
-	return table, nil
+	similar := similartext.FindFromMap(tables, tableName)
+	return nil, ErrTableNotFound.New(tableName + similar)
 }
 
 // LockTable adds a lock for the given table and session client. It is assumed
@@ -1,6 +1,8 @@
 package sql_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/src-d/go-mysql-server/memory"
@@ -21,6 +23,27 @@ func TestCatalogCurrentDatabase(t *testing.T) {
 	require.Equal("bar", c.CurrentDatabase())
 }
 
+func TestCatalogSchemaVersion(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	version := c.SchemaVersion()
+
+	c.AddDatabase(memory.NewDatabase("foo"))
+	require.NotEqual(version, c.SchemaVersion())
+	version = c.SchemaVersion()
+
+	c.SchemaChanged()
+	require.NotEqual(version, c.SchemaVersion())
+	version = c.SchemaVersion()
+
+	c.SetTableStatistics("foo", "bar", &sql.TableStatistics{})
+	require.NotEqual(version, c.SchemaVersion())
+	version = c.SchemaVersion()
+
+	require.Equal(version, c.SchemaVersion())
+}
+
 func TestAllDatabases(t *testing.T) {
 	require := require.New(t)
 
@@ -90,6 +113,153 @@ func TestCatalogTable(t *testing.T) {
 	require.Equal(mytable, table)
 }
 
+func TestCatalogDropDatabase(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(memory.NewDatabase("foo"))
+	bar := memory.NewDatabase("bar")
+	c.AddDatabase(bar)
+	c.SetTableStatistics("foo", "t", &sql.TableStatistics{RowCount: 1})
+
+	version := c.SchemaVersion()
+	require.NoError(c.DropDatabase("FOO"))
+	require.NotEqual(version, c.SchemaVersion())
+	require.Equal(sql.Databases{bar}, c.AllDatabases())
+	require.Equal("", c.CurrentDatabase())
+	require.Nil(c.TableStatistics("foo", "t"))
+
+	_, err := c.Database("foo")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	err = c.DropDatabase("foo")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	// Adding a database with the name of another replaces it.
+	bar2 := memory.NewDatabase("bar")
+	c.AddDatabase(bar2)
+	require.Equal(sql.Databases{bar2}, c.AllDatabases())
+}
+
+func TestCatalogRenameDatabase(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	foo := memory.NewDatabase("foo")
+	c.AddDatabase(foo)
+	c.AddDatabase(memory.NewDatabase("bar"))
+	c.AddDatabase(notRenamableDatabase{memory.NewDatabase("baz")})
+	stats := &sql.TableStatistics{RowCount: 1}
+	c.SetTableStatistics("foo", "t", stats)
+
+	err := c.RenameDatabase("foo", "BAR")
+	require.True(sql.ErrDatabaseExists.Is(err))
+
+	err = c.RenameDatabase("qux", "quux")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	err = c.RenameDatabase("baz", "quux")
+	require.True(sql.ErrDatabaseNotRenamable.Is(err))
+
+	require.NoError(c.RenameDatabase("foo", "qux"))
+	require.Equal("qux", foo.Name())
+	require.Equal("qux", c.CurrentDatabase())
+	require.Equal(stats, c.TableStatistics("qux", "t"))
+	require.Nil(c.TableStatistics("foo", "t"))
+
+	db, err := c.Database("qux")
+	require.NoError(err)
+	require.Equal(foo, db)
+
+	_, err = c.Database("foo")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+}
+
+func TestCatalogConcurrentDatabases(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	c.AddDatabase(memory.NewDatabase("foo"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("db%d_%d", i, j)
+				c.AddDatabase(memory.NewDatabase(name))
+				if j%2 == 0 {
+					require.NoError(c.RenameDatabase(name, name+"_renamed"))
+					name += "_renamed"
+				}
+				require.NoError(c.DropDatabase(name))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := c.Database("foo")
+				require.NoError(err)
+				c.AllDatabases()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(c.AllDatabases(), 1)
+}
+
+func TestCatalogResolveDatabase(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	foo := memory.NewDatabase("foo")
+	foo.AddTable("t", memory.NewTable("t", nil))
+	c.AddDatabase(foo)
+
+	ctx := sql.NewEmptyContext()
+	_, err := c.ResolveDatabase(ctx, "bar")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	bar := memory.NewDatabase("bar")
+	bar.AddTable("t", memory.NewTable("t", nil))
+	version := c.SchemaVersion()
+	c.SetDatabaseProvider(databaseProvider{bar})
+	require.NotEqual(version, c.SchemaVersion())
+
+	db, err := c.ResolveDatabase(ctx, "foo")
+	require.NoError(err)
+	require.Equal(foo, db)
+
+	db, err = c.ResolveDatabase(ctx, "BAR")
+	require.NoError(err)
+	require.Equal(bar, db)
+
+	table, err := c.ResolveTable(ctx, "bar", "T")
+	require.NoError(err)
+	require.Equal(bar.Tables()["t"], table)
+
+	_, err = c.ResolveTable(ctx, "bar", "u")
+	require.True(sql.ErrTableNotFound.Is(err))
+
+	_, err = c.ResolveDatabase(ctx, "baz")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	// Only the databases added to the catalog are listed.
+	require.Equal(sql.Databases{foo}, c.AllDatabases())
+}
+
+type databaseProvider sql.Databases
+
+func (p databaseProvider) Database(ctx *sql.Context, name string) (sql.Database, error) {
+	return sql.Databases(p).Database(name)
+}
+
+type notRenamableDatabase struct {
+	sql.Database
+}
+
 func TestCatalogUnlockTables(t *testing.T) {
 	require := require.New(t)
 
@@ -111,6 +281,39 @@ func TestCatalogUnlockTables(t *testing.T) {
 	require.Equal(1, t2.unlocks)
 }
 
+func TestCatalogRowChangeHooks(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	require.Nil(c.StatementChangeRecorder())
+
+	var notified []string
+	c.AddRowChangeHook(func(ctx *sql.Context, changes sql.TableChanges) {
+		notified = append(notified, "first "+changes.Table)
+	})
+	c.AddRowChangeHook(func(ctx *sql.Context, changes sql.TableChanges) {
+		notified = append(notified, "second "+changes.Table)
+	})
+
+	changes := sql.TableChanges{
+		Database: "db",
+		Table:    "t",
+		Rows:     []sql.RowChange{{Type: sql.RowInserted, After: sql.NewRow(1)}},
+	}
+
+	recorder := c.StatementChangeRecorder()
+	require.NotNil(recorder)
+	require.NoError(recorder.RecordChanges(sql.NewEmptyContext(), changes))
+	require.Equal([]string{"first t", "second t"}, notified)
+
+	// Statements that change no rows are not notified.
+	require.NoError(recorder.RecordChanges(sql.NewEmptyContext(), sql.TableChanges{Table: "u"}))
+	require.Len(notified, 2)
+
+	c.NotifyRowChanges(sql.NewEmptyContext(), changes)
+	require.Len(notified, 4)
+}
+
 type lockableTable struct {
 	sql.Table
 	unlocks int
@@ -130,3 +333,47 @@ func (l *lockableTable) Unlock(ctx *sql.Context, id uint32) error {
 	l.unlocks++
 	return nil
 }
+
+func TestCatalogTimeZone(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	require.Equal(sql.SystemTimeZone, c.TimeZone())
+
+	require.NoError(c.SetTimeZone("Europe/Madrid"))
+	require.Equal("Europe/Madrid", c.TimeZone())
+
+	err := c.SetTimeZone("+15:00")
+	require.Error(err)
+	require.True(sql.ErrUnknownTimeZone.Is(err))
+	require.Equal("Europe/Madrid", c.TimeZone())
+}
+
+func TestCatalogCaseSensitiveNames(t *testing.T) {
+	require := require.New(t)
+
+	c := sql.NewCatalog()
+	db := memory.NewDatabase("Foo")
+	mytable := memory.NewTable("Bar", nil)
+	db.AddTable("Bar", mytable)
+	c.AddDatabase(db)
+
+	table, err := c.ResolveTable(sql.NewEmptyContext(), "foo", "bar")
+	require.NoError(err)
+	require.Equal(mytable, table)
+
+	version := c.SchemaVersion()
+	c.SetCaseSensitiveNames(true)
+	require.True(c.CaseSensitiveNames())
+	require.NotEqual(version, c.SchemaVersion())
+
+	_, err = c.ResolveTable(sql.NewEmptyContext(), "foo", "Bar")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	_, err = c.Table("Foo", "bar")
+	require.True(sql.ErrTableNotFound.Is(err))
+
+	table, err = c.ResolveTable(sql.NewEmptyContext(), "Foo", "Bar")
+	require.NoError(err)
+	require.Equal(mytable, table)
+}
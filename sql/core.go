@@ -95,6 +95,16 @@ type Aggregation interface {
 	Merge(ctx *Context, buffer, partial Row) error
 }
 
+// NonDeterministicExpression is an expression whose result doesn't only
+// depend on its children and the row it's evaluated with, such as the
+// current time or the current database, so it cannot be evaluated before
+// the query is executed.
+type NonDeterministicExpression interface {
+	Expression
+	// IsNonDeterministic reports whether the expression is non deterministic.
+	IsNonDeterministic() bool
+}
+
 // Node is a node in the execution plan tree.
 type Node interface {
 	Resolvable
@@ -196,6 +206,40 @@ type ProjectedTable interface {
 	Projection() []string
 }
 
+// LimitedTable is a table that can stop producing rows once a given number
+// of them has been returned, so the rows discarded by a LIMIT clause are
+// never read. The limit applies after the filters pushed down to the table
+// and may be applied to each partition separately, as the engine still
+// limits the rows returned by the table.
+type LimitedTable interface {
+	Table
+	WithLimit(limit uint64) Table
+	Limit() uint64
+}
+
+// OrderByColumn is a column by which the rows of a TopNTable are sorted.
+type OrderByColumn struct {
+	// Name of the column.
+	Name string
+	// Descending is true if the rows must be sorted in descending order.
+	Descending bool
+	// NullsFirst is true if null values must be returned before any other
+	// value.
+	NullsFirst bool
+}
+
+// TopNTable is a table that can produce only the first N rows of its data
+// sorted by some of its columns, so a query with ORDER BY and LIMIT does not
+// need to read and sort all the rows of the table. As in LimitedTable, the
+// limit applies after the filters pushed down to the table and it may be
+// applied to each partition separately, as the engine still sorts and
+// limits the rows returned by the table.
+type TopNTable interface {
+	Table
+	WithTopN(order []OrderByColumn, limit uint64) Table
+	TopN() ([]OrderByColumn, uint64)
+}
+
 // IndexableTable represents a table that supports being indexed and
 // receiving indexes to be able to speed up its execution.
 type IndexableTable interface {
@@ -211,6 +255,24 @@ type Inserter interface {
 	Insert(*Context, Row) error
 }
 
+// BatchInserter is an Inserter that can insert several rows at once, which
+// is faster than inserting them one at a time for bulk loads.
+type BatchInserter interface {
+	Inserter
+	// InsertBatch inserts the given rows, either all or none of them.
+	InsertBatch(*Context, []Row) error
+}
+
+// AutoIncrementTable is a table with a column whose values are generated
+// when rows are inserted without them.
+type AutoIncrementTable interface {
+	Table
+	// NextAutoIncrementValue returns the value to generate for the next row
+	// inserted without a value for the auto increment column, which is
+	// greater than any value of the column inserted before.
+	NextAutoIncrementValue(*Context) (uint64, error)
+}
+
 // Deleter allow rows to be deleted from tables.
 type Deleter interface {
 	// Delete the given row. Returns ErrDeleteRowNotFound if the row was not found.
@@ -263,48 +325,66 @@ type Lockable interface {
 }
 
 // EvaluateCondition evaluates a condition, which is an expression whose value
-// will be coerced to boolean.
+// will be coerced to boolean. Conditions whose value is NULL, which are
+// UNKNOWN, are false.
 func EvaluateCondition(ctx *Context, cond Expression, row Row) (bool, error) {
-	v, err := cond.Eval(ctx, row)
+	v, err := EvaluateTruth(ctx, cond, row)
 	if err != nil {
 		return false, err
 	}
 
+	return v == true, nil
+}
+
+// EvaluateTruth evaluates a condition following the three-valued logic of
+// SQL: its value is coerced to true or false, unless it's NULL, which is
+// UNKNOWN and returned as nil.
+func EvaluateTruth(ctx *Context, cond Expression, row Row) (interface{}, error) {
+	v, err := cond.Eval(ctx, row)
+	if v == nil || err != nil {
+		return nil, err
+	}
+
+	return isTrue(v), nil
+}
+
+// isTrue coerces a value that is not NULL to boolean.
+func isTrue(v interface{}) bool {
 	switch b := v.(type) {
 	case bool:
-		return b, nil
+		return b
 	case int:
-		return b != int(0), nil
+		return b != int(0)
 	case int64:
-		return b != int64(0), nil
+		return b != int64(0)
 	case int32:
-		return b != int32(0), nil
+		return b != int32(0)
 	case int16:
-		return b != int16(0), nil
+		return b != int16(0)
 	case int8:
-		return b != int8(0), nil
+		return b != int8(0)
 	case uint:
-		return b != uint(0), nil
+		return b != uint(0)
 	case uint64:
-		return b != uint64(0), nil
+		return b != uint64(0)
 	case uint32:
-		return b != uint32(0), nil
+		return b != uint32(0)
 	case uint16:
-		return b != uint16(0), nil
+		return b != uint16(0)
 	case uint8:
-		return b != uint8(0), nil
+		return b != uint8(0)
 	case time.Duration:
-		return int64(b) != 0, nil
+		return int64(b) != 0
 	case time.Time:
-		return b.UnixNano() != 0, nil
+		return b.UnixNano() != 0
 	case float64:
-		return int(math.Round(v.(float64))) != 0, nil
+		return int(math.Round(v.(float64))) != 0
 	case float32:
-		return int(math.Round(float64(v.(float32)))) != 0, nil
+		return int(math.Round(float64(v.(float32)))) != 0
 	case string:
 		parsed, err := strconv.ParseFloat(v.(string), 64)
-		return err == nil && int(parsed) != 0, nil
+		return err == nil && int(parsed) != 0
 	default:
-		return false, nil
+		return false
 	}
 }
@@ -47,3 +47,22 @@ func TestEvaluateCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateTruth(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	for _, v := range conditions {
+		b, err := sql.EvaluateTruth(ctx, expression.NewLiteral(v.value, v.t), sql.NewRow())
+		require.NoError(err)
+		require.Equal(v.evaluated, b)
+	}
+
+	b, err := sql.EvaluateTruth(ctx, expression.NewLiteral(nil, sql.Null), sql.NewRow())
+	require.NoError(err)
+	require.Nil(b)
+
+	ok, err := sql.EvaluateCondition(ctx, expression.NewLiteral(nil, sql.Null), sql.NewRow())
+	require.NoError(err)
+	require.False(ok)
+}
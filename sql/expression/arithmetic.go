@@ -100,18 +100,19 @@ func (a *Arithmetic) IsNullable() bool {
 
 // Type returns the greatest type for given operation.
 func (a *Arithmetic) Type() sql.Type {
+	left, right := numericType(a.Left), numericType(a.Right)
 	switch a.Op {
 	case sqlparser.PlusStr, sqlparser.MinusStr, sqlparser.MultStr, sqlparser.DivStr:
 		if isInterval(a.Left) || isInterval(a.Right) {
 			return sql.Timestamp
 		}
 
-		if sql.IsTime(a.Left.Type()) && sql.IsTime(a.Right.Type()) {
+		if sql.IsTime(left) && sql.IsTime(right) {
 			return sql.Int64
 		}
 
-		if sql.IsInteger(a.Left.Type()) && sql.IsInteger(a.Right.Type()) {
-			if sql.IsUnsigned(a.Left.Type()) && sql.IsUnsigned(a.Right.Type()) {
+		if sql.IsInteger(left) && sql.IsInteger(right) {
+			if sql.IsUnsigned(left) && sql.IsUnsigned(right) {
 				return sql.Uint64
 			}
 			return sql.Int64
@@ -123,7 +124,7 @@ func (a *Arithmetic) Type() sql.Type {
 		return sql.Uint64
 
 	case sqlparser.BitAndStr, sqlparser.BitOrStr, sqlparser.BitXorStr, sqlparser.IntDivStr, sqlparser.ModStr:
-		if sql.IsUnsigned(a.Left.Type()) && sql.IsUnsigned(a.Right.Type()) {
+		if sql.IsUnsigned(left) && sql.IsUnsigned(right) {
 			return sql.Uint64
 		}
 		return sql.Int64
@@ -156,7 +157,8 @@ func (a *Arithmetic) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, nil
 	}
 
-	lval, rval, err = a.convertLeftRight(lval, rval)
+	lval, rval = HexNumber(a.Left, lval), HexNumber(a.Right, rval)
+	lval, rval, err = a.convertLeftRight(ctx, lval, rval)
 	if err != nil {
 		return nil, err
 	}
@@ -220,14 +222,14 @@ func (a *Arithmetic) evalLeftRight(ctx *sql.Context, row sql.Row) (interface{},
 	return lval, rval, nil
 }
 
-func (a *Arithmetic) convertLeftRight(left interface{}, right interface{}) (interface{}, interface{}, error) {
+func (a *Arithmetic) convertLeftRight(ctx *sql.Context, left interface{}, right interface{}) (interface{}, interface{}, error) {
 	var err error
 	typ := a.Type()
 
 	if i, ok := left.(*TimeDelta); ok {
 		left = i
 	} else {
-		left, err = typ.Convert(left)
+		left, err = convertToType(ctx, typ, left)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -236,7 +238,7 @@ func (a *Arithmetic) convertLeftRight(left interface{}, right interface{}) (inte
 	if i, ok := right.(*TimeDelta); ok {
 		right = i
 	} else {
-		right, err = typ.Convert(right)
+		right, err = convertToType(ctx, typ, right)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -495,8 +497,9 @@ func (e *UnaryMinus) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, nil
 	}
 
-	if !sql.IsNumber(e.Child.Type()) {
-		child, err = sql.Float64.Convert(child)
+	child = HexNumber(e.Child, child)
+	if !sql.IsNumber(numericType(e.Child)) {
+		child, err = convertToType(ctx, sql.Float64, child)
 		if err != nil {
 			child = 0.0
 		}
@@ -522,7 +525,7 @@ func (e *UnaryMinus) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 
 // Type implements the sql.Expression interface.
 func (e *UnaryMinus) Type() sql.Type {
-	typ := e.Child.Type()
+	typ := numericType(e.Child)
 	if !sql.IsNumber(typ) {
 		return sql.Float64
 	}
@@ -1,6 +1,7 @@
 package expression
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -414,6 +415,8 @@ func TestUnaryMinus(t *testing.T) {
 		{"float64", float64(1), sql.Float64, float64(-1)},
 		{"int text", "1", sql.Text, float64(-1)},
 		{"float text", "1.2", sql.Text, float64(-1.2)},
+		{"number prefix text", "1.2abc", sql.Text, float64(-1.2)},
+		{"not a number text", "abc", sql.Text, float64(0)},
 		{"nil", nil, sql.Text, nil},
 	}
 
@@ -426,3 +429,70 @@ func TestUnaryMinus(t *testing.T) {
 		})
 	}
 }
+
+func TestArithmeticWithStrings(t *testing.T) {
+	testCases := []struct {
+		name        string
+		left, right interface{}
+		op          string
+		expected    interface{}
+		warnings    int
+	}{
+		{"number", "3", int64(1), "+", float64(4), 0},
+		{"spaces", " 3 ", int64(1), "+", float64(4), 0},
+		{"number prefix", "3abc", int64(1), "+", float64(4), 1},
+		{"empty", "", int64(1), "+", float64(1), 1},
+		{"not a number", "abc", int64(2), "*", float64(0), 1},
+		{"both strings", "2.5x", "2y", "*", float64(5), 2},
+		{"integer division", "7abc", int64(2), "div", int64(3), 1},
+		{"modulo", "7abc", int64(4), "%", int64(3), 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := sql.NewEmptyContext()
+			result, err := NewArithmetic(
+				NewLiteral(tt.left, typeOf(tt.left)),
+				NewLiteral(tt.right, typeOf(tt.right)),
+				tt.op,
+			).Eval(ctx, nil)
+			require.NoError(err)
+			require.Equal(tt.expected, result)
+			require.Len(ctx.Warnings(), tt.warnings)
+		})
+	}
+}
+
+func typeOf(v interface{}) sql.Type {
+	if _, ok := v.(string); ok {
+		return sql.Text
+	}
+	return sql.Int64
+}
+
+func TestArithmeticWithHexLiterals(t *testing.T) {
+	require := require.New(t)
+
+	plus := NewPlus(NewHexLiteral([]byte{0x01, 0x00}), NewLiteral(int64(1), sql.Int64))
+	require.Equal(sql.Int64, plus.Type())
+	result, err := plus.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal(int64(257), result)
+
+	mult := NewMult(NewHexLiteral([]byte{0x02}), NewHexLiteral([]byte{0x03}))
+	require.Equal(sql.Uint64, mult.Type())
+	result, err = mult.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal(uint64(6), result)
+
+	minus := NewUnaryMinus(NewHexLiteral([]byte{0x10}))
+	result, err = minus.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal(int64(-16), result)
+
+	// Numbers longer than 8 bytes are clamped.
+	require.Equal(uint64(math.MaxUint64), HexNumber(NewHexLiteral([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0}), nil))
+	require.Equal(uint64(1), HexNumber(NewHexLiteral([]byte{0, 0, 0, 0, 0, 0, 0, 0, 1}), nil))
+	require.Equal("foo", HexNumber(NewLiteral([]byte("foo"), sql.Blob), "foo"))
+}
@@ -52,7 +52,7 @@ func (b *Between) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, nil
 	}
 
-	val, err = typ.Convert(val)
+	val, err = convertToType(ctx, typ, val)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +66,7 @@ func (b *Between) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, nil
 	}
 
-	lower, err = typ.Convert(lower)
+	lower, err = convertToType(ctx, typ, lower)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +80,7 @@ func (b *Between) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, nil
 	}
 
-	upper, err = typ.Convert(upper)
+	upper, err = convertToType(ctx, typ, upper)
 	if err != nil {
 		return nil, err
 	}
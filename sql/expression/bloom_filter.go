@@ -0,0 +1,149 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// bloomFilterFalsePositiveRate is the rate of false positives of the bloom
+// filters built with the keys of a join.
+const bloomFilterFalsePositiveRate = 0.01
+
+// BloomFilter is an expression that checks whether the key of a row may be
+// one of the keys of the rows of the other side of a join, so the rows that
+// cannot match any of them are skipped before they're joined. The keys of
+// the other side are only known once the join has read all of its rows, so
+// until then the expression is true for every row. All the copies of the
+// expression share the same keys.
+type BloomFilter struct {
+	keys []sql.Expression
+	ref  *BloomFilterRef
+}
+
+// BloomFilterRef holds the keys of a BloomFilter, which are set during the
+// execution of the join.
+type BloomFilterRef struct {
+	filter atomic.Value
+}
+
+// NewBloomFilter creates a new BloomFilter expression with the given key
+// expressions and no keys set.
+func NewBloomFilter(keys ...sql.Expression) *BloomFilter {
+	return &BloomFilter{keys, new(BloomFilterRef)}
+}
+
+// Keys returns the expressions of the key of a row.
+func (e *BloomFilter) Keys() []sql.Expression { return e.keys }
+
+// Ref returns the holder of the keys of the expression.
+func (e *BloomFilter) Ref() *BloomFilterRef { return e.ref }
+
+// WithRef returns a copy of the expression sharing the keys of the given
+// holder.
+func (e *BloomFilter) WithRef(ref *BloomFilterRef) *BloomFilter {
+	return &BloomFilter{e.keys, ref}
+}
+
+// WithKeys returns a copy of the expression with the given key expressions
+// sharing the keys of this one.
+func (e *BloomFilter) WithKeys(keys ...sql.Expression) *BloomFilter {
+	return &BloomFilter{keys, e.ref}
+}
+
+// Build sets the keys of the expression, and of all its copies, to the keys
+// of the given rows.
+func (e *BloomFilter) Build(ctx *sql.Context, rows []sql.Row) error {
+	f := sql.NewBloomFilter(uint64(len(rows)), bloomFilterFalsePositiveRate)
+	for _, row := range rows {
+		hash, ok, err := e.hash(ctx, row)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			f.Add(hash)
+		}
+	}
+
+	e.ref.filter.Store(f)
+	return nil
+}
+
+// hash returns the hash of the key of the row, or false if any of the
+// values of the key is null, as it cannot be equal to any other key.
+func (e *BloomFilter) hash(ctx *sql.Context, row sql.Row) (uint64, bool, error) {
+	var values = make([]interface{}, len(e.keys))
+	for i, k := range e.keys {
+		v, err := k.Eval(ctx, row)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if v == nil {
+			return 0, false, nil
+		}
+
+		// Values are converted, so equal values of different Go types
+		// have the same hash.
+		v, err = k.Type().Convert(v)
+		if err != nil {
+			return 0, false, err
+		}
+		values[i] = v
+	}
+
+	return sql.CacheKey(values), true, nil
+}
+
+// Resolved implements the Expression interface.
+func (e *BloomFilter) Resolved() bool {
+	for _, k := range e.keys {
+		if !k.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNullable implements the Expression interface.
+func (e *BloomFilter) IsNullable() bool { return false }
+
+// Type implements the Expression interface.
+func (e *BloomFilter) Type() sql.Type { return sql.Boolean }
+
+// Children implements the Expression interface.
+func (e *BloomFilter) Children() []sql.Expression { return e.keys }
+
+// Eval implements the Expression interface.
+func (e *BloomFilter) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	f, ok := e.ref.filter.Load().(*sql.BloomFilter)
+	if !ok {
+		return true, nil
+	}
+
+	hash, ok, err := e.hash(ctx, row)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return f.MayContain(hash), nil
+}
+
+// WithChildren implements the Expression interface.
+func (e *BloomFilter) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != len(e.keys) {
+		return nil, sql.ErrInvalidChildrenNumber.New(e, len(children), len(e.keys))
+	}
+	return e.WithKeys(children...), nil
+}
+
+func (e *BloomFilter) String() string {
+	var keys = make([]string, len(e.keys))
+	for i, k := range e.keys {
+		keys[i] = k.String()
+	}
+	return fmt.Sprintf("BLOOM_FILTER(%s)", strings.Join(keys, ", "))
+}
@@ -0,0 +1,78 @@
+package expression
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	probe := NewBloomFilter(
+		NewGetField(0, sql.Int64, "a", true),
+		NewGetField(1, sql.Text, "b", true),
+	)
+	build := probe.WithKeys(
+		NewGetField(1, sql.Int64, "a", true),
+		NewGetField(0, sql.Text, "b", true),
+	)
+
+	v, err := probe.Eval(ctx, sql.NewRow(int64(1), "foo"))
+	require.NoError(err)
+	require.Equal(true, v)
+
+	var rows []sql.Row
+	for i := 0; i < 100; i++ {
+		rows = append(rows, sql.NewRow(fmt.Sprint(i), int64(i)))
+	}
+	rows = append(rows, sql.NewRow(nil, int64(1000)))
+	require.NoError(build.Build(ctx, rows))
+
+	for i := 0; i < 100; i++ {
+		v, err := probe.Eval(ctx, sql.NewRow(int64(i), fmt.Sprint(i)))
+		require.NoError(err)
+		require.Equal(true, v)
+	}
+
+	// Values of other types are converted to the type of the key.
+	v, err = probe.Eval(ctx, sql.NewRow(int32(1), "1"))
+	require.NoError(err)
+	require.Equal(true, v)
+
+	v, err = probe.Eval(ctx, sql.NewRow(int64(1000), nil))
+	require.NoError(err)
+	require.Equal(false, v)
+
+	var falsePositives int
+	for i := 100; i < 1100; i++ {
+		v, err := probe.Eval(ctx, sql.NewRow(int64(i), fmt.Sprint(i)))
+		require.NoError(err)
+		if v == true {
+			falsePositives++
+		}
+	}
+	require.True(falsePositives < 50, "%d false positives", falsePositives)
+
+	other := probe.WithRef(new(BloomFilterRef))
+	v, err = other.Eval(ctx, sql.NewRow(int64(1000), "1000"))
+	require.NoError(err)
+	require.Equal(true, v)
+}
+
+func TestBloomFilterWithChildren(t *testing.T) {
+	require := require.New(t)
+
+	f := NewBloomFilter(NewGetField(0, sql.Int64, "a", false))
+	require.Equal("BLOOM_FILTER(a)", f.String())
+
+	e, err := f.WithChildren(NewGetField(1, sql.Int64, "a", false))
+	require.NoError(err)
+	require.Equal(f.Ref(), e.(*BloomFilter).Ref())
+
+	_, err = f.WithChildren()
+	require.Error(err)
+}
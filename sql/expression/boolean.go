@@ -2,7 +2,6 @@ package expression
 
 import (
 	"fmt"
-	"reflect"
 
 	"github.com/src-d/go-mysql-server/sql"
 )
@@ -22,30 +21,15 @@ func (e *Not) Type() sql.Type {
 	return sql.Boolean
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. The negation of NULL, which is
+// UNKNOWN, is NULL.
 func (e *Not) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
-	v, err := e.Child.Eval(ctx, row)
-	if err != nil {
+	v, err := sql.EvaluateTruth(ctx, e.Child, row)
+	if v == nil || err != nil {
 		return nil, err
 	}
 
-	if v == nil {
-		return nil, nil
-	}
-
-	b, ok := v.(bool)
-	if !ok {
-		v, _ = e.Type().Convert(v)
-		if v == nil {
-			return nil, nil
-		}
-
-		if b, ok = v.(bool); !ok {
-			return nil, sql.ErrInvalidType.New(reflect.TypeOf(v).String())
-		}
-	}
-
-	return !b, nil
+	return !v.(bool), nil
 }
 
 func (e *Not) String() string {
@@ -20,4 +20,6 @@ func TestNot(t *testing.T) {
 	require.False(eval(t, e, sql.NewRow(time.Now())).(bool))
 	require.False(eval(t, e, sql.NewRow(time.Second)).(bool))
 	require.True(eval(t, e, sql.NewRow("any string always false")).(bool))
+	require.False(eval(t, e, sql.NewRow("1")).(bool))
+	require.Nil(eval(t, NewNot(NewNot(e)), sql.NewRow(nil)))
 }
@@ -3,6 +3,7 @@ package expression
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/src-d/go-mysql-server/internal/regex"
 	"github.com/src-d/go-mysql-server/sql"
@@ -46,7 +47,9 @@ func (c *comparison) Compare(ctx *sql.Context, row sql.Row) (int, error) {
 		return c.Left().Type().Compare(left, right)
 	}
 
-	left, right, err = c.castLeftAndRight(left, right)
+	left = sessionTime(ctx, c.Left(), left)
+	right = sessionTime(ctx, c.Right(), right)
+	left, right, err = c.castLeftAndRight(ctx, left, right)
 	if err != nil {
 		return 0, err
 	}
@@ -68,9 +71,42 @@ func (c *comparison) evalLeftAndRight(ctx *sql.Context, row sql.Row) (interface{
 	return left, right, nil
 }
 
-func (c *comparison) castLeftAndRight(left, right interface{}) (interface{}, interface{}, error) {
+// sessionTime converts a timestamp, which is kept in UTC, to the time zone
+// of the session, as it's compared with a value of another type.
+func sessionTime(ctx *sql.Context, e sql.Expression, v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok && sql.IsTimestamp(e.Type()) {
+		return sql.ConvertTimeZone(t.UTC(), time.UTC, ctx.Location())
+	}
+	return v
+}
+
+// numberFromString returns the number of a string used as a number, or the
+// value as it is if it's not a string.
+func numberFromString(ctx *sql.Context, v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return sql.NumberFromString(ctx, s)
+	}
+	return v
+}
+
+// convertToType converts a value to a type. Strings converted to numeric
+// types are read as numbers, from their start.
+func convertToType(ctx *sql.Context, typ sql.Type, v interface{}) (interface{}, error) {
+	if sql.IsNumber(typ) {
+		v = numberFromString(ctx, v)
+	}
+	return typ.Convert(v)
+}
+
+func (c *comparison) castLeftAndRight(ctx *sql.Context, left, right interface{}) (interface{}, interface{}, error) {
 	if sql.IsNumber(c.Left().Type()) || sql.IsNumber(c.Right().Type()) {
-		if sql.IsDecimal(c.Left().Type()) || sql.IsDecimal(c.Right().Type()) {
+		// Hexadecimal literals compared with numbers are numbers too, and
+		// strings are read as numbers, which may have decimals, so they are
+		// compared as floats.
+		lt, rt := numericType(c.Left()), numericType(c.Right())
+		left = numberFromString(ctx, HexNumber(c.Left(), left))
+		right = numberFromString(ctx, HexNumber(c.Right(), right))
+		if sql.IsDecimal(lt) || sql.IsDecimal(rt) || sql.IsText(lt) || sql.IsText(rt) {
 			l, r, err := convertLeftAndRight(left, right, ConvertToDecimal)
 			if err != nil {
 				return nil, nil, err
@@ -80,7 +116,7 @@ func (c *comparison) castLeftAndRight(left, right interface{}) (interface{}, int
 			return l, r, nil
 		}
 
-		if sql.IsSigned(c.Left().Type()) || sql.IsSigned(c.Right().Type()) {
+		if sql.IsSigned(lt) || sql.IsSigned(rt) {
 			l, r, err := convertLeftAndRight(left, right, ConvertToSigned)
 			if err != nil {
 				return nil, nil, err
@@ -99,6 +135,19 @@ func (c *comparison) castLeftAndRight(left, right interface{}) (interface{}, int
 		return l, r, nil
 	}
 
+	if sql.IsTime(c.Left().Type()) || sql.IsTime(c.Right().Type()) {
+		l, r, err := convertLeftAndRight(left, right, ConvertToDatetime)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Values that are not times are compared as strings.
+		if l != nil && r != nil {
+			c.compareType = sql.Datetime
+			return l, r, nil
+		}
+	}
+
 	left, right, err := convertLeftAndRight(left, right, ConvertToChar)
 	if err != nil {
 		return nil, nil, err
@@ -448,7 +497,9 @@ func NewIn(left sql.Expression, right sql.Expression) *In {
 	return &In{newComparison(left, right)}
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. If the expression is not found
+// but the list has NULL values, the result is NULL, as it's UNKNOWN whether
+// the expression is in the list.
 func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 	typ := in.Left().Type()
 	leftElems := sql.NumColumns(typ)
@@ -461,7 +512,7 @@ func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 
-	left, err = typ.Convert(left)
+	left, err = convertToType(ctx, typ, left)
 	if err != nil {
 		return nil, err
 	}
@@ -474,13 +525,19 @@ func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			}
 		}
 
+		var hasNull bool
 		for _, el := range right {
 			right, err := el.Eval(ctx, row)
 			if err != nil {
 				return nil, err
 			}
 
-			right, err = typ.Convert(right)
+			if right == nil {
+				hasNull = true
+				continue
+			}
+
+			right, err = convertToType(ctx, typ, right)
 			if err != nil {
 				return nil, err
 			}
@@ -495,6 +552,10 @@ func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			}
 		}
 
+		if hasNull {
+			return nil, nil
+		}
+
 		return false, nil
 	case *Subquery:
 		if leftElems > 1 {
@@ -507,7 +568,13 @@ func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			return nil, err
 		}
 
+		var hasNull bool
 		for _, val := range values {
+			if val == nil {
+				hasNull = true
+				continue
+			}
+
 			val, err = typ.Convert(val)
 			if err != nil {
 				return nil, err
@@ -523,6 +590,10 @@ func (in *In) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			}
 		}
 
+		if hasNull {
+			return nil, nil
+		}
+
 		return false, nil
 	default:
 		return nil, ErrUnsupportedInOperand.New(right)
@@ -556,7 +627,9 @@ func NewNotIn(left sql.Expression, right sql.Expression) *NotIn {
 	return &NotIn{newComparison(left, right)}
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. If the expression is not found
+// but the list has NULL values, the result is NULL, as it's UNKNOWN whether
+// the expression is in the list.
 func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 	typ := in.Left().Type()
 	leftElems := sql.NumColumns(typ)
@@ -569,7 +642,7 @@ func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 
-	left, err = typ.Convert(left)
+	left, err = convertToType(ctx, typ, left)
 	if err != nil {
 		return nil, err
 	}
@@ -582,13 +655,19 @@ func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			}
 		}
 
+		var hasNull bool
 		for _, el := range right {
 			right, err := el.Eval(ctx, row)
 			if err != nil {
 				return nil, err
 			}
 
-			right, err = typ.Convert(right)
+			if right == nil {
+				hasNull = true
+				continue
+			}
+
+			right, err = convertToType(ctx, typ, right)
 			if err != nil {
 				return nil, err
 			}
@@ -603,6 +682,10 @@ func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			}
 		}
 
+		if hasNull {
+			return nil, nil
+		}
+
 		return true, nil
 	case *Subquery:
 		if leftElems > 1 {
@@ -615,7 +698,13 @@ func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			return nil, err
 		}
 
+		var hasNull bool
 		for _, val := range values {
+			if val == nil {
+				hasNull = true
+				continue
+			}
+
 			val, err = typ.Convert(val)
 			if err != nil {
 				return nil, err
@@ -631,6 +720,10 @@ func (in *NotIn) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 			}
 		}
 
+		if hasNull {
+			return nil, nil
+		}
+
 		return true, nil
 	default:
 		return nil, ErrUnsupportedInOperand.New(right)
@@ -122,6 +122,76 @@ func TestEquals(t *testing.T) {
 	}
 }
 
+func TestCompareStringsWithNumbers(t *testing.T) {
+	testCases := []struct {
+		str      string
+		num      interface{}
+		typ      sql.Type
+		expected int
+	}{
+		{"3", int64(3), sql.Int64, 0},
+		{"3abc", int64(3), sql.Int64, 0},
+		{"", int64(0), sql.Int64, 0},
+		{"abc", int64(0), sql.Int64, 0},
+		{"3.5", int64(3), sql.Int64, 1},
+		{"3.0", int64(3), sql.Int64, 0},
+		{" -2x", int8(-1), sql.Int8, -1},
+		{"1e2", uint64(100), sql.Uint64, 0},
+		{"2.5 apples", 2.5, sql.Float64, 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.str, func(t *testing.T) {
+			require := require.New(t)
+			ctx := sql.NewEmptyContext()
+			cmp, err := expression.NewEquals(
+				expression.NewLiteral(tt.str, sql.Text),
+				expression.NewLiteral(tt.num, tt.typ),
+			).Compare(ctx, nil)
+			require.NoError(err)
+			require.Equal(tt.expected, cmp)
+		})
+	}
+
+	ctx := sql.NewEmptyContext()
+	_, err := expression.NewLessThan(
+		expression.NewLiteral(int64(4), sql.Int64),
+		expression.NewLiteral("3abc", sql.Text),
+	).Compare(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, ctx.Warnings(), 1)
+	require.Equal(t, 1292, ctx.Warnings()[0].Code)
+	require.Equal(t, "Truncated incorrect DOUBLE value: '3abc'", ctx.Warnings()[0].Message)
+}
+
+func TestCompareHexLiterals(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	// Hexadecimal literals are numbers when compared with numbers, and
+	// strings otherwise.
+	cmp, err := expression.NewEquals(
+		expression.NewHexLiteral([]byte{'A'}),
+		expression.NewLiteral(int64(65), sql.Int64),
+	).Compare(ctx, nil)
+	require.NoError(err)
+	require.Equal(0, cmp)
+
+	cmp, err = expression.NewEquals(
+		expression.NewHexLiteral([]byte{'A'}),
+		expression.NewLiteral("A", sql.Text),
+	).Compare(ctx, nil)
+	require.NoError(err)
+	require.Equal(0, cmp)
+
+	cmp, err = expression.NewLessThan(
+		expression.NewLiteral(int8(-1), sql.Int8),
+		expression.NewHexLiteral([]byte{0xFF}),
+	).Compare(ctx, nil)
+	require.NoError(err)
+	require.Equal(-1, cmp)
+}
+
 func TestLessThan(t *testing.T) {
 	require := require.New(t)
 	for resultType, cmpCase := range comparisonCases {
@@ -290,6 +360,28 @@ func TestIn(t *testing.T) {
 			false,
 			nil,
 		},
+		{
+			"left is in right with nulls",
+			expression.NewGetField(0, sql.Int64, "foo", false),
+			expression.NewTuple(
+				expression.NewLiteral(nil, sql.Null),
+				expression.NewGetField(0, sql.Int64, "foo", false),
+			),
+			sql.NewRow(int64(1)),
+			true,
+			nil,
+		},
+		{
+			"left is not in right with nulls",
+			expression.NewGetField(0, sql.Int64, "foo", false),
+			expression.NewTuple(
+				expression.NewLiteral(int64(2), sql.Int64),
+				expression.NewLiteral(nil, sql.Null),
+			),
+			sql.NewRow(int64(1)),
+			nil,
+			nil,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -461,6 +553,28 @@ func TestNotIn(t *testing.T) {
 			true,
 			nil,
 		},
+		{
+			"left is in right with nulls",
+			expression.NewGetField(0, sql.Int64, "foo", false),
+			expression.NewTuple(
+				expression.NewLiteral(nil, sql.Null),
+				expression.NewGetField(0, sql.Int64, "foo", false),
+			),
+			sql.NewRow(int64(1)),
+			false,
+			nil,
+		},
+		{
+			"left is not in right with nulls",
+			expression.NewGetField(0, sql.Int64, "foo", false),
+			expression.NewTuple(
+				expression.NewLiteral(int64(2), sql.Int64),
+				expression.NewLiteral(nil, sql.Null),
+			),
+			sql.NewRow(int64(1)),
+			nil,
+			nil,
+		},
 	}
 
 	for _, tt := range testCases {
@@ -26,6 +26,8 @@ const (
 	ConvertToDate = "date"
 	// ConvertToDatetime is a conversion to datetune.
 	ConvertToDatetime = "datetime"
+	// ConvertToTime is a conversion to time.
+	ConvertToTime = "time"
 	// ConvertToDecimal is a conversion to decimal.
 	ConvertToDecimal = "decimal"
 	// ConvertToJSON is a conversion to json.
@@ -41,6 +43,9 @@ type Convert struct {
 	UnaryExpression
 	// Type to cast
 	castToType string
+	// precision is the number of digits of the fractional seconds of the
+	// conversions to datetime and time.
+	precision int
 }
 
 // NewConvert creates a new Convert expression.
@@ -51,10 +56,18 @@ func NewConvert(expr sql.Expression, castToType string) *Convert {
 	}
 }
 
+// NewConvertWithPrecision creates a new Convert expression to datetime or
+// time whose values have the given number of digits of fractional seconds.
+func NewConvertWithPrecision(expr sql.Expression, castToType string, precision int) *Convert {
+	c := NewConvert(expr, castToType)
+	c.precision = precision
+	return c
+}
+
 // IsNullable implements the Expression interface.
 func (c *Convert) IsNullable() bool {
 	switch c.castToType {
-	case ConvertToDate, ConvertToDatetime:
+	case ConvertToDate, ConvertToDatetime, ConvertToTime:
 		return true
 	default:
 		return c.Child.IsNullable()
@@ -71,7 +84,9 @@ func (c *Convert) Type() sql.Type {
 	case ConvertToDate:
 		return sql.Date
 	case ConvertToDatetime:
-		return sql.Timestamp
+		return sql.TimestampWithPrecision(c.precision)
+	case ConvertToTime:
+		return sql.TimeWithPrecision(c.precision)
 	case ConvertToDecimal:
 		return sql.Float64
 	case ConvertToJSON:
@@ -87,6 +102,9 @@ func (c *Convert) Type() sql.Type {
 
 // Name implements the Expression interface.
 func (c *Convert) String() string {
+	if c.precision > 0 {
+		return fmt.Sprintf("convert(%v, %v(%d))", c.Child, c.castToType, c.precision)
+	}
 	return fmt.Sprintf("convert(%v, %v)", c.Child, c.castToType)
 }
 
@@ -95,7 +113,7 @@ func (c *Convert) WithChildren(children ...sql.Expression) (sql.Expression, erro
 	if len(children) != 1 {
 		return nil, sql.ErrInvalidChildrenNumber.New(c, len(children), 1)
 	}
-	return NewConvert(children[0], c.castToType), nil
+	return NewConvertWithPrecision(children[0], c.castToType, c.precision), nil
 }
 
 // Eval implements the Expression interface.
@@ -109,6 +127,11 @@ func (c *Convert) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, nil
 	}
 
+	switch c.castToType {
+	case ConvertToDecimal, ConvertToSigned, ConvertToUnsigned:
+		val = numberFromString(ctx, HexNumber(c.Child, val))
+	}
+
 	casted, err := convertValue(val, c.castToType)
 	if err != nil {
 		return nil, ErrConvertExpression.Wrap(err, c.String(), c.castToType)
@@ -154,6 +177,13 @@ func convertValue(val interface{}, castTo string) (interface{}, error) {
 		}
 
 		return sql.ValidateTime(d.(time.Time)), nil
+	case ConvertToTime:
+		d, err := sql.Time.Convert(val)
+		if err != nil {
+			return nil, nil
+		}
+
+		return d, nil
 	case ConvertToDecimal:
 		d, err := cast.ToFloat64E(val)
 		if err != nil {
@@ -33,6 +33,22 @@ func TestConvert(t *testing.T) {
 			expected:    uint64(18446744073709551611),
 			expectedErr: false,
 		},
+		{
+			name:        "convert number prefix to signed",
+			row:         nil,
+			expression:  NewLiteral("12abc", sql.Text),
+			castTo:      ConvertToSigned,
+			expected:    int64(12),
+			expectedErr: false,
+		},
+		{
+			name:        "convert number prefix to decimal",
+			row:         nil,
+			expression:  NewLiteral(" 1.5e1x", sql.Text),
+			castTo:      ConvertToDecimal,
+			expected:    float64(15),
+			expectedErr: false,
+		},
 		{
 			name:        "convert string to signed",
 			row:         nil,
@@ -62,6 +62,9 @@ func (f *First) Update(ctx *sql.Context, buffer, row sql.Row) error {
 
 // Merge implements the Aggregation interface.
 func (f *First) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
+	if buffer[0] == nil {
+		buffer[0] = partial[0]
+	}
 	return nil
 }
 
@@ -0,0 +1,103 @@
+package aggregation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Grouping tells the rows of the groups of grouping sets, ROLLUP or CUBE in
+// which its arguments, which are grouping expressions, are rolled up, that
+// is, NULL because they're not in the grouping set of the group. It returns
+// a bitmask with a bit for each argument, the last one being the lowest,
+// which is 1 if the argument is rolled up. The grouping sets replace it with
+// its value in each of them, so as an aggregation it's always 0, as in the
+// groups of a GROUP BY without grouping sets.
+// It implements the Aggregation interface.
+type Grouping struct {
+	args []sql.Expression
+}
+
+var _ sql.Aggregation = (*Grouping)(nil)
+
+// NewGrouping returns a new Grouping node.
+func NewGrouping(args ...sql.Expression) (sql.Expression, error) {
+	if len(args) == 0 {
+		return nil, sql.ErrInvalidArgumentNumber.New("GROUPING", "1 or more", 0)
+	}
+
+	return &Grouping{args}, nil
+}
+
+// Value returns the value of the function in the groups of a grouping set
+// in which the expressions for which the given function returns true are
+// rolled up.
+func (g *Grouping) Value(rolledUp func(sql.Expression) bool) int64 {
+	var v int64
+	for _, arg := range g.args {
+		v <<= 1
+		if rolledUp(arg) {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// Type returns the resultant type of the aggregation.
+func (g *Grouping) Type() sql.Type {
+	return sql.Int64
+}
+
+// IsNullable implements the sql.Expression interface.
+func (g *Grouping) IsNullable() bool {
+	return false
+}
+
+// Resolved implements the sql.Expression interface.
+func (g *Grouping) Resolved() bool {
+	for _, arg := range g.args {
+		if !arg.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// Children implements the sql.Expression interface.
+func (g *Grouping) Children() []sql.Expression {
+	return g.args
+}
+
+func (g *Grouping) String() string {
+	var args = make([]string, len(g.args))
+	for i, arg := range g.args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("GROUPING(%s)", strings.Join(args, ", "))
+}
+
+// WithChildren implements the sql.Expression interface.
+func (g *Grouping) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	return NewGrouping(children...)
+}
+
+// NewBuffer creates a new buffer to compute the result.
+func (g *Grouping) NewBuffer() sql.Row {
+	return sql.NewRow(int64(0))
+}
+
+// Update implements the Aggregation interface.
+func (g *Grouping) Update(ctx *sql.Context, buffer, row sql.Row) error {
+	return nil
+}
+
+// Merge implements the Aggregation interface.
+func (g *Grouping) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
+	return nil
+}
+
+// Eval implements the Aggregation interface.
+func (g *Grouping) Eval(ctx *sql.Context, buffer sql.Row) (interface{}, error) {
+	return buffer[0], nil
+}
@@ -0,0 +1,42 @@
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrouping(t *testing.T) {
+	require := require.New(t)
+
+	_, err := NewGrouping()
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	a := expression.NewGetField(0, sql.Int64, "a", false)
+	b := expression.NewGetField(1, sql.Int64, "b", false)
+	c := expression.NewGetField(2, sql.Int64, "c", false)
+	g, err := NewGrouping(a, b, c)
+	require.NoError(err)
+	require.Equal("GROUPING(a, b, c)", g.String())
+
+	grouping := g.(*Grouping)
+	rolledUp := func(exprs ...sql.Expression) func(sql.Expression) bool {
+		return func(e sql.Expression) bool {
+			for _, r := range exprs {
+				if r == e {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	require.Equal(int64(0), grouping.Value(rolledUp()))
+	require.Equal(int64(1), grouping.Value(rolledUp(c)))
+	require.Equal(int64(5), grouping.Value(rolledUp(a, c)))
+	require.Equal(int64(7), grouping.Value(rolledUp(a, b, c)))
+
+	// As an aggregation, the arguments are never rolled up.
+	require.Equal(int64(0), aggregate(t, grouping, sql.NewRow(int64(1), int64(2), int64(3))))
+}
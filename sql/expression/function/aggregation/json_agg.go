@@ -0,0 +1,225 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrJSONObjectAggNullKey is returned when a JSON_OBJECTAGG key is NULL.
+var ErrJSONObjectAggNullKey = errors.NewKind("JSON documents may not contain NULL member names")
+
+// JSONArrayAgg aggregates all the values of a group, including NULLs, in a
+// single JSON array.
+// It implements the Aggregation interface.
+type JSONArrayAgg struct {
+	expression.UnaryExpression
+}
+
+// NewJSONArrayAgg returns a new JSONArrayAgg node.
+func NewJSONArrayAgg(e sql.Expression) *JSONArrayAgg {
+	return &JSONArrayAgg{expression.UnaryExpression{Child: e}}
+}
+
+// Type returns the resultant type of the aggregation.
+func (j *JSONArrayAgg) Type() sql.Type {
+	return sql.JSON
+}
+
+// IsNullable implements the sql.Expression interface.
+func (j *JSONArrayAgg) IsNullable() bool {
+	return true
+}
+
+func (j *JSONArrayAgg) String() string {
+	return fmt.Sprintf("JSON_ARRAYAGG(%s)", j.Child)
+}
+
+// WithChildren implements the sql.Expression interface.
+func (j *JSONArrayAgg) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 1)
+	}
+	return NewJSONArrayAgg(children[0]), nil
+}
+
+// NewBuffer creates a new buffer to compute the result.
+func (j *JSONArrayAgg) NewBuffer() sql.Row {
+	return sql.NewRow(nil)
+}
+
+// Update implements the Aggregation interface.
+func (j *JSONArrayAgg) Update(ctx *sql.Context, buffer, row sql.Row) error {
+	v, err := j.Child.Eval(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	v, err = jsonValue(j.Child.Type(), v)
+	if err != nil {
+		return err
+	}
+
+	values, _ := buffer[0].([]interface{})
+	buffer[0] = append(values, v)
+
+	return nil
+}
+
+// Merge implements the Aggregation interface.
+func (j *JSONArrayAgg) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
+	values, _ := buffer[0].([]interface{})
+	partialValues, _ := partial[0].([]interface{})
+	if len(partialValues) > 0 {
+		buffer[0] = append(values, partialValues...)
+	}
+	return nil
+}
+
+// Eval implements the Aggregation interface.
+func (j *JSONArrayAgg) Eval(ctx *sql.Context, buffer sql.Row) (interface{}, error) {
+	values, ok := buffer[0].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return values, nil
+}
+
+// JSONObjectAgg aggregates the key-value pairs of a group in a single JSON
+// object. If a key appears more than once, the last value is kept.
+// It implements the Aggregation interface.
+type JSONObjectAgg struct {
+	expression.BinaryExpression
+}
+
+// NewJSONObjectAgg returns a new JSONObjectAgg node.
+func NewJSONObjectAgg(key, value sql.Expression) *JSONObjectAgg {
+	return &JSONObjectAgg{expression.BinaryExpression{Left: key, Right: value}}
+}
+
+// Type returns the resultant type of the aggregation.
+func (j *JSONObjectAgg) Type() sql.Type {
+	return sql.JSON
+}
+
+// IsNullable implements the sql.Expression interface.
+func (j *JSONObjectAgg) IsNullable() bool {
+	return true
+}
+
+func (j *JSONObjectAgg) String() string {
+	return fmt.Sprintf("JSON_OBJECTAGG(%s, %s)", j.Left, j.Right)
+}
+
+// WithChildren implements the sql.Expression interface.
+func (j *JSONObjectAgg) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 2)
+	}
+	return NewJSONObjectAgg(children[0], children[1]), nil
+}
+
+// NewBuffer creates a new buffer to compute the result.
+func (j *JSONObjectAgg) NewBuffer() sql.Row {
+	return sql.NewRow(nil)
+}
+
+// Update implements the Aggregation interface.
+func (j *JSONObjectAgg) Update(ctx *sql.Context, buffer, row sql.Row) error {
+	key, err := j.Left.Eval(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	if key == nil {
+		return ErrJSONObjectAggNullKey.New()
+	}
+
+	key, err = sql.Text.Convert(key)
+	if err != nil {
+		return err
+	}
+
+	v, err := j.Right.Eval(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	v, err = jsonValue(j.Right.Type(), v)
+	if err != nil {
+		return err
+	}
+
+	obj, ok := buffer[0].(map[string]interface{})
+	if !ok {
+		obj = make(map[string]interface{})
+		buffer[0] = obj
+	}
+	obj[key.(string)] = v
+
+	return nil
+}
+
+// Merge implements the Aggregation interface.
+func (j *JSONObjectAgg) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
+	partialObj, ok := partial[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	obj, ok := buffer[0].(map[string]interface{})
+	if !ok {
+		obj = make(map[string]interface{}, len(partialObj))
+		buffer[0] = obj
+	}
+
+	for k, v := range partialObj {
+		obj[k] = v
+	}
+
+	return nil
+}
+
+// Eval implements the Aggregation interface.
+func (j *JSONObjectAgg) Eval(ctx *sql.Context, buffer sql.Row) (interface{}, error) {
+	obj, ok := buffer[0].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return obj, nil
+}
+
+// jsonValue returns the value to be stored in a JSON document for the given
+// value of the given type. JSON values are decoded so they're nested in the
+// resulting document instead of being added as strings.
+func jsonValue(typ sql.Type, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if typ == sql.JSON {
+		b, ok := v.([]byte)
+		if !ok {
+			val, err := sql.JSON.Convert(v)
+			if err != nil {
+				return nil, err
+			}
+			b = val.([]byte)
+		}
+
+		var doc interface{}
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	if b, ok := v.([]byte); ok {
+		return string(b), nil
+	}
+
+	return v, nil
+}
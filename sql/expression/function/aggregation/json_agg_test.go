@@ -0,0 +1,120 @@
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONArrayAgg(t *testing.T) {
+	testCases := []struct {
+		name     string
+		typ      sql.Type
+		rows     []sql.Row
+		expected interface{}
+	}{
+		{"no rows", sql.Int64, nil, nil},
+		{"one row", sql.Int64, []sql.Row{{int64(1)}}, []interface{}{int64(1)}},
+		{
+			"with nulls",
+			sql.Text,
+			[]sql.Row{{"a"}, {nil}, {"b"}},
+			[]interface{}{"a", nil, "b"},
+		},
+		{
+			"json values",
+			sql.JSON,
+			[]sql.Row{{[]byte(`{"a": 1}`)}, {`[1, 2]`}},
+			[]interface{}{map[string]interface{}{"a": float64(1)}, []interface{}{float64(1), float64(2)}},
+		},
+		{"binary values", sql.Blob, []sql.Row{{[]byte("foo")}}, []interface{}{"foo"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := NewJSONArrayAgg(expression.NewGetField(0, tt.typ, "", true))
+			require.Equal(t, tt.expected, aggregate(t, agg, tt.rows...))
+		})
+	}
+}
+
+func TestJSONArrayAggMerge(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	agg := NewJSONArrayAgg(expression.NewGetField(0, sql.Int64, "", true))
+
+	buf := agg.NewBuffer()
+	require.NoError(agg.Update(ctx, buf, sql.NewRow(int64(1))))
+
+	partial := agg.NewBuffer()
+	require.NoError(agg.Update(ctx, partial, sql.NewRow(int64(2))))
+	require.NoError(agg.Update(ctx, partial, sql.NewRow(int64(3))))
+
+	require.NoError(agg.Merge(ctx, buf, partial))
+	require.NoError(agg.Merge(ctx, buf, agg.NewBuffer()))
+
+	v, err := agg.Eval(ctx, buf)
+	require.NoError(err)
+	require.Equal([]interface{}{int64(1), int64(2), int64(3)}, v)
+}
+
+func TestJSONObjectAgg(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rows     []sql.Row
+		expected interface{}
+	}{
+		{"no rows", nil, nil},
+		{
+			"multiple rows",
+			[]sql.Row{{"a", int64(1)}, {"b", nil}, {int64(3), int64(3)}},
+			map[string]interface{}{"a": int64(1), "b": nil, "3": int64(3)},
+		},
+		{
+			"duplicated keys",
+			[]sql.Row{{"a", int64(1)}, {"a", int64(2)}},
+			map[string]interface{}{"a": int64(2)},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			agg := NewJSONObjectAgg(
+				expression.NewGetField(0, sql.Text, "", true),
+				expression.NewGetField(1, sql.Int64, "", true),
+			)
+			require.Equal(t, tt.expected, aggregate(t, agg, tt.rows...))
+		})
+	}
+}
+
+func TestJSONObjectAggNullKey(t *testing.T) {
+	require := require.New(t)
+	agg := NewJSONObjectAgg(
+		expression.NewGetField(0, sql.Text, "", true),
+		expression.NewGetField(1, sql.Int64, "", true),
+	)
+
+	err := agg.Update(sql.NewEmptyContext(), agg.NewBuffer(), sql.NewRow(nil, int64(1)))
+	require.True(ErrJSONObjectAggNullKey.Is(err))
+}
+
+func TestJSONObjectAggMerge(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	agg := NewJSONObjectAgg(
+		expression.NewGetField(0, sql.Text, "", true),
+		expression.NewGetField(1, sql.Int64, "", true),
+	)
+
+	buf := agg.NewBuffer()
+	partial := agg.NewBuffer()
+	require.NoError(agg.Update(ctx, partial, sql.NewRow("a", int64(1))))
+	require.NoError(agg.Merge(ctx, buf, partial))
+
+	v, err := agg.Eval(ctx, buf)
+	require.NoError(err)
+	require.Equal(map[string]interface{}{"a": int64(1)}, v)
+}
@@ -58,7 +58,9 @@ func (l *Last) Update(ctx *sql.Context, buffer, row sql.Row) error {
 
 // Merge implements the Aggregation interface.
 func (l *Last) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
-	buffer[0] = partial[0]
+	if partial[0] != nil {
+		buffer[0] = partial[0]
+	}
 	return nil
 }
 
@@ -93,3 +93,23 @@ func TestMax_Eval_Empty(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal(nil, v)
 }
+
+func TestMax_Merge(t *testing.T) {
+	assert := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	m := NewMax(expression.NewGetField(0, sql.Int32, "field", true))
+	b := m.NewBuffer()
+	assert.NoError(m.Update(ctx, b, sql.NewRow(int32(6))))
+
+	partial := m.NewBuffer()
+	assert.NoError(m.Update(ctx, partial, sql.NewRow(int32(7))))
+	assert.NoError(m.Update(ctx, partial, sql.NewRow(int32(2))))
+
+	assert.NoError(m.Merge(ctx, b, partial))
+	assert.NoError(m.Merge(ctx, b, m.NewBuffer()))
+
+	v, err := m.Eval(ctx, b)
+	assert.NoError(err)
+	assert.Equal(int32(7), v)
+}
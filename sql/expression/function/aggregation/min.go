@@ -79,7 +79,24 @@ func (m *Min) Update(ctx *sql.Context, buffer, row sql.Row) error {
 
 // Merge implements the Aggregation interface.
 func (m *Min) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
-	return m.Update(ctx, buffer, partial)
+	if partial[0] == nil {
+		return nil
+	}
+
+	if buffer[0] == nil {
+		buffer[0] = partial[0]
+		return nil
+	}
+
+	cmp, err := m.Child.Type().Compare(partial[0], buffer[0])
+	if err != nil {
+		return err
+	}
+	if cmp == -1 {
+		buffer[0] = partial[0]
+	}
+
+	return nil
 }
 
 // Eval implements the Aggregation interface
@@ -67,7 +67,17 @@ func (m *Sum) Update(ctx *sql.Context, buffer, row sql.Row) error {
 
 // Merge implements the Aggregation interface.
 func (m *Sum) Merge(ctx *sql.Context, buffer, partial sql.Row) error {
-	return m.Update(ctx, buffer, partial)
+	if partial[0] == nil {
+		return nil
+	}
+
+	if buffer[0] == nil {
+		buffer[0] = float64(0)
+	}
+
+	buffer[0] = buffer[0].(float64) + partial[0].(float64)
+
+	return nil
 }
 
 // Eval implements the Aggregation interface.
@@ -73,3 +73,22 @@ func TestSum(t *testing.T) {
 		})
 	}
 }
+
+func TestSumMerge(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	sum := NewSum(expression.NewGetField(0, nil, "", false))
+
+	buf := sum.NewBuffer()
+	require.NoError(sum.Merge(ctx, buf, sum.NewBuffer()))
+
+	partial := sum.NewBuffer()
+	require.NoError(sum.Update(ctx, partial, sql.NewRow(int64(1))))
+	require.NoError(sum.Update(ctx, partial, sql.NewRow(int64(3))))
+	require.NoError(sum.Merge(ctx, buf, partial))
+	require.NoError(sum.Merge(ctx, buf, partial))
+
+	result, err := sum.Eval(ctx, buf)
+	require.NoError(err)
+	require.Equal(float64(8), result)
+}
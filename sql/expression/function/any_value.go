@@ -0,0 +1,43 @@
+package function
+
+import (
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// AnyValue is a function that returns the value of its argument. In a
+// grouped query, the value is the one of any row of the group, and the
+// argument is not required to be in the grouping columns when
+// ONLY_FULL_GROUP_BY is enabled.
+type AnyValue struct {
+	expression.UnaryExpression
+}
+
+// NewAnyValue creates a new AnyValue expression.
+func NewAnyValue(e sql.Expression) sql.Expression {
+	return &AnyValue{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (a *AnyValue) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	return a.Child.Eval(ctx, row)
+}
+
+func (a *AnyValue) String() string {
+	return fmt.Sprintf("ANY_VALUE(%s)", a.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (a *AnyValue) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(a, len(children), 1)
+	}
+	return NewAnyValue(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (a *AnyValue) Type() sql.Type {
+	return a.Child.Type()
+}
@@ -0,0 +1,19 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnyValue(t *testing.T) {
+	require := require.New(t)
+	f := NewAnyValue(expression.NewGetField(0, sql.Int64, "foo", true))
+
+	require.Equal(sql.Int64, f.Type())
+	require.Equal("ANY_VALUE(foo)", f.String())
+	require.Equal(int64(3), eval(t, f, sql.NewRow(int64(3))))
+	require.Nil(eval(t, f, sql.NewRow(nil)))
+}
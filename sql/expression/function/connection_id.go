@@ -30,6 +30,9 @@ func (c ConnectionID) WithChildren(children ...sql.Expression) (sql.Expression,
 // IsNullable implements the sql.Expression interface.
 func (ConnectionID) IsNullable() bool { return false }
 
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (ConnectionID) IsNonDeterministic() bool { return true }
+
 // String implements the fmt.Stringer interface.
 func (ConnectionID) String() string { return "connection_id()" }
 
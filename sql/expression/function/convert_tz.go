@@ -0,0 +1,106 @@
+package function
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// ConvertTz is a function that converts a datetime from a time zone to
+// another one. The time zones are SYSTEM, offsets from UTC, such as
+// '+02:00', or named time zones, such as 'Europe/Madrid'.
+type ConvertTz struct {
+	dt   sql.Expression
+	from sql.Expression
+	to   sql.Expression
+}
+
+// NewConvertTz creates a new ConvertTz expression.
+func NewConvertTz(dt, from, to sql.Expression) sql.Expression {
+	return &ConvertTz{dt, from, to}
+}
+
+// Children implements the Expression interface.
+func (c *ConvertTz) Children() []sql.Expression {
+	return []sql.Expression{c.dt, c.from, c.to}
+}
+
+// Resolved implements the Expression interface.
+func (c *ConvertTz) Resolved() bool {
+	return c.dt.Resolved() && c.from.Resolved() && c.to.Resolved()
+}
+
+// IsNullable implements the Expression interface.
+func (c *ConvertTz) IsNullable() bool {
+	return true
+}
+
+func (c *ConvertTz) String() string {
+	return fmt.Sprintf("convert_tz(%s, %s, %s)", c.dt, c.from, c.to)
+}
+
+// Type implements the Expression interface. The fractional seconds of the
+// datetime are kept.
+func (c *ConvertTz) Type() sql.Type {
+	return sql.DatetimeWithPrecision(sql.Precision(c.dt.Type()))
+}
+
+// WithChildren implements the Expression interface.
+func (c *ConvertTz) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 3 {
+		return nil, sql.ErrInvalidChildrenNumber.New(c, len(children), 3)
+	}
+	return NewConvertTz(children[0], children[1], children[2]), nil
+}
+
+// Eval implements the Expression interface. It returns NULL if the datetime
+// or any of the time zones are not valid.
+func (c *ConvertTz) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	val, err := c.dt.Eval(ctx, row)
+	if val == nil || err != nil {
+		return nil, err
+	}
+
+	// Timestamps are kept in UTC, but they are given in the time zone of
+	// the session.
+	if t, ok := val.(time.Time); ok && sql.IsTimestamp(c.dt.Type()) {
+		val = sql.ConvertTimeZone(t.UTC(), time.UTC, ctx.Location())
+	}
+
+	dt, err := sql.Datetime.Convert(val)
+	if err != nil {
+		return nil, nil
+	}
+
+	from, err := c.location(ctx, c.from, row)
+	if from == nil || err != nil {
+		return nil, err
+	}
+
+	to, err := c.location(ctx, c.to, row)
+	if to == nil || err != nil {
+		return nil, err
+	}
+
+	return sql.ConvertTimeZone(dt.(time.Time), from, to), nil
+}
+
+// location returns the location of a time zone, or nil if it's unknown.
+func (c *ConvertTz) location(ctx *sql.Context, e sql.Expression, row sql.Row) (*time.Location, error) {
+	val, err := e.Eval(ctx, row)
+	if val == nil || err != nil {
+		return nil, err
+	}
+
+	name, err := sql.Text.Convert(val)
+	if err != nil {
+		return nil, nil
+	}
+
+	loc, err := sql.LoadTimeZone(name.(string))
+	if err != nil {
+		return nil, nil
+	}
+	return loc, nil
+}
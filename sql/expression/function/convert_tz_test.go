@@ -0,0 +1,42 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTz(t *testing.T) {
+	f := NewConvertTz(
+		expression.NewGetField(0, sql.Text, "", true),
+		expression.NewGetField(1, sql.Text, "", true),
+		expression.NewGetField(2, sql.Text, "", true),
+	)
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"null datetime", sql.NewRow(nil, "+00:00", "+02:00"), nil},
+		{"null time zone", sql.NewRow("2019-01-02 03:04:05", nil, "+02:00"), nil},
+		{"offsets", sql.NewRow("2019-01-02 03:04:05", "+00:00", "+02:00"), time.Date(2019, 1, 2, 5, 4, 5, 0, time.UTC)},
+		{"negative offset", sql.NewRow("2019-01-02 03:04:05", "+01:00", "-03:30"), time.Date(2019, 1, 1, 22, 34, 5, 0, time.UTC)},
+		{"named time zones", sql.NewRow("2019-07-02 03:04:05", "UTC", "Europe/Madrid"), time.Date(2019, 7, 2, 5, 4, 5, 0, time.UTC)},
+		{"time", sql.NewRow(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC), "Europe/Madrid", "UTC"), time.Date(2019, 1, 2, 2, 4, 5, 0, time.UTC)},
+		{"unknown time zone", sql.NewRow("2019-01-02 03:04:05", "+00:00", "Foo/Bar"), nil},
+		{"invalid datetime", sql.NewRow("foo", "+00:00", "+02:00"), nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			v, err := f.Eval(sql.NewEmptyContext(), tt.row)
+			require.NoError(err)
+			require.Equal(tt.expected, v)
+		})
+	}
+}
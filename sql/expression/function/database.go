@@ -25,6 +25,9 @@ func (db *Database) IsNullable() bool {
 	return true
 }
 
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (*Database) IsNonDeterministic() bool { return true }
+
 func (*Database) String() string {
 	return "DATABASE()"
 }
@@ -0,0 +1,78 @@
+package function
+
+import (
+	"io"
+	"math"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrGenerateSeriesStep is returned when the step of GENERATE_SERIES is 0.
+var ErrGenerateSeriesStep = errors.NewKind("step of generate_series cannot be 0")
+
+// GenerateSeries is a table function that returns the integers from a start
+// to a stop value, both included, in a single column named value:
+//
+//	SELECT * FROM generate_series(start, stop[, step])
+//
+// The step is 1 unless it's given, and it's negative for descending series.
+// There are no rows if the stop value can't be reached from the start or
+// any of the arguments is NULL.
+var GenerateSeries = sql.TableFunction{
+	Name:     "generate_series",
+	Args:     []sql.Type{sql.Int64, sql.Int64, sql.Int64},
+	Variadic: true,
+	Schema: func(ctx *sql.Context, args ...interface{}) (sql.Schema, error) {
+		if len(args) > 3 {
+			return nil, sql.ErrInvalidArgumentNumber.New("generate_series", "2 or 3", len(args))
+		}
+
+		if len(args) == 3 && args[2] == int64(0) {
+			return nil, ErrGenerateSeriesStep.New()
+		}
+
+		return sql.Schema{{Name: "value", Type: sql.Int64}}, nil
+	},
+	RowIter: func(ctx *sql.Context, args ...interface{}) (sql.RowIter, error) {
+		step := interface{}(int64(1))
+		if len(args) == 3 {
+			step = args[2]
+		}
+
+		if args[0] == nil || args[1] == nil || step == nil {
+			return sql.RowsToRowIter(), nil
+		}
+
+		return &seriesIter{
+			next: args[0].(int64),
+			stop: args[1].(int64),
+			step: step.(int64),
+		}, nil
+	},
+}
+
+type seriesIter struct {
+	next, stop, step int64
+	done             bool
+}
+
+func (i *seriesIter) Next() (sql.Row, error) {
+	if i.done || (i.step > 0 && i.next > i.stop) || (i.step < 0 && i.next < i.stop) {
+		return nil, io.EOF
+	}
+
+	n := i.next
+	// The series ends before the next value overflows.
+	if (i.step > 0 && n > math.MaxInt64-i.step) || (i.step < 0 && n < math.MinInt64-i.step) {
+		i.done = true
+	} else {
+		i.next += i.step
+	}
+
+	return sql.NewRow(n), nil
+}
+
+func (i *seriesIter) Close() error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package function
+
+import (
+	"math"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSeries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []interface{}
+		expected []sql.Row
+	}{
+		{"ascending", []interface{}{1, 3}, []sql.Row{{int64(1)}, {int64(2)}, {int64(3)}}},
+		{"step", []interface{}{1, 6, 2}, []sql.Row{{int64(1)}, {int64(3)}, {int64(5)}}},
+		{"descending", []interface{}{3, 1, -1}, []sql.Row{{int64(3)}, {int64(2)}, {int64(1)}}},
+		{"single value", []interface{}{2, 2}, []sql.Row{{int64(2)}}},
+		{"unreachable stop", []interface{}{3, 1}, nil},
+		{"null", []interface{}{nil, 1}, nil},
+		{"null step", []interface{}{1, 2, nil}, nil},
+		{
+			"overflow",
+			[]interface{}{int64(math.MaxInt64 - 1), int64(math.MaxInt64), 2},
+			[]sql.Row{{int64(math.MaxInt64 - 1)}},
+		},
+		{
+			"negative overflow",
+			[]interface{}{int64(math.MinInt64 + 1), int64(math.MinInt64), -1},
+			[]sql.Row{{int64(math.MinInt64 + 1)}, {int64(math.MinInt64)}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := sql.NewEmptyContext()
+
+			table, err := GenerateSeries.Table(ctx, tt.args...)
+			require.NoError(err)
+			require.Equal(sql.Schema{{Name: "value", Type: sql.Int64, Source: "generate_series"}}, table.Schema())
+
+			partitions, err := table.Partitions(ctx)
+			require.NoError(err)
+			p, err := partitions.Next()
+			require.NoError(err)
+
+			iter, err := table.PartitionRows(ctx, p)
+			require.NoError(err)
+			rows, err := sql.RowIterToRows(iter)
+			require.NoError(err)
+			require.Equal(tt.expected, rows)
+		})
+	}
+}
+
+func TestGenerateSeriesErrors(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	_, err := GenerateSeries.Table(ctx, 1)
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	_, err = GenerateSeries.Table(ctx, 1, 2, 3, 4)
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	_, err = GenerateSeries.Table(ctx, 1, 2, 0)
+	require.True(ErrGenerateSeriesStep.Is(err))
+}
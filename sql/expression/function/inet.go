@@ -0,0 +1,299 @@
+package function
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// InetAton is a function that returns the numeric value of an IPv4 address
+// given in dotted-quad representation. Short forms such as 127.1 are
+// interpreted the same way MySQL does.
+type InetAton struct {
+	expression.UnaryExpression
+}
+
+// NewInetAton creates a new InetAton expression.
+func NewInetAton(e sql.Expression) sql.Expression {
+	return &InetAton{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (i *InetAton) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	v, err := i.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	v, err = sql.Text.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := parseIPv4(v.(string))
+	if !ok {
+		return nil, nil
+	}
+
+	return n, nil
+}
+
+// parseIPv4 parses an IPv4 address following the rules of MySQL's
+// INET_ATON, where missing octets are filled with zeros right before the
+// last one.
+func parseIPv4(s string) (uint32, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	var (
+		result   uint32
+		octet    uint32
+		dots     int
+		hasDigit bool
+	)
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			octet = octet*10 + uint32(c-'0')
+			if octet > 255 {
+				return 0, false
+			}
+			hasDigit = true
+		case c == '.':
+			if dots == 3 || !hasDigit {
+				return 0, false
+			}
+			result = result<<8 + octet
+			octet = 0
+			hasDigit = false
+			dots++
+		default:
+			return 0, false
+		}
+	}
+
+	if !hasDigit {
+		return 0, false
+	}
+
+	switch dots {
+	case 1:
+		result <<= 16
+	case 2:
+		result <<= 8
+	}
+
+	return result<<8 + octet, true
+}
+
+func (i *InetAton) String() string {
+	return fmt.Sprintf("INET_ATON(%s)", i.Child)
+}
+
+// IsNullable implements the Expression interface.
+func (i *InetAton) IsNullable() bool { return true }
+
+// WithChildren implements the Expression interface.
+func (i *InetAton) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(i, len(children), 1)
+	}
+	return NewInetAton(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (i *InetAton) Type() sql.Type {
+	return sql.Uint32
+}
+
+// InetNtoa is a function that returns the dotted-quad representation of an
+// IPv4 address given as a number.
+type InetNtoa struct {
+	expression.UnaryExpression
+}
+
+// NewInetNtoa creates a new InetNtoa expression.
+func NewInetNtoa(e sql.Expression) sql.Expression {
+	return &InetNtoa{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (i *InetNtoa) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	v, err := i.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	v, err = sql.Int64.Convert(v)
+	if err != nil {
+		return nil, nil
+	}
+
+	n := v.(int64)
+	if n < 0 || n > int64(^uint32(0)) {
+		return nil, nil
+	}
+
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).String(), nil
+}
+
+func (i *InetNtoa) String() string {
+	return fmt.Sprintf("INET_NTOA(%s)", i.Child)
+}
+
+// IsNullable implements the Expression interface.
+func (i *InetNtoa) IsNullable() bool { return true }
+
+// WithChildren implements the Expression interface.
+func (i *InetNtoa) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(i, len(children), 1)
+	}
+	return NewInetNtoa(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (i *InetNtoa) Type() sql.Type {
+	return sql.Text
+}
+
+// Inet6Aton is a function that returns the binary representation of an IPv4
+// or IPv6 address in network byte order. IPv4 addresses are returned as 4
+// bytes and IPv6 addresses as 16 bytes.
+type Inet6Aton struct {
+	expression.UnaryExpression
+}
+
+// NewInet6Aton creates a new Inet6Aton expression.
+func NewInet6Aton(e sql.Expression) sql.Expression {
+	return &Inet6Aton{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (i *Inet6Aton) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	v, err := i.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	v, err = sql.Text.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := v.(string)
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, nil
+	}
+
+	if !strings.Contains(s, ":") {
+		return []byte(ip.To4()), nil
+	}
+
+	return []byte(ip.To16()), nil
+}
+
+func (i *Inet6Aton) String() string {
+	return fmt.Sprintf("INET6_ATON(%s)", i.Child)
+}
+
+// IsNullable implements the Expression interface.
+func (i *Inet6Aton) IsNullable() bool { return true }
+
+// WithChildren implements the Expression interface.
+func (i *Inet6Aton) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(i, len(children), 1)
+	}
+	return NewInet6Aton(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (i *Inet6Aton) Type() sql.Type {
+	return sql.Blob
+}
+
+// Inet6Ntoa is a function that returns the string representation of an IPv4
+// or IPv6 address given in its binary form.
+type Inet6Ntoa struct {
+	expression.UnaryExpression
+}
+
+// NewInet6Ntoa creates a new Inet6Ntoa expression.
+func NewInet6Ntoa(e sql.Expression) sql.Expression {
+	return &Inet6Ntoa{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (i *Inet6Ntoa) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	v, err := i.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	var b []byte
+	switch v := v.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, nil
+	}
+
+	switch len(b) {
+	case net.IPv4len:
+		return net.IP(b).String(), nil
+	case net.IPv6len:
+		ip := net.IP(b)
+		if v4 := ip.To4(); v4 != nil {
+			// Go prints IPv4-mapped addresses as plain IPv4, while MySQL
+			// keeps the IPv6 prefix.
+			return "::ffff:" + v4.String(), nil
+		}
+		return ip.String(), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (i *Inet6Ntoa) String() string {
+	return fmt.Sprintf("INET6_NTOA(%s)", i.Child)
+}
+
+// IsNullable implements the Expression interface.
+func (i *Inet6Ntoa) IsNullable() bool { return true }
+
+// WithChildren implements the Expression interface.
+func (i *Inet6Ntoa) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(i, len(children), 1)
+	}
+	return NewInet6Ntoa(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (i *Inet6Ntoa) Type() sql.Type {
+	return sql.Text
+}
@@ -0,0 +1,133 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInetAton(t *testing.T) {
+	f := NewInetAton(expression.NewGetField(0, sql.Text, "", true))
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"null", sql.NewRow(nil), nil},
+		{"empty", sql.NewRow(""), nil},
+		{"full address", sql.NewRow("10.0.5.9"), uint32(167773449)},
+		{"loopback", sql.NewRow("127.0.0.1"), uint32(2130706433)},
+		{"short form", sql.NewRow("127.1"), uint32(2130706433)},
+		{"three parts", sql.NewRow("1.2.3"), uint32(16908291)},
+		{"single number", sql.NewRow("255"), uint32(255)},
+		{"octet out of range", sql.NewRow("256.0.0.1"), nil},
+		{"too many octets", sql.NewRow("1.2.3.4.5"), nil},
+		{"trailing dot", sql.NewRow("1.2.3."), nil},
+		{"invalid character", sql.NewRow("1.2.a.4"), nil},
+		{"binary", sql.NewRow([]byte("192.168.1.1")), uint32(3232235777)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, tt.row))
+		})
+	}
+
+	require.Equal(t, sql.Uint32, f.Type())
+	require.True(t, f.IsNullable())
+}
+
+func TestInetNtoa(t *testing.T) {
+	f := NewInetNtoa(expression.NewGetField(0, sql.Int64, "", true))
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"null", sql.NewRow(nil), nil},
+		{"zero", sql.NewRow(int64(0)), "0.0.0.0"},
+		{"address", sql.NewRow(int64(167773449)), "10.0.5.9"},
+		{"unsigned", sql.NewRow(uint32(4294967295)), "255.255.255.255"},
+		{"string", sql.NewRow("2130706433"), "127.0.0.1"},
+		{"negative", sql.NewRow(int64(-1)), nil},
+		{"out of range", sql.NewRow(int64(4294967296)), nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, tt.row))
+		})
+	}
+
+	require.Equal(t, sql.Text, f.Type())
+}
+
+func TestInet6Aton(t *testing.T) {
+	f := NewInet6Aton(expression.NewGetField(0, sql.Text, "", true))
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"null", sql.NewRow(nil), nil},
+		{"invalid", sql.NewRow("foo"), nil},
+		{"short ipv4", sql.NewRow("127.1"), nil},
+		{"ipv4", sql.NewRow("10.0.5.9"), []byte{10, 0, 5, 9}},
+		{
+			"ipv6",
+			sql.NewRow("fdfe::5a55:caff:fefa:9089"),
+			[]byte{0xfd, 0xfe, 0, 0, 0, 0, 0, 0, 0x5a, 0x55, 0xca, 0xff, 0xfe, 0xfa, 0x90, 0x89},
+		},
+		{
+			"ipv4 mapped",
+			sql.NewRow("::ffff:10.0.5.9"),
+			[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 10, 0, 5, 9},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, tt.row))
+		})
+	}
+
+	require.Equal(t, sql.Blob, f.Type())
+}
+
+func TestInet6Ntoa(t *testing.T) {
+	f := NewInet6Ntoa(expression.NewGetField(0, sql.Blob, "", true))
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"null", sql.NewRow(nil), nil},
+		{"invalid length", sql.NewRow([]byte{1, 2, 3}), nil},
+		{"not binary", sql.NewRow(int64(1)), nil},
+		{"ipv4", sql.NewRow([]byte{10, 0, 5, 9}), "10.0.5.9"},
+		{
+			"ipv6",
+			sql.NewRow([]byte{0xfd, 0xfe, 0, 0, 0, 0, 0, 0, 0x5a, 0x55, 0xca, 0xff, 0xfe, 0xfa, 0x90, 0x89}),
+			"fdfe::5a55:caff:fefa:9089",
+		},
+		{
+			"ipv4 mapped",
+			sql.NewRow([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 10, 0, 5, 9}),
+			"::ffff:10.0.5.9",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, tt.row))
+		})
+	}
+
+	require.Equal(t, sql.Text, f.Type())
+}
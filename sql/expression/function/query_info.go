@@ -0,0 +1,170 @@
+package function
+
+import (
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// LastInsertID returns the last automatically generated value of the
+// session. When called with an argument, the value of the argument is
+// returned and remembered as the next value to be returned by
+// LAST_INSERT_ID().
+type LastInsertID struct {
+	expr sql.Expression
+}
+
+// NewLastInsertID creates a new LastInsertID UDF.
+func NewLastInsertID(args ...sql.Expression) (sql.Expression, error) {
+	switch len(args) {
+	case 0:
+		return &LastInsertID{}, nil
+	case 1:
+		return &LastInsertID{args[0]}, nil
+	default:
+		return nil, sql.ErrInvalidArgumentNumber.New("LAST_INSERT_ID", "0 or 1", len(args))
+	}
+}
+
+// Children implements the sql.Expression interface.
+func (l *LastInsertID) Children() []sql.Expression {
+	if l.expr == nil {
+		return nil
+	}
+	return []sql.Expression{l.expr}
+}
+
+// Type implements the sql.Expression interface.
+func (l *LastInsertID) Type() sql.Type { return sql.Uint64 }
+
+// Resolved implements the sql.Expression interface.
+func (l *LastInsertID) Resolved() bool {
+	return l.expr == nil || l.expr.Resolved()
+}
+
+// WithChildren implements the Expression interface.
+func (l *LastInsertID) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	return NewLastInsertID(children...)
+}
+
+// IsNullable implements the sql.Expression interface.
+func (l *LastInsertID) IsNullable() bool {
+	return l.expr != nil && l.expr.IsNullable()
+}
+
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (*LastInsertID) IsNonDeterministic() bool { return true }
+
+// String implements the fmt.Stringer interface.
+func (l *LastInsertID) String() string {
+	if l.expr == nil {
+		return "LAST_INSERT_ID()"
+	}
+	return fmt.Sprintf("LAST_INSERT_ID(%s)", l.expr)
+}
+
+// Eval implements the sql.Expression interface.
+func (l *LastInsertID) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	if l.expr == nil {
+		return uint64(ctx.GetLastQueryInfo(sql.LastInsertID)), nil
+	}
+
+	v, err := l.expr.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	v, err = sql.Uint64.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.SetLastQueryInfo(sql.LastInsertID, int64(v.(uint64)))
+	return v, nil
+}
+
+// RowCount returns the number of rows changed, deleted or inserted by the
+// previous statement. It is -1 if the previous statement returned a result
+// set.
+type RowCount struct{}
+
+// NewRowCount creates a new RowCount UDF.
+func NewRowCount() sql.Expression {
+	return RowCount{}
+}
+
+// Children implements the sql.Expression interface.
+func (RowCount) Children() []sql.Expression { return nil }
+
+// Type implements the sql.Expression interface.
+func (RowCount) Type() sql.Type { return sql.Int64 }
+
+// Resolved implements the sql.Expression interface.
+func (RowCount) Resolved() bool { return true }
+
+// WithChildren implements the Expression interface.
+func (r RowCount) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(r, len(children), 0)
+	}
+	return r, nil
+}
+
+// IsNullable implements the sql.Expression interface.
+func (RowCount) IsNullable() bool { return false }
+
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (RowCount) IsNonDeterministic() bool { return true }
+
+// String implements the fmt.Stringer interface.
+func (RowCount) String() string { return "ROW_COUNT()" }
+
+// Eval implements the sql.Expression interface.
+func (RowCount) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
+	return ctx.GetLastQueryInfo(sql.RowCount), nil
+}
+
+// FoundRows returns the number of rows the previous SELECT returned or, if it
+// was issued with SQL_CALC_FOUND_ROWS, the number of rows it would have
+// returned without the LIMIT clause.
+type FoundRows struct{}
+
+// NewFoundRows creates a new FoundRows UDF.
+func NewFoundRows() sql.Expression {
+	return FoundRows{}
+}
+
+// Children implements the sql.Expression interface.
+func (FoundRows) Children() []sql.Expression { return nil }
+
+// Type implements the sql.Expression interface.
+func (FoundRows) Type() sql.Type { return sql.Int64 }
+
+// Resolved implements the sql.Expression interface.
+func (FoundRows) Resolved() bool { return true }
+
+// WithChildren implements the Expression interface.
+func (f FoundRows) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(f, len(children), 0)
+	}
+	return f, nil
+}
+
+// IsNullable implements the sql.Expression interface.
+func (FoundRows) IsNullable() bool { return false }
+
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (FoundRows) IsNonDeterministic() bool { return true }
+
+// String implements the fmt.Stringer interface.
+func (FoundRows) String() string { return "FOUND_ROWS()" }
+
+// Eval implements the sql.Expression interface.
+func (FoundRows) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
+	return ctx.GetLastQueryInfo(sql.FoundRows), nil
+}
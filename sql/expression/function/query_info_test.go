@@ -0,0 +1,66 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastInsertID(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	f, err := NewLastInsertID()
+	require.NoError(err)
+	require.Equal("LAST_INSERT_ID()", f.String())
+
+	v, err := f.Eval(ctx, nil)
+	require.NoError(err)
+	require.Equal(uint64(0), v)
+
+	ctx.SetLastQueryInfo(sql.LastInsertID, 10)
+	v, err = f.Eval(ctx, nil)
+	require.NoError(err)
+	require.Equal(uint64(10), v)
+
+	set, err := NewLastInsertID(expression.NewGetField(0, sql.Int64, "", true))
+	require.NoError(err)
+
+	v, err = set.Eval(ctx, sql.NewRow(int64(25)))
+	require.NoError(err)
+	require.Equal(uint64(25), v)
+	require.Equal(int64(25), ctx.GetLastQueryInfo(sql.LastInsertID))
+
+	v, err = set.Eval(ctx, sql.NewRow(nil))
+	require.NoError(err)
+	require.Nil(v)
+	require.Equal(int64(25), ctx.GetLastQueryInfo(sql.LastInsertID))
+
+	_, err = NewLastInsertID(
+		expression.NewLiteral(int64(1), sql.Int64),
+		expression.NewLiteral(int64(2), sql.Int64),
+	)
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+}
+
+func TestRowCount(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	ctx.SetLastQueryInfo(sql.RowCount, -1)
+
+	v, err := NewRowCount().Eval(ctx, nil)
+	require.NoError(err)
+	require.Equal(int64(-1), v)
+}
+
+func TestFoundRows(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+	ctx.SetLastQueryInfo(sql.FoundRows, 42)
+
+	v, err := NewFoundRows().Eval(ctx, nil)
+	require.NoError(err)
+	require.Equal(int64(42), v)
+}
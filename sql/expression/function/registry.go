@@ -37,6 +37,16 @@ var Defaults = []sql.Function{
 		Name: "last",
 		Fn:   func(e sql.Expression) sql.Expression { return aggregation.NewLast(e) },
 	},
+	sql.Function1{
+		Name: "json_arrayagg",
+		Fn:   func(e sql.Expression) sql.Expression { return aggregation.NewJSONArrayAgg(e) },
+	},
+	sql.Function2{
+		Name: "json_objectagg",
+		Fn:   func(k, v sql.Expression) sql.Expression { return aggregation.NewJSONObjectAgg(k, v) },
+	},
+	sql.FunctionN{Name: "grouping", Fn: aggregation.NewGrouping},
+	sql.Function1{Name: "any_value", Fn: NewAnyValue},
 	sql.Function1{Name: "is_binary", Fn: NewIsBinary},
 	sql.FunctionN{Name: "substring", Fn: NewSubstring},
 	sql.Function3{Name: "substring_index", Fn: NewSubstringIndex},
@@ -50,6 +60,7 @@ var Defaults = []sql.Function{
 	sql.Function1{Name: "hour", Fn: NewHour},
 	sql.Function1{Name: "minute", Fn: NewMinute},
 	sql.Function1{Name: "second", Fn: NewSecond},
+	sql.Function1{Name: "microsecond", Fn: NewMicrosecond},
 	sql.Function1{Name: "dayofweek", Fn: NewDayOfWeek},
 	sql.Function1{Name: "dayofmonth", Fn: NewDay},
 	sql.Function1{Name: "dayofyear", Fn: NewDayOfYear},
@@ -66,6 +77,9 @@ var Defaults = []sql.Function{
 	sql.Function1{Name: "floor", Fn: NewFloor},
 	sql.FunctionN{Name: "round", Fn: NewRound},
 	sql.Function0{Name: "connection_id", Fn: NewConnectionID},
+	sql.FunctionN{Name: "last_insert_id", Fn: NewLastInsertID},
+	sql.Function0{Name: "row_count", Fn: NewRowCount},
+	sql.Function0{Name: "found_rows", Fn: NewFoundRows},
 	sql.Function1{Name: "soundex", Fn: NewSoundex},
 	sql.FunctionN{Name: "json_extract", Fn: NewJSONExtract},
 	sql.Function1{Name: "json_unquote", Fn: NewJSONUnquote},
@@ -86,7 +100,8 @@ var Defaults = []sql.Function{
 	sql.Function3{Name: "replace", Fn: NewReplace},
 	sql.Function2{Name: "ifnull", Fn: NewIfNull},
 	sql.Function2{Name: "nullif", Fn: NewNullIf},
-	sql.Function0{Name: "now", Fn: NewNow},
+	sql.FunctionN{Name: "now", Fn: NewNowWithPrecision},
+	sql.Function3{Name: "convert_tz", Fn: NewConvertTz},
 	sql.Function1{Name: "sleep", Fn: NewSleep},
 	sql.Function1{Name: "to_base64", Fn: NewToBase64},
 	sql.Function1{Name: "from_base64", Fn: NewFromBase64},
@@ -99,4 +114,23 @@ var Defaults = []sql.Function{
 	sql.Function1{Name: "character_length", Fn: NewCharLength},
 	sql.Function1{Name: "explode", Fn: NewExplode},
 	sql.FunctionN{Name: "regexp_matches", Fn: NewRegexpMatches},
+	sql.Function1{Name: "inet_aton", Fn: NewInetAton},
+	sql.Function1{Name: "inet_ntoa", Fn: NewInetNtoa},
+	sql.Function1{Name: "inet6_aton", Fn: NewInet6Aton},
+	sql.Function1{Name: "inet6_ntoa", Fn: NewInet6Ntoa},
+	sql.Function1{Name: "st_geomfromtext", Fn: NewSTGeomFromText},
+	sql.Function1{Name: "st_geometryfromtext", Fn: NewSTGeomFromText},
+	sql.Function1{Name: "st_astext", Fn: NewSTAsText},
+	sql.Function1{Name: "st_aswkt", Fn: NewSTAsText},
+	sql.Function1{Name: "st_geometrytype", Fn: NewSTGeometryType},
+	sql.Function2{Name: "point", Fn: NewPoint},
+	sql.Function1{Name: "st_x", Fn: NewPointAxisFunc(xAxis)},
+	sql.Function1{Name: "st_y", Fn: NewPointAxisFunc(yAxis)},
+	sql.Function1{Name: "st_area", Fn: NewSTArea},
+	sql.Function1{Name: "st_length", Fn: NewSTLength},
+	sql.Function2{Name: "st_distance", Fn: NewSTDistance},
+	sql.Function2{Name: "st_contains", Fn: NewSpatialRelationFunc(containsRelation)},
+	sql.Function2{Name: "st_within", Fn: NewSpatialRelationFunc(withinRelation)},
+	sql.Function2{Name: "st_intersects", Fn: NewSpatialRelationFunc(intersectsRelation)},
+	GenerateSeries,
 }
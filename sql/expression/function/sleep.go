@@ -59,6 +59,9 @@ func (s *Sleep) IsNullable() bool {
 	return false
 }
 
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (*Sleep) IsNonDeterministic() bool { return true }
+
 // WithChildren implements the Expression interface.
 func (s *Sleep) WithChildren(children ...sql.Expression) (sql.Expression, error) {
 	if len(children) != 1 {
@@ -0,0 +1,751 @@
+package function
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidGeometryArgument is returned when a spatial function receives a
+// geometry of a type it does not support.
+var ErrInvalidGeometryArgument = errors.NewKind("incorrect geometry argument %s to function %s")
+
+// evalGeometry evaluates the given expression and converts the result to a
+// geometry. A nil geometry is returned if the expression evaluates to NULL.
+func evalGeometry(ctx *sql.Context, e sql.Expression, row sql.Row) (sql.GeometryValue, error) {
+	v, err := e.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	v, err = sql.Geometry.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(sql.GeometryValue), nil
+}
+
+// STGeomFromText is a function that returns a geometry from its well-known
+// text representation.
+type STGeomFromText struct {
+	expression.UnaryExpression
+}
+
+// NewSTGeomFromText creates a new STGeomFromText expression.
+func NewSTGeomFromText(e sql.Expression) sql.Expression {
+	return &STGeomFromText{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (g *STGeomFromText) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	v, err := g.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	v, err = sql.Text.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.ParseWKT(v.(string))
+}
+
+func (g *STGeomFromText) String() string {
+	return fmt.Sprintf("ST_GEOMFROMTEXT(%s)", g.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (g *STGeomFromText) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(g, len(children), 1)
+	}
+	return NewSTGeomFromText(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (g *STGeomFromText) Type() sql.Type {
+	return sql.Geometry
+}
+
+// STAsText is a function that returns the well-known text representation of a
+// geometry.
+type STAsText struct {
+	expression.UnaryExpression
+}
+
+// NewSTAsText creates a new STAsText expression.
+func NewSTAsText(e sql.Expression) sql.Expression {
+	return &STAsText{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (a *STAsText) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	g, err := evalGeometry(ctx, a.Child, row)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	return g.WKT(), nil
+}
+
+func (a *STAsText) String() string {
+	return fmt.Sprintf("ST_ASTEXT(%s)", a.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (a *STAsText) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(a, len(children), 1)
+	}
+	return NewSTAsText(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (a *STAsText) Type() sql.Type {
+	return sql.Text
+}
+
+// STGeometryType is a function that returns the name of the type of a
+// geometry.
+type STGeometryType struct {
+	expression.UnaryExpression
+}
+
+// NewSTGeometryType creates a new STGeometryType expression.
+func NewSTGeometryType(e sql.Expression) sql.Expression {
+	return &STGeometryType{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (g *STGeometryType) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	geom, err := evalGeometry(ctx, g.Child, row)
+	if err != nil || geom == nil {
+		return nil, err
+	}
+
+	return geom.GeometryType(), nil
+}
+
+func (g *STGeometryType) String() string {
+	return fmt.Sprintf("ST_GEOMETRYTYPE(%s)", g.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (g *STGeometryType) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(g, len(children), 1)
+	}
+	return NewSTGeometryType(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (g *STGeometryType) Type() sql.Type {
+	return sql.Text
+}
+
+// Point is a function that returns a point geometry with the given
+// coordinates.
+type Point struct {
+	expression.BinaryExpression
+}
+
+// NewPoint creates a new Point expression.
+func NewPoint(x, y sql.Expression) sql.Expression {
+	return &Point{expression.BinaryExpression{Left: x, Right: y}}
+}
+
+// Eval implements the Expression interface.
+func (p *Point) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	x, err := p.Left.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := p.Right.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if x == nil || y == nil {
+		return nil, nil
+	}
+
+	x, err = sql.Float64.Convert(x)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err = sql.Float64.Convert(y)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.Point{X: x.(float64), Y: y.(float64)}, nil
+}
+
+func (p *Point) String() string {
+	return fmt.Sprintf("POINT(%s, %s)", p.Left, p.Right)
+}
+
+// WithChildren implements the Expression interface.
+func (p *Point) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 2)
+	}
+	return NewPoint(children[0], children[1]), nil
+}
+
+// Type implements the Expression interface.
+func (p *Point) Type() sql.Type {
+	return sql.Geometry
+}
+
+type pointAxis byte
+
+const (
+	xAxis pointAxis = 'x'
+	yAxis pointAxis = 'y'
+)
+
+// NewPointAxisFunc returns a PointAxis creator function for the given axis.
+func NewPointAxisFunc(axis pointAxis) func(e sql.Expression) sql.Expression {
+	return func(e sql.Expression) sql.Expression {
+		return NewPointAxis(axis, e)
+	}
+}
+
+// NewPointAxis creates a new PointAxis expression.
+func NewPointAxis(axis pointAxis, e sql.Expression) sql.Expression {
+	return &PointAxis{expression.UnaryExpression{Child: e}, axis}
+}
+
+// PointAxis is a function that returns one of the coordinates of a point.
+type PointAxis struct {
+	expression.UnaryExpression
+	axis pointAxis
+}
+
+// Eval implements the Expression interface.
+func (p *PointAxis) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	g, err := evalGeometry(ctx, p.Child, row)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	point, ok := g.(sql.Point)
+	if !ok {
+		return nil, ErrInvalidGeometryArgument.New(g.GeometryType(), p.name())
+	}
+
+	if p.axis == xAxis {
+		return point.X, nil
+	}
+	return point.Y, nil
+}
+
+func (p *PointAxis) name() string {
+	if p.axis == xAxis {
+		return "ST_X"
+	}
+	return "ST_Y"
+}
+
+func (p *PointAxis) String() string {
+	return fmt.Sprintf("%s(%s)", p.name(), p.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (p *PointAxis) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
+	}
+	return NewPointAxis(p.axis, children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (p *PointAxis) Type() sql.Type {
+	return sql.Float64
+}
+
+// STArea is a function that returns the area of a polygon.
+type STArea struct {
+	expression.UnaryExpression
+}
+
+// NewSTArea creates a new STArea expression.
+func NewSTArea(e sql.Expression) sql.Expression {
+	return &STArea{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (a *STArea) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	g, err := evalGeometry(ctx, a.Child, row)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	poly, ok := g.(sql.Polygon)
+	if !ok {
+		return nil, ErrInvalidGeometryArgument.New(g.GeometryType(), "ST_AREA")
+	}
+
+	area := ringArea(poly.Rings[0])
+	for _, hole := range poly.Rings[1:] {
+		area -= ringArea(hole)
+	}
+
+	return area, nil
+}
+
+// ringArea returns the area enclosed by a ring using the shoelace formula.
+func ringArea(r sql.LineString) float64 {
+	var sum float64
+	for i := 0; i < len(r.Points)-1; i++ {
+		p, q := r.Points[i], r.Points[i+1]
+		sum += p.X*q.Y - q.X*p.Y
+	}
+	return math.Abs(sum) / 2
+}
+
+func (a *STArea) String() string {
+	return fmt.Sprintf("ST_AREA(%s)", a.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (a *STArea) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(a, len(children), 1)
+	}
+	return NewSTArea(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (a *STArea) Type() sql.Type {
+	return sql.Float64
+}
+
+// STLength is a function that returns the length of a line string.
+type STLength struct {
+	expression.UnaryExpression
+}
+
+// NewSTLength creates a new STLength expression.
+func NewSTLength(e sql.Expression) sql.Expression {
+	return &STLength{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface.
+func (l *STLength) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	g, err := evalGeometry(ctx, l.Child, row)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	line, ok := g.(sql.LineString)
+	if !ok {
+		return nil, ErrInvalidGeometryArgument.New(g.GeometryType(), "ST_LENGTH")
+	}
+
+	var length float64
+	for i := 0; i < len(line.Points)-1; i++ {
+		length += pointDistance(line.Points[i], line.Points[i+1])
+	}
+
+	return length, nil
+}
+
+func (l *STLength) String() string {
+	return fmt.Sprintf("ST_LENGTH(%s)", l.Child)
+}
+
+// WithChildren implements the Expression interface.
+func (l *STLength) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(l, len(children), 1)
+	}
+	return NewSTLength(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (l *STLength) Type() sql.Type {
+	return sql.Float64
+}
+
+// STDistance is a function that returns the minimum cartesian distance between
+// two geometries.
+type STDistance struct {
+	expression.BinaryExpression
+}
+
+// NewSTDistance creates a new STDistance expression.
+func NewSTDistance(a, b sql.Expression) sql.Expression {
+	return &STDistance{expression.BinaryExpression{Left: a, Right: b}}
+}
+
+// Eval implements the Expression interface.
+func (d *STDistance) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	a, b, err := evalGeometries(ctx, d.BinaryExpression, row)
+	if err != nil || a == nil || b == nil {
+		return nil, err
+	}
+
+	return geometryDistance(a, b), nil
+}
+
+func (d *STDistance) String() string {
+	return fmt.Sprintf("ST_DISTANCE(%s, %s)", d.Left, d.Right)
+}
+
+// WithChildren implements the Expression interface.
+func (d *STDistance) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(d, len(children), 2)
+	}
+	return NewSTDistance(children[0], children[1]), nil
+}
+
+// Type implements the Expression interface.
+func (d *STDistance) Type() sql.Type {
+	return sql.Float64
+}
+
+type spatialRelation string
+
+const (
+	containsRelation   spatialRelation = "ST_CONTAINS"
+	withinRelation     spatialRelation = "ST_WITHIN"
+	intersectsRelation spatialRelation = "ST_INTERSECTS"
+)
+
+// NewSpatialRelationFunc returns a SpatialRelation creator function for the
+// given relation.
+func NewSpatialRelationFunc(rel spatialRelation) func(a, b sql.Expression) sql.Expression {
+	return func(a, b sql.Expression) sql.Expression {
+		return NewSpatialRelation(rel, a, b)
+	}
+}
+
+// NewSpatialRelation creates a new SpatialRelation expression.
+func NewSpatialRelation(rel spatialRelation, a, b sql.Expression) sql.Expression {
+	return &SpatialRelation{expression.BinaryExpression{Left: a, Right: b}, rel}
+}
+
+// SpatialRelation is a function that returns whether two geometries satisfy
+// a spatial relation, such as one containing the other.
+type SpatialRelation struct {
+	expression.BinaryExpression
+	relation spatialRelation
+}
+
+// Eval implements the Expression interface.
+func (s *SpatialRelation) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	a, b, err := evalGeometries(ctx, s.BinaryExpression, row)
+	if err != nil || a == nil || b == nil {
+		return nil, err
+	}
+
+	switch s.relation {
+	case containsRelation:
+		return geometryContains(a, b), nil
+	case withinRelation:
+		return geometryContains(b, a), nil
+	default:
+		return geometryDistance(a, b) == 0, nil
+	}
+}
+
+func (s *SpatialRelation) String() string {
+	return fmt.Sprintf("%s(%s, %s)", s.relation, s.Left, s.Right)
+}
+
+// WithChildren implements the Expression interface.
+func (s *SpatialRelation) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(s, len(children), 2)
+	}
+	return NewSpatialRelation(s.relation, children[0], children[1]), nil
+}
+
+// Type implements the Expression interface.
+func (s *SpatialRelation) Type() sql.Type {
+	return sql.Boolean
+}
+
+func evalGeometries(
+	ctx *sql.Context,
+	e expression.BinaryExpression,
+	row sql.Row,
+) (sql.GeometryValue, sql.GeometryValue, error) {
+	a, err := evalGeometry(ctx, e.Left, row)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := evalGeometry(ctx, e.Right, row)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, b, nil
+}
+
+type segment struct {
+	a, b sql.Point
+}
+
+// geometrySegments returns the segments that make up the given geometry. A
+// point is returned as a segment of length zero.
+func geometrySegments(g sql.GeometryValue) []segment {
+	switch g := g.(type) {
+	case sql.Point:
+		return []segment{{g, g}}
+	case sql.LineString:
+		return lineSegments(g)
+	case sql.Polygon:
+		var segments []segment
+		for _, r := range g.Rings {
+			segments = append(segments, lineSegments(r)...)
+		}
+		return segments
+	default:
+		return nil
+	}
+}
+
+func lineSegments(l sql.LineString) []segment {
+	segments := make([]segment, 0, len(l.Points)-1)
+	for i := 0; i < len(l.Points)-1; i++ {
+		segments = append(segments, segment{l.Points[i], l.Points[i+1]})
+	}
+	return segments
+}
+
+func geometryPoints(g sql.GeometryValue) []sql.Point {
+	switch g := g.(type) {
+	case sql.Point:
+		return []sql.Point{g}
+	case sql.LineString:
+		return g.Points
+	case sql.Polygon:
+		var points []sql.Point
+		for _, r := range g.Rings {
+			points = append(points, r.Points...)
+		}
+		return points
+	default:
+		return nil
+	}
+}
+
+// geometryDistance returns the minimum distance between two geometries,
+// which is zero if they intersect.
+func geometryDistance(a, b sql.GeometryValue) float64 {
+	if polygonCoversAny(a, b) || polygonCoversAny(b, a) {
+		return 0
+	}
+
+	dist := math.Inf(1)
+	for _, s1 := range geometrySegments(a) {
+		for _, s2 := range geometrySegments(b) {
+			dist = math.Min(dist, segmentDistance(s1, s2))
+		}
+	}
+	return dist
+}
+
+// polygonCoversAny returns whether a is a polygon and any of the points of
+// b lies inside it.
+func polygonCoversAny(a, b sql.GeometryValue) bool {
+	poly, ok := a.(sql.Polygon)
+	if !ok {
+		return false
+	}
+
+	for _, p := range geometryPoints(b) {
+		if polygonContainsPoint(poly, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// geometryContains returns whether no point of b lies outside of a and at
+// least one point of b lies in the interior of a.
+func geometryContains(a, b sql.GeometryValue) bool {
+	switch a := a.(type) {
+	case sql.Point:
+		for _, p := range geometryPoints(b) {
+			if p != a {
+				return false
+			}
+		}
+		return true
+	case sql.LineString:
+		if _, ok := b.(sql.Polygon); ok {
+			return false
+		}
+
+		for _, s := range geometrySegments(b) {
+			if !lineCoversPoint(a, s.a) || !lineCoversPoint(a, s.b) || !lineCoversPoint(a, midpoint(s)) {
+				return false
+			}
+		}
+		return true
+	case sql.Polygon:
+		var interior bool
+		for _, p := range geometryPoints(b) {
+			if polygonContainsPoint(a, p) {
+				interior = true
+			} else if !polygonBoundaryCoversPoint(a, p) {
+				return false
+			}
+		}
+
+		for _, s := range geometrySegments(b) {
+			m := midpoint(s)
+			if polygonContainsPoint(a, m) {
+				interior = true
+			} else if !polygonBoundaryCoversPoint(a, m) {
+				return false
+			}
+
+			for _, edge := range geometrySegments(a) {
+				if segmentsCross(s, edge) {
+					return false
+				}
+			}
+		}
+
+		return interior
+	default:
+		return false
+	}
+}
+
+// polygonContainsPoint returns whether the point lies in the interior of
+// the polygon, that is, inside its exterior ring, outside all its holes and
+// not on its boundary.
+func polygonContainsPoint(poly sql.Polygon, p sql.Point) bool {
+	if polygonBoundaryCoversPoint(poly, p) || !ringContainsPoint(poly.Rings[0], p) {
+		return false
+	}
+
+	for _, hole := range poly.Rings[1:] {
+		if ringContainsPoint(hole, p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func polygonBoundaryCoversPoint(poly sql.Polygon, p sql.Point) bool {
+	for _, r := range poly.Rings {
+		if lineCoversPoint(r, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContainsPoint uses ray casting to tell whether the point is inside the
+// ring. The result is undefined for points on the ring itself.
+func ringContainsPoint(r sql.LineString, p sql.Point) bool {
+	var inside bool
+	for i, j := 0, len(r.Points)-1; i < len(r.Points); j, i = i, i+1 {
+		a, b := r.Points[i], r.Points[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) &&
+			p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func lineCoversPoint(l sql.LineString, p sql.Point) bool {
+	for _, s := range lineSegments(l) {
+		if pointSegmentDistance(p, s) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func midpoint(s segment) sql.Point {
+	return sql.Point{X: (s.a.X + s.b.X) / 2, Y: (s.a.Y + s.b.Y) / 2}
+}
+
+func pointDistance(a, b sql.Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+func pointSegmentDistance(p sql.Point, s segment) float64 {
+	dx, dy := s.b.X-s.a.X, s.b.Y-s.a.Y
+	if dx == 0 && dy == 0 {
+		return pointDistance(p, s.a)
+	}
+
+	t := ((p.X-s.a.X)*dx + (p.Y-s.a.Y)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return pointDistance(p, sql.Point{X: s.a.X + t*dx, Y: s.a.Y + t*dy})
+}
+
+func segmentDistance(s1, s2 segment) float64 {
+	if segmentsIntersect(s1, s2) {
+		return 0
+	}
+
+	return math.Min(
+		math.Min(pointSegmentDistance(s1.a, s2), pointSegmentDistance(s1.b, s2)),
+		math.Min(pointSegmentDistance(s2.a, s1), pointSegmentDistance(s2.b, s1)),
+	)
+}
+
+// orientation returns the sign of the cross product of (b - a) and (c - a),
+// which tells whether c is to the left of, to the right of or on the line
+// that goes through a and b.
+func orientation(a, b, c sql.Point) int {
+	v := (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func segmentsIntersect(s1, s2 segment) bool {
+	if segmentsCross(s1, s2) {
+		return true
+	}
+
+	return pointSegmentDistance(s1.a, s2) == 0 ||
+		pointSegmentDistance(s1.b, s2) == 0 ||
+		pointSegmentDistance(s2.a, s1) == 0 ||
+		pointSegmentDistance(s2.b, s1) == 0
+}
+
+// segmentsCross returns whether the segments intersect in a single point
+// that is not an endpoint of either of them.
+func segmentsCross(s1, s2 segment) bool {
+	o1 := orientation(s1.a, s1.b, s2.a)
+	o2 := orientation(s1.a, s1.b, s2.b)
+	o3 := orientation(s2.a, s2.b, s1.a)
+	o4 := orientation(s2.a, s2.b, s1.b)
+	return o1*o2 < 0 && o3*o4 < 0
+}
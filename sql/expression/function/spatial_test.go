@@ -0,0 +1,183 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	square         = "POLYGON((0 0,10 0,10 10,0 10,0 0))"
+	squareWithHole = "POLYGON((0 0,10 0,10 10,0 10,0 0),(4 4,6 4,6 6,4 6,4 4))"
+)
+
+func TestSTGeomFromText(t *testing.T) {
+	require := require.New(t)
+	f := NewSTGeomFromText(expression.NewGetField(0, sql.Text, "", true))
+
+	require.Equal(nil, eval(t, f, sql.NewRow(nil)))
+	require.Equal(sql.Point{X: 1, Y: 2}, eval(t, f, sql.NewRow("POINT(1 2)")))
+	require.Equal(sql.Geometry, f.Type())
+
+	_, err := f.Eval(sql.NewEmptyContext(), sql.NewRow("POINT(1)"))
+	require.Error(err)
+	require.True(sql.ErrInvalidWKT.Is(err))
+}
+
+func TestSTAsText(t *testing.T) {
+	f := NewSTAsText(expression.NewGetField(0, sql.Geometry, "", true))
+
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+	}{
+		{"null", sql.NewRow(nil), nil},
+		{"point", sql.NewRow(sql.Point{X: 1, Y: 2.5}), "POINT(1 2.5)"},
+		{"text", sql.NewRow("LINESTRING(0 0, 1 1)"), "LINESTRING(0 0,1 1)"},
+		{"polygon", sql.NewRow(squareWithHole), squareWithHole},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, tt.row))
+		})
+	}
+
+	require.Equal(t, sql.Text, f.Type())
+}
+
+func TestSTGeometryType(t *testing.T) {
+	f := NewSTGeometryType(expression.NewGetField(0, sql.Geometry, "", true))
+
+	require.Equal(t, nil, eval(t, f, sql.NewRow(nil)))
+	require.Equal(t, "POINT", eval(t, f, sql.NewRow("POINT(1 2)")))
+	require.Equal(t, "LINESTRING", eval(t, f, sql.NewRow("LINESTRING(0 0,1 1)")))
+	require.Equal(t, "POLYGON", eval(t, f, sql.NewRow(square)))
+}
+
+func TestPoint(t *testing.T) {
+	f := NewPoint(
+		expression.NewGetField(0, sql.Float64, "", true),
+		expression.NewGetField(1, sql.Float64, "", true),
+	)
+
+	require.Equal(t, nil, eval(t, f, sql.NewRow(nil, 1.0)))
+	require.Equal(t, nil, eval(t, f, sql.NewRow(1.0, nil)))
+	require.Equal(t, sql.Point{X: 1, Y: 2}, eval(t, f, sql.NewRow(int64(1), "2")))
+	require.Equal(t, sql.Geometry, f.Type())
+}
+
+func TestPointAxis(t *testing.T) {
+	require := require.New(t)
+	x := NewPointAxis(xAxis, expression.NewGetField(0, sql.Geometry, "", true))
+	y := NewPointAxis(yAxis, expression.NewGetField(0, sql.Geometry, "", true))
+
+	require.Equal(nil, eval(t, x, sql.NewRow(nil)))
+	require.Equal(1.5, eval(t, x, sql.NewRow("POINT(1.5 -2)")))
+	require.Equal(-2.0, eval(t, y, sql.NewRow("POINT(1.5 -2)")))
+
+	_, err := x.Eval(sql.NewEmptyContext(), sql.NewRow(square))
+	require.Error(err)
+	require.True(ErrInvalidGeometryArgument.Is(err))
+}
+
+func TestSTArea(t *testing.T) {
+	require := require.New(t)
+	f := NewSTArea(expression.NewGetField(0, sql.Geometry, "", true))
+
+	require.Equal(nil, eval(t, f, sql.NewRow(nil)))
+	require.Equal(100.0, eval(t, f, sql.NewRow(square)))
+	require.Equal(96.0, eval(t, f, sql.NewRow(squareWithHole)))
+	require.Equal(0.5, eval(t, f, sql.NewRow("POLYGON((0 0,0 1,1 0,0 0))")))
+
+	_, err := f.Eval(sql.NewEmptyContext(), sql.NewRow("POINT(1 1)"))
+	require.Error(err)
+	require.True(ErrInvalidGeometryArgument.Is(err))
+}
+
+func TestSTLength(t *testing.T) {
+	require := require.New(t)
+	f := NewSTLength(expression.NewGetField(0, sql.Geometry, "", true))
+
+	require.Equal(nil, eval(t, f, sql.NewRow(nil)))
+	require.Equal(10.0, eval(t, f, sql.NewRow("LINESTRING(0 0,3 4,6 0)")))
+
+	_, err := f.Eval(sql.NewEmptyContext(), sql.NewRow(square))
+	require.Error(err)
+	require.True(ErrInvalidGeometryArgument.Is(err))
+}
+
+func TestSTDistance(t *testing.T) {
+	f := NewSTDistance(
+		expression.NewGetField(0, sql.Geometry, "", true),
+		expression.NewGetField(1, sql.Geometry, "", true),
+	)
+
+	testCases := []struct {
+		name     string
+		a, b     interface{}
+		expected interface{}
+	}{
+		{"null", nil, "POINT(0 0)", nil},
+		{"points", "POINT(0 0)", "POINT(3 4)", 5.0},
+		{"same point", "POINT(1 1)", "POINT(1 1)", 0.0},
+		{"point and line", "POINT(1 1)", "LINESTRING(0 0,2 0)", 1.0},
+		{"point past line end", "POINT(5 4)", "LINESTRING(0 0,2 0)", 5.0},
+		{"crossing lines", "LINESTRING(0 0,10 10)", "LINESTRING(0 10,10 0)", 0.0},
+		{"parallel lines", "LINESTRING(0 0,1 0)", "LINESTRING(0 2,1 2)", 2.0},
+		{"point inside polygon", square, "POINT(1 1)", 0.0},
+		{"point outside polygon", square, "POINT(13 14)", 5.0},
+		{"point in hole", squareWithHole, "POINT(5 5)", 1.0},
+		{"polygons", square, "POLYGON((12 0,13 0,13 1,12 0))", 2.0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, f, sql.NewRow(tt.a, tt.b)))
+		})
+	}
+
+	require.Equal(t, sql.Float64, f.Type())
+}
+
+func TestSpatialRelation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		relation spatialRelation
+		a, b     interface{}
+		expected interface{}
+	}{
+		{"null", containsRelation, square, nil, nil},
+		{"polygon contains point", containsRelation, square, "POINT(1 1)", true},
+		{"polygon does not contain point in hole", containsRelation, squareWithHole, "POINT(5 5)", false},
+		{"polygon does not contain boundary point", containsRelation, square, "POINT(0 5)", false},
+		{"polygon does not contain outside point", containsRelation, square, "POINT(11 5)", false},
+		{"polygon contains line", containsRelation, square, "LINESTRING(1 1,2 2)", true},
+		{"polygon does not contain line crossing hole", containsRelation, squareWithHole, "LINESTRING(1 1,9 9)", false},
+		{"polygon does not contain boundary line", containsRelation, square, "LINESTRING(0 0,10 0)", false},
+		{"polygon contains smaller polygon", containsRelation, square, "POLYGON((1 1,2 1,2 2,1 1))", true},
+		{"line contains point", containsRelation, "LINESTRING(0 0,10 0)", "POINT(5 0)", true},
+		{"line contains line", containsRelation, "LINESTRING(0 0,10 0)", "LINESTRING(2 0,4 0)", true},
+		{"line does not contain polygon", containsRelation, "LINESTRING(0 0,10 0)", square, false},
+		{"point contains point", containsRelation, "POINT(1 1)", "POINT(1 1)", true},
+		{"point within polygon", withinRelation, "POINT(1 1)", square, true},
+		{"polygon not within point", withinRelation, square, "POINT(1 1)", false},
+		{"lines intersect", intersectsRelation, "LINESTRING(0 0,10 10)", "LINESTRING(0 10,10 0)", true},
+		{"lines do not intersect", intersectsRelation, "LINESTRING(0 0,1 0)", "LINESTRING(0 2,1 2)", false},
+		{"polygon intersects point", intersectsRelation, square, "POINT(0 5)", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewSpatialRelation(
+				tt.relation,
+				expression.NewGetField(0, sql.Geometry, "", true),
+				expression.NewGetField(1, sql.Geometry, "", true),
+			)
+			require.Equal(t, tt.expected, eval(t, f, sql.NewRow(tt.a, tt.b)))
+		})
+	}
+}
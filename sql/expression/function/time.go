@@ -3,6 +3,7 @@ package function
 import (
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/src-d/go-mysql-server/sql"
@@ -243,6 +244,34 @@ func (s *Second) WithChildren(children ...sql.Expression) (sql.Expression, error
 	return NewSecond(children[0]), nil
 }
 
+// Microsecond is a function that returns the microseconds of a date.
+type Microsecond struct {
+	expression.UnaryExpression
+}
+
+// NewMicrosecond creates a new Microsecond UDF.
+func NewMicrosecond(date sql.Expression) sql.Expression {
+	return &Microsecond{expression.UnaryExpression{Child: date}}
+}
+
+func (m *Microsecond) String() string { return fmt.Sprintf("MICROSECOND(%s)", m.Child) }
+
+// Type implements the Expression interface.
+func (m *Microsecond) Type() sql.Type { return sql.Int32 }
+
+// Eval implements the Expression interface.
+func (m *Microsecond) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	return getDatePart(ctx, m.UnaryExpression, row, micro)
+}
+
+// WithChildren implements the Expression interface.
+func (m *Microsecond) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(m, len(children), 1)
+	}
+	return NewMicrosecond(children[0]), nil
+}
+
 // DayOfWeek is a function that returns the day of the week from a date where
 // 1 = Sunday, ..., 7 = Saturday.
 type DayOfWeek struct {
@@ -300,9 +329,11 @@ func (d *DayOfYear) WithChildren(children ...sql.Expression) (sql.Expression, er
 	return NewDayOfYear(children[0]), nil
 }
 
+// datePartFunc returns a function that returns a part of a date, which is
+// NULL for zero dates, as they are not valid dates.
 func datePartFunc(fn func(time.Time) int) func(interface{}) interface{} {
 	return func(v interface{}) interface{} {
-		if v == nil {
+		if v == nil || sql.IsZeroTime(v.(time.Time)) {
 			return nil
 		}
 
@@ -310,6 +341,18 @@ func datePartFunc(fn func(time.Time) int) func(interface{}) interface{} {
 	}
 }
 
+// zeroDatePartFunc returns a function that returns a part of a date, which
+// is 0 for zero dates, as all of their parts are 0.
+func zeroDatePartFunc(fn func(time.Time) int) func(interface{}) interface{} {
+	part := datePartFunc(fn)
+	return func(v interface{}) interface{} {
+		if t, ok := v.(time.Time); ok && sql.IsZeroTime(t) {
+			return int32(0)
+		}
+		return part(v)
+	}
+}
+
 // YearWeek is a function that returns year and week for a date.
 // The year in the result may be different from the year in the date argument for the first and the last week of the year.
 // Details: https://dev.mysql.com/doc/refman/5.5/en/date-and-time-functions.html#function_yearweek
@@ -493,13 +536,14 @@ func calcDaynr(yyyy, mm, dd int32) int32 {
 }
 
 var (
-	year      = datePartFunc((time.Time).Year)
-	month     = datePartFunc(func(t time.Time) int { return int(t.Month()) })
-	day       = datePartFunc((time.Time).Day)
+	year      = zeroDatePartFunc((time.Time).Year)
+	month     = zeroDatePartFunc(func(t time.Time) int { return int(t.Month()) })
+	day       = zeroDatePartFunc((time.Time).Day)
 	weekday   = datePartFunc(func(t time.Time) int { return (int(t.Weekday()) + 6) % 7 })
-	hour      = datePartFunc((time.Time).Hour)
-	minute    = datePartFunc((time.Time).Minute)
-	second    = datePartFunc((time.Time).Second)
+	hour      = zeroDatePartFunc((time.Time).Hour)
+	minute    = zeroDatePartFunc((time.Time).Minute)
+	second    = zeroDatePartFunc((time.Time).Second)
+	micro     = zeroDatePartFunc(func(t time.Time) int { return t.Nanosecond() / int(time.Microsecond) })
 	dayOfWeek = datePartFunc(func(t time.Time) int { return int(t.Weekday()) + 1 })
 	dayOfYear = datePartFunc((time.Time).YearDay)
 )
@@ -511,17 +555,60 @@ var defaultClock = time.Now
 // Now is a function that returns the current time.
 type Now struct {
 	clock
+	// precision is the number of digits of the fractional seconds.
+	precision int
 }
 
 // NewNow returns a new Now node.
 func NewNow() sql.Expression {
-	return &Now{defaultClock}
+	return &Now{clock: defaultClock}
+}
+
+// NewNowWithPrecision returns a new Now node for NOW() or NOW(fsp), where
+// fsp is the number of digits of the fractional seconds, up to 6.
+func NewNowWithPrecision(args ...sql.Expression) (sql.Expression, error) {
+	switch len(args) {
+	case 0:
+		return NewNow(), nil
+	case 1:
+		precision, err := literalPrecision(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return &Now{clock: defaultClock, precision: precision}, nil
+	default:
+		return nil, sql.ErrInvalidArgumentNumber.New("NOW", "0 or 1", len(args))
+	}
+}
+
+// literalPrecision returns the precision of the fractional seconds given
+// as the constant argument of a function.
+func literalPrecision(e sql.Expression) (int, error) {
+	lit, ok := e.(*expression.Literal)
+	if !ok {
+		return 0, sql.ErrInvalidPrecision.New(e)
+	}
+
+	v, err := sql.Int64.Convert(lit.Value())
+	if err != nil || v.(int64) < 0 {
+		return 0, sql.ErrInvalidPrecision.New(e)
+	}
+
+	if v.(int64) > sql.MaxTimePrecision {
+		return 0, sql.ErrTooBigPrecision.New(v, sql.MaxTimePrecision)
+	}
+	return int(v.(int64)), nil
 }
 
 // Type implements the sql.Expression interface.
-func (*Now) Type() sql.Type { return sql.Timestamp }
+func (n *Now) Type() sql.Type { return sql.TimestampWithPrecision(n.precision) }
 
-func (*Now) String() string { return "NOW()" }
+func (n *Now) String() string {
+	if n.precision > 0 {
+		return fmt.Sprintf("NOW(%d)", n.precision)
+	}
+	return "NOW()"
+}
 
 // IsNullable implements the sql.Expression interface.
 func (*Now) IsNullable() bool { return false }
@@ -532,11 +619,18 @@ func (*Now) Resolved() bool { return true }
 // Children implements the sql.Expression interface.
 func (*Now) Children() []sql.Expression { return nil }
 
-// Eval implements the sql.Expression interface.
+// Eval implements the sql.Expression interface. The fractional seconds
+// beyond the precision are truncated, as in MySQL.
 func (n *Now) Eval(*sql.Context, sql.Row) (interface{}, error) {
-	return n.clock(), nil
+	if n.precision == 0 {
+		return n.clock(), nil
+	}
+	return n.clock().Truncate(time.Duration(math.Pow10(9 - n.precision))), nil
 }
 
+// IsNonDeterministic implements the sql.NonDeterministicExpression interface.
+func (*Now) IsNonDeterministic() bool { return true }
+
 // WithChildren implements the Expression interface.
 func (n *Now) WithChildren(children ...sql.Expression) (sql.Expression, error) {
 	if len(children) != 0 {
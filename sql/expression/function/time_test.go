@@ -29,6 +29,7 @@ func TestTime_Year(t *testing.T) {
 		{"date as string", sql.NewRow(stringDate), int32(2007), false},
 		{"date as time", sql.NewRow(time.Now()), int32(time.Now().UTC().Year()), false},
 		{"date as unix timestamp", sql.NewRow(int64(tsDate)), int32(2009), false},
+		{"zero date", sql.NewRow("0000-00-00"), int32(0), false},
 	}
 
 	for _, tt := range testCases {
@@ -60,6 +61,8 @@ func TestTime_Month(t *testing.T) {
 		{"date as string", sql.NewRow(stringDate), int32(1), false},
 		{"date as time", sql.NewRow(time.Now()), int32(time.Now().UTC().Month()), false},
 		{"date as unix timestamp", sql.NewRow(int64(tsDate)), int32(11), false},
+		{"zero date", sql.NewRow(sql.ZeroTime), int32(0), false},
+		{"first date", sql.NewRow(time.Time{}), int32(1), false},
 	}
 
 	for _, tt := range testCases {
@@ -91,6 +94,7 @@ func TestTime_Day(t *testing.T) {
 		{"date as string", sql.NewRow(stringDate), int32(2), false},
 		{"date as time", sql.NewRow(time.Now()), int32(time.Now().UTC().Day()), false},
 		{"date as unix timestamp", sql.NewRow(int64(tsDate)), int32(22), false},
+		{"zero date", sql.NewRow("0000-00-00 00:00:00"), int32(0), false},
 	}
 
 	for _, tt := range testCases {
@@ -230,6 +234,36 @@ func TestTime_Second(t *testing.T) {
 	}
 }
 
+func TestTime_Microsecond(t *testing.T) {
+	f := NewMicrosecond(expression.NewGetField(0, sql.Text, "foo", false))
+	ctx := sql.NewEmptyContext()
+	testCases := []struct {
+		name     string
+		row      sql.Row
+		expected interface{}
+		err      bool
+	}{
+		{"null date", sql.NewRow(nil), nil, false},
+		{"invalid type", sql.NewRow([]byte{0, 1, 2}), nil, false},
+		{"date as string", sql.NewRow(stringDate), int32(0), false},
+		{"date with fraction as string", sql.NewRow("2007-01-02 14:15:16.123456"), int32(123456), false},
+		{"date as time", sql.NewRow(time.Date(2007, 1, 2, 14, 15, 16, 123456789, time.UTC)), int32(123456), false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			val, err := f.Eval(ctx, tt.row)
+			if tt.err {
+				require.Error(err)
+			} else {
+				require.NoError(err)
+				require.Equal(tt.expected, val)
+			}
+		})
+	}
+}
+
 func TestTime_DayOfWeek(t *testing.T) {
 	f := NewDayOfWeek(expression.NewGetField(0, sql.Text, "foo", false))
 	ctx := sql.NewEmptyContext()
@@ -245,6 +279,7 @@ func TestTime_DayOfWeek(t *testing.T) {
 		{"date as string", sql.NewRow(stringDate), int32(3), false},
 		{"date as time", sql.NewRow(time.Now()), int32(time.Now().UTC().Weekday() + 1), false},
 		{"date as unix timestamp", sql.NewRow(int64(tsDate)), int32(1), false},
+		{"zero date", sql.NewRow("0000-00-00"), nil, false},
 	}
 
 	for _, tt := range testCases {
@@ -348,13 +383,35 @@ func TestNow(t *testing.T) {
 	clk := clock(func() time.Time {
 		return date
 	})
-	f := &Now{clk}
+	f := &Now{clock: clk}
 
 	result, err := f.Eval(nil, nil)
 	require.NoError(err)
 	require.Equal(date, result)
 }
 
+func TestNowWithPrecision(t *testing.T) {
+	require := require.New(t)
+	date := time.Date(2018, time.December, 2, 16, 25, 0, 123456789, time.Local)
+
+	e, err := NewNowWithPrecision(expression.NewLiteral(int8(3), sql.Int8))
+	require.NoError(err)
+	f := e.(*Now)
+	f.clock = func() time.Time { return date }
+	require.Equal(sql.TimestampWithPrecision(3), f.Type())
+	require.Equal("NOW(3)", f.String())
+
+	result, err := f.Eval(nil, nil)
+	require.NoError(err)
+	require.Equal(date.Truncate(time.Millisecond), result)
+
+	_, err = NewNowWithPrecision(expression.NewLiteral(int8(7), sql.Int8))
+	require.True(sql.ErrTooBigPrecision.Is(err))
+
+	_, err = NewNowWithPrecision(expression.NewGetField(0, sql.Int8, "foo", false))
+	require.True(sql.ErrInvalidPrecision.Is(err))
+}
+
 func TestDate(t *testing.T) {
 	f := NewDate(expression.NewGetField(0, sql.Text, "foo", false))
 	ctx := sql.NewEmptyContext()
@@ -1,7 +1,9 @@
 package expression
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 
 	"github.com/src-d/go-mysql-server/sql"
 )
@@ -10,6 +12,7 @@ import (
 type Literal struct {
 	value     interface{}
 	fieldType sql.Type
+	hex       bool
 }
 
 // NewLiteral creates a new Literal expression.
@@ -22,6 +25,13 @@ func NewLiteral(value interface{}, fieldType sql.Type) *Literal {
 	}
 }
 
+// NewHexLiteral creates a Literal of a hexadecimal or bit-value literal,
+// such as X'4142', 0x4142 or b'0100000101000010', which is a binary string,
+// but an unsigned integer when it's used as a number.
+func NewHexLiteral(value []byte) *Literal {
+	return &Literal{value: value, fieldType: sql.Blob, hex: true}
+}
+
 // Resolved implements the Expression interface.
 func (p *Literal) Resolved() bool {
 	return true
@@ -47,6 +57,9 @@ func (p *Literal) String() string {
 	case string:
 		return fmt.Sprintf("%q", v)
 	case []byte:
+		if p.hex {
+			return fmt.Sprintf("0x%X", v)
+		}
 		return "BLOB"
 	default:
 		return fmt.Sprint(v)
@@ -70,3 +83,39 @@ func (*Literal) Children() []sql.Expression {
 func (p *Literal) Value() interface{} {
 	return p.value
 }
+
+// IsHex returns whether the literal is a hexadecimal or bit-value literal.
+func (p *Literal) IsHex() bool {
+	return p.hex
+}
+
+// HexNumber returns the unsigned integer of a hexadecimal or bit-value
+// literal used as a number, whose bytes are read in big-endian order, or the
+// value as it is for other expressions. Numbers longer than 8 bytes are
+// clamped to the maximum unsigned integer.
+func HexNumber(e sql.Expression, v interface{}) interface{} {
+	l, ok := e.(*Literal)
+	if !ok || !l.hex {
+		return v
+	}
+
+	b := bytes.TrimLeft(l.value.([]byte), "\x00")
+	if len(b) > 8 {
+		return uint64(math.MaxUint64)
+	}
+
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// numericType returns the type of an expression used as a number, which is
+// an unsigned integer for hexadecimal and bit-value literals.
+func numericType(e sql.Expression) sql.Type {
+	if l, ok := e.(*Literal); ok && l.hex {
+		return sql.Uint64
+	}
+	return e.Type()
+}
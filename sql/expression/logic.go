@@ -41,9 +41,11 @@ func (*And) Type() sql.Type {
 	return sql.Boolean
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. It's false if any of the
+// expressions is false, even if the other one is NULL, and NULL if none of
+// them is false but any of them is NULL.
 func (a *And) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
-	lval, err := a.Left.Eval(ctx, row)
+	lval, err := sql.EvaluateTruth(ctx, a.Left, row)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +54,7 @@ func (a *And) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return false, nil
 	}
 
-	rval, err := a.Right.Eval(ctx, row)
+	rval, err := sql.EvaluateTruth(ctx, a.Right, row)
 	if err != nil {
 		return nil, err
 	}
@@ -95,9 +97,11 @@ func (*Or) Type() sql.Type {
 	return sql.Boolean
 }
 
-// Eval implements the Expression interface.
+// Eval implements the Expression interface. It's true if any of the
+// expressions is true, even if the other one is NULL, and NULL if none of
+// them is true but any of them is NULL.
 func (o *Or) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
-	lval, err := o.Left.Eval(ctx, row)
+	lval, err := sql.EvaluateTruth(ctx, o.Left, row)
 	if err != nil {
 		return nil, err
 	}
@@ -106,16 +110,20 @@ func (o *Or) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return true, nil
 	}
 
-	rval, err := o.Right.Eval(ctx, row)
+	rval, err := sql.EvaluateTruth(ctx, o.Right, row)
 	if err != nil {
 		return nil, err
 	}
 
-	if lval == nil && rval == nil {
+	if rval == true {
+		return true, nil
+	}
+
+	if lval == nil || rval == nil {
 		return nil, nil
 	}
 
-	return rval == true, nil
+	return false, nil
 }
 
 // WithChildren implements the Expression interface.
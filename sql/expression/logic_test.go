@@ -47,6 +47,8 @@ func TestOr(t *testing.T) {
 		{"left is null, right is not", nil, true, true},
 		{"left is false, right is true", false, true, true},
 		{"right is null, left is not", true, nil, true},
+		{"left is false, right is null", false, nil, nil},
+		{"left is null, right is false", nil, false, nil},
 		{"both true", true, true, true},
 		{"both false", false, false, false},
 		{"both null", nil, nil, nil},
@@ -65,6 +67,37 @@ func TestOr(t *testing.T) {
 	}
 }
 
+func TestLogicWithNumbers(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		e        sql.Expression
+		expected interface{}
+	}{
+		{"0 AND 1", NewAnd(NewLiteral(int8(0), sql.Int8), NewLiteral(int8(1), sql.Int8)), false},
+		{"0 AND NULL", NewAnd(NewLiteral(int8(0), sql.Int8), NewLiteral(nil, sql.Null)), false},
+		{"NULL AND 0", NewAnd(NewLiteral(nil, sql.Null), NewLiteral(0.0, sql.Float64)), false},
+		{"2 AND 3", NewAnd(NewLiteral(int8(2), sql.Int8), NewLiteral(int8(3), sql.Int8)), true},
+		{"2 AND NULL", NewAnd(NewLiteral(int8(2), sql.Int8), NewLiteral(nil, sql.Null)), nil},
+		{"1 OR 0", NewOr(NewLiteral(int8(1), sql.Int8), NewLiteral(int8(0), sql.Int8)), true},
+		{"0 OR 5", NewOr(NewLiteral(int8(0), sql.Int8), NewLiteral(int8(5), sql.Int8)), true},
+		{"0 OR '0'", NewOr(NewLiteral(int8(0), sql.Int8), NewLiteral("0", sql.Text)), false},
+		{"NULL OR 1", NewOr(NewLiteral(nil, sql.Null), NewLiteral(int8(1), sql.Int8)), true},
+		{"0 OR NULL", NewOr(NewLiteral(int8(0), sql.Int8), NewLiteral(nil, sql.Null)), nil},
+		{"NOT NULL", NewNot(NewLiteral(nil, sql.Null)), nil},
+		{"NOT (NULL AND FALSE)", NewNot(NewAnd(NewLiteral(nil, sql.Null), NewLiteral(false, sql.Boolean))), true},
+		{"NOT (NULL OR FALSE)", NewNot(NewOr(NewLiteral(nil, sql.Null), NewLiteral(false, sql.Boolean))), nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			result, err := tt.e.Eval(sql.NewEmptyContext(), sql.NewRow())
+			require.NoError(err)
+			require.Equal(tt.expected, result)
+		})
+	}
+}
+
 func TestJoinAnd(t *testing.T) {
 	require := require.New(t)
 
@@ -30,6 +30,13 @@ func (s *SetField) Type() sql.Type {
 // Eval implements the Expression interface.
 // Returns a copy of the given row with an updated value.
 func (s *SetField) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	return s.Assign(ctx, row, 1)
+}
+
+// Assign returns a copy of the given row with an updated value. The row is
+// the given row number of the statement, counting from 1, which is the one
+// reported when the value is not valid for the column.
+func (s *SetField) Assign(ctx *sql.Context, row sql.Row, rowNum int64) (sql.Row, error) {
 	getField, ok := s.Left.(*GetField)
 	if !ok {
 		return nil, errCannotSetField.New(s.Left)
@@ -42,7 +49,12 @@ func (s *SetField) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
 		return nil, err
 	}
 	if val != nil {
-		val, err = getField.fieldType.Convert(val)
+		if sql.IsNumber(getField.fieldType) {
+			val = HexNumber(s.Right, val)
+		}
+
+		col := &sql.Column{Name: getField.name, Type: getField.fieldType}
+		val, err = sql.ConvertColumnValue(ctx, col, rowNum, val)
 		if err != nil {
 			return nil, err
 		}
@@ -2,6 +2,8 @@ package expression
 
 import (
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/src-d/go-mysql-server/sql"
 	errors "gopkg.in/src-d/go-errors.v1"
@@ -9,56 +11,49 @@ import (
 
 var errExpectedSingleRow = errors.NewKind("the subquery returned more than 1 row")
 
-// Subquery that is executed as an expression.
+// Subquery that is executed as an expression. The subquery is executed
+// only the first time it's evaluated during each execution of a query, as
+// it does not depend on the rows it is evaluated with, so the same subquery
+// can be used in several places.
 type Subquery struct {
 	Query sql.Node
-	value interface{}
+
+	mu sync.Mutex
+	// execution is the memory of the query the values were computed for,
+	// which is different for every execution of the query.
+	execution *sql.QueryMemory
+	values    []interface{}
 }
 
 // NewSubquery returns a new subquery node.
 func NewSubquery(node sql.Node) *Subquery {
-	return &Subquery{node, nil}
+	return &Subquery{Query: node}
 }
 
 // Eval implements the Expression interface.
 func (s *Subquery) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
-	if s.value != nil {
-		if elems, ok := s.value.([]interface{}); ok {
-			if len(elems) > 1 {
-				return nil, errExpectedSingleRow.New()
-			}
-			return elems[0], nil
-		}
-		return s.value, nil
-	}
-
-	iter, err := s.Query.RowIter(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	rows, err := sql.RowIterToRows(iter)
+	values, err := s.EvalMultiple(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(rows) == 0 {
-		s.value = nil
+	switch len(values) {
+	case 0:
 		return nil, nil
-	}
-
-	if len(rows) > 1 {
+	case 1:
+		return values[0], nil
+	default:
 		return nil, errExpectedSingleRow.New()
 	}
-
-	s.value = rows[0][0]
-	return s.value, nil
 }
 
 // EvalMultiple returns all rows returned by a subquery.
 func (s *Subquery) EvalMultiple(ctx *sql.Context) ([]interface{}, error) {
-	if s.value != nil {
-		return s.value.([]interface{}), nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.execution != nil && s.execution == ctx.QueryMemory() {
+		return s.values, nil
 	}
 
 	iter, err := s.Query.RowIter(ctx)
@@ -71,17 +66,13 @@ func (s *Subquery) EvalMultiple(ctx *sql.Context) ([]interface{}, error) {
 		return nil, err
 	}
 
-	if len(rows) == 0 {
-		s.value = []interface{}{}
-		return nil, nil
-	}
-
 	var result = make([]interface{}, len(rows))
 	for i, row := range rows {
 		result[i] = row[0]
 	}
-	s.value = result
 
+	s.values = result
+	s.execution = ctx.QueryMemory()
 	return result, nil
 }
 
@@ -119,7 +110,90 @@ func (s *Subquery) Children() []sql.Expression {
 
 // WithQuery returns the subquery with the query node changed.
 func (s *Subquery) WithQuery(node sql.Node) *Subquery {
-	ns := *s
-	ns.Query = node
-	return &ns
+	return NewSubquery(node)
+}
+
+// ErrInvalidExistsOperand is returned when the operand of EXISTS is not a
+// subquery.
+var ErrInvalidExistsOperand = errors.NewKind("EXISTS expects a subquery, got %T")
+
+// Exists is an expression that checks whether a subquery returns any row.
+// The subquery is only executed the first time it's evaluated during each
+// execution of a query.
+type Exists struct {
+	Query *Subquery
+
+	mu sync.Mutex
+	// execution is the memory of the query the value was computed for,
+	// which is different for every execution of the query.
+	execution *sql.QueryMemory
+	value     bool
+}
+
+// NewExists returns a new Exists expression.
+func NewExists(query *Subquery) *Exists {
+	return &Exists{Query: query}
+}
+
+// Eval implements the Expression interface.
+func (e *Exists) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.execution != nil && e.execution == ctx.QueryMemory() {
+		return e.value, nil
+	}
+
+	iter, err := e.Query.Query.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = iter.Next()
+	if err != nil && err != io.EOF {
+		_ = iter.Close()
+		return nil, err
+	}
+
+	if cerr := iter.Close(); cerr != nil {
+		return nil, cerr
+	}
+
+	e.value = err != io.EOF
+	e.execution = ctx.QueryMemory()
+	return e.value, nil
+}
+
+// IsNullable implements the Expression interface.
+func (e *Exists) IsNullable() bool { return false }
+
+func (e *Exists) String() string {
+	return fmt.Sprintf("EXISTS %s", e.Query)
+}
+
+// Resolved implements the Expression interface.
+func (e *Exists) Resolved() bool {
+	return e.Query.Resolved()
+}
+
+// Type implements the Expression interface.
+func (e *Exists) Type() sql.Type { return sql.Boolean }
+
+// WithChildren implements the Expression interface.
+func (e *Exists) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(e, len(children), 1)
+	}
+
+	query, ok := children[0].(*Subquery)
+	if !ok {
+		return nil, ErrInvalidExistsOperand.New(children[0])
+	}
+
+	return NewExists(query), nil
+}
+
+// Children implements the Expression interface.
+func (e *Exists) Children() []sql.Expression {
+	return []sql.Expression{e.Query}
 }
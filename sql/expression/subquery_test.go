@@ -1,6 +1,7 @@
 package expression_test
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/src-d/go-mysql-server/memory"
@@ -67,3 +68,90 @@ func TestSubqueryMultipleRows(t *testing.T) {
 	require.NoError(err)
 	require.Equal(values, []interface{}{"one", "two", "three"})
 }
+
+func TestExists(t *testing.T) {
+	require := require.New(t)
+	table := memory.NewTable("", nil)
+	empty := memory.NewTable("", nil)
+	require.NoError(table.Insert(sql.NewEmptyContext(), nil))
+	require.NoError(table.Insert(sql.NewEmptyContext(), nil))
+
+	exists := expression.NewExists(expression.NewSubquery(plan.NewResolvedTable(table)))
+	value, err := exists.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal(true, value)
+
+	exists = expression.NewExists(expression.NewSubquery(plan.NewResolvedTable(empty)))
+	value, err = exists.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal(false, value)
+}
+
+func TestExistsPerQuery(t *testing.T) {
+	require := require.New(t)
+	table := memory.NewTable("", nil)
+	exists := expression.NewExists(expression.NewSubquery(plan.NewResolvedTable(table)))
+
+	ctx := sql.NewEmptyContext()
+	value, err := exists.Eval(ctx, nil)
+	require.NoError(err)
+	require.Equal(false, value)
+
+	require.NoError(table.Insert(ctx, nil))
+
+	value, err = exists.Eval(ctx, nil)
+	require.NoError(err)
+	require.Equal(false, value, "the value is kept during the same query")
+
+	value, err = exists.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal(true, value, "the value is computed again for a new query")
+}
+
+func TestExistsConcurrentEval(t *testing.T) {
+	require := require.New(t)
+	table := memory.NewTable("", nil)
+	require.NoError(table.Insert(sql.NewEmptyContext(), nil))
+	exists := expression.NewExists(expression.NewSubquery(plan.NewResolvedTable(table)))
+
+	ctx := sql.NewEmptyContext()
+	var wg sync.WaitGroup
+	values := make([]interface{}, 8)
+	errs := make([]error, len(values))
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], errs[i] = exists.Eval(ctx, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range values {
+		require.NoError(errs[i])
+		require.Equal(true, values[i])
+	}
+}
+
+func TestSubqueryPerQuery(t *testing.T) {
+	require := require.New(t)
+	table := memory.NewTable("foo", sql.Schema{
+		{Name: "t", Source: "foo", Type: sql.Text},
+	})
+	subquery := expression.NewSubquery(plan.NewResolvedTable(table))
+
+	ctx := sql.NewEmptyContext()
+	value, err := subquery.Eval(ctx, nil)
+	require.NoError(err)
+	require.Nil(value)
+
+	require.NoError(table.Insert(ctx, sql.Row{"one"}))
+
+	value, err = subquery.Eval(ctx, nil)
+	require.NoError(err)
+	require.Nil(value, "the value is kept during the same query")
+
+	value, err = subquery.Eval(sql.NewEmptyContext(), nil)
+	require.NoError(err)
+	require.Equal("one", value, "the value is computed again for a new query")
+}
@@ -28,3 +28,29 @@ func TransformUp(e sql.Expression, f sql.TransformExprFunc) (sql.Expression, err
 
 	return f(e)
 }
+
+// TransformDown applies a transformation function to the given expression
+// from the top down. The children of the expression returned by the
+// function are the ones transformed afterwards.
+func TransformDown(e sql.Expression, f sql.TransformExprFunc) (sql.Expression, error) {
+	e, err := f(e)
+	if err != nil {
+		return nil, err
+	}
+
+	children := e.Children()
+	if len(children) == 0 {
+		return e, nil
+	}
+
+	newChildren := make([]sql.Expression, len(children))
+	for i, c := range children {
+		c, err := TransformDown(c, f)
+		if err != nil {
+			return nil, err
+		}
+		newChildren[i] = c
+	}
+
+	return e.WithChildren(newChildren...)
+}
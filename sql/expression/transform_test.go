@@ -0,0 +1,53 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformUp(t *testing.T) {
+	require := require.New(t)
+
+	e := NewEquals(NewUnresolvedColumn("a"), NewLiteral(int64(1), sql.Int64))
+
+	var visited []string
+	result, err := TransformUp(e, func(e sql.Expression) (sql.Expression, error) {
+		visited = append(visited, e.String())
+		if _, ok := e.(*UnresolvedColumn); ok {
+			return NewGetField(0, sql.Int64, "a", false), nil
+		}
+		return e, nil
+	})
+	require.NoError(err)
+
+	require.Equal([]string{"a", "1", "a = 1"}, visited)
+	require.Equal(
+		NewEquals(NewGetField(0, sql.Int64, "a", false), NewLiteral(int64(1), sql.Int64)),
+		result,
+	)
+}
+
+func TestTransformDown(t *testing.T) {
+	require := require.New(t)
+
+	e := NewNot(NewUnresolvedColumn("a"))
+
+	var visited []string
+	result, err := TransformDown(e, func(e sql.Expression) (sql.Expression, error) {
+		visited = append(visited, e.String())
+		switch e := e.(type) {
+		case *Not:
+			// The children of the new node are transformed as well.
+			return NewIsNull(e.Child), nil
+		case *UnresolvedColumn:
+			return NewGetField(0, sql.Int64, "a", false), nil
+		}
+		return e, nil
+	})
+	require.NoError(err)
+
+	require.Equal([]string{"NOT(a)", "a"}, visited)
+	require.Equal(NewIsNull(NewGetField(0, sql.Int64, "a", false)), result)
+}
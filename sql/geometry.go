@@ -0,0 +1,421 @@
+package sql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+var (
+	// ErrInvalidWKT is returned when a geometry cannot be parsed from its
+	// well-known text representation.
+	ErrInvalidWKT = errors.NewKind("invalid well-known text geometry: %s")
+
+	// ErrInvalidWKB is returned when a geometry cannot be decoded from its
+	// binary representation.
+	ErrInvalidWKB = errors.NewKind("invalid binary geometry: %s")
+
+	// ErrNotGeometry is returned when a value cannot be converted to a
+	// geometry.
+	ErrNotGeometry = errors.NewKind("value of type %T is not a geometry")
+)
+
+// GeometryValue is a value of the GEOMETRY type. The concrete types are
+// Point, LineString and Polygon, whose coordinates are in a cartesian plane.
+type GeometryValue interface {
+	// GeometryType returns the name of the geometry type, as returned by
+	// ST_GeometryType.
+	GeometryType() string
+	// WKT returns the well-known text representation of the geometry.
+	WKT() string
+	// WKB returns the well-known binary representation of the geometry.
+	WKB() []byte
+}
+
+// Point is a geometry with a single location.
+type Point struct {
+	X, Y float64
+}
+
+// GeometryType implements the GeometryValue interface.
+func (Point) GeometryType() string { return "POINT" }
+
+// WKT implements the GeometryValue interface.
+func (p Point) WKT() string {
+	return "POINT(" + p.coords() + ")"
+}
+
+// WKB implements the GeometryValue interface.
+func (p Point) WKB() []byte {
+	var buf bytes.Buffer
+	writeWKBHeader(&buf, wkbPoint)
+	writeWKBPoint(&buf, p)
+	return buf.Bytes()
+}
+
+func (p Point) coords() string {
+	return formatCoord(p.X) + " " + formatCoord(p.Y)
+}
+
+// LineString is a geometry made of a sequence of connected points.
+type LineString struct {
+	Points []Point
+}
+
+// GeometryType implements the GeometryValue interface.
+func (LineString) GeometryType() string { return "LINESTRING" }
+
+// WKT implements the GeometryValue interface.
+func (l LineString) WKT() string {
+	return "LINESTRING(" + l.coords() + ")"
+}
+
+// WKB implements the GeometryValue interface.
+func (l LineString) WKB() []byte {
+	var buf bytes.Buffer
+	writeWKBHeader(&buf, wkbLineString)
+	writeWKBPoints(&buf, l.Points)
+	return buf.Bytes()
+}
+
+func (l LineString) coords() string {
+	parts := make([]string, len(l.Points))
+	for i, p := range l.Points {
+		parts[i] = p.coords()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Polygon is a geometry made of an exterior ring and zero or more interior
+// rings, which are closed line strings.
+type Polygon struct {
+	Rings []LineString
+}
+
+// GeometryType implements the GeometryValue interface.
+func (Polygon) GeometryType() string { return "POLYGON" }
+
+// WKT implements the GeometryValue interface.
+func (p Polygon) WKT() string {
+	parts := make([]string, len(p.Rings))
+	for i, r := range p.Rings {
+		parts[i] = "(" + r.coords() + ")"
+	}
+	return "POLYGON(" + strings.Join(parts, ",") + ")"
+}
+
+// WKB implements the GeometryValue interface.
+func (p Polygon) WKB() []byte {
+	var buf bytes.Buffer
+	writeWKBHeader(&buf, wkbPolygon)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(p.Rings)))
+	for _, r := range p.Rings {
+		writeWKBPoints(&buf, r.Points)
+	}
+	return buf.Bytes()
+}
+
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+const (
+	wkbPoint      uint32 = 1
+	wkbLineString uint32 = 2
+	wkbPolygon    uint32 = 3
+)
+
+func writeWKBHeader(buf *bytes.Buffer, typ uint32) {
+	buf.WriteByte(1) // little endian
+	_ = binary.Write(buf, binary.LittleEndian, typ)
+}
+
+func writeWKBPoint(buf *bytes.Buffer, p Point) {
+	_ = binary.Write(buf, binary.LittleEndian, p.X)
+	_ = binary.Write(buf, binary.LittleEndian, p.Y)
+}
+
+func writeWKBPoints(buf *bytes.Buffer, points []Point) {
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(points)))
+	for _, p := range points {
+		writeWKBPoint(buf, p)
+	}
+}
+
+// ParseWKT parses a geometry from its well-known text representation.
+func ParseWKT(s string) (GeometryValue, error) {
+	s = strings.TrimSpace(s)
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return nil, ErrInvalidWKT.New(s)
+	}
+
+	body := s[open+1 : len(s)-1]
+	switch strings.ToUpper(strings.TrimSpace(s[:open])) {
+	case "POINT":
+		p, err := parseWKTPoint(body)
+		if err != nil {
+			return nil, ErrInvalidWKT.New(s)
+		}
+		return p, nil
+	case "LINESTRING":
+		l, err := parseWKTLineString(body)
+		if err != nil || len(l.Points) < 2 {
+			return nil, ErrInvalidWKT.New(s)
+		}
+		return l, nil
+	case "POLYGON":
+		var rings []LineString
+		for _, r := range splitWKTGroups(body) {
+			l, err := parseWKTLineString(r)
+			if err != nil || !isRing(l) {
+				return nil, ErrInvalidWKT.New(s)
+			}
+			rings = append(rings, l)
+		}
+
+		if len(rings) == 0 {
+			return nil, ErrInvalidWKT.New(s)
+		}
+		return Polygon{rings}, nil
+	default:
+		return nil, ErrInvalidWKT.New(s)
+	}
+}
+
+func parseWKTPoint(s string) (Point, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Point{}, fmt.Errorf("invalid point: %s", s)
+	}
+
+	x, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Point{}, err
+	}
+
+	y, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return Point{}, err
+	}
+
+	return Point{x, y}, nil
+}
+
+func parseWKTLineString(s string) (LineString, error) {
+	var points []Point
+	for _, part := range strings.Split(s, ",") {
+		p, err := parseWKTPoint(part)
+		if err != nil {
+			return LineString{}, err
+		}
+		points = append(points, p)
+	}
+	return LineString{points}, nil
+}
+
+// splitWKTGroups splits a list of parenthesized groups such as
+// "(0 0,1 1),(2 2,3 3)" into the contents of each group.
+func splitWKTGroups(s string) []string {
+	var groups []string
+	var depth, start int
+	for i, c := range s {
+		switch c {
+		case '(':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				groups = append(groups, s[start:i])
+			}
+		}
+	}
+	return groups
+}
+
+func isRing(l LineString) bool {
+	return len(l.Points) >= 4 && l.Points[0] == l.Points[len(l.Points)-1]
+}
+
+// ParseWKB decodes a geometry from its well-known binary representation.
+func ParseWKB(b []byte) (GeometryValue, error) {
+	r := &wkbReader{data: b}
+	g := r.geometry()
+	if r.err != nil {
+		return nil, ErrInvalidWKB.New(r.err)
+	}
+	if r.pos != len(b) {
+		return nil, ErrInvalidWKB.New("trailing bytes")
+	}
+	return g, nil
+}
+
+type wkbReader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	err   error
+}
+
+func (r *wkbReader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("unexpected end of data")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *wkbReader) uint32() uint32 {
+	b := r.read(4)
+	if b == nil {
+		return 0
+	}
+	return r.order.Uint32(b)
+}
+
+func (r *wkbReader) point() Point {
+	b := r.read(16)
+	if b == nil {
+		return Point{}
+	}
+	return Point{
+		math.Float64frombits(r.order.Uint64(b[:8])),
+		math.Float64frombits(r.order.Uint64(b[8:])),
+	}
+}
+
+func (r *wkbReader) points() []Point {
+	n := r.uint32()
+	if r.err == nil && int(n)*16 > len(r.data)-r.pos {
+		r.err = fmt.Errorf("unexpected end of data")
+	}
+	if r.err != nil {
+		return nil
+	}
+
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = r.point()
+	}
+	return points
+}
+
+func (r *wkbReader) geometry() GeometryValue {
+	b := r.read(1)
+	if b == nil {
+		return nil
+	}
+
+	switch b[0] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		r.err = fmt.Errorf("invalid byte order %d", b[0])
+		return nil
+	}
+
+	switch typ := r.uint32(); typ {
+	case wkbPoint:
+		return r.point()
+	case wkbLineString:
+		return LineString{r.points()}
+	case wkbPolygon:
+		n := r.uint32()
+		var rings []LineString
+		for i := uint32(0); i < n && r.err == nil; i++ {
+			rings = append(rings, LineString{r.points()})
+		}
+		return Polygon{rings}
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("unsupported geometry type %d", typ)
+		}
+		return nil
+	}
+}
+
+type geometryT struct{}
+
+func (t geometryT) String() string { return "GEOMETRY" }
+
+// Type implements Type interface.
+func (t geometryT) Type() query.Type {
+	return sqltypes.Geometry
+}
+
+// SQL implements Type interface. Geometries are sent using the MySQL internal
+// format, which is the well-known binary representation prefixed by a 4-byte
+// SRID.
+func (t geometryT) SQL(v interface{}) (sqltypes.Value, error) {
+	if v == nil {
+		return sqltypes.NULL, nil
+	}
+
+	g, err := t.Convert(v)
+	if err != nil {
+		return sqltypes.Value{}, err
+	}
+
+	return sqltypes.MakeTrusted(sqltypes.Geometry, geometryToMySQL(g.(GeometryValue))), nil
+}
+
+// Convert implements Type interface. Geometries can be converted from their
+// well-known text representation or from the MySQL internal format.
+func (t geometryT) Convert(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case GeometryValue:
+		return v, nil
+	case string:
+		return ParseWKT(v)
+	case []byte:
+		if len(v) < 4 {
+			return nil, ErrInvalidWKB.New("missing SRID")
+		}
+		return ParseWKB(v[4:])
+	default:
+		return nil, ErrNotGeometry.New(v)
+	}
+}
+
+// Compare implements Type interface.
+func (t geometryT) Compare(a interface{}, b interface{}) (int, error) {
+	if hasNulls, res := compareNulls(a, b); hasNulls {
+		return res, nil
+	}
+
+	ga, err := t.Convert(a)
+	if err != nil {
+		return 0, err
+	}
+
+	gb, err := t.Convert(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return bytes.Compare(ga.(GeometryValue).WKB(), gb.(GeometryValue).WKB()), nil
+}
+
+func geometryToMySQL(g GeometryValue) []byte {
+	// SRID is always 0, since only cartesian coordinates are supported.
+	return append([]byte{0, 0, 0, 0}, g.WKB()...)
+}
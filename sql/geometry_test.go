@@ -0,0 +1,131 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+func TestParseWKT(t *testing.T) {
+	testCases := []struct {
+		name     string
+		wkt      string
+		expected GeometryValue
+		err      bool
+	}{
+		{"point", "POINT(1 2)", Point{1, 2}, false},
+		{"point with spaces", " point ( 1.5  -2 ) ", Point{1.5, -2}, false},
+		{"linestring", "LINESTRING(0 0,1 1,2 0)", LineString{[]Point{{0, 0}, {1, 1}, {2, 0}}}, false},
+		{
+			"polygon with hole",
+			"POLYGON((0 0,4 0,4 4,0 4,0 0),(1 1,2 1,2 2,1 1))",
+			Polygon{[]LineString{
+				{[]Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}},
+				{[]Point{{1, 1}, {2, 1}, {2, 2}, {1, 1}}},
+			}},
+			false,
+		},
+		{"unknown type", "CIRCLE(0 0)", nil, true},
+		{"missing parens", "POINT 1 2", nil, true},
+		{"point with three coords", "POINT(1 2 3)", nil, true},
+		{"invalid number", "POINT(a 2)", nil, true},
+		{"linestring with one point", "LINESTRING(0 0)", nil, true},
+		{"open ring", "POLYGON((0 0,1 0,1 1,0 1))", nil, true},
+		{"ring too short", "POLYGON((0 0,1 1,0 0))", nil, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			g, err := ParseWKT(tt.wkt)
+			if tt.err {
+				require.Error(err)
+				require.True(ErrInvalidWKT.Is(err))
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.expected, g)
+		})
+	}
+}
+
+func TestWKBRoundTrip(t *testing.T) {
+	geometries := []string{
+		"POINT(1 -2.5)",
+		"LINESTRING(0 0,1 1,2 0)",
+		"POLYGON((0 0,4 0,4 4,0 4,0 0),(1 1,2 1,2 2,1 1))",
+	}
+
+	for _, wkt := range geometries {
+		t.Run(wkt, func(t *testing.T) {
+			require := require.New(t)
+			g, err := ParseWKT(wkt)
+			require.NoError(err)
+			require.Equal(wkt, g.WKT())
+
+			decoded, err := ParseWKB(g.WKB())
+			require.NoError(err)
+			require.Equal(g, decoded)
+		})
+	}
+}
+
+func TestParseWKBBigEndian(t *testing.T) {
+	b := []byte{
+		0,
+		0, 0, 0, 1,
+		0x3f, 0xf0, 0, 0, 0, 0, 0, 0,
+		0x40, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	g, err := ParseWKB(b)
+	require.NoError(t, err)
+	require.Equal(t, Point{1, 2}, g)
+}
+
+func TestParseWKBInvalid(t *testing.T) {
+	point := Point{1, 2}.WKB()
+	testCases := []struct {
+		name string
+		wkb  []byte
+	}{
+		{"empty", nil},
+		{"invalid byte order", append([]byte{2}, point[1:]...)},
+		{"unknown type", []byte{1, 9, 0, 0, 0}},
+		{"truncated", point[:len(point)-1]},
+		{"trailing bytes", append(point, 0)},
+		{"too many points", []byte{1, 2, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWKB(tt.wkb)
+			require.Error(t, err)
+			require.True(t, ErrInvalidWKB.Is(err))
+		})
+	}
+}
+
+func TestGeometry(t *testing.T) {
+	require := require.New(t)
+
+	convert(t, Geometry, nil, nil)
+	convert(t, Geometry, "POINT(1 2)", Point{1, 2})
+	convert(t, Geometry, Point{1, 2}, Point{1, 2})
+	convert(t, Geometry, append([]byte{0, 0, 0, 0}, Point{1, 2}.WKB()...), Point{1, 2})
+	convertErr(t, Geometry, "POINT(1)")
+	convertErr(t, Geometry, []byte{0, 0})
+	convertErr(t, Geometry, 1)
+
+	eq(t, Geometry, "POINT(1 2)", Point{1, 2})
+	lt(t, Geometry, Point{1, 2}, LineString{[]Point{{0, 0}, {1, 1}}})
+
+	v, err := Geometry.SQL(Point{1, 2})
+	require.NoError(err)
+	require.Equal(sqltypes.Geometry, v.Type())
+	require.Equal(append([]byte{0, 0, 0, 0}, Point{1, 2}.WKB()...), v.Raw())
+
+	require.Equal(sqltypes.NULL, mustSQL(Geometry.SQL(nil)))
+}
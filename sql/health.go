@@ -0,0 +1,10 @@
+package sql
+
+// HealthChecker is implemented by the databases whose backends can be
+// checked, such as the ones reading from remote services, so the status of
+// the engine reports whether they can be reached.
+type HealthChecker interface {
+	// CheckHealth returns an error if the backend of the database cannot
+	// be used. It must return once the context is done.
+	CheckHealth(ctx *Context) error
+}
@@ -0,0 +1,121 @@
+package sql
+
+import "strings"
+
+// QueryHints are the optimizer hints of a query, given in the comment that
+// follows its SELECT keyword, as in "SELECT /*+ JOIN_ORDER(b, a) */ ...".
+// They override the decisions of the analyzer when it picks a bad strategy
+// for the query.
+type QueryHints struct {
+	// JoinOrder contains the tables, by name or alias, in the order in which
+	// they must be joined.
+	JoinOrder []string
+	// NoIndex contains the indexes that must not be used for each table. No
+	// index of a table is used if its list is empty.
+	NoIndex map[string][]string
+	// UseIndex contains the only indexes that can be used for each table.
+	UseIndex map[string][]string
+	// ForceIndex contains the tables whose allowed indexes are used even if
+	// scanning the table is estimated to be cheaper.
+	ForceIndex map[string]bool
+	// Parallelism is the number of partitions of the tables iterated
+	// concurrently, or zero if it's not set.
+	Parallelism int
+}
+
+// IndexHintType is the type of an index hint.
+type IndexHintType byte
+
+const (
+	// UseIndexHint only allows using the given indexes.
+	UseIndexHint IndexHintType = iota
+	// ForceIndexHint only allows using the given indexes, and uses them
+	// even if scanning the table is estimated to be cheaper.
+	ForceIndexHint
+	// IgnoreIndexHint does not allow using the given indexes.
+	IgnoreIndexHint
+)
+
+// IndexHint is an index hint of a table in the FROM clause of a query, as
+// in "FROM t USE INDEX (idx)".
+type IndexHint struct {
+	Type    IndexHintType
+	Indexes []string
+}
+
+// AddIndexHint adds the given index hint of the table to the hints. An
+// IGNORE hint without indexes does not allow using any index of the table,
+// and neither does a USE hint without indexes. A FORCE hint without indexes
+// is ignored.
+func (h *QueryHints) AddIndexHint(table string, hint IndexHint) {
+	if len(hint.Indexes) == 0 {
+		if hint.Type == ForceIndexHint {
+			return
+		}
+
+		if h.NoIndex == nil {
+			h.NoIndex = make(map[string][]string)
+		}
+		h.NoIndex[table] = []string{}
+		return
+	}
+
+	switch hint.Type {
+	case IgnoreIndexHint:
+		if h.NoIndex == nil {
+			h.NoIndex = make(map[string][]string)
+		}
+
+		names, ok := h.NoIndex[table]
+		if !ok || len(names) > 0 {
+			h.NoIndex[table] = append(names, hint.Indexes...)
+		}
+	case UseIndexHint, ForceIndexHint:
+		if h.UseIndex == nil {
+			h.UseIndex = make(map[string][]string)
+		}
+		h.UseIndex[table] = append(h.UseIndex[table], hint.Indexes...)
+
+		if hint.Type == ForceIndexHint {
+			if h.ForceIndex == nil {
+				h.ForceIndex = make(map[string]bool)
+			}
+			h.ForceIndex[table] = true
+		}
+	}
+}
+
+// IndexForced returns whether the allowed indexes of the given table must
+// be used even if scanning the table is estimated to be cheaper.
+func (h *QueryHints) IndexForced(table string) bool {
+	return h != nil && h.ForceIndex[table]
+}
+
+// IndexAllowed returns whether the hints allow using the index with the
+// given id on the given table.
+func (h *QueryHints) IndexAllowed(table, index string) bool {
+	if h == nil {
+		return true
+	}
+
+	if names, ok := h.NoIndex[table]; ok {
+		if len(names) == 0 || containsName(names, index) {
+			return false
+		}
+	}
+
+	if names, ok := h.UseIndex[table]; ok {
+		return containsName(names, index)
+	}
+
+	return true
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
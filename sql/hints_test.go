@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryHintsIndexAllowed(t *testing.T) {
+	hints := &QueryHints{
+		NoIndex: map[string][]string{
+			"a": {},
+			"b": {"idx1"},
+		},
+		UseIndex: map[string][]string{
+			"c": {"idx1", "idx2"},
+		},
+	}
+
+	testCases := []struct {
+		hints    *QueryHints
+		table    string
+		index    string
+		expected bool
+	}{
+		{hints, "a", "idx1", false},
+		{hints, "b", "idx1", false},
+		{hints, "b", "IDX1", false},
+		{hints, "b", "idx2", true},
+		{hints, "c", "idx2", true},
+		{hints, "c", "idx3", false},
+		{hints, "d", "idx1", true},
+		{nil, "a", "idx1", true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.table+"."+tt.index, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.hints.IndexAllowed(tt.table, tt.index))
+		})
+	}
+}
+
+func TestQueryHintsAddIndexHint(t *testing.T) {
+	require := require.New(t)
+
+	hints := new(QueryHints)
+	hints.AddIndexHint("a", IndexHint{Type: IgnoreIndexHint, Indexes: []string{"idx1"}})
+	hints.AddIndexHint("a", IndexHint{Type: IgnoreIndexHint, Indexes: []string{"idx2"}})
+	hints.AddIndexHint("b", IndexHint{Type: IgnoreIndexHint})
+	hints.AddIndexHint("b", IndexHint{Type: IgnoreIndexHint, Indexes: []string{"idx1"}})
+	hints.AddIndexHint("c", IndexHint{Type: UseIndexHint, Indexes: []string{"idx1"}})
+	hints.AddIndexHint("d", IndexHint{Type: ForceIndexHint, Indexes: []string{"idx2"}})
+	hints.AddIndexHint("e", IndexHint{Type: ForceIndexHint})
+	hints.AddIndexHint("f", IndexHint{Type: UseIndexHint})
+
+	require.Equal(&QueryHints{
+		NoIndex: map[string][]string{
+			"a": {"idx1", "idx2"},
+			"b": {},
+			"f": {},
+		},
+		UseIndex: map[string][]string{
+			"c": {"idx1"},
+			"d": {"idx2"},
+		},
+		ForceIndex: map[string]bool{"d": true},
+	}, hints)
+
+	require.False(hints.IndexForced("c"))
+	require.True(hints.IndexForced("d"))
+	require.False((*QueryHints)(nil).IndexForced("d"))
+}
@@ -1,9 +1,11 @@
 package sql
 
 import (
+	"fmt"
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/src-d/go-mysql-server/internal/similartext"
 
@@ -153,6 +155,58 @@ type IndexDriver interface {
 	Delete(Index, PartitionIter) error
 }
 
+// IndexDriverFactory creates an index driver that keeps the data of its
+// indexes under the given root directory, the one of the index registry.
+type IndexDriverFactory func(root string) (IndexDriver, error)
+
+var indexDriverFactories = struct {
+	sync.RWMutex
+	factories map[string]IndexDriverFactory
+}{factories: make(map[string]IndexDriverFactory)}
+
+// RegisterIndexDriverFactory makes the index driver with the given ID
+// available to all the index registries, which create it the first time an
+// index uses it, such as with CREATE INDEX ... USING id, or when indexes are
+// loaded. It's meant to be called by the init functions of the packages of
+// index drivers, so they are plugged in just by importing them.
+func RegisterIndexDriverFactory(id string, factory IndexDriverFactory) {
+	indexDriverFactories.Lock()
+	defer indexDriverFactories.Unlock()
+	indexDriverFactories.factories[id] = factory
+}
+
+func indexDriverFactory(id string) IndexDriverFactory {
+	indexDriverFactories.RLock()
+	defer indexDriverFactories.RUnlock()
+	return indexDriverFactories.factories[id]
+}
+
+func indexDriverFactoryIDs() []string {
+	indexDriverFactories.RLock()
+	defer indexDriverFactories.RUnlock()
+
+	var ids []string
+	for id := range indexDriverFactories.factories {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// IndexProgress is the progress of the creation of an index.
+type IndexProgress struct {
+	// Rows is the number of rows indexed so far.
+	Rows int64
+	// Total is the number of rows of the table, or -1 if it's unknown.
+	Total int64
+}
+
+func (p IndexProgress) String() string {
+	if p.Total < 0 {
+		return fmt.Sprintf("%d rows indexed", p.Rows)
+	}
+	return fmt.Sprintf("%d of %d rows indexed", p.Rows, p.Total)
+}
+
 type indexKey struct {
 	db, id string
 }
@@ -166,6 +220,7 @@ type IndexRegistry struct {
 	indexes    map[indexKey]Index
 	indexOrder []indexKey
 	statuses   map[indexKey]IndexStatus
+	progress   map[indexKey]IndexProgress
 
 	driversMut sync.RWMutex
 	drivers    map[string]IndexDriver
@@ -173,6 +228,9 @@ type IndexRegistry struct {
 	rcmut            sync.RWMutex
 	refCounts        map[indexKey]int
 	deleteIndexQueue map[indexKey]chan<- struct{}
+
+	// changes is incremented every time the indexes that can be used change.
+	changes uint64
 }
 
 // NewIndexRegistry returns a new Index Registry.
@@ -180,6 +238,7 @@ func NewIndexRegistry() *IndexRegistry {
 	return &IndexRegistry{
 		indexes:          make(map[indexKey]Index),
 		statuses:         make(map[indexKey]IndexStatus),
+		progress:         make(map[indexKey]IndexProgress),
 		drivers:          make(map[string]IndexDriver),
 		refCounts:        make(map[indexKey]int),
 		deleteIndexQueue: make(map[indexKey]chan<- struct{}),
@@ -193,6 +252,34 @@ func (r *IndexRegistry) IndexDriver(id string) IndexDriver {
 	return r.drivers[id]
 }
 
+// LoadIndexDriver returns the IndexDriver with the given ID, creating it
+// with its factory if it's not registered yet. It returns nil if there is
+// no driver nor factory with that ID.
+func (r *IndexRegistry) LoadIndexDriver(id string) (IndexDriver, error) {
+	if d := r.IndexDriver(id); d != nil {
+		return d, nil
+	}
+
+	factory := indexDriverFactory(id)
+	if factory == nil {
+		return nil, nil
+	}
+
+	r.driversMut.Lock()
+	defer r.driversMut.Unlock()
+	if d, ok := r.drivers[id]; ok {
+		return d, nil
+	}
+
+	d, err := factory(r.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	r.drivers[id] = d
+	return d, nil
+}
+
 // DefaultIndexDriver returns the default index driver, which is the only
 // driver when there is 1 driver in the registry. If there are more than
 // 1 drivers in the registry, this will return the empty string, as there
@@ -215,8 +302,15 @@ func (r *IndexRegistry) RegisterIndexDriver(driver IndexDriver) {
 	r.drivers[driver.ID()] = driver
 }
 
-// LoadIndexes loads all indexes for all dbs, tables and drivers.
+// LoadIndexes loads all indexes for all dbs, tables and drivers, including
+// the ones with a factory that were not created yet.
 func (r *IndexRegistry) LoadIndexes(dbs Databases) error {
+	for _, id := range indexDriverFactoryIDs() {
+		if _, err := r.LoadIndexDriver(id); err != nil {
+			return err
+		}
+	}
+
 	r.driversMut.RLock()
 	defer r.driversMut.RUnlock()
 	r.mut.Lock()
@@ -253,6 +347,7 @@ func (r *IndexRegistry) LoadIndexes(dbs Databases) error {
 
 					if checksum == "" || checksum == idxChecksum {
 						r.statuses[k] = IndexReady
+						atomic.AddUint64(&r.changes, 1)
 					} else {
 						logrus.Warnf(
 							"index %q is outdated and will not be used, you can remove it using `DROP INDEX %s ON %s`",
@@ -273,7 +368,7 @@ func (r *IndexRegistry) LoadIndexes(dbs Databases) error {
 // MarkOutdated sets the index status as outdated. This method is not thread
 // safe and should not be used directly except for testing.
 func (r *IndexRegistry) MarkOutdated(idx Index) {
-	r.statuses[indexKey{idx.Database(), idx.ID()}] = IndexOutdated
+	r.setStatus(idx, IndexOutdated)
 }
 
 func (r *IndexRegistry) retainIndex(db, id string) {
@@ -302,6 +397,21 @@ func (r *IndexRegistry) CanRemoveIndex(idx Index) bool {
 	return status == IndexReady || status == IndexOutdated
 }
 
+// NotReadyIndexes returns the number of indexes that cannot be used yet,
+// as they are being created or deleted.
+func (r *IndexRegistry) NotReadyIndexes() int {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	var n int
+	for k := range r.indexes {
+		if r.statuses[k] == IndexNotReady {
+			n++
+		}
+	}
+	return n
+}
+
 func (r *IndexRegistry) canUseIndex(idx Index) bool {
 	if idx == nil {
 		return false
@@ -312,6 +422,7 @@ func (r *IndexRegistry) canUseIndex(idx Index) bool {
 // setStatus is not thread-safe, it should be guarded using mut.
 func (r *IndexRegistry) setStatus(idx Index, status IndexStatus) {
 	r.statuses[indexKey{idx.Database(), idx.ID()}] = status
+	atomic.AddUint64(&r.changes, 1)
 }
 
 // ReleaseIndex releases an index after it's been used.
@@ -525,6 +636,7 @@ func (r *IndexRegistry) AddIndex(
 	r.setStatus(idx, IndexNotReady)
 	key := indexKey{idx.Database(), idx.ID()}
 	r.indexes[key] = idx
+	r.progress[key] = IndexProgress{Total: -1}
 	r.indexOrder = append(r.indexOrder, key)
 	r.mut.Unlock()
 
@@ -535,6 +647,7 @@ func (r *IndexRegistry) AddIndex(
 		r.mut.Lock()
 		defer r.mut.Unlock()
 		r.setStatus(idx, IndexReady)
+		delete(r.progress, key)
 		close(_ready)
 	}()
 
@@ -586,6 +699,7 @@ func (r *IndexRegistry) DeleteIndex(db, id string, force bool) (<-chan struct{},
 		defer r.rcmut.Unlock()
 
 		delete(r.indexes, key)
+		delete(r.progress, key)
 		var pos = -1
 		for i, k := range r.indexOrder {
 			if k == key {
@@ -609,6 +723,7 @@ func (r *IndexRegistry) DeleteIndex(db, id string, force bool) (<-chan struct{},
 		r.mut.Lock()
 		defer r.mut.Unlock()
 		delete(r.indexes, key)
+		delete(r.progress, key)
 
 		done <- struct{}{}
 	}()
@@ -616,6 +731,27 @@ func (r *IndexRegistry) DeleteIndex(db, id string, force bool) (<-chan struct{},
 	return done, nil
 }
 
+// IndexProgress returns the progress of the creation of the given index,
+// and whether it's being created.
+func (r *IndexRegistry) IndexProgress(idx Index) (IndexProgress, bool) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	p, ok := r.progress[indexKey{idx.Database(), idx.ID()}]
+	return p, ok
+}
+
+// SetIndexProgress updates the progress of the creation of the given index,
+// which is shown by SHOW INDEX until it's ready. It does nothing if the
+// index is not being created.
+func (r *IndexRegistry) SetIndexProgress(idx Index, progress IndexProgress) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	key := indexKey{idx.Database(), idx.ID()}
+	if _, ok := r.progress[key]; ok {
+		r.progress[key] = progress
+	}
+}
+
 // IndexStatus represents the current status in which the index is.
 type IndexStatus byte
 
@@ -118,11 +118,13 @@ func TestAddIndex(t *testing.T) {
 	i := r.Index("foo", "foo")
 	require.False(r.CanUseIndex(i))
 
+	changes := r.changes
 	done <- struct{}{}
 
 	<-ready
 	i = r.Index("foo", "foo")
 	require.True(r.CanUseIndex(i))
+	require.True(r.changes > changes)
 
 	_, _, err = r.AddIndex(idx)
 	require.Error(err)
@@ -333,6 +335,89 @@ func TestLoadOutdatedIndexes(t *testing.T) {
 	require.Equal(registry.statuses[indexKey{"db1", "idx2"}], IndexOutdated)
 }
 
+func TestLoadIndexDriver(t *testing.T) {
+	require := require.New(t)
+
+	var roots []string
+	RegisterIndexDriverFactory("test-factory", func(root string) (IndexDriver, error) {
+		roots = append(roots, root)
+		return &loadDriver{id: "test-factory", indexes: []Index{
+			&dummyIdx{id: "idx1", database: "db1", table: "t1"},
+		}}, nil
+	})
+	RegisterIndexDriverFactory("test-failing-factory", func(string) (IndexDriver, error) {
+		return nil, fmt.Errorf("cannot create driver")
+	})
+	defer func() {
+		indexDriverFactories.Lock()
+		delete(indexDriverFactories.factories, "test-factory")
+		delete(indexDriverFactories.factories, "test-failing-factory")
+		indexDriverFactories.Unlock()
+	}()
+
+	registry := NewIndexRegistry()
+	registry.Root = "/indexes"
+	require.Nil(registry.IndexDriver("test-factory"))
+
+	d, err := registry.LoadIndexDriver("test-factory")
+	require.NoError(err)
+	require.Equal("test-factory", d.ID())
+	require.Equal(d, registry.IndexDriver("test-factory"))
+
+	d, err = registry.LoadIndexDriver("test-factory")
+	require.NoError(err)
+	require.Equal("test-factory", d.ID())
+	require.Equal([]string{"/indexes"}, roots)
+
+	d, err = registry.LoadIndexDriver("unknown")
+	require.NoError(err)
+	require.Nil(d)
+
+	_, err = registry.LoadIndexDriver("test-failing-factory")
+	require.Error(err)
+	require.Error(NewIndexRegistry().LoadIndexes(nil))
+
+	indexDriverFactories.Lock()
+	delete(indexDriverFactories.factories, "test-failing-factory")
+	indexDriverFactories.Unlock()
+
+	registry = NewIndexRegistry()
+	require.NoError(registry.LoadIndexes(Databases{
+		dummyDB{name: "db1", tables: map[string]Table{"t1": &dummyTable{name: "t1"}}},
+	}))
+	require.NotNil(registry.Index("db1", "idx1"))
+}
+
+func TestIndexProgress(t *testing.T) {
+	require := require.New(t)
+
+	registry := NewIndexRegistry()
+	idx := &dummyIdx{id: "idx", database: "db", table: "t"}
+
+	registry.SetIndexProgress(idx, IndexProgress{Rows: 1})
+	_, ok := registry.IndexProgress(idx)
+	require.False(ok)
+
+	created, ready, err := registry.AddIndex(idx)
+	require.NoError(err)
+
+	progress, ok := registry.IndexProgress(idx)
+	require.True(ok)
+	require.Equal(IndexProgress{Total: -1}, progress)
+	require.Equal("0 rows indexed", progress.String())
+
+	registry.SetIndexProgress(idx, IndexProgress{Rows: 10, Total: 20})
+	progress, ok = registry.IndexProgress(idx)
+	require.True(ok)
+	require.Equal("10 of 20 rows indexed", progress.String())
+
+	close(created)
+	<-ready
+
+	_, ok = registry.IndexProgress(idx)
+	require.False(ok)
+}
+
 type dummyDB struct {
 	name   string
 	tables map[string]Table
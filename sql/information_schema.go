@@ -201,9 +201,10 @@ func columnsRowIter(cat *Catalog) RowIter {
 		for _, t := range db.Tables() {
 			for i, c := range t.Schema() {
 				var (
-					nullable string
-					charName interface{}
-					collName interface{}
+					nullable  string
+					charName  interface{}
+					collName  interface{}
+					precision interface{}
 				)
 				if c.Nullable {
 					nullable = "YES"
@@ -214,6 +215,9 @@ func columnsRowIter(cat *Catalog) RowIter {
 					charName = "utf8mb4"
 					collName = "utf8_bin"
 				}
+				if IsTimestamp(c.Type) || IsDatetime(c.Type) || IsTimeOfDay(c.Type) {
+					precision = uint64(Precision(c.Type))
+				}
 				rows = append(rows, Row{
 					"def",                                  // table_catalog
 					db.Name(),                              // table_schema
@@ -227,7 +231,7 @@ func columnsRowIter(cat *Catalog) RowIter {
 					nil,                                    // character_octet_length
 					nil,                                    // numeric_precision
 					nil,                                    // numeric_scale
-					nil,                                    // datetime_precision
+					precision,                              // datetime_precision
 					charName,                               // character_set_name
 					collName,                               // collation_name
 					strings.ToLower(MySQLTypeName(c.Type)), // column_type
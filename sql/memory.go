@@ -1,11 +1,15 @@
 package sql
 
 import (
+	"math"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/sirupsen/logrus"
 	errors "gopkg.in/src-d/go-errors.v1"
 )
 
@@ -99,14 +103,35 @@ func HasAvailableMemory(r Reporter) bool {
 	return r.UsedMemory() < maxMemory
 }
 
+// AvailableMemory returns the number of bytes that can still be used before
+// reaching the max memory limit. If there is no limit, math.MaxUint64 is
+// returned.
+func AvailableMemory(r Reporter) uint64 {
+	maxMemory := r.MaxMemory()
+	if maxMemory == 0 {
+		return math.MaxUint64
+	}
+
+	used := r.UsedMemory()
+	if used >= maxMemory {
+		return 0
+	}
+
+	return maxMemory - used
+}
+
 // MemoryManager is in charge of keeping track and managing all the components that operate
 // in memory. There should only be one instance of a memory manager running at the
 // same time in each process.
 type MemoryManager struct {
-	mu       sync.RWMutex
-	reporter Reporter
-	caches   map[uint64]Disposable
-	token    uint64
+	// reserved is the number of bytes reserved by the queries running. It's
+	// the first field so it's aligned for atomic operations.
+	reserved    uint64
+	maxReserved uint64
+	mu          sync.RWMutex
+	reporter    Reporter
+	caches      map[uint64]Disposable
+	token       uint64
 }
 
 // NewMemoryManager creates a new manager with the given memory reporter. If nil is given,
@@ -117,8 +142,9 @@ func NewMemoryManager(r Reporter) *MemoryManager {
 	}
 
 	return &MemoryManager{
-		reporter: r,
-		caches:   make(map[uint64]Disposable),
+		maxReserved: maxServerQueryMemory,
+		reporter:    r,
+		caches:      make(map[uint64]Disposable),
 	}
 }
 
@@ -127,6 +153,11 @@ func (m *MemoryManager) HasAvailable() bool {
 	return HasAvailableMemory(m.reporter)
 }
 
+// Available returns the number of bytes the memory manager can still use.
+func (m *MemoryManager) Available() uint64 {
+	return AvailableMemory(m.reporter)
+}
+
 // DisposeFunc is a function to completely erase a cache and remove it from the manager.
 type DisposeFunc func()
 
@@ -192,3 +223,182 @@ func (m *MemoryManager) Free() {
 		}
 	}
 }
+
+// ErrQueryMemoryExceeded is returned when the memory reserved by the
+// operators of a query exceeds the maximum memory allowed for a query.
+var ErrQueryMemoryExceeded = errors.NewKind("query exceeded its maximum memory of %d bytes")
+
+// IsMemoryExceeded reports whether the error was caused by a query or the
+// server running out of memory, in which case the operators that can write
+// their rows to disk do so instead of failing.
+func IsMemoryExceeded(err error) bool {
+	return ErrNoMemoryAvailable.Is(err) || ErrQueryMemoryExceeded.Is(err)
+}
+
+const (
+	maxQueryMemoryKey        = "MAX_QUERY_MEMORY"
+	maxQueryMemorySessionVar = "max_query_memory"
+	maxServerQueryMemoryKey  = "MAX_SERVER_QUERY_MEMORY"
+)
+
+// bytesByEnv returns the number of bytes set in the given environment
+// variable, or zero if it's not set or it's not a number.
+func bytesByEnv(key string) uint64 {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return 0
+	}
+
+	v, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		logrus.Warnf("%s environment variable must be a number, but got: %s", key, val)
+		return 0
+	}
+	return v
+}
+
+// maxQueryMemory is the default maximum number of bytes that the operators
+// of a query can reserve. Zero means there is no limit.
+var maxQueryMemory = bytesByEnv(maxQueryMemoryKey)
+
+// maxServerQueryMemory is the maximum number of bytes that the operators of
+// all the queries running at the same time can reserve. Zero means there is
+// no limit.
+var maxServerQueryMemory = bytesByEnv(maxServerQueryMemoryKey)
+
+// queryMemoryLimit returns the maximum memory of the queries of the given
+// session, which is set in the max_query_memory session variable or,
+// otherwise, in the MAX_QUERY_MEMORY environment variable.
+func queryMemoryLimit(s Session) uint64 {
+	_, val := s.Get(maxQueryMemorySessionVar)
+	if val == nil {
+		return maxQueryMemory
+	}
+
+	n, err := Int64.Convert(val)
+	if err != nil || n.(int64) < 0 {
+		return maxQueryMemory
+	}
+	return uint64(n.(int64))
+}
+
+// Reserved returns the number of bytes reserved by the queries running.
+func (m *MemoryManager) Reserved() uint64 {
+	return atomic.LoadUint64(&m.reserved)
+}
+
+// NewQueryMemory returns the memory accounting of a query that can reserve
+// up to max bytes, or with no limit if max is zero. The memory reserved by
+// the query also counts towards the limit of all the queries of the server.
+func (m *MemoryManager) NewQueryMemory(max uint64) *QueryMemory {
+	return &QueryMemory{manager: m, max: max}
+}
+
+func (m *MemoryManager) reserve(n uint64) error {
+	for {
+		reserved := atomic.LoadUint64(&m.reserved)
+		if m.maxReserved > 0 && reserved+n > m.maxReserved {
+			return ErrNoMemoryAvailable.New()
+		}
+
+		if atomic.CompareAndSwapUint64(&m.reserved, reserved, reserved+n) {
+			return nil
+		}
+	}
+}
+
+func (m *MemoryManager) release(n uint64) {
+	atomic.AddUint64(&m.reserved, ^(n - 1))
+}
+
+// QueryMemory keeps track of the memory used by the operators of a query
+// that keep rows in memory, such as sorts, joins, groupings and distincts.
+// Operators reserve the estimated size of the rows they keep before keeping
+// them, and release it once they no longer need them.
+type QueryMemory struct {
+	// used is the first field so it's aligned for atomic operations.
+	used    uint64
+	manager *MemoryManager
+	max     uint64
+}
+
+// Max returns the maximum number of bytes the query can reserve, or zero if
+// there is no limit.
+func (q *QueryMemory) Max() uint64 { return q.max }
+
+// Used returns the number of bytes reserved by the query.
+func (q *QueryMemory) Used() uint64 { return atomic.LoadUint64(&q.used) }
+
+// Reserve the given number of bytes. ErrQueryMemoryExceeded is returned if
+// the query would exceed its maximum memory, and ErrNoMemoryAvailable if
+// the server would exceed the maximum memory of all its queries. Nothing is
+// reserved in that case.
+func (q *QueryMemory) Reserve(n uint64) error {
+	for {
+		used := atomic.LoadUint64(&q.used)
+		if q.max > 0 && used+n > q.max {
+			return ErrQueryMemoryExceeded.New(q.max)
+		}
+
+		if atomic.CompareAndSwapUint64(&q.used, used, used+n) {
+			break
+		}
+	}
+
+	if err := q.manager.reserve(n); err != nil {
+		atomic.AddUint64(&q.used, ^(n - 1))
+		return err
+	}
+	return nil
+}
+
+// Release the given number of bytes previously reserved.
+func (q *QueryMemory) Release(n uint64) {
+	if n == 0 {
+		return
+	}
+
+	atomic.AddUint64(&q.used, ^(n - 1))
+	q.manager.release(n)
+}
+
+// NewAccount returns an account to keep track of the memory reserved by a
+// single operator of the query.
+func (q *QueryMemory) NewAccount() *MemoryAccount {
+	return &MemoryAccount{query: q}
+}
+
+// MemoryAccount is the memory reserved by an operator of a query, so it can
+// be released all at once when the operator no longer needs it.
+type MemoryAccount struct {
+	query    *QueryMemory
+	reserved uint64
+}
+
+// Reserve the given number of bytes for the operator. See
+// QueryMemory.Reserve.
+func (a *MemoryAccount) Reserve(n uint64) error {
+	if err := a.query.Reserve(n); err != nil {
+		return err
+	}
+	a.reserved += n
+	return nil
+}
+
+// Release the given number of bytes reserved by the operator.
+func (a *MemoryAccount) Release(n uint64) {
+	if n > a.reserved {
+		n = a.reserved
+	}
+	a.query.Release(n)
+	a.reserved -= n
+}
+
+// Reserved returns the number of bytes reserved by the operator.
+func (a *MemoryAccount) Reserved() uint64 { return a.reserved }
+
+// ReleaseAll releases all the memory reserved by the operator.
+func (a *MemoryAccount) ReleaseAll() {
+	a.query.Release(a.reserved)
+	a.reserved = 0
+}
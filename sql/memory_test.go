@@ -1,6 +1,9 @@
 package sql
 
 import (
+	"context"
+	"math"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -54,6 +57,12 @@ func TestHasAvailable(t *testing.T) {
 	require.False(t, HasAvailableMemory(fixedReporter(6, 5)))
 }
 
+func TestAvailableMemory(t *testing.T) {
+	require.Equal(t, uint64(3), AvailableMemory(fixedReporter(2, 5)))
+	require.Equal(t, uint64(0), AvailableMemory(fixedReporter(6, 5)))
+	require.Equal(t, uint64(math.MaxUint64), AvailableMemory(fixedReporter(6, 0)))
+}
+
 type mockReporter struct {
 	f   func() uint64
 	max uint64
@@ -77,3 +86,74 @@ func (m mockMemory) Free() {
 		m.f()
 	}
 }
+
+func TestQueryMemory(t *testing.T) {
+	require := require.New(t)
+
+	m := NewMemoryManager(nil)
+	m.maxReserved = 100
+
+	q1 := m.NewQueryMemory(60)
+	q2 := m.NewQueryMemory(0)
+	require.Equal(uint64(60), q1.Max())
+
+	a := q1.NewAccount()
+	require.NoError(a.Reserve(40))
+	require.NoError(a.Reserve(20))
+
+	err := a.Reserve(1)
+	require.Error(err)
+	require.True(ErrQueryMemoryExceeded.Is(err))
+	require.True(IsMemoryExceeded(err))
+	require.Equal(uint64(60), a.Reserved())
+
+	b := q2.NewAccount()
+	require.NoError(b.Reserve(30))
+
+	err = b.Reserve(20)
+	require.Error(err)
+	require.True(ErrNoMemoryAvailable.Is(err))
+	require.Equal(uint64(30), q2.Used())
+	require.Equal(uint64(90), m.Reserved())
+
+	a.Release(20)
+	require.Equal(uint64(40), q1.Used())
+	require.NoError(b.Reserve(20))
+
+	a.ReleaseAll()
+	b.ReleaseAll()
+	require.Equal(uint64(0), q1.Used())
+	require.Equal(uint64(0), q2.Used())
+	require.Equal(uint64(0), m.Reserved())
+}
+
+func TestQueryMemoryLimit(t *testing.T) {
+	require := require.New(t)
+
+	s := NewBaseSession()
+	require.Equal(maxQueryMemory, queryMemoryLimit(s))
+
+	s.Set(maxQueryMemorySessionVar, Int64, int64(1024))
+	require.Equal(uint64(1024), queryMemoryLimit(s))
+
+	ctx := NewContext(context.Background(), WithSession(s))
+	require.Equal(uint64(1024), ctx.QueryMemory().Max())
+
+	_, child := ctx.Span("foo")
+	require.True(ctx.QueryMemory() == child.QueryMemory())
+}
+
+func TestBytesByEnv(t *testing.T) {
+	require := require.New(t)
+
+	const key = "TEST_MAX_QUERY_MEMORY"
+	defer os.Unsetenv(key)
+
+	require.Equal(uint64(0), bytesByEnv(key))
+
+	os.Setenv(key, "2048")
+	require.Equal(uint64(2048), bytesByEnv(key))
+
+	os.Setenv(key, "2GB")
+	require.Equal(uint64(0), bytesByEnv(key))
+}
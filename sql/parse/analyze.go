@@ -0,0 +1,97 @@
+package parse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+func parseAnalyzeTable(ctx *sql.Context, query string) (sql.Node, error) {
+	var r = bufio.NewReader(strings.NewReader(query))
+	var tables []*plan.UnresolvedTable
+	err := parseFuncs{
+		expect("analyze"),
+		skipSpaces,
+		maybeReadAnalyzeModifier,
+		skipSpaces,
+		expect("table"),
+		skipSpaces,
+		readAnalyzeTables(&tables),
+		skipSpaces,
+		checkEOF,
+	}.exec(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewAnalyzeTable(tables...), nil
+}
+
+// maybeReadAnalyzeModifier skips the NO_WRITE_TO_BINLOG or LOCAL modifiers,
+// which have no effect because there is no binary log.
+func maybeReadAnalyzeModifier(rd *bufio.Reader) error {
+	var ident string
+	if err := readIdent(&ident)(rd); err != nil {
+		return err
+	}
+
+	switch ident {
+	case "no_write_to_binlog", "local":
+		return nil
+	default:
+		unreadString(rd, ident)
+		return nil
+	}
+}
+
+func readAnalyzeTables(tables *[]*plan.UnresolvedTable) parseFunc {
+	return func(rd *bufio.Reader) error {
+		for {
+			var db, name string
+			if err := readQuotableIdent(&name)(rd); err != nil {
+				return err
+			}
+
+			b, err := rd.Peek(1)
+			if err == nil && string(b) == "." {
+				if _, err := rd.Discard(1); err != nil {
+					return err
+				}
+
+				db = name
+				if err := readQuotableIdent(&name)(rd); err != nil {
+					return err
+				}
+			}
+
+			*tables = append(*tables, plan.NewUnresolvedTable(name, db))
+
+			if err := skipSpaces(rd); err != nil {
+				return err
+			}
+
+			b, err = rd.Peek(1)
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if string(b) != "," {
+				return nil
+			}
+
+			if _, err := rd.Discard(1); err != nil {
+				return err
+			}
+
+			if err := skipSpaces(rd); err != nil {
+				return err
+			}
+		}
+	}
+}
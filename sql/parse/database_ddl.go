@@ -0,0 +1,93 @@
+package parse
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+func parseCreateDatabase(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var name, options string
+	var ifNotExists bool
+	err := parseFuncs{
+		expect("create"),
+		skipSpaces,
+		oneOf("database", "schema"),
+		skipSpaces,
+		readIfExists(&ifNotExists, "not"),
+		readQuotableIdent(&name),
+		skipSpaces,
+		readRemaining(&options),
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkDatabaseOptions(options); err != nil {
+		return nil, err
+	}
+
+	return plan.NewCreateDatabase(name, ifNotExists), nil
+}
+
+func parseDropDatabase(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var name string
+	var ifExists bool
+	err := parseFuncs{
+		expect("drop"),
+		skipSpaces,
+		oneOf("database", "schema"),
+		skipSpaces,
+		readIfExists(&ifExists),
+		readQuotableIdent(&name),
+		skipSpaces,
+		checkEOF,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewDropDatabase(name, ifExists), nil
+}
+
+// readIfExists reads an optional IF EXISTS clause, with the given words
+// between IF and EXISTS.
+func readIfExists(found *bool, words ...string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, "if")
+		if err != nil || ident == "" {
+			return err
+		}
+
+		var steps parseFuncs
+		for _, w := range append(words, "exists") {
+			steps = append(steps, expect(w), skipSpaces)
+		}
+
+		*found = true
+		return steps.exec(rd)
+	}
+}
+
+// checkDatabaseOptions checks the options after the name of a database,
+// which are only its character set and collation. Databases always have
+// the default ones, so they are ignored.
+func checkDatabaseOptions(options string) error {
+	words := strings.Fields(strings.ToLower(options))
+	if len(words) == 0 {
+		return nil
+	}
+
+	switch strings.SplitN(words[0], "=", 2)[0] {
+	case "default", "character", "charset", "collate":
+		return nil
+	default:
+		return errUnexpectedSyntax.New("character set or collation", words[0])
+	}
+}
@@ -0,0 +1,47 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDatabaseDDL(t *testing.T) {
+	testCases := []struct {
+		query  string
+		result sql.Node
+		err    bool
+	}{
+		{"CREATE DATABASE mydb", plan.NewCreateDatabase("mydb", false), false},
+		{"create schema if not exists `my``db`", plan.NewCreateDatabase("my`db", true), false},
+		{
+			"CREATE DATABASE IF NOT EXISTS `mydb` /*!40100 DEFAULT CHARACTER SET utf8mb4 */",
+			plan.NewCreateDatabase("mydb", true),
+			false,
+		},
+		{"CREATE DATABASE mydb CHARACTER SET = utf8mb4 COLLATE utf8mb4_bin", plan.NewCreateDatabase("mydb", false), false},
+		{"DROP DATABASE mydb", plan.NewDropDatabase("mydb", false), false},
+		{"DROP SCHEMA IF EXISTS `mydb`", plan.NewDropDatabase("mydb", true), false},
+		{"CREATE DATABASE IF EXISTS mydb", nil, true},
+		{"CREATE DATABASE mydb ENGINE = InnoDB", nil, true},
+		{"DROP DATABASE IF NOT EXISTS mydb", nil, true},
+		{"DROP DATABASE mydb, other", nil, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := Parse(sql.NewEmptyContext(), tt.query)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.result, result)
+		})
+	}
+}
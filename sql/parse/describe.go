@@ -11,7 +11,7 @@ import (
 
 var (
 	errInvalidDescribeFormat = errors.NewKind("invalid format %q for DESCRIBE, supported formats: %s")
-	describeSupportedFormats = []string{"tree"}
+	describeSupportedFormats = []string{"tree", "json"}
 )
 
 func parseDescribeQuery(ctx *sql.Context, s string) (sql.Node, error) {
@@ -34,7 +34,7 @@ func parseDescribeQuery(ctx *sql.Context, s string) (sql.Node, error) {
 		return nil, err
 	}
 
-	if format != "tree" {
+	if format != "tree" && format != "json" {
 		return nil, errInvalidDescribeFormat.New(
 			format,
 			strings.Join(describeSupportedFormats, ", "),
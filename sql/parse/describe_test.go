@@ -47,6 +47,14 @@ func TestParseDescribeQuery(t *testing.T) {
 			),
 			nil,
 		},
+		{
+			"EXPLAIN FORMAT=JSON SELECT * FROM foo",
+			plan.NewDescribeQuery("json", plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				plan.NewUnresolvedTable("foo", "")),
+			),
+			nil,
+		},
 		{
 			"EXPLAIN FORMAT=tree SELECT * FROM foo",
 			plan.NewDescribeQuery("tree", plan.NewProject(
@@ -0,0 +1,103 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// StatementParser parses a statement, given without comments or trailing
+// semicolon, into the node that runs it.
+type StatementParser func(ctx *sql.Context, query string) (sql.Node, error)
+
+// Statement is a custom statement, recognized by the words it starts with.
+type Statement struct {
+	// Prefix is the words the statement starts with, such as
+	// "REINDEX SOURCE", which are not case sensitive.
+	Prefix string
+	// Parse parses the statement.
+	Parse StatementParser
+}
+
+// Dialect extends the SQL parsed by the engine with custom statements, so
+// domain-specific commands can be used along with standard SQL. The
+// statements it returns are analyzed and run as the rest, so their nodes
+// must be resolved once their children are. The zero value, as well as a
+// nil Dialect, parses standard SQL only.
+type Dialect struct {
+	// Statements are parsed before the standard statements, so they can
+	// also replace them. When more than one has the prefix of a query,
+	// the first one parses it.
+	Statements []Statement
+	// Fallback, if set, parses the statements that could not be parsed
+	// otherwise. If it returns a nil node without error, the query fails
+	// with the error of the standard parser.
+	Fallback StatementParser
+}
+
+// Parse parses the given SQL sentence with the statements of the dialect
+// and returns the corresponding node.
+func (d *Dialect) Parse(ctx *sql.Context, query string) (sql.Node, error) {
+	span, ctx := ctx.Span("parse", opentracing.Tag{Key: "query", Value: query})
+	defer span.Finish()
+
+	s := strings.TrimSpace(removeComments(query))
+	if strings.HasSuffix(s, ";") {
+		s = s[:len(s)-1]
+	}
+
+	if s == "" {
+		ctx.Warn(0, "query was empty after trimming comments, so it will be ignored")
+		return plan.Nothing, nil
+	}
+
+	if d == nil {
+		return parse(ctx, s)
+	}
+
+	if stmt, ok := d.statement(s); ok {
+		return stmt.Parse(ctx, s)
+	}
+
+	node, err := parse(ctx, s)
+	if err != nil && d.Fallback != nil {
+		fallback, ferr := d.Fallback(ctx, s)
+		if ferr != nil {
+			return nil, ferr
+		}
+
+		if fallback != nil {
+			return fallback, nil
+		}
+	}
+
+	return node, err
+}
+
+// statement returns the first statement of the dialect with the prefix of
+// the given query.
+func (d *Dialect) statement(query string) (Statement, bool) {
+	words := strings.Fields(query)
+	for _, stmt := range d.Statements {
+		if hasPrefixWords(words, strings.Fields(stmt.Prefix)) {
+			return stmt, true
+		}
+	}
+	return Statement{}, false
+}
+
+func hasPrefixWords(words, prefix []string) bool {
+	if len(prefix) == 0 || len(prefix) > len(words) {
+		return false
+	}
+
+	for i, w := range prefix {
+		if !strings.EqualFold(words[i], w) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,91 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialect(t *testing.T) {
+	reindex := func(ctx *sql.Context, query string) (sql.Node, error) {
+		words := strings.Fields(query)
+		if len(words) != 3 {
+			return nil, fmt.Errorf("expected REINDEX SOURCE name")
+		}
+		return plan.NewUnresolvedTable(words[2], ""), nil
+	}
+
+	d := &Dialect{
+		Statements: []Statement{
+			{Prefix: "REINDEX SOURCE", Parse: reindex},
+			{Prefix: "reindex", Parse: func(*sql.Context, string) (sql.Node, error) {
+				return plan.NewUnresolvedTable("all", ""), nil
+			}},
+			{Prefix: "show tables", Parse: func(*sql.Context, string) (sql.Node, error) {
+				return plan.NewUnresolvedTable("tables", ""), nil
+			}},
+		},
+		Fallback: func(ctx *sql.Context, query string) (sql.Node, error) {
+			if strings.HasPrefix(strings.ToLower(query), "ping") {
+				return plan.NewUnresolvedTable("ping", ""), nil
+			}
+			return nil, nil
+		},
+	}
+
+	testCases := []struct {
+		query    string
+		expected sql.Node
+		err      bool
+	}{
+		{"reindex  source\tfoo;", plan.NewUnresolvedTable("foo", ""), false},
+		{"/* comment */ REINDEX SOURCE foo", plan.NewUnresolvedTable("foo", ""), false},
+		{"REINDEX SOURCE", nil, true},
+		{"REINDEX SOURCE foo bar", nil, true},
+		{"REINDEX", plan.NewUnresolvedTable("all", ""), false},
+		{"REINDEXES", nil, true},
+		{"SHOW TABLES", plan.NewUnresolvedTable("tables", ""), false},
+		{"PING server", plan.NewUnresolvedTable("ping", ""), false},
+		{"PONG server", nil, true},
+		{
+			"SELECT 1",
+			plan.NewProject(
+				[]sql.Expression{expression.NewLiteral(int8(1), sql.Int8)},
+				plan.NewUnresolvedTable("dual", ""),
+			),
+			false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+
+			node, err := d.Parse(sql.NewEmptyContext(), tt.query)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.expected, node)
+		})
+	}
+}
+
+func TestNilDialect(t *testing.T) {
+	require := require.New(t)
+
+	var d *Dialect
+	_, err := d.Parse(sql.NewEmptyContext(), "REINDEX SOURCE foo")
+	require.Error(err)
+
+	node, err := d.Parse(sql.NewEmptyContext(), "SHOW PROCESSLIST")
+	require.NoError(err)
+	require.Equal(plan.NewShowProcessList(), node)
+}
@@ -0,0 +1,113 @@
+package parse
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// parseDump parses a DUMP statement, which dumps a database, optionally
+// only some of its tables, or several databases, all of them if none are
+// given:
+//
+//	DUMP DATABASE db [TABLES t1, t2] INTO 'file'
+//	DUMP DATABASES [db1, db2] INTO 'file'
+func parseDump(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var kind, file string
+	var opts plan.DumpOptions
+	err := parseFuncs{
+		expect("dump"),
+		skipSpaces,
+		readIdent(&kind),
+		skipSpaces,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "database":
+		var db string
+		err = parseFuncs{
+			readQuotableIdent(&db),
+			skipSpaces,
+			readDumpTables(&opts.Tables),
+		}.exec(r)
+		opts.Databases = []string{db}
+	case "databases":
+		opts.CreateDatabases = true
+		err = readIdentList(&opts.Databases, "into")(r)
+	default:
+		err = errUnexpectedSyntax.New("database or databases", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = parseFuncs{
+		expect("into"),
+		skipSpaces,
+		readStringLiteral(&file),
+		skipSpaces,
+		checkEOF,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewDump(opts, file), nil
+}
+
+// readDumpTables reads the optional TABLES clause of a dump.
+func readDumpTables(tables *[]string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, "tables")
+		if err != nil || ident == "" {
+			return err
+		}
+
+		if err := readIdentList(tables, "into")(rd); err != nil {
+			return err
+		}
+
+		if len(*tables) == 0 {
+			return errUnexpectedSyntax.New("table", "into")
+		}
+		return nil
+	}
+}
+
+// readIdentList reads identifiers separated by commas, if the next one is
+// not the given keyword.
+func readIdentList(idents *[]string, end string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, end)
+		if err != nil {
+			return err
+		}
+		if ident != "" {
+			unreadString(rd, ident+" ")
+			return nil
+		}
+
+		for {
+			var ident string
+			err := parseFuncs{
+				readQuotableIdent(&ident),
+				skipSpaces,
+			}.exec(rd)
+			if err != nil {
+				return err
+			}
+			*idents = append(*idents, ident)
+
+			if !readComma(rd) {
+				return nil
+			}
+		}
+	}
+}
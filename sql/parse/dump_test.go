@@ -0,0 +1,59 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDump(t *testing.T) {
+	testCases := []struct {
+		query  string
+		result sql.Node
+		err    bool
+	}{
+		{
+			"DUMP DATABASE mydb INTO 'mydb.sql'",
+			plan.NewDump(plan.DumpOptions{Databases: []string{"mydb"}}, "mydb.sql"),
+			false,
+		},
+		{
+			"dump database `mydb` tables a, `b` into \"tables.sql\"",
+			plan.NewDump(plan.DumpOptions{Databases: []string{"mydb"}, Tables: []string{"a", "b"}}, "tables.sql"),
+			false,
+		},
+		{
+			"DUMP DATABASES INTO 'all.sql'",
+			plan.NewDump(plan.DumpOptions{CreateDatabases: true}, "all.sql"),
+			false,
+		},
+		{
+			"DUMP DATABASES a, b INTO 'ab.sql'",
+			plan.NewDump(plan.DumpOptions{Databases: []string{"a", "b"}, CreateDatabases: true}, "ab.sql"),
+			false,
+		},
+		{"DUMP DATABASE mydb", nil, true},
+		{"DUMP DATABASE INTO 'mydb.sql'", nil, true},
+		{"DUMP DATABASE mydb TABLES INTO 'mydb.sql'", nil, true},
+		{"DUMP DATABASES a, INTO 'ab.sql'", nil, true},
+		{"DUMP DATABASE mydb INTO mydb.sql", nil, true},
+		{"DUMP DATABASE mydb INTO 'mydb.sql' LIMIT 1", nil, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := Parse(sql.NewEmptyContext(), tt.query)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.result, result)
+		})
+	}
+}
@@ -0,0 +1,608 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// tableFunctionComment is the comment of the subqueries the calls to table
+// functions in the FROM clause are rewritten to, as vitess cannot parse
+// them.
+const tableFunctionComment = "/* table function */"
+
+// asOfComment is the comment of the subqueries the tables queried AS OF a
+// version are rewritten to, as vitess cannot parse them.
+const asOfComment = "/* as of */"
+
+// tableSampleComment is the comment of the subqueries the tables queried
+// with TABLESAMPLE are rewritten to, as vitess cannot parse them.
+const tableSampleComment = "/* tablesample */"
+
+// lateralComment is the comment added to the subqueries of LATERAL derived
+// tables, as vitess cannot parse the keyword.
+const lateralComment = "/* lateral */"
+
+// fromClauseEnd are the words that end the FROM clause of a query.
+var fromClauseEnd = map[string]bool{
+	"where": true, "group": true, "having": true, "order": true,
+	"limit": true, "union": true, "into": true, "procedure": true,
+	"for": true, "lock": true, "window": true, "select": true,
+}
+
+// notTableFunctions are the words that can be followed by a parenthesis
+// in the FROM clause without being the name of a table function.
+var notTableFunctions = map[string]bool{
+	"select": true, "lateral": true, "values": true, "on": true,
+	"using": true, "index": true, "key": true, "partition": true,
+}
+
+// aliasFollowers are the words that can follow a table in the FROM clause
+// without being its alias.
+var aliasFollowers = map[string]bool{
+	"on": true, "using": true, "join": true, "inner": true, "cross": true,
+	"left": true, "right": true, "natural": true, "straight_join": true,
+	"use": true, "force": true, "ignore": true,
+}
+
+// queryLevel is the state of the scan of a level of parentheses.
+type queryLevel struct {
+	// query is whether the level is a query, rather than the arguments of
+	// a function or a list of values.
+	query bool
+	// from is whether the scan is in the FROM clause of the query.
+	from bool
+	// table is whether the next word is the start of a table.
+	table bool
+	// lateral is whether the level is the query of a LATERAL derived table
+	// whose SELECT is not written yet.
+	lateral bool
+}
+
+// rewriteFromClauses rewrites the tables of the FROM clauses of a query
+// that vitess cannot parse as subqueries it can parse:
+//
+//   - Calls to table functions, such as generate_series(1, 10), are
+//     rewritten as subqueries marked with tableFunctionComment that select
+//     the call. Calls without an alias are given the name of the function.
+//   - Tables queried AS OF a version, such as t AS OF '2019-01-01', are
+//     rewritten as subqueries marked with asOfComment that select the
+//     version from the table. Tables without an alias are given their name.
+//   - Tables queried with TABLESAMPLE, such as t AS x TABLESAMPLE
+//     BERNOULLI (10) REPEATABLE (1), are rewritten as subqueries marked
+//     with tableSampleComment that select the method, percentage and seed
+//     from the table. Samples without a method get DefaultSampleMethod,
+//     and tables without an alias are given their name.
+//   - LATERAL derived tables, such as LATERAL (SELECT ...) AS t, are
+//     rewritten as their subqueries marked with lateralComment.
+func rewriteFromClauses(s string) string {
+	var b strings.Builder
+	levels := []*queryLevel{{query: true}}
+	// lateral is whether the next query is the one of a LATERAL derived
+	// table.
+	var lateral bool
+
+	for i := 0; i < len(s); {
+		level := levels[len(levels)-1]
+		c := s[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			if c == '`' && level.query && level.table {
+				if end, ok := rewriteAsOf(&b, s, i); ok {
+					i = end
+					level.table = false
+					continue
+				}
+
+				if end, ok := rewriteTableSample(&b, s, i); ok {
+					i = end
+					level.table = false
+					continue
+				}
+			}
+
+			end := quotedEnd(s, i)
+			b.WriteString(s[i:end])
+			i = end
+			level.table = false
+			continue
+		case c == '(':
+			// A level is a query if its first word is SELECT.
+			next := &queryLevel{query: strings.EqualFold(nextWord(s, i+1), "select")}
+			if next.query && lateral {
+				next.lateral, lateral = true, false
+			}
+			levels = append(levels, next)
+			level.table = false
+		case c == ')':
+			if len(levels) > 1 {
+				levels = levels[:len(levels)-1]
+			}
+		case c == ',':
+			level.table = level.query && level.from
+		case isWordByte(c):
+			end := i
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+			word := strings.ToLower(s[i:end])
+
+			if level.query {
+				if open := spaceEnd(s, end); level.table && word == "lateral" && open < len(s) && s[open] == '(' {
+					lateral = true
+					i = open
+					continue
+				}
+
+				if level.table && !notTableFunctions[word] {
+					open := spaceEnd(s, end)
+					if open < len(s) && s[open] == '(' {
+						close := closingParen(s, open)
+						if close > 0 {
+							b.WriteString("(select " + tableFunctionComment + " ")
+							b.WriteString(s[i : close+1])
+							b.WriteString(")")
+							i = close + 1
+							level.table = false
+							if !hasAlias(s, i) {
+								b.WriteString(" AS `" + word + "`")
+							}
+							continue
+						}
+					}
+
+					if end, ok := rewriteAsOf(&b, s, i); ok {
+						i = end
+						level.table = false
+						continue
+					}
+
+					if end, ok := rewriteTableSample(&b, s, i); ok {
+						i = end
+						level.table = false
+						continue
+					}
+				}
+
+				switch {
+				case word == "from":
+					level.from = true
+					level.table = true
+				case strings.HasSuffix(word, "join") && level.from:
+					level.table = true
+				case fromClauseEnd[word]:
+					level.from = false
+					level.table = false
+				default:
+					level.table = false
+				}
+
+				if level.lateral && word == "select" {
+					b.WriteString(s[i:end] + " " + lateralComment)
+					level.lateral = false
+					i = end
+					continue
+				}
+			}
+
+			b.WriteString(s[i:end])
+			i = end
+			continue
+		}
+
+		b.WriteByte(c)
+		i++
+	}
+
+	return b.String()
+}
+
+// rewriteAsOf writes the subquery the table at the given position of the
+// query is rewritten to if it's queried AS OF a version, returning the
+// position after the version and whether it was rewritten.
+func rewriteAsOf(b *strings.Builder, s string, pos int) (int, bool) {
+	end := tableEnd(s, pos)
+	if !strings.EqualFold(nextWord(s, end), "as") {
+		return pos, false
+	}
+
+	of := spaceEnd(s, end) + len("as")
+	if !strings.EqualFold(nextWord(s, of), "of") {
+		return pos, false
+	}
+
+	start := spaceEnd(s, of) + len("of")
+	versionEnd := asOfEnd(s, start)
+	version := strings.TrimSpace(s[start:versionEnd])
+	if version == "" {
+		return pos, false
+	}
+
+	table := s[pos:end]
+	b.WriteString("(select " + asOfComment + " " + version + " from " + table + ")")
+	if !hasAlias(s, versionEnd) {
+		b.WriteString(" AS " + tableName(table))
+	}
+
+	return versionEnd, true
+}
+
+// rewriteTableSample writes the subquery the table at the given position
+// of the query is rewritten to if it's queried with TABLESAMPLE, which
+// follows its alias, returning the position after the sample and whether
+// it was rewritten.
+func rewriteTableSample(b *strings.Builder, s string, pos int) (int, bool) {
+	end := tableEnd(s, pos)
+	table := s[pos:end]
+	alias := tableName(table)
+
+	next := spaceEnd(s, end)
+	if strings.EqualFold(nextWord(s, next), "as") {
+		next = spaceEnd(s, next+len("as"))
+	}
+
+	if !strings.EqualFold(nextWord(s, next), "tablesample") {
+		aliasEnd := tableEnd(s, next)
+		if aliasEnd == next || !hasAlias(s, end) {
+			return pos, false
+		}
+
+		alias = s[next:aliasEnd]
+		next = spaceEnd(s, aliasEnd)
+		if !strings.EqualFold(nextWord(s, next), "tablesample") {
+			return pos, false
+		}
+	}
+
+	next = spaceEnd(s, next+len("tablesample"))
+	method := nextWord(s, next)
+	open := spaceEnd(s, next+len(method))
+	if open >= len(s) || s[open] != '(' {
+		return pos, false
+	}
+
+	if method == "" {
+		method = string(sql.DefaultSampleMethod)
+	}
+
+	close := closingParen(s, open)
+	if close < 0 {
+		return pos, false
+	}
+
+	args := "'" + method + "', " + s[open+1:close]
+	sampleEnd := close + 1
+
+	next = spaceEnd(s, sampleEnd)
+	if strings.EqualFold(nextWord(s, next), "repeatable") {
+		open := spaceEnd(s, next+len("repeatable"))
+		if open >= len(s) || s[open] != '(' {
+			return pos, false
+		}
+
+		close := closingParen(s, open)
+		if close < 0 {
+			return pos, false
+		}
+
+		args += ", " + s[open+1:close]
+		sampleEnd = close + 1
+	}
+
+	b.WriteString("(select " + tableSampleComment + " " + args + " from " + table + ") AS " + alias)
+	return sampleEnd, true
+}
+
+// tableEnd returns the position after the name of the table, which may be
+// qualified with its database, at the given position of the query.
+func tableEnd(s string, pos int) int {
+	for pos < len(s) {
+		switch c := s[pos]; {
+		case c == '`':
+			pos = quotedEnd(s, pos)
+		case isWordByte(c):
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// tableName returns the name of the given table without its database,
+// quoted.
+func tableName(table string) string {
+	name := table
+	for i := 0; i < len(table); {
+		switch table[i] {
+		case '`':
+			i = quotedEnd(table, i)
+			continue
+		case '.':
+			name = table[i+1:]
+		}
+		i++
+	}
+
+	if strings.HasPrefix(name, "`") {
+		return name
+	}
+	return "`" + name + "`"
+}
+
+// asOfEnd returns the position after the version of a table queried AS OF
+// it that starts at the given position of the query. The version ends with
+// the table, before its alias.
+func asOfEnd(s string, pos int) int {
+	end := pos
+	for i := pos; i < len(s); {
+		switch c := s[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = quotedEnd(s, i)
+			end = i
+		case c == '(':
+			close := closingParen(s, i)
+			if close < 0 {
+				return len(s)
+			}
+			i = close + 1
+			end = i
+		case c == ',' || c == ')':
+			return end
+		case isWordByte(c):
+			j := i
+			for j < len(s) && isWordByte(s[j]) {
+				j++
+			}
+
+			word := strings.ToLower(s[i:j])
+			if word == "as" || fromClauseEnd[word] || aliasFollowers[word] {
+				return end
+			}
+			i = j
+			end = j
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			i++
+			end = i
+		}
+	}
+	return end
+}
+
+// hasAlias returns whether the table that ends at the given position of
+// the query has an alias.
+func hasAlias(s string, pos int) bool {
+	if pos = spaceEnd(s, pos); pos < len(s) && (s[pos] == '`' || s[pos] == '\'' || s[pos] == '"') {
+		return true
+	}
+
+	word := strings.ToLower(nextWord(s, pos))
+	if word == "" {
+		return false
+	}
+	return word == "as" || (!fromClauseEnd[word] && !aliasFollowers[word])
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// spaceEnd returns the position after the spaces at the given position of
+// the query.
+func spaceEnd(s string, pos int) int {
+	for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t' || s[pos] == '\n' || s[pos] == '\r') {
+		pos++
+	}
+	return pos
+}
+
+// nextWord returns the word starting at the given position of the query,
+// after any spaces, or an empty string if there is none.
+func nextWord(s string, pos int) string {
+	start := spaceEnd(s, pos)
+	end := start
+	for end < len(s) && isWordByte(s[end]) {
+		end++
+	}
+	return s[start:end]
+}
+
+// quotedEnd returns the position after the quoted string or identifier
+// starting at the given position of the query.
+func quotedEnd(s string, pos int) int {
+	quote := s[pos]
+	for i := pos + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// closingParen returns the position of the parenthesis that closes the one
+// at the given position of the query, or -1 if it's not closed.
+func closingParen(s string, pos int) int {
+	depth := 0
+	for i := pos; i < len(s); {
+		switch s[i] {
+		case '\'', '"', '`':
+			i = quotedEnd(s, i)
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return -1
+}
+
+// isLateral returns whether the table is a LATERAL derived table, whose
+// subquery was marked with lateralComment.
+func isLateral(te sqlparser.TableExpr) bool {
+	t, ok := te.(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return false
+	}
+
+	subquery, ok := t.Expr.(*sqlparser.Subquery)
+	if !ok {
+		return false
+	}
+
+	sel, ok := subquery.Select.(*sqlparser.Select)
+	if !ok {
+		return false
+	}
+
+	for _, c := range sel.Comments {
+		if string(c) == lateralComment {
+			return true
+		}
+	}
+	return false
+}
+
+// tableFunctionToTable returns the call to a table function in the FROM
+// clause that was rewritten to the given subquery, if it's one.
+func tableFunctionToTable(
+	ctx *sql.Context,
+	subquery *sqlparser.Subquery,
+) (*plan.UnresolvedTableFunction, bool, error) {
+	sel, ok := subquery.Select.(*sqlparser.Select)
+	if !ok || len(sel.Comments) != 1 || string(sel.Comments[0]) != tableFunctionComment {
+		return nil, false, nil
+	}
+
+	if len(sel.SelectExprs) != 1 {
+		return nil, false, nil
+	}
+
+	expr, ok := sel.SelectExprs[0].(*sqlparser.AliasedExpr)
+	if !ok {
+		return nil, false, nil
+	}
+
+	fn, ok := expr.Expr.(*sqlparser.FuncExpr)
+	if !ok {
+		return nil, false, ErrUnsupportedSyntax.New(sqlparser.String(expr.Expr))
+	}
+
+	if !fn.Qualifier.IsEmpty() || fn.Distinct {
+		return nil, false, ErrUnsupportedSyntax.New(sqlparser.String(fn))
+	}
+
+	args, err := selectExprsToExpressions(ctx, fn.Exprs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return plan.NewUnresolvedTableFunction(fn.Name.Lowered(), args...), true, nil
+}
+
+// asOfToTable returns the table queried AS OF a version that was rewritten
+// to the given subquery, if it's one.
+func asOfToTable(
+	ctx *sql.Context,
+	subquery *sqlparser.Subquery,
+) (*plan.UnresolvedTable, bool, error) {
+	sel, ok := subquery.Select.(*sqlparser.Select)
+	if !ok || len(sel.Comments) != 1 || string(sel.Comments[0]) != asOfComment {
+		return nil, false, nil
+	}
+
+	if len(sel.SelectExprs) != 1 || len(sel.From) != 1 {
+		return nil, false, nil
+	}
+
+	expr, ok := sel.SelectExprs[0].(*sqlparser.AliasedExpr)
+	if !ok {
+		return nil, false, nil
+	}
+
+	from, ok := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return nil, false, nil
+	}
+
+	name, ok := from.Expr.(sqlparser.TableName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	version, err := exprToExpression(ctx, expr.Expr)
+	if err != nil {
+		return nil, false, err
+	}
+
+	table := plan.NewUnresolvedTable(name.Name.String(), name.Qualifier.String())
+	table.AsOf = version
+	return table, true, nil
+}
+
+// tableSampleToTable returns the table queried with TABLESAMPLE that was
+// rewritten to the given subquery, if it's one.
+func tableSampleToTable(
+	ctx *sql.Context,
+	subquery *sqlparser.Subquery,
+) (*plan.UnresolvedTable, bool, error) {
+	sel, ok := subquery.Select.(*sqlparser.Select)
+	if !ok || len(sel.Comments) != 1 || string(sel.Comments[0]) != tableSampleComment {
+		return nil, false, nil
+	}
+
+	if len(sel.SelectExprs) < 2 || len(sel.SelectExprs) > 3 || len(sel.From) != 1 {
+		return nil, false, ErrUnsupportedSyntax.New(sqlparser.String(sel.SelectExprs))
+	}
+
+	from, ok := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return nil, false, nil
+	}
+
+	name, ok := from.Expr.(sqlparser.TableName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	args, err := selectExprsToExpressions(ctx, sel.SelectExprs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	method, err := args[0].Eval(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sample := &plan.UnresolvedTableSample{
+		Method:     method.(string),
+		Percentage: args[1],
+	}
+	if len(args) == 3 {
+		sample.Seed = args[2]
+	}
+
+	table := plan.NewUnresolvedTable(name.Name.String(), name.Qualifier.String())
+	table.Sample = sample
+	return table, true, nil
+}
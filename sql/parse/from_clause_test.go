@@ -0,0 +1,289 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteFromClauses(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{
+			"SELECT * FROM generate_series(1, 10)",
+			"SELECT * FROM (select /* table function */ generate_series(1, 10)) AS `generate_series`",
+		},
+		{
+			"SELECT g.n FROM generate_series(1, 10) g WHERE n > 2",
+			"SELECT g.n FROM (select /* table function */ generate_series(1, 10)) g WHERE n > 2",
+		},
+		{
+			"SELECT * FROM t, read_csv('a,b(.csv') AS c JOIN f (1) ON 1 = 1",
+			"SELECT * FROM t, (select /* table function */ read_csv('a,b(.csv')) AS c JOIN (select /* table function */ f (1)) AS `f` ON 1 = 1",
+		},
+		{
+			"SELECT * FROM t WHERE i IN (SELECT n FROM f(lower('A')))",
+			"SELECT * FROM t WHERE i IN (SELECT n FROM (select /* table function */ f(lower('A'))) AS `f`)",
+		},
+		{
+			"SELECT EXTRACT(YEAR FROM f(d)), count(*) FROM t USE INDEX (i) WHERE f(a) GROUP BY a, f(b)",
+			"SELECT EXTRACT(YEAR FROM f(d)), count(*) FROM t USE INDEX (i) WHERE f(a) GROUP BY a, f(b)",
+		},
+		{
+			"SELECT * FROM (SELECT 1) AS t JOIN u USING (id)",
+			"SELECT * FROM (SELECT 1) AS t JOIN u USING (id)",
+		},
+		{
+			"SELECT 'FROM f(1)' FROM `f`",
+			"SELECT 'FROM f(1)' FROM `f`",
+		},
+		{
+			"SELECT * FROM t AS OF '2019-01-01' WHERE a = 1",
+			"SELECT * FROM (select /* as of */ '2019-01-01' from t) AS `t` WHERE a = 1",
+		},
+		{
+			"SELECT * FROM db.t AS OF NOW() - INTERVAL 1 DAY AS x JOIN `u v` as of 'abc' ON 1 = 1",
+			"SELECT * FROM (select /* as of */ NOW() - INTERVAL 1 DAY from db.t) AS x JOIN (select /* as of */ 'abc' from `u v`) AS `u v` ON 1 = 1",
+		},
+		{
+			"SELECT * FROM t AS OF 3, u AS OF (1 + 2)",
+			"SELECT * FROM (select /* as of */ 3 from t) AS `t`, (select /* as of */ (1 + 2) from u) AS `u`",
+		},
+		{
+			"SELECT * FROM t AS of_t, u AS OF",
+			"SELECT * FROM t AS of_t, u AS OF",
+		},
+		{
+			"SELECT * FROM t TABLESAMPLE BERNOULLI (10) WHERE a = 1",
+			"SELECT * FROM (select /* tablesample */ 'BERNOULLI', 10 from t) AS `t` WHERE a = 1",
+		},
+		{
+			"SELECT * FROM db.t AS x tablesample system (f(1)) REPEATABLE (42), `u v` y TABLESAMPLE SYSTEM(5) JOIN w ON 1 = 1",
+			"SELECT * FROM (select /* tablesample */ 'system', f(1), 42 from db.t) AS x, (select /* tablesample */ 'SYSTEM', 5 from `u v`) AS y JOIN w ON 1 = 1",
+		},
+		{
+			"SELECT * FROM t x TABLESAMPLE (200)",
+			"SELECT * FROM (select /* tablesample */ 'BERNOULLI', 200 from t) AS x",
+		},
+		{
+			"SELECT * FROM t x WHERE tablesample = 1",
+			"SELECT * FROM t x WHERE tablesample = 1",
+		},
+		{
+			"SELECT * FROM t, LATERAL (SELECT * FROM u WHERE u.a = t.a) AS x",
+			"SELECT * FROM t, (SELECT /* lateral */ * FROM u WHERE u.a = t.a) AS x",
+		},
+		{
+			"SELECT * FROM t LEFT JOIN lateral(SELECT (SELECT 1) FROM u) x ON true",
+			"SELECT * FROM t LEFT JOIN (SELECT /* lateral */ (SELECT 1) FROM u) x ON true",
+		},
+		{
+			"SELECT lateral FROM lateral, (SELECT 1) AS x",
+			"SELECT lateral FROM lateral, (SELECT 1) AS x",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.expected, rewriteFromClauses(tt.query))
+		})
+	}
+}
+
+func TestParseTableFunctions(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected sql.Node
+	}{
+		{
+			"SELECT * FROM generate_series(1, 10)",
+			plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				plan.NewUnresolvedTableFunction(
+					"generate_series",
+					expression.NewLiteral(int8(1), sql.Int8),
+					expression.NewLiteral(int8(10), sql.Int8),
+				),
+			),
+		},
+		{
+			"SELECT n FROM GENERATE_SERIES(1, 10) AS g",
+			plan.NewProject(
+				[]sql.Expression{expression.NewUnresolvedColumn("n")},
+				plan.NewTableAlias("g", plan.NewUnresolvedTableFunction(
+					"generate_series",
+					expression.NewLiteral(int8(1), sql.Int8),
+					expression.NewLiteral(int8(10), sql.Int8),
+				)),
+			),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			node, err := Parse(sql.NewEmptyContext(), tt.query)
+			require.NoError(err)
+			require.Equal(tt.expected, node)
+		})
+	}
+}
+
+func TestParseAsOf(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected sql.Node
+	}{
+		{
+			"SELECT * FROM t AS OF 'abc'",
+			plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				asOfTable("t", "", expression.NewLiteral("abc", sql.Text)),
+			),
+		},
+		{
+			"SELECT a FROM db.t AS OF 2 AS x",
+			plan.NewProject(
+				[]sql.Expression{expression.NewUnresolvedColumn("a")},
+				plan.NewTableAlias("x", asOfTable("t", "db", expression.NewLiteral(int8(2), sql.Int8))),
+			),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			node, err := Parse(sql.NewEmptyContext(), tt.query)
+			require.NoError(err)
+			require.Equal(tt.expected, node)
+		})
+	}
+}
+
+func TestParseTableSample(t *testing.T) {
+	sampleTable := func(name, method string, percentage, seed sql.Expression) *plan.UnresolvedTable {
+		t := plan.NewUnresolvedTable(name, "")
+		t.Sample = &plan.UnresolvedTableSample{Method: method, Percentage: percentage, Seed: seed}
+		return t
+	}
+	ten := expression.NewLiteral(int8(10), sql.Int8)
+
+	testCases := []struct {
+		query    string
+		expected sql.Node
+	}{
+		{
+			"SELECT * FROM t TABLESAMPLE BERNOULLI (10)",
+			plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				sampleTable("t", "BERNOULLI", ten, nil),
+			),
+		},
+		{
+			"SELECT * FROM t TABLESAMPLE (10)",
+			plan.NewProject(
+				[]sql.Expression{expression.NewStar()},
+				sampleTable("t", "BERNOULLI", ten, nil),
+			),
+		},
+		{
+			"SELECT a FROM t AS x TABLESAMPLE system (10) REPEATABLE (1 + 1)",
+			plan.NewProject(
+				[]sql.Expression{expression.NewUnresolvedColumn("a")},
+				plan.NewTableAlias("x", sampleTable("t", "system", ten, expression.NewArithmetic(
+					expression.NewLiteral(int8(1), sql.Int8),
+					expression.NewLiteral(int8(1), sql.Int8),
+					"+",
+				))),
+			),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			node, err := Parse(sql.NewEmptyContext(), tt.query)
+			require.NoError(err)
+			require.Equal(tt.expected, node)
+		})
+	}
+}
+
+func TestParseLateral(t *testing.T) {
+	derived := plan.NewSubqueryAlias("x", plan.NewProject(
+		[]sql.Expression{expression.NewUnresolvedColumn("b")},
+		plan.NewFilter(
+			expression.NewEquals(
+				expression.NewUnresolvedQualifiedColumn("u", "a"),
+				expression.NewUnresolvedQualifiedColumn("t", "a"),
+			),
+			plan.NewUnresolvedTable("u", ""),
+		),
+	))
+	star := []sql.Expression{expression.NewStar()}
+
+	testCases := []struct {
+		query    string
+		expected sql.Node
+	}{
+		{
+			"SELECT * FROM t, LATERAL (SELECT b FROM u WHERE u.a = t.a) x",
+			plan.NewProject(star, plan.NewLateralJoin(
+				plan.NewUnresolvedTable("t", ""),
+				derived,
+				expression.NewLiteral(true, sql.Boolean),
+			)),
+		},
+		{
+			"SELECT * FROM t JOIN LATERAL (SELECT b FROM u WHERE u.a = t.a) x ON x.b > 1",
+			plan.NewProject(star, plan.NewLateralJoin(
+				plan.NewUnresolvedTable("t", ""),
+				derived,
+				expression.NewGreaterThan(
+					expression.NewUnresolvedQualifiedColumn("x", "b"),
+					expression.NewLiteral(int8(1), sql.Int8),
+				),
+			)),
+		},
+		{
+			"SELECT * FROM t LEFT JOIN LATERAL (SELECT b FROM u WHERE u.a = t.a) x ON true",
+			plan.NewProject(star, plan.NewLeftLateralJoin(
+				plan.NewUnresolvedTable("t", ""),
+				derived,
+				expression.NewLiteral(true, sql.Boolean),
+			)),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			node, err := Parse(sql.NewEmptyContext(), tt.query)
+			require.NoError(err)
+			require.Equal(tt.expected, node)
+		})
+	}
+}
+
+func TestParseLateralErrors(t *testing.T) {
+	require := require.New(t)
+
+	_, err := Parse(sql.NewEmptyContext(), "SELECT * FROM t RIGHT JOIN LATERAL (SELECT 1) x ON true")
+	require.Error(err)
+	require.True(ErrUnsupportedFeature.Is(err))
+
+	_, err = Parse(sql.NewEmptyContext(), "SELECT * FROM t JOIN LATERAL (SELECT 1 AS a) x USING (a)")
+	require.Error(err)
+	require.True(ErrUnsupportedFeature.Is(err))
+}
+
+func asOfTable(name, db string, version sql.Expression) *plan.UnresolvedTable {
+	t := plan.NewUnresolvedTable(name, db)
+	t.AsOf = version
+	return t
+}
@@ -0,0 +1,393 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+var errUnknownPrivilege = errors.NewKind("unknown privilege: %s")
+
+func parseGrant(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var privileges sql.PrivilegeType
+	var level sql.PrivilegeLevel
+	var users []string
+	var grantOption bool
+	err := parseFuncs{
+		expect("grant"),
+		skipSpaces,
+		readPrivileges(&privileges),
+		expect("on"),
+		skipSpaces,
+		maybeReadTableKeyword,
+		readPrivilegeLevel(&level),
+		skipSpaces,
+		expect("to"),
+		skipSpaces,
+		readUsers(&users),
+		skipSpaces,
+		maybeReadGrantOption(&grantOption),
+		skipSpaces,
+		checkEOF,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if grantOption {
+		privileges |= sql.GrantOptionPrivilege
+	}
+
+	return plan.NewGrant(privileges, level, users...), nil
+}
+
+func parseRevoke(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var privileges sql.PrivilegeType
+	var users []string
+	var next string
+	err := parseFuncs{
+		expect("revoke"),
+		skipSpaces,
+		readPrivileges(&privileges),
+		readIdent(&next),
+		skipSpaces,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// REVOKE ALL PRIVILEGES, GRANT OPTION FROM user revokes everything.
+	level := sql.GlobalPrivilegeLevel
+	switch next {
+	case "on":
+		err = parseFuncs{
+			maybeReadTableKeyword,
+			readPrivilegeLevel(&level),
+			skipSpaces,
+			expect("from"),
+			skipSpaces,
+		}.exec(r)
+		if err != nil {
+			return nil, err
+		}
+	case "from":
+		if privileges != sql.AllPrivileges|sql.GrantOptionPrivilege {
+			return nil, errUnexpectedSyntax.New("on", next)
+		}
+	default:
+		return nil, errUnexpectedSyntax.New("on", next)
+	}
+
+	err = parseFuncs{
+		readUsers(&users),
+		skipSpaces,
+		checkEOF,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewRevoke(privileges, level, users...), nil
+}
+
+func parseShowGrants(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var users []string
+	err := parseFuncs{
+		expect("show"),
+		skipSpaces,
+		expect("grants"),
+		skipSpaces,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var next string
+	if err := readIdent(&next)(r); err != nil {
+		return nil, err
+	}
+
+	switch next {
+	case "":
+	case "for":
+		err = parseFuncs{
+			skipSpaces,
+			readUser(&users),
+			skipSpaces,
+			checkEOF,
+		}.exec(r)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errUnexpectedSyntax.New("for", next)
+	}
+
+	var user string
+	if len(users) > 0 {
+		user = users[0]
+	}
+
+	return plan.NewShowGrants(user), nil
+}
+
+// readPrivileges reads a list of privileges separated by commas.
+func readPrivileges(privileges *sql.PrivilegeType) parseFunc {
+	return func(rd *bufio.Reader) error {
+		for {
+			var name string
+			if err := readIdent(&name)(rd); err != nil {
+				return err
+			}
+
+			var second string
+			switch name {
+			case "lock":
+				second = "tables"
+			case "grant":
+				second = "option"
+			case "all":
+				if err := skipSpaces(rd); err != nil {
+					return err
+				}
+
+				var ident string
+				if err := readIdent(&ident)(rd); err != nil {
+					return err
+				}
+
+				if ident != "privileges" {
+					unreadString(rd, ident)
+				}
+			}
+
+			if second != "" {
+				err := parseFuncs{skipSpaces, expect(second)}.exec(rd)
+				if err != nil {
+					return err
+				}
+				name += " " + second
+			}
+
+			p, ok := sql.ParsePrivilege(name)
+			if !ok {
+				return errUnknownPrivilege.New(name)
+			}
+			*privileges |= p
+
+			if err := skipSpaces(rd); err != nil {
+				return err
+			}
+
+			if !readComma(rd) {
+				return nil
+			}
+		}
+	}
+}
+
+// maybeReadTableKeyword skips the optional TABLE keyword before a privilege
+// level.
+func maybeReadTableKeyword(rd *bufio.Reader) error {
+	var ident string
+	if err := readIdent(&ident)(rd); err != nil {
+		return err
+	}
+
+	if ident != "table" {
+		unreadString(rd, ident)
+		return nil
+	}
+
+	return skipSpaces(rd)
+}
+
+// readPrivilegeLevel reads a privilege level, which is *, *.*, db.*,
+// db.table or table. The database of the levels without one is left
+// empty, as it is the current database.
+func readPrivilegeLevel(level *sql.PrivilegeLevel) parseFunc {
+	return func(rd *bufio.Reader) error {
+		first, err := readLevelPart(rd)
+		if err != nil {
+			return err
+		}
+
+		b, err := rd.Peek(1)
+		if err != nil || b[0] != '.' {
+			*level = sql.PrivilegeLevel{Table: first}
+			return nil
+		}
+
+		if _, err := rd.Discard(1); err != nil {
+			return err
+		}
+
+		second, err := readLevelPart(rd)
+		if err != nil {
+			return err
+		}
+
+		if first == "*" && second != "*" {
+			return errUnexpectedSyntax.New("*", second)
+		}
+
+		*level = sql.PrivilegeLevel{Database: first, Table: second}
+		return nil
+	}
+}
+
+func readLevelPart(rd *bufio.Reader) (string, error) {
+	b, err := rd.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if b[0] == '*' {
+		_, err := rd.Discard(1)
+		return "*", err
+	}
+
+	var ident string
+	if err := readQuotableIdent(&ident)(rd); err != nil {
+		return "", err
+	}
+
+	if ident == "" {
+		return "", errUnexpectedSyntax.New("identifier", string(b))
+	}
+
+	return ident, nil
+}
+
+// readUsers reads a list of users separated by commas.
+func readUsers(users *[]string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		for {
+			if err := readUser(users)(rd); err != nil {
+				return err
+			}
+
+			if err := skipSpaces(rd); err != nil {
+				return err
+			}
+
+			if !readComma(rd) {
+				return nil
+			}
+		}
+	}
+}
+
+// readUser reads a user account, which can be quoted and followed by the
+// host it connects from. As the hosts are checked when users log in, the
+// host of the account is ignored.
+func readUser(users *[]string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		name, err := readAccountPart(rd)
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
+			return errUnexpectedSyntax.New("user", "EOF")
+		}
+
+		b, err := rd.Peek(1)
+		if err == nil && b[0] == '@' {
+			if _, err := rd.Discard(1); err != nil {
+				return err
+			}
+
+			if _, err := readAccountPart(rd); err != nil {
+				return err
+			}
+		}
+
+		*users = append(*users, name)
+		return nil
+	}
+}
+
+// readAccountPart reads the name or host of an account, either quoted or
+// up to the next space, comma or @.
+func readAccountPart(rd *bufio.Reader) (string, error) {
+	var buf bytes.Buffer
+	var quote rune
+	for {
+		r, _, err := rd.ReadRune()
+		if err == io.EOF {
+			if quote != 0 {
+				return "", errUnexpectedSyntax.New(string(quote), "EOF")
+			}
+			return buf.String(), nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case quote != 0:
+			if r == quote {
+				return buf.String(), nil
+			}
+		case buf.Len() == 0 && (r == '\'' || r == '"' || r == '`'):
+			quote = r
+			continue
+		case unicode.IsSpace(r) || r == ',' || r == '@':
+			return buf.String(), rd.UnreadRune()
+		}
+
+		buf.WriteRune(r)
+	}
+}
+
+// maybeReadGrantOption reads the optional WITH GRANT OPTION clause.
+func maybeReadGrantOption(grantOption *bool) parseFunc {
+	return func(rd *bufio.Reader) error {
+		var ident string
+		if err := readIdent(&ident)(rd); err != nil {
+			return err
+		}
+
+		if ident != "with" {
+			unreadString(rd, ident)
+			return nil
+		}
+
+		*grantOption = true
+		return parseFuncs{
+			skipSpaces,
+			expect("grant"),
+			skipSpaces,
+			expect("option"),
+		}.exec(rd)
+	}
+}
+
+// readComma reads a comma and the spaces after it, if the next character is
+// a comma.
+func readComma(rd *bufio.Reader) bool {
+	b, err := rd.Peek(1)
+	if err != nil || b[0] != ',' {
+		return false
+	}
+
+	if _, err := rd.Discard(1); err != nil {
+		return false
+	}
+
+	return skipSpaces(rd) == nil
+}
@@ -0,0 +1,85 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrant(t *testing.T) {
+	testCases := []struct {
+		query  string
+		result sql.Node
+		err    bool
+	}{
+		{
+			"GRANT SELECT ON mydb.* TO user",
+			plan.NewGrant(sql.SelectPrivilege, sql.PrivilegeLevel{Database: "mydb", Table: "*"}, "user"),
+			false,
+		},
+		{
+			"grant select, insert, lock tables on TABLE `mydb`.`mytable` to 'User'@'%', `other`@localhost",
+			plan.NewGrant(
+				sql.SelectPrivilege|sql.InsertPrivilege|sql.LockTablesPrivilege,
+				sql.PrivilegeLevel{Database: "mydb", Table: "mytable"},
+				"User", "other",
+			),
+			false,
+		},
+		{
+			"GRANT ALL PRIVILEGES ON *.* TO root WITH GRANT OPTION",
+			plan.NewGrant(sql.AllPrivileges|sql.GrantOptionPrivilege, sql.GlobalPrivilegeLevel, "root"),
+			false,
+		},
+		{
+			"GRANT ALL ON * TO user",
+			plan.NewGrant(sql.AllPrivileges, sql.PrivilegeLevel{Table: "*"}, "user"),
+			false,
+		},
+		{
+			"GRANT UPDATE ON mytable TO user",
+			plan.NewGrant(sql.UpdatePrivilege, sql.PrivilegeLevel{Table: "mytable"}, "user"),
+			false,
+		},
+		{"GRANT SUPER ON *.* TO user", nil, true},
+		{"GRANT SELECT ON *.mytable TO user", nil, true},
+		{"GRANT SELECT ON mydb.* user", nil, true},
+		{"GRANT SELECT ON mydb.* TO user WITH OPTION", nil, true},
+		{
+			"REVOKE DELETE, DROP ON mydb.* FROM user, 'other'",
+			plan.NewRevoke(
+				sql.DeletePrivilege|sql.DropPrivilege,
+				sql.PrivilegeLevel{Database: "mydb", Table: "*"},
+				"user", "other",
+			),
+			false,
+		},
+		{
+			"REVOKE ALL PRIVILEGES, GRANT OPTION FROM user",
+			plan.NewRevoke(sql.AllPrivileges|sql.GrantOptionPrivilege, sql.GlobalPrivilegeLevel, "user"),
+			false,
+		},
+		{"REVOKE SELECT FROM user", nil, true},
+		{"REVOKE SELECT ON mydb.* TO user", nil, true},
+		{"SHOW GRANTS", plan.NewShowGrants(""), false},
+		{"SHOW GRANTS FOR 'user'@'localhost'", plan.NewShowGrants("user"), false},
+		{"SHOW GRANTS TO user", nil, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := Parse(sql.NewEmptyContext(), tt.query)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.result, result)
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// ErrTooManyGroupingSets is returned when a GROUP BY clause has more
+// grouping sets than the maximum.
+var ErrTooManyGroupingSets = errors.NewKind("too many grouping sets in group by clause, the maximum is %d")
+
+// maxGroupingSets is the maximum number of grouping sets of a GROUP BY
+// clause, as every row is aggregated once for each of them.
+const maxGroupingSets = 4096
+
+// The functions the grouping sets of GROUP BY clauses are rewritten to, as
+// vitess cannot parse them.
+const (
+	groupingSetsFunction = "grouping_sets"
+	groupingSetFunction  = "grouping_set"
+	rollupFunction       = "rollup"
+	cubeFunction         = "cube"
+)
+
+// rewriteGroupingSets rewrites the grouping sets of the GROUP BY clauses of
+// a query that vitess cannot parse as calls to functions it can parse:
+//
+//   - GROUPING SETS ((a, b), a, ()) is rewritten to
+//     grouping_sets(grouping_set(a, b), a, grouping_set()).
+//   - GROUP BY a, b WITH ROLLUP is rewritten to GROUP BY rollup(a, b).
+//
+// ROLLUP(a, b) and CUBE(a, b) are parsed as calls already.
+func rewriteGroupingSets(s string) string {
+	lower := strings.ToLower(s)
+	if !strings.Contains(lower, "grouping") && !strings.Contains(lower, "rollup") {
+		return s
+	}
+
+	var out []byte
+	// groupBy are the positions of the output after the GROUP BY of every
+	// level of parentheses, or -1 if there is none.
+	groupBy := []int{-1}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := quotedEnd(s, i)
+			out = append(out, s[i:end]...)
+			i = end
+			continue
+		case c == '(':
+			groupBy = append(groupBy, -1)
+		case c == ')':
+			if len(groupBy) > 1 {
+				groupBy = groupBy[:len(groupBy)-1]
+			}
+		case isWordByte(c):
+			end := i
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+
+			switch strings.ToLower(s[i:end]) {
+			case "group":
+				if strings.EqualFold(nextWord(s, end), "by") {
+					end = spaceEnd(s, end) + len("by")
+					out = append(out, s[i:end]...)
+					groupBy[len(groupBy)-1] = len(out)
+					i = end
+					continue
+				}
+			case "grouping":
+				if strings.EqualFold(nextWord(s, end), "sets") {
+					open := spaceEnd(s, spaceEnd(s, end)+len("sets"))
+					if open < len(s) && s[open] == '(' {
+						if close := closingParen(s, open); close > 0 {
+							out = append(out, groupingSetsFunction+"("...)
+							out = append(out, rewriteGroupingSetsList(s[open+1:close])...)
+							out = append(out, ')')
+							i = close + 1
+							continue
+						}
+					}
+				}
+			case "with":
+				pos := groupBy[len(groupBy)-1]
+				if pos >= 0 && strings.EqualFold(nextWord(s, end), "rollup") {
+					exprs := strings.TrimSpace(string(out[pos:]))
+					out = append(out[:pos], " "+rollupFunction+"("+exprs+")"...)
+					groupBy[len(groupBy)-1] = -1
+					i = spaceEnd(s, end) + len("rollup")
+					continue
+				}
+			}
+
+			out = append(out, s[i:end]...)
+			i = end
+			continue
+		}
+
+		out = append(out, c)
+		i++
+	}
+
+	return string(out)
+}
+
+// rewriteGroupingSetsList rewrites the list of grouping sets of GROUPING
+// SETS, in which the sets in parentheses are rewritten as calls to
+// grouping_set.
+func rewriteGroupingSetsList(s string) string {
+	var elems []string
+	start, depth := 0, 0
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\'', '"', '`':
+			i = quotedEnd(s, i)
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				elems = append(elems, s[start:i])
+				start = i + 1
+			}
+		}
+		i++
+	}
+	elems = append(elems, s[start:])
+
+	for i, e := range elems {
+		e = strings.TrimSpace(e)
+		if strings.HasPrefix(e, "(") && closingParen(e, 0) == len(e)-1 {
+			e = groupingSetFunction + e
+		}
+		elems[i] = rewriteGroupingSets(e)
+	}
+
+	return strings.Join(elems, ", ")
+}
+
+// groupByToGroupingSets converts a GROUP BY clause, returning its grouping
+// expressions and the indexes of the ones of each of its grouping sets,
+// which are nil if it has none. ROLLUP(a, b) is the same as GROUPING SETS
+// ((a, b), (a), ()) and CUBE(a, b) as GROUPING SETS ((a, b), (a), (b), ()).
+// As in the SQL standard, the grouping sets of the elements of the clause
+// are combined, so GROUP BY a, ROLLUP(b) is GROUPING SETS ((a, b), (a)).
+func groupByToGroupingSets(ctx *sql.Context, g sqlparser.GroupBy) ([]sql.Expression, [][]int, error) {
+	var hasSets bool
+	for _, e := range g {
+		if groupingSetsFunc(e) != "" {
+			hasSets = true
+			break
+		}
+	}
+
+	if !hasSets {
+		exprs, err := groupByToExpressions(ctx, g)
+		return exprs, nil, err
+	}
+
+	c := &groupingSetsConverter{ctx: ctx, indexes: make(map[string]int)}
+	sets, err := c.combine(g)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.exprs, sets, nil
+}
+
+// groupingSetsFunc returns the name of the function of the grouping sets
+// the expression is a call to, or an empty string if it's not one.
+func groupingSetsFunc(e sqlparser.Expr) string {
+	fn, ok := e.(*sqlparser.FuncExpr)
+	if !ok || !fn.Qualifier.IsEmpty() || fn.Distinct {
+		return ""
+	}
+
+	switch name := fn.Name.Lowered(); name {
+	case groupingSetsFunction, groupingSetFunction, rollupFunction, cubeFunction:
+		return name
+	default:
+		return ""
+	}
+}
+
+// groupingSetsConverter converts the grouping sets of a GROUP BY clause,
+// keeping its distinct grouping expressions.
+type groupingSetsConverter struct {
+	ctx     *sql.Context
+	exprs   []sql.Expression
+	indexes map[string]int
+}
+
+// sets returns the grouping sets of an element of a GROUP BY clause.
+func (c *groupingSetsConverter) sets(e sqlparser.Expr) ([][]int, error) {
+	name := groupingSetsFunc(e)
+	if name == "" {
+		idx, err := c.index(e)
+		if err != nil {
+			return nil, err
+		}
+		return [][]int{{idx}}, nil
+	}
+
+	args, err := groupingSetsArgs(e.(*sqlparser.FuncExpr))
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case groupingSetFunction:
+		return c.combine(args)
+	case groupingSetsFunction:
+		var sets [][]int
+		for _, arg := range args {
+			argSets, err := c.sets(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			sets = append(sets, argSets...)
+			if len(sets) > maxGroupingSets {
+				return nil, ErrTooManyGroupingSets.New(maxGroupingSets)
+			}
+		}
+		return sets, nil
+	}
+
+	if len(args) >= 31 || len(args) > 0 && name == cubeFunction && 1<<uint(len(args)) > maxGroupingSets {
+		return nil, ErrTooManyGroupingSets.New(maxGroupingSets)
+	}
+
+	items := make([][]int, len(args))
+	for i, arg := range args {
+		if items[i], err = c.item(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	var sets [][]int
+	if name == rollupFunction {
+		for n := len(items); n >= 0; n-- {
+			sets = append(sets, concatSets(items[:n]))
+		}
+		return sets, nil
+	}
+
+	// The sets of CUBE are every combination of its items, in the order of
+	// the bits of the mask of the items in them, the first item being the
+	// most significant.
+	for mask := 1<<uint(len(items)) - 1; mask >= 0; mask-- {
+		var in [][]int
+		for i, item := range items {
+			if mask&(1<<uint(len(items)-1-i)) != 0 {
+				in = append(in, item)
+			}
+		}
+		sets = append(sets, concatSets(in))
+	}
+	return sets, nil
+}
+
+// combine returns the grouping sets of a list of elements, which are the
+// combinations of the grouping sets of each of them.
+func (c *groupingSetsConverter) combine(elems []sqlparser.Expr) ([][]int, error) {
+	sets := [][]int{{}}
+	for _, e := range elems {
+		elemSets, err := c.sets(e)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(sets)*len(elemSets) > maxGroupingSets {
+			return nil, ErrTooManyGroupingSets.New(maxGroupingSets)
+		}
+
+		var combined [][]int
+		for _, set := range sets {
+			for _, elemSet := range elemSets {
+				combined = append(combined, concatSets([][]int{set, elemSet}))
+			}
+		}
+		sets = combined
+	}
+
+	return sets, nil
+}
+
+// item returns the indexes of the grouping expressions of an item of ROLLUP
+// or CUBE, which can be a list of expressions in parentheses that are
+// rolled up together.
+func (c *groupingSetsConverter) item(e sqlparser.Expr) ([]int, error) {
+	exprs := []sqlparser.Expr{e}
+	if tuple, ok := e.(sqlparser.ValTuple); ok {
+		exprs = tuple
+	}
+
+	indexes := make([]int, len(exprs))
+	for i, e := range exprs {
+		var err error
+		if indexes[i], err = c.index(e); err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+// index returns the index of a grouping expression, adding it to the
+// grouping expressions if it's not one of them yet.
+func (c *groupingSetsConverter) index(e sqlparser.Expr) (int, error) {
+	if name := groupingSetsFunc(e); name != "" {
+		return 0, ErrUnsupportedSyntax.New(sqlparser.String(e))
+	}
+
+	expr, err := exprToExpression(c.ctx, e)
+	if err != nil {
+		return 0, err
+	}
+
+	key := expr.String()
+	if idx, ok := c.indexes[key]; ok {
+		return idx, nil
+	}
+
+	c.exprs = append(c.exprs, expr)
+	c.indexes[key] = len(c.exprs) - 1
+	return len(c.exprs) - 1, nil
+}
+
+// groupingSetsArgs returns the arguments of a call to a function of the
+// grouping sets.
+func groupingSetsArgs(fn *sqlparser.FuncExpr) ([]sqlparser.Expr, error) {
+	args := make([]sqlparser.Expr, len(fn.Exprs))
+	for i, e := range fn.Exprs {
+		aliased, ok := e.(*sqlparser.AliasedExpr)
+		if !ok {
+			return nil, ErrUnsupportedSyntax.New(sqlparser.String(fn))
+		}
+		args[i] = aliased.Expr
+	}
+	return args, nil
+}
+
+// concatSets returns the indexes of the given sets in a single set, without
+// repeating them.
+func concatSets(sets [][]int) []int {
+	set := []int{}
+	seen := make(map[int]bool)
+	for _, s := range sets {
+		for _, idx := range s {
+			if !seen[idx] {
+				seen[idx] = true
+				set = append(set, idx)
+			}
+		}
+	}
+	return set
+}
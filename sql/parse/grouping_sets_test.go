@@ -0,0 +1,129 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteGroupingSets(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{
+			"SELECT a, b FROM t GROUP BY GROUPING SETS ((a, b), a, ())",
+			"SELECT a, b FROM t GROUP BY grouping_sets(grouping_set(a, b), a, grouping_set())",
+		},
+		{
+			"SELECT a FROM t GROUP BY grouping sets (rollup(a), (f(a), 'GROUPING SETS (a)'))",
+			"SELECT a FROM t GROUP BY grouping_sets(rollup(a), grouping_set(f(a), 'GROUPING SETS (a)'))",
+		},
+		{
+			"SELECT a, b FROM t GROUP BY a, b WITH ROLLUP HAVING a > 1",
+			"SELECT a, b FROM t GROUP BY rollup(a, b) HAVING a > 1",
+		},
+		{
+			"SELECT * FROM (SELECT a FROM t GROUP BY a with rollup) s GROUP BY a",
+			"SELECT * FROM (SELECT a FROM t GROUP BY rollup(a)) s GROUP BY a",
+		},
+		{
+			"SELECT 'GROUP BY a WITH ROLLUP', `grouping sets` FROM t",
+			"SELECT 'GROUP BY a WITH ROLLUP', `grouping sets` FROM t",
+		},
+		{
+			"SELECT a FROM t GROUP BY ROLLUP(a), CUBE(b)",
+			"SELECT a FROM t GROUP BY ROLLUP(a), CUBE(b)",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require.Equal(t, tt.expected, rewriteGroupingSets(tt.query))
+		})
+	}
+}
+
+func TestParseGroupingSets(t *testing.T) {
+	a := expression.NewUnresolvedColumn("a")
+	b := expression.NewUnresolvedColumn("b")
+	c := expression.NewUnresolvedColumn("c")
+	count := expression.NewUnresolvedFunction("count", true, expression.NewStar())
+
+	testCases := []struct {
+		query    string
+		grouping []sql.Expression
+		sets     [][]int
+	}{
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY ROLLUP(a, b)",
+			[]sql.Expression{a, b},
+			[][]int{{0, 1}, {0}, {}},
+		},
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY a, b WITH ROLLUP",
+			[]sql.Expression{a, b},
+			[][]int{{0, 1}, {0}, {}},
+		},
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY CUBE(a, b)",
+			[]sql.Expression{a, b},
+			[][]int{{0, 1}, {0}, {1}, {}},
+		},
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY GROUPING SETS ((a, b), b, ())",
+			[]sql.Expression{a, b},
+			[][]int{{0, 1}, {1}, {}},
+		},
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY c, ROLLUP((a, b))",
+			[]sql.Expression{c, a, b},
+			[][]int{{0, 1, 2}, {0}},
+		},
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY a, GROUPING SETS (a, b, rollup(c))",
+			[]sql.Expression{a, b, c},
+			[][]int{{0}, {0, 1}, {0, 2}, {0}},
+		},
+		{
+			"SELECT a, b, COUNT(*) FROM t GROUP BY ROLLUP(2, 1)",
+			[]sql.Expression{b, a},
+			[][]int{{0, 1}, {0}, {}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+
+			node, err := Parse(sql.NewEmptyContext(), tt.query)
+			require.NoError(err)
+
+			expected := plan.NewGroupBy(
+				[]sql.Expression{a, b, count},
+				tt.grouping,
+				plan.NewUnresolvedTable("t", ""),
+			).WithGroupingSets(tt.sets)
+			require.Equal(expected, node)
+		})
+	}
+}
+
+func TestParseGroupingSetsErrors(t *testing.T) {
+	require := require.New(t)
+
+	_, err := Parse(sql.NewEmptyContext(), "SELECT COUNT(*) FROM t GROUP BY CUBE(a, b, c, d, e, f, g, h, i, j, k, l, m)")
+	require.Error(err)
+	require.True(ErrTooManyGroupingSets.Is(err))
+
+	_, err = Parse(sql.NewEmptyContext(), "SELECT COUNT(*) FROM t GROUP BY ROLLUP(CUBE(a))")
+	require.Error(err)
+	require.True(ErrUnsupportedSyntax.Is(err))
+
+	_, err = Parse(sql.NewEmptyContext(), "SELECT a FROM t GROUP BY ROLLUP(2)")
+	require.Error(err)
+	require.True(ErrGroupByColumnIndex.Is(err))
+}
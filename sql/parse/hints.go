@@ -0,0 +1,146 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+var (
+	// selectHintsRegex matches the optimizer hints comment of a SELECT,
+	// which must follow the SELECT keyword.
+	selectHintsRegex = regexp.MustCompile(`(?is)^\s*select\s*/\*\+(.*?)\*/`)
+	// maxExecutionTimeHintRegex matches the MAX_EXECUTION_TIME(n) hint,
+	// where n is the maximum execution time in milliseconds.
+	maxExecutionTimeHintRegex = regexp.MustCompile(`(?i)(?:^|\s)max_execution_time\s*\(\s*(\d+)\s*\)`)
+	// hintRegex matches each of the hints in an optimizer hints comment,
+	// with the arguments between parentheses, if any.
+	hintRegex = regexp.MustCompile(`(?s)([[:alpha:]_]+)\s*(?:\(([^)]*)\))?`)
+	// hintArgsSeparatorRegex matches the separator of hint arguments.
+	hintArgsSeparatorRegex = regexp.MustCompile(`[\s,]+`)
+)
+
+// Hints returns the optimizer hints of the outermost SELECT of the query,
+// along with the index hints of the tables of the given node, which is the
+// result of parsing the query, or nil if there are none. Optimizer hints
+// that are not supported or have invalid arguments are ignored with a
+// warning. MAX_EXECUTION_TIME is not part of the returned hints, as it's
+// read with MaxExecutionTime.
+func Hints(ctx *sql.Context, query string, parsed sql.Node) *sql.QueryHints {
+	hints := optimizerHints(ctx, query)
+
+	plan.InspectWithSubqueries(parsed, func(n sql.Node) bool {
+		if t, ok := n.(*plan.UnresolvedTable); ok {
+			for _, h := range t.IndexHints {
+				if hints == nil {
+					hints = new(sql.QueryHints)
+				}
+				hints.AddIndexHint(t.Name(), h)
+			}
+		}
+		return true
+	})
+
+	return hints
+}
+
+func optimizerHints(ctx *sql.Context, query string) *sql.QueryHints {
+	m := selectHintsRegex.FindStringSubmatch(query)
+	if m == nil {
+		return nil
+	}
+
+	var hints = new(sql.QueryHints)
+	for _, h := range hintRegex.FindAllStringSubmatch(m[1], -1) {
+		name := strings.ToUpper(h[1])
+		args := hintArgsSeparatorRegex.Split(strings.TrimSpace(h[2]), -1)
+		if len(args) == 1 && args[0] == "" {
+			args = nil
+		}
+
+		switch name {
+		case "JOIN_ORDER":
+			if len(args) < 2 {
+				break
+			}
+			hints.JoinOrder = args
+			continue
+		case "NO_INDEX":
+			if len(args) == 0 {
+				break
+			}
+			hints.AddIndexHint(args[0], sql.IndexHint{Type: sql.IgnoreIndexHint, Indexes: args[1:]})
+			continue
+		case "INDEX", "USE_INDEX":
+			if len(args) < 2 {
+				break
+			}
+			hints.AddIndexHint(args[0], sql.IndexHint{Type: sql.UseIndexHint, Indexes: args[1:]})
+			continue
+		case "PARALLEL":
+			if len(args) != 1 {
+				break
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				break
+			}
+			hints.Parallelism = n
+			continue
+		case "MAX_EXECUTION_TIME":
+			continue
+		}
+
+		ctx.Warn(1064, "optimizer hint %s is not supported or has invalid arguments, it will be ignored", strings.TrimSpace(h[0]))
+	}
+
+	return hints
+}
+
+// MaxExecutionTime returns the maximum execution time of the query set with
+// the MAX_EXECUTION_TIME optimizer hint, as in
+// "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t", and whether the query
+// has the hint. The hint is only taken into account in the outermost SELECT
+// of the query.
+func MaxExecutionTime(query string) (time.Duration, bool) {
+	m := selectHintsRegex.FindStringSubmatch(query)
+	if m == nil {
+		return 0, false
+	}
+
+	m = maxExecutionTimeHintRegex.FindStringSubmatch(m[1])
+	if m == nil {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * time.Millisecond, true
+}
+
+// convertIndexHints converts the index hints of a table in the FROM clause.
+func convertIndexHints(h *sqlparser.IndexHints) (sql.IndexHint, error) {
+	var hint sql.IndexHint
+	switch h.Type {
+	case sqlparser.UseStr:
+		hint.Type = sql.UseIndexHint
+	case sqlparser.ForceStr:
+		hint.Type = sql.ForceIndexHint
+	case sqlparser.IgnoreStr:
+		hint.Type = sql.IgnoreIndexHint
+	default:
+		return hint, ErrUnsupportedSyntax.New(h)
+	}
+
+	for _, idx := range h.Indexes {
+		hint.Indexes = append(hint.Indexes, idx.String())
+	}
+	return hint, nil
+}
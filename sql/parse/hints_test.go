@@ -0,0 +1,101 @@
+package parse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxExecutionTime(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t", time.Second, true},
+		{"select/*+ max_execution_time ( 5 ) */ a FROM t", 5 * time.Millisecond, true},
+		{"SELECT /*+ NO_INDEX_MERGE(t) MAX_EXECUTION_TIME(20) */ * FROM t", 20 * time.Millisecond, true},
+		{"SELECT /* MAX_EXECUTION_TIME(1000) */ * FROM t", 0, false},
+		{"SELECT * FROM t WHERE a IN (SELECT /*+ MAX_EXECUTION_TIME(1000) */ b FROM u)", 0, false},
+		{"SELECT /*+ NO_INDEX_MERGE(t) */ * FROM t", 0, false},
+		{"SELECT /*+ FOO_MAX_EXECUTION_TIME(1) */ * FROM t", 0, false},
+		{"SELECT * FROM t", 0, false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			d, ok := MaxExecutionTime(tt.query)
+			require.Equal(tt.ok, ok)
+			require.Equal(tt.expected, d)
+		})
+	}
+}
+
+func TestHints(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected *sql.QueryHints
+		warnings int
+	}{
+		{
+			"SELECT /*+ JOIN_ORDER(b, a) PARALLEL(4) */ * FROM a, b",
+			&sql.QueryHints{JoinOrder: []string{"b", "a"}, Parallelism: 4},
+			0,
+		},
+		{
+			"select /*+ no_index(a) NO_INDEX(b idx1, idx2) INDEX(c idx3) USE_INDEX(c idx4) */ * FROM a",
+			&sql.QueryHints{
+				NoIndex: map[string][]string{
+					"a": {},
+					"b": {"idx1", "idx2"},
+				},
+				UseIndex: map[string][]string{
+					"c": {"idx3", "idx4"},
+				},
+			},
+			0,
+		},
+		{
+			"SELECT /*+ NO_INDEX(a idx1) NO_INDEX(a) */ * FROM a",
+			&sql.QueryHints{NoIndex: map[string][]string{"a": {}}},
+			0,
+		},
+		{
+			"SELECT /*+ MAX_EXECUTION_TIME(10) BKA(a) JOIN_ORDER(a) PARALLEL(x) */ * FROM a",
+			&sql.QueryHints{},
+			3,
+		},
+		{
+			"SELECT /*+ NO_INDEX(a idx1) */ * FROM a USE INDEX (idx2), b FORCE INDEX (idx3, idx4)" +
+				" WHERE a.x IN (SELECT y FROM c IGNORE INDEX (idx6))",
+			&sql.QueryHints{
+				NoIndex: map[string][]string{
+					"a": {"idx1"},
+					"c": {"idx6"},
+				},
+				UseIndex: map[string][]string{
+					"a": {"idx2"},
+					"b": {"idx3", "idx4"},
+				},
+				ForceIndex: map[string]bool{"b": true},
+			},
+			0,
+		},
+		{"SELECT /* JOIN_ORDER(b, a) */ * FROM a, b", nil, 0},
+		{"SELECT * FROM a, b", nil, 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			ctx := sql.NewEmptyContext()
+			parsed, err := Parse(ctx, tt.query)
+			require.NoError(err)
+			require.Equal(tt.expected, Hints(ctx, tt.query, parsed))
+			require.Len(ctx.Warnings(), tt.warnings)
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+func parseLoadData(s string) (sql.Node, error) {
+	r := bufio.NewReader(strings.NewReader(s))
+
+	var local, replace bool
+	var file, db, table string
+	var columns []string
+	format := plan.DefaultLoadDataFormat()
+	err := parseFuncs{
+		expect("load"),
+		skipSpaces,
+		expect("data"),
+		skipSpaces,
+		readLoadDataModifiers(&local),
+		readStringLiteral(&file),
+		skipSpaces,
+		readLoadDataDuplicates(&replace),
+		expect("into"),
+		skipSpaces,
+		expect("table"),
+		skipSpaces,
+		readTableName(&db, &table),
+		skipSpaces,
+		readLoadDataCharset,
+		readLoadDataFields(&format),
+		readLoadDataLines(&format),
+		readLoadDataIgnore(&format),
+		readLoadDataColumns(&columns),
+		checkEOF,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewLoadData(
+		plan.NewUnresolvedTable(table, db),
+		file,
+		local,
+		replace,
+		columns,
+		format,
+	), nil
+}
+
+// nextIdent reads the next identifier and the spaces after it. If it's not
+// one of the given ones, it's unread and an empty string is returned.
+func nextIdent(rd *bufio.Reader, idents ...string) (string, error) {
+	var ident string
+	if err := readIdent(&ident)(rd); err != nil {
+		return "", err
+	}
+
+	for _, i := range idents {
+		if ident == i {
+			return ident, skipSpaces(rd)
+		}
+	}
+
+	unreadString(rd, ident)
+	return "", nil
+}
+
+// readLoadDataModifiers reads the modifiers before INFILE. LOW_PRIORITY and
+// CONCURRENT have no effect, as the table is not locked.
+func readLoadDataModifiers(local *bool) parseFunc {
+	return func(rd *bufio.Reader) error {
+		for {
+			ident, err := nextIdent(rd, "low_priority", "concurrent", "local", "infile")
+			if err != nil {
+				return err
+			}
+
+			switch ident {
+			case "local":
+				*local = true
+			case "infile":
+				return nil
+			case "":
+				return expect("infile")(rd)
+			}
+		}
+	}
+}
+
+func readLoadDataDuplicates(replace *bool) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, "replace", "ignore")
+		if err != nil {
+			return err
+		}
+
+		if ident == "ignore" {
+			return ErrUnsupportedFeature.New("LOAD DATA INFILE with IGNORE")
+		}
+
+		*replace = ident == "replace"
+		return nil
+	}
+}
+
+// readLoadDataCharset skips the character set of the file, as all of them
+// are read as UTF-8.
+func readLoadDataCharset(rd *bufio.Reader) error {
+	ident, err := nextIdent(rd, "character", "charset")
+	if err != nil || ident == "" {
+		return err
+	}
+
+	if ident == "character" {
+		steps := parseFuncs{expect("set"), skipSpaces}
+		if err := steps.exec(rd); err != nil {
+			return err
+		}
+	}
+
+	var charset string
+	return parseFuncs{readValue(&charset), skipSpaces}.exec(rd)
+}
+
+func readLoadDataFields(format *plan.LoadDataFormat) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, "fields", "columns")
+		if err != nil || ident == "" {
+			return err
+		}
+
+		for {
+			ident, err := nextIdent(rd, "terminated", "optionally", "enclosed", "escaped")
+			if err != nil {
+				return err
+			}
+
+			switch ident {
+			case "terminated":
+				err = readByString(&format.FieldsTerminatedBy)(rd)
+			case "optionally":
+				format.FieldsOptionallyEnclosed = true
+				err = parseFuncs{
+					expect("enclosed"),
+					skipSpaces,
+					readByString(&format.FieldsEnclosedBy),
+				}.exec(rd)
+			case "enclosed":
+				err = readByString(&format.FieldsEnclosedBy)(rd)
+			case "escaped":
+				err = readByString(&format.FieldsEscapedBy)(rd)
+			default:
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readLoadDataLines(format *plan.LoadDataFormat) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, "lines")
+		if err != nil || ident == "" {
+			return err
+		}
+
+		for {
+			ident, err := nextIdent(rd, "starting", "terminated")
+			if err != nil {
+				return err
+			}
+
+			switch ident {
+			case "starting":
+				err = readByString(&format.LinesStartingBy)(rd)
+			case "terminated":
+				err = readByString(&format.LinesTerminatedBy)(rd)
+			default:
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readLoadDataIgnore(format *plan.LoadDataFormat) parseFunc {
+	return func(rd *bufio.Reader) error {
+		ident, err := nextIdent(rd, "ignore")
+		if err != nil || ident == "" {
+			return err
+		}
+
+		var n string
+		err = parseFuncs{
+			readValue(&n),
+			skipSpaces,
+			oneOf("lines", "rows"),
+			skipSpaces,
+		}.exec(rd)
+		if err != nil {
+			return err
+		}
+
+		format.IgnoreLines, err = strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return errUnexpectedSyntax.New("number of lines", n)
+		}
+		return nil
+	}
+}
+
+func readLoadDataColumns(columns *[]string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		b, err := rd.Peek(1)
+		if err == io.EOF || (err == nil && b[0] != '(') {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := rd.Discard(1); err != nil {
+			return err
+		}
+
+		for {
+			var column string
+			err := parseFuncs{
+				skipSpaces,
+				readQuotableIdent(&column),
+				skipSpaces,
+			}.exec(rd)
+			if err != nil {
+				return err
+			}
+
+			if column == "" {
+				return errUnexpectedSyntax.New("column", "EOF")
+			}
+			*columns = append(*columns, column)
+
+			r, _, err := rd.ReadRune()
+			if err != nil {
+				return err
+			}
+
+			switch r {
+			case ',':
+				continue
+			case ')':
+				return skipSpaces(rd)
+			default:
+				return errUnexpectedSyntax.New(", or )", string(r))
+			}
+		}
+	}
+}
+
+// readByString reads the BY keyword followed by a string.
+func readByString(s *string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		return parseFuncs{
+			expect("by"),
+			skipSpaces,
+			readStringLiteral(s),
+			skipSpaces,
+		}.exec(rd)
+	}
+}
+
+// readTableName reads a table name, optionally qualified with its database.
+func readTableName(db, table *string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		if err := readQuotableIdent(table)(rd); err != nil {
+			return err
+		}
+
+		b, err := rd.Peek(1)
+		if err != nil || b[0] != '.' {
+			return nil
+		}
+
+		if _, err := rd.Discard(1); err != nil {
+			return err
+		}
+
+		*db = *table
+		return readQuotableIdent(table)(rd)
+	}
+}
+
+// readStringLiteral reads a string literal quoted with single or double
+// quotes, which contains backslash escape sequences and quotes written
+// twice.
+func readStringLiteral(s *string) parseFunc {
+	return func(rd *bufio.Reader) error {
+		quote, _, err := rd.ReadRune()
+		if err != nil {
+			return err
+		}
+
+		if quote != '\'' && quote != '"' {
+			return errUnexpectedSyntax.New("string", string(quote))
+		}
+
+		var buf bytes.Buffer
+		for {
+			r, _, err := rd.ReadRune()
+			if err == io.EOF {
+				return errUnexpectedSyntax.New(string(quote), "EOF")
+			}
+			if err != nil {
+				return err
+			}
+
+			switch r {
+			case '\\':
+				r, _, err = rd.ReadRune()
+				if err == io.EOF {
+					return errUnexpectedSyntax.New(string(quote), "EOF")
+				}
+				if err != nil {
+					return err
+				}
+
+				if e, ok := stringEscapes[r]; ok {
+					r = e
+				}
+			case quote:
+				b, err := rd.Peek(1)
+				if err != nil || rune(b[0]) != quote {
+					*s = buf.String()
+					return nil
+				}
+
+				if _, err := rd.Discard(1); err != nil {
+					return err
+				}
+			}
+
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// stringEscapes are the characters written with an escape sequence in
+// string literals, by the character following the backslash.
+var stringEscapes = map[rune]rune{
+	'0': 0,
+	'b': '\b',
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+	'Z': 0x1a,
+}
@@ -0,0 +1,70 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLoadData(t *testing.T) {
+	csv := plan.DefaultLoadDataFormat()
+	csv.FieldsTerminatedBy = ","
+	csv.FieldsEnclosedBy = `"`
+	csv.FieldsOptionallyEnclosed = true
+	csv.LinesTerminatedBy = "\r\n"
+	csv.IgnoreLines = 1
+
+	prefixed := plan.DefaultLoadDataFormat()
+	prefixed.FieldsEscapedBy = ""
+	prefixed.LinesStartingBy = "xxx"
+	prefixed.LinesTerminatedBy = ";"
+
+	testCases := []struct {
+		query  string
+		result sql.Node
+		err    bool
+	}{
+		{
+			"LOAD DATA INFILE '/tmp/Data.txt' INTO TABLE mytable",
+			plan.NewLoadData(plan.NewUnresolvedTable("mytable", ""), "/tmp/Data.txt", false, false, nil, plan.DefaultLoadDataFormat()),
+			false,
+		},
+		{
+			"load data low_priority local infile \"data.csv\" replace into table mydb.`mytable` " +
+				"character set utf8mb4 " +
+				`fields terminated by ',' optionally enclosed by '"' ` +
+				`lines terminated by '\r\n' ignore 1 lines (a, ` + "`b`)",
+			plan.NewLoadData(plan.NewUnresolvedTable("mytable", "mydb"), "data.csv", true, true, []string{"a", "b"}, csv),
+			false,
+		},
+		{
+			"LOAD DATA INFILE 'data.txt' INTO TABLE mytable COLUMNS ESCAPED BY '' LINES STARTING BY 'xxx' TERMINATED BY ';'",
+			plan.NewLoadData(plan.NewUnresolvedTable("mytable", ""), "data.txt", false, false, nil, prefixed),
+			false,
+		},
+		{"LOAD DATA 'data.txt' INTO TABLE mytable", nil, true},
+		{"LOAD DATA INFILE data.txt INTO TABLE mytable", nil, true},
+		{"LOAD DATA INFILE 'data.txt INTO TABLE mytable", nil, true},
+		{"LOAD DATA INFILE 'data.txt' IGNORE INTO TABLE mytable", nil, true},
+		{"LOAD DATA INFILE 'data.txt' INTO TABLE mytable IGNORE x LINES", nil, true},
+		{"LOAD DATA INFILE 'data.txt' INTO TABLE mytable (a, b", nil, true},
+		{"LOAD DATA INFILE 'data.txt' INTO TABLE mytable SET a = 1", nil, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := Parse(sql.NewEmptyContext(), tt.query)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.result, result)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package parse
 
 import (
 	"bufio"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,7 +10,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/opentracing/opentracing-go"
 	"github.com/src-d/go-mysql-server/sql"
 	"github.com/src-d/go-mysql-server/sql/expression"
 	"github.com/src-d/go-mysql-server/sql/expression/function"
@@ -31,23 +31,40 @@ var (
 
 	// ErrInvalidSortOrder is returned when a sort order is not valid.
 	ErrInvalidSortOrder = errors.NewKind("invalid sort order: %s")
+
+	// ErrGroupByColumnIndex is returned when a group by refers to a column
+	// of the select list by a position that is not in it.
+	ErrGroupByColumnIndex = errors.NewKind("unknown column %d in group by clause")
 )
 
 var (
-	describeTablesRegex  = regexp.MustCompile(`^(describe|desc)\s+table\s+(.*)`)
-	createIndexRegex     = regexp.MustCompile(`^create\s+index\s+`)
-	dropIndexRegex       = regexp.MustCompile(`^drop\s+index\s+`)
-	showIndexRegex       = regexp.MustCompile(`^show\s+(index|indexes|keys)\s+(from|in)\s+\S+\s*`)
-	showCreateRegex      = regexp.MustCompile(`^show create\s+\S+\s*`)
-	showVariablesRegex   = regexp.MustCompile(`^show\s+(.*)?variables\s*`)
-	showWarningsRegex    = regexp.MustCompile(`^show\s+warnings\s*`)
-	showCollationRegex   = regexp.MustCompile(`^show\s+collation\s*`)
-	describeRegex        = regexp.MustCompile(`^(describe|desc|explain)\s+(.*)\s+`)
-	fullProcessListRegex = regexp.MustCompile(`^show\s+(full\s+)?processlist$`)
-	unlockTablesRegex    = regexp.MustCompile(`^unlock\s+tables$`)
-	lockTablesRegex      = regexp.MustCompile(`^lock\s+tables\s`)
-	setRegex             = regexp.MustCompile(`^set\s+`)
-	createViewRegex      = regexp.MustCompile(`^create\s+view\s+`)
+	describeTablesRegex   = regexp.MustCompile(`^(describe|desc)\s+table\s+(.*)`)
+	createIndexRegex      = regexp.MustCompile(`^create\s+index\s+`)
+	dropIndexRegex        = regexp.MustCompile(`^drop\s+index\s+`)
+	showIndexRegex        = regexp.MustCompile(`^show\s+(index|indexes|keys)\s+(from|in)\s+\S+\s*`)
+	showCreateRegex       = regexp.MustCompile(`^show create\s+\S+\s*`)
+	showVariablesRegex    = regexp.MustCompile(`^show\s+(.*)?variables\s*`)
+	showWarningsRegex     = regexp.MustCompile(`^show\s+warnings\s*`)
+	showCollationRegex    = regexp.MustCompile(`^show\s+collation\s*`)
+	describeRegex         = regexp.MustCompile(`^(describe|desc|explain)\s+(.*)\s+`)
+	fullProcessListRegex  = regexp.MustCompile(`^show\s+(full\s+)?processlist$`)
+	unlockTablesRegex     = regexp.MustCompile(`^unlock\s+tables$`)
+	lockTablesRegex       = regexp.MustCompile(`^lock\s+tables\s`)
+	setRegex              = regexp.MustCompile(`^set\s+`)
+	createViewRegex       = regexp.MustCompile(`^create\s+view\s+`)
+	analyzeTableRegex     = regexp.MustCompile(`^analyze\s+((no_write_to_binlog|local)\s+)?table\s+`)
+	createPartitionRegex  = regexp.MustCompile(`(?s)^create\s+table\s+.*\)\s*partition\s+by\s+`)
+	grantRegex            = regexp.MustCompile(`^grant\s+`)
+	revokeRegex           = regexp.MustCompile(`^revoke\s+`)
+	showGrantsRegex       = regexp.MustCompile(`^show\s+grants\b`)
+	showMasterStatusRegex = regexp.MustCompile(`^show\s+master\s+status$`)
+	showBinaryLogsRegex   = regexp.MustCompile(`^show\s+(binary|master)\s+logs$`)
+	loadDataRegex         = regexp.MustCompile(`^load\s+data\s`)
+	createDatabaseRegex   = regexp.MustCompile(`^create\s+(database|schema)\s`)
+	dropDatabaseRegex     = regexp.MustCompile(`^drop\s+(database|schema)\s`)
+	dumpRegex             = regexp.MustCompile(`^dump\s+(database|databases)\b`)
+	intoFileRegex         = regexp.MustCompile(`(?s)^[\s(]*select\s.*\binto\s+(outfile|dumpfile)\b`)
+	calcFoundRowsRegex    = regexp.MustCompile(`(?i)^(select\s+(?:(?:all|distinct|distinctrow|high_priority|straight_join|sql_small_result|sql_big_result|sql_buffer_result|sql_cache|sql_no_cache)\s+)*)sql_calc_found_rows\s+`)
 )
 
 // These constants aren't exported from vitess for some reason. This could be removed if we changed this.
@@ -62,19 +79,12 @@ const (
 
 // Parse parses the given SQL sentence and returns the corresponding node.
 func Parse(ctx *sql.Context, query string) (sql.Node, error) {
-	span, ctx := ctx.Span("parse", opentracing.Tag{Key: "query", Value: query})
-	defer span.Finish()
-
-	s := strings.TrimSpace(removeComments(query))
-	if strings.HasSuffix(s, ";") {
-		s = s[:len(s)-1]
-	}
-
-	if s == "" {
-		ctx.Warn(0, "query was empty after trimming comments, so it will be ignored")
-		return plan.Nothing, nil
-	}
+	return (*Dialect)(nil).Parse(ctx, query)
+}
 
+// parse parses the given SQL sentence, which has no comments or trailing
+// semicolon, with the standard statements.
+func parse(ctx *sql.Context, s string) (sql.Node, error) {
 	lowerQuery := strings.ToLower(s)
 
 	switch true {
@@ -98,6 +108,10 @@ func Parse(ctx *sql.Context, query string) (sql.Node, error) {
 		return parseDescribeQuery(ctx, s)
 	case fullProcessListRegex.MatchString(lowerQuery):
 		return plan.NewShowProcessList(), nil
+	case showMasterStatusRegex.MatchString(lowerQuery):
+		return plan.NewShowMasterStatus(), nil
+	case showBinaryLogsRegex.MatchString(lowerQuery):
+		return plan.NewShowBinaryLogs(), nil
 	case unlockTablesRegex.MatchString(lowerQuery):
 		return plan.NewUnlockTables(), nil
 	case lockTablesRegex.MatchString(lowerQuery):
@@ -107,14 +121,60 @@ func Parse(ctx *sql.Context, query string) (sql.Node, error) {
 	case createViewRegex.MatchString(lowerQuery):
 		// CREATE VIEW parses as a CREATE DDL statement with an empty table spec
 		return nil, ErrUnsupportedFeature.New("CREATE VIEW")
+	case analyzeTableRegex.MatchString(lowerQuery):
+		return parseAnalyzeTable(ctx, s)
+	case createPartitionRegex.MatchString(lowerQuery):
+		return parseCreatePartitionedTable(ctx, s)
+	case grantRegex.MatchString(lowerQuery):
+		return parseGrant(s)
+	case revokeRegex.MatchString(lowerQuery):
+		return parseRevoke(s)
+	case showGrantsRegex.MatchString(lowerQuery):
+		return parseShowGrants(s)
+	case loadDataRegex.MatchString(lowerQuery):
+		return parseLoadData(s)
+	case createDatabaseRegex.MatchString(lowerQuery):
+		return parseCreateDatabase(s)
+	case dropDatabaseRegex.MatchString(lowerQuery):
+		return parseDropDatabase(s)
+	case dumpRegex.MatchString(lowerQuery):
+		return parseDump(s)
+	case intoFileRegex.MatchString(lowerQuery) && intoFileClause(s) >= 0:
+		return parseSelectIntoFile(ctx, s)
+	case calcFoundRowsRegex.MatchString(s):
+		return parseCalcFoundRows(ctx, s)
+	}
+
+	s = rewriteGroupingSets(rewriteFromClauses(s))
+	stmt, err := sqlparser.Parse(s)
+	if err != nil {
+		return nil, err
 	}
 
+	return convert(ctx, stmt, s)
+}
+
+// parseCalcFoundRows parses a SELECT with the SQL_CALC_FOUND_ROWS modifier,
+// which vitess does not support, by removing the modifier and flagging the
+// outermost LIMIT so it counts all the rows it skips.
+func parseCalcFoundRows(ctx *sql.Context, s string) (sql.Node, error) {
+	s = rewriteGroupingSets(rewriteFromClauses(calcFoundRowsRegex.ReplaceAllString(s, "$1")))
+
 	stmt, err := sqlparser.Parse(s)
 	if err != nil {
 		return nil, err
 	}
 
-	return convert(ctx, stmt, s)
+	node, err := convert(ctx, stmt, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit, ok := node.(*plan.Limit); ok {
+		limit.CalcFoundRows = true
+	}
+
+	return node, nil
 }
 
 func parseDescribeTables(s string) (sql.Node, error) {
@@ -168,6 +228,10 @@ func convert(ctx *sql.Context, stmt sqlparser.Statement, query string) (sql.Node
 		return convertSet(ctx, n)
 	case *sqlparser.Use:
 		return convertUse(n)
+	case *sqlparser.Begin:
+		return plan.NewStartTransaction(), nil
+	case *sqlparser.Commit:
+		return plan.NewCommit(), nil
 	case *sqlparser.Rollback:
 		return plan.NewRollback(), nil
 	case *sqlparser.Delete:
@@ -518,6 +582,24 @@ func tableSpecToSchema(tableSpec *sqlparser.TableSpec) (sql.Schema, error) {
 	return schema, nil
 }
 
+// timePrecision returns the number of digits of fractional seconds given
+// as the length of a time type, as in DATETIME(6).
+func timePrecision(length *sqlparser.SQLVal) (int, error) {
+	if length == nil {
+		return 0, nil
+	}
+
+	precision, err := strconv.Atoi(string(length.Val))
+	if err != nil {
+		return 0, err
+	}
+
+	if precision > sql.MaxTimePrecision {
+		return 0, sql.ErrTooBigPrecision.New(precision, sql.MaxTimePrecision)
+	}
+	return precision, nil
+}
+
 // getColumn returns the sql.Column for the column definition given, as part of a create table statement.
 func getColumn(cd *sqlparser.ColumnDefinition, indexes []*sqlparser.IndexDefinition) (*sql.Column, error) {
 	typ := cd.Type
@@ -526,6 +608,23 @@ func getColumn(cd *sqlparser.ColumnDefinition, indexes []*sqlparser.IndexDefinit
 		return nil, err
 	}
 
+	if sql.IsTime(internalTyp) || sql.IsTimeOfDay(internalTyp) {
+		precision, err := timePrecision(typ.Length)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case precision == 0:
+		case sql.IsTimestamp(internalTyp):
+			internalTyp = sql.TimestampWithPrecision(precision)
+		case sql.IsDatetime(internalTyp):
+			internalTyp = sql.DatetimeWithPrecision(precision)
+		case sql.IsTimeOfDay(internalTyp):
+			internalTyp = sql.TimeWithPrecision(precision)
+		}
+	}
+
 	// Primary key info can either be specified in the column's type info (for in-line declarations), or in a slice of
 	// indexes attached to the table def. We have to check both places to find if a column is part of the primary key
 	isPkey := cd.Type.KeyOpt == colKeyPrimary
@@ -545,10 +644,11 @@ func getColumn(cd *sqlparser.ColumnDefinition, indexes []*sqlparser.IndexDefinit
 	}
 
 	return &sql.Column{
-		Nullable:   !bool(typ.NotNull),
-		Type:       internalTyp,
-		Name:       cd.Name.String(),
-		PrimaryKey: isPkey,
+		Nullable:      !bool(typ.NotNull),
+		Type:          internalTyp,
+		Name:          cd.Name.String(),
+		PrimaryKey:    isPkey,
+		AutoIncrement: bool(typ.Autoincrement),
 		// TODO
 		Default: nil,
 	}, nil
@@ -616,9 +716,13 @@ func tableExprsToTable(
 		return nodes[0], nil
 	}
 
-	join := plan.NewCrossJoin(nodes[0], nodes[1])
-	for i := 2; i < len(nodes); i++ {
-		join = plan.NewCrossJoin(join, nodes[i])
+	join := nodes[0]
+	for i := 1; i < len(nodes); i++ {
+		if isLateral(te[i]) {
+			join = plan.NewLateralJoin(join, nodes[i], expression.NewLiteral(true, sql.Boolean))
+		} else {
+			join = plan.NewCrossJoin(join, nodes[i])
+		}
 	}
 
 	return join, nil
@@ -636,12 +740,56 @@ func tableExprToTable(
 		switch e := t.Expr.(type) {
 		case sqlparser.TableName:
 			node := plan.NewUnresolvedTable(e.Name.String(), e.Qualifier.String())
+			if t.Hints != nil {
+				hint, err := convertIndexHints(t.Hints)
+				if err != nil {
+					return nil, err
+				}
+				node.IndexHints = []sql.IndexHint{hint}
+			}
+
 			if !t.As.IsEmpty() {
 				return plan.NewTableAlias(t.As.String(), node), nil
 			}
 
 			return node, nil
 		case *sqlparser.Subquery:
+			table, ok, err := asOfToTable(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				if t.As.IsEmpty() || t.As.String() == table.Name() {
+					return table, nil
+				}
+				return plan.NewTableAlias(t.As.String(), table), nil
+			}
+
+			table, ok, err = tableSampleToTable(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				if t.As.IsEmpty() || t.As.String() == table.Name() {
+					return table, nil
+				}
+				return plan.NewTableAlias(t.As.String(), table), nil
+			}
+
+			fn, ok, err := tableFunctionToTable(ctx, e)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				if t.As.IsEmpty() || t.As.String() == fn.Name() {
+					return fn, nil
+				}
+				return plan.NewTableAlias(t.As.String(), fn), nil
+			}
+
 			node, err := convert(ctx, e.Select, "")
 			if err != nil {
 				return nil, err
@@ -672,6 +820,10 @@ func tableExprToTable(
 			return nil, err
 		}
 
+		if isLateral(t.RightExpr) {
+			return lateralJoinToJoin(ctx, t, left, right)
+		}
+
 		if t.Join == sqlparser.NaturalJoinStr {
 			return plan.NewNaturalJoin(left, right), nil
 		}
@@ -698,6 +850,37 @@ func tableExprToTable(
 	}
 }
 
+// lateralJoinToJoin converts a join with a LATERAL derived table on its
+// right side, which can only be an inner or a left join. Joins without a
+// condition join all the rows.
+func lateralJoinToJoin(
+	ctx *sql.Context,
+	t *sqlparser.JoinTableExpr,
+	left, right sql.Node,
+) (sql.Node, error) {
+	if len(t.Condition.Using) > 0 {
+		return nil, ErrUnsupportedFeature.New("USING with LATERAL")
+	}
+
+	var cond sql.Expression = expression.NewLiteral(true, sql.Boolean)
+	if t.Condition.On != nil {
+		var err error
+		cond, err = exprToExpression(ctx, t.Condition.On)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch t.Join {
+	case sqlparser.JoinStr:
+		return plan.NewLateralJoin(left, right, cond), nil
+	case sqlparser.LeftJoinStr:
+		return plan.NewLeftLateralJoin(left, right, cond), nil
+	default:
+		return nil, ErrUnsupportedFeature.New(t.Join + " LATERAL")
+	}
+}
+
 func whereToFilter(ctx *sql.Context, w *sqlparser.Where, child sql.Node) (*plan.Filter, error) {
 	c, err := exprToExpression(ctx, w.Expr)
 	if err != nil {
@@ -850,7 +1033,7 @@ func selectToProjectOrGroupBy(
 	}
 
 	if isAgg {
-		groupingExprs, err := groupByToExpressions(ctx, g)
+		groupingExprs, sets, err := groupByToGroupingSets(ctx, g)
 		if err != nil {
 			return nil, err
 		}
@@ -858,20 +1041,25 @@ func selectToProjectOrGroupBy(
 		agglen := int64(len(selectExprs))
 		for i, ge := range groupingExprs {
 			// if GROUP BY index
-			if l, ok := ge.(*expression.Literal); ok && sql.IsNumber(l.Type()) {
+			if l, ok := ge.(*expression.Literal); ok && sql.IsInteger(l.Type()) {
 				if i64, err := sql.Int64.Convert(l.Value()); err == nil {
-					if idx, ok := i64.(int64); ok && idx > 0 && idx <= agglen {
-						aggexpr := selectExprs[idx-1]
-						if alias, ok := aggexpr.(*expression.Alias); ok {
-							aggexpr = expression.NewUnresolvedColumn(alias.Name())
-						}
-						groupingExprs[i] = aggexpr
+					idx, _ := i64.(int64)
+					if idx <= 0 || idx > agglen {
+						return nil, ErrGroupByColumnIndex.New(idx)
+					}
+
+					// The expression is grouped instead of its alias, which
+					// may be the name of another column.
+					aggexpr := selectExprs[idx-1]
+					if alias, ok := aggexpr.(*expression.Alias); ok {
+						aggexpr = alias.Child
 					}
+					groupingExprs[i] = aggexpr
 				}
 			}
 		}
 
-		return plan.NewGroupBy(selectExprs, groupingExprs, child), nil
+		return plan.NewGroupBy(selectExprs, groupingExprs, child).WithGroupingSets(sets), nil
 	}
 
 	return plan.NewProject(selectExprs, child), nil
@@ -1000,6 +1188,16 @@ func exprToExpression(ctx *sql.Context, e sqlparser.Expr) (sql.Expression, error
 			return nil, err
 		}
 
+		switch strings.ToLower(v.Type.Type) {
+		case expression.ConvertToDatetime, expression.ConvertToTime:
+			precision, err := timePrecision(v.Type.Length)
+			if err != nil {
+				return nil, err
+			}
+
+			return expression.NewConvertWithPrecision(expr, strings.ToLower(v.Type.Type), precision), nil
+		}
+
 		return expression.NewConvert(expr, v.Type.Type), nil
 	case *sqlparser.RangeCond:
 		val, err := exprToExpression(ctx, v.Left)
@@ -1046,6 +1244,12 @@ func exprToExpression(ctx *sql.Context, e sqlparser.Expr) (sql.Expression, error
 			return nil, err
 		}
 		return expression.NewSubquery(node), nil
+	case *sqlparser.ExistsExpr:
+		node, err := convert(ctx, v.Subquery.Select, "")
+		if err != nil {
+			return nil, err
+		}
+		return expression.NewExists(expression.NewSubquery(node)), nil
 	case *sqlparser.CaseExpr:
 		return caseExprToExpression(ctx, v)
 	case *sqlparser.IntervalExpr:
@@ -1055,7 +1259,7 @@ func exprToExpression(ctx *sql.Context, e sqlparser.Expr) (sql.Expression, error
 
 func isAggregateFunc(v *sqlparser.FuncExpr) bool {
 	switch v.Name.Lowered() {
-	case "first", "last":
+	case "first", "last", "json_arrayagg", "json_objectagg":
 		return true
 	}
 
@@ -1116,22 +1320,46 @@ func convertVal(v *sqlparser.SQLVal) (sql.Expression, error) {
 			v = strings.Trim(v[1:], "'")
 		}
 
-		return convertInt(v, 16)
+		// Numbers with an odd number of digits have a leading zero.
+		if len(v)%2 != 0 {
+			v = "0" + v
+		}
+
+		val, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, err
+		}
+		return expression.NewHexLiteral(val), nil
 	case sqlparser.HexVal:
 		val, err := v.HexDecode()
 		if err != nil {
 			return nil, err
 		}
-		return expression.NewLiteral(val, sql.Blob), nil
+		return expression.NewHexLiteral(val), nil
 	case sqlparser.ValArg:
 		return expression.NewLiteral(string(v.Val), sql.Text), nil
 	case sqlparser.BitVal:
-		return expression.NewLiteral(v.Val[0] == '1', sql.Boolean), nil
+		return expression.NewHexLiteral(decodeBits(string(v.Val))), nil
 	}
 
 	return nil, ErrInvalidSQLValType.New(v.Type)
 }
 
+// decodeBits returns the bytes of the bits of a bit-value literal, whose
+// first byte has leading zeros if the number of bits is not a multiple of 8.
+func decodeBits(bits string) []byte {
+	if len(bits)%8 != 0 {
+		bits = strings.Repeat("0", 8-len(bits)%8) + bits
+	}
+
+	val := make([]byte, len(bits)/8)
+	for i := range val {
+		b, _ := strconv.ParseUint(bits[i*8:i*8+8], 2, 8)
+		val[i] = byte(b)
+	}
+	return val
+}
+
 func isExprToExpression(ctx *sql.Context, c *sqlparser.IsExpr) (sql.Expression, error) {
 	e, err := exprToExpression(ctx, c.Expr)
 	if err != nil {
@@ -1247,6 +1475,42 @@ func unaryExprToExpression(ctx *sql.Context, e *sqlparser.UnaryExpr) (sql.Expres
 
 		return expression.NewUnaryMinus(expr), nil
 
+	case sqlparser.Utf8mb4Str:
+		expr, err := exprToExpression(ctx, e.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		// Strings and hexadecimal literals with a character set introducer
+		// are strings.
+		if l, ok := expr.(*expression.Literal); ok {
+			switch v := l.Value().(type) {
+			case string:
+				return expression.NewLiteral(v, sql.Text), nil
+			case []byte:
+				return expression.NewLiteral(string(v), sql.Text), nil
+			}
+		}
+
+		return expression.NewConvert(expr, expression.ConvertToChar), nil
+
+	case sqlparser.UBinaryStr:
+		expr, err := exprToExpression(ctx, e.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		if l, ok := expr.(*expression.Literal); ok {
+			switch v := l.Value().(type) {
+			case string:
+				return expression.NewLiteral([]byte(v), sql.Blob), nil
+			case []byte:
+				return expression.NewLiteral(v, sql.Blob), nil
+			}
+		}
+
+		return expression.NewConvert(expr, expression.ConvertToBinary), nil
+
 	default:
 		return nil, ErrUnsupportedFeature.New("unary operator: " + e.Operator)
 	}
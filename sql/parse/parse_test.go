@@ -51,6 +51,27 @@ var fixtures = map[string]sql.Node{
 			Nullable: true,
 		}},
 	),
+	`CREATE TABLE t1(a DATETIME(6), b TIMESTAMP(3), c TIME(1), d TIME)`: plan.NewCreateTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.DatetimeWithPrecision(6),
+			Nullable: true,
+		}, {
+			Name:     "b",
+			Type:     sql.TimestampWithPrecision(3),
+			Nullable: true,
+		}, {
+			Name:     "c",
+			Type:     sql.TimeWithPrecision(1),
+			Nullable: true,
+		}, {
+			Name:     "d",
+			Type:     sql.Time,
+			Nullable: true,
+		}},
+	),
 	`CREATE TABLE t1(a INTEGER NOT NULL PRIMARY KEY, b TEXT)`: plan.NewCreateTable(
 		sql.UnresolvedDatabase(""),
 		"t1",
@@ -96,6 +117,54 @@ var fixtures = map[string]sql.Node{
 			PrimaryKey: true,
 		}},
 	),
+	`CREATE TABLE t1(a INTEGER, b TEXT) PARTITION BY RANGE (a) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN MAXVALUE)`: plan.NewCreatePartitionedTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.Int32,
+			Nullable: true,
+		}, {
+			Name:     "b",
+			Type:     sql.Text,
+			Nullable: true,
+		}},
+		&sql.Partitioning{
+			Type:   sql.RangePartitioning,
+			Column: "a",
+			Definitions: []sql.PartitionDefinition{
+				{Name: "p0", LessThan: int8(10)},
+				{Name: "p1"},
+			},
+		},
+	),
+	`CREATE TABLE t1(a INTEGER) PARTITION BY LIST (a) (PARTITION p0 VALUES IN (1, 2), PARTITION p1 VALUES IN (3, NULL))`: plan.NewCreatePartitionedTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.Int32,
+			Nullable: true,
+		}},
+		&sql.Partitioning{
+			Type:   sql.ListPartitioning,
+			Column: "a",
+			Definitions: []sql.PartitionDefinition{
+				{Name: "p0", Values: []interface{}{int8(1), int8(2)}},
+				{Name: "p1", Values: []interface{}{int8(3), nil}},
+			},
+		},
+	),
+	`CREATE TABLE t1(a INTEGER) PARTITION BY HASH (a) PARTITIONS 2`: plan.NewCreatePartitionedTable(
+		sql.UnresolvedDatabase(""),
+		"t1",
+		sql.Schema{{
+			Name:     "a",
+			Type:     sql.Int32,
+			Nullable: true,
+		}},
+		sql.NewHashPartitioning("a", 2),
+	),
 	`DROP TABLE foo;`: plan.NewDropTable(
 		sql.UnresolvedDatabase(""), false, "foo",
 	),
@@ -188,6 +257,23 @@ var fixtures = map[string]sql.Node{
 			plan.NewUnresolvedTable("foo", ""),
 		),
 	),
+	`SELECT SQL_CALC_FOUND_ROWS foo, bar FROM foo LIMIT 10;`: &plan.Limit{
+		UnaryNode: plan.UnaryNode{Child: plan.NewProject(
+			[]sql.Expression{
+				expression.NewUnresolvedColumn("foo"),
+				expression.NewUnresolvedColumn("bar"),
+			},
+			plan.NewUnresolvedTable("foo", ""),
+		)},
+		Limit:         10,
+		CalcFoundRows: true,
+	},
+	`select distinct sql_calc_found_rows foo from foo`: plan.NewDistinct(
+		plan.NewProject(
+			[]sql.Expression{expression.NewUnresolvedColumn("foo")},
+			plan.NewUnresolvedTable("foo", ""),
+		),
+	),
 	`SELECT foo, bar FROM foo ORDER BY baz DESC;`: plan.NewSort(
 		[]plan.SortField{{Column: expression.NewUnresolvedColumn("baz"), Order: plan.Descending, NullOrdering: plan.NullsFirst}},
 		plan.NewProject(
@@ -275,6 +361,16 @@ var fixtures = map[string]sql.Node{
 		},
 		plan.NewUnresolvedTable("t1", ""),
 	),
+	`SELECT foo AS bar, bar AS foo FROM t1 GROUP BY 2;`: plan.NewGroupBy(
+		[]sql.Expression{
+			expression.NewAlias(expression.NewUnresolvedColumn("foo"), "bar"),
+			expression.NewAlias(expression.NewUnresolvedColumn("bar"), "foo"),
+		},
+		[]sql.Expression{
+			expression.NewUnresolvedColumn("bar"),
+		},
+		plan.NewUnresolvedTable("t1", ""),
+	),
 	`SELECT COUNT(*) FROM t1;`: plan.NewGroupBy(
 		[]sql.Expression{
 			expression.NewUnresolvedFunction("count", true,
@@ -508,13 +604,35 @@ var fixtures = map[string]sql.Node{
 	),
 	`SELECT 0x01AF`: plan.NewProject(
 		[]sql.Expression{
-			expression.NewLiteral(int16(431), sql.Int16),
+			expression.NewHexLiteral([]byte{0x01, 0xAF}),
+		},
+		plan.NewUnresolvedTable("dual", ""),
+	),
+	`SELECT 0x1AF`: plan.NewProject(
+		[]sql.Expression{
+			expression.NewHexLiteral([]byte{0x01, 0xAF}),
 		},
 		plan.NewUnresolvedTable("dual", ""),
 	),
 	`SELECT X'41'`: plan.NewProject(
 		[]sql.Expression{
-			expression.NewLiteral([]byte{'A'}, sql.Blob),
+			expression.NewHexLiteral([]byte{'A'}),
+		},
+		plan.NewUnresolvedTable("dual", ""),
+	),
+	`SELECT b'1000001', B'100000101000010'`: plan.NewProject(
+		[]sql.Expression{
+			expression.NewHexLiteral([]byte{'A'}),
+			expression.NewHexLiteral([]byte{'A', 'B'}),
+		},
+		plan.NewUnresolvedTable("dual", ""),
+	),
+	`SELECT _utf8mb4'abc', _utf8mb4 x'41', _binary'abc', _utf8mb4 a`: plan.NewProject(
+		[]sql.Expression{
+			expression.NewLiteral("abc", sql.Text),
+			expression.NewLiteral("A", sql.Text),
+			expression.NewLiteral([]byte("abc"), sql.Blob),
+			expression.NewConvert(expression.NewUnresolvedColumn("a"), expression.ConvertToChar),
 		},
 		plan.NewUnresolvedTable("dual", ""),
 	),
@@ -569,6 +687,13 @@ var fixtures = map[string]sql.Node{
 		},
 		plan.NewUnresolvedTable("foo", ""),
 	),
+	`SELECT CAST(a AS DATETIME(6)), CAST(b AS TIME) FROM foo`: plan.NewProject(
+		[]sql.Expression{
+			expression.NewConvertWithPrecision(expression.NewUnresolvedColumn("a"), expression.ConvertToDatetime, 6),
+			expression.NewConvertWithPrecision(expression.NewUnresolvedColumn("b"), expression.ConvertToTime, 0),
+		},
+		plan.NewUnresolvedTable("foo", ""),
+	),
 	`SELECT 2 = 2 FROM foo`: plan.NewProject(
 		[]sql.Expression{
 			expression.NewEquals(expression.NewLiteral(int8(2), sql.Int8), expression.NewLiteral(int8(2), sql.Int8)),
@@ -637,6 +762,18 @@ var fixtures = map[string]sql.Node{
 			plan.NewUnresolvedTable("foo", ""),
 		),
 	),
+	`SELECT * FROM foo WHERE NOT EXISTS (SELECT 1)`: plan.NewProject(
+		[]sql.Expression{expression.NewStar()},
+		plan.NewFilter(
+			expression.NewNot(
+				expression.NewExists(expression.NewSubquery(plan.NewProject(
+					[]sql.Expression{expression.NewLiteral(int8(1), sql.Int8)},
+					plan.NewUnresolvedTable("dual", ""),
+				))),
+			),
+			plan.NewUnresolvedTable("foo", ""),
+		),
+	),
 	`SELECT a, b FROM t ORDER BY 2, 1`: plan.NewSort(
 		[]plan.SortField{
 			{
@@ -791,6 +928,9 @@ var fixtures = map[string]sql.Node{
 	),
 	`SHOW FULL PROCESSLIST`: plan.NewShowProcessList(),
 	`SHOW PROCESSLIST`:      plan.NewShowProcessList(),
+	`SHOW MASTER STATUS`:    plan.NewShowMasterStatus(),
+	`SHOW BINARY LOGS`:      plan.NewShowBinaryLogs(),
+	`SHOW MASTER LOGS`:      plan.NewShowBinaryLogs(),
 	`SELECT @@allowed_max_packet`: plan.NewProject([]sql.Expression{
 		expression.NewUnresolvedColumn("@@allowed_max_packet"),
 	}, plan.NewUnresolvedTable("dual", "")),
@@ -1006,6 +1146,16 @@ var fixtures = map[string]sql.Node{
 		{Table: plan.NewUnresolvedTable("bar", ""), Write: true},
 		{Table: plan.NewUnresolvedTable("baz", "")},
 	}),
+	`ANALYZE TABLE foo`: plan.NewAnalyzeTable(
+		plan.NewUnresolvedTable("foo", ""),
+	),
+	`ANALYZE LOCAL TABLE foo, bar.baz`: plan.NewAnalyzeTable(
+		plan.NewUnresolvedTable("foo", ""),
+		plan.NewUnresolvedTable("baz", "bar"),
+	),
+	"ANALYZE NO_WRITE_TO_BINLOG TABLE `foo`": plan.NewAnalyzeTable(
+		plan.NewUnresolvedTable("foo", ""),
+	),
 	`SHOW CREATE DATABASE foo`:               plan.NewShowCreateDatabase(sql.UnresolvedDatabase("foo"), false),
 	`SHOW CREATE SCHEMA foo`:                 plan.NewShowCreateDatabase(sql.UnresolvedDatabase("foo"), false),
 	`SHOW CREATE DATABASE IF NOT EXISTS foo`: plan.NewShowCreateDatabase(sql.UnresolvedDatabase("foo"), true),
@@ -1098,6 +1248,9 @@ var fixtures = map[string]sql.Node{
 		plan.NewShowCollation(),
 	),
 	`ROLLBACK`:                               plan.NewRollback(),
+	`BEGIN`:                                  plan.NewStartTransaction(),
+	`START TRANSACTION`:                      plan.NewStartTransaction(),
+	`COMMIT`:                                 plan.NewCommit(),
 	"SHOW CREATE TABLE `mytable`":            plan.NewShowCreateTable("", nil, "mytable"),
 	"SHOW CREATE TABLE `mydb`.`mytable`":     plan.NewShowCreateTable("mydb", nil, "mytable"),
 	"SHOW CREATE TABLE `my.table`":           plan.NewShowCreateTable("", nil, "my.table"),
@@ -1280,6 +1433,7 @@ func TestParse(t *testing.T) {
 }
 
 var fixturesErrors = map[string]*errors.Kind{
+	`CREATE TABLE t1(a INTEGER) PARTITION BY KEY (a)`: errInvalidPartitionClause,
 	`SHOW METHEMONEY`:                           ErrUnsupportedFeature,
 	`LOCK TABLES foo AS READ`:                   errUnexpectedSyntax,
 	`LOCK TABLES foo LOW_PRIORITY READ`:         errUnexpectedSyntax,
@@ -1297,6 +1451,10 @@ var fixturesErrors = map[string]*errors.Kind{
 	`SELECT '2018-05-01' + (INTERVAL 1 DAY + INTERVAL 1 DAY)`: ErrUnsupportedSyntax,
 	`SELECT AVG(DISTINCT foo) FROM b`:                         ErrUnsupportedSyntax,
 	`CREATE VIEW view1 AS SELECT x FROM t1 WHERE x>0`:         ErrUnsupportedFeature,
+	`CREATE TABLE t1(a DATETIME(7))`:                          sql.ErrTooBigPrecision,
+	`SELECT CAST(a AS TIME(9)) FROM foo`:                      sql.ErrTooBigPrecision,
+	`SELECT foo FROM t1 GROUP BY 2`:                           ErrGroupByColumnIndex,
+	`SELECT foo FROM t1 GROUP BY 0`:                           ErrGroupByColumnIndex,
 }
 
 func TestParseErrors(t *testing.T) {
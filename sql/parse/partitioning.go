@@ -0,0 +1,171 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	errors "gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// errInvalidPartitionClause is returned when the PARTITION BY clause of a
+// CREATE TABLE cannot be parsed.
+var errInvalidPartitionClause = errors.NewKind("invalid PARTITION BY clause: %s")
+
+var (
+	partitionByRegex         = regexp.MustCompile(`(?is)\)\s*partition\s+by\s+`)
+	hashPartitioningRegex    = regexp.MustCompile("(?is)^(?:linear\\s+)?hash\\s*\\(\\s*`?(\\w+)`?\\s*\\)(?:\\s+partitions\\s+(\\d+))?$")
+	definedPartitioningRegex = regexp.MustCompile("(?is)^(range|list)\\s*\\(\\s*`?(\\w+)`?\\s*\\)\\s*\\((.*)\\)$")
+	partitionDefinitionRegex = regexp.MustCompile("(?is)^partition\\s+`?(\\w+)`?\\s+values\\s+(?:less\\s+than\\s+(?:(maxvalue)|\\((.*)\\))|in\\s*\\((.*)\\))$")
+)
+
+// parseCreatePartitionedTable parses a CREATE TABLE statement with a
+// PARTITION BY clause, which vitess does not support. The statement without
+// the clause is parsed by vitess and the clause is parsed separately.
+func parseCreatePartitionedTable(ctx *sql.Context, s string) (sql.Node, error) {
+	matches := partitionByRegex.FindAllStringIndex(s, -1)
+	match := matches[len(matches)-1]
+
+	stmt, err := sqlparser.Parse(s[:match[0]+1])
+	if err != nil {
+		return nil, err
+	}
+
+	ddl, ok := stmt.(*sqlparser.DDL)
+	if !ok || ddl.Action != sqlparser.CreateStr || ddl.TableSpec == nil {
+		return nil, ErrUnsupportedSyntax.New(s)
+	}
+
+	schema, err := tableSpecToSchema(ddl.TableSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	partitioning, err := parsePartitioning(ctx, strings.TrimSpace(s[match[1]:]))
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewCreatePartitionedTable(
+		sql.UnresolvedDatabase(""),
+		ddl.Table.Name.String(),
+		schema,
+		partitioning,
+	), nil
+}
+
+// parsePartitioning parses the PARTITION BY clause after the BY keyword.
+func parsePartitioning(ctx *sql.Context, s string) (*sql.Partitioning, error) {
+	if m := hashPartitioningRegex.FindStringSubmatch(s); m != nil {
+		var partitions = 1
+		if m[2] != "" {
+			var err error
+			partitions, err = strconv.Atoi(m[2])
+			if err != nil || partitions < 1 {
+				return nil, errInvalidPartitionClause.New(s)
+			}
+		}
+
+		return sql.NewHashPartitioning(m[1], partitions), nil
+	}
+
+	m := definedPartitioningRegex.FindStringSubmatch(s)
+	if m == nil {
+		return nil, errInvalidPartitionClause.New(s)
+	}
+
+	var p = &sql.Partitioning{Type: sql.RangePartitioning, Column: m[2]}
+	if strings.ToLower(m[1]) == "list" {
+		p.Type = sql.ListPartitioning
+	}
+
+	for _, def := range splitTopLevel(m[3]) {
+		d := partitionDefinitionRegex.FindStringSubmatch(strings.TrimSpace(def))
+		if d == nil {
+			return nil, errInvalidPartitionClause.New(def)
+		}
+
+		var definition = sql.PartitionDefinition{Name: d[1]}
+		switch {
+		case p.Type == sql.RangePartitioning && d[2] != "":
+		case p.Type == sql.RangePartitioning && d[3] != "":
+			if strings.ToLower(strings.TrimSpace(d[3])) == "maxvalue" {
+				break
+			}
+
+			values, err := parsePartitionValues(ctx, d[3])
+			if err != nil {
+				return nil, err
+			}
+
+			if len(values) != 1 || values[0] == nil {
+				return nil, errInvalidPartitionClause.New(def)
+			}
+			definition.LessThan = values[0]
+		case p.Type == sql.ListPartitioning && d[4] != "":
+			values, err := parsePartitionValues(ctx, d[4])
+			if err != nil {
+				return nil, err
+			}
+			definition.Values = values
+		default:
+			return nil, errInvalidPartitionClause.New(def)
+		}
+
+		p.Definitions = append(p.Definitions, definition)
+	}
+
+	return p, nil
+}
+
+// parsePartitionValues parses a list of constant values separated by
+// commas.
+func parsePartitionValues(ctx *sql.Context, s string) ([]interface{}, error) {
+	var values []interface{}
+	for _, v := range splitTopLevel(s) {
+		e, err := parseExpr(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.Resolved() {
+			return nil, errInvalidPartitionClause.New(v)
+		}
+
+		val, err := e.Eval(ctx, nil)
+		if err != nil {
+			return nil, errInvalidPartitionClause.New(v)
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// splitTopLevel splits the string by the commas that are not inside
+// parentheses or quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	var level, start int
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			level++
+		case r == ')':
+			level--
+		case r == ',' && level == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// intoFileClause returns the position of the INTO OUTFILE or INTO DUMPFILE
+// clause of a query, which cannot be in its subqueries, or -1 if it has
+// none.
+func intoFileClause(s string) int {
+	depth := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = quotedEnd(s, i)
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case isWordByte(c):
+			end := i
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+
+			if depth == 0 && strings.EqualFold(s[i:end], "into") {
+				next := strings.ToLower(nextWord(s, end))
+				if next == "outfile" || next == "dumpfile" {
+					return i
+				}
+			}
+
+			i = end
+			continue
+		}
+		i++
+	}
+	return -1
+}
+
+// parseSelectIntoFile parses a query with an INTO OUTFILE or INTO DUMPFILE
+// clause, which is either after its columns or at its end. The query
+// without the clause is parsed as any other.
+func parseSelectIntoFile(ctx *sql.Context, s string) (sql.Node, error) {
+	pos := intoFileClause(s)
+	r := bufio.NewReader(strings.NewReader(s[pos:]))
+
+	var kind, file string
+	format := plan.DefaultLoadDataFormat()
+	err := parseFuncs{
+		expect("into"),
+		skipSpaces,
+		readIdent(&kind),
+		skipSpaces,
+		readStringLiteral(&file),
+		skipSpaces,
+	}.exec(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dump := kind == "dumpfile"
+	if !dump {
+		err = parseFuncs{
+			readLoadDataCharset,
+			readLoadDataFields(&format),
+			readLoadDataLines(&format),
+		}.exec(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rest, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := parse(ctx, s[:pos]+" "+string(rest))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := node.(*plan.SelectIntoFile); ok {
+		return nil, ErrUnsupportedFeature.New("several INTO clauses")
+	}
+
+	return plan.NewSelectIntoFile(node, file, dump, format), nil
+}
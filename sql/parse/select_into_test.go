@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectIntoFile(t *testing.T) {
+	csv := plan.DefaultLoadDataFormat()
+	csv.FieldsTerminatedBy = ","
+	csv.FieldsEnclosedBy = `"`
+	csv.LinesTerminatedBy = "\r\n"
+
+	testCases := []struct {
+		query  string
+		inner  string
+		file   string
+		dump   bool
+		format plan.LoadDataFormat
+		err    bool
+	}{
+		{
+			"SELECT a, b FROM t WHERE b = 'into outfile' ORDER BY a INTO OUTFILE '/tmp/out.txt'",
+			"SELECT a, b FROM t WHERE b = 'into outfile' ORDER BY a",
+			"/tmp/out.txt",
+			false,
+			plan.DefaultLoadDataFormat(),
+			false,
+		},
+		{
+			"select a into outfile 'out.csv' character set utf8mb4 " +
+				`fields terminated by ',' enclosed by '"' lines terminated by '\r\n' ` +
+				"from t where a in (select b from u)",
+			"select a from t where a in (select b from u)",
+			"out.csv",
+			false,
+			csv,
+			false,
+		},
+		{
+			"SELECT data FROM t LIMIT 1 INTO DUMPFILE 'out.bin'",
+			"SELECT data FROM t LIMIT 1",
+			"out.bin",
+			true,
+			plan.DefaultLoadDataFormat(),
+			false,
+		},
+		{"SELECT a FROM t INTO OUTFILE out.txt", "", "", false, plan.LoadDataFormat{}, true},
+		{"SELECT a FROM t INTO DUMPFILE 'out.bin' FIELDS TERMINATED BY ','", "", "", false, plan.LoadDataFormat{}, true},
+		{"SELECT a INTO OUTFILE 'a' FROM t INTO OUTFILE 'b'", "", "", false, plan.LoadDataFormat{}, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			require := require.New(t)
+			ctx := sql.NewEmptyContext()
+
+			result, err := Parse(ctx, tt.query)
+			if tt.err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			inner, err := Parse(ctx, tt.inner)
+			require.NoError(err)
+			require.Equal(plan.NewSelectIntoFile(inner, tt.file, tt.dump, tt.format), result)
+		})
+	}
+
+	// Queries whose subqueries have the clause are not written to files.
+	_, err := Parse(sql.NewEmptyContext(), "SELECT * FROM (SELECT a FROM t INTO OUTFILE 'x') s")
+	require.Error(t, err)
+}
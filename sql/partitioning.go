@@ -0,0 +1,421 @@
+package sql
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+var (
+	// ErrInvalidPartitioning is returned when the partitioning of a table
+	// is not valid for its schema.
+	ErrInvalidPartitioning = errors.NewKind("invalid partitioning: %s")
+
+	// ErrNoPartitionForValue is returned when a row is inserted in a
+	// partitioned table and none of its partitions accept the value of the
+	// partitioning column.
+	ErrNoPartitionForValue = errors.NewKind("table has no partition for value %v")
+)
+
+// PrunableTable is a table whose rows are distributed in partitions by the
+// value of one of its columns, as declared with PARTITION BY. Only the
+// partitions that may contain rows matching the filters of a query are
+// read.
+type PrunableTable interface {
+	Table
+	// Partitioning returns how the rows of the table are partitioned.
+	Partitioning() *Partitioning
+	// WithSelectedPartitions returns a table that only returns the rows of
+	// the partitions with the given names.
+	WithSelectedPartitions(names []string) Table
+	// SelectedPartitions returns the names of the partitions that will be
+	// read, or nil if all of them will.
+	SelectedPartitions() []string
+}
+
+// PartitionedTableCreator should be implemented by databases that can create
+// new tables with declarative partitioning.
+type PartitionedTableCreator interface {
+	CreatePartitionedTable(ctx *Context, name string, schema Schema, partitioning *Partitioning) error
+}
+
+// PartitioningType is the way the rows of a table are distributed in its
+// partitions.
+type PartitioningType byte
+
+const (
+	// RangePartitioning puts every row in the first partition whose upper
+	// bound is greater than the value of the row.
+	RangePartitioning PartitioningType = iota + 1
+	// ListPartitioning puts every row in the partition whose list of
+	// values contains the value of the row.
+	ListPartitioning
+	// HashPartitioning puts every row in a partition chosen by the hash
+	// of the value of the row.
+	HashPartitioning
+)
+
+func (t PartitioningType) String() string {
+	switch t {
+	case RangePartitioning:
+		return "RANGE"
+	case ListPartitioning:
+		return "LIST"
+	case HashPartitioning:
+		return "HASH"
+	default:
+		return "invalid PartitioningType"
+	}
+}
+
+// PartitionDefinition is one of the partitions declared for a table.
+type PartitionDefinition struct {
+	// Name of the partition.
+	Name string
+	// LessThan is the exclusive upper bound of the values of the partition
+	// in RANGE partitioning. A nil value is MAXVALUE.
+	LessThan interface{}
+	// Values are the values of the partition in LIST partitioning.
+	Values []interface{}
+}
+
+// Partitioning describes how the rows of a table are distributed in its
+// partitions.
+type Partitioning struct {
+	Type PartitioningType
+	// Column is the name of the column whose values decide the partition
+	// of each row.
+	Column string
+	// Definitions are the partitions of the table.
+	Definitions []PartitionDefinition
+}
+
+// NewHashPartitioning returns a HASH partitioning by the given column with
+// the given number of partitions, which are named p0, p1, ...
+func NewHashPartitioning(column string, partitions int) *Partitioning {
+	var defs = make([]PartitionDefinition, partitions)
+	for i := range defs {
+		defs[i] = PartitionDefinition{Name: fmt.Sprintf("p%d", i)}
+	}
+
+	return &Partitioning{
+		Type:        HashPartitioning,
+		Column:      column,
+		Definitions: defs,
+	}
+}
+
+// Names returns the names of all the partitions.
+func (p *Partitioning) Names() []string {
+	var names = make([]string, len(p.Definitions))
+	for i, d := range p.Definitions {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// columnType returns the type of the partitioning column in the schema.
+func (p *Partitioning) columnType(schema Schema) (Type, error) {
+	for _, col := range schema {
+		if strings.ToLower(col.Name) == strings.ToLower(p.Column) {
+			return col.Type, nil
+		}
+	}
+	return nil, ErrInvalidPartitioning.New(fmt.Sprintf("unknown column %q", p.Column))
+}
+
+// Resolve checks the partitioning is valid for a table with the given
+// schema and returns a copy of it whose values are converted to the type
+// of the partitioning column.
+func (p *Partitioning) Resolve(schema Schema) (*Partitioning, error) {
+	typ, err := p.columnType(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Definitions) == 0 {
+		return nil, ErrInvalidPartitioning.New("no partitions defined")
+	}
+
+	var resolved = &Partitioning{Type: p.Type, Column: p.Column}
+	var names = make(map[string]struct{})
+	for i, d := range p.Definitions {
+		name := strings.ToLower(d.Name)
+		if _, ok := names[name]; ok {
+			return nil, ErrInvalidPartitioning.New(fmt.Sprintf("duplicated partition %q", d.Name))
+		}
+		names[name] = struct{}{}
+
+		def := PartitionDefinition{Name: d.Name}
+		switch p.Type {
+		case RangePartitioning:
+			if d.LessThan == nil {
+				if i != len(p.Definitions)-1 {
+					return nil, ErrInvalidPartitioning.New("MAXVALUE can only be used in the last partition")
+				}
+				break
+			}
+
+			def.LessThan, err = typ.Convert(d.LessThan)
+			if err != nil {
+				return nil, ErrInvalidPartitioning.Wrap(err, err.Error())
+			}
+
+			if i > 0 {
+				cmp, err := typ.Compare(resolved.Definitions[i-1].LessThan, def.LessThan)
+				if err != nil {
+					return nil, err
+				}
+
+				if cmp >= 0 {
+					return nil, ErrInvalidPartitioning.New("VALUES LESS THAN must be strictly increasing for each partition")
+				}
+			}
+		case ListPartitioning:
+			for _, v := range d.Values {
+				if v != nil {
+					v, err = typ.Convert(v)
+					if err != nil {
+						return nil, ErrInvalidPartitioning.Wrap(err, err.Error())
+					}
+				}
+				def.Values = append(def.Values, v)
+			}
+		case HashPartitioning:
+		default:
+			return nil, ErrInvalidPartitioning.New(p.Type.String())
+		}
+
+		resolved.Definitions = append(resolved.Definitions, def)
+	}
+
+	return resolved, nil
+}
+
+// PartitionOf returns the index of the partition of a row whose
+// partitioning column has the given value. The partitioning must have been
+// resolved.
+func (p *Partitioning) PartitionOf(typ Type, value interface{}) (int, error) {
+	if value != nil {
+		var err error
+		value, err = typ.Convert(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	switch p.Type {
+	case RangePartitioning:
+		// NULL values go to the first partition, as they are less than any
+		// other value.
+		if value == nil {
+			return 0, nil
+		}
+
+		for i, d := range p.Definitions {
+			if d.LessThan == nil {
+				return i, nil
+			}
+
+			cmp, err := typ.Compare(value, d.LessThan)
+			if err != nil {
+				return 0, err
+			}
+
+			if cmp < 0 {
+				return i, nil
+			}
+		}
+	case ListPartitioning:
+		for i, d := range p.Definitions {
+			for _, v := range d.Values {
+				if v == nil || value == nil {
+					if v == nil && value == nil {
+						return i, nil
+					}
+					continue
+				}
+
+				cmp, err := typ.Compare(value, v)
+				if err != nil {
+					return 0, err
+				}
+
+				if cmp == 0 {
+					return i, nil
+				}
+			}
+		}
+	case HashPartitioning:
+		return p.hash(value), nil
+	}
+
+	return 0, ErrNoPartitionForValue.New(value)
+}
+
+func (p *Partitioning) hash(value interface{}) int {
+	n := uint64(len(p.Definitions))
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case int8, int16, int32, int64:
+		i, _ := Int64.Convert(v)
+		if i := i.(int64); i < 0 {
+			return int(uint64(-i) % n)
+		}
+		return int(uint64(i.(int64)) % n)
+	case uint8, uint16, uint32, uint64:
+		u, _ := Uint64.Convert(v)
+		return int(u.(uint64) % n)
+	default:
+		return int(uint64(crc32.ChecksumIEEE([]byte(fmt.Sprint(v)))) % n)
+	}
+}
+
+// PartitionRange is a range of values of the partitioning column. Nil
+// bounds mean the range is unbounded on that side.
+type PartitionRange struct {
+	Lower, Upper                   interface{}
+	LowerInclusive, UpperInclusive bool
+}
+
+// NewPartitionPoint returns a range that only contains the given value.
+func NewPartitionPoint(value interface{}) PartitionRange {
+	return PartitionRange{
+		Lower:          value,
+		Upper:          value,
+		LowerInclusive: true,
+		UpperInclusive: true,
+	}
+}
+
+// PartitionsInRange returns the names of the partitions that may contain
+// rows whose partitioning column, of the given type, has a value in the
+// given range. NULL values are never in a range. The partitioning must
+// have been resolved.
+func (p *Partitioning) PartitionsInRange(typ Type, r PartitionRange) ([]string, error) {
+	var err error
+	if r.Lower != nil {
+		if r.Lower, err = typ.Convert(r.Lower); err != nil {
+			return p.Names(), nil
+		}
+	}
+
+	if r.Upper != nil {
+		if r.Upper, err = typ.Convert(r.Upper); err != nil {
+			return p.Names(), nil
+		}
+	}
+
+	var names []string
+	switch p.Type {
+	case RangePartitioning:
+		var lower interface{}
+		for _, d := range p.Definitions {
+			ok, err := rangesOverlap(typ, r, lower, d.LessThan)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				names = append(names, d.Name)
+			}
+			lower = d.LessThan
+		}
+	case ListPartitioning:
+		for _, d := range p.Definitions {
+			for _, v := range d.Values {
+				if v == nil {
+					continue
+				}
+
+				ok, err := r.contains(typ, v)
+				if err != nil {
+					return nil, err
+				}
+
+				if ok {
+					names = append(names, d.Name)
+					break
+				}
+			}
+		}
+	case HashPartitioning:
+		if r.Lower == nil || !r.LowerInclusive || !r.UpperInclusive {
+			return p.Names(), nil
+		}
+
+		cmp, err := typ.Compare(r.Lower, r.Upper)
+		if err != nil {
+			return nil, err
+		}
+
+		if cmp != 0 {
+			return p.Names(), nil
+		}
+
+		names = append(names, p.Definitions[p.hash(r.Lower)].Name)
+	}
+
+	return names, nil
+}
+
+// contains reports whether the value is in the range.
+func (r PartitionRange) contains(typ Type, v interface{}) (bool, error) {
+	if r.Lower != nil {
+		cmp, err := typ.Compare(v, r.Lower)
+		if err != nil {
+			return false, err
+		}
+
+		if cmp < 0 || (cmp == 0 && !r.LowerInclusive) {
+			return false, nil
+		}
+	}
+
+	if r.Upper != nil {
+		cmp, err := typ.Compare(v, r.Upper)
+		if err != nil {
+			return false, err
+		}
+
+		if cmp > 0 || (cmp == 0 && !r.UpperInclusive) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// rangesOverlap reports whether the range may contain values in the range
+// of a RANGE partition, which goes from lower, inclusive, to upper,
+// exclusive. Nil bounds of the partition are unbounded.
+func rangesOverlap(typ Type, r PartitionRange, lower, upper interface{}) (bool, error) {
+	// The range must start before the end of the partition.
+	if r.Lower != nil && upper != nil {
+		cmp, err := typ.Compare(r.Lower, upper)
+		if err != nil {
+			return false, err
+		}
+
+		if cmp >= 0 {
+			return false, nil
+		}
+	}
+
+	// And end after the start of the partition.
+	if r.Upper != nil && lower != nil {
+		cmp, err := typ.Compare(r.Upper, lower)
+		if err != nil {
+			return false, err
+		}
+
+		if cmp < 0 || (cmp == 0 && !r.UpperInclusive) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
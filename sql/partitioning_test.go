@@ -0,0 +1,236 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var partitioningSchema = Schema{
+	{Name: "a", Type: Int64},
+	{Name: "b", Type: Text},
+}
+
+func TestPartitioningResolve(t *testing.T) {
+	testCases := []struct {
+		name         string
+		partitioning *Partitioning
+		err          bool
+	}{
+		{
+			"range",
+			&Partitioning{RangePartitioning, "A", []PartitionDefinition{
+				{Name: "p0", LessThan: int8(10)},
+				{Name: "p1", LessThan: nil},
+			}},
+			false,
+		},
+		{
+			"unknown column",
+			&Partitioning{RangePartitioning, "c", []PartitionDefinition{
+				{Name: "p0", LessThan: nil},
+			}},
+			true,
+		},
+		{
+			"no partitions",
+			&Partitioning{ListPartitioning, "a", nil},
+			true,
+		},
+		{
+			"duplicated partition",
+			&Partitioning{ListPartitioning, "a", []PartitionDefinition{
+				{Name: "p0", Values: []interface{}{1}},
+				{Name: "P0", Values: []interface{}{2}},
+			}},
+			true,
+		},
+		{
+			"maxvalue is not last",
+			&Partitioning{RangePartitioning, "a", []PartitionDefinition{
+				{Name: "p0", LessThan: nil},
+				{Name: "p1", LessThan: 10},
+			}},
+			true,
+		},
+		{
+			"not increasing",
+			&Partitioning{RangePartitioning, "a", []PartitionDefinition{
+				{Name: "p0", LessThan: 10},
+				{Name: "p1", LessThan: 10},
+			}},
+			true,
+		},
+		{
+			"invalid value",
+			&Partitioning{ListPartitioning, "a", []PartitionDefinition{
+				{Name: "p0", Values: []interface{}{"foo"}},
+			}},
+			true,
+		},
+		{
+			"hash",
+			NewHashPartitioning("b", 4),
+			false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			_, err := tt.partitioning.Resolve(partitioningSchema)
+			if tt.err {
+				require.Error(err)
+				require.True(ErrInvalidPartitioning.Is(err))
+			} else {
+				require.NoError(err)
+			}
+		})
+	}
+}
+
+func TestPartitionOf(t *testing.T) {
+	rangeP, err := (&Partitioning{RangePartitioning, "a", []PartitionDefinition{
+		{Name: "p0", LessThan: 10},
+		{Name: "p1", LessThan: 20},
+	}}).Resolve(partitioningSchema)
+	require.NoError(t, err)
+
+	listP, err := (&Partitioning{ListPartitioning, "a", []PartitionDefinition{
+		{Name: "p0", Values: []interface{}{1, 3}},
+		{Name: "p1", Values: []interface{}{2, nil}},
+	}}).Resolve(partitioningSchema)
+	require.NoError(t, err)
+
+	hashP, err := NewHashPartitioning("a", 4).Resolve(partitioningSchema)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		partitioning *Partitioning
+		value        interface{}
+		expected     int
+		err          bool
+	}{
+		{"range first", rangeP, int64(5), 0, false},
+		{"range bound", rangeP, int64(10), 1, false},
+		{"range null", rangeP, nil, 0, false},
+		{"range out", rangeP, int64(20), 0, true},
+		{"list value", listP, int64(3), 0, false},
+		{"list null", listP, nil, 1, false},
+		{"list out", listP, int64(4), 0, true},
+		{"hash", hashP, int64(6), 2, false},
+		{"hash negative", hashP, int64(-5), 1, false},
+		{"hash null", hashP, nil, 0, false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			p, err := tt.partitioning.PartitionOf(Int64, tt.value)
+			if tt.err {
+				require.Error(err)
+				require.True(ErrNoPartitionForValue.Is(err))
+			} else {
+				require.NoError(err)
+				require.Equal(tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestPartitionsInRange(t *testing.T) {
+	rangeP, err := (&Partitioning{RangePartitioning, "a", []PartitionDefinition{
+		{Name: "p0", LessThan: 10},
+		{Name: "p1", LessThan: 20},
+		{Name: "p2", LessThan: nil},
+	}}).Resolve(partitioningSchema)
+	require.NoError(t, err)
+
+	listP, err := (&Partitioning{ListPartitioning, "a", []PartitionDefinition{
+		{Name: "p0", Values: []interface{}{1, 3}},
+		{Name: "p1", Values: []interface{}{2, nil}},
+		{Name: "p2", Values: []interface{}{5}},
+	}}).Resolve(partitioningSchema)
+	require.NoError(t, err)
+
+	hashP, err := NewHashPartitioning("a", 4).Resolve(partitioningSchema)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		partitioning *Partitioning
+		r            PartitionRange
+		expected     []string
+	}{
+		{
+			"range point",
+			rangeP,
+			NewPartitionPoint(int64(10)),
+			[]string{"p1"},
+		},
+		{
+			"range less than bound",
+			rangeP,
+			PartitionRange{Upper: int64(10)},
+			[]string{"p0"},
+		},
+		{
+			"range less than or equal to bound",
+			rangeP,
+			PartitionRange{Upper: int64(10), UpperInclusive: true},
+			[]string{"p0", "p1"},
+		},
+		{
+			"range greater than",
+			rangeP,
+			PartitionRange{Lower: int64(19)},
+			[]string{"p1", "p2"},
+		},
+		{
+			"range between",
+			rangeP,
+			PartitionRange{Lower: int64(5), Upper: int64(15), LowerInclusive: true, UpperInclusive: true},
+			[]string{"p0", "p1"},
+		},
+		{
+			"range not convertible",
+			rangeP,
+			NewPartitionPoint("foo"),
+			[]string{"p0", "p1", "p2"},
+		},
+		{
+			"list range",
+			listP,
+			PartitionRange{Lower: int64(1), Upper: int64(3)},
+			[]string{"p1"},
+		},
+		{
+			"list no partitions",
+			listP,
+			NewPartitionPoint(int64(4)),
+			nil,
+		},
+		{
+			"hash point",
+			hashP,
+			NewPartitionPoint(int64(5)),
+			[]string{"p1"},
+		},
+		{
+			"hash range",
+			hashP,
+			PartitionRange{Lower: int64(1), Upper: int64(2), LowerInclusive: true, UpperInclusive: true},
+			[]string{"p0", "p1", "p2", "p3"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			names, err := tt.partitioning.PartitionsInRange(Int64, tt.r)
+			require.NoError(err)
+			require.Equal(tt.expected, names)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// dualTableName is the name of the table of the queries without a FROM
+// clause, which everyone can read.
+const dualTableName = "dual"
+
+// TableAccesses returns the accesses made to tables by a parsed statement,
+// before it's analyzed. Tables without a database are in the current
+// database of the catalog, whose schemas are used to find the tables of the
+// columns that are not qualified.
+func TableAccesses(ctx *sql.Context, c *sql.Catalog, n sql.Node) []sql.TableAccess {
+	a := &accessCollector{ctx: ctx, catalog: c, db: c.CurrentDatabase()}
+	a.collect(n)
+	return a.accesses
+}
+
+type accessCollector struct {
+	ctx      *sql.Context
+	catalog  *sql.Catalog
+	db       string
+	accesses []sql.TableAccess
+}
+
+// accessedTable is a table of a scope, with the alias it's referenced by.
+type accessedTable struct {
+	db     string
+	name   string
+	alias  string
+	schema sql.Schema
+}
+
+func (a *accessCollector) collect(n sql.Node) {
+	switch n := n.(type) {
+	case *InsertInto:
+		tables, _ := a.scope(n.Left)
+		for _, t := range tables {
+			columns := n.Columns
+			if len(columns) == 0 {
+				columns = schemaColumns(t.schema)
+			}
+			a.add(sql.InsertPrivilege, t, columns)
+		}
+		a.read(n.Right)
+	case *LoadData:
+		tables, _ := a.scope(n.Child)
+		for _, t := range tables {
+			columns := n.Columns
+			if len(columns) == 0 {
+				columns = schemaColumns(t.schema)
+			}
+			a.add(sql.InsertPrivilege, t, columns)
+		}
+	case *Update:
+		tables, _ := a.scope(n.Node)
+		var fields []sql.Expression
+		for _, e := range n.UpdateExprs {
+			if f, ok := e.(*expression.SetField); ok {
+				fields = append(fields, f.Left)
+			}
+		}
+		a.addAll(sql.UpdatePrivilege, tables, fields)
+	case *DeleteFrom:
+		tables, _ := a.scope(n.Node)
+		a.addAll(sql.DeletePrivilege, tables, nil)
+	case *CreateTable:
+		a.addTable(sql.CreatePrivilege, n.Database().Name(), n.TableName())
+	case *DropTable:
+		for _, name := range n.TableNames() {
+			a.addTable(sql.DropPrivilege, n.Database().Name(), name)
+		}
+	case *CreateDatabase:
+		a.addTable(sql.CreatePrivilege, n.Name, "*")
+	case *DropDatabase:
+		a.addTable(sql.DropPrivilege, n.Name, "*")
+	case *Dump:
+		// Dumping whole databases needs the privileges on all their tables.
+		dbs := n.Options.Databases
+		if len(dbs) == 0 {
+			dbs = []string{"*"}
+		}
+		for _, db := range dbs {
+			if len(n.Options.Tables) == 0 {
+				a.addTable(sql.SelectPrivilege, db, "*")
+			}
+			for _, table := range n.Options.Tables {
+				a.addTable(sql.SelectPrivilege, db, table)
+			}
+		}
+	case *CreateIndex:
+		tables, _ := a.scope(n.Table)
+		a.addAll(sql.IndexPrivilege, tables, n.Exprs)
+	case *DropIndex:
+		tables, _ := a.scope(n.Table)
+		a.addAll(sql.IndexPrivilege, tables, nil)
+	case *LockTables:
+		for _, l := range n.Locks {
+			tables, _ := a.scope(l.Table)
+			a.addAll(sql.LockTablesPrivilege|sql.SelectPrivilege, tables, nil)
+		}
+	case *Grant:
+		// Users can only grant the privileges they have been granted.
+		level := PrivilegeLevel(n.Level, a.db)
+		a.addTable(n.Privileges|sql.GrantOptionPrivilege, level.Database, level.Table)
+	case *Revoke:
+		level := PrivilegeLevel(n.Level, a.db)
+		a.addTable(n.Privileges|sql.GrantOptionPrivilege, level.Database, level.Table)
+	default:
+		a.read(n)
+	}
+}
+
+// read adds the accesses of a query that reads the tables of its scope.
+func (a *accessCollector) read(n sql.Node) {
+	tables, exprs := a.scope(n)
+	a.addAll(sql.SelectPrivilege, tables, exprs)
+}
+
+// scope returns the tables and expressions of the scope of a query. The
+// queries of subqueries have their own scopes, which are read.
+func (a *accessCollector) scope(n sql.Node) ([]*accessedTable, []sql.Expression) {
+	var tables []*accessedTable
+	var exprs []sql.Expression
+	aliases := make(map[*UnresolvedTable]string)
+
+	Inspect(n, func(node sql.Node) bool {
+		switch node := node.(type) {
+		case nil:
+			return false
+		case *SubqueryAlias:
+			if node != n {
+				a.read(node.Child)
+				return false
+			}
+		case *TableAlias:
+			if t, ok := node.Child.(*UnresolvedTable); ok {
+				aliases[t] = node.Name()
+			}
+		case *UnresolvedTable:
+			if node.Database == "" && strings.EqualFold(node.Name(), dualTableName) {
+				return false
+			}
+
+			t := &accessedTable{db: node.Database, name: node.Name()}
+			if t.db == "" {
+				t.db = a.db
+			}
+
+			t.alias = t.name
+			if alias, ok := aliases[node]; ok {
+				t.alias = alias
+			}
+
+			if table, err := a.catalog.ResolveTable(a.ctx, t.db, t.name); err == nil {
+				t.schema = table.Schema()
+			}
+
+			tables = append(tables, t)
+		}
+
+		if e, ok := node.(sql.Expressioner); ok {
+			for _, expr := range e.Expressions() {
+				expression.Inspect(expr, func(e sql.Expression) bool {
+					if s, ok := e.(*expression.Subquery); ok {
+						a.read(s.Query)
+						return false
+					}
+					return true
+				})
+				exprs = append(exprs, expr)
+			}
+		}
+
+		return true
+	})
+
+	return tables, exprs
+}
+
+// addAll adds an access to each table with the columns of the table used by
+// the expressions.
+func (a *accessCollector) addAll(
+	privileges sql.PrivilegeType,
+	tables []*accessedTable,
+	exprs []sql.Expression,
+) {
+	columns := make([][]string, len(tables))
+	use := func(i int, name string) {
+		for _, c := range columns[i] {
+			if strings.EqualFold(c, name) {
+				return
+			}
+		}
+		columns[i] = append(columns[i], name)
+	}
+
+	for _, expr := range exprs {
+		expression.Inspect(expr, func(e sql.Expression) bool {
+			switch e := e.(type) {
+			case *expression.Subquery:
+				return false
+			case *expression.Star:
+				for i, t := range tables {
+					if e.Table == "" || strings.EqualFold(e.Table, t.alias) {
+						for _, c := range schemaColumns(t.schema) {
+							use(i, c)
+						}
+					}
+				}
+			case *expression.UnresolvedColumn:
+				for i, t := range tables {
+					if e.Table() != "" && !strings.EqualFold(e.Table(), t.alias) {
+						continue
+					}
+
+					// Columns that are not qualified are in the only table
+					// of the scope or in the tables that have them.
+					if e.Table() != "" || len(tables) == 1 || t.schema.Contains(e.Name(), t.name) {
+						use(i, e.Name())
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	for i, t := range tables {
+		a.add(privileges, t, columns[i])
+	}
+}
+
+func (a *accessCollector) add(privileges sql.PrivilegeType, t *accessedTable, columns []string) {
+	a.accesses = append(a.accesses, sql.TableAccess{
+		Privileges: privileges,
+		Database:   t.db,
+		Table:      t.name,
+		Columns:    columns,
+	})
+}
+
+func (a *accessCollector) addTable(privileges sql.PrivilegeType, db, table string) {
+	if db == "" {
+		db = a.db
+	}
+
+	a.accesses = append(a.accesses, sql.TableAccess{
+		Privileges: privileges,
+		Database:   db,
+		Table:      table,
+	})
+}
+
+func schemaColumns(schema sql.Schema) []string {
+	var columns []string
+	for _, c := range schema {
+		columns = append(columns, c.Name)
+	}
+	return columns
+}
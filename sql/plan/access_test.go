@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableAccesses(t *testing.T) {
+	db := memory.NewDatabase("mydb")
+	db.AddTable("t1", memory.NewTable("t1", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t1"},
+		{Name: "b", Type: sql.Text, Source: "t1"},
+	}))
+	db.AddTable("t2", memory.NewTable("t2", sql.Schema{
+		{Name: "c", Type: sql.Int64, Source: "t2"},
+	}))
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+
+	col := expression.NewUnresolvedColumn
+	qcol := expression.NewUnresolvedQualifiedColumn
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected []sql.TableAccess
+	}{
+		{
+			"select",
+			NewProject(
+				[]sql.Expression{col("b")},
+				NewFilter(
+					expression.NewEquals(col("a"), col("b")),
+					NewUnresolvedTable("t1", ""),
+				),
+			),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t1", Columns: []string{"b", "a"}},
+			},
+		},
+		{
+			"select star",
+			NewProject(
+				[]sql.Expression{expression.NewStar()},
+				NewUnresolvedTable("t1", ""),
+			),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t1", Columns: []string{"a", "b"}},
+			},
+		},
+		{
+			"join",
+			NewProject(
+				[]sql.Expression{col("a"), qcol("x", "c"), expression.NewQualifiedStar("t2")},
+				NewCrossJoin(
+					NewUnresolvedTable("t1", ""),
+					NewTableAlias("x", NewUnresolvedTable("t2", "")),
+				),
+			),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t1", Columns: []string{"a"}},
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t2", Columns: []string{"c"}},
+			},
+		},
+		{
+			"subquery",
+			NewFilter(
+				expression.NewIn(
+					col("a"),
+					expression.NewSubquery(NewProject(
+						[]sql.Expression{col("c")},
+						NewUnresolvedTable("t2", "other"),
+					)),
+				),
+				NewUnresolvedTable("t1", ""),
+			),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege, Database: "other", Table: "t2", Columns: []string{"c"}},
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t1", Columns: []string{"a"}},
+			},
+		},
+		{
+			"insert",
+			NewInsertInto(
+				NewUnresolvedTable("t1", ""),
+				NewProject(
+					[]sql.Expression{col("c")},
+					NewUnresolvedTable("t2", ""),
+				),
+				false, []string{"a"},
+			),
+			[]sql.TableAccess{
+				{Privileges: sql.InsertPrivilege, Database: "mydb", Table: "t1", Columns: []string{"a"}},
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t2", Columns: []string{"c"}},
+			},
+		},
+		{
+			"update",
+			NewUpdate(
+				NewFilter(
+					expression.NewEquals(col("a"), expression.NewLiteral(int64(1), sql.Int64)),
+					NewUnresolvedTable("t1", ""),
+				),
+				[]sql.Expression{
+					expression.NewSetField(col("b"), expression.NewLiteral("x", sql.Text)),
+				},
+			),
+			[]sql.TableAccess{
+				{Privileges: sql.UpdatePrivilege, Database: "mydb", Table: "t1", Columns: []string{"b"}},
+			},
+		},
+		{
+			"drop table",
+			NewDropTable(sql.UnresolvedDatabase(""), false, "t1", "t2"),
+			[]sql.TableAccess{
+				{Privileges: sql.DropPrivilege, Database: "mydb", Table: "t1"},
+				{Privileges: sql.DropPrivilege, Database: "mydb", Table: "t2"},
+			},
+		},
+		{
+			"grant",
+			NewGrant(sql.SelectPrivilege, sql.PrivilegeLevel{Table: "*"}, "user"),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege | sql.GrantOptionPrivilege, Database: "mydb", Table: "*"},
+			},
+		},
+		{
+			"create database",
+			NewCreateDatabase("newdb", false),
+			[]sql.TableAccess{
+				{Privileges: sql.CreatePrivilege, Database: "newdb", Table: "*"},
+			},
+		},
+		{
+			"dump tables",
+			NewDump(DumpOptions{Databases: []string{"mydb"}, Tables: []string{"t1", "t2"}}, "mydb.sql"),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t1"},
+				{Privileges: sql.SelectPrivilege, Database: "mydb", Table: "t2"},
+			},
+		},
+		{
+			"dump all databases",
+			NewDump(DumpOptions{}, "all.sql"),
+			[]sql.TableAccess{
+				{Privileges: sql.SelectPrivilege, Database: "*", Table: "*"},
+			},
+		},
+		{
+			"dual",
+			NewProject(
+				[]sql.Expression{expression.NewLiteral(int64(1), sql.Int64)},
+				NewUnresolvedTable("dual", ""),
+			),
+			nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, TableAccesses(sql.NewEmptyContext(), catalog, tt.node))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// AnalyzeTable is a node that collects the statistics of some tables and
+// stores them in the catalog, so the analyzer can use them.
+type AnalyzeTable struct {
+	Catalog         *sql.Catalog
+	CurrentDatabase string
+	Tables          []*UnresolvedTable
+}
+
+var analyzeTableSchema = sql.Schema{
+	{Name: "Table", Type: sql.Text},
+	{Name: "Op", Type: sql.Text},
+	{Name: "Msg_type", Type: sql.Text},
+	{Name: "Msg_text", Type: sql.Text},
+}
+
+// NewAnalyzeTable creates a new AnalyzeTable node.
+func NewAnalyzeTable(tables ...*UnresolvedTable) *AnalyzeTable {
+	return &AnalyzeTable{Tables: tables}
+}
+
+// Children implements the sql.Node interface.
+func (*AnalyzeTable) Children() []sql.Node { return nil }
+
+// Resolved implements the sql.Node interface.
+func (*AnalyzeTable) Resolved() bool { return true }
+
+// Schema implements the sql.Node interface.
+func (*AnalyzeTable) Schema() sql.Schema { return analyzeTableSchema }
+
+// RowIter implements the sql.Node interface.
+func (n *AnalyzeTable) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.AnalyzeTable")
+	defer span.Finish()
+
+	var rows = make([]sql.Row, len(n.Tables))
+	for i, t := range n.Tables {
+		db := t.Database
+		if db == "" {
+			db = n.CurrentDatabase
+		}
+
+		name := fmt.Sprintf("%s.%s", db, t.Name())
+		if err := n.analyze(ctx, db, t.Name()); err != nil {
+			// As in MySQL, failing to analyze a table does not make the
+			// whole statement fail.
+			rows[i] = sql.NewRow(name, "analyze", "Error", err.Error())
+			continue
+		}
+
+		rows[i] = sql.NewRow(name, "analyze", "status", "OK")
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+func (n *AnalyzeTable) analyze(ctx *sql.Context, db, name string) error {
+	table, err := n.Catalog.ResolveTable(ctx, db, name)
+	if err != nil {
+		return err
+	}
+
+	stats, err := sql.CollectStatistics(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	n.Catalog.SetTableStatistics(db, name, stats)
+	return nil
+}
+
+// WithChildren implements the sql.Node interface.
+func (n *AnalyzeTable) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 0)
+	}
+
+	return n, nil
+}
+
+func (n *AnalyzeTable) String() string {
+	var tables = make([]string, len(n.Tables))
+	for i, t := range n.Tables {
+		if t.Database != "" {
+			tables[i] = fmt.Sprintf("%s.%s", t.Database, t.Name())
+		} else {
+			tables[i] = t.Name()
+		}
+	}
+	return fmt.Sprintf("AnalyzeTable(%s)", strings.Join(tables, ", "))
+}
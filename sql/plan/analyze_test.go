@@ -0,0 +1,45 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeTable(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	table := memory.NewTable("foo", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "foo"},
+	})
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(1))))
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(2))))
+
+	db := memory.NewDatabase("db")
+	db.AddTable("foo", table)
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+
+	node := NewAnalyzeTable(NewUnresolvedTable("foo", ""), NewUnresolvedTable("bar", "db"))
+	node.Catalog = catalog
+	node.CurrentDatabase = "db"
+
+	iter, err := node.RowIter(ctx)
+	require.NoError(err)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Len(rows, 2)
+	require.Equal(sql.NewRow("db.foo", "analyze", "status", "OK"), rows[0])
+	require.Equal(sql.NewRow("db.bar", "analyze", "Error"), rows[1][:3])
+
+	stats := catalog.TableStatistics("db", "foo")
+	require.NotNil(stats)
+	require.Equal(uint64(2), stats.RowCount)
+	require.Equal(uint64(2), stats.Column("a").DistinctCount)
+	require.Nil(catalog.TableStatistics("db", "bar"))
+}
@@ -0,0 +1,133 @@
+package plan
+
+import (
+	"io"
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// CachedResults is a node that keeps in memory the rows of its child the
+// first time they are read, and returns them again without reading its
+// child for the rest of iterators. The same node can be used in several
+// places of a plan to execute the same query only once.
+type CachedResults struct {
+	UnaryNode
+	cache *resultsCache
+}
+
+// NewCachedResults creates a new CachedResults node.
+func NewCachedResults(child sql.Node) *CachedResults {
+	return &CachedResults{
+		UnaryNode: UnaryNode{Child: child},
+		cache:     new(resultsCache),
+	}
+}
+
+// RowIter implements the Node interface.
+func (n *CachedResults) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.CachedResults")
+
+	rows, err := n.cache.load(ctx, n.Child)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, sql.RowsToRowIter(rows...)), nil
+}
+
+// Dispose releases the rows kept in memory. They will be read again from
+// the child by the next iterator.
+func (n *CachedResults) Dispose() {
+	n.cache.dispose()
+}
+
+// WithChildren implements the Node interface.
+func (n *CachedResults) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 1)
+	}
+
+	return NewCachedResults(children[0]), nil
+}
+
+func (n *CachedResults) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("CachedResults")
+	_ = pr.WriteChildren(n.Child.String())
+	return pr.String()
+}
+
+type resultsCache struct {
+	mu     sync.Mutex
+	loaded bool
+	rows   []sql.Row
+	memory *sql.MemoryAccount
+}
+
+func (c *resultsCache) load(ctx *sql.Context, node sql.Node) ([]sql.Row, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loaded {
+		return c.rows, nil
+	}
+
+	if c.memory == nil {
+		c.memory = ctx.QueryMemory().NewAccount()
+	}
+
+	iter, err := node.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sql.Row
+	for {
+		if err := ctx.CheckCanceled(); err != nil {
+			_ = iter.Close()
+			c.memory.ReleaseAll()
+			return nil, err
+		}
+
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err == nil {
+			err = c.memory.Reserve(estimateRowSize(row))
+		}
+
+		if err != nil {
+			_ = iter.Close()
+			c.memory.ReleaseAll()
+			return nil, err
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := iter.Close(); err != nil {
+		c.memory.ReleaseAll()
+		return nil, err
+	}
+
+	c.rows = rows
+	c.loaded = true
+	return rows, nil
+}
+
+func (c *resultsCache) dispose() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.memory != nil {
+		c.memory.ReleaseAll()
+		c.memory = nil
+	}
+
+	c.rows = nil
+	c.loaded = false
+}
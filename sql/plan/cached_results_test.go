@@ -0,0 +1,30 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedResults(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	table := memory.NewTable("foo", lSchema)
+	insertData(t, table)
+
+	cached := NewCachedResults(NewResolvedTable(table))
+	require.Equal(lSchema, cached.Schema())
+
+	rows := collectRows(t, cached)
+	require.Len(rows, 2)
+
+	// The rows are not read again from the table.
+	require.NoError(table.Insert(ctx, sql.NewRow("col1_3", "col2_3", int32(5), int64(6))))
+	require.Equal(rows, collectRows(t, cached))
+
+	cached.Dispose()
+	require.Len(collectRows(t, cached), 3)
+}
@@ -0,0 +1,61 @@
+package plan
+
+import "github.com/src-d/go-mysql-server/sql"
+
+// ChangeTarget is the table whose changed rows are recorded by an INSERT,
+// UPDATE or DELETE statement.
+type ChangeTarget struct {
+	Recorder sql.ChangeRecorder
+	Database string
+	Table    string
+}
+
+// changeLog collects the rows changed by a statement. A nil changeLog
+// does not record anything.
+type changeLog struct {
+	target  *ChangeTarget
+	changes sql.TableChanges
+}
+
+func newChangeLog(target *ChangeTarget, schema sql.Schema) *changeLog {
+	if target == nil || target.Recorder == nil {
+		return nil
+	}
+
+	return &changeLog{
+		target: target,
+		changes: sql.TableChanges{
+			Database: target.Database,
+			Table:    target.Table,
+			Schema:   schema,
+		},
+	}
+}
+
+func (l *changeLog) add(typ sql.RowChangeType, before, after sql.Row) {
+	if l == nil {
+		return
+	}
+
+	change := sql.RowChange{Type: typ}
+	if before != nil {
+		change.Before = before.Copy()
+	}
+	if after != nil {
+		change.After = after.Copy()
+	}
+	l.changes.Rows = append(l.changes.Rows, change)
+}
+
+// record records the changes collected, if any, returning err if it's not
+// nil or the error recording them otherwise.
+func (l *changeLog) record(ctx *sql.Context, err error) error {
+	if l == nil || len(l.changes.Rows) == 0 {
+		return err
+	}
+
+	if rerr := l.target.Recorder.RecordChanges(ctx, l.changes); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+type changeRecorder []sql.TableChanges
+
+func (r *changeRecorder) RecordChanges(ctx *sql.Context, changes sql.TableChanges) error {
+	*r = append(*r, changes)
+	return nil
+}
+
+func TestRecordChanges(t *testing.T) {
+	require := require.New(t)
+
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+		{Name: "b", Type: sql.Text, Source: "t"},
+	}
+	table := memory.NewTable("t", schema)
+	ctx := sql.NewEmptyContext()
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(1), "foo")))
+
+	var recorder changeRecorder
+	target := &ChangeTarget{Recorder: &recorder, Database: "db", Table: "t"}
+	values := func(a int64, b string) sql.Node {
+		return NewValues([][]sql.Expression{{
+			expression.NewLiteral(a, sql.Int64),
+			expression.NewLiteral(b, sql.Text),
+		}})
+	}
+
+	replace := NewInsertInto(NewResolvedTable(table), values(1, "foo"), true, nil)
+	replace.Changes = target
+	_, err := replace.Execute(ctx)
+	require.NoError(err)
+
+	insert := NewInsertInto(NewResolvedTable(table), values(2, "bar"), false, nil)
+	insert.Changes = target
+	_, err = insert.Execute(ctx)
+	require.NoError(err)
+
+	update := NewUpdate(NewResolvedTable(table), []sql.Expression{
+		expression.NewSetField(
+			expression.NewGetFieldWithTable(1, sql.Text, "t", "b", false),
+			expression.NewLiteral("baz", sql.Text),
+		),
+	})
+	update.Changes = target
+	_, _, err = update.Execute(ctx)
+	require.NoError(err)
+
+	// Statements that change nothing do not record anything.
+	del := NewDeleteFrom(NewFilter(expression.NewLiteral(false, sql.Boolean), NewResolvedTable(table)))
+	del.Changes = target
+	_, err = del.Execute(ctx)
+	require.NoError(err)
+
+	del = NewDeleteFrom(NewResolvedTable(table))
+	del.Changes = target
+	_, err = del.Execute(ctx)
+	require.NoError(err)
+
+	changes := func(rows ...sql.RowChange) sql.TableChanges {
+		return sql.TableChanges{Database: "db", Table: "t", Schema: schema, Rows: rows}
+	}
+	require.Equal(changeRecorder{
+		changes(
+			sql.RowChange{Type: sql.RowDeleted, Before: sql.NewRow(int64(1), "foo")},
+			sql.RowChange{Type: sql.RowInserted, After: sql.NewRow(int64(1), "foo")},
+		),
+		changes(
+			sql.RowChange{Type: sql.RowInserted, After: sql.NewRow(int64(2), "bar")},
+		),
+		changes(
+			sql.RowChange{Type: sql.RowUpdated, Before: sql.NewRow(int64(1), "foo"), After: sql.NewRow(int64(1), "baz")},
+			sql.RowChange{Type: sql.RowUpdated, Before: sql.NewRow(int64(2), "bar"), After: sql.NewRow(int64(2), "baz")},
+		),
+		changes(
+			sql.RowChange{Type: sql.RowDeleted, Before: sql.NewRow(int64(1), "baz")},
+			sql.RowChange{Type: sql.RowDeleted, Before: sql.NewRow(int64(2), "baz")},
+		),
+	}, recorder)
+}
@@ -0,0 +1,102 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// ColumnOrigin is the column of a table a column of the schema of a node
+// comes from.
+type ColumnOrigin struct {
+	// Table is the name the table is referred with in the query, which is
+	// its alias if it has one.
+	Table string
+	// OriginalTable is the name of the table.
+	OriginalTable string
+	// Column is the column of the table.
+	Column *sql.Column
+}
+
+// ColumnOrigins returns the table columns the columns of the schema of a
+// node come from, which are nil for the columns that are not columns of a
+// table, such as the ones computed with expressions. Columns selected with
+// an alias come from the column they're an alias of.
+func ColumnOrigins(n sql.Node) []*ColumnOrigin {
+	switch n := n.(type) {
+	case *ResolvedTable:
+		schema := n.Schema()
+		origins := make([]*ColumnOrigin, len(schema))
+		for i, col := range schema {
+			origins[i] = &ColumnOrigin{Table: n.Name(), OriginalTable: n.Name(), Column: col}
+		}
+		return origins
+	case *TableAlias:
+		return renameOrigins(ColumnOrigins(n.Child), n.Name())
+	case *SubqueryAlias:
+		return renameOrigins(ColumnOrigins(n.Child), n.Name())
+	case *Project:
+		return expressionOrigins(n.Projections, ColumnOrigins(n.Child))
+	case *GroupBy:
+		return expressionOrigins(n.Aggregate, ColumnOrigins(n.Child))
+	}
+
+	// Nodes that don't change the columns of their children, such as
+	// filters, sorts or joins, return the columns of their children.
+	schema := n.Schema()
+	var origins []*ColumnOrigin
+	var columns sql.Schema
+	for _, child := range n.Children() {
+		origins = append(origins, ColumnOrigins(child)...)
+		columns = append(columns, child.Schema()...)
+		if sameColumns(schema, columns) {
+			return origins
+		}
+	}
+
+	return make([]*ColumnOrigin, len(schema))
+}
+
+// renameOrigins returns the given origins with the table referred with
+// the given name.
+func renameOrigins(origins []*ColumnOrigin, table string) []*ColumnOrigin {
+	renamed := make([]*ColumnOrigin, len(origins))
+	for i, o := range origins {
+		if o != nil {
+			renamed[i] = &ColumnOrigin{Table: table, OriginalTable: o.OriginalTable, Column: o.Column}
+		}
+	}
+	return renamed
+}
+
+// expressionOrigins returns the origins of the columns of the given
+// expressions, which are the ones of the columns of the child they refer
+// to.
+func expressionOrigins(exprs []sql.Expression, child []*ColumnOrigin) []*ColumnOrigin {
+	origins := make([]*ColumnOrigin, len(exprs))
+	for i, e := range exprs {
+		if alias, ok := e.(*expression.Alias); ok {
+			e = alias.Child
+		}
+
+		if gf, ok := e.(*expression.GetField); ok && gf.Index() >= 0 && gf.Index() < len(child) {
+			origins[i] = child[gf.Index()]
+		}
+	}
+	return origins
+}
+
+// sameColumns reports whether two schemas have the same columns.
+func sameColumns(a, b sql.Schema) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !strings.EqualFold(a[i].Name, b[i].Name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnOrigins(t *testing.T) {
+	require := require.New(t)
+
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t", PrimaryKey: true},
+		{Name: "b", Type: sql.Text, Source: "t"},
+	}
+	table := NewResolvedTable(memory.NewTable("t", schema))
+	other := NewResolvedTable(memory.NewTable("u", sql.Schema{
+		{Name: "c", Type: sql.Int64, Source: "u"},
+	}))
+
+	node := NewProject(
+		[]sql.Expression{
+			expression.NewAlias(expression.NewGetFieldWithTable(1, sql.Text, "x", "b", false), "foo"),
+			expression.NewGetFieldWithTable(2, sql.Int64, "u", "c", false),
+			expression.NewLiteral(int64(1), sql.Int64),
+		},
+		NewFilter(
+			expression.NewLiteral(true, sql.Boolean),
+			NewCrossJoin(NewTableAlias("x", table), other),
+		),
+	)
+
+	require.Equal([]*ColumnOrigin{
+		{Table: "x", OriginalTable: "t", Column: schema[1]},
+		{Table: "u", OriginalTable: "u", Column: other.Schema()[0]},
+		nil,
+	}, ColumnOrigins(node))
+
+	groupBy := NewGroupBy(
+		[]sql.Expression{
+			expression.NewGetFieldWithTable(0, sql.Int64, "t", "a", false),
+			expression.NewLiteral(int64(1), sql.Int64),
+		},
+		[]sql.Expression{expression.NewGetFieldWithTable(0, sql.Int64, "t", "a", false)},
+		table,
+	)
+	require.Equal([]*ColumnOrigin{
+		{Table: "t", OriginalTable: "t", Column: schema[0]},
+		nil,
+	}, ColumnOrigins(groupBy))
+}
@@ -112,7 +112,10 @@ func (c *CreateIndex) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	if c.Driver == "" {
 		driver = c.Catalog.DefaultIndexDriver()
 	} else {
-		driver = c.Catalog.IndexDriver(c.Driver)
+		driver, err = c.Catalog.LoadIndexDriver(c.Driver)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if driver == nil {
@@ -165,7 +168,13 @@ func (c *CreateIndex) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 		return nil, err
 	}
 
-	log := logrus.WithFields(logrus.Fields{
+	var total int64 = -1
+	if stats := c.Catalog.TableStatistics(c.CurrentDatabase, table.Name()); stats != nil {
+		total = int64(stats.RowCount)
+	}
+	c.Catalog.SetIndexProgress(index, sql.IndexProgress{Total: total})
+
+	log := ctx.Logger().WithFields(logrus.Fields{
 		"id":     index.ID(),
 		"driver": index.Driver(),
 	})
@@ -204,7 +213,13 @@ func (c *CreateIndex) createIndex(
 
 	l := log.WithField("id", index.ID())
 
-	err := driver.Save(ctx, index, newLoggingPartitionKeyValueIter(ctx, l, iter))
+	progress := func(rows uint64) {
+		p, _ := c.Catalog.IndexProgress(index)
+		p.Rows = int64(rows)
+		c.Catalog.SetIndexProgress(index, p)
+	}
+
+	err := driver.Save(ctx, index, newLoggingPartitionKeyValueIter(ctx, l, iter, progress))
 	close(done)
 
 	if err != nil {
@@ -220,12 +235,12 @@ func (c *CreateIndex) createIndex(
 		})
 
 		ctx.Error(0, "unable to save the index: %s", err)
-		logrus.WithField("err", err).Error("unable to save the index")
+		log.WithField("err", err).Error("unable to save the index")
 
 		deleted, err := c.Catalog.DeleteIndex(index.Database(), index.ID(), true)
 		if err != nil {
 			ctx.Error(0, "unable to delete index: %s", err)
-			logrus.WithField("err", err).Error("unable to delete the index")
+			log.WithField("err", err).Error("unable to delete the index")
 		} else {
 			<-deleted
 		}
@@ -393,17 +408,22 @@ type loggingPartitionKeyValueIter struct {
 	log  *logrus.Entry
 	iter sql.PartitionIndexKeyValueIter
 	rows uint64
+	// progress is notified of the number of rows read every batch of
+	// rows.
+	progress func(rows uint64)
 }
 
 func newLoggingPartitionKeyValueIter(
 	ctx *sql.Context,
 	log *logrus.Entry,
 	iter sql.PartitionIndexKeyValueIter,
+	progress func(rows uint64),
 ) *loggingPartitionKeyValueIter {
 	return &loggingPartitionKeyValueIter{
-		ctx:  ctx,
-		log:  log,
-		iter: iter,
+		ctx:      ctx,
+		log:      log,
+		iter:     iter,
+		progress: progress,
 	}
 }
 
@@ -413,7 +433,7 @@ func (i *loggingPartitionKeyValueIter) Next() (sql.Partition, sql.IndexKeyValueI
 		return nil, nil, err
 	}
 
-	return p, newLoggingKeyValueIter(i.ctx, i.log, iter, &i.rows), nil
+	return p, newLoggingKeyValueIter(i.ctx, i.log, iter, &i.rows, i.progress), nil
 }
 
 func (i *loggingPartitionKeyValueIter) Close() error {
@@ -421,12 +441,13 @@ func (i *loggingPartitionKeyValueIter) Close() error {
 }
 
 type loggingKeyValueIter struct {
-	ctx   *sql.Context
-	span  opentracing.Span
-	log   *logrus.Entry
-	iter  sql.IndexKeyValueIter
-	rows  *uint64
-	start time.Time
+	ctx      *sql.Context
+	span     opentracing.Span
+	log      *logrus.Entry
+	iter     sql.IndexKeyValueIter
+	rows     *uint64
+	progress func(rows uint64)
+	start    time.Time
 }
 
 func newLoggingKeyValueIter(
@@ -434,13 +455,15 @@ func newLoggingKeyValueIter(
 	log *logrus.Entry,
 	iter sql.IndexKeyValueIter,
 	rows *uint64,
+	progress func(rows uint64),
 ) *loggingKeyValueIter {
 	return &loggingKeyValueIter{
-		ctx:   ctx,
-		log:   log,
-		iter:  iter,
-		start: time.Now(),
-		rows:  rows,
+		ctx:      ctx,
+		log:      log,
+		iter:     iter,
+		start:    time.Now(),
+		rows:     rows,
+		progress: progress,
 	}
 }
 
@@ -462,6 +485,10 @@ func (i *loggingKeyValueIter) Next() ([]interface{}, []byte, error) {
 			"rows":     *i.rows,
 		}).Debugf("still creating index")
 
+		if i.progress != nil {
+			i.progress(*i.rows)
+		}
+
 		if i.span != nil {
 			i.span.LogKV("duration", duration.String())
 			i.span.Finish()
@@ -171,6 +171,50 @@ func TestCreateIndexSync(t *testing.T) {
 	require.True(found)
 }
 
+func TestCreateIndexWithDriverFactory(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("foo", sql.Schema{
+		{Name: "a", Source: "foo"},
+	})
+
+	driver := new(mockDriver)
+	sql.RegisterIndexDriverFactory("mock-factory", func(string) (sql.IndexDriver, error) {
+		return driver, nil
+	})
+
+	catalog := sql.NewCatalog()
+	db := memory.NewDatabase("foo")
+	db.AddTable("foo", table)
+	catalog.AddDatabase(db)
+
+	exprs := []sql.Expression{
+		expression.NewGetFieldWithTable(0, sql.Int64, "foo", "a", true),
+	}
+
+	ci := NewCreateIndex(
+		"idx", NewResolvedTable(table), exprs, "mock-factory",
+		map[string]string{"async": "false"},
+	)
+	ci.Catalog = catalog
+	ci.CurrentDatabase = "foo"
+
+	_, err := ci.RowIter(sql.NewEmptyContext())
+	require.NoError(err)
+	require.Equal([]string{"idx"}, driver.saved)
+	require.NotNil(catalog.IndexRegistry.Index("foo", "idx"))
+
+	ci = NewCreateIndex(
+		"idx2", NewResolvedTable(table), exprs, "unknown",
+		map[string]string{"async": "false"},
+	)
+	ci.Catalog = catalog
+	ci.CurrentDatabase = "foo"
+
+	_, err = ci.RowIter(sql.NewEmptyContext())
+	require.True(ErrInvalidIndexDriver.Is(err))
+}
+
 func TestCreateIndexChecksum(t *testing.T) {
 	require := require.New(t)
 
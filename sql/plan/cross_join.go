@@ -97,6 +97,10 @@ type crossJoinIterator struct {
 
 func (i *crossJoinIterator) Next() (sql.Row, error) {
 	for {
+		if err := i.s.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
 		if i.leftRow == nil {
 			r, err := i.l.Next()
 			if err != nil {
@@ -0,0 +1,113 @@
+package plan
+
+import (
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// CreateDatabase is a node creating a database with the database creator of
+// the catalog.
+type CreateDatabase struct {
+	Name        string
+	IfNotExists bool
+	Catalog     *sql.Catalog
+}
+
+// NewCreateDatabase creates a CreateDatabase node.
+func NewCreateDatabase(name string, ifNotExists bool) *CreateDatabase {
+	return &CreateDatabase{Name: name, IfNotExists: ifNotExists}
+}
+
+// Resolved implements the Node interface.
+func (c *CreateDatabase) Resolved() bool { return true }
+
+// Schema implements the Node interface.
+func (c *CreateDatabase) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (c *CreateDatabase) Children() []sql.Node { return nil }
+
+// RowIter implements the Node interface.
+func (c *CreateDatabase) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	if _, err := c.Catalog.ResolveDatabase(ctx, c.Name); err == nil {
+		if !c.IfNotExists {
+			return nil, sql.ErrDatabaseExists.New(c.Name)
+		}
+
+		ctx.Warn(1007, "can't create database %s; database exists", c.Name)
+		return sql.RowsToRowIter(), nil
+	}
+
+	creator := c.Catalog.DatabaseCreator()
+	if creator == nil {
+		return nil, sql.ErrDatabaseCreation.New(c.Name)
+	}
+
+	db, err := creator.CreateDatabase(ctx, c.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Catalog.AddDatabase(db)
+	return sql.RowsToRowIter(), nil
+}
+
+// WithChildren implements the Node interface.
+func (c *CreateDatabase) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(c, len(children), 0)
+	}
+	return c, nil
+}
+
+func (c *CreateDatabase) String() string {
+	return fmt.Sprintf("CreateDatabase(%s)", c.Name)
+}
+
+// DropDatabase is a node removing a database from the catalog.
+type DropDatabase struct {
+	Name     string
+	IfExists bool
+	Catalog  *sql.Catalog
+}
+
+// NewDropDatabase creates a DropDatabase node.
+func NewDropDatabase(name string, ifExists bool) *DropDatabase {
+	return &DropDatabase{Name: name, IfExists: ifExists}
+}
+
+// Resolved implements the Node interface.
+func (d *DropDatabase) Resolved() bool { return true }
+
+// Schema implements the Node interface.
+func (d *DropDatabase) Schema() sql.Schema { return nil }
+
+// Children implements the Node interface.
+func (d *DropDatabase) Children() []sql.Node { return nil }
+
+// RowIter implements the Node interface.
+func (d *DropDatabase) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	err := d.Catalog.DropDatabase(d.Name)
+	if sql.ErrDatabaseNotFound.Is(err) && d.IfExists {
+		ctx.Warn(1008, "can't drop database %s; database doesn't exist", d.Name)
+		return sql.RowsToRowIter(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.RowsToRowIter(), nil
+}
+
+// WithChildren implements the Node interface.
+func (d *DropDatabase) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(d, len(children), 0)
+	}
+	return d, nil
+}
+
+func (d *DropDatabase) String() string {
+	return fmt.Sprintf("DropDatabase(%s)", d.Name)
+}
@@ -0,0 +1,49 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDropDatabase(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	catalog := sql.NewCatalog()
+	create := func(name string, ifNotExists bool) error {
+		c := NewCreateDatabase(name, ifNotExists)
+		c.Catalog = catalog
+		_, err := c.RowIter(ctx)
+		return err
+	}
+	drop := func(name string, ifExists bool) error {
+		d := NewDropDatabase(name, ifExists)
+		d.Catalog = catalog
+		_, err := d.RowIter(ctx)
+		return err
+	}
+
+	require.True(sql.ErrDatabaseCreation.Is(create("mydb", false)))
+
+	catalog.SetDatabaseCreator(memory.DatabaseCreator{})
+	require.NoError(create("mydb", false))
+	db, err := catalog.Database("mydb")
+	require.NoError(err)
+	require.Equal("mydb", db.Name())
+
+	require.True(sql.ErrDatabaseExists.Is(create("mydb", false)))
+	require.NoError(create("mydb", true))
+	require.Equal(1007, ctx.Warnings()[0].Code)
+
+	require.NoError(drop("mydb", false))
+	_, err = catalog.Database("mydb")
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+
+	require.True(sql.ErrDatabaseNotFound.Is(drop("mydb", false)))
+	ctx.ClearWarnings()
+	require.NoError(drop("mydb", true))
+	require.Equal(1008, ctx.Warnings()[0].Code)
+}
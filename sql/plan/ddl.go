@@ -10,11 +10,16 @@ import (
 var ErrCreateTableNotSupported = errors.NewKind("tables cannot be created on database %s")
 var ErrDropTableNotSupported = errors.NewKind("tables cannot be dropped on database %s")
 
+// ErrPartitioningNotSupported is thrown when the database doesn't support
+// the creation of partitioned tables.
+var ErrPartitioningNotSupported = errors.NewKind("partitioned tables cannot be created on database %s")
+
 // CreateTable is a node describing the creation of some table.
 type CreateTable struct {
-	db     sql.Database
-	name   string
-	schema sql.Schema
+	db           sql.Database
+	name         string
+	schema       sql.Schema
+	partitioning *sql.Partitioning
 }
 
 // NewCreateTable creates a new CreateTable node
@@ -30,6 +35,19 @@ func NewCreateTable(db sql.Database, name string, schema sql.Schema) *CreateTabl
 	}
 }
 
+// NewCreatePartitionedTable creates a new CreateTable node for a table
+// with the given partitioning.
+func NewCreatePartitionedTable(
+	db sql.Database,
+	name string,
+	schema sql.Schema,
+	partitioning *sql.Partitioning,
+) *CreateTable {
+	c := NewCreateTable(db, name, schema)
+	c.partitioning = partitioning
+	return c
+}
+
 var _ sql.Databaser = (*CreateTable)(nil)
 
 // Database implements the sql.Databaser interface.
@@ -44,6 +62,11 @@ func (c *CreateTable) WithDatabase(db sql.Database) (sql.Node, error) {
 	return &nc, nil
 }
 
+// TableName returns the name of the table to create.
+func (c *CreateTable) TableName() string {
+	return c.name
+}
+
 // Resolved implements the Resolvable interface.
 func (c *CreateTable) Resolved() bool {
 	_, ok := c.db.(sql.UnresolvedDatabase)
@@ -52,6 +75,15 @@ func (c *CreateTable) Resolved() bool {
 
 // RowIter implements the Node interface.
 func (c *CreateTable) RowIter(s *sql.Context) (sql.RowIter, error) {
+	if c.partitioning != nil {
+		creatable, ok := c.db.(sql.PartitionedTableCreator)
+		if !ok {
+			return nil, ErrPartitioningNotSupported.New(c.db.Name())
+		}
+
+		return sql.RowsToRowIter(), creatable.CreatePartitionedTable(s, c.name, c.schema, c.partitioning)
+	}
+
 	creatable, ok := c.db.(sql.TableCreator)
 	if ok {
 		return sql.RowsToRowIter(), creatable.CreateTable(s, c.name, c.schema)
@@ -108,6 +140,11 @@ func (d *DropTable) WithDatabase(db sql.Database) (sql.Node, error) {
 	return &nc, nil
 }
 
+// TableNames returns the names of the tables to drop.
+func (d *DropTable) TableNames() []string {
+	return d.names
+}
+
 // Resolved implements the Resolvable interface.
 func (d *DropTable) Resolved() bool {
 	_, ok := d.db.(sql.UnresolvedDatabase)
@@ -11,11 +11,13 @@ var ErrDeleteFromNotSupported = errors.NewKind("table doesn't support DELETE FRO
 // DeleteFrom is a node describing a deletion from some table.
 type DeleteFrom struct {
 	sql.Node
+	// Changes is where the deleted rows are recorded, if they are.
+	Changes *ChangeTarget
 }
 
 // NewDeleteFrom creates a DeleteFrom node.
 func NewDeleteFrom(n sql.Node) *DeleteFrom {
-	return &DeleteFrom{n}
+	return &DeleteFrom{Node: n}
 }
 
 // Schema implements the Node interface.
@@ -65,12 +67,17 @@ func getDeletableTable(t sql.Table) (sql.Deleter, error) {
 }
 
 // Execute deletes the rows in the database.
-func (p *DeleteFrom) Execute(ctx *sql.Context) (int, error) {
+func (p *DeleteFrom) Execute(ctx *sql.Context) (_ int, err error) {
 	deletable, err := getDeletable(p.Node)
 	if err != nil {
 		return 0, err
 	}
 
+	changes := newChangeLog(p.Changes, p.Node.Schema())
+	defer func() {
+		err = changes.record(ctx, err)
+	}()
+
 	iter, err := p.Node.RowIter(ctx)
 	if err != nil {
 		return 0, err
@@ -92,6 +99,7 @@ func (p *DeleteFrom) Execute(ctx *sql.Context) (int, error) {
 			_ = iter.Close()
 			return i, err
 		}
+		changes.add(sql.RowDeleted, row, nil)
 
 		i++
 	}
@@ -101,11 +109,16 @@ func (p *DeleteFrom) Execute(ctx *sql.Context) (int, error) {
 
 // RowIter implements the Node interface.
 func (p *DeleteFrom) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.DeleteFrom")
+	defer span.Finish()
+
 	n, err := p.Execute(ctx)
+	span.SetTag("rows", n)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx.SetLastQueryInfo(sql.RowCount, int64(n))
 	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
 }
 
@@ -114,7 +127,9 @@ func (p *DeleteFrom) WithChildren(children ...sql.Node) (sql.Node, error) {
 	if len(children) != 1 {
 		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
 	}
-	return NewDeleteFrom(children[0]), nil
+	np := NewDeleteFrom(children[0])
+	np.Changes = p.Changes
+	return np, nil
 }
 
 func (p DeleteFrom) String() string {
@@ -1,10 +1,13 @@
 package plan
 
 import (
+	"encoding/json"
 	"io"
+	"reflect"
 	"strings"
 
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
 )
 
 // Describe is a node that describes its children.
@@ -91,6 +94,14 @@ func (d *DescribeQuery) Schema() sql.Schema {
 
 // RowIter implements the Node interface.
 func (d *DescribeQuery) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	if d.Format == "json" {
+		plan, err := json.MarshalIndent(describeJSON(d.Child), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return sql.RowsToRowIter(sql.NewRow(string(plan))), nil
+	}
+
 	var rows []sql.Row
 	for _, l := range strings.Split(d.Child.String(), "\n") {
 		if strings.TrimSpace(l) != "" {
@@ -115,3 +126,93 @@ func (d *DescribeQuery) WithChildren(children ...sql.Node) (sql.Node, error) {
 
 	return NewDescribeQuery(d.Format, children[0]), nil
 }
+
+// jsonNode is the description of a node in the JSON plans of DescribeQuery.
+type jsonNode struct {
+	// Node is the type of the node.
+	Node string `json:"node"`
+	// Description is the line describing the node in the tree format.
+	Description string       `json:"description"`
+	Schema      []jsonColumn `json:"schema,omitempty"`
+	Expressions []string     `json:"expressions,omitempty"`
+	Subqueries  []*jsonNode  `json:"subqueries,omitempty"`
+	Children    []*jsonNode  `json:"children,omitempty"`
+}
+
+type jsonColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Source   string `json:"source,omitempty"`
+	Nullable bool   `json:"nullable"`
+}
+
+// describeJSON returns the description of the plan of the given node for
+// the JSON format. Nodes that only wrap their child without changing its
+// description, such as the ones tracking the process of the query, are not
+// included.
+func describeJSON(n sql.Node) *jsonNode {
+	children := n.Children()
+	if len(children) == 1 && n.String() == children[0].String() {
+		return describeJSON(children[0])
+	}
+
+	typ := reflect.TypeOf(n)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	node := &jsonNode{
+		Node:        typ.Name(),
+		Description: nodeDescription(n),
+	}
+
+	for _, col := range n.Schema() {
+		node.Schema = append(node.Schema, jsonColumn{
+			Name:     col.Name,
+			Type:     sql.MySQLTypeName(col.Type),
+			Source:   col.Source,
+			Nullable: col.Nullable,
+		})
+	}
+
+	if e, ok := n.(sql.Expressioner); ok {
+		for _, e := range e.Expressions() {
+			node.Expressions = append(node.Expressions, e.String())
+			expression.Inspect(e, func(e sql.Expression) bool {
+				if s, ok := e.(*expression.Subquery); ok {
+					node.Subqueries = append(node.Subqueries, describeJSON(s.Query))
+				}
+				return true
+			})
+		}
+	}
+
+	for _, c := range children {
+		node.Children = append(node.Children, describeJSON(c))
+	}
+
+	return node
+}
+
+// nodeDescription returns the description of the node in the tree format,
+// without the description of its children. Nodes without children, such as
+// tables, are only described with their first line.
+func nodeDescription(n sql.Node) string {
+	s := n.String()
+	if children := n.Children(); len(children) > 0 {
+		var descriptions = make([]string, len(children))
+		for i, c := range children {
+			descriptions[i] = c.String()
+		}
+
+		p := sql.NewTreePrinter()
+		_ = p.WriteNode("")
+		_ = p.WriteChildren(descriptions...)
+		rendered := strings.TrimPrefix(p.String(), "\n")
+		if strings.HasSuffix(s, rendered) {
+			return strings.TrimSpace(strings.TrimSuffix(s, rendered))
+		}
+	}
+
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
@@ -90,3 +90,90 @@ func TestDescribeQuery(t *testing.T) {
 
 	require.Equal(expected, rows)
 }
+
+func TestDescribeQueryJSON(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("foo", sql.Schema{
+		{Source: "foo", Name: "a", Type: sql.Text},
+	})
+
+	node := NewDescribeQuery("json", NewQueryProcess(NewFilter(
+		expression.NewEquals(
+			expression.NewGetFieldWithTable(0, sql.Text, "foo", "a", false),
+			expression.NewSubquery(NewProject(
+				[]sql.Expression{expression.NewLiteral("foo", sql.Text)},
+				NewResolvedTable(table),
+			)),
+		),
+		NewResolvedTable(table),
+	), nil))
+
+	iter, err := node.RowIter(sql.NewEmptyContext())
+	require.NoError(err)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Len(rows, 1)
+
+	expected := `{
+  "node": "Filter",
+  "description": "Filter(foo.a = (Project(\"foo\")\n └─ Table(foo)\n     └─ Column(a, TEXT, nullable=false)\n))",
+  "schema": [
+    {
+      "name": "a",
+      "type": "TEXT",
+      "source": "foo",
+      "nullable": false
+    }
+  ],
+  "expressions": [
+    "foo.a = (Project(\"foo\")\n └─ Table(foo)\n     └─ Column(a, TEXT, nullable=false)\n)"
+  ],
+  "subqueries": [
+    {
+      "node": "Project",
+      "description": "Project(\"foo\")",
+      "schema": [
+        {
+          "name": "\"foo\"",
+          "type": "TEXT",
+          "nullable": false
+        }
+      ],
+      "expressions": [
+        "\"foo\""
+      ],
+      "children": [
+        {
+          "node": "ResolvedTable",
+          "description": "Table(foo)",
+          "schema": [
+            {
+              "name": "a",
+              "type": "TEXT",
+              "source": "foo",
+              "nullable": false
+            }
+          ]
+        }
+      ]
+    }
+  ],
+  "children": [
+    {
+      "node": "ResolvedTable",
+      "description": "Table(foo)",
+      "schema": [
+        {
+          "name": "a",
+          "type": "TEXT",
+          "source": "foo",
+          "nullable": false
+        }
+      ]
+    }
+  ]
+}`
+	require.Equal(expected, rows[0][0])
+}
@@ -58,22 +58,34 @@ func (d Distinct) String() string {
 // Even though they are just 64-bit integers, this could be a problem in large
 // result sets.
 type distinctIter struct {
+	ctx       *sql.Context
 	childIter sql.RowIter
 	seen      sql.KeyValueCache
 	dispose   sql.DisposeFunc
+	memory    *sql.MemoryAccount
 }
 
+// distinctHashSize is the estimated number of bytes used to keep the hash
+// of a row in memory.
+const distinctHashSize = 16
+
 func newDistinctIter(ctx *sql.Context, child sql.RowIter) *distinctIter {
 	cache, dispose := ctx.Memory.NewHistoryCache()
 	return &distinctIter{
+		ctx:       ctx,
 		childIter: child,
 		seen:      cache,
 		dispose:   dispose,
+		memory:    ctx.QueryMemory().NewAccount(),
 	}
 }
 
 func (di *distinctIter) Next() (sql.Row, error) {
 	for {
+		if err := di.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
 		row, err := di.childIter.Next()
 		if err != nil {
 			if err == io.EOF {
@@ -87,6 +99,10 @@ func (di *distinctIter) Next() (sql.Row, error) {
 			continue
 		}
 
+		if err := di.memory.Reserve(distinctHashSize); err != nil {
+			return nil, err
+		}
+
 		if err := di.seen.Put(hash, struct{}{}); err != nil {
 			return nil, err
 		}
@@ -104,6 +120,7 @@ func (di *distinctIter) Dispose() {
 	if di.dispose != nil {
 		di.dispose()
 	}
+	di.memory.ReleaseAll()
 }
 
 // OrderedDistinct is a Distinct node optimized for sorted row sets.
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"context"
 	"io"
 	"testing"
 
@@ -169,3 +170,33 @@ func BenchmarkOrderedDistinct(b *testing.B) {
 		require.Equal(100, rows)
 	}
 }
+
+func TestDistinctQueryMemory(t *testing.T) {
+	require := require.New(t)
+
+	child := memory.NewTable("test", sql.Schema{
+		{Name: "a", Type: sql.Int64},
+	})
+	for i := 0; i < 10; i++ {
+		require.NoError(child.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i))))
+	}
+
+	session := sql.NewBaseSession()
+	session.Set("max_query_memory", sql.Int64, int64(5*distinctHashSize))
+	ctx := sql.NewContext(context.Background(), sql.WithSession(session))
+
+	iter, err := NewDistinct(NewResolvedTable(child)).RowIter(ctx)
+	require.NoError(err)
+
+	for i := 0; i < 5; i++ {
+		_, err = iter.Next()
+		require.NoError(err)
+	}
+
+	_, err = iter.Next()
+	require.Error(err)
+	require.True(sql.ErrQueryMemoryExceeded.Is(err))
+
+	require.NoError(iter.Close())
+	require.Equal(uint64(0), ctx.QueryMemory().Used())
+}
@@ -42,7 +42,7 @@ func (d *DropIndex) Children() []sql.Node { return []sql.Node{d.Table} }
 
 // RowIter implements the Node interface.
 func (d *DropIndex) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	db, err := d.Catalog.Database(d.CurrentDatabase)
+	db, err := d.Catalog.ResolveDatabase(ctx, d.CurrentDatabase)
 	if err != nil {
 		return nil, err
 	}
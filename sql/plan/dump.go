@@ -0,0 +1,375 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// ErrDumpTables is returned when the tables to dump are given for several
+// databases.
+var ErrDumpTables = errors.NewKind("tables can only be dumped from a single database")
+
+// dumpInsertSize is the size after which the rows of a table are written
+// in a new INSERT statement.
+const dumpInsertSize = 1 << 20
+
+// DumpOptions are the options of a dump.
+type DumpOptions struct {
+	// Databases are the databases to dump, or all of them if it's empty,
+	// except for the information schema.
+	Databases []string
+	// Tables are the tables to dump from the only database, or all of its
+	// tables if it's empty.
+	Tables []string
+	// CreateDatabases is whether the statements creating and using the
+	// databases are written, which they always are when all or several
+	// databases are dumped.
+	CreateDatabases bool
+}
+
+// WriteDump writes the schema and rows of the tables of the given databases
+// as SQL statements compatible with the ones written by mysqldump, so they
+// can be restored in the engine or in MySQL. It returns the number of rows
+// written.
+func WriteDump(ctx *sql.Context, catalog *sql.Catalog, w io.Writer, opts DumpOptions) (int64, error) {
+	dbs, err := dumpDatabases(ctx, catalog, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	d := &dumpWriter{
+		ctx:    ctx,
+		w:      bufio.NewWriter(w),
+		create: opts.CreateDatabases || len(opts.Databases) != 1,
+	}
+
+	d.header(dbs)
+	for _, db := range dbs {
+		if err := d.database(db, opts.Tables); err != nil {
+			return d.rows, err
+		}
+	}
+	d.footer()
+
+	return d.rows, d.w.Flush()
+}
+
+func dumpDatabases(ctx *sql.Context, catalog *sql.Catalog, opts DumpOptions) ([]sql.Database, error) {
+	if len(opts.Tables) > 0 && len(opts.Databases) != 1 {
+		return nil, ErrDumpTables.New()
+	}
+
+	if len(opts.Databases) == 0 {
+		var dbs []sql.Database
+		for _, db := range catalog.AllDatabases() {
+			if db.Name() != sql.InformationSchemaDatabaseName {
+				dbs = append(dbs, db)
+			}
+		}
+		return dbs, nil
+	}
+
+	dbs := make([]sql.Database, len(opts.Databases))
+	for i, name := range opts.Databases {
+		db, err := catalog.ResolveDatabase(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		dbs[i] = db
+	}
+	return dbs, nil
+}
+
+// dumpWriter writes the statements of a dump. Errors writing are kept by
+// the buffered writer and returned when it's flushed.
+type dumpWriter struct {
+	ctx    *sql.Context
+	w      *bufio.Writer
+	create bool
+	rows   int64
+}
+
+func (d *dumpWriter) header(dbs []sql.Database) {
+	fmt.Fprintf(d.w, "-- go-mysql-server dump\n--\n")
+	if len(dbs) == 1 {
+		fmt.Fprintf(d.w, "-- Database: %s\n", dbs[0].Name())
+	}
+	fmt.Fprintf(d.w, "-- ------------------------------------------------------\n\n")
+
+	d.w.WriteString(`/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
+/*!40101 SET @OLD_CHARACTER_SET_RESULTS=@@CHARACTER_SET_RESULTS */;
+/*!40101 SET @OLD_COLLATION_CONNECTION=@@COLLATION_CONNECTION */;
+/*!40101 SET NAMES utf8mb4 */;
+/*!40103 SET @OLD_TIME_ZONE=@@TIME_ZONE */;
+/*!40103 SET TIME_ZONE='+00:00' */;
+/*!40014 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0 */;
+/*!40014 SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0 */;
+/*!40101 SET @OLD_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_VALUE_ON_ZERO' */;
+/*!40111 SET @OLD_SQL_NOTES=@@SQL_NOTES, SQL_NOTES=0 */;
+`)
+}
+
+func (d *dumpWriter) footer() {
+	d.w.WriteString(`/*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
+
+/*!40101 SET SQL_MODE=@OLD_SQL_MODE */;
+/*!40014 SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS */;
+/*!40014 SET UNIQUE_CHECKS=@OLD_UNIQUE_CHECKS */;
+/*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;
+/*!40101 SET CHARACTER_SET_RESULTS=@OLD_CHARACTER_SET_RESULTS */;
+/*!40101 SET COLLATION_CONNECTION=@OLD_COLLATION_CONNECTION */;
+/*!40111 SET SQL_NOTES=@OLD_SQL_NOTES */;
+
+-- Dump completed
+`)
+}
+
+func (d *dumpWriter) database(db sql.Database, names []string) error {
+	if d.create {
+		name := quoteIdentifier(db.Name())
+		fmt.Fprintf(d.w, "\n--\n-- Current Database: %s\n--\n\n", name)
+		fmt.Fprintf(
+			d.w,
+			"CREATE DATABASE IF NOT EXISTS %s /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE %s */;\n\n",
+			name,
+			defaultCollation,
+		)
+		fmt.Fprintf(d.w, "USE %s;\n", name)
+	}
+
+	tables := db.Tables()
+	if len(names) == 0 {
+		for name := range tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		table, ok := tables[name]
+		if !ok {
+			return sql.ErrTableNotFound.New(name)
+		}
+
+		if err := d.table(table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *dumpWriter) table(table sql.Table) error {
+	name := quoteIdentifier(table.Name())
+	fmt.Fprintf(d.w, "\n--\n-- Table structure for table %s\n--\n\n", name)
+	fmt.Fprintf(d.w, "DROP TABLE IF EXISTS %s;\n", name)
+	d.w.WriteString("/*!40101 SET @saved_cs_client     = @@character_set_client */;\n")
+	d.w.WriteString("/*!40101 SET character_set_client = utf8 */;\n")
+	fmt.Fprintf(d.w, "%s;\n", produceCreateStatement(table))
+	d.w.WriteString("/*!40101 SET character_set_client = @saved_cs_client */;\n")
+
+	fmt.Fprintf(d.w, "\n--\n-- Dumping data for table %s\n--\n\n", name)
+	fmt.Fprintf(d.w, "LOCK TABLES %s WRITE;\n", name)
+	fmt.Fprintf(d.w, "/*!40000 ALTER TABLE %s DISABLE KEYS */;\n", name)
+
+	if err := d.rowsOf(table); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(d.w, "/*!40000 ALTER TABLE %s ENABLE KEYS */;\n", name)
+	d.w.WriteString("UNLOCK TABLES;\n")
+	return nil
+}
+
+// rowsOf writes the rows of a table in INSERT statements with several rows
+// each.
+func (d *dumpWriter) rowsOf(table sql.Table) (err error) {
+	iter, err := NewResolvedTable(table).RowIter(d.ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := iter.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	schema := table.Schema()
+	var size int
+	for {
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		values := make([]string, len(row))
+		for i, v := range row {
+			if values[i], err = dumpValue(schema[i].Type, v); err != nil {
+				return err
+			}
+		}
+		tuple := "(" + strings.Join(values, ",") + ")"
+
+		if size == 0 {
+			n, _ := fmt.Fprintf(d.w, "INSERT INTO %s VALUES %s", quoteIdentifier(table.Name()), tuple)
+			size = n
+		} else {
+			n, _ := d.w.WriteString("," + tuple)
+			size += n
+		}
+
+		if size >= dumpInsertSize {
+			d.w.WriteString(";\n")
+			size = 0
+		}
+		d.rows++
+	}
+
+	if size > 0 {
+		d.w.WriteString(";\n")
+	}
+	return nil
+}
+
+// dumpValue returns the SQL literal of a value. Timestamps are written in
+// UTC, which is the time zone set by the dump.
+func dumpValue(typ sql.Type, v interface{}) (string, error) {
+	if t, ok := v.(time.Time); ok && typ.Type() == sqltypes.Timestamp {
+		v = t.UTC()
+	}
+
+	value, err := typ.SQL(v)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case value.IsNull():
+		return "NULL", nil
+	case value.IsIntegral() || value.IsFloat() || value.Type() == sqltypes.Bit:
+		return value.ToString(), nil
+	case value.Type() == sqltypes.Blob:
+		return "X'" + strings.ToUpper(hex.EncodeToString(value.ToBytes())) + "'", nil
+	default:
+		return quoteString(value.ToString()), nil
+	}
+}
+
+var stringEscaper = strings.NewReplacer(
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+	"'", `\'`,
+	`"`, `\"`,
+	`\`, `\\`,
+)
+
+// quoteString returns a string literal with the escape sequences of the
+// mysqldump strings.
+func quoteString(s string) string {
+	return "'" + stringEscaper.Replace(s) + "'"
+}
+
+func quoteIdentifier(s string) string {
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// Dump is a node writing a dump of databases to a file of the server. The
+// file is created in the secure_file_priv directory of the catalog, and
+// cannot exist already.
+type Dump struct {
+	Options DumpOptions
+	File    string
+	Catalog *sql.Catalog
+}
+
+// NewDump creates a Dump node.
+func NewDump(opts DumpOptions, file string) *Dump {
+	return &Dump{Options: opts, File: file}
+}
+
+// Resolved implements the Node interface.
+func (d *Dump) Resolved() bool { return true }
+
+// Children implements the Node interface.
+func (d *Dump) Children() []sql.Node { return nil }
+
+// Schema implements the Node interface.
+func (d *Dump) Schema() sql.Schema {
+	return sql.Schema{{
+		Name:     "updated",
+		Type:     sql.Int64,
+		Default:  int64(0),
+		Nullable: false,
+	}}
+}
+
+// RowIter implements the Node interface.
+func (d *Dump) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.Dump")
+	defer span.Finish()
+
+	n, err := d.Execute(ctx)
+	span.SetTag("rows", n)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.SetLastQueryInfo(sql.RowCount, n)
+	return sql.RowsToRowIter(sql.NewRow(n)), nil
+}
+
+// Execute writes the dump to the file, returning the number of rows
+// written. The file is removed if it cannot be written.
+func (d *Dump) Execute(ctx *sql.Context) (n int64, err error) {
+	path, err := secureFilePath(d.Catalog, d.File, false)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	return WriteDump(ctx, d.Catalog, f, d.Options)
+}
+
+// WithChildren implements the Node interface.
+func (d *Dump) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(d, len(children), 0)
+	}
+	return d, nil
+}
+
+func (d *Dump) String() string {
+	dbs := "*"
+	if len(d.Options.Databases) > 0 {
+		dbs = strings.Join(d.Options.Databases, ", ")
+	}
+	return fmt.Sprintf("Dump(%s INTO %s)", dbs, d.File)
+}
@@ -0,0 +1,138 @@
+package plan
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpValue(t *testing.T) {
+	testCases := []struct {
+		typ      sql.Type
+		value    interface{}
+		expected string
+	}{
+		{sql.Int32, nil, "NULL"},
+		{sql.Int64, int64(-1), "-1"},
+		{sql.Float64, 1.5, "1.5"},
+		{sql.Boolean, true, "1"},
+		{sql.Text, "it's \"a\"\n\\\x00\x1a\r", `'it\'s \"a\"\n\\\0\Z\r'`},
+		{sql.Blob, []byte{0xca, 0xfe}, "X'CAFE'"},
+		{sql.JSON, `{"a":1}`, `'{\"a\":1}'`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expected, func(t *testing.T) {
+			value, err := dumpValue(tt.typ, tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func newDumpTestCatalog(t *testing.T) *sql.Catalog {
+	a := memory.NewTable("a", sql.Schema{{Name: "id", Type: sql.Int64, Source: "a", PrimaryKey: true}})
+	b := memory.NewTable("b", sql.Schema{{Name: "name", Type: sql.Text, Source: "b", Nullable: true}})
+
+	ctx := sql.NewEmptyContext()
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(t, a.Insert(ctx, sql.NewRow(i)))
+	}
+	require.NoError(t, b.Insert(ctx, sql.NewRow(nil)))
+
+	db := memory.NewDatabase("mydb")
+	db.AddTable("a", a)
+	db.AddTable("b", b)
+	other := memory.NewDatabase("other")
+
+	catalog := sql.NewCatalog()
+	catalog.AddDatabase(db)
+	catalog.AddDatabase(other)
+	return catalog
+}
+
+func TestWriteDump(t *testing.T) {
+	require := require.New(t)
+	catalog := newDumpTestCatalog(t)
+	ctx := sql.NewEmptyContext()
+
+	var buf bytes.Buffer
+	n, err := WriteDump(ctx, catalog, &buf, DumpOptions{Databases: []string{"mydb"}, Tables: []string{"a"}})
+	require.NoError(err)
+	require.Equal(int64(3), n)
+
+	dump := buf.String()
+	require.NotContains(dump, "CREATE DATABASE")
+	require.NotContains(dump, "`b`")
+	require.Contains(dump, "DROP TABLE IF EXISTS `a`;\n")
+	require.Contains(dump, "CREATE TABLE `a` (\n  `id` bigint NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n")
+	require.Contains(dump, "LOCK TABLES `a` WRITE;\n"+
+		"/*!40000 ALTER TABLE `a` DISABLE KEYS */;\n"+
+		"INSERT INTO `a` VALUES (1),(2),(3);\n"+
+		"/*!40000 ALTER TABLE `a` ENABLE KEYS */;\n"+
+		"UNLOCK TABLES;\n")
+
+	// All the databases but the information schema are dumped.
+	buf.Reset()
+	n, err = WriteDump(ctx, catalog, &buf, DumpOptions{})
+	require.NoError(err)
+	require.Equal(int64(4), n)
+	dump = buf.String()
+	require.Contains(dump, "CREATE DATABASE IF NOT EXISTS `mydb`")
+	require.Contains(dump, "USE `other`;\n")
+	require.Contains(dump, "INSERT INTO `b` VALUES (NULL);\n")
+	require.NotContains(dump, sql.InformationSchemaDatabaseName)
+
+	_, err = WriteDump(ctx, catalog, &buf, DumpOptions{Tables: []string{"a"}})
+	require.True(ErrDumpTables.Is(err))
+
+	_, err = WriteDump(ctx, catalog, &buf, DumpOptions{Databases: []string{"mydb"}, Tables: []string{"c"}})
+	require.True(sql.ErrTableNotFound.Is(err))
+
+	_, err = WriteDump(ctx, catalog, &buf, DumpOptions{Databases: []string{"nope"}})
+	require.True(sql.ErrDatabaseNotFound.Is(err))
+}
+
+func TestDumpFile(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "dump")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	catalog := newDumpTestCatalog(t)
+	catalog.SetSecureFilePriv(dir)
+	ctx := sql.NewEmptyContext()
+	dump := func(file string, opts DumpOptions) (int64, error) {
+		d := NewDump(opts, file)
+		d.Catalog = catalog
+		return d.Execute(ctx)
+	}
+
+	n, err := dump("mydb.sql", DumpOptions{Databases: []string{"mydb"}})
+	require.NoError(err)
+	require.Equal(int64(4), n)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "mydb.sql"))
+	require.NoError(err)
+	require.Contains(string(data), "INSERT INTO `a` VALUES (1),(2),(3);\n")
+
+	// Files are never overwritten.
+	_, err = dump("mydb.sql", DumpOptions{Databases: []string{"mydb"}})
+	require.True(os.IsExist(err))
+
+	// The file is not left behind if the dump fails.
+	_, err = dump("nope.sql", DumpOptions{Databases: []string{"mydb"}, Tables: []string{"nope"}})
+	require.True(sql.ErrTableNotFound.Is(err))
+	_, err = os.Stat(filepath.Join(dir, "nope.sql"))
+	require.True(os.IsNotExist(err))
+
+	_, err = dump("../mydb.sql", DumpOptions{})
+	require.True(ErrSecureFilePriv.Is(err))
+}
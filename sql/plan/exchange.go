@@ -6,6 +6,7 @@ import (
 	"io"
 	"sync"
 
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/src-d/go-mysql-server/sql"
 	errors "gopkg.in/src-d/go-errors.v1"
 )
@@ -46,12 +47,15 @@ func (e *Exchange) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 		return nil, ErrNoPartitionable.New()
 	}
 
+	span, ctx := ctx.Span("plan.Exchange", opentracing.Tag{Key: "parallelism", Value: e.Parallelism})
+
 	partitions, err := t.Partitions(ctx)
 	if err != nil {
+		span.Finish()
 		return nil, err
 	}
 
-	return newExchangeRowIter(ctx, e.Parallelism, partitions, e.Child), nil
+	return sql.NewSpanIter(span, newExchangeRowIter(ctx, e.Parallelism, partitions, e.Child)), nil
 }
 
 func (e *Exchange) String() string {
@@ -308,7 +312,15 @@ func (exchangePartition) Children() []sql.Node { return nil }
 func (exchangePartition) Resolved() bool { return true }
 
 func (p *exchangePartition) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	return p.table.PartitionRows(ctx, p.Partition)
+	span, ctx := ctx.Span("plan.ExchangePartition", opentracing.Tag{Key: "partition", Value: string(p.Key())})
+
+	iter, err := p.table.PartitionRows(ctx, p.Partition)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, iter), nil
 }
 
 func (p *exchangePartition) Schema() sql.Schema {
@@ -72,6 +72,7 @@ type FilterIter struct {
 	cond      sql.Expression
 	childIter sql.RowIter
 	ctx       *sql.Context
+	batch     sql.RowBatchIter
 }
 
 // NewFilterIter creates a new FilterIter.
@@ -80,12 +81,16 @@ func NewFilterIter(
 	cond sql.Expression,
 	child sql.RowIter,
 ) *FilterIter {
-	return &FilterIter{cond, child, ctx}
+	return &FilterIter{cond: cond, childIter: child, ctx: ctx}
 }
 
 // Next implements the RowIter interface.
 func (i *FilterIter) Next() (sql.Row, error) {
 	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
 		row, err := i.childIter.Next()
 		if err != nil {
 			return nil, err
@@ -102,6 +107,41 @@ func (i *FilterIter) Next() (sql.Row, error) {
 	}
 }
 
+// NextBatch implements the RowBatchIter interface.
+func (i *FilterIter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.childIter)
+	}
+
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return 0, err
+		}
+
+		n, err := i.batch.NextBatch(rows)
+		if err != nil {
+			return 0, err
+		}
+
+		var matched int
+		for _, row := range rows[:n] {
+			ok, err := sql.EvaluateCondition(i.ctx, i.cond, row)
+			if err != nil {
+				return 0, err
+			}
+
+			if ok {
+				rows[matched] = row
+				matched++
+			}
+		}
+
+		if matched > 0 {
+			return matched, nil
+		}
+	}
+}
+
 // Close implements the RowIter interface.
 func (i *FilterIter) Close() error {
 	return i.childIter.Close()
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"io"
 	"testing"
 
 	"github.com/src-d/go-mysql-server/memory"
@@ -86,3 +87,53 @@ func TestFilter(t *testing.T) {
 	require.Equal(int32(3333), row[2])
 	require.Equal(int64(4444), row[3])
 }
+
+func TestFilterBatches(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	child := memory.NewPartitionedTable("test", sql.Schema{
+		{Name: "col1", Type: sql.Int64, Nullable: true},
+	}, 2)
+
+	for i := int64(0); i < 10; i++ {
+		require.NoError(child.Insert(ctx, sql.NewRow(i)))
+	}
+
+	f := NewProject(
+		[]sql.Expression{expression.NewGetField(0, sql.Int64, "col1", true)},
+		NewFilter(
+			expression.NewGreaterThan(
+				expression.NewGetField(0, sql.Int64, "col1", true),
+				expression.NewLiteral(int64(5), sql.Int64),
+			),
+			NewResolvedTable(child),
+		),
+	)
+
+	iter, err := f.RowIter(ctx)
+	require.NoError(err)
+
+	batches, ok := iter.(sql.RowBatchIter)
+	require.True(ok)
+
+	var rows []sql.Row
+	batch := make([]sql.Row, 3)
+	for {
+		n, err := batches.NextBatch(batch)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		require.True(n > 0 && n <= len(batch))
+		rows = append(rows, batch[:n]...)
+	}
+	require.NoError(iter.Close())
+
+	require.ElementsMatch([]sql.Row{
+		sql.NewRow(int64(6)),
+		sql.NewRow(int64(7)),
+		sql.NewRow(int64(8)),
+		sql.NewRow(int64(9)),
+	}, rows)
+}
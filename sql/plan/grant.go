@@ -0,0 +1,221 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrPrivilegesNotEnabled is returned when privileges are granted, revoked
+// or shown in a catalog without a privilege store.
+var ErrPrivilegesNotEnabled = errors.NewKind("privileges are not enabled")
+
+// Grant grants privileges to some users.
+type Grant struct {
+	Privileges      sql.PrivilegeType
+	Level           sql.PrivilegeLevel
+	Users           []string
+	Catalog         *sql.Catalog
+	CurrentDatabase string
+}
+
+var _ sql.Node = (*Grant)(nil)
+
+// NewGrant creates a new Grant node. An empty database in the level means
+// the current database.
+func NewGrant(privileges sql.PrivilegeType, level sql.PrivilegeLevel, users ...string) *Grant {
+	return &Grant{Privileges: privileges, Level: level, Users: users}
+}
+
+// Resolved implements the sql.Node interface.
+func (*Grant) Resolved() bool { return true }
+
+// Children implements the sql.Node interface.
+func (*Grant) Children() []sql.Node { return nil }
+
+// Schema implements the sql.Node interface.
+func (*Grant) Schema() sql.Schema { return nil }
+
+// RowIter implements the sql.Node interface.
+func (g *Grant) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	store, err := privilegeStore(g.Catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	level := PrivilegeLevel(g.Level, g.CurrentDatabase)
+	for _, u := range g.Users {
+		if err := store.Grant(u, level, g.Privileges); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.RowsToRowIter(), nil
+}
+
+// WithChildren implements the sql.Node interface.
+func (g *Grant) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(g, len(children), 0)
+	}
+
+	return g, nil
+}
+
+func (g *Grant) String() string {
+	return fmt.Sprintf(
+		"Grant(%s ON %s TO %s)",
+		g.Privileges,
+		PrivilegeLevel(g.Level, g.CurrentDatabase),
+		strings.Join(g.Users, ", "),
+	)
+}
+
+// Revoke revokes privileges from some users.
+type Revoke struct {
+	Privileges      sql.PrivilegeType
+	Level           sql.PrivilegeLevel
+	Users           []string
+	Catalog         *sql.Catalog
+	CurrentDatabase string
+}
+
+var _ sql.Node = (*Revoke)(nil)
+
+// NewRevoke creates a new Revoke node. An empty database in the level means
+// the current database.
+func NewRevoke(privileges sql.PrivilegeType, level sql.PrivilegeLevel, users ...string) *Revoke {
+	return &Revoke{Privileges: privileges, Level: level, Users: users}
+}
+
+// Resolved implements the sql.Node interface.
+func (*Revoke) Resolved() bool { return true }
+
+// Children implements the sql.Node interface.
+func (*Revoke) Children() []sql.Node { return nil }
+
+// Schema implements the sql.Node interface.
+func (*Revoke) Schema() sql.Schema { return nil }
+
+// RowIter implements the sql.Node interface.
+func (r *Revoke) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	store, err := privilegeStore(r.Catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	level := PrivilegeLevel(r.Level, r.CurrentDatabase)
+	for _, u := range r.Users {
+		if err := store.Revoke(u, level, r.Privileges); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.RowsToRowIter(), nil
+}
+
+// WithChildren implements the sql.Node interface.
+func (r *Revoke) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(r, len(children), 0)
+	}
+
+	return r, nil
+}
+
+func (r *Revoke) String() string {
+	return fmt.Sprintf(
+		"Revoke(%s ON %s FROM %s)",
+		r.Privileges,
+		PrivilegeLevel(r.Level, r.CurrentDatabase),
+		strings.Join(r.Users, ", "),
+	)
+}
+
+// ShowGrants shows the privileges granted to a user.
+type ShowGrants struct {
+	// User whose grants are shown. If empty, the current user.
+	User    string
+	Catalog *sql.Catalog
+}
+
+var _ sql.Node = (*ShowGrants)(nil)
+
+// NewShowGrants creates a new ShowGrants node.
+func NewShowGrants(user string) *ShowGrants {
+	return &ShowGrants{User: user}
+}
+
+// Resolved implements the sql.Node interface.
+func (*ShowGrants) Resolved() bool { return true }
+
+// Children implements the sql.Node interface.
+func (*ShowGrants) Children() []sql.Node { return nil }
+
+// Schema implements the sql.Node interface.
+func (s *ShowGrants) Schema() sql.Schema {
+	return sql.Schema{{Name: "Grants", Type: sql.Text}}
+}
+
+// RowIter implements the sql.Node interface.
+func (s *ShowGrants) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	store, err := privilegeStore(s.Catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	user := s.User
+	if user == "" {
+		user = ctx.Client().User
+	}
+
+	grants, err := store.Grants(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grants) == 0 {
+		grants = []sql.Grant{{User: user, Level: sql.GlobalPrivilegeLevel}}
+	}
+
+	var rows []sql.Row
+	for _, g := range grants {
+		rows = append(rows, sql.NewRow(g.String()))
+	}
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// WithChildren implements the sql.Node interface.
+func (s *ShowGrants) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(s, len(children), 0)
+	}
+
+	return s, nil
+}
+
+func (s *ShowGrants) String() string {
+	if s.User == "" {
+		return "ShowGrants"
+	}
+	return fmt.Sprintf("ShowGrants(%s)", s.User)
+}
+
+// PrivilegeLevel returns the given level with the current database in
+// place of an empty one.
+func PrivilegeLevel(level sql.PrivilegeLevel, currentDatabase string) sql.PrivilegeLevel {
+	if level.Database == "" {
+		level.Database = currentDatabase
+	}
+	return level
+}
+
+func privilegeStore(c *sql.Catalog) (sql.PrivilegeStore, error) {
+	if c == nil || c.Privileges() == nil {
+		return nil, ErrPrivilegesNotEnabled.New()
+	}
+	return c.Privileges(), nil
+}
@@ -0,0 +1,110 @@
+package plan
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantAndRevoke(t *testing.T) {
+	require := require.New(t)
+
+	store := sql.NewMemoryPrivilegeStore()
+	catalog := sql.NewCatalog()
+	catalog.SetPrivileges(store)
+	ctx := sql.NewEmptyContext()
+
+	grant := NewGrant(sql.SelectPrivilege|sql.InsertPrivilege, sql.PrivilegeLevel{Table: "*"}, "a", "b")
+	grant.Catalog = catalog
+	grant.CurrentDatabase = "mydb"
+
+	iter, err := grant.RowIter(ctx)
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	revoke := NewRevoke(sql.InsertPrivilege, sql.PrivilegeLevel{Database: "mydb", Table: "*"}, "b")
+	revoke.Catalog = catalog
+
+	iter, err = revoke.RowIter(ctx)
+	require.NoError(err)
+	_, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+
+	level := sql.PrivilegeLevel{Database: "mydb", Table: "*"}
+	grants, err := store.Grants("a")
+	require.NoError(err)
+	require.Equal([]sql.Grant{{
+		User:       "a",
+		Level:      level,
+		Privileges: sql.SelectPrivilege | sql.InsertPrivilege,
+	}}, grants)
+
+	grants, err = store.Grants("b")
+	require.NoError(err)
+	require.Equal([]sql.Grant{{
+		User:       "b",
+		Level:      level,
+		Privileges: sql.SelectPrivilege,
+	}}, grants)
+}
+
+func TestShowGrants(t *testing.T) {
+	require := require.New(t)
+
+	store := sql.NewMemoryPrivilegeStore()
+	require.NoError(store.Grant("a", sql.GlobalPrivilegeLevel, sql.SelectPrivilege))
+	require.NoError(store.Grant("a", sql.PrivilegeLevel{Database: "mydb", Table: "t"}, sql.AllPrivileges))
+
+	catalog := sql.NewCatalog()
+	catalog.SetPrivileges(store)
+
+	session := sql.NewSession("", "", "a", 1)
+	ctx := sql.NewContext(context.Background(), sql.WithSession(session))
+
+	testCases := []struct {
+		user     string
+		expected []sql.Row
+	}{
+		{
+			"",
+			[]sql.Row{
+				{"GRANT SELECT ON *.* TO `a`"},
+				{"GRANT ALL PRIVILEGES ON `mydb`.`t` TO `a`"},
+			},
+		},
+		{
+			"b",
+			[]sql.Row{{"GRANT USAGE ON *.* TO `b`"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		n := NewShowGrants(tt.user)
+		n.Catalog = catalog
+
+		iter, err := n.RowIter(ctx)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		require.Equal(tt.expected, rows)
+	}
+}
+
+func TestPrivilegesNotEnabled(t *testing.T) {
+	require := require.New(t)
+
+	catalog := sql.NewCatalog()
+	nodes := []sql.Node{
+		&Grant{Privileges: sql.SelectPrivilege, Users: []string{"a"}, Catalog: catalog},
+		&Revoke{Privileges: sql.SelectPrivilege, Users: []string{"a"}, Catalog: catalog},
+		&ShowGrants{Catalog: catalog},
+	}
+
+	for _, n := range nodes {
+		_, err := n.RowIter(sql.NewEmptyContext())
+		require.True(ErrPrivilegesNotEnabled.Is(err))
+	}
+}
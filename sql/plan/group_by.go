@@ -20,6 +20,11 @@ type GroupBy struct {
 	UnaryNode
 	Aggregate []sql.Expression
 	Grouping  []sql.Expression
+	// GroupingSets are the indexes of the grouping expressions of each of
+	// the grouping sets the rows are grouped by, as with GROUPING SETS,
+	// ROLLUP or CUBE, or nil if they're only grouped by all of them. The
+	// grouping expressions not in a set are NULL in its groups.
+	GroupingSets [][]int
 }
 
 // NewGroupBy creates a new GroupBy node.
@@ -35,6 +40,14 @@ func NewGroupBy(
 	}
 }
 
+// WithGroupingSets returns a copy of the node grouped by the given grouping
+// sets.
+func (p *GroupBy) WithGroupingSets(sets [][]int) *GroupBy {
+	np := *p
+	np.GroupingSets = sets
+	return &np
+}
+
 // Resolved implements the Resolvable interface.
 func (p *GroupBy) Resolved() bool {
 	return p.UnaryNode.Child.Resolved() &&
@@ -61,7 +74,7 @@ func (p *GroupBy) Schema() sql.Schema {
 		s[i] = &sql.Column{
 			Name:     name,
 			Type:     e.Type(),
-			Nullable: e.IsNullable(),
+			Nullable: e.IsNullable() || p.GroupingSets != nil && !isAggregation(e),
 			Source:   table,
 		}
 	}
@@ -69,13 +82,37 @@ func (p *GroupBy) Schema() sql.Schema {
 	return s
 }
 
-// RowIter implements the Node interface.
+// RowIter implements the Node interface. If the rows are read from the
+// partitions of a table in parallel, the rows of every partition are
+// aggregated separately, and the partial aggregations of each group are
+// merged afterwards.
 func (p *GroupBy) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	span, ctx := ctx.Span("plan.GroupBy", opentracing.Tags{
 		"groupings":  len(p.Grouping),
 		"aggregates": len(p.Aggregate),
 	})
 
+	if p.GroupingSets != nil {
+		return p.groupingSetsIter(ctx, span)
+	}
+
+	if exchange, ok := p.Child.(*Exchange); ok {
+		partial := &partialGroupBy{
+			UnaryNode: UnaryNode{Child: exchange.Child},
+			aggregate: p.Aggregate,
+			grouping:  p.Grouping,
+		}
+
+		i, err := NewExchange(exchange.Parallelism, partial).RowIter(ctx)
+		if err != nil {
+			span.Finish()
+			return nil, err
+		}
+
+		iter := newGroupByMergeIter(ctx, p.Aggregate, len(p.Grouping) > 0, i)
+		return sql.NewSpanIter(span, iter), nil
+	}
+
 	i, err := p.Child.RowIter(ctx)
 	if err != nil {
 		span.Finish()
@@ -98,7 +135,7 @@ func (p *GroupBy) WithChildren(children ...sql.Node) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
 	}
 
-	return NewGroupBy(p.Aggregate, p.Grouping, children[0]), nil
+	return NewGroupBy(p.Aggregate, p.Grouping, children[0]).WithGroupingSets(p.GroupingSets), nil
 }
 
 // WithExpressions implements the Node interface.
@@ -119,7 +156,7 @@ func (p *GroupBy) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
 		grouping[i] = exprs[i+offset]
 	}
 
-	return NewGroupBy(agg, grouping, p.Child), nil
+	return NewGroupBy(agg, grouping, p.Child).WithGroupingSets(p.GroupingSets), nil
 }
 
 func (p *GroupBy) String() string {
@@ -136,11 +173,24 @@ func (p *GroupBy) String() string {
 		grouping[i] = g.String()
 	}
 
-	_ = pr.WriteChildren(
+	children := []string{
 		fmt.Sprintf("Aggregate(%s)", strings.Join(aggregate, ", ")),
 		fmt.Sprintf("Grouping(%s)", strings.Join(grouping, ", ")),
-		p.Child.String(),
-	)
+	}
+
+	if p.GroupingSets != nil {
+		var sets = make([]string, len(p.GroupingSets))
+		for i, set := range p.GroupingSets {
+			exprs := make([]string, len(set))
+			for j, idx := range set {
+				exprs[j] = grouping[idx]
+			}
+			sets[i] = "(" + strings.Join(exprs, ", ") + ")"
+		}
+		children = append(children, fmt.Sprintf("GroupingSets(%s)", strings.Join(sets, ", ")))
+	}
+
+	_ = pr.WriteChildren(append(children, p.Child.String())...)
 	return pr.String()
 }
 
@@ -158,6 +208,9 @@ type groupByIter struct {
 	ctx       *sql.Context
 	buf       []sql.Row
 	done      bool
+	// partial makes the iterator return the buffers of the aggregation
+	// instead of its result.
+	partial bool
 }
 
 func newGroupByIter(ctx *sql.Context, aggregate []sql.Expression, child sql.RowIter) *groupByIter {
@@ -181,6 +234,10 @@ func (i *groupByIter) Next() (sql.Row, error) {
 	}
 
 	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
 		row, err := i.child.Next()
 		if err != nil {
 			if err == io.EOF {
@@ -194,6 +251,9 @@ func (i *groupByIter) Next() (sql.Row, error) {
 		}
 	}
 
+	if i.partial {
+		return sql.NewRow(uint64(0), i.buf), nil
+	}
 	return evalBuffers(i.ctx, i.buf, i.aggregate)
 }
 
@@ -202,6 +262,28 @@ func (i *groupByIter) Close() error {
 	return i.child.Close()
 }
 
+const (
+	tmpTableSizeKey        = "TMP_TABLE_SIZE"
+	tmpTableSizeSessionVar = "tmp_table_size"
+)
+
+// tmpTableSize is the estimated number of bytes of groups a GroupBy node
+// keeps in memory. Once it's exceeded, the rows of the new groups are
+// spilled to disk and aggregated afterwards. If it's zero, rows are only
+// spilled when there is no memory available.
+var tmpTableSize = bufferSizeByEnv(tmpTableSizeKey)
+
+const (
+	// groupByPartitions is the number of files the rows are spilled to, by
+	// the hash of their grouping key, so the groups of every file can be
+	// aggregated in memory separately.
+	groupByPartitions = 16
+	// maxGroupBySpillDepth is the maximum number of times the rows of a
+	// group can be spilled. Every level uses different bits of the key to
+	// choose the partition.
+	maxGroupBySpillDepth = 4
+)
+
 type groupByGroupingIter struct {
 	aggregate   []sql.Expression
 	grouping    []sql.Expression
@@ -211,6 +293,27 @@ type groupByGroupingIter struct {
 	child       sql.RowIter
 	ctx         *sql.Context
 	dispose     sql.DisposeFunc
+	memory      *sql.MemoryAccount
+
+	// sets are the grouping sets the rows are grouped by, if any, and
+	// keySets the index of the set of each group in memory.
+	sets    []groupingSet
+	keySets []int
+
+	// bufferSize is the estimated number of bytes of groups that can be
+	// kept in memory, and size the one of the groups in memory.
+	bufferSize uint64
+	size       uint64
+
+	// depth is the number of times the rows aggregated by the iterator
+	// have been spilled.
+	depth      int
+	partitions []*spillFile
+	spilled    *groupByGroupingIter
+
+	// partial makes the iterator return the key and the buffers of every
+	// group instead of the result of its aggregation.
+	partial bool
 }
 
 func newGroupByGroupingIter(
@@ -223,6 +326,7 @@ func newGroupByGroupingIter(
 		grouping:  grouping,
 		child:     child,
 		ctx:       ctx,
+		memory:    ctx.QueryMemory().NewAccount(),
 	}
 }
 
@@ -235,19 +339,81 @@ func (i *groupByGroupingIter) Next() (sql.Row, error) {
 	}
 
 	if i.pos >= len(i.keys) {
-		return nil, io.EOF
+		return i.nextSpilled()
 	}
 
-	buffers, err := i.aggregation.Get(i.keys[i.pos])
+	key := i.keys[i.pos]
+	buffers, err := i.aggregation.Get(key)
 	if err != nil {
 		return nil, err
 	}
+
+	aggregate := i.aggregate
+	if i.sets != nil {
+		aggregate = i.sets[i.keySets[i.pos]].aggregate
+	}
 	i.pos++
-	return evalBuffers(i.ctx, buffers.([]sql.Row), i.aggregate)
+
+	if i.partial {
+		return sql.NewRow(key, buffers), nil
+	}
+	return evalBuffers(i.ctx, buffers.([]sql.Row), aggregate)
+}
+
+// nextSpilled returns the next group of the rows spilled to disk, which are
+// aggregated one partition at a time once the groups in memory have been
+// returned.
+func (i *groupByGroupingIter) nextSpilled() (sql.Row, error) {
+	for {
+		if i.spilled == nil {
+			if len(i.partitions) == 0 {
+				return nil, io.EOF
+			}
+
+			// The groups in memory are no longer needed.
+			if i.dispose != nil {
+				i.dispose()
+				i.dispose = nil
+			}
+			i.memory.ReleaseAll()
+
+			rows, err := i.partitions[0].iter()
+			if err != nil {
+				return nil, err
+			}
+
+			i.spilled = newGroupByGroupingIter(i.ctx, i.aggregate, i.grouping, rows)
+			i.spilled.sets = i.sets
+			i.spilled.depth = i.depth + 1
+			i.spilled.partial = i.partial
+		}
+
+		row, err := i.spilled.Next()
+		if err != io.EOF {
+			return row, err
+		}
+
+		if err := i.spilled.Close(); err != nil {
+			return nil, err
+		}
+		i.spilled = nil
+
+		p := i.partitions[0]
+		i.partitions = i.partitions[1:]
+		if err := p.remove(); err != nil {
+			return nil, err
+		}
+	}
 }
 
 func (i *groupByGroupingIter) compute() error {
+	i.bufferSize = bufferSizeFor(i.ctx, tmpTableSizeSessionVar, tmpTableSize)
+
 	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return err
+		}
+
 		row, err := i.child.Next()
 		if err != nil {
 			if err == io.EOF {
@@ -256,41 +422,146 @@ func (i *groupByGroupingIter) compute() error {
 			return err
 		}
 
-		key, err := groupingKey(i.ctx, i.grouping, row)
+		switch {
+		case i.sets == nil:
+			err = i.add(0, row)
+		case i.depth > 0:
+			// The rows spilled end with the index of the grouping set
+			// they're aggregated in.
+			err = i.add(int(row[len(row)-1].(int64)), row)
+		default:
+			// Rows are aggregated in every grouping set in a single pass.
+			for set := range i.sets {
+				if err = i.add(set, row); err != nil {
+					break
+				}
+			}
+		}
+
 		if err != nil {
 			return err
 		}
+	}
 
-		if _, err := i.aggregation.Get(key); err != nil {
-			var buf = make([]sql.Row, len(i.aggregate))
-			for j, a := range i.aggregate {
-				buf[j] = fillBuffer(a)
-			}
+	return nil
+}
+
+// add aggregates the row in its group of the given grouping set, which is
+// always 0 if there are no grouping sets. The row is spilled to disk if its
+// group is not in memory and there is no room for it.
+func (i *groupByGroupingIter) add(set int, row sql.Row) error {
+	grouping, aggregate := i.grouping, i.aggregate
+	if i.sets != nil {
+		grouping, aggregate = i.sets[set].grouping, i.sets[set].aggregate
+	}
+
+	key, err := groupingKey(i.ctx, grouping, row)
+	if err != nil {
+		return err
+	}
+
+	if i.sets != nil {
+		key = groupingSetKey(key, set)
+	}
+
+	b, err := i.aggregation.Get(key)
+	if err != nil {
+		if i.partitions != nil || (i.bufferSize > 0 && i.size >= i.bufferSize && i.canSpill()) {
+			return i.spill(key, set, row)
+		}
 
-			if err := i.aggregation.Put(key, buf); err != nil {
+		rowSize := estimateRowSize(row)
+		if err := i.memory.Reserve(rowSize); err != nil {
+			if !sql.IsMemoryExceeded(err) || !i.canSpill() {
 				return err
 			}
 
-			i.keys = append(i.keys, key)
+			return i.spill(key, set, row)
 		}
 
-		b, err := i.aggregation.Get(key)
-		if err != nil {
-			return err
+		var buf = make([]sql.Row, len(aggregate))
+		for j, a := range aggregate {
+			buf[j] = fillBuffer(a)
 		}
 
-		err = updateBuffers(i.ctx, b.([]sql.Row), i.aggregate, row)
-		if err != nil {
-			return err
+		if err := i.aggregation.Put(key, buf); err != nil {
+			i.memory.Release(rowSize)
+			if !sql.ErrNoMemoryAvailable.Is(err) || !i.canSpill() {
+				return err
+			}
+
+			return i.spill(key, set, row)
 		}
+
+		i.keys = append(i.keys, key)
+		i.keySets = append(i.keySets, set)
+		i.size += rowSize
+		b = buf
 	}
 
-	return nil
+	return updateBuffers(i.ctx, b.([]sql.Row), aggregate, row)
+}
+
+// canSpill reports whether the rows can be spilled to disk. Rows spilled
+// too many times are kept in memory, as they belong to too few groups to
+// be split any further.
+func (i *groupByGroupingIter) canSpill() bool {
+	return i.depth < maxGroupBySpillDepth
+}
+
+// spill writes the row of a group that is not in memory to the partition
+// of its key. Rows of the groups in memory keep being aggregated in memory.
+// With grouping sets, the index of the set of the group is added to the
+// end of the row, as it's only aggregated in that set once it's read.
+func (i *groupByGroupingIter) spill(key uint64, set int, row sql.Row) error {
+	if i.partitions == nil {
+		i.partitions = make([]*spillFile, groupByPartitions)
+		for j := range i.partitions {
+			f, err := newSpillFile("group-by")
+			if err != nil {
+				return err
+			}
+			i.partitions[j] = f
+		}
+	}
+
+	if i.sets != nil && i.depth == 0 {
+		row = append(row[:len(row):len(row)], int64(set))
+	}
+
+	p := (key >> uint(8*i.depth)) % groupByPartitions
+	return i.partitions[p].write(row)
 }
 
 func (i *groupByGroupingIter) Close() error {
+	var err error
+	if i.spilled != nil {
+		err = i.spilled.Close()
+		i.spilled = nil
+	}
+
+	for _, p := range i.partitions {
+		if p == nil {
+			continue
+		}
+
+		if rerr := p.remove(); err == nil {
+			err = rerr
+		}
+	}
+	i.partitions = nil
+
+	if i.dispose != nil {
+		i.dispose()
+		i.dispose = nil
+	}
 	i.aggregation = nil
-	return i.child.Close()
+	i.memory.ReleaseAll()
+
+	if cerr := i.child.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 var table = crc64.MakeTable(crc64.ISO)
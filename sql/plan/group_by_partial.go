@@ -0,0 +1,201 @@
+package plan
+
+import (
+	"io"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// partialGroupBy aggregates the rows of a partition of the table read by a
+// GroupBy in parallel. Its rows have the key of a group and its buffers,
+// which are merged with the ones of the rest of partitions.
+type partialGroupBy struct {
+	UnaryNode
+	aggregate []sql.Expression
+	grouping  []sql.Expression
+}
+
+// Schema implements the Node interface.
+func (p *partialGroupBy) Schema() sql.Schema { return nil }
+
+// RowIter implements the Node interface.
+func (p *partialGroupBy) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	i, err := p.Child.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.grouping) == 0 {
+		iter := newGroupByIter(ctx, p.aggregate, i)
+		iter.partial = true
+		return iter, nil
+	}
+
+	iter := newGroupByGroupingIter(ctx, p.aggregate, p.grouping, i)
+	iter.partial = true
+	return iter, nil
+}
+
+// WithChildren implements the Node interface.
+func (p *partialGroupBy) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
+	}
+
+	return &partialGroupBy{
+		UnaryNode: UnaryNode{Child: children[0]},
+		aggregate: p.aggregate,
+		grouping:  p.grouping,
+	}, nil
+}
+
+func (p *partialGroupBy) String() string {
+	var aggregate = make([]string, len(p.aggregate))
+	for i, agg := range p.aggregate {
+		aggregate[i] = agg.String()
+	}
+
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("PartialGroupBy(%s)", strings.Join(aggregate, ", "))
+	_ = pr.WriteChildren(p.Child.String())
+	return pr.String()
+}
+
+// groupByMergeIter merges the buffers of the groups aggregated separately
+// by partialGroupBy nodes. All the groups are kept in memory, as their
+// buffers cannot be spilled.
+type groupByMergeIter struct {
+	aggregate []sql.Expression
+	// grouped is whether the rows are grouped, so no group is returned
+	// when there are no rows.
+	grouped bool
+	child   sql.RowIter
+	ctx     *sql.Context
+	memory  *sql.MemoryAccount
+
+	groups map[uint64][]sql.Row
+	keys   []uint64
+	pos    int
+}
+
+func newGroupByMergeIter(
+	ctx *sql.Context,
+	aggregate []sql.Expression,
+	grouped bool,
+	child sql.RowIter,
+) *groupByMergeIter {
+	return &groupByMergeIter{
+		aggregate: aggregate,
+		grouped:   grouped,
+		child:     child,
+		ctx:       ctx,
+		memory:    ctx.QueryMemory().NewAccount(),
+	}
+}
+
+func (i *groupByMergeIter) Next() (sql.Row, error) {
+	if i.groups == nil {
+		i.groups = make(map[uint64][]sql.Row)
+		if err := i.merge(); err != nil {
+			return nil, err
+		}
+
+		if len(i.keys) == 0 && !i.grouped {
+			var buf = make([]sql.Row, len(i.aggregate))
+			for j, a := range i.aggregate {
+				buf[j] = fillBuffer(a)
+			}
+			i.groups[0] = buf
+			i.keys = append(i.keys, 0)
+		}
+	}
+
+	if i.pos >= len(i.keys) {
+		return nil, io.EOF
+	}
+
+	buffers := i.groups[i.keys[i.pos]]
+	i.pos++
+	return evalBuffers(i.ctx, buffers, i.aggregate)
+}
+
+func (i *groupByMergeIter) merge() error {
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return err
+		}
+
+		row, err := i.child.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		key := row[0].(uint64)
+		partial := row[1].([]sql.Row)
+
+		buffers, ok := i.groups[key]
+		if !ok {
+			var size uint64
+			for _, b := range partial {
+				size += estimateRowSize(b)
+			}
+			if err := i.memory.Reserve(size); err != nil {
+				return err
+			}
+
+			i.groups[key] = partial
+			i.keys = append(i.keys, key)
+			continue
+		}
+
+		if err := mergeBuffers(i.ctx, buffers, partial, i.aggregate); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *groupByMergeIter) Close() error {
+	i.groups = nil
+	i.memory.ReleaseAll()
+	return i.child.Close()
+}
+
+func mergeBuffers(
+	ctx *sql.Context,
+	buffers, partial []sql.Row,
+	aggregate []sql.Expression,
+) error {
+	for i, a := range aggregate {
+		if err := mergeBuffer(ctx, buffers, partial, i, a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mergeBuffer(
+	ctx *sql.Context,
+	buffers, partial []sql.Row,
+	idx int,
+	expr sql.Expression,
+) error {
+	switch n := expr.(type) {
+	case sql.Aggregation:
+		return n.Merge(ctx, buffers[idx], partial[idx])
+	case *expression.Alias:
+		return mergeBuffer(ctx, buffers, partial, idx, n.Child)
+	default:
+		// Any value of the group is valid for the expressions that are not
+		// aggregations.
+		if len(buffers[idx]) == 0 {
+			buffers[idx] = partial[idx]
+		}
+		return nil
+	}
+}
@@ -1,6 +1,9 @@
 package plan
 
 import (
+	"io"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/src-d/go-mysql-server/memory"
@@ -222,3 +225,121 @@ func benchmarkTable(t testing.TB) sql.Table {
 
 	return table
 }
+
+func TestGroupBySpill(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "group-by-spill")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	defer func(dir string) { spillDir = dir }(spillDir)
+	spillDir = dir
+
+	child := memory.NewPartitionedTable("test", sql.Schema{
+		{Name: "col1", Type: sql.Int64},
+		{Name: "col2", Type: sql.Int64},
+	}, 2)
+
+	for i := 0; i < 300; i++ {
+		require.NoError(child.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i%50), int64(i))))
+	}
+
+	p := NewGroupBy(
+		[]sql.Expression{
+			expression.NewGetField(0, sql.Int64, "col1", false),
+			aggregation.NewCount(expression.NewGetField(1, sql.Int64, "col2", false)),
+			aggregation.NewSum(expression.NewGetField(1, sql.Int64, "col2", false)),
+		},
+		[]sql.Expression{
+			expression.NewGetField(0, sql.Int64, "col1", false),
+		},
+		NewResolvedTable(child),
+	)
+
+	expected, err := sql.NodeToRows(sql.NewEmptyContext(), p)
+	require.NoError(err)
+	require.Len(expected, 50)
+
+	ctx := sql.NewEmptyContext()
+	ctx.Set(tmpTableSizeSessionVar, sql.Int64, int64(100))
+
+	iter, err := p.RowIter(ctx)
+	require.NoError(err)
+
+	var rows []sql.Row
+	for i := 0; i < len(expected); i++ {
+		row, err := iter.Next()
+		require.NoError(err)
+		rows = append(rows, row)
+
+		if i == 0 {
+			files, err := ioutil.ReadDir(dir)
+			require.NoError(err)
+			require.NotEmpty(files)
+		}
+	}
+
+	_, err = iter.Next()
+	require.Equal(io.EOF, err)
+	require.NoError(iter.Close())
+	require.ElementsMatch(expected, rows)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.Len(files, 0)
+}
+
+func TestGroupByParallel(t *testing.T) {
+	child := memory.NewPartitionedTable("test", sql.Schema{
+		{Name: "col1", Type: sql.Int64},
+		{Name: "col2", Type: sql.Int64},
+	}, 4)
+
+	for i := 0; i < 300; i++ {
+		require.NoError(t, child.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i%7), int64(i))))
+	}
+
+	col1 := expression.NewGetField(0, sql.Int64, "col1", false)
+	col2 := expression.NewGetField(1, sql.Int64, "col2", false)
+	aggregate := []sql.Expression{
+		expression.NewAlias(col1, "c"),
+		aggregation.NewCount(col2),
+		aggregation.NewCountDistinct(col1),
+		aggregation.NewSum(col2),
+		aggregation.NewAvg(col2),
+		aggregation.NewMax(col2),
+		aggregation.NewMin(col2),
+	}
+
+	testCases := []struct {
+		name     string
+		grouping []sql.Expression
+		table    sql.Table
+	}{
+		{"grouping", []sql.Expression{col1}, child},
+		{"no grouping", nil, child},
+		{"empty grouping", []sql.Expression{col1}, memory.NewPartitionedTable("empty", child.Schema(), 4)},
+		{"empty no grouping", nil, memory.NewPartitionedTable("empty", child.Schema(), 4)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := sql.NewEmptyContext()
+
+			// Any value of the column is valid without grouping.
+			aggregate := aggregate
+			if len(tt.grouping) == 0 {
+				aggregate = aggregate[1:]
+			}
+
+			expected, err := sql.NodeToRows(ctx, NewGroupBy(aggregate, tt.grouping, NewResolvedTable(tt.table)))
+			require.NoError(err)
+
+			rows, err := sql.NodeToRows(ctx, NewGroupBy(aggregate, tt.grouping, NewExchange(2, NewResolvedTable(tt.table))))
+			require.NoError(err)
+			require.ElementsMatch(expected, rows)
+		})
+	}
+}
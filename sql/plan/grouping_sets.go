@@ -0,0 +1,129 @@
+package plan
+
+import (
+	"encoding/binary"
+	"hash/crc64"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function/aggregation"
+)
+
+// groupingSet is one of the grouping sets of a GroupBy, with the
+// expressions its rows are grouped by and the aggregate expressions of its
+// groups, in which the grouping expressions not in the set are NULL.
+type groupingSet struct {
+	grouping  []sql.Expression
+	aggregate []sql.Expression
+}
+
+// groupingSetsIter returns the iterator of the groups of every grouping
+// set of the node, which aggregates the rows in all of them in a single
+// pass. The rows of the partitions of a table read in parallel are
+// aggregated together, as the partial aggregations of every partition are
+// merged without their grouping sets.
+func (p *GroupBy) groupingSetsIter(ctx *sql.Context, span opentracing.Span) (sql.RowIter, error) {
+	sets, err := p.groupingSets()
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	i, err := p.Child.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	iter := newGroupByGroupingIter(ctx, p.Aggregate, p.Grouping, i)
+	iter.sets = sets
+	return sql.NewSpanIter(span, iter), nil
+}
+
+// groupingSets returns the grouping sets of the node.
+func (p *GroupBy) groupingSets() ([]groupingSet, error) {
+	sets := make([]groupingSet, len(p.GroupingSets))
+	for i, indexes := range p.GroupingSets {
+		rolledUp := make(map[string]bool, len(p.Grouping))
+		for _, g := range p.Grouping {
+			rolledUp[g.String()] = true
+		}
+
+		for _, idx := range indexes {
+			if idx < 0 || idx >= len(p.Grouping) {
+				return nil, ErrGroupBy.New(p.GroupingSets)
+			}
+
+			sets[i].grouping = append(sets[i].grouping, p.Grouping[idx])
+			delete(rolledUp, p.Grouping[idx].String())
+		}
+
+		sets[i].aggregate = make([]sql.Expression, len(p.Aggregate))
+		for j, e := range p.Aggregate {
+			rolled, err := rollUp(e, rolledUp)
+			if err != nil {
+				return nil, err
+			}
+			sets[i].aggregate[j] = rolled
+		}
+	}
+
+	return sets, nil
+}
+
+// rollUp replaces the grouping expressions not in a grouping set, given by
+// their string, with NULL in an aggregate expression, and the calls to
+// GROUPING with their value in the set. The arguments of the aggregations
+// are kept, as they're aggregated in every set.
+func rollUp(e sql.Expression, rolledUp map[string]bool) (sql.Expression, error) {
+	if g, ok := e.(*aggregation.Grouping); ok {
+		v := g.Value(func(e sql.Expression) bool {
+			return rolledUp[e.String()]
+		})
+		return expression.NewLiteral(v, g.Type()), nil
+	}
+
+	if _, ok := e.(sql.Aggregation); ok {
+		return e, nil
+	}
+
+	if _, ok := e.(*expression.Alias); !ok && rolledUp[e.String()] {
+		return expression.NewLiteral(nil, e.Type()), nil
+	}
+
+	children := e.Children()
+	if len(children) == 0 {
+		return e, nil
+	}
+
+	rolled := make([]sql.Expression, len(children))
+	for i, child := range children {
+		var err error
+		if rolled[i], err = rollUp(child, rolledUp); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.WithChildren(rolled...)
+}
+
+// groupingSetKey returns the key of a group of a grouping set from the key
+// of its grouping values, which tells apart the groups of different sets
+// with the same values.
+func groupingSetKey(key uint64, set int) uint64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(set))
+	return crc64.Update(key, table, b[:])
+}
+
+// isAggregation reports whether the expression is an aggregation, which
+// may have an alias.
+func isAggregation(e sql.Expression) bool {
+	if alias, ok := e.(*expression.Alias); ok {
+		e = alias.Child
+	}
+
+	_, ok := e.(sql.Aggregation)
+	return ok
+}
@@ -0,0 +1,244 @@
+package plan
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/src-d/go-mysql-server/sql/expression/function/aggregation"
+	"github.com/stretchr/testify/require"
+)
+
+func newGroupingSetsTable(t *testing.T) *memory.Table {
+	child := memory.NewPartitionedTable("test", sql.Schema{
+		{Name: "col1", Type: sql.Text},
+		{Name: "col2", Type: sql.Int64},
+		{Name: "col3", Type: sql.Int64},
+	}, 2)
+
+	rows := []sql.Row{
+		sql.NewRow("a", int64(1), int64(1)),
+		sql.NewRow("a", int64(2), int64(2)),
+		sql.NewRow("a", int64(2), int64(3)),
+		sql.NewRow("b", int64(1), int64(4)),
+	}
+
+	for _, row := range rows {
+		require.NoError(t, child.Insert(sql.NewEmptyContext(), row))
+	}
+	return child
+}
+
+func TestGroupingSets(t *testing.T) {
+	col1 := expression.NewGetField(0, sql.Text, "col1", false)
+	col2 := expression.NewGetField(1, sql.Int64, "col2", false)
+	col3 := expression.NewGetField(2, sql.Int64, "col3", false)
+
+	testCases := []struct {
+		name     string
+		sets     [][]int
+		expected []sql.Row
+	}{
+		{
+			"rollup",
+			[][]int{{0, 1}, {0}, {}},
+			[]sql.Row{
+				{"a", int64(1), float64(1)},
+				{"a", int64(2), float64(5)},
+				{"b", int64(1), float64(4)},
+				{"a", nil, float64(6)},
+				{"b", nil, float64(4)},
+				{nil, nil, float64(10)},
+			},
+		},
+		{
+			"sets",
+			[][]int{{1}, {0}},
+			[]sql.Row{
+				{nil, int64(1), float64(5)},
+				{nil, int64(2), float64(5)},
+				{"a", nil, float64(6)},
+				{"b", nil, float64(4)},
+			},
+		},
+		{
+			"repeated set",
+			[][]int{{}, {}},
+			[]sql.Row{
+				{nil, nil, float64(10)},
+				{nil, nil, float64(10)},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			p := NewGroupBy(
+				[]sql.Expression{col1, col2, aggregation.NewSum(col3)},
+				[]sql.Expression{col1, col2},
+				NewResolvedTable(newGroupingSetsTable(t)),
+			).WithGroupingSets(tt.sets)
+
+			rows, err := sql.NodeToRows(sql.NewEmptyContext(), p)
+			require.NoError(err)
+			require.ElementsMatch(tt.expected, rows)
+		})
+	}
+}
+
+func TestGroupingSetsSchema(t *testing.T) {
+	require := require.New(t)
+
+	p := NewGroupBy(
+		[]sql.Expression{
+			expression.NewGetField(0, sql.Text, "col1", false),
+			expression.NewAlias(aggregation.NewCount(expression.NewStar()), "c"),
+		},
+		[]sql.Expression{expression.NewGetField(0, sql.Text, "col1", false)},
+		NewResolvedTable(newGroupingSetsTable(t)),
+	)
+
+	require.False(p.Schema()[0].Nullable)
+
+	p = p.WithGroupingSets([][]int{{0}, {}})
+	require.True(p.Schema()[0].Nullable)
+	require.False(p.Schema()[1].Nullable)
+}
+
+func TestGroupingSetsRollUpExpressions(t *testing.T) {
+	require := require.New(t)
+
+	col1 := expression.NewGetField(0, sql.Text, "col1", false)
+	col2 := expression.NewGetField(1, sql.Int64, "col2", false)
+
+	p := NewGroupBy(
+		[]sql.Expression{
+			expression.NewAlias(expression.NewPlus(col2, expression.NewLiteral(int64(1), sql.Int64)), "next"),
+			aggregation.NewMax(col2),
+		},
+		[]sql.Expression{col1, col2},
+		NewResolvedTable(newGroupingSetsTable(t)),
+	).WithGroupingSets([][]int{{0, 1}, {0}})
+
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), p)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{int64(2), int64(1)},
+		{int64(3), int64(2)},
+		{int64(2), int64(1)},
+		{nil, int64(2)},
+		{nil, int64(1)},
+	}, rows)
+}
+
+func TestGroupingSetsGrouping(t *testing.T) {
+	require := require.New(t)
+
+	col1 := expression.NewGetField(0, sql.Text, "col1", false)
+	col2 := expression.NewGetField(1, sql.Int64, "col2", false)
+
+	grouping, err := aggregation.NewGrouping(col1, col2)
+	require.NoError(err)
+
+	p := NewGroupBy(
+		[]sql.Expression{col1, col2, expression.NewAlias(grouping, "g")},
+		[]sql.Expression{col1, col2},
+		NewResolvedTable(newGroupingSetsTable(t)),
+	)
+
+	// Without grouping sets, the grouping expressions are never rolled up.
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), p)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{"a", int64(1), int64(0)},
+		{"a", int64(2), int64(0)},
+		{"b", int64(1), int64(0)},
+	}, rows)
+
+	rows, err = sql.NodeToRows(sql.NewEmptyContext(), p.WithGroupingSets([][]int{{0, 1}, {1}, {}}))
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{"a", int64(1), int64(0)},
+		{"a", int64(2), int64(0)},
+		{"b", int64(1), int64(0)},
+		{nil, int64(1), int64(2)},
+		{nil, int64(2), int64(2)},
+		{nil, nil, int64(3)},
+	}, rows)
+}
+
+func TestGroupingSetsInvalidIndex(t *testing.T) {
+	require := require.New(t)
+
+	p := NewGroupBy(
+		[]sql.Expression{aggregation.NewCount(expression.NewStar())},
+		[]sql.Expression{expression.NewGetField(0, sql.Text, "col1", false)},
+		NewResolvedTable(newGroupingSetsTable(t)),
+	).WithGroupingSets([][]int{{1}})
+
+	_, err := p.RowIter(sql.NewEmptyContext())
+	require.Error(err)
+	require.True(ErrGroupBy.Is(err))
+}
+
+func TestGroupingSetsSpill(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "grouping-sets-spill")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	defer func(dir string) { spillDir = dir }(spillDir)
+	spillDir = dir
+
+	child := memory.NewPartitionedTable("test", sql.Schema{
+		{Name: "col1", Type: sql.Int64},
+		{Name: "col2", Type: sql.Int64},
+	}, 2)
+
+	for i := 0; i < 300; i++ {
+		require.NoError(child.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i%50), int64(i))))
+	}
+
+	p := NewGroupBy(
+		[]sql.Expression{
+			expression.NewGetField(0, sql.Int64, "col1", false),
+			aggregation.NewCount(expression.NewGetField(1, sql.Int64, "col2", false)),
+		},
+		[]sql.Expression{
+			expression.NewGetField(0, sql.Int64, "col1", false),
+		},
+		NewResolvedTable(child),
+	).WithGroupingSets([][]int{{0}, {}})
+
+	expected, err := sql.NodeToRows(sql.NewEmptyContext(), p)
+	require.NoError(err)
+	require.Len(expected, 51)
+	require.Contains(expected, sql.NewRow(nil, int64(300)))
+
+	ctx := sql.NewEmptyContext()
+	ctx.Set(tmpTableSizeSessionVar, sql.Int64, int64(100))
+
+	iter, err := p.RowIter(ctx)
+	require.NoError(err)
+
+	row, err := iter.Next()
+	require.NoError(err)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.NotEmpty(files)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.ElementsMatch(expected, append(rows, row))
+
+	files, err = ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.Len(files, 0)
+}
@@ -0,0 +1,228 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// IndexedJoin is an inner join that, instead of iterating all the rows of
+// the secondary table for every row of the primary side, looks up in an
+// index of the secondary table only the rows matching the primary row.
+// The secondary table is not a child of the node, so it's not transformed
+// by other rules.
+type IndexedJoin struct {
+	UnaryNode
+	// Table is the secondary side of the join.
+	Table sql.IndexableTable
+	// Cond is the condition of the join, which is evaluated with the rows
+	// returned by the index.
+	Cond sql.Expression
+	// Index is the index of the secondary table used to look up the rows.
+	Index sql.Index
+	// Keys are evaluated with every row of the primary side to get the key
+	// to look up in the index, in the order of the index expressions.
+	Keys []sql.Expression
+}
+
+// NewIndexedJoin creates a new IndexedJoin node.
+func NewIndexedJoin(
+	primary sql.Node,
+	table sql.IndexableTable,
+	cond sql.Expression,
+	index sql.Index,
+	keys []sql.Expression,
+) *IndexedJoin {
+	return &IndexedJoin{
+		UnaryNode: UnaryNode{Child: primary},
+		Table:     table,
+		Cond:      cond,
+		Index:     index,
+		Keys:      keys,
+	}
+}
+
+// Schema implements the Node interface.
+func (j *IndexedJoin) Schema() sql.Schema {
+	left, right := j.Child.Schema(), j.Table.Schema()
+	schema := make(sql.Schema, 0, len(left)+len(right))
+	schema = append(schema, left...)
+	return append(schema, right...)
+}
+
+// Resolved implements the Resolvable interface.
+func (j *IndexedJoin) Resolved() bool {
+	if !j.Child.Resolved() || !j.Cond.Resolved() {
+		return false
+	}
+
+	for _, k := range j.Keys {
+		if !k.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// RowIter implements the Node interface.
+func (j *IndexedJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.IndexedJoin", opentracing.Tags{
+		"index": j.Index.ID(),
+		"table": j.Table.Name(),
+	})
+
+	primary, err := j.Child.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, &indexedJoinIter{
+		ctx:     ctx,
+		join:    j,
+		primary: primary,
+	}), nil
+}
+
+// WithChildren implements the Node interface.
+func (j *IndexedJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 1)
+	}
+
+	nj := *j
+	nj.UnaryNode = UnaryNode{Child: children[0]}
+	return &nj, nil
+}
+
+// Expressions implements the Expressioner interface.
+func (j *IndexedJoin) Expressions() []sql.Expression {
+	return append([]sql.Expression{j.Cond}, j.Keys...)
+}
+
+// WithExpressions implements the Expressioner interface.
+func (j *IndexedJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
+	if len(exprs) != len(j.Keys)+1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), len(j.Keys)+1)
+	}
+
+	nj := *j
+	nj.Cond = exprs[0]
+	nj.Keys = exprs[1:]
+	return &nj, nil
+}
+
+func (j *IndexedJoin) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("IndexedJoin(%s)", j.Cond)
+	_ = pr.WriteChildren(
+		j.Child.String(),
+		fmt.Sprintf("IndexedTable(%s, index=%s)", j.Table.Name(), j.Index.ID()),
+	)
+	return pr.String()
+}
+
+type indexedJoinIter struct {
+	ctx     *sql.Context
+	join    *IndexedJoin
+	primary sql.RowIter
+
+	primaryRow sql.Row
+	secondary  sql.RowIter
+}
+
+func (i *indexedJoinIter) Next() (sql.Row, error) {
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
+		if i.secondary == nil {
+			if err := i.lookupNextRow(); err != nil {
+				return nil, err
+			}
+
+			if i.secondary == nil {
+				continue
+			}
+		}
+
+		row, err := i.secondary.Next()
+		if err == io.EOF {
+			if err := i.secondary.Close(); err != nil {
+				return nil, err
+			}
+			i.secondary = nil
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row = joinRows(i.primaryRow, row)
+		matches, err := sql.EvaluateCondition(i.ctx, i.join.Cond, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			return row, nil
+		}
+	}
+}
+
+// lookupNextRow reads the next row of the primary side and looks up the
+// matching rows of the secondary table. The secondary iterator is left
+// unset if the key cannot match any row.
+func (i *indexedJoinIter) lookupNextRow() error {
+	row, err := i.primary.Next()
+	if err != nil {
+		return err
+	}
+
+	i.primaryRow = row
+
+	key := make([]interface{}, len(i.join.Keys))
+	for idx, k := range i.join.Keys {
+		v, err := k.Eval(i.ctx, row)
+		if err != nil {
+			return err
+		}
+
+		// Nothing is equal to NULL.
+		if v == nil {
+			return nil
+		}
+
+		key[idx] = v
+	}
+
+	lookup, err := i.join.Index.Get(key...)
+	if err != nil {
+		return err
+	}
+
+	table := i.join.Table.WithIndexLookup(lookup)
+	i.secondary, err = NewResolvedTable(table).RowIter(i.ctx)
+	return err
+}
+
+func (i *indexedJoinIter) Close() error {
+	if i.secondary != nil {
+		if err := i.secondary.Close(); err != nil {
+			_ = i.primary.Close()
+			return err
+		}
+	}
+
+	return i.primary.Close()
+}
+
+func joinRows(left, right sql.Row) sql.Row {
+	row := make(sql.Row, 0, len(left)+len(right))
+	row = append(row, left...)
+	return append(row, right...)
+}
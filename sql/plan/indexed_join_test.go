@@ -0,0 +1,132 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexedJoin(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	primary := memory.NewPartitionedTable("foo", sql.Schema{
+		{Name: "a", Source: "foo", Type: sql.Int64, Nullable: true},
+	}, 2)
+	for _, a := range []interface{}{int64(1), int64(2), nil, int64(4)} {
+		require.NoError(primary.Insert(ctx, sql.NewRow(a)))
+	}
+
+	secondary := memory.NewPartitionedTable("bar", sql.Schema{
+		{Name: "b", Source: "bar", Type: sql.Int64},
+		{Name: "c", Source: "bar", Type: sql.Text},
+	}, 2)
+	rows := []sql.Row{
+		sql.NewRow(int64(1), "one"),
+		sql.NewRow(int64(2), "two"),
+		sql.NewRow(int64(2), "another two"),
+		sql.NewRow(int64(3), "three"),
+	}
+	for _, row := range rows {
+		require.NoError(secondary.Insert(ctx, row))
+	}
+
+	a := expression.NewGetFieldWithTable(0, sql.Int64, "foo", "a", true)
+	b := expression.NewGetFieldWithTable(1, sql.Int64, "bar", "b", false)
+	c := expression.NewGetFieldWithTable(2, sql.Text, "bar", "c", false)
+
+	join := NewIndexedJoin(
+		NewResolvedTable(primary),
+		&keyIndexedTable{Table: secondary},
+		expression.NewAnd(
+			expression.NewEquals(a, b),
+			expression.NewNot(expression.NewEquals(c, expression.NewLiteral("two", sql.Text))),
+		),
+		&keyIndex{table: "bar", expr: b},
+		[]sql.Expression{a},
+	)
+
+	require.Equal(sql.Schema{
+		{Name: "a", Source: "foo", Type: sql.Int64, Nullable: true},
+		{Name: "b", Source: "bar", Type: sql.Int64},
+		{Name: "c", Source: "bar", Type: sql.Text},
+	}, join.Schema())
+
+	result, err := sql.NodeToRows(ctx, join)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		sql.NewRow(int64(1), int64(1), "one"),
+		sql.NewRow(int64(2), int64(2), "another two"),
+	}, result)
+}
+
+// keyIndex is an index of a single column whose lookups only contain the
+// key, which keyIndexedTable uses to filter its rows.
+type keyIndex struct {
+	table string
+	expr  sql.Expression
+}
+
+var _ sql.Index = (*keyIndex)(nil)
+
+func (i *keyIndex) Get(key ...interface{}) (sql.IndexLookup, error) {
+	return &keyLookup{key[0]}, nil
+}
+func (i *keyIndex) Has(sql.Partition, ...interface{}) (bool, error) {
+	panic("not implemented")
+}
+func (i *keyIndex) ID() string            { return i.expr.String() }
+func (i *keyIndex) Database() string      { return "" }
+func (i *keyIndex) Table() string         { return i.table }
+func (i *keyIndex) Expressions() []string { return []string{i.expr.String()} }
+func (i *keyIndex) Driver() string        { return "" }
+
+type keyLookup struct {
+	key interface{}
+}
+
+func (keyLookup) Values(sql.Partition) (sql.IndexValueIter, error) {
+	panic("not implemented")
+}
+func (keyLookup) Indexes() []string { return nil }
+
+type keyIndexedTable struct {
+	*memory.Table
+	lookup *keyLookup
+}
+
+var _ sql.IndexableTable = (*keyIndexedTable)(nil)
+
+func (t *keyIndexedTable) WithIndexLookup(lookup sql.IndexLookup) sql.Table {
+	return &keyIndexedTable{t.Table, lookup.(*keyLookup)}
+}
+
+func (t *keyIndexedTable) IndexLookup() sql.IndexLookup {
+	if t.lookup == nil {
+		return nil
+	}
+	return t.lookup
+}
+
+func (t *keyIndexedTable) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
+	iter, err := t.Table.PartitionRows(ctx, p)
+	if err != nil || t.lookup == nil {
+		return iter, err
+	}
+
+	rows, err := sql.RowIterToRows(iter)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []sql.Row
+	for _, row := range rows {
+		if row[0] == t.lookup.key {
+			matches = append(matches, row)
+		}
+	}
+	return sql.RowsToRowIter(matches...), nil
+}
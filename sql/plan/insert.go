@@ -23,6 +23,8 @@ type InsertInto struct {
 	BinaryNode
 	Columns   []string
 	IsReplace bool
+	// Changes is where the inserted rows are recorded, if they are.
+	Changes *ChangeTarget
 }
 
 // NewInsertInto creates an InsertInto node.
@@ -67,7 +69,7 @@ func getInsertableTable(t sql.Table) (sql.Inserter, error) {
 }
 
 // Execute inserts the rows in the database.
-func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
+func (p *InsertInto) Execute(ctx *sql.Context) (_ int, err error) {
 	insertable, err := getInsertable(p.Left)
 	if err != nil {
 		return 0, err
@@ -85,6 +87,11 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 	dstSchema := p.Left.Schema()
 	projExprs := make([]sql.Expression, len(dstSchema))
 
+	changes := newChangeLog(p.Changes, dstSchema)
+	defer func() {
+		err = changes.record(ctx, err)
+	}()
+
 	// If no columns are given, we assume the full schema in order
 	if len(p.Columns) == 0 {
 		p.Columns = make([]string, len(dstSchema))
@@ -114,20 +121,29 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 		}
 
 		if !found {
-			if !f.Nullable && f.Default == nil {
+			if !f.Nullable && f.Default == nil && !f.AutoIncrement {
 				return 0, ErrInsertIntoNonNullableDefaultNullColumn.New(f.Name)
 			}
 			projExprs[i] = expression.NewLiteral(f.Default, f.Type)
 		}
 	}
 
-	proj := NewProject(projExprs, p.Right)
+	source := p.Right
+	if values, ok := source.(*Values); ok {
+		source = p.hexNumbers(values, dstSchema)
+	}
+
+	proj := NewProject(projExprs, source)
 
 	iter, err := proj.RowIter(ctx)
 	if err != nil {
 		return 0, err
 	}
 
+	autoIncrement, _ := insertable.(sql.AutoIncrementTable)
+	var lastInsertID uint64
+	var replaced int64
+
 	i := 0
 	for {
 		row, err := iter.Next()
@@ -139,19 +155,41 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 			return i, err
 		}
 
+		if autoIncrement != nil {
+			id, err := generateAutoIncrement(ctx, autoIncrement, dstSchema, row)
+			if err != nil {
+				_ = iter.Close()
+				return i, err
+			}
+
+			// The last insert id is the first value generated by the insert.
+			if lastInsertID == 0 {
+				lastInsertID = id
+			}
+		}
+
 		err = p.validateNullability(ctx, dstSchema, row)
 		if err != nil {
 			_ = iter.Close()
 			return i, err
 		}
 
-		// Convert integer values in row to specified type in schema
+		// Convert integer, boolean, double, string and time values in row
+		// to specified type in schema, as the ones of dumps are written as
+		// numbers and strings. Timestamps given as strings are in the time
+		// zone of the session, and fractional seconds are rounded to the
+		// precision of the columns. Values out of range or not valid for
+		// their columns are adjusted to them, unless in strict mode.
 		for colIdx, oldValue := range row {
 			dstColType := projExprs[colIdx].Type()
 
-			if sql.IsInteger(dstColType) && oldValue != nil {
-				newValue, err := dstColType.Convert(oldValue)
+			convert := sql.IsInteger(dstColType) || sql.IsTime(dstColType) || sql.IsTimeOfDay(dstColType) ||
+				dstColType == sql.Boolean || dstColType == sql.Float64 ||
+				sql.IsChar(dstColType) || sql.IsVarChar(dstColType)
+			if convert && oldValue != nil {
+				newValue, err := sql.ConvertColumnValue(ctx, dstSchema[colIdx], int64(i+1), oldValue)
 				if err != nil {
+					_ = iter.Close()
 					return i, err
 				}
 
@@ -166,6 +204,8 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 					return i, err
 				}
 			} else {
+				changes.add(sql.RowDeleted, row, nil)
+				replaced++
 				i++
 			}
 
@@ -179,19 +219,83 @@ func (p *InsertInto) Execute(ctx *sql.Context) (int, error) {
 				return i, err
 			}
 		}
+		changes.add(sql.RowInserted, nil, row)
 		i++
 	}
 
+	if lastInsertID > 0 {
+		ctx.SetLastQueryInfo(sql.LastInsertID, int64(lastInsertID))
+	}
+	ctx.SetLastQueryInfo(sql.InsertID, int64(lastInsertID))
+	ctx.SetLastQueryInfo(sql.Duplicates, replaced)
+
 	return i, nil
 }
 
+// generateAutoIncrement sets the value of the auto increment column of the
+// row if it's null, and returns the generated value, or zero if no value
+// was generated.
+func generateAutoIncrement(
+	ctx *sql.Context,
+	table sql.AutoIncrementTable,
+	schema sql.Schema,
+	row sql.Row,
+) (uint64, error) {
+	for i, col := range schema {
+		if !col.AutoIncrement || row[i] != nil {
+			continue
+		}
+
+		id, err := table.NextAutoIncrementValue(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		row[i], err = col.Type.Convert(id)
+		if err != nil {
+			return 0, err
+		}
+
+		return id, nil
+	}
+
+	return 0, nil
+}
+
+// hexNumbers replaces the hexadecimal and bit-value literals of values
+// inserted in numeric columns with their numbers.
+func (p *InsertInto) hexNumbers(values *Values, dstSchema sql.Schema) *Values {
+	types := make(map[string]sql.Type, len(dstSchema))
+	for _, col := range dstSchema {
+		types[col.Name] = col.Type
+	}
+
+	tuples := make([][]sql.Expression, len(values.ExpressionTuples))
+	for i, tuple := range values.ExpressionTuples {
+		tuples[i] = make([]sql.Expression, len(tuple))
+		for j, e := range tuple {
+			tuples[i][j] = e
+			if l, ok := e.(*expression.Literal); ok && l.IsHex() && j < len(p.Columns) && sql.IsNumber(types[p.Columns[j]]) {
+				tuples[i][j] = expression.NewLiteral(expression.HexNumber(l, l.Value()), sql.Uint64)
+			}
+		}
+	}
+
+	return NewValues(tuples)
+}
+
 // RowIter implements the Node interface.
 func (p *InsertInto) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.InsertInto")
+	defer span.Finish()
+
 	n, err := p.Execute(ctx)
+	span.SetTag("rows", n)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx.SetLastQueryInfo(sql.RowCount, int64(n))
 	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
 }
 
@@ -201,7 +305,9 @@ func (p *InsertInto) WithChildren(children ...sql.Node) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 2)
 	}
 
-	return NewInsertInto(children[0], children[1], p.IsReplace, p.Columns), nil
+	np := NewInsertInto(children[0], children[1], p.IsReplace, p.Columns)
+	np.Changes = p.Changes
+	return np, nil
 }
 
 func (p InsertInto) String() string {
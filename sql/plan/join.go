@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"reflect"
@@ -8,6 +9,7 @@ import (
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
 )
 
 const (
@@ -26,6 +28,13 @@ func shouldUseMemoryJoinsByEnv() bool {
 type InnerJoin struct {
 	BinaryNode
 	Cond sql.Expression
+	// Strategy is the algorithm used to compute the join.
+	Strategy JoinStrategy
+	// BloomFilter, if set, is built with the keys of the rows of the right
+	// side, which are evaluated on them, when they are kept in memory. It
+	// shares its keys with a BloomFilter on the left side, so the rows of
+	// the left side that don't match any of them are skipped.
+	BloomFilter *expression.BloomFilter
 }
 
 // NewInnerJoin creates a new inner join node from two tables.
@@ -51,7 +60,7 @@ func (j *InnerJoin) Resolved() bool {
 
 // RowIter implements the Node interface.
 func (j *InnerJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	return joinRowIter(ctx, innerJoin, j.Left, j.Right, j.Cond)
+	return joinRowIter(ctx, innerJoin, j.Strategy, j.Left, j.Right, j.Cond, j.BloomFilter)
 }
 
 // WithChildren implements the Node interface.
@@ -60,7 +69,9 @@ func (j *InnerJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 2)
 	}
 
-	return NewInnerJoin(children[0], children[1], j.Cond), nil
+	nj := *j
+	nj.BinaryNode = BinaryNode{Left: children[0], Right: children[1]}
+	return &nj, nil
 }
 
 // WithExpressions implements the Expressioner interface.
@@ -69,12 +80,14 @@ func (j *InnerJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), 1)
 	}
 
-	return NewInnerJoin(j.Left, j.Right, exprs[0]), nil
+	nj := *j
+	nj.Cond = exprs[0]
+	return &nj, nil
 }
 
 func (j *InnerJoin) String() string {
 	pr := sql.NewTreePrinter()
-	_ = pr.WriteNode("InnerJoin(%s)", j.Cond)
+	_ = pr.WriteNode("InnerJoin(%s)%s", j.Cond, j.Strategy.suffix())
 	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
 	return pr.String()
 }
@@ -88,6 +101,8 @@ func (j *InnerJoin) Expressions() []sql.Expression {
 type LeftJoin struct {
 	BinaryNode
 	Cond sql.Expression
+	// Strategy is the algorithm used to compute the join.
+	Strategy JoinStrategy
 }
 
 // NewLeftJoin creates a new left join node from two tables.
@@ -113,7 +128,7 @@ func (j *LeftJoin) Resolved() bool {
 
 // RowIter implements the Node interface.
 func (j *LeftJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	return joinRowIter(ctx, leftJoin, j.Left, j.Right, j.Cond)
+	return joinRowIter(ctx, leftJoin, j.Strategy, j.Left, j.Right, j.Cond, nil)
 }
 
 // WithChildren implements the Node interface.
@@ -122,7 +137,9 @@ func (j *LeftJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 1)
 	}
 
-	return NewLeftJoin(children[0], children[1], j.Cond), nil
+	nj := *j
+	nj.BinaryNode = BinaryNode{Left: children[0], Right: children[1]}
+	return &nj, nil
 }
 
 // WithExpressions implements the Expressioner interface.
@@ -131,12 +148,14 @@ func (j *LeftJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), 1)
 	}
 
-	return NewLeftJoin(j.Left, j.Right, exprs[0]), nil
+	nj := *j
+	nj.Cond = exprs[0]
+	return &nj, nil
 }
 
 func (j *LeftJoin) String() string {
 	pr := sql.NewTreePrinter()
-	_ = pr.WriteNode("LeftJoin(%s)", j.Cond)
+	_ = pr.WriteNode("LeftJoin(%s)%s", j.Cond, j.Strategy.suffix())
 	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
 	return pr.String()
 }
@@ -150,6 +169,8 @@ func (j *LeftJoin) Expressions() []sql.Expression {
 type RightJoin struct {
 	BinaryNode
 	Cond sql.Expression
+	// Strategy is the algorithm used to compute the join.
+	Strategy JoinStrategy
 }
 
 // NewRightJoin creates a new right join node from two tables.
@@ -175,7 +196,7 @@ func (j *RightJoin) Resolved() bool {
 
 // RowIter implements the Node interface.
 func (j *RightJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	return joinRowIter(ctx, rightJoin, j.Left, j.Right, j.Cond)
+	return joinRowIter(ctx, rightJoin, j.Strategy, j.Left, j.Right, j.Cond, nil)
 }
 
 // WithChildren implements the Node interface.
@@ -184,7 +205,9 @@ func (j *RightJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 2)
 	}
 
-	return NewRightJoin(children[0], children[1], j.Cond), nil
+	nj := *j
+	nj.BinaryNode = BinaryNode{Left: children[0], Right: children[1]}
+	return &nj, nil
 }
 
 // WithExpressions implements the Expressioner interface.
@@ -193,12 +216,14 @@ func (j *RightJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
 		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), 1)
 	}
 
-	return NewRightJoin(j.Left, j.Right, exprs[0]), nil
+	nj := *j
+	nj.Cond = exprs[0]
+	return &nj, nil
 }
 
 func (j *RightJoin) String() string {
 	pr := sql.NewTreePrinter()
-	_ = pr.WriteNode("RightJoin(%s)", j.Cond)
+	_ = pr.WriteNode("RightJoin(%s)%s", j.Cond, j.Strategy.suffix())
 	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
 	return pr.String()
 }
@@ -229,11 +254,48 @@ func (t joinType) String() string {
 	}
 }
 
+// JoinStrategy is the algorithm used to compute a join.
+type JoinStrategy byte
+
+const (
+	// AutoJoinStrategy decides the algorithm during the execution of the
+	// join, depending on whether the secondary side fits in memory or not.
+	AutoJoinStrategy JoinStrategy = iota
+	// InMemoryJoinStrategy loads all the rows of the secondary side of the
+	// join in memory, so each side is iterated exactly once.
+	InMemoryJoinStrategy
+	// MultipassJoinStrategy iterates the secondary side of the join once for
+	// each row in the primary side.
+	MultipassJoinStrategy
+)
+
+func (s JoinStrategy) String() string {
+	switch s {
+	case AutoJoinStrategy:
+		return "auto"
+	case InMemoryJoinStrategy:
+		return "in memory"
+	case MultipassJoinStrategy:
+		return "multipass"
+	default:
+		return "INVALID"
+	}
+}
+
+func (s JoinStrategy) suffix() string {
+	if s == AutoJoinStrategy {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", s)
+}
+
 func joinRowIter(
 	ctx *sql.Context,
 	typ joinType,
+	strategy JoinStrategy,
 	left, right sql.Node,
 	cond sql.Expression,
+	bloomFilter *expression.BloomFilter,
 ) (sql.RowIter, error) {
 	var leftName, rightName string
 	if leftTable, ok := left.(sql.Nameable); ok {
@@ -260,8 +322,13 @@ func joinRowIter(
 	}
 
 	var mode = unknownMode
-	if useInMemoryJoins || inMemorySession {
+	switch {
+	case useInMemoryJoins || inMemorySession:
+		mode = memoryMode
+	case strategy == InMemoryJoinStrategy:
 		mode = memoryMode
+	case strategy == MultipassJoinStrategy:
+		mode = multipassMode
 	}
 
 	cache, dispose := ctx.Memory.NewRowsCache()
@@ -280,6 +347,24 @@ func joinRowIter(
 			mode:              mode,
 			secondaryRows:     cache,
 			dispose:           dispose,
+			memory:            ctx.QueryMemory().NewAccount(),
+		}), nil
+	}
+
+	if bloomFilter != nil && mode == memoryMode {
+		// The primary side is iterated once the rows of the secondary side
+		// have been loaded in memory and the bloom filter built with them.
+		return sql.NewSpanIter(span, &joinIter{
+			typ:               typ,
+			primaryProvider:   left,
+			secondaryProvider: right,
+			ctx:               ctx,
+			cond:              cond,
+			mode:              mode,
+			secondaryRows:     cache,
+			dispose:           dispose,
+			memory:            ctx.QueryMemory().NewAccount(),
+			bloomFilter:       bloomFilter,
 		}), nil
 	}
 
@@ -298,6 +383,7 @@ func joinRowIter(
 		mode:              mode,
 		secondaryRows:     cache,
 		dispose:           dispose,
+		memory:            ctx.QueryMemory().NewAccount(),
 	}), nil
 }
 
@@ -324,6 +410,7 @@ const (
 type joinIter struct {
 	typ               joinType
 	primary           sql.RowIter
+	primaryProvider   rowIterProvider
 	secondaryProvider rowIterProvider
 	secondary         sql.RowIter
 	ctx               *sql.Context
@@ -338,6 +425,8 @@ type joinIter struct {
 	secondaryRows sql.RowsCache
 	pos           int
 	dispose       sql.DisposeFunc
+	memory        *sql.MemoryAccount
+	bloomFilter   *expression.BloomFilter
 }
 
 func (i *joinIter) Dispose() {
@@ -345,9 +434,16 @@ func (i *joinIter) Dispose() {
 		i.dispose()
 		i.dispose = nil
 	}
+	i.memory.ReleaseAll()
 }
 
 func (i *joinIter) loadPrimary() error {
+	if i.primary == nil {
+		if err := i.startPrimary(); err != nil {
+			return err
+		}
+	}
+
 	if i.primaryRow == nil {
 		r, err := i.primary.Next()
 		if err != nil {
@@ -364,6 +460,28 @@ func (i *joinIter) loadPrimary() error {
 	return nil
 }
 
+// startPrimary loads the rows of the secondary side in memory and builds
+// the bloom filter with them before the primary side is iterated, so the
+// rows of the primary side that cannot match any of them are skipped as
+// soon as possible.
+func (i *joinIter) startPrimary() error {
+	if err := i.loadSecondaryInMemory(); err != nil {
+		return err
+	}
+
+	if err := i.bloomFilter.Build(i.ctx, i.secondaryRows.Get()); err != nil {
+		return err
+	}
+
+	iter, err := i.primaryProvider.RowIter(i.ctx)
+	if err != nil {
+		return err
+	}
+
+	i.primary = iter
+	return nil
+}
+
 func (i *joinIter) loadSecondaryInMemory() error {
 	iter, err := i.secondaryProvider.RowIter(i.ctx)
 	if err != nil {
@@ -379,6 +497,10 @@ func (i *joinIter) loadSecondaryInMemory() error {
 			return err
 		}
 
+		if err := i.memory.Reserve(estimateRowSize(row)); err != nil {
+			return err
+		}
+
 		if err := i.secondaryRows.Add(row); err != nil {
 			return err
 		}
@@ -442,6 +564,11 @@ func (i *joinIter) loadSecondary() (row sql.Row, err error) {
 		var switchToMultipass bool
 		if !i.ctx.Memory.HasAvailable() {
 			switchToMultipass = true
+		} else if err := i.memory.Reserve(estimateRowSize(rightRow)); err != nil {
+			if !sql.IsMemoryExceeded(err) {
+				return nil, err
+			}
+			switchToMultipass = true
 		} else {
 			err := i.secondaryRows.Add(rightRow)
 			if err != nil && !sql.ErrNoMemoryAvailable.Is(err) {
@@ -461,6 +588,10 @@ func (i *joinIter) loadSecondary() (row sql.Row, err error) {
 
 func (i *joinIter) Next() (sql.Row, error) {
 	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
 		if err := i.loadPrimary(); err != nil {
 			return nil, err
 		}
@@ -478,12 +609,12 @@ func (i *joinIter) Next() (sql.Row, error) {
 		}
 
 		row := i.buildRow(primary, secondary)
-		v, err := i.cond.Eval(i.ctx, row)
+		matches, err := sql.EvaluateCondition(i.ctx, i.cond, row)
 		if err != nil {
 			return nil, err
 		}
 
-		if v == false {
+		if !matches {
 			continue
 		}
 
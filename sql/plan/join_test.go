@@ -93,6 +93,70 @@ func testInnerJoin(t *testing.T, ctx *sql.Context) {
 		{"col1_2", "col2_2", int32(3), int64(4), "col1_2", "col2_2", int32(3), int64(4)},
 	}, rows)
 }
+func TestJoinCanceled(t *testing.T) {
+	require := require.New(t)
+
+	ltable := memory.NewTable("left", lSchema)
+	rtable := memory.NewTable("right", rSchema)
+	insertData(t, ltable)
+	insertData(t, rtable)
+
+	j := NewInnerJoin(
+		NewResolvedTable(ltable),
+		NewResolvedTable(rtable),
+		expression.NewEquals(
+			expression.NewGetField(0, sql.Text, "lcol1", false),
+			expression.NewGetField(4, sql.Text, "rcol1", false),
+		))
+
+	c, cancel := context.WithCancel(context.Background())
+	ctx := sql.NewContext(c)
+	ctx.Set(inMemoryJoinSessionVar, sql.Text, "true")
+
+	iter, err := j.RowIter(ctx)
+	require.NoError(err)
+
+	_, err = iter.Next()
+	require.NoError(err)
+
+	cancel()
+	_, err = iter.Next()
+	require.Equal(context.Canceled, err)
+	require.NoError(iter.Close())
+}
+
+func TestJoinStrategy(t *testing.T) {
+	ltable := memory.NewTable("left", lSchema)
+	rtable := memory.NewTable("right", rSchema)
+	insertData(t, ltable)
+	insertData(t, rtable)
+
+	for _, strategy := range []JoinStrategy{InMemoryJoinStrategy, MultipassJoinStrategy} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			require := require.New(t)
+			j := NewInnerJoin(
+				NewResolvedTable(ltable),
+				NewResolvedTable(rtable),
+				expression.NewEquals(
+					expression.NewGetField(0, sql.Text, "lcol1", false),
+					expression.NewGetField(4, sql.Text, "rcol1", false),
+				))
+			j.Strategy = strategy
+
+			node, err := j.WithChildren(j.Children()...)
+			require.NoError(err)
+			require.Equal(strategy, node.(*InnerJoin).Strategy)
+			require.Contains(node.String(), "["+strategy.String()+"]")
+
+			rows := collectRows(t, node)
+			require.Equal([]sql.Row{
+				{"col1_1", "col2_1", int32(1), int64(2), "col1_1", "col2_1", int32(1), int64(2)},
+				{"col1_2", "col2_2", int32(3), int64(4), "col1_2", "col2_2", int32(3), int64(4)},
+			}, rows)
+		})
+	}
+}
+
 func TestInnerJoinEmpty(t *testing.T) {
 	require := require.New(t)
 	ctx := sql.NewEmptyContext()
@@ -114,6 +178,43 @@ func TestInnerJoinEmpty(t *testing.T) {
 	assertRows(t, iter, 0)
 }
 
+func TestInnerJoinBloomFilter(t *testing.T) {
+	require := require.New(t)
+
+	ltable := memory.NewTable("left", lSchema)
+	rtable := memory.NewTable("right", rSchema)
+	insertData(t, ltable)
+	require.NoError(rtable.Insert(
+		sql.NewEmptyContext(),
+		sql.NewRow("col1_2", "col2_2", int32(3), int64(4)),
+	))
+
+	filter := expression.NewBloomFilter(
+		expression.NewGetFieldWithTable(0, sql.Text, "left", "lcol1", false),
+	)
+
+	j := NewInnerJoin(
+		NewFilter(filter, NewResolvedTable(ltable)),
+		NewResolvedTable(rtable),
+		expression.NewEquals(
+			expression.NewGetField(0, sql.Text, "lcol1", false),
+			expression.NewGetField(4, sql.Text, "rcol1", false),
+		))
+	j.Strategy = InMemoryJoinStrategy
+	j.BloomFilter = filter.WithKeys(
+		expression.NewGetFieldWithTable(0, sql.Text, "right", "rcol1", false),
+	)
+
+	rows := collectRows(t, j)
+	require.Equal([]sql.Row{
+		{"col1_2", "col2_2", int32(3), int64(4), "col1_2", "col2_2", int32(3), int64(4)},
+	}, rows)
+
+	v, err := filter.Eval(sql.NewEmptyContext(), sql.NewRow("col1_2"))
+	require.NoError(err)
+	require.Equal(true, v)
+}
+
 func BenchmarkInnerJoin(b *testing.B) {
 	t1 := memory.NewTable("foo", sql.Schema{
 		{Name: "a", Source: "foo", Type: sql.Int64},
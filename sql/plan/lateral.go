@@ -0,0 +1,359 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrLateralRowNotBound is returned when the query of a LATERAL derived
+// table is executed without the row of the tables preceding it.
+var ErrLateralRowNotBound = errors.NewKind("the query of a LATERAL derived table can only be executed by its join")
+
+// LateralJoin is a join of the rows of its left side with a LATERAL derived
+// table, whose query can refer to the columns of the left side, so it's
+// executed again for each of their rows.
+type LateralJoin struct {
+	BinaryNode
+	Cond sql.Expression
+	// Outer is whether the rows of the left side without matching rows are
+	// returned with NULL in the columns of the derived table, as with a
+	// LEFT JOIN.
+	Outer bool
+}
+
+// NewLateralJoin creates a new lateral join node, which only returns the
+// rows of the left side with matching rows.
+func NewLateralJoin(left, right sql.Node, cond sql.Expression) *LateralJoin {
+	return &LateralJoin{
+		BinaryNode: BinaryNode{
+			Left:  left,
+			Right: right,
+		},
+		Cond: cond,
+	}
+}
+
+// NewLeftLateralJoin creates a new lateral join node that returns all the
+// rows of the left side, as LEFT JOIN LATERAL.
+func NewLeftLateralJoin(left, right sql.Node, cond sql.Expression) *LateralJoin {
+	j := NewLateralJoin(left, right, cond)
+	j.Outer = true
+	return j
+}
+
+// Schema implements the Node interface.
+func (j *LateralJoin) Schema() sql.Schema {
+	if j.Outer {
+		return append(j.Left.Schema(), makeNullable(j.Right.Schema())...)
+	}
+	return append(j.Left.Schema(), j.Right.Schema()...)
+}
+
+// Resolved implements the Resolvable interface.
+func (j *LateralJoin) Resolved() bool {
+	return j.Left.Resolved() && j.Right.Resolved() && j.Cond.Resolved()
+}
+
+// RowIter implements the Node interface.
+func (j *LateralJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.LateralJoin")
+
+	l, err := j.Left.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	return sql.NewSpanIter(span, &lateralJoinIter{
+		ctx:      ctx,
+		left:     l,
+		right:    j.Right,
+		cond:     j.Cond,
+		outer:    j.Outer,
+		indexes:  lateralRowIndexes(j.Left.Schema(), findLateralRow(j.Right)),
+		rightLen: len(j.Right.Schema()),
+	}), nil
+}
+
+// WithChildren implements the Node interface.
+func (j *LateralJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 2)
+	}
+
+	nj := *j
+	nj.Left, nj.Right = children[0], children[1]
+	return &nj, nil
+}
+
+// WithExpressions implements the Expressioner interface.
+func (j *LateralJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
+	if len(exprs) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), 1)
+	}
+
+	nj := *j
+	nj.Cond = exprs[0]
+	return &nj, nil
+}
+
+// Expressions implements the Expressioner interface.
+func (j *LateralJoin) Expressions() []sql.Expression {
+	return []sql.Expression{j.Cond}
+}
+
+func (j *LateralJoin) String() string {
+	name := "LateralJoin"
+	if j.Outer {
+		name = "LeftLateralJoin"
+	}
+
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("%s(%s)", name, j.Cond)
+	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
+	return pr.String()
+}
+
+// LateralRow is the row of the tables preceding a LATERAL derived table,
+// which is read by its query as one more table to refer to their columns.
+// It's bound to each of their rows by the LateralJoin of the derived table
+// before executing the query.
+type LateralRow struct {
+	schema sql.Schema
+	tables map[string]string
+	row    sql.Row
+}
+
+// NewLateralRow creates a new lateral row node with the schema of the
+// tables preceding a LATERAL derived table and their names by the names
+// they're referred with in the query, which are their aliases or names.
+func NewLateralRow(schema sql.Schema, tables map[string]string) *LateralRow {
+	return &LateralRow{schema: schema, tables: tables}
+}
+
+// Tables returns the names of the tables of the row by the names they're
+// referred with in the query.
+func (r *LateralRow) Tables() map[string]string {
+	return r.tables
+}
+
+// WithRow returns a copy of the node bound to the given row.
+func (r *LateralRow) WithRow(row sql.Row) *LateralRow {
+	nr := *r
+	nr.row = row
+	return &nr
+}
+
+// Resolved implements the Resolvable interface.
+func (*LateralRow) Resolved() bool { return true }
+
+// Children implements the Node interface.
+func (*LateralRow) Children() []sql.Node { return nil }
+
+// Schema implements the Node interface.
+func (r *LateralRow) Schema() sql.Schema { return r.schema }
+
+// RowIter implements the Node interface.
+func (r *LateralRow) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	if r.row == nil {
+		return nil, ErrLateralRowNotBound.New()
+	}
+	return sql.RowsToRowIter(r.row), nil
+}
+
+// WithChildren implements the Node interface.
+func (r *LateralRow) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(r, len(children), 0)
+	}
+	return r, nil
+}
+
+func (r *LateralRow) String() string {
+	var names []string
+	for name := range r.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("LateralRow(%s)", strings.Join(names, ", "))
+}
+
+// findLateralRow returns the lateral row read by the query of a LATERAL
+// derived table, or nil if it does not read it. The ones of the derived
+// tables of lateral joins in the query are not its row.
+func findLateralRow(n sql.Node) *LateralRow {
+	switch n := n.(type) {
+	case *LateralRow:
+		return n
+	case *SubqueryAlias:
+		return findLateralRow(n.Child)
+	case *LateralJoin:
+		return findLateralRow(n.Left)
+	}
+
+	for _, child := range n.Children() {
+		if row := findLateralRow(child); row != nil {
+			return row
+		}
+	}
+	return nil
+}
+
+// bindLateralRow returns the query of a LATERAL derived table with its
+// lateral row bound to the given row.
+func bindLateralRow(n sql.Node, row sql.Row) (sql.Node, error) {
+	switch n := n.(type) {
+	case *LateralRow:
+		return n.WithRow(row), nil
+	case *SubqueryAlias:
+		child, err := bindLateralRow(n.Child, row)
+		if err != nil {
+			return nil, err
+		}
+		return NewSubqueryAlias(n.Name(), child), nil
+	case *LateralJoin:
+		left, err := bindLateralRow(n.Left, row)
+		if err != nil {
+			return nil, err
+		}
+		return n.WithChildren(left, n.Right)
+	}
+
+	children := n.Children()
+	if len(children) == 0 {
+		return n, nil
+	}
+
+	newChildren := make([]sql.Node, len(children))
+	for i, child := range children {
+		var err error
+		if newChildren[i], err = bindLateralRow(child, row); err != nil {
+			return nil, err
+		}
+	}
+	return n.WithChildren(newChildren...)
+}
+
+// lateralRowIndexes returns the indexes of the columns of a lateral row in
+// the rows of the left side of its join, which are -1 for the columns that
+// are not in them. The left side may have lost the columns not used since
+// the query of the derived table was analyzed, so they're found by name.
+func lateralRowIndexes(left sql.Schema, row *LateralRow) []int {
+	if row == nil {
+		return nil
+	}
+
+	indexes := make([]int, len(row.schema))
+	for i, col := range row.schema {
+		indexes[i] = -1
+		for j, leftCol := range left {
+			if strings.EqualFold(col.Source, leftCol.Source) && strings.EqualFold(col.Name, leftCol.Name) {
+				indexes[i] = j
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+type lateralJoinIter struct {
+	ctx      *sql.Context
+	left     sql.RowIter
+	right    sql.Node
+	cond     sql.Expression
+	outer    bool
+	indexes  []int
+	rightLen int
+
+	leftRow sql.Row
+	r       sql.RowIter
+	matched bool
+}
+
+func (i *lateralJoinIter) Next() (sql.Row, error) {
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
+		if i.r == nil {
+			row, err := i.left.Next()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := i.open(row); err != nil {
+				return nil, err
+			}
+		}
+
+		rightRow, err := i.r.Next()
+		if err == io.EOF {
+			err = i.r.Close()
+			i.r = nil
+			if err != nil {
+				return nil, err
+			}
+
+			if i.outer && !i.matched {
+				return append(i.leftRow, make(sql.Row, i.rightLen)...), nil
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(sql.Row, 0, len(i.leftRow)+len(rightRow))
+		row = append(append(row, i.leftRow...), rightRow...)
+		ok, err := sql.EvaluateCondition(i.ctx, i.cond, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			i.matched = true
+			return row, nil
+		}
+	}
+}
+
+// open starts the iteration of the rows of the derived table for the given
+// row of the left side.
+func (i *lateralJoinIter) open(row sql.Row) error {
+	lateralRow := make(sql.Row, len(i.indexes))
+	for j, idx := range i.indexes {
+		if idx >= 0 {
+			lateralRow[j] = row[idx]
+		}
+	}
+
+	right, err := bindLateralRow(i.right, lateralRow)
+	if err != nil {
+		return err
+	}
+
+	r, err := right.RowIter(i.ctx)
+	if err != nil {
+		return err
+	}
+
+	i.leftRow, i.r, i.matched = row, r, false
+	return nil
+}
+
+func (i *lateralJoinIter) Close() error {
+	err := i.left.Close()
+	if i.r != nil {
+		if rerr := i.r.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
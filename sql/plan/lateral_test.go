@@ -0,0 +1,116 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func newLateralTable(t *testing.T, name string, values ...int64) *memory.Table {
+	table := memory.NewTable(name, sql.Schema{
+		{Name: "n", Type: sql.Int64, Source: name},
+	})
+
+	for _, v := range values {
+		require.NoError(t, table.Insert(sql.NewEmptyContext(), sql.NewRow(v)))
+	}
+	return table
+}
+
+// newLateralQuery returns the query of a derived table with the values of
+// the table b lower than the value of the row of the table a.
+func newLateralQuery(t *testing.T, a *memory.Table) sql.Node {
+	b := newLateralTable(t, "b", 1, 2, 3)
+	return NewSubqueryAlias("t", NewProject(
+		[]sql.Expression{expression.NewGetFieldWithTable(1, sql.Int64, "b", "n", false)},
+		NewFilter(
+			expression.NewLessThan(
+				expression.NewGetFieldWithTable(1, sql.Int64, "b", "n", false),
+				expression.NewGetFieldWithTable(0, sql.Int64, "a", "n", false),
+			),
+			NewCrossJoin(
+				NewLateralRow(a.Schema(), map[string]string{"a": "a"}),
+				NewResolvedTable(b),
+			),
+		),
+	))
+}
+
+func TestLateralJoin(t *testing.T) {
+	require := require.New(t)
+
+	a := newLateralTable(t, "a", 1, 2, 3)
+	j := NewLateralJoin(
+		NewResolvedTable(a),
+		newLateralQuery(t, a),
+		expression.NewLiteral(true, sql.Boolean),
+	)
+	require.True(j.Resolved())
+	require.False(j.Schema()[1].Nullable)
+
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), j)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{int64(2), int64(1)},
+		{int64(3), int64(1)},
+		{int64(3), int64(2)},
+	}, rows)
+}
+
+func TestLeftLateralJoin(t *testing.T) {
+	require := require.New(t)
+
+	a := newLateralTable(t, "a", 1, 2, 3)
+	j := NewLeftLateralJoin(
+		NewResolvedTable(a),
+		newLateralQuery(t, a),
+		expression.NewNot(expression.NewEquals(
+			expression.NewGetFieldWithTable(1, sql.Int64, "t", "n", false),
+			expression.NewLiteral(int64(2), sql.Int64),
+		)),
+	)
+	require.True(j.Schema()[1].Nullable)
+
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), j)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{int64(1), nil},
+		{int64(2), int64(1)},
+		{int64(3), int64(1)},
+	}, rows)
+}
+
+func TestLateralJoinPrunedLeft(t *testing.T) {
+	require := require.New(t)
+
+	a := newLateralTable(t, "a", 1, 2, 3)
+	c := newLateralTable(t, "c", 5)
+
+	// The left side of the join has more columns than when the query of
+	// the derived table was analyzed, and in a different order.
+	j := NewLateralJoin(
+		NewCrossJoin(NewResolvedTable(c), NewResolvedTable(a)),
+		newLateralQuery(t, a),
+		expression.NewLiteral(true, sql.Boolean),
+	)
+
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), j)
+	require.NoError(err)
+	require.ElementsMatch([]sql.Row{
+		{int64(5), int64(2), int64(1)},
+		{int64(5), int64(3), int64(1)},
+		{int64(5), int64(3), int64(2)},
+	}, rows)
+}
+
+func TestLateralRowNotBound(t *testing.T) {
+	require := require.New(t)
+
+	a := newLateralTable(t, "a", 1)
+	_, err := sql.NodeToRows(sql.NewEmptyContext(), newLateralQuery(t, a))
+	require.Error(err)
+	require.True(ErrLateralRowNotBound.Is(err))
+}
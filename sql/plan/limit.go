@@ -11,6 +11,10 @@ import (
 type Limit struct {
 	UnaryNode
 	Limit int64
+	// CalcFoundRows makes the node keep consuming its child after the limit
+	// has been reached, so the total number of rows can be reported with
+	// FOUND_ROWS().
+	CalcFoundRows bool
 }
 
 // NewLimit creates a new Limit node with the given size.
@@ -30,12 +34,25 @@ func (l *Limit) Resolved() bool {
 func (l *Limit) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 	span, ctx := ctx.Span("plan.Limit", opentracing.Tag{Key: "limit", Value: l.Limit})
 
+	// The rows skipped by an offset are found rows too, so the offset is
+	// applied by the limit itself to count them.
+	if o, ok := l.Child.(*Offset); ok && l.CalcFoundRows {
+		it, err := o.Child.RowIter(ctx)
+		if err != nil {
+			span.Finish()
+			return nil, err
+		}
+
+		offset := &offsetIter{skip: o.Offset, childIter: it}
+		return sql.NewSpanIter(span, &limitIter{l: l, childIter: offset, offset: offset, ctx: ctx}), nil
+	}
+
 	li, err := l.Child.RowIter(ctx)
 	if err != nil {
 		span.Finish()
 		return nil, err
 	}
-	return sql.NewSpanIter(span, &limitIter{l, 0, li}), nil
+	return sql.NewSpanIter(span, &limitIter{l: l, childIter: li, ctx: ctx}), nil
 }
 
 // WithChildren implements the Node interface.
@@ -43,12 +60,18 @@ func (l *Limit) WithChildren(children ...sql.Node) (sql.Node, error) {
 	if len(children) != 1 {
 		return nil, sql.ErrInvalidChildrenNumber.New(l, len(children), 1)
 	}
-	return NewLimit(l.Limit, children[0]), nil
+	limit := NewLimit(l.Limit, children[0])
+	limit.CalcFoundRows = l.CalcFoundRows
+	return limit, nil
 }
 
 func (l Limit) String() string {
 	pr := sql.NewTreePrinter()
-	_ = pr.WriteNode("Limit(%d)", l.Limit)
+	if l.CalcFoundRows {
+		_ = pr.WriteNode("Limit(%d, SQL_CALC_FOUND_ROWS)", l.Limit)
+	} else {
+		_ = pr.WriteNode("Limit(%d)", l.Limit)
+	}
 	_ = pr.WriteChildren(l.Child.String())
 	return pr.String()
 }
@@ -57,14 +80,25 @@ type limitIter struct {
 	l          *Limit
 	currentPos int64
 	childIter  sql.RowIter
+	// offset is the iterator of the offset applied by the limit, if any.
+	offset *offsetIter
+	ctx    *sql.Context
 }
 
 func (li *limitIter) Next() (sql.Row, error) {
 	if li.currentPos >= li.l.Limit {
+		if li.l.CalcFoundRows {
+			if err := li.countRemaining(); err != nil {
+				return nil, err
+			}
+		}
 		return nil, io.EOF
 	}
 
 	childRow, err := li.childIter.Next()
+	if err == io.EOF && li.l.CalcFoundRows {
+		li.ctx.SetLastQueryInfo(sql.FoundRows, li.foundRows())
+	}
 	li.currentPos++
 	if err != nil {
 		return nil, err
@@ -73,6 +107,35 @@ func (li *limitIter) Next() (sql.Row, error) {
 	return childRow, nil
 }
 
+// countRemaining drains the child iterator and records the total number of
+// rows it produced as the found rows of the session.
+func (li *limitIter) countRemaining() error {
+	for {
+		if err := li.ctx.CheckCanceled(); err != nil {
+			return err
+		}
+
+		_, err := li.childIter.Next()
+		if err == io.EOF {
+			li.ctx.SetLastQueryInfo(sql.FoundRows, li.foundRows())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		li.currentPos++
+	}
+}
+
+// foundRows returns the number of rows produced by the child iterator,
+// including the ones skipped by the offset.
+func (li *limitIter) foundRows() int64 {
+	if li.offset != nil {
+		return li.currentPos + li.offset.skipped
+	}
+	return li.currentPos
+}
+
 func (li *limitIter) Close() error {
 	return li.childIter.Close()
 }
@@ -3,6 +3,7 @@ package plan
 import (
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"testing"
 
@@ -65,6 +66,25 @@ func TestLimitGreaterThanTotal(t *testing.T) {
 	testLimitOverflow(t, iterator, testingLimit, size)
 }
 
+func TestLimitCalcFoundRows(t *testing.T) {
+	require := require.New(t)
+	table, size := getTestingTable(t)
+
+	for _, limit := range []int64{1, int64(size) + 1} {
+		ctx := sql.NewEmptyContext()
+		node := NewLimit(limit, NewResolvedTable(table))
+		node.CalcFoundRows = true
+
+		iter, err := node.RowIter(ctx)
+		require.NoError(err)
+
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		require.Len(rows, int(math.Min(float64(limit), float64(size))))
+		require.Equal(int64(size), ctx.GetLastQueryInfo(sql.FoundRows))
+	}
+}
+
 func testLimitOverflow(t *testing.T, iter sql.RowIter, limit int, dataSize int) {
 	require := require.New(t)
 	for i := 0; i < limit+1; i++ {
@@ -0,0 +1,449 @@
+package plan
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrSecureFilePriv is returned when a query reads or writes a file of the
+// server that is not in the secure_file_priv directory of the catalog.
+var ErrSecureFilePriv = errors.NewKind("file %s cannot be used, as it's not in the secure_file_priv directory")
+
+// ErrLoadDataValue is returned when a field of a file loaded by LOAD DATA
+// INFILE cannot be converted to the type of its column.
+var ErrLoadDataValue = errors.NewKind("invalid value for column %s at line %d: %s")
+
+// LoadData is a node inserting the rows read from a text file in a table,
+// like LOAD DATA INFILE. The file is read from the client if it's local,
+// and from the secure_file_priv directory of the catalog otherwise.
+type LoadData struct {
+	UnaryNode
+	File      string
+	Local     bool
+	IsReplace bool
+	// Columns are the columns of the fields of the lines, which are all
+	// the columns of the table in order if it's empty.
+	Columns []string
+	Format  LoadDataFormat
+	Catalog *sql.Catalog
+	// Changes is where the inserted rows are recorded, if they are.
+	Changes *ChangeTarget
+}
+
+// NewLoadData creates a LoadData node.
+func NewLoadData(
+	table sql.Node,
+	file string,
+	local bool,
+	isReplace bool,
+	columns []string,
+	format LoadDataFormat,
+) *LoadData {
+	return &LoadData{
+		UnaryNode: UnaryNode{Child: table},
+		File:      file,
+		Local:     local,
+		IsReplace: isReplace,
+		Columns:   columns,
+		Format:    format,
+	}
+}
+
+// Schema implements the Node interface.
+func (p *LoadData) Schema() sql.Schema {
+	return sql.Schema{{
+		Name:     "updated",
+		Type:     sql.Int64,
+		Default:  int64(0),
+		Nullable: false,
+	}}
+}
+
+// RowIter implements the Node interface.
+func (p *LoadData) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.LoadData")
+	defer span.Finish()
+
+	n, err := p.Execute(ctx)
+	span.SetTag("rows", n)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.SetLastQueryInfo(sql.RowCount, int64(n))
+	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
+}
+
+// Execute inserts the rows of the file in the table, returning the number
+// of rows affected.
+func (p *LoadData) Execute(ctx *sql.Context) (_ int, err error) {
+	if err := p.Format.validate(); err != nil {
+		return 0, err
+	}
+
+	insertable, err := getInsertable(p.Child)
+	if err != nil {
+		return 0, err
+	}
+
+	schema := p.Child.Schema()
+	columns, err := p.columnIndexes(schema)
+	if err != nil {
+		return 0, err
+	}
+
+	l := &rowLoader{ctx: ctx, inserter: insertable, changes: newChangeLog(p.Changes, schema)}
+	defer func() {
+		err = l.changes.record(ctx, err)
+	}()
+
+	if p.IsReplace {
+		var ok bool
+		if l.replacer, ok = insertable.(sql.Replacer); !ok {
+			return 0, ErrReplaceIntoNotSupported.New()
+		}
+	} else {
+		l.batch, _ = insertable.(sql.BatchInserter)
+	}
+
+	f, err := p.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	r := newLoadDataReader(f, p.Format)
+	if err := r.skipLines(p.Format.IgnoreLines); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	if table, ok := insertable.(sql.AutoIncrementTable); ok {
+		if l.nextID, err = table.NextAutoIncrementValue(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	for {
+		fields, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return l.count, err
+		}
+
+		row, err := p.row(ctx, schema, columns, fields, r.line)
+		if err != nil {
+			return l.count, err
+		}
+
+		if err := l.autoIncrement(schema, row); err != nil {
+			return l.count, err
+		}
+
+		for i, col := range schema {
+			if !col.Nullable && row[i] == nil {
+				return l.count, ErrInsertIntoNonNullableProvidedNull.New(col.Name)
+			}
+		}
+
+		if err := l.add(row); err != nil {
+			return l.count, err
+		}
+	}
+
+	if err := l.flush(); err != nil {
+		return l.count, err
+	}
+
+	if l.firstID > 0 {
+		ctx.SetLastQueryInfo(sql.LastInsertID, int64(l.firstID))
+	}
+	ctx.SetLastQueryInfo(sql.InsertID, int64(l.firstID))
+	ctx.SetLastQueryInfo(sql.Duplicates, int64(l.replaced))
+
+	return l.count, nil
+}
+
+// columnIndexes returns the indexes in the schema of the columns of the
+// fields of the lines.
+func (p *LoadData) columnIndexes(schema sql.Schema) ([]int, error) {
+	if len(p.Columns) == 0 {
+		indexes := make([]int, len(schema))
+		for i := range schema {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	indexes := make([]int, len(p.Columns))
+	for i, name := range p.Columns {
+		indexes[i] = -1
+		for j, col := range schema {
+			if strings.EqualFold(col.Name, name) {
+				indexes[i] = j
+				break
+			}
+		}
+
+		if indexes[i] < 0 {
+			return nil, ErrInsertIntoNonexistentColumn.New(name)
+		}
+
+		for _, prev := range indexes[:i] {
+			if prev == indexes[i] {
+				return nil, ErrInsertIntoDuplicateColumn.New(name)
+			}
+		}
+	}
+
+	for i, col := range schema {
+		found := false
+		for _, idx := range indexes {
+			found = found || idx == i
+		}
+
+		if !found && !col.Nullable && col.Default == nil && !col.AutoIncrement {
+			return nil, ErrInsertIntoNonNullableDefaultNullColumn.New(col.Name)
+		}
+	}
+
+	return indexes, nil
+}
+
+// row returns the row of the fields of a line. The columns without a field,
+// as the line has fewer fields than columns, have their default value, and
+// the fields without a column are ignored. Timestamps are read in the time
+// zone of the session, and the values not valid for their columns are
+// adjusted to them, unless in strict mode.
+func (p *LoadData) row(ctx *sql.Context, schema sql.Schema, columns []int, fields []interface{}, line int64) (sql.Row, error) {
+	row := make(sql.Row, len(schema))
+	for i, col := range schema {
+		row[i] = col.Default
+	}
+
+	for i, idx := range columns {
+		if i >= len(fields) {
+			break
+		}
+
+		if fields[i] == nil {
+			row[idx] = nil
+			continue
+		}
+
+		v, err := sql.ConvertColumnValue(ctx, schema[idx], line, fields[i])
+		if err != nil {
+			if sql.ErrValueOutOfRange.Is(err) || sql.ErrTruncatedWrongValue.Is(err) || sql.ErrDataTooLong.Is(err) {
+				return nil, err
+			}
+			return nil, ErrLoadDataValue.New(schema[idx].Name, line, err)
+		}
+		row[idx] = v
+	}
+
+	return row, nil
+}
+
+// open opens the file loaded, from the client if it's local, or from the
+// secure_file_priv directory of the catalog.
+func (p *LoadData) open(ctx *sql.Context) (io.ReadCloser, error) {
+	if p.Local {
+		return ctx.OpenLocalFile(p.File)
+	}
+
+	path, err := secureFilePath(p.Catalog, p.File, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+// secureFilePath returns the path of a file of the server, which must be in
+// the secure_file_priv directory of the catalog, and is relative to it if
+// it's not absolute. Links are followed so they cannot point outside of the
+// directory, but only the ones in the directories of the file if it does
+// not exist yet.
+func secureFilePath(catalog *sql.Catalog, file string, exists bool) (string, error) {
+	var dir string
+	if catalog != nil {
+		dir = catalog.SecureFilePriv()
+	}
+	if dir == "" {
+		return "", ErrSecureFilePriv.New(file)
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if exists {
+		path, err = filepath.EvalSymlinks(path)
+	} else {
+		var parent string
+		parent, err = filepath.EvalSymlinks(filepath.Dir(path))
+		path = filepath.Join(parent, filepath.Base(path))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrSecureFilePriv.New(file)
+	}
+
+	return path, nil
+}
+
+// WithChildren implements the Node interface.
+func (p *LoadData) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
+	}
+
+	np := *p
+	np.Child = children[0]
+	return &np, nil
+}
+
+func (p *LoadData) String() string {
+	pr := sql.NewTreePrinter()
+	kind := "LoadData"
+	if p.IsReplace {
+		kind = "LoadDataReplace"
+	}
+
+	if p.Local {
+		_ = pr.WriteNode("%s(LOCAL %s)", kind, p.File)
+	} else {
+		_ = pr.WriteNode("%s(%s)", kind, p.File)
+	}
+	_ = pr.WriteChildren(p.Child.String())
+	return pr.String()
+}
+
+// rowLoader inserts the rows loaded in a table, in batches if the table
+// supports them, counting the rows affected.
+type rowLoader struct {
+	ctx      *sql.Context
+	inserter sql.Inserter
+	batch    sql.BatchInserter
+	replacer sql.Replacer
+	changes  *changeLog
+	pending  []sql.Row
+	count    int
+	// replaced is the number of rows replaced by the ones loaded.
+	replaced int
+	// nextID is the next value of the auto increment column, if the table
+	// has one, which is tracked by the loader as the table does not know
+	// the rows of the current batch.
+	nextID uint64
+	// firstID is the first value generated, which is the last insert id.
+	firstID uint64
+}
+
+// autoIncrement sets the value of the auto increment column of the row if
+// it's NULL, keeping the next value greater than the ones of the rows
+// loaded.
+func (l *rowLoader) autoIncrement(schema sql.Schema, row sql.Row) error {
+	if l.nextID == 0 {
+		return nil
+	}
+
+	for i, col := range schema {
+		if !col.AutoIncrement {
+			continue
+		}
+
+		if row[i] != nil {
+			v, err := sql.Int64.Convert(row[i])
+			if err == nil && v.(int64) > 0 && uint64(v.(int64)) >= l.nextID {
+				l.nextID = uint64(v.(int64)) + 1
+			}
+			return nil
+		}
+
+		if l.firstID == 0 {
+			l.firstID = l.nextID
+		}
+
+		var err error
+		row[i], err = col.Type.Convert(l.nextID)
+		l.nextID++
+		return err
+	}
+
+	return nil
+}
+
+func (l *rowLoader) add(row sql.Row) error {
+	switch {
+	case l.replacer != nil:
+		if err := l.replacer.Delete(l.ctx, row); err == nil {
+			l.changes.add(sql.RowDeleted, row, nil)
+			l.replaced++
+			l.count++
+		} else if err != sql.ErrDeleteRowNotFound {
+			return err
+		}
+
+		if err := l.replacer.Insert(l.ctx, row); err != nil {
+			return err
+		}
+	case l.batch != nil:
+		l.pending = append(l.pending, row)
+		if len(l.pending) < sql.RowBatchSize {
+			return nil
+		}
+		return l.flush()
+	default:
+		if err := l.inserter.Insert(l.ctx, row); err != nil {
+			return err
+		}
+	}
+
+	l.changes.add(sql.RowInserted, nil, row)
+	l.count++
+	return l.ctx.CheckCanceled()
+}
+
+// flush inserts the rows of the current batch, if any.
+func (l *rowLoader) flush() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+
+	if err := l.batch.InsertBatch(l.ctx, l.pending); err != nil {
+		return err
+	}
+
+	for _, row := range l.pending {
+		l.changes.add(sql.RowInserted, nil, row)
+	}
+
+	// The rows may be kept by the table, so the slice is not reused.
+	l.count += len(l.pending)
+	l.pending = nil
+	return l.ctx.CheckCanceled()
+}
@@ -0,0 +1,379 @@
+package plan
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidLoadDataFormat is returned when the format of the file loaded
+// by LOAD DATA INFILE cannot be read.
+var ErrInvalidLoadDataFormat = errors.NewKind("invalid LOAD DATA format: %s")
+
+// LoadDataFormat is the format of the files loaded by LOAD DATA INFILE,
+// which are text files with a row per line.
+type LoadDataFormat struct {
+	// FieldsTerminatedBy separates the fields of a line.
+	FieldsTerminatedBy string
+	// FieldsEnclosedBy is the character quoting the fields, which contain
+	// the terminators of fields and lines when they're enclosed. Fields
+	// are not enclosed if it's empty.
+	FieldsEnclosedBy string
+	// FieldsOptionallyEnclosed is whether only some fields are enclosed,
+	// which does not change how they're read.
+	FieldsOptionallyEnclosed bool
+	// FieldsEscapedBy is the character escaping the special characters
+	// of the fields, and \N, which is NULL. Nothing is escaped if it's
+	// empty.
+	FieldsEscapedBy string
+	// LinesStartingBy is the prefix of the lines, which is skipped along
+	// with what comes before it. Lines without it are skipped.
+	LinesStartingBy string
+	// LinesTerminatedBy separates the lines.
+	LinesTerminatedBy string
+	// IgnoreLines is the number of lines skipped at the start of the file,
+	// such as a header with the names of the columns.
+	IgnoreLines int64
+}
+
+// DefaultLoadDataFormat returns the format of the files loaded when none is
+// given, with the fields separated by tabs and escaped by backslashes, and
+// the lines separated by newlines.
+func DefaultLoadDataFormat() LoadDataFormat {
+	return LoadDataFormat{
+		FieldsTerminatedBy: "\t",
+		FieldsEscapedBy:    `\`,
+		LinesTerminatedBy:  "\n",
+	}
+}
+
+func (f LoadDataFormat) validate() error {
+	switch {
+	case f.FieldsTerminatedBy == "":
+		return ErrInvalidLoadDataFormat.New("fields must be terminated by a string")
+	case f.LinesTerminatedBy == "":
+		return ErrInvalidLoadDataFormat.New("lines must be terminated by a string")
+	case len(f.FieldsEnclosedBy) > 1:
+		return ErrInvalidLoadDataFormat.New("fields must be enclosed by a single character")
+	case len(f.FieldsEscapedBy) > 1:
+		return ErrInvalidLoadDataFormat.New("fields must be escaped by a single character")
+	case f.IgnoreLines < 0:
+		return ErrInvalidLoadDataFormat.New("the number of lines ignored cannot be negative")
+	}
+	return nil
+}
+
+// escapedChars are the characters written with an escape sequence, by the
+// character following the escape character.
+var escapedChars = map[byte]byte{
+	'0': 0,
+	'b': '\b',
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+	'Z': 0x1a,
+}
+
+// loadDataReader reads the fields of the lines of a file with the given
+// format. The fields are strings, or nil if they're NULL.
+type loadDataReader struct {
+	r       *bufio.Reader
+	format  LoadDataFormat
+	enclose byte
+	escape  byte
+	// line is the number of lines read.
+	line int64
+}
+
+func newLoadDataReader(r io.Reader, format LoadDataFormat) *loadDataReader {
+	lr := &loadDataReader{r: bufio.NewReader(r), format: format}
+	if format.FieldsEnclosedBy != "" {
+		lr.enclose = format.FieldsEnclosedBy[0]
+	}
+	if format.FieldsEscapedBy != "" {
+		lr.escape = format.FieldsEscapedBy[0]
+	}
+	return lr
+}
+
+// next returns the fields of the next line, or io.EOF if there are no more
+// lines.
+func (r *loadDataReader) next() ([]interface{}, error) {
+	if err := r.skipToLine(); err != nil {
+		return nil, err
+	}
+
+	var fields []interface{}
+	for {
+		field, last, err := r.field()
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+		if last {
+			r.line++
+			return fields, nil
+		}
+	}
+}
+
+// skipToLine skips what comes before the start of the next line, which is
+// its prefix if lines have one. It returns io.EOF if there are no more
+// lines.
+func (r *loadDataReader) skipToLine() error {
+	if _, err := r.r.Peek(1); err != nil {
+		return err
+	}
+
+	prefix := r.format.LinesStartingBy
+	if prefix == "" {
+		return nil
+	}
+
+	for {
+		ok, err := r.match(prefix)
+		if err != nil || ok {
+			return err
+		}
+
+		if _, err := r.r.ReadByte(); err != nil {
+			return err
+		}
+	}
+}
+
+// match discards s if it's next in the file, returning whether it was.
+func (r *loadDataReader) match(s string) (bool, error) {
+	b, err := r.r.Peek(len(s))
+	if err == io.EOF || (err == nil && string(b) != s) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = r.r.Discard(len(s))
+	return err == nil, err
+}
+
+// terminator discards the terminator of a field if it's next in the file,
+// returning whether there was one and whether it also ended the line,
+// which the end of the file does.
+func (r *loadDataReader) terminator() (found, last bool, err error) {
+	if ok, err := r.match(r.format.FieldsTerminatedBy); ok || err != nil {
+		return ok, false, err
+	}
+
+	if ok, err := r.match(r.format.LinesTerminatedBy); ok || err != nil {
+		return ok, ok, err
+	}
+
+	if _, err := r.r.Peek(1); err == io.EOF {
+		return true, true, nil
+	} else if err != nil {
+		return false, false, err
+	}
+
+	return false, false, nil
+}
+
+// field reads the next field of the line, returning whether it was the
+// last one.
+func (r *loadDataReader) field() (interface{}, bool, error) {
+	enclosed := false
+	if r.enclose != 0 {
+		b, err := r.r.Peek(1)
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+
+		if len(b) > 0 && b[0] == r.enclose {
+			enclosed = true
+			if _, err := r.r.Discard(1); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	var buf []byte
+	var null bool
+	for {
+		if !enclosed {
+			found, last, err := r.terminator()
+			if err != nil {
+				return nil, false, err
+			}
+
+			if found {
+				// Unenclosed NULL words are NULL when fields can be
+				// enclosed, as NULL values cannot be written otherwise.
+				if null || (r.enclose != 0 && string(buf) == "NULL") {
+					return nil, last, nil
+				}
+				return string(buf), last, nil
+			}
+		}
+
+		b, err := r.r.ReadByte()
+		if err == io.EOF {
+			// The enclosed field is not closed before the end of the file.
+			return string(buf), true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch {
+		case b == r.escape && r.escape != 0:
+			c, err := r.r.ReadByte()
+			if err == io.EOF {
+				buf = append(buf, b)
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+
+			if c == 'N' && len(buf) == 0 && !enclosed {
+				null = true
+			} else if null {
+				null = false
+				buf = append(buf, 'N')
+			}
+
+			if e, ok := escapedChars[c]; ok {
+				c = e
+			}
+			if !null {
+				buf = append(buf, c)
+			}
+			continue
+		case enclosed && b == r.enclose:
+			// The enclosing character is written twice inside fields.
+			if ok, err := r.match(string(r.enclose)); err != nil {
+				return nil, false, err
+			} else if ok {
+				buf = append(buf, b)
+				continue
+			}
+
+			found, last, err := r.terminator()
+			if err != nil {
+				return nil, false, err
+			}
+
+			if found {
+				return string(buf), last, nil
+			}
+		}
+
+		if null {
+			null = false
+			buf = append(buf, 'N')
+		}
+		buf = append(buf, b)
+	}
+}
+
+// skipLines skips the given number of lines.
+func (r *loadDataReader) skipLines(n int64) error {
+	for i := int64(0); i < n; i++ {
+		if _, err := r.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadDataWriter writes rows to a file with the format of the files loaded
+// by LOAD DATA INFILE, so they can be loaded back.
+type loadDataWriter struct {
+	w       *bufio.Writer
+	format  LoadDataFormat
+	schema  sql.Schema
+	enclose byte
+	escape  byte
+}
+
+func newLoadDataWriter(w io.Writer, format LoadDataFormat, schema sql.Schema) *loadDataWriter {
+	lw := &loadDataWriter{w: bufio.NewWriter(w), format: format, schema: schema}
+	if format.FieldsEnclosedBy != "" {
+		lw.enclose = format.FieldsEnclosedBy[0]
+	}
+	if format.FieldsEscapedBy != "" {
+		lw.escape = format.FieldsEscapedBy[0]
+	}
+	return lw
+}
+
+// write writes a row as a line of the file.
+func (w *loadDataWriter) write(row sql.Row) error {
+	w.w.WriteString(w.format.LinesStartingBy)
+	for i, v := range row {
+		if i > 0 {
+			w.w.WriteString(w.format.FieldsTerminatedBy)
+		}
+
+		if v == nil {
+			if w.escape != 0 {
+				w.w.WriteByte(w.escape)
+				w.w.WriteByte('N')
+			} else {
+				w.w.WriteString("NULL")
+			}
+			continue
+		}
+
+		value, err := w.schema[i].Type.SQL(v)
+		if err != nil {
+			return err
+		}
+
+		// Only the strings are enclosed if the fields are optionally
+		// enclosed.
+		enclosed := w.enclose != 0 &&
+			(!w.format.FieldsOptionallyEnclosed || sql.IsText(w.schema[i].Type))
+		if enclosed {
+			w.w.WriteByte(w.enclose)
+		}
+		w.field(value.ToBytes())
+		if enclosed {
+			w.w.WriteByte(w.enclose)
+		}
+	}
+
+	_, err := w.w.WriteString(w.format.LinesTerminatedBy)
+	return err
+}
+
+// field writes the value of a field, escaping the characters that would
+// not be read back otherwise: the escape and enclosing characters, NUL,
+// and the first characters of the terminators if fields are not enclosed.
+func (w *loadDataWriter) field(value []byte) {
+	if w.escape == 0 {
+		w.w.Write(value)
+		return
+	}
+
+	for _, b := range value {
+		switch {
+		case b == 0:
+			w.w.WriteByte(w.escape)
+			b = '0'
+		case b == w.escape,
+			w.enclose != 0 && b == w.enclose,
+			w.enclose == 0 && b == w.format.FieldsTerminatedBy[0],
+			w.enclose == 0 && b == w.format.LinesTerminatedBy[0]:
+			w.w.WriteByte(w.escape)
+		}
+		w.w.WriteByte(b)
+	}
+}
+
+// flush writes the lines buffered.
+func (w *loadDataWriter) flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,240 @@
+package plan
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDataReader(t *testing.T) {
+	csv := DefaultLoadDataFormat()
+	csv.FieldsTerminatedBy = ","
+	csv.FieldsEnclosedBy = `"`
+	csv.LinesTerminatedBy = "\r\n"
+
+	prefixed := DefaultLoadDataFormat()
+	prefixed.FieldsTerminatedBy = "||"
+	prefixed.FieldsEscapedBy = ""
+	prefixed.LinesStartingBy = "xxx"
+
+	testCases := []struct {
+		name     string
+		format   LoadDataFormat
+		data     string
+		expected [][]interface{}
+	}{
+		{
+			"default",
+			DefaultLoadDataFormat(),
+			"1\ta\\tb\n\\N\t\\\\N\n\nc\\nd\t\\0\\Z\\x",
+			[][]interface{}{
+				{"1", "a\tb"},
+				{nil, `\N`},
+				{""},
+				{"c\nd", "\x00\x1ax"},
+			},
+		},
+		{
+			"enclosed",
+			csv,
+			"1,\"a,\"\"b\"\"\r\nc\",NULL\r\n\"NULL\",\"x\"y\",\\N\r\n",
+			[][]interface{}{
+				{"1", "a,\"b\"\r\nc", nil},
+				{"NULL", `x"y`, nil},
+			},
+		},
+		{
+			"prefixed",
+			prefixed,
+			"skipped\nfooxxxa||b\\c||\nxxxd",
+			[][]interface{}{
+				{"a", `b\c`, ""},
+				{"d"},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			r := newLoadDataReader(strings.NewReader(tt.data), tt.format)
+
+			var lines [][]interface{}
+			for {
+				fields, err := r.next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(err)
+				lines = append(lines, fields)
+			}
+
+			require.Equal(tt.expected, lines)
+			require.Equal(int64(len(tt.expected)), r.line)
+		})
+	}
+}
+
+// batchTable is a table inserting rows in batches.
+type batchTable struct {
+	*memory.Table
+	batches []int
+}
+
+func (t *batchTable) InsertBatch(ctx *sql.Context, rows []sql.Row) error {
+	t.batches = append(t.batches, len(rows))
+	for _, row := range rows {
+		if err := t.Insert(ctx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newLoadDataTable() *memory.Table {
+	return memory.NewTable("t", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t", AutoIncrement: true},
+		{Name: "name", Type: sql.Text, Source: "t"},
+		{Name: "score", Type: sql.Float64, Source: "t", Nullable: true, Default: 1.5},
+	})
+}
+
+func localFiles(files map[string]string) sql.LocalFileOpener {
+	return func(name string) (io.ReadCloser, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return ioutil.NopCloser(strings.NewReader(data)), nil
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	require := require.New(t)
+
+	var lines []string
+	for i := 1; i <= sql.RowBatchSize+2; i++ {
+		lines = append(lines, "name\t2")
+	}
+	ctx := sql.NewEmptyContext().WithLocalFiles(localFiles(map[string]string{
+		"data.csv": "id,name,score\n7,\"a, b\",3\n\\N,c\n8,d,\\N,ignored\n",
+		"big.tsv":  strings.Join(lines, "\n"),
+		"bad.tsv":  "1\tfoo\tbar",
+	}))
+
+	table := newLoadDataTable()
+	var recorder changeRecorder
+	format := DefaultLoadDataFormat()
+	format.FieldsTerminatedBy = ","
+	format.FieldsEnclosedBy = `"`
+	format.IgnoreLines = 1
+
+	load := NewLoadData(NewResolvedTable(table), "data.csv", true, false, nil, format)
+	load.Changes = &ChangeTarget{Recorder: &recorder, Database: "db", Table: "t"}
+	n, err := load.Execute(ctx)
+	require.NoError(err)
+	require.Equal(3, n)
+	require.Len(recorder, 1)
+	require.Len(recorder[0].Rows, 3)
+
+	rows, err := sql.NodeToRows(ctx, NewResolvedTable(table))
+	require.NoError(err)
+	require.Equal([]sql.Row{
+		{int64(7), "a, b", float64(3)},
+		{int64(8), "c", 1.5},
+		{int64(8), "d", nil},
+	}, rows)
+
+	// Rows are inserted in batches by the tables that support them, with the
+	// auto increment values of the rows of the batch.
+	batched := &batchTable{Table: newLoadDataTable()}
+	load = NewLoadData(NewResolvedTable(batched), "big.tsv", true, false, []string{"name", "score"}, DefaultLoadDataFormat())
+	n, err = load.Execute(ctx)
+	require.NoError(err)
+	require.Equal(sql.RowBatchSize+2, n)
+
+	load = NewLoadData(NewResolvedTable(batched), "big.tsv", true, false, []string{"NAME"}, DefaultLoadDataFormat())
+	_, err = load.Execute(ctx)
+	require.NoError(err)
+	require.Equal([]int{sql.RowBatchSize, 2, sql.RowBatchSize, 2}, batched.batches)
+
+	rows, err = sql.NodeToRows(ctx, NewResolvedTable(batched))
+	require.NoError(err)
+	require.Len(rows, 2*(sql.RowBatchSize+2))
+	for i, row := range rows {
+		require.Equal(int64(i+1), row[0])
+	}
+
+	// Values that are not valid for their columns are errors in strict mode,
+	// and are adjusted to them with a warning otherwise.
+	ctx.Set(sql.SQLModeVariable, sql.Text, "STRICT_TRANS_TABLES")
+	load = NewLoadData(NewResolvedTable(table), "bad.tsv", true, false, nil, DefaultLoadDataFormat())
+	_, err = load.Execute(ctx)
+	require.True(sql.ErrTruncatedWrongValue.Is(err))
+
+	ctx.Set(sql.SQLModeVariable, sql.Text, "")
+	n, err = load.Execute(ctx)
+	require.NoError(err)
+	require.Equal(1, n)
+	require.Len(ctx.Warnings(), 1)
+	require.Equal(1366, ctx.Warnings()[0].Code)
+
+	load = NewLoadData(NewResolvedTable(table), "data.csv", false, false, []string{"name", "id", "name"}, format)
+	_, err = load.Execute(ctx)
+	require.True(ErrInsertIntoDuplicateColumn.Is(err))
+
+	load = NewLoadData(NewResolvedTable(table), "data.csv", false, false, []string{"score"}, format)
+	_, err = load.Execute(ctx)
+	require.True(ErrInsertIntoNonNullableDefaultNullColumn.Is(err))
+
+	_, err = NewLoadData(NewResolvedTable(table), "data.csv", true, false, nil, format).Execute(sql.NewEmptyContext())
+	require.True(sql.ErrLocalFilesDisabled.Is(err))
+}
+
+func TestLoadDataSecureFilePriv(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "load-data")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files := filepath.Join(dir, "files")
+	require.NoError(os.Mkdir(files, 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(files, "data.txt"), []byte("1\tfoo\n"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("2\tbar\n"), 0644))
+	require.NoError(os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(files, "link.txt")))
+
+	ctx := sql.NewEmptyContext()
+	load := func(file string) (int, error) {
+		catalog := sql.NewCatalog()
+		catalog.SetSecureFilePriv(files)
+		l := NewLoadData(NewResolvedTable(newLoadDataTable()), file, false, false, nil, DefaultLoadDataFormat())
+		l.Catalog = catalog
+		return l.Execute(ctx)
+	}
+
+	n, err := load("data.txt")
+	require.NoError(err)
+	require.Equal(1, n)
+
+	n, err = load(filepath.Join(files, "data.txt"))
+	require.NoError(err)
+	require.Equal(1, n)
+
+	for _, file := range []string{"../secret.txt", filepath.Join(dir, "secret.txt"), "link.txt"} {
+		_, err = load(file)
+		require.True(ErrSecureFilePriv.Is(err), file)
+	}
+
+	l := NewLoadData(NewResolvedTable(newLoadDataTable()), "data.txt", false, false, nil, DefaultLoadDataFormat())
+	l.Catalog = sql.NewCatalog()
+	_, err = l.Execute(ctx)
+	require.True(ErrSecureFilePriv.Is(err))
+}
@@ -33,7 +33,7 @@ func (o *Offset) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 		span.Finish()
 		return nil, err
 	}
-	return sql.NewSpanIter(span, &offsetIter{o.Offset, it}), nil
+	return sql.NewSpanIter(span, &offsetIter{skip: o.Offset, childIter: it}), nil
 }
 
 // WithChildren implements the Node interface.
@@ -53,6 +53,7 @@ func (o Offset) String() string {
 
 type offsetIter struct {
 	skip      int64
+	skipped   int64
 	childIter sql.RowIter
 }
 
@@ -64,6 +65,7 @@ func (i *offsetIter) Next() (sql.Row, error) {
 				return nil, err
 			}
 			i.skip--
+			i.skipped++
 		}
 	}
 
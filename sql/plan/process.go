@@ -161,6 +161,7 @@ func (t *ProcessTable) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.Row
 
 type trackedRowIter struct {
 	iter   sql.RowIter
+	batch  sql.RowBatchIter
 	onDone NotifyFunc
 	onNext NotifyFunc
 }
@@ -188,6 +189,28 @@ func (i *trackedRowIter) Next() (sql.Row, error) {
 	return row, nil
 }
 
+func (i *trackedRowIter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.iter)
+	}
+
+	n, err := i.batch.NextBatch(rows)
+	if err != nil {
+		if err == io.EOF {
+			i.done()
+		}
+		return 0, err
+	}
+
+	if i.onNext != nil {
+		for j := 0; j < n; j++ {
+			i.onNext()
+		}
+	}
+
+	return n, nil
+}
+
 func (i *trackedRowIter) Close() error {
 	i.done()
 	return i.iter.Close()
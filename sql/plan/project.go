@@ -66,7 +66,7 @@ func (p *Project) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 		span.Finish()
 		return nil, err
 	}
-	return sql.NewSpanIter(span, &iter{p, i, ctx}), nil
+	return sql.NewSpanIter(span, &iter{p: p, childIter: i, ctx: ctx}), nil
 }
 
 func (p *Project) String() string {
@@ -107,6 +107,7 @@ type iter struct {
 	p         *Project
 	childIter sql.RowIter
 	ctx       *sql.Context
+	batch     sql.RowBatchIter
 }
 
 func (i *iter) Next() (sql.Row, error) {
@@ -117,6 +118,26 @@ func (i *iter) Next() (sql.Row, error) {
 	return filterRow(i.ctx, i.p.Projections, childRow)
 }
 
+func (i *iter) NextBatch(rows []sql.Row) (int, error) {
+	if i.batch == nil {
+		i.batch = sql.NewRowBatchIter(i.childIter)
+	}
+
+	n, err := i.batch.NextBatch(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	for j, row := range rows[:n] {
+		rows[j], err = filterRow(i.ctx, i.p.Projections, row)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
 func (i *iter) Close() error {
 	return i.childIter.Close()
 }
@@ -1,7 +1,6 @@
 package plan
 
 import (
-	"context"
 	"io"
 
 	"github.com/src-d/go-mysql-server/sql"
@@ -59,13 +58,12 @@ type tableIter struct {
 	partitions sql.PartitionIter
 	partition  sql.Partition
 	rows       sql.RowIter
+	batch      sql.RowBatchIter
 }
 
 func (i *tableIter) Next() (sql.Row, error) {
-	select {
-	case <-i.ctx.Done():
-		return nil, context.Canceled
-	default:
+	if err := i.ctx.CheckCanceled(); err != nil {
+		return nil, err
 	}
 
 	if i.partition == nil {
@@ -106,6 +104,52 @@ func (i *tableIter) Next() (sql.Row, error) {
 	return row, err
 }
 
+func (i *tableIter) NextBatch(rows []sql.Row) (int, error) {
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return 0, err
+		}
+
+		if i.partition == nil {
+			partition, err := i.partitions.Next()
+			if err != nil {
+				if err == io.EOF {
+					if e := i.partitions.Close(); e != nil {
+						return 0, e
+					}
+				}
+
+				return 0, err
+			}
+
+			i.partition = partition
+		}
+
+		if i.rows == nil {
+			iter, err := i.table.PartitionRows(i.ctx, i.partition)
+			if err != nil {
+				return 0, err
+			}
+
+			i.rows = iter
+			i.batch = sql.NewRowBatchIter(iter)
+		}
+
+		n, err := i.batch.NextBatch(rows)
+		if err != io.EOF {
+			return n, err
+		}
+
+		if err = i.rows.Close(); err != nil {
+			return 0, err
+		}
+
+		i.partition = nil
+		i.rows = nil
+		i.batch = nil
+	}
+}
+
 func (i *tableIter) Close() error {
 	if i.rows != nil {
 		if err := i.rows.Close(); err != nil {
@@ -0,0 +1,183 @@
+package plan
+
+import (
+	"io"
+	"os"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrDumpFileRows is returned when a query written to a file with INTO
+// DUMPFILE returns more than one row.
+var ErrDumpFileRows = errors.NewKind("the result of INTO DUMPFILE consisted of more than one row")
+
+// SelectIntoFile is a node writing the rows of a query to a file of the
+// server, with a line per row like SELECT ... INTO OUTFILE, or its single
+// row without any formatting like SELECT ... INTO DUMPFILE. The file is
+// created in the secure_file_priv directory of the catalog, and cannot
+// exist already.
+type SelectIntoFile struct {
+	UnaryNode
+	File string
+	// Dump is whether the file is written like INTO DUMPFILE.
+	Dump    bool
+	Format  LoadDataFormat
+	Catalog *sql.Catalog
+}
+
+// NewSelectIntoFile creates a SelectIntoFile node.
+func NewSelectIntoFile(query sql.Node, file string, dump bool, format LoadDataFormat) *SelectIntoFile {
+	return &SelectIntoFile{
+		UnaryNode: UnaryNode{Child: query},
+		File:      file,
+		Dump:      dump,
+		Format:    format,
+	}
+}
+
+// Schema implements the Node interface.
+func (p *SelectIntoFile) Schema() sql.Schema {
+	return sql.Schema{{
+		Name:     "updated",
+		Type:     sql.Int64,
+		Default:  int64(0),
+		Nullable: false,
+	}}
+}
+
+// RowIter implements the Node interface.
+func (p *SelectIntoFile) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.SelectIntoFile")
+	defer span.Finish()
+
+	n, err := p.Execute(ctx)
+	span.SetTag("rows", n)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.SetLastQueryInfo(sql.RowCount, int64(n))
+	return sql.RowsToRowIter(sql.NewRow(int64(n))), nil
+}
+
+// Execute writes the rows of the query to the file, returning the number
+// of rows written. The file is removed if they cannot be written.
+func (p *SelectIntoFile) Execute(ctx *sql.Context) (n int, err error) {
+	if !p.Dump {
+		if err := p.Format.validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	path, err := secureFilePath(p.Catalog, p.File, false)
+	if err != nil {
+		return 0, err
+	}
+
+	iter, err := p.Child.RowIter(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := iter.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	if p.Dump {
+		return p.dump(f, iter)
+	}
+
+	w := newLoadDataWriter(f, p.Format, p.Child.Schema())
+	for {
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+
+		if err := w.write(row); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, w.flush()
+}
+
+// dump writes the values of the single row of the query, if any, without
+// any separators.
+func (p *SelectIntoFile) dump(f *os.File, iter sql.RowIter) (int, error) {
+	row, err := iter.Next()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := iter.Next(); err != io.EOF {
+		if err == nil {
+			err = ErrDumpFileRows.New()
+		}
+		return 0, err
+	}
+
+	schema := p.Child.Schema()
+	for i, v := range row {
+		if v == nil {
+			continue
+		}
+
+		value, err := schema[i].Type.SQL(v)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := f.Write(value.ToBytes()); err != nil {
+			return 0, err
+		}
+	}
+
+	return 1, nil
+}
+
+// WithChildren implements the Node interface.
+func (p *SelectIntoFile) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
+	}
+
+	np := *p
+	np.Child = children[0]
+	return &np, nil
+}
+
+func (p *SelectIntoFile) String() string {
+	pr := sql.NewTreePrinter()
+	kind := "OUTFILE"
+	if p.Dump {
+		kind = "DUMPFILE"
+	}
+
+	_ = pr.WriteNode("SelectIntoFile(%s %s)", kind, p.File)
+	_ = pr.WriteChildren(p.Child.String())
+	return pr.String()
+}
@@ -0,0 +1,144 @@
+package plan
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDataWriter(t *testing.T) {
+	schema := sql.Schema{
+		{Name: "id", Type: sql.Int64, Nullable: true},
+		{Name: "name", Type: sql.Text, Nullable: true},
+	}
+	rows := []sql.Row{
+		{int64(1), "a\tb\nc"},
+		{nil, `\N`},
+		{int64(2), "x\x00,\"y\""},
+	}
+
+	csv := DefaultLoadDataFormat()
+	csv.FieldsTerminatedBy = ","
+	csv.FieldsEnclosedBy = `"`
+	csv.FieldsOptionallyEnclosed = true
+	csv.LinesTerminatedBy = "\r\n"
+
+	unescaped := DefaultLoadDataFormat()
+	unescaped.FieldsEscapedBy = ""
+	unescaped.LinesStartingBy = "> "
+
+	testCases := []struct {
+		name     string
+		format   LoadDataFormat
+		expected string
+	}{
+		{
+			"default",
+			DefaultLoadDataFormat(),
+			"1\ta\\\tb\\\nc\n\\N\t\\\\N\n2\tx\\0,\"y\"\n",
+		},
+		{
+			"enclosed",
+			csv,
+			"1,\"a\tb\nc\"\r\n\\N,\"\\\\N\"\r\n2,\"x\\0,\\\"y\\\"\"\r\n",
+		},
+		{
+			"unescaped",
+			unescaped,
+			"> 1\ta\tb\nc\n> NULL\t\\N\n> 2\tx\x00,\"y\"\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var buf bytes.Buffer
+			w := newLoadDataWriter(&buf, tt.format, schema)
+			for _, row := range rows {
+				require.NoError(w.write(row))
+			}
+			require.NoError(w.flush())
+			require.Equal(tt.expected, buf.String())
+
+			if tt.format.FieldsEscapedBy == "" {
+				return
+			}
+
+			// The escaped files are read back as they were written.
+			r := newLoadDataReader(&buf, tt.format)
+			for _, expected := range [][]interface{}{
+				{"1", "a\tb\nc"},
+				{nil, `\N`},
+				{"2", "x\x00,\"y\""},
+			} {
+				fields, err := r.next()
+				require.NoError(err)
+				require.Equal(expected, fields)
+			}
+		})
+	}
+}
+
+func TestSelectIntoFile(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "select-into")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "id", Type: sql.Int64, Source: "t"},
+		{Name: "data", Type: sql.Blob, Source: "t", Nullable: true},
+	})
+	ctx := sql.NewEmptyContext()
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(1), []byte("foo"))))
+
+	catalog := sql.NewCatalog()
+	catalog.SetSecureFilePriv(dir)
+	write := func(file string, dump bool) (int, error) {
+		p := NewSelectIntoFile(NewResolvedTable(table), file, dump, DefaultLoadDataFormat())
+		p.Catalog = catalog
+		return p.Execute(ctx)
+	}
+
+	n, err := write("out.txt", false)
+	require.NoError(err)
+	require.Equal(1, n)
+
+	n, err = write("out.bin", true)
+	require.NoError(err)
+	require.Equal(1, n)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(err)
+	require.Equal("1\tfoo\n", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "out.bin"))
+	require.NoError(err)
+	require.Equal("1foo", string(data))
+
+	// Files are never overwritten.
+	_, err = write("out.txt", false)
+	require.True(os.IsExist(err))
+
+	// The file is not left behind if the rows cannot be written.
+	require.NoError(table.Insert(ctx, sql.NewRow(int64(2), nil)))
+	_, err = write("two.bin", true)
+	require.True(ErrDumpFileRows.Is(err))
+	_, err = os.Stat(filepath.Join(dir, "two.bin"))
+	require.True(os.IsNotExist(err))
+
+	_, err = write("../out.txt", false)
+	require.True(ErrSecureFilePriv.Is(err))
+
+	p := NewSelectIntoFile(NewResolvedTable(table), "new.txt", false, DefaultLoadDataFormat())
+	_, err = p.Execute(ctx)
+	require.True(ErrSecureFilePriv.Is(err))
+}
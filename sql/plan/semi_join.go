@@ -0,0 +1,394 @@
+package plan
+
+import (
+	"io"
+	"reflect"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+)
+
+// SemiJoin is a join that returns the rows of its left side matching at
+// least one row of its right side, each of them once and only with the
+// columns of the left side.
+type SemiJoin struct {
+	BinaryNode
+	Cond sql.Expression
+}
+
+// NewSemiJoin creates a new semi join node from two tables.
+func NewSemiJoin(left, right sql.Node, cond sql.Expression) *SemiJoin {
+	return &SemiJoin{
+		BinaryNode: BinaryNode{
+			Left:  left,
+			Right: right,
+		},
+		Cond: cond,
+	}
+}
+
+// Schema implements the Node interface.
+func (j *SemiJoin) Schema() sql.Schema {
+	return j.Left.Schema()
+}
+
+// Resolved implements the Resolvable interface.
+func (j *SemiJoin) Resolved() bool {
+	return j.Left.Resolved() && j.Right.Resolved() && j.Cond.Resolved()
+}
+
+// RowIter implements the Node interface.
+func (j *SemiJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	return semiJoinRowIter(ctx, "plan.SemiJoin", false, j.Left, j.Right, j.Cond)
+}
+
+// WithChildren implements the Node interface.
+func (j *SemiJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 2)
+	}
+
+	return NewSemiJoin(children[0], children[1], j.Cond), nil
+}
+
+// WithExpressions implements the Expressioner interface.
+func (j *SemiJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
+	if len(exprs) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), 1)
+	}
+
+	return NewSemiJoin(j.Left, j.Right, exprs[0]), nil
+}
+
+// Expressions implements the Expressioner interface.
+func (j *SemiJoin) Expressions() []sql.Expression {
+	return []sql.Expression{j.Cond}
+}
+
+func (j *SemiJoin) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("SemiJoin(%s)", j.Cond)
+	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
+	return pr.String()
+}
+
+// AntiJoin is a join that returns the rows of its left side not matching
+// any row of its right side, only with the columns of the left side.
+type AntiJoin struct {
+	BinaryNode
+	Cond sql.Expression
+}
+
+// NewAntiJoin creates a new anti join node from two tables.
+func NewAntiJoin(left, right sql.Node, cond sql.Expression) *AntiJoin {
+	return &AntiJoin{
+		BinaryNode: BinaryNode{
+			Left:  left,
+			Right: right,
+		},
+		Cond: cond,
+	}
+}
+
+// Schema implements the Node interface.
+func (j *AntiJoin) Schema() sql.Schema {
+	return j.Left.Schema()
+}
+
+// Resolved implements the Resolvable interface.
+func (j *AntiJoin) Resolved() bool {
+	return j.Left.Resolved() && j.Right.Resolved() && j.Cond.Resolved()
+}
+
+// RowIter implements the Node interface.
+func (j *AntiJoin) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	return semiJoinRowIter(ctx, "plan.AntiJoin", true, j.Left, j.Right, j.Cond)
+}
+
+// WithChildren implements the Node interface.
+func (j *AntiJoin) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 2 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(children), 2)
+	}
+
+	return NewAntiJoin(children[0], children[1], j.Cond), nil
+}
+
+// WithExpressions implements the Expressioner interface.
+func (j *AntiJoin) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
+	if len(exprs) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(j, len(exprs), 1)
+	}
+
+	return NewAntiJoin(j.Left, j.Right, exprs[0]), nil
+}
+
+// Expressions implements the Expressioner interface.
+func (j *AntiJoin) Expressions() []sql.Expression {
+	return []sql.Expression{j.Cond}
+}
+
+func (j *AntiJoin) String() string {
+	pr := sql.NewTreePrinter()
+	_ = pr.WriteNode("AntiJoin(%s)", j.Cond)
+	_ = pr.WriteChildren(j.Left.String(), j.Right.String())
+	return pr.String()
+}
+
+func semiJoinRowIter(
+	ctx *sql.Context,
+	name string,
+	anti bool,
+	left, right sql.Node,
+	cond sql.Expression,
+) (sql.RowIter, error) {
+	var leftName, rightName string
+	if leftTable, ok := left.(sql.Nameable); ok {
+		leftName = leftTable.Name()
+	} else {
+		leftName = reflect.TypeOf(left).String()
+	}
+
+	if rightTable, ok := right.(sql.Nameable); ok {
+		rightName = rightTable.Name()
+	} else {
+		rightName = reflect.TypeOf(right).String()
+	}
+
+	span, ctx := ctx.Span(name, opentracing.Tags{
+		"left":  leftName,
+		"right": rightName,
+	})
+
+	l, err := left.RowIter(ctx)
+	if err != nil {
+		span.Finish()
+		return nil, err
+	}
+
+	iter := &semiJoinIter{
+		anti:    anti,
+		left:    l,
+		right:   right,
+		leftLen: len(left.Schema()),
+		ctx:     ctx,
+		cond:    cond,
+		memory:  ctx.QueryMemory().NewAccount(),
+	}
+	iter.leftKey, iter.rightKey = semiJoinKeys(cond, iter.leftLen)
+
+	return sql.NewSpanIter(span, iter), nil
+}
+
+// semiJoinKeys returns the expressions of the left and right side of the
+// condition, or of one of its conjunctions, when it's an equality of values
+// of the same type from each side, so the rows of the right side can be
+// looked up by their hash instead of evaluating the condition with all of
+// them.
+func semiJoinKeys(cond sql.Expression, leftLen int) (left, right sql.Expression) {
+	if and, ok := cond.(*expression.And); ok {
+		if left, right = semiJoinKeys(and.Left, leftLen); left != nil {
+			return left, right
+		}
+		return semiJoinKeys(and.Right, leftLen)
+	}
+
+	eq, ok := cond.(*expression.Equals)
+	if !ok || eq.Left().Type() != eq.Right().Type() {
+		return nil, nil
+	}
+
+	left, right = eq.Left(), eq.Right()
+	switch {
+	case usesOnlyFields(left, 0, leftLen) && usesOnlyFields(right, leftLen, -1):
+		return left, right
+	case usesOnlyFields(right, 0, leftLen) && usesOnlyFields(left, leftLen, -1):
+		return right, left
+	default:
+		return nil, nil
+	}
+}
+
+// usesOnlyFields reports whether the expression uses fields and all of them
+// have an index between from, inclusive, and to, exclusive, or above from if
+// to is negative.
+func usesOnlyFields(e sql.Expression, from, to int) bool {
+	var hasFields, ok = false, true
+	expression.Inspect(e, func(e sql.Expression) bool {
+		switch e := e.(type) {
+		case *expression.GetField:
+			hasFields = true
+			if e.Index() < from || (to >= 0 && e.Index() >= to) {
+				ok = false
+			}
+		case *expression.Subquery:
+			ok = false
+		}
+		return ok
+	})
+	return hasFields && ok
+}
+
+type semiJoinIter struct {
+	anti    bool
+	left    sql.RowIter
+	right   sql.Node
+	leftLen int
+	ctx     *sql.Context
+	cond    sql.Expression
+	memory  *sql.MemoryAccount
+
+	leftKey, rightKey sql.Expression
+
+	loaded    bool
+	rightRows []sql.Row
+	// rowsByKey contains the rows of the right side by the hash of their
+	// key, when the condition is an equality of keys.
+	rowsByKey map[uint64][]sql.Row
+}
+
+func (i *semiJoinIter) loadRight() error {
+	iter, err := i.right.RowIter(i.ctx)
+	if err != nil {
+		return err
+	}
+
+	if i.rightKey != nil {
+		i.rowsByKey = make(map[uint64][]sql.Row)
+	}
+
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			_ = iter.Close()
+			return err
+		}
+
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			_ = iter.Close()
+			return err
+		}
+
+		if err := i.memory.Reserve(estimateRowSize(row)); err != nil {
+			_ = iter.Close()
+			return err
+		}
+
+		if i.rightKey == nil {
+			i.rightRows = append(i.rightRows, row)
+			continue
+		}
+
+		// The key of the right side is evaluated with the row in the
+		// position it has in the rows the condition is evaluated with.
+		key, ok, err := i.hashKey(i.rightKey, append(make(sql.Row, i.leftLen), row...))
+		if err != nil {
+			_ = iter.Close()
+			return err
+		}
+
+		if ok {
+			i.rowsByKey[key] = append(i.rowsByKey[key], row)
+		}
+	}
+
+	i.loaded = true
+	return iter.Close()
+}
+
+// hashKey returns the hash of the key evaluated with the given row, or
+// false if it's null, as it cannot be equal to any other key.
+func (i *semiJoinIter) hashKey(key sql.Expression, row sql.Row) (uint64, bool, error) {
+	v, err := key.Eval(i.ctx, row)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if v == nil {
+		return 0, false, nil
+	}
+
+	v, err = key.Type().Convert(v)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return sql.CacheKey(v), true, nil
+}
+
+// candidates returns the rows of the right side that may match the given
+// row of the left side.
+func (i *semiJoinIter) candidates(row sql.Row) ([]sql.Row, error) {
+	if i.leftKey == nil {
+		return i.rightRows, nil
+	}
+
+	key, ok, err := i.hashKey(i.leftKey, row)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return i.rowsByKey[key], nil
+}
+
+func (i *semiJoinIter) matches(row sql.Row) (bool, error) {
+	candidates, err := i.candidates(row)
+	if err != nil {
+		return false, err
+	}
+
+	var joined = make(sql.Row, i.leftLen, i.leftLen+len(i.right.Schema()))
+	copy(joined, row)
+	for _, r := range candidates {
+		matches, err := sql.EvaluateCondition(i.ctx, i.cond, append(joined[:i.leftLen], r...))
+		if err != nil {
+			return false, err
+		}
+
+		if matches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (i *semiJoinIter) Next() (sql.Row, error) {
+	if !i.loaded {
+		if err := i.loadRight(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return nil, err
+		}
+
+		row, err := i.left.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		match, err := i.matches(row)
+		if err != nil {
+			return nil, err
+		}
+
+		if match != i.anti {
+			return row, nil
+		}
+	}
+}
+
+func (i *semiJoinIter) Close() error {
+	i.rightRows = nil
+	i.rowsByKey = nil
+	i.memory.ReleaseAll()
+	return i.left.Close()
+}
@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemiJoin(t *testing.T) {
+	ltable := memory.NewTable("left", lSchema)
+	rtable := memory.NewTable("right", rSchema)
+	insertData(t, ltable)
+	require.NoError(t, rtable.Insert(
+		sql.NewEmptyContext(),
+		sql.NewRow("col1_3", "col2_3", int32(5), int64(4)),
+	))
+
+	emptyTable := memory.NewTable("empty", rSchema)
+
+	// lcol4 = rcol4
+	keysEqual := expression.NewEquals(
+		expression.NewGetFieldWithTable(3, sql.Int64, "left", "lcol4", false),
+		expression.NewGetFieldWithTable(7, sql.Int64, "right", "rcol4", false),
+	)
+	// lcol3 < rcol3
+	lessThan := expression.NewLessThan(
+		expression.NewGetFieldWithTable(2, sql.Int32, "left", "lcol3", false),
+		expression.NewGetFieldWithTable(6, sql.Int32, "right", "rcol3", false),
+	)
+
+	first := sql.NewRow("col1_1", "col2_1", int32(1), int64(2))
+	second := sql.NewRow("col1_2", "col2_2", int32(3), int64(4))
+
+	testCases := []struct {
+		name     string
+		node     sql.Node
+		expected []sql.Row
+	}{
+		{
+			"semi join with equal keys",
+			NewSemiJoin(NewResolvedTable(ltable), NewResolvedTable(rtable), keysEqual),
+			[]sql.Row{second},
+		},
+		{
+			"anti join with equal keys",
+			NewAntiJoin(NewResolvedTable(ltable), NewResolvedTable(rtable), keysEqual),
+			[]sql.Row{first},
+		},
+		{
+			"semi join with equal keys and other condition",
+			NewSemiJoin(
+				NewResolvedTable(ltable),
+				NewResolvedTable(rtable),
+				expression.NewAnd(lessThan, keysEqual),
+			),
+			[]sql.Row{second},
+		},
+		{
+			"semi join with other condition",
+			NewSemiJoin(NewResolvedTable(ltable), NewResolvedTable(rtable), lessThan),
+			[]sql.Row{first, second},
+		},
+		{
+			"semi join always true",
+			NewSemiJoin(
+				NewResolvedTable(ltable),
+				NewResolvedTable(rtable),
+				expression.NewLiteral(true, sql.Boolean),
+			),
+			[]sql.Row{first, second},
+		},
+		{
+			"semi join with empty right side",
+			NewSemiJoin(
+				NewResolvedTable(ltable),
+				NewResolvedTable(emptyTable),
+				expression.NewLiteral(true, sql.Boolean),
+			),
+			nil,
+		},
+		{
+			"anti join with empty right side",
+			NewAntiJoin(NewResolvedTable(ltable), NewResolvedTable(emptyTable), keysEqual),
+			[]sql.Row{first, second},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			require.Equal(lSchema, tt.node.Schema())
+			require.Equal(tt.expected, collectRows(t, tt.node))
+		})
+	}
+}
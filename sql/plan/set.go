@@ -10,8 +10,11 @@ import (
 )
 
 // Set configuration variables. Right now, only session variables are supported.
+// The time zone is the only global variable, which is the one of the
+// catalog, used as the time zone of the new sessions.
 type Set struct {
 	Variables []SetVariable
+	Catalog   *sql.Catalog
 }
 
 // SetVariable is a key-value pair to represent the value that will be set on
@@ -23,7 +26,7 @@ type SetVariable struct {
 
 // NewSet creates a new Set node.
 func NewSet(vars ...SetVariable) *Set {
-	return &Set{vars}
+	return &Set{Variables: vars}
 }
 
 // Resolved implements the sql.Node interface.
@@ -65,7 +68,9 @@ func (s *Set) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
 		}
 	}
 
-	return NewSet(vars...), nil
+	ns := NewSet(vars...)
+	ns.Catalog = s.Catalog
+	return ns, nil
 }
 
 // Expressions implements the sql.Expressioner interface.
@@ -93,10 +98,9 @@ func (s *Set) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 			err   error
 		)
 
-		name := strings.TrimPrefix(
-			strings.TrimPrefix(strings.TrimLeft(v.Name, "@"), sessionPrefix),
-			globalPrefix,
-		)
+		name := strings.TrimPrefix(strings.TrimLeft(v.Name, "@"), sessionPrefix)
+		global := strings.HasPrefix(name, globalPrefix)
+		name = strings.TrimPrefix(name, globalPrefix)
 
 		if _, ok := v.Value.(*expression.DefaultColumn); ok {
 			valtyp, ok := sql.DefaultSessionConfig()[name]
@@ -104,6 +108,9 @@ func (s *Set) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 				continue
 			}
 			value, typ = valtyp.Value, valtyp.Typ
+			if name == sql.TimeZoneVariable && !global && s.Catalog != nil {
+				value = s.Catalog.TimeZone()
+			}
 		} else {
 			value, err = v.Value.Eval(ctx, nil)
 			if err != nil {
@@ -112,6 +119,20 @@ func (s *Set) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 			typ = v.Value.Type()
 		}
 
+		if name == sql.TimeZoneVariable {
+			tz, ok := value.(string)
+			if _, err := sql.LoadTimeZone(tz); !ok || err != nil {
+				return nil, sql.ErrUnknownTimeZone.New(value)
+			}
+
+			if global && s.Catalog != nil {
+				if err := s.Catalog.SetTimeZone(tz); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
 		ctx.Set(name, typ, value)
 	}
 
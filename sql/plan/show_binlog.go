@@ -0,0 +1,137 @@
+package plan
+
+import (
+	"github.com/src-d/go-mysql-server/sql"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrBinaryLogDisabled is returned when the binary log is shown and the
+// catalog does not have one.
+var ErrBinaryLogDisabled = errors.NewKind("You are not using binary logging")
+
+// binaryLog returns the binary log of the catalog, if it has one.
+func binaryLog(c *sql.Catalog) (sql.BinaryLog, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	log, ok := c.ChangeRecorder().(sql.BinaryLog)
+	return log, ok
+}
+
+// ShowMasterStatus is a node that shows the position of the binary log.
+type ShowMasterStatus struct {
+	Catalog *sql.Catalog
+}
+
+// NewShowMasterStatus creates a new ShowMasterStatus node.
+func NewShowMasterStatus() *ShowMasterStatus {
+	return new(ShowMasterStatus)
+}
+
+// Resolved implements the Resolvable interface.
+func (*ShowMasterStatus) Resolved() bool {
+	return true
+}
+
+// Children implements the Node interface.
+func (*ShowMasterStatus) Children() []sql.Node {
+	return nil
+}
+
+// Schema implements the Node interface.
+func (*ShowMasterStatus) Schema() sql.Schema {
+	return sql.Schema{
+		{Name: "File", Type: sql.Text},
+		{Name: "Position", Type: sql.Uint64},
+		{Name: "Binlog_Do_DB", Type: sql.Text},
+		{Name: "Binlog_Ignore_DB", Type: sql.Text},
+		{Name: "Executed_Gtid_Set", Type: sql.Text},
+	}
+}
+
+// RowIter implements the Node interface. Without a binary log, it returns
+// no rows, as MySQL does.
+func (p *ShowMasterStatus) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	log, ok := binaryLog(p.Catalog)
+	if !ok {
+		return sql.RowsToRowIter(), nil
+	}
+
+	status := log.Status()
+	return sql.RowsToRowIter(sql.NewRow(
+		status.File,
+		status.Position,
+		"",
+		"",
+		status.ExecutedGtidSet,
+	)), nil
+}
+
+// WithChildren implements the Node interface.
+func (p *ShowMasterStatus) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 0)
+	}
+
+	return p, nil
+}
+
+func (*ShowMasterStatus) String() string {
+	return "ShowMasterStatus"
+}
+
+// ShowBinaryLogs is a node that shows the files of the binary log.
+type ShowBinaryLogs struct {
+	Catalog *sql.Catalog
+}
+
+// NewShowBinaryLogs creates a new ShowBinaryLogs node.
+func NewShowBinaryLogs() *ShowBinaryLogs {
+	return new(ShowBinaryLogs)
+}
+
+// Resolved implements the Resolvable interface.
+func (*ShowBinaryLogs) Resolved() bool {
+	return true
+}
+
+// Children implements the Node interface.
+func (*ShowBinaryLogs) Children() []sql.Node {
+	return nil
+}
+
+// Schema implements the Node interface.
+func (*ShowBinaryLogs) Schema() sql.Schema {
+	return sql.Schema{
+		{Name: "Log_name", Type: sql.Text},
+		{Name: "File_size", Type: sql.Uint64},
+	}
+}
+
+// RowIter implements the Node interface.
+func (p *ShowBinaryLogs) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	log, ok := binaryLog(p.Catalog)
+	if !ok {
+		return nil, ErrBinaryLogDisabled.New()
+	}
+
+	var rows []sql.Row
+	for _, f := range log.Files() {
+		rows = append(rows, sql.NewRow(f.Name, f.Size))
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// WithChildren implements the Node interface.
+func (p *ShowBinaryLogs) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 0)
+	}
+
+	return p, nil
+}
+
+func (*ShowBinaryLogs) String() string {
+	return "ShowBinaryLogs"
+}
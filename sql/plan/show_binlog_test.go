@@ -0,0 +1,52 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+type testBinaryLog struct{ changeRecorder }
+
+func (testBinaryLog) Status() sql.BinlogStatus {
+	return sql.BinlogStatus{File: "log.000002", Position: 42, ExecutedGtidSet: "uuid:1-3"}
+}
+
+func (testBinaryLog) Files() []sql.BinlogFile {
+	return []sql.BinlogFile{{Name: "log.000001", Size: 100}, {Name: "log.000002", Size: 42}}
+}
+
+func TestShowBinaryLog(t *testing.T) {
+	require := require.New(t)
+
+	ctx := sql.NewEmptyContext()
+	catalog := sql.NewCatalog()
+
+	status := NewShowMasterStatus()
+	status.Catalog = catalog
+	iter, err := status.RowIter(ctx)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Len(rows, 0)
+
+	logs := NewShowBinaryLogs()
+	logs.Catalog = catalog
+	_, err = logs.RowIter(ctx)
+	require.True(ErrBinaryLogDisabled.Is(err))
+
+	catalog.SetChangeRecorder(new(testBinaryLog))
+
+	iter, err = status.RowIter(ctx)
+	require.NoError(err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"log.000002", uint64(42), "", "", "uuid:1-3"}}, rows)
+
+	iter, err = logs.RowIter(ctx)
+	require.NoError(err)
+	rows, err = sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal([]sql.Row{{"log.000001", uint64(100)}, {"log.000002", uint64(42)}}, rows)
+}
@@ -35,8 +35,8 @@ func (n *ShowCreateTable) WithChildren(children ...sql.Node) (sql.Node, error) {
 }
 
 // RowIter implements the Node interface
-func (n *ShowCreateTable) RowIter(*sql.Context) (sql.RowIter, error) {
-	db, err := n.Catalog.Database(n.CurrentDatabase)
+func (n *ShowCreateTable) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	db, err := n.Catalog.ResolveDatabase(ctx, n.CurrentDatabase)
 	if err != nil {
 		return nil, err
 	}
@@ -88,19 +88,24 @@ func (i *showCreateTablesIter) Next() (sql.Row, error) {
 func produceCreateStatement(table sql.Table) string {
 	schema := table.Schema()
 	colStmts := make([]string, len(schema))
+	var primaryKey []string
 
 	// Statement creation parts for each column
 	for i, col := range schema {
-		stmt := fmt.Sprintf("  `%s` %s", col.Name, strings.ToLower(sql.MySQLTypeName(col.Type)))
+		stmt := fmt.Sprintf("  %s %s", quoteIdentifier(col.Name), strings.ToLower(sql.MySQLTypeName(col.Type)))
 
 		if !col.Nullable {
 			stmt = fmt.Sprintf("%s NOT NULL", stmt)
 		}
 
+		if col.AutoIncrement {
+			stmt = fmt.Sprintf("%s AUTO_INCREMENT", stmt)
+		}
+
 		switch def := col.Default.(type) {
 		case string:
 			if def != "" {
-				stmt = fmt.Sprintf("%s DEFAULT %q", stmt, def)
+				stmt = fmt.Sprintf("%s DEFAULT %s", stmt, quoteString(def))
 			}
 		default:
 			if def != nil {
@@ -109,11 +114,18 @@ func produceCreateStatement(table sql.Table) string {
 		}
 
 		colStmts[i] = stmt
+		if col.PrimaryKey {
+			primaryKey = append(primaryKey, quoteIdentifier(col.Name))
+		}
+	}
+
+	if len(primaryKey) > 0 {
+		colStmts = append(colStmts, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(primaryKey, ",")))
 	}
 
 	return fmt.Sprintf(
-		"CREATE TABLE `%s` (\n%s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
-		table.Name(),
+		"CREATE TABLE %s (\n%s\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		quoteIdentifier(table.Name()),
 		strings.Join(colStmts, ",\n"),
 	)
 }
@@ -49,3 +49,24 @@ func TestShowCreateTable(t *testing.T) {
 
 	require.Equal(expected, row)
 }
+
+func TestShowCreateTablePrimaryKey(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable(
+		"t",
+		sql.Schema{
+			&sql.Column{Name: "a", Type: sql.Int24, PrimaryKey: true},
+			&sql.Column{Name: "b", Type: sql.Text, PrimaryKey: true},
+			&sql.Column{Name: "c", Type: sql.Text, Default: "it's", Nullable: true},
+		})
+
+	require.Equal(
+		"CREATE TABLE `t` (\n  `a` mediumint NOT NULL,\n"+
+			"  `b` text NOT NULL,\n"+
+			"  `c` text DEFAULT 'it\\'s',\n"+
+			"  PRIMARY KEY (`a`,`b`)\n"+
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		produceCreateStatement(table),
+	)
+}
@@ -113,6 +113,7 @@ func (i *showIndexesIter) Next() (sql.Row, error) {
 	var (
 		nullable string
 		visible  string
+		comment  string
 	)
 	columnName, expression := "NULL", show.expression
 	if ok, null := isColumn(show.expression, i.db.Tables()[i.table]); ok {
@@ -126,6 +127,9 @@ func (i *showIndexesIter) Next() (sql.Row, error) {
 	} else {
 		visible = "NO"
 	}
+	if progress, ok := i.registry.IndexProgress(show.index); ok {
+		comment = "building: " + progress.String()
+	}
 	return sql.NewRow(
 		i.table,             // "Table" string
 		int32(1),            // "Non_unique" int32, Values [0, 1]
@@ -138,7 +142,7 @@ func (i *showIndexesIter) Next() (sql.Row, error) {
 		"NULL",              // "Packed" string
 		nullable,            // "Null" string, Values [YES, '']
 		show.index.Driver(), // "Index_type" string
-		"",                  // "Comment" string
+		comment,             // "Comment" string, the progress of indexes being created
 		"",                  // "Index_comment" string
 		visible,             // "Visible" string, Values [YES, NO]
 		expression,          // "Expression" string
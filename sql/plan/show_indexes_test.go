@@ -139,3 +139,45 @@ func TestShowIndexes(t *testing.T) {
 		})
 	}
 }
+
+func TestShowIndexesProgress(t *testing.T) {
+	require := require.New(t)
+
+	db := memory.NewDatabase("test")
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+	})
+	db.AddTable("t", table)
+
+	r := sql.NewIndexRegistry()
+	idx := &mockIndex{
+		db:    "test",
+		table: "t",
+		id:    "t_idx",
+		exprs: []sql.Expression{expression.NewGetFieldWithTable(0, sql.Int64, "t", "a", false)},
+	}
+
+	created, ready, err := r.AddIndex(idx)
+	require.NoError(err)
+	r.SetIndexProgress(idx, sql.IndexProgress{Rows: 10000, Total: 25000})
+
+	comments := func() []interface{} {
+		iter, err := NewShowIndexes(db, "t", r).RowIter(sql.NewEmptyContext())
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+
+		var result []interface{}
+		for _, row := range rows {
+			result = append(result, row[11], row[13])
+		}
+		return result
+	}
+
+	require.Equal([]interface{}{"building: 10000 of 25000 rows indexed", "NO"}, comments())
+
+	close(created)
+	<-ready
+
+	require.Equal([]interface{}{"", "YES"}, comments())
+}
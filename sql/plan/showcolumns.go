@@ -75,6 +75,11 @@ func (s *ShowColumns) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 			defaultVal = fmt.Sprint(col.Default)
 		}
 
+		var extra string
+		if col.AutoIncrement {
+			extra = "auto_increment"
+		}
+
 		if s.Full {
 			row = sql.Row{
 				col.Name,
@@ -83,7 +88,7 @@ func (s *ShowColumns) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 				null,
 				"", // Key
 				defaultVal,
-				"", // Extra
+				extra,
 				"", // Privileges
 				"", // Comment
 			}
@@ -94,7 +99,7 @@ func (s *ShowColumns) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 				null,
 				"", // Key
 				defaultVal,
-				"", // Extra
+				extra,
 			}
 		}
 
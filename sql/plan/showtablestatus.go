@@ -64,7 +64,7 @@ func (s *ShowTableStatus) RowIter(ctx *sql.Context) (sql.RowIter, error) {
 			}
 		}
 	} else {
-		db, err := s.Catalog.Database(s.Catalog.CurrentDatabase())
+		db, err := s.Catalog.ResolveDatabase(ctx, s.Catalog.CurrentDatabase())
 		if err != nil {
 			return nil, err
 		}
@@ -13,6 +13,16 @@ import (
 // ErrUnableSort is thrown when something happens on sorting
 var ErrUnableSort = errors.NewKind("unable to sort")
 
+const (
+	sortBufferSizeKey        = "SORT_BUFFER_SIZE"
+	sortBufferSizeSessionVar = "sort_buffer_size"
+)
+
+// sortBufferSize is the estimated number of bytes of rows a Sort node keeps
+// in memory before spilling them to disk. If it's zero, rows are only
+// spilled when there is no memory available.
+var sortBufferSize = bufferSizeByEnv(sortBufferSizeKey)
+
 // Sort is the sort node.
 type Sort struct {
 	UnaryNode
@@ -139,11 +149,12 @@ func (s *Sort) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
 }
 
 type sortIter struct {
-	ctx        *sql.Context
-	s          *Sort
-	childIter  sql.RowIter
-	sortedRows []sql.Row
-	idx        int
+	ctx       *sql.Context
+	s         *Sort
+	childIter sql.RowIter
+	sorted    sql.RowIter
+	runs      []*spillFile
+	memory    *sql.MemoryAccount
 }
 
 func newSortIter(ctx *sql.Context, s *Sort, child sql.RowIter) *sortIter {
@@ -151,37 +162,53 @@ func newSortIter(ctx *sql.Context, s *Sort, child sql.RowIter) *sortIter {
 		ctx:       ctx,
 		s:         s,
 		childIter: child,
-		idx:       -1,
+		memory:    ctx.QueryMemory().NewAccount(),
 	}
 }
 
 func (i *sortIter) Next() (sql.Row, error) {
-	if i.idx == -1 {
-		err := i.computeSortedRows()
-		if err != nil {
+	if i.sorted == nil {
+		if err := i.computeSortedRows(); err != nil {
 			return nil, err
 		}
-		i.idx = 0
 	}
 
-	if i.idx >= len(i.sortedRows) {
-		return nil, io.EOF
-	}
-	row := i.sortedRows[i.idx]
-	i.idx++
-	return row, nil
+	return i.sorted.Next()
 }
 
 func (i *sortIter) Close() error {
-	i.sortedRows = nil
-	return i.childIter.Close()
+	var err error
+	if i.sorted != nil {
+		err = i.sorted.Close()
+	}
+
+	if rerr := i.removeRuns(i.runs...); err == nil {
+		err = rerr
+	}
+	i.runs = nil
+	i.memory.ReleaseAll()
+
+	if cerr := i.childIter.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
+// computeSortedRows reads all the rows of the child. Rows are kept in memory
+// until their estimated size exceeds the sort buffer size, the memory of the
+// query is exceeded or there is no more memory available, in which case
+// they are sorted and spilled to a temporary file. The spilled runs are
+// merged with the rows left in memory once all of them have been read.
 func (i *sortIter) computeSortedRows() error {
-	cache, dispose := i.ctx.Memory.NewRowsCache()
-	defer dispose()
+	bufferSize := bufferSizeFor(i.ctx, sortBufferSizeSessionVar, sortBufferSize)
 
+	var rows []sql.Row
+	var size uint64
 	for {
+		if err := i.ctx.CheckCanceled(); err != nil {
+			return err
+		}
+
 		row, err := i.childIter.Next()
 		if err == io.EOF {
 			break
@@ -190,25 +217,128 @@ func (i *sortIter) computeSortedRows() error {
 			return err
 		}
 
-		if err := cache.Add(row); err != nil {
+		rowSize := estimateRowSize(row)
+		err = i.memory.Reserve(rowSize)
+		if err != nil && !sql.IsMemoryExceeded(err) {
 			return err
 		}
+		queryExceeded := err != nil
+
+		rows = append(rows, row)
+		size += rowSize
+
+		exceeded := bufferSize > 0 && size >= bufferSize
+		noMemory := len(rows) >= minSortRunRows && !i.ctx.Memory.HasAvailable()
+		if exceeded || noMemory || queryExceeded {
+			if err := i.spill(rows); err != nil {
+				return err
+			}
+			rows, size = nil, 0
+			i.memory.ReleaseAll()
+		}
+	}
+
+	if err := i.sortRows(rows); err != nil {
+		return err
 	}
 
-	rows := cache.Get()
+	if len(i.runs) == 0 {
+		i.sorted = sql.RowsToRowIter(rows...)
+		return nil
+	}
+
+	if err := i.reduceRuns(); err != nil {
+		return err
+	}
+
+	iters, err := i.runIters(i.runs)
+	if err != nil {
+		return err
+	}
+
+	i.sorted = newSortMergeIter(i.ctx, i.s.SortFields, append(iters, sql.RowsToRowIter(rows...)))
+	return nil
+}
+
+func (i *sortIter) sortRows(rows []sql.Row) error {
 	sorter := &sorter{
 		sortFields: i.s.SortFields,
 		rows:       rows,
 		lastError:  nil,
+		ctx:        i.ctx,
 	}
 	sort.Stable(sorter)
-	if sorter.lastError != nil {
-		return sorter.lastError
+	return sorter.lastError
+}
+
+// spill sorts the given rows and writes them to a new run.
+func (i *sortIter) spill(rows []sql.Row) error {
+	if err := i.sortRows(rows); err != nil {
+		return err
 	}
-	i.sortedRows = rows
+
+	run, err := writeSpillFile("sort", sql.RowsToRowIter(rows...))
+	if err != nil {
+		return err
+	}
+
+	i.runs = append(i.runs, run)
 	return nil
 }
 
+// reduceRuns merges the first runs into a single one until there are no
+// more than maxSortMergeRuns runs, so the number of files open at the same
+// time is bounded. Runs are always merged in the order they were written
+// to keep the sort stable.
+func (i *sortIter) reduceRuns() error {
+	for len(i.runs) > maxSortMergeRuns {
+		group := i.runs[:maxSortMergeRuns]
+		iters, err := i.runIters(group)
+		if err != nil {
+			return err
+		}
+
+		merged, err := writeSpillFile("sort", newSortMergeIter(i.ctx, i.s.SortFields, iters))
+		if err != nil {
+			return err
+		}
+
+		if err := i.removeRuns(group...); err != nil {
+			_ = merged.remove()
+			return err
+		}
+
+		i.runs = append([]*spillFile{merged}, i.runs[maxSortMergeRuns:]...)
+	}
+
+	return nil
+}
+
+func (i *sortIter) runIters(runs []*spillFile) ([]sql.RowIter, error) {
+	var iters = make([]sql.RowIter, len(runs))
+	for idx, r := range runs {
+		iter, err := r.iter()
+		if err != nil {
+			for _, it := range iters[:idx] {
+				_ = it.Close()
+			}
+			return nil, err
+		}
+		iters[idx] = iter
+	}
+	return iters, nil
+}
+
+func (i *sortIter) removeRuns(runs ...*spillFile) error {
+	var err error
+	for _, r := range runs {
+		if rerr := r.remove(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
 type sorter struct {
 	sortFields []SortField
 	rows       []sql.Row
@@ -229,28 +359,39 @@ func (s *sorter) Less(i, j int) bool {
 		return false
 	}
 
-	a := s.rows[i]
-	b := s.rows[j]
-	for _, sf := range s.sortFields {
+	less, err := lessRows(s.ctx, s.sortFields, s.rows[i], s.rows[j])
+	if err != nil {
+		s.lastError = err
+		return false
+	}
+	return less
+}
+
+// lessRows reports whether the row a goes before the row b according to the
+// given sort fields.
+func lessRows(ctx *sql.Context, sortFields []SortField, a, b sql.Row) (bool, error) {
+	for _, sf := range sortFields {
 		typ := sf.Column.Type()
-		av, err := sf.Column.Eval(s.ctx, a)
+		av, err := sf.Column.Eval(ctx, a)
 		if err != nil {
-			s.lastError = ErrUnableSort.Wrap(err)
-			return false
+			return false, ErrUnableSort.Wrap(err)
 		}
 
-		bv, err := sf.Column.Eval(s.ctx, b)
+		bv, err := sf.Column.Eval(ctx, b)
 		if err != nil {
-			s.lastError = ErrUnableSort.Wrap(err)
-			return false
+			return false, ErrUnableSort.Wrap(err)
+		}
+
+		if av == nil && bv == nil {
+			continue
 		}
 
 		if av == nil {
-			return sf.NullOrdering == NullsFirst
+			return sf.NullOrdering == NullsFirst, nil
 		}
 
 		if bv == nil {
-			return sf.NullOrdering != NullsFirst
+			return sf.NullOrdering != NullsFirst, nil
 		}
 
 		if sf.Order == Descending {
@@ -259,17 +400,16 @@ func (s *sorter) Less(i, j int) bool {
 
 		cmp, err := typ.Compare(av, bv)
 		if err != nil {
-			s.lastError = err
-			return false
+			return false, err
 		}
 
 		switch cmp {
 		case -1:
-			return true
+			return true, nil
 		case 1:
-			return false
+			return false, nil
 		}
 	}
 
-	return false
+	return false, nil
 }
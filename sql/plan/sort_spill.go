@@ -0,0 +1,137 @@
+package plan
+
+import (
+	"container/heap"
+	"io"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// maxSortMergeRuns is the maximum number of runs merged at the same time.
+var maxSortMergeRuns = 64
+
+// minSortRunRows is the minimum number of rows spilled to disk when there is
+// no memory available, so a query doesn't write a run for every row when
+// the memory is used by something else.
+const minSortRunRows = 1024
+
+// sortMergeIter merges several iterators of sorted rows into a single one.
+// If rows are equal, the ones of the first iterators go first.
+type sortMergeIter struct {
+	iters   []sql.RowIter
+	rows    *mergeRows
+	started bool
+}
+
+func newSortMergeIter(ctx *sql.Context, sortFields []SortField, iters []sql.RowIter) *sortMergeIter {
+	return &sortMergeIter{
+		iters: iters,
+		rows:  &mergeRows{ctx: ctx, sortFields: sortFields},
+	}
+}
+
+func (i *sortMergeIter) Next() (sql.Row, error) {
+	if err := i.rows.ctx.CheckCanceled(); err != nil {
+		return nil, err
+	}
+
+	if !i.started {
+		i.started = true
+		for idx := range i.iters {
+			if err := i.readNext(idx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if i.rows.Len() == 0 {
+		return nil, io.EOF
+	}
+
+	next := heap.Pop(i.rows).(mergeRow)
+	if i.rows.lastError != nil {
+		return nil, i.rows.lastError
+	}
+
+	if err := i.readNext(next.iter); err != nil {
+		return nil, err
+	}
+
+	return next.row, nil
+}
+
+// readNext reads the next row of the iterator with the given index and
+// adds it to the rows being merged.
+func (i *sortMergeIter) readNext(idx int) error {
+	row, err := i.iters[idx].Next()
+	if err == io.EOF {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	heap.Push(i.rows, mergeRow{row, idx})
+	return i.rows.lastError
+}
+
+func (i *sortMergeIter) Close() error {
+	var err error
+	for _, iter := range i.iters {
+		if cerr := iter.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type mergeRow struct {
+	row  sql.Row
+	iter int
+}
+
+// mergeRows is a heap of the next row of every iterator being merged.
+type mergeRows struct {
+	ctx        *sql.Context
+	sortFields []SortField
+	rows       []mergeRow
+	lastError  error
+}
+
+func (r *mergeRows) Len() int { return len(r.rows) }
+
+func (r *mergeRows) Swap(i, j int) { r.rows[i], r.rows[j] = r.rows[j], r.rows[i] }
+
+func (r *mergeRows) Less(i, j int) bool {
+	if r.lastError != nil {
+		return false
+	}
+
+	a, b := r.rows[i], r.rows[j]
+	less, err := lessRows(r.ctx, r.sortFields, a.row, b.row)
+	if err != nil {
+		r.lastError = err
+		return false
+	}
+
+	if less {
+		return true
+	}
+
+	greater, err := lessRows(r.ctx, r.sortFields, b.row, a.row)
+	if err != nil {
+		r.lastError = err
+		return false
+	}
+
+	return !greater && a.iter < b.iter
+}
+
+func (r *mergeRows) Push(x interface{}) { r.rows = append(r.rows, x.(mergeRow)) }
+
+func (r *mergeRows) Pop() interface{} {
+	last := r.rows[len(r.rows)-1]
+	r.rows = r.rows[:len(r.rows)-1]
+	return last
+}
@@ -1,7 +1,12 @@
 package plan
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/src-d/go-mysql-server/memory"
 	"github.com/src-d/go-mysql-server/sql"
@@ -131,3 +136,132 @@ func TestSortDescending(t *testing.T) {
 	require.NoError(err)
 	require.Equal(expected, actual)
 }
+
+func TestSortSpill(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "sort-spill")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	defer func(dir string, runs int) {
+		spillDir, maxSortMergeRuns = dir, runs
+	}(spillDir, maxSortMergeRuns)
+	spillDir, maxSortMergeRuns = dir, 3
+
+	schema := sql.Schema{
+		{Name: "a", Type: sql.Int64, Nullable: true},
+		{Name: "b", Type: sql.Text},
+		{Name: "c", Type: sql.Timestamp},
+	}
+
+	child := memory.NewPartitionedTable("test", schema, 2)
+	for i := 0; i < 100; i++ {
+		var a interface{} = int64(i % 7)
+		if i%10 == 0 {
+			a = nil
+		}
+
+		row := sql.NewRow(a, fmt.Sprintf("row%d", i), time.Date(2019, 1, i%28+1, 0, 0, 0, 0, time.UTC))
+		require.NoError(child.Insert(sql.NewEmptyContext(), row))
+	}
+
+	sf := []SortField{
+		{Column: expression.NewGetField(0, sql.Int64, "a", true), Order: Descending, NullOrdering: NullsLast},
+		{Column: expression.NewGetField(2, sql.Timestamp, "c", false), Order: Ascending, NullOrdering: NullsFirst},
+	}
+	s := NewSort(sf, NewResolvedTable(child))
+
+	expected, err := sql.NodeToRows(sql.NewEmptyContext(), s)
+	require.NoError(err)
+	require.Len(expected, 100)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.Len(files, 0)
+
+	ctx := sql.NewEmptyContext()
+	ctx.Set(sortBufferSizeSessionVar, sql.Int64, int64(500))
+
+	iter, err := s.RowIter(ctx)
+	require.NoError(err)
+
+	var actual []sql.Row
+	for i := 0; i < len(expected); i++ {
+		row, err := iter.Next()
+		require.NoError(err)
+		actual = append(actual, row)
+
+		if i == 0 {
+			files, err := ioutil.ReadDir(dir)
+			require.NoError(err)
+			require.True(len(files) > 1 && len(files) <= 3)
+		}
+	}
+	require.NoError(iter.Close())
+	require.Equal(expected, actual)
+
+	files, err = ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.Len(files, 0)
+}
+
+func TestSortSpillQueryMemory(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "sort-spill")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	defer func(dir string) { spillDir = dir }(spillDir)
+	spillDir = dir
+
+	child := memory.NewTable("test", sql.Schema{
+		{Name: "a", Type: sql.Int64},
+	})
+	for i := 0; i < 50; i++ {
+		require.NoError(child.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i%7))))
+	}
+
+	s := NewSort([]SortField{
+		{Column: expression.NewGetField(0, sql.Int64, "a", false), Order: Ascending},
+	}, NewResolvedTable(child))
+
+	expected, err := sql.NodeToRows(sql.NewEmptyContext(), s)
+	require.NoError(err)
+
+	session := sql.NewBaseSession()
+	session.Set("max_query_memory", sql.Int64, int64(240))
+	ctx := sql.NewContext(context.Background(), sql.WithSession(session))
+
+	iter, err := s.RowIter(ctx)
+	require.NoError(err)
+
+	row, err := iter.Next()
+	require.NoError(err)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.True(len(files) > 1)
+	require.True(ctx.QueryMemory().Used() <= 240)
+
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, append([]sql.Row{row}, rows...))
+	require.Equal(uint64(0), ctx.QueryMemory().Used())
+}
+
+func TestBufferSizeByEnv(t *testing.T) {
+	require := require.New(t)
+
+	const key = "TEST_BUFFER_SIZE"
+	defer os.Unsetenv(key)
+
+	require.Equal(uint64(0), bufferSizeByEnv(key))
+
+	os.Setenv(key, " 1024 ")
+	require.Equal(uint64(1024), bufferSizeByEnv(key))
+
+	os.Setenv(key, "1MB")
+	require.Equal(uint64(0), bufferSizeByEnv(key))
+}
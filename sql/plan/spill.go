@@ -0,0 +1,186 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrSpill is returned when rows cannot be written to or read from a
+// temporary file.
+var ErrSpill = errors.NewKind("unable to spill rows to disk: %s")
+
+// spillDir is the directory where the rows that don't fit in memory are
+// written. If it's empty, the default directory for temporary files is used.
+var spillDir = ""
+
+// bufferSizeByEnv returns the number of bytes set in the given environment
+// variable, or zero if it's not set or it's not a number.
+func bufferSizeByEnv(key string) uint64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		logrus.Warnf("%s environment variable must be a number, but got: %s", key, v)
+		return 0
+	}
+	return n
+}
+
+// bufferSizeFor returns the number of bytes set in the given session
+// variable, which has precedence over the given default.
+func bufferSizeFor(ctx *sql.Context, sessionVar string, def uint64) uint64 {
+	_, val := ctx.Get(sessionVar)
+	if val == nil {
+		return def
+	}
+
+	n, err := sql.Int64.Convert(val)
+	if err != nil || n.(int64) <= 0 {
+		return def
+	}
+	return uint64(n.(int64))
+}
+
+func init() {
+	// Values of these types are stored in interfaces, so they need to be
+	// registered to be encoded in the spill files.
+	gob.Register(time.Time{})
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(sql.Point{})
+	gob.Register(sql.LineString{})
+	gob.Register(sql.Polygon{})
+}
+
+// spillFile is a temporary file with rows that don't fit in memory. Rows
+// are appended to it with write, and read in the same order with iter once
+// all of them have been written.
+type spillFile struct {
+	file *os.File
+	w    *bufio.Writer
+	enc  *gob.Encoder
+}
+
+func newSpillFile(prefix string) (*spillFile, error) {
+	f, err := ioutil.TempFile(spillDir, "go-mysql-server-"+prefix)
+	if err != nil {
+		return nil, ErrSpill.New(err)
+	}
+
+	w := bufio.NewWriter(f)
+	return &spillFile{f, w, gob.NewEncoder(w)}, nil
+}
+
+// writeSpillFile writes all the rows of the given iterator in a new spill
+// file. The iterator is closed after that.
+func writeSpillFile(prefix string, iter sql.RowIter) (*spillFile, error) {
+	f, err := newSpillFile(prefix)
+	if err != nil {
+		_ = iter.Close()
+		return nil, err
+	}
+
+	for {
+		row, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err == nil {
+			err = f.write(row)
+		}
+
+		if err != nil {
+			_ = iter.Close()
+			_ = f.remove()
+			return nil, err
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		_ = f.remove()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *spillFile) write(row sql.Row) error {
+	if err := f.enc.Encode(row); err != nil {
+		return ErrSpill.New(err)
+	}
+	return nil
+}
+
+// iter returns an iterator of the rows of the file from the beginning.
+func (f *spillFile) iter() (sql.RowIter, error) {
+	if err := f.w.Flush(); err != nil {
+		return nil, ErrSpill.New(err)
+	}
+
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return nil, ErrSpill.New(err)
+	}
+
+	return &spillFileIter{gob.NewDecoder(bufio.NewReader(f.file))}, nil
+}
+
+// remove closes and deletes the file.
+func (f *spillFile) remove() error {
+	if err := f.file.Close(); err != nil {
+		_ = os.Remove(f.file.Name())
+		return err
+	}
+	return os.Remove(f.file.Name())
+}
+
+type spillFileIter struct {
+	dec *gob.Decoder
+}
+
+func (i *spillFileIter) Next() (sql.Row, error) {
+	var row sql.Row
+	if err := i.dec.Decode(&row); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, ErrSpill.New(err)
+	}
+	return row, nil
+}
+
+// Close does nothing, as the file is owned by the spillFile.
+func (i *spillFileIter) Close() error { return nil }
+
+// estimateRowSize returns the approximate number of bytes used by the row
+// in memory.
+func estimateRowSize(row sql.Row) uint64 {
+	// Every value is stored in an interface.
+	size := uint64(len(row)) * 16
+	for _, v := range row {
+		switch v := v.(type) {
+		case string:
+			size += uint64(len(v))
+		case []byte:
+			size += uint64(len(v))
+		case time.Time:
+			size += 24
+		default:
+			size += 8
+		}
+	}
+	return size
+}
@@ -0,0 +1,144 @@
+package plan
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// SamplingTable is a wrapper for sql.Tables that can't sample their rows
+// themselves, which returns only the rows of a sample of the table. With
+// the SYSTEM method the partitions of the table not chosen are skipped, so
+// their rows are never read, and with the BERNOULLI method each row is
+// chosen after it's read.
+type SamplingTable struct {
+	sql.Table
+	sample sql.TableSample
+	// seed is the seed of the sample, or a random one if the sample is not
+	// repeatable.
+	seed int64
+}
+
+var _ sql.SampledTable = (*SamplingTable)(nil)
+
+// NewSamplingTable returns a new SamplingTable with the given sample of the
+// table.
+func NewSamplingTable(t sql.Table, sample sql.TableSample) *SamplingTable {
+	seed := sample.Seed
+	if !sample.Repeatable {
+		seed = rand.Int63()
+	}
+	return &SamplingTable{Table: t, sample: sample, seed: seed}
+}
+
+// WithSample implements the sql.SampledTable interface.
+func (t *SamplingTable) WithSample(sample sql.TableSample) sql.Table {
+	return NewSamplingTable(t.Table, sample)
+}
+
+// Sample implements the sql.SampledTable interface.
+func (t *SamplingTable) Sample() *sql.TableSample {
+	return &t.sample
+}
+
+// Partitions implements the sql.Table interface.
+func (t *SamplingTable) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
+	iter, err := t.Table.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.sample.Method != sql.SystemSample {
+		return iter, nil
+	}
+	return &samplingPartitionIter{iter, t}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *SamplingTable) PartitionRows(ctx *sql.Context, p sql.Partition) (sql.RowIter, error) {
+	iter, err := t.Table.PartitionRows(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.sample.Method != sql.BernoulliSample {
+		return iter, nil
+	}
+
+	// Each partition has its own random numbers, so the rows chosen don't
+	// depend on the order the partitions are read.
+	random := rand.New(rand.NewSource(int64(t.hash(p.Key()))))
+	return &samplingRowIter{iter, t.sample.Percentage, random}, nil
+}
+
+func (t *SamplingTable) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("%s", t.sample)
+	_ = p.WriteChildren(t.Table.String())
+	return p.String()
+}
+
+// hash returns the hash of the seed of the sample and the given partition
+// key.
+func (t *SamplingTable) hash(key []byte) uint64 {
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(t.seed))
+	_, _ = h.Write(seed[:])
+	_, _ = h.Write(key)
+
+	// The higher bits of FNV hashes barely change for keys that only
+	// differ in their last bytes, so they're mixed as in MurmurHash3.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// chosen returns whether the partition with the given key is in the sample.
+func (t *SamplingTable) chosen(key []byte) bool {
+	// The 53 higher bits of the hash make a float64 in [0, 1).
+	return float64(t.hash(key)>>11)/(1<<53)*100 < t.sample.Percentage
+}
+
+type samplingPartitionIter struct {
+	sql.PartitionIter
+	table *SamplingTable
+}
+
+func (i *samplingPartitionIter) Next() (sql.Partition, error) {
+	for {
+		p, err := i.PartitionIter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if i.table.chosen(p.Key()) {
+			return p, nil
+		}
+	}
+}
+
+type samplingRowIter struct {
+	sql.RowIter
+	percentage float64
+	random     *rand.Rand
+}
+
+func (i *samplingRowIter) Next() (sql.Row, error) {
+	for {
+		row, err := i.RowIter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if i.random.Float64()*100 < i.percentage {
+			return row, nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func newSampleTable(t *testing.T) *memory.Table {
+	table := memory.NewPartitionedTable("t", sql.Schema{
+		{Name: "i", Type: sql.Int64, Source: "t"},
+	}, 20)
+
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, table.Insert(sql.NewEmptyContext(), sql.NewRow(int64(i))))
+	}
+	return table
+}
+
+func sampleRows(t *testing.T, table sql.Table, sample sql.TableSample) []sql.Row {
+	rows, err := sql.NodeToRows(sql.NewEmptyContext(), NewResolvedTable(NewSamplingTable(table, sample)))
+	require.NoError(t, err)
+	return rows
+}
+
+func TestSamplingTable(t *testing.T) {
+	table := newSampleTable(t)
+
+	for _, method := range []sql.SampleMethod{sql.BernoulliSample, sql.SystemSample} {
+		t.Run(string(method), func(t *testing.T) {
+			require := require.New(t)
+
+			require.Len(sampleRows(t, table, sql.TableSample{Method: method, Percentage: 100}), 1000)
+			require.Len(sampleRows(t, table, sql.TableSample{Method: method, Percentage: 0}), 0)
+
+			sample := sql.TableSample{Method: method, Percentage: 30}.WithSeed(1)
+			rows := sampleRows(t, table, sample)
+			require.True(len(rows) > 0 && len(rows) < 1000, "sample of %d rows", len(rows))
+			require.Equal(rows, sampleRows(t, table, sample))
+		})
+	}
+}
+
+func TestSamplingTableSystem(t *testing.T) {
+	require := require.New(t)
+
+	table := newSampleTable(t)
+	sample := sql.TableSample{Method: sql.SystemSample, Percentage: 50}.WithSeed(3)
+
+	// The rows of a partition are all in the sample or not at all.
+	var partitions = make(map[int64]int)
+	for _, row := range sampleRows(t, table, sample) {
+		partitions[row[0].(int64)%20]++
+	}
+
+	require.NotEmpty(partitions)
+	for p, n := range partitions {
+		require.Equal(50, n, "partition %d", p)
+	}
+}
+
+func TestSamplingTableWithSample(t *testing.T) {
+	require := require.New(t)
+
+	table := newSampleTable(t)
+	sample := sql.TableSample{Method: sql.BernoulliSample, Percentage: 10}
+	sampled := NewSamplingTable(table, sample)
+	require.Equal(&sample, sampled.Sample())
+
+	other := sql.TableSample{Method: sql.SystemSample, Percentage: 20}.WithSeed(1)
+	resampled := sampled.WithSample(other).(*SamplingTable)
+	require.Equal(table, resampled.Table)
+	require.Equal(&other, resampled.Sample())
+}
@@ -2,14 +2,80 @@ package plan
 
 import "github.com/src-d/go-mysql-server/sql"
 
-// Rollback undoes the changes performed in a transaction.
+// StartTransaction starts a transaction in the session.
+type StartTransaction struct{}
+
+// NewStartTransaction creates a new StartTransaction node.
+func NewStartTransaction() *StartTransaction { return new(StartTransaction) }
+
+// RowIter implements the sql.Node interface.
+func (*StartTransaction) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	sql.SetInTransaction(ctx.Session, true)
+	return sql.RowsToRowIter(), nil
+}
+
+func (*StartTransaction) String() string { return "START TRANSACTION" }
+
+// WithChildren implements the Node interface.
+func (s *StartTransaction) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(s, len(children), 0)
+	}
+
+	return s, nil
+}
+
+// Resolved implements the sql.Node interface.
+func (*StartTransaction) Resolved() bool { return true }
+
+// Children implements the sql.Node interface.
+func (*StartTransaction) Children() []sql.Node { return nil }
+
+// Schema implements the sql.Node interface.
+func (*StartTransaction) Schema() sql.Schema { return nil }
+
+// Commit ends the transaction of the session, keeping its changes.
+type Commit struct{}
+
+// NewCommit creates a new Commit node.
+func NewCommit() *Commit { return new(Commit) }
+
+// RowIter implements the sql.Node interface.
+func (*Commit) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	sql.SetInTransaction(ctx.Session, false)
+	return sql.RowsToRowIter(), nil
+}
+
+func (*Commit) String() string { return "COMMIT" }
+
+// WithChildren implements the Node interface.
+func (c *Commit) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(c, len(children), 0)
+	}
+
+	return c, nil
+}
+
+// Resolved implements the sql.Node interface.
+func (*Commit) Resolved() bool { return true }
+
+// Children implements the sql.Node interface.
+func (*Commit) Children() []sql.Node { return nil }
+
+// Schema implements the sql.Node interface.
+func (*Commit) Schema() sql.Schema { return nil }
+
+// Rollback undoes the changes performed in a transaction. As the engine
+// does not have transactions, it only ends the transaction of the session.
 type Rollback struct{}
 
 // NewRollback creates a new Rollback node.
 func NewRollback() *Rollback { return new(Rollback) }
 
 // RowIter implements the sql.Node interface.
-func (*Rollback) RowIter(*sql.Context) (sql.RowIter, error) {
+func (*Rollback) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	sql.SetInTransaction(ctx.Session, false)
 	return sql.RowsToRowIter(), nil
 }
 
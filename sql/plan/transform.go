@@ -34,6 +34,61 @@ func TransformUp(node sql.Node, f sql.TransformNodeFunc) (sql.Node, error) {
 	return f(node)
 }
 
+// TransformDown applies a transformation function to the given tree from the
+// top down. The children of the node returned by the function are the ones
+// transformed afterwards, so the function can replace a node with a whole
+// new subtree.
+func TransformDown(node sql.Node, f sql.TransformNodeFunc) (sql.Node, error) {
+	node, err := f(node)
+	if err != nil {
+		return nil, err
+	}
+
+	if o, ok := node.(sql.OpaqueNode); ok && o.Opaque() {
+		return node, nil
+	}
+
+	children := node.Children()
+	if len(children) == 0 {
+		return node, nil
+	}
+
+	newChildren := make([]sql.Node, len(children))
+	for i, c := range children {
+		c, err := TransformDown(c, f)
+		if err != nil {
+			return nil, err
+		}
+		newChildren[i] = c
+	}
+
+	return node.WithChildren(newChildren...)
+}
+
+// TransformUpWithSubqueries applies a transformation function to the given
+// tree from the bottom up, like TransformUp, and to the plans of the
+// subqueries used as expressions in it.
+func TransformUpWithSubqueries(node sql.Node, f sql.TransformNodeFunc) (sql.Node, error) {
+	node, err := TransformExpressionsUp(node, func(e sql.Expression) (sql.Expression, error) {
+		s, ok := e.(*expression.Subquery)
+		if !ok {
+			return e, nil
+		}
+
+		query, err := TransformUpWithSubqueries(s.Query, f)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.WithQuery(query), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return TransformUp(node, f)
+}
+
 // TransformExpressionsUp applies a transformation function to all expressions
 // on the given tree from the bottom up.
 func TransformExpressionsUp(node sql.Node, f sql.TransformExprFunc) (sql.Node, error) {
@@ -42,3 +42,60 @@ func TestTransformUp(t *testing.T) {
 	)
 	require.Equal(ep, pt)
 }
+
+func TestTransformDown(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("resolved", sql.Schema{
+		{Name: "a", Type: sql.Text},
+	})
+	p := NewProject(nil, NewFilter(nil, NewUnresolvedTable("unresolved", "")))
+
+	var visited []string
+	pt, err := TransformDown(p, func(n sql.Node) (sql.Node, error) {
+		switch n := n.(type) {
+		case *Filter:
+			visited = append(visited, "filter")
+			// The children of the new node are transformed as well.
+			return NewLimit(1, n.Child), nil
+		case *UnresolvedTable:
+			visited = append(visited, "table")
+			return NewResolvedTable(table), nil
+		default:
+			visited = append(visited, "other")
+			return n, nil
+		}
+	})
+	require.NoError(err)
+
+	require.Equal([]string{"other", "filter", "table"}, visited)
+	require.Equal(NewProject(nil, NewLimit(1, NewResolvedTable(table))), pt)
+}
+
+func TestTransformUpWithSubqueries(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("resolved", sql.Schema{
+		{Name: "a", Type: sql.Text},
+	})
+	p := NewFilter(
+		expression.NewSubquery(NewUnresolvedTable("sub", "")),
+		NewUnresolvedTable("unresolved", ""),
+	)
+
+	pt, err := TransformUpWithSubqueries(p, func(n sql.Node) (sql.Node, error) {
+		if _, ok := n.(*UnresolvedTable); ok {
+			return NewResolvedTable(table), nil
+		}
+		return n, nil
+	})
+	require.NoError(err)
+
+	require.Equal(
+		NewFilter(
+			expression.NewSubquery(NewResolvedTable(table)),
+			NewResolvedTable(table),
+		),
+		pt,
+	)
+}
@@ -2,6 +2,7 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/src-d/go-mysql-server/sql"
 	errors "gopkg.in/src-d/go-errors.v1"
@@ -14,11 +15,38 @@ var ErrUnresolvedTable = errors.NewKind("unresolved table")
 type UnresolvedTable struct {
 	name     string
 	Database string
+	// IndexHints are the index hints given for the table in the query.
+	IndexHints []sql.IndexHint
+	// AsOf is the version the table is queried as of, or nil if it's
+	// queried as it is.
+	AsOf sql.Expression
+	// Sample is the sample of the rows of the table given in TABLESAMPLE,
+	// or nil if all its rows are queried.
+	Sample *UnresolvedTableSample
+}
+
+// UnresolvedTableSample is the TABLESAMPLE clause of a table, whose
+// percentage and seed are not evaluated yet.
+type UnresolvedTableSample struct {
+	// Method used to choose the rows of the sample.
+	Method string
+	// Percentage of the rows of the table in the sample.
+	Percentage sql.Expression
+	// Seed given in REPEATABLE, or nil if the sample is not repeatable.
+	Seed sql.Expression
+}
+
+func (s *UnresolvedTableSample) String() string {
+	str := fmt.Sprintf("TABLESAMPLE %s (%s)", strings.ToUpper(s.Method), s.Percentage)
+	if s.Seed != nil {
+		str += fmt.Sprintf(" REPEATABLE (%s)", s.Seed)
+	}
+	return str
 }
 
 // NewUnresolvedTable creates a new Unresolved table.
 func NewUnresolvedTable(name, db string) *UnresolvedTable {
-	return &UnresolvedTable{name, db}
+	return &UnresolvedTable{name: name, Database: db}
 }
 
 // Name implements the Nameable interface.
@@ -52,5 +80,79 @@ func (t *UnresolvedTable) WithChildren(children ...sql.Node) (sql.Node, error) {
 }
 
 func (t UnresolvedTable) String() string {
-	return fmt.Sprintf("UnresolvedTable(%s)", t.name)
+	name := t.name
+	if t.AsOf != nil {
+		name += fmt.Sprintf(" AS OF %s", t.AsOf)
+	}
+	if t.Sample != nil {
+		name += " " + t.Sample.String()
+	}
+	return fmt.Sprintf("UnresolvedTable(%s)", name)
+}
+
+// UnresolvedTableFunction is a call to a table function in the FROM clause
+// that has not been resolved yet.
+type UnresolvedTableFunction struct {
+	name string
+	// Arguments of the call.
+	Arguments []sql.Expression
+}
+
+var _ sql.Expressioner = (*UnresolvedTableFunction)(nil)
+
+// NewUnresolvedTableFunction creates a new UnresolvedTableFunction node.
+func NewUnresolvedTableFunction(name string, args ...sql.Expression) *UnresolvedTableFunction {
+	return &UnresolvedTableFunction{name: name, Arguments: args}
+}
+
+// Name implements the Nameable interface.
+func (t *UnresolvedTableFunction) Name() string {
+	return t.name
+}
+
+// Resolved implements the Resolvable interface.
+func (*UnresolvedTableFunction) Resolved() bool {
+	return false
+}
+
+// Children implements the Node interface.
+func (*UnresolvedTableFunction) Children() []sql.Node { return nil }
+
+// Schema implements the Node interface.
+func (*UnresolvedTableFunction) Schema() sql.Schema { return nil }
+
+// RowIter implements the RowIter interface.
+func (*UnresolvedTableFunction) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	return nil, ErrUnresolvedTable.New()
+}
+
+// WithChildren implements the Node interface.
+func (t *UnresolvedTableFunction) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(t, len(children), 0)
+	}
+
+	return t, nil
+}
+
+// Expressions implements the Expressioner interface.
+func (t *UnresolvedTableFunction) Expressions() []sql.Expression {
+	return t.Arguments
+}
+
+// WithExpressions implements the Expressioner interface.
+func (t *UnresolvedTableFunction) WithExpressions(exprs ...sql.Expression) (sql.Node, error) {
+	if len(exprs) != len(t.Arguments) {
+		return nil, sql.ErrInvalidChildrenNumber.New(t, len(exprs), len(t.Arguments))
+	}
+
+	return NewUnresolvedTableFunction(t.name, exprs...), nil
+}
+
+func (t UnresolvedTableFunction) String() string {
+	args := make([]string, len(t.Arguments))
+	for i, arg := range t.Arguments {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("UnresolvedTableFunction(%s(%s))", t.name, strings.Join(args, ", "))
 }
@@ -2,6 +2,7 @@ package plan
 
 import (
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
 	"gopkg.in/src-d/go-errors.v1"
 	"io"
 )
@@ -13,11 +14,13 @@ var ErrUpdateUnexpectedSetResult = errors.NewKind("attempted to set field but ex
 type Update struct {
 	sql.Node
 	UpdateExprs []sql.Expression
+	// Changes is where the updated rows are recorded, if they are.
+	Changes *ChangeTarget
 }
 
 // NewUpdate creates an Update node.
 func NewUpdate(n sql.Node, updateExprs []sql.Expression) *Update {
-	return &Update{n, updateExprs}
+	return &Update{Node: n, UpdateExprs: updateExprs}
 }
 
 // Expressions implements the Expressioner interface.
@@ -88,13 +91,18 @@ func getUpdatableTable(t sql.Table) (sql.Updater, error) {
 }
 
 // Execute inserts the rows in the database.
-func (p *Update) Execute(ctx *sql.Context) (int, int, error) {
+func (p *Update) Execute(ctx *sql.Context) (_ int, _ int, err error) {
 	updatable, err := getUpdatable(p.Node)
 	if err != nil {
 		return 0, 0, err
 	}
 	schema := p.Node.Schema()
 
+	changes := newChangeLog(p.Changes, schema)
+	defer func() {
+		err = changes.record(ctx, err)
+	}()
+
 	iter, err := p.Node.RowIter(ctx)
 	if err != nil {
 		return 0, 0, err
@@ -113,7 +121,7 @@ func (p *Update) Execute(ctx *sql.Context) (int, int, error) {
 		}
 		rowsMatched++
 
-		newRow, err := p.applyUpdates(ctx, oldRow)
+		newRow, err := p.applyUpdates(ctx, oldRow, int64(rowsMatched))
 		if err != nil {
 			_ = iter.Close()
 			return rowsMatched, rowsUpdated, err
@@ -125,6 +133,7 @@ func (p *Update) Execute(ctx *sql.Context) (int, int, error) {
 					_ = iter.Close()
 					return rowsMatched, rowsUpdated, err
 				}
+				changes.add(sql.RowUpdated, oldRow, newRow)
 				rowsUpdated++
 			}
 		} else {
@@ -138,11 +147,18 @@ func (p *Update) Execute(ctx *sql.Context) (int, int, error) {
 
 // RowIter implements the Node interface.
 func (p *Update) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	span, ctx := ctx.Span("plan.Update")
+	defer span.Finish()
+
 	matched, updated, err := p.Execute(ctx)
+	span.SetTag("matched", matched)
+	span.SetTag("updated", updated)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx.SetLastQueryInfo(sql.RowCount, int64(updated))
+
 	return sql.RowsToRowIter(sql.NewRow(int64(matched), int64(updated))), nil
 }
 
@@ -151,7 +167,9 @@ func (p *Update) WithChildren(children ...sql.Node) (sql.Node, error) {
 	if len(children) != 1 {
 		return nil, sql.ErrInvalidChildrenNumber.New(p, len(children), 1)
 	}
-	return NewUpdate(children[0], p.UpdateExprs), nil
+	np := NewUpdate(children[0], p.UpdateExprs)
+	np.Changes = p.Changes
+	return np, nil
 }
 
 // WithExpressions implements the Expressioner interface.
@@ -159,7 +177,9 @@ func (p *Update) WithExpressions(newExprs ...sql.Expression) (sql.Node, error) {
 	if len(newExprs) != len(p.UpdateExprs) {
 		return nil, sql.ErrInvalidChildrenNumber.New(p, len(p.UpdateExprs), 1)
 	}
-	return NewUpdate(p.Node, newExprs), nil
+	np := NewUpdate(p.Node, newExprs)
+	np.Changes = p.Changes
+	return np, nil
 }
 
 func (p Update) String() string {
@@ -172,10 +192,20 @@ func (p Update) String() string {
 	return pr.String()
 }
 
-func (p *Update) applyUpdates(ctx *sql.Context, row sql.Row) (sql.Row, error) {
+// applyUpdates returns the given row, which is the given row number of
+// the statement, with the values of the update expressions.
+func (p *Update) applyUpdates(ctx *sql.Context, row sql.Row, rowNum int64) (sql.Row, error) {
 	var ok bool
 	prev := row
 	for _, updateExpr := range p.UpdateExprs {
+		if set, isSet := updateExpr.(*expression.SetField); isSet {
+			var err error
+			if prev, err = set.Assign(ctx, prev, rowNum); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		val, err := updateExpr.Eval(ctx, prev)
 		if err != nil {
 			return nil, err
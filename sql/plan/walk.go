@@ -48,6 +48,29 @@ func Inspect(node sql.Node, f func(sql.Node) bool) {
 	Walk(inspector(f), node)
 }
 
+// InspectWithSubqueries traverses the plan like Inspect, but also the plans
+// of the subqueries used as expressions, right after the node containing
+// them is inspected and before its children.
+func InspectWithSubqueries(node sql.Node, f func(sql.Node) bool) {
+	Inspect(node, func(node sql.Node) bool {
+		if !f(node) {
+			return false
+		}
+
+		if n, ok := node.(sql.Expressioner); ok {
+			for _, e := range n.Expressions() {
+				expression.Inspect(e, func(e sql.Expression) bool {
+					if s, ok := e.(*expression.Subquery); ok {
+						InspectWithSubqueries(s.Query, f)
+					}
+					return true
+				})
+			}
+		}
+		return true
+	})
+}
+
 // WalkExpressions traverses the plan and calls expression.Walk on any
 // expression it finds.
 func WalkExpressions(v expression.Visitor, node sql.Node) {
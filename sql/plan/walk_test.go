@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
 	"github.com/stretchr/testify/require"
 )
 
@@ -88,3 +89,20 @@ func TestInspect(t *testing.T) {
 		visited,
 	)
 }
+
+func TestInspectWithSubqueries(t *testing.T) {
+	t1 := NewUnresolvedTable("foo", "")
+	t2 := NewUnresolvedTable("bar", "")
+	filter := NewFilter(expression.NewSubquery(t2), t1)
+
+	var visited []sql.Node
+	InspectWithSubqueries(filter, func(node sql.Node) bool {
+		visited = append(visited, node)
+		return true
+	})
+
+	require.Equal(t,
+		[]sql.Node{filter, t2, nil, t1, nil, nil},
+		visited,
+	)
+}
@@ -0,0 +1,268 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrPrivilegeCheckFailed is returned when the user lacks the privileges
+// needed to run a statement.
+var ErrPrivilegeCheckFailed = errors.NewKind("%s command denied to user '%s' for %s")
+
+// PrivilegeType is a set of privileges that can be granted to a user.
+type PrivilegeType uint32
+
+const (
+	// SelectPrivilege allows reading the rows of tables.
+	SelectPrivilege PrivilegeType = 1 << iota
+	// InsertPrivilege allows inserting rows in tables.
+	InsertPrivilege
+	// UpdatePrivilege allows updating the rows of tables.
+	UpdatePrivilege
+	// DeletePrivilege allows deleting the rows of tables.
+	DeletePrivilege
+	// CreatePrivilege allows creating tables.
+	CreatePrivilege
+	// DropPrivilege allows dropping tables.
+	DropPrivilege
+	// IndexPrivilege allows creating and dropping indexes.
+	IndexPrivilege
+	// AlterPrivilege allows altering tables.
+	AlterPrivilege
+	// LockTablesPrivilege allows locking tables.
+	LockTablesPrivilege
+	// GrantOptionPrivilege allows granting and revoking the privileges the
+	// user has to other users.
+	GrantOptionPrivilege
+
+	// AllPrivileges are all the privileges but GrantOptionPrivilege, like
+	// ALL PRIVILEGES in MySQL.
+	AllPrivileges = SelectPrivilege | InsertPrivilege | UpdatePrivilege |
+		DeletePrivilege | CreatePrivilege | DropPrivilege | IndexPrivilege |
+		AlterPrivilege | LockTablesPrivilege
+)
+
+var privilegeNames = []struct {
+	privilege PrivilegeType
+	name      string
+}{
+	{SelectPrivilege, "SELECT"},
+	{InsertPrivilege, "INSERT"},
+	{UpdatePrivilege, "UPDATE"},
+	{DeletePrivilege, "DELETE"},
+	{CreatePrivilege, "CREATE"},
+	{DropPrivilege, "DROP"},
+	{IndexPrivilege, "INDEX"},
+	{AlterPrivilege, "ALTER"},
+	{LockTablesPrivilege, "LOCK TABLES"},
+	{GrantOptionPrivilege, "GRANT OPTION"},
+}
+
+// ParsePrivilege returns the privilege with the given name, which is case
+// insensitive. ALL and ALL PRIVILEGES are AllPrivileges.
+func ParsePrivilege(name string) (PrivilegeType, bool) {
+	name = strings.ToUpper(strings.Join(strings.Fields(name), " "))
+	if name == "ALL" || name == "ALL PRIVILEGES" {
+		return AllPrivileges, true
+	}
+
+	for _, p := range privilegeNames {
+		if p.name == name {
+			return p.privilege, true
+		}
+	}
+
+	return 0, false
+}
+
+// String returns the names of the privileges, separated by commas.
+func (p PrivilegeType) String() string {
+	var names []string
+	if p&AllPrivileges == AllPrivileges {
+		names = append(names, "ALL PRIVILEGES")
+		p &^= AllPrivileges
+	}
+
+	for _, n := range privilegeNames {
+		if p&n.privilege != 0 {
+			names = append(names, n.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "USAGE"
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// PrivilegeLevel is the scope of a grant. A "*" database covers all the
+// databases, and a "*" table all the tables of the database.
+type PrivilegeLevel struct {
+	Database string
+	Table    string
+}
+
+// GlobalPrivilegeLevel covers all the tables of all the databases.
+var GlobalPrivilegeLevel = PrivilegeLevel{"*", "*"}
+
+// Contains checks if the level covers the given table. An empty table
+// checks the privileges on the database itself.
+func (l PrivilegeLevel) Contains(db, table string) bool {
+	if l.Database != "*" && !strings.EqualFold(l.Database, db) {
+		return false
+	}
+
+	return l.Table == "*" || strings.EqualFold(l.Table, table)
+}
+
+func (l PrivilegeLevel) String() string {
+	quote := func(s string) string {
+		if s == "*" {
+			return s
+		}
+		return fmt.Sprintf("`%s`", s)
+	}
+
+	return fmt.Sprintf("%s.%s", quote(l.Database), quote(l.Table))
+}
+
+// Grant is a set of privileges granted to a user at some level.
+type Grant struct {
+	User       string
+	Level      PrivilegeLevel
+	Privileges PrivilegeType
+}
+
+// String returns the GRANT statement that grants the privileges.
+func (g Grant) String() string {
+	var option string
+	privileges := g.Privileges
+	if privileges&GrantOptionPrivilege != 0 {
+		privileges &^= GrantOptionPrivilege
+		option = " WITH GRANT OPTION"
+	}
+
+	return fmt.Sprintf("GRANT %s ON %s TO `%s`%s", privileges, g.Level, g.User, option)
+}
+
+// TableAccess is an access made by a statement to a table, or to all the
+// tables of a database when Table is "*".
+type TableAccess struct {
+	// Privileges needed by the access, such as SelectPrivilege for reads.
+	Privileges PrivilegeType
+	Database   string
+	Table      string
+	// Columns of the table used by the statement. It's empty when the
+	// statement uses the table as a whole, as DELETE and DROP TABLE do.
+	Columns []string
+}
+
+// PrivilegeStore keeps the privileges granted to the users. Implementing
+// it allows persisting them or taking them from an external system.
+type PrivilegeStore interface {
+	// Grant grants the privileges to the user at the given level.
+	Grant(user string, level PrivilegeLevel, privileges PrivilegeType) error
+	// Revoke revokes the privileges granted to the user at the given level.
+	Revoke(user string, level PrivilegeLevel, privileges PrivilegeType) error
+	// Grants returns the privileges granted to the user.
+	Grants(user string) ([]Grant, error)
+}
+
+// HasPrivileges checks if the privileges granted to the user in the store
+// include the given ones on a table of a database.
+func HasPrivileges(
+	store PrivilegeStore,
+	user, db, table string,
+	privileges PrivilegeType,
+) (bool, error) {
+	grants, err := store.Grants(user)
+	if err != nil {
+		return false, err
+	}
+
+	var granted PrivilegeType
+	for _, g := range grants {
+		if g.Level.Contains(db, table) {
+			granted |= g.Privileges
+		}
+	}
+
+	return granted&privileges == privileges, nil
+}
+
+// MemoryPrivilegeStore is a PrivilegeStore that keeps the privileges in
+// memory.
+type MemoryPrivilegeStore struct {
+	mu     sync.RWMutex
+	grants map[string]map[PrivilegeLevel]PrivilegeType
+}
+
+// NewMemoryPrivilegeStore creates an empty MemoryPrivilegeStore.
+func NewMemoryPrivilegeStore() *MemoryPrivilegeStore {
+	return &MemoryPrivilegeStore{
+		grants: make(map[string]map[PrivilegeLevel]PrivilegeType),
+	}
+}
+
+// Grant implements the PrivilegeStore interface.
+func (s *MemoryPrivilegeStore) Grant(
+	user string,
+	level PrivilegeLevel,
+	privileges PrivilegeType,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levels, ok := s.grants[user]
+	if !ok {
+		levels = make(map[PrivilegeLevel]PrivilegeType)
+		s.grants[user] = levels
+	}
+
+	levels[level] |= privileges
+	return nil
+}
+
+// Revoke implements the PrivilegeStore interface.
+func (s *MemoryPrivilegeStore) Revoke(
+	user string,
+	level PrivilegeLevel,
+	privileges PrivilegeType,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levels, ok := s.grants[user]
+	if !ok {
+		return nil
+	}
+
+	levels[level] &^= privileges
+	if levels[level] == 0 {
+		delete(levels, level)
+	}
+
+	return nil
+}
+
+// Grants implements the PrivilegeStore interface.
+func (s *MemoryPrivilegeStore) Grants(user string) ([]Grant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var grants []Grant
+	for level, privileges := range s.grants[user] {
+		grants = append(grants, Grant{user, level, privileges})
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].Level.String() < grants[j].Level.String()
+	})
+
+	return grants, nil
+}
@@ -0,0 +1,114 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrivilege(t *testing.T) {
+	testCases := []struct {
+		name      string
+		privilege PrivilegeType
+		ok        bool
+	}{
+		{"select", SelectPrivilege, true},
+		{"INSERT", InsertPrivilege, true},
+		{"lock  tables", LockTablesPrivilege, true},
+		{"grant option", GrantOptionPrivilege, true},
+		{"all", AllPrivileges, true},
+		{"ALL PRIVILEGES", AllPrivileges, true},
+		{"super", 0, false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := ParsePrivilege(tt.name)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.privilege, p)
+		})
+	}
+}
+
+func TestPrivilegeTypeString(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("USAGE", PrivilegeType(0).String())
+	require.Equal("SELECT, INSERT", (SelectPrivilege | InsertPrivilege).String())
+	require.Equal("ALL PRIVILEGES", AllPrivileges.String())
+	require.Equal(
+		"ALL PRIVILEGES, GRANT OPTION",
+		(AllPrivileges | GrantOptionPrivilege).String(),
+	)
+}
+
+func TestPrivilegeLevelContains(t *testing.T) {
+	testCases := []struct {
+		level    PrivilegeLevel
+		db       string
+		table    string
+		contains bool
+	}{
+		{GlobalPrivilegeLevel, "db", "t", true},
+		{PrivilegeLevel{"db", "*"}, "db", "t", true},
+		{PrivilegeLevel{"db", "*"}, "DB", "t", true},
+		{PrivilegeLevel{"db", "*"}, "other", "t", false},
+		{PrivilegeLevel{"db", "t"}, "db", "t", true},
+		{PrivilegeLevel{"db", "t"}, "db", "other", false},
+		{PrivilegeLevel{"db", "t"}, "db", "*", false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			require.Equal(t, tt.contains, tt.level.Contains(tt.db, tt.table))
+		})
+	}
+}
+
+func TestMemoryPrivilegeStore(t *testing.T) {
+	require := require.New(t)
+
+	store := NewMemoryPrivilegeStore()
+	require.NoError(store.Grant("user", PrivilegeLevel{"db", "*"}, SelectPrivilege))
+	require.NoError(store.Grant("user", PrivilegeLevel{"db", "*"}, InsertPrivilege))
+	require.NoError(store.Grant("user", PrivilegeLevel{"db", "t"}, DeletePrivilege))
+
+	grants, err := store.Grants("user")
+	require.NoError(err)
+	require.Equal([]Grant{
+		{"user", PrivilegeLevel{"db", "*"}, SelectPrivilege | InsertPrivilege},
+		{"user", PrivilegeLevel{"db", "t"}, DeletePrivilege},
+	}, grants)
+
+	ok, err := HasPrivileges(store, "user", "db", "t", SelectPrivilege|DeletePrivilege)
+	require.NoError(err)
+	require.True(ok)
+
+	ok, err = HasPrivileges(store, "user", "db", "other", SelectPrivilege|DeletePrivilege)
+	require.NoError(err)
+	require.False(ok)
+
+	ok, err = HasPrivileges(store, "other", "db", "t", SelectPrivilege)
+	require.NoError(err)
+	require.False(ok)
+
+	require.NoError(store.Revoke("user", PrivilegeLevel{"db", "*"}, SelectPrivilege))
+	require.NoError(store.Revoke("user", PrivilegeLevel{"db", "t"}, DeletePrivilege))
+	require.NoError(store.Revoke("other", GlobalPrivilegeLevel, AllPrivileges))
+
+	grants, err = store.Grants("user")
+	require.NoError(err)
+	require.Equal([]Grant{
+		{"user", PrivilegeLevel{"db", "*"}, InsertPrivilege},
+	}, grants)
+}
+
+func TestGrantString(t *testing.T) {
+	require := require.New(t)
+
+	g := Grant{"user", PrivilegeLevel{"db", "*"}, SelectPrivilege | InsertPrivilege}
+	require.Equal("GRANT SELECT, INSERT ON `db`.* TO `user`", g.String())
+
+	g = Grant{"user", GlobalPrivilegeLevel, AllPrivileges | GrantOptionPrivilege}
+	require.Equal("GRANT ALL PRIVILEGES ON *.* TO `user` WITH GRANT OPTION", g.String())
+}
@@ -51,8 +51,10 @@ type RowIter interface {
 // RowIterToRows converts a row iterator to a slice of rows.
 func RowIterToRows(i RowIter) ([]Row, error) {
 	var rows []Row
+	var batch = make([]Row, RowBatchSize)
+	iter := NewRowBatchIter(i)
 	for {
-		row, err := i.Next()
+		n, err := iter.NextBatch(batch)
 		if err == io.EOF {
 			break
 		}
@@ -61,7 +63,7 @@ func RowIterToRows(i RowIter) ([]Row, error) {
 			return nil, err
 		}
 
-		rows = append(rows, row)
+		rows = append(rows, batch[:n]...)
 	}
 
 	return rows, i.Close()
@@ -0,0 +1,93 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidSampleMethod is returned when a table is sampled with a method
+// other than the ones supported.
+var ErrInvalidSampleMethod = errors.NewKind("unknown TABLESAMPLE method %q, expecting BERNOULLI or SYSTEM")
+
+// ErrInvalidSamplePercentage is returned when the percentage of the rows of
+// a table in a sample is not between 0 and 100.
+var ErrInvalidSamplePercentage = errors.NewKind("percentage of TABLESAMPLE must be between 0 and 100, got %v")
+
+// SampleMethod is the method used to choose the rows of a sample of a
+// table.
+type SampleMethod string
+
+const (
+	// BernoulliSample chooses each row of the table with the probability
+	// given by the percentage of the sample, so all the rows are read.
+	BernoulliSample SampleMethod = "BERNOULLI"
+	// SystemSample chooses each partition, or block of rows of the storage,
+	// of the table with the probability given by the percentage of the
+	// sample, so the rows of the partitions not chosen are never read.
+	SystemSample SampleMethod = "SYSTEM"
+	// DefaultSampleMethod is the method of the samples whose method is not
+	// given in the TABLESAMPLE clause.
+	DefaultSampleMethod = BernoulliSample
+)
+
+// TableSample is a sample of the rows of a table, as given in the
+// TABLESAMPLE clause of a query:
+//
+//	SELECT ... FROM t [AS alias] TABLESAMPLE [BERNOULLI | SYSTEM] (percentage) [REPEATABLE (seed)]
+type TableSample struct {
+	// Method used to choose the rows of the sample.
+	Method SampleMethod
+	// Percentage of the rows of the table in the sample, from 0 to 100.
+	// It's the probability of each row of being chosen, so the number of
+	// rows of the sample is approximate.
+	Percentage float64
+	// Repeatable is whether the sample is the same every time the table is
+	// queried with the same seed, as long as its rows don't change.
+	Repeatable bool
+	// Seed of the random choice of the rows of a repeatable sample.
+	Seed int64
+}
+
+// NewTableSample creates a new sample of a table, checking its method and
+// percentage.
+func NewTableSample(method string, percentage float64) (TableSample, error) {
+	m := SampleMethod(strings.ToUpper(method))
+	if m != BernoulliSample && m != SystemSample {
+		return TableSample{}, ErrInvalidSampleMethod.New(method)
+	}
+
+	if percentage < 0 || percentage > 100 {
+		return TableSample{}, ErrInvalidSamplePercentage.New(percentage)
+	}
+
+	return TableSample{Method: m, Percentage: percentage}, nil
+}
+
+// WithSeed returns the sample made repeatable with the given seed.
+func (s TableSample) WithSeed(seed int64) TableSample {
+	s.Repeatable = true
+	s.Seed = seed
+	return s
+}
+
+func (s TableSample) String() string {
+	str := fmt.Sprintf("TABLESAMPLE %s (%v)", s.Method, s.Percentage)
+	if s.Repeatable {
+		str += fmt.Sprintf(" REPEATABLE (%d)", s.Seed)
+	}
+	return str
+}
+
+// SampledTable is a table that can return a sample of its rows without
+// reading all of them, such as the tables of storages that can skip their
+// blocks or remote databases that can sample their rows. The tables that
+// don't implement it are sampled by the engine, which still needs to read
+// all the rows of the partitions chosen. The filters and limit pushed down
+// to the table apply to the rows of the sample.
+type SampledTable interface {
+	Table
+	WithSample(sample TableSample) Table
+	Sample() *TableSample
+}
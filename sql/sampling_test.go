@@ -0,0 +1,32 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTableSample(t *testing.T) {
+	require := require.New(t)
+
+	s, err := NewTableSample("bernoulli", 12.5)
+	require.NoError(err)
+	require.Equal(TableSample{Method: BernoulliSample, Percentage: 12.5}, s)
+	require.Equal("TABLESAMPLE BERNOULLI (12.5)", s.String())
+
+	s = s.WithSeed(7)
+	require.True(s.Repeatable)
+	require.Equal("TABLESAMPLE BERNOULLI (12.5) REPEATABLE (7)", s.String())
+
+	_, err = NewTableSample("System", 100)
+	require.NoError(err)
+
+	_, err = NewTableSample("block", 10)
+	require.True(ErrInvalidSampleMethod.Is(err))
+
+	_, err = NewTableSample("system", -1)
+	require.True(ErrInvalidSamplePercentage.Is(err))
+
+	_, err = NewTableSample("system", 100.5)
+	require.True(ErrInvalidSamplePercentage.Is(err))
+}
@@ -0,0 +1,178 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrFunctionArgumentType is returned when an argument of a scalar function
+// does not have the type it's declared with, and cannot be converted to it.
+var ErrFunctionArgumentType = errors.NewKind("function '%s' expected argument %d of type %s, but got %s")
+
+// ScalarFunction is a scalar function defined with a Go function and the
+// types of its arguments and result. Unlike the rest of functions, which
+// build an expression from their arguments, it only needs to compute its
+// result from the values of the arguments. The analyzer validates the types
+// of the arguments, and they are converted to the declared types before Fn
+// is called, as the result is converted to the declared type after.
+type ScalarFunction struct {
+	// Name of the function, which is not case sensitive.
+	Name string
+	// Args are the types of the arguments.
+	Args []Type
+	// Variadic makes the last type of Args the one of any number of
+	// arguments at the end, including none.
+	Variadic bool
+	// Returns is the type of the result.
+	Returns Type
+	// NonDeterministic must be set if the result does not only depend on
+	// the arguments, so calls with constant arguments are not evaluated
+	// before the query is executed.
+	NonDeterministic bool
+	// Fn computes the result from the arguments, which are nil when they
+	// are NULL. A nil result is NULL.
+	Fn func(ctx *Context, args ...interface{}) (interface{}, error)
+}
+
+// Call implements the Function interface.
+func (fn ScalarFunction) Call(args ...Expression) (Expression, error) {
+	if err := fn.checkArity(len(args)); err != nil {
+		return nil, err
+	}
+	return &ScalarFunctionCall{fn: fn, args: args}, nil
+}
+
+func (fn ScalarFunction) checkArity(n int) error {
+	return checkFunctionArity(fn.name(), fn.Args, fn.Variadic, n)
+}
+
+// ArgType returns the type the i-th argument of the function is declared
+// with.
+func (fn ScalarFunction) ArgType(i int) Type {
+	return functionArgType(fn.Args, i)
+}
+
+// checkFunctionArity checks that a function declared with the given
+// argument types can be called with n arguments.
+func checkFunctionArity(name string, args []Type, variadic bool, n int) error {
+	if variadic && len(args) > 0 {
+		if n < len(args)-1 {
+			return ErrInvalidArgumentNumber.New(name, fmt.Sprintf("at least %d", len(args)-1), n)
+		}
+		return nil
+	}
+
+	if n != len(args) {
+		return ErrInvalidArgumentNumber.New(name, len(args), n)
+	}
+	return nil
+}
+
+func functionArgType(args []Type, i int) Type {
+	if i >= len(args) {
+		return args[len(args)-1]
+	}
+	return args[i]
+}
+
+func (fn ScalarFunction) name() string { return strings.ToLower(fn.Name) }
+
+func (ScalarFunction) isFunction() {}
+
+// ScalarFunctionCall is the expression of a call to a ScalarFunction.
+type ScalarFunctionCall struct {
+	fn   ScalarFunction
+	args []Expression
+}
+
+var _ NonDeterministicExpression = (*ScalarFunctionCall)(nil)
+
+// Function returns the function called.
+func (c *ScalarFunctionCall) Function() ScalarFunction { return c.fn }
+
+// Resolved implements the Expression interface.
+func (c *ScalarFunctionCall) Resolved() bool {
+	for _, arg := range c.args {
+		if !arg.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNullable implements the Expression interface.
+func (c *ScalarFunctionCall) IsNullable() bool { return true }
+
+// Type implements the Expression interface.
+func (c *ScalarFunctionCall) Type() Type { return c.fn.Returns }
+
+// IsNonDeterministic implements the NonDeterministicExpression interface.
+func (c *ScalarFunctionCall) IsNonDeterministic() bool { return c.fn.NonDeterministic }
+
+// Children implements the Expression interface.
+func (c *ScalarFunctionCall) Children() []Expression { return c.args }
+
+// WithChildren implements the Expression interface.
+func (c *ScalarFunctionCall) WithChildren(children ...Expression) (Expression, error) {
+	if len(children) != len(c.args) {
+		return nil, ErrInvalidChildrenNumber.New(c, len(children), len(c.args))
+	}
+	return &ScalarFunctionCall{fn: c.fn, args: children}, nil
+}
+
+// Eval implements the Expression interface.
+func (c *ScalarFunctionCall) Eval(ctx *Context, row Row) (interface{}, error) {
+	args, err := evalFunctionArgs(ctx, c.fn.name(), c.fn.ArgType, c.args, row)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.fn.Fn(ctx, args...)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return c.fn.Returns.Convert(result)
+}
+
+// evalFunctionArgs evaluates the arguments of a function call with the given
+// row, converting the values that are not NULL to the declared types.
+func evalFunctionArgs(
+	ctx *Context,
+	name string,
+	argType func(int) Type,
+	exprs []Expression,
+	row Row,
+) ([]interface{}, error) {
+	args := make([]interface{}, len(exprs))
+	for i, arg := range exprs {
+		v, err := arg.Eval(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			typ := argType(i)
+			converted, err := typ.Convert(v)
+			if err != nil {
+				return nil, ErrFunctionArgumentType.New(name, i+1, typ, fmt.Sprintf("%q", fmt.Sprint(v)))
+			}
+			v = converted
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+func (c *ScalarFunctionCall) String() string {
+	return functionCallString(c.fn.name(), c.args)
+}
+
+func functionCallString(name string, args []Expression) string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(strs, ", "))
+}
@@ -0,0 +1,76 @@
+package sql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScalarFunction(t *testing.T) {
+	require := require.New(t)
+
+	var called [][]interface{}
+	fn := sql.ScalarFunction{
+		Name:     "JOIN_WORDS",
+		Args:     []sql.Type{sql.Int64, sql.Text},
+		Variadic: true,
+		Returns:  sql.Text,
+		Fn: func(ctx *sql.Context, args ...interface{}) (interface{}, error) {
+			called = append(called, args)
+			if args[0] == nil {
+				return nil, nil
+			}
+
+			var words []string
+			for _, w := range args[1:] {
+				if w != nil {
+					words = append(words, w.(string))
+				}
+			}
+			return strings.Repeat(strings.Join(words, " "), int(args[0].(int64))), nil
+		},
+	}
+
+	r := sql.NewFunctionRegistry()
+	require.NoError(r.Register(fn))
+	f, err := r.Function("join_words")
+	require.NoError(err)
+
+	_, err = f.Call()
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	e, err := f.Call(
+		expression.NewLiteral("2", sql.Text),
+		expression.NewGetField(0, sql.Text, "a", true),
+		expression.NewGetField(1, sql.Int32, "b", true),
+	)
+	require.NoError(err)
+	require.Equal(sql.Text, e.Type())
+	require.Equal("join_words(\"2\", a, b)", e.String())
+
+	ctx := sql.NewEmptyContext()
+	v, err := e.Eval(ctx, sql.NewRow("a", int32(1)))
+	require.NoError(err)
+	require.Equal("a 1a 1", v)
+	require.Equal([]interface{}{int64(2), "a", "1"}, called[0])
+
+	v, err = e.Eval(ctx, sql.NewRow(nil, int32(1)))
+	require.NoError(err)
+	require.Equal("11", v)
+	require.Equal([]interface{}{int64(2), nil, "1"}, called[1])
+
+	e, err = f.Call(expression.NewLiteral("abc", sql.Text))
+	require.NoError(err)
+	_, err = e.Eval(ctx, nil)
+	require.True(sql.ErrFunctionArgumentType.Is(err))
+	require.Len(called, 2)
+
+	e, err = f.Call(expression.NewLiteral(nil, sql.Null))
+	require.NoError(err)
+	v, err = e.Eval(ctx, nil)
+	require.NoError(err)
+	require.Nil(v)
+}
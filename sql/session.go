@@ -6,10 +6,13 @@ import (
 	"io"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/log"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-errors.v1"
 )
 
 type key uint
@@ -49,17 +52,54 @@ type Session interface {
 	ClearWarnings()
 	// WarningCount returns a number of session warnings
 	WarningCount() uint16
+	// SetLastQueryInfo sets the value of a piece of information about the
+	// last executed query, such as the number of affected or found rows.
+	SetLastQueryInfo(key string, value int64)
+	// GetLastQueryInfo returns the value of a piece of information about the
+	// last executed query.
+	GetLastQueryInfo(key string) int64
+	// SetState attaches custom state to the session with the given key, or
+	// removes it if the value is nil, so custom functions and hooks can
+	// share state, such as the tenant of the client, during the session.
+	// As with the values of a context.Context, keys should be of
+	// unexported types, so the state of different packages does not
+	// collide, and typed accessors should be provided to use it.
+	SetState(key, value interface{})
+	// State returns the custom state attached to the session with the
+	// given key, or nil if there is none.
+	State(key interface{}) interface{}
 }
 
+const (
+	// RowCount is the key of the number of rows affected by the last
+	// statement, as returned by ROW_COUNT().
+	RowCount = "row_count"
+	// FoundRows is the key of the number of rows found by the last SELECT,
+	// as returned by FOUND_ROWS().
+	FoundRows = "found_rows"
+	// LastInsertID is the key of the last automatically generated value, as
+	// returned by LAST_INSERT_ID().
+	LastInsertID = "last_insert_id"
+	// InsertID is the key of the first value automatically generated by the
+	// last INSERT or LOAD DATA statement, or 0 if it did not generate any,
+	// which is sent to the client along with the rows affected.
+	InsertID = "insert_id"
+	// Duplicates is the key of the number of rows replaced by the last
+	// REPLACE or LOAD DATA statement.
+	Duplicates = "duplicates"
+)
+
 // BaseSession is the basic session type.
 type BaseSession struct {
-	id       uint32
-	addr     string
-	client   Client
-	mu       sync.RWMutex
-	config   map[string]TypedValue
-	warnings []*Warning
-	warncnt  uint16
+	id        uint32
+	addr      string
+	client    Client
+	mu        sync.RWMutex
+	config    map[string]TypedValue
+	warnings  []*Warning
+	warncnt   uint16
+	queryInfo map[string]int64
+	state     map[interface{}]interface{}
 }
 
 // Address returns the server address.
@@ -147,6 +187,45 @@ func (s *BaseSession) WarningCount() uint16 {
 	return uint16(len(s.warnings))
 }
 
+// SetLastQueryInfo implements the Session interface.
+func (s *BaseSession) SetLastQueryInfo(key string, value int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queryInfo == nil {
+		s.queryInfo = make(map[string]int64)
+	}
+	s.queryInfo[key] = value
+}
+
+// GetLastQueryInfo implements the Session interface.
+func (s *BaseSession) GetLastQueryInfo(key string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.queryInfo[key]
+}
+
+// SetState implements the Session interface.
+func (s *BaseSession) SetState(key, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value == nil {
+		delete(s.state, key)
+		return
+	}
+
+	if s.state == nil {
+		s.state = make(map[interface{}]interface{})
+	}
+	s.state[key] = value
+}
+
+// State implements the Session interface.
+func (s *BaseSession) State(key interface{}) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state[key]
+}
+
 type (
 	// TypedValue is a value along with its type.
 	TypedValue struct {
@@ -165,21 +244,41 @@ type (
 // DefaultSessionConfig returns default values for session variables
 func DefaultSessionConfig() map[string]TypedValue {
 	return map[string]TypedValue{
-		"auto_increment_increment": TypedValue{Int64, int64(1)},
-		"time_zone":                TypedValue{Text, time.Local.String()},
-		"system_time_zone":         TypedValue{Text, time.Local.String()},
-		"max_allowed_packet":       TypedValue{Int32, math.MaxInt32},
-		"sql_mode":                 TypedValue{Text, ""},
-		"gtid_mode":                TypedValue{Int32, int32(0)},
-		"collation_database":       TypedValue{Text, "utf8_bin"},
-		"ndbinfo_version":          TypedValue{Text, ""},
-		"sql_select_limit":         TypedValue{Int32, math.MaxInt32},
-		"transaction_isolation":    TypedValue{Text, "READ UNCOMMITTED"},
-		"version":                  TypedValue{Text, ""},
-		"version_comment":          TypedValue{Text, ""},
+		"auto_increment_increment":       TypedValue{Int64, int64(1)},
+		"autocommit":                     TypedValue{Int64, int64(1)},
+		"time_zone":                      TypedValue{Text, SystemTimeZone},
+		"system_time_zone":               TypedValue{Text, systemTimeZoneName()},
+		"max_allowed_packet":             TypedValue{Int32, math.MaxInt32},
+		"sql_mode":                       TypedValue{Text, ""},
+		"lower_case_table_names":         TypedValue{Int32, int32(2)},
+		"gtid_mode":                      TypedValue{Int32, int32(0)},
+		"collation_database":             TypedValue{Text, "utf8_bin"},
+		"ndbinfo_version":                TypedValue{Text, ""},
+		"sql_select_limit":               TypedValue{Int32, math.MaxInt32},
+		"transaction_isolation":          TypedValue{Text, "READ UNCOMMITTED"},
+		"version":                        TypedValue{Text, ""},
+		"version_comment":                TypedValue{Text, ""},
+		"socket":                         TypedValue{Text, ""},
+		"wait_timeout":                   TypedValue{Int64, int64(28800)},
+		"interactive_timeout":            TypedValue{Int64, int64(28800)},
+		"net_read_timeout":               TypedValue{Int64, int64(30)},
+		"net_write_timeout":              TypedValue{Int64, int64(60)},
+		"long_query_time":                TypedValue{Float64, float64(10)},
+		"trace_context":                  TypedValue{Text, ""},
+		"session_track_schema":           TypedValue{Int64, int64(1)},
+		"session_track_state_change":     TypedValue{Int64, int64(0)},
+		"session_track_system_variables": TypedValue{Text, "time_zone,autocommit,character_set_client,character_set_results,character_set_connection"},
+		"session_track_transaction_info": TypedValue{Text, "OFF"},
 	}
 }
 
+// systemTimeZoneName returns the abbreviated name of the time zone of the
+// system, such as UTC or CET.
+func systemTimeZoneName() string {
+	name, _ := time.Now().Zone()
+	return name
+}
+
 // HasDefaultValue checks if session variable value is the default one.
 func HasDefaultValue(s Session, key string) (bool, interface{}) {
 	typ, val := s.Get(key)
@@ -207,15 +306,29 @@ func NewBaseSession() Session {
 	return &BaseSession{config: DefaultSessionConfig()}
 }
 
+// ErrLocalFilesDisabled is returned when a query reads a file of its client,
+// which cannot be read.
+var ErrLocalFilesDisabled = errors.NewKind("the files of the client cannot be read: %s")
+
+// LocalFileOpener opens the files of the client of a query, such as the
+// ones loaded by LOAD DATA LOCAL INFILE. The files must be closed once
+// they're read.
+type LocalFileOpener func(name string) (io.ReadCloser, error)
+
 // Context of the query execution.
 type Context struct {
 	context.Context
 	Session
-	Memory   *MemoryManager
-	pid      uint64
-	query    string
-	tracer   opentracing.Tracer
-	rootSpan opentracing.Span
+	Memory      *MemoryManager
+	pid         uint64
+	query       string
+	tracer      opentracing.Tracer
+	rootSpan    opentracing.Span
+	queryMemory *QueryMemory
+	hints       *QueryHints
+	examined    *uint64
+	logger      logrus.FieldLogger
+	localFiles  LocalFileOpener
 }
 
 // ContextOption is a function to configure the context.
@@ -256,6 +369,14 @@ func WithMemoryManager(m *MemoryManager) ContextOption {
 	}
 }
 
+// WithLogger sets the logger the context logs with. By default, it's the
+// standard logger of logrus.
+func WithLogger(l logrus.FieldLogger) ContextOption {
+	return func(ctx *Context) {
+		ctx.logger = l
+	}
+}
+
 // WithRootSpan sets the root span of the context.
 func WithRootSpan(s opentracing.Span) ContextOption {
 	return func(ctx *Context) {
@@ -272,7 +393,7 @@ func NewContext(
 	ctx context.Context,
 	opts ...ContextOption,
 ) *Context {
-	c := &Context{ctx, NewBaseSession(), nil, 0, "", opentracing.NoopTracer{}, nil}
+	c := &Context{ctx, NewBaseSession(), nil, 0, "", opentracing.NoopTracer{}, nil, nil, nil, new(uint64), logrus.StandardLogger(), nil}
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -280,6 +401,12 @@ func NewContext(
 	if c.Memory == nil {
 		c.Memory = NewMemoryManager(ProcessMemory)
 	}
+
+	if c.logger == nil {
+		c.logger = logrus.StandardLogger()
+	}
+
+	c.queryMemory = c.Memory.NewQueryMemory(queryMemoryLimit(c.Session))
 	return c
 }
 
@@ -292,26 +419,91 @@ func (c *Context) Pid() uint64 { return c.pid }
 // Query returns the query string associated with this context.
 func (c *Context) Query() string { return c.query }
 
+// QueryMemory returns the memory accounting of the query, which is shared
+// by all the contexts derived from this one.
+func (c *Context) QueryMemory() *QueryMemory { return c.queryMemory }
+
+// AddRowsExamined adds n to the number of rows read from the tables by the
+// query.
+func (c *Context) AddRowsExamined(n uint64) { atomic.AddUint64(c.examined, n) }
+
+// RowsExamined returns the number of rows read from the tables by the
+// query, which is shared by all the contexts derived from this one.
+func (c *Context) RowsExamined() uint64 { return atomic.LoadUint64(c.examined) }
+
+// Logger returns the logger of the context, with the id of the connection
+// of its session, the id of its query and its user as fields, so the logs
+// of a query can be told apart from the rest.
+func (c *Context) Logger() *logrus.Entry {
+	return c.logger.WithFields(logrus.Fields{
+		"connection_id": c.Session.ID(),
+		"query_id":      c.pid,
+		"user":          c.Session.Client().User,
+	})
+}
+
+// Hints returns the optimizer hints of the query, which may be nil.
+func (c *Context) Hints() *QueryHints { return c.hints }
+
+// WithHints returns a new context with the given optimizer hints.
+func (c *Context) WithHints(hints *QueryHints) *Context {
+	return &Context{c.Context, c.Session, c.Memory, c.Pid(), c.Query(), c.tracer, c.rootSpan, c.queryMemory, hints, c.examined, c.logger, c.localFiles}
+}
+
+// WithLocalFiles returns a copy of the context whose queries read the files
+// of their client with the given opener.
+func (c *Context) WithLocalFiles(open LocalFileOpener) *Context {
+	nc := c.WithContext(c.Context)
+	nc.localFiles = open
+	return nc
+}
+
+// OpenLocalFile opens a file of the client of the query. It fails with
+// ErrLocalFilesDisabled if the files of the client cannot be read.
+func (c *Context) OpenLocalFile(name string) (io.ReadCloser, error) {
+	if c.localFiles == nil {
+		return nil, ErrLocalFilesDisabled.New(name)
+	}
+	return c.localFiles(name)
+}
+
 // Span creates a new tracing span with the given context.
 // It will return the span and a new context that should be passed to all
-// childrens of this span.
+// childrens of this span. Spans without a parent in the context are
+// children of the root span, if any.
 func (c *Context) Span(
 	opName string,
 	opts ...opentracing.StartSpanOption,
 ) (opentracing.Span, *Context) {
 	parentSpan := opentracing.SpanFromContext(c.Context)
+	if parentSpan == nil && c.rootSpan != nil {
+		parentSpan = c.rootSpan
+	}
 	if parentSpan != nil {
 		opts = append(opts, opentracing.ChildOf(parentSpan.Context()))
 	}
 	span := c.tracer.StartSpan(opName, opts...)
 	ctx := opentracing.ContextWithSpan(c.Context, span)
 
-	return span, &Context{ctx, c.Session, c.Memory, c.Pid(), c.Query(), c.tracer, c.rootSpan}
+	return span, &Context{ctx, c.Session, c.Memory, c.Pid(), c.Query(), c.tracer, c.rootSpan, c.queryMemory, c.hints, c.examined, c.logger, c.localFiles}
 }
 
 // WithContext returns a new context with the given underlying context.
 func (c *Context) WithContext(ctx context.Context) *Context {
-	return &Context{ctx, c.Session, c.Memory, c.Pid(), c.Query(), c.tracer, c.rootSpan}
+	return &Context{ctx, c.Session, c.Memory, c.Pid(), c.Query(), c.tracer, c.rootSpan, c.queryMemory, c.hints, c.examined, c.logger, c.localFiles}
+}
+
+// CheckCanceled returns context.Canceled if the context has been cancelled,
+// which happens when the query is killed, runs out of time or its client
+// goes away, and nil otherwise. Iterators check it on every row they read,
+// so they stop as soon as possible instead of reading the rest of them.
+func (c *Context) CheckCanceled() error {
+	select {
+	case <-c.Done():
+		return context.Canceled
+	default:
+		return nil
+	}
 }
 
 // RootSpan returns the root span, if any.
@@ -348,6 +540,7 @@ func NewSpanIter(span opentracing.Span, iter RowIter) RowIter {
 type spanIter struct {
 	span  opentracing.Span
 	iter  RowIter
+	batch RowBatchIter
 	count int
 	max   time.Duration
 	min   time.Duration
@@ -387,6 +580,29 @@ func (i *spanIter) Next() (Row, error) {
 	return row, nil
 }
 
+func (i *spanIter) NextBatch(rows []Row) (int, error) {
+	if i.batch == nil {
+		i.batch = NewRowBatchIter(i.iter)
+	}
+
+	start := time.Now()
+
+	n, err := i.batch.NextBatch(rows)
+	if err == io.EOF {
+		i.finish()
+		return 0, err
+	}
+
+	if err != nil {
+		i.finishWithError(err)
+		return 0, err
+	}
+
+	i.count += n
+	i.updateTimings(start)
+	return n, nil
+}
+
 func (i *spanIter) finish() {
 	var avg time.Duration
 	if i.count > 0 {
@@ -5,6 +5,9 @@ import (
 	"io"
 	"testing"
 
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,6 +38,38 @@ func TestSessionConfig(t *testing.T) {
 	require.Equal(1, sess.Warnings()[2].Code)
 }
 
+func TestSessionLastQueryInfo(t *testing.T) {
+	require := require.New(t)
+
+	sess := NewSession("foo", "baz", "bar", 1)
+	require.Equal(int64(0), sess.GetLastQueryInfo(RowCount))
+
+	sess.SetLastQueryInfo(RowCount, -1)
+	sess.SetLastQueryInfo(FoundRows, 5)
+
+	require.Equal(int64(-1), sess.GetLastQueryInfo(RowCount))
+	require.Equal(int64(5), sess.GetLastQueryInfo(FoundRows))
+	require.Equal(int64(0), sess.GetLastQueryInfo(LastInsertID))
+}
+
+type tenantKey struct{}
+
+func TestSessionState(t *testing.T) {
+	require := require.New(t)
+
+	sess := NewSession("foo", "baz", "bar", 1)
+	require.Nil(sess.State(tenantKey{}))
+
+	sess.SetState(tenantKey{}, "acme")
+	sess.SetState("tenant", "other")
+	require.Equal("acme", sess.State(tenantKey{}))
+	require.Equal("other", sess.State("tenant"))
+
+	sess.SetState(tenantKey{}, nil)
+	require.Nil(sess.State(tenantKey{}))
+	require.Equal("other", sess.State("tenant"))
+}
+
 func TestHasDefaultValue(t *testing.T) {
 	require := require.New(t)
 	sess := NewSession("foo", "baz", "bar", 1)
@@ -127,3 +162,53 @@ func TestSessionIterator(t *testing.T) {
 
 	cancelFunc()
 }
+
+func TestContextCheckCanceled(t *testing.T) {
+	require := require.New(t)
+
+	c, cancel := context.WithCancel(context.Background())
+	ctx := NewContext(c)
+	require.NoError(ctx.CheckCanceled())
+
+	_, span := ctx.Span("foo")
+	cancel()
+	require.Equal(context.Canceled, ctx.CheckCanceled())
+	require.Equal(context.Canceled, span.CheckCanceled())
+}
+
+func TestContextSpanRootSpan(t *testing.T) {
+	require := require.New(t)
+
+	tracer := mocktracer.New()
+	root := tracer.StartSpan("query")
+	ctx := NewContext(context.Background(), WithTracer(tracer), WithRootSpan(root))
+
+	span, ctx := ctx.Span("parse")
+	child, _ := ctx.Span("plan.Project")
+
+	rootID := root.Context().(mocktracer.MockSpanContext).SpanID
+	spanID := span.Context().(mocktracer.MockSpanContext).SpanID
+	require.Equal(rootID, span.(*mocktracer.MockSpan).ParentID)
+	require.Equal(spanID, child.(*mocktracer.MockSpan).ParentID)
+}
+
+func TestContextLogger(t *testing.T) {
+	require := require.New(t)
+
+	logger, hook := test.NewNullLogger()
+	ctx := NewContext(
+		context.Background(),
+		WithSession(NewSession("localhost", "client", "user", 7)),
+		WithPid(3),
+		WithLogger(logger),
+	)
+
+	ctx.Logger().Info("foo")
+	require.Len(hook.Entries, 1)
+	require.Equal("foo", hook.LastEntry().Message)
+	require.Equal(logrus.Fields{
+		"connection_id": uint32(7),
+		"query_id":      uint64(3),
+		"user":          "user",
+	}, hook.LastEntry().Data)
+}
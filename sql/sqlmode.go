@@ -0,0 +1,357 @@
+package sql
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// SQLModeVariable is the variable with the SQL modes of a session, which
+// is a comma-separated list of modes, such as 'STRICT_TRANS_TABLES'.
+const SQLModeVariable = "sql_mode"
+
+var (
+	// ErrValueOutOfRange is returned in strict mode when a value written to
+	// a column is out of the range of its type.
+	ErrValueOutOfRange = errors.NewKind("Out of range value for column '%s' at row %d")
+
+	// ErrTruncatedWrongValue is returned when a value written to a column is
+	// not a valid value of its type.
+	ErrTruncatedWrongValue = errors.NewKind("Incorrect %s value: '%v' for column '%s' at row %d")
+
+	// ErrDataTooLong is returned in strict mode when a string written to a
+	// column is longer than the column.
+	ErrDataTooLong = errors.NewKind("Data too long for column '%s' at row %d")
+)
+
+// Codes of the warnings of the values adjusted to their columns.
+const (
+	warnDataOutOfRange = 1264
+	warnDataTruncated  = 1265
+	warnTruncatedWrong = 1366
+	warnTruncatedValue = 1292
+)
+
+// The SQL modes that change how values are written to columns.
+const (
+	strictTransTables = "STRICT_TRANS_TABLES"
+	strictAllTables   = "STRICT_ALL_TABLES"
+	noZeroDate        = "NO_ZERO_DATE"
+	noZeroInDate      = "NO_ZERO_IN_DATE"
+	traditional       = "TRADITIONAL"
+)
+
+// combinationModes are the SQL modes that are shorthands for other ones.
+var combinationModes = map[string][]string{
+	traditional: {
+		strictTransTables, strictAllTables, noZeroInDate, noZeroDate,
+		"ERROR_FOR_DIVISION_BY_ZERO", "NO_ENGINE_SUBSTITUTION",
+	},
+}
+
+// numberPrefixRegex matches the number at the start of a string, which is
+// the value MySQL reads from strings that are not numbers.
+var numberPrefixRegex = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?`)
+
+// HasSQLMode reports whether a mode is one of the SQL modes of the session,
+// or one of the modes of a combination mode of the session, such as
+// TRADITIONAL.
+func (c *Context) HasSQLMode(mode string) bool {
+	_, v := c.Get(SQLModeVariable)
+	modes, _ := v.(string)
+	for _, m := range strings.Split(modes, ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == strings.ToUpper(mode) {
+			return true
+		}
+
+		for _, cm := range combinationModes[m] {
+			if strings.EqualFold(cm, mode) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StrictMode reports whether the session is in strict mode, in which the
+// values that are not valid for their columns are errors instead of being
+// adjusted to them.
+func (c *Context) StrictMode() bool {
+	return c.HasSQLMode(strictTransTables) || c.HasSQLMode(strictAllTables)
+}
+
+// valueProblem is the reason a value had to be adjusted to a column.
+type valueProblem int
+
+const (
+	noProblem valueProblem = iota
+	outOfRange
+	wrongValue
+	tooLong
+)
+
+// ConvertColumnValue converts a value written to a column in the given row
+// of a statement, counting from 1. In strict mode, numbers and times out of
+// the range of the column, values that are not numbers written to numeric
+// columns, strings longer than the column and dates that are not valid are
+// errors. Otherwise, the values are clamped to the range of the column, the
+// numbers are read from the start of the strings, the strings are truncated
+// and the dates are zero dates, and a warning is added to the session. Times
+// that are not valid are always errors.
+func ConvertColumnValue(ctx *Context, col *Column, row int64, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if IsTime(col.Type) {
+		return convertTimeValue(ctx, col, row, v)
+	}
+
+	fitted, problem := fitValue(col.Type, v)
+	var err error
+	var code int
+	switch problem {
+	case outOfRange:
+		err, code = ErrValueOutOfRange.New(col.Name, row), warnDataOutOfRange
+	case wrongValue:
+		err, code = ErrTruncatedWrongValue.New(typeKind(col.Type), v, col.Name, row), warnTruncatedWrong
+	case tooLong:
+		err, code = ErrDataTooLong.New(col.Name, row), warnDataTruncated
+	}
+
+	if err != nil {
+		if ctx.StrictMode() || problem == wrongValue && IsTimeOfDay(col.Type) {
+			return nil, err
+		}
+		ctx.Warn(code, "%s", err)
+	}
+
+	return ConvertValue(ctx, col.Type, fitted)
+}
+
+// convertTimeValue converts a value written to a date, datetime or timestamp
+// column. Zero dates are not valid if NO_ZERO_DATE is enabled. Dates whose
+// month or day is 0 can't be kept, so they are zero dates with a warning,
+// unless NO_ZERO_IN_DATE is enabled, in which case they are not valid.
+func convertTimeValue(ctx *Context, col *Column, row int64, v interface{}) (interface{}, error) {
+	t, err := ConvertValue(ctx, col.Type, v)
+	if err == nil && (!IsZeroTime(t.(time.Time)) || !ctx.HasSQLMode(noZeroDate)) {
+		return t, nil
+	}
+
+	strict := ctx.StrictMode()
+	if s, ok := v.(string); ok && err != nil && zeroInDateRegex.MatchString(strings.TrimSpace(s)) {
+		strict = strict && ctx.HasSQLMode(noZeroInDate)
+	}
+
+	err = ErrTruncatedWrongValue.New(typeKind(col.Type), v, col.Name, row)
+	if strict {
+		return nil, err
+	}
+
+	ctx.Warn(warnTruncatedWrong, "%s", err)
+	return ZeroTime, nil
+}
+
+// typeKind returns the name of the kind of values of a type used in the
+// messages of the values that are not valid.
+func typeKind(t Type) string {
+	switch {
+	case IsInteger(t):
+		return "integer"
+	case IsDecimal(t):
+		return "double"
+	case IsTimeOfDay(t):
+		return "time"
+	case t == Date:
+		return "date"
+	default:
+		return "datetime"
+	}
+}
+
+// fitValue adjusts a value to a type, returning the reason it had to be
+// adjusted, if any. The values of other types are returned as they are.
+func fitValue(t Type, v interface{}) (interface{}, valueProblem) {
+	switch {
+	case IsInteger(t):
+		return fitInteger(t, v)
+	case IsDecimal(t):
+		return fitFloat(t, v)
+	case IsTimeOfDay(t):
+		return fitTimeOfDay(v)
+	case IsChar(t) || IsVarChar(t):
+		return fitString(t.(interface{ Capacity() int }).Capacity(), v)
+	default:
+		return v, noProblem
+	}
+}
+
+// parseNumber reads a number from a string, or from its start if the whole
+// string is not a number, in which case it's not valid.
+func parseNumber(s string) (interface{}, valueProblem) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, noProblem
+	}
+
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n, noProblem
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil || math.IsInf(f, 0) {
+		return f, noProblem
+	}
+
+	f, _ := strconv.ParseFloat(numberPrefixRegex.FindString(s), 64)
+	return f, wrongValue
+}
+
+// NumberFromString reads the number of a string used as a number, ignoring
+// leading and trailing spaces. As MySQL does, if the whole string is not a
+// number, the number at its start is used, or 0 if there's none, and a
+// warning is added to the session. The number is an int64, an uint64 or a
+// float64.
+func NumberFromString(ctx *Context, s string) interface{} {
+	n, problem := parseNumber(s)
+	if problem != noProblem {
+		ctx.Warn(warnTruncatedValue, "Truncated incorrect DOUBLE value: '%s'", s)
+	}
+	return n
+}
+
+// integerRanges are the minimum and maximum values of the integer types.
+var integerRanges = map[Type]struct {
+	min int64
+	max uint64
+}{
+	Int8:   {math.MinInt8, math.MaxInt8},
+	Int16:  {math.MinInt16, math.MaxInt16},
+	Int32:  {math.MinInt32, math.MaxInt32},
+	Int64:  {math.MinInt64, math.MaxInt64},
+	Uint8:  {0, math.MaxUint8},
+	Uint16: {0, math.MaxUint16},
+	Uint32: {0, math.MaxUint32},
+	Uint64: {0, math.MaxUint64},
+}
+
+// fitInteger clamps an integer to the range of its type. Floats are rounded
+// to the nearest integer.
+func fitInteger(t Type, v interface{}) (interface{}, valueProblem) {
+	problem := noProblem
+	if s, ok := v.(string); ok {
+		v, problem = parseNumber(s)
+	}
+
+	r := integerRanges[t]
+	switch n := v.(type) {
+	case int, int8, int16, int32, int64:
+		i := cast.ToInt64(n)
+		switch {
+		case i < r.min:
+			return r.min, outOfRange
+		case i > 0 && uint64(i) > r.max:
+			return r.max, outOfRange
+		}
+		return i, problem
+	case uint, uint8, uint16, uint32, uint64:
+		if u := cast.ToUint64(n); u > r.max {
+			return r.max, outOfRange
+		}
+		return n, problem
+	case float32, float64:
+		f := math.Round(cast.ToFloat64(n))
+		switch {
+		case math.IsNaN(f):
+			return int64(0), wrongValue
+		case f < float64(r.min):
+			return r.min, outOfRange
+		// The maximum of 64 bits integers is rounded to the next power of
+		// two as a float, which is out of range.
+		case f >= float64(r.max)+1:
+			return r.max, outOfRange
+		case f < 0:
+			return int64(f), problem
+		}
+		return uint64(f), problem
+	default:
+		return v, problem
+	}
+}
+
+// fitFloat clamps a float to the range of its type.
+func fitFloat(t Type, v interface{}) (interface{}, valueProblem) {
+	problem := noProblem
+	if s, ok := v.(string); ok {
+		v, problem = parseNumber(s)
+	}
+
+	f, err := cast.ToFloat64E(v)
+	if err != nil {
+		return v, problem
+	}
+
+	max := math.MaxFloat64
+	if t == Float32 {
+		max = math.MaxFloat32
+	}
+
+	switch {
+	case f > max:
+		return max, outOfRange
+	case f < -max:
+		return -max, outOfRange
+	}
+	return f, problem
+}
+
+// fitTimeOfDay clamps a time to the range of the TIME type.
+func fitTimeOfDay(v interface{}) (interface{}, valueProblem) {
+	d, err := Time.Convert(v)
+	if err == nil {
+		return d, noProblem
+	}
+
+	if !ErrTimeOutOfRange.Is(err) {
+		return v, wrongValue
+	}
+
+	var negative bool
+	switch n := v.(type) {
+	case string:
+		negative = strings.HasPrefix(strings.TrimSpace(n), "-")
+	case time.Duration:
+		negative = n < 0
+	default:
+		negative = cast.ToFloat64(v) < 0
+	}
+
+	if negative {
+		return -maxTimeOfDay, outOfRange
+	}
+	return maxTimeOfDay, outOfRange
+}
+
+// fitString truncates a string to the length of its type, without
+// splitting its characters.
+func fitString(length int, v interface{}) (interface{}, valueProblem) {
+	s, ok := v.(string)
+	if !ok || len(s) <= length {
+		return v, noProblem
+	}
+
+	var end int
+	for i := range s {
+		if i > length {
+			break
+		}
+		end = i
+	}
+	return s[:end], tooLong
+}
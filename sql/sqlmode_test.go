@@ -0,0 +1,179 @@
+package sql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+func TestStrictMode(t *testing.T) {
+	require := require.New(t)
+	ctx := NewEmptyContext()
+	require.False(ctx.StrictMode())
+
+	ctx.Set(SQLModeVariable, Text, "NO_ZERO_DATE, strict_trans_tables")
+	require.True(ctx.HasSQLMode("NO_ZERO_DATE"))
+	require.True(ctx.StrictMode())
+
+	ctx.Set(SQLModeVariable, Text, "TRADITIONAL")
+	require.True(ctx.StrictMode())
+	require.True(ctx.HasSQLMode("NO_ZERO_DATE"))
+	require.True(ctx.HasSQLMode("no_zero_in_date"))
+
+	ctx.Set(SQLModeVariable, Text, "ANSI_QUOTES")
+	require.False(ctx.HasSQLMode("NO_ZERO_DATE"))
+	require.False(ctx.StrictMode())
+}
+
+func TestConvertColumnValue(t *testing.T) {
+	testCases := []struct {
+		typ      Type
+		value    interface{}
+		expected interface{}
+		err      *errors.Kind
+	}{
+		{Int8, int64(100), int8(100), nil},
+		{Int8, int64(300), int8(math.MaxInt8), ErrValueOutOfRange},
+		{Int8, "-300", int8(math.MinInt8), ErrValueOutOfRange},
+		{Int8, 1.5, int8(2), nil},
+		{Int8, " 12 ", int8(12), nil},
+		{Int8, "12abc", int8(12), ErrTruncatedWrongValue},
+		{Int8, "abc", int8(0), ErrTruncatedWrongValue},
+		{Uint8, int64(-1), uint8(0), ErrValueOutOfRange},
+		{Uint32, "1e10", uint32(math.MaxUint32), ErrValueOutOfRange},
+		{Int64, uint64(math.MaxUint64), int64(math.MaxInt64), ErrValueOutOfRange},
+		{Int64, 1e19, int64(math.MaxInt64), ErrValueOutOfRange},
+		{Uint64, "18446744073709551615", uint64(math.MaxUint64), nil},
+		{Uint64, 2e19, uint64(math.MaxUint64), ErrValueOutOfRange},
+		{Float64, "1.5e1", 15.0, nil},
+		{Float64, "1e400", math.MaxFloat64, ErrValueOutOfRange},
+		{Float64, "-1e400", -math.MaxFloat64, ErrValueOutOfRange},
+		{Float64, "2.5 apples", 2.5, ErrTruncatedWrongValue},
+		{TimeWithPrecision(0), "900:00:00", maxTimeOfDay, ErrValueOutOfRange},
+		{TimeWithPrecision(0), "-900:00:00", -maxTimeOfDay, ErrValueOutOfRange},
+		{VarChar(3), "abc", "abc", nil},
+		{VarChar(3), "abcd", "abc", ErrDataTooLong},
+		{VarChar(3), "añb", "añ", ErrDataTooLong},
+		{Text, "abcd", "abcd", nil},
+	}
+
+	for _, tt := range testCases {
+		col := &Column{Name: "c", Type: tt.typ}
+
+		ctx := NewEmptyContext()
+		v, err := ConvertColumnValue(ctx, col, 2, tt.value)
+		require.NoError(t, err, "%s %v", tt.typ, tt.value)
+		require.Equal(t, tt.expected, v, "%s %v", tt.typ, tt.value)
+		if tt.err == nil {
+			require.Empty(t, ctx.Warnings())
+		} else {
+			require.Len(t, ctx.Warnings(), 1)
+			require.Contains(t, ctx.Warnings()[0].Message, "column 'c' at row 2")
+		}
+
+		ctx.Set(SQLModeVariable, Text, "STRICT_ALL_TABLES")
+		v, err = ConvertColumnValue(ctx, col, 2, tt.value)
+		if tt.err == nil {
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		} else {
+			require.True(t, tt.err.Is(err), "%s %v: %v", tt.typ, tt.value, err)
+		}
+	}
+}
+
+func TestConvertColumnValueTimes(t *testing.T) {
+	require := require.New(t)
+	ctx := NewEmptyContext()
+
+	// Dates that are not valid are zero dates if not in strict mode, but
+	// times that are not valid are always errors.
+	for _, typ := range []Type{Date, Datetime, Timestamp} {
+		v, err := ConvertColumnValue(ctx, &Column{Name: "c", Type: typ}, 1, "foo")
+		require.NoError(err)
+		require.Equal(ZeroTime, v)
+	}
+	require.Len(ctx.Warnings(), 3)
+
+	_, err := ConvertColumnValue(ctx, &Column{Name: "c", Type: Time}, 1, "foo")
+	require.True(ErrTruncatedWrongValue.Is(err))
+
+	ctx.Set(SQLModeVariable, Text, "STRICT_TRANS_TABLES")
+	_, err = ConvertColumnValue(ctx, &Column{Name: "c", Type: Date}, 1, "foo")
+	require.True(ErrTruncatedWrongValue.Is(err))
+	ctx.Set(SQLModeVariable, Text, "")
+
+	v, err := ConvertColumnValue(ctx, &Column{Name: "c", Type: Datetime}, 1, "2019-01-02 03:04:05")
+	require.NoError(err)
+	require.Equal(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC), v)
+
+	v, err = ConvertColumnValue(ctx, &Column{Name: "c", Type: Int64}, 1, nil)
+	require.NoError(err)
+	require.Nil(v)
+}
+
+func TestNumberFromString(t *testing.T) {
+	testCases := []struct {
+		str      string
+		expected interface{}
+		warning  bool
+	}{
+		{"12", int64(12), false},
+		{" -12 ", int64(-12), false},
+		{"18446744073709551615", uint64(math.MaxUint64), false},
+		{"1.5e2", 150.0, false},
+		{"3abc", 3.0, true},
+		{".5 apples", 0.5, true},
+		{"0x10", 0.0, true},
+		{"abc", 0.0, true},
+		{"", 0.0, true},
+	}
+
+	for _, tt := range testCases {
+		ctx := NewEmptyContext()
+		require.Equal(t, tt.expected, NumberFromString(ctx, tt.str), tt.str)
+		require.Equal(t, tt.warning, len(ctx.Warnings()) == 1, tt.str)
+	}
+}
+
+func TestConvertColumnValueZeroDates(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		value    string
+		expected interface{}
+		warning  bool
+	}{
+		{"", "0000-00-00", ZeroTime, false},
+		{"", "0000-00-00 00:00:00.000", ZeroTime, false},
+		{"", "2019-00-10", ZeroTime, true},
+		{"NO_ZERO_DATE", "0000-00-00", ZeroTime, true},
+		{"NO_ZERO_DATE", "2019-01-00", ZeroTime, true},
+		{"STRICT_ALL_TABLES", "0000-00-00", ZeroTime, false},
+		{"STRICT_ALL_TABLES", "2019-01-00", ZeroTime, true},
+		{"STRICT_ALL_TABLES,NO_ZERO_DATE", "0000-00-00", nil, false},
+		{"STRICT_ALL_TABLES,NO_ZERO_DATE", "2019-01-00", ZeroTime, true},
+		{"STRICT_ALL_TABLES,NO_ZERO_IN_DATE", "0000-00-00", ZeroTime, false},
+		{"STRICT_ALL_TABLES,NO_ZERO_IN_DATE", "2019-01-00", nil, false},
+		{"TRADITIONAL", "0000-00-00 00:00:00", nil, false},
+		{"TRADITIONAL", "2019-00-01 12:00:00", nil, false},
+	}
+
+	for _, tt := range testCases {
+		for _, typ := range []Type{Date, Datetime, Timestamp} {
+			ctx := NewEmptyContext()
+			ctx.Set(SQLModeVariable, Text, tt.mode)
+			v, err := ConvertColumnValue(ctx, &Column{Name: "c", Type: typ}, 1, tt.value)
+			if tt.expected == nil {
+				require.True(t, ErrTruncatedWrongValue.Is(err), "%s %s %s", tt.mode, tt.value, typ)
+				continue
+			}
+
+			require.NoError(t, err, "%s %s %s", tt.mode, tt.value, typ)
+			require.Equal(t, tt.expected, v, "%s %s %s", tt.mode, tt.value, typ)
+			require.Equal(t, tt.warning, len(ctx.Warnings()) == 1, "%s %s %s", tt.mode, tt.value, typ)
+		}
+	}
+}
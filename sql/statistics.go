@@ -0,0 +1,286 @@
+package sql
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// StatisticsTable is a table that can provide statistics about its data.
+// The analyzer uses them to estimate the cost of the different ways a query
+// can be executed, such as the order of the joins, the algorithm used to
+// compute them or whether an index should be used.
+type StatisticsTable interface {
+	Table
+	// Statistics returns the statistics of the table. A nil result means
+	// there are no statistics available.
+	Statistics(*Context) (*TableStatistics, error)
+}
+
+// TableStatistics contains the statistics of a table.
+type TableStatistics struct {
+	// RowCount is the number of rows in the table.
+	RowCount uint64
+	// Columns contains the statistics of each column, by column name.
+	// Columns without statistics may be missing.
+	Columns map[string]*ColumnStatistics
+}
+
+// Column returns the statistics of the column with the given name, or nil
+// if there are none.
+func (s *TableStatistics) Column(name string) *ColumnStatistics {
+	if s == nil || s.Columns == nil {
+		return nil
+	}
+	return s.Columns[name]
+}
+
+// ColumnStatistics contains the statistics of a column.
+type ColumnStatistics struct {
+	// DistinctCount is the number of distinct non-null values, also known as
+	// the cardinality of the column.
+	DistinctCount uint64
+	// NullCount is the number of rows in which the column is null.
+	NullCount uint64
+	// Min is the smallest non-null value of the column.
+	Min interface{}
+	// Max is the greatest non-null value of the column.
+	Max interface{}
+	// Histogram describes the distribution of the non-null values. It may be
+	// empty.
+	Histogram Histogram
+}
+
+// Histogram is a list of buckets that describe the distribution of the
+// values of a column. Buckets are sorted by their upper bound in ascending
+// order and every bucket contains the values greater than the upper bound of
+// the previous one and less than or equal to its own upper bound.
+type Histogram []HistogramBucket
+
+// HistogramBucket is a range of values in a histogram.
+type HistogramBucket struct {
+	// UpperBound is the greatest value in the bucket.
+	UpperBound interface{}
+	// RowCount is the number of rows whose value is in the bucket.
+	RowCount uint64
+	// DistinctCount is the number of distinct values in the bucket.
+	DistinctCount uint64
+}
+
+// NullFraction returns the fraction of rows in which the column is null,
+// given the number of rows of the table.
+func (s *ColumnStatistics) NullFraction(rowCount uint64) float64 {
+	if rowCount == 0 {
+		return 0
+	}
+	return float64(s.NullCount) / float64(rowCount)
+}
+
+// DefaultHistogramBuckets is the maximum number of buckets in the histograms
+// built by CollectStatistics.
+const DefaultHistogramBuckets = 32
+
+// CollectStatistics reads all the rows of the table and computes the
+// statistics of all its columns.
+func CollectStatistics(ctx *Context, t Table) (*TableStatistics, error) {
+	schema := t.Schema()
+	values := make([][]interface{}, len(schema))
+	stats := &TableStatistics{
+		Columns: make(map[string]*ColumnStatistics, len(schema)),
+	}
+	for _, col := range schema {
+		stats.Columns[col.Name] = new(ColumnStatistics)
+	}
+
+	partitions, err := t.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p, err := partitions.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = partitions.Close()
+			return nil, err
+		}
+
+		rows, err := t.PartitionRows(ctx, p)
+		if err != nil {
+			_ = partitions.Close()
+			return nil, err
+		}
+
+		for {
+			row, err := rows.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				_ = rows.Close()
+				_ = partitions.Close()
+				return nil, err
+			}
+
+			stats.RowCount++
+			for i, v := range row {
+				if i >= len(schema) {
+					break
+				}
+
+				if v == nil {
+					stats.Columns[schema[i].Name].NullCount++
+				} else {
+					values[i] = append(values[i], v)
+				}
+			}
+		}
+
+		if err := rows.Close(); err != nil {
+			_ = partitions.Close()
+			return nil, err
+		}
+	}
+
+	if err := partitions.Close(); err != nil {
+		return nil, err
+	}
+
+	for i, col := range schema {
+		if err := collectColumnStatistics(stats.Columns[col.Name], col.Type, values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// collectColumnStatistics computes the cardinality, bounds and histogram of
+// a column given all its non-null values.
+func collectColumnStatistics(stats *ColumnStatistics, typ Type, values []interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	var err error
+	sort.SliceStable(values, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+
+		var cmp int
+		cmp, err = typ.Compare(values[i], values[j])
+		return cmp < 0
+	})
+	if err != nil {
+		return err
+	}
+
+	stats.Min = values[0]
+	stats.Max = values[len(values)-1]
+
+	// Buckets are filled up to the same number of rows, but rows with the
+	// same value are never split in different buckets.
+	bucketSize := (len(values) + DefaultHistogramBuckets - 1) / DefaultHistogramBuckets
+	var bucket HistogramBucket
+	for i, v := range values {
+		if i == 0 {
+			stats.DistinctCount++
+			bucket.DistinctCount++
+		} else {
+			cmp, err := typ.Compare(values[i-1], v)
+			if err != nil {
+				return err
+			}
+
+			if cmp != 0 {
+				if bucket.RowCount >= uint64(bucketSize) {
+					stats.Histogram = append(stats.Histogram, bucket)
+					bucket = HistogramBucket{}
+				}
+
+				stats.DistinctCount++
+				bucket.DistinctCount++
+			}
+		}
+
+		bucket.RowCount++
+		bucket.UpperBound = v
+	}
+
+	stats.Histogram = append(stats.Histogram, bucket)
+	return nil
+}
+
+// StatisticsRegistry keeps the statistics collected for the tables of all
+// databases, so the analyzer can use them for tables that cannot provide
+// their own statistics.
+type StatisticsRegistry struct {
+	mu    sync.RWMutex
+	stats map[string]map[string]*TableStatistics
+	// changes is incremented every time the statistics change.
+	changes uint64
+}
+
+// NewStatisticsRegistry returns a new empty statistics registry.
+func NewStatisticsRegistry() *StatisticsRegistry {
+	return &StatisticsRegistry{
+		stats: make(map[string]map[string]*TableStatistics),
+	}
+}
+
+// TableStatistics returns the statistics of the given table, or nil if there
+// are none.
+func (r *StatisticsRegistry) TableStatistics(db, table string) *TableStatistics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.stats[strings.ToLower(db)][strings.ToLower(table)]
+}
+
+// SetTableStatistics stores the statistics of the given table, replacing
+// the previous ones.
+func (r *StatisticsRegistry) SetTableStatistics(db, table string, stats *TableStatistics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	db = strings.ToLower(db)
+	if _, ok := r.stats[db]; !ok {
+		r.stats[db] = make(map[string]*TableStatistics)
+	}
+	r.stats[db][strings.ToLower(table)] = stats
+	atomic.AddUint64(&r.changes, 1)
+}
+
+// DeleteTableStatistics removes the statistics of the given table.
+func (r *StatisticsRegistry) DeleteTableStatistics(db, table string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.stats[strings.ToLower(db)], strings.ToLower(table))
+	atomic.AddUint64(&r.changes, 1)
+}
+
+// deleteDatabase removes the statistics of the tables of the given database.
+func (r *StatisticsRegistry) deleteDatabase(db string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.stats, strings.ToLower(db))
+	atomic.AddUint64(&r.changes, 1)
+}
+
+// renameDatabase moves the statistics of the tables of the given database
+// to the new name of the database.
+func (r *StatisticsRegistry) renameDatabase(db, newName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	db, newName = strings.ToLower(db), strings.ToLower(newName)
+	if stats, ok := r.stats[db]; ok && db != newName {
+		delete(r.stats, db)
+		r.stats[newName] = stats
+	}
+	atomic.AddUint64(&r.changes, 1)
+}
@@ -0,0 +1,79 @@
+package sql_test
+
+import (
+	"testing"
+
+	"github.com/src-d/go-mysql-server/memory"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectStatistics(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	table := memory.NewPartitionedTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t", Nullable: true},
+		{Name: "b", Type: sql.Text, Source: "t"},
+	}, 2)
+
+	for _, row := range []sql.Row{
+		sql.NewRow(int64(3), "a"),
+		sql.NewRow(int64(1), "b"),
+		sql.NewRow(nil, "c"),
+		sql.NewRow(int64(2), "d"),
+		sql.NewRow(int64(1), "e"),
+	} {
+		require.NoError(table.Insert(ctx, row))
+	}
+
+	stats, err := sql.CollectStatistics(ctx, table)
+	require.NoError(err)
+	require.Equal(uint64(5), stats.RowCount)
+
+	a := stats.Column("a")
+	require.Equal(uint64(3), a.DistinctCount)
+	require.Equal(uint64(1), a.NullCount)
+	require.Equal(int64(1), a.Min)
+	require.Equal(int64(3), a.Max)
+	require.Equal(0.2, a.NullFraction(stats.RowCount))
+	require.Equal(sql.Histogram{
+		{UpperBound: int64(1), RowCount: 2, DistinctCount: 1},
+		{UpperBound: int64(2), RowCount: 1, DistinctCount: 1},
+		{UpperBound: int64(3), RowCount: 1, DistinctCount: 1},
+	}, a.Histogram)
+
+	b := stats.Column("b")
+	require.Equal(uint64(5), b.DistinctCount)
+	require.Equal(uint64(0), b.NullCount)
+	require.Equal("a", b.Min)
+	require.Equal("e", b.Max)
+}
+
+func TestCollectStatisticsEmptyTable(t *testing.T) {
+	require := require.New(t)
+
+	table := memory.NewTable("t", sql.Schema{
+		{Name: "a", Type: sql.Int64, Source: "t"},
+	})
+
+	stats, err := sql.CollectStatistics(sql.NewEmptyContext(), table)
+	require.NoError(err)
+	require.Equal(uint64(0), stats.RowCount)
+	require.Equal(&sql.ColumnStatistics{}, stats.Column("a"))
+}
+
+func TestStatisticsRegistry(t *testing.T) {
+	require := require.New(t)
+
+	r := sql.NewStatisticsRegistry()
+	require.Nil(r.TableStatistics("db", "t"))
+
+	stats := &sql.TableStatistics{RowCount: 10}
+	r.SetTableStatistics("db", "T", stats)
+	require.Equal(stats, r.TableStatistics("DB", "t"))
+	require.Nil(r.TableStatistics("other", "t"))
+
+	r.DeleteTableStatistics("db", "t")
+	require.Nil(r.TableStatistics("db", "t"))
+}
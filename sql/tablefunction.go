@@ -0,0 +1,143 @@
+package sql
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrTableFunctionInExpression is returned when a table function is called
+// outside of the FROM clause.
+var ErrTableFunctionInExpression = errors.NewKind("table function '%s' can only be used in the FROM clause")
+
+// ErrNotTableFunction is returned when a function that is not a table
+// function is used in the FROM clause.
+var ErrNotTableFunction = errors.NewKind("function '%s' is not a table function")
+
+// TableFunction is a function used as a table in the FROM clause of queries,
+// such as SELECT * FROM generate_series(1, 100), whose rows and their schema
+// are computed from the values of its arguments. The arguments must be
+// constant, as they are evaluated when the query is analyzed, and they are
+// converted to the declared types.
+type TableFunction struct {
+	// Name of the function, which is not case sensitive. It's the name of
+	// the table unless it's given an alias in the query.
+	Name string
+	// Args are the types of the arguments.
+	Args []Type
+	// Variadic makes the last type of Args the one of any number of
+	// arguments at the end, including none.
+	Variadic bool
+	// Schema returns the schema of the rows for the given arguments, which
+	// are nil when they are NULL.
+	Schema func(ctx *Context, args ...interface{}) (Schema, error)
+	// RowIter returns the rows for the given arguments. It's called every
+	// time the rows are read, so they can be read more than once in the
+	// same query.
+	RowIter func(ctx *Context, args ...interface{}) (RowIter, error)
+}
+
+// Call implements the Function interface. Table functions cannot be called
+// in expressions, so it always fails.
+func (fn TableFunction) Call(args ...Expression) (Expression, error) {
+	return nil, ErrTableFunctionInExpression.New(fn.name())
+}
+
+// ArgType returns the type the i-th argument of the function is declared
+// with.
+func (fn TableFunction) ArgType(i int) Type {
+	return functionArgType(fn.Args, i)
+}
+
+// Table returns the table of the rows of the function for the values of
+// the given arguments.
+func (fn TableFunction) Table(ctx *Context, args ...interface{}) (Table, error) {
+	if err := checkFunctionArity(fn.name(), fn.Args, fn.Variadic, len(args)); err != nil {
+		return nil, err
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, v := range args {
+		if v == nil {
+			continue
+		}
+
+		typ := fn.ArgType(i)
+		var err error
+		converted[i], err = typ.Convert(v)
+		if err != nil {
+			return nil, ErrFunctionArgumentType.New(fn.name(), i+1, typ, fmt.Sprintf("%q", fmt.Sprint(v)))
+		}
+	}
+
+	schema, err := fn.Schema(ctx, converted...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fn.name()
+	s := make(Schema, len(schema))
+	for i, col := range schema {
+		c := *col
+		c.Source = name
+		s[i] = &c
+	}
+
+	return &tableFunctionTable{fn: fn, args: converted, schema: s}, nil
+}
+
+func (fn TableFunction) name() string { return strings.ToLower(fn.Name) }
+
+func (TableFunction) isFunction() {}
+
+// tableFunctionTable is the table of the rows of a table function, which
+// has a single partition.
+type tableFunctionTable struct {
+	fn     TableFunction
+	args   []interface{}
+	schema Schema
+}
+
+var _ Table = (*tableFunctionTable)(nil)
+
+func (t *tableFunctionTable) Name() string { return t.fn.name() }
+
+func (t *tableFunctionTable) String() string {
+	args := make([]string, len(t.args))
+	for i, arg := range t.args {
+		if arg == nil {
+			args[i] = "NULL"
+		} else {
+			args[i] = fmt.Sprintf("%#v", arg)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", t.fn.name(), strings.Join(args, ", "))
+}
+
+func (t *tableFunctionTable) Schema() Schema { return t.schema }
+
+func (t *tableFunctionTable) Partitions(*Context) (PartitionIter, error) {
+	return &tableFunctionPartitionIter{}, nil
+}
+
+func (t *tableFunctionTable) PartitionRows(ctx *Context, _ Partition) (RowIter, error) {
+	return t.fn.RowIter(ctx, t.args...)
+}
+
+type tableFunctionPartition struct{}
+
+func (tableFunctionPartition) Key() []byte { return nil }
+
+type tableFunctionPartitionIter struct{ done bool }
+
+func (i *tableFunctionPartitionIter) Next() (Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return tableFunctionPartition{}, nil
+}
+
+func (i *tableFunctionPartitionIter) Close() error { return nil }
@@ -0,0 +1,65 @@
+package sql_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/expression"
+	"github.com/stretchr/testify/require"
+)
+
+var generateSeries = sql.TableFunction{
+	Name: "GENERATE_SERIES",
+	Args: []sql.Type{sql.Int64, sql.Int64},
+	Schema: func(ctx *sql.Context, args ...interface{}) (sql.Schema, error) {
+		return sql.Schema{{Name: "n", Type: sql.Int64}}, nil
+	},
+	RowIter: func(ctx *sql.Context, args ...interface{}) (sql.RowIter, error) {
+		var rows []sql.Row
+		if args[0] != nil && args[1] != nil {
+			for i := args[0].(int64); i <= args[1].(int64); i++ {
+				rows = append(rows, sql.NewRow(i))
+			}
+		}
+		return sql.RowsToRowIter(rows...), nil
+	},
+}
+
+func TestTableFunction(t *testing.T) {
+	require := require.New(t)
+	ctx := sql.NewEmptyContext()
+
+	_, err := generateSeries.Call(expression.NewLiteral(int64(1), sql.Int64))
+	require.True(sql.ErrTableFunctionInExpression.Is(err))
+
+	_, err = generateSeries.Table(ctx, 1)
+	require.True(sql.ErrInvalidArgumentNumber.Is(err))
+
+	_, err = generateSeries.Table(ctx, "a", 2)
+	require.True(sql.ErrFunctionArgumentType.Is(err))
+
+	table, err := generateSeries.Table(ctx, int8(1), "3")
+	require.NoError(err)
+	require.Equal("generate_series", table.Name())
+	require.Equal("generate_series(1, 3)", table.String())
+	require.Equal(sql.Schema{{Name: "n", Type: sql.Int64, Source: "generate_series"}}, table.Schema())
+
+	// The rows can be read more than once.
+	for i := 0; i < 2; i++ {
+		partitions, err := table.Partitions(ctx)
+		require.NoError(err)
+		p, err := partitions.Next()
+		require.NoError(err)
+
+		iter, err := table.PartitionRows(ctx, p)
+		require.NoError(err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(err)
+		require.Equal([]sql.Row{{int64(1)}, {int64(2)}, {int64(3)}}, rows)
+
+		_, err = partitions.Next()
+		require.Equal(io.EOF, err)
+		require.NoError(partitions.Close())
+	}
+}
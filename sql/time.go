@@ -0,0 +1,205 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/proto/query"
+)
+
+var (
+	// ErrTimeOutOfRange is returned when a value of the TIME type is not
+	// between -838:59:59 and 838:59:59.
+	ErrTimeOutOfRange = errors.NewKind("time value %v is out of range")
+
+	// ErrTooBigPrecision is returned when the fractional seconds of a time
+	// type have more digits than the maximum.
+	ErrTooBigPrecision = errors.NewKind("too-big precision %d specified, maximum is %d")
+
+	// ErrInvalidPrecision is returned when the precision of fractional
+	// seconds is not a constant integer.
+	ErrInvalidPrecision = errors.NewKind("invalid precision of fractional seconds: %s")
+)
+
+// maxTimeOfDay is the maximum absolute value of the TIME type.
+const maxTimeOfDay = 838*time.Hour + 59*time.Minute + 59*time.Second
+
+var (
+	// timeRegex matches the times with colons, such as '-12:34:56.789',
+	// which may have a number of days, as in '1 12:34:56', and may omit the
+	// seconds.
+	timeRegex = regexp.MustCompile(`^(-)?(?:(\d+) )?(\d+):(\d{1,2})(?::(\d{1,2}))?(\.\d+)?$`)
+	// timeNumberRegex matches the times without colons, such as '123456.789',
+	// whose last digits are the seconds and the minutes.
+	timeNumberRegex = regexp.MustCompile(`^(-)?(\d+)(\.\d+)?$`)
+)
+
+// timeT is the TIME type, whose values are durations, as they may be
+// negative or longer than a day.
+type timeT struct {
+	precision int
+}
+
+func (t timeT) String() string { return withPrecision("TIME", t.precision) }
+
+// Type implements Type interface.
+func (t timeT) Type() query.Type {
+	return sqltypes.Time
+}
+
+// SQL implements Type interface.
+func (t timeT) SQL(v interface{}) (sqltypes.Value, error) {
+	if v == nil {
+		return sqltypes.NULL, nil
+	}
+
+	v, err := t.Convert(v)
+	if err != nil {
+		return sqltypes.Value{}, err
+	}
+
+	return sqltypes.MakeTrusted(sqltypes.Time, []byte(formatTimeOfDay(v.(time.Duration), t.precision))), nil
+}
+
+// formatTimeOfDay formats a time as [-]HH:MM:SS with the given number of
+// digits of fractional seconds, truncating the rest of them.
+func formatTimeOfDay(d time.Duration, precision int) string {
+	var sign string
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign, d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second)
+	if precision > 0 {
+		s += fmt.Sprintf(".%06d", d%time.Second/time.Microsecond)[:precision+1]
+	}
+	return s
+}
+
+// Convert implements Type interface. Times are read from durations, the
+// time of the day of times, strings such as '12:34:56.789', '1 12:34' or
+// '123456', and numbers such as 123456.
+func (t timeT) Convert(v interface{}) (interface{}, error) {
+	var d time.Duration
+	switch value := v.(type) {
+	case time.Duration:
+		d = value
+	case time.Time:
+		d = timeOfDay(value)
+	case string:
+		var ok bool
+		if d, ok = parseTimeOfDay(strings.TrimSpace(value)); !ok {
+			return nil, ErrConvertingToTime.New(v)
+		}
+	case float32, float64:
+		f, err := Float64.Convert(v)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		if d, ok = parseTimeOfDay(strconv.FormatFloat(f.(float64), 'f', -1, 64)); !ok {
+			return nil, ErrConvertingToTime.New(v)
+		}
+	default:
+		n, err := Int64.Convert(v)
+		if err != nil {
+			return nil, ErrInvalidType.New(reflect.TypeOf(v))
+		}
+
+		var ok bool
+		if d, ok = parseTimeOfDay(strconv.FormatInt(n.(int64), 10)); !ok {
+			return nil, ErrConvertingToTime.New(v)
+		}
+	}
+
+	if d > maxTimeOfDay || d < -maxTimeOfDay {
+		return nil, ErrTimeOutOfRange.New(v)
+	}
+	return d, nil
+}
+
+// parseTimeOfDay parses a time with colons or a number whose last digits are
+// the seconds and the minutes. Datetimes are parsed as their time.
+func parseTimeOfDay(s string) (time.Duration, bool) {
+	var negative bool
+	var days, hours, minutes, seconds int64
+	var fraction string
+	if m := timeRegex.FindStringSubmatch(s); m != nil {
+		negative, fraction = m[1] != "", m[6]
+		days, _ = strconv.ParseInt("0"+m[2], 10, 64)
+		hours, _ = strconv.ParseInt(m[3], 10, 64)
+		minutes, _ = strconv.ParseInt(m[4], 10, 64)
+		seconds, _ = strconv.ParseInt("0"+m[5], 10, 64)
+	} else if m := timeNumberRegex.FindStringSubmatch(s); m != nil {
+		negative, fraction = m[1] != "", m[3]
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		hours, minutes, seconds = n/10000, n/100%100, n%100
+	} else if t, err := Datetime.Convert(s); err == nil {
+		return timeOfDay(t.(time.Time)), true
+	} else {
+		return 0, false
+	}
+
+	if minutes > 59 || seconds > 59 || hours > math.MaxInt32 || days > 34 {
+		return 0, false
+	}
+
+	d := time.Duration(days*24+hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second
+	if fraction != "" {
+		f, _ := strconv.ParseFloat(fraction, 64)
+		d += time.Duration(math.Round(f*1e6)) * time.Microsecond
+	}
+
+	if negative {
+		d = -d
+	}
+	return d, true
+}
+
+// timeOfDay returns the time elapsed since the start of the day of a time.
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+}
+
+// Compare implements Type interface.
+func (t timeT) Compare(a interface{}, b interface{}) (int, error) {
+	if hasNulls, res := compareNulls(a, b); hasNulls {
+		return res, nil
+	}
+
+	av, err := t.Convert(a)
+	if err != nil {
+		return 0, err
+	}
+
+	bv, err := t.Convert(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case av.(time.Duration) < bv.(time.Duration):
+		return -1, nil
+	case av.(time.Duration) > bv.(time.Duration):
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
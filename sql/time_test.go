@@ -0,0 +1,107 @@
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTime(t *testing.T) {
+	require := require.New(t)
+
+	d := 12*time.Hour + 34*time.Minute + 56*time.Second + 789*time.Millisecond
+	tests := []struct {
+		value    interface{}
+		expected time.Duration
+	}{
+		{d, d},
+		{"12:34:56.789", d},
+		{" 12:34:56.789 ", d},
+		{"-12:34:56.789", -d},
+		{"123456.789", d},
+		{123456.789, d},
+		{123456, d - 789*time.Millisecond},
+		{"12:34", d - 56789*time.Millisecond},
+		{"1 12:34:56", 36*time.Hour + 34*time.Minute + 56*time.Second},
+		{"838:59:59", maxTimeOfDay},
+		{"2019-01-02 12:34:56.789", d},
+		{time.Date(2019, 1, 2, 12, 34, 56, 789000000, time.UTC), d},
+	}
+
+	for _, tt := range tests {
+		v, err := Time.Convert(tt.value)
+		require.NoError(err, "%v", tt.value)
+		require.Equal(tt.expected, v, "%v", tt.value)
+	}
+
+	for _, v := range []interface{}{"839:00:00", "12:60:00", "foo", 8390000} {
+		_, err := Time.Convert(v)
+		require.Error(err, "%v", v)
+	}
+
+	_, err := Time.Convert("839:00:00")
+	require.True(ErrTimeOutOfRange.Is(err))
+
+	v, err := Time.SQL("12:34:56.789")
+	require.NoError(err)
+	require.Equal("12:34:56", v.ToString())
+
+	v, err = TimeWithPrecision(2).SQL("-12:34:56.789")
+	require.NoError(err)
+	require.Equal("-12:34:56.78", v.ToString())
+
+	v, err = TimeWithPrecision(6).SQL("838:59:59")
+	require.NoError(err)
+	require.Equal("838:59:59.000000", v.ToString())
+
+	lt(t, Time, "-00:00:01", "00:00:00")
+	eq(t, Time, "12:34:56", 123456)
+	gt(t, Time, "100:00:00", "99:59:59.999")
+
+	require.Equal("TIME(3)", TimeWithPrecision(3).String())
+	require.Equal("TIME(3)", MySQLTypeName(TimeWithPrecision(3)))
+	require.True(IsTimeOfDay(TimeWithPrecision(3)))
+	require.False(IsTime(Time))
+}
+
+func TestTimePrecision(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Date(2019, 1, 2, 3, 4, 5, 123456789, time.UTC)
+
+	// Values keep their fractional seconds until they are stored.
+	v, err := DatetimeWithPrecision(3).Convert("2019-01-02 03:04:05.123456789")
+	require.NoError(err)
+	require.Equal(now, v)
+
+	tests := []struct {
+		typ       Type
+		name      string
+		precision int
+		sql       string
+		rounded   time.Time
+	}{
+		{Datetime, "DATETIME", 0, "2019-01-02 03:04:05", now.Round(time.Second)},
+		{DatetimeWithPrecision(3), "DATETIME(3)", 3, "2019-01-02 03:04:05.123", now.Round(time.Millisecond)},
+		{TimestampWithPrecision(6), "TIMESTAMP(6)", 6, "2019-01-02 03:04:05.123456", now.Round(time.Microsecond)},
+	}
+
+	for _, tt := range tests {
+		require.Equal(tt.name, tt.typ.String())
+		require.Equal(tt.name, MySQLTypeName(tt.typ))
+		require.Equal(tt.precision, Precision(tt.typ))
+
+		v, err := tt.typ.SQL(now)
+		require.NoError(err)
+		require.Equal(tt.sql, v.ToString())
+
+		require.Equal(tt.rounded, RoundTime(tt.typ, now))
+	}
+
+	require.Equal(6*time.Hour+time.Second, RoundTime(Time, 6*time.Hour+999*time.Millisecond))
+	require.Equal(int64(1), RoundTime(Datetime, int64(1)))
+
+	lt(t, DatetimeWithPrecision(6), now, now.Add(time.Microsecond))
+	eq(t, TimestampWithPrecision(6), now, now)
+}
@@ -0,0 +1,117 @@
+package sql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// ErrUnknownTimeZone is returned when a time zone is neither SYSTEM, an
+// offset from UTC nor a named time zone.
+var ErrUnknownTimeZone = errors.NewKind("unknown or incorrect time zone: '%s'")
+
+// SystemTimeZone is the name of the time zone of the system, which is the
+// default time zone of the sessions.
+const SystemTimeZone = "SYSTEM"
+
+// TimeZoneVariable is the variable with the time zone of a session.
+const TimeZoneVariable = "time_zone"
+
+var timeZoneOffsetRegex = regexp.MustCompile(`^([+-])(\d{1,2}):(\d{2})$`)
+
+// timeZones caches the locations of the named time zones, which are read
+// from the time zone database of the system.
+var timeZones sync.Map
+
+// LoadTimeZone returns the location of a time zone, which is SYSTEM, an
+// offset from UTC between -13:59 and +14:00, such as '+02:00', or a named
+// time zone of the time zone database of the system, such as
+// 'Europe/Madrid' or 'UTC'.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if strings.EqualFold(name, SystemTimeZone) {
+		return time.Local, nil
+	}
+
+	if m := timeZoneOffsetRegex.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		offset := hours*60 + minutes
+		if minutes > 59 || m[1] == "-" && offset > 13*60+59 || m[1] == "+" && offset > 14*60 {
+			return nil, ErrUnknownTimeZone.New(name)
+		}
+
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(name, offset*60), nil
+	}
+
+	if loc, ok := timeZones.Load(strings.ToLower(name)); ok {
+		return loc.(*time.Location), nil
+	}
+
+	// Local is the name of the system time zone for Go, not for MySQL.
+	if name == "" || strings.EqualFold(name, "local") {
+		return nil, ErrUnknownTimeZone.New(name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrUnknownTimeZone.New(name)
+	}
+
+	timeZones.Store(strings.ToLower(name), loc)
+	return loc, nil
+}
+
+// ConvertTimeZone converts the wall time of a time in a time zone to the
+// one in another time zone. Times without time zone, such as the ones of
+// DATETIME values, are kept as the wall time in UTC. The zero date is the
+// same in all time zones.
+func ConvertTimeZone(t time.Time, from, to *time.Location) time.Time {
+	if from == to || IsZeroTime(t) {
+		return t
+	}
+
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), from).In(to)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// Location returns the location of the time zone of the session, or the
+// one of the system if it's unknown.
+func (c *Context) Location() *time.Location {
+	_, v := c.Get(TimeZoneVariable)
+	name, _ := v.(string)
+	loc, err := LoadTimeZone(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ConvertValue converts a value written to a column of the given type.
+// TIMESTAMP values are kept in UTC, so the ones given as strings are read
+// in the time zone of the session, and the fractional seconds of times are
+// rounded to the precision of the column.
+func ConvertValue(ctx *Context, typ Type, v interface{}) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok || typ.Type() != sqltypes.Timestamp {
+		v, err := typ.Convert(v)
+		if err != nil {
+			return nil, err
+		}
+		return RoundTime(typ, v), nil
+	}
+
+	t, err := typ.Convert(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return RoundTime(typ, ConvertTimeZone(t.(time.Time), ctx.Location(), time.UTC)), nil
+}
@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTimeZone(t *testing.T) {
+	testCases := []struct {
+		name   string
+		offset int
+		err    bool
+	}{
+		{"+00:00", 0, false},
+		{"+02:00", 2 * 3600, false},
+		{"-05:30", -(5*3600 + 30*60), false},
+		{"+14:00", 14 * 3600, false},
+		{"-13:59", -(13*3600 + 59*60), false},
+		{"UTC", 0, false},
+		{"+14:01", 0, true},
+		{"-14:00", 0, true},
+		{"+01:60", 0, true},
+		{"Local", 0, true},
+		{"", 0, true},
+		{"Mars/Olympus_Mons", 0, true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			loc, err := LoadTimeZone(tt.name)
+			if tt.err {
+				require.Error(err)
+				require.True(ErrUnknownTimeZone.Is(err))
+				return
+			}
+
+			require.NoError(err)
+			_, offset := time.Date(2019, 1, 1, 0, 0, 0, 0, loc).Zone()
+			require.Equal(tt.offset, offset)
+		})
+	}
+
+	loc, err := LoadTimeZone("system")
+	require.NoError(t, err)
+	require.Equal(t, time.Local, loc)
+}
+
+func TestConvertTimeZone(t *testing.T) {
+	require := require.New(t)
+
+	madrid, err := LoadTimeZone("Europe/Madrid")
+	require.NoError(err)
+
+	// Madrid is at +01:00 in winter and +02:00 in summer.
+	winter := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Equal(time.Date(2019, 1, 2, 4, 4, 5, 0, time.UTC), ConvertTimeZone(winter, time.UTC, madrid))
+	summer := time.Date(2019, 7, 2, 3, 4, 5, 0, time.UTC)
+	require.Equal(time.Date(2019, 7, 2, 5, 4, 5, 0, time.UTC), ConvertTimeZone(summer, time.UTC, madrid))
+	require.Equal(summer, ConvertTimeZone(ConvertTimeZone(summer, time.UTC, madrid), madrid, time.UTC))
+}
+
+func TestConvertValue(t *testing.T) {
+	require := require.New(t)
+
+	ctx := NewEmptyContext()
+	ctx.Set(TimeZoneVariable, Text, "+02:00")
+	require.Equal(ctx.Location().String(), "+02:00")
+
+	v, err := ConvertValue(ctx, Timestamp, "2019-01-02 03:04:05")
+	require.NoError(err)
+	require.Equal(time.Date(2019, 1, 2, 1, 4, 5, 0, time.UTC), v)
+
+	// Datetimes have no time zone.
+	v, err = ConvertValue(ctx, Datetime, "2019-01-02 03:04:05")
+	require.NoError(err)
+	require.Equal(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC), v)
+
+	v, err = ConvertValue(ctx, Int64, "1")
+	require.NoError(err)
+	require.Equal(int64(1), v)
+}
@@ -0,0 +1,47 @@
+package sql
+
+import "strings"
+
+// AutocommitVariable is the session variable that enables autocommit, with
+// which the statements that change data are not part of a transaction
+// unless one is started explicitly.
+const AutocommitVariable = "autocommit"
+
+// transactionKey is the key of the session state with whether the session
+// is in a transaction.
+type transactionKey struct{}
+
+// Autocommit reports whether autocommit is enabled in the session, which
+// it is unless its autocommit variable is disabled.
+func Autocommit(s Session) bool {
+	_, value := s.Get(AutocommitVariable)
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "ON") || v == "1"
+	default:
+		n, err := Int64.Convert(v)
+		return err != nil || n.(int64) != 0
+	}
+}
+
+// InTransaction reports whether the session is in a transaction, which is
+// started with START TRANSACTION or by the first statement that changes
+// data when autocommit is disabled, and ends with COMMIT or ROLLBACK. As
+// the engine does not have transactions, the changes are always applied
+// when they are made, and the transaction is only reported to the client.
+func InTransaction(s Session) bool {
+	return s.State(transactionKey{}) != nil
+}
+
+// SetInTransaction sets whether the session is in a transaction.
+func SetInTransaction(s Session, in bool) {
+	if in {
+		s.SetState(transactionKey{}, true)
+	} else {
+		s.SetState(transactionKey{}, nil)
+	}
+}
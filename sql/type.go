@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -126,6 +127,9 @@ type Column struct {
 	Source string
 	// PrimaryKey is true if the column is part of the primary key for its table.
 	PrimaryKey bool
+	// AutoIncrement is true if the values of the column are generated when
+	// rows are inserted without them.
+	AutoIncrement bool
 }
 
 // Check ensures the value is correct for this column.
@@ -163,6 +167,27 @@ type Type interface {
 
 var maxTime = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
 
+// ZeroTime is the zero date of the DATE, DATETIME and TIMESTAMP types, such
+// as '0000-00-00 00:00:00', which MySQL uses as a dummy date. Its year,
+// month and day can't be 0, so it's the first instant of the year -1,
+// which is before any date that can be written with 4 digits, and it's
+// formatted as the zero date. The zero value of time.Time is the date
+// '0001-01-01', not the zero date.
+var ZeroTime = time.Date(-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// IsZeroTime returns whether a time is the zero date.
+func IsZeroTime(t time.Time) bool {
+	return t.Equal(ZeroTime)
+}
+
+// zeroTimeRegex matches the zero dates, which may have a zero time, such as
+// '0000-00-00' or '0000-00-00 00:00:00.000'.
+var zeroTimeRegex = regexp.MustCompile(`^0000-00-00(?:[ T]00:00:00(?:\.0*)?)?$`)
+
+// zeroInDateRegex matches the dates whose month or day is 0, such as
+// '2019-00-00' or '2019-01-00 12:34:56', which are not valid dates.
+var zeroInDateRegex = regexp.MustCompile(`^\d{4}-(?:00?-\d{1,2}|\d{1,2}-00?)(?:[ T]|$)`)
+
 // ValidateTime receives a time and returns either that time or nil if it's
 // not a valid time.
 func ValidateTime(t time.Time) interface{} {
@@ -209,6 +234,8 @@ var (
 	Date dateT
 	// Datetime is a date and a time
 	Datetime datetimeT
+	// Time is a time of the day or an interval of time.
+	Time timeT
 	// Text is a string type.
 	Text textT
 	// Boolean is a boolean type.
@@ -217,6 +244,8 @@ var (
 	JSON jsonT
 	// Blob is a type that holds a chunk of binary data.
 	Blob blobT
+	// Geometry is a spatial type that holds points, line strings and polygons.
+	Geometry geometryT
 )
 
 // Tuple returns a new tuple type with the given element types.
@@ -229,6 +258,28 @@ func Array(underlying Type) Type {
 	return arrayT{underlying}
 }
 
+// MaxTimePrecision is the maximum number of digits of the fractional
+// seconds of the time types.
+const MaxTimePrecision = 6
+
+// TimestampWithPrecision returns a new Timestamp type whose values have the
+// given number of digits of fractional seconds, as TIMESTAMP(6).
+func TimestampWithPrecision(precision int) Type {
+	return timestampT{precision: precision}
+}
+
+// DatetimeWithPrecision returns a new Datetime type whose values have the
+// given number of digits of fractional seconds, as DATETIME(6).
+func DatetimeWithPrecision(precision int) Type {
+	return datetimeT{precision: precision}
+}
+
+// TimeWithPrecision returns a new Time type whose values have the given
+// number of digits of fractional seconds, as TIME(6).
+func TimeWithPrecision(precision int) Type {
+	return timeT{precision: precision}
+}
+
 // Char returns a new Char type of the given length.
 func Char(length int) Type {
 	return charT{length: length}
@@ -272,6 +323,8 @@ func MysqlTypeToType(sql query.Type) (Type, error) {
 		return Timestamp, nil
 	case sqltypes.Date:
 		return Date, nil
+	case sqltypes.Time:
+		return Time, nil
 	case sqltypes.Text:
 		return Text, nil
 	case sqltypes.Char:
@@ -290,6 +343,8 @@ func MysqlTypeToType(sql query.Type) (Type, error) {
 		return JSON, nil
 	case sqltypes.Blob:
 		return Blob, nil
+	case sqltypes.Geometry:
+		return Geometry, nil
 	default:
 		return nil, ErrTypeNotSupported.New(sql)
 	}
@@ -494,9 +549,11 @@ func compareUnsignedInts(a interface{}, b interface{}) (int, error) {
 	return +1, nil
 }
 
-type timestampT struct{}
+type timestampT struct {
+	precision int
+}
 
-func (t timestampT) String() string { return "TIMESTAMP" }
+func (t timestampT) String() string { return withPrecision("TIMESTAMP", t.precision) }
 
 // Type implements Type interface.
 func (t timestampT) Type() query.Type {
@@ -507,6 +564,63 @@ func (t timestampT) Type() query.Type {
 // using the format of Go "time" package.
 const TimestampLayout = "2006-01-02 15:04:05"
 
+// fractionLayout is the layout of the fractional seconds of the time types
+// with the maximum precision.
+const fractionLayout = ".000000"
+
+// withPrecision returns the name of a time type with the number of digits
+// of its fractional seconds, if any.
+func withPrecision(name string, precision int) string {
+	if precision == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s(%d)", name, precision)
+}
+
+// formatTime formats a time with the given number of digits of fractional
+// seconds, truncating the rest of them.
+func formatTime(t time.Time, layout string, precision int) string {
+	if IsZeroTime(t) {
+		s := "0000-00-00 00:00:00"
+		if precision > 0 {
+			s += fractionLayout[:precision+1]
+		}
+		return s
+	}
+
+	if precision == 0 {
+		return t.Format(layout)
+	}
+	return t.Format(layout + fractionLayout[:precision+1])
+}
+
+// fractionUnit returns the duration of the last digit of fractional seconds
+// with the given precision.
+func fractionUnit(precision int) time.Duration {
+	unit := time.Second
+	for i := 0; i < precision; i++ {
+		unit /= 10
+	}
+	return unit
+}
+
+// RoundTime rounds the fractional seconds of a time to the precision of a
+// time type, as MySQL does with the values stored in columns of the type.
+// Other values are returned as they are.
+func RoundTime(typ Type, v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		if IsTimestamp(typ) || IsDatetime(typ) {
+			return v.Round(fractionUnit(Precision(typ)))
+		}
+	case time.Duration:
+		if IsTimeOfDay(typ) {
+			return v.Round(fractionUnit(Precision(typ)))
+		}
+	}
+	return v
+}
+
 // TimestampLayouts hold extra timestamps allowed for parsing. It does
 // not have all the layouts supported by mysql. Missing are two digit year
 // versions of common cases and dates that use non common separators.
@@ -532,7 +646,7 @@ func (t timestampT) SQL(v interface{}) (sqltypes.Value, error) {
 
 	return sqltypes.MakeTrusted(
 		sqltypes.Timestamp,
-		[]byte(v.(time.Time).Format(TimestampLayout)),
+		[]byte(formatTime(v.(time.Time), TimestampLayout, t.precision)),
 	), nil
 }
 
@@ -542,6 +656,10 @@ func (t timestampT) Convert(v interface{}) (interface{}, error) {
 	case time.Time:
 		return value.UTC(), nil
 	case string:
+		if zeroTimeRegex.MatchString(value) {
+			return ZeroTime, nil
+		}
+
 		t, err := time.Parse(TimestampLayout, value)
 		if err != nil {
 			failed := true
@@ -610,6 +728,10 @@ func (t dateT) SQL(v interface{}) (sqltypes.Value, error) {
 		return sqltypes.Value{}, err
 	}
 
+	if IsZeroTime(v.(time.Time)) {
+		return sqltypes.MakeTrusted(sqltypes.Timestamp, []byte("0000-00-00")), nil
+	}
+
 	return sqltypes.MakeTrusted(
 		sqltypes.Timestamp,
 		[]byte(v.(time.Time).Format(DateLayout)),
@@ -621,6 +743,10 @@ func (t dateT) Convert(v interface{}) (interface{}, error) {
 	case time.Time:
 		return truncateDate(value).UTC(), nil
 	case string:
+		if zeroTimeRegex.MatchString(value) {
+			return ZeroTime, nil
+		}
+
 		t, err := time.Parse(DateLayout, value)
 		if err != nil {
 			return nil, ErrConvertingToTime.Wrap(err, v)
@@ -651,13 +777,15 @@ func (t dateT) Compare(a, b interface{}) (int, error) {
 	return 0, nil
 }
 
-type datetimeT struct{}
+type datetimeT struct {
+	precision int
+}
 
 // DatetimeLayout is the layout of the MySQL date format in the representation
 // Go understands.
 const DatetimeLayout = "2006-01-02 15:04:05"
 
-func (t datetimeT) String() string { return "DATETIME" }
+func (t datetimeT) String() string { return withPrecision("DATETIME", t.precision) }
 
 func (t datetimeT) Type() query.Type {
 	return sqltypes.Datetime
@@ -675,7 +803,7 @@ func (t datetimeT) SQL(v interface{}) (sqltypes.Value, error) {
 
 	return sqltypes.MakeTrusted(
 		sqltypes.Datetime,
-		[]byte(v.(time.Time).Format(DatetimeLayout)),
+		[]byte(formatTime(v.(time.Time), DatetimeLayout, t.precision)),
 	), nil
 }
 
@@ -684,6 +812,10 @@ func (t datetimeT) Convert(v interface{}) (interface{}, error) {
 	case time.Time:
 		return value.UTC(), nil
 	case string:
+		if zeroTimeRegex.MatchString(value) {
+			return ZeroTime, nil
+		}
+
 		t, err := time.Parse(DatetimeLayout, value)
 		if err != nil {
 			return nil, ErrConvertingToTime.Wrap(err, v)
@@ -870,13 +1002,13 @@ func (t booleanT) Convert(v interface{}) (interface{}, error) {
 	case bool:
 		return b, nil
 	case int, int64, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
-		return b != 0, nil
+		return cast.ToInt64(b) != 0, nil
 	case time.Duration:
 		return int64(b) != 0, nil
 	case time.Time:
 		return b.UnixNano() != 0, nil
 	case float32, float64:
-		return int(math.Round(v.(float64))) != 0, nil
+		return int(math.Round(cast.ToFloat64(v))) != 0, nil
 	case string:
 		return false, nil
 
@@ -1187,7 +1319,40 @@ func IsInteger(t Type) bool {
 
 // IsTime checks if t is a timestamp, date or datetime
 func IsTime(t Type) bool {
-	return t == Timestamp || t == Date || t == Datetime
+	return IsTimestamp(t) || t == Date || IsDatetime(t)
+}
+
+// IsTimestamp checks if t is a timestamp type of any precision.
+func IsTimestamp(t Type) bool {
+	_, ok := t.(timestampT)
+	return ok
+}
+
+// IsDatetime checks if t is a datetime type of any precision.
+func IsDatetime(t Type) bool {
+	_, ok := t.(datetimeT)
+	return ok
+}
+
+// IsTimeOfDay checks if t is a TIME type of any precision.
+func IsTimeOfDay(t Type) bool {
+	_, ok := t.(timeT)
+	return ok
+}
+
+// Precision returns the number of digits of the fractional seconds of the
+// values of a timestamp, datetime or time type, which is 0 for other types.
+func Precision(t Type) int {
+	switch t := t.(type) {
+	case timestampT:
+		return t.precision
+	case datetimeT:
+		return t.precision
+	case timeT:
+		return t.precision
+	default:
+		return 0
+	}
 }
 
 // IsDecimal checks if t is decimal type.
@@ -1247,6 +1412,10 @@ func MySQLTypeName(t Type) string {
 		return "SMALLINT"
 	case sqltypes.Uint16:
 		return "SMALLINT UNSIGNED"
+	case sqltypes.Int24:
+		return "MEDIUMINT"
+	case sqltypes.Uint24:
+		return "MEDIUMINT UNSIGNED"
 	case sqltypes.Int32:
 		return "INTEGER"
 	case sqltypes.Int64:
@@ -1260,11 +1429,13 @@ func MySQLTypeName(t Type) string {
 	case sqltypes.Float64:
 		return "DOUBLE"
 	case sqltypes.Timestamp:
-		return "TIMESTAMP"
+		return withPrecision("TIMESTAMP", Precision(t))
 	case sqltypes.Datetime:
-		return "DATETIME"
+		return withPrecision("DATETIME", Precision(t))
 	case sqltypes.Date:
 		return "DATE"
+	case sqltypes.Time:
+		return withPrecision("TIME", Precision(t))
 	case sqltypes.Char:
 		return fmt.Sprintf("CHAR(%v)", t.(charT).Capacity())
 	case sqltypes.VarChar:
@@ -1277,11 +1448,64 @@ func MySQLTypeName(t Type) string {
 		return "JSON"
 	case sqltypes.Blob:
 		return "BLOB"
+	case sqltypes.Geometry:
+		return "GEOMETRY"
 	default:
 		return "UNKNOWN"
 	}
 }
 
+// DisplayLength returns the maximum number of characters of the values of a
+// type written as text, or of bytes for binary types, which is the length of
+// the columns of the result sets of MySQL.
+func DisplayLength(t Type) int64 {
+	var fraction int64
+	if p := Precision(t); p > 0 {
+		fraction = int64(p) + 1
+	}
+
+	switch t.Type() {
+	case sqltypes.Int8:
+		return 4
+	case sqltypes.Uint8:
+		return 3
+	case sqltypes.Int16:
+		return 6
+	case sqltypes.Uint16:
+		return 5
+	case sqltypes.Int24:
+		return 9
+	case sqltypes.Uint24:
+		return 8
+	case sqltypes.Int32:
+		return 11
+	case sqltypes.Uint32:
+		return 10
+	case sqltypes.Int64, sqltypes.Uint64:
+		return 20
+	case sqltypes.Float32:
+		return 12
+	case sqltypes.Float64:
+		return 22
+	case sqltypes.Timestamp, sqltypes.Datetime:
+		return 19 + fraction
+	case sqltypes.Date, sqltypes.Time:
+		return 10 + fraction
+	case sqltypes.Char:
+		return int64(t.(charT).Capacity())
+	case sqltypes.VarChar:
+		return int64(t.(varCharT).Capacity())
+	case sqltypes.Text, sqltypes.Blob:
+		return math.MaxUint16
+	case sqltypes.Bit:
+		return 1
+	case sqltypes.TypeJSON, sqltypes.Geometry:
+		return math.MaxUint32
+	default:
+		return 0
+	}
+}
+
 // UnderlyingType returns the underlying type of an array if the type is an
 // array, or the type itself in any other case.
 func UnderlyingType(t Type) Type {
@@ -381,6 +381,64 @@ func TestDatetime(t *testing.T) {
 	gt(t, Datetime, after, now)
 }
 
+func TestZeroTime(t *testing.T) {
+	require := require.New(t)
+
+	for _, typ := range []Type{Date, Datetime, Timestamp} {
+		for _, s := range []string{"0000-00-00", "0000-00-00 00:00:00", "0000-00-00 00:00:00.000000"} {
+			v, err := typ.Convert(s)
+			require.NoError(err, "%s %s", typ, s)
+			require.Equal(ZeroTime, v, "%s %s", typ, s)
+		}
+
+		_, err := typ.Convert("2019-00-01")
+		require.Error(err)
+
+		lt(t, typ, ZeroTime, time.Date(1000, time.January, 1, 0, 0, 0, 0, time.UTC))
+		eq(t, typ, ZeroTime, ZeroTime)
+	}
+
+	v, err := Date.SQL(ZeroTime)
+	require.NoError(err)
+	require.Equal("0000-00-00", v.ToString())
+
+	v, err = Datetime.SQL(ZeroTime)
+	require.NoError(err)
+	require.Equal("0000-00-00 00:00:00", v.ToString())
+
+	v, err = TimestampWithPrecision(3).SQL(ZeroTime)
+	require.NoError(err)
+	require.Equal("0000-00-00 00:00:00.000", v.ToString())
+
+	madrid, err := LoadTimeZone("Europe/Madrid")
+	require.NoError(err)
+	require.Equal(ZeroTime, ConvertTimeZone(ZeroTime, time.UTC, madrid))
+
+	// The first date of the calendar, which is the zero value of
+	// time.Time, is not the zero date.
+	first := time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	require.False(IsZeroTime(first))
+	require.False(IsZeroTime(time.Time{}))
+	for typ, s := range map[Type]string{
+		Date:      "0001-01-01",
+		Datetime:  "0001-01-01 00:00:00",
+		Timestamp: "0001-01-01 00:00:00",
+	} {
+		v, err := typ.Convert(s)
+		require.NoError(err, "%s", typ)
+		require.Equal(first, v, "%s", typ)
+		lt(t, typ, ZeroTime, first)
+	}
+
+	v, err = Date.SQL(first)
+	require.NoError(err)
+	require.Equal("0001-01-01", v.ToString())
+
+	v, err = Datetime.SQL(first)
+	require.NoError(err)
+	require.Equal("0001-01-01 00:00:00", v.ToString())
+}
+
 func TestBlob(t *testing.T) {
 	require := require.New(t)
 
@@ -616,3 +674,27 @@ func mustSQL(v sqltypes.Value, err error) sqltypes.Value {
 	}
 	return v
 }
+
+func TestDisplayLength(t *testing.T) {
+	testCases := []struct {
+		typ      Type
+		expected int64
+	}{
+		{Int8, 4},
+		{Uint32, 10},
+		{Int64, 20},
+		{Float64, 22},
+		{Date, 10},
+		{Datetime, 19},
+		{TimestampWithPrecision(6), 26},
+		{TimeWithPrecision(2), 13},
+		{VarChar(42), 42},
+		{Text, 65535},
+		{JSON, 4294967295},
+		{Null, 0},
+	}
+
+	for _, tt := range testCases {
+		require.Equal(t, tt.expected, DisplayLength(tt.typ), tt.typ.String())
+	}
+}
@@ -0,0 +1,25 @@
+package sql
+
+import "gopkg.in/src-d/go-errors.v1"
+
+// ErrAsOfNotSupported is returned when a table that does not keep its
+// history is queried AS OF a version.
+var ErrAsOfNotSupported = errors.NewKind("table %q does not support AS OF")
+
+// ErrVersionNotFound is returned by versioned tables when they have no
+// version for the value given in AS OF.
+var ErrVersionNotFound = errors.NewKind("table %q has no version %v")
+
+// VersionedTable is a table that keeps the history of its rows, such as the
+// ones of git-like or snapshot stores, so it can be queried as it was in a
+// previous version with SELECT ... FROM t AS OF <version>.
+type VersionedTable interface {
+	Table
+	// AsOf returns the table as it was in the given version, which is the
+	// value of the expression given in AS OF. It's a string for literals
+	// such as '2019-01-01' or a commit hash, an int64 for numbers and a
+	// time.Time for timestamps such as TIMESTAMP('2019-01-01'), so tables
+	// versioned by time can convert strings with Timestamp.Convert. The
+	// schema of the table returned may differ from the current one.
+	AsOf(ctx *Context, version interface{}) (Table, error)
+}
@@ -0,0 +1,71 @@
+package sqle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// Status is the health of an engine, as reported to the readiness probes
+// of orchestrators.
+type Status struct {
+	// Ready is whether the engine can serve queries, which it can when the
+	// health checks of all its databases pass.
+	Ready bool
+	// Databases are the names of the databases of the catalog.
+	Databases []string
+	// NotReadyIndexes is the number of indexes that cannot be used yet, as
+	// they are being created or deleted.
+	NotReadyIndexes int
+	// Checks are the health checks of the databases implementing
+	// sql.HealthChecker.
+	Checks []HealthCheck
+}
+
+// HealthCheck is the result of the health check of a database.
+type HealthCheck struct {
+	Database string
+	Duration time.Duration
+	// Err is the error of the check, or nil if it passed.
+	Err error
+}
+
+// Status returns the health of the engine. The health checks of the
+// databases run concurrently, and they must return once ctx is done.
+func (e *Engine) Status(ctx *sql.Context) Status {
+	dbs := e.Catalog.AllDatabases()
+	s := Status{
+		Ready:           true,
+		Databases:       make([]string, len(dbs)),
+		NotReadyIndexes: e.Catalog.NotReadyIndexes(),
+	}
+
+	var checkers []sql.Database
+	for i, db := range dbs {
+		s.Databases[i] = db.Name()
+		if _, ok := db.(sql.HealthChecker); ok {
+			checkers = append(checkers, db)
+		}
+	}
+
+	s.Checks = make([]HealthCheck, len(checkers))
+	var wg sync.WaitGroup
+	for i, db := range checkers {
+		wg.Add(1)
+		go func(i int, db sql.Database) {
+			defer wg.Done()
+			start := time.Now()
+			err := db.(sql.HealthChecker).CheckHealth(ctx)
+			s.Checks[i] = HealthCheck{Database: db.Name(), Duration: time.Since(start), Err: err}
+		}(i, db)
+	}
+	wg.Wait()
+
+	for _, c := range s.Checks {
+		if c.Err != nil {
+			s.Ready = false
+		}
+	}
+	return s
+}
@@ -0,0 +1,165 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// socketStates are the names of the states of the sockets in the tables of
+// connections of the proc file system.
+var socketStates = map[int64]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0A: "LISTEN",
+	0x0B: "CLOSING",
+}
+
+var protocols = []string{"tcp", "tcp6", "udp", "udp6"}
+
+func (p procFS) connections() ([]sql.Row, error) {
+	pids, err := p.socketPids()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sql.Row
+	for _, protocol := range protocols {
+		path := p.path("net", protocol)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		s := bufio.NewScanner(bytes.NewReader(data))
+		// The first line has the names of the columns.
+		s.Scan()
+		for s.Scan() {
+			fields := strings.Fields(s.Text())
+			if len(fields) < 10 {
+				return nil, ErrInvalidFile.New(path, "missing fields")
+			}
+
+			localAddr, localPort, err := parseSocketAddress(fields[1])
+			if err != nil {
+				return nil, ErrInvalidFile.New(path, err)
+			}
+
+			remoteAddr, remotePort, err := parseSocketAddress(fields[2])
+			if err != nil {
+				return nil, ErrInvalidFile.New(path, err)
+			}
+
+			state, err := strconv.ParseInt(fields[3], 16, 64)
+			if err != nil {
+				return nil, ErrInvalidFile.New(path, err)
+			}
+
+			uid, err := strconv.ParseInt(fields[7], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidFile.New(path, err)
+			}
+
+			inode, err := strconv.ParseInt(fields[9], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidFile.New(path, err)
+			}
+
+			var pid interface{}
+			if p, ok := pids[inode]; ok {
+				pid = p
+			}
+
+			rows = append(rows, sql.NewRow(
+				protocol,
+				localAddr,
+				localPort,
+				remoteAddr,
+				remotePort,
+				socketStates[state],
+				uid,
+				inode,
+				pid,
+			))
+		}
+	}
+	return rows, nil
+}
+
+// socketPids returns the processes that have the sockets open by the
+// inodes of the sockets, for the processes that can be inspected.
+func (p procFS) socketPids() (map[int64]int64, error) {
+	all, err := p.pids()
+	if err != nil {
+		return nil, err
+	}
+
+	pids := make(map[int64]int64)
+	for _, pid := range all {
+		fds, err := p.fds(pid)
+		if skip(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, target := range fds {
+			if !strings.HasPrefix(target, "socket:[") || !strings.HasSuffix(target, "]") {
+				continue
+			}
+
+			inode, err := strconv.ParseInt(target[len("socket:["):len(target)-1], 10, 64)
+			if err == nil {
+				pids[inode] = pid
+			}
+		}
+	}
+	return pids, nil
+}
+
+// parseSocketAddress parses an address of the tables of connections, such
+// as 0100007F:0050, which is the address in hexadecimal with its 32-bit
+// words in the byte order of the host, which is little endian in the
+// architectures supported, and the port in hexadecimal.
+func parseSocketAddress(s string) (string, int32, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+
+	ip, err := hex.DecodeString(parts[0])
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+
+	for i := 0; i < len(ip); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(ip[i:]))
+	}
+
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+
+	return net.IP(ip).String(), int32(port), nil
+}
@@ -0,0 +1,158 @@
+// Package system implements a read-only database whose tables describe the
+// operating system the engine runs in, such as its processes and network
+// connections.
+package system
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// DatabaseName is the name of the system database.
+const DatabaseName = "system"
+
+const (
+	// ProcessesTableName is the name of the table of processes.
+	ProcessesTableName = "processes"
+	// EnvironmentTableName is the name of the table of the environment
+	// variables of the engine.
+	EnvironmentTableName = "environment"
+	// OpenFilesTableName is the name of the table of the files opened by
+	// the processes.
+	OpenFilesTableName = "open_files"
+	// ConnectionsTableName is the name of the table of network
+	// connections.
+	ConnectionsTableName = "connections"
+)
+
+// Database is the system database. Its tables are read every time they are
+// queried, so they describe the system when the query runs. The tables of
+// processes, open files and connections are read from the proc file
+// system of Linux, and are empty in systems without it. Only the processes
+// the engine can inspect, usually the ones of its user, have open files.
+type Database struct {
+	tables map[string]sql.Table
+}
+
+var _ sql.Database = (*Database)(nil)
+
+// NewDatabase creates the system database.
+func NewDatabase() *Database {
+	return newDatabase("/proc")
+}
+
+// newDatabase creates the system database reading the proc file system
+// mounted at the given path.
+func newDatabase(proc string) *Database {
+	p := procFS(proc)
+	tables := []*table{
+		{name: ProcessesTableName, schema: processesSchema, rows: p.processes},
+		{name: EnvironmentTableName, schema: environmentSchema, rows: environment},
+		{name: OpenFilesTableName, schema: openFilesSchema, rows: p.openFiles},
+		{name: ConnectionsTableName, schema: connectionsSchema, rows: p.connections},
+	}
+
+	d := &Database{tables: make(map[string]sql.Table)}
+	for _, t := range tables {
+		d.tables[t.name] = t
+	}
+	return d
+}
+
+// Name implements the sql.Database interface.
+func (d *Database) Name() string {
+	return DatabaseName
+}
+
+// Tables implements the sql.Database interface.
+func (d *Database) Tables() map[string]sql.Table {
+	return d.tables
+}
+
+var environmentSchema = sql.Schema{
+	{Name: "name", Type: sql.Text, Source: EnvironmentTableName},
+	{Name: "value", Type: sql.Text, Source: EnvironmentTableName},
+}
+
+func environment() ([]sql.Row, error) {
+	var rows []sql.Row
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			rows = append(rows, sql.NewRow(parts[0], parts[1]))
+		}
+	}
+	return rows, nil
+}
+
+// table is a table of the system database whose rows are read with a
+// function.
+type table struct {
+	name   string
+	schema sql.Schema
+	rows   func() ([]sql.Row, error)
+}
+
+var _ sql.Table = (*table)(nil)
+
+// Name implements the sql.Table interface.
+func (t *table) Name() string {
+	return t.name
+}
+
+// Schema implements the sql.Table interface.
+func (t *table) Schema() sql.Schema {
+	return t.schema
+}
+
+// String implements the sql.Table interface.
+func (t *table) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("SystemTable(%s)", t.name)
+	var schema = make([]string, len(t.schema))
+	for i, col := range t.schema {
+		schema[i] = fmt.Sprintf(
+			"Column(%s, %s, nullable=%v)",
+			col.Name,
+			col.Type.Type().String(),
+			col.Nullable,
+		)
+	}
+	_ = p.WriteChildren(schema...)
+	return p.String()
+}
+
+// Partitions implements the sql.Table interface. The rows of the table are
+// in a single partition.
+func (t *table) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return &partitionIter{}, nil
+}
+
+// PartitionRows implements the sql.Table interface.
+func (t *table) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	rows, err := t.rows()
+	if err != nil {
+		return nil, err
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+type partition struct{}
+
+func (partition) Key() []byte { return []byte("0") }
+
+type partitionIter struct{ done bool }
+
+func (i *partitionIter) Next() (sql.Partition, error) {
+	if i.done {
+		return nil, io.EOF
+	}
+	i.done = true
+	return partition{}, nil
+}
+
+func (i *partitionIter) Close() error { return nil }
@@ -0,0 +1,151 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProc creates a proc file system with two processes, one of them
+// without open files, the files of an exited process, and a socket of each
+// version of TCP.
+func fakeProc(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	files := map[string]string{
+		"stat":       "cpu  1 2 3 4\nbtime 1546300800\nprocesses 100\n",
+		"1/stat":     "1 (my (init)) S 0 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 500 1000000 3 18446744073709551615\n",
+		"1/status":   "Name:\tinit\nUid:\t0\t0\t0\t0\n",
+		"1/cmdline":  "/sbin/init\x00splash\x00",
+		"42/stat":    "42 (kworker) I 1 0 0 0 -1 0 0 0 0 0 0 0 0 0 20 0 4 0 1000 0 0 0\n",
+		"42/status":  "Name:\tkworker\nUid:\t1000\t1000\t1000\t1000\n",
+		"42/cmdline": "",
+		"99/status":  "Name:\texited\n",
+		"net/tcp": "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+			"   0: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1234 1 0000000000000000 100 0 0 10 0\n",
+		"net/tcp6": "  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n" +
+			"   0: 00000000000000000000000001000000:1F90 00000000000000000000000001000000:D431 01 00000000:00000000 00:00000000 00000000  1000        0 5678 1 0000000000000000 20 4 30 10 -1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "1", "fd"), 0755))
+	links := map[string]string{"0": "/dev/null", "3": "socket:[1234]", "10": "/var/log/init.log"}
+	for fd, target := range links {
+		require.NoError(t, os.Symlink(target, filepath.Join(dir, "1", "fd", fd)))
+	}
+
+	return dir
+}
+
+func testQuery(t *testing.T, e *sqle.Engine, query string, expected []sql.Row) {
+	t.Helper()
+	require := require.New(t)
+
+	_, iter, err := e.Query(sql.NewEmptyContext(), query)
+	require.NoError(err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(err)
+	require.Equal(expected, rows)
+}
+
+func TestDatabase(t *testing.T) {
+	db := newDatabase(fakeProc(t))
+	require.Equal(t, "system", db.Name())
+
+	e := sqle.NewDefault()
+	e.AddDatabase(db)
+
+	t.Setenv("GMS_SYSTEM_TEST", "a=b")
+	boot := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := int64(os.Getpagesize())
+
+	testCases := []struct {
+		query    string
+		expected []sql.Row
+	}{
+		{
+			"SELECT * FROM system.processes ORDER BY pid",
+			[]sql.Row{
+				{int64(1), int64(0), "my (init)", "S", int64(0), int64(1), 3 * page, int64(1000000), boot.Add(5 * time.Second), "/sbin/init splash"},
+				{int64(42), int64(1), "kworker", "I", int64(1000), int64(4), int64(0), int64(0), boot.Add(10 * time.Second), ""},
+			},
+		},
+		{
+			"SELECT p.name, f.fd, f.path FROM system.open_files f INNER JOIN system.processes p ON f.pid = p.pid ORDER BY f.fd",
+			[]sql.Row{
+				{"my (init)", int64(0), "/dev/null"},
+				{"my (init)", int64(3), "socket:[1234]"},
+				{"my (init)", int64(10), "/var/log/init.log"},
+			},
+		},
+		{
+			"SELECT * FROM system.connections ORDER BY inode",
+			[]sql.Row{
+				{"tcp", "127.0.0.1", int32(3306), "0.0.0.0", int32(0), "LISTEN", int64(0), int64(1234), int64(1)},
+				{"tcp6", "::1", int32(8080), "::1", int32(54321), "ESTABLISHED", int64(1000), int64(5678), nil},
+			},
+		},
+		{
+			"SELECT value FROM system.environment WHERE name = 'GMS_SYSTEM_TEST'",
+			[]sql.Row{{"a=b"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			testQuery(t, e, tt.query, tt.expected)
+		})
+	}
+}
+
+func TestDatabaseWithoutProc(t *testing.T) {
+	e := sqle.NewDefault()
+	e.AddDatabase(newDatabase(filepath.Join(fakeProc(t), "missing")))
+
+	testQuery(t, e, "SELECT COUNT(*) FROM system.processes", []sql.Row{{int64(0)}})
+	testQuery(t, e, "SELECT COUNT(*) FROM system.open_files", []sql.Row{{int64(0)}})
+	testQuery(t, e, "SELECT COUNT(*) FROM system.connections", []sql.Row{{int64(0)}})
+}
+
+func TestDatabaseCurrentProcess(t *testing.T) {
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("no proc file system")
+	}
+
+	e := sqle.NewDefault()
+	e.AddDatabase(NewDatabase())
+
+	pid := strconv.Itoa(os.Getpid())
+	testQuery(t, e, "SELECT COUNT(*) FROM system.processes WHERE pid = "+pid, []sql.Row{{int64(1)}})
+	testQuery(t, e, "SELECT COUNT(*) > 0 FROM system.open_files WHERE pid = "+pid, []sql.Row{{true}})
+}
+
+func TestParseSocketAddress(t *testing.T) {
+	require := require.New(t)
+
+	addr, port, err := parseSocketAddress("0100007F:0050")
+	require.NoError(err)
+	require.Equal("127.0.0.1", addr)
+	require.Equal(int32(80), port)
+
+	addr, port, err = parseSocketAddress("B80D0120000000000000000001000000:01BB")
+	require.NoError(err)
+	require.Equal("2001:db8::1", addr)
+	require.Equal(int32(443), port)
+
+	_, _, err = parseSocketAddress("0100007F")
+	require.Error(err)
+}
@@ -0,0 +1,274 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/src-d/go-mysql-server/sql"
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrInvalidFile is returned when a file of the proc file system cannot be
+// parsed.
+var ErrInvalidFile = errors.NewKind("unable to parse %s: %s")
+
+// clockTicks is the number of clock ticks per second of the times of the
+// proc file system, which is always 100 for user space.
+const clockTicks = 100
+
+var processesSchema = sql.Schema{
+	{Name: "pid", Type: sql.Int64, Source: ProcessesTableName},
+	{Name: "ppid", Type: sql.Int64, Source: ProcessesTableName},
+	{Name: "name", Type: sql.Text, Source: ProcessesTableName},
+	{Name: "state", Type: sql.Text, Source: ProcessesTableName},
+	{Name: "uid", Type: sql.Int64, Source: ProcessesTableName, Nullable: true},
+	{Name: "threads", Type: sql.Int64, Source: ProcessesTableName},
+	{Name: "resident_size", Type: sql.Int64, Source: ProcessesTableName},
+	{Name: "virtual_size", Type: sql.Int64, Source: ProcessesTableName},
+	{Name: "start_time", Type: sql.Timestamp, Source: ProcessesTableName, Nullable: true},
+	{Name: "cmdline", Type: sql.Text, Source: ProcessesTableName},
+}
+
+var openFilesSchema = sql.Schema{
+	{Name: "pid", Type: sql.Int64, Source: OpenFilesTableName},
+	{Name: "fd", Type: sql.Int64, Source: OpenFilesTableName},
+	{Name: "path", Type: sql.Text, Source: OpenFilesTableName},
+}
+
+var connectionsSchema = sql.Schema{
+	{Name: "protocol", Type: sql.Text, Source: ConnectionsTableName},
+	{Name: "local_address", Type: sql.Text, Source: ConnectionsTableName},
+	{Name: "local_port", Type: sql.Int32, Source: ConnectionsTableName},
+	{Name: "remote_address", Type: sql.Text, Source: ConnectionsTableName},
+	{Name: "remote_port", Type: sql.Int32, Source: ConnectionsTableName},
+	{Name: "state", Type: sql.Text, Source: ConnectionsTableName},
+	{Name: "uid", Type: sql.Int64, Source: ConnectionsTableName},
+	{Name: "inode", Type: sql.Int64, Source: ConnectionsTableName},
+	{Name: "pid", Type: sql.Int64, Source: ConnectionsTableName, Nullable: true},
+}
+
+// procFS is the path of a proc file system.
+type procFS string
+
+func (p procFS) path(elem ...string) string {
+	return filepath.Join(append([]string{string(p)}, elem...)...)
+}
+
+// skip returns whether an error reading the files of a process means it
+// must be skipped, because it exited or it cannot be inspected.
+func skip(err error) bool {
+	return os.IsNotExist(err) || os.IsPermission(err)
+}
+
+// pids returns the identifiers of the running processes.
+func (p procFS) pids() ([]int64, error) {
+	entries, err := ioutil.ReadDir(string(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pids []int64
+	for _, e := range entries {
+		if pid, err := strconv.ParseInt(e.Name(), 10, 64); err == nil && e.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// bootTime returns the time the system booted, or the zero time if it is
+// not known.
+func (p procFS) bootTime() time.Time {
+	data, err := ioutil.ReadFile(p.path("stat"))
+	if err != nil {
+		return time.Time{}
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			if secs, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				return time.Unix(secs, 0).UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func (p procFS) processes() ([]sql.Row, error) {
+	pids, err := p.pids()
+	if err != nil {
+		return nil, err
+	}
+
+	boot := p.bootTime()
+	var rows []sql.Row
+	for _, pid := range pids {
+		row, err := p.process(pid, boot)
+		if skip(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// process returns the row of a process, read from its stat, status and
+// cmdline files.
+func (p procFS) process(pid int64, boot time.Time) (sql.Row, error) {
+	dir := strconv.FormatInt(pid, 10)
+	statPath := p.path(dir, "stat")
+	data, err := ioutil.ReadFile(statPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name is between parentheses and may have spaces and parentheses,
+	// so the rest of the fields start after the last one.
+	stat := string(data)
+	start, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return nil, ErrInvalidFile.New(statPath, "missing name")
+	}
+	name := stat[start+1 : end]
+
+	// Fields are numbered from 1 in proc(5), and the first one after the
+	// name is the third one.
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return nil, ErrInvalidFile.New(statPath, "missing fields")
+	}
+	field := func(n int) (int64, error) {
+		v, err := strconv.ParseInt(fields[n-3], 10, 64)
+		if err != nil {
+			return 0, ErrInvalidFile.New(statPath, err)
+		}
+		return v, nil
+	}
+
+	var values [5]int64
+	for i, n := range []int{4, 20, 22, 23, 24} {
+		if values[i], err = field(n); err != nil {
+			return nil, err
+		}
+	}
+	ppid, threads, ticks, vsize, rss := values[0], values[1], values[2], values[3], values[4]
+
+	var startTime interface{}
+	if !boot.IsZero() {
+		startTime = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+	}
+
+	uid, err := p.uid(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdline, err := ioutil.ReadFile(p.path(dir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+
+	return sql.NewRow(
+		pid,
+		ppid,
+		name,
+		fields[0],
+		uid,
+		threads,
+		rss*int64(os.Getpagesize()),
+		vsize,
+		startTime,
+		strings.Join(args, " "),
+	), nil
+}
+
+// uid returns the real user ID of a process from its status file, or nil
+// if it has none.
+func (p procFS) uid(dir string) (interface{}, error) {
+	data, err := ioutil.ReadFile(p.path(dir, "status"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) > 1 && fields[0] == "Uid:" {
+			uid, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidFile.New(p.path(dir, "status"), err)
+			}
+			return uid, nil
+		}
+	}
+	return nil, nil
+}
+
+// fds returns the paths the file descriptors of a process link to by
+// descriptor.
+func (p procFS) fds(pid int64) (map[int64]string, error) {
+	dir := p.path(strconv.FormatInt(pid, 10), "fd")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fds := make(map[int64]string, len(entries))
+	for _, e := range entries {
+		fd, err := strconv.ParseInt(e.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// Descriptors may be closed after the directory is read.
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		fds[fd] = target
+	}
+	return fds, nil
+}
+
+func (p procFS) openFiles() ([]sql.Row, error) {
+	pids, err := p.pids()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sql.Row
+	for _, pid := range pids {
+		fds, err := p.fds(pid)
+		if skip(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start := len(rows)
+		for fd, path := range fds {
+			rows = append(rows, sql.NewRow(pid, fd, path))
+		}
+
+		files := rows[start:]
+		sort.Slice(files, func(i, j int) bool { return files[i][1].(int64) < files[j][1].(int64) })
+	}
+	return rows, nil
+}